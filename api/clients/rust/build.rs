@@ -0,0 +1,10 @@
+fn main() -> Result<(), Box<dyn std::error::Error>> {
+    tonic_build::configure().build_server(false).compile(
+        &[
+            "../../proto/disperser/disperser.proto",
+            "../../proto/retriever/retriever.proto",
+        ],
+        &["../../proto"],
+    )?;
+    Ok(())
+}
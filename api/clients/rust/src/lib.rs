@@ -0,0 +1,19 @@
+//! gRPC client for the 0G DA disperser and retriever, generated from api/proto at build time.
+
+pub mod disperser {
+    tonic::include_proto!("disperser");
+
+    /// Metadata exchanged by the Disperser service besides its messages, see
+    /// api/proto/disperser/disperser.proto.
+    pub mod headers {
+        pub const PRICE_QUOTE: &str = "x-zgda-price-quote";
+        pub const QUORUM_SIGNED: &str = "x-zgda-quorum-signed";
+        pub const STALENESS_MS: &str = "x-zgda-staleness-ms";
+        pub const QUARANTINED: &str = "x-zgda-quarantined";
+        pub const KV_STATE: &str = "x-zgda-kv-state";
+    }
+}
+
+pub mod retriever {
+    tonic::include_proto!("retriever");
+}
@@ -0,0 +1,11 @@
+// The messages and service clients are generated from api/proto by `npm run generate`.
+export * as disperser from "./gen/disperser/disperser";
+export * as retriever from "./gen/retriever/retriever";
+
+// Metadata exchanged by the Disperser service besides its messages, see
+// api/proto/disperser/disperser.proto.
+export const PriceQuoteHeader = "x-zgda-price-quote";
+export const QuorumSignedHeader = "x-zgda-quorum-signed";
+export const StalenessHeader = "x-zgda-staleness-ms";
+export const QuarantinedHeader = "x-zgda-quarantined";
+export const KVStateHeader = "x-zgda-kv-state";
@@ -26,16 +26,57 @@ type DisperserClient interface {
 	// This executes the dispersal async, i.e. it returns once the request
 	// is accepted. The client could use GetBlobStatus() API to poll the the
 	// processing status of the blob.
+	//
+	// Request metadata:
+	//   x-zgda-price-quote: the quote token returned by the /price HTTP endpoint the request
+	//     is made under, required if pricing is enabled
+	//   x-zgda-priority: the priority lane of the blob, 0 (default) to 2, higher lanes being
+	//     batched first; lanes above 0 are restricted to the configured priority accounts
+	//
+	// Response headers:
+	//   x-zgda-quarantined: "true" if the blob is held for review
 	DisperseBlob(ctx context.Context, in *DisperseBlobRequest, opts ...grpc.CallOption) (*DisperseBlobReply, error)
+	// This API accepts a blob too large for a single message in chunks: the client streams
+	// DisperseBlobRequest messages whose data are concatenated in order, and closes the
+	// stream to get the reply DisperseBlob would have returned for the assembled blob,
+	// which is capped at the same max blob size.
+	//
+	// Request metadata and response headers: same as DisperseBlob
+	DisperseBlobStream(ctx context.Context, opts ...grpc.CallOption) (Disperser_DisperseBlobStreamClient, error)
 	// This API is meant to be polled for the blob status.
+	//
+	// Response headers:
+	//   x-zgda-quorum-signed: on confirmed blobs, "<quorum id>:<percentage>" for each quorum
+	//   x-zgda-quorum-fallback: on confirmed blobs, the id of each quorum in which the blob
+	//     fell short of its optional thresholds and was confirmed with the default ones
+	//   x-zgda-staleness-ms: set if the status was read from a replica, the bound of its lag
+	//   x-zgda-quarantined: "true" while the blob is held for review; the status stays
+	//     PROCESSING
+	//   x-zgda-kv-state: on finalized blobs, "pending" or "stored" once the blob is durable
+	//     in the store it is retrieved from
 	GetBlobStatus(ctx context.Context, in *BlobStatusRequest, opts ...grpc.CallOption) (*BlobStatusReply, error)
+	// This API pushes the status of a blob, first the current one, then each time it
+	// changes, and ends once the blob reached a final status: FINALIZED, FAILED or
+	// INSUFFICIENT_SIGNATURES. Clients waiting for a blob can use it instead of polling
+	// GetBlobStatus().
+	//
+	// Response headers: those of GetBlobStatus for the first reply
+	SubscribeBlobStatus(ctx context.Context, in *BlobStatusRequest, opts ...grpc.CallOption) (Disperser_SubscribeBlobStatusClient, error)
 	// This retrieves the requested blob from the Disperser's backend.
-	// This is a more efficient way to retrieve blobs than directly retrieving
-	// from the DA Nodes (see detail about this approach in
-	// api/proto/retriever/retriever.proto).
 	// The blob should have been initially dispersed via this Disperser service
 	// for this API to work.
 	RetrieveBlob(ctx context.Context, in *RetrieveBlobRequest, opts ...grpc.CallOption) (*RetrieveBlobReply, error)
+	// This API returns the status of a batch with a confirmed blob, including the batches
+	// whose blobs were all finalized.
+	//
+	// Response headers:
+	//   x-zgda-staleness-ms: set if the status was read from a replica, the bound of its lag
+	GetBatchStatus(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchStatus, error)
+	// This API returns the headers of the confirmed blobs of a batch with a multiproof
+	// against the batch root, for indexers and bridges processing whole batches.
+	//
+	// Response headers: same as GetBatchStatus
+	GetBatchCertificate(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchCertificate, error)
 }
 
 type disperserClient struct {
@@ -55,6 +96,40 @@ func (c *disperserClient) DisperseBlob(ctx context.Context, in *DisperseBlobRequ
 	return out, nil
 }
 
+func (c *disperserClient) DisperseBlobStream(ctx context.Context, opts ...grpc.CallOption) (Disperser_DisperseBlobStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Disperser_ServiceDesc.Streams[0], "/disperser.Disperser/DisperseBlobStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &disperserDisperseBlobStreamClient{stream}
+	return x, nil
+}
+
+type Disperser_DisperseBlobStreamClient interface {
+	Send(*DisperseBlobRequest) error
+	CloseAndRecv() (*DisperseBlobReply, error)
+	grpc.ClientStream
+}
+
+type disperserDisperseBlobStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *disperserDisperseBlobStreamClient) Send(m *DisperseBlobRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *disperserDisperseBlobStreamClient) CloseAndRecv() (*DisperseBlobReply, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(DisperseBlobReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *disperserClient) GetBlobStatus(ctx context.Context, in *BlobStatusRequest, opts ...grpc.CallOption) (*BlobStatusReply, error) {
 	out := new(BlobStatusReply)
 	err := c.cc.Invoke(ctx, "/disperser.Disperser/GetBlobStatus", in, out, opts...)
@@ -64,6 +139,38 @@ func (c *disperserClient) GetBlobStatus(ctx context.Context, in *BlobStatusReque
 	return out, nil
 }
 
+func (c *disperserClient) SubscribeBlobStatus(ctx context.Context, in *BlobStatusRequest, opts ...grpc.CallOption) (Disperser_SubscribeBlobStatusClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Disperser_ServiceDesc.Streams[1], "/disperser.Disperser/SubscribeBlobStatus", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &disperserSubscribeBlobStatusClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Disperser_SubscribeBlobStatusClient interface {
+	Recv() (*BlobStatusReply, error)
+	grpc.ClientStream
+}
+
+type disperserSubscribeBlobStatusClient struct {
+	grpc.ClientStream
+}
+
+func (x *disperserSubscribeBlobStatusClient) Recv() (*BlobStatusReply, error) {
+	m := new(BlobStatusReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *disperserClient) RetrieveBlob(ctx context.Context, in *RetrieveBlobRequest, opts ...grpc.CallOption) (*RetrieveBlobReply, error) {
 	out := new(RetrieveBlobReply)
 	err := c.cc.Invoke(ctx, "/disperser.Disperser/RetrieveBlob", in, out, opts...)
@@ -73,6 +180,24 @@ func (c *disperserClient) RetrieveBlob(ctx context.Context, in *RetrieveBlobRequ
 	return out, nil
 }
 
+func (c *disperserClient) GetBatchStatus(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchStatus, error) {
+	out := new(BatchStatus)
+	err := c.cc.Invoke(ctx, "/disperser.Disperser/GetBatchStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *disperserClient) GetBatchCertificate(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchCertificate, error) {
+	out := new(BatchCertificate)
+	err := c.cc.Invoke(ctx, "/disperser.Disperser/GetBatchCertificate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DisperserServer is the server API for Disperser service.
 // All implementations must embed UnimplementedDisperserServer
 // for forward compatibility
@@ -81,16 +206,57 @@ type DisperserServer interface {
 	// This executes the dispersal async, i.e. it returns once the request
 	// is accepted. The client could use GetBlobStatus() API to poll the the
 	// processing status of the blob.
+	//
+	// Request metadata:
+	//   x-zgda-price-quote: the quote token returned by the /price HTTP endpoint the request
+	//     is made under, required if pricing is enabled
+	//   x-zgda-priority: the priority lane of the blob, 0 (default) to 2, higher lanes being
+	//     batched first; lanes above 0 are restricted to the configured priority accounts
+	//
+	// Response headers:
+	//   x-zgda-quarantined: "true" if the blob is held for review
 	DisperseBlob(context.Context, *DisperseBlobRequest) (*DisperseBlobReply, error)
+	// This API accepts a blob too large for a single message in chunks: the client streams
+	// DisperseBlobRequest messages whose data are concatenated in order, and closes the
+	// stream to get the reply DisperseBlob would have returned for the assembled blob,
+	// which is capped at the same max blob size.
+	//
+	// Request metadata and response headers: same as DisperseBlob
+	DisperseBlobStream(Disperser_DisperseBlobStreamServer) error
 	// This API is meant to be polled for the blob status.
+	//
+	// Response headers:
+	//   x-zgda-quorum-signed: on confirmed blobs, "<quorum id>:<percentage>" for each quorum
+	//   x-zgda-quorum-fallback: on confirmed blobs, the id of each quorum in which the blob
+	//     fell short of its optional thresholds and was confirmed with the default ones
+	//   x-zgda-staleness-ms: set if the status was read from a replica, the bound of its lag
+	//   x-zgda-quarantined: "true" while the blob is held for review; the status stays
+	//     PROCESSING
+	//   x-zgda-kv-state: on finalized blobs, "pending" or "stored" once the blob is durable
+	//     in the store it is retrieved from
 	GetBlobStatus(context.Context, *BlobStatusRequest) (*BlobStatusReply, error)
+	// This API pushes the status of a blob, first the current one, then each time it
+	// changes, and ends once the blob reached a final status: FINALIZED, FAILED or
+	// INSUFFICIENT_SIGNATURES. Clients waiting for a blob can use it instead of polling
+	// GetBlobStatus().
+	//
+	// Response headers: those of GetBlobStatus for the first reply
+	SubscribeBlobStatus(*BlobStatusRequest, Disperser_SubscribeBlobStatusServer) error
 	// This retrieves the requested blob from the Disperser's backend.
-	// This is a more efficient way to retrieve blobs than directly retrieving
-	// from the DA Nodes (see detail about this approach in
-	// api/proto/retriever/retriever.proto).
 	// The blob should have been initially dispersed via this Disperser service
 	// for this API to work.
 	RetrieveBlob(context.Context, *RetrieveBlobRequest) (*RetrieveBlobReply, error)
+	// This API returns the status of a batch with a confirmed blob, including the batches
+	// whose blobs were all finalized.
+	//
+	// Response headers:
+	//   x-zgda-staleness-ms: set if the status was read from a replica, the bound of its lag
+	GetBatchStatus(context.Context, *BatchRequest) (*BatchStatus, error)
+	// This API returns the headers of the confirmed blobs of a batch with a multiproof
+	// against the batch root, for indexers and bridges processing whole batches.
+	//
+	// Response headers: same as GetBatchStatus
+	GetBatchCertificate(context.Context, *BatchRequest) (*BatchCertificate, error)
 	mustEmbedUnimplementedDisperserServer()
 }
 
@@ -101,12 +267,24 @@ type UnimplementedDisperserServer struct {
 func (UnimplementedDisperserServer) DisperseBlob(context.Context, *DisperseBlobRequest) (*DisperseBlobReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DisperseBlob not implemented")
 }
+func (UnimplementedDisperserServer) DisperseBlobStream(Disperser_DisperseBlobStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method DisperseBlobStream not implemented")
+}
 func (UnimplementedDisperserServer) GetBlobStatus(context.Context, *BlobStatusRequest) (*BlobStatusReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetBlobStatus not implemented")
 }
+func (UnimplementedDisperserServer) SubscribeBlobStatus(*BlobStatusRequest, Disperser_SubscribeBlobStatusServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeBlobStatus not implemented")
+}
 func (UnimplementedDisperserServer) RetrieveBlob(context.Context, *RetrieveBlobRequest) (*RetrieveBlobReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RetrieveBlob not implemented")
 }
+func (UnimplementedDisperserServer) GetBatchStatus(context.Context, *BatchRequest) (*BatchStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBatchStatus not implemented")
+}
+func (UnimplementedDisperserServer) GetBatchCertificate(context.Context, *BatchRequest) (*BatchCertificate, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBatchCertificate not implemented")
+}
 func (UnimplementedDisperserServer) mustEmbedUnimplementedDisperserServer() {}
 
 // UnsafeDisperserServer may be embedded to opt out of forward compatibility for this service.
@@ -138,6 +316,32 @@ func _Disperser_DisperseBlob_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Disperser_DisperseBlobStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DisperserServer).DisperseBlobStream(&disperserDisperseBlobStreamServer{stream})
+}
+
+type Disperser_DisperseBlobStreamServer interface {
+	SendAndClose(*DisperseBlobReply) error
+	Recv() (*DisperseBlobRequest, error)
+	grpc.ServerStream
+}
+
+type disperserDisperseBlobStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *disperserDisperseBlobStreamServer) SendAndClose(m *DisperseBlobReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *disperserDisperseBlobStreamServer) Recv() (*DisperseBlobRequest, error) {
+	m := new(DisperseBlobRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func _Disperser_GetBlobStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(BlobStatusRequest)
 	if err := dec(in); err != nil {
@@ -156,6 +360,27 @@ func _Disperser_GetBlobStatus_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Disperser_SubscribeBlobStatus_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BlobStatusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DisperserServer).SubscribeBlobStatus(m, &disperserSubscribeBlobStatusServer{stream})
+}
+
+type Disperser_SubscribeBlobStatusServer interface {
+	Send(*BlobStatusReply) error
+	grpc.ServerStream
+}
+
+type disperserSubscribeBlobStatusServer struct {
+	grpc.ServerStream
+}
+
+func (x *disperserSubscribeBlobStatusServer) Send(m *BlobStatusReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Disperser_RetrieveBlob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(RetrieveBlobRequest)
 	if err := dec(in); err != nil {
@@ -174,6 +399,42 @@ func _Disperser_RetrieveBlob_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Disperser_GetBatchStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DisperserServer).GetBatchStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/disperser.Disperser/GetBatchStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DisperserServer).GetBatchStatus(ctx, req.(*BatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Disperser_GetBatchCertificate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DisperserServer).GetBatchCertificate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/disperser.Disperser/GetBatchCertificate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DisperserServer).GetBatchCertificate(ctx, req.(*BatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Disperser_ServiceDesc is the grpc.ServiceDesc for Disperser service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -193,7 +454,26 @@ var Disperser_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RetrieveBlob",
 			Handler:    _Disperser_RetrieveBlob_Handler,
 		},
+		{
+			MethodName: "GetBatchStatus",
+			Handler:    _Disperser_GetBatchStatus_Handler,
+		},
+		{
+			MethodName: "GetBatchCertificate",
+			Handler:    _Disperser_GetBatchCertificate_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "DisperseBlobStream",
+			Handler:       _Disperser_DisperseBlobStream_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "SubscribeBlobStatus",
+			Handler:       _Disperser_SubscribeBlobStatus_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "disperser/disperser.proto",
 }
@@ -0,0 +1,103 @@
+// Package certificate holds the certificates the disperser serves for the confirmed blobs
+// and batches. They are shared by the server and the clients verifying them, so the package
+// depends on no server package.
+package certificate
+
+import (
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// BatchStatus is the status of a batch, derived from the metadata of its blobs
+type BatchStatus struct {
+	BatchHeaderHash         hexutil.Bytes   `json:"batch_header_hash"`
+	BatchID                 uint32          `json:"batch_id"`
+	BatchRoot               hexutil.Bytes   `json:"batch_root"`
+	Status                  string          `json:"status"`
+	Epoch                   uint64          `json:"epoch"`
+	QuorumId                uint64          `json:"quorum_id"`
+	NumBlobs                int             `json:"num_blobs"`
+	SubmissionTxnHash       eth_common.Hash `json:"submission_txn_hash"`
+	ConfirmationTxnHash     eth_common.Hash `json:"confirmation_txn_hash"`
+	ConfirmationBlockNumber uint32          `json:"confirmation_block_number"`
+	// SignerBitmap are the signers of the quorum that signed the batch, see core.SignerBitmap
+	SignerBitmap hexutil.Bytes `json:"signer_bitmap,omitempty"`
+	NumSigners   uint32        `json:"num_signers,omitempty"`
+	SignedCount  int           `json:"signed_count,omitempty"`
+	// ReferenceBlockNumber is the block the epoch of the signers was set at
+	ReferenceBlockNumber uint32 `json:"reference_block_number,omitempty"`
+	// Retention is the storage period of the blobs of the batch, if configured
+	Retention *Retention `json:"retention,omitempty"`
+	// Venue is the deployment the batch was confirmed on, if a fallback one is configured
+	Venue *ConfirmationVenue `json:"venue,omitempty"`
+}
+
+// ConfirmationVenue is the deployment of the DA contracts a batch was confirmed on. It is the
+// JSON encoding of disperser.ConfirmationVenue in api/proto/disperser/disperser.proto.
+type ConfirmationVenue struct {
+	ChainID  uint64        `json:"chain_id"`
+	Contract hexutil.Bytes `json:"contract"`
+	Fallback bool          `json:"fallback,omitempty"`
+}
+
+// Retention is the storage period of the blobs of a batch. It is the JSON encoding of
+// disperser.Retention in api/proto/disperser/disperser.proto.
+type Retention struct {
+	ExpiryBlockNumber uint64 `json:"expiry_block_number"`
+	ExpiryTime        int64  `json:"expiry_time,omitempty"`
+	RenewalStatus     string `json:"renewal_status"`
+}
+
+// QuorumResult is the share of the stake of a quorum that signed a blob, with the security
+// parameters it was confirmed under
+type QuorumResult struct {
+	QuorumID           uint8 `json:"quorum_id"`
+	PercentSigned      uint8 `json:"percent_signed"`
+	AdversaryThreshold uint8 `json:"adversary_threshold,omitempty"`
+	QuorumThreshold    uint8 `json:"quorum_threshold,omitempty"`
+	Fallback           bool  `json:"fallback,omitempty"`
+}
+
+// BlobCertificate is the header of a blob in a batch with its inclusion proof. It is the
+// JSON encoding of disperser.BlobCertificate in api/proto/disperser/disperser.proto.
+type BlobCertificate struct {
+	BlobIndex      uint32          `json:"blob_index"`
+	DataRoot       hexutil.Bytes   `json:"data_root"`
+	CommitmentRoot hexutil.Bytes   `json:"commitment_root"`
+	Length         uint32          `json:"length"`
+	InclusionProof hexutil.Bytes   `json:"inclusion_proof"`
+	QuorumResults  []*QuorumResult `json:"quorum_results,omitempty"`
+	// AggregateSignature is the aggregate BLS signature of the signers of the blob, a
+	// serialized G1 point, empty for the blobs confirmed before it was recorded
+	AggregateSignature hexutil.Bytes `json:"aggregate_signature,omitempty"`
+	// AggregatePubKey is the aggregate public key of the signers of the blob, a serialized
+	// G2 point
+	AggregatePubKey hexutil.Bytes `json:"aggregate_pub_key,omitempty"`
+}
+
+// BatchMultiProof proves all blobs of a certificate against the batch root at once. The
+// hashes are keyed by node index, the root being 1 and the leaf of blob i being Values+i,
+// which is the layout of merkletree.MultiProof.
+type BatchMultiProof struct {
+	Values  uint64                   `json:"values"`
+	Indices []uint64                 `json:"indices"`
+	Hashes  map[uint64]hexutil.Bytes `json:"hashes"`
+}
+
+// BatchCertificate bundles the headers of all known blobs of a batch with a multiproof
+// against the batch root, for consumers processing whole batches.
+type BatchCertificate struct {
+	BatchStatus
+	Blobs      []*BlobCertificate `json:"blobs"`
+	MultiProof *BatchMultiProof   `json:"multi_proof"`
+}
+
+// Blob returns the certificate of the blob at index in the batch, nil if it isn't known
+func (c *BatchCertificate) Blob(index uint32) *BlobCertificate {
+	for _, blob := range c.Blobs {
+		if blob.BlobIndex == index {
+			return blob
+		}
+	}
+	return nil
+}
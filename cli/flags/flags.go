@@ -4,6 +4,7 @@ import (
 	"github.com/0glabs/0g-da-client/common"
 	"github.com/0glabs/0g-da-client/common/aws"
 	"github.com/0glabs/0g-da-client/common/logging"
+	"github.com/0glabs/0g-da-client/disperser/common/pgstore"
 	"github.com/urfave/cli"
 )
 
@@ -26,6 +27,61 @@ var (
 		Required: true,
 		EnvVar:   common.PrefixEnvVar(envVarPrefix, "TABLE_NAME"),
 	}
+	CheckpointFileFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "checkpoint-file"),
+		Usage:  "File recording the progress of an index rebuild. An existing checkpoint is resumed",
+		Value:  "rebuild-indexes.checkpoint",
+		EnvVar: common.PrefixEnvVar(envVarPrefix, "CHECKPOINT_FILE"),
+	}
+	ReplicaRegionFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "replica-region"),
+		Usage:    "AWS region of the replica table",
+		Required: true,
+		EnvVar:   common.PrefixEnvVar(envVarPrefix, "REPLICA_REGION"),
+	}
+	ReplicaEndpointURLFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "replica-endpoint-url"),
+		Usage:  "AWS endpoint URL of the replica table",
+		EnvVar: common.PrefixEnvVar(envVarPrefix, "REPLICA_ENDPOINT_URL"),
+	}
+	ReplicaTableNameFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "replica-table-name"),
+		Usage:    "Name of the replica dynamodb table",
+		Required: true,
+		EnvVar:   common.PrefixEnvVar(envVarPrefix, "REPLICA_TABLE_NAME"),
+	}
+	ForceFlag = cli.BoolFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "force"),
+		Usage:  "Continue when the primary table cannot be read",
+		EnvVar: common.PrefixEnvVar(envVarPrefix, "FORCE"),
+	}
+	SnapshotFileFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "snapshot-file"),
+		Usage:    "File the disperser snapshot is written to or restored from",
+		Required: true,
+		EnvVar:   common.PrefixEnvVar(envVarPrefix, "SNAPSHOT_FILE"),
+	}
+	EncodingJournalPathFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "encoding-journal-path"),
+		Usage:  "Encoding journal of the batcher included in the snapshot or restored. Left out if empty",
+		EnvVar: common.PrefixEnvVar(envVarPrefix, "ENCODING_JOURNAL_PATH"),
+	}
+	BatchJournalPathFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "batch-journal-path"),
+		Usage:  "Batch journal of the batcher included in the snapshot or restored. Left out if empty",
+		EnvVar: common.PrefixEnvVar(envVarPrefix, "BATCH_JOURNAL_PATH"),
+	}
+	MetadataHashAsBlobKeyFlag = cli.BoolFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "metadata-hash-as-blob-key"),
+		Usage:  "Whether the blob store of the disperser keys blobs by their metadata hash",
+		EnvVar: common.PrefixEnvVar(envVarPrefix, "METADATA_HASH_AS_BLOB_KEY"),
+	}
+	PageSizeFlag = cli.IntFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "page-size"),
+		Usage:  "Number of records scanned per page",
+		Value:  100,
+		EnvVar: common.PrefixEnvVar(envVarPrefix, "PAGE_SIZE"),
+	}
 )
 
 // Flags contains the list of configuration options available to the binary.
@@ -33,4 +89,5 @@ var Flags []cli.Flag
 
 func init() {
 	Flags = append(logging.CLIFlags(envVarPrefix, FlagPrefix), aws.ClientFlags(envVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, pgstore.CLIFlags(envVarPrefix, FlagPrefix)...)
 }
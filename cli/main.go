@@ -5,13 +5,18 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/0glabs/0g-da-client/cli/flags"
+	"github.com/0glabs/0g-da-client/common"
 	"github.com/0glabs/0g-da-client/common/aws/dynamodb"
 	"github.com/0glabs/0g-da-client/common/aws/s3"
 	"github.com/0glabs/0g-da-client/common/logging"
 	"github.com/0glabs/0g-da-client/common/store"
+	"github.com/0glabs/0g-da-client/disperser"
 	"github.com/0glabs/0g-da-client/disperser/common/blobstore"
+	"github.com/0glabs/0g-da-client/disperser/common/pgstore"
+	"github.com/0glabs/0g-da-client/disperser/snapshot"
 	"github.com/urfave/cli"
 )
 
@@ -99,6 +104,38 @@ func main() {
 					Flags:   append(flags.Flags, flags.DynamoDBTableNameFlag),
 					Action:  ClearBucketTable,
 				},
+				{
+					Name:    "rebuild_metadata_indexes",
+					Aliases: []string{"rmi"},
+					Usage:   "rewrite every blob metadata record to rebuild the secondary indexes",
+					Flags:   append(flags.Flags, flags.DynamoDBTableNameFlag, flags.CheckpointFileFlag, flags.PageSizeFlag),
+					Action:  RebuildMetadataIndexes,
+				},
+				{
+					Name:    "promote_replica",
+					Aliases: []string{"pr"},
+					Usage:   "catch the metadata replica up with the primary table so it can serve as the new primary",
+					Flags:   append(flags.Flags, flags.DynamoDBTableNameFlag, flags.ReplicaRegionFlag, flags.ReplicaEndpointURLFlag, flags.ReplicaTableNameFlag, flags.ForceFlag, flags.PageSizeFlag),
+					Action:  PromoteReplica,
+				},
+			},
+		},
+		{
+			Name:  "snapshot",
+			Usage: "snapshot of the disperser state for upgrades",
+			Subcommands: []cli.Command{
+				{
+					Name:   "take",
+					Usage:  "write the queued blobs, the dispersal nonces and the batcher journals of a stopped disperser to a snapshot file",
+					Flags:  append(flags.Flags, snapshotFlags()...),
+					Action: TakeSnapshot,
+				},
+				{
+					Name:   "restore",
+					Usage:  "restore a snapshot file into the blob store and batcher journals of a new disperser",
+					Flags:  append(flags.Flags, snapshotFlags()...),
+					Action: RestoreSnapshot,
+				},
 			},
 		},
 	}
@@ -244,6 +281,191 @@ func ClearBucketTable(ctx *cli.Context) error {
 	return nil
 }
 
+func RebuildMetadataIndexes(ctx *cli.Context) error {
+	config := NewConfig(ctx)
+
+	logger, err := logging.GetLogger(config.LoggerConfig)
+	if err != nil {
+		return err
+	}
+	dynamoClient, err := getDynamodbClient(config)
+	if err != nil {
+		return err
+	}
+
+	checkpointFile := ctx.String(flags.CheckpointFileFlag.Name)
+	checkpoint, err := blobstore.LoadRebuildCheckpoint(checkpointFile)
+	if err != nil {
+		return err
+	}
+	if checkpoint.Done {
+		log.Println("index rebuild already completed, remove the checkpoint file to run again:", checkpointFile)
+		return nil
+	}
+	if checkpoint.LastKey != nil {
+		log.Println("resuming index rebuild after", checkpoint.Scanned, "records")
+	}
+
+	tableName := ctx.String(flags.DynamoDBTableNameFlag.Name)
+	metadataStore := blobstore.NewBlobMetadataStore(dynamoClient, logger, tableName, 0)
+	start := time.Now()
+	err = metadataStore.RebuildIndexes(context.Background(), checkpoint, int32(ctx.Int(flags.PageSizeFlag.Name)), func(checkpoint *blobstore.RebuildCheckpoint) error {
+		log.Printf("scanned %d, rewritten %d, skipped %d, changed meanwhile %d (%s elapsed)", checkpoint.Scanned, checkpoint.Rewritten, checkpoint.Skipped, checkpoint.Changed, time.Since(start).Round(time.Second))
+		return blobstore.SaveRebuildCheckpoint(checkpointFile, checkpoint)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Println("index rebuild completed")
+	return nil
+}
+
+// PromoteReplica copies whatever the asynchronous replication has not yet applied from
+// the primary table to the replica. When the primary region is down the copy fails and
+// --force promotes the replica as is, losing only the replications still in flight.
+func PromoteReplica(ctx *cli.Context) error {
+	config := NewConfig(ctx)
+
+	logger, err := logging.GetLogger(config.LoggerConfig)
+	if err != nil {
+		return err
+	}
+	dynamoClient, err := getDynamodbClient(config)
+	if err != nil {
+		return err
+	}
+
+	replicationConfig := blobstore.ReplicationConfig{
+		Region:      ctx.String(flags.ReplicaRegionFlag.Name),
+		EndpointURL: ctx.String(flags.ReplicaEndpointURLFlag.Name),
+		TableName:   ctx.String(flags.ReplicaTableNameFlag.Name),
+	}
+	replicaStore, err := blobstore.NewReplicaMetadataStore(replicationConfig, config.AwsClientConfig, logger)
+	if err != nil {
+		return err
+	}
+	primaryStore := blobstore.NewBlobMetadataStore(dynamoClient, logger, ctx.String(flags.DynamoDBTableNameFlag.Name), 0)
+
+	err = primaryStore.CopyTo(context.Background(), replicaStore, int32(ctx.Int(flags.PageSizeFlag.Name)), func(copied int) error {
+		log.Printf("copied or deleted %d records of the replica", copied)
+		return nil
+	})
+	if err != nil {
+		if !ctx.Bool(flags.ForceFlag.Name) {
+			return fmt.Errorf("failed to catch up the replica, rerun with --%s to promote it anyway: %w", flags.ForceFlag.Name, err)
+		}
+		log.Println("failed to catch up the replica, promoting anyway:", err)
+	}
+
+	log.Printf("replica promoted, start the disperser with --<prefix>.aws.region %s --<prefix>.dynamodb-table-name %s", replicationConfig.Region, replicationConfig.TableName)
+	return nil
+}
+
+// snapshotFlags are the flags of the snapshot commands. The blob store is read from
+// postgres if its DSN is set, from s3 and dynamodb otherwise.
+func snapshotFlags() []cli.Flag {
+	bucket, table := flags.S3BucketNameFlag, flags.DynamoDBTableNameFlag
+	bucket.Required = false
+	table.Required = false
+	return []cli.Flag{bucket, table, flags.SnapshotFileFlag, flags.EncodingJournalPathFlag, flags.BatchJournalPathFlag, flags.MetadataHashAsBlobKeyFlag}
+}
+
+// TakeSnapshot writes the blobs a disperser still acts on, its dispersal nonces and the
+// journals of its batcher to the snapshot file. The api server and batcher must be stopped
+// for the snapshot to be consistent, the batcher otherwise holding the locks of the journals.
+func TakeSnapshot(ctx *cli.Context) error {
+	config := NewConfig(ctx)
+	logger, err := logging.GetLogger(config.LoggerConfig)
+	if err != nil {
+		return err
+	}
+	store, err := getBlobStore(ctx, config, logger)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(ctx.String(flags.SnapshotFileFlag.Name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	summary, err := snapshot.Take(context.Background(), store, snapshotJournals(ctx), file, logger)
+	if err == nil {
+		err = file.Sync()
+	}
+	if err != nil {
+		// a partial snapshot must not be restored
+		_ = os.Remove(file.Name())
+		return err
+	}
+
+	log.Printf("snapshot written to %s: blobs %v, journal entries %d, batch journal entries %d, signer nonces %d", file.Name(), summary.Blobs, summary.JournalEntries, summary.BatchJournalEntries, summary.Nonces)
+	if summary.Skipped > 0 {
+		log.Printf("%d confirmed blobs whose content was removed are left to the old disperser to finalize", summary.Skipped)
+	}
+	return nil
+}
+
+// RestoreSnapshot stores the blobs and dispersal nonces of the snapshot file in the blob
+// store of the new disperser and writes its journals, before the new disperser is started.
+func RestoreSnapshot(ctx *cli.Context) error {
+	config := NewConfig(ctx)
+	logger, err := logging.GetLogger(config.LoggerConfig)
+	if err != nil {
+		return err
+	}
+	store, err := getBlobStore(ctx, config, logger)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(ctx.String(flags.SnapshotFileFlag.Name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	summary, err := snapshot.Restore(context.Background(), file, store, snapshotJournals(ctx), logger)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("snapshot restored from %s: blobs %v, journal entries %d, batch journal entries %d, signer nonces %d", file.Name(), summary.Blobs, summary.JournalEntries, summary.BatchJournalEntries, summary.Nonces)
+	return nil
+}
+
+func snapshotJournals(ctx *cli.Context) snapshot.Journals {
+	return snapshot.Journals{
+		Encoding: ctx.String(flags.EncodingJournalPathFlag.Name),
+		Batch:    ctx.String(flags.BatchJournalPathFlag.Name),
+	}
+}
+
+func getBlobStore(ctx *cli.Context, cfg *Config, logger common.Logger) (disperser.BlobStore, error) {
+	metadataHashAsBlobKey := ctx.Bool(flags.MetadataHashAsBlobKeyFlag.Name)
+	pgConfig := pgstore.ReadCLIConfig(ctx, flags.FlagPrefix)
+	if pgConfig.Enabled() {
+		return pgstore.Open(context.Background(), pgConfig, metadataHashAsBlobKey, logger)
+	}
+
+	bucketName := ctx.String(flags.S3BucketNameFlag.Name)
+	tableName := ctx.String(flags.DynamoDBTableNameFlag.Name)
+	if bucketName == "" || tableName == "" {
+		return nil, fmt.Errorf("either --%s or both --%s and --%s must be set",
+			common.PrefixFlag(flags.FlagPrefix, pgstore.DSNFlagName), flags.S3BucketNameFlag.Name, flags.DynamoDBTableNameFlag.Name)
+	}
+	s3Client, err := getS3Client(cfg)
+	if err != nil {
+		return nil, err
+	}
+	dynamoClient, err := getDynamodbClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	metadataStore := blobstore.NewBlobMetadataStore(dynamoClient, logger, tableName, 0)
+	return blobstore.NewSharedStorage(bucketName, s3Client, metadataHashAsBlobKey, metadataStore, logger), nil
+}
+
 func getS3Client(cfg *Config) (*s3.Client, error) {
 	logger, err := logging.GetLogger(cfg.LoggerConfig)
 	if err != nil {
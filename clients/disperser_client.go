@@ -0,0 +1,376 @@
+// Package clients is a Go client of the disperser gRPC API for integrators. It streams the
+// blobs too large for a single message in chunks, retries the calls failing with transient
+// errors, waits for the blobs to be confirmed or finalized, signs the dispersals of an
+// account and verifies the retrieved blobs.
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/tlsconfig"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser/dispersal"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrDispersalFailed    = errors.New("blob dispersal failed")
+	ErrVerificationFailed = errors.New("retrieved blob failed verification")
+)
+
+// DefaultChunkSize is the size of the messages the blobs larger than it are streamed in,
+// below the 4 MiB messages the disperser accepts
+const DefaultChunkSize = 1024 * 1024
+
+// BackoffConfig is an exponential backoff
+type BackoffConfig struct {
+	// Initial is the first delay
+	Initial time.Duration
+	// Max caps the delay
+	Max time.Duration
+	// Multiplier is applied to the delay after each attempt
+	Multiplier float64
+	// MaxAttempts bounds the attempts of a call, unbounded if 0. Unused by the status polls,
+	// which are bounded by their context.
+	MaxAttempts int
+}
+
+var (
+	DefaultRetry = BackoffConfig{Initial: 500 * time.Millisecond, Max: 10 * time.Second, Multiplier: 2, MaxAttempts: 5}
+	DefaultPoll  = BackoffConfig{Initial: time.Second, Max: 30 * time.Second, Multiplier: 1.5}
+)
+
+func (c BackoffConfig) next(delay time.Duration) time.Duration {
+	next := time.Duration(float64(delay) * c.Multiplier)
+	if c.Max > 0 && next > c.Max {
+		return c.Max
+	}
+	return max(next, delay)
+}
+
+func (c BackoffConfig) orDefault(d BackoffConfig) BackoffConfig {
+	if c.Initial <= 0 {
+		return d
+	}
+	if c.Multiplier < 1 {
+		c.Multiplier = 1
+	}
+	return c
+}
+
+type Config struct {
+	DisperserAddr string
+	// TLS secures the connection to the disperser
+	TLS tlsconfig.ClientConfig
+	// Timeout bounds each attempt of a call to the disperser, unbounded if 0
+	Timeout time.Duration
+	// ChunkSize is the size of the messages blobs larger than it are streamed in, defaults
+	// to DefaultChunkSize
+	ChunkSize int
+	// Retry is the backoff of the calls failing with transient errors, defaults to
+	// DefaultRetry. The delays the disperser asks for when rate limiting are honored. The
+	// dispersals are only retried when rate limited, see DisperseBlob.
+	Retry BackoffConfig
+	// Poll is the backoff of the status polls of WaitForConfirmation and WaitForFinality,
+	// defaults to DefaultPoll
+	Poll BackoffConfig
+	// Signer signs the dispersals if set. Unsigned dispersals are accounted to the API key
+	// or the address of the client.
+	Signer Signer
+	// Verifier checks the retrieved blobs against their certificate if set
+	Verifier Verifier
+}
+
+// DisperseRequest is a blob to disperse and its options
+type DisperseRequest struct {
+	Data []byte
+	// SecurityParams are the thresholds the blob is confirmed with, at most one per quorum
+	SecurityParams []*pb.SecurityParams
+	// Tags are application metadata stored with the blob
+	Tags map[string]string
+	// Priority is the priority lane of the blob, 0 to 2, lanes above 0 being restricted to
+	// the priority accounts of the disperser
+	Priority int
+	// PriceQuote is the quote token of the /price endpoint the blob is dispersed under,
+	// required if the disperser prices the dispersals. It must be quoted for the account
+	// of the dispersal and at least the size of the blob.
+	PriceQuote string
+}
+
+// DisperserClient calls the disperser gRPC API
+type DisperserClient struct {
+	config    Config
+	disperser pb.DisperserClient
+	conn      *grpc.ClientConn
+	logger    common.Logger
+}
+
+func NewDisperserClient(config Config, logger common.Logger) (*DisperserClient, error) {
+	if config.DisperserAddr == "" {
+		return nil, errors.New("disperser address is required")
+	}
+	creds, err := tlsconfig.ClientCredentials(config.TLS, logger)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.Dial(
+		config.DisperserAddr,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(1024*1024*1024)), // 1 GiB
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial disperser: %w", err)
+	}
+	c := newDisperserClient(config, pb.NewDisperserClient(conn), logger)
+	c.conn = conn
+	return c, nil
+}
+
+func newDisperserClient(config Config, disperser pb.DisperserClient, logger common.Logger) *DisperserClient {
+	if config.ChunkSize <= 0 {
+		config.ChunkSize = DefaultChunkSize
+	}
+	config.Retry = config.Retry.orDefault(DefaultRetry)
+	config.Poll = config.Poll.orDefault(DefaultPoll)
+	return &DisperserClient{config: config, disperser: disperser, logger: logger}
+}
+
+func (c *DisperserClient) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// DisperseBlob disperses a blob, streamed in chunks if it is larger than the chunk size,
+// and returns the reply of the disperser, whose request ID identifies the blob. A dispersal
+// isn't idempotent, so it is only retried when the disperser rejected it before accepting
+// the blob, which it does when rate limiting. A signed dispersal is signed again with a new
+// nonce for every attempt, the disperser using up the nonce of a rejected one, and the
+// dispersals of a KeySigner are sent one at a time in the order of their nonces. Any other
+// error may come after the blob was accepted, and is returned: the blob could be dispersed
+// twice.
+func (c *DisperserClient) DisperseBlob(ctx context.Context, req *DisperseRequest) (*pb.DisperseBlobReply, error) {
+	if len(req.Data) == 0 || len(req.Data) > core.MaxBlobSize {
+		return nil, fmt.Errorf("blob size must be in range [1, %d]", core.MaxBlobSize)
+	}
+	request := &pb.DisperseBlobRequest{
+		Data:           req.Data,
+		SecurityParams: req.SecurityParams,
+		Tags:           req.Tags,
+	}
+	if req.Priority > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, dispersal.PriorityHeader, strconv.Itoa(req.Priority))
+	}
+	if req.PriceQuote != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, dispersal.PriceQuoteHeader, req.PriceQuote)
+	}
+
+	var reply *pb.DisperseBlobReply
+	err := c.retry(ctx, "DisperseBlob", rejected, func(ctx context.Context) error {
+		attempt := request
+		if c.config.Signer != nil {
+			if signer, ok := c.config.Signer.(sequencedSigner); ok {
+				release, err := signer.acquire(ctx)
+				if err != nil {
+					return err
+				}
+				defer release()
+			}
+			nonce, signature, err := c.config.Signer.Sign(ctx, dispersal.SignedRequest(request))
+			if err != nil {
+				return fmt.Errorf("failed to sign dispersal: %w", err)
+			}
+			attempt = &pb.DisperseBlobRequest{
+				Data:           request.Data,
+				SecurityParams: request.SecurityParams,
+				Tags:           request.Tags,
+				Nonce:          nonce,
+				Signature:      signature,
+			}
+		}
+		var err error
+		if len(req.Data) > c.config.ChunkSize {
+			reply, err = c.disperseStream(ctx, attempt)
+		} else {
+			reply, err = c.disperser.DisperseBlob(ctx, attempt)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to disperse blob: %w", err)
+	}
+	c.logger.Debug("[client] blob dispersed", "request id", string(reply.GetRequestId()), "size", len(req.Data))
+	return reply, nil
+}
+
+// disperseStream streams request in chunks, the options of the blob sent with the first
+func (c *DisperserClient) disperseStream(ctx context.Context, request *pb.DisperseBlobRequest) (*pb.DisperseBlobReply, error) {
+	stream, err := c.disperser.DisperseBlobStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data := request.GetData()
+	for offset := 0; offset < len(data); offset += c.config.ChunkSize {
+		chunk := &pb.DisperseBlobRequest{Data: data[offset:min(offset+c.config.ChunkSize, len(data))]}
+		if offset == 0 {
+			chunk.SecurityParams = request.GetSecurityParams()
+			chunk.Nonce = request.GetNonce()
+			chunk.Signature = request.GetSignature()
+			chunk.Tags = request.GetTags()
+		}
+		if err := stream.Send(chunk); err != nil {
+			// the error of a stream closed by the disperser is that of CloseAndRecv
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+	}
+	return stream.CloseAndRecv()
+}
+
+// GetBlobStatus returns the status of the blob of a request
+func (c *DisperserClient) GetBlobStatus(ctx context.Context, requestID []byte) (*pb.BlobStatusReply, error) {
+	var reply *pb.BlobStatusReply
+	err := c.retry(ctx, "GetBlobStatus", transient, func(ctx context.Context) error {
+		var err error
+		reply, err = c.disperser.GetBlobStatus(ctx, &pb.BlobStatusRequest{RequestId: requestID})
+		return err
+	})
+	return reply, err
+}
+
+// WaitForConfirmation polls the status of the blob of a request until it is confirmed or
+// finalized, or ctx is done. ErrDispersalFailed is returned if the dispersal failed.
+func (c *DisperserClient) WaitForConfirmation(ctx context.Context, requestID []byte) (*pb.BlobStatusReply, error) {
+	return c.waitFor(ctx, requestID, false)
+}
+
+// WaitForFinality is WaitForConfirmation waiting for the blob to be finalized
+func (c *DisperserClient) WaitForFinality(ctx context.Context, requestID []byte) (*pb.BlobStatusReply, error) {
+	return c.waitFor(ctx, requestID, true)
+}
+
+func (c *DisperserClient) waitFor(ctx context.Context, requestID []byte, finalized bool) (*pb.BlobStatusReply, error) {
+	delay := c.config.Poll.Initial
+	for {
+		reply, err := c.GetBlobStatus(ctx, requestID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get blob status: %w", err)
+		}
+		switch reply.GetStatus() {
+		case pb.BlobStatus_FINALIZED:
+			return reply, nil
+		case pb.BlobStatus_CONFIRMED:
+			if !finalized {
+				return reply, nil
+			}
+		case pb.BlobStatus_FAILED, pb.BlobStatus_INSUFFICIENT_SIGNATURES:
+			return reply, fmt.Errorf("%w: request %s is %s", ErrDispersalFailed, requestID, reply.GetStatus())
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = c.config.Poll.next(delay)
+	}
+}
+
+// RetrieveBlob retrieves the data of a confirmed blob from the disperser. certificate is the
+// status of the blob once confirmed, as returned by WaitForConfirmation, which the data is
+// checked against by the verifier if set. ErrVerificationFailed is returned if the data
+// isn't that of the certified blob.
+func (c *DisperserClient) RetrieveBlob(ctx context.Context, certificate *pb.BlobStatusReply) ([]byte, error) {
+	if !certified(certificate) {
+		return nil, fmt.Errorf("blob is %s, not confirmed", certificate.GetStatus())
+	}
+	header := certificate.GetInfo().GetBlobHeader()
+	var reply *pb.RetrieveBlobReply
+	err := c.retry(ctx, "RetrieveBlob", transient, func(ctx context.Context) error {
+		var err error
+		reply, err = c.disperser.RetrieveBlob(ctx, &pb.RetrieveBlobRequest{
+			StorageRoot: header.GetStorageRoot(),
+			Epoch:       header.GetEpoch(),
+			QuorumId:    header.GetQuorumId(),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve blob: %w", err)
+	}
+	if c.config.Verifier != nil {
+		if err := c.config.Verifier.Verify(ctx, certificate, reply.GetData()); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrVerificationFailed, err)
+		}
+	}
+	return reply.GetData(), nil
+}
+
+// retry calls fn until it succeeds, fails with an error that isn't retryable, or runs out
+// of attempts
+func (c *DisperserClient) retry(ctx context.Context, call string, retryable func(error) bool, fn func(ctx context.Context) error) error {
+	delay := c.config.Retry.Initial
+	for attempt := 1; ; attempt++ {
+		callCtx, cancel := ctx, context.CancelFunc(func() {})
+		if c.config.Timeout > 0 {
+			callCtx, cancel = context.WithTimeout(ctx, c.config.Timeout)
+		}
+		err := fn(callCtx)
+		cancel()
+		if err == nil || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+		if c.config.Retry.MaxAttempts > 0 && attempt >= c.config.Retry.MaxAttempts {
+			return err
+		}
+
+		wait := max(delay, retryDelay(err))
+		c.logger.Debug("[client] retrying call", "call", call, "attempt", attempt, "delay", wait, "err", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		delay = c.config.Retry.next(delay)
+	}
+}
+
+// transient returns whether a call failing with err may succeed if retried
+func transient(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// rejected returns whether a dispersal failing with err was rejected before the blob was
+// accepted, the quotas and rate limits being checked first
+func rejected(err error) bool {
+	return status.Code(err) == codes.ResourceExhausted
+}
+
+// retryDelay returns the delay the disperser asked for in the details of err, 0 if none
+func retryDelay(err error) time.Duration {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			return info.GetRetryDelay().AsDuration()
+		}
+	}
+	return 0
+}
@@ -0,0 +1,208 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser/dispersal"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeDisperser struct {
+	pb.DisperserClient
+	// unavailable is the number of calls failing before the disperser answers
+	unavailable int
+	// rateLimited is the number of dispersals rejected before the disperser accepts one
+	rateLimited int
+	requests    []*pb.DisperseBlobRequest
+	chunks      []*pb.DisperseBlobRequest
+	statuses    []pb.BlobStatus
+	data        []byte
+}
+
+func (d *fakeDisperser) fail() error {
+	if d.unavailable > 0 {
+		d.unavailable--
+		return status.Error(codes.Unavailable, "connection refused")
+	}
+	return nil
+}
+
+func (d *fakeDisperser) DisperseBlob(ctx context.Context, in *pb.DisperseBlobRequest, opts ...grpc.CallOption) (*pb.DisperseBlobReply, error) {
+	d.requests = append(d.requests, in)
+	if d.rateLimited > 0 {
+		d.rateLimited--
+		return nil, status.Error(codes.ResourceExhausted, "rate limited")
+	}
+	if err := d.fail(); err != nil {
+		return nil, err
+	}
+	return &pb.DisperseBlobReply{Result: pb.BlobStatus_PROCESSING, RequestId: []byte("request")}, nil
+}
+
+func (d *fakeDisperser) DisperseBlobStream(ctx context.Context, opts ...grpc.CallOption) (pb.Disperser_DisperseBlobStreamClient, error) {
+	return &fakeStream{d: d}, nil
+}
+
+func (d *fakeDisperser) GetBlobStatus(ctx context.Context, in *pb.BlobStatusRequest, opts ...grpc.CallOption) (*pb.BlobStatusReply, error) {
+	if err := d.fail(); err != nil {
+		return nil, err
+	}
+	s := d.statuses[0]
+	if len(d.statuses) > 1 {
+		d.statuses = d.statuses[1:]
+	}
+	root := [32]byte{1}
+	return &pb.BlobStatusReply{Status: s, Info: &pb.BlobInfo{BlobHeader: &pb.BlobHeader{StorageRoot: root[:]}}}, nil
+}
+
+func (d *fakeDisperser) RetrieveBlob(ctx context.Context, in *pb.RetrieveBlobRequest, opts ...grpc.CallOption) (*pb.RetrieveBlobReply, error) {
+	return &pb.RetrieveBlobReply{Data: d.data}, nil
+}
+
+type fakeStream struct {
+	grpc.ClientStream
+	d *fakeDisperser
+}
+
+func (s *fakeStream) Send(chunk *pb.DisperseBlobRequest) error {
+	s.d.chunks = append(s.d.chunks, chunk)
+	return nil
+}
+
+func (s *fakeStream) CloseAndRecv() (*pb.DisperseBlobReply, error) {
+	return &pb.DisperseBlobReply{Result: pb.BlobStatus_PROCESSING, RequestId: []byte("streamed")}, nil
+}
+
+type rejectingVerifier struct{}
+
+func (rejectingVerifier) Verify(ctx context.Context, certificate *pb.BlobStatusReply, data []byte) error {
+	return errors.New("storage root mismatch")
+}
+
+func TestDisperserClient(t *testing.T) {
+	ctx := context.Background()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	domain := core.DispersalDomain{ChainID: 16600, Contract: eth_common.HexToAddress("0x01")}
+	signer := NewKeySigner(key, domain)
+	d := &fakeDisperser{rateLimited: 2}
+	fast := BackoffConfig{Initial: time.Millisecond, Multiplier: 2, MaxAttempts: 3}
+	c := newDisperserClient(Config{ChunkSize: 4, Retry: fast, Poll: fast, Signer: signer}, d, mock.NewLogger(false))
+
+	// rate limited dispersals are retried, signed again with a new nonce
+	reply, err := c.DisperseBlob(ctx, &DisperseRequest{Data: []byte("blob"), Tags: map[string]string{"block": "1"}})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("request"), reply.GetRequestId())
+	require.Len(t, d.requests, 3)
+	assert.Less(t, d.requests[0].GetNonce(), d.requests[1].GetNonce())
+	assert.Less(t, d.requests[1].GetNonce(), d.requests[2].GetNonce())
+	for _, request := range d.requests {
+		account, err := core.RecoverDispersalSigner(domain, dispersal.SignedRequest(request), request.GetSignature())
+		require.NoError(t, err)
+		assert.Equal(t, signer.Address(), account)
+	}
+
+	// but not those which may have reached the disperser
+	d.unavailable = 1
+	_, err = c.DisperseBlob(ctx, &DisperseRequest{Data: []byte("blob")})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Len(t, d.requests, 4)
+	// a signature is only valid for the domain it was signed for
+	account, err := core.RecoverDispersalSigner(core.DispersalDomain{ChainID: 1, Contract: domain.Contract}, dispersal.SignedRequest(d.requests[3]), d.requests[3].GetSignature())
+	require.NoError(t, err)
+	assert.NotEqual(t, signer.Address(), account)
+
+	// blobs larger than the chunk size are streamed, the options in the first chunk
+	reply, err = c.DisperseBlob(ctx, &DisperseRequest{Data: []byte("larger blob"), Tags: map[string]string{"block": "2"}})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("streamed"), reply.GetRequestId())
+	require.Len(t, d.chunks, 3)
+	assert.Equal(t, "larg", string(d.chunks[0].GetData()))
+	assert.Equal(t, "2", d.chunks[0].GetTags()["block"])
+	assert.NotEmpty(t, d.chunks[0].GetSignature())
+	assert.Equal(t, "lob", string(d.chunks[2].GetData()))
+	assert.Empty(t, d.chunks[2].GetSignature())
+
+	// confirmation returns before finality, which waits for it
+	d.statuses = []pb.BlobStatus{pb.BlobStatus_PROCESSING, pb.BlobStatus_CONFIRMED, pb.BlobStatus_FINALIZED}
+	blob, err := c.WaitForConfirmation(ctx, []byte("request"))
+	require.NoError(t, err)
+	assert.Equal(t, pb.BlobStatus_CONFIRMED, blob.GetStatus())
+	blob, err = c.WaitForFinality(ctx, []byte("request"))
+	require.NoError(t, err)
+	assert.Equal(t, pb.BlobStatus_FINALIZED, blob.GetStatus())
+
+	d.statuses = []pb.BlobStatus{pb.BlobStatus_INSUFFICIENT_SIGNATURES}
+	_, err = c.WaitForFinality(ctx, []byte("request"))
+	assert.ErrorIs(t, err, ErrDispersalFailed)
+
+	// calls give up after the max attempts
+	d.unavailable = 3
+	_, err = c.GetBlobStatus(ctx, []byte("request"))
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	// retrieved blobs are checked by the verifier against their certificate
+	d.data = []byte("blob")
+	data, err := c.RetrieveBlob(ctx, blob)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("blob"), data)
+	c.config.Verifier = rejectingVerifier{}
+	_, err = c.RetrieveBlob(ctx, blob)
+	assert.ErrorIs(t, err, ErrVerificationFailed)
+	// which must be that of a confirmed blob
+	_, err = c.RetrieveBlob(ctx, &pb.BlobStatusReply{Status: pb.BlobStatus_PROCESSING, Info: blob.GetInfo()})
+	assert.ErrorContains(t, err, "not confirmed")
+}
+
+func TestConcurrentSignedDispersals(t *testing.T) {
+	ctx := context.Background()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := NewKeySigner(key, core.DispersalDomain{ChainID: 16600, Contract: eth_common.HexToAddress("0x01")})
+	d := &fakeDisperser{}
+	fast := BackoffConfig{Initial: time.Millisecond, Multiplier: 2, MaxAttempts: 3}
+	// two clients sharing the signer
+	clients := []*DisperserClient{
+		newDisperserClient(Config{ChunkSize: 4, Retry: fast, Poll: fast, Signer: signer}, d, mock.NewLogger(false)),
+		newDisperserClient(Config{ChunkSize: 4, Retry: fast, Poll: fast, Signer: signer}, d, mock.NewLogger(false)),
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(c *DisperserClient) {
+			defer wg.Done()
+			_, err := c.DisperseBlob(ctx, &DisperseRequest{Data: []byte("blob")})
+			assert.NoError(t, err)
+		}(clients[i%2])
+	}
+	wg.Wait()
+
+	// the dispersals reached the disperser one at a time, in the order of their nonces
+	require.Len(t, d.requests, 16)
+	for i := 1; i < len(d.requests); i++ {
+		assert.Less(t, d.requests[i-1].GetNonce(), d.requests[i].GetNonce())
+	}
+
+	// a dispersal waiting for the signer gives up with its context
+	release, err := signer.acquire(ctx)
+	require.NoError(t, err)
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = clients[0].DisperseBlob(waitCtx, &DisperseRequest{Data: []byte("blob")})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	release()
+}
@@ -0,0 +1,82 @@
+package clients
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/core"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signer signs the dispersals of an account, which the disperser records as the account of
+// the blobs. Implementations may hold the key in a wallet, a KMS or a hardware signer.
+type Signer interface {
+	// Sign returns a nonce the account didn't use before and the ECDSA signature
+	// [R || S || V] of the core.DispersalDigest of req with that nonce, for the dispersal
+	// domain of the disperser, the nonce of req being ignored. The disperser accepts the
+	// nonces up to disperser.NonceWindowSize below the highest one the account used, in any
+	// order.
+	Sign(ctx context.Context, req core.DispersalRequest) (uint64, []byte, error)
+}
+
+// sequencedSigner is a Signer whose dispersals are sent one at a time, so that they reach the
+// disperser in the order of their nonces
+type sequencedSigner interface {
+	// acquire holds the signer from the signing of a dispersal until the disperser answered
+	// it, until release is called
+	acquire(ctx context.Context) (release func(), err error)
+}
+
+// KeySigner signs with a private key. Its nonces count up from the time it was created in
+// nanoseconds, so that they keep increasing across restarts. The clients sharing it send the
+// dispersals it signs one at a time, from the signing of one until the disperser answered
+// it, so that concurrent dispersals never reach the disperser out of the order of their
+// nonces. An account mustn't sign with several KeySigners at once, the nonces of the one
+// created first falling below the window of the other.
+type KeySigner struct {
+	key    *ecdsa.PrivateKey
+	domain core.DispersalDomain
+
+	mu        sync.Mutex
+	lastNonce uint64
+	// dispersing holds the signer while one of its dispersals is being sent
+	dispersing chan struct{}
+}
+
+var _ Signer = (*KeySigner)(nil)
+var _ sequencedSigner = (*KeySigner)(nil)
+
+// NewKeySigner returns a signer of the dispersals to the disperser of domain
+func NewKeySigner(key *ecdsa.PrivateKey, domain core.DispersalDomain) *KeySigner {
+	return &KeySigner{key: key, domain: domain, lastNonce: uint64(time.Now().UnixNano()), dispersing: make(chan struct{}, 1)}
+}
+
+// Address is the account of the dispersals signed
+func (s *KeySigner) Address() eth_common.Address {
+	return crypto.PubkeyToAddress(s.key.PublicKey)
+}
+
+func (s *KeySigner) Sign(ctx context.Context, req core.DispersalRequest) (uint64, []byte, error) {
+	s.mu.Lock()
+	s.lastNonce++
+	req.Nonce = s.lastNonce
+	s.mu.Unlock()
+
+	signature, err := core.SignDispersal(s.key, s.domain, req)
+	if err != nil {
+		return 0, nil, err
+	}
+	return req.Nonce, signature, nil
+}
+
+func (s *KeySigner) acquire(ctx context.Context) (func(), error) {
+	select {
+	case s.dispersing <- struct{}{}:
+		return func() { <-s.dispersing }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,65 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Verifier checks that the data retrieved for a blob is that of its certificate, the status
+// of the blob once confirmed
+type Verifier interface {
+	Verify(ctx context.Context, certificate *pb.BlobStatusReply, data []byte) error
+}
+
+// Encoder computes the commitments of a blob, such as the encoder client of the disperser
+type Encoder interface {
+	EncodeBlob(ctx context.Context, data []byte, log common.Logger) (*core.BlobCommitments, error)
+}
+
+// EncoderVerifier encodes the retrieved data with an encoder of the client's choosing and
+// compares its storage root with the certified one, so that neither the disperser nor the
+// operators are trusted with the data. The verify package also checks the erasure
+// commitment, the length, the inclusion proof and the aggregate signature of a blob against
+// the certificate of its batch.
+type EncoderVerifier struct {
+	Encoder Encoder
+	Logger  common.Logger
+}
+
+var _ Verifier = (*EncoderVerifier)(nil)
+
+func (v *EncoderVerifier) Verify(ctx context.Context, certificate *pb.BlobStatusReply, data []byte) error {
+	if !certified(certificate) {
+		return fmt.Errorf("blob is %s, not confirmed", certificate.GetStatus())
+	}
+	if len(data) == 0 {
+		return errors.New("blob is empty")
+	}
+	commitments, err := v.Encoder.EncodeBlob(ctx, data, v.Logger)
+	if err != nil {
+		return fmt.Errorf("failed to encode blob: %w", err)
+	}
+	certified := certificate.GetInfo().GetBlobHeader().GetStorageRoot()
+	if !bytes.Equal(commitments.StorageRoot, certified) {
+		return fmt.Errorf("storage root %s, certified %s", hexutil.Encode(commitments.StorageRoot), hexutil.Encode(certified))
+	}
+	return nil
+}
+
+// certified returns whether the status of a blob certifies its storage root, which it does
+// once the blob is confirmed
+func certified(certificate *pb.BlobStatusReply) bool {
+	switch certificate.GetStatus() {
+	case pb.BlobStatus_CONFIRMED, pb.BlobStatus_FINALIZED:
+		return len(certificate.GetInfo().GetBlobHeader().GetStorageRoot()) == 32
+	default:
+		return false
+	}
+}
@@ -0,0 +1,258 @@
+// Package approval gates the dangerous actions of the admin APIs behind the approval of
+// several admins, so that a single leaked admin token can't run them.
+package approval
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+)
+
+type Config struct {
+	// Admins maps the bearer tokens of the admins to their names, the actions aren't gated
+	// if empty
+	Admins map[string]string
+	// Threshold is the number of distinct admins who must request an action before it runs
+	Threshold int
+	// TTL is how long the approvals of an action wait for the others
+	TTL time.Duration
+	// AuditFile is appended the audit entries as json lines, unused if empty
+	AuditFile string
+}
+
+func (c Config) Enabled() bool {
+	return len(c.Admins) > 0
+}
+
+// Audit events
+const (
+	EventApproved = "approved"
+	EventExecuted = "executed"
+	EventRejected = "rejected"
+)
+
+// AuditEntry records an admin request of a gated action
+type AuditEntry struct {
+	Time      time.Time `json:"time"`
+	Event     string    `json:"event"`
+	Action    string    `json:"action"`
+	Admin     string    `json:"admin,omitempty"`
+	Approvers []string  `json:"approvers,omitempty"`
+	// Status is the http status the action replied with once executed
+	Status int `json:"status,omitempty"`
+}
+
+// PendingAction is an action approved by fewer admins than the threshold
+type PendingAction struct {
+	Action    string    `json:"action"`
+	Approvers []string  `json:"approvers"`
+	Threshold int       `json:"threshold"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Gate runs an action once Threshold distinct admins requested it with identical method,
+// path and query within TTL. The requests before then are answered 202 Accepted with the
+// PendingAction.
+type Gate struct {
+	config Config
+	logger common.Logger
+
+	mu      sync.Mutex
+	pending map[string]*PendingAction
+	now     func() time.Time
+}
+
+// NewGate returns the gate of config, nil if it isn't enabled
+func NewGate(config Config, logger common.Logger) *Gate {
+	if !config.Enabled() {
+		return nil
+	}
+	if config.Threshold < 1 {
+		config.Threshold = 1
+	}
+	return &Gate{
+		config:  config,
+		logger:  logger,
+		pending: make(map[string]*PendingAction),
+		now:     time.Now,
+	}
+}
+
+// Admin returns the name of the admin whose bearer token r carries, false for a nil gate
+func (g *Gate) Admin(r *http.Request) (string, bool) {
+	if g == nil {
+		return "", false
+	}
+	sent := []byte(r.Header.Get("Authorization"))
+	// compare with every token so that the time doesn't tell which one matched
+	var admin string
+	for token, name := range g.config.Admins {
+		if subtle.ConstantTimeCompare(sent, []byte("Bearer "+token)) == 1 {
+			admin = name
+		}
+	}
+	return admin, admin != ""
+}
+
+// Authorized serves the requests of the given method sending the bearer token of an admin
+// API, unless it is empty, or the token of an admin of g, which may be nil
+func Authorized(token string, g *Gate, method string, handler http.HandlerFunc) http.HandlerFunc {
+	expected := []byte("Bearer " + token)
+	return func(w http.ResponseWriter, r *http.Request) {
+		tokenSent := token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) == 1
+		if _, admin := g.Admin(r); !tokenSent && !admin {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Method != method {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// Gated serves the requests of the given method to a dangerous action
+func (g *Gate) Gated(method string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		action := r.Method + " " + r.URL.Path
+		if query := r.URL.Query().Encode(); query != "" {
+			action += "?" + query
+		}
+		admin, ok := g.Admin(r)
+		if !ok {
+			g.audit(AuditEntry{Event: EventRejected, Action: action})
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Method != method {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		pending, approved := g.approve(action, admin)
+		if !approved {
+			g.audit(AuditEntry{Event: EventApproved, Action: action, Admin: admin, Approvers: pending.Approvers})
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			_ = json.NewEncoder(w).Encode(pending)
+			return
+		}
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler(recorder, r)
+		g.audit(AuditEntry{Event: EventExecuted, Action: action, Admin: admin, Approvers: pending.Approvers, Status: recorder.status})
+	}
+}
+
+// approve records the approval of an action by an admin, returning whether the action is
+// approved by enough admins to run. The approvals are cleared once it is.
+func (g *Gate) approve(action string, admin string) (PendingAction, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	for key, p := range g.pending {
+		if !now.Before(p.ExpiresAt) {
+			delete(g.pending, key)
+		}
+	}
+
+	p, ok := g.pending[action]
+	if !ok {
+		p = &PendingAction{Action: action, Threshold: g.config.Threshold, ExpiresAt: now.Add(g.config.TTL)}
+		g.pending[action] = p
+	}
+	if !contains(p.Approvers, admin) {
+		p.Approvers = append(p.Approvers, admin)
+	}
+	approved := len(p.Approvers) >= g.config.Threshold
+	if approved {
+		delete(g.pending, action)
+	}
+	pending := *p
+	pending.Approvers = append([]string{}, p.Approvers...)
+	return pending, approved
+}
+
+// Pending returns the actions waiting for approvals, sorted by action
+func (g *Gate) Pending() []PendingAction {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	pending := make([]PendingAction, 0, len(g.pending))
+	for _, p := range g.pending {
+		if now.Before(p.ExpiresAt) {
+			copied := *p
+			copied.Approvers = append([]string{}, p.Approvers...)
+			pending = append(pending, copied)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Action < pending[j].Action })
+	return pending
+}
+
+// HandlePending serves GET requests of an admin listing the pending actions
+func (g *Gate) HandlePending(w http.ResponseWriter, r *http.Request) {
+	if _, ok := g.Admin(r); !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(g.Pending())
+}
+
+// audit logs an audit entry and appends it to the audit file
+func (g *Gate) audit(entry AuditEntry) {
+	entry.Time = g.now().UTC()
+	g.logger.Info("[approval] admin action "+entry.Event, "action", entry.Action, "admin", entry.Admin, "approvers", strings.Join(entry.Approvers, ","), "status", entry.Status)
+	if g.config.AuditFile == "" {
+		return
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		g.logger.Error("[approval] failed to encode audit entry", "err", err)
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	file, err := os.OpenFile(g.config.AuditFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err == nil {
+		_, err = file.Write(append(line, '\n'))
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		g.logger.Error("[approval] failed to write audit entry", "file", g.config.AuditFile, "err", err)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// statusRecorder records the status an action replies with
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,76 @@
+package approval
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGate(t *testing.T) {
+	now := time.Unix(1000, 0)
+	gate := NewGate(Config{
+		Admins:    map[string]string{"ta": "alice", "tb": "bob", "tc": "carol"},
+		Threshold: 2,
+		TTL:       time.Minute,
+		AuditFile: filepath.Join(t.TempDir(), "audit.jsonl"),
+	}, mock.NewLogger(false))
+	gate.now = func() time.Time { return now }
+	runs := 0
+	handler := gate.Gated(http.MethodPost, func(w http.ResponseWriter, r *http.Request) { runs++ })
+	request := func(token string, query string) int {
+		r := httptest.NewRequest(http.MethodPost, "/safe-mode?"+query, nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, request("", "enabled=true"))
+	assert.Equal(t, http.StatusUnauthorized, request("nope", "enabled=true"))
+
+	// the same admin approving twice doesn't count, a different request is another action
+	assert.Equal(t, http.StatusAccepted, request("ta", "enabled=true"))
+	assert.Equal(t, http.StatusAccepted, request("ta", "enabled=true"))
+	assert.Equal(t, http.StatusAccepted, request("tb", "enabled=false"))
+	assert.Len(t, gate.Pending(), 2)
+	assert.Equal(t, http.StatusOK, request("tc", "enabled=true"))
+	assert.Equal(t, 1, runs)
+	assert.Len(t, gate.Pending(), 1)
+
+	// approvals expire after the ttl
+	now = now.Add(2 * time.Minute)
+	assert.Empty(t, gate.Pending())
+	assert.Equal(t, http.StatusAccepted, request("ta", "enabled=false"))
+	assert.Equal(t, 1, runs)
+
+	assert.Nil(t, NewGate(Config{}, mock.NewLogger(false)))
+}
+
+func TestAuthorized(t *testing.T) {
+	gate := NewGate(Config{Admins: map[string]string{"ta": "alice"}}, mock.NewLogger(false))
+	request := func(handler http.HandlerFunc, method string, token string) int {
+		r := httptest.NewRequest(method, "/quarantine", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w.Code
+	}
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	handler := Authorized("token", gate, http.MethodGet, ok)
+	assert.Equal(t, http.StatusOK, request(handler, http.MethodGet, "token"))
+	assert.Equal(t, http.StatusOK, request(handler, http.MethodGet, "ta"))
+	assert.Equal(t, http.StatusUnauthorized, request(handler, http.MethodGet, "nope"))
+	assert.Equal(t, http.StatusMethodNotAllowed, request(handler, http.MethodPost, "token"))
+
+	// an empty token is never accepted, even without a gate
+	handler = Authorized("", nil, http.MethodGet, ok)
+	assert.Equal(t, http.StatusUnauthorized, request(handler, http.MethodGet, ""))
+}
@@ -0,0 +1,72 @@
+package approval
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/urfave/cli"
+)
+
+const (
+	AdminsFlagName    = "approval.admins"
+	ThresholdFlagName = "approval.threshold"
+	TTLFlagName       = "approval.ttl"
+	AuditFileFlagName = "approval.audit-file"
+)
+
+func CLIFlags(envPrefix string, flagPrefix string) []cli.Flag {
+	return []cli.Flag{
+		cli.StringSliceFlag{
+			Name:   common.PrefixFlag(flagPrefix, AdminsFlagName),
+			Usage:  "Admins approving the dangerous admin actions, as name=token with the bearer token of the admin. The actions only need the admin token if none",
+			EnvVar: common.PrefixEnvVar(envPrefix, "APPROVAL_ADMINS"),
+		},
+		cli.IntFlag{
+			Name:   common.PrefixFlag(flagPrefix, ThresholdFlagName),
+			Usage:  "Number of distinct admins who must request a dangerous admin action before it runs",
+			Value:  2,
+			EnvVar: common.PrefixEnvVar(envPrefix, "APPROVAL_THRESHOLD"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, TTLFlagName),
+			Usage:  "How long the approvals of an admin action wait for the others",
+			Value:  time.Hour,
+			EnvVar: common.PrefixEnvVar(envPrefix, "APPROVAL_TTL"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, AuditFileFlagName),
+			Usage:  "File the approvals and runs of the dangerous admin actions are appended to as json lines, besides the log",
+			EnvVar: common.PrefixEnvVar(envPrefix, "APPROVAL_AUDIT_FILE"),
+		},
+	}
+}
+
+func ReadCLIConfig(ctx *cli.Context, flagPrefix string) (Config, error) {
+	config := Config{
+		Admins:    make(map[string]string),
+		Threshold: ctx.GlobalInt(common.PrefixFlag(flagPrefix, ThresholdFlagName)),
+		TTL:       ctx.GlobalDuration(common.PrefixFlag(flagPrefix, TTLFlagName)),
+		AuditFile: ctx.GlobalString(common.PrefixFlag(flagPrefix, AuditFileFlagName)),
+	}
+	names := make(map[string]bool)
+	for _, entry := range ctx.GlobalStringSlice(common.PrefixFlag(flagPrefix, AdminsFlagName)) {
+		name, token, ok := strings.Cut(entry, "=")
+		if !ok || name == "" || token == "" {
+			return Config{}, fmt.Errorf("invalid %s entry, expected <name>=<token>", AdminsFlagName)
+		}
+		if names[name] {
+			return Config{}, fmt.Errorf("admin %s is listed twice in %s", name, AdminsFlagName)
+		}
+		if _, ok := config.Admins[token]; ok {
+			return Config{}, fmt.Errorf("admin %s shares its token with another admin", name)
+		}
+		names[name] = true
+		config.Admins[token] = name
+	}
+	if config.Enabled() && (config.Threshold < 1 || config.Threshold > len(config.Admins)) {
+		return Config{}, fmt.Errorf("%s must be between 1 and the %d admins", ThresholdFlagName, len(config.Admins))
+	}
+	return config, nil
+}
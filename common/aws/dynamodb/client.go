@@ -2,6 +2,7 @@ package dynamodb
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"sync"
@@ -36,6 +37,12 @@ var (
 	clientRef *Client
 )
 
+// ErrItemNotFound is returned by the updates of items that must exist
+var ErrItemNotFound = errors.New("item not found")
+
+// ErrConditionFailed is returned by the conditional updates whose condition doesn't hold
+var ErrConditionFailed = errors.New("condition failed")
+
 type Item = map[string]types.AttributeValue
 type Key = map[string]types.AttributeValue
 type ExpresseionValues = map[string]types.AttributeValue
@@ -48,37 +55,45 @@ type Client struct {
 func NewClient(cfg commonaws.ClientConfig, logger common.Logger) (*Client, error) {
 	var err error
 	once.Do(func() {
-		createClient := func(service, region string, options ...interface{}) (aws.Endpoint, error) {
-			if cfg.EndpointURL != "" {
-				return aws.Endpoint{
-					PartitionID:   "aws",
-					URL:           cfg.EndpointURL,
-					SigningRegion: cfg.Region,
-				}, nil
-			}
-			return aws.Endpoint{}, &aws.EndpointNotFoundError{}
-		}
-		customResolver := aws.EndpointResolverWithOptionsFunc(createClient)
+		clientRef, err = newClient(cfg, logger)
+	})
+	return clientRef, err
+}
 
-		options := [](func(*config.LoadOptions) error){
-			config.WithRegion(cfg.Region),
-			config.WithEndpointResolverWithOptions(customResolver),
-			config.WithRetryMode(aws.RetryModeStandard),
-		}
-		// If access key and secret access key are not provided, use the default credential provider
-		if len(cfg.AccessKey) > 0 && len(cfg.SecretAccessKey) > 0 {
-			options = append(options, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretAccessKey, "")))
-		}
-		awsConfig, errCfg := config.LoadDefaultConfig(context.Background(), options...)
+// NewStandaloneClient returns a client that is not shared with other callers of NewClient.
+// It is used to talk to a table in a different region than the default client.
+func NewStandaloneClient(cfg commonaws.ClientConfig, logger common.Logger) (*Client, error) {
+	return newClient(cfg, logger)
+}
 
-		if errCfg != nil {
-			err = errCfg
-			return
+func newClient(cfg commonaws.ClientConfig, logger common.Logger) (*Client, error) {
+	createClient := func(service, region string, options ...interface{}) (aws.Endpoint, error) {
+		if cfg.EndpointURL != "" {
+			return aws.Endpoint{
+				PartitionID:   "aws",
+				URL:           cfg.EndpointURL,
+				SigningRegion: cfg.Region,
+			}, nil
 		}
-		dynamoClient := dynamodb.NewFromConfig(awsConfig)
-		clientRef = &Client{dynamoClient: dynamoClient, logger: logger}
-	})
-	return clientRef, err
+		return aws.Endpoint{}, &aws.EndpointNotFoundError{}
+	}
+	customResolver := aws.EndpointResolverWithOptionsFunc(createClient)
+
+	options := [](func(*config.LoadOptions) error){
+		config.WithRegion(cfg.Region),
+		config.WithEndpointResolverWithOptions(customResolver),
+		config.WithRetryMode(aws.RetryModeStandard),
+	}
+	// If access key and secret access key are not provided, use the default credential provider
+	if len(cfg.AccessKey) > 0 && len(cfg.SecretAccessKey) > 0 {
+		options = append(options, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretAccessKey, "")))
+	}
+	awsConfig, err := config.LoadDefaultConfig(context.Background(), options...)
+	if err != nil {
+		return nil, err
+	}
+	dynamoClient := dynamodb.NewFromConfig(awsConfig)
+	return &Client{dynamoClient: dynamoClient, logger: logger}, nil
 }
 
 func (c *Client) CreateTable(ctx context.Context, cfg commonaws.ClientConfig, name string, input *dynamodb.CreateTableInput) (*types.TableDescription, error) {
@@ -129,6 +144,28 @@ func (c *Client) PutItem(ctx context.Context, tableName string, item Item) (err
 	return nil
 }
 
+// PutItemIf is like PutItem, but only writes item if condition holds on the item it replaces,
+// if any. ErrConditionFailed is returned otherwise, nothing being written.
+func (c *Client) PutItemIf(ctx context.Context, tableName string, item Item, condition expression.ConditionBuilder) error {
+	expr, err := expression.NewBuilder().WithCondition(condition).Build()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(tableName),
+		Item:                      item,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ConditionExpression:       expr.Condition(),
+	})
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return ErrConditionFailed
+	}
+	return err
+}
+
 // PutItems puts items in batches of 25 items (which is a limit DynamoDB imposes)
 // It returns the items that failed to be put.
 func (c *Client) PutItems(ctx context.Context, tableName string, items []Item) ([]Item, error) {
@@ -166,6 +203,120 @@ func (c *Client) UpdateItem(ctx context.Context, tableName string, key Key, item
 	return resp.Attributes, err
 }
 
+// UpdateItemRemoving is like UpdateItem, but also removes the remove attributes of the item
+func (c *Client) UpdateItemRemoving(ctx context.Context, tableName string, key Key, item Item, remove []string) error {
+	update := expression.UpdateBuilder{}
+	for itemKey, itemValue := range item {
+		if _, ok := key[itemKey]; ok {
+			// Cannot update the key
+			continue
+		}
+		update = update.Set(expression.Name(itemKey), expression.Value(itemValue))
+	}
+	for _, name := range remove {
+		update = update.Remove(expression.Name(name))
+	}
+	expr, err := expression.NewBuilder().WithUpdate(update).Build()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(tableName),
+		Key:                       key,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+	})
+	return err
+}
+
+// UpdateItemIf is like UpdateItem, but only updates an existing item on which condition
+// holds. ErrConditionFailed is returned otherwise, nothing being written.
+func (c *Client) UpdateItemIf(ctx context.Context, tableName string, key Key, item Item, condition expression.ConditionBuilder) error {
+	return c.UpdateItemRemovingIf(ctx, tableName, key, item, nil, condition)
+}
+
+// UpdateItemRemovingIf is like UpdateItemRemoving, but only updates an existing item on
+// which condition holds, like UpdateItemIf
+func (c *Client) UpdateItemRemovingIf(ctx context.Context, tableName string, key Key, item Item, remove []string, condition expression.ConditionBuilder) error {
+	update := expression.UpdateBuilder{}
+	for itemKey, itemValue := range item {
+		if _, ok := key[itemKey]; ok {
+			// Cannot update the key
+			continue
+		}
+		update = update.Set(expression.Name(itemKey), expression.Value(itemValue))
+	}
+	for _, name := range remove {
+		update = update.Remove(expression.Name(name))
+	}
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(tableName),
+		Key:                       key,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+	})
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return ErrConditionFailed
+	}
+	return err
+}
+
+// AppendToList appends values to the list attribute of an existing item, starting the list if
+// the item has none. ErrItemNotFound is returned if there is no item with key, and
+// ErrConditionFailed if the list would exceed maxLength items, unless maxLength is 0.
+func (c *Client) AppendToList(ctx context.Context, tableName string, key Key, attribute string, values []types.AttributeValue, maxLength int) error {
+	name := expression.Name(attribute)
+	update := expression.Set(name, expression.ListAppend(
+		expression.IfNotExists(name, expression.Value(&types.AttributeValueMemberL{Value: []types.AttributeValue{}})),
+		expression.Value(&types.AttributeValueMemberL{Value: values}),
+	))
+	// the key attributes of an existing item are always set
+	var condition expression.ConditionBuilder
+	for keyName := range key {
+		condition = expression.AttributeExists(expression.Name(keyName))
+		break
+	}
+	if maxLength > 0 {
+		condition = condition.And(expression.Or(
+			expression.AttributeNotExists(name),
+			expression.LessThanEqual(expression.Size(name), expression.Value(maxLength-len(values))),
+		))
+	}
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                           aws.String(tableName),
+		Key:                                 key,
+		ExpressionAttributeNames:            expr.Names(),
+		ExpressionAttributeValues:           expr.Values(),
+		UpdateExpression:                    expr.Update(),
+		ConditionExpression:                 expr.Condition(),
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		// the item is returned if it exists, so it's the list that is full
+		if len(conditionFailed.Item) > 0 {
+			return ErrConditionFailed
+		}
+		return ErrItemNotFound
+	}
+	return err
+}
+
 func (c *Client) GetItem(ctx context.Context, tableName string, key Key) (Item, error) {
 	resp, err := c.dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{Key: key, TableName: aws.String(tableName)})
 	if err != nil {
@@ -175,6 +326,16 @@ func (c *Client) GetItem(ctx context.Context, tableName string, key Key) (Item,
 	return resp.Item, nil
 }
 
+// GetItemConsistent is like GetItem but uses a strongly consistent read
+func (c *Client) GetItemConsistent(ctx context.Context, tableName string, key Key) (Item, error) {
+	resp, err := c.dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{Key: key, TableName: aws.String(tableName), ConsistentRead: aws.Bool(true)})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Item, nil
+}
+
 // QueryIndex returns all items in the index that match the given key
 func (c *Client) QueryIndex(ctx context.Context, tableName string, indexName string, keyCondition string, expAttributeValues ExpresseionValues) ([]Item, error) {
 	response, err := c.dynamoClient.Query(ctx, &dynamodb.QueryInput{
@@ -190,6 +351,46 @@ func (c *Client) QueryIndex(ctx context.Context, tableName string, indexName str
 	return response.Items, nil
 }
 
+// Query returns all items of the table that match the given key, reading the pages of the
+// responses over 1MB
+func (c *Client) Query(ctx context.Context, tableName string, keyCondition string, expAttributeValues ExpresseionValues) ([]Item, error) {
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String(tableName),
+		KeyConditionExpression:    aws.String(keyCondition),
+		ExpressionAttributeValues: expAttributeValues,
+	}
+	var items []Item
+	for {
+		response, err := c.dynamoClient.Query(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, response.Items...)
+		if len(response.LastEvaluatedKey) == 0 {
+			return items, nil
+		}
+		input.ExclusiveStartKey = response.LastEvaluatedKey
+	}
+}
+
+// ScanPage returns up to limit items of the table starting after exclusiveStartKey,
+// along with the key to resume from. The returned key is nil once the scan is complete.
+func (c *Client) ScanPage(ctx context.Context, tableName string, exclusiveStartKey Key, limit int32) ([]Item, Key, error) {
+	input := &dynamodb.ScanInput{
+		TableName:         aws.String(tableName),
+		ExclusiveStartKey: exclusiveStartKey,
+	}
+	if limit > 0 {
+		input.Limit = aws.Int32(limit)
+	}
+	response, err := c.dynamoClient.Scan(ctx, input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return response.Items, response.LastEvaluatedKey, nil
+}
+
 func (c *Client) DeleteItem(ctx context.Context, tableName string, key Key) error {
 	_, err := c.dynamoClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{Key: key, TableName: aws.String(tableName)})
 	if err != nil {
@@ -240,7 +441,11 @@ func (c *Client) writeItems(ctx context.Context, tableName string, requestItems
 		// check for unprocessed items
 		if len(output.UnprocessedItems) > 0 {
 			for _, req := range output.UnprocessedItems[tableName] {
-				failedItems = append(failedItems, req.DeleteRequest.Key)
+				if req.PutRequest != nil {
+					failedItems = append(failedItems, req.PutRequest.Item)
+				} else {
+					failedItems = append(failedItems, req.DeleteRequest.Key)
+				}
 			}
 		}
 
@@ -132,6 +132,17 @@ func (s *Client) UploadObject(ctx context.Context, bucket string, key string, da
 	return nil
 }
 
+// PutObject writes an object, overwriting the object at key if any
+func (s *Client) PutObject(ctx context.Context, bucket string, key string, data []byte, contentType string) error {
+	_, err := s.s3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentType),
+	})
+	return err
+}
+
 func (s *Client) DeleteObject(ctx context.Context, bucket string, key string) error {
 	_, err := s.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
 		Bucket: aws.String(bucket),
@@ -0,0 +1,37 @@
+package healthcheck
+
+import (
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/urfave/cli"
+)
+
+const (
+	IntervalFlagName = "health.interval"
+	TimeoutFlagName  = "health.timeout"
+)
+
+func CLIFlags(envPrefix string, flagPrefix string) []cli.Flag {
+	return []cli.Flag{
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, IntervalFlagName),
+			Usage:  "interval the components are checked at, reported at /health on the readyz port and in the component_status metric. 0 to disable",
+			Value:  15 * time.Second,
+			EnvVar: common.PrefixEnvVar(envPrefix, "HEALTH_INTERVAL"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, TimeoutFlagName),
+			Usage:  "timeout of each component check",
+			Value:  5 * time.Second,
+			EnvVar: common.PrefixEnvVar(envPrefix, "HEALTH_TIMEOUT"),
+		},
+	}
+}
+
+func ReadCLIConfig(ctx *cli.Context, flagPrefix string) Config {
+	return Config{
+		Interval: ctx.GlobalDuration(common.PrefixFlag(flagPrefix, IntervalFlagName)),
+		Timeout:  ctx.GlobalDuration(common.PrefixFlag(flagPrefix, TimeoutFlagName)),
+	}
+}
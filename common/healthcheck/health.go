@@ -0,0 +1,226 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Status is the health of a component
+type Status string
+
+const (
+	StatusOK Status = "ok"
+	// StatusDegraded is a component working with reduced capacity or falling behind
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+var statuses = []Status{StatusOK, StatusDegraded, StatusDown}
+
+// ErrDegraded is wrapped by the errors of the checks of degraded components, see Degraded
+var ErrDegraded = errors.New("degraded")
+
+// Degraded returns the error of a check whose component is degraded rather than down
+func Degraded(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrDegraded, fmt.Sprintf(format, args...))
+}
+
+// Check returns nil if a component is healthy, an error wrapping ErrDegraded if it is
+// degraded, and any other error if it is down
+type Check func(ctx context.Context) error
+
+// ComponentStatus is the result of the last check of a component
+type ComponentStatus struct {
+	Status    Status    `json:"status"`
+	Message   string    `json:"message,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report is the health of the service, down if a component is down and degraded if one is
+// degraded
+type Report struct {
+	Status     Status                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+// Config is the schedule of the checks
+type Config struct {
+	// Interval is the interval the components are checked at, the checks are disabled if 0
+	Interval time.Duration
+	// Timeout bounds each check
+	Timeout time.Duration
+}
+
+func (c Config) Enabled() bool {
+	return c.Interval > 0
+}
+
+// Monitor checks the components of a service in the background, and reports their
+// health as JSON and in the component_status gauge, set to 1 for the status of each
+// component and 0 for the others
+type Monitor struct {
+	config Config
+	logger common.Logger
+	gauge  *prometheus.GaugeVec
+
+	mu      sync.RWMutex
+	names   []string
+	checks  map[string]Check
+	results map[string]ComponentStatus
+	// lastRun is when the checks last ran, for liveness
+	lastRun time.Time
+}
+
+// NewMonitor creates a monitor whose gauge is registered with reg under namespace
+func NewMonitor(config Config, reg prometheus.Registerer, namespace string, logger common.Logger) *Monitor {
+	return &Monitor{
+		config: config,
+		logger: logger,
+		gauge: promauto.With(reg).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "component_status",
+				Help:      "health of each component of the service, 1 for its current status",
+			},
+			[]string{"component", "status"},
+		),
+		checks:  make(map[string]Check),
+		results: make(map[string]ComponentStatus),
+	}
+}
+
+// Add adds the check of a component, replacing the check of the same name
+func (m *Monitor) Add(name string, check Check) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.checks[name]; !ok {
+		m.names = append(m.names, name)
+	}
+	m.checks[name] = check
+}
+
+// Start checks the components every interval until ctx is done
+func (m *Monitor) Start(ctx context.Context) {
+	m.CheckAll(ctx)
+	go func() {
+		ticker := time.NewTicker(m.config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.CheckAll(ctx)
+			}
+		}
+	}()
+}
+
+// CheckAll checks all the components
+func (m *Monitor) CheckAll(ctx context.Context) {
+	m.mu.RLock()
+	names := append([]string(nil), m.names...)
+	checks := make([]Check, len(names))
+	for i, name := range names {
+		checks[i] = m.checks[name]
+	}
+	m.mu.RUnlock()
+
+	for i, name := range names {
+		checkCtx, cancel := ctx, context.CancelFunc(func() {})
+		if m.config.Timeout > 0 {
+			checkCtx, cancel = context.WithTimeout(ctx, m.config.Timeout)
+		}
+		err := checks[i](checkCtx)
+		cancel()
+		m.record(name, err, time.Now())
+	}
+	m.mu.Lock()
+	m.lastRun = time.Now()
+	m.mu.Unlock()
+}
+
+func (m *Monitor) record(name string, err error, now time.Time) {
+	result := ComponentStatus{Status: StatusOK, CheckedAt: now}
+	if err != nil {
+		result.Status = StatusDown
+		if errors.Is(err, ErrDegraded) {
+			result.Status = StatusDegraded
+		}
+		result.Message = err.Error()
+	}
+	m.mu.Lock()
+	previous, checked := m.results[name]
+	m.results[name] = result
+	m.mu.Unlock()
+
+	for _, status := range statuses {
+		value := 0.0
+		if status == result.Status {
+			value = 1
+		}
+		m.gauge.WithLabelValues(name, string(status)).Set(value)
+	}
+	if checked && previous.Status == result.Status {
+		return
+	}
+	switch result.Status {
+	case StatusOK:
+		if checked {
+			m.logger.Info("[health] component recovered", "component", name)
+		}
+	default:
+		m.logger.Warn("[health] component unhealthy", "component", name, "status", result.Status, "err", err)
+	}
+}
+
+// Report returns the results of the last checks, components not checked yet left out
+func (m *Monitor) Report() Report {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	report := Report{Status: StatusOK, Components: make(map[string]ComponentStatus, len(m.results))}
+	for name, result := range m.results {
+		report.Components[name] = result
+		if result.Status == StatusDown || (result.Status == StatusDegraded && report.Status == StatusOK) {
+			report.Status = result.Status
+		}
+	}
+	return report
+}
+
+// HandleHealth serves the report, with status 503 if a component is down
+func (m *Monitor) HandleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	report := m.Report()
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status == StatusDown {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// HandleLivez serves the liveness of the process, with status 503 once the checks stopped
+// running for three intervals. Unlike the health, it doesn't depend on the components, so
+// that a liveness probe doesn't restart the process over an unreachable dependency.
+func (m *Monitor) HandleLivez(w http.ResponseWriter, r *http.Request) {
+	m.mu.RLock()
+	lastRun := m.lastRun
+	m.mu.RUnlock()
+	if m.config.Enabled() && !lastRun.IsZero() && time.Since(lastRun) > 3*m.config.Interval {
+		http.Error(w, fmt.Sprintf("health checks stalled since %s", lastRun.Format(time.RFC3339)), http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = w.Write([]byte("ok\n"))
+}
@@ -0,0 +1,61 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMonitor(t *testing.T) {
+	var encoderErr, chainErr error
+	monitor := NewMonitor(Config{Interval: time.Minute, Timeout: time.Second}, prometheus.NewRegistry(), "test", mock.NewLogger(false))
+	monitor.Add("encoders", func(ctx context.Context) error { return encoderErr })
+	monitor.Add("chain", func(ctx context.Context) error { return chainErr })
+
+	report := func() (int, Report) {
+		rec := httptest.NewRecorder()
+		monitor.HandleHealth(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+		var report Report
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+		return rec.Code, report
+	}
+
+	monitor.CheckAll(context.Background())
+	code, r := report()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusOK, r.Status)
+	assert.Len(t, r.Components, 2)
+
+	// a degraded component degrades the service without failing the probe
+	encoderErr = Degraded("1 of 2 encoders unreachable")
+	monitor.CheckAll(context.Background())
+	code, r = report()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusDegraded, r.Status)
+	assert.Equal(t, StatusDegraded, r.Components["encoders"].Status)
+	assert.Equal(t, 1.0, testutil.ToFloat64(monitor.gauge.WithLabelValues("encoders", string(StatusDegraded))))
+	assert.Equal(t, 0.0, testutil.ToFloat64(monitor.gauge.WithLabelValues("encoders", string(StatusOK))))
+
+	// a component down takes the service down
+	chainErr = errors.New("connection refused")
+	monitor.CheckAll(context.Background())
+	code, r = report()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusDown, r.Status)
+	assert.Equal(t, "connection refused", r.Components["chain"].Message)
+
+	// the liveness doesn't depend on the components
+	rec := httptest.NewRecorder()
+	monitor.HandleLivez(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
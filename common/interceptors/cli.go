@@ -0,0 +1,97 @@
+package interceptors
+
+import (
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/urfave/cli"
+)
+
+const (
+	AuthTokensFlagName      = "auth-tokens"
+	MaxRequestBytesFlagName = "max-request-bytes"
+
+	MaxMessageBytesFlagName      = "grpc.max-message-bytes"
+	MaxConcurrentStreamsFlagName = "grpc.max-concurrent-streams"
+	ConnectionTimeoutFlagName    = "grpc.connection-timeout"
+	IdleTimeoutFlagName          = "grpc.idle-timeout"
+	RecvTimeoutFlagName          = "grpc.recv-timeout"
+	UnaryReadTimeoutFlagName     = "grpc.unary-read-timeout"
+	DrainTimeoutFlagName         = "grpc.drain-timeout"
+)
+
+func CLIFlags(envPrefix string, flagPrefix string) []cli.Flag {
+	return []cli.Flag{
+		cli.StringSliceFlag{
+			Name:   common.PrefixFlag(flagPrefix, AuthTokensFlagName),
+			Usage:  "bearer tokens accepted in the authorization header. Authentication is disabled if none are given",
+			EnvVar: common.PrefixEnvVar(envPrefix, "AUTH_TOKENS"),
+		},
+		cli.IntFlag{
+			Name:   common.PrefixFlag(flagPrefix, MaxRequestBytesFlagName),
+			Usage:  "maximum size of a unary request message in bytes, 0 for no limit",
+			Value:  0,
+			EnvVar: common.PrefixEnvVar(envPrefix, "MAX_REQUEST_BYTES"),
+		},
+		cli.IntFlag{
+			Name:   common.PrefixFlag(flagPrefix, MaxMessageBytesFlagName),
+			Usage:  "maximum size of a received grpc message in bytes, 0 for the default of the server",
+			EnvVar: common.PrefixEnvVar(envPrefix, "GRPC_MAX_MESSAGE_BYTES"),
+		},
+		cli.UintFlag{
+			Name:   common.PrefixFlag(flagPrefix, MaxConcurrentStreamsFlagName),
+			Usage:  "maximum number of concurrent grpc calls per connection, 0 for no limit",
+			Value:  100,
+			EnvVar: common.PrefixEnvVar(envPrefix, "GRPC_MAX_CONCURRENT_STREAMS"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, ConnectionTimeoutFlagName),
+			Usage:  "timeout of the handshake of new grpc connections",
+			Value:  20 * time.Second,
+			EnvVar: common.PrefixEnvVar(envPrefix, "GRPC_CONNECTION_TIMEOUT"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, IdleTimeoutFlagName),
+			Usage:  "close grpc connections without calls for this long, 0 to keep them",
+			Value:  5 * time.Minute,
+			EnvVar: common.PrefixEnvVar(envPrefix, "GRPC_IDLE_TIMEOUT"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, RecvTimeoutFlagName),
+			Usage:  "maximum wait for each message of a client stream, 0 for no limit",
+			Value:  30 * time.Second,
+			EnvVar: common.PrefixEnvVar(envPrefix, "GRPC_RECV_TIMEOUT"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, UnaryReadTimeoutFlagName),
+			Usage:  "maximum wait for the request of a unary grpc call, 0 for no limit",
+			Value:  30 * time.Second,
+			EnvVar: common.PrefixEnvVar(envPrefix, "GRPC_UNARY_READ_TIMEOUT"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, DrainTimeoutFlagName),
+			Usage:  "how long the grpc calls in flight may complete on shutdown before they are cancelled, 0 for no limit",
+			Value:  30 * time.Second,
+			EnvVar: common.PrefixEnvVar(envPrefix, "GRPC_DRAIN_TIMEOUT"),
+		},
+	}
+}
+
+func ReadCLIConfig(ctx *cli.Context, flagPrefix string) Config {
+	return Config{
+		AuthTokens:      ctx.GlobalStringSlice(common.PrefixFlag(flagPrefix, AuthTokensFlagName)),
+		MaxRequestBytes: ctx.GlobalInt(common.PrefixFlag(flagPrefix, MaxRequestBytesFlagName)),
+	}
+}
+
+func ReadGuardCLIConfig(ctx *cli.Context, flagPrefix string) GuardConfig {
+	return GuardConfig{
+		MaxMessageBytes:      ctx.GlobalInt(common.PrefixFlag(flagPrefix, MaxMessageBytesFlagName)),
+		MaxConcurrentStreams: uint32(ctx.GlobalUint(common.PrefixFlag(flagPrefix, MaxConcurrentStreamsFlagName))),
+		ConnectionTimeout:    ctx.GlobalDuration(common.PrefixFlag(flagPrefix, ConnectionTimeoutFlagName)),
+		IdleTimeout:          ctx.GlobalDuration(common.PrefixFlag(flagPrefix, IdleTimeoutFlagName)),
+		RecvTimeout:          ctx.GlobalDuration(common.PrefixFlag(flagPrefix, RecvTimeoutFlagName)),
+		UnaryReadTimeout:     ctx.GlobalDuration(common.PrefixFlag(flagPrefix, UnaryReadTimeoutFlagName)),
+		DrainTimeout:         ctx.GlobalDuration(common.PrefixFlag(flagPrefix, DrainTimeoutFlagName)),
+	}
+}
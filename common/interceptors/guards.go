@@ -0,0 +1,152 @@
+package interceptors
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/tap"
+)
+
+// GuardConfig bounds the resources a single client can hold on a grpc server, whose
+// defaults let one client buffer arbitrarily large messages on arbitrarily many streams
+type GuardConfig struct {
+	// MaxMessageBytes is the size of the largest message received, the server default if 0
+	MaxMessageBytes int
+	// MaxConcurrentStreams limits the calls served at once on a connection, no limit if 0
+	MaxConcurrentStreams uint32
+	// ConnectionTimeout bounds the handshake of new connections, the grpc default if 0
+	ConnectionTimeout time.Duration
+	// IdleTimeout closes the connections without calls for this long, never if 0
+	IdleTimeout time.Duration
+	// RecvTimeout bounds the wait for each message of a client stream, no limit if 0
+	RecvTimeout time.Duration
+	// UnaryReadTimeout bounds the read of the request of a unary call from when the call
+	// starts, no limit if 0. The streams have RecvTimeout instead.
+	UnaryReadTimeout time.Duration
+	// DrainTimeout is how long the calls in flight may complete on shutdown before they
+	// are cancelled, no limit if 0
+	DrainTimeout time.Duration
+}
+
+// GuardServerOptions returns the options applying the guards to a grpc server
+func GuardServerOptions(config GuardConfig) []grpc.ServerOption {
+	var opts []grpc.ServerOption
+	if config.MaxMessageBytes > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(config.MaxMessageBytes))
+	}
+	if config.MaxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(config.MaxConcurrentStreams))
+	}
+	if config.ConnectionTimeout > 0 {
+		opts = append(opts, grpc.ConnectionTimeout(config.ConnectionTimeout))
+	}
+	if config.IdleTimeout > 0 {
+		opts = append(opts, grpc.KeepaliveParams(keepalive.ServerParameters{MaxConnectionIdle: config.IdleTimeout}))
+	}
+	if config.RecvTimeout > 0 {
+		opts = append(opts, grpc.ChainStreamInterceptor(recvTimeout(config.RecvTimeout)))
+	}
+	if config.UnaryReadTimeout > 0 {
+		opts = append(opts,
+			grpc.InTapHandle(readDeadline(config.UnaryReadTimeout)),
+			grpc.ChainUnaryInterceptor(unaryRead),
+			grpc.ChainStreamInterceptor(streamRead),
+		)
+	}
+	return opts
+}
+
+// Drain stops gs accepting calls and waits for those in flight, cancelling them once the
+// drain timeout has passed
+func Drain(gs *grpc.Server, config GuardConfig) {
+	if config.DrainTimeout <= 0 {
+		gs.GracefulStop()
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		gs.GracefulStop()
+		close(done)
+	}()
+	timer := time.NewTimer(config.DrainTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		gs.Stop()
+	}
+}
+
+// recvTimeout fails the client streams waiting longer than timeout for a message
+func recvTimeout(timeout time.Duration) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !info.IsClientStream {
+			return handler(srv, ss)
+		}
+		return handler(srv, &timeoutStream{ServerStream: ss, timeout: timeout})
+	}
+}
+
+type timeoutStream struct {
+	grpc.ServerStream
+	timeout time.Duration
+}
+
+// RecvMsg returns DeadlineExceeded if no message arrives in time. The handler then returns
+// it, ending the stream, which unblocks the pending receive.
+func (s *timeoutStream) RecvMsg(m interface{}) error {
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.ServerStream.RecvMsg(m)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return status.Errorf(codes.DeadlineExceeded, "no message received for %v", s.timeout)
+	}
+}
+
+type readTimerKey struct{}
+
+// readDeadline cancels the calls whose request isn't read within timeout. grpc reads the
+// request of a unary call before any interceptor runs, so the deadline is set on the
+// context of the stream as it is created, and lifted by unaryRead once the request is read.
+func readDeadline(timeout time.Duration) tap.ServerInHandle {
+	return func(ctx context.Context, info *tap.Info) (context.Context, error) {
+		ctx, cancel := context.WithCancel(ctx)
+		timer := time.AfterFunc(timeout, cancel)
+		return context.WithValue(ctx, readTimerKey{}, timer), nil
+	}
+}
+
+// stopReadDeadline lifts the read deadline of the call of ctx. The context is cancelled
+// with the call still.
+func stopReadDeadline(ctx context.Context) {
+	if timer, ok := ctx.Value(readTimerKey{}).(*time.Timer); ok {
+		timer.Stop()
+	}
+}
+
+// unaryRead lifts the read deadline of the unary calls, whose request is read
+func unaryRead(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	stopReadDeadline(ctx)
+	if ctx.Err() != nil {
+		return nil, status.Error(codes.DeadlineExceeded, "request not read in time")
+	}
+	return handler(ctx, req)
+}
+
+// streamRead lifts the read deadline of the streams, which read their messages in the
+// handler under RecvTimeout
+func streamRead(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	stopReadDeadline(ss.Context())
+	return handler(srv, ss)
+}
@@ -0,0 +1,67 @@
+package interceptors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type blockingStream struct {
+	grpc.ServerStream
+	unblock chan struct{}
+}
+
+func (s *blockingStream) RecvMsg(m interface{}) error {
+	<-s.unblock
+	return nil
+}
+
+func TestRecvTimeout(t *testing.T) {
+	interceptor := recvTimeout(10 * time.Millisecond)
+	stream := &blockingStream{unblock: make(chan struct{})}
+	defer close(stream.unblock)
+
+	err := interceptor(nil, stream, &grpc.StreamServerInfo{IsClientStream: true}, func(srv interface{}, ss grpc.ServerStream) error {
+		return ss.RecvMsg(nil)
+	})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	// server streams are left to the handler
+	err = interceptor(nil, stream, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+		assert.Same(t, stream, ss)
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestReadDeadline(t *testing.T) {
+	tap := readDeadline(10 * time.Millisecond)
+
+	// the request of the call is never read
+	ctx, err := tap(context.Background(), nil)
+	assert.NoError(t, err)
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("call not cancelled")
+	}
+	_, err = unaryRead(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		t.Fatal("handler called")
+		return nil, nil
+	})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	// the request is read in time, the handler then has no deadline
+	ctx, err = tap(context.Background(), nil)
+	assert.NoError(t, err)
+	_, err = unaryRead(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		time.Sleep(30 * time.Millisecond)
+		return nil, ctx.Err()
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,202 @@
+// Package interceptors provides the interceptor stack shared by the grpc servers:
+// panic recovery, request IDs, request logging, per-method metrics, authentication
+// and request size limits.
+package interceptors
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// RequestIDHeader is read from the incoming metadata and echoed in the response header.
+	// A request ID is generated when the client doesn't send one.
+	RequestIDHeader = "x-request-id"
+	authHeader      = "authorization"
+)
+
+// exemptMethodPrefixes are never authenticated so that load balancers and tooling keep working
+var exemptMethodPrefixes = []string{
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.",
+}
+
+type Config struct {
+	// AuthTokens are the accepted bearer tokens. Authentication is disabled if empty.
+	AuthTokens []string
+	// MaxRequestBytes limits the size of unary requests. No limit if zero.
+	MaxRequestBytes int
+}
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the request ID assigned by the interceptors
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+type metrics struct {
+	requests *prometheus.CounterVec
+	latency  *prometheus.SummaryVec
+}
+
+func newMetrics(reg prometheus.Registerer, namespace string) *metrics {
+	return &metrics{
+		requests: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "grpc_requests_total",
+				Help:      "number of grpc requests by method and status code",
+			},
+			[]string{"method", "code"},
+		),
+		latency: promauto.With(reg).NewSummaryVec(
+			prometheus.SummaryOpts{
+				Namespace:  namespace,
+				Name:       "grpc_latency_ms",
+				Help:       "grpc request latency summary in milliseconds",
+				Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+			},
+			[]string{"method"},
+		),
+	}
+}
+
+type stack struct {
+	config  Config
+	logger  common.Logger
+	metrics *metrics
+}
+
+// ServerOptions returns the options installing the interceptor stack on a grpc server.
+// Metrics are registered on reg under namespace.
+func ServerOptions(config Config, logger common.Logger, reg prometheus.Registerer, namespace string) []grpc.ServerOption {
+	s := &stack{
+		config:  config,
+		logger:  logger,
+		metrics: newMetrics(reg, namespace),
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(s.unary),
+		grpc.ChainStreamInterceptor(s.stream),
+	}
+}
+
+func (s *stack) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	start := time.Now()
+	ctx, requestID := s.withRequestID(ctx)
+	defer func() {
+		if r := recover(); r != nil {
+			err = s.recovered(info.FullMethod, requestID, r)
+		}
+		s.observe(info.FullMethod, requestID, start, err)
+	}()
+
+	if err := s.authenticate(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	if s.config.MaxRequestBytes > 0 {
+		if msg, ok := req.(proto.Message); ok && proto.Size(msg) > s.config.MaxRequestBytes {
+			return nil, status.Errorf(codes.ResourceExhausted, "request of %d bytes exceeds the limit of %d bytes", proto.Size(msg), s.config.MaxRequestBytes)
+		}
+	}
+	return handler(ctx, req)
+}
+
+func (s *stack) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	start := time.Now()
+	ctx, requestID := s.withRequestID(ss.Context())
+	defer func() {
+		if r := recover(); r != nil {
+			err = s.recovered(info.FullMethod, requestID, r)
+		}
+		s.observe(info.FullMethod, requestID, start, err)
+	}()
+
+	if err := s.authenticate(ctx, info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+}
+
+func (s *stack) withRequestID(ctx context.Context) (context.Context, string) {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(RequestIDHeader)) > 0 {
+		requestID = md.Get(RequestIDHeader)[0]
+	} else {
+		buf := make([]byte, 8)
+		_, _ = rand.Read(buf)
+		requestID = hex.EncodeToString(buf)
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, requestID))
+	return context.WithValue(ctx, requestIDKey{}, requestID), requestID
+}
+
+func (s *stack) authenticate(ctx context.Context, method string) error {
+	if len(s.config.AuthTokens) == 0 {
+		return nil
+	}
+	for _, prefix := range exemptMethodPrefixes {
+		if strings.HasPrefix(method, prefix) {
+			return nil
+		}
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(authHeader)
+	if len(values) == 0 {
+		return status.Error(codes.Unauthenticated, "missing authorization header")
+	}
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok {
+		return status.Error(codes.Unauthenticated, "expected a bearer token")
+	}
+	for _, accepted := range s.config.AuthTokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(accepted)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid token")
+}
+
+func (s *stack) recovered(method, requestID string, r interface{}) error {
+	s.logger.Error("[grpc] panic in handler", "method", method, "requestID", requestID, "panic", r, "stack", string(debug.Stack()))
+	return status.Error(codes.Internal, "internal error")
+}
+
+func (s *stack) observe(method, requestID string, start time.Time, err error) {
+	code := status.Code(err)
+	elapsed := time.Since(start)
+	s.metrics.requests.WithLabelValues(method, code.String()).Inc()
+	s.metrics.latency.WithLabelValues(method).Observe(float64(elapsed.Milliseconds()))
+	if err != nil {
+		s.logger.Warn("[grpc] request failed", "method", method, "requestID", requestID, "code", code.String(), "duration", elapsed, "err", err)
+		return
+	}
+	s.logger.Debug("[grpc] request served", "method", method, "requestID", requestID, "duration", elapsed)
+}
+
+// serverStream overrides the context of a stream with the one carrying the request ID
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,65 @@
+package interceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func newTestStack(config Config) *stack {
+	return &stack{
+		config:  config,
+		logger:  mock.NewLogger(false),
+		metrics: newMetrics(prometheus.NewRegistry(), "test"),
+	}
+}
+
+var info = &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+
+func TestPanicRecovery(t *testing.T) {
+	s := newTestStack(Config{})
+	_, err := s.unary(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestRequestID(t *testing.T) {
+	s := newTestStack(Config{})
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIDHeader, "abc"))
+	_, err := s.unary(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		assert.Equal(t, "abc", RequestIDFromContext(ctx))
+		return nil, nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestAuthAndSizeLimit(t *testing.T) {
+	s := newTestStack(Config{AuthTokens: []string{"secret"}, MaxRequestBytes: 4})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return req, nil }
+
+	_, err := s.unary(context.Background(), nil, info, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(authHeader, "Bearer wrong"))
+	_, err = s.unary(ctx, nil, info, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(authHeader, "Bearer secret"))
+	_, err = s.unary(ctx, wrapperspb.Bytes([]byte{1}), info, handler)
+	assert.NoError(t, err)
+	_, err = s.unary(ctx, wrapperspb.Bytes(make([]byte, 16)), info, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// health checks are not authenticated
+	_, err = s.unary(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler)
+	assert.NoError(t, err)
+}
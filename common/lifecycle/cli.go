@@ -0,0 +1,44 @@
+package lifecycle
+
+import (
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/urfave/cli"
+)
+
+const (
+	StartTimeoutFlagName = "lifecycle.start-timeout"
+	StopTimeoutFlagName  = "lifecycle.stop-timeout"
+	ReadyzPortFlagName   = "lifecycle.readyz-port"
+)
+
+func CLIFlags(envPrefix string, flagPrefix string) []cli.Flag {
+	return []cli.Flag{
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, StartTimeoutFlagName),
+			Usage:  "how long each component may take to start, such as waiting for the chain to be reachable",
+			Value:  time.Minute,
+			EnvVar: common.PrefixEnvVar(envPrefix, "LIFECYCLE_START_TIMEOUT"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, StopTimeoutFlagName),
+			Usage:  "how long each component may take to stop gracefully on shutdown",
+			Value:  30 * time.Second,
+			EnvVar: common.PrefixEnvVar(envPrefix, "LIFECYCLE_STOP_TIMEOUT"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, ReadyzPortFlagName),
+			Usage:  "port /readyz and the other probes of the service are served on, /readyz reporting whether all components started and their dependencies are ready, for Kubernetes readiness probes. Disabled if empty",
+			EnvVar: common.PrefixEnvVar(envPrefix, "LIFECYCLE_READYZ_PORT"),
+		},
+	}
+}
+
+func ReadCLIConfig(ctx *cli.Context, flagPrefix string) Config {
+	return Config{
+		StartTimeout: ctx.GlobalDuration(common.PrefixFlag(flagPrefix, StartTimeoutFlagName)),
+		StopTimeout:  ctx.GlobalDuration(common.PrefixFlag(flagPrefix, StopTimeoutFlagName)),
+		ReadyzPort:   ctx.GlobalString(common.PrefixFlag(flagPrefix, ReadyzPortFlagName)),
+	}
+}
@@ -0,0 +1,220 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/hashicorp/go-multierror"
+)
+
+type Config struct {
+	// StartTimeout bounds the start of each component that doesn't set its own
+	StartTimeout time.Duration
+	// StopTimeout bounds the graceful stop of each component
+	StopTimeout time.Duration
+	// ReadyzPort is the port /readyz is served on by Run, see Readiness. Disabled if empty.
+	ReadyzPort string
+}
+
+// Component is a part of a service started and stopped by a Manager
+type Component struct {
+	Name string
+	// Start returns once the component is ready. The context is canceled when the component
+	// is stopped, so the goroutines started with it end then.
+	Start func(ctx context.Context) error
+	// Stop stops the component gracefully before its context is canceled, nil if canceling
+	// the context is enough
+	Stop func(ctx context.Context) error
+	// StartTimeout overrides the start timeout of the manager if positive
+	StartTimeout time.Duration
+}
+
+type running struct {
+	name   string
+	stop   func(ctx context.Context) error
+	cancel context.CancelFunc
+}
+
+// Manager starts components in the order they were added, each once the previous ones are
+// ready, and stops them in the reverse order
+type Manager struct {
+	config     Config
+	components []Component
+	running    []running
+	// failed receives the error of the first served component that returned
+	failed chan error
+	// readiness is the readiness of the service, see AddReadiness
+	readiness readiness
+
+	logger common.Logger
+}
+
+func NewManager(config Config, logger common.Logger) *Manager {
+	return &Manager{
+		config: config,
+		failed: make(chan error, 1),
+		logger: logger,
+	}
+}
+
+// Add appends components to the start order
+func (m *Manager) Add(components ...Component) {
+	m.components = append(m.components, components...)
+}
+
+// Serve appends a component that runs serve in the background, such as a server blocking
+// until its context is canceled. It is ready once serve is called, and serve returning
+// while the component runs stops the manager. Stopping it cancels the context of serve and
+// waits for serve to return.
+func (m *Manager) Serve(name string, serve func(ctx context.Context) error) {
+	var cancel context.CancelFunc
+	done := make(chan struct{})
+	m.Add(Component{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			ctx, cancel = context.WithCancel(ctx)
+			go func() {
+				defer close(done)
+				err := serve(ctx)
+				if ctx.Err() != nil {
+					return
+				}
+				if err == nil {
+					err = errors.New("stopped")
+				}
+				select {
+				case m.failed <- fmt.Errorf("%s: %w", name, err):
+				default:
+				}
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+}
+
+// Start starts the components in order. If one fails to start in time, the components
+// already started are stopped and its error is returned.
+func (m *Manager) Start(ctx context.Context) error {
+	for _, component := range m.components {
+		timeout := component.StartTimeout
+		if timeout <= 0 {
+			timeout = m.config.StartTimeout
+		}
+		m.logger.Info("[lifecycle] starting", "component", component.Name)
+		componentCtx, cancel := context.WithCancel(ctx)
+		if err := start(componentCtx, component, timeout); err != nil {
+			cancel()
+			if stopErr := m.Stop(); stopErr != nil {
+				m.logger.Error("[lifecycle] failed to stop after a failed start", "err", stopErr)
+			}
+			return fmt.Errorf("failed to start %s: %w", component.Name, err)
+		}
+		m.running = append(m.running, running{name: component.Name, stop: component.Stop, cancel: cancel})
+	}
+	m.setStarted(true)
+	return nil
+}
+
+func start(ctx context.Context, component Component, timeout time.Duration) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- component.Start(ctx)
+	}()
+	if timeout <= 0 {
+		return <-done
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("not ready within %s", timeout)
+	}
+}
+
+// Stop stops the running components in the reverse order they were started
+func (m *Manager) Stop() error {
+	m.setStarted(false)
+	var result *multierror.Error
+	for i := len(m.running) - 1; i >= 0; i-- {
+		component := m.running[i]
+		m.logger.Info("[lifecycle] stopping", "component", component.name)
+		if component.stop != nil {
+			ctx, cancel := context.WithCancel(context.Background())
+			if m.config.StopTimeout > 0 {
+				ctx, cancel = context.WithTimeout(context.Background(), m.config.StopTimeout)
+			}
+			if err := component.stop(ctx); err != nil {
+				result = multierror.Append(result, fmt.Errorf("failed to stop %s: %w", component.name, err))
+			}
+			cancel()
+		}
+		component.cancel()
+	}
+	m.running = nil
+	return result.ErrorOrNil()
+}
+
+// Run starts the components and stops them once ctx is done, the process is interrupted or
+// terminated, or a served component returns. The readiness is served while they start and
+// run if a readyz port is set.
+func (m *Manager) Run(ctx context.Context) error {
+	if m.config.ReadyzPort != "" {
+		readyzCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		m.serveReadyz(readyzCtx)
+	}
+	if err := m.Start(ctx); err != nil {
+		return err
+	}
+	m.logger.Info("[lifecycle] all components started")
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case sig := <-signals:
+		m.logger.Info("[lifecycle] shutting down", "signal", sig)
+	case err = <-m.failed:
+		m.logger.Error("[lifecycle] component failed, shutting down", "err", err)
+	}
+	if stopErr := m.Stop(); stopErr != nil {
+		err = multierror.Append(err, stopErr).ErrorOrNil()
+	}
+	return err
+}
+
+// Poll returns once check succeeds, checking every interval until ctx is done. It makes a
+// start waiting for a dependency to be reachable.
+func Poll(ctx context.Context, interval time.Duration, check func(ctx context.Context) error) error {
+	for {
+		err := check(ctx)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %v", ctx.Err(), err)
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagerOrder(t *testing.T) {
+	var events []string
+	component := func(name string) Component {
+		return Component{
+			Name: name,
+			Start: func(ctx context.Context) error {
+				events = append(events, "start "+name)
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				events = append(events, "stop "+name)
+				return nil
+			},
+		}
+	}
+
+	manager := NewManager(Config{StartTimeout: time.Second, StopTimeout: time.Second}, mock.NewLogger(false))
+	manager.Add(component("store"), component("chain"), component("api"))
+	require.NoError(t, manager.Start(context.Background()))
+	require.NoError(t, manager.Stop())
+	assert.Equal(t, []string{"start store", "start chain", "start api", "stop api", "stop chain", "stop store"}, events)
+
+	// a component not ready in time stops those already started
+	events = nil
+	manager = NewManager(Config{StartTimeout: 10 * time.Millisecond}, mock.NewLogger(false))
+	manager.Add(component("store"), Component{Name: "chain", Start: func(ctx context.Context) error {
+		return Poll(ctx, time.Millisecond, func(ctx context.Context) error { return errors.New("unreachable") })
+	}}, component("api"))
+	err := manager.Start(context.Background())
+	assert.ErrorContains(t, err, "failed to start chain")
+	assert.Equal(t, []string{"start store", "stop store"}, events)
+}
+
+func TestManagerServe(t *testing.T) {
+	manager := NewManager(Config{StopTimeout: time.Second}, mock.NewLogger(false))
+	stopped := make(chan struct{})
+	manager.Serve("api", func(ctx context.Context) error {
+		<-ctx.Done()
+		close(stopped)
+		return nil
+	})
+	require.NoError(t, manager.Start(context.Background()))
+	require.NoError(t, manager.Stop())
+	select {
+	case <-stopped:
+	default:
+		t.Fatal("stop returned before the served component")
+	}
+
+	// a served component returning on its own stops the manager
+	manager = NewManager(Config{}, mock.NewLogger(false))
+	manager.Serve("api", func(ctx context.Context) error { return errors.New("listen failed") })
+	assert.ErrorContains(t, manager.Run(context.Background()), "api: listen failed")
+}
+
+func TestManagerReadiness(t *testing.T) {
+	manager := NewManager(Config{}, mock.NewLogger(false))
+	started := make(chan struct{})
+	manager.Add(Component{Name: "store", Start: func(ctx context.Context) error {
+		<-started
+		return nil
+	}})
+	var chainErr error
+	manager.AddReadiness("chain", func(ctx context.Context) error { return chainErr })
+
+	readyz := func() (int, Readiness) {
+		recorder := httptest.NewRecorder()
+		manager.HandleReadyz(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var readiness Readiness
+		require.NoError(t, json.NewDecoder(recorder.Body).Decode(&readiness))
+		return recorder.Code, readiness
+	}
+
+	done := make(chan error)
+	go func() { done <- manager.Start(context.Background()) }()
+	code, readiness := readyz()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, map[string]string{"components": "starting", "chain": "ok"}, readiness.Checks)
+
+	close(started)
+	require.NoError(t, <-done)
+	code, readiness = readyz()
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, readiness.Ready)
+
+	chainErr = errors.New("unreachable")
+	code, readiness = readyz()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unreachable", readiness.Checks["chain"])
+
+	require.NoError(t, manager.Stop())
+	code, _ = readyz()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+}
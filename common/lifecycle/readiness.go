@@ -0,0 +1,131 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// readinessCheckTimeout bounds each readiness check of a /readyz request
+const readinessCheckTimeout = 5 * time.Second
+
+// ReadinessCheck returns nil if a dependency of the service is ready, such as the chain
+// being reachable or an indexer having caught up with it
+type ReadinessCheck func(ctx context.Context) error
+
+// Readiness is the state reported at /readyz. The service is ready once all its
+// components started and all its readiness checks pass.
+type Readiness struct {
+	Ready bool `json:"ready"`
+	// Checks are the results of the readiness checks by name, "ok" if they passed
+	Checks map[string]string `json:"checks"`
+}
+
+type readiness struct {
+	mu      sync.RWMutex
+	started bool
+	names   []string
+	checks  map[string]ReadinessCheck
+	// handlers are the other probes served with /readyz, by path
+	handlers map[string]http.HandlerFunc
+}
+
+// AddReadiness adds a check to the readiness of the service
+func (m *Manager) AddReadiness(name string, check ReadinessCheck) {
+	m.readiness.mu.Lock()
+	defer m.readiness.mu.Unlock()
+	if m.readiness.checks == nil {
+		m.readiness.checks = make(map[string]ReadinessCheck)
+	}
+	if _, ok := m.readiness.checks[name]; !ok {
+		m.readiness.names = append(m.readiness.names, name)
+	}
+	m.readiness.checks[name] = check
+}
+
+// HandleProbe serves handler at path on the readyz port, along with /readyz. Probes added
+// once the manager runs are not served.
+func (m *Manager) HandleProbe(path string, handler http.HandlerFunc) {
+	m.readiness.mu.Lock()
+	defer m.readiness.mu.Unlock()
+	if m.readiness.handlers == nil {
+		m.readiness.handlers = make(map[string]http.HandlerFunc)
+	}
+	m.readiness.handlers[path] = handler
+}
+
+func (m *Manager) setStarted(started bool) {
+	m.readiness.mu.Lock()
+	defer m.readiness.mu.Unlock()
+	m.readiness.started = started
+}
+
+// Readiness runs the readiness checks and reports whether the service is ready
+func (m *Manager) Readiness(ctx context.Context) Readiness {
+	m.readiness.mu.RLock()
+	started := m.readiness.started
+	names := append([]string(nil), m.readiness.names...)
+	checks := make([]ReadinessCheck, len(names))
+	for i, name := range names {
+		checks[i] = m.readiness.checks[name]
+	}
+	m.readiness.mu.RUnlock()
+
+	readiness := Readiness{Ready: started, Checks: make(map[string]string, len(names)+1)}
+	readiness.Checks["components"] = "ok"
+	if !started {
+		readiness.Checks["components"] = "starting"
+	}
+	for i, name := range names {
+		checkCtx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+		err := checks[i](checkCtx)
+		cancel()
+		if err != nil {
+			readiness.Ready = false
+			readiness.Checks[name] = err.Error()
+			continue
+		}
+		readiness.Checks[name] = "ok"
+	}
+	return readiness
+}
+
+// HandleReadyz serves the readiness of the service, with status 503 until it is ready, for
+// the readiness probes of orchestrators such as Kubernetes
+func (m *Manager) HandleReadyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	readiness := m.Readiness(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	if !readiness.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(readiness)
+}
+
+// serveReadyz serves /readyz on the readyz port until ctx is done
+func (m *Manager) serveReadyz(ctx context.Context) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/readyz", m.HandleReadyz)
+	m.readiness.mu.RLock()
+	for path, handler := range m.readiness.handlers {
+		mux.HandleFunc(path, handler)
+	}
+	m.readiness.mu.RUnlock()
+	server := &http.Server{Addr: fmt.Sprintf(":%s", m.config.ReadyzPort), Handler: mux}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	go func() {
+		m.logger.Info("[lifecycle] serving readiness", "port", m.config.ReadyzPort)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			m.logger.Error("[lifecycle] readiness server failed", "err", err)
+		}
+	}()
+}
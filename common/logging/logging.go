@@ -3,6 +3,7 @@ package logging
 import (
 	"fmt"
 	"os"
+	"sync/atomic"
 
 	"github.com/0glabs/0g-da-client/common"
 	"github.com/ethereum/go-ethereum/log"
@@ -10,10 +11,49 @@ import (
 
 type Logger struct {
 	log.Logger
+	// levels are shared by the loggers derived with New
+	levels *levels
+}
+
+// levels are the lowest levels logged to stdout and to the log file
+type levels struct {
+	std  atomic.Int64
+	file atomic.Int64
+}
+
+// levelFilter passes the records of h at level or above, level changing at runtime
+func levelFilter(level *atomic.Int64, h log.Handler) log.Handler {
+	return log.FilterHandler(func(r *log.Record) bool {
+		return r.Lvl <= log.Lvl(level.Load())
+	}, h)
 }
 
 func (l *Logger) New(ctx ...interface{}) common.Logger {
-	return &Logger{Logger: l.Logger.New(ctx...)}
+	return &Logger{Logger: l.Logger.New(ctx...), levels: l.levels}
+}
+
+// SetLevel changes the lowest level logger, created by GetLogger, logs to stdout and to the
+// log file. An empty level restores the levels of config.
+func SetLevel(logger common.Logger, config Config, level string) error {
+	l, ok := logger.(*Logger)
+	if !ok {
+		return fmt.Errorf("log level of %T can't be changed", logger)
+	}
+	stdLevel, fileLevel := config.StdLevel, config.FileLevel
+	if level != "" {
+		stdLevel, fileLevel = level, level
+	}
+	std, err := log.LvlFromString(stdLevel)
+	if err != nil {
+		return err
+	}
+	file, err := log.LvlFromString(fileLevel)
+	if err != nil {
+		return err
+	}
+	l.levels.std.Store(int64(std))
+	l.levels.file.Store(int64(file))
+	return nil
 }
 
 func (l *Logger) SetHandler(h log.Handler) {
@@ -31,7 +71,9 @@ func GetLogger(cfg Config) (common.Logger, error) {
 		return nil, err
 	}
 
-	logger := &Logger{Logger: log.New()}
+	logger := &Logger{Logger: log.New(), levels: &levels{}}
+	logger.levels.std.Store(int64(stdLevel))
+	logger.levels.file.Store(int64(fileLevel))
 	// This is required to print locations of log calls
 	// This was recently added in this PR: https://github.com/ethereum/go-ethereum/pull/28069/files
 	// where the default behavior was changed to not print origins
@@ -39,13 +81,13 @@ func GetLogger(cfg Config) (common.Logger, error) {
 	// We should evaluate enabling/disabling this based on the flag
 	log.PrintOrigins(true)
 	stdh := log.StreamHandler(os.Stdout, log.TerminalFormat(false))
-	stdHandler := log.CallerFileHandler(log.LvlFilterHandler(stdLevel, stdh))
+	stdHandler := log.CallerFileHandler(levelFilter(&logger.levels.std, stdh))
 	if cfg.Path != "" {
 		fh, err := log.FileHandler(cfg.Path, log.LogfmtFormat())
 		if err != nil {
 			return nil, err
 		}
-		fileHandler := log.LvlFilterHandler(fileLevel, fh)
+		fileHandler := levelFilter(&logger.levels.file, fh)
 		logger.SetHandler(log.MultiHandler(fileHandler, stdHandler))
 	} else {
 		logger.SetHandler(stdHandler)
@@ -28,6 +28,11 @@ func (s *S3Client) UploadObject(ctx context.Context, bucket string, key string,
 	return nil
 }
 
+func (s *S3Client) PutObject(ctx context.Context, bucket string, key string, data []byte, contentType string) error {
+	s.bucket[key] = data
+	return nil
+}
+
 func (s *S3Client) DeleteObject(ctx context.Context, bucket string, key string) error {
 	delete(s.bucket, key)
 	return nil
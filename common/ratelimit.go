@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/http"
 	"strings"
 	"time"
 
@@ -78,6 +79,26 @@ func GetClientAddress(ctx context.Context, header string, numProxies int, allowD
 	return "", fmt.Errorf("failed to get ip")
 }
 
+// GetHTTPClientAddress is GetClientAddress for HTTP requests
+func GetHTTPClientAddress(r *http.Request, header string, numProxies int, allowDirectConnectionFallback bool) (string, error) {
+	if header != "" && numProxies > 0 {
+		parts := splitHeader(r.Header.Values(header))
+		if len(parts) >= numProxies {
+			return parts[len(parts)-numProxies], nil
+		}
+	}
+
+	if header == "" || allowDirectConnectionFallback {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			return "", err
+		}
+		return host, nil
+	}
+
+	return "", fmt.Errorf("failed to get ip")
+}
+
 func splitHeader(header []string) []string {
 	var result []string
 	for _, h := range header {
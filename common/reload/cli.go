@@ -0,0 +1,33 @@
+package reload
+
+import (
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/urfave/cli"
+)
+
+const (
+	FileFlagName = "reload.file"
+	URLFlagName  = "reload.url"
+)
+
+func CLIFlags(envPrefix string, flagPrefix string) []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, FileFlagName),
+			Usage:  "JSON file of the tunables reloaded on SIGHUP without a restart, disabled if empty",
+			EnvVar: common.PrefixEnvVar(envPrefix, "RELOAD_FILE"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, URLFlagName),
+			Usage:  "URL of the config service the tunables are fetched from on SIGHUP, if no reload file is set",
+			EnvVar: common.PrefixEnvVar(envPrefix, "RELOAD_URL"),
+		},
+	}
+}
+
+func ReadCLIConfig(ctx *cli.Context, flagPrefix string) Config {
+	return Config{
+		File: ctx.GlobalString(common.PrefixFlag(flagPrefix, FileFlagName)),
+		URL:  ctx.GlobalString(common.PrefixFlag(flagPrefix, URLFlagName)),
+	}
+}
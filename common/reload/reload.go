@@ -0,0 +1,183 @@
+// Package reload loads the tunables of a service from a JSON file or a config service, and
+// loads them again on SIGHUP, so they can be changed without a restart.
+package reload
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+)
+
+// fetchTimeout bounds the request to the config service
+const fetchTimeout = 10 * time.Second
+
+// maxDocumentSize bounds the tunables document
+const maxDocumentSize = 1 << 20
+
+type Config struct {
+	// File is the JSON file the tunables are read from
+	File string
+	// URL is the config service the tunables are fetched from with a GET, if File is empty
+	URL string
+}
+
+func (c Config) Enabled() bool {
+	return c.File != "" || c.URL != ""
+}
+
+// Duration is a time.Duration read from a JSON string such as "1m30s"
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string: %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Reloader holds the active tunables T. A reload fetches the document, decodes and validates
+// it, and only then swaps it in and applies it, so invalid tunables leave the active ones
+// in place. A document unchanged since it was last applied isn't applied again, since
+// SIGHUP also rotates the TLS certificates and the values set otherwise since, such as
+// through an admin API, must be kept.
+type Reloader[T any] struct {
+	config   Config
+	validate func(*T) error
+	logger   common.Logger
+	client   *http.Client
+
+	// mu serializes the reloads, so the tunables are applied in the order they are swapped
+	mu       sync.Mutex
+	appliers []func(*T)
+	current  atomic.Pointer[T]
+	// applied is the document last applied
+	applied []byte
+}
+
+// NewReloader creates a reloader of the tunables validated by validate, nil if they need none
+func NewReloader[T any](config Config, validate func(*T) error, logger common.Logger) *Reloader[T] {
+	return &Reloader[T]{
+		config:   config,
+		validate: validate,
+		logger:   logger,
+		client:   &http.Client{Timeout: fetchTimeout},
+	}
+}
+
+// OnReload registers apply to be called with the tunables of each successful reload
+func (r *Reloader[T]) OnReload(apply func(*T)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.appliers = append(r.appliers, apply)
+}
+
+// Current returns the active tunables, nil until loaded
+func (r *Reloader[T]) Current() *T {
+	return r.current.Load()
+}
+
+// Reload loads the tunables and applies them if valid
+func (r *Reloader[T]) Reload(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := r.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	if r.applied != nil && bytes.Equal(data, r.applied) {
+		r.logger.Debug("[reload] tunables unchanged")
+		return nil
+	}
+	tunables := new(T)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(tunables); err != nil {
+		return fmt.Errorf("invalid tunables: %w", err)
+	}
+	if r.validate != nil {
+		if err := r.validate(tunables); err != nil {
+			return fmt.Errorf("invalid tunables: %w", err)
+		}
+	}
+	r.current.Store(tunables)
+	r.applied = data
+	for _, apply := range r.appliers {
+		apply(tunables)
+	}
+	return nil
+}
+
+func (r *Reloader[T]) fetch(ctx context.Context) ([]byte, error) {
+	if r.config.File != "" {
+		data, err := os.ReadFile(r.config.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tunables: %w", err)
+		}
+		return data, nil
+	}
+	if r.config.URL == "" {
+		return nil, errors.New("no tunables source configured")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.config.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tunables: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch tunables: config service returned %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxDocumentSize))
+}
+
+// Start loads the tunables, failing if they can't be, and loads them again on each SIGHUP
+// until ctx is done. A failed reload is logged and keeps the active tunables.
+func (r *Reloader[T]) Start(ctx context.Context) error {
+	if err := r.Reload(ctx); err != nil {
+		return err
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				if err := r.Reload(ctx); err != nil {
+					r.logger.Error("[reload] failed to reload tunables, keeping the active ones", "err", err)
+					continue
+				}
+				r.logger.Info("[reload] tunables reloaded")
+			}
+		}
+	}()
+	return nil
+}
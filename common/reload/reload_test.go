@@ -0,0 +1,69 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tunables struct {
+	Interval Duration `json:"interval"`
+	Limit    int      `json:"limit"`
+}
+
+func TestReload(t *testing.T) {
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), "tunables.json")
+	write := func(doc string) {
+		require.NoError(t, os.WriteFile(file, []byte(doc), 0o600))
+	}
+	validate := func(t *tunables) error {
+		if t.Limit < 0 {
+			return errors.New("negative limit")
+		}
+		return nil
+	}
+	reloader := NewReloader(Config{File: file}, validate, mock.NewLogger(false))
+	var applied []int
+	reloader.OnReload(func(t *tunables) { applied = append(applied, t.Limit) })
+
+	write(`{"interval": "1m30s", "limit": 3}`)
+	require.NoError(t, reloader.Reload(ctx))
+	assert.Equal(t, Duration(90*time.Second), reloader.Current().Interval)
+
+	// invalid tunables keep the active ones
+	write(`{"interval": "1m30s", "limit": -1}`)
+	assert.Error(t, reloader.Reload(ctx))
+	write(`{"interval": "1m30s", "limt": 4}`)
+	assert.Error(t, reloader.Reload(ctx))
+	write(`{"interval": 90}`)
+	assert.Error(t, reloader.Reload(ctx))
+	assert.Equal(t, 3, reloader.Current().Limit)
+	assert.Equal(t, []int{3}, applied)
+
+	// an unchanged document isn't applied again
+	write(`{"interval": "1m30s", "limit": 3}`)
+	require.NoError(t, reloader.Reload(ctx))
+	assert.Equal(t, []int{3}, applied)
+	write(`{"interval": "1m30s", "limit": 4}`)
+	require.NoError(t, reloader.Reload(ctx))
+	assert.Equal(t, []int{3, 4}, applied)
+
+	// or fetched from a config service
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"limit": 5}`))
+	}))
+	defer service.Close()
+	reloader = NewReloader[tunables](Config{URL: service.URL}, nil, mock.NewLogger(false))
+	require.NoError(t, reloader.Reload(ctx))
+	assert.Equal(t, 5, reloader.Current().Limit)
+}
@@ -0,0 +1,78 @@
+package srs
+
+import (
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/urfave/cli"
+)
+
+const (
+	URLsFlagName       = "urls"
+	CacheDirFlagName   = "cache-dir"
+	OrderFlagName      = "order"
+	G1ChecksumFlagName = "g1-checksum"
+	G2ChecksumFlagName = "g2-checksum"
+)
+
+type Config struct {
+	// URLs are the mirrors to download from, tried in order
+	URLs []string
+	// CacheDir is the directory g1.point and g2.point are written to, which the encoder
+	// loads them from with its G1_PATH and G2_PATH
+	CacheDir string
+	// Order is the number of points required from each file, at least the SRS_ORDER of the
+	// encoder
+	Order uint64
+	// G1Checksum and G2Checksum are hex encoded sha256 digests of the downloaded subsets,
+	// the first Order points of each file, not of the published files. Verification is
+	// skipped if they are empty.
+	G1Checksum string
+	G2Checksum string
+}
+
+func CLIFlags(envPrefix string, flagPrefix string) []cli.Flag {
+	return []cli.Flag{
+		cli.StringSliceFlag{
+			Name:     common.PrefixFlag(flagPrefix, URLsFlagName),
+			Usage:    "Base URLs serving g1.point and g2.point, tried in order",
+			EnvVar:   common.PrefixEnvVar(envPrefix, "URLS"),
+			Required: false,
+			Value:    &cli.StringSlice{},
+		},
+		cli.StringFlag{
+			Name:     common.PrefixFlag(flagPrefix, CacheDirFlagName),
+			Usage:    "Directory the SRS files are cached in, which the encoder loads them from with its G1_PATH and G2_PATH",
+			Required: false,
+			Value:    "./srs",
+			EnvVar:   common.PrefixEnvVar(envPrefix, "CACHE_DIR"),
+		},
+		cli.Uint64Flag{
+			Name:     common.PrefixFlag(flagPrefix, OrderFlagName),
+			Usage:    "Number of SRS points to download, at least the SRS_ORDER of the encoder",
+			Required: false,
+			Value:    300000,
+			EnvVar:   common.PrefixEnvVar(envPrefix, "ORDER"),
+		},
+		cli.StringFlag{
+			Name:     common.PrefixFlag(flagPrefix, G1ChecksumFlagName),
+			Usage:    "Expected sha256 (hex) of the first order points of g1.point, not of the published file",
+			Required: false,
+			EnvVar:   common.PrefixEnvVar(envPrefix, "G1_CHECKSUM"),
+		},
+		cli.StringFlag{
+			Name:     common.PrefixFlag(flagPrefix, G2ChecksumFlagName),
+			Usage:    "Expected sha256 (hex) of the first order points of g2.point, not of the published file",
+			Required: false,
+			EnvVar:   common.PrefixEnvVar(envPrefix, "G2_CHECKSUM"),
+		},
+	}
+}
+
+func ReadCLIConfig(ctx *cli.Context, flagPrefix string) Config {
+	return Config{
+		URLs:       ctx.GlobalStringSlice(common.PrefixFlag(flagPrefix, URLsFlagName)),
+		CacheDir:   ctx.GlobalString(common.PrefixFlag(flagPrefix, CacheDirFlagName)),
+		Order:      ctx.GlobalUint64(common.PrefixFlag(flagPrefix, OrderFlagName)),
+		G1Checksum: ctx.GlobalString(common.PrefixFlag(flagPrefix, G1ChecksumFlagName)),
+		G2Checksum: ctx.GlobalString(common.PrefixFlag(flagPrefix, G2ChecksumFlagName)),
+	}
+}
@@ -0,0 +1,181 @@
+package srs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/0glabs/0g-da-client/common"
+)
+
+const (
+	G1FileName = "g1.point"
+	G2FileName = "g2.point"
+
+	// G1PointSize and G2PointSize are the sizes of compressed bn254 points
+	G1PointSize = 32
+	G2PointSize = 64
+
+	partialSuffix = ".part"
+)
+
+var ErrChecksumMismatch = errors.New("srs checksum mismatch")
+
+// Downloader fetches the first Order points of the SRS files into a local
+// cache, for the encoder to load them from. Interrupted downloads are kept as
+// partial files and resumed with HTTP range requests on the next run. As only
+// the subset is downloaded, the checksums are those of the subsets, not of the
+// published files.
+type Downloader struct {
+	config     Config
+	httpClient *http.Client
+	logger     common.Logger
+}
+
+func NewDownloader(config Config, httpClient *http.Client, logger common.Logger) (*Downloader, error) {
+	if len(config.URLs) == 0 {
+		return nil, errors.New("no srs download url is configured")
+	}
+	if config.Order == 0 {
+		return nil, errors.New("srs order must be positive")
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Downloader{
+		config:     config,
+		httpClient: httpClient,
+		logger:     logger,
+	}, nil
+}
+
+// Ensure makes sure both SRS subsets are present in the cache directory,
+// downloading whatever is missing.
+func (d *Downloader) Ensure(ctx context.Context) error {
+	if err := os.MkdirAll(d.config.CacheDir, 0755); err != nil {
+		return fmt.Errorf("failed to create srs cache dir: %w", err)
+	}
+	if err := d.ensureFile(ctx, G1FileName, d.config.Order*G1PointSize, d.config.G1Checksum); err != nil {
+		return err
+	}
+	return d.ensureFile(ctx, G2FileName, d.config.Order*G2PointSize, d.config.G2Checksum)
+}
+
+// Path returns the local path of a cached SRS file.
+func (d *Downloader) Path(name string) string {
+	return filepath.Join(d.config.CacheDir, name)
+}
+
+func (d *Downloader) ensureFile(ctx context.Context, name string, size uint64, checksum string) error {
+	path := d.Path(name)
+	if info, err := os.Stat(path); err == nil && uint64(info.Size()) == size {
+		if err := verifyChecksum(path, checksum); err == nil {
+			d.logger.Info("[srs] using cached file", "path", path)
+			return nil
+		}
+		d.logger.Warn("[srs] cached file is corrupted, downloading again", "path", path)
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+
+	var lastErr error
+	for _, baseURL := range d.config.URLs {
+		url := strings.TrimSuffix(baseURL, "/") + "/" + name
+		err := d.download(ctx, url, path+partialSuffix, size)
+		if err == nil {
+			err = verifyChecksum(path+partialSuffix, checksum)
+			if err == nil {
+				return os.Rename(path+partialSuffix, path)
+			}
+			// a corrupted partial file can't be resumed
+			_ = os.Remove(path + partialSuffix)
+		}
+		d.logger.Warn("[srs] failed to download", "url", url, "err", err)
+		lastErr = err
+	}
+	return fmt.Errorf("failed to download %s from all urls: %w", name, lastErr)
+}
+
+func (d *Downloader) download(ctx context.Context, url string, path string, size uint64) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	offset := uint64(info.Size())
+	if offset > size {
+		offset = 0
+	}
+	if offset == size {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, size-1))
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// range requests not supported, start over
+		offset = 0
+	default:
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	if err := f.Truncate(int64(offset)); err != nil {
+		return err
+	}
+	if _, err := f.Seek(int64(offset), io.SeekStart); err != nil {
+		return err
+	}
+
+	d.logger.Info("[srs] downloading", "url", url, "offset", offset, "size", size)
+	n, err := io.Copy(f, io.LimitReader(resp.Body, int64(size-offset)))
+	if err != nil {
+		return err
+	}
+	if offset+uint64(n) != size {
+		return fmt.Errorf("short download: got %d of %d bytes", offset+uint64(n), size)
+	}
+	return f.Sync()
+}
+
+func verifyChecksum(path string, checksum string) error {
+	if checksum == "" {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return err
+	}
+	if !strings.EqualFold(hex.EncodeToString(hasher.Sum(nil)), strings.TrimPrefix(checksum, "0x")) {
+		return fmt.Errorf("%w: %s", ErrChecksumMismatch, path)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package srs_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/common/srs"
+	"github.com/stretchr/testify/assert"
+)
+
+const order = 16
+
+func newServer(g1, g2 []byte) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+srs.G1FileName, func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, srs.G1FileName, time.Time{}, bytes.NewReader(g1))
+	})
+	mux.HandleFunc("/"+srs.G2FileName, func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, srs.G2FileName, time.Time{}, bytes.NewReader(g2))
+	})
+	return httptest.NewServer(mux)
+}
+
+func checksum(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestEnsureResumesPartialDownload(t *testing.T) {
+	g1 := bytes.Repeat([]byte{1}, 2*order*srs.G1PointSize)
+	g2 := bytes.Repeat([]byte{2}, 2*order*srs.G2PointSize)
+	server := newServer(g1, g2)
+	defer server.Close()
+
+	dir := t.TempDir()
+	// a previous run left a partial g1 file behind
+	err := os.WriteFile(filepath.Join(dir, srs.G1FileName+".part"), g1[:10], 0644)
+	assert.NoError(t, err)
+
+	d, err := srs.NewDownloader(srs.Config{
+		URLs:       []string{"http://127.0.0.1:1", server.URL},
+		CacheDir:   dir,
+		Order:      order,
+		G1Checksum: checksum(g1[:order*srs.G1PointSize]),
+		G2Checksum: checksum(g2[:order*srs.G2PointSize]),
+	}, nil, mock.NewLogger(false))
+	assert.NoError(t, err)
+	assert.NoError(t, d.Ensure(context.Background()))
+
+	got, err := os.ReadFile(d.Path(srs.G1FileName))
+	assert.NoError(t, err)
+	assert.Equal(t, g1[:order*srs.G1PointSize], got)
+	got, err = os.ReadFile(d.Path(srs.G2FileName))
+	assert.NoError(t, err)
+	assert.Equal(t, g2[:order*srs.G2PointSize], got)
+
+	// cached files are reused without contacting the server
+	server.Close()
+	assert.NoError(t, d.Ensure(context.Background()))
+}
+
+func TestEnsureChecksumMismatch(t *testing.T) {
+	g1 := bytes.Repeat([]byte{1}, order*srs.G1PointSize)
+	g2 := bytes.Repeat([]byte{2}, order*srs.G2PointSize)
+	server := newServer(g1, g2)
+	defer server.Close()
+
+	d, err := srs.NewDownloader(srs.Config{
+		URLs:       []string{server.URL},
+		CacheDir:   t.TempDir(),
+		Order:      order,
+		G1Checksum: checksum([]byte("other")),
+	}, nil, mock.NewLogger(false))
+	assert.NoError(t, err)
+	err = d.Ensure(context.Background())
+	assert.True(t, errors.Is(err, srs.ErrChecksumMismatch))
+}
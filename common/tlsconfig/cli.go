@@ -0,0 +1,87 @@
+package tlsconfig
+
+import (
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/urfave/cli"
+)
+
+const (
+	CertFileFlagName     = "tls.cert-file"
+	KeyFileFlagName      = "tls.key-file"
+	ClientCAFileFlagName = "tls.client-ca-file"
+	EnabledFlagName      = "tls.enabled"
+	CAFileFlagName       = "tls.ca-file"
+	ServerNameFlagName   = "tls.server-name"
+)
+
+// ServerCLIFlags are the flags of the TLS config of a grpc server
+func ServerCLIFlags(envPrefix string, flagPrefix string) []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, CertFileFlagName),
+			Usage:  "PEM certificate the grpc server presents, served without TLS if empty. Reloaded on SIGHUP",
+			EnvVar: common.PrefixEnvVar(envPrefix, "TLS_CERT_FILE"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, KeyFileFlagName),
+			Usage:  "PEM private key of the grpc server certificate",
+			EnvVar: common.PrefixEnvVar(envPrefix, "TLS_KEY_FILE"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, ClientCAFileFlagName),
+			Usage:  "PEM CAs the grpc clients must present a certificate signed by (mutual TLS), clients aren't authenticated if empty",
+			EnvVar: common.PrefixEnvVar(envPrefix, "TLS_CLIENT_CA_FILE"),
+		},
+	}
+}
+
+func ReadServerCLIConfig(ctx *cli.Context, flagPrefix string) ServerConfig {
+	return ServerConfig{
+		CertFile:     ctx.GlobalString(common.PrefixFlag(flagPrefix, CertFileFlagName)),
+		KeyFile:      ctx.GlobalString(common.PrefixFlag(flagPrefix, KeyFileFlagName)),
+		ClientCAFile: ctx.GlobalString(common.PrefixFlag(flagPrefix, ClientCAFileFlagName)),
+	}
+}
+
+// ClientCLIFlags are the flags of the TLS config of a grpc client
+func ClientCLIFlags(envPrefix string, flagPrefix string) []cli.Flag {
+	return []cli.Flag{
+		cli.BoolFlag{
+			Name:   common.PrefixFlag(flagPrefix, EnabledFlagName),
+			Usage:  "Connect with TLS, implied by the other tls flags",
+			EnvVar: common.PrefixEnvVar(envPrefix, "TLS_ENABLED"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, CAFileFlagName),
+			Usage:  "PEM CAs the server certificate is verified against, the system CAs if empty. Reloaded on SIGHUP",
+			EnvVar: common.PrefixEnvVar(envPrefix, "TLS_CA_FILE"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, CertFileFlagName),
+			Usage:  "PEM client certificate presented to servers requiring mutual TLS",
+			EnvVar: common.PrefixEnvVar(envPrefix, "TLS_CERT_FILE"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, KeyFileFlagName),
+			Usage:  "PEM private key of the client certificate",
+			EnvVar: common.PrefixEnvVar(envPrefix, "TLS_KEY_FILE"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, ServerNameFlagName),
+			Usage:  "Name the server certificate is verified for, the host dialed if empty",
+			EnvVar: common.PrefixEnvVar(envPrefix, "TLS_SERVER_NAME"),
+		},
+	}
+}
+
+func ReadClientCLIConfig(ctx *cli.Context, flagPrefix string) ClientConfig {
+	config := ClientConfig{
+		CAFile:     ctx.GlobalString(common.PrefixFlag(flagPrefix, CAFileFlagName)),
+		CertFile:   ctx.GlobalString(common.PrefixFlag(flagPrefix, CertFileFlagName)),
+		KeyFile:    ctx.GlobalString(common.PrefixFlag(flagPrefix, KeyFileFlagName)),
+		ServerName: ctx.GlobalString(common.PrefixFlag(flagPrefix, ServerNameFlagName)),
+	}
+	config.Enabled = ctx.GlobalBool(common.PrefixFlag(flagPrefix, EnabledFlagName)) ||
+		config.CAFile != "" || config.CertFile != "" || config.ServerName != ""
+	return config
+}
@@ -0,0 +1,202 @@
+// Package tlsconfig secures the gRPC servers and clients with TLS, and mutual TLS when the
+// peers present certificates signed by a configured CA. The certificates are reloaded from
+// their files on SIGHUP, so they can be rotated without a restart.
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/0glabs/0g-da-client/common"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type ServerConfig struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile requires the clients to present a certificate signed by one of its CAs,
+	// clients aren't authenticated if empty
+	ClientCAFile string
+}
+
+func (c ServerConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+type ClientConfig struct {
+	Enabled bool
+	// CAFile holds the CAs the server certificate is verified against, the system CAs if empty
+	CAFile string
+	// CertFile and KeyFile are the certificate presented to servers requiring mutual TLS
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the name the server certificate is verified for, the host of the
+	// address dialed if empty
+	ServerName string
+}
+
+// ServerCredentials returns the transport credentials of a grpc server, nil if TLS isn't
+// enabled
+func ServerCredentials(config ServerConfig, logger common.Logger) (credentials.TransportCredentials, error) {
+	if !config.Enabled() {
+		return nil, nil
+	}
+	if config.KeyFile == "" {
+		return nil, errors.New("tls key file is required with the tls cert file")
+	}
+	return newReloadingCredentials("server", logger, func() (*tls.Config, error) {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tls certificate: %w", err)
+		}
+		tlsConfig := &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+		if config.ClientCAFile != "" {
+			tlsConfig.ClientCAs, err = loadCAs(config.ClientCAFile)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+		return tlsConfig, nil
+	})
+}
+
+// ClientCredentials returns the transport credentials of a grpc client, insecure ones if
+// TLS isn't enabled
+func ClientCredentials(config ClientConfig, logger common.Logger) (credentials.TransportCredentials, error) {
+	if !config.Enabled {
+		return insecure.NewCredentials(), nil
+	}
+	if (config.CertFile == "") != (config.KeyFile == "") {
+		return nil, errors.New("tls cert file and key file must be given together")
+	}
+	return newReloadingCredentials("client", logger, func() (*tls.Config, error) {
+		tlsConfig := &tls.Config{
+			ServerName: config.ServerName,
+			MinVersion: tls.VersionTLS12,
+		}
+		if config.CAFile != "" {
+			cas, err := loadCAs(config.CAFile)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = cas
+		}
+		if config.CertFile != "" {
+			cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load tls client certificate: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		return tlsConfig, nil
+	})
+}
+
+func loadCAs(file string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tls ca file: %w", err)
+	}
+	cas := x509.NewCertPool()
+	if !cas.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in tls ca file %s", file)
+	}
+	return cas, nil
+}
+
+// Source is implemented by the credentials of ServerCredentials and ClientCredentials when
+// TLS is enabled, for the transports other than grpc, like QUIC, which take a tls config
+type Source interface {
+	// TLSConfig returns a copy of the tls config last loaded
+	TLSConfig() *tls.Config
+}
+
+// reloadingCredentials handshakes with the tls config last loaded, which is loaded again on
+// SIGHUP. The connections established keep the config they were established with.
+type reloadingCredentials struct {
+	side   string
+	load   func() (*tls.Config, error)
+	logger common.Logger
+
+	mu      sync.RWMutex
+	config  *tls.Config
+	current credentials.TransportCredentials
+}
+
+func newReloadingCredentials(side string, logger common.Logger, load func() (*tls.Config, error)) (*reloadingCredentials, error) {
+	c := &reloadingCredentials{side: side, load: load, logger: logger}
+	if err := c.reload(); err != nil {
+		return nil, err
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			if err := c.reload(); err != nil {
+				logger.Error("[tls] failed to reload certificates, keeping the previous ones", "side", side, "err", err)
+				continue
+			}
+			logger.Info("[tls] reloaded certificates", "side", side)
+		}
+	}()
+	return c, nil
+}
+
+func (c *reloadingCredentials) reload() error {
+	tlsConfig, err := c.load()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.config = tlsConfig
+	c.current = credentials.NewTLS(tlsConfig)
+	return nil
+}
+
+func (c *reloadingCredentials) TLSConfig() *tls.Config {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.config.Clone()
+}
+
+func (c *reloadingCredentials) get() credentials.TransportCredentials {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.current
+}
+
+func (c *reloadingCredentials) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return c.get().ClientHandshake(ctx, authority, conn)
+}
+
+func (c *reloadingCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return c.get().ServerHandshake(conn)
+}
+
+func (c *reloadingCredentials) Info() credentials.ProtocolInfo {
+	return c.get().Info()
+}
+
+// Clone shares the credentials, which are only replaced as a whole
+func (c *reloadingCredentials) Clone() credentials.TransportCredentials {
+	return c
+}
+
+// OverrideServerName is deprecated by grpc, the server name is configured instead
+func (c *reloadingCredentials) OverrideServerName(string) error {
+	return errors.New("override the tls server name in the client config")
+}
@@ -0,0 +1,108 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// issue writes a certificate for localhost signed by the CA, self-signed if ca is nil, and
+// its key to dir, returning the certificate and key files
+func issue(t *testing.T, dir string, name string, ca *x509.Certificate, caKey *ecdsa.PrivateKey) (string, string, *x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if ca == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+		ca, caKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile, cert, key
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	logger := mock.NewLogger(false)
+	caFile, _, ca, caKey := issue(t, dir, "ca", nil, nil)
+	serverCert, serverKey, _, _ := issue(t, dir, "server", ca, caKey)
+	clientCert, clientKey, _, _ := issue(t, dir, "client", ca, caKey)
+	// the clients keep verifying the server against the CA the client CAs are rotated from
+	rootsFile := filepath.Join(dir, "roots.crt")
+	caPEM, err := os.ReadFile(caFile)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(rootsFile, caPEM, 0o600))
+
+	serverCreds, err := ServerCredentials(ServerConfig{CertFile: serverCert, KeyFile: serverKey, ClientCAFile: caFile}, logger)
+	require.NoError(t, err)
+	server := grpc.NewServer(grpc.Creds(serverCreds))
+	grpc_health_v1.RegisterHealthServer(server, health.NewServer())
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	check := func(config ClientConfig) error {
+		creds, err := ClientCredentials(config, logger)
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		conn, err := grpc.DialContext(ctx, listener.Addr().String(), grpc.WithTransportCredentials(creds))
+		require.NoError(t, err)
+		defer conn.Close()
+		_, err = grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+		return err
+	}
+
+	client := ClientConfig{Enabled: true, CAFile: rootsFile, CertFile: clientCert, KeyFile: clientKey, ServerName: "localhost"}
+	assert.NoError(t, check(client))
+	assert.Error(t, check(ClientConfig{Enabled: true, CAFile: rootsFile, ServerName: "localhost"}))
+	assert.Error(t, check(ClientConfig{}))
+
+	// a certificate of another CA is accepted once the files are rotated and reloaded
+	otherDir := t.TempDir()
+	otherCAFile, _, otherCA, otherCAKey := issue(t, otherDir, "ca", nil, nil)
+	otherCert, otherKey, _, _ := issue(t, otherDir, "client", otherCA, otherCAKey)
+	other := ClientConfig{Enabled: true, CAFile: rootsFile, CertFile: otherCert, KeyFile: otherKey, ServerName: "localhost"}
+	assert.Error(t, check(other))
+	caPEM, err = os.ReadFile(otherCAFile)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(caFile, caPEM, 0o600))
+	require.NoError(t, serverCreds.(*reloadingCredentials).reload())
+	assert.NoError(t, check(other))
+	assert.Error(t, check(client))
+}
@@ -0,0 +1,98 @@
+package tracing
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/urfave/cli"
+)
+
+const (
+	EndpointFlagName       = "tracing.otlp-endpoint"
+	HeadersFlagName        = "tracing.otlp-headers"
+	ServiceNameFlagName    = "tracing.service-name"
+	SampleRatioFlagName    = "tracing.sample-ratio"
+	ExportIntervalFlagName = "tracing.export-interval"
+)
+
+type Config struct {
+	// Endpoint is the OTLP/HTTP traces endpoint of the collector, such as
+	// http://tempo:4318/v1/traces, tracing is disabled if empty
+	Endpoint string
+	// Headers are sent with the exports, such as the authorization of a hosted collector
+	Headers     map[string]string
+	ServiceName string
+	// SampleRatio is the ratio of the traces started by the service that are recorded. The
+	// traces continued from another service are recorded if the other service sampled them.
+	SampleRatio    float64
+	ExportInterval time.Duration
+	QueueSize      int
+}
+
+func (c Config) Enabled() bool {
+	return c.Endpoint != ""
+}
+
+// CLIFlags are the flags of the tracing of a service, named serviceName by default
+func CLIFlags(envPrefix string, flagPrefix string, serviceName string) []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, EndpointFlagName),
+			Usage:  "OTLP/HTTP traces endpoint the spans are exported to, such as http://tempo:4318/v1/traces. Tracing is disabled if empty",
+			EnvVar: common.PrefixEnvVar(envPrefix, "TRACING_OTLP_ENDPOINT"),
+		},
+		cli.StringSliceFlag{
+			Name:   common.PrefixFlag(flagPrefix, HeadersFlagName),
+			Usage:  "Headers sent with the exported spans, as key=value",
+			EnvVar: common.PrefixEnvVar(envPrefix, "TRACING_OTLP_HEADERS"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, ServiceNameFlagName),
+			Usage:  "Service name the spans are exported with",
+			Value:  serviceName,
+			EnvVar: common.PrefixEnvVar(envPrefix, "TRACING_SERVICE_NAME"),
+		},
+		cli.Float64Flag{
+			Name:   common.PrefixFlag(flagPrefix, SampleRatioFlagName),
+			Usage:  "Ratio of the traces started by the service that are recorded, from 0 to 1. Traces continued from another service follow its sampling",
+			Value:  1,
+			EnvVar: common.PrefixEnvVar(envPrefix, "TRACING_SAMPLE_RATIO"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, ExportIntervalFlagName),
+			Usage:  "Interval the spans ended are exported at",
+			Value:  defaultExportInterval,
+			EnvVar: common.PrefixEnvVar(envPrefix, "TRACING_EXPORT_INTERVAL"),
+		},
+	}
+}
+
+func ReadCLIConfig(ctx *cli.Context, flagPrefix string) (Config, error) {
+	config := Config{
+		Endpoint:       ctx.GlobalString(common.PrefixFlag(flagPrefix, EndpointFlagName)),
+		Headers:        make(map[string]string),
+		ServiceName:    ctx.GlobalString(common.PrefixFlag(flagPrefix, ServiceNameFlagName)),
+		SampleRatio:    ctx.GlobalFloat64(common.PrefixFlag(flagPrefix, SampleRatioFlagName)),
+		ExportInterval: ctx.GlobalDuration(common.PrefixFlag(flagPrefix, ExportIntervalFlagName)),
+	}
+	if !config.Enabled() {
+		return config, nil
+	}
+	if u, err := url.Parse(config.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Config{}, fmt.Errorf("%s must be an http(s) url", EndpointFlagName)
+	}
+	if config.SampleRatio < 0 || config.SampleRatio > 1 {
+		return Config{}, fmt.Errorf("%s must be between 0 and 1", SampleRatioFlagName)
+	}
+	for _, entry := range ctx.GlobalStringSlice(common.PrefixFlag(flagPrefix, HeadersFlagName)) {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return Config{}, fmt.Errorf("invalid %s entry, expected <key>=<value>", HeadersFlagName)
+		}
+		config.Headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return config, nil
+}
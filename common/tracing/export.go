@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	defaultQueueSize      = 2048
+	defaultExportInterval = 5 * time.Second
+	exportTimeout         = 10 * time.Second
+)
+
+// NewTracer returns the tracer of config exporting its spans over OTLP/HTTP, nil if tracing
+// isn't enabled
+func NewTracer(config Config, logger common.Logger) (*Tracer, error) {
+	if !config.Enabled() {
+		return nil, nil
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultQueueSize
+	}
+	if config.ExportInterval <= 0 {
+		config.ExportInterval = defaultExportInterval
+	}
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tracing endpoint: %w", err)
+	}
+	options := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(endpoint.Host),
+		otlptracehttp.WithURLPath(endpoint.Path),
+		otlptracehttp.WithHeaders(config.Headers),
+		otlptracehttp.WithTimeout(exportTimeout),
+	}
+	if endpoint.Scheme == "http" {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	// the exporter connects on export, New fails only on invalid options
+	exporter, err := otlptracehttp.New(context.Background(), options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the span exporter: %w", err)
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", config.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter,
+			sdktrace.WithMaxQueueSize(config.QueueSize),
+			sdktrace.WithBatchTimeout(config.ExportInterval),
+			sdktrace.WithExportTimeout(exportTimeout),
+		),
+		sdktrace.WithResource(res),
+		// the traces continued from another service are recorded if the other service sampled them
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	return &Tracer{
+		logger:   logger,
+		provider: provider,
+		tracer:   provider.Tracer(instrumentationName),
+	}, nil
+}
@@ -0,0 +1,25 @@
+package tracing
+
+import (
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+)
+
+// ServerOptions trace the requests of a grpc server, continuing the traces of the clients
+// propagating a trace context. None if the tracer is nil.
+func (t *Tracer) ServerOptions() []grpc.ServerOption {
+	if t == nil {
+		return nil
+	}
+	return []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithTracerProvider(t.provider), otelgrpc.WithPropagators(Propagator))),
+	}
+}
+
+// DialOptions propagate the span carried by the context of the requests of a grpc client,
+// recording the client spans with the global provider the tracer registers on start
+func DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler(otelgrpc.WithPropagators(Propagator))),
+	}
+}
@@ -0,0 +1,130 @@
+// Package tracing records the spans of the journey of the blobs through the disperser with the
+// OpenTelemetry SDK and exports them to an OpenTelemetry collector, Jaeger or Tempo over
+// OTLP/HTTP. Spans are propagated in process through the context, across services in the W3C
+// trace context and baggage: in the gRPC metadata of the requests, and in the request header
+// of the blobs between the API server and the batcher.
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// instrumentationName is the name of the tracer the spans of the disperser are recorded with
+const instrumentationName = "github.com/0glabs/0g-da-client"
+
+// Attribute is a key value annotating a span
+type Attribute = attribute.KeyValue
+
+func String(key string, value string) Attribute {
+	return attribute.String(key, value)
+}
+
+func Int(key string, value int64) Attribute {
+	return attribute.Int64(key, value)
+}
+
+func Bool(key string, value bool) Attribute {
+	return attribute.Bool(key, value)
+}
+
+// Propagator propagates the W3C traceparent, tracestate and baggage of the spans across
+// services
+var Propagator propagation.TextMapPropagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// Inject returns the trace context and baggage of the span carried by ctx as propagated to
+// another service, nil if ctx carries none
+func Inject(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	Propagator.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// Extract returns ctx carrying the remote span and baggage of a trace context received from
+// another service
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	return Propagator.Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// End ends span, failed with err as its status if err isn't nil
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Tracer starts spans and exports them in batches. A nil tracer records no span.
+type Tracer struct {
+	logger   common.Logger
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+}
+
+var noopTracer = noop.NewTracerProvider().Tracer(instrumentationName)
+
+func (t *Tracer) otelTracer() trace.Tracer {
+	if t == nil {
+		return noopTracer
+	}
+	return t.tracer
+}
+
+// StartSpan starts a span child of the span carried by ctx, or the root of a new trace
+// sampled at the sample ratio, returning ctx carrying it
+func (t *Tracer) StartSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, trace.Span) {
+	return t.otelTracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartChild starts a span child of the span of a trace context injected by another service,
+// a span recording nothing if there is none. The batcher continues the traces of the blobs
+// with it, never starting traces of its own.
+func (t *Tracer) StartChild(carrier map[string]string, name string, attrs ...Attribute) trace.Span {
+	return t.StartChildAt(carrier, name, time.Now(), attrs...)
+}
+
+// StartChildAt starts a span like StartChild, which started at start, such as the wait for a
+// receipt that began before the span could be started
+func (t *Tracer) StartChildAt(carrier map[string]string, name string, start time.Time, attrs ...Attribute) trace.Span {
+	ctx := Extract(context.Background(), carrier)
+	if t == nil || !trace.SpanContextFromContext(ctx).IsValid() {
+		return trace.SpanFromContext(context.Background())
+	}
+	_, span := t.tracer.Start(ctx, name, trace.WithTimestamp(start), trace.WithAttributes(attrs...))
+	return span
+}
+
+// Start registers the tracer as the global provider of the otel API, so that the spans of
+// the libraries instrumented with it join the traces of the disperser
+func (t *Tracer) Start(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	otel.SetTracerProvider(t.provider)
+	otel.SetTextMapPropagator(Propagator)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		t.logger.Error("[tracing] failed to export spans", "err", err)
+	}))
+}
+
+// Stop flushes the spans still queued, waiting for the export until ctx is done. The tracer
+// is added to the lifecycle first, so it is stopped last with the spans of the others.
+func (t *Tracer) Stop(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return t.provider.Shutdown(ctx)
+}
@@ -0,0 +1,132 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestPropagation(t *testing.T) {
+	carrier := map[string]string{
+		"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"tracestate":  "vendor=opaque",
+		"baggage":     "tenant=rollup",
+	}
+	ctx := Extract(context.Background(), carrier)
+	sc := trace.SpanContextFromContext(ctx)
+	require.True(t, sc.IsValid())
+	assert.True(t, sc.IsSampled())
+	assert.Equal(t, "vendor=opaque", sc.TraceState().String())
+	assert.Equal(t, "rollup", baggage.FromContext(ctx).Member("tenant").Value())
+	assert.Equal(t, carrier, Inject(ctx))
+
+	assert.Nil(t, Inject(context.Background()))
+	for _, invalid := range []string{
+		"",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		ctx := Extract(context.Background(), map[string]string{"traceparent": invalid})
+		assert.False(t, trace.SpanContextFromContext(ctx).IsValid(), invalid)
+	}
+}
+
+func TestExport(t *testing.T) {
+	var mu sync.Mutex
+	var exported []*tracepb.Span
+	var authorization string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var req collectortrace.ExportTraceServiceRequest
+		require.NoError(t, proto.Unmarshal(body, &req))
+		mu.Lock()
+		defer mu.Unlock()
+		authorization = r.Header.Get("Authorization")
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		for _, attr := range req.ResourceSpans[0].Resource.Attributes {
+			if attr.Key == "service.name" {
+				assert.Equal(t, "apiserver", attr.Value.GetStringValue())
+			}
+		}
+		for _, scope := range req.ResourceSpans[0].ScopeSpans {
+			exported = append(exported, scope.Spans...)
+		}
+	}))
+	defer collector.Close()
+
+	tracer, err := NewTracer(Config{
+		Endpoint:    collector.URL + "/v1/traces",
+		Headers:     map[string]string{"Authorization": "Bearer token"},
+		ServiceName: "apiserver",
+		SampleRatio: 1,
+	}, mock.NewLogger(false))
+	require.NoError(t, err)
+	tracer.Start(context.Background())
+
+	ctx, root := tracer.StartSpan(context.Background(), "disperse", Int("size", 1024))
+	_, child := tracer.StartSpan(ctx, "store")
+	End(child, errors.New("store failed"))
+	End(root, nil)
+	// the batcher continues the trace from the trace context stored with the blob
+	remote := tracer.StartChild(Inject(ctx), "encode", Bool("cached", true))
+	End(remote, nil)
+	assert.False(t, tracer.StartChild(nil, "encode").IsRecording())
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, tracer.Stop(stopCtx))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, "Bearer token", authorization)
+	require.Len(t, exported, 3)
+	byName := make(map[string]*tracepb.Span)
+	for _, span := range exported {
+		assert.Equal(t, exported[0].TraceId, span.TraceId)
+		byName[span.Name] = span
+	}
+	assert.Empty(t, byName["disperse"].ParentSpanId)
+	assert.Equal(t, int64(1024), byName["disperse"].Attributes[0].Value.GetIntValue())
+	assert.Equal(t, byName["disperse"].SpanId, byName["store"].ParentSpanId)
+	assert.Equal(t, tracepb.Status_STATUS_CODE_ERROR, byName["store"].Status.Code)
+	assert.Equal(t, byName["disperse"].SpanId, byName["encode"].ParentSpanId)
+	traceID := root.SpanContext().TraceID()
+	assert.Equal(t, hex.EncodeToString(traceID[:]), hex.EncodeToString(byName["encode"].TraceId))
+}
+
+func TestSampling(t *testing.T) {
+	tracer, err := NewTracer(Config{Endpoint: "http://localhost:4318/v1/traces", SampleRatio: 0}, mock.NewLogger(false))
+	require.NoError(t, err)
+	defer func() { _ = tracer.Stop(context.Background()) }()
+	_, span := tracer.StartSpan(context.Background(), "disperse")
+	assert.False(t, span.IsRecording())
+	// a trace sampled by the client is recorded regardless of the ratio
+	ctx := Extract(context.Background(), map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"})
+	_, span = tracer.StartSpan(ctx, "disperse")
+	assert.True(t, span.IsRecording())
+	span = tracer.StartChild(map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"}, "encode")
+	assert.False(t, span.IsRecording())
+
+	var disabled *Tracer
+	_, span = disabled.StartSpan(context.Background(), "disperse")
+	End(span, nil)
+	assert.False(t, span.IsRecording())
+	assert.Nil(t, disabled.ServerOptions())
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"bytes"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+)
+
+// ChunkBufferPool holds the buffers the encoded slices of blobs are framed into for the
+// signers. Frames are as large as the blobs and only live until the sign request is sent.
+var ChunkBufferPool = NewBufferPool(64*1024, 64*1024*1024)
+
+// BufferPool recycles byte buffers in power of two size classes, so that a buffer taken
+// for a small blob doesn't hold the memory of a large one, and the other way around
+type BufferPool struct {
+	minShift int
+	classes  []sync.Pool
+
+	gets     atomic.Uint64
+	hits     atomic.Uint64
+	puts     atomic.Uint64
+	oversize atomic.Uint64
+}
+
+// BufferPoolStats counts the operations of a pool since it was created
+type BufferPoolStats struct {
+	// Gets is the number of buffers taken, Hits those that were recycled
+	Gets uint64
+	Hits uint64
+	// Puts is the number of buffers returned to the pool
+	Puts uint64
+	// Oversize is the number of buffers taken or returned outside of the size classes,
+	// neither recycled nor kept
+	Oversize uint64
+}
+
+// NewBufferPool returns a pool of buffers from minSize to maxSize, both rounded up to a
+// power of two
+func NewBufferPool(minSize, maxSize int) *BufferPool {
+	minShift := shiftFor(minSize)
+	maxShift := shiftFor(maxSize)
+	if maxShift < minShift {
+		maxShift = minShift
+	}
+	return &BufferPool{
+		minShift: minShift,
+		classes:  make([]sync.Pool, maxShift-minShift+1),
+	}
+}
+
+// Get returns an empty buffer that can hold size bytes without growing
+func (p *BufferPool) Get(size int) *bytes.Buffer {
+	p.gets.Add(1)
+	class := shiftFor(size) - p.minShift
+	if class < 0 {
+		class = 0
+	}
+	if class >= len(p.classes) {
+		p.oversize.Add(1)
+		return bytes.NewBuffer(make([]byte, 0, size))
+	}
+	if buf, ok := p.classes[class].Get().(*bytes.Buffer); ok {
+		p.hits.Add(1)
+		buf.Reset()
+		return buf
+	}
+	return bytes.NewBuffer(make([]byte, 0, 1<<(class+p.minShift)))
+}
+
+// Put returns a buffer to the pool. The buffer, and the slices of its content, must not be
+// used afterwards.
+func (p *BufferPool) Put(buf *bytes.Buffer) {
+	if buf == nil {
+		return
+	}
+	// the largest class the buffer can serve every Get of
+	class := bits.Len(uint(buf.Cap())) - 1 - p.minShift
+	if class < 0 || class >= len(p.classes) {
+		p.oversize.Add(1)
+		return
+	}
+	p.puts.Add(1)
+	p.classes[class].Put(buf)
+}
+
+func (p *BufferPool) Stats() BufferPoolStats {
+	return BufferPoolStats{
+		Gets:     p.gets.Load(),
+		Hits:     p.hits.Load(),
+		Puts:     p.puts.Load(),
+		Oversize: p.oversize.Load(),
+	}
+}
+
+// shiftFor returns the exponent of the smallest power of two of at least size
+func shiftFor(size int) int {
+	if size <= 1 {
+		return 0
+	}
+	return bits.Len(uint(size - 1))
+}
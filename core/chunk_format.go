@@ -0,0 +1,239 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ChunkFormat is the wire format of the encoded slices sent to a signer. The legacy format
+// sends every slice as its own protobuf field. The framed formats pack all slices of a
+// blob into a single versioned frame, optionally compressed, so that the bytes on the wire
+// no longer depend on how the Go or protobuf types lay them out.
+type ChunkFormat uint8
+
+const (
+	ChunkFormatLegacy ChunkFormat = iota
+	ChunkFormatFramed
+	ChunkFormatFramedFlate
+)
+
+const (
+	ChunkFormatLegacyName      = "legacy"
+	ChunkFormatFramedName      = "framed"
+	ChunkFormatFramedFlateName = "framed-flate"
+)
+
+const (
+	chunkFrameVersion    = 1
+	chunkFrameFlagFlate  = 1 << 0
+	chunkFrameHeaderSize = 2
+	// maxChunkFrameSize bounds the decoded size of a frame, well above the encoded size of
+	// the largest blob
+	maxChunkFrameSize = MaxBlobSize * 8
+)
+
+var chunkFormatNames = map[ChunkFormat]string{
+	ChunkFormatLegacy:      ChunkFormatLegacyName,
+	ChunkFormatFramed:      ChunkFormatFramedName,
+	ChunkFormatFramedFlate: ChunkFormatFramedFlateName,
+}
+
+func (f ChunkFormat) String() string {
+	if name, ok := chunkFormatNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(f))
+}
+
+// GetChunkFormat returns the format with the given name. An empty name selects legacy.
+func GetChunkFormat(name string) (ChunkFormat, error) {
+	if name == "" {
+		return ChunkFormatLegacy, nil
+	}
+	for format, formatName := range chunkFormatNames {
+		if formatName == name {
+			return format, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown chunk format %q, expected one of %v", name, ChunkFormatNames())
+}
+
+func ChunkFormatNames() []string {
+	names := make([]string, 0, len(chunkFormatNames))
+	for _, name := range chunkFormatNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseChunkFormats parses a comma separated list of format names, as advertised by
+// signers. Unknown names are skipped since signers may support formats newer than ours.
+func ParseChunkFormats(list string) []ChunkFormat {
+	formats := make([]ChunkFormat, 0)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		format, err := GetChunkFormat(name)
+		if err != nil {
+			continue
+		}
+		formats = append(formats, format)
+	}
+	return formats
+}
+
+// NegotiateChunkFormat returns the first of the preferred formats the peer supports, or
+// legacy if there is none
+func NegotiateChunkFormat(preferred, supported []ChunkFormat) ChunkFormat {
+	for _, p := range preferred {
+		for _, s := range supported {
+			if p == s {
+				return p
+			}
+		}
+	}
+	return ChunkFormatLegacy
+}
+
+// EncodeChunks serializes the slices of a blob in the given format. A frame is laid out as
+// a version byte, a flags byte and a body holding the number of slices followed by each
+// slice, every length being a uvarint. With flate the body is DEFLATE compressed.
+func EncodeChunks(format ChunkFormat, slices [][]byte) ([][]byte, error) {
+	if format == ChunkFormatLegacy {
+		return slices, nil
+	}
+	var buf bytes.Buffer
+	if err := encodeFrame(&buf, format, slices); err != nil {
+		return nil, err
+	}
+	return [][]byte{buf.Bytes()}, nil
+}
+
+// EncodeChunksPooled is EncodeChunks framing the slices into a buffer of the pool. Calling
+// release returns the buffer to the pool once the frame is no longer used.
+func EncodeChunksPooled(pool *BufferPool, format ChunkFormat, slices [][]byte) (frames [][]byte, release func(), err error) {
+	if format == ChunkFormatLegacy {
+		return slices, func() {}, nil
+	}
+	size := chunkFrameHeaderSize + (len(slices)+1)*binary.MaxVarintLen64
+	for _, slice := range slices {
+		size += len(slice)
+	}
+	buf := pool.Get(size)
+	if err := encodeFrame(buf, format, slices); err != nil {
+		pool.Put(buf)
+		return nil, nil, err
+	}
+	return [][]byte{buf.Bytes()}, func() { pool.Put(buf) }, nil
+}
+
+// flateWriters recycles the compressors of the flate frames, each holding several hundred
+// KiB of state
+var flateWriters sync.Pool
+
+func encodeFrame(buf *bytes.Buffer, format ChunkFormat, slices [][]byte) error {
+	var flags byte
+	switch format {
+	case ChunkFormatFramed:
+	case ChunkFormatFramedFlate:
+		flags |= chunkFrameFlagFlate
+	default:
+		return fmt.Errorf("unknown chunk format %d", format)
+	}
+
+	buf.Write([]byte{chunkFrameVersion, flags})
+
+	var body io.Writer = buf
+	var compressor *flate.Writer
+	if flags&chunkFrameFlagFlate != 0 {
+		if w, ok := flateWriters.Get().(*flate.Writer); ok {
+			compressor = w
+			compressor.Reset(buf)
+		} else {
+			var err error
+			compressor, err = flate.NewWriter(buf, flate.DefaultCompression)
+			if err != nil {
+				return err
+			}
+		}
+		defer flateWriters.Put(compressor)
+		body = compressor
+	}
+
+	var length [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(length[:], uint64(len(slices)))
+	if _, err := body.Write(length[:n]); err != nil {
+		return err
+	}
+	for _, slice := range slices {
+		n = binary.PutUvarint(length[:], uint64(len(slice)))
+		if _, err := body.Write(length[:n]); err != nil {
+			return err
+		}
+		if _, err := body.Write(slice); err != nil {
+			return err
+		}
+	}
+	if compressor != nil {
+		return compressor.Close()
+	}
+	return nil
+}
+
+// DecodeChunks is the inverse of EncodeChunks
+func DecodeChunks(format ChunkFormat, data [][]byte) ([][]byte, error) {
+	if format == ChunkFormatLegacy {
+		return data, nil
+	}
+	if len(data) != 1 {
+		return nil, fmt.Errorf("expected a single chunk frame, got %d", len(data))
+	}
+	frame := data[0]
+	if len(frame) < chunkFrameHeaderSize {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if frame[0] != chunkFrameVersion {
+		return nil, fmt.Errorf("unsupported chunk frame version %d", frame[0])
+	}
+
+	var body interface {
+		io.Reader
+		io.ByteReader
+	} = bytes.NewReader(frame[chunkFrameHeaderSize:])
+	if frame[1]&chunkFrameFlagFlate != 0 {
+		decompressor := flate.NewReader(body)
+		defer decompressor.Close()
+		body = bufio.NewReader(io.LimitReader(decompressor, maxChunkFrameSize))
+	}
+
+	count, err := binary.ReadUvarint(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read slice count: %w", err)
+	}
+	slices := make([][]byte, 0)
+	for i := uint64(0); i < count; i++ {
+		length, err := binary.ReadUvarint(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read length of slice %d: %w", i, err)
+		}
+		if length > maxChunkFrameSize {
+			return nil, fmt.Errorf("slice %d of %d bytes exceeds the frame limit", i, length)
+		}
+		slice := make([]byte, length)
+		if _, err := io.ReadFull(body, slice); err != nil {
+			return nil, fmt.Errorf("failed to read slice %d: %w", i, err)
+		}
+		slices = append(slices, slice)
+	}
+	return slices, nil
+}
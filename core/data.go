@@ -16,6 +16,11 @@ type AccountID = string
 // QuorumID is a unique identifier for a quorum; initially ZGDA wil support upt to 256 quorums
 type QuorumID = uint8
 
+// MinQuorumThreshold is the lowest quorum threshold of the protocol, in percent of the stake
+// of a quorum. Blobs are signed by at least two thirds of a quorum whatever threshold they
+// request.
+const MinQuorumThreshold = 67
+
 // SecurityParam contains the quorum ID and the adversary threshold for the quorum;
 type SecurityParam struct {
 	QuorumID QuorumID `json:"quorum_id"`
@@ -23,6 +28,9 @@ type SecurityParam struct {
 	AdversaryThreshold uint8 `json:"adversary_threshold"`
 	// QuorumThreshold is the amount of stake that must sign a message for it to be considered valid as a percentage of the total stake in the quorum
 	QuorumThreshold uint8 `json:"quorum_threshold"`
+	// Optional thresholds fall back to the defaults of the disperser if the blob falls short
+	// of them, when the disperser allows it
+	Optional bool `json:"optional,omitempty"`
 	// Rate Limit. This is a temporary measure until the node can derive rates on its own using rollup authentication. This is used
 	// for restricting the rate at which retrievers are able to download data from the DA node to a multiple of the rate at which the
 	// data was posted to the DA node.
@@ -34,6 +42,13 @@ type QuorumResult struct {
 	QuorumID QuorumID
 	// PercentSigned is percentage of the total stake for the quorum that signed for a particular batch.
 	PercentSigned uint8
+	// AdversaryThreshold and QuorumThreshold are the percentages of the security parameters
+	// the blob was confirmed under, 0 for blobs confirmed before they were recorded
+	AdversaryThreshold uint8
+	QuorumThreshold    uint8
+	// Fallback is set if the blob fell short of the optional thresholds it requested and was
+	// confirmed with the thresholds above, the defaults of the disperser
+	Fallback bool
 }
 
 // Blob stores the data and header of a single data blob. Blobs are the fundamental unit of data posted to ZGDA by users.
@@ -48,7 +63,21 @@ type BlobRequestHeader struct {
 	SecurityParams []*SecurityParam `json:"security_params"`
 	// AccountID is the account that is paying for the blob to be stored
 	AccountID AccountID `json:"account_id"`
-}
+	// Signer is the hex address of the account which signed the dispersal, the AccountID of
+	// signed dispersals. Empty if the dispersal wasn't signed.
+	Signer string `json:"signer,omitempty"`
+	// Tags are application metadata the blob was dispersed with
+	Tags map[string]string `json:"tags,omitempty"`
+	// Priority is the lane of the blob in the batcher, blobs of higher lanes are encoded
+	// and batched first. 0 is the default lane of bulk traffic, MaxBlobPriority the highest.
+	Priority uint8 `json:"priority,omitempty"`
+	// TraceContext is the W3C trace context and baggage of the dispersal, the batcher
+	// continues the trace of the blob from it. Empty if the dispersal wasn't traced.
+	TraceContext map[string]string `json:"trace_context,omitempty"`
+}
+
+// MaxBlobPriority is the highest priority lane of blobs
+const MaxBlobPriority uint8 = 2
 
 // BlobQuorumInfo contains the quorum IDs and parameters for a blob specific to a given quorum
 type BlobQuorumInfo struct {
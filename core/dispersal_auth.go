@@ -0,0 +1,111 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var ErrInvalidDispersalSignature = errors.New("invalid dispersal signature")
+
+// DispersalSecurityParam is a security parameter of a dispersal as requested
+type DispersalSecurityParam struct {
+	QuorumID           uint32
+	AdversaryThreshold uint32
+	QuorumThreshold    uint32
+	Optional           bool
+}
+
+// DispersalDomain is the disperser a dispersal is signed for, so that its signature can't be
+// replayed to the disperser of another deployment: the chain ID and the address of the DA
+// entrance contract the disperser uploads the data roots to
+type DispersalDomain struct {
+	ChainID  uint64
+	Contract common.Address
+}
+
+// IsZero returns whether no domain is set
+func (d DispersalDomain) IsZero() bool {
+	return d == DispersalDomain{}
+}
+
+// DispersalRequest is the part of a dispersal signed by its account
+type DispersalRequest struct {
+	Data           []byte
+	Nonce          uint64
+	SecurityParams []DispersalSecurityParam
+	Tags           map[string]string
+}
+
+// DispersalDigest is the digest signed by the account dispersing a blob to the disperser of
+// domain. It is the EIP-191 personal message digest of the 32 bytes
+// keccak256(keccak256(data) || nonce || keccak256(params) || keccak256(tags) || chain ID ||
+// contract), so that wallets sign it with personal_sign, with the nonce and the chain ID as
+// 8 big endian bytes. params are the security parameters in the order requested, each as
+// quorum id, adversary threshold and quorum threshold of 4 big endian bytes and a byte 1 if
+// optional, 0 otherwise. tags are keccak256(key) || keccak256(value) of the tags in the
+// order of their keys.
+func DispersalDigest(domain DispersalDomain, req DispersalRequest) [32]byte {
+	params := make([]byte, 0, 13*len(req.SecurityParams))
+	for _, param := range req.SecurityParams {
+		params = binary.BigEndian.AppendUint32(params, param.QuorumID)
+		params = binary.BigEndian.AppendUint32(params, param.AdversaryThreshold)
+		params = binary.BigEndian.AppendUint32(params, param.QuorumThreshold)
+		optional := byte(0)
+		if param.Optional {
+			optional = 1
+		}
+		params = append(params, optional)
+	}
+
+	keys := make([]string, 0, len(req.Tags))
+	for key := range req.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	tags := make([]byte, 0, 64*len(keys))
+	for _, key := range keys {
+		tags = append(tags, crypto.Keccak256([]byte(key))...)
+		tags = append(tags, crypto.Keccak256([]byte(req.Tags[key]))...)
+	}
+
+	message := make([]byte, 0, 132)
+	message = append(message, crypto.Keccak256(req.Data)...)
+	message = binary.BigEndian.AppendUint64(message, req.Nonce)
+	message = append(message, crypto.Keccak256(params)...)
+	message = append(message, crypto.Keccak256(tags)...)
+	message = binary.BigEndian.AppendUint64(message, domain.ChainID)
+	message = append(message, domain.Contract.Bytes()...)
+	return [32]byte(accounts.TextHash(crypto.Keccak256(message)))
+}
+
+// SignDispersal signs a dispersal to the disperser of domain, returning the signature as
+// [R || S || V]
+func SignDispersal(key *ecdsa.PrivateKey, domain DispersalDomain, req DispersalRequest) ([]byte, error) {
+	digest := DispersalDigest(domain, req)
+	return crypto.Sign(digest[:], key)
+}
+
+// RecoverDispersalSigner returns the address of the account which signed a dispersal to the
+// disperser of domain
+func RecoverDispersalSigner(domain DispersalDomain, req DispersalRequest, signature []byte) (common.Address, error) {
+	if len(signature) != crypto.SignatureLength {
+		return common.Address{}, ErrInvalidDispersalSignature
+	}
+	// personal_sign returns the recovery id offset by 27
+	if signature[crypto.RecoveryIDOffset] >= 27 {
+		signature = append([]byte{}, signature...)
+		signature[crypto.RecoveryIDOffset] -= 27
+	}
+	digest := DispersalDigest(domain, req)
+	pubKey, err := crypto.SigToPub(digest[:], signature)
+	if err != nil {
+		return common.Address{}, ErrInvalidDispersalSignature
+	}
+	return crypto.PubkeyToAddress(*pubKey), nil
+}
@@ -59,6 +59,48 @@ func SplitToMatrix(blobLength uint, targetRowNum uint) (uint, uint) {
 	return rows, cols
 }
 
+// EncoderParams are the parameters of the encoder, which pads every blob to BlobCapacity
+// bytes and erasure codes it into one slice of SliceSize bytes per slot of a quorum
+type EncoderParams struct {
+	BlobCapacity uint
+	SliceSize    uint
+}
+
+// DefaultEncoderParams pads a blob to a matrix of MaxRows rows of MaxCols symbols, each
+// slice an erasure coded row of the matrix with its commitment
+var DefaultEncoderParams = EncoderParams{
+	BlobCapacity: MaxBlobSize,
+	SliceSize:    MaxCols*CoeffSize + CommitmentSize,
+}
+
+// EncodedSize returns the size of a blob encoded into slices, which are all the same size
+func EncodedSize(slices [][]byte) uint {
+	if len(slices) == 0 {
+		return 0
+	}
+	return uint(len(slices) * len(slices[0]))
+}
+
+// EncodingEstimate is the layout of an encoded blob, see EncoderParams.Estimate
+type EncodingEstimate struct {
+	Slices      uint
+	SliceSize   uint
+	EncodedSize uint
+	// Capacity is the size of the largest blob encoded into the same slices
+	Capacity uint
+}
+
+// Estimate returns the layout of a blob encoded for a quorum of slots slots, as EncodedSize
+// measures the slices of the encoder reply. It holds for any blob up to BlobCapacity bytes.
+func (p EncoderParams) Estimate(slots uint) EncodingEstimate {
+	return EncodingEstimate{
+		Slices:      slots,
+		SliceSize:   p.SliceSize,
+		EncodedSize: slots * p.SliceSize,
+		Capacity:    p.BlobCapacity,
+	}
+}
+
 // GetBlobSize converts from blob length in symbols to blob size in bytes. This is not an exact conversion.
 func GetBlobSize(blobLength uint) uint {
 	return blobLength * ScalarSize
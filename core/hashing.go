@@ -0,0 +1,124 @@
+package core
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+
+	"github.com/wealdtech/go-merkletree"
+	"github.com/wealdtech/go-merkletree/blake2b"
+	"github.com/wealdtech/go-merkletree/keccak256"
+	"github.com/wealdtech/go-merkletree/sha3"
+)
+
+// HashSuite is the hash function used for blob header hashes, batch header hashes and
+// the merkle tree over blob headers. Settlement chains verify these hashes on chain, so
+// the suite has to match a hash the chain can compute natively.
+type HashSuite interface {
+	merkletree.HashType
+	Name() string
+}
+
+const (
+	Keccak256SuiteName  = "keccak256"
+	SHA256SuiteName     = "sha256"
+	SHA3_256SuiteName   = "sha3-256"
+	Blake2b256SuiteName = "blake2b-256"
+)
+
+type namedHashSuite struct {
+	merkletree.HashType
+	name string
+}
+
+func (s namedHashSuite) Name() string {
+	return s.name
+}
+
+// sha256Hash is SHA-256 from the SHA-2 family, which the merkletree package doesn't provide
+type sha256Hash struct{}
+
+func (sha256Hash) Hash(data ...[]byte) []byte {
+	hasher := sha256.New()
+	for _, d := range data {
+		hasher.Write(d)
+	}
+	return hasher.Sum(nil)
+}
+
+func (sha256Hash) HashLength() int {
+	return sha256.Size
+}
+
+var (
+	// Keccak256Suite is the suite used by EVM chains and the default for all hashes
+	Keccak256Suite HashSuite = namedHashSuite{keccak256.New(), Keccak256SuiteName}
+	// SHA256Suite uses SHA-256, available as a precompile on EVM chains and natively on most others
+	SHA256Suite     HashSuite = namedHashSuite{sha256Hash{}, SHA256SuiteName}
+	SHA3_256Suite   HashSuite = namedHashSuite{sha3.New256(), SHA3_256SuiteName}
+	Blake2b256Suite HashSuite = namedHashSuite{blake2b.New(), Blake2b256SuiteName}
+)
+
+var hashSuites = map[string]HashSuite{
+	Keccak256SuiteName:  Keccak256Suite,
+	SHA256SuiteName:     SHA256Suite,
+	SHA3_256SuiteName:   SHA3_256Suite,
+	Blake2b256SuiteName: Blake2b256Suite,
+}
+
+// GetHashSuite returns the suite with the given name. An empty name selects keccak256.
+func GetHashSuite(name string) (HashSuite, error) {
+	if name == "" {
+		return Keccak256Suite, nil
+	}
+	suite, ok := hashSuites[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown hash suite %q, expected one of %v", name, HashSuiteNames())
+	}
+	return suite, nil
+}
+
+func HashSuiteNames() []string {
+	names := make([]string, 0, len(hashSuites))
+	for name := range hashSuites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func hashWith(suite HashSuite, data []byte) [32]byte {
+	var hash [32]byte
+	copy(hash[:], suite.Hash(data))
+	return hash
+}
+
+// VerifyBlobHeaderHash reports which of the given suites produced hash for the header.
+// All known suites are tried if none are given, which lets hashes produced before and
+// after a network switched suites be verified with the same call.
+func VerifyBlobHeaderHash(header *BlobHeader, hash [32]byte, suites ...HashSuite) (HashSuite, bool, error) {
+	return verifyHash(hash, suites, header.GetBlobHeaderHashWith)
+}
+
+// VerifyBatchHeaderHash is VerifyBlobHeaderHash for batch headers
+func VerifyBatchHeaderHash(header *BatchHeader, hash [32]byte, suites ...HashSuite) (HashSuite, bool, error) {
+	return verifyHash(hash, suites, header.GetBatchHeaderHashWith)
+}
+
+func verifyHash(hash [32]byte, suites []HashSuite, hashFn func(HashSuite) ([32]byte, error)) (HashSuite, bool, error) {
+	if len(suites) == 0 {
+		for _, name := range HashSuiteNames() {
+			suites = append(suites, hashSuites[name])
+		}
+	}
+	for _, suite := range suites {
+		computed, err := hashFn(suite)
+		if err != nil {
+			return nil, false, err
+		}
+		if computed == hash {
+			return suite, true, nil
+		}
+	}
+	return nil, false, nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/gob"
 	"errors"
 	"fmt"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/wealdtech/go-merkletree"
@@ -16,16 +17,21 @@ var ErrInvalidCommitment = errors.New("invalid commitment")
 
 // SetBatchRoot sets the BatchRoot field of the BatchHeader to the Merkle root of the blob headers in the batch (i.e. the root of the Merkle tree whose leaves are the blob headers)
 func (h *BatchHeader) SetBatchRoot(blobHeaders []*BlobHeader) (*merkletree.MerkleTree, error) {
+	return h.SetBatchRootWith(blobHeaders, Keccak256Suite)
+}
+
+// SetBatchRootWith is SetBatchRoot with the blob header hashes and the tree computed using suite
+func (h *BatchHeader) SetBatchRootWith(blobHeaders []*BlobHeader, suite HashSuite) (*merkletree.MerkleTree, error) {
 	leafs := make([][]byte, len(blobHeaders))
 	for i, header := range blobHeaders {
-		leaf, err := header.GetBlobHeaderHash()
+		leaf, err := header.GetBlobHeaderHashWith(suite)
 		if err != nil {
 			return nil, fmt.Errorf("failed to compute blob header hash: %w", err)
 		}
 		leafs[i] = leaf[:]
 	}
 
-	tree, err := merkletree.NewTree(merkletree.WithData(leafs), merkletree.WithHashType(keccak256.New()))
+	tree, err := merkletree.NewTree(merkletree.WithData(leafs), merkletree.WithHashType(suite))
 	if err != nil {
 		return nil, err
 	}
@@ -34,6 +40,47 @@ func (h *BatchHeader) SetBatchRoot(blobHeaders []*BlobHeader) (*merkletree.Merkl
 	return tree, nil
 }
 
+// BatchInclusionProofs returns the inclusion proofs of the blob headers against the batch
+// root set by SetBatchRootWith, by position. Unlike MerkleTree.GenerateProof, which looks
+// the leaf up by value, the proof of a header appearing twice in a batch is that of its
+// own index.
+func BatchInclusionProofs(blobHeaders []*BlobHeader, suite HashSuite) ([]*merkletree.Proof, error) {
+	if len(blobHeaders) == 0 {
+		return nil, errors.New("no blob headers to prove")
+	}
+	// same layout as merkletree.NewTree: the root at 1, leaves padded with zero hashes
+	// to a power of two
+	width := 1
+	for width < len(blobHeaders) {
+		width *= 2
+	}
+	nodes := make([][]byte, 2*width)
+	for i := range nodes[width:] {
+		if i >= len(blobHeaders) {
+			nodes[width+i] = make([]byte, suite.HashLength())
+			continue
+		}
+		leaf, err := blobHeaders[i].GetBlobHeaderHashWith(suite)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute blob header hash: %w", err)
+		}
+		nodes[width+i] = suite.Hash(leaf[:])
+	}
+	for i := width - 1; i > 0; i-- {
+		nodes[i] = suite.Hash(nodes[2*i], nodes[2*i+1])
+	}
+
+	proofs := make([]*merkletree.Proof, len(blobHeaders))
+	for index := range blobHeaders {
+		hashes := make([][]byte, 0)
+		for node := index + width; node > 1; node /= 2 {
+			hashes = append(hashes, nodes[node^1])
+		}
+		proofs[index] = &merkletree.Proof{Hashes: hashes, Index: uint64(index)}
+	}
+	return proofs, nil
+}
+
 func (h *BatchHeader) Encode() ([]byte, error) {
 	// The order here has to match the field ordering of ReducedBatchHeader defined in IZGDAServiceManager.sol
 	// ref: https://github.com/0glabs/0g-da-client/blob/master/contracts/src/interfaces/IZGDAServiceManager.sol#L43
@@ -76,17 +123,17 @@ func (h *BatchHeader) Encode() ([]byte, error) {
 // GetBatchHeaderHash returns the hash of the reduced BatchHeader that is used to sign the Batch
 // ref: https://github.com/0glabs/0g-da-client/blob/master/contracts/src/libraries/ZGDAHasher.sol#L65
 func (h BatchHeader) GetBatchHeaderHash() ([32]byte, error) {
+	return h.GetBatchHeaderHashWith(Keccak256Suite)
+}
+
+// GetBatchHeaderHashWith is GetBatchHeaderHash computed using suite
+func (h BatchHeader) GetBatchHeaderHashWith(suite HashSuite) ([32]byte, error) {
 	headerByte, err := h.Encode()
 	if err != nil {
 		return [32]byte{}, err
 	}
 
-	var headerHash [32]byte
-	hasher := sha3.NewLegacyKeccak256()
-	hasher.Write(headerByte)
-	copy(headerHash[:], hasher.Sum(nil)[:32])
-
-	return headerHash, nil
+	return hashWith(suite, headerByte), nil
 }
 
 func (h *BlobHeader) SetCommitmentRoot(commitments []Commitment) error {
@@ -116,17 +163,17 @@ func GetCommitmentHash(commitment Commitment) [32]byte {
 
 // GetBlobHeaderHash returns the hash of the BlobHeader that is used to sign the Blob
 func (h BlobHeader) GetBlobHeaderHash() ([32]byte, error) {
+	return h.GetBlobHeaderHashWith(Keccak256Suite)
+}
+
+// GetBlobHeaderHashWith is GetBlobHeaderHash computed using suite
+func (h BlobHeader) GetBlobHeaderHashWith(suite HashSuite) ([32]byte, error) {
 	headerByte, err := h.Encode()
 	if err != nil {
 		return [32]byte{}, err
 	}
 
-	var headerHash [32]byte
-	hasher := sha3.NewLegacyKeccak256()
-	hasher.Write(headerByte)
-	copy(headerHash[:], hasher.Sum(nil)[:32])
-
-	return headerHash, nil
+	return hashWith(suite, headerByte), nil
 }
 
 func (h *BlobHeader) GetQuorumBlobParamsHash() ([32]byte, error) {
@@ -226,3 +273,65 @@ func Decode(data []byte, obj any) error {
 	}
 	return nil
 }
+
+// SignatureMessage is the message the operators sign for a blob, binding its storage root
+// and erasure commitment to the epoch and the quorum it is dispersed in
+func SignatureMessage(dataRoot [32]byte, epoch, quorumId *big.Int, erasureCommitment *G1Point) ([32]byte, error) {
+	dataType, err := abi.NewType("tuple", "", []abi.ArgumentMarshaling{
+		{
+			Name: "dataRoot",
+			Type: "bytes32",
+		},
+		{
+			Name: "epoch",
+			Type: "uint256",
+		},
+		{
+			Name: "quorumId",
+			Type: "uint256",
+		},
+		{
+			Name: "X",
+			Type: "uint256",
+		},
+		{
+			Name: "Y",
+			Type: "uint256",
+		},
+	})
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	arguments := abi.Arguments{
+		{
+			Type: dataType,
+		},
+	}
+
+	o := struct {
+		DataRoot [32]byte
+		Epoch    *big.Int
+		QuorumId *big.Int
+		X        *big.Int
+		Y        *big.Int
+	}{
+		DataRoot: dataRoot,
+		Epoch:    epoch,
+		QuorumId: quorumId,
+		X:        erasureCommitment.X.BigInt(new(big.Int)),
+		Y:        erasureCommitment.Y.BigInt(new(big.Int)),
+	}
+
+	bytes, err := arguments.Pack(o)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	var headerHash [32]byte
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write(bytes)
+	copy(headerHash[:], hasher.Sum(nil)[:32])
+
+	return headerHash, nil
+}
@@ -0,0 +1,262 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/wealdtech/go-merkletree"
+)
+
+var fuzzHashSuites = []HashSuite{Keccak256Suite, SHA256Suite, SHA3_256Suite, Blake2b256Suite}
+
+// fuzzBlobHeaders splits data into the commitment roots of at most 64 blob headers
+func fuzzBlobHeaders(data []byte, rootLength uint8) []*BlobHeader {
+	size := int(rootLength)%64 + 1
+	headers := make([]*BlobHeader, 0)
+	for len(data) > 0 && len(headers) < 64 {
+		n := size
+		if n > len(data) {
+			n = len(data)
+		}
+		headers = append(headers, &BlobHeader{CommitmentRoot: data[:n], Length: uint(len(headers))})
+		data = data[n:]
+	}
+	return headers
+}
+
+// FuzzBatchInclusionProofs checks the inclusion proofs of BatchInclusionProofs against the
+// batch root of SetBatchRootWith and the proofs of the merkletree package, for every suite
+func FuzzBatchInclusionProofs(f *testing.F) {
+	f.Add(bytes.Repeat([]byte{1}, 64), uint8(31), uint8(0))
+	f.Add([]byte{0}, uint8(0), uint8(0))
+	f.Add(bytes.Repeat([]byte{7}, 5*32), uint8(31), uint8(3))
+
+	f.Fuzz(func(t *testing.T, data []byte, rootLength uint8, duplicate uint8) {
+		headers := fuzzBlobHeaders(data, rootLength)
+		if len(headers) == 0 {
+			return
+		}
+		// a blob dispersed twice lands in the batch with the same header
+		if d := int(duplicate) % len(headers); d > 0 {
+			headers[d] = headers[0]
+		}
+
+		for _, suite := range fuzzHashSuites {
+			var batchHeader BatchHeader
+			tree, err := batchHeader.SetBatchRootWith(headers, suite)
+			if err != nil {
+				t.Fatalf("%s: failed to set batch root: %v", suite.Name(), err)
+			}
+			proofs, err := BatchInclusionProofs(headers, suite)
+			if err != nil {
+				t.Fatalf("%s: failed to generate proofs: %v", suite.Name(), err)
+			}
+			if len(proofs) != len(headers) {
+				t.Fatalf("%s: got %d proofs for %d headers", suite.Name(), len(proofs), len(headers))
+			}
+
+			firstIndex := make(map[string]int)
+			for i, header := range headers {
+				leaf, err := header.GetBlobHeaderHashWith(suite)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if proofs[i].Index != uint64(i) {
+					t.Fatalf("%s: proof of blob %d has index %d", suite.Name(), i, proofs[i].Index)
+				}
+				ok, err := merkletree.VerifyProofUsing(leaf[:], false, proofs[i], [][]byte{batchHeader.BatchRoot[:]}, suite)
+				if err != nil || !ok {
+					t.Fatalf("%s: proof of blob %d doesn't verify: %v", suite.Name(), i, err)
+				}
+
+				// the merkletree package proves the first leaf of a value only
+				if _, ok := firstIndex[string(leaf[:])]; !ok {
+					firstIndex[string(leaf[:])] = i
+					expected, err := tree.GenerateProof(leaf[:], 0)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if expected.Index != proofs[i].Index || !equalHashes(expected.Hashes, proofs[i].Hashes) {
+						t.Fatalf("%s: proof of blob %d differs from the merkletree package", suite.Name(), i)
+					}
+				}
+
+				// a tampered proof must not verify
+				if len(proofs[i].Hashes) > 0 {
+					tampered := &merkletree.Proof{Hashes: make([][]byte, len(proofs[i].Hashes)), Index: proofs[i].Index}
+					copy(tampered.Hashes, proofs[i].Hashes)
+					tampered.Hashes[0] = append([]byte{}, tampered.Hashes[0]...)
+					tampered.Hashes[0][0] ^= 1
+					if ok, _ := merkletree.VerifyProofUsing(leaf[:], false, tampered, [][]byte{batchHeader.BatchRoot[:]}, suite); ok {
+						t.Fatalf("%s: tampered proof of blob %d verifies", suite.Name(), i)
+					}
+				}
+			}
+		}
+	})
+}
+
+// FuzzVerifyInclusionProof verifies the inclusion proof of a serialized blob header, given
+// as the concatenation of its sibling hashes, against a batch root. It must not panic, and
+// a proof that verifies must no longer verify once the header or the proof is tampered with.
+func FuzzVerifyInclusionProof(f *testing.F) {
+	headers := []*BlobHeader{
+		{CommitmentRoot: bytes.Repeat([]byte{1}, 64), Length: 1},
+		{CommitmentRoot: bytes.Repeat([]byte{2}, 64), Length: 32},
+	}
+	var batchHeader BatchHeader
+	if _, err := batchHeader.SetBatchRoot(headers); err != nil {
+		f.Fatal(err)
+	}
+	proofs, err := BatchInclusionProofs(headers, Keccak256Suite)
+	if err != nil {
+		f.Fatal(err)
+	}
+	for i, header := range headers {
+		data, err := header.Serialize()
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data, bytes.Join(proofs[i].Hashes, nil), uint64(i), batchHeader.BatchRoot[:], Keccak256Suite.Name())
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte, proof []byte, index uint64, root []byte, suiteName string) {
+		suite, err := GetHashSuite(suiteName)
+		if err != nil {
+			return
+		}
+		header, err := new(BlobHeader).Deserialize(data)
+		if err != nil {
+			return
+		}
+		leaf, err := header.GetBlobHeaderHashWith(suite)
+		if err != nil {
+			return
+		}
+		hashLength := suite.HashLength()
+		if len(proof)%hashLength != 0 || len(proof)/hashLength >= 64 {
+			return
+		}
+		hashes := make([][]byte, 0, len(proof)/hashLength)
+		for i := 0; i < len(proof); i += hashLength {
+			hashes = append(hashes, proof[i:i+hashLength])
+		}
+		ok, err := merkletree.VerifyProofUsing(leaf[:], false, &merkletree.Proof{Hashes: hashes, Index: index}, [][]byte{root}, suite)
+		if err != nil || !ok {
+			return
+		}
+
+		if len(header.CommitmentRoot) > 0 {
+			tampered := &BlobHeader{CommitmentRoot: append([]byte{}, header.CommitmentRoot...), Length: header.Length}
+			tampered.CommitmentRoot[0] ^= 1
+			if tamperedLeaf, err := tampered.GetBlobHeaderHashWith(suite); err == nil {
+				if ok, _ := merkletree.VerifyProofUsing(tamperedLeaf[:], false, &merkletree.Proof{Hashes: hashes, Index: index}, [][]byte{root}, suite); ok {
+					t.Fatalf("%s: proof verifies a tampered blob header", suite.Name())
+				}
+			}
+		}
+		if len(hashes) > 0 {
+			tampered := make([][]byte, len(hashes))
+			copy(tampered, hashes)
+			tampered[0] = append([]byte{}, hashes[0]...)
+			tampered[0][0] ^= 1
+			if ok, _ := merkletree.VerifyProofUsing(leaf[:], false, &merkletree.Proof{Hashes: tampered, Index: index}, [][]byte{root}, suite); ok {
+				t.Fatalf("%s: tampered proof verifies", suite.Name())
+			}
+		}
+	})
+}
+
+func equalHashes(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// FuzzBlobHeaderDeserialize feeds arbitrary bytes to the decoding and hashing of blob
+// headers, which must fail without panicking and round trip when they succeed
+func FuzzBlobHeaderDeserialize(f *testing.F) {
+	for _, header := range []*BlobHeader{
+		{CommitmentRoot: bytes.Repeat([]byte{1}, 32), Length: 16},
+		{CommitmentRoot: []byte{}, Length: 0},
+	} {
+		data, err := header.Serialize()
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		header, err := new(BlobHeader).Deserialize(data)
+		if err != nil {
+			return
+		}
+		hashes := make([][32]byte, len(fuzzHashSuites))
+		for i, suite := range fuzzHashSuites {
+			hashes[i], err = header.GetBlobHeaderHashWith(suite)
+			if errors.Is(err, ErrInvalidCommitment) {
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s: failed to hash blob header: %v", suite.Name(), err)
+			}
+		}
+
+		serialized, err := header.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := new(BlobHeader).Deserialize(serialized)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, suite := range fuzzHashSuites {
+			hash, err := decoded.GetBlobHeaderHashWith(suite)
+			if err != nil || hash != hashes[i] {
+				t.Fatalf("%s: blob header hash changed over a round trip", suite.Name())
+			}
+		}
+	})
+}
+
+// FuzzBatchHeaderDeserialize is FuzzBlobHeaderDeserialize for batch headers
+func FuzzBatchHeaderDeserialize(f *testing.F) {
+	header := &BatchHeader{BatchRoot: [32]byte{1, 2, 3}}
+	data, err := header.Serialize()
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(data)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		header, err := new(BatchHeader).Deserialize(data)
+		if err != nil {
+			return
+		}
+		for _, suite := range fuzzHashSuites {
+			hash, err := header.GetBatchHeaderHashWith(suite)
+			if err != nil {
+				t.Fatalf("%s: failed to hash batch header: %v", suite.Name(), err)
+			}
+			serialized, err := header.Serialize()
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := new(BatchHeader).Deserialize(serialized)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rehashed, err := decoded.GetBatchHeaderHashWith(suite); err != nil || rehashed != hash {
+				t.Fatalf("%s: batch header hash changed over a round trip", suite.Name())
+			}
+		}
+	})
+}
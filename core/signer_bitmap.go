@@ -0,0 +1,34 @@
+package core
+
+// SignerBitmap records which signers of a quorum signed a batch. Bit i, the bit 1<<(i%8) of
+// byte i/8 like the slice bitmaps of CommitRootSubmission, stands for the i-th distinct
+// signer in the quorum returned by the DA signers contract for the epoch of the batch.
+type SignerBitmap []byte
+
+// NewSignerBitmap returns an empty bitmap for a quorum of n signers
+func NewSignerBitmap(n int) SignerBitmap {
+	return make(SignerBitmap, (n+7)/8)
+}
+
+// Set marks the signer at index i as signed
+func (b SignerBitmap) Set(i int) {
+	if i >= 0 && i/8 < len(b) {
+		b[i/8] |= 1 << (i % 8)
+	}
+}
+
+// Signed returns whether the signer at index i signed
+func (b SignerBitmap) Signed(i int) bool {
+	return i >= 0 && i/8 < len(b) && b[i/8]&(1<<(i%8)) != 0
+}
+
+// Count returns the number of signers that signed
+func (b SignerBitmap) Count() int {
+	n := 0
+	for _, v := range b {
+		for ; v != 0; v &= v - 1 {
+			n++
+		}
+	}
+	return n
+}
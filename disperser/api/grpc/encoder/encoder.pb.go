@@ -156,6 +156,102 @@ func (x *EncodeBlobReply) GetEncodedSlice() [][]byte {
 	return nil
 }
 
+// EncodeBlobsRequest batches the requests of several small blobs
+type EncodeBlobsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Requests []*EncodeBlobRequest `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
+}
+
+func (x *EncodeBlobsRequest) Reset() {
+	*x = EncodeBlobsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_encoder_encoder_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EncodeBlobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EncodeBlobsRequest) ProtoMessage() {}
+
+func (x *EncodeBlobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_encoder_encoder_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EncodeBlobsRequest.ProtoReflect.Descriptor instead.
+func (*EncodeBlobsRequest) Descriptor() ([]byte, []int) {
+	return file_encoder_encoder_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *EncodeBlobsRequest) GetRequests() []*EncodeBlobRequest {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+// EncodeBlobsReply holds the replies in the order of the requests
+type EncodeBlobsReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Replies []*EncodeBlobReply `protobuf:"bytes,1,rep,name=replies,proto3" json:"replies,omitempty"`
+}
+
+func (x *EncodeBlobsReply) Reset() {
+	*x = EncodeBlobsReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_encoder_encoder_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EncodeBlobsReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EncodeBlobsReply) ProtoMessage() {}
+
+func (x *EncodeBlobsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_encoder_encoder_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EncodeBlobsReply.ProtoReflect.Descriptor instead.
+func (*EncodeBlobsReply) Descriptor() ([]byte, []int) {
+	return file_encoder_encoder_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *EncodeBlobsReply) GetReplies() []*EncodeBlobReply {
+	if x != nil {
+		return x.Replies
+	}
+	return nil
+}
+
 var File_encoder_encoder_proto protoreflect.FileDescriptor
 
 var file_encoder_encoder_proto_rawDesc = []byte{
@@ -178,11 +274,25 @@ var file_encoder_encoder_proto_rawDesc = []byte{
 	0x28, 0x0c, 0x52, 0x0b, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x64, 0x44, 0x61, 0x74, 0x61, 0x12,
 	0x23, 0x0a, 0x0d, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x64, 0x5f, 0x73, 0x6c, 0x69, 0x63, 0x65,
 	0x18, 0x05, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x0c, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x64, 0x53,
-	0x6c, 0x69, 0x63, 0x65, 0x32, 0x4f, 0x0a, 0x07, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x72, 0x12,
-	0x44, 0x0a, 0x0a, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x42, 0x6c, 0x6f, 0x62, 0x12, 0x1a, 0x2e,
-	0x65, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x72, 0x2e, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x42, 0x6c,
-	0x6f, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x65, 0x6e, 0x63, 0x6f,
-	0x64, 0x65, 0x72, 0x2e, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x42, 0x6c, 0x6f, 0x62, 0x52, 0x65,
+	0x6c, 0x69, 0x63, 0x65, 0x22, 0x4c, 0x0a, 0x12, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x42, 0x6c,
+	0x6f, 0x62, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x36, 0x0a, 0x08, 0x72, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x65,
+	0x6e, 0x63, 0x6f, 0x64, 0x65, 0x72, 0x2e, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x42, 0x6c, 0x6f,
+	0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x08, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x73, 0x22, 0x46, 0x0a, 0x10, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x42, 0x6c, 0x6f, 0x62,
+	0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x32, 0x0a, 0x07, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x65,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x65,
+	0x72, 0x2e, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x42, 0x6c, 0x6f, 0x62, 0x52, 0x65, 0x70, 0x6c,
+	0x79, 0x52, 0x07, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x65, 0x73, 0x32, 0x98, 0x01, 0x0a, 0x07, 0x45,
+	0x6e, 0x63, 0x6f, 0x64, 0x65, 0x72, 0x12, 0x44, 0x0a, 0x0a, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x65,
+	0x42, 0x6c, 0x6f, 0x62, 0x12, 0x1a, 0x2e, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x72, 0x2e, 0x45,
+	0x6e, 0x63, 0x6f, 0x64, 0x65, 0x42, 0x6c, 0x6f, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x18, 0x2e, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x72, 0x2e, 0x45, 0x6e, 0x63, 0x6f, 0x64,
+	0x65, 0x42, 0x6c, 0x6f, 0x62, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x47, 0x0a, 0x0b,
+	0x45, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x42, 0x6c, 0x6f, 0x62, 0x73, 0x12, 0x1b, 0x2e, 0x65, 0x6e,
+	0x63, 0x6f, 0x64, 0x65, 0x72, 0x2e, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x42, 0x6c, 0x6f, 0x62,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x65, 0x6e, 0x63, 0x6f, 0x64,
+	0x65, 0x72, 0x2e, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x42, 0x6c, 0x6f, 0x62, 0x73, 0x52, 0x65,
 	0x70, 0x6c, 0x79, 0x22, 0x00, 0x42, 0x31, 0x5a, 0x2f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e,
 	0x63, 0x6f, 0x6d, 0x2f, 0x30, 0x67, 0x6c, 0x61, 0x62, 0x73, 0x2f, 0x30, 0x67, 0x2d, 0x64, 0x61,
 	0x2d, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x67, 0x72, 0x70, 0x63,
@@ -201,19 +311,25 @@ func file_encoder_encoder_proto_rawDescGZIP() []byte {
 	return file_encoder_encoder_proto_rawDescData
 }
 
-var file_encoder_encoder_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_encoder_encoder_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
 var file_encoder_encoder_proto_goTypes = []interface{}{
-	(*EncodeBlobRequest)(nil), // 0: encoder.EncodeBlobRequest
-	(*EncodeBlobReply)(nil),   // 1: encoder.EncodeBlobReply
+	(*EncodeBlobRequest)(nil),  // 0: encoder.EncodeBlobRequest
+	(*EncodeBlobReply)(nil),    // 1: encoder.EncodeBlobReply
+	(*EncodeBlobsRequest)(nil), // 2: encoder.EncodeBlobsRequest
+	(*EncodeBlobsReply)(nil),   // 3: encoder.EncodeBlobsReply
 }
 var file_encoder_encoder_proto_depIdxs = []int32{
-	0, // 0: encoder.Encoder.EncodeBlob:input_type -> encoder.EncodeBlobRequest
-	1, // 1: encoder.Encoder.EncodeBlob:output_type -> encoder.EncodeBlobReply
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	0, // 0: encoder.EncodeBlobsRequest.requests:type_name -> encoder.EncodeBlobRequest
+	1, // 1: encoder.EncodeBlobsReply.replies:type_name -> encoder.EncodeBlobReply
+	0, // 2: encoder.Encoder.EncodeBlob:input_type -> encoder.EncodeBlobRequest
+	2, // 3: encoder.Encoder.EncodeBlobs:input_type -> encoder.EncodeBlobsRequest
+	1, // 4: encoder.Encoder.EncodeBlob:output_type -> encoder.EncodeBlobReply
+	3, // 5: encoder.Encoder.EncodeBlobs:output_type -> encoder.EncodeBlobsReply
+	4, // [4:6] is the sub-list for method output_type
+	2, // [2:4] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_encoder_encoder_proto_init() }
@@ -246,6 +362,30 @@ func file_encoder_encoder_proto_init() {
 				return nil
 			}
 		}
+		file_encoder_encoder_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EncodeBlobsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_encoder_encoder_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EncodeBlobsReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -253,7 +393,7 @@ func file_encoder_encoder_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_encoder_encoder_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   4,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
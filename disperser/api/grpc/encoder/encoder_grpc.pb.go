@@ -23,6 +23,7 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type EncoderClient interface {
 	EncodeBlob(ctx context.Context, in *EncodeBlobRequest, opts ...grpc.CallOption) (*EncodeBlobReply, error)
+	EncodeBlobs(ctx context.Context, in *EncodeBlobsRequest, opts ...grpc.CallOption) (*EncodeBlobsReply, error)
 }
 
 type encoderClient struct {
@@ -42,11 +43,21 @@ func (c *encoderClient) EncodeBlob(ctx context.Context, in *EncodeBlobRequest, o
 	return out, nil
 }
 
+func (c *encoderClient) EncodeBlobs(ctx context.Context, in *EncodeBlobsRequest, opts ...grpc.CallOption) (*EncodeBlobsReply, error) {
+	out := new(EncodeBlobsReply)
+	err := c.cc.Invoke(ctx, "/encoder.Encoder/EncodeBlobs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // EncoderServer is the server API for Encoder service.
 // All implementations must embed UnimplementedEncoderServer
 // for forward compatibility
 type EncoderServer interface {
 	EncodeBlob(context.Context, *EncodeBlobRequest) (*EncodeBlobReply, error)
+	EncodeBlobs(context.Context, *EncodeBlobsRequest) (*EncodeBlobsReply, error)
 	mustEmbedUnimplementedEncoderServer()
 }
 
@@ -57,6 +68,9 @@ type UnimplementedEncoderServer struct {
 func (UnimplementedEncoderServer) EncodeBlob(context.Context, *EncodeBlobRequest) (*EncodeBlobReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method EncodeBlob not implemented")
 }
+func (UnimplementedEncoderServer) EncodeBlobs(context.Context, *EncodeBlobsRequest) (*EncodeBlobsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EncodeBlobs not implemented")
+}
 func (UnimplementedEncoderServer) mustEmbedUnimplementedEncoderServer() {}
 
 // UnsafeEncoderServer may be embedded to opt out of forward compatibility for this service.
@@ -88,6 +102,24 @@ func _Encoder_EncodeBlob_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Encoder_EncodeBlobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncodeBlobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EncoderServer).EncodeBlobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/encoder.Encoder/EncodeBlobs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EncoderServer).EncodeBlobs(ctx, req.(*EncodeBlobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Encoder_ServiceDesc is the grpc.ServiceDesc for Encoder service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -99,6 +131,10 @@ var Encoder_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "EncodeBlob",
 			Handler:    _Encoder_EncodeBlob_Handler,
 		},
+		{
+			MethodName: "EncodeBlobs",
+			Handler:    _Encoder_EncodeBlobs_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "encoder/encoder.proto",
@@ -23,6 +23,12 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type SignerClient interface {
 	BatchSign(ctx context.Context, in *BatchSignRequest, opts ...grpc.CallOption) (*BatchSignReply, error)
+	// GetSlice returns an encoded slice the signer stored for a blob it signed, NOT_FOUND if
+	// it doesn't hold it. Signers serving it advertise the slice-reads feature.
+	GetSlice(ctx context.Context, in *GetSliceRequest, opts ...grpc.CallOption) (*GetSliceReply, error)
+	// ProbeBandwidth receives a payload the disperser times the upload of, and replies with
+	// its size. Signers serving it advertise the bandwidth-probes feature.
+	ProbeBandwidth(ctx context.Context, in *ProbeRequest, opts ...grpc.CallOption) (*ProbeReply, error)
 }
 
 type signerClient struct {
@@ -42,11 +48,35 @@ func (c *signerClient) BatchSign(ctx context.Context, in *BatchSignRequest, opts
 	return out, nil
 }
 
+func (c *signerClient) GetSlice(ctx context.Context, in *GetSliceRequest, opts ...grpc.CallOption) (*GetSliceReply, error) {
+	out := new(GetSliceReply)
+	err := c.cc.Invoke(ctx, "/signer.Signer/GetSlice", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *signerClient) ProbeBandwidth(ctx context.Context, in *ProbeRequest, opts ...grpc.CallOption) (*ProbeReply, error) {
+	out := new(ProbeReply)
+	err := c.cc.Invoke(ctx, "/signer.Signer/ProbeBandwidth", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SignerServer is the server API for Signer service.
 // All implementations must embed UnimplementedSignerServer
 // for forward compatibility
 type SignerServer interface {
 	BatchSign(context.Context, *BatchSignRequest) (*BatchSignReply, error)
+	// GetSlice returns an encoded slice the signer stored for a blob it signed, NOT_FOUND if
+	// it doesn't hold it. Signers serving it advertise the slice-reads feature.
+	GetSlice(context.Context, *GetSliceRequest) (*GetSliceReply, error)
+	// ProbeBandwidth receives a payload the disperser times the upload of, and replies with
+	// its size. Signers serving it advertise the bandwidth-probes feature.
+	ProbeBandwidth(context.Context, *ProbeRequest) (*ProbeReply, error)
 	mustEmbedUnimplementedSignerServer()
 }
 
@@ -57,6 +87,12 @@ type UnimplementedSignerServer struct {
 func (UnimplementedSignerServer) BatchSign(context.Context, *BatchSignRequest) (*BatchSignReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method BatchSign not implemented")
 }
+func (UnimplementedSignerServer) GetSlice(context.Context, *GetSliceRequest) (*GetSliceReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSlice not implemented")
+}
+func (UnimplementedSignerServer) ProbeBandwidth(context.Context, *ProbeRequest) (*ProbeReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProbeBandwidth not implemented")
+}
 func (UnimplementedSignerServer) mustEmbedUnimplementedSignerServer() {}
 
 // UnsafeSignerServer may be embedded to opt out of forward compatibility for this service.
@@ -88,6 +124,42 @@ func _Signer_BatchSign_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Signer_GetSlice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSliceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SignerServer).GetSlice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/signer.Signer/GetSlice",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SignerServer).GetSlice(ctx, req.(*GetSliceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Signer_ProbeBandwidth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProbeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SignerServer).ProbeBandwidth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/signer.Signer/ProbeBandwidth",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SignerServer).ProbeBandwidth(ctx, req.(*ProbeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Signer_ServiceDesc is the grpc.ServiceDesc for Signer service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -99,6 +171,14 @@ var Signer_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "BatchSign",
 			Handler:    _Signer_BatchSign_Handler,
 		},
+		{
+			MethodName: "GetSlice",
+			Handler:    _Signer_GetSlice_Handler,
+		},
+		{
+			MethodName: "ProbeBandwidth",
+			Handler:    _Signer_ProbeBandwidth_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "signer/signer.proto",
@@ -0,0 +1,40 @@
+package apiserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/0glabs/0g-da-client/disperser"
+)
+
+const (
+	// the admin APIs serve small requests, a client slower than these holds a connection
+	// for nothing
+	adminReadHeaderTimeout = 5 * time.Second
+	adminReadTimeout       = 10 * time.Second
+	adminWriteTimeout      = time.Minute
+	adminIdleTimeout       = 2 * time.Minute
+)
+
+// serveAdmin serves the handler of an admin API on the local port until ctx is done
+func serveAdmin(ctx context.Context, port string, handler http.Handler) error {
+	server := &http.Server{
+		Addr:              fmt.Sprintf("%s:%s", disperser.Localhost, port),
+		Handler:           handler,
+		ReadHeaderTimeout: adminReadHeaderTimeout,
+		ReadTimeout:       adminReadTimeout,
+		WriteTimeout:      adminWriteTimeout,
+		IdleTimeout:       adminIdleTimeout,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = server.Shutdown(context.Background())
+	}()
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,520 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/certificate"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const proofHashLength = 32
+
+var errBatchNotFound = errors.New("batch not found")
+
+// The certificates served by the batch endpoints, see the certificate package
+type (
+	BatchStatus       = certificate.BatchStatus
+	ConfirmationVenue = certificate.ConfirmationVenue
+	Retention         = certificate.Retention
+	QuorumResult      = certificate.QuorumResult
+	BlobCertificate   = certificate.BlobCertificate
+	BatchMultiProof   = certificate.BatchMultiProof
+	BatchCertificate  = certificate.BatchCertificate
+)
+
+func confirmationVenue(venue *disperser.ConfirmationVenue) *ConfirmationVenue {
+	if venue == nil {
+		return nil
+	}
+	return &ConfirmationVenue{ChainID: venue.ChainID, Contract: venue.Contract.Bytes(), Fallback: venue.Fallback}
+}
+
+func venueReply(venue *disperser.ConfirmationVenue) *pb.ConfirmationVenue {
+	if venue == nil {
+		return nil
+	}
+	return &pb.ConfirmationVenue{ChainId: venue.ChainID, Contract: venue.Contract.Bytes(), Fallback: venue.Fallback}
+}
+
+// BlobListing is a confirmed blob of a batch with the tags it was dispersed with
+type BlobListing struct {
+	RequestID       string            `json:"request_id"`
+	BatchHeaderHash hexutil.Bytes     `json:"batch_header_hash"`
+	BlobIndex       uint32            `json:"blob_index"`
+	DataRoot        hexutil.Bytes     `json:"data_root"`
+	Status          string            `json:"status"`
+	Tags            map[string]string `json:"tags,omitempty"`
+}
+
+// BlobList lists the blobs of a batch matching the tag filter of the request
+type BlobList struct {
+	Blobs []*BlobListing `json:"blobs"`
+}
+
+// getBatchMetadata returns the metadata of the confirmed blobs of a batch ordered by blob
+// index. The blobs finalized and removed from the blob store are read from the kv store. The
+// staleness is set if the metadata was read from the replica.
+func (s *DispersalServer) getBatchMetadata(ctx context.Context, batchHeaderHash [32]byte) ([]*disperser.BlobMetadata, *time.Duration, error) {
+	metadatas, staleness, fromReplica, err := s.getAllBlobMetadataByBatch(ctx, batchHeaderHash)
+	if err != nil {
+		s.logger.Debug("[apiserver] failed to get batch metadata", "err", err)
+	}
+
+	confirmed := make([]*disperser.BlobMetadata, 0, len(metadatas))
+	indices := make(map[uint32]bool, len(metadatas))
+	for _, metadata := range metadatas {
+		if ok, _ := metadata.IsConfirmed(); ok {
+			confirmed = append(confirmed, metadata)
+			indices[metadata.ConfirmationInfo.BlobIndex] = true
+		}
+	}
+	finalized, err := s.getFinalizedBatchMetadata(ctx, batchHeaderHash)
+	if err != nil {
+		s.logger.Warn("[apiserver] failed to get the finalized blobs of the batch from kv", "err", err)
+	}
+	for _, metadata := range finalized {
+		if !indices[metadata.ConfirmationInfo.BlobIndex] {
+			confirmed = append(confirmed, metadata)
+		}
+	}
+	if len(confirmed) == 0 {
+		return nil, nil, errBatchNotFound
+	}
+	sort.Slice(confirmed, func(i, j int) bool {
+		return confirmed[i].ConfirmationInfo.BlobIndex < confirmed[j].ConfirmationInfo.BlobIndex
+	})
+	if !fromReplica {
+		return confirmed, nil, nil
+	}
+	return confirmed, &staleness, nil
+}
+
+// getFinalizedBatchMetadata returns the metadata of the blobs of a batch finalized to the kv
+// store with their confirmation info, which outlive their metadata in the blob store
+func (s *DispersalServer) getFinalizedBatchMetadata(ctx context.Context, batchHeaderHash [32]byte) ([]*disperser.BlobMetadata, error) {
+	if s.kvStore == nil {
+		return nil, nil
+	}
+	keys, err := s.kvStore.GetBatchBlobKeys(ctx, batchHeaderHash)
+	if err != nil {
+		return nil, err
+	}
+	metadatas := make([]*disperser.BlobMetadata, 0, len(keys))
+	for _, key := range keys {
+		confirmation, err := s.getConfirmationFromKv(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if confirmation == nil || confirmation.Info == nil {
+			continue
+		}
+		metadatas = append(metadatas, finalizedMetadata(key, confirmation))
+	}
+	return metadatas, nil
+}
+
+// finalizedMetadata returns the metadata of a blob finalized to the kv store under key, with
+// the confirmation info and tags of its confirmation
+func finalizedMetadata(key []byte, confirmation *disperser.BlobConfirmation) *disperser.BlobMetadata {
+	metadata := withTags(&disperser.BlobMetadata{
+		BlobStatus:       disperser.Finalized,
+		ConfirmationInfo: confirmation.Info,
+	}, confirmation.Tags)
+	if blobKey, err := disperser.ParseBlobKey(string(key)); err == nil {
+		metadata.BlobHash, metadata.MetadataHash = blobKey.BlobHash, blobKey.MetadataHash
+	}
+	return metadata
+}
+
+func batchStatus(metadatas []*disperser.BlobMetadata) BatchStatus {
+	info := metadatas[0].ConfirmationInfo
+	status := disperser.Finalized
+	for _, metadata := range metadatas {
+		if metadata.BlobStatus != disperser.Finalized {
+			status = disperser.Confirmed
+			break
+		}
+	}
+
+	return BatchStatus{
+		BatchHeaderHash:         info.BatchHeaderHash[:],
+		BatchID:                 info.BatchID,
+		BatchRoot:               info.BatchRoot,
+		Status:                  getResponseStatus(status).String(),
+		Epoch:                   info.Epoch,
+		QuorumId:                info.QuorumId,
+		NumBlobs:                len(metadatas),
+		SubmissionTxnHash:       info.SubmissionTxnHash,
+		ConfirmationTxnHash:     info.ConfirmationTxnHash,
+		ConfirmationBlockNumber: info.ConfirmationBlockNumber,
+		SignerBitmap:            hexutil.Bytes(info.SignerBitmap),
+		NumSigners:              info.NumSigners,
+		SignedCount:             info.SignerBitmap.Count(),
+		ReferenceBlockNumber:    info.ReferenceBlockNumber,
+		Venue:                   confirmationVenue(info.Venue),
+	}
+}
+
+func batchCertificate(metadatas []*disperser.BlobMetadata) (*BatchCertificate, error) {
+	certificate := &BatchCertificate{
+		BatchStatus: batchStatus(metadatas),
+		Blobs:       make([]*BlobCertificate, len(metadatas)),
+	}
+
+	indices := make([]uint32, len(metadatas))
+	proofs := make([][]byte, len(metadatas))
+	for i, metadata := range metadatas {
+		info := metadata.ConfirmationInfo
+		certificate.Blobs[i] = &BlobCertificate{
+			BlobIndex:      info.BlobIndex,
+			DataRoot:       info.DataRoot,
+			CommitmentRoot: info.CommitmentRoot,
+			Length:         info.Length,
+			InclusionProof: info.BlobInclusionProof,
+			QuorumResults:  quorumResults(info.QuorumResults),
+
+			AggregateSignature: info.AggregateSignature,
+			AggregatePubKey:    info.AggregatePubKey,
+		}
+		indices[i] = info.BlobIndex
+		proofs[i] = info.BlobInclusionProof
+	}
+
+	multiProof, err := buildMultiProof(indices, proofs)
+	if err != nil {
+		return nil, err
+	}
+	certificate.MultiProof = multiProof
+	return certificate, nil
+}
+
+// quorumResults lists the results by quorum ID
+func quorumResults(results map[core.QuorumID]*core.QuorumResult) []*QuorumResult {
+	if len(results) == 0 {
+		return nil
+	}
+	list := make([]*QuorumResult, 0, len(results))
+	for quorumID, result := range results {
+		list = append(list, &QuorumResult{
+			QuorumID:           quorumID,
+			PercentSigned:      result.PercentSigned,
+			AdversaryThreshold: result.AdversaryThreshold,
+			QuorumThreshold:    result.QuorumThreshold,
+			Fallback:           result.Fallback,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].QuorumID < list[j].QuorumID })
+	return list
+}
+
+// buildMultiProof merges the inclusion proofs of blobs of the same batch, dropping the
+// hashes that can be computed from the blobs themselves
+func buildMultiProof(indices []uint32, proofs [][]byte) (*BatchMultiProof, error) {
+	if len(proofs) == 0 || len(proofs) != len(indices) {
+		return nil, fmt.Errorf("expected an inclusion proof for each of the %d blobs, got %d", len(indices), len(proofs))
+	}
+	depth := -1
+	for i, proof := range proofs {
+		if len(proof)%proofHashLength != 0 || (depth >= 0 && len(proof)/proofHashLength != depth) {
+			return nil, fmt.Errorf("inclusion proof of blob %d has an unexpected length %d", indices[i], len(proof))
+		}
+		depth = len(proof) / proofHashLength
+	}
+	if depth >= 64 {
+		return nil, fmt.Errorf("inclusion proofs of depth %d are too deep", depth)
+	}
+
+	values := uint64(1) << depth
+	hashes := make(map[uint64]hexutil.Bytes)
+	calculated := make(map[uint64]bool)
+	multiProof := &BatchMultiProof{
+		Values:  values,
+		Indices: make([]uint64, len(indices)),
+	}
+	for i, index := range indices {
+		if uint64(index) >= values {
+			return nil, fmt.Errorf("blob index %d is out of range of its inclusion proof", index)
+		}
+		multiProof.Indices[i] = uint64(index)
+
+		level := 0
+		for node := uint64(index) + values; node > 1; node /= 2 {
+			hashes[node^1] = proofs[i][level*proofHashLength : (level+1)*proofHashLength]
+			calculated[node] = true
+			level++
+		}
+	}
+	for node := range hashes {
+		if calculated[node] {
+			delete(hashes, node)
+		}
+	}
+	multiProof.Hashes = hashes
+	return multiProof, nil
+}
+
+// parseBatchQuery reads the batch from the header_hash query parameter. Batches aren't looked
+// up by batch ID, which isn't unique.
+func parseBatchQuery(r *http.Request) ([32]byte, error) {
+	hash := r.URL.Query().Get("header_hash")
+	if hash == "" {
+		return [32]byte{}, errors.New("header_hash is required")
+	}
+	return parseBatchHeaderHash(hexutil.Decode(ensureHexPrefix(hash)))
+}
+
+func parseBatchHeaderHash(decoded []byte, err error) ([32]byte, error) {
+	var batchHeaderHash [32]byte
+	if err != nil || len(decoded) != 32 {
+		return batchHeaderHash, errors.New("batch header hash must be 32 bytes")
+	}
+	copy(batchHeaderHash[:], decoded)
+	return batchHeaderHash, nil
+}
+
+func ensureHexPrefix(s string) string {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return s
+	}
+	return "0x" + s
+}
+
+func (s *DispersalServer) handleBatchStatus(w http.ResponseWriter, r *http.Request) {
+	s.serveBatch(w, r, "GetBatchStatus", func(metadatas []*disperser.BlobMetadata) (interface{}, error) {
+		status := batchStatus(metadatas)
+		status.Retention = s.batchRetention(r.Context(), metadatas)
+		return status, nil
+	})
+}
+
+func (s *DispersalServer) handleListBlobs(w http.ResponseWriter, r *http.Request) {
+	filter := parseTagFilter(r)
+	s.serveBatch(w, r, "ListBlobs", func(metadatas []*disperser.BlobMetadata) (interface{}, error) {
+		return listBlobs(metadatas, filter), nil
+	})
+}
+
+func listBlobs(metadatas []*disperser.BlobMetadata, filter tagFilter) *BlobList {
+	list := &BlobList{Blobs: make([]*BlobListing, 0, len(metadatas))}
+	for _, metadata := range metadatas {
+		if !filter.matches(metadata) {
+			continue
+		}
+		list.Blobs = append(list.Blobs, &BlobListing{
+			RequestID:       metadata.GetBlobKey().String(),
+			BatchHeaderHash: metadata.ConfirmationInfo.BatchHeaderHash[:],
+			BlobIndex:       metadata.ConfirmationInfo.BlobIndex,
+			DataRoot:        metadata.ConfirmationInfo.DataRoot,
+			Status:          getResponseStatus(metadata.BlobStatus).String(),
+			Tags:            blobTags(metadata),
+		})
+	}
+	return list
+}
+
+func (s *DispersalServer) handleBatchCertificate(w http.ResponseWriter, r *http.Request) {
+	s.serveBatch(w, r, "GetBatchCertificate", func(metadatas []*disperser.BlobMetadata) (interface{}, error) {
+		certificate, err := batchCertificate(metadatas)
+		if err != nil {
+			return nil, err
+		}
+		certificate.Retention = s.batchRetention(r.Context(), metadatas)
+		return certificate, nil
+	})
+}
+
+func (s *DispersalServer) serveBatch(w http.ResponseWriter, r *http.Request, method string, build func([]*disperser.BlobMetadata) (interface{}, error)) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	batchHeaderHash, err := parseBatchQuery(r)
+	if err != nil {
+		s.metrics.HandleFailedRequest(0, method)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	metadatas, staleness, err := s.getBatchMetadata(r.Context(), batchHeaderHash)
+	if err != nil {
+		s.metrics.HandleFailedRequest(0, method)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	reply, err := build(metadatas)
+	if err != nil {
+		s.logger.Error("[apiserver] failed to build batch reply", "method", method, "err", err)
+		s.metrics.HandleFailedRequest(0, method)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.metrics.HandleSuccessfulRequest(0, method)
+	if staleness != nil {
+		w.Header().Set(StalenessHeader, formatStaleness(*staleness))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+// GetBatchStatus returns the status of a batch looked up by the hash of its header
+func (s *DispersalServer) GetBatchStatus(ctx context.Context, req *pb.BatchRequest) (*pb.BatchStatus, error) {
+	metadatas, err := s.batchRequestMetadata(ctx, "GetBatchStatus", req)
+	if err != nil {
+		return nil, err
+	}
+	reply := batchStatusReply(metadatas)
+	reply.Retention = blobRetention(s.retentionConfig(ctx, metadatas[0].ConfirmationInfo), metadatas[0].ConfirmationInfo, time.Now())
+	s.metrics.HandleSuccessfulRequest(0, "GetBatchStatus")
+	return reply, nil
+}
+
+// GetBatchCertificate returns the certificate of a batch looked up by the hash of its header
+func (s *DispersalServer) GetBatchCertificate(ctx context.Context, req *pb.BatchRequest) (*pb.BatchCertificate, error) {
+	metadatas, err := s.batchRequestMetadata(ctx, "GetBatchCertificate", req)
+	if err != nil {
+		return nil, err
+	}
+	certificate, err := batchCertificate(metadatas)
+	if err != nil {
+		s.logger.Error("[apiserver] failed to build batch certificate", "err", err)
+		s.metrics.HandleFailedRequest(0, "GetBatchCertificate")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	reply := batchCertificateReply(certificate, metadatas)
+	reply.Status.Retention = blobRetention(s.retentionConfig(ctx, metadatas[0].ConfirmationInfo), metadatas[0].ConfirmationInfo, time.Now())
+	s.metrics.HandleSuccessfulRequest(0, "GetBatchCertificate")
+	return reply, nil
+}
+
+// batchRequestMetadata returns the metadata of the confirmed blobs of the batch of a request,
+// setting the staleness header if read from the replica
+func (s *DispersalServer) batchRequestMetadata(ctx context.Context, method string, req *pb.BatchRequest) ([]*disperser.BlobMetadata, error) {
+	timer := prometheus.NewTimer(prometheus.ObserverFunc(func(f float64) {
+		s.metrics.ObserveLatency(method, f*1000) // make milliseconds
+	}))
+	defer timer.ObserveDuration()
+
+	batchHeaderHash, err := parseBatchHeaderHash(req.GetBatchHeaderHash(), nil)
+	if err != nil {
+		s.metrics.HandleFailedRequest(0, method)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	metadatas, staleness, err := s.getBatchMetadata(ctx, batchHeaderHash)
+	if err != nil {
+		s.metrics.HandleFailedRequest(0, method)
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if staleness != nil {
+		setStalenessHeader(ctx, *staleness)
+	}
+	return metadatas, nil
+}
+
+// batchStatusReply encodes the status of the batch of metadatas
+func batchStatusReply(metadatas []*disperser.BlobMetadata) *pb.BatchStatus {
+	batch := batchStatus(metadatas)
+	return &pb.BatchStatus{
+		BatchHeaderHash:         batch.BatchHeaderHash,
+		BatchId:                 batch.BatchID,
+		BatchRoot:               batch.BatchRoot,
+		Status:                  pb.BlobStatus(pb.BlobStatus_value[batch.Status]),
+		Epoch:                   batch.Epoch,
+		QuorumId:                batch.QuorumId,
+		NumBlobs:                int64(batch.NumBlobs),
+		SubmissionTxnHash:       batch.SubmissionTxnHash.Bytes(),
+		ConfirmationTxnHash:     batch.ConfirmationTxnHash.Bytes(),
+		ConfirmationBlockNumber: batch.ConfirmationBlockNumber,
+		SignerBitmap:            batch.SignerBitmap,
+		NumSigners:              batch.NumSigners,
+		SignedCount:             int64(batch.SignedCount),
+		ReferenceBlockNumber:    batch.ReferenceBlockNumber,
+		Venue:                   venueReply(metadatas[0].ConfirmationInfo.Venue),
+	}
+}
+
+// batchCertificateReply encodes the certificate of the batch of metadatas
+func batchCertificateReply(certificate *BatchCertificate, metadatas []*disperser.BlobMetadata) *pb.BatchCertificate {
+	reply := &pb.BatchCertificate{
+		Status: batchStatusReply(metadatas),
+		Blobs:  make([]*pb.BlobCertificate, len(certificate.Blobs)),
+		MultiProof: &pb.BatchMultiProof{
+			Values:  certificate.MultiProof.Values,
+			Indices: certificate.MultiProof.Indices,
+			Hashes:  make(map[uint64][]byte, len(certificate.MultiProof.Hashes)),
+		},
+	}
+	for i, blob := range certificate.Blobs {
+		reply.Blobs[i] = &pb.BlobCertificate{
+			BlobIndex:      blob.BlobIndex,
+			DataRoot:       blob.DataRoot,
+			CommitmentRoot: blob.CommitmentRoot,
+			Length:         blob.Length,
+			InclusionProof: blob.InclusionProof,
+
+			AggregateSignature: blob.AggregateSignature,
+			AggregatePubKey:    blob.AggregatePubKey,
+		}
+		for _, result := range blob.QuorumResults {
+			reply.Blobs[i].QuorumResults = append(reply.Blobs[i].QuorumResults, &pb.QuorumResult{
+				QuorumId:           uint32(result.QuorumID),
+				PercentSigned:      uint32(result.PercentSigned),
+				AdversaryThreshold: uint32(result.AdversaryThreshold),
+				QuorumThreshold:    uint32(result.QuorumThreshold),
+				Fallback:           result.Fallback,
+			})
+		}
+	}
+	for node, hash := range certificate.MultiProof.Hashes {
+		reply.MultiProof.Hashes[node] = hash
+	}
+	return reply
+}
+
+// startBatchHTTPServer serves the batch endpoints:
+//   - GET /batch/status?header_hash=<hex>
+//   - GET /batch/certificate?header_hash=<hex>
+//   - GET /batch/blobs?header_hash=<hex>[&tag=<key>[=<value>]...]
+//   - GET /blobs?tag=<key>[=<value>][&tag=...][&limit=<n>]
+//   - GET /blob/<batch header hash>/<blob index>[?verify]
+//   - GET /blob/events?request_id=<request id>
+func (s *DispersalServer) startBatchHTTPServer(ctx context.Context) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/batch/status", s.handleBatchStatus)
+	mux.HandleFunc("/batch/certificate", s.handleBatchCertificate)
+	mux.HandleFunc("/batch/blobs", s.handleListBlobs)
+	mux.HandleFunc("/blobs", s.handleListTaggedBlobs)
+	mux.HandleFunc("/blob/", s.handleBlob)
+	mux.HandleFunc("/blob/events", s.handleBlobEvents)
+	mux.HandleFunc("/estimate", s.handleEstimate)
+
+	host := s.config.HTTPHost
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	server := &http.Server{Addr: net.JoinHostPort(host, s.config.HTTPPort), Handler: mux}
+	go func() {
+		<-ctx.Done()
+		_ = server.Shutdown(context.Background())
+	}()
+	go func() {
+		s.logger.Info("[apiserver] batch api listening", "address", server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("[apiserver] batch api stopped", "err", err)
+		}
+	}()
+}
@@ -0,0 +1,136 @@
+package apiserver
+
+import (
+	"bytes"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/wealdtech/go-merkletree"
+)
+
+// FuzzBuildMultiProof merges the inclusion proofs of a subset of the blobs of a batch and
+// checks the multiproof against the batch root. With corrupt set, the proof of the first
+// blob is replaced by it, which must be rejected or produce a multiproof without panicking.
+func FuzzBuildMultiProof(f *testing.F) {
+	f.Add(bytes.Repeat([]byte{1}, 5*32), uint64(0b1101), []byte{})
+	f.Add([]byte{1}, uint64(1), []byte{})
+	f.Add(bytes.Repeat([]byte{2}, 3*32), uint64(0b11), bytes.Repeat([]byte{3}, 31))
+
+	f.Fuzz(func(t *testing.T, data []byte, selection uint64, corrupt []byte) {
+		headers := make([]*core.BlobHeader, 0)
+		for i := 0; i < len(data) && len(headers) < 64; i += 32 {
+			end := i + 32
+			if end > len(data) {
+				end = len(data)
+			}
+			headers = append(headers, &core.BlobHeader{CommitmentRoot: data[i:end], Length: uint(i)})
+		}
+		if len(headers) == 0 {
+			return
+		}
+		var batchHeader core.BatchHeader
+		if _, err := batchHeader.SetBatchRoot(headers); err != nil {
+			t.Fatal(err)
+		}
+		proofs, err := core.BatchInclusionProofs(headers, core.Keccak256Suite)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		indices := make([]uint32, 0)
+		serialized := make([][]byte, 0)
+		leaves := make([][]byte, 0)
+		for i := range headers {
+			if selection&(1<<uint(i)) == 0 {
+				continue
+			}
+			leaf, err := headers[i].GetBlobHeaderHash()
+			if err != nil {
+				t.Fatal(err)
+			}
+			indices = append(indices, uint32(i))
+			serialized = append(serialized, bytes.Join(proofs[i].Hashes, nil))
+			leaves = append(leaves, leaf[:])
+		}
+
+		if len(corrupt) > 0 && len(serialized) > 0 {
+			serialized[0] = corrupt
+			_, _ = buildMultiProof(indices, serialized)
+			return
+		}
+		multiProof, err := buildMultiProof(indices, serialized)
+		if len(indices) == 0 {
+			if err == nil {
+				t.Fatal("expected an error without blobs")
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("failed to build multiproof: %v", err)
+		}
+
+		hashes := make(map[uint64][]byte, len(multiProof.Hashes))
+		for node, hash := range multiProof.Hashes {
+			hashes[node] = hash
+		}
+		proof, err := merkletree.NewMultiProof(
+			merkletree.WithValues(multiProof.Values),
+			merkletree.WithIndices(multiProof.Indices),
+			merkletree.WithHashes(hashes),
+			merkletree.WithHashType(core.Keccak256Suite),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ok, err := proof.Verify(leaves, batchHeader.BatchRoot[:])
+		if err != nil || !ok {
+			t.Fatalf("multiproof of blobs %v doesn't verify: %v", indices, err)
+		}
+	})
+}
+
+// FuzzParseBlobPath checks that paths accepted by the blob endpoint are those it serves
+func FuzzParseBlobPath(f *testing.F) {
+	f.Add("/blob/" + hexutil.Encode(bytes.Repeat([]byte{0xab}, 32)) + "/3")
+	f.Add("/blob/0x/1")
+	f.Add("/blob//")
+
+	f.Fuzz(func(t *testing.T, path string) {
+		batchHeaderHash, index, err := parseBlobPath(path)
+		if err != nil {
+			return
+		}
+		canonical := fmt.Sprintf("/blob/%s/%d", hexutil.Encode(batchHeaderHash[:]), index)
+		reparsedHash, reparsedIndex, err := parseBlobPath(canonical)
+		if err != nil || reparsedHash != batchHeaderHash || reparsedIndex != index {
+			t.Fatalf("%q parsed to %s which doesn't parse back", path, canonical)
+		}
+	})
+}
+
+// FuzzParseBatchQuery feeds arbitrary query strings to the batch endpoints
+func FuzzParseBatchQuery(f *testing.F) {
+	f.Add("header_hash=" + hexutil.Encode(bytes.Repeat([]byte{0xcd}, 32)))
+	f.Add("batch_id=42")
+	f.Add("header_hash=0x&batch_id=-1")
+
+	f.Fuzz(func(t *testing.T, query string) {
+		if _, err := url.ParseQuery(query); err != nil {
+			return
+		}
+		r := httptest.NewRequest("GET", "/batch/status", nil)
+		r.URL.RawQuery = query
+		batchHeaderHash, err := parseBatchQuery(r)
+		if err != nil {
+			return
+		}
+		decoded, err := hexutil.Decode(ensureHexPrefix(r.URL.Query().Get("header_hash")))
+		if err != nil || !bytes.Equal(decoded, batchHeaderHash[:]) {
+			t.Fatalf("query %q parsed to header hash %x", query, batchHeaderHash[:])
+		}
+	})
+}
@@ -0,0 +1,306 @@
+package apiserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/go-merkletree"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestBuildMultiProof(t *testing.T) {
+	headers := make([]*core.BlobHeader, 5)
+	for i := range headers {
+		root := make([]byte, 32)
+		root[0] = byte(i + 1)
+		headers[i] = &core.BlobHeader{CommitmentRoot: root, Length: uint(i + 1)}
+	}
+	var batchHeader core.BatchHeader
+	tree, err := batchHeader.SetBatchRoot(headers)
+	assert.NoError(t, err)
+
+	blobs := []uint32{0, 2, 3}
+	proofs := make([][]byte, len(blobs))
+	leaves := make([][]byte, len(blobs))
+	for i, index := range blobs {
+		leaf, err := headers[index].GetBlobHeaderHash()
+		assert.NoError(t, err)
+		leaves[i] = leaf[:]
+		proof, err := tree.GenerateProof(leaf[:], 0)
+		assert.NoError(t, err)
+		for _, hash := range proof.Hashes {
+			proofs[i] = append(proofs[i], hash...)
+		}
+	}
+
+	multiProof, err := buildMultiProof(blobs, proofs)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(8), multiProof.Values)
+
+	hashes := make(map[uint64][]byte, len(multiProof.Hashes))
+	for node, hash := range multiProof.Hashes {
+		hashes[node] = hash
+	}
+	proof, err := merkletree.NewMultiProof(
+		merkletree.WithValues(multiProof.Values),
+		merkletree.WithIndices(multiProof.Indices),
+		merkletree.WithHashes(hashes),
+		merkletree.WithHashType(core.Keccak256Suite),
+	)
+	assert.NoError(t, err)
+	ok, err := proof.Verify(leaves, batchHeader.BatchRoot[:])
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = buildMultiProof([]uint32{0, 1}, [][]byte{proofs[0], proofs[1][:32]})
+	assert.Error(t, err)
+}
+
+func TestQuorumResults(t *testing.T) {
+	assert.Nil(t, quorumResults(nil))
+
+	results := quorumResults(map[core.QuorumID]*core.QuorumResult{
+		2: {QuorumID: 2, PercentSigned: 70},
+		0: {QuorumID: 0, PercentSigned: 100},
+	})
+	assert.Equal(t, []*QuorumResult{{QuorumID: 0, PercentSigned: 100}, {QuorumID: 2, PercentSigned: 70}}, results)
+
+	encoded, err := json.Marshal(&BlobCertificate{QuorumResults: results[:1]})
+	assert.NoError(t, err)
+	assert.Contains(t, string(encoded), `"quorum_results":[{"quorum_id":0,"percent_signed":100}]`)
+}
+
+func TestBatchStatusSignerBitmap(t *testing.T) {
+	bitmap := core.NewSignerBitmap(10)
+	bitmap.Set(0)
+	bitmap.Set(9)
+	assert.True(t, bitmap.Signed(9))
+	assert.False(t, bitmap.Signed(1))
+	assert.False(t, bitmap.Signed(16))
+
+	status := batchStatus([]*disperser.BlobMetadata{{
+		BlobStatus:       disperser.Confirmed,
+		ConfirmationInfo: &disperser.ConfirmationInfo{SignerBitmap: bitmap, NumSigners: 10},
+	}})
+	assert.Equal(t, []byte{0x01, 0x02}, []byte(status.SignerBitmap))
+	assert.Equal(t, uint32(10), status.NumSigners)
+	assert.Equal(t, 2, status.SignedCount)
+	assert.Nil(t, status.Venue)
+}
+
+func TestBatchStatusVenue(t *testing.T) {
+	venue := &disperser.ConfirmationVenue{ChainID: 2, Contract: eth_common.HexToAddress("0x02"), Fallback: true}
+	status := batchStatus([]*disperser.BlobMetadata{{
+		BlobStatus:       disperser.Confirmed,
+		ConfirmationInfo: &disperser.ConfirmationInfo{Venue: venue},
+	}})
+	require.NotNil(t, status.Venue)
+	assert.Equal(t, ConfirmationVenue{ChainID: 2, Contract: venue.Contract.Bytes(), Fallback: true}, *status.Venue)
+
+	reply := venueReply(venue)
+	assert.Equal(t, uint64(2), reply.GetChainId())
+	assert.Equal(t, venue.Contract.Bytes(), reply.GetContract())
+	assert.True(t, reply.GetFallback())
+	assert.Nil(t, venueReply(nil))
+}
+
+func TestListBlobsByTags(t *testing.T) {
+	tagged := func(index uint32, tags map[string]string) *disperser.BlobMetadata {
+		return &disperser.BlobMetadata{
+			BlobHash:         "hash",
+			MetadataHash:     strconv.Itoa(int(index)),
+			BlobStatus:       disperser.Confirmed,
+			RequestMetadata:  &disperser.RequestMetadata{BlobRequestHeader: core.BlobRequestHeader{Tags: tags}},
+			ConfirmationInfo: &disperser.ConfirmationInfo{BlobIndex: index},
+		}
+	}
+	metadatas := []*disperser.BlobMetadata{
+		tagged(0, map[string]string{"rollup": "a", "block": "7"}),
+		tagged(1, map[string]string{"rollup": "b"}),
+		tagged(2, nil),
+		{BlobStatus: disperser.Finalized, ConfirmationInfo: &disperser.ConfirmationInfo{BlobIndex: 3}},
+	}
+	indexes := func(query string) []uint32 {
+		list := listBlobs(metadatas, parseTagFilter(httptest.NewRequest("GET", "/batch/blobs?"+query, nil)))
+		indexes := make([]uint32, 0)
+		for _, blob := range list.Blobs {
+			indexes = append(indexes, blob.BlobIndex)
+		}
+		return indexes
+	}
+
+	assert.Equal(t, []uint32{0, 1, 2, 3}, indexes(""))
+	assert.Equal(t, []uint32{0, 1}, indexes("tag=rollup"))
+	assert.Equal(t, []uint32{0}, indexes("tag=rollup=a&tag=block=7"))
+	assert.Empty(t, indexes("tag=rollup=a&tag=block=8"))
+
+	// the keys may have a '=', the values can't
+	value := "v"
+	assert.Equal(t, tagFilter{"a=b": &value}, parseTagFilter(httptest.NewRequest("GET", "/batch/blobs?tag=a%3Db%3Dv", nil)))
+	_, err := validateTags(map[string]string{"a=b": "v"})
+	assert.NoError(t, err)
+	_, err = validateTags(map[string]string{"a": "b=v"})
+	assert.Error(t, err)
+
+	_, err = validateTags(map[string]string{"": "empty key"})
+	assert.Error(t, err)
+	tags := make(map[string]string)
+	for i := 0; i <= maxTags; i++ {
+		tags[strconv.Itoa(i)] = "v"
+	}
+	_, err = validateTags(tags)
+	assert.Error(t, err)
+}
+
+func TestFinalizedBatchFromKv(t *testing.T) {
+	logger := mock.NewLogger(false)
+	kvStore, err := disperser.NewLevelDBStore(t.TempDir(), 0, logger)
+	require.NoError(t, err)
+	s := NewDispersalServer(disperser.ServerConfig{}, memorydb.NewBlobStore(1<<30, logger), logger, disperser.NewMetrics("0", logger), nil, RateConfig{}, false, kvStore, "", nil, nil, nil, nil, nil, nil, nil)
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+
+	// the blobs of the batch were finalized and removed from the blob store
+	batchHeaderHash := [32]byte{7}
+	for i := uint32(0); i < 2; i++ {
+		blobKey := disperser.BlobKey{BlobHash: "ab0" + strconv.Itoa(int(i)), MetadataHash: "cd"}
+		confirmation, err := (&disperser.BlobConfirmation{BlockNumber: 500, Info: &disperser.ConfirmationInfo{
+			BatchHeaderHash:         batchHeaderHash,
+			BatchID:                 1,
+			BlobIndex:               i,
+			DataRoot:                []byte{byte(i)},
+			BlobInclusionProof:      bytes.Repeat([]byte{byte(i + 1)}, 32),
+			ConfirmationBlockNumber: 500,
+			AggregateSignature:      []byte{byte(i), 0xaa},
+		}}).Serialize()
+		require.NoError(t, err)
+		_, err = kvStore.StoreMetadataBatch(ctx, [][]byte{[]byte(blobKey.String())}, [][]byte{[]byte("metadata")}, [][]byte{confirmation}, [][]byte{[]byte("data")})
+		require.NoError(t, err)
+	}
+
+	batch, err := s.GetBatchStatus(ctx, &pb.BatchRequest{BatchHeaderHash: batchHeaderHash[:]})
+	require.NoError(t, err)
+	assert.Equal(t, pb.BlobStatus_FINALIZED, batch.Status)
+	assert.Equal(t, int64(2), batch.NumBlobs)
+	assert.Equal(t, uint32(500), batch.ConfirmationBlockNumber)
+
+	certificate, err := s.GetBatchCertificate(ctx, &pb.BatchRequest{BatchHeaderHash: batchHeaderHash[:]})
+	require.NoError(t, err)
+	require.Len(t, certificate.Blobs, 2)
+	assert.Equal(t, []byte{1}, certificate.Blobs[1].DataRoot)
+	assert.Equal(t, []byte{1, 0xaa}, certificate.Blobs[1].AggregateSignature)
+	assert.Equal(t, []uint64{0, 1}, certificate.MultiProof.Indices)
+	assert.Empty(t, certificate.MultiProof.Hashes)
+
+	// the blobs of the batch keep their request ID
+	metadatas, _, err := s.getBatchMetadata(ctx, batchHeaderHash)
+	require.NoError(t, err)
+	assert.Equal(t, "ab00-cd", listBlobs(metadatas, nil).Blobs[0].RequestID)
+
+	other := [32]byte{8}
+	_, err = s.GetBatchStatus(ctx, &pb.BatchRequest{BatchHeaderHash: other[:]})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = s.GetBatchStatus(ctx, &pb.BatchRequest{BatchHeaderHash: []byte{7}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestListFinalizedBlobsByTags(t *testing.T) {
+	logger := mock.NewLogger(false)
+	kvStore, err := disperser.NewLevelDBStore(t.TempDir(), 0, logger)
+	require.NoError(t, err)
+	s := NewDispersalServer(disperser.ServerConfig{}, memorydb.NewBlobStore(1<<30, logger), logger, disperser.NewMetrics("0", logger), nil, RateConfig{}, false, kvStore, "", nil, nil, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	// two finalized batches whose blobs were removed from the blob store
+	store := func(batchHeaderHash [32]byte, index uint32, tags map[string]string) {
+		blobKey := disperser.BlobKey{BlobHash: fmt.Sprintf("ab%x%d", batchHeaderHash[0], index), MetadataHash: "cd"}
+		confirmation, err := (&disperser.BlobConfirmation{
+			Info: &disperser.ConfirmationInfo{BatchHeaderHash: batchHeaderHash, BlobIndex: index},
+			Tags: tags,
+		}).Serialize()
+		require.NoError(t, err)
+		_, err = kvStore.StoreMetadataBatch(ctx, [][]byte{[]byte(blobKey.String())}, [][]byte{[]byte("metadata")}, [][]byte{confirmation}, [][]byte{[]byte("data")})
+		require.NoError(t, err)
+	}
+	first, second := [32]byte{1}, [32]byte{2}
+	store(first, 0, map[string]string{"rollup": "a", "block": "7"})
+	store(first, 1, map[string]string{"rollup": "b"})
+	store(second, 0, map[string]string{"rollup": "a", "block": "8"})
+
+	// the tags of a finalized batch are read back from the kv store
+	metadatas, _, err := s.getBatchMetadata(ctx, first)
+	require.NoError(t, err)
+	list := listBlobs(metadatas, parseTagFilter(httptest.NewRequest("GET", "/batch/blobs?tag=rollup=a", nil)))
+	require.Len(t, list.Blobs, 1)
+	assert.Equal(t, map[string]string{"rollup": "a", "block": "7"}, list.Blobs[0].Tags)
+
+	// and indexed across batches
+	requestIDs := func(query string, limit int) []string {
+		list, err := s.listTaggedBlobs(ctx, parseTagFilter(httptest.NewRequest("GET", "/blobs?"+query, nil)), limit)
+		require.NoError(t, err)
+		requestIDs := make([]string, 0)
+		for _, blob := range list.Blobs {
+			requestIDs = append(requestIDs, blob.RequestID)
+		}
+		return requestIDs
+	}
+	assert.Equal(t, []string{"ab10-cd", "ab20-cd"}, requestIDs("tag=rollup=a", maxTaggedBlobs))
+	assert.Equal(t, []string{"ab20-cd"}, requestIDs("tag=rollup&tag=block=8", maxTaggedBlobs))
+	assert.Equal(t, []string{"ab10-cd"}, requestIDs("tag=rollup", 1))
+	assert.Empty(t, requestIDs("tag=block=9", maxTaggedBlobs))
+
+	w := httptest.NewRecorder()
+	s.handleListTaggedBlobs(w, httptest.NewRequest("GET", "/blobs", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestFinalizedBlobEvents(t *testing.T) {
+	logger := mock.NewLogger(false)
+	kvStore, err := disperser.NewLevelDBStore(t.TempDir(), 0, logger)
+	require.NoError(t, err)
+	s := NewDispersalServer(disperser.ServerConfig{}, memorydb.NewBlobStore(1<<30, logger), logger, disperser.NewMetrics("0", logger), nil, RateConfig{}, false, kvStore, "", nil, nil, nil, nil, nil, nil, nil)
+
+	// the blob was finalized and removed from the blob store with its history
+	blobKey := disperser.BlobKey{BlobHash: "ab", MetadataHash: "cd"}
+	finalizedAt := time.Unix(1000, 0).UTC()
+	confirmation, err := (&disperser.BlobConfirmation{
+		Info:   &disperser.ConfirmationInfo{BatchHeaderHash: [32]byte{1}},
+		Events: []disperser.BlobEvent{{Type: disperser.BlobReceived, Time: finalizedAt.Add(-time.Minute)}, {Type: disperser.BlobFinalized, Time: finalizedAt}},
+	}).Serialize()
+	require.NoError(t, err)
+	_, err = kvStore.StoreMetadataBatch(context.Background(), [][]byte{[]byte(blobKey.String())}, [][]byte{[]byte("metadata")}, [][]byte{confirmation}, [][]byte{[]byte("data")})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	s.handleBlobEvents(w, httptest.NewRequest("GET", "/blob/events?request_id="+blobKey.String(), nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var history BlobHistory
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	assert.Equal(t, disperser.Finalized.String(), history.Status)
+	require.Len(t, history.Events, 2)
+	assert.Equal(t, disperser.BlobFinalized, history.Events[1].Type)
+
+	// from another client, not to be rate limited
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/blob/events?request_id=ef-01", nil)
+	r.RemoteAddr = "192.0.2.2:1234"
+	s.handleBlobEvents(w, r)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,103 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/disperser"
+)
+
+// BlobHistory is the reply of GET /blob/events
+type BlobHistory struct {
+	RequestID string `json:"request_id"`
+	// Status is the current status of the blob
+	Status string                `json:"status"`
+	Events []disperser.BlobEvent `json:"events"`
+}
+
+// handleBlobEvents serves the lifecycle history of a blob, in time order, for operators and
+// clients looking for the step a blob is stuck at. The history of a finalized blob moves to
+// the kv store with the blob.
+func (s *DispersalServer) handleBlobEvents(w http.ResponseWriter, r *http.Request) {
+	const method = "GetBlobEvents"
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	origin, err := common.GetHTTPClientAddress(r, s.rateConfig.ClientIPHeader, 2, true)
+	if err != nil {
+		s.metrics.HandleFailedRequest(0, method)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !s.readRateLimiterManager.GetRateLimiter(origin).Allow() {
+		s.metrics.HandleFailedRequest(0, method)
+		http.Error(w, "request ratelimited", http.StatusTooManyRequests)
+		return
+	}
+
+	key, err := disperser.ParseBlobKey(r.URL.Query().Get("request_id"))
+	if err != nil {
+		s.metrics.HandleFailedRequest(0, method)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	metadata, err := s.blobReader.GetBlobMetadata(r.Context(), key)
+	if err == nil {
+		var events []disperser.BlobEvent
+		events, err = s.blobReader.GetBlobEvents(r.Context(), key)
+		if err == nil {
+			s.metrics.HandleSuccessfulRequest(0, method)
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(&BlobHistory{
+				RequestID: key.String(),
+				Status:    metadata.BlobStatus.String(),
+				Events:    disperser.SortBlobEvents(events),
+			})
+			return
+		}
+	}
+	if errors.Is(err, disperser.ErrBlobNotFound) {
+		var history *BlobHistory
+		history, err = s.finalizedBlobHistory(r.Context(), key)
+		if err == nil && history != nil {
+			s.metrics.HandleSuccessfulRequest(0, method)
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(history)
+			return
+		}
+		if err == nil {
+			s.metrics.HandleFailedRequest(0, method)
+			http.Error(w, "blob not found", http.StatusNotFound)
+			return
+		}
+	}
+	s.metrics.HandleFailedRequest(0, method)
+	s.logger.Error("[apiserver] failed to get blob events", "key", key.String(), "err", err)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// finalizedBlobHistory returns the history of a blob finalized to the kv store, nil if it
+// isn't there
+func (s *DispersalServer) finalizedBlobHistory(ctx context.Context, key disperser.BlobKey) (*BlobHistory, error) {
+	if s.kvStore == nil {
+		return nil, nil
+	}
+	confirmation, err := s.getConfirmationFromKv(ctx, []byte(key.String()))
+	if err != nil || confirmation == nil {
+		return nil, err
+	}
+	events := confirmation.Events
+	if events == nil {
+		events = make([]disperser.BlobEvent, 0)
+	}
+	return &BlobHistory{
+		RequestID: key.String(),
+		Status:    disperser.Finalized.String(),
+		Events:    events,
+	}, nil
+}
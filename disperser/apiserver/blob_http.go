@@ -0,0 +1,264 @@
+package apiserver
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Headers of the GET /blob replies letting clients verify the data against the batch root
+// without the gRPC API. They are set if the request has the verify query parameter.
+const (
+	DataRootHeader       = "x-zgda-data-root"
+	BatchRootHeader      = "x-zgda-batch-root"
+	InclusionProofHeader = "x-zgda-inclusion-proof"
+	EpochHeader          = "x-zgda-epoch"
+	QuorumIDHeader       = "x-zgda-quorum-id"
+)
+
+// blobChunkSize is the size of the chunks a blob is written in
+const blobChunkSize = 64 * 1024
+
+var errBlobNotFound = errors.New("blob not found")
+
+var verificationHeaders = strings.Join([]string{DataRootHeader, BatchRootHeader, InclusionProofHeader, EpochHeader, QuorumIDHeader, StalenessHeader, "ETag"}, ", ")
+
+// parseBlobPath reads the batch header hash and blob index of /blob/{batchHeaderHash}/{index}
+func parseBlobPath(path string) ([32]byte, uint32, error) {
+	var batchHeaderHash [32]byte
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(path, "/blob/"), "/"), "/")
+	if len(parts) != 2 {
+		return batchHeaderHash, 0, errors.New("path must be /blob/{batchHeaderHash}/{index}")
+	}
+	decoded, err := hexutil.Decode(ensureHexPrefix(parts[0]))
+	if err != nil || len(decoded) != 32 {
+		return batchHeaderHash, 0, errors.New("batch header hash must be 32 hex encoded bytes")
+	}
+	copy(batchHeaderHash[:], decoded)
+	index, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return batchHeaderHash, 0, errors.New("invalid blob index")
+	}
+	return batchHeaderHash, uint32(index), nil
+}
+
+// blobETag is the entity tag of the copies of a blob whose data root is the one of info,
+// which identifies their content
+func blobETag(info *disperser.ConfirmationInfo) string {
+	return `"` + hexutil.Encode(info.DataRoot) + `"`
+}
+
+func matchesETag(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// locateBlob returns the confirmation info of the blob at index in the batch of
+// batchHeaderHash, and its metadata if it is still in the blob store. The blobs finalized
+// and removed from the blob store are found in the kv store.
+func (s *DispersalServer) locateBlob(ctx context.Context, batchHeaderHash [32]byte, index uint32) (*disperser.ConfirmationInfo, *disperser.BlobMetadata, *time.Duration, error) {
+	metadatas, staleness, fromReplica, err := s.getAllBlobMetadataByBatch(ctx, batchHeaderHash)
+	if err == nil {
+		for _, metadata := range metadatas {
+			if ok, _ := metadata.IsConfirmed(); ok && metadata.ConfirmationInfo.BlobIndex == index {
+				if !fromReplica {
+					return metadata.ConfirmationInfo, metadata, nil, nil
+				}
+				return metadata.ConfirmationInfo, metadata, &staleness, nil
+			}
+		}
+	}
+	if s.kvStore == nil {
+		return nil, nil, nil, errBlobNotFound
+	}
+	key, err := s.kvStore.GetBatchBlobKey(ctx, batchHeaderHash, index)
+	if errors.Is(err, disperser.ErrKeyNotFound) {
+		return nil, nil, nil, errBlobNotFound
+	} else if err != nil {
+		return nil, nil, nil, err
+	}
+	confirmation, err := s.getConfirmationFromKv(ctx, key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if confirmation == nil || confirmation.Info == nil {
+		return nil, nil, nil, errBlobNotFound
+	}
+	return confirmation.Info, nil, nil, nil
+}
+
+// confirmedBlobData returns the data of a confirmed blob, and whether it is the copy the
+// disperser encoded to the data root of info: the content in the blob store, or in the kv
+// store once finalized, which is keyed by the data root. The data of the retriever, which
+// can't be checked against the data root without encoding it, isn't.
+func (s *DispersalServer) confirmedBlobData(ctx context.Context, info *disperser.ConfirmationInfo, metadata *disperser.BlobMetadata) ([]byte, bool, error) {
+	if metadata != nil {
+		data, err := s.blobReader.GetBlobContent(ctx, metadata)
+		if err == nil {
+			return data, true, nil
+		}
+		s.logger.Debug("[apiserver] confirmed blob content not in the blob store", "key", metadata.GetBlobKey().String(), "err", err)
+	}
+	if data, ok := s.kvBlobData(ctx, info.DataRoot, info.Epoch, info.QuorumId); ok {
+		return data, true, nil
+	}
+	data, err := s.retrieverBlobData(ctx, info.DataRoot, info.Epoch, info.QuorumId)
+	return data, false, err
+}
+
+// handleBlob serves the data of a confirmed blob. The data is written in chunks so the
+// reply uses chunked transfer encoding, and clients can start consuming it before the last
+// chunk is written.
+func (s *DispersalServer) handleBlob(w http.ResponseWriter, r *http.Request) {
+	const method = "GetBlobHTTP"
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Expose-Headers", verificationHeaders)
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	origin, err := common.GetHTTPClientAddress(r, s.rateConfig.ClientIPHeader, 2, true)
+	if err != nil {
+		s.metrics.HandleFailedRequest(0, method)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// the retrieval quotas are kept by the address of the connection, which unlike the
+	// client ip header can't be spoofed
+	peerAddress, err := common.GetHTTPClientAddress(r, "", 0, true)
+	if err != nil {
+		s.metrics.HandleFailedRequest(0, method)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !s.readRateLimiterManager.GetRateLimiter(origin).Allow() {
+		s.metrics.HandleFailedRequest(0, method)
+		http.Error(w, "request ratelimited", http.StatusTooManyRequests)
+		return
+	}
+
+	batchHeaderHash, index, err := parseBlobPath(r.URL.Path)
+	if err != nil {
+		s.metrics.HandleFailedRequest(0, method)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	info, metadata, staleness, err := s.locateBlob(r.Context(), batchHeaderHash, index)
+	if errors.Is(err, errBlobNotFound) {
+		s.metrics.HandleFailedRequest(0, method)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		s.logger.Error("[apiserver] failed to locate blob", "batchHeaderHash", hexutil.Encode(batchHeaderHash[:]), "index", index, "err", err)
+		s.metrics.HandleFailedRequest(0, method)
+		http.Error(w, "failed to locate blob", http.StatusInternalServerError)
+		return
+	}
+
+	if _, ok := r.URL.Query()["verify"]; ok {
+		w.Header().Set(DataRootHeader, hexutil.Encode(info.DataRoot))
+		w.Header().Set(BatchRootHeader, hexutil.Encode(info.BatchRoot))
+		w.Header().Set(InclusionProofHeader, hexutil.Encode(info.BlobInclusionProof))
+		w.Header().Set(EpochHeader, strconv.FormatUint(info.Epoch, 10))
+		w.Header().Set(QuorumIDHeader, strconv.FormatUint(info.QuorumId, 10))
+	}
+	if staleness != nil {
+		w.Header().Set(StalenessHeader, formatStaleness(*staleness))
+	}
+
+	// the blob is read before answering a conditional request, so that blobs which can't be
+	// read are never reported unmodified
+	var estimate int
+	if metadata != nil && metadata.RequestMetadata != nil {
+		estimate = int(metadata.RequestMetadata.BlobSize)
+	}
+	release, err := s.admitRetrieval(r.Context(), method, r.Header.Get(s.accounts.header()), peerAddress, estimate)
+	if err != nil {
+		writeRejection(w, err)
+		return
+	}
+	data, verified, err := s.confirmedBlobData(r.Context(), info, metadata)
+	defer func() { release(len(data)) }()
+	if err != nil {
+		s.logger.Error("[apiserver] failed to retrieve blob", "batchHeaderHash", hexutil.Encode(batchHeaderHash[:]), "index", index, "err", err)
+		s.metrics.HandleFailedRequest(0, method)
+		http.Error(w, "failed to retrieve blob", http.StatusBadGateway)
+		return
+	}
+
+	// the copies of the disperser are tagged with their data root, the others with their
+	// hash and revalidated on each use since the retriever may serve other data
+	var etag string
+	if verified {
+		etag = blobETag(info)
+		w.Header().Set("Cache-Control", "public, max-age=31536000")
+	} else {
+		etag = `"` + crypto.Keccak256Hash(data).Hex() + `"`
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	w.Header().Set("ETag", etag)
+	if matchesETag(r.Header.Get("If-None-Match"), etag) {
+		s.metrics.HandleSuccessfulRequest(0, method)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if r.Method == http.MethodHead {
+		s.metrics.HandleSuccessfulRequest(0, method)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	s.metrics.HandleSuccessfulRequest(len(data), method)
+	flusher, _ := w.(http.Flusher)
+	for offset := 0; offset < len(data); offset += blobChunkSize {
+		end := offset + blobChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		if _, err := w.Write(data[offset:end]); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// writeRejection replies to a request rejected with a grpc status, setting the Retry-After
+// header from its RetryInfo
+func writeRejection(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(info.GetRetryDelay().AsDuration().Seconds()))))
+		}
+	}
+	code := http.StatusTooManyRequests
+	switch st.Code() {
+	case codes.Unauthenticated:
+		code = http.StatusUnauthorized
+	case codes.Canceled, codes.DeadlineExceeded:
+		code = http.StatusServiceUnavailable
+	}
+	http.Error(w, st.Message(), code)
+}
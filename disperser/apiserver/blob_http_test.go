@@ -0,0 +1,34 @@
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBlobPath(t *testing.T) {
+	hash := "0x0102030405060708091011121314151617181920212223242526272829303132"
+	batchHeaderHash, index, err := parseBlobPath("/blob/" + hash + "/7")
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0x01), batchHeaderHash[0])
+	assert.Equal(t, byte(0x32), batchHeaderHash[31])
+	assert.Equal(t, uint32(7), index)
+
+	_, _, err = parseBlobPath("/blob/" + hash[2:] + "/0/")
+	assert.NoError(t, err)
+
+	for _, path := range []string{"/blob/", "/blob/" + hash, "/blob/0x01/1", "/blob/" + hash + "/-1", "/blob/" + hash + "/1/2"} {
+		_, _, err = parseBlobPath(path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestBlobETag(t *testing.T) {
+	etag := blobETag(&disperser.ConfirmationInfo{DataRoot: []byte{0xab, 0xcd}})
+	assert.Equal(t, `"0xabcd"`, etag)
+	assert.True(t, matchesETag(`"0x01", W/"0xabcd"`, etag))
+	assert.True(t, matchesETag("*", etag))
+	assert.False(t, matchesETag("", etag))
+	assert.False(t, matchesETag(`"0x01"`, etag))
+}
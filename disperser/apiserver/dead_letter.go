@@ -0,0 +1,222 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/approval"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/urfave/cli"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// DeadLetterReasonHeader carries the reason code on the GetBlobStatus replies of a
+	// dead-lettered blob, whose status is FAILED for older clients
+	DeadLetterReasonHeader = "x-zgda-dead-letter-reason"
+
+	DeadLetterHTTPPortFlagName   = "dead-letter.http-port"
+	DeadLetterAdminTokenFlagName = "dead-letter.admin-token"
+)
+
+var errNotDeadLettered = errors.New("blob is not dead-lettered")
+
+type DeadLetterConfig struct {
+	// HTTPPort serves the admin API of the dead-lettered blobs
+	HTTPPort string
+	// AdminToken must be sent as a bearer token to the admin API, which is disabled if empty
+	// and no approval admins are configured
+	AdminToken string
+	// Approval gates the resubmissions behind the approval of several admins, whose tokens
+	// are accepted in place of AdminToken
+	Approval approval.Config
+}
+
+func (c DeadLetterConfig) Enabled() bool {
+	return c.AdminToken != "" || c.Approval.Enabled()
+}
+
+func DeadLetterCLIFlags(envPrefix string, flagPrefix string) []cli.Flag {
+	flags := []cli.Flag{
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, DeadLetterHTTPPortFlagName),
+			Usage:  "port of the admin api of the dead-lettered blobs",
+			Value:  "9302",
+			EnvVar: common.PrefixEnvVar(envPrefix, "DEAD_LETTER_HTTP_PORT"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, DeadLetterAdminTokenFlagName),
+			Usage:  "bearer token required by the dead letter admin api. The admin api is disabled if empty",
+			EnvVar: common.PrefixEnvVar(envPrefix, "DEAD_LETTER_ADMIN_TOKEN"),
+		},
+	}
+	return append(flags, approval.CLIFlags(common.PrefixEnvVar(envPrefix, "DEAD_LETTER"), common.PrefixFlag(flagPrefix, "dead-letter"))...)
+}
+
+func ReadDeadLetterConfig(ctx *cli.Context, flagPrefix string) (DeadLetterConfig, error) {
+	approvalConfig, err := approval.ReadCLIConfig(ctx, common.PrefixFlag(flagPrefix, "dead-letter"))
+	if err != nil {
+		return DeadLetterConfig{}, err
+	}
+	return DeadLetterConfig{
+		HTTPPort:   ctx.GlobalString(common.PrefixFlag(flagPrefix, DeadLetterHTTPPortFlagName)),
+		AdminToken: ctx.GlobalString(common.PrefixFlag(flagPrefix, DeadLetterAdminTokenFlagName)),
+		Approval:   approvalConfig,
+	}, nil
+}
+
+// DeadLetters serves the admin API listing, inspecting and resubmitting the blobs moved to
+// the DeadLettered status by the batcher
+type DeadLetters struct {
+	config    DeadLetterConfig
+	blobStore disperser.BlobStore
+	// gate is nil unless resubmissions need the approval of several admins
+	gate   *approval.Gate
+	logger common.Logger
+}
+
+func NewDeadLetters(config DeadLetterConfig, blobStore disperser.BlobStore, logger common.Logger) *DeadLetters {
+	return &DeadLetters{
+		config:    config,
+		blobStore: blobStore,
+		gate:      approval.NewGate(config.Approval, logger),
+		logger:    logger,
+	}
+}
+
+// Handler returns the routes of the admin API:
+//   - GET /dead-letter?reason=<code> lists the dead-lettered blobs, of a reason code if set
+//   - GET /dead-letter/blob?request_id=<id> returns the metadata of a dead-lettered blob
+//   - POST /dead-letter/resubmit?request_id=<id> queues a blob for encoding with its retries
+//     reset, once enough admins sent the same request if approvals are configured
+//   - GET /approvals lists the resubmissions waiting for the approval of more admins
+func (d *DeadLetters) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/dead-letter", d.authorized(http.MethodGet, d.handleList))
+	mux.HandleFunc("/dead-letter/blob", d.authorized(http.MethodGet, d.handleBlob))
+	if d.gate != nil {
+		mux.HandleFunc("/dead-letter/resubmit", d.gate.Gated(http.MethodPost, d.handleResubmit))
+		mux.HandleFunc("/approvals", d.gate.HandlePending)
+	} else {
+		mux.HandleFunc("/dead-letter/resubmit", d.authorized(http.MethodPost, d.handleResubmit))
+	}
+	return mux
+}
+
+// authorized serves the requests sending the admin token, or the token of an approval admin
+func (d *DeadLetters) authorized(method string, handler http.HandlerFunc) http.HandlerFunc {
+	return approval.Authorized(d.config.AdminToken, d.gate, method, handler)
+}
+
+// Serve serves the admin API until ctx is done
+func (d *DeadLetters) Serve(ctx context.Context) error {
+	d.logger.Info("[apiserver] dead letter admin api listening", "port", d.config.HTTPPort)
+	return serveAdmin(ctx, d.config.HTTPPort, d.Handler())
+}
+
+type deadLetteredBlob struct {
+	RequestID   string `json:"request_id"`
+	Reason      string `json:"reason"`
+	NumRetries  uint   `json:"num_retries"`
+	AccountID   string `json:"account_id,omitempty"`
+	BlobSize    uint   `json:"blob_size"`
+	RequestedAt uint64 `json:"requested_at"`
+}
+
+func newDeadLetteredBlob(metadata *disperser.BlobMetadata) deadLetteredBlob {
+	blob := deadLetteredBlob{
+		RequestID:  metadata.GetBlobKey().String(),
+		Reason:     metadata.DeadLetterReason,
+		NumRetries: metadata.NumRetries,
+	}
+	if metadata.RequestMetadata != nil {
+		blob.AccountID = metadata.RequestMetadata.AccountID
+		blob.BlobSize = metadata.RequestMetadata.BlobSize
+		blob.RequestedAt = metadata.RequestMetadata.RequestedAt
+	}
+	return blob
+}
+
+func (d *DeadLetters) handleList(w http.ResponseWriter, r *http.Request) {
+	metadatas, err := d.blobStore.GetBlobMetadataByStatus(r.Context(), disperser.DeadLettered)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	reason := r.URL.Query().Get("reason")
+	blobs := make([]deadLetteredBlob, 0, len(metadatas))
+	for _, metadata := range metadatas {
+		if reason != "" && metadata.DeadLetterReason != reason {
+			continue
+		}
+		blobs = append(blobs, newDeadLetteredBlob(metadata))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(blobs)
+}
+
+func (d *DeadLetters) handleBlob(w http.ResponseWriter, r *http.Request) {
+	metadata, err := d.getDeadLettered(r)
+	if err != nil {
+		writeDeadLetterError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(newDeadLetteredBlob(metadata))
+}
+
+// handleResubmit resubmits a blob with the conditional update of the store, so that a blob
+// resubmitted or confirmed concurrently is answered with a conflict
+func (d *DeadLetters) handleResubmit(w http.ResponseWriter, r *http.Request) {
+	key, err := disperser.ParseBlobKey(r.URL.Query().Get("request_id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = d.blobStore.ResubmitBlob(r.Context(), &disperser.BlobMetadata{BlobHash: key.BlobHash, MetadataHash: key.MetadataHash})
+	if errors.Is(err, disperser.ErrBlobNotFound) || errors.Is(err, disperser.ErrStatusConflict) {
+		writeDeadLetterError(w, err)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	d.logger.Info("[apiserver] dead-lettered blob resubmitted", "key", key.String())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (d *DeadLetters) getDeadLettered(r *http.Request) (*disperser.BlobMetadata, error) {
+	key, err := disperser.ParseBlobKey(r.URL.Query().Get("request_id"))
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := d.blobStore.GetBlobMetadata(r.Context(), key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", disperser.ErrBlobNotFound, err)
+	}
+	if metadata.BlobStatus != disperser.DeadLettered {
+		return nil, errNotDeadLettered
+	}
+	return metadata, nil
+}
+
+func writeDeadLetterError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, disperser.ErrBlobNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, errNotDeadLettered), errors.Is(err, disperser.ErrStatusConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// setDeadLetterReasonHeader reports the reason code on the reply of a dead-lettered blob
+func setDeadLetterReasonHeader(ctx context.Context, reason string) {
+	_ = grpc.SetHeader(ctx, metadata.Pairs(DeadLetterReasonHeader, reason))
+}
@@ -0,0 +1,57 @@
+package apiserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadLetterResubmit(t *testing.T) {
+	store := memorydb.NewBlobStore(core.MaxBlobSize*4, mock.NewLogger(false))
+	handler := NewDeadLetters(DeadLetterConfig{AdminToken: "token"}, store, mock.NewLogger(false)).Handler()
+
+	ctx := context.Background()
+	key, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("blob")}, 1)
+	assert.NoError(t, err)
+
+	request := func(method, path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path+"?request_id="+key.String(), nil)
+		r.Header.Set("Authorization", "Bearer token")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	// a blob still processing can't be resubmitted
+	assert.Equal(t, http.StatusConflict, request(http.MethodPost, "/dead-letter/resubmit").Code)
+
+	// the failure beyond the retries dead-letters the blob
+	metadata, err := store.GetBlobMetadata(ctx, key)
+	assert.NoError(t, err)
+	assert.NoError(t, store.HandleBlobFailure(ctx, metadata, 0))
+	metadata, err = store.GetBlobMetadata(ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, disperser.DeadLettered, metadata.BlobStatus)
+	assert.Equal(t, disperser.DeadLetterRetriesExhausted, metadata.DeadLetterReason)
+
+	w := request(http.MethodGet, "/dead-letter")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), disperser.DeadLetterRetriesExhausted)
+
+	assert.Equal(t, http.StatusNoContent, request(http.MethodPost, "/dead-letter/resubmit").Code)
+	metadata, err = store.GetBlobMetadata(ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, disperser.Processing, metadata.BlobStatus)
+	assert.Zero(t, metadata.NumRetries)
+	assert.Empty(t, metadata.DeadLetterReason)
+
+	// a concurrent resubmission that lost the race conflicts
+	assert.Equal(t, http.StatusConflict, request(http.MethodPost, "/dead-letter/resubmit").Code)
+}
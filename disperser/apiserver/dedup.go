@@ -0,0 +1,202 @@
+package apiserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// DuplicateHeader is set to "true" on the replies of DisperseBlob answered with the request
+// ID of an earlier dispersal of the same blob
+const DuplicateHeader = "x-zgda-duplicate"
+
+// defaultDedupEntries bounds the dispersals tracked if not configured
+const defaultDedupEntries = 100000
+
+type dedupKey [32]byte
+
+type dedupEntry struct {
+	key      disperser.BlobKey
+	storedAt time.Time
+}
+
+// pendingDispersal is a dispersal being stored, which the duplicates received meanwhile wait for
+type pendingDispersal struct {
+	done chan struct{}
+	err  error
+}
+
+// deduplicator tracks the recent dispersals by content
+type deduplicator struct {
+	config disperser.DedupConfig
+
+	mu      sync.Mutex
+	entries *lru.Cache[dedupKey, dedupEntry]
+	pending map[dedupKey]*pendingDispersal
+	now     func() time.Time
+}
+
+func newDeduplicator(config disperser.DedupConfig) *deduplicator {
+	if !config.Enabled() {
+		return nil
+	}
+	size := config.MaxEntries
+	if size <= 0 {
+		size = defaultDedupEntries
+	}
+	entries, _ := lru.New[dedupKey, dedupEntry](size)
+	return &deduplicator{
+		config:  config,
+		entries: entries,
+		pending: make(map[dedupKey]*pendingDispersal),
+		now:     time.Now,
+	}
+}
+
+// dedupKeyOf addresses a dispersal by the blob data and its security params
+func dedupKeyOf(blob *core.Blob) dedupKey {
+	h := sha256.New()
+	for _, param := range blob.RequestHeader.SecurityParams {
+		var optional byte
+		if param.Optional {
+			optional = 1
+		}
+		h.Write([]byte{param.QuorumID, param.AdversaryThreshold, param.QuorumThreshold, optional})
+	}
+	h.Write([]byte{byte(len(blob.RequestHeader.SecurityParams))})
+	keys := make([]string, 0, len(blob.RequestHeader.Tags))
+	for key := range blob.RequestHeader.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write([]byte(blob.RequestHeader.Tags[key]))
+		h.Write([]byte{0})
+	}
+	h.Write([]byte{byte(len(keys))})
+	h.Write(blob.Data)
+	var key dedupKey
+	h.Sum(key[:0])
+	return key
+}
+
+// lookup returns the request ID of an earlier dispersal of key, waiting for it if it is
+// still being stored. Otherwise it returns the pending dispersal the caller must store and
+// then pass to finish, which the duplicates received meanwhile wait for.
+func (d *deduplicator) lookup(ctx context.Context, key dedupKey) (disperser.BlobKey, *pendingDispersal, error) {
+	for {
+		d.mu.Lock()
+		if p, ok := d.pending[key]; ok {
+			d.mu.Unlock()
+			select {
+			case <-p.done:
+				// look the stored dispersal up, or take over if it failed
+				continue
+			case <-ctx.Done():
+				return disperser.BlobKey{}, nil, ctx.Err()
+			}
+		}
+		if entry, ok := d.entries.Get(key); ok && d.now().Sub(entry.storedAt) < d.config.Window {
+			d.mu.Unlock()
+			return entry.key, nil, nil
+		}
+		p := &pendingDispersal{done: make(chan struct{})}
+		d.pending[key] = p
+		d.mu.Unlock()
+		return disperser.BlobKey{}, p, nil
+	}
+}
+
+// finish records the outcome of a pending dispersal, releasing its duplicates
+func (d *deduplicator) finish(key dedupKey, p *pendingDispersal, blobKey disperser.BlobKey, err error) {
+	d.mu.Lock()
+	delete(d.pending, key)
+	if err == nil {
+		d.entries.Add(key, dedupEntry{key: blobKey, storedAt: d.now()})
+	}
+	d.mu.Unlock()
+	p.err = err
+	close(p.done)
+}
+
+// forget drops an earlier dispersal of key that can't be reused
+func (d *deduplicator) forget(key dedupKey, blobKey disperser.BlobKey) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if entry, ok := d.entries.Peek(key); ok && entry.key == blobKey {
+		d.entries.Remove(key)
+	}
+}
+
+// findDuplicate returns the metadata of a live earlier dispersal of key, or the pending
+// dispersal the caller must store. Earlier dispersals finalized and removed from the blob
+// store are looked up in the kv store, those that failed or were dropped are dispersed again.
+func (s *DispersalServer) findDuplicate(ctx context.Context, key dedupKey) (*disperser.BlobMetadata, *pendingDispersal, error) {
+	for {
+		blobKey, p, err := s.dedup.lookup(ctx, key)
+		if err != nil || p != nil {
+			return nil, p, err
+		}
+		// the earlier dispersal was usually stored moments ago by this server
+		metadata, err := s.blobReader.GetBlobMetadataWithOptions(ctx, blobKey, disperser.ReadOptions{Consistency: disperser.SessionRead})
+		if err == nil {
+			switch metadata.BlobStatus {
+			case disperser.Processing, disperser.Confirmed, disperser.Finalized:
+				return metadata, nil, nil
+			}
+		} else {
+			metadata, err = s.finalizedDuplicate(ctx, blobKey)
+			if err != nil {
+				return nil, nil, err
+			}
+			if metadata != nil {
+				return metadata, nil, nil
+			}
+		}
+		s.dedup.forget(key, blobKey)
+	}
+}
+
+// finalizedDuplicate returns the metadata of an earlier dispersal finalized to the kv store
+// and removed from the blob store, nil if it isn't there
+func (s *DispersalServer) finalizedDuplicate(ctx context.Context, blobKey disperser.BlobKey) (*disperser.BlobMetadata, error) {
+	if s.kvStore == nil {
+		return nil, nil
+	}
+	key := []byte(blobKey.String())
+	_, err := s.kvStore.GetMetadata(ctx, key)
+	if errors.Is(err, disperser.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	confirmation, err := s.getConfirmationFromKv(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if confirmation == nil {
+		// finalized before its confirmation was recorded
+		return &disperser.BlobMetadata{
+			BlobHash:     blobKey.BlobHash,
+			MetadataHash: blobKey.MetadataHash,
+			BlobStatus:   disperser.Finalized,
+		}, nil
+	}
+	return finalizedMetadata(key, confirmation), nil
+}
+
+func setDuplicateHeader(ctx context.Context) {
+	_ = grpc.SetHeader(ctx, metadata.Pairs(DuplicateHeader, "true"))
+}
@@ -0,0 +1,106 @@
+package apiserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDuplicate(t *testing.T) {
+	ctx := context.Background()
+	store := memorydb.NewBlobStore(core.MaxBlobSize*4, mock.NewLogger(false))
+	s := &DispersalServer{blobStore: store, blobReader: store, dedup: newDeduplicator(disperser.DedupConfig{Window: time.Hour})}
+	blob := &core.Blob{Data: []byte("blob")}
+	key := dedupKeyOf(blob)
+	assert.NotEqual(t, key, dedupKeyOf(&core.Blob{Data: []byte("blob"), RequestHeader: core.BlobRequestHeader{SecurityParams: []*core.SecurityParam{{QuorumID: 1}}}}))
+
+	existing, pending, err := s.findDuplicate(ctx, key)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+	require.NotNil(t, pending)
+
+	// a duplicate received while the original is stored waits for it
+	duplicate := make(chan *disperser.BlobMetadata)
+	go func() {
+		existing, _, _ := s.findDuplicate(ctx, key)
+		duplicate <- existing
+	}()
+	blobKey, err := store.StoreBlob(ctx, blob, 1)
+	require.NoError(t, err)
+	s.dedup.finish(key, pending, blobKey, nil)
+	select {
+	case existing = <-duplicate:
+		require.NotNil(t, existing)
+		assert.Equal(t, blobKey, existing.GetBlobKey())
+		assert.Equal(t, disperser.Processing, existing.BlobStatus)
+	case <-time.After(5 * time.Second):
+		t.Fatal("duplicate not released")
+	}
+
+	// failed dispersals are dispersed again
+	require.NoError(t, store.MarkBlobFailed(ctx, blobKey))
+	existing, pending, err = s.findDuplicate(ctx, key)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+	require.NotNil(t, pending)
+	blobKey, err = store.StoreBlob(ctx, blob, 2)
+	require.NoError(t, err)
+	s.dedup.finish(key, pending, blobKey, nil)
+	existing, _, err = s.findDuplicate(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, blobKey, existing.GetBlobKey())
+
+	// and so are the dispersals past the window
+	s.dedup.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	existing, pending, err = s.findDuplicate(ctx, key)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+	assert.NotNil(t, pending)
+}
+
+func TestFindDuplicateFinalized(t *testing.T) {
+	ctx := context.Background()
+	logger := mock.NewLogger(false)
+	store := memorydb.NewBlobStore(core.MaxBlobSize*4, logger)
+	kvStore, err := disperser.NewLevelDBStore(t.TempDir(), 0, logger)
+	require.NoError(t, err)
+	s := &DispersalServer{blobStore: store, blobReader: store, kvStore: kvStore, dedup: newDeduplicator(disperser.DedupConfig{Window: time.Hour})}
+	blob := &core.Blob{Data: []byte("blob")}
+	key := dedupKeyOf(blob)
+
+	_, pending, err := s.findDuplicate(ctx, key)
+	require.NoError(t, err)
+	blobKey, err := store.StoreBlob(ctx, blob, 1)
+	require.NoError(t, err)
+	s.dedup.finish(key, pending, blobKey, nil)
+
+	// the original was finalized to kv and removed from the blob store
+	confirmation, err := (&disperser.BlobConfirmation{BlockNumber: 500, Info: &disperser.ConfirmationInfo{ConfirmationBlockNumber: 500}}).Serialize()
+	require.NoError(t, err)
+	_, err = kvStore.StoreMetadataBatch(ctx, [][]byte{[]byte(blobKey.String())}, [][]byte{[]byte("metadata")}, [][]byte{confirmation}, [][]byte{[]byte("data")})
+	require.NoError(t, err)
+	metadata, err := store.GetBlobMetadata(ctx, blobKey)
+	require.NoError(t, err)
+	require.NoError(t, store.RemoveBlob(ctx, metadata))
+
+	existing, pending, err := s.findDuplicate(ctx, key)
+	require.NoError(t, err)
+	assert.Nil(t, pending)
+	require.NotNil(t, existing)
+	assert.Equal(t, blobKey, existing.GetBlobKey())
+	assert.Equal(t, disperser.Finalized, existing.BlobStatus)
+
+	// without kv, it is dispersed again
+	s.kvStore = nil
+	existing, pending, err = s.findDuplicate(ctx, key)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+	assert.NotNil(t, pending)
+}
@@ -0,0 +1,51 @@
+package apiserver
+
+import (
+	"context"
+	"errors"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/dispersal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// authenticate returns the address of the account which signed a dispersal, empty if the
+// dispersal isn't signed and signatures aren't required. The nonce of the dispersal is used
+// by useNonce.
+func (s *DispersalServer) authenticate(req *pb.DisperseBlobRequest) (string, error) {
+	if len(req.GetSignature()) == 0 {
+		if s.config.RequireSignatures {
+			return "", status.Error(codes.Unauthenticated, "dispersals must be signed")
+		}
+		return "", nil
+	}
+	if s.config.DispersalDomain.IsZero() {
+		return "", status.Error(codes.FailedPrecondition, "the disperser has no dispersal domain to verify signatures for")
+	}
+	signer, err := core.RecoverDispersalSigner(s.config.DispersalDomain, dispersal.SignedRequest(req), req.GetSignature())
+	if errors.Is(err, core.ErrInvalidDispersalSignature) {
+		return "", status.Error(codes.Unauthenticated, err.Error())
+	} else if err != nil {
+		return "", err
+	}
+	return signer.Hex(), nil
+}
+
+// useNonce records the nonce of a signed dispersal in the blob store, rejecting it unless
+// the nonce window of the signer accepts it. It is checked before the dispersal takes its
+// quota and is charged, so that a replayed dispersal is never charged. The nonce is used up
+// before the later checks, so a dispersal rejected after this point must be signed again
+// with a new nonce to be retried.
+func (s *DispersalServer) useNonce(ctx context.Context, signer string, nonce uint64) error {
+	if signer == "" {
+		return nil
+	}
+	err := s.blobStore.UseDispersalNonce(ctx, signer, nonce)
+	if errors.Is(err, disperser.ErrNonceUsed) {
+		return status.Errorf(codes.Unauthenticated, "nonce %d of %s already used", nonce, signer)
+	}
+	return err
+}
@@ -0,0 +1,136 @@
+package apiserver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/0glabs/0g-da-client/disperser/dispersal"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthenticate(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	domain := core.DispersalDomain{ChainID: 16600, Contract: eth_common.HexToAddress("0x01")}
+	s := &DispersalServer{config: disperser.ServerConfig{DispersalDomain: domain}}
+
+	req := &pb.DisperseBlobRequest{
+		Data:           []byte("signed blob"),
+		Nonce:          7,
+		SecurityParams: []*pb.SecurityParams{{QuorumId: 0, AdversaryThreshold: 20, QuorumThreshold: 80}},
+		Tags:           map[string]string{"block": "1"},
+	}
+	req.Signature, err = core.SignDispersal(key, domain, dispersal.SignedRequest(req))
+	require.NoError(t, err)
+	signer, err := s.authenticate(req)
+	require.NoError(t, err)
+	assert.Equal(t, address, signer)
+
+	// the signature covers the whole request
+	for _, tampered := range []*pb.DisperseBlobRequest{
+		{Data: []byte("other blob"), Nonce: 7, SecurityParams: req.SecurityParams, Tags: req.Tags, Signature: req.Signature},
+		{Data: req.Data, Nonce: 8, SecurityParams: req.SecurityParams, Tags: req.Tags, Signature: req.Signature},
+		{Data: req.Data, Nonce: 7, SecurityParams: []*pb.SecurityParams{{QuorumId: 0, AdversaryThreshold: 40, QuorumThreshold: 50}}, Tags: req.Tags, Signature: req.Signature},
+		{Data: req.Data, Nonce: 7, SecurityParams: req.SecurityParams, Tags: map[string]string{"block": "2"}, Signature: req.Signature},
+	} {
+		signer, err := s.authenticate(tampered)
+		if err == nil {
+			assert.NotEqual(t, address, signer)
+		}
+	}
+	_, err = s.authenticate(&pb.DisperseBlobRequest{Data: req.Data, Nonce: 7, Signature: req.Signature[:64]})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// signatures with the recovery id of personal_sign are accepted
+	signature := append([]byte{}, req.Signature...)
+	signature[64] += 27
+	signer, err = s.authenticate(&pb.DisperseBlobRequest{Data: req.Data, Nonce: 7, SecurityParams: req.SecurityParams, Tags: req.Tags, Signature: signature})
+	require.NoError(t, err)
+	assert.Equal(t, address, signer)
+
+	// the signature is bound to the domain of the disperser
+	for _, other := range []core.DispersalDomain{
+		{ChainID: 1, Contract: domain.Contract},
+		{ChainID: domain.ChainID, Contract: eth_common.HexToAddress("0x02")},
+	} {
+		s := &DispersalServer{config: disperser.ServerConfig{DispersalDomain: other}}
+		signer, err := s.authenticate(req)
+		if err == nil {
+			assert.NotEqual(t, address, signer)
+		}
+	}
+	// and rejected by a disperser without one
+	_, err = (&DispersalServer{}).authenticate(req)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// unsigned dispersals are rejected only if signatures are required
+	signer, err = s.authenticate(&pb.DisperseBlobRequest{Data: req.Data})
+	require.NoError(t, err)
+	assert.Empty(t, signer)
+	s.config.RequireSignatures = true
+	_, err = s.authenticate(&pb.DisperseBlobRequest{Data: req.Data})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestDispersalNonces(t *testing.T) {
+	logger := mock.NewLogger(false)
+	store := memorydb.NewBlobStore(1<<30, logger)
+	domain := core.DispersalDomain{ChainID: 16600, Contract: eth_common.HexToAddress("0x01")}
+	config := disperser.ServerConfig{
+		Quotas:          disperser.QuotaConfig{RequestsPerSecond: 1, Burst: time.Second},
+		DispersalDomain: domain,
+	}
+	s := NewDispersalServer(config, store, logger, disperser.NewMetrics("0", logger), nil, RateConfig{}, false, nil, "", nil, nil, nil, nil, nil, nil, nil)
+	now := time.Unix(1700000000, 0)
+	s.quotas.now = func() time.Time { return now }
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signed := func(data string, nonce uint64) *pb.DisperseBlobRequest {
+		req := &pb.DisperseBlobRequest{Data: []byte(data), Nonce: nonce}
+		req.Signature, err = core.SignDispersal(key, domain, dispersal.SignedRequest(req))
+		require.NoError(t, err)
+		return req
+	}
+
+	_, err = s.DisperseBlob(ctx, signed("first", 1000))
+	require.NoError(t, err)
+
+	// a dispersal over the quota uses up its nonce before it is charged, and is signed
+	// again to be retried
+	_, err = s.DisperseBlob(ctx, signed("second", 1002))
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	now = now.Add(time.Second)
+	_, err = s.DisperseBlob(ctx, signed("second", 1002))
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = s.DisperseBlob(ctx, signed("second", 1003))
+	require.NoError(t, err)
+
+	// concurrent dispersals may reach the store out of order
+	now = now.Add(time.Second)
+	_, err = s.DisperseBlob(ctx, signed("third", 1001))
+	require.NoError(t, err)
+
+	// replayed nonces and those below the window are rejected, as recorded in the blob store
+	now = now.Add(time.Second)
+	_, err = s.DisperseBlob(ctx, signed("third", 1001))
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = s.DisperseBlob(ctx, signed("fourth", 1003-disperser.NonceWindowSize))
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.ErrorIs(t, store.UseDispersalNonce(context.Background(), crypto.PubkeyToAddress(key.PublicKey).Hex(), 1003), disperser.ErrNonceUsed)
+}
@@ -0,0 +1,52 @@
+package apiserver
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/core"
+)
+
+// DisperseBlobStream assembles a blob uploaded in chunks and disperses it as DisperseBlob does
+func (s *DispersalServer) DisperseBlobStream(stream pb.Disperser_DisperseBlobStreamServer) error {
+	req, err := receiveBlobChunks(stream)
+	if err != nil {
+		s.logger.Debug("[apiserver] failed to receive a streamed blob", "err", err)
+		return err
+	}
+	reply, err := s.disperseBlob(stream.Context(), "DisperseBlobStream", req)
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(reply)
+}
+
+// receiveBlobChunks concatenates the data of the requests received until the client closes
+// the stream, failing as soon as the blob exceeds the max blob size. The security params
+// the signature and the tags are those of the first request.
+func receiveBlobChunks(stream pb.Disperser_DisperseBlobStreamServer) (*pb.DisperseBlobRequest, error) {
+	req := &pb.DisperseBlobRequest{}
+	var data []byte
+	for first := true; ; first = false {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			req.Data = data
+			return req, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if first {
+			req.SecurityParams = chunk.GetSecurityParams()
+			req.Nonce = chunk.GetNonce()
+			req.Signature = chunk.GetSignature()
+			req.Tags = chunk.GetTags()
+		}
+		if len(data)+len(chunk.GetData()) > core.MaxBlobSize {
+			return nil, fmt.Errorf("blob size cannot exceed %v KiB", core.MaxBlobSize/1024)
+		}
+		data = append(data, chunk.GetData()...)
+	}
+}
@@ -0,0 +1,34 @@
+package apiserver
+
+import (
+	"io"
+	"testing"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/stretchr/testify/assert"
+)
+
+type chunkStream struct {
+	pb.Disperser_DisperseBlobStreamServer
+	chunks [][]byte
+}
+
+func (c *chunkStream) Recv() (*pb.DisperseBlobRequest, error) {
+	if len(c.chunks) == 0 {
+		return nil, io.EOF
+	}
+	chunk := c.chunks[0]
+	c.chunks = c.chunks[1:]
+	return &pb.DisperseBlobRequest{Data: chunk}, nil
+}
+
+func TestReceiveBlobChunks(t *testing.T) {
+	req, err := receiveBlobChunks(&chunkStream{chunks: [][]byte{[]byte("ab"), nil, []byte("cd")}})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("abcd"), req.GetData())
+
+	half := make([]byte, core.MaxBlobSize/2)
+	_, err = receiveBlobChunks(&chunkStream{chunks: [][]byte{half, half, []byte("e")}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,199 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/core"
+	eth_common "github.com/ethereum/go-ethereum/common"
+)
+
+// EncodingEstimate is the encoded size of a blob, letting clients size their blobs to the
+// boundaries of the encoding before dispersing them. The encoder pads every blob to Capacity
+// bytes and erasure codes it into one slice of SliceSize bytes per quorum slot, Slices for
+// the largest quorum the blob is dispersed to.
+type EncodingEstimate struct {
+	BlobSize    uint `json:"blob_size"`
+	Slices      uint `json:"slices"`
+	SliceSize   uint `json:"slice_size"`
+	EncodedSize uint `json:"encoded_size"`
+	// Capacity is the size of the largest blob with the same encoded size, the blob could
+	// grow by PaddingBytes at no cost
+	Capacity     uint `json:"capacity"`
+	PaddingBytes uint `json:"padding_bytes"`
+	// Overhead is the encoded size per byte of the blob
+	Overhead float64           `json:"overhead"`
+	Quorums  []*QuorumEstimate `json:"quorums"`
+}
+
+// QuorumEstimate splits the slices of the encoded blob across the operators of a quorum by
+// the slots they hold
+type QuorumEstimate struct {
+	QuorumID    uint64              `json:"quorum_id"`
+	Slices      int                 `json:"slices"`
+	EncodedSize uint                `json:"encoded_size"`
+	Operators   []*OperatorEstimate `json:"operators"`
+}
+
+type OperatorEstimate struct {
+	Operator string `json:"operator"`
+	Slices   int    `json:"slices"`
+	Bytes    uint64 `json:"bytes"`
+}
+
+// estimateEncoding estimates the encoding of a blob of size bytes dispersed to quorums, to
+// every quorum registered on chain if none. The encoded size depends on the slots of the
+// quorums, so the estimate needs the quorum registry.
+func (s *DispersalServer) estimateEncoding(ctx context.Context, size uint, quorums []uint64) (*EncodingEstimate, error) {
+	params := s.config.EncoderParams
+	if size == 0 || size > params.BlobCapacity {
+		return nil, fmt.Errorf("blob size must be in range [1, %d]", params.BlobCapacity)
+	}
+	if s.quorums == nil {
+		return nil, errQuorumsUnavailable
+	}
+
+	count, err := s.quorums.QuorumCount(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errQuorumsUnavailable, err)
+	}
+	if len(quorums) == 0 {
+		for id := uint64(0); id < count; id++ {
+			quorums = append(quorums, id)
+		}
+	}
+	estimate := &EncodingEstimate{BlobSize: size, SliceSize: params.SliceSize}
+	for _, id := range quorums {
+		if id >= count {
+			return nil, fmt.Errorf("quorum %d is not registered, %d quorums are", id, count)
+		}
+		slots, err := s.quorums.QuorumSlots(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errQuorumsUnavailable, err)
+		}
+		quorum := estimateQuorum(id, slots, params)
+		estimate.Quorums = append(estimate.Quorums, quorum)
+		// the quorums sign the slices of the same encoding, which has a slice for each slot
+		// of the largest
+		if uint(quorum.Slices) > estimate.Slices {
+			encoding := params.Estimate(uint(quorum.Slices))
+			estimate.Slices = encoding.Slices
+			estimate.EncodedSize = encoding.EncodedSize
+			estimate.Capacity = encoding.Capacity
+		}
+	}
+	if estimate.Slices == 0 {
+		return nil, fmt.Errorf("%w: no quorum slots", errQuorumsUnavailable)
+	}
+	estimate.PaddingBytes = estimate.Capacity - size
+	estimate.Overhead = float64(estimate.EncodedSize) / float64(size)
+	return estimate, nil
+}
+
+var errQuorumsUnavailable = errors.New("quorums unavailable")
+
+// estimateQuorum splits the slices of a blob encoded with params across the signers of the
+// slots of a quorum, the largest share first
+func estimateQuorum(id uint64, slots []eth_common.Address, params core.EncoderParams) *QuorumEstimate {
+	slices := make(map[string]int)
+	for _, signer := range slots {
+		slices[signer.Hex()]++
+	}
+	quorum := &QuorumEstimate{
+		QuorumID:    id,
+		Slices:      len(slots),
+		EncodedSize: params.Estimate(uint(len(slots))).EncodedSize,
+		Operators:   make([]*OperatorEstimate, 0, len(slices)),
+	}
+	for operator, n := range slices {
+		quorum.Operators = append(quorum.Operators, &OperatorEstimate{
+			Operator: operator,
+			Slices:   n,
+			Bytes:    uint64(n) * uint64(params.SliceSize),
+		})
+	}
+	sort.Slice(quorum.Operators, func(i, j int) bool {
+		a, b := quorum.Operators[i], quorum.Operators[j]
+		if a.Slices != b.Slices {
+			return a.Slices > b.Slices
+		}
+		return a.Operator < b.Operator
+	})
+	return quorum
+}
+
+// parseQuorums reads a comma separated list of quorum IDs
+func parseQuorums(value string) ([]uint64, error) {
+	var quorums []uint64
+	seen := make(map[uint64]bool)
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quorum %q", part)
+		}
+		if !seen[id] {
+			seen[id] = true
+			quorums = append(quorums, id)
+		}
+	}
+	return quorums, nil
+}
+
+// handleEstimate serves GET /estimate?size={blobSize}&quorums={quorumID,...}
+func (s *DispersalServer) handleEstimate(w http.ResponseWriter, r *http.Request) {
+	const method = "EstimateEncoding"
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	origin, err := common.GetHTTPClientAddress(r, s.rateConfig.ClientIPHeader, 2, true)
+	if err != nil {
+		s.metrics.HandleFailedRequest(0, method)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !s.readRateLimiterManager.GetRateLimiter(origin).Allow() {
+		s.metrics.HandleFailedRequest(0, method)
+		http.Error(w, "request ratelimited", http.StatusTooManyRequests)
+		return
+	}
+
+	size, err := strconv.ParseUint(r.URL.Query().Get("size"), 10, 32)
+	if err != nil {
+		s.metrics.HandleFailedRequest(0, method)
+		http.Error(w, "invalid size", http.StatusBadRequest)
+		return
+	}
+	quorums, err := parseQuorums(r.URL.Query().Get("quorums"))
+	if err != nil {
+		s.metrics.HandleFailedRequest(0, method)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	estimate, err := s.estimateEncoding(r.Context(), uint(size), quorums)
+	if errors.Is(err, errQuorumsUnavailable) {
+		s.logger.Error("[apiserver] failed to read the quorums of an estimate", "err", err)
+		s.metrics.HandleFailedRequest(0, method)
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		s.metrics.HandleFailedRequest(0, method)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.metrics.HandleSuccessfulRequest(0, method)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(estimate)
+}
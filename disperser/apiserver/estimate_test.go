@@ -0,0 +1,60 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateEncoding(t *testing.T) {
+	ctx := context.Background()
+	a, b := eth_common.HexToAddress("0x01"), eth_common.HexToAddress("0x02")
+	s := &DispersalServer{
+		config:  disperser.ServerConfig{EncoderParams: core.DefaultEncoderParams},
+		quorums: NewQuorumRegistry(&fakeQuorumReader{quorums: 2, slots: [][]eth_common.Address{{a, b, a, a}, {b}}}),
+		logger:  mock.NewLogger(false),
+	}
+
+	estimate, err := s.estimateEncoding(ctx, 100, []uint64{0})
+	require.NoError(t, err)
+	golden, err := os.ReadFile("testdata/estimate.json")
+	require.NoError(t, err)
+	encoded, err := json.Marshal(estimate)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(golden), string(encoded))
+
+	// the encoded size is that of the slices of an encoder reply, one per slot
+	slices := make([][]byte, 4)
+	for i := range slices {
+		slices[i] = make([]byte, core.DefaultEncoderParams.SliceSize)
+	}
+	assert.Equal(t, core.EncodedSize(slices), estimate.EncodedSize)
+
+	// the blob is padded to the capacity, so its encoded size doesn't grow with it, and
+	// the quorums share the slices of the largest
+	largest, err := s.estimateEncoding(ctx, core.MaxBlobSize, nil)
+	require.NoError(t, err)
+	assert.Equal(t, estimate.EncodedSize, largest.EncodedSize)
+	assert.Zero(t, largest.PaddingBytes)
+	require.Len(t, largest.Quorums, 2)
+	assert.Equal(t, core.DefaultEncoderParams.SliceSize, largest.Quorums[1].EncodedSize)
+
+	_, err = s.estimateEncoding(ctx, 0, nil)
+	assert.Error(t, err)
+	_, err = s.estimateEncoding(ctx, core.MaxBlobSize+1, nil)
+	assert.Error(t, err)
+	_, err = s.estimateEncoding(ctx, 100, []uint64{2})
+	assert.Error(t, err)
+
+	s.quorums = nil
+	_, err = s.estimateEncoding(ctx, 100, nil)
+	assert.ErrorIs(t, err, errQuorumsUnavailable)
+}
@@ -0,0 +1,24 @@
+package apiserver
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// KVStateHeader is set on the GetBlobStatus replies of finalized blobs to report whether
+// the blob has been persisted to the kv db it is served from after finalization
+const KVStateHeader = "x-zgda-kv-state"
+
+const (
+	// KVStatePending means the blob is finalized but its kv write has not been verified yet;
+	// the finalizer retries it until it is
+	KVStatePending = "pending"
+	// KVStateStored means the blob was written to the kv db and read back
+	KVStateStored = "stored"
+)
+
+func setKVStateHeader(ctx context.Context, state string) {
+	_ = grpc.SetHeader(ctx, metadata.Pairs(KVStateHeader, state))
+}
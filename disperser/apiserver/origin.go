@@ -0,0 +1,49 @@
+package apiserver
+
+import (
+	"context"
+	"crypto/subtle"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/disperser/dispersal"
+	"google.golang.org/grpc/metadata"
+)
+
+// clientAddress returns the address of the client of a grpc request: the origin relayed by an
+// authenticated gateway, or else the address of the client ip header or of the connection
+func (s *DispersalServer) clientAddress(ctx context.Context) (string, error) {
+	if origin, ok := s.gatewayOrigin(ctx); ok {
+		return origin, nil
+	}
+	return common.GetClientAddress(ctx, s.rateConfig.ClientIPHeader, 2, true)
+}
+
+// peerAddress returns the address the accounts of the requests without an API key nor a
+// signer are keyed on: the origin relayed by an authenticated gateway, or else the address
+// of the connection, which unlike the client ip header can't be spoofed by the client
+func (s *DispersalServer) peerAddress(ctx context.Context) (string, error) {
+	if origin, ok := s.gatewayOrigin(ctx); ok {
+		return origin, nil
+	}
+	return common.GetClientAddress(ctx, "", 0, true)
+}
+
+// gatewayOrigin returns the address of the client a gateway relays a request for, if the
+// request carries the gateway token
+func (s *DispersalServer) gatewayOrigin(ctx context.Context) (string, bool) {
+	if s.config.GatewayToken == "" {
+		return "", false
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	tokens, origins := md.Get(dispersal.GatewayTokenHeader), md.Get(dispersal.OriginHeader)
+	if len(tokens) != 1 || len(origins) != 1 || origins[0] == "" {
+		return "", false
+	}
+	if subtle.ConstantTimeCompare([]byte(tokens[0]), []byte(s.config.GatewayToken)) != 1 {
+		return "", false
+	}
+	return origins[0], true
+}
@@ -0,0 +1,35 @@
+package apiserver
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/dispersal"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestClientAddress(t *testing.T) {
+	s := &DispersalServer{config: disperser.ServerConfig{GatewayToken: "secret"}, rateConfig: RateConfig{ClientIPHeader: "x-forwarded-for"}}
+	call := func(pairs ...string) context.Context {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1}})
+		return metadata.NewIncomingContext(ctx, metadata.Pairs(pairs...))
+	}
+
+	// the origin relayed by the gateway is trusted along with its token only
+	addr, err := s.clientAddress(call(dispersal.GatewayTokenHeader, "secret", dispersal.OriginHeader, "1.2.3.4"))
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3.4", addr)
+
+	addr, err = s.clientAddress(call(dispersal.GatewayTokenHeader, "guess", dispersal.OriginHeader, "1.2.3.4"))
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", addr)
+
+	s.config.GatewayToken = ""
+	addr, err = s.clientAddress(call(dispersal.GatewayTokenHeader, "", dispersal.OriginHeader, "1.2.3.4"))
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", addr)
+}
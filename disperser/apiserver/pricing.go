@@ -0,0 +1,383 @@
+package apiserver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/dispersal"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/urfave/cli"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	PricingEnabledFlagName         = "pricing.enabled"
+	PricingHTTPPortFlagName        = "pricing.http-port"
+	PricingBaseFeeFlagName         = "pricing.base-fee-per-byte"
+	PricingBacklogTargetFlagName   = "pricing.backlog-target"
+	PricingReferenceGasFlagName    = "pricing.reference-gas-price"
+	PricingGasRPCFlagName          = "pricing.gas-rpc"
+	PricingQuoteTTLFlagName        = "pricing.quote-ttl"
+	PricingRefreshIntervalFlagName = "pricing.refresh-interval"
+	PricingSecretFlagName          = "pricing.secret"
+)
+
+var (
+	ErrPriceQuoteRequired = errors.New("price quote required")
+	ErrInvalidPriceQuote  = errors.New("invalid price quote")
+	ErrPriceQuoteExpired  = errors.New("price quote expired")
+	ErrPriceQuoteMismatch = errors.New("price quote issued for another account or a smaller blob")
+)
+
+type PricingConfig struct {
+	Enabled  bool
+	HTTPPort string
+	// BaseFeePerByte is the fee per byte when there is no backlog and gas is at the reference price
+	BaseFeePerByte *big.Int
+	// BacklogTarget is the number of processing blobs at which the fee doubles
+	BacklogTarget uint64
+	// ReferenceGasPrice is the gas price above which the fee scales with gas. Gas is ignored if zero.
+	ReferenceGasPrice *big.Int
+	GasRPC            string
+	QuoteTTL          time.Duration
+	RefreshInterval   time.Duration
+	// Secret signs the quotes. A random secret is generated if empty, which invalidates
+	// outstanding quotes on restart and can't be shared between disperser instances.
+	Secret []byte
+}
+
+func PricingCLIFlags(envPrefix string, flagPrefix string) []cli.Flag {
+	return []cli.Flag{
+		cli.BoolFlag{
+			Name:   common.PrefixFlag(flagPrefix, PricingEnabledFlagName),
+			Usage:  "require DisperseBlob requests to carry a signed price quote",
+			EnvVar: common.PrefixEnvVar(envPrefix, "PRICING_ENABLED"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, PricingHTTPPortFlagName),
+			Usage:  "port of the http server handing out price quotes",
+			Value:  "9200",
+			EnvVar: common.PrefixEnvVar(envPrefix, "PRICING_HTTP_PORT"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, PricingBaseFeeFlagName),
+			Usage:  "fee per byte in wei without backlog or gas surcharge",
+			Value:  "1",
+			EnvVar: common.PrefixEnvVar(envPrefix, "PRICING_BASE_FEE_PER_BYTE"),
+		},
+		cli.Uint64Flag{
+			Name:   common.PrefixFlag(flagPrefix, PricingBacklogTargetFlagName),
+			Usage:  "number of processing blobs at which the fee doubles",
+			Value:  1000,
+			EnvVar: common.PrefixEnvVar(envPrefix, "PRICING_BACKLOG_TARGET"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, PricingReferenceGasFlagName),
+			Usage:  "gas price in wei above which the fee scales with gas, 0 to ignore gas",
+			Value:  "0",
+			EnvVar: common.PrefixEnvVar(envPrefix, "PRICING_REFERENCE_GAS_PRICE"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, PricingGasRPCFlagName),
+			Usage:  "chain rpc used to query the gas price",
+			EnvVar: common.PrefixEnvVar(envPrefix, "PRICING_GAS_RPC"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, PricingQuoteTTLFlagName),
+			Usage:  "how long a price quote is honored",
+			Value:  time.Minute,
+			EnvVar: common.PrefixEnvVar(envPrefix, "PRICING_QUOTE_TTL"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, PricingRefreshIntervalFlagName),
+			Usage:  "interval at which the backlog and gas price are sampled",
+			Value:  10 * time.Second,
+			EnvVar: common.PrefixEnvVar(envPrefix, "PRICING_REFRESH_INTERVAL"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, PricingSecretFlagName),
+			Usage:  "hex encoded secret used to sign price quotes",
+			EnvVar: common.PrefixEnvVar(envPrefix, "PRICING_SECRET"),
+		},
+	}
+}
+
+func ReadPricingConfig(ctx *cli.Context, flagPrefix string) (PricingConfig, error) {
+	baseFee, ok := new(big.Int).SetString(ctx.GlobalString(common.PrefixFlag(flagPrefix, PricingBaseFeeFlagName)), 10)
+	if !ok {
+		return PricingConfig{}, fmt.Errorf("invalid %s", PricingBaseFeeFlagName)
+	}
+	referenceGas, ok := new(big.Int).SetString(ctx.GlobalString(common.PrefixFlag(flagPrefix, PricingReferenceGasFlagName)), 10)
+	if !ok {
+		return PricingConfig{}, fmt.Errorf("invalid %s", PricingReferenceGasFlagName)
+	}
+	var secret []byte
+	if s := ctx.GlobalString(common.PrefixFlag(flagPrefix, PricingSecretFlagName)); s != "" {
+		var err error
+		secret, err = hexutil.Decode(s)
+		if err != nil {
+			return PricingConfig{}, fmt.Errorf("invalid %s: %w", PricingSecretFlagName, err)
+		}
+	}
+	return PricingConfig{
+		Enabled:           ctx.GlobalBool(common.PrefixFlag(flagPrefix, PricingEnabledFlagName)),
+		HTTPPort:          ctx.GlobalString(common.PrefixFlag(flagPrefix, PricingHTTPPortFlagName)),
+		BaseFeePerByte:    baseFee,
+		BacklogTarget:     ctx.GlobalUint64(common.PrefixFlag(flagPrefix, PricingBacklogTargetFlagName)),
+		ReferenceGasPrice: referenceGas,
+		GasRPC:            ctx.GlobalString(common.PrefixFlag(flagPrefix, PricingGasRPCFlagName)),
+		QuoteTTL:          ctx.GlobalDuration(common.PrefixFlag(flagPrefix, PricingQuoteTTLFlagName)),
+		RefreshInterval:   ctx.GlobalDuration(common.PrefixFlag(flagPrefix, PricingRefreshIntervalFlagName)),
+		Secret:            secret,
+	}, nil
+}
+
+// GasPriceOracle is satisfied by an ethclient.Client
+type GasPriceOracle interface {
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+}
+
+// PriceQuote is the price a blob of up to Size bytes of Account is dispersed at until
+// ExpiresAt, all of which the token of the quote signs
+type PriceQuote struct {
+	FeePerByte *big.Int `json:"fee_per_byte"`
+	Size       uint64   `json:"size"`
+	Account    string   `json:"account"`
+	ExpiresAt  int64    `json:"expires_at"`
+}
+
+// covers tells whether the quote applies to a blob of size bytes of account. The signer
+// accounts are compared as addresses, whatever their case.
+func (q *PriceQuote) covers(size uint64, account string) bool {
+	if size > q.Size {
+		return false
+	}
+	if eth_common.IsHexAddress(q.Account) && eth_common.IsHexAddress(account) {
+		return eth_common.HexToAddress(q.Account) == eth_common.HexToAddress(account)
+	}
+	return q.Account == account
+}
+
+// Pricer quotes a per-byte fee that rises with the number of blobs waiting to be
+// dispersed and with the gas price. Quotes are signed so that DisperseBlob can honor
+// a quote until it expires without keeping track of the quotes it handed out.
+type Pricer struct {
+	config    PricingConfig
+	blobStore disperser.BlobStoreReader
+	gasOracle GasPriceOracle
+	logger    common.Logger
+
+	mu         sync.RWMutex
+	feePerByte *big.Int
+	backlog    int
+	gasPrice   *big.Int
+}
+
+// NewPricer creates a pricer. gasOracle may be nil, in which case gas doesn't affect the fee.
+func NewPricer(config PricingConfig, blobStore disperser.BlobStoreReader, gasOracle GasPriceOracle, logger common.Logger) (*Pricer, error) {
+	if config.BacklogTarget == 0 {
+		return nil, errors.New("pricing backlog target must be positive")
+	}
+	if len(config.Secret) == 0 {
+		config.Secret = make([]byte, 32)
+		if _, err := rand.Read(config.Secret); err != nil {
+			return nil, err
+		}
+	}
+	return &Pricer{
+		config:     config,
+		blobStore:  blobStore,
+		gasOracle:  gasOracle,
+		logger:     logger,
+		feePerByte: new(big.Int).Set(config.BaseFeePerByte),
+	}, nil
+}
+
+func (p *Pricer) Start(ctx context.Context) {
+	p.refresh(ctx)
+	go func() {
+		ticker := time.NewTicker(p.config.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.refresh(ctx)
+			}
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/price", p.handlePrice)
+	go func() {
+		addr := fmt.Sprintf("%s:%s", disperser.Localhost, p.config.HTTPPort)
+		p.logger.Info("[apiserver] pricing server listening", "address", addr)
+		err := http.ListenAndServe(addr, mux)
+		p.logger.Error("[apiserver] pricing server failed", "err", err)
+	}()
+}
+
+func (p *Pricer) refresh(ctx context.Context) {
+	processing, err := p.blobStore.GetBlobMetadataByStatus(ctx, disperser.Processing)
+	if err != nil {
+		p.logger.Warn("[apiserver] failed to sample backlog for pricing", "err", err)
+		return
+	}
+	var gasPrice *big.Int
+	if p.gasOracle != nil {
+		gasPrice, err = p.gasOracle.SuggestGasPrice(ctx)
+		if err != nil {
+			p.logger.Warn("[apiserver] failed to sample gas price for pricing", "err", err)
+			gasPrice = nil
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.backlog = len(processing)
+	if gasPrice != nil {
+		p.gasPrice = gasPrice
+	}
+	p.feePerByte = computeFeePerByte(p.config, p.backlog, p.gasPrice)
+}
+
+// computeFeePerByte returns base * (1 + backlog/backlogTarget) * max(1, gasPrice/referenceGasPrice)
+func computeFeePerByte(config PricingConfig, backlog int, gasPrice *big.Int) *big.Int {
+	target := new(big.Int).SetUint64(config.BacklogTarget)
+	fee := new(big.Int).Add(target, big.NewInt(int64(backlog)))
+	fee.Mul(fee, config.BaseFeePerByte)
+	fee.Div(fee, target)
+
+	if gasPrice != nil && config.ReferenceGasPrice != nil && config.ReferenceGasPrice.Sign() > 0 && gasPrice.Cmp(config.ReferenceGasPrice) > 0 {
+		fee.Mul(fee, gasPrice)
+		fee.Div(fee, config.ReferenceGasPrice)
+	}
+	return fee
+}
+
+// Quote returns the current price of a blob of up to size bytes of account and the signed
+// token to attach to DisperseBlob
+func (p *Pricer) Quote(size uint64, account string) (*PriceQuote, string, error) {
+	p.mu.RLock()
+	quote := &PriceQuote{
+		FeePerByte: new(big.Int).Set(p.feePerByte),
+		Size:       size,
+		Account:    account,
+		ExpiresAt:  time.Now().Add(p.config.QuoteTTL).Unix(),
+	}
+	p.mu.RUnlock()
+
+	payload, err := json.Marshal(quote)
+	if err != nil {
+		return nil, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(p.sign(payload))
+	return quote, token, nil
+}
+
+// VerifyQuote checks the signature and expiry of a token returned by Quote, and that it was
+// issued for a blob of at least size bytes of account
+func (p *Pricer) VerifyQuote(token string, size uint64, account string) (*PriceQuote, error) {
+	encodedPayload, encodedSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidPriceQuote
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, ErrInvalidPriceQuote
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encodedSig)
+	if err != nil || !hmac.Equal(sig, p.sign(payload)) {
+		return nil, ErrInvalidPriceQuote
+	}
+
+	quote := &PriceQuote{}
+	if err := json.Unmarshal(payload, quote); err != nil {
+		return nil, ErrInvalidPriceQuote
+	}
+	if time.Now().Unix() > quote.ExpiresAt {
+		return nil, ErrPriceQuoteExpired
+	}
+	if !quote.covers(size, account) {
+		return nil, ErrPriceQuoteMismatch
+	}
+	return quote, nil
+}
+
+// verifyRequestQuote verifies the quote attached to the grpc request metadata against the
+// size and account of the blob
+func (p *Pricer) verifyRequestQuote(ctx context.Context, size uint64, account string) (*PriceQuote, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md.Get(dispersal.PriceQuoteHeader)) == 0 {
+		return nil, ErrPriceQuoteRequired
+	}
+	return p.VerifyQuote(md.Get(dispersal.PriceQuoteHeader)[0], size, account)
+}
+
+func (p *Pricer) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, p.config.Secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+type priceReply struct {
+	FeePerByte string `json:"fee_per_byte"`
+	TotalFee   string `json:"total_fee"`
+	Size       uint64 `json:"size"`
+	Account    string `json:"account"`
+	ExpiresAt  int64  `json:"expires_at"`
+	Backlog    int    `json:"backlog"`
+	Quote      string `json:"quote"`
+}
+
+// handlePrice serves GET /price?size={blobSize}&account={account}. The quote holds for the
+// blobs of up to size bytes of the account, the signer address of the signed dispersals,
+// "key:" and the name of the API key of the others, or else the client address.
+func (p *Pricer) handlePrice(w http.ResponseWriter, r *http.Request) {
+	size, err := strconv.ParseUint(r.URL.Query().Get("size"), 10, 64)
+	if err != nil || size == 0 {
+		http.Error(w, "invalid size", http.StatusBadRequest)
+		return
+	}
+	account := r.URL.Query().Get("account")
+	if account == "" {
+		http.Error(w, "missing account", http.StatusBadRequest)
+		return
+	}
+	quote, token, err := p.Quote(size, account)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	p.mu.RLock()
+	reply := priceReply{
+		FeePerByte: quote.FeePerByte.String(),
+		TotalFee:   new(big.Int).Mul(quote.FeePerByte, new(big.Int).SetUint64(size)).String(),
+		Size:       size,
+		Account:    account,
+		ExpiresAt:  quote.ExpiresAt,
+		Backlog:    p.backlog,
+		Quote:      token,
+	}
+	p.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(reply)
+}
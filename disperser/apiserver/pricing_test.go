@@ -0,0 +1,130 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/0glabs/0g-da-client/disperser/dispersal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestComputeFeePerByte(t *testing.T) {
+	config := PricingConfig{
+		BaseFeePerByte:    big.NewInt(100),
+		BacklogTarget:     10,
+		ReferenceGasPrice: big.NewInt(1000),
+	}
+	assert.Equal(t, big.NewInt(100), computeFeePerByte(config, 0, nil))
+	assert.Equal(t, big.NewInt(200), computeFeePerByte(config, 10, nil))
+	// gas below the reference price doesn't lower the fee
+	assert.Equal(t, big.NewInt(150), computeFeePerByte(config, 5, big.NewInt(500)))
+	assert.Equal(t, big.NewInt(600), computeFeePerByte(config, 10, big.NewInt(3000)))
+}
+
+func TestPriceQuote(t *testing.T) {
+	pricer, err := NewPricer(PricingConfig{
+		BaseFeePerByte: big.NewInt(7),
+		BacklogTarget:  10,
+		QuoteTTL:       time.Minute,
+	}, nil, nil, mock.NewLogger(false))
+	assert.NoError(t, err)
+
+	account := "0x00000000000000000000000000000000000000aB"
+	quote, token, err := pricer.Quote(100, account)
+	assert.NoError(t, err)
+	verified, err := pricer.VerifyQuote(token, 100, account)
+	assert.NoError(t, err)
+	assert.Equal(t, quote.FeePerByte, verified.FeePerByte)
+	// a smaller blob of the account, its address in another case, is covered
+	_, err = pricer.VerifyQuote(token, 60, strings.ToLower(account))
+	assert.NoError(t, err)
+
+	// the size and account are signed into the token
+	_, err = pricer.VerifyQuote(token, 101, account)
+	assert.ErrorIs(t, err, ErrPriceQuoteMismatch)
+	_, err = pricer.VerifyQuote(token, 100, "key:other")
+	assert.ErrorIs(t, err, ErrPriceQuoteMismatch)
+	payload, sig, _ := strings.Cut(token, ".")
+	_, err = pricer.VerifyQuote(payload+"x."+sig, 100, account)
+	assert.ErrorIs(t, err, ErrInvalidPriceQuote)
+	_, other, err := pricer.Quote(1000, "key:other")
+	assert.NoError(t, err)
+	otherPayload, _, _ := strings.Cut(other, ".")
+	_, err = pricer.VerifyQuote(otherPayload+"."+sig, 1000, "key:other")
+	assert.ErrorIs(t, err, ErrInvalidPriceQuote)
+
+	pricer.config.QuoteTTL = -time.Minute
+	_, token, err = pricer.Quote(100, account)
+	assert.NoError(t, err)
+	_, err = pricer.VerifyQuote(token, 100, account)
+	assert.ErrorIs(t, err, ErrPriceQuoteExpired)
+}
+
+func TestHandlePrice(t *testing.T) {
+	pricer, err := NewPricer(PricingConfig{
+		BaseFeePerByte: big.NewInt(7),
+		BacklogTarget:  10,
+		QuoteTTL:       time.Minute,
+	}, nil, nil, mock.NewLogger(false))
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	pricer.handlePrice(w, httptest.NewRequest(http.MethodGet, "/price?size=100", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	pricer.handlePrice(w, httptest.NewRequest(http.MethodGet, "/price?size=100&account=key:rollup", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var reply priceReply
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&reply))
+	assert.Equal(t, "700", reply.TotalFee)
+	_, err = pricer.VerifyQuote(reply.Quote, 100, "key:rollup")
+	assert.NoError(t, err)
+}
+
+func TestDisperseUnderPriceQuote(t *testing.T) {
+	logger := mock.NewLogger(false)
+	pricer, err := NewPricer(PricingConfig{
+		BaseFeePerByte: big.NewInt(7),
+		BacklogTarget:  10,
+		QuoteTTL:       time.Minute,
+	}, nil, nil, logger)
+	require.NoError(t, err)
+	s := NewDispersalServer(disperser.ServerConfig{}, memorydb.NewBlobStore(1<<30, logger), logger, disperser.NewMetrics("0", logger), nil, RateConfig{}, false, nil, "", pricer, nil, nil, nil, nil, nil, nil)
+	peerCtx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+	withQuote := func(token string) context.Context {
+		return metadata.NewIncomingContext(peerCtx, metadata.Pairs(dispersal.PriceQuoteHeader, token))
+	}
+
+	_, err = s.DisperseBlob(peerCtx, &pb.DisperseBlobRequest{Data: []byte("blob")})
+	assert.ErrorIs(t, err, ErrPriceQuoteRequired)
+
+	// a quote of another account or of a smaller blob isn't honored
+	_, token, err := pricer.Quote(4, "10.0.0.2")
+	require.NoError(t, err)
+	_, err = s.DisperseBlob(withQuote(token), &pb.DisperseBlobRequest{Data: []byte("blob")})
+	assert.ErrorIs(t, err, ErrPriceQuoteMismatch)
+	_, token, err = pricer.Quote(3, "10.0.0.1")
+	require.NoError(t, err)
+	_, err = s.DisperseBlob(withQuote(token), &pb.DisperseBlobRequest{Data: []byte("blob")})
+	assert.ErrorIs(t, err, ErrPriceQuoteMismatch)
+
+	_, token, err = pricer.Quote(4, "10.0.0.1")
+	require.NoError(t, err)
+	_, err = s.DisperseBlob(withQuote(token), &pb.DisperseBlobRequest{Data: []byte("blob")})
+	assert.NoError(t, err)
+}
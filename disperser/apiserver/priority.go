@@ -0,0 +1,30 @@
+package apiserver
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser/dispersal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// requestPriority reads the priority lane a request of account asks for. Only the priority
+// accounts may ask for a lane above the default one, which are API keys or signers: the
+// client addresses aren't authenticated.
+func (s *DispersalServer) requestPriority(ctx context.Context, account string) (uint8, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md.Get(dispersal.PriorityHeader)) == 0 {
+		return 0, nil
+	}
+	priority, err := strconv.ParseUint(md.Get(dispersal.PriorityHeader)[0], 10, 8)
+	if err != nil || priority > uint64(core.MaxBlobPriority) {
+		return 0, status.Errorf(codes.InvalidArgument, "priority must be between 0 and %d", core.MaxBlobPriority)
+	}
+	if priority > 0 && !accountIn(s.priorityAccounts, account) {
+		return 0, status.Error(codes.PermissionDenied, "account is not allowed to submit priority blobs")
+	}
+	return uint8(priority), nil
+}
@@ -0,0 +1,34 @@
+package apiserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/0glabs/0g-da-client/disperser/dispersal"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestRequestPriority(t *testing.T) {
+	signer := "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	s := &DispersalServer{priorityAccounts: newAccountSet([]string{"rollup", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", "10.0.0.1"})}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(dispersal.PriorityHeader, "2"))
+
+	for _, account := range []string{apiKeyAccountPrefix + "rollup", signer} {
+		priority, err := s.requestPriority(ctx, account)
+		assert.NoError(t, err, account)
+		assert.Equal(t, uint8(2), priority, account)
+	}
+	// the client addresses aren't authenticated, even if listed
+	for _, account := range []string{"10.0.0.1", "rollup", apiKeyAccountPrefix + "other"} {
+		_, err := s.requestPriority(ctx, account)
+		assert.Equal(t, codes.PermissionDenied, status.Code(err), account)
+	}
+
+	// the default lane needs no permission
+	priority, err := s.requestPriority(context.Background(), "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Zero(t, priority)
+}
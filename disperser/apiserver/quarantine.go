@@ -0,0 +1,429 @@
+package apiserver
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/approval"
+	"github.com/0glabs/0g-da-client/disperser"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/urfave/cli"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// QuarantinedHeader is set to "true" on the replies of DisperseBlob and GetBlobStatus
+	// while a blob is held in quarantine. The status stays PROCESSING for older clients.
+	QuarantinedHeader = "x-zgda-quarantined"
+
+	QuarantineEnabledFlagName           = "quarantine.enabled"
+	QuarantineHTTPPortFlagName          = "quarantine.http-port"
+	QuarantineAdminTokenFlagName        = "quarantine.admin-token"
+	QuarantineMinBlobSizeFlagName       = "quarantine.min-blob-size"
+	QuarantineMaxBlobSizeFlagName       = "quarantine.max-blob-size"
+	QuarantineAccountRateLimitFlagName  = "quarantine.account-rate-limit"
+	QuarantineAccountRateWindowFlagName = "quarantine.account-rate-window"
+	QuarantineDenylistFileFlagName      = "quarantine.denylist-file"
+)
+
+var errNotQuarantined = errors.New("blob is not quarantined")
+
+type QuarantineConfig struct {
+	Enabled bool
+	// HTTPPort serves the admin API reviewing quarantined blobs
+	HTTPPort string
+	// AdminToken must be sent as a bearer token to the admin API, unless approval admins are
+	// configured, whose tokens are accepted in its place
+	AdminToken string
+	// Approval gates the releases and rejections behind the approval of several admins
+	Approval approval.Config
+	// Blobs smaller than MinBlobSize or larger than MaxBlobSize are quarantined. 0 disables a bound.
+	MinBlobSize int
+	MaxBlobSize int
+	// Blobs of an account beyond AccountRateLimit within AccountRateWindow are quarantined.
+	// 0 disables the check.
+	AccountRateLimit  int
+	AccountRateWindow time.Duration
+	// DenylistFile holds hex encoded sha256 hashes of contents to quarantine, one per line
+	DenylistFile string
+}
+
+func QuarantineCLIFlags(envPrefix string, flagPrefix string) []cli.Flag {
+	flags := []cli.Flag{
+		cli.BoolFlag{
+			Name:   common.PrefixFlag(flagPrefix, QuarantineEnabledFlagName),
+			Usage:  "hold blobs matching the quarantine predicates for review before encoding",
+			EnvVar: common.PrefixEnvVar(envPrefix, "QUARANTINE_ENABLED"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, QuarantineHTTPPortFlagName),
+			Usage:  "port of the admin api reviewing quarantined blobs",
+			Value:  "9300",
+			EnvVar: common.PrefixEnvVar(envPrefix, "QUARANTINE_HTTP_PORT"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, QuarantineAdminTokenFlagName),
+			Usage:  "bearer token required by the quarantine admin api, unless approval admins are configured",
+			EnvVar: common.PrefixEnvVar(envPrefix, "QUARANTINE_ADMIN_TOKEN"),
+		},
+		cli.IntFlag{
+			Name:   common.PrefixFlag(flagPrefix, QuarantineMinBlobSizeFlagName),
+			Usage:  "quarantine blobs smaller than this many bytes, 0 to disable",
+			EnvVar: common.PrefixEnvVar(envPrefix, "QUARANTINE_MIN_BLOB_SIZE"),
+		},
+		cli.IntFlag{
+			Name:   common.PrefixFlag(flagPrefix, QuarantineMaxBlobSizeFlagName),
+			Usage:  "quarantine blobs larger than this many bytes, 0 to disable",
+			EnvVar: common.PrefixEnvVar(envPrefix, "QUARANTINE_MAX_BLOB_SIZE"),
+		},
+		cli.IntFlag{
+			Name:   common.PrefixFlag(flagPrefix, QuarantineAccountRateLimitFlagName),
+			Usage:  "quarantine blobs of an account beyond this many per rate window, 0 to disable",
+			EnvVar: common.PrefixEnvVar(envPrefix, "QUARANTINE_ACCOUNT_RATE_LIMIT"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, QuarantineAccountRateWindowFlagName),
+			Usage:  "window of the per account rate check",
+			Value:  time.Minute,
+			EnvVar: common.PrefixEnvVar(envPrefix, "QUARANTINE_ACCOUNT_RATE_WINDOW"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, QuarantineDenylistFileFlagName),
+			Usage:  "file of hex encoded sha256 content hashes to quarantine, one per line",
+			EnvVar: common.PrefixEnvVar(envPrefix, "QUARANTINE_DENYLIST_FILE"),
+		},
+	}
+	return append(flags, approval.CLIFlags(common.PrefixEnvVar(envPrefix, "QUARANTINE"), common.PrefixFlag(flagPrefix, "quarantine"))...)
+}
+
+func ReadQuarantineConfig(ctx *cli.Context, flagPrefix string) (QuarantineConfig, error) {
+	approvalConfig, err := approval.ReadCLIConfig(ctx, common.PrefixFlag(flagPrefix, "quarantine"))
+	if err != nil {
+		return QuarantineConfig{}, err
+	}
+	return QuarantineConfig{
+		Enabled:           ctx.GlobalBool(common.PrefixFlag(flagPrefix, QuarantineEnabledFlagName)),
+		HTTPPort:          ctx.GlobalString(common.PrefixFlag(flagPrefix, QuarantineHTTPPortFlagName)),
+		AdminToken:        ctx.GlobalString(common.PrefixFlag(flagPrefix, QuarantineAdminTokenFlagName)),
+		MinBlobSize:       ctx.GlobalInt(common.PrefixFlag(flagPrefix, QuarantineMinBlobSizeFlagName)),
+		MaxBlobSize:       ctx.GlobalInt(common.PrefixFlag(flagPrefix, QuarantineMaxBlobSizeFlagName)),
+		AccountRateLimit:  ctx.GlobalInt(common.PrefixFlag(flagPrefix, QuarantineAccountRateLimitFlagName)),
+		AccountRateWindow: ctx.GlobalDuration(common.PrefixFlag(flagPrefix, QuarantineAccountRateWindowFlagName)),
+		DenylistFile:      ctx.GlobalString(common.PrefixFlag(flagPrefix, QuarantineDenylistFileFlagName)),
+		Approval:          approvalConfig,
+	}, nil
+}
+
+// QuarantinePredicate decides whether a blob submitted by an account is held for review,
+// returning the reason if so
+type QuarantinePredicate interface {
+	Check(account string, data []byte) (string, bool)
+}
+
+type sizePredicate struct {
+	min, max int
+}
+
+func (p *sizePredicate) Check(account string, data []byte) (string, bool) {
+	if p.min > 0 && len(data) < p.min {
+		return fmt.Sprintf("blob size %d is below %d", len(data), p.min), true
+	}
+	if p.max > 0 && len(data) > p.max {
+		return fmt.Sprintf("blob size %d is above %d", len(data), p.max), true
+	}
+	return "", false
+}
+
+// accountRatePredicate counts the blobs of each account in a sliding window. The least
+// recent accounts are forgotten beyond quotaAccounts, so idle accounts don't pile up.
+type accountRatePredicate struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	mu       sync.Mutex
+	requests *lru.Cache[string, []time.Time]
+}
+
+func newAccountRatePredicate(limit int, window time.Duration) *accountRatePredicate {
+	requests, _ := lru.New[string, []time.Time](quotaAccounts)
+	return &accountRatePredicate{
+		limit:    limit,
+		window:   window,
+		now:      time.Now,
+		requests: requests,
+	}
+}
+
+func (p *accountRatePredicate) Check(account string, data []byte) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	cutoff := now.Add(-p.window)
+	times, _ := p.requests.Get(account)
+	i := 0
+	for i < len(times) && !times[i].After(cutoff) {
+		i++
+	}
+	times = append(times[i:], now)
+	p.requests.Add(account, times)
+	if n := len(times); n > p.limit {
+		return fmt.Sprintf("account sent %d blobs within %s", n, p.window), true
+	}
+	return "", false
+}
+
+type denylistPredicate struct {
+	hashes map[[sha256.Size]byte]struct{}
+}
+
+func loadDenylist(path string) (*denylistPredicate, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open denylist: %w", err)
+	}
+	defer file.Close()
+
+	p := &denylistPredicate{hashes: make(map[[sha256.Size]byte]struct{})}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		decoded, err := hex.DecodeString(strings.TrimPrefix(text, "0x"))
+		if err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("denylist line %d is not a sha256 hash", line)
+		}
+		var hash [sha256.Size]byte
+		copy(hash[:], decoded)
+		p.hashes[hash] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read denylist: %w", err)
+	}
+	return p, nil
+}
+
+func (p *denylistPredicate) Check(account string, data []byte) (string, bool) {
+	if _, ok := p.hashes[sha256.Sum256(data)]; ok {
+		return "content hash is denylisted", true
+	}
+	return "", false
+}
+
+// Quarantine holds blobs matching any of its predicates out of the encoding queue, in the
+// Quarantined status, until they are released or rejected through the admin API
+type Quarantine struct {
+	config     QuarantineConfig
+	predicates []QuarantinePredicate
+	blobStore  disperser.BlobStore
+	// gate is nil unless the reviews need the approval of several admins
+	gate   *approval.Gate
+	logger common.Logger
+}
+
+func NewQuarantine(config QuarantineConfig, blobStore disperser.BlobStore, logger common.Logger) (*Quarantine, error) {
+	if config.AdminToken == "" && !config.Approval.Enabled() {
+		return nil, errors.New("quarantine admin token or approval admins are required")
+	}
+
+	var predicates []QuarantinePredicate
+	if config.MinBlobSize > 0 || config.MaxBlobSize > 0 {
+		predicates = append(predicates, &sizePredicate{min: config.MinBlobSize, max: config.MaxBlobSize})
+	}
+	if config.AccountRateLimit > 0 {
+		if config.AccountRateWindow <= 0 {
+			return nil, errors.New("quarantine account rate window must be positive")
+		}
+		predicates = append(predicates, newAccountRatePredicate(config.AccountRateLimit, config.AccountRateWindow))
+	}
+	if config.DenylistFile != "" {
+		denylist, err := loadDenylist(config.DenylistFile)
+		if err != nil {
+			return nil, err
+		}
+		predicates = append(predicates, denylist)
+	}
+	if len(predicates) == 0 {
+		logger.Warn("[apiserver] quarantine is enabled without predicates")
+	}
+
+	return &Quarantine{
+		config:     config,
+		predicates: predicates,
+		blobStore:  blobStore,
+		gate:       approval.NewGate(config.Approval, logger),
+		logger:     logger,
+	}, nil
+}
+
+// Check returns the reasons of all the predicates matching the blob. Every predicate is
+// evaluated so that stateful ones see every request.
+func (q *Quarantine) Check(account string, data []byte) (string, bool) {
+	var reasons []string
+	for _, predicate := range q.predicates {
+		if reason, ok := predicate.Check(account, data); ok {
+			reasons = append(reasons, reason)
+		}
+	}
+	if len(reasons) == 0 {
+		return "", false
+	}
+	return strings.Join(reasons, "; "), true
+}
+
+// Handler returns the routes of the admin API:
+//   - GET /quarantine lists the quarantined blobs
+//   - GET /quarantine/blob?request_id=<id> returns the content of a quarantined blob
+//   - POST /quarantine/release?request_id=<id> queues a blob for encoding
+//   - POST /quarantine/reject?request_id=<id> marks a blob as failed
+//   - GET /approvals lists the reviews waiting for the approval of more admins
+//
+// The reviews run once enough admins sent the same request if approvals are configured.
+func (q *Quarantine) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/quarantine", q.authorized(http.MethodGet, q.handleList))
+	mux.HandleFunc("/quarantine/blob", q.authorized(http.MethodGet, q.handleBlob))
+	mux.HandleFunc("/quarantine/release", q.gated(http.MethodPost, q.handleRelease))
+	mux.HandleFunc("/quarantine/reject", q.gated(http.MethodPost, q.handleReject))
+	if q.gate != nil {
+		mux.HandleFunc("/approvals", q.gate.HandlePending)
+	}
+	return mux
+}
+
+// Serve serves the admin API until ctx is done
+func (q *Quarantine) Serve(ctx context.Context) error {
+	q.logger.Info("[apiserver] quarantine admin api listening", "port", q.config.HTTPPort)
+	return serveAdmin(ctx, q.config.HTTPPort, q.Handler())
+}
+
+// authorized serves the requests sending the admin token, or the token of an approval admin
+func (q *Quarantine) authorized(method string, handler http.HandlerFunc) http.HandlerFunc {
+	return approval.Authorized(q.config.AdminToken, q.gate, method, handler)
+}
+
+// gated serves the reviews, behind the approval gate if there is one
+func (q *Quarantine) gated(method string, handler http.HandlerFunc) http.HandlerFunc {
+	if q.gate == nil {
+		return q.authorized(method, handler)
+	}
+	return q.gate.Gated(method, handler)
+}
+
+type quarantinedBlob struct {
+	RequestID   string `json:"request_id"`
+	Reason      string `json:"reason"`
+	BlobSize    uint   `json:"blob_size"`
+	RequestedAt uint64 `json:"requested_at"`
+}
+
+func (q *Quarantine) handleList(w http.ResponseWriter, r *http.Request) {
+	metadatas, err := q.blobStore.GetBlobMetadataByStatus(r.Context(), disperser.Quarantined)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	blobs := make([]quarantinedBlob, 0, len(metadatas))
+	for _, metadata := range metadatas {
+		blob := quarantinedBlob{
+			RequestID: metadata.GetBlobKey().String(),
+			Reason:    metadata.QuarantineReason,
+		}
+		if metadata.RequestMetadata != nil {
+			blob.BlobSize = metadata.RequestMetadata.BlobSize
+			blob.RequestedAt = metadata.RequestMetadata.RequestedAt
+		}
+		blobs = append(blobs, blob)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(blobs)
+}
+
+func (q *Quarantine) handleBlob(w http.ResponseWriter, r *http.Request) {
+	metadata, err := q.getQuarantined(r)
+	if err != nil {
+		writeQuarantineError(w, err)
+		return
+	}
+	data, err := q.blobStore.GetBlobContent(r.Context(), metadata)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, _ = w.Write(data)
+}
+
+func (q *Quarantine) handleRelease(w http.ResponseWriter, r *http.Request) {
+	q.review(w, r, disperser.Processing)
+}
+
+func (q *Quarantine) handleReject(w http.ResponseWriter, r *http.Request) {
+	q.review(w, r, disperser.Failed)
+}
+
+// review moves a blob out of quarantine with the conditional update of the store, so that a
+// blob reviewed concurrently is answered with a conflict
+func (q *Quarantine) review(w http.ResponseWriter, r *http.Request, status disperser.BlobStatus) {
+	key, err := disperser.ParseBlobKey(r.URL.Query().Get("request_id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = q.blobStore.ReviewQuarantinedBlob(r.Context(), key, status)
+	if errors.Is(err, disperser.ErrBlobNotFound) || errors.Is(err, disperser.ErrStatusConflict) {
+		writeQuarantineError(w, err)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	q.logger.Info("[apiserver] quarantined blob reviewed", "key", key.String(), "status", status)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (q *Quarantine) getQuarantined(r *http.Request) (*disperser.BlobMetadata, error) {
+	key, err := disperser.ParseBlobKey(r.URL.Query().Get("request_id"))
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := q.blobStore.GetBlobMetadata(r.Context(), key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", disperser.ErrBlobNotFound, err)
+	}
+	if metadata.BlobStatus != disperser.Quarantined {
+		return nil, errNotQuarantined
+	}
+	return metadata, nil
+}
+
+func writeQuarantineError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, disperser.ErrBlobNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, errNotQuarantined), errors.Is(err, disperser.ErrStatusConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// setQuarantinedHeader flags the reply of a quarantined blob
+func setQuarantinedHeader(ctx context.Context) {
+	_ = grpc.SetHeader(ctx, metadata.Pairs(QuarantinedHeader, "true"))
+}
@@ -0,0 +1,138 @@
+package apiserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/approval"
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuarantinePredicates(t *testing.T) {
+	denied := sha256.Sum256([]byte("denied"))
+	denylist := filepath.Join(t.TempDir(), "denylist")
+	assert.NoError(t, os.WriteFile(denylist, []byte("# denied content\n"+hex.EncodeToString(denied[:])+"\n"), 0644))
+
+	q, err := NewQuarantine(QuarantineConfig{
+		AdminToken:        "token",
+		MaxBlobSize:       8,
+		AccountRateLimit:  2,
+		AccountRateWindow: time.Minute,
+		DenylistFile:      denylist,
+	}, nil, mock.NewLogger(false))
+	assert.NoError(t, err)
+
+	_, quarantined := q.Check("a", []byte("ok"))
+	assert.False(t, quarantined)
+	_, quarantined = q.Check("b", []byte("denied"))
+	assert.True(t, quarantined)
+	_, quarantined = q.Check("c", []byte("too large"))
+	assert.True(t, quarantined)
+
+	// the third blob of an account within the window is quarantined, other accounts are not
+	_, quarantined = q.Check("a", []byte("ok"))
+	assert.False(t, quarantined)
+	_, quarantined = q.Check("a", []byte("ok"))
+	assert.True(t, quarantined)
+	_, quarantined = q.Check("d", []byte("ok"))
+	assert.False(t, quarantined)
+}
+
+func TestAccountRatePredicateWindow(t *testing.T) {
+	now := time.Unix(1000, 0)
+	p := newAccountRatePredicate(1, time.Minute)
+	p.now = func() time.Time { return now }
+
+	_, quarantined := p.Check("a", nil)
+	assert.False(t, quarantined)
+	_, quarantined = p.Check("a", nil)
+	assert.True(t, quarantined)
+
+	now = now.Add(2 * time.Minute)
+	_, quarantined = p.Check("a", nil)
+	assert.False(t, quarantined)
+}
+
+func TestQuarantineReview(t *testing.T) {
+	store := memorydb.NewBlobStore(core.MaxBlobSize*4, mock.NewLogger(false))
+	q, err := NewQuarantine(QuarantineConfig{AdminToken: "token"}, store, mock.NewLogger(false))
+	assert.NoError(t, err)
+	handler := q.Handler()
+
+	ctx := context.Background()
+	key, err := store.StoreQuarantinedBlob(ctx, &core.Blob{Data: []byte("blob")}, 1, "reason")
+	assert.NoError(t, err)
+
+	request := func(method, path string, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path+"?request_id="+key.String(), nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodPost, "/quarantine/release", "wrong").Code)
+
+	w := request(http.MethodGet, "/quarantine/blob", "token")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "blob", w.Body.String())
+
+	assert.Equal(t, http.StatusNoContent, request(http.MethodPost, "/quarantine/release", "token").Code)
+	metadata, err := store.GetBlobMetadata(ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, disperser.Processing, metadata.BlobStatus)
+
+	// a released blob can't be reviewed again
+	assert.Equal(t, http.StatusConflict, request(http.MethodPost, "/quarantine/release", "token").Code)
+	assert.Equal(t, http.StatusConflict, request(http.MethodPost, "/quarantine/reject", "token").Code)
+	metadata, err = store.GetBlobMetadata(ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, disperser.Processing, metadata.BlobStatus)
+}
+
+func TestQuarantineApproval(t *testing.T) {
+	store := memorydb.NewBlobStore(core.MaxBlobSize*4, mock.NewLogger(false))
+	q, err := NewQuarantine(QuarantineConfig{Approval: approval.Config{
+		Admins:    map[string]string{"ta": "alice", "tb": "bob"},
+		Threshold: 2,
+		TTL:       time.Minute,
+	}}, store, mock.NewLogger(false))
+	assert.NoError(t, err)
+	handler := q.Handler()
+
+	ctx := context.Background()
+	key, err := store.StoreQuarantinedBlob(ctx, &core.Blob{Data: []byte("blob")}, 1, "reason")
+	assert.NoError(t, err)
+	request := func(method, path string, token string) int {
+		r := httptest.NewRequest(method, path+"?request_id="+key.String(), nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// an admin may inspect the blob on their own, rejecting it takes two
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/quarantine/blob", "ta"))
+	assert.Equal(t, http.StatusAccepted, request(http.MethodPost, "/quarantine/reject", "ta"))
+	metadata, err := store.GetBlobMetadata(ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, disperser.Quarantined, metadata.BlobStatus)
+	assert.Equal(t, http.StatusNoContent, request(http.MethodPost, "/quarantine/reject", "tb"))
+	metadata, err = store.GetBlobMetadata(ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, disperser.Failed, metadata.BlobStatus)
+
+	_, err = NewQuarantine(QuarantineConfig{}, store, mock.NewLogger(false))
+	assert.Error(t, err)
+}
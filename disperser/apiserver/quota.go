@@ -0,0 +1,360 @@
+package apiserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/disperser"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/urfave/cli"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	// RetryAfterHeader is set on the dispersals rejected over the quota of their account to
+	// the seconds after which they would be accepted. The RetryInfo of the error holds the
+	// exact delay.
+	RetryAfterHeader = "retry-after"
+	// DefaultAPIKeyHeader carries the API key of the dispersals if not configured. The
+	// gateway forwards the headers of its prefix.
+	DefaultAPIKeyHeader = "x-zgda-api-key"
+
+	QuotaBytesPerSecondFlagName    = "quota.bytes-per-second"
+	QuotaRequestsPerSecondFlagName = "quota.requests-per-second"
+	QuotaBurstFlagName             = "quota.burst"
+	QuotaAPIKeyHeaderFlagName      = "quota.api-key-header"
+	QuotaAPIKeysFlagName           = "quota.api-keys"
+	QuotaAllowlistFlagName         = "quota.allowlist"
+
+	// apiKeyAccountPrefix tells the accounts of the API keys from the client addresses
+	apiKeyAccountPrefix = "key:"
+	// quotaAccounts bounds the accounts whose buckets are kept
+	quotaAccounts = 100000
+)
+
+func QuotaCLIFlags(envPrefix string, flagPrefix string) []cli.Flag {
+	return []cli.Flag{
+		cli.IntFlag{
+			Name:   common.PrefixFlag(flagPrefix, QuotaBytesPerSecondFlagName),
+			Usage:  "bytes per second each account may disperse, unlimited if 0",
+			EnvVar: common.PrefixEnvVar(envPrefix, "QUOTA_BYTES_PER_SECOND"),
+		},
+		cli.Float64Flag{
+			Name:   common.PrefixFlag(flagPrefix, QuotaRequestsPerSecondFlagName),
+			Usage:  "dispersals per second of each account, unlimited if 0",
+			EnvVar: common.PrefixEnvVar(envPrefix, "QUOTA_REQUESTS_PER_SECOND"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, QuotaBurstFlagName),
+			Usage:  "how long of the quotas an idle account may disperse at once",
+			Value:  time.Second,
+			EnvVar: common.PrefixEnvVar(envPrefix, "QUOTA_BURST"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, QuotaAPIKeyHeaderFlagName),
+			Usage:  "header of the API keys identifying the accounts",
+			Value:  DefaultAPIKeyHeader,
+			EnvVar: common.PrefixEnvVar(envPrefix, "QUOTA_API_KEY_HEADER"),
+		},
+		cli.StringSliceFlag{
+			Name:   common.PrefixFlag(flagPrefix, QuotaAPIKeysFlagName),
+			Usage:  "API keys of the accounts as <name>=<key>, the address of the connection is the account of the requests without a key nor a signature",
+			EnvVar: common.PrefixEnvVar(envPrefix, "QUOTA_API_KEYS"),
+		},
+		cli.StringSliceFlag{
+			Name:   common.PrefixFlag(flagPrefix, QuotaAllowlistFlagName),
+			Usage:  "API key names or signer addresses of the accounts exempt from the quotas, such as trusted rollup sequencers",
+			EnvVar: common.PrefixEnvVar(envPrefix, "QUOTA_ALLOWLIST"),
+		},
+	}
+}
+
+func ReadQuotaConfig(ctx *cli.Context, flagPrefix string) (disperser.QuotaConfig, error) {
+	apiKeys := make(map[string]string)
+	for _, entry := range ctx.GlobalStringSlice(common.PrefixFlag(flagPrefix, QuotaAPIKeysFlagName)) {
+		name, key, ok := strings.Cut(entry, "=")
+		if !ok || name == "" || key == "" {
+			return disperser.QuotaConfig{}, errors.New("invalid API key, expected <name>=<key>")
+		}
+		if _, ok := apiKeys[key]; ok {
+			return disperser.QuotaConfig{}, fmt.Errorf("API key of %s is used by another account", name)
+		}
+		apiKeys[key] = name
+	}
+	config := disperser.QuotaConfig{
+		BytesPerSecond:    ctx.GlobalInt(common.PrefixFlag(flagPrefix, QuotaBytesPerSecondFlagName)),
+		RequestsPerSecond: ctx.GlobalFloat64(common.PrefixFlag(flagPrefix, QuotaRequestsPerSecondFlagName)),
+		Burst:             ctx.GlobalDuration(common.PrefixFlag(flagPrefix, QuotaBurstFlagName)),
+		APIKeyHeader:      strings.ToLower(ctx.GlobalString(common.PrefixFlag(flagPrefix, QuotaAPIKeyHeaderFlagName))),
+		APIKeys:           apiKeys,
+		Allowlist:         ctx.GlobalStringSlice(common.PrefixFlag(flagPrefix, QuotaAllowlistFlagName)),
+	}
+	if config.BytesPerSecond < 0 || config.RequestsPerSecond < 0 {
+		return disperser.QuotaConfig{}, errors.New("quotas must not be negative")
+	}
+	return config, nil
+}
+
+// tokenBucket holds tokens refilled at a fixed rate up to a capacity
+type tokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// refill adds the tokens accrued since the last update
+func (b *tokenBucket) refill(now time.Time, rate float64, capacity float64) {
+	if b.updatedAt.IsZero() {
+		b.tokens = capacity
+	} else if elapsed := now.Sub(b.updatedAt).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+elapsed*rate)
+	}
+	b.updatedAt = now
+}
+
+// wait returns how long until the bucket holds n tokens
+func (b *tokenBucket) wait(n float64, rate float64) time.Duration {
+	if b.tokens >= n {
+		return 0
+	}
+	return time.Duration((n - b.tokens) / rate * float64(time.Second))
+}
+
+type accountBuckets struct {
+	bytes    tokenBucket
+	requests tokenBucket
+}
+
+// accounts resolves the accounts of the requests from their API keys and client addresses
+type accounts struct {
+	apiKeyHeader string
+	apiKeys      map[string]string
+	// allowlist holds the allowlisted API key names and signers, the signers by address
+	allowlist map[string]bool
+}
+
+func newAccounts(config disperser.QuotaConfig) *accounts {
+	if config.APIKeyHeader == "" {
+		config.APIKeyHeader = DefaultAPIKeyHeader
+	}
+	return &accounts{
+		apiKeyHeader: config.APIKeyHeader,
+		apiKeys:      config.APIKeys,
+		allowlist:    newAccountSet(config.Allowlist),
+	}
+}
+
+// newAccountSet returns the set of accounts, API key names or signer addresses, holding the
+// signers by address
+func newAccountSet(accounts []string) map[string]bool {
+	set := make(map[string]bool, len(accounts))
+	for _, account := range accounts {
+		if eth_common.IsHexAddress(account) {
+			account = eth_common.HexToAddress(account).Hex()
+		}
+		set[account] = true
+	}
+	return set
+}
+
+// accountIn returns whether the account of a request is in a set of newAccountSet. Only the
+// accounts of the API keys and of the signers can be: the client addresses aren't
+// authenticated.
+func accountIn(set map[string]bool, account string) bool {
+	if name, ok := strings.CutPrefix(account, apiKeyAccountPrefix); ok {
+		return set[name]
+	}
+	return eth_common.IsHexAddress(account) && set[eth_common.HexToAddress(account).Hex()]
+}
+
+// header returns the header of the API keys
+func (a *accounts) header() string {
+	if a == nil {
+		return DefaultAPIKeyHeader
+	}
+	return a.apiKeyHeader
+}
+
+// apiKey returns the API key of a grpc request, empty if it has none
+func (a *accounts) apiKey(ctx context.Context) string {
+	if a == nil {
+		return ""
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md.Get(a.apiKeyHeader)) == 0 {
+		return ""
+	}
+	return md.Get(a.apiKeyHeader)[0]
+}
+
+// resolve returns the account of a request, the name of its API key or else its client
+// address. The API keys are ignored if none are configured.
+func (a *accounts) resolve(apiKey string, origin string) (string, error) {
+	if a == nil || apiKey == "" || len(a.apiKeys) == 0 {
+		return origin, nil
+	}
+	name, ok := a.apiKeys[apiKey]
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "unknown API key")
+	}
+	return apiKeyAccountPrefix + name, nil
+}
+
+// allowlisted returns whether an account is exempt from the quotas, see accountIn
+func (a *accounts) allowlisted(account string) bool {
+	if a == nil {
+		return false
+	}
+	return accountIn(a.allowlist, account)
+}
+
+// quotas limits the dispersals of each account, none while no quota is set
+type quotas struct {
+	mu       sync.Mutex
+	config   disperser.QuotaConfig
+	accounts *lru.Cache[string, *accountBuckets]
+	now      func() time.Time
+}
+
+func newQuotas(config disperser.QuotaConfig) *quotas {
+	if config.Burst <= 0 {
+		config.Burst = time.Second
+	}
+	accounts, _ := lru.New[string, *accountBuckets](quotaAccounts)
+	return &quotas{
+		config:   config,
+		accounts: accounts,
+		now:      time.Now,
+	}
+}
+
+// enabled returns whether the dispersals are limited
+func (q *quotas) enabled() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.config.Enabled()
+}
+
+// setRequestsPerSecond replaces the requests quota, the tokens held by the accounts being
+// kept up to the burst of the new quota
+func (q *quotas) setRequestsPerSecond(rate float64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.config.RequestsPerSecond = rate
+}
+
+// quotaTaken is a dispersal taken from the quotas of an account
+type quotaTaken struct {
+	account  string
+	bytes    float64
+	requests float64
+}
+
+// take takes a dispersal of size bytes from the quotas of an account, returning how long
+// until it would fit if it doesn't. A blob larger than the burst of the bytes quota fits
+// once the bucket is full.
+func (q *quotas) take(account string, size int) (*quotaTaken, time.Duration, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	buckets, ok := q.accounts.Get(account)
+	if !ok {
+		buckets = &accountBuckets{}
+		q.accounts.Add(account, buckets)
+	}
+	now := q.now()
+	burst := q.config.Burst.Seconds()
+
+	var wait time.Duration
+	if rate := float64(q.config.BytesPerSecond); rate > 0 {
+		capacity := rate * burst
+		buckets.bytes.refill(now, rate, capacity)
+		wait = buckets.bytes.wait(math.Min(float64(size), capacity), rate)
+	}
+	if rate := q.config.RequestsPerSecond; rate > 0 {
+		buckets.requests.refill(now, rate, math.Max(rate*burst, 1))
+		wait = max(wait, buckets.requests.wait(1, rate))
+	}
+	if wait > 0 {
+		return nil, wait, false
+	}
+	taken := &quotaTaken{account: account}
+	if q.config.BytesPerSecond > 0 {
+		taken.bytes = math.Min(float64(size), float64(q.config.BytesPerSecond)*burst)
+		buckets.bytes.tokens -= taken.bytes
+	}
+	if q.config.RequestsPerSecond > 0 {
+		taken.requests = 1
+		buckets.requests.tokens--
+	}
+	return taken, 0, true
+}
+
+// giveBack returns a dispersal taken from the quotas of an account that wasn't stored, such
+// as a duplicate or a dispersal rejected after its quota was taken
+func (q *quotas) giveBack(taken *quotaTaken) {
+	if q == nil || taken == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	buckets, ok := q.accounts.Get(taken.account)
+	if !ok {
+		return
+	}
+	burst := q.config.Burst.Seconds()
+	if rate := float64(q.config.BytesPerSecond); rate > 0 {
+		buckets.bytes.tokens = math.Min(rate*burst, buckets.bytes.tokens+taken.bytes)
+	}
+	if rate := q.config.RequestsPerSecond; rate > 0 {
+		buckets.requests.tokens = math.Min(math.Max(rate*burst, 1), buckets.requests.tokens+taken.requests)
+	}
+}
+
+// requestAccount returns the account of a dispersal, its signer if signed, or else the name
+// of its API key or the address of its peer, see peerAddress. The API keys are honoured
+// whether or not the dispersals are limited.
+func (s *DispersalServer) requestAccount(ctx context.Context, peerAddress string, signer string) (string, error) {
+	if signer != "" {
+		return signer, nil
+	}
+	return s.accounts.resolve(s.accounts.apiKey(ctx), peerAddress)
+}
+
+// takeQuota takes a dispersal of size bytes from the quotas of an account, rejecting it if it
+// exceeds them. It returns nil if the account isn't limited.
+func (s *DispersalServer) takeQuota(ctx context.Context, account string, size int) (*quotaTaken, error) {
+	if s.quotas == nil || !s.quotas.enabled() || s.accounts.allowlisted(account) {
+		return nil, nil
+	}
+	taken, wait, ok := s.quotas.take(account, size)
+	if !ok {
+		return nil, quotaExceededError(ctx, fmt.Sprintf("quota of account %s exceeded", account), wait)
+	}
+	return taken, nil
+}
+
+// quotaExceededError is the error of a request over a quota of its account, with the delay
+// after which it would be accepted, if known, as RetryInfo and in the retry-after header
+func quotaExceededError(ctx context.Context, message string, wait time.Duration) error {
+	if wait <= 0 {
+		return status.Error(codes.ResourceExhausted, message)
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RetryAfterHeader, strconv.Itoa(int(math.Ceil(wait.Seconds())))))
+	st := status.New(codes.ResourceExhausted, fmt.Sprintf("%s, retry in %v", message, wait.Round(time.Millisecond)))
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
@@ -0,0 +1,139 @@
+package apiserver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestQuotas(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	config := disperser.QuotaConfig{
+		BytesPerSecond:    1000,
+		RequestsPerSecond: 2,
+		Burst:             2 * time.Second,
+		APIKeys:           map[string]string{"secret": "rollup", "other": "indexer"},
+		Allowlist:         []string{"rollup", "10.0.0.1", "0x00000000000000000000000000000000000000aa"},
+	}
+	s := &DispersalServer{accounts: newAccounts(config), quotas: newQuotas(config)}
+	s.quotas.now = func() time.Time { return now }
+	withKey := func(key string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(DefaultAPIKeyHeader, key))
+	}
+	takeSigned := func(ctx context.Context, origin string, signer string, size int) (string, error) {
+		account, err := s.requestAccount(ctx, origin, signer)
+		if err != nil {
+			return "", err
+		}
+		_, err = s.takeQuota(ctx, account, size)
+		return account, err
+	}
+	take := func(ctx context.Context, origin string, size int) (string, error) {
+		return takeSigned(ctx, origin, "", size)
+	}
+
+	// the burst holds 2000 bytes and 4 dispersals
+	account, err := take(context.Background(), "10.0.0.2", 1500)
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.2", account)
+	_, err = take(context.Background(), "10.0.0.2", 1000)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	details := status.Convert(err).Details()
+	require.Len(t, details, 1)
+	assert.Equal(t, 500*time.Millisecond, details[0].(*errdetails.RetryInfo).GetRetryDelay().AsDuration())
+	now = now.Add(500 * time.Millisecond)
+	_, err = take(context.Background(), "10.0.0.2", 1000)
+	require.NoError(t, err)
+
+	// blobs larger than the burst pass on a full bucket, and the accounts are independent
+	account, err = take(withKey("other"), "10.0.0.2", 5000)
+	require.NoError(t, err)
+	assert.Equal(t, "key:indexer", account)
+	for i := 0; i < 3; i++ {
+		_, err = take(withKey("other"), "10.0.0.3", 1)
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	}
+
+	// the requests quota applies to empty blobs
+	for i := 0; i < 4; i++ {
+		_, err = take(context.Background(), "10.0.0.4", 0)
+		require.NoError(t, err)
+	}
+	_, err = take(context.Background(), "10.0.0.4", 0)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// a dispersal not stored, such as a duplicate, gives back its quota
+	now = now.Add(time.Second)
+	taken, err := s.takeQuota(context.Background(), "10.0.0.6", 2000)
+	require.NoError(t, err)
+	_, err = s.takeQuota(context.Background(), "10.0.0.6", 1000)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	s.quotas.giveBack(taken)
+	_, err = s.takeQuota(context.Background(), "10.0.0.6", 2000)
+	require.NoError(t, err)
+
+	// allowlisted keys and signers aren't limited, unknown keys are rejected
+	for i := 0; i < 10; i++ {
+		_, err = take(withKey("secret"), "10.0.0.5", 5000)
+		require.NoError(t, err)
+		_, err = takeSigned(context.Background(), "10.0.0.5", "0x00000000000000000000000000000000000000AA", 5000)
+		require.NoError(t, err)
+	}
+	_, err = take(withKey("unknown"), "10.0.0.5", 1)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// client addresses aren't authenticated, so they can't be allowlisted
+	_, err = take(context.Background(), "10.0.0.1", 5000)
+	require.NoError(t, err)
+	_, err = take(context.Background(), "10.0.0.1", 5000)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestAccountsWithoutQuotas(t *testing.T) {
+	config := disperser.QuotaConfig{APIKeys: map[string]string{"secret": "rollup"}}
+	s := &DispersalServer{accounts: newAccounts(config), quotas: newQuotas(config)}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(DefaultAPIKeyHeader, "secret"))
+
+	// the API keys name the accounts the backlog is shared between even if unlimited
+	account, err := s.requestAccount(ctx, "10.0.0.2", "")
+	require.NoError(t, err)
+	assert.Equal(t, "key:rollup", account)
+	taken, err := s.takeQuota(ctx, account, 1<<20)
+	require.NoError(t, err)
+	assert.Nil(t, taken)
+}
+
+func TestDuplicateGivesBackQuota(t *testing.T) {
+	logger := mock.NewLogger(false)
+	config := disperser.ServerConfig{
+		Quotas: disperser.QuotaConfig{RequestsPerSecond: 1, Burst: 2 * time.Second},
+		Dedup:  disperser.DedupConfig{Window: time.Minute, MaxEntries: 10},
+	}
+	s := NewDispersalServer(config, memorydb.NewBlobStore(1<<30, logger), logger, disperser.NewMetrics("0", logger), nil, RateConfig{}, false, nil, "", nil, nil, nil, nil, nil, nil, nil)
+	now := time.Unix(1700000000, 0)
+	s.quotas.now = func() time.Time { return now }
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+
+	_, err := s.DisperseBlob(ctx, &pb.DisperseBlobRequest{Data: []byte("first")})
+	require.NoError(t, err)
+	_, err = s.DisperseBlob(ctx, &pb.DisperseBlobRequest{Data: []byte("first")})
+	require.NoError(t, err)
+	// the duplicate left the second dispersal of the burst
+	_, err = s.DisperseBlob(ctx, &pb.DisperseBlobRequest{Data: []byte("second")})
+	require.NoError(t, err)
+	_, err = s.DisperseBlob(ctx, &pb.DisperseBlobRequest{Data: []byte("third")})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
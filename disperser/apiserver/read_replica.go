@@ -0,0 +1,97 @@
+package apiserver
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/0glabs/0g-da-client/disperser"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// StalenessHeader is set on replies read from the metadata replica, to the bound of the
+// replication lag in milliseconds. Replies without it were read from the primary store.
+const StalenessHeader = "x-zgda-staleness-ms"
+
+func (s *DispersalServer) replicaStaleness() (time.Duration, bool) {
+	if s.readReplica == nil {
+		return 0, false
+	}
+	return s.readReplica.Staleness()
+}
+
+// getBlobMetadata reads the blob metadata from the replica while it is fresh enough, and
+// from the primary store otherwise or if the blob hasn't been replicated yet. fromReplica
+// reports where the metadata was read from.
+//
+// The staleness is only a bound while the writers keep sending heartbeats, a stuck or
+// crashed writer leaves writes behind that no heartbeat accounts for. So the replica only
+// answers for the blobs it has in a final status, which no later write can change, and the
+// other reads fall back to a consistent read of the primary store. The primary store is
+// always read consistently, as the API servers share it: a client polling the status of the
+// blob it just dispersed may land on another server than the one it dispersed through.
+func (s *DispersalServer) getBlobMetadata(ctx context.Context, key disperser.BlobKey) (metadata *disperser.BlobMetadata, staleness time.Duration, fromReplica bool, err error) {
+	if staleness, ok := s.replicaStaleness(); ok {
+		metadata, err := s.readReplica.GetBlobMetadata(ctx, key)
+		if err == nil && metadata != nil && metadata.BlobHash != "" && isFinalBlobStatus(metadata.BlobStatus) {
+			return metadata, staleness, true, nil
+		}
+		if err != nil {
+			s.logger.Debug("[apiserver] failed to read blob metadata from the replica", "blobKey", key.String(), "err", err)
+		}
+	}
+	metadata, err = s.blobReader.GetBlobMetadataWithOptions(ctx, key, disperser.ReadOptions{Consistency: disperser.StrongRead})
+	return metadata, 0, false, err
+}
+
+// getAllBlobMetadataByBatch is the batch counterpart of getBlobMetadata. The replica only
+// answers for the batches it has every blob of, all in a final status.
+func (s *DispersalServer) getAllBlobMetadataByBatch(ctx context.Context, batchHeaderHash [32]byte) (metadatas []*disperser.BlobMetadata, staleness time.Duration, fromReplica bool, err error) {
+	if staleness, ok := s.replicaStaleness(); ok {
+		metadatas, err := s.readReplica.GetAllBlobMetadataByBatch(ctx, batchHeaderHash)
+		if err == nil && isCompleteFinalBatch(metadatas) {
+			return metadatas, staleness, true, nil
+		}
+		if err != nil {
+			s.logger.Debug("[apiserver] failed to read batch metadata from the replica", "err", err)
+		}
+	}
+	metadatas, err = s.blobReader.GetAllBlobMetadataByBatch(ctx, batchHeaderHash)
+	return metadatas, 0, false, err
+}
+
+// isFinalBlobStatus returns whether a blob in the status is never written again, but to be
+// removed
+func isFinalBlobStatus(status disperser.BlobStatus) bool {
+	switch status {
+	case disperser.Finalized, disperser.Failed, disperser.InsufficientSignatures:
+		return true
+	}
+	return false
+}
+
+// isCompleteFinalBatch returns whether the replica has every blob of a batch, all in a final
+// status
+func isCompleteFinalBatch(metadatas []*disperser.BlobMetadata) bool {
+	if len(metadatas) == 0 {
+		return false
+	}
+	for _, metadata := range metadatas {
+		if !isFinalBlobStatus(metadata.BlobStatus) || metadata.ConfirmationInfo == nil {
+			return false
+		}
+		if int(metadata.ConfirmationInfo.BlobCount) != len(metadatas) {
+			return false
+		}
+	}
+	return true
+}
+
+func formatStaleness(staleness time.Duration) string {
+	return strconv.FormatInt(staleness.Milliseconds(), 10)
+}
+
+func setStalenessHeader(ctx context.Context, staleness time.Duration) {
+	_ = grpc.SetHeader(ctx, metadata.Pairs(StalenessHeader, formatStaleness(staleness)))
+}
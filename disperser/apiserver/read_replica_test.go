@@ -0,0 +1,118 @@
+package apiserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// consistencyRecorder records the consistency the metadata was read with
+type consistencyRecorder struct {
+	disperser.BlobStoreReader
+	reads []disperser.ReadConsistency
+}
+
+func (r *consistencyRecorder) GetBlobMetadata(ctx context.Context, key disperser.BlobKey) (*disperser.BlobMetadata, error) {
+	r.reads = append(r.reads, disperser.EventualRead)
+	return r.BlobStoreReader.GetBlobMetadata(ctx, key)
+}
+
+func (r *consistencyRecorder) GetBlobMetadataWithOptions(ctx context.Context, key disperser.BlobKey, opts disperser.ReadOptions) (*disperser.BlobMetadata, error) {
+	r.reads = append(r.reads, opts.Consistency)
+	return r.BlobStoreReader.GetBlobMetadataWithOptions(ctx, key, opts)
+}
+
+func TestReadAfterWriteConsistency(t *testing.T) {
+	ctx := context.Background()
+	store := memorydb.NewBlobStore(core.MaxBlobSize*4, mock.NewLogger(false))
+	reader := &consistencyRecorder{BlobStoreReader: store}
+	s := &DispersalServer{blobStore: store, blobReader: reader, dedup: newDeduplicator(disperser.DedupConfig{Window: time.Hour}), logger: mock.NewLogger(false)}
+
+	blob := &core.Blob{Data: []byte("blob")}
+	key := dedupKeyOf(blob)
+	_, pending, err := s.findDuplicate(ctx, key)
+	require.NoError(t, err)
+	blobKey, err := store.StoreBlob(ctx, blob, 1)
+	require.NoError(t, err)
+	s.dedup.finish(key, pending, blobKey, nil)
+
+	// the duplicate and the status poll right after the dispersal
+	existing, _, err := s.findDuplicate(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, blobKey, existing.GetBlobKey())
+	metadata, _, fromReplica, err := s.getBlobMetadata(ctx, blobKey)
+	require.NoError(t, err)
+	assert.False(t, fromReplica)
+	assert.Equal(t, disperser.Processing, metadata.BlobStatus)
+
+	// the duplicate was stored by this server, while the status may be polled on any
+	assert.Equal(t, []disperser.ReadConsistency{disperser.SessionRead, disperser.StrongRead}, reader.reads)
+}
+
+// staticReplica is a replica within its staleness bound holding fixed metadata
+type staticReplica struct {
+	metadata map[disperser.BlobKey]*disperser.BlobMetadata
+}
+
+func (r *staticReplica) Staleness() (time.Duration, bool) {
+	return time.Second, true
+}
+
+func (r *staticReplica) GetBlobMetadata(ctx context.Context, key disperser.BlobKey) (*disperser.BlobMetadata, error) {
+	if metadata, ok := r.metadata[key]; ok {
+		return metadata, nil
+	}
+	return nil, disperser.ErrBlobNotFound
+}
+
+func (r *staticReplica) GetAllBlobMetadataByBatch(ctx context.Context, batchHeaderHash [32]byte) ([]*disperser.BlobMetadata, error) {
+	metadatas := make([]*disperser.BlobMetadata, 0, len(r.metadata))
+	for _, metadata := range r.metadata {
+		metadatas = append(metadatas, metadata)
+	}
+	return metadatas, nil
+}
+
+func TestReplicaServesOnlyFinalStatuses(t *testing.T) {
+	ctx := context.Background()
+	store := memorydb.NewBlobStore(core.MaxBlobSize*4, mock.NewLogger(false))
+	reader := &consistencyRecorder{BlobStoreReader: store}
+	replica := &staticReplica{metadata: make(map[disperser.BlobKey]*disperser.BlobMetadata)}
+	s := &DispersalServer{blobStore: store, blobReader: reader, readReplica: replica, logger: mock.NewLogger(false)}
+
+	finalized, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("finalized")}, 1)
+	require.NoError(t, err)
+	processing, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("processing")}, 2)
+	require.NoError(t, err)
+	info := &disperser.ConfirmationInfo{BlobCount: 2}
+	replica.metadata[finalized] = &disperser.BlobMetadata{BlobHash: finalized.BlobHash, MetadataHash: finalized.MetadataHash, BlobStatus: disperser.Finalized, ConfirmationInfo: info}
+	// the replica lags behind a confirmation, its heartbeats notwithstanding
+	replica.metadata[processing] = &disperser.BlobMetadata{BlobHash: processing.BlobHash, MetadataHash: processing.MetadataHash, BlobStatus: disperser.Processing}
+	metadata, err := store.GetBlobMetadata(ctx, processing)
+	require.NoError(t, err)
+	_, err = store.MarkBlobConfirmed(ctx, metadata, info)
+	require.NoError(t, err)
+
+	metadata, staleness, fromReplica, err := s.getBlobMetadata(ctx, finalized)
+	require.NoError(t, err)
+	assert.True(t, fromReplica)
+	assert.Equal(t, time.Second, staleness)
+	assert.Equal(t, disperser.Finalized, metadata.BlobStatus)
+
+	metadata, _, fromReplica, err = s.getBlobMetadata(ctx, processing)
+	require.NoError(t, err)
+	assert.False(t, fromReplica)
+	assert.Equal(t, disperser.Confirmed, metadata.BlobStatus)
+	assert.Equal(t, []disperser.ReadConsistency{disperser.StrongRead}, reader.reads)
+
+	// nor a batch it has a blob of in a status that may change
+	_, _, fromReplica, _ = s.getAllBlobMetadataByBatch(ctx, [32]byte{1})
+	assert.False(t, fromReplica)
+}
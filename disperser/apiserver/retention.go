@@ -0,0 +1,123 @@
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// storagePeriodBlocks bounds the confirmation blocks whose storage period is cached
+const storagePeriodBlocks = 4096
+
+// StoragePeriod returns the number of blocks from their confirmation the operators keep the
+// blobs retrievable, for the blobs confirmed at a block
+type StoragePeriod interface {
+	PeriodBlocks(ctx context.Context, blockNumber uint64) (uint64, error)
+}
+
+type chainStoragePeriod struct {
+	caller   bind.ContractCaller
+	contract eth_common.Address
+	method   string
+	// periods caches the period by block, which a block never changes
+	periods *lru.Cache[uint64, uint64]
+}
+
+// NewChainStoragePeriod reads the storage period from the view function method of contract,
+// such as "storagePeriodBlocks()", returning a uint256 number of blocks. The period of the
+// blobs is read at their confirmation block, so that a change of the period doesn't apply to
+// the blobs confirmed before it, which needs the state of that block on the node.
+func NewChainStoragePeriod(caller bind.ContractCaller, contract eth_common.Address, method string) StoragePeriod {
+	periods, _ := lru.New[uint64, uint64](storagePeriodBlocks)
+	return &chainStoragePeriod{caller: caller, contract: contract, method: method, periods: periods}
+}
+
+func (p *chainStoragePeriod) PeriodBlocks(ctx context.Context, blockNumber uint64) (uint64, error) {
+	if period, ok := p.periods.Get(blockNumber); ok {
+		return period, nil
+	}
+
+	// the cache isn't locked across the call, so a slow node only holds back the reads of
+	// the blocks not cached yet
+	out, err := p.caller.CallContract(ctx, ethereum.CallMsg{To: &p.contract, Data: crypto.Keccak256([]byte(p.method))[:4]}, new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return 0, fmt.Errorf("failed to call %s on %s at block %d: %w", p.method, p.contract, blockNumber, err)
+	}
+	if len(out) != 32 {
+		return 0, fmt.Errorf("unexpected result of %s on %s: %x", p.method, p.contract, out)
+	}
+	period := new(big.Int).SetBytes(out)
+	if !period.IsUint64() {
+		return 0, fmt.Errorf("unexpected storage period %s", period)
+	}
+	p.periods.Add(blockNumber, period.Uint64())
+	return period.Uint64(), nil
+}
+
+// retentionConfig returns the retention config of the blobs of a confirmation with the
+// storage period read from chain at their confirmation block, or the configured one if it
+// isn't read from chain or can't be read
+func (s *DispersalServer) retentionConfig(ctx context.Context, info *disperser.ConfirmationInfo) disperser.RetentionConfig {
+	config := s.config.Retention
+	if s.storagePeriod == nil || info == nil || info.ConfirmationBlockNumber == 0 {
+		return config
+	}
+	period, err := s.storagePeriod.PeriodBlocks(ctx, uint64(info.ConfirmationBlockNumber))
+	if err != nil {
+		s.logger.Warn("[apiserver] failed to read the storage period, using the configured one", "period", config.PeriodBlocks, "block", info.ConfirmationBlockNumber, "err", err)
+		return config
+	}
+	config.PeriodBlocks = period
+	return config
+}
+
+// blobRetention returns the storage period of a confirmed blob, nil if the storage period
+// isn't known or the blob doesn't record its confirmation block, such as the blobs finalized
+// before the kv store recorded their confirmation
+func blobRetention(config disperser.RetentionConfig, info *disperser.ConfirmationInfo, now time.Time) *pb.Retention {
+	if config.PeriodBlocks == 0 || info == nil || info.ConfirmationBlockNumber == 0 {
+		return nil
+	}
+	retention := &pb.Retention{
+		ExpiryBlockNumber: uint64(info.ConfirmationBlockNumber) + config.PeriodBlocks,
+		RenewalStatus:     pb.RenewalStatus_RENEWAL_UNKNOWN,
+	}
+	if info.ConfirmedAt == 0 || config.BlockTime == 0 {
+		return retention
+	}
+	expiry := time.Unix(info.ConfirmedAt, 0).Add(time.Duration(config.PeriodBlocks) * config.BlockTime)
+	retention.ExpiryTime = expiry.Unix()
+	switch {
+	case !now.Before(expiry):
+		retention.RenewalStatus = pb.RenewalStatus_EXPIRED
+	case !now.Add(config.RenewalWindow).Before(expiry):
+		retention.RenewalStatus = pb.RenewalStatus_RENEWAL_DUE
+	default:
+		retention.RenewalStatus = pb.RenewalStatus_RETAINED
+	}
+	return retention
+}
+
+// batchRetention returns the storage period of the blobs of a batch, which share their
+// confirmation, nil if unknown
+func (s *DispersalServer) batchRetention(ctx context.Context, metadatas []*disperser.BlobMetadata) *Retention {
+	info := metadatas[0].ConfirmationInfo
+	retention := blobRetention(s.retentionConfig(ctx, info), info, time.Now())
+	if retention == nil {
+		return nil
+	}
+	return &Retention{
+		ExpiryBlockNumber: retention.ExpiryBlockNumber,
+		ExpiryTime:        retention.ExpiryTime,
+		RenewalStatus:     retention.RenewalStatus.String(),
+	}
+}
@@ -0,0 +1,117 @@
+package apiserver
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/ethereum/go-ethereum"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlobRetention(t *testing.T) {
+	config := disperser.RetentionConfig{PeriodBlocks: 1000, BlockTime: 2 * time.Second, RenewalWindow: 10 * time.Minute}
+	confirmedAt := time.Unix(10000, 0)
+	info := &disperser.ConfirmationInfo{ConfirmationBlockNumber: 500, ConfirmedAt: confirmedAt.Unix()}
+
+	assert.Nil(t, blobRetention(disperser.RetentionConfig{}, info, confirmedAt))
+	// the blobs finalized to the kv store before their confirmation was recorded
+	assert.Nil(t, blobRetention(config, &disperser.ConfirmationInfo{}, confirmedAt))
+
+	retention := blobRetention(config, info, confirmedAt)
+	require.NotNil(t, retention)
+	assert.Equal(t, uint64(1500), retention.ExpiryBlockNumber)
+	assert.Equal(t, confirmedAt.Add(2000*time.Second).Unix(), retention.ExpiryTime)
+	assert.Equal(t, pb.RenewalStatus_RETAINED, retention.RenewalStatus)
+
+	assert.Equal(t, pb.RenewalStatus_RENEWAL_DUE, blobRetention(config, info, confirmedAt.Add(1400*time.Second)).RenewalStatus)
+	assert.Equal(t, pb.RenewalStatus_EXPIRED, blobRetention(config, info, confirmedAt.Add(2000*time.Second)).RenewalStatus)
+
+	// the expiry time of the blobs confirmed before the confirmation time was recorded is unknown
+	retention = blobRetention(config, &disperser.ConfirmationInfo{ConfirmationBlockNumber: 500}, confirmedAt)
+	assert.Equal(t, uint64(1500), retention.ExpiryBlockNumber)
+	assert.Zero(t, retention.ExpiryTime)
+	assert.Equal(t, pb.RenewalStatus_RENEWAL_UNKNOWN, retention.RenewalStatus)
+}
+
+type fakeContractCaller struct {
+	mu     sync.Mutex
+	calls  int
+	blocks []uint64
+	// results are the periods by block, result that of the others
+	results map[uint64][]byte
+	result  []byte
+	err     error
+	// block holds the calls until closed, if set
+	block chan struct{}
+}
+
+func (c *fakeContractCaller) CodeAt(ctx context.Context, contract eth_common.Address, blockNumber *big.Int) ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func (c *fakeContractCaller) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if c.block != nil {
+		<-c.block
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	c.blocks = append(c.blocks, blockNumber.Uint64())
+	if result, ok := c.results[blockNumber.Uint64()]; ok {
+		return result, c.err
+	}
+	return c.result, c.err
+}
+
+func period(blocks int64) []byte {
+	return eth_common.LeftPadBytes(big.NewInt(blocks).Bytes(), 32)
+}
+
+func TestRetentionConfigFromChain(t *testing.T) {
+	// the period was raised at block 200
+	caller := &fakeContractCaller{result: period(3000), results: map[uint64][]byte{100: period(2000)}}
+	s := &DispersalServer{
+		config:        disperser.ServerConfig{Retention: disperser.RetentionConfig{PeriodBlocks: 1000, PeriodContract: "0x01", PeriodMethod: "storagePeriodBlocks()"}},
+		storagePeriod: NewChainStoragePeriod(caller, eth_common.HexToAddress("0x01"), "storagePeriodBlocks()"),
+		logger:        mock.NewLogger(false),
+	}
+	ctx := context.Background()
+	old := &disperser.ConfirmationInfo{ConfirmationBlockNumber: 100}
+	recent := &disperser.ConfirmationInfo{ConfirmationBlockNumber: 300}
+	// the blobs keep the period of their confirmation block
+	assert.Equal(t, uint64(2000), s.retentionConfig(ctx, old).PeriodBlocks)
+	assert.Equal(t, uint64(3000), s.retentionConfig(ctx, recent).PeriodBlocks)
+	assert.Equal(t, []uint64{100, 300}, caller.blocks)
+	// the period of a block is cached
+	assert.Equal(t, uint64(2000), s.retentionConfig(ctx, old).PeriodBlocks)
+	assert.Equal(t, 2, caller.calls)
+	// the blobs without confirmation block aren't read
+	assert.Equal(t, uint64(1000), s.retentionConfig(ctx, &disperser.ConfirmationInfo{}).PeriodBlocks)
+	assert.Equal(t, 2, caller.calls)
+
+	// a slow node doesn't hold back the periods cached
+	caller.block = make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.retentionConfig(ctx, &disperser.ConfirmationInfo{ConfirmationBlockNumber: 400})
+	}()
+	assert.Equal(t, uint64(3000), s.retentionConfig(ctx, recent).PeriodBlocks)
+	close(caller.block)
+	<-done
+	caller.block = nil
+
+	// the configured period is used while the chain can't be read
+	caller.err = errors.New("unavailable")
+	s.storagePeriod = NewChainStoragePeriod(caller, eth_common.HexToAddress("0x01"), "storagePeriodBlocks()")
+	assert.Equal(t, uint64(1000), s.retentionConfig(ctx, recent).PeriodBlocks)
+}
@@ -0,0 +1,248 @@
+package apiserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/disperser"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/urfave/cli"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	RetrievalQuotaBytesPerSecondFlagName = "retrieval-quota.bytes-per-second"
+	RetrievalQuotaBurstFlagName          = "retrieval-quota.burst"
+	RetrievalQuotaMaxConcurrentFlagName  = "retrieval-quota.max-concurrent"
+	RetrievalQuotaQueueTimeoutFlagName   = "retrieval-quota.queue-timeout"
+
+	// the reasons of the throttled retrievals in the metrics
+	retrievalThrottledBytes       = "bytes"
+	retrievalThrottledConcurrency = "concurrency"
+)
+
+func RetrievalQuotaCLIFlags(envPrefix string, flagPrefix string) []cli.Flag {
+	return []cli.Flag{
+		cli.IntFlag{
+			Name:   common.PrefixFlag(flagPrefix, RetrievalQuotaBytesPerSecondFlagName),
+			Usage:  "bytes per second each account may retrieve, unlimited if 0",
+			EnvVar: common.PrefixEnvVar(envPrefix, "RETRIEVAL_QUOTA_BYTES_PER_SECOND"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, RetrievalQuotaBurstFlagName),
+			Usage:  "how long of the bytes quota an idle account may retrieve at once",
+			Value:  10 * time.Second,
+			EnvVar: common.PrefixEnvVar(envPrefix, "RETRIEVAL_QUOTA_BURST"),
+		},
+		cli.IntFlag{
+			Name:   common.PrefixFlag(flagPrefix, RetrievalQuotaMaxConcurrentFlagName),
+			Usage:  "retrievals of each account run at once, unlimited if 0",
+			EnvVar: common.PrefixEnvVar(envPrefix, "RETRIEVAL_QUOTA_MAX_CONCURRENT"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, RetrievalQuotaQueueTimeoutFlagName),
+			Usage:  "how long a retrieval waits for a concurrent retrieval of its account to finish",
+			Value:  5 * time.Second,
+			EnvVar: common.PrefixEnvVar(envPrefix, "RETRIEVAL_QUOTA_QUEUE_TIMEOUT"),
+		},
+	}
+}
+
+func ReadRetrievalQuotaConfig(ctx *cli.Context, flagPrefix string) (disperser.RetrievalQuotaConfig, error) {
+	config := disperser.RetrievalQuotaConfig{
+		BytesPerSecond: ctx.GlobalInt(common.PrefixFlag(flagPrefix, RetrievalQuotaBytesPerSecondFlagName)),
+		Burst:          ctx.GlobalDuration(common.PrefixFlag(flagPrefix, RetrievalQuotaBurstFlagName)),
+		MaxConcurrent:  ctx.GlobalInt(common.PrefixFlag(flagPrefix, RetrievalQuotaMaxConcurrentFlagName)),
+		QueueTimeout:   ctx.GlobalDuration(common.PrefixFlag(flagPrefix, RetrievalQuotaQueueTimeoutFlagName)),
+	}
+	if config.BytesPerSecond < 0 || config.MaxConcurrent < 0 {
+		return disperser.RetrievalQuotaConfig{}, errors.New("retrieval quotas must not be negative")
+	}
+	return config, nil
+}
+
+// retrievalThrottled rejects a retrieval over a retrieval quota of its account
+type retrievalThrottled struct {
+	reason string
+	// wait is how long until the retrieval would be accepted, 0 if unknown
+	wait time.Duration
+}
+
+func (e *retrievalThrottled) Error() string {
+	return fmt.Sprintf("retrieval %s quota exceeded", e.reason)
+}
+
+// retrievalSlots are the concurrent retrievals of an account
+type retrievalSlots struct {
+	slots chan struct{}
+	// users is the number of retrievals holding or waiting for a slot
+	users int
+}
+
+// retrievalBucket is the bytes bucket of an account
+type retrievalBucket struct {
+	tokenBucket
+	// lastSize is the size of the last retrieval of the account, the estimate of the next
+	// one whose size isn't known
+	lastSize int
+}
+
+// retrievalQuotas limits the retrievals of each account
+type retrievalQuotas struct {
+	config disperser.RetrievalQuotaConfig
+
+	mu      sync.Mutex
+	buckets *lru.Cache[string, *retrievalBucket]
+	// running holds the slots of the accounts with retrievals running or queued
+	running map[string]*retrievalSlots
+	now     func() time.Time
+}
+
+func newRetrievalQuotas(config disperser.RetrievalQuotaConfig) *retrievalQuotas {
+	if !config.Enabled() {
+		return nil
+	}
+	if config.Burst <= 0 {
+		config.Burst = time.Second
+	}
+	buckets, _ := lru.New[string, *retrievalBucket](quotaAccounts)
+	return &retrievalQuotas{
+		config:  config,
+		buckets: buckets,
+		running: make(map[string]*retrievalSlots),
+		now:     time.Now,
+	}
+}
+
+// admit admits a retrieval of an account of about estimate bytes, the size of the last
+// retrieval of the account if 0, waiting up to the queue timeout for a concurrent retrieval
+// to finish. The estimate is reserved from the bytes bucket of the account, so concurrent
+// retrievals can't together retrieve more than it holds. It returns how long the retrieval
+// was queued, and the function to call with the bytes retrieved once it is done, which
+// settles the difference with the estimate.
+func (q *retrievalQuotas) admit(ctx context.Context, account string, estimate int) (func(size int), time.Duration, error) {
+	settle, wait := q.reserve(account, estimate)
+	if wait > 0 {
+		return nil, 0, &retrievalThrottled{reason: retrievalThrottledBytes, wait: wait}
+	}
+	if q.config.MaxConcurrent <= 0 {
+		return settle, 0, nil
+	}
+
+	q.mu.Lock()
+	slots, ok := q.running[account]
+	if !ok {
+		slots = &retrievalSlots{slots: make(chan struct{}, q.config.MaxConcurrent)}
+		q.running[account] = slots
+	}
+	slots.users++
+	q.mu.Unlock()
+
+	var queued time.Duration
+	select {
+	case slots.slots <- struct{}{}:
+	default:
+		start := time.Now()
+		timer := time.NewTimer(q.config.QueueTimeout)
+		defer timer.Stop()
+		select {
+		case slots.slots <- struct{}{}:
+			queued = time.Since(start)
+		case <-timer.C:
+			q.leave(account, slots)
+			settle(0)
+			return nil, time.Since(start), &retrievalThrottled{reason: retrievalThrottledConcurrency}
+		case <-ctx.Done():
+			q.leave(account, slots)
+			settle(0)
+			return nil, time.Since(start), status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	return func(size int) {
+		<-slots.slots
+		q.leave(account, slots)
+		settle(size)
+	}, queued, nil
+}
+
+// reserve takes the estimated bytes of a retrieval from the bucket of an account, at most
+// what a full bucket holds, returning the function settling the bytes retrieved. It returns
+// how long until they would be in the bucket if they aren't, nothing being taken. The bucket
+// goes below empty if a retrieval exceeds its estimate, and the account waits for the excess
+// to be refilled.
+func (q *retrievalQuotas) reserve(account string, estimate int) (func(size int), time.Duration) {
+	rate := float64(q.config.BytesPerSecond)
+	if rate <= 0 {
+		return func(int) {}, 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	bucket := q.bucket(account, rate)
+	if estimate <= 0 {
+		estimate = bucket.lastSize
+	}
+	reserved := math.Min(float64(estimate), rate*q.config.Burst.Seconds())
+	if wait := bucket.wait(reserved, rate); wait > 0 {
+		return nil, wait
+	}
+	bucket.tokens -= reserved
+	return func(size int) {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		bucket := q.bucket(account, rate)
+		bucket.tokens += reserved - float64(size)
+		if size > 0 {
+			bucket.lastSize = size
+		}
+	}, 0
+}
+
+// bucket returns the refilled bytes bucket of an account, q.mu must be held
+func (q *retrievalQuotas) bucket(account string, rate float64) *retrievalBucket {
+	bucket, ok := q.buckets.Get(account)
+	if !ok {
+		bucket = &retrievalBucket{}
+		q.buckets.Add(account, bucket)
+	}
+	bucket.refill(q.now(), rate, rate*q.config.Burst.Seconds())
+	return bucket
+}
+
+func (q *retrievalQuotas) leave(account string, slots *retrievalSlots) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	slots.users--
+	if slots.users == 0 {
+		delete(q.running, account)
+	}
+}
+
+// admitRetrieval resolves the account of a retrieval of about estimate bytes, 0 if unknown,
+// and admits it under the retrieval quotas of the account, returning the function to call
+// with the bytes retrieved. The account of a retrieval without an API key is its peer
+// address, see peerAddress. The rejected retrievals fail with ResourceExhausted.
+func (s *DispersalServer) admitRetrieval(ctx context.Context, method string, apiKey string, peerAddress string, estimate int) (func(size int), error) {
+	account, err := s.accounts.resolve(apiKey, peerAddress)
+	if err != nil {
+		return nil, err
+	}
+	if s.retrievalQuotas == nil || s.accounts.allowlisted(account) {
+		return func(int) {}, nil
+	}
+	release, queued, err := s.retrievalQuotas.admit(ctx, account, estimate)
+	if s.retrievalQuotas.config.MaxConcurrent > 0 {
+		s.metrics.ObserveRetrievalQueueWait(method, queued.Seconds())
+	}
+	var throttled *retrievalThrottled
+	if errors.As(err, &throttled) {
+		s.metrics.HandleThrottledRetrieval(throttled.reason, method)
+		s.logger.Debug("[apiserver] retrieval throttled", "account", account, "reason", throttled.reason, "wait", throttled.wait)
+		return nil, quotaExceededError(ctx, fmt.Sprintf("retrieval %s quota of account %s exceeded", throttled.reason, account), throttled.wait)
+	}
+	return release, err
+}
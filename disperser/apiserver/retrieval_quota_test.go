@@ -0,0 +1,86 @@
+package apiserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetrievalQuotas(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	logger := mock.NewLogger(false)
+	s := &DispersalServer{
+		accounts: newAccounts(disperser.QuotaConfig{APIKeys: map[string]string{"token": "indexer"}, Allowlist: []string{"indexer"}}),
+		retrievalQuotas: newRetrievalQuotas(disperser.RetrievalQuotaConfig{
+			BytesPerSecond: 1000,
+			Burst:          time.Second,
+			MaxConcurrent:  1,
+			QueueTimeout:   100 * time.Millisecond,
+		}),
+		metrics: disperser.NewMetrics("", logger),
+		logger:  logger,
+	}
+	s.retrievalQuotas.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// a second retrieval waits for the first to finish
+	release, err := s.admitRetrieval(ctx, "RetrieveBlob", "", "10.0.0.1", 0)
+	require.NoError(t, err)
+	admitted := make(chan func(int))
+	go func() {
+		release, err := s.admitRetrieval(ctx, "RetrieveBlob", "", "10.0.0.1", 0)
+		assert.NoError(t, err)
+		admitted <- release
+	}()
+	time.Sleep(10 * time.Millisecond)
+	release(100)
+	second := <-admitted
+
+	// a third times out in the queue while the second runs, other accounts aren't queued
+	_, err = s.admitRetrieval(ctx, "RetrieveBlob", "", "10.0.0.1", 0)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	other, err := s.admitRetrieval(ctx, "RetrieveBlob", "", "10.0.0.2", 0)
+	require.NoError(t, err)
+	other(0)
+	assert.Equal(t, 1.0, testutil.ToFloat64(s.metrics.ThrottledRetrievals.WithLabelValues(retrievalThrottledConcurrency, "RetrieveBlob")))
+
+	// the account is throttled until the bytes retrieved beyond the bucket are refilled
+	second(2900)
+	_, err = s.admitRetrieval(ctx, "RetrieveBlob", "", "10.0.0.1", 0)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	details := status.Convert(err).Details()
+	require.Len(t, details, 1)
+	// and the next retrieval, estimated at the size of the last one, is reserved
+	now = now.Add(2 * time.Second)
+	_, err = s.admitRetrieval(ctx, "RetrieveBlob", "", "10.0.0.1", 0)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	now = now.Add(time.Second)
+	release, err = s.admitRetrieval(ctx, "RetrieveBlob", "", "10.0.0.1", 0)
+	require.NoError(t, err)
+	release(0)
+	assert.Empty(t, s.retrievalQuotas.running)
+
+	// concurrent retrievals reserve their estimates, and settle the difference when done
+	s.retrievalQuotas.config.MaxConcurrent = 0
+	first, err := s.admitRetrieval(ctx, "RetrieveBlob", "", "10.0.0.3", 600)
+	require.NoError(t, err)
+	_, err = s.admitRetrieval(ctx, "RetrieveBlob", "", "10.0.0.3", 600)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	first(100)
+	_, err = s.admitRetrieval(ctx, "RetrieveBlob", "", "10.0.0.3", 600)
+	require.NoError(t, err)
+
+	// allowlisted accounts aren't limited
+	for i := 0; i < 3; i++ {
+		_, err = s.admitRetrieval(ctx, "RetrieveBlob", "token", "10.0.0.9", 0)
+		require.NoError(t, err)
+	}
+}
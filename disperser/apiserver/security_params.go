@@ -0,0 +1,150 @@
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	eth_common "github.com/ethereum/go-ethereum/common"
+)
+
+// quorumCountTTL is how long the number of quorums registered on chain is cached
+const quorumCountTTL = time.Minute
+
+// QuorumRegistry returns the number of quorums registered on chain in the current epoch,
+// quorum IDs range from 0 to the count excluded, and their slots
+type QuorumRegistry interface {
+	QuorumCount(ctx context.Context) (uint64, error)
+	// QuorumSlots returns the signer of each slice of the blobs dispersed to a quorum
+	QuorumSlots(ctx context.Context, quorumID uint64) ([]eth_common.Address, error)
+}
+
+// QuorumReader reads the quorums from the DASigners contract
+type QuorumReader interface {
+	EpochNumber(opts *bind.CallOpts) (*big.Int, error)
+	QuorumCount(opts *bind.CallOpts, epoch *big.Int) (*big.Int, error)
+	GetQuorum(opts *bind.CallOpts, epoch *big.Int, quorumId *big.Int) ([]eth_common.Address, error)
+}
+
+type chainQuorums struct {
+	reader QuorumReader
+
+	mu     sync.Mutex
+	count  uint64
+	readAt time.Time
+	slots  map[uint64]quorumSlots
+}
+
+type quorumSlots struct {
+	signers []eth_common.Address
+	readAt  time.Time
+}
+
+// NewQuorumRegistry reads the quorums of the current epoch from the DASigners contract, at
+// most once per minute
+func NewQuorumRegistry(reader QuorumReader) QuorumRegistry {
+	return &chainQuorums{reader: reader, slots: make(map[uint64]quorumSlots)}
+}
+
+func (q *chainQuorums) QuorumSlots(ctx context.Context, quorumID uint64) ([]eth_common.Address, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if cached, ok := q.slots[quorumID]; ok && time.Since(cached.readAt) < quorumCountTTL {
+		return cached.signers, nil
+	}
+
+	opts := &bind.CallOpts{Context: ctx}
+	epoch, err := q.reader.EpochNumber(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get epoch number: %w", err)
+	}
+	signers, err := q.reader.GetQuorum(opts, epoch, new(big.Int).SetUint64(quorumID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quorum %d of epoch %s: %w", quorumID, epoch, err)
+	}
+	q.slots[quorumID] = quorumSlots{signers: signers, readAt: time.Now()}
+	return signers, nil
+}
+
+func (q *chainQuorums) QuorumCount(ctx context.Context) (uint64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.readAt.IsZero() && time.Since(q.readAt) < quorumCountTTL {
+		return q.count, nil
+	}
+
+	opts := &bind.CallOpts{Context: ctx}
+	epoch, err := q.reader.EpochNumber(opts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get epoch number: %w", err)
+	}
+	count, err := q.reader.QuorumCount(opts, epoch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get quorum count of epoch %s: %w", epoch, err)
+	}
+	if !count.IsUint64() {
+		return 0, fmt.Errorf("unexpected quorum count %s", count)
+	}
+	q.count, q.readAt = count.Uint64(), time.Now()
+	return q.count, nil
+}
+
+// validateSecurityParams checks the security parameters requested for a blob against the
+// limits of the server and the protocol, and the quorums registered on chain
+func (s *DispersalServer) validateSecurityParams(ctx context.Context, params []*pb.SecurityParams) ([]*core.SecurityParam, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+	limits := s.config.SecurityParams
+	// the limit of the server may raise the threshold of the protocol, never lower it
+	minQuorumThreshold := uint32(max(limits.MinQuorumThreshold, core.MinQuorumThreshold))
+	var quorumCount uint64
+	if s.quorums != nil {
+		var err error
+		quorumCount, err = s.quorums.QuorumCount(ctx)
+		if err != nil {
+			s.logger.Error("[apiserver] failed to read the quorums registered on chain", "err", err)
+			return nil, fmt.Errorf("failed to validate the security params")
+		}
+	}
+
+	result := make([]*core.SecurityParam, 0, len(params))
+	seen := make(map[uint32]bool, len(params))
+	for _, param := range params {
+		quorumID := param.GetQuorumId()
+		if seen[quorumID] {
+			return nil, fmt.Errorf("duplicate security params for quorum %d", quorumID)
+		}
+		seen[quorumID] = true
+		if quorumID > math.MaxUint8 {
+			return nil, fmt.Errorf("invalid quorum %d", quorumID)
+		}
+		if s.quorums != nil && uint64(quorumID) >= quorumCount {
+			return nil, fmt.Errorf("quorum %d is not registered on chain, there are %d quorums", quorumID, quorumCount)
+		}
+
+		adversary, threshold := param.GetAdversaryThreshold(), param.GetQuorumThreshold()
+		if adversary == 0 || threshold > 100 || adversary >= threshold {
+			return nil, fmt.Errorf("invalid security params for quorum %d: 0 < adversary threshold < quorum threshold <= 100", quorumID)
+		}
+		if threshold < minQuorumThreshold {
+			return nil, fmt.Errorf("quorum threshold of quorum %d must be at least %d", quorumID, minQuorumThreshold)
+		}
+		if threshold-adversary < uint32(limits.MinThresholdGap) {
+			return nil, fmt.Errorf("quorum threshold of quorum %d must exceed its adversary threshold by at least %d", quorumID, limits.MinThresholdGap)
+		}
+		result = append(result, &core.SecurityParam{
+			QuorumID:           core.QuorumID(quorumID),
+			AdversaryThreshold: uint8(adversary),
+			QuorumThreshold:    uint8(threshold),
+			Optional:           param.GetOptional(),
+		})
+	}
+	return result, nil
+}
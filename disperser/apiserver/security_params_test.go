@@ -0,0 +1,82 @@
+package apiserver
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQuorumReader registers quorums quorums in every epoch
+type fakeQuorumReader struct {
+	quorums int64
+	reads   int
+	// slots are the signers of the slices of each quorum
+	slots [][]eth_common.Address
+}
+
+func (r *fakeQuorumReader) EpochNumber(opts *bind.CallOpts) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+func (r *fakeQuorumReader) QuorumCount(opts *bind.CallOpts, epoch *big.Int) (*big.Int, error) {
+	r.reads++
+	return big.NewInt(r.quorums), nil
+}
+
+func (r *fakeQuorumReader) GetQuorum(opts *bind.CallOpts, epoch *big.Int, quorumId *big.Int) ([]eth_common.Address, error) {
+	return r.slots[quorumId.Int64()], nil
+}
+
+func TestValidateSecurityParams(t *testing.T) {
+	ctx := context.Background()
+	reader := &fakeQuorumReader{quorums: 2}
+	s := &DispersalServer{
+		config:  disperser.ServerConfig{SecurityParams: disperser.SecurityParamLimits{MinQuorumThreshold: 50, MinThresholdGap: 10}},
+		quorums: NewQuorumRegistry(reader),
+		logger:  mock.NewLogger(false),
+	}
+
+	params, err := s.validateSecurityParams(ctx, nil)
+	require.NoError(t, err)
+	assert.Nil(t, params)
+
+	params, err = s.validateSecurityParams(ctx, []*pb.SecurityParams{
+		{QuorumId: 0, AdversaryThreshold: 20, QuorumThreshold: 80},
+		{QuorumId: 1, AdversaryThreshold: 40, QuorumThreshold: 70},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []*core.SecurityParam{
+		{QuorumID: 0, AdversaryThreshold: 20, QuorumThreshold: 80},
+		{QuorumID: 1, AdversaryThreshold: 40, QuorumThreshold: 70},
+	}, params)
+
+	for _, invalid := range [][]*pb.SecurityParams{
+		// quorum not registered on chain
+		{{QuorumId: 2, AdversaryThreshold: 20, QuorumThreshold: 80}},
+		// duplicate quorum
+		{{QuorumId: 0, AdversaryThreshold: 20, QuorumThreshold: 80}, {QuorumId: 0, AdversaryThreshold: 30, QuorumThreshold: 90}},
+		// thresholds out of range
+		{{QuorumId: 0, AdversaryThreshold: 0, QuorumThreshold: 80}},
+		{{QuorumId: 0, AdversaryThreshold: 20, QuorumThreshold: 101}},
+		{{QuorumId: 0, AdversaryThreshold: 80, QuorumThreshold: 80}},
+		// below the limits of the server
+		{{QuorumId: 0, AdversaryThreshold: 20, QuorumThreshold: 40}},
+		{{QuorumId: 0, AdversaryThreshold: 75, QuorumThreshold: 80}},
+		// below the two thirds of the protocol, which the server limit of 50 can't lower
+		{{QuorumId: 0, AdversaryThreshold: 20, QuorumThreshold: 60}},
+	} {
+		_, err := s.validateSecurityParams(ctx, invalid)
+		assert.Error(t, err, invalid)
+	}
+	// the quorum count is cached
+	assert.Equal(t, 1, reader.reads)
+}
@@ -2,21 +2,33 @@ package apiserver
 
 import (
 	"context"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"math/big"
 	"net"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
 	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
 	"github.com/0glabs/0g-da-client/common"
 	healthcheck "github.com/0glabs/0g-da-client/common/healthcheck"
+	"github.com/0glabs/0g-da-client/common/interceptors"
+	"github.com/0glabs/0g-da-client/common/tlsconfig"
+	"github.com/0glabs/0g-da-client/common/tracing"
 	"github.com/0glabs/0g-da-client/core"
 	"github.com/0glabs/0g-da-client/disperser"
 	"github.com/0glabs/0g-da-client/disperser/api/grpc/retriever"
+	"github.com/0glabs/0g-da-client/disperser/payments"
 	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	metadata_pkg "google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 )
 
 var errSystemRateLimit = fmt.Errorf("request ratelimited: system limit")
@@ -24,18 +36,83 @@ var errAccountRateLimit = fmt.Errorf("request ratelimited: account limit")
 
 const systemAccountKey = "system"
 
+// maxMessageBytes is the default size of the largest message received, a request carrying
+// the largest blob with room for the rest of the message
+const maxMessageBytes = core.MaxBlobSize + 1024*1024
+
+// QuorumSignedHeader is set on confirmed blob status replies to "<quorum id>:<percentage>" for
+// each quorum, the percentage of the quorum that signed the blob. Blobs of partially confirmed
+// batches may carry less than the full guarantee, so clients can decide whether to accept them.
+const QuorumSignedHeader = "x-zgda-quorum-signed"
+
+// Headers of confirmed blob status replies letting clients assess the achieved security.
+// QuorumThresholdsHeader is "<quorum id>:<adversary threshold>:<quorum threshold>" for each
+// quorum, and ReferenceBlockHeader is the block the epoch of the signers was set at.
+const (
+	QuorumThresholdsHeader = "x-zgda-quorum-thresholds"
+	ReferenceBlockHeader   = "x-zgda-reference-block"
+)
+
+// QuorumFallbackHeader is set on confirmed blob status replies to the id of each quorum in
+// which the blob fell short of the optional thresholds it requested, and was confirmed with
+// the default thresholds reported in QuorumThresholdsHeader instead
+const QuorumFallbackHeader = "x-zgda-quorum-fallback"
+
+// Headers of blob status replies locating the blob in its batch, for the verification of its
+// inclusion on chain. They are set as soon as the batch is created, before it is confirmed:
+// BatchHeaderHashHeader is the hex batch header hash, BlobIndexHeader the index of the blob in
+// the batch and BlobCountHeader the number of blobs of the batch. The batch of a processing
+// blob may still fail, the blob is then batched again with another position.
+const (
+	BatchHeaderHashHeader = "x-zgda-batch-header-hash"
+	BlobIndexHeader       = "x-zgda-blob-index"
+	BlobCountHeader       = "x-zgda-blob-count"
+)
+
 type DispersalServer struct {
 	pb.UnimplementedDisperserServer
 	mu *sync.RWMutex
 
 	config disperser.ServerConfig
 
-	blobStore disperser.BlobStore
+	// blobStore stores the blobs dispersed, and blobReader serves the read handlers, which
+	// can't change the blobs
+	blobStore   disperser.BlobStoreWriter
+	blobReader  disperser.BlobStoreReader
+	readReplica disperser.MetadataReplica
+	// statusSubscriptions counts the open SubscribeBlobStatus streams, and
+	// clientSubscriptions those of each client address
+	subscriptionsMu     sync.Mutex
+	statusSubscriptions int
+	clientSubscriptions map[string]int
+	// quorums validates the quorums of the requested security params, nil if unchecked
+	quorums QuorumRegistry
+	// storagePeriod reads the storage period of the blobs from chain, nil if configured
+	storagePeriod StoragePeriod
 
 	rateConfig  RateConfig
 	ratelimiter common.RateLimiter
+	pricer      *Pricer
+	quarantine  *Quarantine
+	// dedup is nil if duplicate dispersals are dispersed again
+	dedup    *deduplicator
+	accounts *accounts
+	// quotas is nil if the accounts aren't limited
+	quotas *quotas
+	// retrievalQuotas is nil if the retrievals of the accounts aren't limited
+	retrievalQuotas *retrievalQuotas
+	// meter charges the dispersals to the credit of the accounts, nil if they are free
+	meter *payments.Meter
+	// priorityAccounts may submit blobs above the default priority lane, see accountIn
+	priorityAccounts map[string]bool
 
 	metrics *disperser.Metrics
+	// tracer traces the requests, nil if they aren't traced
+	tracer *tracing.Tracer
+	// events records the reception of the blobs in their lifecycle history
+	events *disperser.BlobEventLog
+	// requestClock times the requests, which makes their IDs unique
+	requestClock *disperser.RequestClock
 
 	metadataHashAsBlobKey bool
 	kvStore               *disperser.Store
@@ -43,9 +120,10 @@ type DispersalServer struct {
 	logger common.Logger
 
 	retrieverAddr string
+	// retrieverCreds secure the connections to the retriever, set by Start
+	retrieverCreds credentials.TransportCredentials
 
-	writeRateLimiterManager *ClientRateLimiterManager
-	readRateLimiterManager  *ClientRateLimiterManager
+	readRateLimiterManager *ClientRateLimiterManager
 }
 
 // NewServer creates a new Server struct with the provided parameters.
@@ -61,28 +139,74 @@ func NewDispersalServer(
 	metadataHashAsBlobKey bool,
 	kvStore *disperser.Store,
 	retrieverAddr string,
+	pricer *Pricer,
+	quarantine *Quarantine,
+	readReplica disperser.MetadataReplica,
+	quorums QuorumRegistry,
+	storagePeriod StoragePeriod,
+	meter *payments.Meter,
+	tracer *tracing.Tracer,
 ) *DispersalServer {
 
+	replicaID := config.ReplicaID
+	if replicaID == 0 {
+		replicaID = disperser.RandomReplicaID()
+		logger.Info("[apiserver] drew a random replica id, set one per server if several share the blob store", "replica id", replicaID)
+	}
+	if config.EncoderParams.SliceSize == 0 {
+		config.EncoderParams = core.DefaultEncoderParams
+	}
+
 	return &DispersalServer{
 		config:                config,
+		requestClock:          disperser.NewRequestClock(replicaID),
 		blobStore:             store,
+		blobReader:            disperser.ReadOnly(store),
+		readReplica:           readReplica,
+		quorums:               quorums,
+		storagePeriod:         storagePeriod,
 		metrics:               metrics,
 		logger:                logger,
 		ratelimiter:           ratelimiter,
 		rateConfig:            rateConfig,
+		pricer:                pricer,
+		quarantine:            quarantine,
+		dedup:                 newDeduplicator(config.Dedup),
+		accounts:              newAccounts(config.Quotas),
+		quotas:                newQuotas(config.Quotas),
+		retrievalQuotas:       newRetrievalQuotas(config.RetrievalQuotas),
+		meter:                 meter,
+		tracer:                tracer,
+		events:                disperser.NewBlobEventLog(store, logger),
 		mu:                    &sync.RWMutex{},
 		metadataHashAsBlobKey: metadataHashAsBlobKey,
 		kvStore:               kvStore,
 		retrieverAddr:         retrieverAddr,
+		priorityAccounts:      newAccountSet(config.PriorityAccounts),
 
-		writeRateLimiterManager: NewClientRateLimiterManager(3),
-		readRateLimiterManager:  NewClientRateLimiterManager(20),
+		readRateLimiterManager: NewClientRateLimiterManager(orDefault(config.ReadRequestsPerMinute, 20)),
+	}
+}
+
+// Tune applies the rate limits of the tunables reloaded at runtime, those left out keeping
+// their current value. The write limit is the requests quota of the accounts.
+func (s *DispersalServer) Tune(tunables *disperser.Tunables) {
+	if tunables.WriteRequestsPerMinute > 0 {
+		s.quotas.setRequestsPerSecond(float64(tunables.WriteRequestsPerMinute) / 60)
+	}
+	if tunables.ReadRequestsPerMinute > 0 {
+		s.readRateLimiterManager.SetMaxRequests(tunables.ReadRequestsPerMinute)
 	}
 }
 
 func (s *DispersalServer) DisperseBlob(ctx context.Context, req *pb.DisperseBlobRequest) (*pb.DisperseBlobReply, error) {
+	return s.disperseBlob(ctx, "DisperseBlob", req)
+}
+
+// disperseBlob stores the blob of a dispersal request, method naming the API in the metrics
+func (s *DispersalServer) disperseBlob(ctx context.Context, method string, req *pb.DisperseBlobRequest) (*pb.DisperseBlobReply, error) {
 	timer := prometheus.NewTimer(prometheus.ObserverFunc(func(f float64) {
-		s.metrics.ObserveLatency("DisperseBlob", f*1000) // make milliseconds
+		s.metrics.ObserveLatency(method, f*1000) // make milliseconds
 	}))
 	defer timer.ObserveDuration()
 
@@ -95,30 +219,134 @@ func (s *DispersalServer) DisperseBlob(ctx context.Context, req *pb.DisperseBlob
 		return nil, fmt.Errorf("blob size must be greater than 0")
 	}
 
-	blob := getBlobFromRequest(req)
+	securityParams, err := s.validateSecurityParams(ctx, req.GetSecurityParams())
+	if err != nil {
+		s.metrics.HandleFailedRequest(blobSize, method)
+		return nil, err
+	}
+	tags, err := validateTags(req.GetTags())
+	if err != nil {
+		s.metrics.HandleFailedRequest(blobSize, method)
+		return nil, err
+	}
+	blob := getBlobFromRequest(req, securityParams)
+	blob.RequestHeader.Tags = tags
+	blob.RequestHeader.TraceContext = tracing.Inject(ctx)
 
-	origin, err := common.GetClientAddress(ctx, s.rateConfig.ClientIPHeader, 2, true)
+	origin, err := s.clientAddress(ctx)
 	if err != nil {
-		s.metrics.HandleFailedRequest(blobSize, "DisperseBlob")
+		s.metrics.HandleFailedRequest(blobSize, method)
 		return nil, err
 	}
 
 	s.logger.Debug("[apiserver] received a new blob request", "origin", origin)
+	blob.RequestHeader.Signer, err = s.authenticate(req)
+	if err != nil {
+		s.metrics.HandleFailedRequest(blobSize, method)
+		return nil, err
+	}
+	peerAddress, err := s.peerAddress(ctx)
+	if err != nil {
+		s.metrics.HandleFailedRequest(blobSize, method)
+		return nil, err
+	}
+	// the signer, or else the API key or the peer address, is the account the batcher
+	// shares the backlog between
+	blob.RequestHeader.AccountID, err = s.requestAccount(ctx, peerAddress, blob.RequestHeader.Signer)
+	if err != nil {
+		s.metrics.HandleFailedRequest(blobSize, method)
+		return nil, err
+	}
+	blob.RequestHeader.Priority, err = s.requestPriority(ctx, blob.RequestHeader.AccountID)
+	if err != nil {
+		s.metrics.HandleFailedRequest(blobSize, method)
+		return nil, err
+	}
 
-	limiter := s.writeRateLimiterManager.GetRateLimiter(origin)
-	if !limiter.Allow() {
-		s.logger.Debug("[apiserver] client %s: Rate limit exceeded for disperse blob\n", origin)
-		return nil, fmt.Errorf("request ratelimited")
+	// the fee of the dispersal is charged at the price quoted for the blob, or the default one
+	var feePerByte *big.Int
+	if s.pricer != nil {
+		quote, err := s.pricer.verifyRequestQuote(ctx, uint64(blobSize), blob.RequestHeader.AccountID)
+		if err != nil {
+			s.metrics.HandleFailedRequest(blobSize, method)
+			return nil, err
+		}
+		s.logger.Debug("[apiserver] blob accepted under price quote", "feePerByte", quote.FeePerByte, "expiresAt", quote.ExpiresAt)
+		feePerByte = quote.FeePerByte
+	}
+
+	if err := s.useNonce(ctx, blob.RequestHeader.Signer, req.GetNonce()); err != nil {
+		s.metrics.HandleFailedRequest(blobSize, method)
+		return nil, err
 	}
 
-	requestedAt := uint64(time.Now().UnixNano())
-	metadataKey, err := s.blobStore.StoreBlob(ctx, blob, requestedAt)
+	// the quota and the charge are given back unless the blob is stored
+	taken, err := s.takeQuota(ctx, blob.RequestHeader.AccountID, blobSize)
 	if err != nil {
-		s.metrics.HandleFailedRequest(blobSize, "DisperseBlob")
+		s.metrics.HandleAccountRateLimitedRequest(blobSize, method)
+		return nil, err
+	}
+	charge, err := s.meter.Charge(ctx, blob.RequestHeader.AccountID, blobSize, feePerByte)
+	if errors.Is(err, payments.ErrInsufficientCredit) {
+		s.quotas.giveBack(taken)
+		s.metrics.HandleInsufficientCreditRequest(blobSize, method)
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	} else if err != nil {
+		s.quotas.giveBack(taken)
+		s.metrics.HandleFailedRequest(blobSize, method)
+		return nil, err
+	}
+	refund := func() {
+		s.quotas.giveBack(taken)
+		s.meter.Refund(ctx, charge)
+	}
+	requestedAt := s.requestClock.Next()
+	ctx, span := s.tracer.StartSpan(ctx, "store blob", tracing.Int("blob.size", int64(blobSize)), tracing.String("account", string(blob.RequestHeader.AccountID)))
+	var metadataKey disperser.BlobKey
+	var receivedDetail string
+	if reason, quarantined := s.checkQuarantine(origin, blob.Data); quarantined {
+		metadataKey, err = s.blobStore.StoreQuarantinedBlob(ctx, blob, requestedAt, reason)
+		receivedDetail = "quarantined: " + reason
+		if err == nil {
+			s.logger.Warn("[apiserver] blob quarantined", "key", metadataKey.String(), "origin", origin, "reason", reason)
+			setQuarantinedHeader(ctx)
+		}
+	} else if s.dedup != nil {
+		key := dedupKeyOf(blob)
+		existing, pending, lookupErr := s.findDuplicate(ctx, key)
+		if lookupErr != nil {
+			tracing.End(span, lookupErr)
+			refund()
+			s.metrics.HandleFailedRequest(blobSize, method)
+			return nil, lookupErr
+		}
+		if existing != nil {
+			span.SetAttributes(tracing.String("blob.key", existing.GetBlobKey().String()), tracing.Bool("duplicate", true))
+			tracing.End(span, nil)
+			refund()
+			s.metrics.HandleDuplicateRequest(blobSize, method)
+			setDuplicateHeader(ctx)
+			s.logger.Info("[apiserver] received a duplicate blob", "key", existing.GetBlobKey().String(), "status", existing.BlobStatus.String(), "origin", origin)
+			return &pb.DisperseBlobReply{
+				Result:    getResponseStatus(existing.BlobStatus),
+				RequestId: []byte(existing.GetBlobKey().String()),
+			}, nil
+		}
+		metadataKey, err = s.blobStore.StoreBlob(ctx, blob, requestedAt)
+		s.dedup.finish(key, pending, metadataKey, err)
+	} else {
+		metadataKey, err = s.blobStore.StoreBlob(ctx, blob, requestedAt)
+	}
+	span.SetAttributes(tracing.String("blob.key", metadataKey.String()))
+	tracing.End(span, err)
+	if err != nil {
+		refund()
+		s.metrics.HandleFailedRequest(blobSize, method)
 		return nil, err
 	}
 
-	s.metrics.HandleSuccessfulRequest(blobSize, "DisperseBlob")
+	s.metrics.HandleSuccessfulRequest(blobSize, method)
+	s.events.Record(metadataKey, disperser.BlobReceived, receivedDetail)
 
 	s.logger.Info("[apiserver] received a new blob: ", "key", metadataKey.String())
 	return &pb.DisperseBlobReply{
@@ -127,6 +355,26 @@ func (s *DispersalServer) DisperseBlob(ctx context.Context, req *pb.DisperseBlob
 	}, nil
 }
 
+func (s *DispersalServer) checkQuarantine(origin string, data []byte) (string, bool) {
+	if s.quarantine == nil {
+		return "", false
+	}
+	return s.quarantine.Check(origin, data)
+}
+
+// getConfirmationFromKv returns the confirmation of a blob finalized to the kv store, nil for
+// the blobs finalized before their confirmation was recorded
+func (s *DispersalServer) getConfirmationFromKv(ctx context.Context, key []byte) (*disperser.BlobConfirmation, error) {
+	val, err := s.kvStore.GetConfirmation(ctx, key)
+	if errors.Is(err, disperser.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return new(disperser.BlobConfirmation).Deserialize(val)
+}
+
 func (s *DispersalServer) getMetadataFromKv(ctx context.Context, key []byte) (*disperser.BlobRetrieveMetadata, error) {
 	val, err := s.kvStore.GetMetadata(ctx, key)
 	if err != nil {
@@ -148,37 +396,60 @@ func (s *DispersalServer) GetBlobStatus(ctx context.Context, req *pb.BlobStatusR
 	}))
 	defer timer.ObserveDuration()
 
-	requestID := req.GetRequestId()
+	s.logger.Info("[apiserver] received a new blob status request", "requestID", string(req.GetRequestId()))
+	return s.blobStatus(ctx, req.GetRequestId())
+}
+
+// blobStatus returns the status reply of a blob, setting the response headers on ctx
+func (s *DispersalServer) blobStatus(ctx context.Context, requestID []byte) (*pb.BlobStatusReply, error) {
 	if len(requestID) == 0 {
 		return nil, fmt.Errorf("invalid request: request_id must not be empty")
 	}
 
-	s.logger.Info("[apiserver] received a new blob status request", "requestID", string(requestID))
 	metadataKey, err := disperser.ParseBlobKey(string(requestID))
 	if err != nil {
 		return nil, err
 	}
 
-	metadata, err := s.blobStore.GetBlobMetadata(ctx, metadataKey)
+	metadata, staleness, fromReplica, err := s.getBlobMetadata(ctx, metadataKey)
 	if err != nil && !s.metadataHashAsBlobKey {
 		return nil, err
 	}
-	if (metadata == nil || metadata.GetBlobKey().String() != string(requestID)) && s.metadataHashAsBlobKey {
-		// check on kv
-		metadataFromKV, err := s.getMetadataFromKv(ctx, requestID)
+	// the headers are kept for the unary clients, a stream only sends those of its first message
+	reply := &pb.BlobStatusReply{}
+	if fromReplica {
+		setStalenessHeader(ctx, staleness)
+		reply.StalenessMs = uint64(staleness.Milliseconds())
+	}
+	if (metadata == nil || metadata.GetBlobKey() != metadataKey) && s.metadataHashAsBlobKey {
+		// check on kv, where the blobs are stored under the canonical request ID
+		metadataFromKV, err := s.getMetadataFromKv(ctx, []byte(metadataKey.String()))
 		if err != nil {
 			s.logger.Warn("get metadata from kv", err)
 		}
 		if metadataFromKV != nil {
+			setKVStateHeader(ctx, KVStateStored)
+			reply.KvState = KVStateStored
 			// metadata = metadataInKV
-			metadata = &disperser.BlobMetadata{
-				BlobStatus: disperser.Finalized,
-				ConfirmationInfo: &disperser.ConfirmationInfo{
-					DataRoot: metadataFromKV.DataRoot,
-					Epoch:    metadataFromKV.Epoch,
-					QuorumId: metadataFromKV.QuorumId,
-				},
+			confirmationInfo := &disperser.ConfirmationInfo{
+				DataRoot: metadataFromKV.DataRoot,
+				Epoch:    metadataFromKV.Epoch,
+				QuorumId: metadataFromKV.QuorumId,
+			}
+			var tags map[string]string
+			if confirmation, err := s.getConfirmationFromKv(ctx, []byte(metadataKey.String())); err != nil {
+				s.logger.Warn("[apiserver] failed to get the confirmation from kv", "err", err)
+			} else if confirmation != nil && confirmation.Info != nil {
+				confirmationInfo, tags = confirmation.Info, confirmation.Tags
+			} else if confirmation != nil {
+				confirmationInfo.ConfirmationBlockNumber = confirmation.BlockNumber
+				confirmationInfo.ConfirmedAt = confirmation.ConfirmedAt
+				tags = confirmation.Tags
 			}
+			metadata = withTags(&disperser.BlobMetadata{
+				BlobStatus:       disperser.Finalized,
+				ConfirmationInfo: confirmationInfo,
+			}, tags)
 		} else {
 			// behavior align with aws dynamodb
 			metadata = &disperser.BlobMetadata{
@@ -194,24 +465,98 @@ func (s *DispersalServer) GetBlobStatus(ctx context.Context, req *pb.BlobStatusR
 
 	s.logger.Debug("[apiserver] isConfirmed", "metadata", metadata, "isConfirmed", isConfirmed)
 	if isConfirmed {
+		if metadata.BlobStatus == disperser.Finalized && metadata.BlobHash != "" {
+			// finalized blobs are removed from the blob store once persisted to kv
+			setKVStateHeader(ctx, KVStatePending)
+			reply.KvState = KVStatePending
+		}
 		confirmationInfo := metadata.ConfirmationInfo
+		header := metadata_pkg.MD{}
+		quorumIDs := make([]core.QuorumID, 0, len(confirmationInfo.QuorumResults))
+		for quorumID := range confirmationInfo.QuorumResults {
+			quorumIDs = append(quorumIDs, quorumID)
+		}
+		sort.Slice(quorumIDs, func(i, j int) bool { return quorumIDs[i] < quorumIDs[j] })
+		for _, quorumID := range quorumIDs {
+			result := confirmationInfo.QuorumResults[quorumID]
+			reply.QuorumResults = append(reply.QuorumResults, &pb.QuorumResult{
+				QuorumId:           uint32(quorumID),
+				PercentSigned:      uint32(result.PercentSigned),
+				AdversaryThreshold: uint32(result.AdversaryThreshold),
+				QuorumThreshold:    uint32(result.QuorumThreshold),
+				Fallback:           result.Fallback,
+			})
+			header.Append(QuorumSignedHeader, fmt.Sprintf("%d:%d", quorumID, result.PercentSigned))
+			if result.AdversaryThreshold > 0 || result.QuorumThreshold > 0 {
+				header.Append(QuorumThresholdsHeader, fmt.Sprintf("%d:%d:%d", quorumID, result.AdversaryThreshold, result.QuorumThreshold))
+			}
+			if result.Fallback {
+				header.Append(QuorumFallbackHeader, strconv.Itoa(int(quorumID)))
+			}
+		}
+		if confirmationInfo.ReferenceBlockNumber > 0 {
+			header.Set(ReferenceBlockHeader, strconv.FormatUint(uint64(confirmationInfo.ReferenceBlockNumber), 10))
+			reply.ReferenceBlockNumber = uint64(confirmationInfo.ReferenceBlockNumber)
+		}
+		setBatchAssignmentHeader(header, &disperser.BatchAssignment{
+			BatchHeaderHash: confirmationInfo.BatchHeaderHash,
+			BlobIndex:       confirmationInfo.BlobIndex,
+			BlobCount:       confirmationInfo.BlobCount,
+		})
+		if header.Len() > 0 {
+			_ = grpc.SetHeader(ctx, header)
+		}
 
-		return &pb.BlobStatusReply{
-			Status: getResponseStatus(metadata.BlobStatus),
-			Info: &pb.BlobInfo{
-				BlobHeader: &pb.BlobHeader{
-					StorageRoot: confirmationInfo.DataRoot,
-					Epoch:       confirmationInfo.Epoch,
-					QuorumId:    confirmationInfo.QuorumId,
-				},
+		reply.Status = getResponseStatus(metadata.BlobStatus)
+		reply.Info = &pb.BlobInfo{
+			BlobHeader: &pb.BlobHeader{
+				StorageRoot: confirmationInfo.DataRoot,
+				Epoch:       confirmationInfo.Epoch,
+				QuorumId:    confirmationInfo.QuorumId,
 			},
-		}, nil
+		}
+		reply.Tags = blobTags(metadata)
+		reply.Retention = blobRetention(s.retentionConfig(ctx, confirmationInfo), confirmationInfo, time.Now())
+		reply.Venue = venueReply(confirmationInfo.Venue)
+		return reply, nil
 	}
 
-	return &pb.BlobStatusReply{
-		Status: getResponseStatus(metadata.BlobStatus),
-		Info:   &pb.BlobInfo{},
-	}, nil
+	if metadata.BlobStatus == disperser.Quarantined {
+		setQuarantinedHeader(ctx)
+		reply.Quarantined = true
+	}
+	if metadata.BlobStatus == disperser.DeadLettered {
+		setDeadLetterReasonHeader(ctx, metadata.DeadLetterReason)
+		reply.DeadLetterReason = metadata.DeadLetterReason
+	}
+	if metadata.BlobStatus == disperser.Processing && metadata.BatchAssignment != nil {
+		header := metadata_pkg.MD{}
+		setBatchAssignmentHeader(header, metadata.BatchAssignment)
+		_ = grpc.SetHeader(ctx, header)
+	}
+	reply.Status = getResponseStatus(metadata.BlobStatus)
+	reply.Info = &pb.BlobInfo{}
+	reply.Tags = blobTags(metadata)
+	return reply, nil
+}
+
+// setBatchAssignmentHeader sets the headers locating a blob in its batch, none for the blobs
+// read back from the kv store, which don't record their batch
+func setBatchAssignmentHeader(header metadata_pkg.MD, assignment *disperser.BatchAssignment) {
+	if assignment.BatchHeaderHash == [32]byte{} {
+		return
+	}
+	header.Set(BatchHeaderHashHeader, hex.EncodeToString(assignment.BatchHeaderHash[:]))
+	header.Set(BlobIndexHeader, strconv.FormatUint(uint64(assignment.BlobIndex), 10))
+	header.Set(BlobCountHeader, strconv.FormatUint(uint64(assignment.BlobCount), 10))
+}
+
+// blobTags returns the tags of a blob, nil if it has none
+func blobTags(metadata *disperser.BlobMetadata) map[string]string {
+	if metadata.RequestMetadata == nil {
+		return nil
+	}
+	return metadata.RequestMetadata.Tags
 }
 
 func (s *DispersalServer) RetrieveBlob(ctx context.Context, req *pb.RetrieveBlobRequest) (*pb.RetrieveBlobReply, error) {
@@ -222,7 +567,7 @@ func (s *DispersalServer) RetrieveBlob(ctx context.Context, req *pb.RetrieveBlob
 
 	s.logger.Info("[apiserver] received a new blob retrieval request", "blob storage root", req.StorageRoot, "blob epoch", req.Epoch, "quorum id", req.QuorumId)
 
-	origin, err := common.GetClientAddress(ctx, s.rateConfig.ClientIPHeader, 2, true)
+	origin, err := s.clientAddress(ctx)
 	if err != nil {
 		s.metrics.HandleFailedRequest(0, "RetrieveBlob")
 		return nil, err
@@ -234,33 +579,73 @@ func (s *DispersalServer) RetrieveBlob(ctx context.Context, req *pb.RetrieveBlob
 		return nil, fmt.Errorf("request ratelimited")
 	}
 
+	peerAddress, err := s.peerAddress(ctx)
+	if err != nil {
+		s.metrics.HandleFailedRequest(0, "RetrieveBlob")
+		return nil, err
+	}
+	release, err := s.admitRetrieval(ctx, "RetrieveBlob", s.accounts.apiKey(ctx), peerAddress, 0)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.retrieveBlobData(ctx, req.StorageRoot, req.Epoch, req.QuorumId)
+	release(len(data))
+	if err != nil {
+		s.logger.Error("Failed to retrieve blob", "err", err)
+		s.metrics.HandleFailedRequest(len(data), "RetrieveBlob")
+
+		return nil, err
+	}
+
+	s.metrics.HandleSuccessfulRequest(len(data), "RetrieveBlob")
+
+	return &pb.RetrieveBlobReply{
+		Data: data,
+	}, nil
+}
+
+// retrieveBlobData reads a blob from the kv store, falling back to the retriever
+func (s *DispersalServer) retrieveBlobData(ctx context.Context, storageRoot []byte, epoch, quorumId uint64) ([]byte, error) {
+	if data, ok := s.kvBlobData(ctx, storageRoot, epoch, quorumId); ok {
+		return data, nil
+	}
+	return s.retrieverBlobData(ctx, storageRoot, epoch, quorumId)
+}
+
+// kvBlobData reads a finalized blob from the kv store, returning false if it isn't there
+func (s *DispersalServer) kvBlobData(ctx context.Context, storageRoot []byte, epoch, quorumId uint64) ([]byte, bool) {
+	if s.kvStore == nil {
+		return nil, false
+	}
 	metaData := disperser.BlobRetrieveMetadata{
-		DataRoot: req.StorageRoot,
-		Epoch:    req.Epoch,
-		QuorumId: req.QuorumId,
+		DataRoot: storageRoot,
+		Epoch:    epoch,
+		QuorumId: quorumId,
 	}
 	blobKey, err := metaData.Serialize()
 	if err != nil {
 		s.logger.Error("[apiserver] failed to serialize metadata")
-	} else {
-		data, err := s.kvStore.GetBlob(ctx, blobKey)
-		if err != nil {
-			s.logger.Error("[apiserver] failed to get blob for key", "blobKey", blobKey)
-		} else {
-			s.metrics.HandleSuccessfulRequest(len(data), "RetrieveBlob")
-			return &pb.RetrieveBlobReply{
-				Data: data,
-			}, nil
-		}
+		return nil, false
 	}
+	data, err := s.kvStore.GetBlob(ctx, blobKey)
+	if err != nil {
+		s.logger.Error("[apiserver] failed to get blob for key", "blobKey", blobKey)
+		return nil, false
+	}
+	return data, true
+}
 
+// retrieverBlobData retrieves a blob from the storage nodes with the retriever
+func (s *DispersalServer) retrieverBlobData(ctx context.Context, storageRoot []byte, epoch, quorumId uint64) ([]byte, error) {
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 	conn, err := grpc.DialContext(
 		ctxWithTimeout,
 		s.retrieverAddr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(1024*1024*1024)), // 1 GiB
+		append(tracing.DialOptions(),
+			grpc.WithTransportCredentials(s.retrieverCreds),
+			grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(1024*1024*1024)), // 1 GiB
+		)...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to dial retriever: %w", err)
@@ -269,24 +654,11 @@ func (s *DispersalServer) RetrieveBlob(ctx context.Context, req *pb.RetrieveBlob
 
 	client := retriever.NewRetrieverClient(conn)
 	reply, err := client.RetrieveBlob(ctx, &retriever.BlobRequest{
-		StorageRoot: req.StorageRoot,
-		Epoch:       req.Epoch,
-		QuorumId:    req.QuorumId,
+		StorageRoot: storageRoot,
+		Epoch:       epoch,
+		QuorumId:    quorumId,
 	})
-
-	data := reply.GetData()
-	if err != nil {
-		s.logger.Error("Failed to retrieve blob", "err", err)
-		s.metrics.HandleFailedRequest(len(data), "RetrieveBlob")
-
-		return nil, err
-	}
-
-	s.metrics.HandleSuccessfulRequest(len(data), "RetrieveBlob")
-
-	return &pb.RetrieveBlobReply{
-		Data: data,
-	}, nil
+	return reply.GetData(), err
 }
 
 func (s *DispersalServer) Start(ctx context.Context) error {
@@ -300,14 +672,43 @@ func (s *DispersalServer) Start(ctx context.Context) error {
 		return fmt.Errorf("could not start tcp listener")
 	}
 
-	opt := grpc.MaxRecvMsgSize(1024 * 1024 * 300) // 300 MiB
-	gs := grpc.NewServer(opt)
+	s.retrieverCreds, err = tlsconfig.ClientCredentials(s.config.RetrieverTLS, s.logger)
+	if err != nil {
+		return err
+	}
+	serverCreds, err := tlsconfig.ServerCredentials(s.config.TLS, s.logger)
+	if err != nil {
+		return err
+	}
+
+	s.events.Start(ctx)
+	if s.config.HTTPPort != "" {
+		s.startBatchHTTPServer(ctx)
+	}
+
+	guards := s.config.Guards
+	if guards.MaxMessageBytes == 0 {
+		guards.MaxMessageBytes = maxMessageBytes
+	}
+	// the requests are traced outermost, rejections by the guards included
+	opts := append(s.tracer.ServerOptions(), interceptors.GuardServerOptions(guards)...)
+	opts = append(opts, interceptors.ServerOptions(s.config.Interceptors, s.logger, s.metrics.Registry(), "zgda_disperser")...)
+	if serverCreds != nil {
+		opts = append(opts, grpc.Creds(serverCreds))
+	}
+	gs := grpc.NewServer(opts...)
 	reflection.Register(gs)
 	pb.RegisterDisperserServer(gs, s)
 
 	// Register Server for Health Checks
 	healthcheck.RegisterHealthServer(gs)
 
+	// stop accepting requests once ctx is done, letting those in flight complete
+	go func() {
+		<-ctx.Done()
+		interceptors.Drain(gs, guards)
+	}()
+
 	s.logger.Info("[apiserver] port", s.config.GrpcPort, "address", listener.Addr().String(), "GRPC Listening")
 	if err := gs.Serve(listener); err != nil {
 		return fmt.Errorf("could not start GRPC server")
@@ -318,11 +719,11 @@ func (s *DispersalServer) Start(ctx context.Context) error {
 
 func getResponseStatus(status disperser.BlobStatus) pb.BlobStatus {
 	switch status {
-	case disperser.Processing:
+	case disperser.Processing, disperser.Quarantined:
 		return pb.BlobStatus_PROCESSING
 	case disperser.Confirmed:
 		return pb.BlobStatus_CONFIRMED
-	case disperser.Failed:
+	case disperser.Failed, disperser.DeadLettered:
 		return pb.BlobStatus_FAILED
 	case disperser.Finalized:
 		return pb.BlobStatus_FINALIZED
@@ -333,10 +734,13 @@ func getResponseStatus(status disperser.BlobStatus) pb.BlobStatus {
 	}
 }
 
-func getBlobFromRequest(req *pb.DisperseBlobRequest) *core.Blob {
+func getBlobFromRequest(req *pb.DisperseBlobRequest, securityParams []*core.SecurityParam) *core.Blob {
 	data := req.GetData()
 
 	blob := &core.Blob{
+		RequestHeader: core.BlobRequestHeader{
+			SecurityParams: securityParams,
+		},
 		Data: data,
 	}
 
@@ -366,6 +770,13 @@ func (rl *TinyRateLimiter) Allow() bool {
 	return true
 }
 
+func orDefault(value, defaultValue int) int {
+	if value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
 type ClientRateLimiterManager struct {
 	clients     map[string]*TinyRateLimiter // Map of client ID to RateLimiter
 	maxRequests int
@@ -394,3 +805,16 @@ func (m *ClientRateLimiterManager) GetRateLimiter(clientID string) *TinyRateLimi
 
 	return limiter
 }
+
+// SetMaxRequests changes the requests allowed per minute to each client
+func (m *ClientRateLimiterManager) SetMaxRequests(maxRequests int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.maxRequests = maxRequests
+	for _, limiter := range m.clients {
+		limiter.mu.Lock()
+		limiter.MaxRequests = maxRequests
+		limiter.mu.Unlock()
+	}
+}
@@ -0,0 +1,42 @@
+package apiserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestTune(t *testing.T) {
+	logger := mock.NewLogger(false)
+	config := disperser.ServerConfig{ReadRequestsPerMinute: 50}
+	s := NewDispersalServer(config, memorydb.NewBlobStore(1<<30, logger), logger, disperser.NewMetrics("0", logger), nil, RateConfig{}, false, nil, "", nil, nil, nil, nil, nil, nil, nil)
+
+	// no quota is set, the dispersals aren't limited
+	assert.False(t, s.quotas.enabled())
+
+	s.Tune(&disperser.Tunables{WriteRequestsPerMinute: 120, ReadRequestsPerMinute: 100})
+	assert.Equal(t, 100, s.readRateLimiterManager.GetRateLimiter("client").MaxRequests)
+	assert.True(t, s.quotas.enabled())
+	assert.Equal(t, 2.0, s.quotas.config.RequestsPerSecond)
+
+	// the limits left out keep their current value
+	s.Tune(&disperser.Tunables{LogLevel: "info"})
+	assert.Equal(t, 100, s.readRateLimiterManager.GetRateLimiter("client").MaxRequests)
+	assert.Equal(t, 2.0, s.quotas.config.RequestsPerSecond)
+
+	// the dispersals are limited by the quota tuned
+	ctx := context.Background()
+	_, err := s.takeQuota(ctx, "account", 1)
+	require.NoError(t, err)
+	_, err = s.takeQuota(ctx, "account", 1)
+	require.NoError(t, err)
+	_, err = s.takeQuota(ctx, "account", 1)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
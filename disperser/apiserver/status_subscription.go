@@ -0,0 +1,147 @@
+package apiserver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// defaultStatusSubscriptionInterval is how often a watched blob is read if not configured
+	defaultStatusSubscriptionInterval = time.Second
+	// defaultMaxStatusSubscriptions bounds the open subscriptions if not configured
+	defaultMaxStatusSubscriptions = 1000
+	// defaultMaxClientStatusSubscriptions bounds the open subscriptions of a client if not
+	// configured
+	defaultMaxClientStatusSubscriptions = 10
+	// defaultMaxStatusSubscriptionDuration bounds how long a subscription is open if not
+	// configured
+	defaultMaxStatusSubscriptionDuration = 10 * time.Minute
+)
+
+// SubscribeBlobStatus sends the status of a blob each time it changes until it is final.
+// The batcher may run in another process, so the status is read from the blob store at an
+// interval rather than notified. Opening a subscription is charged as a read to the rate
+// limiter of the client, the open subscriptions are bounded in all and per client, and each
+// ends with DEADLINE_EXCEEDED after MaxStatusSubscriptionDuration. Everything the headers of
+// GetBlobStatus carry is in the messages, the headers being sent only once.
+func (s *DispersalServer) SubscribeBlobStatus(req *pb.BlobStatusRequest, stream pb.Disperser_SubscribeBlobStatusServer) error {
+	s.logger.Info("[apiserver] received a new blob status subscription", "requestID", string(req.GetRequestId()))
+
+	ctx := stream.Context()
+	origin, err := s.clientAddress(ctx)
+	if err != nil {
+		return err
+	}
+	if !s.readRateLimiterManager.GetRateLimiter(origin).Allow() {
+		return status.Error(codes.ResourceExhausted, "request ratelimited")
+	}
+	// the client ip header can be spoofed, so the subscriptions are counted per connection
+	// address
+	client, err := s.peerAddress(ctx)
+	if err != nil {
+		return err
+	}
+	release, err := s.openStatusSubscription(client)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	duration := s.config.MaxStatusSubscriptionDuration
+	if duration <= 0 {
+		duration = defaultMaxStatusSubscriptionDuration
+	}
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	interval := s.config.StatusSubscriptionInterval
+	if interval <= 0 {
+		interval = defaultStatusSubscriptionInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last *pb.BlobStatusReply
+	for {
+		reply, err := s.blobStatus(ctx, req.GetRequestId())
+		if err != nil {
+			return err
+		}
+		if last == nil || statusChanged(reply, last) {
+			if err := stream.Send(reply); err != nil {
+				return err
+			}
+			last = reply
+		}
+		if isFinalStatus(reply.GetStatus()) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return status.Errorf(codes.DeadlineExceeded, "blob status subscription open for %v, subscribe again", duration)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// openStatusSubscription counts a subscription of a client, returning the func closing it,
+// or ResourceExhausted if there are too many open in all or of the client
+func (s *DispersalServer) openStatusSubscription(client string) (func(), error) {
+	limit := s.config.MaxStatusSubscriptions
+	if limit <= 0 {
+		limit = defaultMaxStatusSubscriptions
+	}
+	clientLimit := s.config.MaxClientStatusSubscriptions
+	if clientLimit <= 0 {
+		clientLimit = defaultMaxClientStatusSubscriptions
+	}
+
+	s.subscriptionsMu.Lock()
+	defer s.subscriptionsMu.Unlock()
+	if s.statusSubscriptions >= limit {
+		return nil, status.Errorf(codes.ResourceExhausted, "too many blob status subscriptions, at most %d", limit)
+	}
+	if s.clientSubscriptions[client] >= clientLimit {
+		return nil, status.Errorf(codes.ResourceExhausted, "too many blob status subscriptions of the client, at most %d", clientLimit)
+	}
+	if s.clientSubscriptions == nil {
+		s.clientSubscriptions = make(map[string]int)
+	}
+	s.statusSubscriptions++
+	s.clientSubscriptions[client]++
+	return func() {
+		s.subscriptionsMu.Lock()
+		defer s.subscriptionsMu.Unlock()
+		s.statusSubscriptions--
+		if s.clientSubscriptions[client]--; s.clientSubscriptions[client] <= 0 {
+			delete(s.clientSubscriptions, client)
+		}
+	}, nil
+}
+
+// statusChanged returns whether a status reply differs from the last one sent, the replica
+// staleness aside since it changes with every read
+func statusChanged(reply, last *pb.BlobStatusReply) bool {
+	reply = proto.Clone(reply).(*pb.BlobStatusReply)
+	reply.StalenessMs = last.GetStalenessMs()
+	return !proto.Equal(reply, last)
+}
+
+// isFinalStatus returns whether a blob in the status can't change anymore
+func isFinalStatus(status pb.BlobStatus) bool {
+	switch status {
+	case pb.BlobStatus_FINALIZED, pb.BlobStatus_FAILED, pb.BlobStatus_INSUFFICIENT_SIGNATURES:
+		return true
+	}
+	return false
+}
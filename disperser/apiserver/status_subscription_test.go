@@ -0,0 +1,144 @@
+package apiserver
+
+import (
+	"context"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type statusStream struct {
+	pb.Disperser_SubscribeBlobStatusServer
+	replies chan *pb.BlobStatusReply
+	client  string
+}
+
+func (s *statusStream) Context() context.Context {
+	client := s.client
+	if client == "" {
+		client = "10.0.0.1"
+	}
+	return peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(client), Port: 1}})
+}
+
+func (s *statusStream) Send(reply *pb.BlobStatusReply) error {
+	s.replies <- reply
+	return nil
+}
+
+func TestSubscribeBlobStatus(t *testing.T) {
+	logger := mock.NewLogger(false)
+	store := memorydb.NewBlobStore(core.MaxBlobSize*4, logger)
+	server := &DispersalServer{
+		config:                 disperser.ServerConfig{StatusSubscriptionInterval: time.Millisecond},
+		blobStore:              store,
+		blobReader:             store,
+		logger:                 logger,
+		readRateLimiterManager: NewClientRateLimiterManager(math.MaxInt32),
+	}
+
+	ctx := context.Background()
+	key, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("blob")}, 1)
+	assert.NoError(t, err)
+
+	stream := &statusStream{replies: make(chan *pb.BlobStatusReply, 16)}
+	done := make(chan error, 1)
+	go func() {
+		done <- server.SubscribeBlobStatus(&pb.BlobStatusRequest{RequestId: []byte(key.String())}, stream)
+	}()
+
+	// the current status is sent right away, then only its changes until it is final
+	assert.Equal(t, pb.BlobStatus_PROCESSING, (<-stream.replies).GetStatus())
+	time.Sleep(10 * time.Millisecond)
+	assert.Empty(t, stream.replies)
+
+	// the confirmation details are in the message, a stream sending its headers only once
+	metadata, err := store.GetBlobMetadata(ctx, key)
+	assert.NoError(t, err)
+	_, err = store.MarkBlobConfirmed(ctx, metadata, &disperser.ConfirmationInfo{
+		ReferenceBlockNumber: 7,
+		QuorumResults: map[core.QuorumID]*core.QuorumResult{
+			1: {QuorumID: 1, PercentSigned: 70, AdversaryThreshold: 33, QuorumThreshold: 67, Fallback: true},
+			0: {QuorumID: 0, PercentSigned: 90},
+		},
+	})
+	assert.NoError(t, err)
+	reply := <-stream.replies
+	assert.Equal(t, pb.BlobStatus_CONFIRMED, reply.GetStatus())
+	assert.Equal(t, uint64(7), reply.GetReferenceBlockNumber())
+	if assert.Len(t, reply.GetQuorumResults(), 2) {
+		assert.Equal(t, uint32(90), reply.GetQuorumResults()[0].GetPercentSigned())
+		assert.Equal(t, uint32(67), reply.GetQuorumResults()[1].GetQuorumThreshold())
+		assert.True(t, reply.GetQuorumResults()[1].GetFallback())
+	}
+
+	assert.NoError(t, store.MarkBlobFinalized(ctx, key))
+	assert.Equal(t, pb.BlobStatus_FINALIZED, (<-stream.replies).GetStatus())
+	assert.NoError(t, <-done)
+
+	// the subscriptions are bounded
+	server.config.MaxStatusSubscriptions = 1
+	server.statusSubscriptions = 1
+	err = server.SubscribeBlobStatus(&pb.BlobStatusRequest{RequestId: []byte(key.String())}, stream)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "at most 1")
+	assert.Equal(t, 1, server.statusSubscriptions)
+	assert.Empty(t, server.clientSubscriptions)
+}
+
+func TestStatusSubscriptionLimits(t *testing.T) {
+	logger := mock.NewLogger(false)
+	store := memorydb.NewBlobStore(core.MaxBlobSize*4, logger)
+	server := &DispersalServer{
+		config: disperser.ServerConfig{
+			StatusSubscriptionInterval:    time.Millisecond,
+			MaxClientStatusSubscriptions:  1,
+			MaxStatusSubscriptionDuration: 50 * time.Millisecond,
+		},
+		blobStore:              store,
+		blobReader:             store,
+		logger:                 logger,
+		readRateLimiterManager: NewClientRateLimiterManager(math.MaxInt32),
+	}
+
+	key, err := store.StoreBlob(context.Background(), &core.Blob{Data: []byte("blob")}, 1)
+	assert.NoError(t, err)
+	req := &pb.BlobStatusRequest{RequestId: []byte(key.String())}
+
+	stream := &statusStream{replies: make(chan *pb.BlobStatusReply, 16)}
+	done := make(chan error, 1)
+	go func() {
+		done <- server.SubscribeBlobStatus(req, stream)
+	}()
+	assert.Equal(t, pb.BlobStatus_PROCESSING, (<-stream.replies).GetStatus())
+
+	// a client has a bounded number of subscriptions open, the others have theirs
+	err = server.SubscribeBlobStatus(req, stream)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "of the client")
+	other := &statusStream{replies: make(chan *pb.BlobStatusReply, 16), client: "10.0.0.2"}
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(server.SubscribeBlobStatus(req, other)))
+
+	// a subscription ends after its duration, closing it for the client
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(<-done))
+	assert.Equal(t, 0, server.statusSubscriptions)
+	assert.Empty(t, server.clientSubscriptions)
+
+	// opening a subscription is charged to the read rate limiter of the client
+	server.readRateLimiterManager.SetMaxRequests(1)
+	assert.True(t, server.readRateLimiterManager.GetRateLimiter("10.0.0.3").Allow())
+	err = server.SubscribeBlobStatus(req, &statusStream{client: "10.0.0.3"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "ratelimited")
+}
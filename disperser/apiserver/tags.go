@@ -0,0 +1,177 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Bounds of the tags clients attach to their blobs
+const (
+	maxTags           = 16
+	maxTagKeyLength   = 64
+	maxTagValueLength = 256
+)
+
+// validateTags checks the tags of a dispersal are within the bounds, returning nil if the
+// dispersal has none
+func validateTags(tags map[string]string) (map[string]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	if len(tags) > maxTags {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d tags are allowed", maxTags)
+	}
+	for key, value := range tags {
+		if key == "" || len(key) > maxTagKeyLength {
+			return nil, status.Errorf(codes.InvalidArgument, "tag keys must be between 1 and %d bytes", maxTagKeyLength)
+		}
+		if len(value) > maxTagValueLength {
+			return nil, status.Errorf(codes.InvalidArgument, "value of tag %s exceeds %d bytes", key, maxTagValueLength)
+		}
+		// the tag filters split on the last '=', so the keys may have one but not the values
+		if strings.Contains(value, "=") {
+			return nil, status.Errorf(codes.InvalidArgument, "value of tag %s contains '='", key)
+		}
+	}
+	return tags, nil
+}
+
+// tagFilter matches the blobs with all of its tags. A tag without a value matches the blobs
+// with the key whatever its value.
+type tagFilter map[string]*string
+
+// parseTagFilter reads the tag query parameters, each <key>=<value> or <key>. The tag is
+// split on its last '=' since the keys may have one but not the values.
+func parseTagFilter(r *http.Request) tagFilter {
+	filter := make(tagFilter)
+	for _, tag := range r.URL.Query()["tag"] {
+		if i := strings.LastIndex(tag, "="); i >= 0 {
+			value := tag[i+1:]
+			filter[tag[:i]] = &value
+		} else {
+			filter[tag] = nil
+		}
+	}
+	return filter
+}
+
+// indexTag returns the tag of the filter to look the blobs up by in the tag index, one with
+// a value if any as it matches fewer blobs
+func (f tagFilter) indexTag() (string, *string) {
+	var key string
+	var value *string
+	found := false
+	for k, v := range f {
+		if !found || (value == nil && v != nil) || ((v != nil) == (value != nil) && k < key) {
+			key, value, found = k, v, true
+		}
+	}
+	return key, value
+}
+
+func (f tagFilter) matches(metadata *disperser.BlobMetadata) bool {
+	if len(f) == 0 {
+		return true
+	}
+	if metadata.RequestMetadata == nil {
+		return false
+	}
+	for key, value := range f {
+		tag, ok := metadata.RequestMetadata.Tags[key]
+		if !ok || (value != nil && tag != *value) {
+			return false
+		}
+	}
+	return true
+}
+
+// withTags returns metadata with the tags of a blob read back from the kv store
+func withTags(metadata *disperser.BlobMetadata, tags map[string]string) *disperser.BlobMetadata {
+	if len(tags) > 0 {
+		metadata.RequestMetadata = &disperser.RequestMetadata{
+			BlobRequestHeader: core.BlobRequestHeader{Tags: tags},
+		}
+	}
+	return metadata
+}
+
+// maxTaggedBlobs bounds the blobs listed by tag across batches
+const maxTaggedBlobs = 1000
+
+// handleListTaggedBlobs serves GET /blobs?tag=<key>[=<value>][&tag=...][&limit=<n>], the
+// blobs finalized to the kv store with all the tags across batches, looked up in the tag
+// index of the kv store. The blobs not finalized yet are listed by batch with /batch/blobs.
+func (s *DispersalServer) handleListTaggedBlobs(w http.ResponseWriter, r *http.Request) {
+	const method = "ListTaggedBlobs"
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	filter := parseTagFilter(r)
+	if len(filter) == 0 {
+		s.metrics.HandleFailedRequest(0, method)
+		http.Error(w, "at least one tag is required", http.StatusBadRequest)
+		return
+	}
+	limit := maxTaggedBlobs
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxTaggedBlobs {
+			s.metrics.HandleFailedRequest(0, method)
+			http.Error(w, fmt.Sprintf("limit must be in range [1, %d]", maxTaggedBlobs), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	if s.kvStore == nil {
+		s.metrics.HandleFailedRequest(0, method)
+		http.Error(w, "the blobs are indexed by tag in the kv store, which isn't configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	list, err := s.listTaggedBlobs(r.Context(), filter, limit)
+	if err != nil {
+		s.logger.Error("[apiserver] failed to list blobs by tag", "err", err)
+		s.metrics.HandleFailedRequest(0, method)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.metrics.HandleSuccessfulRequest(0, method)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(list)
+}
+
+// listTaggedBlobs lists at most limit blobs of the kv store matching filter
+func (s *DispersalServer) listTaggedBlobs(ctx context.Context, filter tagFilter, limit int) (*BlobList, error) {
+	key, value := filter.indexTag()
+	iter := s.kvStore.TaggedBlobIterator(ctx, key, value)
+	defer iter.Release()
+
+	metadatas := make([]*disperser.BlobMetadata, 0)
+	for len(metadatas) < limit && iter.Next() {
+		blobKey := append([]byte(nil), iter.Value()...)
+		confirmation, err := s.getConfirmationFromKv(ctx, blobKey)
+		if err != nil {
+			return nil, err
+		}
+		if confirmation == nil || confirmation.Info == nil {
+			continue
+		}
+		if metadata := finalizedMetadata(blobKey, confirmation); filter.matches(metadata) {
+			metadatas = append(metadatas, metadata)
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return listBlobs(metadatas, nil), nil
+}
@@ -0,0 +1,126 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sync"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/disperser"
+)
+
+// assignmentWriters is how many batch assignments are written to the blob store at once
+const assignmentWriters = 8
+
+// assignmentWriter writes the batch assignments of the blobs to the blob store in the
+// background, so a slow store never holds the dispatch of a batch back. The writes of a blob
+// go to the same worker in the order they are queued, and only the latest one still queued
+// is written, so a cleared assignment is never overwritten by the one it clears. A nil
+// writer writes nothing.
+type assignmentWriter struct {
+	write  func(ctx context.Context, key disperser.BlobKey, assignment *disperser.BatchAssignment) error
+	logger common.Logger
+	shards []*assignmentShard
+	once   sync.Once
+}
+
+type assignmentShard struct {
+	mu sync.Mutex
+	// pending is the latest assignment queued of each blob, nil to clear it
+	pending map[disperser.BlobKey]*disperser.BatchAssignment
+	order   []disperser.BlobKey
+	notify  chan struct{}
+}
+
+func newAssignmentWriter(write func(ctx context.Context, key disperser.BlobKey, assignment *disperser.BatchAssignment) error, logger common.Logger) *assignmentWriter {
+	w := &assignmentWriter{
+		write:  write,
+		logger: logger,
+		shards: make([]*assignmentShard, assignmentWriters),
+	}
+	for i := range w.shards {
+		w.shards[i] = &assignmentShard{
+			pending: make(map[disperser.BlobKey]*disperser.BatchAssignment),
+			notify:  make(chan struct{}, 1),
+		}
+	}
+	return w
+}
+
+// set queues the assignment of a blob, clearing it if assignment is nil
+func (w *assignmentWriter) set(key disperser.BlobKey, assignment *disperser.BatchAssignment) {
+	if w == nil {
+		return
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key.BlobHash))
+	_, _ = h.Write([]byte(key.MetadataHash))
+	shard := w.shards[h.Sum32()%uint32(len(w.shards))]
+
+	shard.mu.Lock()
+	if _, ok := shard.pending[key]; !ok {
+		shard.order = append(shard.order, key)
+	}
+	shard.pending[key] = assignment
+	shard.mu.Unlock()
+	select {
+	case shard.notify <- struct{}{}:
+	default:
+	}
+}
+
+// clear queues the removal of the assignment of a blob whose batch failed
+func (w *assignmentWriter) clear(key disperser.BlobKey) {
+	w.set(key, nil)
+}
+
+// start writes the queued assignments until ctx is done
+func (w *assignmentWriter) start(ctx context.Context) {
+	if w == nil {
+		return
+	}
+	w.once.Do(func() {
+		for _, shard := range w.shards {
+			go w.run(ctx, shard)
+		}
+	})
+}
+
+func (w *assignmentWriter) run(ctx context.Context, shard *assignmentShard) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-shard.notify:
+		}
+		for ctx.Err() == nil {
+			key, assignment, ok := shard.next()
+			if !ok {
+				break
+			}
+			err := w.write(ctx, key, assignment)
+			if errors.Is(err, disperser.ErrBlobNotFound) {
+				w.logger.Debug("[batcher] blob removed before its batch assignment was written", "key", key.String())
+			} else if err != nil {
+				// the status of the blob only lacks its assignment until it is confirmed
+				w.logger.Warn("[batcher] failed to write batch assignment", "key", key.String(), "cleared", assignment == nil, "err", err)
+			}
+		}
+	}
+}
+
+// next takes the oldest blob queued with its latest assignment
+func (s *assignmentShard) next() (disperser.BlobKey, *disperser.BatchAssignment, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.order) == 0 {
+		return disperser.BlobKey{}, nil, false
+	}
+	key := s.order[0]
+	s.order[0] = disperser.BlobKey{}
+	s.order = s.order[1:]
+	assignment := s.pending[key]
+	delete(s.pending, key)
+	return key, assignment, true
+}
@@ -0,0 +1,74 @@
+package batcher
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssignmentWriter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := memorydb.NewBlobStore(core.MaxBlobSize*4, mock.NewLogger(false))
+	key, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("blob")}, uint64(time.Now().UnixNano()))
+	require.NoError(t, err)
+
+	// the first write blocks until released, holding the writes queued behind it
+	release := make(chan struct{})
+	written := make(chan *disperser.BatchAssignment, 8)
+	var blocked atomic.Bool
+	blocked.Store(true)
+	w := newAssignmentWriter(func(ctx context.Context, key disperser.BlobKey, assignment *disperser.BatchAssignment) error {
+		if blocked.CompareAndSwap(true, false) {
+			<-release
+		}
+		err := store.SetBlobBatchAssignment(ctx, key, assignment)
+		written <- assignment
+		return err
+	}, mock.NewLogger(false))
+	w.start(ctx)
+
+	first := &disperser.BatchAssignment{BatchHeaderHash: [32]byte{1}, BlobCount: 1}
+	second := &disperser.BatchAssignment{BatchHeaderHash: [32]byte{2}, BlobIndex: 1, BlobCount: 2}
+	w.set(key, first)
+	require.Eventually(t, func() bool { return !blocked.Load() }, time.Second, time.Millisecond)
+	// the batch of first fails, the blob is batched again, then that batch fails too
+	w.clear(key)
+	w.set(key, second)
+	w.clear(key)
+	close(release)
+
+	assert.Equal(t, first, <-written)
+	// only the latest write queued is written
+	assert.Nil(t, <-written)
+	metadata, err := store.GetBlobMetadata(ctx, key)
+	require.NoError(t, err)
+	assert.Nil(t, metadata.BatchAssignment)
+
+	w.set(key, second)
+	assert.Equal(t, second, <-written)
+	metadata, err = store.GetBlobMetadata(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, second, metadata.BatchAssignment)
+
+	// a blob removed meanwhile isn't written, and the writes go on
+	w.set(disperser.BlobKey{BlobHash: "removed", MetadataHash: "removed"}, first)
+	assert.Equal(t, first, <-written)
+	w.clear(key)
+	assert.Nil(t, <-written)
+	metadata, err = store.GetBlobMetadata(ctx, key)
+	require.NoError(t, err)
+	assert.Nil(t, metadata.BatchAssignment)
+
+	var nilWriter *assignmentWriter
+	nilWriter.set(key, first)
+	nilWriter.start(ctx)
+}
@@ -0,0 +1,249 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/disperser"
+	pb "github.com/0glabs/0g-da-client/disperser/api/grpc/signer"
+	eth_common "github.com/ethereum/go-ethereum/common"
+)
+
+// errBandwidthExcluded is the result of the operators left out of a dispersal by their bandwidth
+var errBandwidthExcluded = errors.New("operator excluded below the bandwidth of the dispatch deadline")
+
+const (
+	// defaultProbeSize is the payload of the bandwidth probes if not configured
+	defaultProbeSize = 64 * 1024
+	// bandwidthSmoothing is the weight of the last probe in the bandwidth estimates
+	bandwidthSmoothing = 0.5
+	// probedIntervals is the number of probe intervals an operator is probed for after it
+	// was last assigned slices
+	probedIntervals = 10
+)
+
+// BandwidthConfig probes the upload bandwidth to the operators with uploads they acknowledge,
+// and leaves the operators whose slices would take longer than the dispatch deadline of their
+// quorum to upload out of the dispersals. Operators are only excluded as long as the stake
+// of the others that are reachable reaches the signing threshold, and never from the
+// retries.
+type BandwidthConfig struct {
+	// ProbeInterval is how often the operators are probed, exclusion is disabled if 0
+	ProbeInterval time.Duration
+	// ProbeSize is the payload of the probes in bytes
+	ProbeSize int
+}
+
+func (c BandwidthConfig) Enabled() bool {
+	return c.ProbeInterval > 0
+}
+
+func (c BandwidthConfig) validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.ProbeSize < 0 {
+		return errors.New("the bandwidth probe size must not be negative")
+	}
+	return nil
+}
+
+type operatorBandwidth struct {
+	socket string
+	// bytesPerSecond is the smoothed bandwidth of the probes, 0 until one succeeded
+	bytesPerSecond float64
+	// unreachable is set while the last probe failed to reach the operator
+	unreachable bool
+	assignedAt  time.Time
+}
+
+type bandwidthMonitor struct {
+	config  BandwidthConfig
+	prober  disperser.BandwidthProber
+	metrics *Metrics
+	logger  common.Logger
+
+	mu        sync.Mutex
+	operators map[eth_common.Address]*operatorBandwidth
+	now       func() time.Time
+}
+
+func newBandwidthMonitor(config BandwidthConfig, prober disperser.BandwidthProber, metrics *Metrics, logger common.Logger) *bandwidthMonitor {
+	if config.ProbeSize == 0 {
+		config.ProbeSize = defaultProbeSize
+	}
+	return &bandwidthMonitor{
+		config:    config,
+		prober:    prober,
+		metrics:   metrics,
+		logger:    logger,
+		operators: make(map[eth_common.Address]*operatorBandwidth),
+		now:       time.Now,
+	}
+}
+
+// track records the operators assigned the slices of a batch, which are probed until they
+// haven't been assigned any for probedIntervals
+func (m *bandwidthMonitor) track(signers map[eth_common.Address]*SignerState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	for addr, state := range signers {
+		if state.SignerInfo == nil {
+			continue
+		}
+		operator, ok := m.operators[addr]
+		if !ok {
+			operator = &operatorBandwidth{}
+			m.operators[addr] = operator
+		}
+		operator.socket = state.Socket
+		operator.assignedAt = now
+	}
+}
+
+// probe measures the bandwidth to each tracked operator
+func (m *bandwidthMonitor) probe(ctx context.Context) {
+	m.mu.Lock()
+	sockets := make(map[eth_common.Address]string, len(m.operators))
+	for addr, operator := range m.operators {
+		if m.now().Sub(operator.assignedAt) > probedIntervals*m.config.ProbeInterval {
+			delete(m.operators, addr)
+			continue
+		}
+		sockets[addr] = operator.socket
+	}
+	m.mu.Unlock()
+
+	payload := make([]byte, m.config.ProbeSize)
+	var wg sync.WaitGroup
+	for addr, socket := range sockets {
+		wg.Add(1)
+		go func(addr eth_common.Address, socket string) {
+			defer wg.Done()
+			elapsed, err := m.prober.Probe(ctx, socket, payload)
+			if errors.Is(err, disperser.ErrBandwidthProbesUnsupported) {
+				// the operator is never measured, so never excluded
+				m.logger.Debug("[signer] operator doesn't serve bandwidth probes", "operator", addr.Hex(), "socket", socket)
+				return
+			}
+			if err != nil {
+				m.logger.Debug("[signer] bandwidth probe failed", "operator", addr.Hex(), "socket", socket, "err", err)
+				m.setUnreachable(addr, true)
+				return
+			}
+			m.setUnreachable(addr, false)
+			m.observe(addr, float64(len(payload))/max(elapsed.Seconds(), 1e-6))
+		}(addr, socket)
+	}
+	wg.Wait()
+}
+
+func (m *bandwidthMonitor) setUnreachable(addr eth_common.Address, unreachable bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if operator, ok := m.operators[addr]; ok {
+		operator.unreachable = unreachable
+	}
+}
+
+func (m *bandwidthMonitor) observe(addr eth_common.Address, bytesPerSecond float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	operator, ok := m.operators[addr]
+	if !ok {
+		return
+	}
+	if operator.bytesPerSecond == 0 {
+		operator.bytesPerSecond = bytesPerSecond
+	} else {
+		operator.bytesPerSecond = bandwidthSmoothing*bytesPerSecond + (1-bandwidthSmoothing)*operator.bytesPerSecond
+	}
+	if m.metrics != nil {
+		m.metrics.UpdateOperatorBandwidth(addr.Hex(), operator.bytesPerSecond)
+	}
+}
+
+// exclude returns the operators whose slices would take longer than deadline to upload,
+// the slowest first, as long as the stake of the others reaches the signing threshold of
+// the total stake. The stake of an operator is its share of the quorum slices, which weighs
+// its signature, and the stake of the operators the last probe couldn't reach doesn't count
+// towards the threshold. Operators not measured yet are never excluded.
+func (m *bandwidthMonitor) exclude(signers map[eth_common.Address]*SignerState, requests map[eth_common.Address][]*pb.SignRequest, deadline time.Duration, required func(totalStake int) int) map[eth_common.Address]struct{} {
+	if deadline <= 0 {
+		return nil
+	}
+	type candidate struct {
+		addr        eth_common.Address
+		upload      time.Duration
+		unreachable bool
+	}
+	candidates := make([]candidate, 0)
+	totalStake, unreachableStake := 0, 0
+	m.mu.Lock()
+	for addr, state := range signers {
+		stake := len(state.sliceIndexes)
+		totalStake += stake
+		operator, ok := m.operators[addr]
+		if !ok {
+			continue
+		}
+		if operator.unreachable {
+			unreachableStake += stake
+		}
+		if operator.bytesPerSecond == 0 {
+			continue
+		}
+		var size int
+		for _, req := range requests[addr] {
+			for _, slice := range req.GetEncodedSlice() {
+				size += len(slice)
+			}
+		}
+		upload := time.Duration(float64(size) / operator.bytesPerSecond * float64(time.Second))
+		if upload > deadline {
+			candidates = append(candidates, candidate{addr: addr, upload: upload, unreachable: operator.unreachable})
+		}
+	}
+	m.mu.Unlock()
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].upload > candidates[j].upload
+	})
+
+	minStake := required(totalStake)
+	available := totalStake - unreachableStake
+	excluded := make(map[eth_common.Address]struct{})
+	for _, c := range candidates {
+		stake := len(signers[c.addr].sliceIndexes)
+		if c.unreachable {
+			// its stake is already left out
+			excluded[c.addr] = struct{}{}
+			continue
+		}
+		if available-stake < minStake {
+			continue
+		}
+		available -= stake
+		excluded[c.addr] = struct{}{}
+	}
+	return excluded
+}
+
+// run probes the operators every interval until ctx is done
+func (m *bandwidthMonitor) run(ctx context.Context) {
+	ticker := time.NewTicker(m.config.ProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.probe(ctx)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/disperser"
+	pb "github.com/0glabs/0g-da-client/disperser/api/grpc/signer"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeProber uploads to each socket in a fixed time, can't reach the sockets with a
+// negative time, and the others don't serve the probes
+type fakeProber map[string]time.Duration
+
+func (p fakeProber) Probe(ctx context.Context, addr string, payload []byte) (time.Duration, error) {
+	elapsed, ok := p[addr]
+	if !ok {
+		return 0, disperser.ErrBandwidthProbesUnsupported
+	}
+	if elapsed < 0 {
+		return 0, errors.New("connection refused")
+	}
+	return elapsed, nil
+}
+
+func twoThirds(total int) int {
+	return minSignedSlices(nil, 0, total)
+}
+
+func TestBandwidthExclusion(t *testing.T) {
+	fast, slow, slower, unmeasured := eth_common.HexToAddress("0x01"), eth_common.HexToAddress("0x02"), eth_common.HexToAddress("0x03"), eth_common.HexToAddress("0x04")
+	signer := func(socket string, slices ...int) *SignerState {
+		return &SignerState{SignerInfo: &SignerInfo{Socket: socket}, sliceIndexes: slices}
+	}
+	signers := map[eth_common.Address]*SignerState{
+		fast:       signer("fast", 0, 1),
+		slow:       signer("slow", 2),
+		slower:     signer("slower", 3),
+		unmeasured: signer("unmeasured", 4),
+	}
+	// 1 KiB per slice, uploaded at 1 MiB/s by fast and at 1 KiB/s by slow and slower
+	prober := fakeProber{
+		"fast":   time.Millisecond,
+		"slow":   time.Second,
+		"slower": 2 * time.Second,
+	}
+	m := newBandwidthMonitor(BandwidthConfig{ProbeInterval: time.Minute, ProbeSize: 1024}, prober, nil, mock.NewLogger(false))
+	m.track(signers)
+	m.probe(context.Background())
+
+	requests := make(map[eth_common.Address][]*pb.SignRequest)
+	for addr, state := range signers {
+		slices := make([][]byte, len(state.sliceIndexes))
+		for i := range slices {
+			slices[i] = make([]byte, 1024)
+		}
+		requests[addr] = []*pb.SignRequest{{EncodedSlice: slices}}
+	}
+
+	// without deadline no operator is excluded
+	assert.Empty(t, m.exclude(signers, requests, 0, twoThirds))
+	// both slow operators are too slow, but excluding both would leave 3 of the 5 slices,
+	// below the threshold of 4 slices, so only the slowest is excluded
+	assert.Equal(t, map[eth_common.Address]struct{}{slower: {}}, m.exclude(signers, requests, 500*time.Millisecond, twoThirds))
+	// with more time only the slowest is too slow
+	assert.Equal(t, map[eth_common.Address]struct{}{slower: {}}, m.exclude(signers, requests, 1500*time.Millisecond, twoThirds))
+	assert.Empty(t, m.exclude(signers, requests, 3*time.Second, twoThirds))
+
+	// the stake of an unreachable operator doesn't count towards the threshold, so the
+	// slowest is kept, while the unreachable one is excluded whenever it is too slow
+	prober["slow"] = -1
+	m.probe(context.Background())
+	assert.Empty(t, m.exclude(signers, requests, 1500*time.Millisecond, twoThirds))
+	assert.Equal(t, map[eth_common.Address]struct{}{slow: {}}, m.exclude(signers, requests, 500*time.Millisecond, twoThirds))
+	prober["slow"] = time.Second
+	m.probe(context.Background())
+	assert.Equal(t, map[eth_common.Address]struct{}{slower: {}}, m.exclude(signers, requests, 1500*time.Millisecond, twoThirds))
+
+	// operators no longer assigned slices stop being probed
+	m.now = func() time.Time { return time.Now().Add(probedIntervals * 2 * time.Minute) }
+	m.probe(context.Background())
+	assert.Empty(t, m.operators)
+}
@@ -0,0 +1,127 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// BatchAlignConfig aligns batch creation with the chain: one batch is created every
+// BlocksPerBatch blocks, when the head reaches the block at Offset within the window, so
+// that batches follow the L1 posting cadence of a rollup. While it is enabled, the batch
+// ticker and the encoded size threshold don't create batches.
+type BatchAlignConfig struct {
+	// BlocksPerBatch is the size of the block windows, 0 to disable alignment
+	BlocksPerBatch uint64
+	// Offset is the block within each window at which its batch is created, below BlocksPerBatch
+	Offset uint64
+	// PollInterval is how often the chain head is read
+	PollInterval time.Duration
+}
+
+func (c BatchAlignConfig) Enabled() bool {
+	return c.BlocksPerBatch > 0
+}
+
+func (c BatchAlignConfig) validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.Offset >= c.BlocksPerBatch {
+		return fmt.Errorf("batch align offset %d must be below the blocks per batch %d", c.Offset, c.BlocksPerBatch)
+	}
+	if c.PollInterval <= 0 {
+		return errors.New("batch align poll interval must be positive")
+	}
+	return nil
+}
+
+// BlockNumberReader reads the chain head for aligned batching
+type BlockNumberReader interface {
+	GetCurrentBlockNumber(ctx context.Context) (uint32, error)
+}
+
+// batchAligner tracks the block windows a batch was created for
+type batchAligner struct {
+	config BatchAlignConfig
+	// next is the first window no batch was created for yet
+	next    uint64
+	started bool
+}
+
+// window returns the window whose batch block is the latest at or before the block, false
+// if the block precedes the batch block of the first window
+func (a *batchAligner) window(block uint64) (uint64, bool) {
+	if block < a.config.Offset {
+		return 0, false
+	}
+	return (block - a.config.Offset) / a.config.BlocksPerBatch, true
+}
+
+// due returns whether the head reached the batch block of a new window, and the number of
+// windows whose batch block passed between two reads of the head without a batch. A
+// batcher starting within a window waits for the next one.
+func (a *batchAligner) due(head uint64) (bool, uint64) {
+	window, ok := a.window(head)
+	if !a.started {
+		a.started = true
+		if ok {
+			a.next = window + 1
+		}
+		return false, 0
+	}
+	if !ok || window < a.next {
+		return false, 0
+	}
+	missed := window - a.next
+	a.next = window + 1
+	return true, missed
+}
+
+// batchBlock returns the block at which the batch of the window is created
+func (a *batchAligner) batchBlock(window uint64) uint64 {
+	return window*a.config.BlocksPerBatch + a.config.Offset
+}
+
+// runAlignedBatches creates the batches at the aligned blocks until ctx is done
+func (b *Batcher) runAlignedBatches(ctx context.Context, batchTrigger *EncodedSizeNotifier) {
+	aligner := &batchAligner{config: b.BatchAlign}
+	ticker := time.NewTicker(b.BatchAlign.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-batchTrigger.Notify:
+			b.logger.Debug("[batcher] batch deferred to the next aligned block")
+		case <-ticker.C:
+			head, err := b.Blocks.GetCurrentBlockNumber(ctx)
+			if err != nil {
+				b.logger.Warn("[batcher] failed to read the chain head for aligned batching", "err", err)
+				continue
+			}
+			due, missed := aligner.due(uint64(head))
+			if !due {
+				continue
+			}
+			if missed > 0 {
+				b.logger.Warn("[batcher] aligned batch blocks passed without a batch", "missed", missed, "head", head)
+			}
+			b.logger.Info("[batcher] creating aligned batch", "head", head, "window", aligner.next-1)
+			if ts, err := b.HandleSingleBatch(ctx); err != nil {
+				b.EncodingStreamer.RemoveBatchingStatus(ts)
+				if errors.Is(err, errNoEncodedResults) {
+					b.logger.Debug("[batcher] no encoded results to make an aligned batch with")
+				} else if errors.Is(err, errBatchDeferred) {
+					b.logger.Debug("[batcher] aligned batch deferred by the drain strategy")
+				} else if errors.Is(err, errSafeMode) {
+					b.logger.Debug("[batcher] aligned batch paused by the safe mode")
+				} else {
+					b.logger.Error("[batcher] failed to process an aligned batch", "err", err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package batcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchAligner(t *testing.T) {
+	config := BatchAlignConfig{BlocksPerBatch: 10, Offset: 3, PollInterval: time.Second}
+	assert.NoError(t, config.validate())
+	a := &batchAligner{config: config}
+
+	// started within the window of block 13, waits for block 23
+	due, _ := a.due(15)
+	assert.False(t, due)
+	due, _ = a.due(22)
+	assert.False(t, due)
+	due, missed := a.due(23)
+	assert.True(t, due)
+	assert.Zero(t, missed)
+	assert.Equal(t, uint64(23), a.batchBlock(a.next-1))
+	due, _ = a.due(25)
+	assert.False(t, due)
+
+	// the heads of blocks 33 and 43 were never read
+	due, missed = a.due(44)
+	assert.True(t, due)
+	assert.Equal(t, uint64(1), missed)
+
+	// started before the first batch block
+	a = &batchAligner{config: config}
+	due, _ = a.due(1)
+	assert.False(t, due)
+	due, _ = a.due(3)
+	assert.True(t, due)
+
+	assert.Error(t, BatchAlignConfig{BlocksPerBatch: 10, Offset: 10, PollInterval: time.Second}.validate())
+	assert.Error(t, BatchAlignConfig{BlocksPerBatch: 10}.validate())
+}
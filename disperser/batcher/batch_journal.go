@@ -0,0 +1,306 @@
+package batcher
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/leveldb"
+	eth_common "github.com/ethereum/go-ethereum/common"
+)
+
+var batchJournalPrefix = []byte("batch-")
+
+var safeModeJournalKey = []byte("safe-mode")
+
+// journaledBatch is the state of a batch between its dispersal and its confirmation
+type journaledBatch struct {
+	BatchID      uint64
+	HeaderHash   [32]byte
+	UploadTxHash eth_common.Hash
+	BlobKeys     []disperser.BlobKey
+	// ConfirmationTxHash is set once the aggregate signatures of the batch were submitted,
+	// along with the confirmation of each blob of the batch not excluded from it, but the
+	// block number of the transaction
+	ConfirmationTxHash *eth_common.Hash
+	Confirmations      []*disperser.ConfirmationInfo
+}
+
+// blobKey returns the key of the blob a confirmation of the batch is for
+func (entry *journaledBatch) blobKey(confirmationInfo *disperser.ConfirmationInfo) (disperser.BlobKey, bool) {
+	if int(confirmationInfo.BlobIndex) >= len(entry.BlobKeys) {
+		return disperser.BlobKey{}, false
+	}
+	return entry.BlobKeys[confirmationInfo.BlobIndex], true
+}
+
+// batchJournal persists the batches in flight from their dispersal to their confirmation,
+// so that a restarted batcher can track the confirmation transactions sent before it
+// stopped instead of dispersing their blobs again.
+type batchJournal struct {
+	db     disperser.DB
+	logger common.Logger
+}
+
+func newBatchJournal(path string, logger common.Logger) (*batchJournal, error) {
+	db, err := leveldb.NewLevelDBStore(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open batch journal at %s: %w", path, err)
+	}
+	return &batchJournal{
+		db:     db,
+		logger: logger,
+	}, nil
+}
+
+func batchJournalKey(batchID uint64) []byte {
+	return binary.BigEndian.AppendUint64(append([]byte{}, batchJournalPrefix...), batchID)
+}
+
+func (j *batchJournal) put(entry *journaledBatch) error {
+	data, err := core.Encode(entry)
+	if err != nil {
+		return err
+	}
+	return j.db.Put(batchJournalKey(entry.BatchID), data)
+}
+
+func (j *batchJournal) get(batchID uint64) (*journaledBatch, error) {
+	data, err := j.db.Get(batchJournalKey(batchID))
+	if err != nil {
+		return nil, err
+	}
+	entry := &journaledBatch{}
+	if err := core.Decode(data, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (j *batchJournal) delete(batchID uint64) error {
+	return j.db.Delete(batchJournalKey(batchID))
+}
+
+func (j *batchJournal) putSafeMode(persisted persistedSafeMode) error {
+	data, err := core.Encode(persisted)
+	if err != nil {
+		return err
+	}
+	return j.db.Put(safeModeJournalKey, data)
+}
+
+// getSafeMode returns the persisted safe mode, false if none was persisted
+func (j *batchJournal) getSafeMode() (persistedSafeMode, bool, error) {
+	var persisted persistedSafeMode
+	data, err := j.db.Get(safeModeJournalKey)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return persisted, false, nil
+	}
+	if err != nil {
+		return persisted, false, err
+	}
+	if err := core.Decode(data, &persisted); err != nil {
+		return persisted, false, err
+	}
+	return persisted, true, nil
+}
+
+// load returns all persisted batches. Entries that can't be decoded are dropped.
+func (j *batchJournal) load() []*journaledBatch {
+	iter := j.db.NewIterator(batchJournalPrefix)
+	defer iter.Release()
+
+	entries := make([]*journaledBatch, 0)
+	corrupted := make([][]byte, 0)
+	for iter.Next() {
+		entry := &journaledBatch{}
+		if err := core.Decode(iter.Value(), entry); err != nil {
+			j.logger.Warn("[batcher] dropping undecodable batch journal entry", "key", iter.Key(), "err", err)
+			corrupted = append(corrupted, append([]byte{}, iter.Key()...))
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if len(corrupted) > 0 {
+		if err := j.db.DeleteBatch(corrupted); err != nil {
+			j.logger.Warn("[batcher] failed to delete batch journal entries", "err", err)
+		}
+	}
+	return entries
+}
+
+// journalDispersedBatch records a batch whose data was uploaded by txHash
+func (e *EncodingStreamer) journalDispersedBatch(batchID uint64, headerHash [32]byte, batch *batch) {
+	if e.batchJournal == nil {
+		return
+	}
+	entry := &journaledBatch{
+		BatchID:      batchID,
+		HeaderHash:   headerHash,
+		UploadTxHash: batch.TxHash,
+		BlobKeys:     make([]disperser.BlobKey, len(batch.BlobMetadata)),
+	}
+	for i, metadata := range batch.BlobMetadata {
+		entry.BlobKeys[i] = metadata.GetBlobKey()
+	}
+	if err := e.batchJournal.put(entry); err != nil {
+		e.logger.Warn("[batcher] failed to journal dispersed batch", "batch ID", batchID, "err", err)
+	}
+}
+
+// journalConfirmation records the transaction submitting the aggregate signatures of a batch
+func (e *EncodingStreamer) journalConfirmation(batchID uint64, txHash eth_common.Hash, confirmations []*disperser.ConfirmationInfo) {
+	if e.batchJournal == nil {
+		return
+	}
+	entry, err := e.batchJournal.get(batchID)
+	if err != nil {
+		e.logger.Warn("[batcher] failed to read journaled batch", "batch ID", batchID, "err", err)
+		return
+	}
+	entry.ConfirmationTxHash = &txHash
+	entry.Confirmations = confirmations
+	if err := e.batchJournal.put(entry); err != nil {
+		e.logger.Warn("[batcher] failed to journal batch confirmation", "batch ID", batchID, "err", err)
+	}
+}
+
+// forgetBatch removes a batch that was confirmed or handed back from the journal
+func (e *EncodingStreamer) forgetBatch(batchID uint64) {
+	if e.batchJournal == nil {
+		return
+	}
+	if err := e.batchJournal.delete(batchID); err != nil {
+		e.logger.Warn("[batcher] failed to delete batch journal entry", "batch ID", batchID, "err", err)
+	}
+}
+
+// recoverBatches resumes the batches journaled by a previous run, before the encoding
+// streamer picks up their blobs again. Their blobs are held as batching until the batch
+// resolves: the blobs of a batch whose confirmation transaction is included are marked
+// confirmed, and the others are handed back to the queue. The signing of a batch that
+// was dispersed but whose signatures weren't submitted is not resumed, since its encoded
+// slices are gone, so its blobs are handed back right away and dispersed again.
+func (b *Batcher) recoverBatches(ctx context.Context) {
+	journal := b.EncodingStreamer.batchJournal
+	entries := journal.load()
+	if len(entries) == 0 {
+		return
+	}
+	b.logger.Info("[batcher] recovering journaled batches", "count", len(entries))
+
+	for _, entry := range entries {
+		if entry.ConfirmationTxHash == nil {
+			b.logger.Info("[batcher] handing back journaled batch without confirmation", "batch ID", entry.BatchID, "upload tx hash", entry.UploadTxHash)
+			b.EncodingStreamer.forgetBatch(entry.BatchID)
+			continue
+		}
+		b.EncodingStreamer.EncodedBlobstore.PutBatchingStatus(entry.BatchID, entry.BlobKeys)
+		for _, key := range entry.BlobKeys {
+			b.EncodingStreamer.EncodedBlobstore.PutEncodingRequest(key)
+		}
+		go b.recoverBatch(ctx, entry)
+	}
+}
+
+// recoverBatch waits for the confirmation transaction of a journaled batch and applies
+// its outcome
+func (b *Batcher) recoverBatch(ctx context.Context, entry *journaledBatch) {
+	txHash := *entry.ConfirmationTxHash
+	confirmer := b.confirmer.confirmer
+	if len(entry.Confirmations) > 0 {
+		confirmer = b.ConfirmationFallback.confirmerOf(entry.Confirmations[0].Venue, confirmer)
+	}
+	since := time.Now()
+	var blockNumber uint64
+	for {
+		err := b.TimeoutConfig.Do(ctx, CallReceiptWait, func(ctx context.Context) error {
+			var err error
+			blockNumber, err = confirmer.WaitForConfirmation(ctx, txHash)
+			return err
+		})
+		if err == nil {
+			var blockHash eth_common.Hash
+			if included, hash, err := inclusion(ctx, confirmer, txHash); err == nil {
+				txHash, blockHash = included, hash
+			}
+			b.confirmJournaledBatch(ctx, entry, txHash, blockHash, uint32(blockNumber))
+			break
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if !b.InFlight.inFlight(err, since, time.Now()) {
+			b.logger.Warn("[batcher] journaled batch confirmation failed, handing back its blobs", "batch ID", entry.BatchID, "transaction hash", txHash, "err", err)
+			b.handBackJournaledBatch(ctx, entry)
+			break
+		}
+		b.logger.Warn("[batcher] journaled batch confirmation has no receipt yet, waiting again", "batch ID", entry.BatchID, "transaction hash", txHash, "err", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+
+	for _, key := range entry.BlobKeys {
+		b.EncodingStreamer.EncodedBlobstore.DeleteEncodingRequest(key)
+	}
+	b.EncodingStreamer.RemoveBatchingStatus(entry.BatchID)
+}
+
+func (b *Batcher) confirmJournaledBatch(ctx context.Context, entry *journaledBatch, txHash eth_common.Hash, blockHash eth_common.Hash, blockNumber uint32) {
+	confirmed := 0
+	for _, confirmationInfo := range entry.Confirmations {
+		confirmationInfo.ConfirmationTxnHash = txHash
+		confirmationInfo.ConfirmationBlockHash = blockHash
+		confirmationInfo.ConfirmationBlockNumber = blockNumber
+		key, ok := entry.blobKey(confirmationInfo)
+		if !ok {
+			continue
+		}
+		metadata, err := b.Queue.GetBlobMetadata(ctx, key)
+		if err != nil {
+			b.logger.Error("[batcher] failed to get metadata of journaled blob", "blob key", key.String(), "err", err)
+			continue
+		}
+		if metadata.BlobStatus != disperser.Processing {
+			continue
+		}
+		err = b.TimeoutConfig.Do(ctx, CallStoreWrite, func(ctx context.Context) error {
+			_, err := b.Queue.MarkBlobConfirmed(ctx, metadata, confirmationInfo)
+			return err
+		})
+		if err != nil {
+			b.logger.Error("[batcher] failed to confirm journaled blob", "blob key", key.String(), "err", err)
+			_ = b.handleFailure(ctx, []*disperser.BlobMetadata{metadata}, FailUpdateConfirmationInfo)
+			continue
+		}
+		b.EncodingStreamer.RemoveEncodedBlob(metadata)
+		b.Metrics.UpdateCompletedBlob(int(metadata.RequestMetadata.BlobSize), disperser.Confirmed)
+		b.events.Record(key, disperser.BlobConfirmed, confirmationInfo.ConfirmationTxnHash.Hex())
+		confirmed++
+	}
+	b.logger.Info("[batcher] recovered journaled batch", "batch ID", entry.BatchID, "confirmed blobs", confirmed)
+}
+
+func (b *Batcher) handBackJournaledBatch(ctx context.Context, entry *journaledBatch) {
+	metadatas := make([]*disperser.BlobMetadata, 0, len(entry.Confirmations))
+	for _, confirmationInfo := range entry.Confirmations {
+		key, ok := entry.blobKey(confirmationInfo)
+		if !ok {
+			continue
+		}
+		metadata, err := b.Queue.GetBlobMetadata(ctx, key)
+		if err != nil || metadata.BlobStatus != disperser.Processing {
+			continue
+		}
+		metadatas = append(metadatas, metadata)
+	}
+	_ = b.handleFailure(ctx, metadatas, FailConfirmBatch)
+}
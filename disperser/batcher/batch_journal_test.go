@@ -0,0 +1,96 @@
+package batcher
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/0glabs/0g-da-client/disperser/contract"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type journalConfirmer struct {
+	included chan uint64
+	// replacement is the transaction included in place of the one submitted, if set
+	replacement eth_common.Hash
+}
+
+func (c *journalConfirmer) Inclusion(ctx context.Context, txHash eth_common.Hash) (eth_common.Hash, eth_common.Hash, error) {
+	if c.replacement != (eth_common.Hash{}) {
+		return c.replacement, eth_common.HexToHash("0xb1"), nil
+	}
+	return txHash, eth_common.HexToHash("0xb1"), nil
+}
+
+func (c *journalConfirmer) WaitForUpload(ctx context.Context, txHash eth_common.Hash) ([]*contract.DataUploadEvent, uint64, error) {
+	return nil, 0, nil
+}
+
+func (c *journalConfirmer) WaitForConfirmation(ctx context.Context, txHash eth_common.Hash) (uint64, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case blockNumber := <-c.included:
+		return blockNumber, nil
+	}
+}
+
+func TestRecoverJournaledBatches(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logger := mock.NewLogger(false)
+	store := memorydb.NewBlobStore(core.MaxBlobSize*4, logger)
+	confirmedKey, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("confirmed")}, 1)
+	assert.NoError(t, err)
+	dispersedKey, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("dispersed")}, 2)
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "batches")
+	streamer, err := NewEncodingStreamer(StreamerConfig{EncodingQueueLimit: 10, BatchJournalPath: path}, store, nil, NewEncodedSizeNotifier(make(chan struct{}, 1), 0), nil, nil, logger)
+	assert.NoError(t, err)
+
+	// a batch whose signatures were submitted and one that was only dispersed
+	txHash := eth_common.HexToHash("0x01")
+	assert.NoError(t, streamer.batchJournal.put(&journaledBatch{BatchID: 1, BlobKeys: []disperser.BlobKey{confirmedKey}}))
+	streamer.journalConfirmation(1, txHash, []*disperser.ConfirmationInfo{{BatchID: 1, ConfirmationTxnHash: txHash}})
+	assert.NoError(t, streamer.batchJournal.put(&journaledBatch{BatchID: 2, BlobKeys: []disperser.BlobKey{dispersedKey}}))
+
+	// its fees were bumped, the replacement was included
+	confirmer := &journalConfirmer{included: make(chan uint64), replacement: eth_common.HexToHash("0x02")}
+	b := &Batcher{
+		Queue:            store,
+		EncodingStreamer: streamer,
+		Metrics:          NewMetrics("9100", logger),
+		confirmer:        &BatchConfirmer{confirmer: confirmer},
+		logger:           logger,
+	}
+	b.recoverBatches(ctx)
+
+	// the blob of the submitted batch is held until its transaction resolves, the other
+	// is handed back to be batched again
+	assert.True(t, streamer.EncodedBlobstore.HasEncodingRequested(confirmedKey))
+	assert.False(t, streamer.EncodedBlobstore.HasEncodingRequested(dispersedKey))
+	assert.Len(t, streamer.batchJournal.load(), 1)
+
+	confirmer.included <- 42
+	assert.Eventually(t, func() bool {
+		return !streamer.EncodedBlobstore.HasEncodingRequested(confirmedKey)
+	}, time.Second, 10*time.Millisecond)
+	metadata, err := store.GetBlobMetadata(ctx, confirmedKey)
+	assert.NoError(t, err)
+	assert.Equal(t, disperser.Confirmed, metadata.BlobStatus)
+	assert.Equal(t, uint32(42), metadata.ConfirmationInfo.ConfirmationBlockNumber)
+	assert.Equal(t, confirmer.replacement, metadata.ConfirmationInfo.ConfirmationTxnHash)
+	assert.Equal(t, eth_common.HexToHash("0xb1"), metadata.ConfirmationInfo.ConfirmationBlockHash)
+	assert.Empty(t, streamer.batchJournal.load())
+
+	metadata, err = store.GetBlobMetadata(ctx, dispersedKey)
+	assert.NoError(t, err)
+	assert.Equal(t, disperser.Processing, metadata.BlobStatus)
+}
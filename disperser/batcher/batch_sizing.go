@@ -0,0 +1,123 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+)
+
+// BatchSizingConfig adapts the encoded size at which a batch is created, BatchSizeMBLimit
+// being the target under reference conditions. Each configured signal scales the target by
+// its ratio to its reference: expensive gas, a confirmation backlog and slow chain writes
+// grow batches so fewer confirmations are sent, and quiet conditions shrink them to cut
+// the latency of blobs.
+type BatchSizingConfig struct {
+	// MinSizeMB and MaxSizeMB bound the target, adaptive sizing is disabled if MaxSizeMB is 0
+	MinSizeMB uint
+	MaxSizeMB uint
+	// ReferenceGasPrice is the gas price in wei the target is scaled against, 0 to ignore gas
+	ReferenceGasPrice uint64
+	// BacklogTarget is the number of batches waiting for confirmation the target is scaled
+	// against, 0 to ignore the backlog
+	BacklogTarget uint
+	// LatencyTarget is the chain write latency the target is scaled against, 0 to ignore it
+	LatencyTarget time.Duration
+	// Interval is how often the target is recomputed
+	Interval time.Duration
+}
+
+func (c BatchSizingConfig) Enabled() bool {
+	return c.MaxSizeMB > 0
+}
+
+func (c BatchSizingConfig) validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.MinSizeMB > c.MaxSizeMB {
+		return errors.New("the minimum batch size target must not exceed the maximum")
+	}
+	if c.Interval <= 0 {
+		return errors.New("the batch sizing interval must be positive")
+	}
+	return nil
+}
+
+// GasPriceReader reads the current gas price for adaptive batch sizing
+type GasPriceReader interface {
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+}
+
+// sizingSignals are the conditions observed when the target is computed. A nil gas price
+// or a zero latency means the signal is not available.
+type sizingSignals struct {
+	gasPrice *big.Int
+	backlog  int
+	latency  time.Duration
+}
+
+// sizingFactors returns the factor of each available signal the base target is scaled by
+func (c BatchSizingConfig) sizingFactors(signals sizingSignals) map[string]float64 {
+	factors := make(map[string]float64)
+	if c.ReferenceGasPrice > 0 && signals.gasPrice != nil {
+		ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(signals.gasPrice), new(big.Float).SetUint64(c.ReferenceGasPrice)).Float64()
+		factors["gas"] = ratio
+	}
+	if c.BacklogTarget > 0 {
+		// an empty backlog halves the target at most, so a quiet chain doesn't zero it
+		factors["backlog"] = float64(signals.backlog+int(c.BacklogTarget)) / float64(2*c.BacklogTarget)
+	}
+	if c.LatencyTarget > 0 && signals.latency > 0 {
+		factors["latency"] = float64(signals.latency) / float64(c.LatencyTarget)
+	}
+	return factors
+}
+
+// target returns the batch size target in bytes for the base target in MB
+func (c BatchSizingConfig) target(baseMB uint, factors map[string]float64) uint64 {
+	size := float64(baseMB) * 1024 * 1024
+	for _, factor := range factors {
+		size *= factor
+	}
+	minSize, maxSize := float64(c.MinSizeMB)*1024*1024, float64(c.MaxSizeMB)*1024*1024
+	if size < minSize {
+		size = minSize
+	}
+	if size > maxSize {
+		size = maxSize
+	}
+	return uint64(size)
+}
+
+// runBatchSizing recomputes the batch size target every interval until ctx is done
+func (b *Batcher) runBatchSizing(ctx context.Context) {
+	ticker := time.NewTicker(b.BatchSizing.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			signals := sizingSignals{
+				backlog: b.confirmer.PendingBatches(),
+				latency: b.confirmer.WriteLatency(),
+			}
+			if b.BatchSizing.ReferenceGasPrice > 0 {
+				gasPrice, err := b.GasPrices.SuggestGasPrice(ctx)
+				if err != nil {
+					b.logger.Warn("[batcher] failed to read the gas price for batch sizing", "err", err)
+				} else {
+					signals.gasPrice = gasPrice
+				}
+			}
+			factors := b.BatchSizing.sizingFactors(signals)
+			target := b.BatchSizing.target(b.BatchSizeMB(), factors)
+			if b.EncodingStreamer.EncodedSizeNotifier.setThreshold(target) {
+				b.logger.Info("[batcher] batch size target changed", "bytes", target, "factors", factors)
+			}
+			b.Metrics.UpdateBatchSizeTarget(target, factors)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package batcher
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchSizingTarget(t *testing.T) {
+	config := BatchSizingConfig{
+		MinSizeMB:         4,
+		MaxSizeMB:         64,
+		ReferenceGasPrice: 10,
+		BacklogTarget:     2,
+		LatencyTarget:     10 * time.Second,
+		Interval:          time.Second,
+	}
+	assert.NoError(t, config.validate())
+	const mb = 1024 * 1024
+
+	// reference conditions leave the base target
+	factors := config.sizingFactors(sizingSignals{gasPrice: big.NewInt(10), backlog: 2, latency: 10 * time.Second})
+	assert.Equal(t, map[string]float64{"gas": 1, "backlog": 1, "latency": 1}, factors)
+	assert.Equal(t, uint64(16*mb), config.target(16, factors))
+
+	// expensive gas grows batches, within the maximum
+	factors = config.sizingFactors(sizingSignals{gasPrice: big.NewInt(30), backlog: 2})
+	assert.Equal(t, uint64(48*mb), config.target(16, factors))
+	factors = config.sizingFactors(sizingSignals{gasPrice: big.NewInt(100), backlog: 2})
+	assert.Equal(t, uint64(64*mb), config.target(16, factors))
+
+	// a quiet chain shrinks them, within the minimum
+	factors = config.sizingFactors(sizingSignals{gasPrice: big.NewInt(5), latency: 5 * time.Second})
+	assert.Equal(t, uint64(4*mb), config.target(16, factors))
+
+	assert.Error(t, BatchSizingConfig{MinSizeMB: 8, MaxSizeMB: 4, Interval: time.Second}.validate())
+}
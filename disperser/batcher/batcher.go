@@ -5,33 +5,30 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/0glabs/0g-da-client/common"
-	"github.com/0glabs/0g-da-client/common/geth"
+	"github.com/0glabs/0g-da-client/common/tlsconfig"
+	"github.com/0glabs/0g-da-client/common/tracing"
 	"github.com/0glabs/0g-da-client/core"
 	"github.com/0glabs/0g-da-client/disperser"
 	"github.com/0glabs/0g-da-client/disperser/contract"
+	"github.com/0glabs/0g-da-client/disperser/encoder"
 	"github.com/0glabs/0g-da-client/disperser/signer"
 	eth_common "github.com/ethereum/go-ethereum/common"
 	"github.com/gammazero/workerpool"
 	"github.com/hashicorp/go-multierror"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/wealdtech/go-merkletree"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	QuantizationFactor = uint(1)
-	indexerWarmupDelay = 2 * time.Second
 )
 
-type TimeoutConfig struct {
-	EncodingTimeout   time.Duration
-	ChainReadTimeout  time.Duration
-	ChainWriteTimeout time.Duration
-	SigningTimeout    time.Duration
-}
-
 type Config struct {
 	PullInterval             time.Duration
 	FinalizerInterval        time.Duration
@@ -44,15 +41,94 @@ type Config struct {
 	MaxNumRetriesPerBlob uint
 	ConfirmerNum         uint
 
-	DAEntranceContractAddress     string
-	DASignersContractAddress      string
-	EncodingInterval              time.Duration
-	SigningInterval               time.Duration
-	MaxNumRetriesForSign          uint
-	FinalizedBlockCount           uint
+	DAEntranceContractAddress string
+	DASignersContractAddress  string
+	EncodingInterval          time.Duration
+	SigningInterval           time.Duration
+	MaxNumRetriesForSign      uint
+	FinalizedBlockCount       uint
+	// ConfirmationDepth, if set, is the number of blocks below the head after which a
+	// confirmation is final, instead of the finalized block of the chain
+	ConfirmationDepth             uint
 	ExpirationPollIntervalSec     uint64
 	SignedPullInterval            time.Duration
 	VerifiedCommitRootsTxGasLimit uint64
+
+	SignerHedging     HedgingConfig
+	EncoderCrossCheck CrossCheckConfig
+	// EncoderBalancer balances the blobs across the encoders if EncoderSocket lists several
+	EncoderBalancer EncoderBalancerConfig
+	// EncoderBatching batches the requests of small blobs to each encoder
+	EncoderBatching encoder.BatchingConfig
+	// EncoderTLS secures the connections to the encoders
+	EncoderTLS tlsconfig.ClientConfig
+	// HashSuite names the hash used for blob and batch header hashes, see core.GetHashSuite
+	HashSuite string
+	// EncodingJournalPath enables persisting encoding results for a warm start, see StreamerConfig
+	EncodingJournalPath string
+	// EncodingCacheSizeMB is the size of the encodings reused for resubmitted blobs, see StreamerConfig
+	EncodingCacheSizeMB uint
+	// BatchJournalPath enables persisting the batches in flight for crash recovery, see StreamerConfig
+	BatchJournalPath string
+	// PartialConfirmation confirms the blobs that reached the signing threshold instead of failing the whole batch
+	PartialConfirmation bool
+	// QuorumFallback confirms the blobs short of the optional thresholds they requested with the default thresholds
+	QuorumFallback bool
+	// ChunkFormats are the encoded slice formats offered to signers in order of preference,
+	// see core.GetChunkFormat. Signers that don't advertise any of them get the legacy format.
+	ChunkFormats []string
+	// SignerDiscovery queries the version and features of signers before sending them slices
+	SignerDiscovery signer.DiscoveryConfig
+	// SignerQUIC sends the slices over QUIC to the signers that advertise it, experimental
+	SignerQUIC signer.QUICConfig
+	Poster     PosterConfig
+	Drain      DrainConfig
+	Admin      AdminConfig
+	// SignerTLS secures the grpc connections to the signers
+	SignerTLS tlsconfig.ClientConfig
+	// BatchGas caps batches by the estimated gas of their confirmation
+	BatchGas BatchGasConfig
+	// MinBatch defers batches of the ticker below a minimum size
+	MinBatch MinBatchConfig
+	// BatchAlign creates batches at fixed block intervals instead
+	BatchAlign BatchAlignConfig
+	// BatchSizing adapts the batch size target to the chain conditions
+	BatchSizing BatchSizingConfig
+	// BatchPipelines is the number of batches created and dispatched concurrently, the
+	// transactions of the account then being sent with locally tracked nonces
+	BatchPipelines uint
+	// EncoderSharedMemoryDir is the directory shared with a co-located encoder to exchange
+	// blobs and encodings through files instead of gRPC messages, unused if empty
+	EncoderSharedMemoryDir string
+	// DeadLetter dead-letters the blobs stuck in Processing
+	DeadLetter DeadLetterConfig
+	// InFlight keeps the blobs of batches whose transaction has no receipt yet out of new
+	// batches until it resolves
+	InFlight InFlightConfig
+	// Fairness reports operators assigned bytes out of proportion with their stake
+	Fairness FairnessConfig
+	// DispatchDeadline bounds the wait for the signatures of the operators of each quorum
+	DispatchDeadline DispatchDeadlineConfig
+	// Bandwidth leaves the operators too slow for the dispatch deadline out of dispersals
+	Bandwidth BandwidthConfig
+	// StatusPage pushes a public status page to object storage
+	StatusPage StatusPageConfig
+	// Retry is the backoff of the blobs of failed batches, per failure reason
+	Retry RetryConfig
+	// Budget bounds the time and the fees spent on each batch
+	Budget BudgetConfig
+	// Watchdog checks that the signers of the confirmed blobs serve their slices
+	Watchdog WatchdogConfig
+	// Fallback is the deployment the aggregate signatures are submitted to while the
+	// primary chain is halted
+	Fallback FallbackConfig
+	// Health bounds the confirmer backlog and the dispersal failures reported as healthy
+	Health HealthConfig
+	// SpendLimit caps the fees of the confirmations and pauses them on cost spikes
+	SpendLimit SpendLimitConfig
+	// SafeMode starts the batcher in safe mode, see SafeModeStatus. It is left and
+	// entered again through the admin API, and kept across restarts in the batch journal.
+	SafeMode bool
 }
 
 type Batcher struct {
@@ -65,9 +141,32 @@ type Batcher struct {
 
 	EncodingStreamer *EncodingStreamer
 	Metrics          *Metrics
+	// Blocks reads the chain head, required for aligned batching
+	Blocks BlockNumberReader
+	// GasPrices reads the gas price, required for batch sizing against a reference gas price
+	GasPrices GasPriceReader
+	// Tracer continues the traces of the blobs dispersed with one through the stages of the
+	// batcher, nothing is traced if nil
+	Tracer *tracing.Tracer
+	// ConfirmationFallback submits the aggregate signatures to the fallback venue while the
+	// primary chain is halted if set
+	ConfirmationFallback *ConfirmationFallback
+
+	// createMu serializes the creation of batches across pipelines
+	createMu sync.Mutex
+	// pause holds new batches back, set through the admin API
+	pause batchingPause
+	// batchSizeMB overrides BatchSizeMBLimit if set through the admin API
+	batchSizeMB atomic.Uint64
+	// pullInterval overrides PullInterval if set by the reloaded tunables
+	pullInterval atomic.Int64
+	// events records the lifecycle of the blobs in their history
+	events *disperser.BlobEventLog
+	// dispersal tracks the failed dispersals for the health checks
+	dispersal dispersalHealth
 
 	finalizer   Finalizer
-	confirmer   *Confirmer
+	confirmer   *BatchConfirmer
 	sliceSigner *SliceSigner
 	logger      common.Logger
 }
@@ -75,12 +174,11 @@ type Batcher struct {
 func NewBatcher(
 	config Config,
 	timeoutConfig TimeoutConfig,
-	ethConfig geth.EthClientConfig,
 	queue disperser.BlobStore,
 	dispatcher disperser.Dispatcher,
 	encoderClient disperser.EncoderClient,
 	finalizer Finalizer,
-	confirmer *Confirmer,
+	confirmer *BatchConfirmer,
 	daContract *contract.DAContract,
 	logger common.Logger,
 	metrics *Metrics,
@@ -90,42 +188,148 @@ func NewBatcher(
 		make(chan struct{}, 1),
 		uint64(config.BatchSizeMBLimit)*1024*1024, // convert to bytes
 	)
+	hashSuite, err := core.GetHashSuite(config.HashSuite)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.MinBatch.validate(); err != nil {
+		return nil, err
+	}
+	if err := config.BatchAlign.validate(); err != nil {
+		return nil, err
+	}
+	if err := config.BatchSizing.validate(); err != nil {
+		return nil, err
+	}
+	if err := config.Fairness.validate(); err != nil {
+		return nil, err
+	}
+	if err := config.DispatchDeadline.validate(); err != nil {
+		return nil, err
+	}
+	if err := config.Bandwidth.validate(); err != nil {
+		return nil, err
+	}
+	if err := config.DeadLetter.validate(); err != nil {
+		return nil, err
+	}
+	if err := config.StatusPage.validate(); err != nil {
+		return nil, err
+	}
+	if err := config.Retry.validate(); err != nil {
+		return nil, err
+	}
+	if err := config.Budget.validate(); err != nil {
+		return nil, err
+	}
+	if err := config.Watchdog.validate(); err != nil {
+		return nil, err
+	}
+	if err := config.Fallback.validate(); err != nil {
+		return nil, err
+	}
+	if err := config.SpendLimit.validate(); err != nil {
+		return nil, err
+	}
+	if config.BatchPipelines > 1 && daContract != nil {
+		daContract.TrackNonces()
+	}
+	maxBlobsPerBatch, err := config.BatchGas.MaxBlobs()
+	if err != nil {
+		return nil, err
+	}
+	if maxBlobsPerBatch > 0 {
+		logger.Info("[batcher] batches capped by gas estimate", "maxBlobs", maxBlobsPerBatch, "blockGasTarget", config.BatchGas.BlockGasTarget, "estimatedGas", config.BatchGas.EstimateGas(uint64(maxBlobsPerBatch)))
+	}
 	streamerConfig := StreamerConfig{
-		SRSOrder:               config.SRSOrder,
-		EncodingRequestTimeout: timeoutConfig.EncodingTimeout,
-		EncodingQueueLimit:     config.EncodingRequestQueueSize,
-		EncodingInterval:       config.EncodingInterval,
+		HashSuite:           hashSuite,
+		SRSOrder:            config.SRSOrder,
+		Timeouts:            timeoutConfig,
+		EncodingQueueLimit:  config.EncodingRequestQueueSize,
+		EncodingInterval:    config.EncodingInterval,
+		EncodingJournalPath: config.EncodingJournalPath,
+		BatchJournalPath:    config.BatchJournalPath,
+		Drain:               config.Drain,
+		MaxBlobsPerBatch:    maxBlobsPerBatch,
+		SafeMode:            config.SafeMode,
+		EncodingCacheSize:   uint64(config.EncodingCacheSizeMB) * 1024 * 1024,
+
+		MaxNumRetriesPerBlob: config.MaxNumRetriesPerBlob,
 	}
 	encodingWorkerPool := workerpool.New(config.NumConnections)
 	encodingStreamer, err := NewEncodingStreamer(streamerConfig, queue, encoderClient, batchTrigger, encodingWorkerPool, metrics.EncodingStreamerMetrics, logger)
 	if err != nil {
 		return nil, err
 	}
+	encodingStreamer.retries, err = newRetryScheduler(config.Retry, config.MaxNumRetriesPerBlob)
+	if err != nil {
+		return nil, err
+	}
 
-	signerClient, err := signer.NewSignerClient(timeoutConfig.SigningTimeout)
+	chunkFormats := make([]core.ChunkFormat, len(config.ChunkFormats))
+	for i, name := range config.ChunkFormats {
+		chunkFormats[i], err = core.GetChunkFormat(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	signerCreds, err := tlsconfig.ClientCredentials(config.SignerTLS, logger)
+	if err != nil {
+		return nil, err
+	}
+	signerClient, err := signer.NewSignerClient(timeoutConfig.SigningTimeout, config.SignerDiscovery, config.SignerQUIC, signerCreds, metrics, chunkFormats...)
 	if err != nil {
 		return nil, err
 	}
+	if config.Watchdog.Enabled() {
+		reader, ok := signerClient.(disperser.SliceReader)
+		if !ok {
+			return nil, errors.New("the signer client can't read slices back from the operators for the watchdog")
+		}
+		confirmer.Watchdog = NewWatchdog(config.Watchdog, reader, timeoutConfig, metrics, logger)
+	}
+	var exclusions *operatorExclusions
+	if config.Watchdog.Enabled() && config.Watchdog.ExclusionPeriod > 0 {
+		exclusions = newOperatorExclusions(config.Watchdog.ExclusionPeriod)
+		confirmer.Watchdog.Challenger = exclusions
+	}
+	if config.SignerHedging.Enabled {
+		signerClient = newHedgedSignerClient(signerClient, config.SignerHedging, metrics)
+	}
 
 	signerTrigger := NewSignatureSizeNotifier(
 		make(chan struct{}, 1),
 		100,
 	)
 	signerConfig := SignerConfig{
-		SigningRequestTimeout: timeoutConfig.SigningTimeout,
-		MaxNumRetriesPerBlob:  config.MaxNumRetriesPerBlob,
-		MaxNumRetriesSign:     config.MaxNumRetriesForSign,
-		SigningInterval:       config.SigningInterval,
+		Timeouts:             timeoutConfig,
+		MaxNumRetriesPerBlob: config.MaxNumRetriesPerBlob,
+		MaxNumRetriesSign:    config.MaxNumRetriesForSign,
+		SigningInterval:      config.SigningInterval,
+		PartialConfirmation:  config.PartialConfirmation,
+		QuorumFallback:       config.QuorumFallback,
+		InFlight:             config.InFlight,
+		Fairness:             config.Fairness,
+		DispatchDeadline:     config.DispatchDeadline,
+		Bandwidth:            config.Bandwidth,
 	}
+	events := disperser.NewBlobEventLog(queue, logger)
+	encodingStreamer.events = events
+	confirmer.events = events
+
 	signingWorkerPool := workerpool.New(config.NumConnections)
+	var registry SignerRegistry
+	if daContract != nil {
+		registry = daContract
+	}
 	sliceSigner, err := NewEncodedSliceSigner(
-		ethConfig,
+		confirmer.confirmer,
 		signerConfig,
 		signingWorkerPool,
 		signerTrigger,
 		signerClient,
 		queue,
-		daContract,
+		registry,
 		metrics,
 		logger,
 		blobKeyCache,
@@ -133,6 +337,8 @@ func NewBatcher(
 	if err != nil {
 		return nil, err
 	}
+	sliceSigner.events = events
+	sliceSigner.exclusions = exclusions
 
 	return &Batcher{
 		Config:        config,
@@ -148,63 +354,100 @@ func NewBatcher(
 		finalizer:   finalizer,
 		confirmer:   confirmer,
 		sliceSigner: sliceSigner,
+		events:      events,
 		logger:      logger,
 	}, nil
 }
 
+// SetSigners replaces the registry of the signers and the client dispersing the slices to
+// them, for networks whose operators are simulated in process. It must be called before the
+// batcher starts.
+func (b *Batcher) SetSigners(registry SignerRegistry, client disperser.SignerClient) {
+	b.sliceSigner.registry = registry
+	b.sliceSigner.signerClient = client
+}
+
+// Start starts the encoding, the confirmation and the batching of the batcher. Services
+// started by a lifecycle manager start them as separate components instead.
 func (b *Batcher) Start(ctx context.Context) error {
-	// Wait for few seconds for indexer to index blockchain
-	// This won't be needed when we switch to using Graph node
-	time.Sleep(indexerWarmupDelay)
-	err := b.EncodingStreamer.Start(ctx)
-	if err != nil {
+	if err := b.StartEncoding(ctx); err != nil {
 		return err
 	}
-	batchTrigger := b.EncodingStreamer.EncodedSizeNotifier
-	submitAggregateSignaturesTrigger := b.sliceSigner.SignatureSizeNotifier
+	if err := b.StartConfirmation(ctx); err != nil {
+		return err
+	}
+	return b.StartBatching(ctx)
+}
+
+// StartEncoding starts the encoding streamer
+func (b *Batcher) StartEncoding(ctx context.Context) error {
+	if b.EncodingStreamer.batchJournal != nil {
+		b.recoverBatches(ctx)
+	}
+	b.EncodingStreamer.Tracer = b.Tracer
+	b.events.Start(ctx)
+	return b.EncodingStreamer.Start(ctx)
+}
 
+// StartConfirmation starts signing, confirming and finalizing the batches created
+func (b *Batcher) StartConfirmation(ctx context.Context) error {
 	b.sliceSigner.EncodingStreamer = b.EncodingStreamer
 	b.sliceSigner.Finalizer = b.finalizer
+	b.sliceSigner.Tracer = b.Tracer
+	b.sliceSigner.spends = b.confirmer
+	b.events.Start(ctx)
 	b.sliceSigner.Start(ctx)
 
 	// confirmer
 	b.confirmer.EncodingStreamer = b.EncodingStreamer
+	b.confirmer.Timeouts = b.TimeoutConfig
 	b.confirmer.SliceSigner = b.sliceSigner
+	b.confirmer.Tracer = b.Tracer
+	b.confirmer.Fallback = b.ConfirmationFallback
 	b.confirmer.Start(ctx)
 	// finalizer
+	if f, ok := b.finalizer.(interface{ setFallback(*ConfirmationFallback) }); ok && b.ConfirmationFallback != nil {
+		f.setFallback(b.ConfirmationFallback)
+	}
+	if finality, ok := b.confirmer.confirmer.(finalityReader); ok {
+		if f, ok := b.finalizer.(interface{ setFinality(finalityReader) }); ok {
+			f.setFinality(finality)
+		}
+	}
 	b.finalizer.Start(ctx)
+	if b.ConfirmationFallback != nil {
+		b.ConfirmationFallback.Start(ctx)
+	}
+	return nil
+}
 
-	go func() {
-		ticker := time.NewTicker(b.PullInterval)
-		defer ticker.Stop()
+// StartBatching starts creating batches of the encoded blobs and submitting the signed ones
+func (b *Batcher) StartBatching(ctx context.Context) error {
+	if b.BatchAlign.Enabled() && b.Blocks == nil {
+		return errors.New("aligned batching requires a chain head reader")
+	}
+	if b.BatchSizing.Enabled() && b.BatchSizing.ReferenceGasPrice > 0 && b.GasPrices == nil {
+		return errors.New("batch sizing against a reference gas price requires a gas price reader")
+	}
+	b.events.Start(ctx)
+	batchTrigger := b.EncodingStreamer.EncodedSizeNotifier
+	submitAggregateSignaturesTrigger := b.sliceSigner.SignatureSizeNotifier
 
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				if ts, err := b.HandleSingleBatch(ctx); err != nil {
-					b.EncodingStreamer.RemoveBatchingStatus(ts)
-					if errors.Is(err, errNoEncodedResults) {
-						b.logger.Debug("[batcher] no encoded results to make a batch with")
-					} else {
-						b.logger.Error("[batcher] failed to process a batch", "err", err)
-					}
-				}
-			case <-batchTrigger.Notify:
-				ticker.Stop()
-				if ts, err := b.HandleSingleBatch(ctx); err != nil {
-					b.EncodingStreamer.RemoveBatchingStatus(ts)
-					if errors.Is(err, errNoEncodedResults) {
-						b.logger.Debug("[batcher] no encoded results to make a batch with(Notified)")
-					} else {
-						b.logger.Error("[batcher] failed to process a batch(Notified)", "err", err)
-					}
-				}
-				ticker.Reset(b.PullInterval)
-			}
-		}
-	}()
+	if b.Admin.Enabled() {
+		NewAdminServer(b.Admin, b, b.logger).Start()
+	}
+
+	if b.BatchSizing.Enabled() {
+		go b.runBatchSizing(ctx)
+	}
+	if b.DeadLetter.Enabled() {
+		go b.runDeadLetterSweep(ctx)
+	}
+	if b.BatchAlign.Enabled() {
+		go b.runAlignedBatches(ctx, batchTrigger)
+	} else {
+		go b.runBatches(ctx, batchTrigger)
+	}
 
 	go func() {
 		ticker := time.NewTicker(b.SignedPullInterval)
@@ -219,6 +462,8 @@ func (b *Batcher) Start(ctx context.Context) error {
 				if err := b.HandleSignedBatch(ctx); err != nil {
 					if errors.Is(err, errNoSignedResults) {
 						b.logger.Debug("[batcher] no signed results to make a batch with")
+					} else if errors.Is(err, errConfirmationPaused) {
+						b.logger.Debug("[batcher] confirmation paused, signed batches held back")
 					} else {
 						b.logger.Error("[batcher] failed to process a signed batch", "err", err)
 					}
@@ -229,12 +474,14 @@ func (b *Batcher) Start(ctx context.Context) error {
 				if err := b.HandleSignedBatch(ctx); err != nil {
 					if errors.Is(err, errNoSignedResults) {
 						b.logger.Debug("[batcher] no signed results to make a batch with(Notified)")
+					} else if errors.Is(err, errConfirmationPaused) {
+						b.logger.Debug("[batcher] confirmation paused, signed batches held back(Notified)")
 					} else {
 						b.logger.Error("[batcher] failed to process a signed batch(Notified)", "err", err)
 					}
 				}
 
-				ticker.Reset((b.PullInterval))
+				ticker.Reset(b.PullIntervalNow())
 			}
 		}
 	}()
@@ -243,7 +490,11 @@ func (b *Batcher) Start(ctx context.Context) error {
 }
 
 func serializeProof(proof *merkletree.Proof) []byte {
-	proofBytes := make([]byte, 0)
+	size := 0
+	for _, hash := range proof.Hashes {
+		size += len(hash)
+	}
+	proofBytes := make([]byte, 0, size)
 	for _, hash := range proof.Hashes {
 		proofBytes = append(proofBytes, hash[:]...)
 	}
@@ -253,7 +504,9 @@ func serializeProof(proof *merkletree.Proof) []byte {
 func (b *Batcher) handleFailure(ctx context.Context, blobMetadatas []*disperser.BlobMetadata, reason FailReason) error {
 	var result *multierror.Error
 	for _, metadata := range blobMetadatas {
-		err := b.Queue.HandleBlobFailure(ctx, metadata, b.MaxNumRetriesPerBlob)
+		err := b.TimeoutConfig.Do(ctx, CallStoreWrite, func(ctx context.Context) error {
+			return b.EncodingStreamer.handleBatchFailure(ctx, b.Queue, metadata, reason, b.MaxNumRetriesPerBlob)
+		})
 		if err != nil {
 			b.logger.Error("[batcher] HandleSingleBatch: error handling blob failure", "err", err)
 			// Append the error
@@ -261,13 +514,104 @@ func (b *Batcher) handleFailure(ctx context.Context, blobMetadatas []*disperser.
 		}
 		b.Metrics.UpdateCompletedBlob(int(metadata.RequestMetadata.BlobSize), disperser.Failed)
 	}
+	b.events.RecordAll(blobMetadatas, disperser.BlobFailed, string(reason))
 	b.Metrics.UpdateBatchError(reason, len(blobMetadatas))
 
 	// Return the error(s)
 	return result.ErrorOrNil()
 }
 
+// recordBatchAssignments queues the position of the blobs in the batch of headerHash, which
+// clients may query before the batch is confirmed. The assignments are written in the
+// background, a blob whose assignment fails to be written is batched all the same, its
+// status only lacks the assignment until it is confirmed.
+func (b *Batcher) recordBatchAssignments(headerHash [32]byte, blobMetadatas []*disperser.BlobMetadata) {
+	for i, metadata := range blobMetadatas {
+		b.EncodingStreamer.assignments.set(metadata.GetBlobKey(), &disperser.BatchAssignment{
+			BatchHeaderHash: headerHash,
+			BlobIndex:       uint32(i),
+			BlobCount:       uint32(len(blobMetadatas)),
+		})
+		b.events.Record(metadata.GetBlobKey(), disperser.BlobBatched, fmt.Sprintf("%x/%d", headerHash, i))
+	}
+}
+
+// runBatches creates batches every pull interval and whenever the encoded size threshold
+// is reached, until ctx is done
+func (b *Batcher) runBatches(ctx context.Context, batchTrigger *EncodedSizeNotifier) {
+	interval := b.PullIntervalNow()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var pipelines *batchPipelines
+	if b.BatchPipelines > 1 {
+		pipelines = newBatchPipelines(b.BatchPipelines)
+	}
+	for {
+		if next := b.PullIntervalNow(); next != interval {
+			interval = next
+			ticker.Reset(interval)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// a draining batcher flushes whatever is left
+			if count, size, oldest := b.EncodingStreamer.EncodedBlobstore.GetPendingEncodedStats(); !b.EncodingStreamer.intake.stopped() && !b.MinBatch.reached(count, size, oldest, time.Now()) {
+				b.logger.Debug("[batcher] batch deferred below the minimum batch size", "blobs", count, "size", size)
+			} else {
+				b.startBatch(ctx, pipelines, "")
+			}
+		case <-batchTrigger.Notify:
+			ticker.Stop()
+			b.startBatch(ctx, pipelines, "(Notified)")
+			ticker.Reset(interval)
+		}
+	}
+}
+
+// startBatch handles a batch in a free pipeline, or right away without pipelines
+func (b *Batcher) startBatch(ctx context.Context, pipelines *batchPipelines, trigger string) {
+	if pipelines == nil {
+		b.handleBatch(ctx, trigger)
+		return
+	}
+	if !pipelines.run(func() { b.handleBatch(ctx, trigger) }) {
+		b.logger.Debug("[batcher] all batch pipelines are busy" + trigger)
+	}
+	b.Metrics.UpdateBusyPipelines(pipelines.busy())
+}
+
+func (b *Batcher) handleBatch(ctx context.Context, trigger string) {
+	if ts, err := b.HandleSingleBatch(ctx); err != nil {
+		b.EncodingStreamer.RemoveBatchingStatus(ts)
+		if errors.Is(err, errNoEncodedResults) {
+			b.logger.Debug("[batcher] no encoded results to make a batch with" + trigger)
+		} else if errors.Is(err, errBatchDeferred) {
+			b.logger.Debug("[batcher] batch deferred by the drain strategy" + trigger)
+		} else if errors.Is(err, errSafeMode) {
+			b.logger.Debug("[batcher] batch paused by the safe mode" + trigger)
+		} else if errors.Is(err, errBatchingPaused) {
+			b.logger.Debug("[batcher] batch paused through the admin api" + trigger)
+		} else if errors.Is(err, errConfirmationPaused) {
+			b.logger.Debug("[batcher] batch paused with the confirmation" + trigger)
+		} else {
+			b.logger.Error("[batcher] failed to process a batch"+trigger, "err", err)
+		}
+	}
+}
+
 func (b *Batcher) HandleSingleBatch(ctx context.Context) (uint64, error) {
+	if b.EncodingStreamer.safeMode.enabled() {
+		return 0, errSafeMode
+	}
+	if b.pause.paused() {
+		return 0, errBatchingPaused
+	}
+	// the data roots of a new batch would be uploaded for signatures that can't be submitted
+	if b.confirmer.confirmationPaused() {
+		return 0, errConfirmationPaused
+	}
 	log := b.logger
 	// start a timer
 	timer := prometheus.NewTimer(prometheus.ObserverFunc(func(f float64) {
@@ -275,60 +619,75 @@ func (b *Batcher) HandleSingleBatch(ctx context.Context) (uint64, error) {
 	}))
 	defer timer.ObserveDuration()
 
+	b.createMu.Lock()
+	// batches forced by an expedited blob skip the drain cap
+	if !b.EncodingStreamer.expediter.takeForceBatch() && !b.EncodingStreamer.drain.batchAllowed() {
+		b.createMu.Unlock()
+		return 0, errBatchDeferred
+	}
+
 	stageTimer := time.Now()
 	log.Info("[batcher] Creating batch", "ts", stageTimer)
 	batch, ts, err := b.EncodingStreamer.CreateBatch()
 	if err != nil {
+		b.createMu.Unlock()
 		return ts, err
 	}
+	b.EncodingStreamer.drain.recordBatch()
+	b.createMu.Unlock()
+	batch.budget = newBatchBudget(b.Budget, stageTimer)
 	log.Info("[batcher] CreateBatch took", "duration", time.Since(stageTimer), "blobNum", len(batch.EncodedBlobs))
 
 	// Get the batch header hash
 	log.Trace("[batcher] Getting batch header hash...")
-	headerHash, err := batch.BatchHeader.GetBatchHeaderHash()
+	headerHash, err := batch.BatchHeader.GetBatchHeaderHashWith(b.EncodingStreamer.HashSuite)
 	if err != nil {
 		_ = b.handleFailure(ctx, batch.BlobMetadata, FailBatchHeaderHash)
 		return ts, fmt.Errorf("HandleSingleBatch: error getting batch header hash: %w", err)
 	}
 
-	proofs := make([]*merkletree.Proof, 0)
-	// Prepare data writes to kv stream
-	for blobIndex := range batch.BlobMetadata {
-		var blobHeader *core.BlobHeader
-		// generate inclusion proof
-		if blobIndex >= len(batch.BlobHeaders) {
-			_ = b.handleFailure(ctx, batch.BlobMetadata, FailBatchBlobIndex)
-			return ts, fmt.Errorf("HandleSingleBatch: error preparing kv data: blob header at index %d not found in batch", blobIndex)
-		}
-		blobHeader = batch.BlobHeaders[blobIndex]
-
-		blobHeaderHash, err := blobHeader.GetBlobHeaderHash()
-		if err != nil {
-			_ = b.handleFailure(ctx, batch.BlobMetadata, FailBatchBlobHeaderHash)
-			return ts, fmt.Errorf("HandleSingleBatch: failed to get blob header hash: %w", err)
-		}
-		merkleProof, err := batch.MerkleTree.GenerateProof(blobHeaderHash[:], 0)
-		if err != nil {
-			_ = b.handleFailure(ctx, batch.BlobMetadata, FailBatchProof)
-			return ts, fmt.Errorf("HandleSingleBatch: failed to generate blob header inclusion proof: %w", err)
-		}
-		proofs = append(proofs, merkleProof)
+	if len(batch.BlobMetadata) > len(batch.BlobHeaders) {
+		_ = b.handleFailure(ctx, batch.BlobMetadata, FailBatchBlobIndex)
+		return ts, fmt.Errorf("HandleSingleBatch: error preparing kv data: blob header at index %d not found in batch", len(batch.BlobHeaders))
 	}
+	// generate inclusion proofs by position, blobs with the same header may share a batch
+	proofs, err := core.BatchInclusionProofs(batch.BlobHeaders, b.EncodingStreamer.HashSuite)
+	if err != nil {
+		_ = b.handleFailure(ctx, batch.BlobMetadata, FailBatchProof)
+		return ts, fmt.Errorf("HandleSingleBatch: failed to generate blob header inclusion proofs: %w", err)
+	}
+	proofs = proofs[:len(batch.BlobMetadata)]
+	b.recordBatchAssignments(headerHash, batch.BlobMetadata)
 
 	// Dispatch encoded batch
 	log.Info("[batcher] Dispatching encoded batch...")
 	stageTimer = time.Now()
-	batch.TxHash, err = b.Dispatcher.DisperseBatch(ctx, headerHash, batch.BatchHeader, batch.EncodedBlobs, batch.BlobHeaders)
+	spans := traceBlobs(b.Tracer, batch.BlobMetadata, "dispatch", stageTimer, tracing.Int("batch.id", int64(ts)), tracing.String("batch.header_hash", eth_common.Hash(headerHash).Hex()), tracing.Int("batch.blobs", int64(len(batch.BlobMetadata))))
+	// the data roots go to the fallback venue while the primary chain is halted, and the
+	// aggregate signatures of the batch follow them there
+	dispatcher := b.Dispatcher
+	if b.ConfirmationFallback != nil {
+		batch.venue = b.ConfirmationFallback.route()
+		if batch.venue != nil {
+			dispatcher = batch.venue.Dispatcher
+		}
+	}
+	err = b.TimeoutConfig.Do(ctx, CallChainWrite, func(ctx context.Context) error {
+		var err error
+		batch.TxHash, err = dispatcher.DisperseBatch(ctx, headerHash, batch.BatchHeader, batch.EncodedBlobs, batch.BlobHeaders)
+		return err
+	})
+	endSpans(spans, err, tracing.String("tx.hash", batch.TxHash.Hex()))
+	b.dispersal.record(err)
 	if err != nil {
 		for _, metadata := range batch.BlobMetadata {
-			meta, err := b.Queue.GetBlobMetadata(ctx, metadata.GetBlobKey())
+			meta, err := b.Queue.GetBlobMetadataWithOptions(ctx, metadata.GetBlobKey(), disperser.ReadOptions{Consistency: disperser.SessionRead})
 			if err != nil {
 				log.Error("[batcher] failed to get blob metadata", "key", metadata.GetBlobKey(), "err", err)
 			} else {
-				if meta.BlobStatus == disperser.Failed {
+				if meta.BlobStatus == disperser.DeadLettered {
 					log.Info("[batcher] disperse batch reach max retries", "key", metadata.GetBlobKey())
 					b.EncodingStreamer.RemoveEncodedBlob(metadata)
-					b.Queue.RemoveBlob(ctx, metadata)
 				}
 			}
 		}
@@ -337,6 +696,8 @@ func (b *Batcher) HandleSingleBatch(ctx context.Context) (uint64, error) {
 		return ts, err
 	}
 	log.Info("[batcher] DisperseBatch took", "duration", time.Since(stageTimer))
+	b.events.RecordAll(batch.BlobMetadata, disperser.BlobDispersed, batch.TxHash.Hex())
+	b.EncodingStreamer.journalDispersedBatch(ts, headerHash, batch)
 
 	b.sliceSigner.SignerChan <- &SignInfo{
 		headerHash: headerHash,
@@ -351,6 +712,9 @@ func (b *Batcher) HandleSingleBatch(ctx context.Context) (uint64, error) {
 
 func (b *Batcher) HandleSignedBatch(ctx context.Context) error {
 	log := b.logger
+	if b.confirmer.confirmationPaused() {
+		return errConfirmationPaused
+	}
 
 	s, signedTs, err := b.sliceSigner.GetCommitRootSubmissionBatch()
 	if err != nil {
@@ -358,6 +722,13 @@ func (b *Batcher) HandleSignedBatch(ctx context.Context) error {
 		return err
 	}
 
+	if b.ConfirmationFallback != nil {
+		// the signatures are submitted to the venue the data roots of their batch were
+		// uploaded to, those of the other venue in a later submission
+		var deferred []uint64
+		s, deferred = splitByVenue(s)
+		b.sliceSigner.ReleaseSubmissions(signedTs, deferred)
+	}
 	log.Info("[batcher] Create signed batch", "batch size", len(s), "signed ts", signedTs)
 
 	submissions := make([]*core.CommitRootSubmission, 0)
@@ -367,6 +738,13 @@ func (b *Batcher) HandleSignedBatch(ctx context.Context) error {
 	proofs := make([][]*merkletree.Proof, 0)
 	epochs := make([]*big.Int, 0)
 	quorumIds := make([]*big.Int, 0)
+	percentSigned := make([]map[int]uint8, 0)
+	excluded := make([]map[int]struct{}, 0)
+	signerBitmaps := make([]core.SignerBitmap, 0)
+	numSigners := make([]int, 0)
+	attesters := make([]map[int][]*SignerState, 0)
+	signatures := make([]map[int]*core.CommitRootSubmission, 0)
+	referenceBlocks := make([]uint32, 0)
 	for _, item := range s {
 		submissions = append(submissions, item.submissions...)
 
@@ -377,25 +755,54 @@ func (b *Batcher) HandleSignedBatch(ctx context.Context) error {
 
 		epochs = append(epochs, item.epoch)
 		quorumIds = append(quorumIds, item.quorumId)
+		percentSigned = append(percentSigned, item.percentSigned)
+		excluded = append(excluded, item.excluded)
+		signerBitmaps = append(signerBitmaps, item.signerBitmap)
+		numSigners = append(numSigners, item.numSigners)
+		attesters = append(attesters, item.attesters)
+		signatures = append(signatures, item.signatures)
+		referenceBlocks = append(referenceBlocks, item.referenceBlock)
 	}
 
 	stageTimer := time.Now()
 	var txHash *eth_common.Hash
+	// the venue is only recorded if there is a fallback one
+	var venue *disperser.ConfirmationVenue
 	if len(submissions) > 0 {
-		hash, err := b.Dispatcher.SubmitAggregateSignatures(ctx, submissions)
+		dispatcher := b.Dispatcher
+		if b.ConfirmationFallback != nil {
+			route := batch[0].venue
+			if route != nil {
+				dispatcher = route.Dispatcher
+			}
+			venue = b.ConfirmationFallback.venueOf(route)
+		}
+		var spans []trace.Span
+		for idx, item := range batch {
+			spans = append(spans, traceBlobs(b.Tracer, item.BlobMetadata, "submit signatures", stageTimer, tracing.Int("batch.id", int64(ts[idx])), tracing.Int("submissions", int64(len(submissions))))...)
+		}
+		var hash eth_common.Hash
+		err := b.TimeoutConfig.Do(ctx, CallChainWrite, func(ctx context.Context) error {
+			var err error
+			hash, err = dispatcher.SubmitAggregateSignatures(ctx, submissions)
+			return err
+		})
+		if err == nil && venue != nil {
+			b.Metrics.IncrementVenueSubmissions(venue.Fallback)
+		}
+		endSpans(spans, err, tracing.String("tx.hash", hash.Hex()))
 		if err != nil {
 			for idx, item := range batch {
 				_ = b.handleFailure(ctx, item.BlobMetadata, FailSubmitAggregateSignatures)
 				for _, metadata := range item.BlobMetadata {
-					meta, err := b.Queue.GetBlobMetadata(ctx, metadata.GetBlobKey())
+					meta, err := b.Queue.GetBlobMetadataWithOptions(ctx, metadata.GetBlobKey(), disperser.ReadOptions{Consistency: disperser.SessionRead})
 					if err != nil {
 						log.Error("[batcher] failed to get blob metadata", "key", metadata.GetBlobKey(), "err", err)
 					} else {
-						if meta.BlobStatus == disperser.Failed {
+						if meta.BlobStatus == disperser.DeadLettered {
 							log.Info("[batcher] submit aggregateSignatures reach max retries", "key", metadata.GetBlobKey())
 							b.EncodingStreamer.RemoveEncodedBlob(metadata)
 							b.sliceSigner.RemoveSignedBlob(ts[idx])
-							b.Queue.RemoveBlob(ctx, metadata)
 						}
 					}
 				}
@@ -417,16 +824,32 @@ func (b *Batcher) HandleSignedBatch(ctx context.Context) error {
 	b.logger.Info("[batcher] submit aggregate signatures", "duration", time.Since(stageTimer))
 
 	b.sliceSigner.SignedBatchSize = 0
-	b.confirmer.ConfirmChan <- &BatchInfo{
+	batchInfo := &BatchInfo{
 		headerHash: headerHash,
 		batch:      batch,
 		ts:         ts,
 		proofs:     proofs,
 		signedTs:   signedTs,
 		txHash:     txHash,
+		venue:      venue,
 		epochs:     epochs,
 		quorumIds:  quorumIds,
+
+		percentSigned: percentSigned,
+		excluded:      excluded,
+		signerBitmaps: signerBitmaps,
+		numSigners:    numSigners,
+		attesters:     attesters,
+		signatures:    signatures,
+
+		referenceBlocks: referenceBlocks,
+	}
+	if txHash != nil {
+		for idx := range batch {
+			b.EncodingStreamer.journalConfirmation(ts[idx], *txHash, batchInfo.confirmationInfos(idx, *txHash, 0))
+		}
 	}
+	b.confirmer.ConfirmChan <- batchInfo
 
 	return nil
 }
@@ -0,0 +1,228 @@
+package batcher
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/batcher/dispatcher"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// The benchmarks run against in-memory stores and dispatch targets, the chain writes
+// delayed by benchChainLatency, so that their results compare across commits with
+// benchstat:
+//
+//	make bench BENCHOUT=old.txt
+//	make bench BENCHOUT=new.txt
+//	benchstat old.txt new.txt
+
+// benchChainLatency is the latency injected into the chain writes of the benchmarks
+const benchChainLatency = time.Millisecond
+
+var (
+	benchBlobCounts     = []int{10, 100, 1000}
+	benchOperatorCounts = []int{10, 100, 1000}
+	// benchSignedBlobCounts are fewer than benchBlobCounts, each signature of each
+	// operator being verified with a pairing
+	benchSignedBlobCounts = []int{1, 8}
+)
+
+// benchSlices is the number of slices of the benchmark blobs, one per operator at most
+const benchSlices = 1024
+
+// latencyDispatcher delays the chain writes of a dispatcher
+type latencyDispatcher struct {
+	disperser.Dispatcher
+	latency time.Duration
+}
+
+func (d *latencyDispatcher) DisperseBatch(ctx context.Context, batchHeaderHash [32]byte, batchHeader *core.BatchHeader, blobCommitments []*core.BlobCommitments, blobHeaders []*core.BlobHeader) (eth_common.Hash, error) {
+	time.Sleep(d.latency)
+	return d.Dispatcher.DisperseBatch(ctx, batchHeaderHash, batchHeader, blobCommitments, blobHeaders)
+}
+
+// benchStreamer returns a streamer holding the encoding results of count stored blobs. The
+// blobs are generated from a fixed seed.
+func benchStreamer(b *testing.B, count int) (*EncodingStreamer, disperser.BlobStore) {
+	ctx := context.Background()
+	logger := mock.NewLogger(false)
+	store := memorydb.NewBlobStore(core.MaxBlobSize*uint64(2*count), logger)
+	streamer, err := NewEncodingStreamer(StreamerConfig{HashSuite: core.Keccak256Suite, EncodingQueueLimit: count}, store, nil, NewEncodedSizeNotifier(make(chan struct{}, 1), 0), nil, nil, logger)
+	require.NoError(b, err)
+
+	keyPair, err := core.GenRandomBlsKeys()
+	require.NoError(b, err)
+	slices := make([][]byte, benchSlices)
+	for i := range slices {
+		slices[i] = make([]byte, 1)
+	}
+	random := rand.New(rand.NewSource(int64(count)))
+	for i := 0; i < count; i++ {
+		data := make([]byte, 64)
+		binary.BigEndian.PutUint64(data, uint64(i))
+		key, err := store.StoreBlob(ctx, &core.Blob{Data: data}, uint64(i))
+		require.NoError(b, err)
+		metadata, err := store.GetBlobMetadata(ctx, key)
+		require.NoError(b, err)
+		storageRoot := make([]byte, 32)
+		random.Read(storageRoot)
+
+		streamer.EncodedBlobstore.PutEncodingRequest(key)
+		require.NoError(b, streamer.EncodedBlobstore.PutEncodingResult(&EncodingResult{
+			BlobMetadata: metadata,
+			BlobCommitments: &core.BlobCommitments{
+				ErasureCommitment: keyPair.GetPubKeyG1(),
+				StorageRoot:       storageRoot,
+				EncodedSlice:      slices,
+			},
+		}))
+	}
+	return streamer, store
+}
+
+func BenchmarkCreateBatch(b *testing.B) {
+	for _, blobs := range benchBlobCounts {
+		b.Run(fmt.Sprintf("blobs=%d", blobs), func(b *testing.B) {
+			streamer, _ := benchStreamer(b, blobs)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				batch, ts, err := streamer.CreateBatch()
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.StopTimer()
+				require.Len(b, batch.BlobMetadata, blobs)
+				streamer.RemoveBatchingStatus(ts)
+				b.StartTimer()
+			}
+		})
+	}
+}
+
+func BenchmarkBatchInclusionProofs(b *testing.B) {
+	for _, blobs := range benchBlobCounts {
+		b.Run(fmt.Sprintf("blobs=%d", blobs), func(b *testing.B) {
+			streamer, _ := benchStreamer(b, blobs)
+			batch, _, err := streamer.CreateBatch()
+			require.NoError(b, err)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := core.BatchInclusionProofs(batch.BlobHeaders, core.Keccak256Suite); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkHandleSingleBatch(b *testing.B) {
+	for _, blobs := range benchBlobCounts {
+		b.Run(fmt.Sprintf("blobs=%d", blobs), func(b *testing.B) {
+			logger := mock.NewLogger(false)
+			streamer, store := benchStreamer(b, blobs)
+			batcher := &Batcher{
+				Queue:            store,
+				Dispatcher:       &latencyDispatcher{Dispatcher: dispatcher.NewMemoryTarget(1, 0), latency: benchChainLatency},
+				EncodingStreamer: streamer,
+				Metrics:          NewMetrics("9100", logger),
+				sliceSigner:      &SliceSigner{SignerChan: make(chan *SignInfo, 1)},
+				logger:           logger,
+			}
+			ctx := context.Background()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ts, err := batcher.HandleSingleBatch(ctx)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.StopTimer()
+				<-batcher.sliceSigner.SignerChan
+				streamer.RemoveBatchingStatus(ts)
+				b.StartTimer()
+			}
+		})
+	}
+}
+
+func BenchmarkAggregateSignatures(b *testing.B) {
+	keyPairs := make([]*core.KeyPair, benchOperatorCounts[len(benchOperatorCounts)-1])
+	for i := range keyPairs {
+		var err error
+		keyPairs[i], err = core.GenRandomBlsKeys()
+		require.NoError(b, err)
+	}
+	epoch, quorumId := big.NewInt(1), big.NewInt(0)
+
+	for _, operators := range benchOperatorCounts {
+		for _, blobs := range benchSignedBlobCounts {
+			b.Run(fmt.Sprintf("operators=%d/blobs=%d", operators, blobs), func(b *testing.B) {
+				logger := mock.NewLogger(false)
+				streamer, _ := benchStreamer(b, blobs)
+				batch, ts, err := streamer.CreateBatch()
+				require.NoError(b, err)
+
+				messages := make([][32]byte, blobs)
+				newBlobs := make([]int, blobs)
+				for i, blob := range batch.EncodedBlobs {
+					var dataRoot [32]byte
+					copy(dataRoot[:], blob.StorageRoot)
+					messages[i], err = core.SignatureMessage(dataRoot, epoch, quorumId, blob.ErasureCommitment)
+					require.NoError(b, err)
+					newBlobs[i] = i
+				}
+				// every operator signs the slices of its own index
+				signers := make(map[eth_common.Address]*SignerState, operators)
+				results := make([]SignRequestResultOrStatus, operators)
+				for i := 0; i < operators; i++ {
+					address := eth_common.BigToAddress(big.NewInt(int64(i + 1)))
+					sliceIndexes := make([]int, 0)
+					for slice := i; slice < benchSlices; slice += operators {
+						sliceIndexes = append(sliceIndexes, slice)
+					}
+					signers[address] = &SignerState{
+						SignerInfo:   &SignerInfo{Signer: address, PkG2: keyPairs[i].GetPubKeyG2()},
+						index:        i,
+						sliceIndexes: sliceIndexes,
+					}
+					signatures := make([]*core.Signature, blobs)
+					for blob, message := range messages {
+						signatures[blob] = keyPairs[i].SignMessage(message)
+					}
+					results[i] = SignRequestResultOrStatus{SignRequestResult: SignRequestResult{signatures: signatures, signer: address}}
+				}
+
+				signer := &SliceSigner{
+					SignatureSizeNotifier: NewSignatureSizeNotifier(make(chan struct{}, 1), 0),
+					pendingSubmissions:    make(map[uint64]*BatchCommitRootSubmission),
+					metrics:               NewMetrics("9100", logger),
+					logger:                logger,
+					signerCache:           newSignerCache(),
+				}
+				signInfo := &SignInfo{batch: batch, ts: ts, epoch: epoch, quorumId: quorumId, signers: signers, newBlobs: newBlobs}
+				update := make(chan SignRequestResultOrStatus, operators)
+				ctx := context.Background()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					for _, result := range results {
+						update <- result
+					}
+					if err := signer.aggregateSignature(ctx, ctx, signInfo, update); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.StopTimer()
+				require.Len(b, signer.pendingSubmissions[ts].submissions, blobs)
+			})
+		}
+	}
+}
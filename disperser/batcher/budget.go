@@ -0,0 +1,212 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// BudgetConfig bounds the time and the transaction fees spent on each batch, from its
+// creation to its confirmation. Every stage consumes from the budget of the batch and the
+// pipeline trades quality for what is left: the signatures of slow operators and quorums
+// are given up once the time runs out, signing isn't retried past it, and the pending
+// confirmation of a batch short of time is replaced with bumped fees while the fee budget
+// allows.
+type BudgetConfig struct {
+	// MaxLatency is the time a batch may take from its creation to its confirmation, no
+	// bound if 0
+	MaxLatency time.Duration
+	// MaxFeeGwei is the fee in gwei the transactions of a batch may cost, no bound if 0.
+	// Transactions confirming several batches are shared evenly between them.
+	MaxFeeGwei uint64
+	// BumpBelow is the fraction of the latency budget left below which the pending
+	// confirmation of a batch is replaced with bumped fees, never if 0
+	BumpBelow float64
+}
+
+func (c BudgetConfig) Enabled() bool {
+	return c.MaxLatency > 0 || c.boundsFee()
+}
+
+func (c BudgetConfig) boundsFee() bool {
+	return c.MaxFeeGwei > 0
+}
+
+// maxFee returns the fee budget in wei
+func (c BudgetConfig) maxFee() *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(c.MaxFeeGwei), big.NewInt(params.GWei))
+}
+
+func (c BudgetConfig) validate() error {
+	if c.MaxLatency < 0 {
+		return errors.New("the batch latency budget must not be negative")
+	}
+	if c.BumpBelow < 0 || c.BumpBelow >= 1 {
+		return errors.New("the fraction of the latency budget to bump fees below must be in [0, 1)")
+	}
+	if c.BumpBelow > 0 && c.MaxLatency == 0 {
+		return errors.New("bumping fees requires a batch latency budget")
+	}
+	return nil
+}
+
+// Budget decisions, as reported by the metrics
+const (
+	// BudgetDispatchCut is a dispatch to the operators cut short by the latency budget
+	BudgetDispatchCut = "dispatch_cut"
+	// BudgetSigningAbandoned is a batch whose signing wasn't retried for lack of time
+	BudgetSigningAbandoned = "signing_abandoned"
+	// BudgetFeeBump is a confirmation replaced with bumped fees for lack of time
+	BudgetFeeBump = "fee_bump"
+	// BudgetFeeBumpSkipped is a fee bump skipped as the fee budget ran out
+	BudgetFeeBumpSkipped = "fee_bump_skipped"
+)
+
+// batchBudget is the budget of a batch, nil if batches have none
+type batchBudget struct {
+	config  BudgetConfig
+	created time.Time
+	now     func() time.Time
+
+	mu  sync.Mutex
+	fee *big.Int
+}
+
+func newBatchBudget(config BudgetConfig, created time.Time) *batchBudget {
+	if !config.Enabled() {
+		return nil
+	}
+	return &batchBudget{config: config, created: created, now: time.Now, fee: new(big.Int)}
+}
+
+// remainingLatency returns the time left to the batch, false if its latency is unbounded
+func (b *batchBudget) remainingLatency() (time.Duration, bool) {
+	if b == nil || b.config.MaxLatency == 0 {
+		return 0, false
+	}
+	return b.config.MaxLatency - b.now().Sub(b.created), true
+}
+
+// latencyExhausted tells whether the batch ran out of time
+func (b *batchBudget) latencyExhausted() bool {
+	remaining, ok := b.remainingLatency()
+	return ok && remaining <= 0
+}
+
+// consumeFee charges the batch with the fee of a transaction
+func (b *batchBudget) consumeFee(fee *big.Int) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fee.Add(b.fee, fee)
+}
+
+// feeExhausted tells whether the transactions of the batch cost its whole fee budget
+func (b *batchBudget) feeExhausted() bool {
+	if b == nil || !b.config.boundsFee() {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.fee.Cmp(b.config.maxFee()) >= 0
+}
+
+// remainingFee returns the fee in wei the transactions of the batch may still cost, nil if
+// its fees are unbounded
+func (b *batchBudget) remainingFee() *big.Int {
+	if b == nil || !b.config.boundsFee() {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	remaining := new(big.Int).Sub(b.config.maxFee(), b.fee)
+	if remaining.Sign() < 0 {
+		return remaining.SetInt64(0)
+	}
+	return remaining
+}
+
+// bumpDue tells whether the pending confirmation of the batch is short of time, which is
+// asked again on every wait for the confirmation so that it keeps being bumped
+func (b *batchBudget) bumpDue() bool {
+	remaining, ok := b.remainingLatency()
+	return ok && b.config.BumpBelow > 0 && remaining < time.Duration(b.config.BumpBelow*float64(b.config.MaxLatency))
+}
+
+// used returns the fractions of the latency and fee budgets the batch used, negative for
+// those unbounded
+func (b *batchBudget) used() (float64, float64) {
+	latency, fee := -1.0, -1.0
+	if b.config.MaxLatency > 0 {
+		latency = float64(b.now().Sub(b.created)) / float64(b.config.MaxLatency)
+	}
+	if b.config.boundsFee() {
+		b.mu.Lock()
+		fee, _ = new(big.Rat).SetFrac(b.fee, b.config.maxFee()).Float64()
+		b.mu.Unlock()
+	}
+	return latency, fee
+}
+
+// feeReporter is implemented by the Confirmers that can tell the fee a transaction paid
+type feeReporter interface {
+	// TxFee returns the fee in wei paid by an included transaction
+	TxFee(ctx context.Context, txHash eth_common.Hash) (*big.Int, error)
+}
+
+// feeBumper is implemented by the Confirmers that can replace a pending transaction with
+// higher fees
+type feeBumper interface {
+	// BumpFees replaces a pending transaction with one costing at most maxFee wei, unbounded
+	// if nil
+	BumpFees(ctx context.Context, txHash eth_common.Hash, maxFee *big.Int) error
+}
+
+// bumpFeeCap returns the fee in wei the replacement of a transaction of the batches may
+// cost without exceeding any of their budgets, its fee being shared evenly between them,
+// nil if none of them bounds fees
+func bumpFeeCap(budgets []*batchBudget) *big.Int {
+	var lowest *big.Int
+	for _, budget := range budgets {
+		if remaining := budget.remainingFee(); remaining != nil && (lowest == nil || remaining.Cmp(lowest) < 0) {
+			lowest = remaining
+		}
+	}
+	if lowest == nil {
+		return nil
+	}
+	return lowest.Mul(lowest, big.NewInt(int64(len(budgets))))
+}
+
+// consumeTxFee charges the batches a transaction included with an even share of its fee,
+// if one of them has a fee budget and the confirmer reports fees
+func consumeTxFee(ctx context.Context, confirmer Confirmer, txHash eth_common.Hash, budgets ...*batchBudget) error {
+	charged := make([]*batchBudget, 0, len(budgets))
+	boundsFee := false
+	for _, budget := range budgets {
+		if budget != nil {
+			charged = append(charged, budget)
+			boundsFee = boundsFee || budget.config.boundsFee()
+		}
+	}
+	reporter, ok := confirmer.(feeReporter)
+	if !boundsFee || !ok {
+		return nil
+	}
+	fee, err := reporter.TxFee(ctx, txHash)
+	if err != nil {
+		return err
+	}
+	share := new(big.Int).Div(fee, big.NewInt(int64(len(charged))))
+	for _, budget := range charged {
+		budget.consumeFee(share)
+	}
+	return nil
+}
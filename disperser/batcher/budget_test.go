@@ -0,0 +1,73 @@
+package batcher
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/disperser/contract"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// feeConfirmer reports the same fee for every transaction
+type feeConfirmer struct {
+	fee *big.Int
+}
+
+func (c *feeConfirmer) WaitForUpload(ctx context.Context, txHash eth_common.Hash) ([]*contract.DataUploadEvent, uint64, error) {
+	return nil, 0, nil
+}
+
+func (c *feeConfirmer) WaitForConfirmation(ctx context.Context, txHash eth_common.Hash) (uint64, error) {
+	return 0, nil
+}
+
+func (c *feeConfirmer) TxFee(ctx context.Context, txHash eth_common.Hash) (*big.Int, error) {
+	return c.fee, nil
+}
+
+func TestBatchBudget(t *testing.T) {
+	assert.Nil(t, newBatchBudget(BudgetConfig{}, time.Now()))
+	assert.Error(t, BudgetConfig{BumpBelow: 0.5}.validate())
+
+	config := BudgetConfig{MaxLatency: time.Minute, MaxFeeGwei: 10, BumpBelow: 0.25}
+	require.NoError(t, config.validate())
+	now := time.Unix(1000, 0)
+	budgets := []*batchBudget{newBatchBudget(config, now), newBatchBudget(config, now)}
+	for _, budget := range budgets {
+		budget.now = func() time.Time { return now }
+	}
+
+	now = now.Add(40 * time.Second)
+	assert.False(t, budgets[0].bumpDue())
+	now = now.Add(10 * time.Second)
+	assert.True(t, budgets[0].bumpDue())
+	// still short of time on the next wait for the confirmation
+	assert.True(t, budgets[0].bumpDue())
+	assert.Equal(t, big.NewInt(20*params.GWei), bumpFeeCap(budgets))
+	assert.Nil(t, bumpFeeCap([]*batchBudget{nil}))
+	assert.False(t, budgets[0].latencyExhausted())
+
+	// the fee of a transaction confirming both batches is shared between them
+	confirmer := &feeConfirmer{fee: big.NewInt(12 * params.GWei)}
+	require.NoError(t, consumeTxFee(context.Background(), confirmer, eth_common.Hash{}, budgets...))
+	assert.False(t, budgets[0].feeExhausted())
+	assert.Equal(t, big.NewInt(4*params.GWei), budgets[0].remainingFee())
+	// the second batch ran out of fees, so a transaction shared with it can't be bumped
+	require.NoError(t, consumeTxFee(context.Background(), confirmer, eth_common.Hash{}, budgets[1:]...))
+	assert.Zero(t, budgets[1].remainingFee().Sign())
+	assert.Zero(t, bumpFeeCap(budgets).Sign())
+	assert.Equal(t, big.NewInt(4*params.GWei), bumpFeeCap(budgets[:1]))
+	require.NoError(t, consumeTxFee(context.Background(), confirmer, eth_common.Hash{}, budgets[0]))
+	assert.True(t, budgets[0].feeExhausted())
+
+	now = now.Add(10 * time.Second)
+	assert.True(t, budgets[1].latencyExhausted())
+	latency, fee := budgets[1].used()
+	assert.InDelta(t, 1, latency, 0.001)
+	assert.InDelta(t, 1.8, fee, 0.001)
+}
@@ -0,0 +1,132 @@
+package batcher
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/0glabs/0g-da-client/common/geth"
+	"github.com/0glabs/0g-da-client/disperser/contract"
+	eth_common "github.com/ethereum/go-ethereum/common"
+)
+
+// Confirmer tracks the inclusion of the transactions returned by a disperser.Dispatcher on
+// its dispatch target. Together they are what an alternative dispatch target implements;
+// the conformance package checks a pair against these contracts.
+//
+// Both methods block until the transaction is included, the context is done or the
+// implementation gives up, and must return an error rather than a zero block number if the
+// transaction is unknown or failed. The batcher calls them again with the same transaction
+// to follow reorgs until the reported block is final according to the Finalizer, so they
+// must report the current inclusion rather than a cached one.
+type Confirmer interface {
+	// WaitForUpload waits for a transaction returned by DisperseBatch and returns the data
+	// uploads it recorded, one per blob in the order of the batch, with the number of the
+	// block including it.
+	WaitForUpload(ctx context.Context, txHash eth_common.Hash) ([]*contract.DataUploadEvent, uint64, error)
+	// WaitForConfirmation waits for a transaction returned by SubmitAggregateSignatures and
+	// returns the number of the block including it.
+	WaitForConfirmation(ctx context.Context, txHash eth_common.Hash) (uint64, error)
+}
+
+// finalityReader is implemented by the Confirmers of the dispatch targets that aren't the
+// chain the finalizer reads, so that it tells when their confirmations are final instead
+type finalityReader interface {
+	// FinalizedBlock returns the number of the latest final block of the target
+	FinalizedBlock(ctx context.Context) (uint64, error)
+	// ConfirmationBlock returns the number of the block including a transaction returned by
+	// SubmitAggregateSignatures, and ethereum.NotFound if the target dropped it
+	ConfirmationBlock(ctx context.Context, txHash eth_common.Hash) (uint64, error)
+}
+
+// inclusionResolver is implemented by the Confirmers whose transactions may be replaced,
+// such as with higher fees, so that the transaction included has another hash
+type inclusionResolver interface {
+	// Inclusion returns the hash of the transaction included in place of txHash and that
+	// of the block including it, once WaitForConfirmation returned
+	Inclusion(ctx context.Context, txHash eth_common.Hash) (eth_common.Hash, eth_common.Hash, error)
+}
+
+// inclusion returns the hash of the transaction the confirmer included in place of txHash
+// and that of its block, zero if the confirmer doesn't report it
+func inclusion(ctx context.Context, confirmer Confirmer, txHash eth_common.Hash) (eth_common.Hash, eth_common.Hash, error) {
+	if resolver, ok := confirmer.(inclusionResolver); ok {
+		return resolver.Inclusion(ctx, txHash)
+	}
+	return txHash, eth_common.Hash{}, nil
+}
+
+// chainConfirmer is the Confirmer of the DA entrance contract
+type chainConfirmer struct {
+	daContract  *contract.DAContract
+	retryOption contract.RetryOption
+}
+
+var _ Confirmer = (*chainConfirmer)(nil)
+var _ feeReporter = (*chainConfirmer)(nil)
+var _ feeBumper = (*chainConfirmer)(nil)
+var _ inclusionResolver = (*chainConfirmer)(nil)
+
+func NewChainConfirmer(daContract *contract.DAContract, ethConfig geth.EthClientConfig) Confirmer {
+	return &chainConfirmer{
+		daContract: daContract,
+		retryOption: contract.RetryOption{
+			Rounds:   ethConfig.ReceiptPollingRounds,
+			Interval: ethConfig.ReceiptPollingInterval,
+		},
+	}
+}
+
+func (c *chainConfirmer) WaitForUpload(ctx context.Context, txHash eth_common.Hash) ([]*contract.DataUploadEvent, uint64, error) {
+	receipt, err := c.daContract.WaitForReceiptContext(ctx, txHash, true, c.retryOption)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	uploadEventHash := eth_common.HexToHash(contract.DataUploadEventHash)
+	var uploads []*contract.DataUploadEvent
+	for _, v := range receipt.Logs {
+		if v.Topics[0] != uploadEventHash {
+			continue
+		}
+		upload, err := c.daContract.ParseDataUpload(*contract.ConvertToGethLog(v))
+		if err != nil {
+			return nil, 0, err
+		}
+		uploads = append(uploads, &contract.DataUploadEvent{
+			DataRoot: upload.DataRoot,
+			Epoch:    upload.Epoch,
+			QuorumId: upload.QuorumId,
+		})
+	}
+	return uploads, receipt.BlockNumber, nil
+}
+
+func (c *chainConfirmer) WaitForConfirmation(ctx context.Context, txHash eth_common.Hash) (uint64, error) {
+	receipt, err := c.daContract.WaitForReceiptContext(ctx, txHash, true, c.retryOption)
+	if err != nil {
+		return 0, err
+	}
+	return receipt.BlockNumber, nil
+}
+
+func (c *chainConfirmer) TxFee(ctx context.Context, txHash eth_common.Hash) (*big.Int, error) {
+	receipt, err := c.daContract.WaitForReceiptContext(ctx, txHash, false, c.retryOption)
+	if err != nil {
+		return nil, err
+	}
+	return c.daContract.ReceiptFee(ctx, receipt)
+}
+
+// Inclusion reads the receipt of txHash, that of the replacement included if the fee
+// manager replaced it
+func (c *chainConfirmer) Inclusion(ctx context.Context, txHash eth_common.Hash) (eth_common.Hash, eth_common.Hash, error) {
+	receipt, err := c.daContract.WaitForReceiptContext(ctx, txHash, false, c.retryOption)
+	if err != nil {
+		return eth_common.Hash{}, eth_common.Hash{}, err
+	}
+	return receipt.TransactionHash, receipt.BlockHash, nil
+}
+
+func (c *chainConfirmer) BumpFees(ctx context.Context, txHash eth_common.Hash, maxFee *big.Int) error {
+	return c.daContract.BumpFees(ctx, txHash, maxFee)
+}
@@ -9,6 +9,8 @@ import (
 
 	"github.com/0glabs/0g-da-client/common"
 	"github.com/0glabs/0g-da-client/common/geth"
+	"github.com/0glabs/0g-da-client/common/tracing"
+	"github.com/0glabs/0g-da-client/core"
 	"github.com/0glabs/0g-da-client/disperser"
 	"github.com/0glabs/0g-da-client/disperser/contract"
 	"github.com/0glabs/0g-storage-client/common/blockchain"
@@ -23,22 +25,42 @@ const (
 	gcPercentageTime = 0.1
 )
 
-type Confirmer struct {
+// BatchConfirmer marks the blobs of the batches whose aggregate signatures were submitted
+// as confirmed, once the Confirmer reports the submission included.
+type BatchConfirmer struct {
 	mu sync.RWMutex
 
 	Queue            disperser.BlobStore
 	EncodingStreamer *EncodingStreamer
 	SliceSigner      *SliceSigner
 
-	daContract  *contract.DAContract
+	confirmer   Confirmer
 	ConfirmChan chan *BatchInfo
+	// Poster posts the certificates of confirmed blobs to a rollup inbox if set
+	Poster *Poster
+	// StatusPage reports the confirmed batches on a public status page if set
+	StatusPage *StatusPage
+	// Watchdog checks that the signers of the confirmed blobs serve their slices if set
+	Watchdog *Watchdog
+	// Timeouts bounds the receipt waits and the store writes of the confirmation
+	Timeouts TimeoutConfig
+	// InFlight keeps batches whose confirmation has no receipt yet from being submitted again
+	InFlight InFlightConfig
+	// Tracer continues the traces of the blobs dispersed with one, set by the batcher
+	Tracer *tracing.Tracer
+	// Fallback is the confirmation fallback of the batcher, set by the batcher
+	Fallback *ConfirmationFallback
+	// spend holds the aggregate signatures back while the confirmations spend too much
+	spend *spendLimit
+	// events records the lifecycle of the blobs, shared with the batcher
+	events *disperser.BlobEventLog
 
 	pendingBatches       []*BatchInfo
 	MaxNumRetriesPerBlob uint
 
 	routines uint
-
-	retryOption contract.RetryOption
+	// writeLatency is the moving average of the time submissions take to be included
+	writeLatency time.Duration
 
 	logger  common.Logger
 	Metrics *Metrics
@@ -51,32 +73,63 @@ type BatchInfo struct {
 	proofs     [][]*merkletree.Proof
 	signedTs   uint64
 	txHash     *eth_common.Hash
-	epochs     []*big.Int
-	quorumIds  []*big.Int
+	// venue is the venue the aggregate signatures were submitted to, nil if there is no
+	// fallback one
+	venue     *disperser.ConfirmationVenue
+	epochs    []*big.Int
+	quorumIds []*big.Int
+
+	percentSigned []map[int]uint8
+	excluded      []map[int]struct{}
+	signerBitmaps []core.SignerBitmap
+	numSigners    []int
+	// attesters are the signers of each blob of the batches, by index in the batch
+	attesters []map[int][]*SignerState
+	// signatures are the aggregate signatures of each blob of the batches, by index in the batch
+	signatures []map[int]*core.CommitRootSubmission
+	// referenceBlocks are the blocks the epochs of the batches were set at
+	referenceBlocks []uint32
+	// waitingSince is when the receipt of the confirmation was first waited for
+	waitingSince time.Time
+	// blockHash is the hash of the block the confirmation was included in, zero if unknown
+	blockHash eth_common.Hash
 }
 
-func NewConfirmer(ethConfig geth.EthClientConfig, batcherConfig Config, queue disperser.BlobStore, daContract *contract.DAContract, logger common.Logger, metrics *Metrics) (*Confirmer, error) {
+func NewBatchConfirmer(ethConfig geth.EthClientConfig, batcherConfig Config, queue disperser.BlobStore, confirmer Confirmer, logger common.Logger, metrics *Metrics) (*BatchConfirmer, error) {
 	if ethConfig.TxGasLimit > 0 {
 		blockchain.CustomGasLimit = uint64(ethConfig.TxGasLimit)
 	}
+	spend, err := newSpendLimit(batcherConfig.SpendLimit, logger)
+	if err != nil {
+		return nil, err
+	}
 
-	return &Confirmer{
+	return &BatchConfirmer{
 		Queue:                queue,
-		daContract:           daContract,
+		confirmer:            confirmer,
 		ConfirmChan:          make(chan *BatchInfo),
 		pendingBatches:       make([]*BatchInfo, 0),
 		routines:             batcherConfig.ConfirmerNum,
 		MaxNumRetriesPerBlob: batcherConfig.MaxNumRetriesPerBlob,
-		retryOption: contract.RetryOption{
-			Rounds:   ethConfig.ReceiptPollingRounds,
-			Interval: ethConfig.ReceiptPollingInterval,
-		},
-		logger:  logger,
-		Metrics: metrics,
+		InFlight:             batcherConfig.InFlight,
+		spend:                spend,
+		logger:               logger,
+		Metrics:              metrics,
 	}, nil
 }
 
-func (c *Confirmer) Start(ctx context.Context) {
+func (c *BatchConfirmer) Start(ctx context.Context) {
+	if c.Poster != nil {
+		c.Poster.spends = c
+		c.Poster.Start(ctx)
+	}
+	if c.StatusPage != nil {
+		c.StatusPage.Start(ctx, c)
+	}
+	if c.Watchdog != nil {
+		c.Watchdog.Start(ctx)
+	}
+
 	go func() {
 		for {
 			select {
@@ -110,7 +163,7 @@ func (c *Confirmer) Start(ctx context.Context) {
 	}
 }
 
-func (c *Confirmer) putPendingBatches(info *BatchInfo) {
+func (c *BatchConfirmer) putPendingBatches(info *BatchInfo) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -118,7 +171,7 @@ func (c *Confirmer) putPendingBatches(info *BatchInfo) {
 	c.logger.Info(`[confirmer] received pending batch`, "queue size", len(c.pendingBatches))
 }
 
-func (c *Confirmer) getPendingBatch() *BatchInfo {
+func (c *BatchConfirmer) getPendingBatch() *BatchInfo {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -131,10 +184,37 @@ func (c *Confirmer) getPendingBatch() *BatchInfo {
 	return info
 }
 
-func (c *Confirmer) handleFailure(ctx context.Context, blobMetadatas []*disperser.BlobMetadata, reason FailReason) error {
+// PendingBatches returns the number of submitted batches waiting for confirmation
+func (c *BatchConfirmer) PendingBatches() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.pendingBatches)
+}
+
+// WriteLatency returns the moving average of the time submissions take to be included, 0
+// before the first one
+func (c *BatchConfirmer) WriteLatency() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.writeLatency
+}
+
+func (c *BatchConfirmer) observeWriteLatency(latency time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.writeLatency == 0 {
+		c.writeLatency = latency
+	} else {
+		c.writeLatency = (4*c.writeLatency + latency) / 5
+	}
+}
+
+func (c *BatchConfirmer) handleFailure(ctx context.Context, blobMetadatas []*disperser.BlobMetadata, reason FailReason) error {
 	var result *multierror.Error
 	for _, metadata := range blobMetadatas {
-		err := c.Queue.HandleBlobFailure(ctx, metadata, c.MaxNumRetriesPerBlob)
+		err := c.Timeouts.Do(ctx, CallStoreWrite, func(ctx context.Context) error {
+			return c.EncodingStreamer.handleBatchFailure(ctx, c.Queue, metadata, reason, c.MaxNumRetriesPerBlob)
+		})
 		if err != nil {
 			c.logger.Error("[confirmer] HandleSingleBatch: error handling blob failure", "err", err)
 			// Append the error
@@ -142,54 +222,119 @@ func (c *Confirmer) handleFailure(ctx context.Context, blobMetadatas []*disperse
 		}
 		c.Metrics.UpdateCompletedBlob(int(metadata.RequestMetadata.BlobSize), disperser.Failed)
 	}
+	c.events.RecordAll(blobMetadatas, disperser.BlobFailed, string(reason))
 	c.Metrics.UpdateBatchError(reason, len(blobMetadatas))
 
 	// Return the error(s)
 	return result.ErrorOrNil()
 }
 
-func (c *Confirmer) waitForReceipt(txHash eth_common.Hash) (uint32, error) {
+// confirmedByAnotherBatch returns whether the blob was confirmed by another batch meanwhile,
+// which happens when the transaction of a batch lands after its blobs were retried. The blob
+// keeps that confirmation rather than being confirmed twice.
+func (c *BatchConfirmer) confirmedByAnotherBatch(ctx context.Context, metadata *disperser.BlobMetadata, confirmationInfo *disperser.ConfirmationInfo) bool {
+	var current *disperser.BlobMetadata
+	err := c.Timeouts.Do(ctx, CallStoreWrite, func(ctx context.Context) error {
+		var err error
+		current, err = c.Queue.GetBlobMetadata(ctx, metadata.GetBlobKey())
+		return err
+	})
+	if err != nil {
+		c.logger.Warn("[confirmer] failed to read the blob before confirming it", "blob key", metadata.GetBlobKey(), "err", err)
+		return false
+	}
+	if !confirmedElsewhere(current, confirmationInfo) {
+		return false
+	}
+	c.logger.Warn("[confirmer] blob already confirmed by another batch, skipping it", "blob key", metadata.GetBlobKey(),
+		"batch header hash", eth_common.Bytes2Hex(confirmationInfo.BatchHeaderHash[:]),
+		"confirmed batch header hash", eth_common.Bytes2Hex(current.ConfirmationInfo.BatchHeaderHash[:]))
+	return true
+}
+
+// confirmerOf returns the Confirmer of the venue the aggregate signatures were submitted to
+func (c *BatchConfirmer) confirmerOf(venue *disperser.ConfirmationVenue) Confirmer {
+	return c.Fallback.confirmerOf(venue, c.confirmer)
+}
+
+func (c *BatchConfirmer) waitForReceipt(ctx context.Context, confirmer Confirmer, txHash eth_common.Hash) (uint32, error) {
 	if txHash.Cmp(eth_common.Hash{}) == 0 {
 		return 0, errors.New("empty transaction hash")
 	}
 	c.logger.Info("[confirmer] Waiting signing batch be confirmed", "transaction hash", txHash)
 	// data is not duplicate, there is a new transaction
-	receipt, err := c.daContract.WaitForReceipt(txHash, true, c.retryOption)
+	var blockNumber uint64
+	start := time.Now()
+	err := c.Timeouts.Do(ctx, CallReceiptWait, func(ctx context.Context) error {
+		var err error
+		blockNumber, err = confirmer.WaitForConfirmation(ctx, txHash)
+		return err
+	})
 	if err != nil {
 		return 0, err
 	}
+	c.observeWriteLatency(time.Since(start))
 
-	blockNumber := receipt.BlockNumber
 	c.logger.Debug("[confirmer] waiting signed tx to be confirmed", "receipt block", blockNumber)
 
 	return uint32(blockNumber), nil
 }
 
-func (c *Confirmer) ConfirmBatch(ctx context.Context, batchInfo *BatchInfo) error {
+func (c *BatchConfirmer) ConfirmBatch(ctx context.Context, batchInfo *BatchInfo) error {
 	blockNumber := uint32(0)
 	txHash := eth_common.MaxHash
+	// the confirmation spans start with the wait for the receipt, retried waits included
+	confirmStart := time.Now()
 	if batchInfo.txHash != nil {
 		txHash = *batchInfo.txHash
+		if batchInfo.waitingSince.IsZero() {
+			batchInfo.waitingSince = time.Now()
+		}
+		confirmer := c.confirmerOf(batchInfo.venue)
+		c.bumpShortOfTime(ctx, confirmer, batchInfo, txHash)
 		var err error
-		blockNumber, err = c.waitForReceipt(txHash)
+		blockNumber, err = c.waitForReceipt(ctx, confirmer, txHash)
+		if err != nil && c.InFlight.inFlight(err, batchInfo.waitingSince, time.Now()) {
+			// submitting the signatures again could confirm the blobs twice if the transaction
+			// lands late, so they stay out of new submissions until it resolves
+			c.logger.Warn("[confirmer] confirmation tx has no receipt yet, waiting again", "transaction hash", txHash, "err", err)
+			c.putPendingBatches(batchInfo)
+			return nil
+		}
+		confirmStart = batchInfo.waitingSince
 		if err != nil {
 			// batch is not confirmed
 			for idx := range batchInfo.ts {
+				endSpans(traceBlobs(c.Tracer, batchInfo.batch[idx].BlobMetadata, "confirm", confirmStart, tracing.Int("batch.id", int64(batchInfo.ts[idx])), tracing.String("tx.hash", txHash.Hex())), err)
 				_ = c.handleFailure(ctx, batchInfo.batch[idx].BlobMetadata, FailConfirmBatch)
 				// c.EncodingStreamer.RemoveBatchingStatus(ts)
+				c.EncodingStreamer.forgetBatch(batchInfo.ts[idx])
 			}
 
 			c.SliceSigner.RemoveBatchingStatus(batchInfo.signedTs)
 			return err
 		}
+		// the finalizer looks the receipt up by the hash of the transaction included, which
+		// differs from the one submitted if the fees were bumped
+		included, blockHash, err := inclusion(ctx, confirmer, txHash)
+		batchInfo.blockHash = blockHash
+		if err != nil {
+			c.logger.Warn("[confirmer] failed to read the inclusion of the confirmation tx", "transaction hash", txHash, "err", err)
+		} else if included != txHash {
+			c.logger.Info("[confirmer] confirmation tx was replaced", "transaction hash", txHash, "included", included)
+			txHash = included
+			batchInfo.txHash = &txHash
+			for idx := range batchInfo.ts {
+				c.EncodingStreamer.journalConfirmation(batchInfo.ts[idx], txHash, batchInfo.confirmationInfos(idx, txHash, 0))
+			}
+		}
+		if err := consumeTxFee(ctx, confirmer, txHash, batchInfo.budgets()...); err != nil {
+			c.logger.Warn("[confirmer] failed to get the fee of the confirmation tx", "transaction hash", txHash, "err", err)
+		}
+		c.recordSpend(ctx, confirmer, txHash, len(batchInfo.batch))
 	}
 
 	for idx, batch := range batchInfo.batch {
-		proofs := batchInfo.proofs[idx]
-
-		epoch := batchInfo.epochs[idx].Uint64()
-		quorumId := batchInfo.quorumIds[idx].Uint64()
-
 		batchID := batchInfo.ts[idx]
 		c.logger.Info("[confirmer] batch confirmed.", "batch ID", batchID, "transaction hash", batch.TxHash)
 		// Mark the blobs as complete
@@ -197,30 +342,43 @@ func (c *Confirmer) ConfirmBatch(ctx context.Context, batchInfo *BatchInfo) erro
 		stageTimer := time.Now()
 		blobsToRetry := make([]*disperser.BlobMetadata, 0)
 		var updateConfirmationInfoErr error
+		confirmedBlobs := 0
+		confirmedIndexes := make([]int, 0, len(batch.BlobMetadata))
+		var confirmationLatency time.Duration
 		for blobIndex, metadata := range batch.BlobMetadata {
-			confirmationInfo := &disperser.ConfirmationInfo{
-				BatchHeaderHash:         batchInfo.headerHash[idx],
-				BlobIndex:               uint32(blobIndex),
-				ReferenceBlockNumber:    0,
-				BatchRoot:               batch.BatchHeader.BatchRoot[:],
-				BlobInclusionProof:      serializeProof(proofs[blobIndex]),
-				CommitmentRoot:          batch.BlobHeaders[blobIndex].CommitmentRoot,
-				DataRoot:                batch.EncodedBlobs[blobIndex].StorageRoot,
-				Epoch:                   epoch,
-				QuorumId:                quorumId,
-				Length:                  uint32(batch.BlobHeaders[blobIndex].Length),
-				BatchID:                 uint32(batchID),
-				SubmissionTxnHash:       batch.TxHash,
-				ConfirmationTxnHash:     txHash,
-				ConfirmationBlockNumber: blockNumber,
+			// excluded blobs of a partially confirmed batch were already handed back for retry
+			if _, ok := batchInfo.excluded[idx][blobIndex]; ok {
+				continue
+			}
+			confirmationInfo := batchInfo.confirmationInfo(idx, blobIndex, txHash, blockNumber)
+			if c.confirmedByAnotherBatch(ctx, metadata, confirmationInfo) {
+				continue
 			}
+			confirmedBlobs++
+			span := c.Tracer.StartChildAt(traceContextOf(metadata), "confirm", confirmStart,
+				tracing.String("blob.key", metadata.GetBlobKey().String()),
+				tracing.Int("batch.id", int64(batchID)),
+				tracing.String("tx.hash", txHash.Hex()),
+				tracing.Int("block.number", int64(blockNumber)),
+			)
+
 			c.logger.Trace("[confirmer] confirming blob", "blob key", metadata.GetBlobKey())
-			_, updateConfirmationInfoErr := c.Queue.MarkBlobConfirmed(ctx, metadata, confirmationInfo)
+			updateConfirmationInfoErr := c.Timeouts.Do(ctx, CallStoreWrite, func(ctx context.Context) error {
+				_, err := c.Queue.MarkBlobConfirmed(ctx, metadata, confirmationInfo)
+				return err
+			})
+			tracing.End(span, updateConfirmationInfoErr)
 			if updateConfirmationInfoErr == nil {
 				c.Metrics.UpdateCompletedBlob(int(metadata.RequestMetadata.BlobSize), disperser.Confirmed)
+				c.events.Record(metadata.GetBlobKey(), disperser.BlobConfirmed, txHash.Hex())
+				confirmedIndexes = append(confirmedIndexes, blobIndex)
 				// remove encoded blob from storage so we don't disperse it again
 				c.EncodingStreamer.RemoveEncodedBlob(metadata)
 				c.logger.Trace("[confirmer] blob confirmed", "blob key", metadata.GetBlobKey())
+				confirmationLatency += time.Since(time.Unix(0, int64(metadata.RequestMetadata.RequestedAt)))
+				if c.Poster != nil {
+					c.Poster.Enqueue(confirmationInfo)
+				}
 
 			} else {
 				c.logger.Error("[confirmer] HandleSingleBatch: error updating blob confirmed metadata", "err", updateConfirmationInfoErr)
@@ -232,7 +390,7 @@ func (c *Confirmer) ConfirmBatch(ctx context.Context, batchInfo *BatchInfo) erro
 
 		if len(blobsToRetry) > 0 {
 			_ = c.handleFailure(ctx, blobsToRetry, FailUpdateConfirmationInfo)
-			if len(blobsToRetry) == len(batch.BlobMetadata) {
+			if len(blobsToRetry) == confirmedBlobs {
 				return fmt.Errorf("HandleSingleBatch: failed to update blob confirmed metadata for all blobs in batch: %w", updateConfirmationInfoErr)
 			}
 		}
@@ -244,6 +402,18 @@ func (c *Confirmer) ConfirmBatch(ctx context.Context, batchInfo *BatchInfo) erro
 			batchSize += int64(blobMeta.RequestMetadata.BlobSize)
 		}
 
+		if c.StatusPage != nil && confirmedBlobs > len(blobsToRetry) {
+			c.StatusPage.recordBatch(batchInfo.statusOf(idx, confirmedBlobs-len(blobsToRetry), confirmationLatency))
+		}
+
+		if batch.budget != nil {
+			c.Metrics.ObserveBatchBudget(batch.budget.used())
+		}
+
+		if c.Watchdog != nil {
+			c.Watchdog.watch(batchInfo, idx, confirmedIndexes)
+		}
+
 		c.SliceSigner.RemoveSignedBlob(batchInfo.ts[idx])
 		c.EncodingStreamer.RemoveBatchingStatus(batchInfo.ts[idx])
 		c.Metrics.IncrementBatchCount(batchSize)
@@ -252,3 +422,159 @@ func (c *Confirmer) ConfirmBatch(ctx context.Context, batchInfo *BatchInfo) erro
 	c.SliceSigner.RemoveBatchingStatus(batchInfo.signedTs)
 	return nil
 }
+
+// bumpShortOfTime replaces the pending confirmation of the batches with bumped fees while
+// one of them is short of time, within what is left of their fee budgets
+func (c *BatchConfirmer) bumpShortOfTime(ctx context.Context, confirmer Confirmer, batchInfo *BatchInfo, txHash eth_common.Hash) {
+	bumper, ok := confirmer.(feeBumper)
+	if !ok {
+		return
+	}
+	budgets := batchInfo.budgets()
+	due := false
+	for _, budget := range budgets {
+		due = due || budget.bumpDue()
+	}
+	if !due {
+		return
+	}
+	maxFee := bumpFeeCap(budgets)
+	if maxFee != nil && maxFee.Sign() == 0 {
+		c.Metrics.IncrementBudgetDecision(BudgetFeeBumpSkipped)
+		c.logger.Warn("[confirmer] batch short of time but out of its fee budget, not bumping fees", "transaction hash", txHash)
+		return
+	}
+	err := bumper.BumpFees(ctx, txHash, maxFee)
+	if errors.Is(err, contract.ErrOverFeeBudget) {
+		c.Metrics.IncrementBudgetDecision(BudgetFeeBumpSkipped)
+		c.logger.Warn("[confirmer] batch short of time but bumping fees would exceed its fee budget", "transaction hash", txHash, "max fee", maxFee)
+		return
+	}
+	if err != nil {
+		c.logger.Warn("[confirmer] failed to bump the fees of the confirmation tx", "transaction hash", txHash, "err", err)
+		return
+	}
+	c.Metrics.IncrementBudgetDecision(BudgetFeeBump)
+	c.logger.Info("[confirmer] bumped the fees of the confirmation tx of batches short of time", "transaction hash", txHash)
+}
+
+// budgets returns the budgets of the batches, leaving out the unbounded ones
+func (batchInfo *BatchInfo) budgets() []*batchBudget {
+	budgets := make([]*batchBudget, 0, len(batchInfo.batch))
+	for _, batch := range batchInfo.batch {
+		if batch.budget != nil {
+			budgets = append(budgets, batch.budget)
+		}
+	}
+	return budgets
+}
+
+// confirmationInfo returns the confirmation of a blob of the batch at idx by txHash
+func (batchInfo *BatchInfo) confirmationInfo(idx int, blobIndex int, txHash eth_common.Hash, blockNumber uint32) *disperser.ConfirmationInfo {
+	batch := batchInfo.batch[idx]
+	quorumId := batchInfo.quorumIds[idx].Uint64()
+	confirmationInfo := &disperser.ConfirmationInfo{
+		BatchHeaderHash:         batchInfo.headerHash[idx],
+		BlobIndex:               uint32(blobIndex),
+		BatchRoot:               batch.BatchHeader.BatchRoot[:],
+		BlobInclusionProof:      serializeProof(batchInfo.proofs[idx][blobIndex]),
+		CommitmentRoot:          batch.BlobHeaders[blobIndex].CommitmentRoot,
+		DataRoot:                batch.EncodedBlobs[blobIndex].StorageRoot,
+		Epoch:                   batchInfo.epochs[idx].Uint64(),
+		QuorumId:                quorumId,
+		Length:                  uint32(batch.BlobHeaders[blobIndex].Length),
+		BatchID:                 uint32(batchInfo.ts[idx]),
+		SubmissionTxnHash:       batch.TxHash,
+		ConfirmationTxnHash:     txHash,
+		ConfirmationBlockNumber: blockNumber,
+		ConfirmedAt:             time.Now().Unix(),
+		Venue:                   batchInfo.venue,
+		ConfirmationBlockHash:   batchInfo.blockHash,
+	}
+	if idx < len(batchInfo.referenceBlocks) {
+		confirmationInfo.ReferenceBlockNumber = batchInfo.referenceBlocks[idx]
+	}
+	if idx < len(batchInfo.signerBitmaps) {
+		confirmationInfo.SignerBitmap = batchInfo.signerBitmaps[idx]
+		confirmationInfo.NumSigners = uint32(batchInfo.numSigners[idx])
+	}
+	if idx < len(batchInfo.signatures) {
+		if submission, ok := batchInfo.signatures[idx][blobIndex]; ok && submission.AggSigs != nil && submission.AggPkG2 != nil {
+			confirmationInfo.AggregateSignature = submission.AggSigs.Serialize()
+			confirmationInfo.AggregatePubKey = submission.AggPkG2.Serialize()
+		}
+	}
+	metadata := batch.BlobMetadata[blobIndex]
+	param := core.SecurityParam{
+		QuorumID:           core.QuorumID(quorumId),
+		AdversaryThreshold: defaultAdversaryThreshold,
+		QuorumThreshold:    defaultQuorumThreshold,
+	}
+	if percent, ok := batchInfo.percentSigned[idx][blobIndex]; ok {
+		result := quorumResult(metadata, core.QuorumID(quorumId), percent)
+		confirmationInfo.QuorumResults = map[core.QuorumID]*core.QuorumResult{core.QuorumID(quorumId): result}
+		param.AdversaryThreshold, param.QuorumThreshold = result.AdversaryThreshold, result.QuorumThreshold
+	} else if requested := requestedSecurityParam(metadata, core.QuorumID(quorumId)); requested != nil {
+		param.AdversaryThreshold, param.QuorumThreshold = requested.AdversaryThreshold, requested.QuorumThreshold
+	}
+	confirmationInfo.BlobQuorumInfos = []*core.BlobQuorumInfo{{SecurityParam: param}}
+	return confirmationInfo
+}
+
+// confirmationInfos returns the confirmations of the blobs of the batch at idx not excluded from it
+func (batchInfo *BatchInfo) confirmationInfos(idx int, txHash eth_common.Hash, blockNumber uint32) []*disperser.ConfirmationInfo {
+	infos := make([]*disperser.ConfirmationInfo, 0, len(batchInfo.batch[idx].BlobMetadata))
+	for blobIndex := range batchInfo.batch[idx].BlobMetadata {
+		if _, ok := batchInfo.excluded[idx][blobIndex]; ok {
+			continue
+		}
+		infos = append(infos, batchInfo.confirmationInfo(idx, blobIndex, txHash, blockNumber))
+	}
+	return infos
+}
+
+// statusOf returns what the status page keeps of the batch at idx, whose blobs were
+// confirmed after a total latency
+func (batchInfo *BatchInfo) statusOf(idx int, blobs int, latency time.Duration) *confirmedBatch {
+	batch := &confirmedBatch{
+		confirmedAt:    time.Now(),
+		blobs:          blobs,
+		latency:        latency / time.Duration(blobs),
+		quorumID:       batchInfo.quorumIds[idx].Uint64(),
+		signersPercent: -1,
+	}
+	if idx < len(batchInfo.signerBitmaps) && batchInfo.numSigners[idx] > 0 {
+		batch.signersPercent = 100 * float64(batchInfo.signerBitmaps[idx].Count()) / float64(batchInfo.numSigners[idx])
+	}
+	if percents := batchInfo.percentSigned[idx]; len(percents) > 0 {
+		for _, percent := range percents {
+			batch.stakePercent += float64(percent)
+		}
+		batch.stakePercent /= float64(len(percents))
+	}
+	return batch
+}
+
+// quorumResult is the result of the quorum the blob was signed by, with the security
+// parameters requested for the quorum, or the defaults of the signer if it requested none
+// or fell back to them
+func quorumResult(metadata *disperser.BlobMetadata, quorumID core.QuorumID, percentSigned uint8) *core.QuorumResult {
+	result := &core.QuorumResult{
+		QuorumID:           quorumID,
+		PercentSigned:      percentSigned,
+		AdversaryThreshold: defaultAdversaryThreshold,
+		QuorumThreshold:    defaultQuorumThreshold,
+	}
+	param := requestedSecurityParam(metadata, quorumID)
+	switch {
+	case param == nil:
+	case param.Optional && percentSigned < param.QuorumThreshold:
+		// only blobs falling back to the defaults are confirmed below the optional thresholds
+		result.Fallback = true
+	default:
+		result.AdversaryThreshold = param.AdversaryThreshold
+		// blobs are signed by two thirds of the quorum whatever they requested
+		result.QuorumThreshold = max(param.QuorumThreshold, defaultQuorumThreshold)
+	}
+	return result
+}
@@ -0,0 +1,72 @@
+package batcher
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuorumResultThresholds(t *testing.T) {
+	metadata := &disperser.BlobMetadata{RequestMetadata: &disperser.RequestMetadata{}}
+	result := quorumResult(metadata, 1, 90)
+	assert.Equal(t, &core.QuorumResult{QuorumID: 1, PercentSigned: 90, AdversaryThreshold: 33, QuorumThreshold: 67}, result)
+
+	// the parameters requested for the quorum take precedence over the defaults
+	metadata.RequestMetadata.SecurityParams = []*core.SecurityParam{
+		{QuorumID: 0, AdversaryThreshold: 10, QuorumThreshold: 20},
+		{QuorumID: 1, AdversaryThreshold: 40, QuorumThreshold: 80},
+	}
+	result = quorumResult(metadata, 1, 90)
+	assert.Equal(t, uint8(40), result.AdversaryThreshold)
+	assert.Equal(t, uint8(80), result.QuorumThreshold)
+}
+
+func TestMinSignedSlices(t *testing.T) {
+	metadata := func(threshold uint8) *disperser.BlobMetadata {
+		return &disperser.BlobMetadata{RequestMetadata: &disperser.RequestMetadata{BlobRequestHeader: core.BlobRequestHeader{
+			SecurityParams: []*core.SecurityParam{{QuorumID: 0, AdversaryThreshold: 10, QuorumThreshold: threshold}},
+		}}}
+	}
+	assert.Equal(t, 7, minSignedSlices(nil, 0, 10))
+	assert.Equal(t, 9, minSignedSlices(metadata(90), 0, 10))
+	// a blob requesting less than two thirds is still signed by two thirds of the slices
+	assert.Equal(t, 7, minSignedSlices(metadata(20), 0, 10))
+	assert.False(t, getBlobQuorumPassStatus(metadata(20), 0, 6, 10))
+}
+
+func TestQuorumFallback(t *testing.T) {
+	logger := mock.NewLogger(false)
+	s := &SliceSigner{logger: logger, metrics: NewMetrics("9100", logger)}
+	metadata := func(params ...*core.SecurityParam) *disperser.BlobMetadata {
+		return &disperser.BlobMetadata{RequestMetadata: &disperser.RequestMetadata{BlobRequestHeader: core.BlobRequestHeader{SecurityParams: params}}}
+	}
+	signInfo := &SignInfo{
+		quorumId: big.NewInt(1),
+		batch: &batch{BlobMetadata: []*disperser.BlobMetadata{
+			metadata(&core.SecurityParam{QuorumID: 1, AdversaryThreshold: 40, QuorumThreshold: 90, Optional: true}),
+			metadata(&core.SecurityParam{QuorumID: 1, AdversaryThreshold: 40, QuorumThreshold: 90}),
+			metadata(&core.SecurityParam{QuorumID: 1, AdversaryThreshold: 40, QuorumThreshold: 90, Optional: true}),
+		}},
+		newBlobs: []int{0, 1, 2},
+	}
+	sigs := []*core.Signature{{}, {}, {}}
+	// 8 of 10 slices reach the default threshold but not the requested one, 6 reach neither
+	signed, total := []int{8, 8, 6}, []int{10, 10, 10}
+	passed := make([]bool, 3)
+	for i := range passed {
+		passed[i] = getBlobQuorumPassStatus(signInfo.batch.BlobMetadata[i], 1, signed[i], total[i])
+	}
+	assert.False(t, s.fallBack(signInfo, sigs, signed, total, passed))
+	// only the optional thresholds fall back
+	assert.Equal(t, []bool{true, false, false}, passed)
+
+	result := quorumResult(signInfo.batch.BlobMetadata[0], 1, 80)
+	assert.Equal(t, &core.QuorumResult{QuorumID: 1, PercentSigned: 80, AdversaryThreshold: 33, QuorumThreshold: 67, Fallback: true}, result)
+	result = quorumResult(signInfo.batch.BlobMetadata[0], 1, 95)
+	assert.False(t, result.Fallback)
+	assert.Equal(t, uint8(90), result.QuorumThreshold)
+}
@@ -0,0 +1,200 @@
+// Package conformance checks implementations of the batcher extension points against the
+// contracts documented on disperser.Dispatcher, batcher.Confirmer and batcher.Finalizer.
+// Implementations run the suites from their own tests:
+//
+//	func TestMyTarget(t *testing.T) {
+//		conformance.TestDispatchTarget(t, conformance.DispatchTarget{
+//			New: func(t *testing.T) (disperser.Dispatcher, batcher.Confirmer) {
+//				target := newMyTarget(t)
+//				return target, target
+//			},
+//		})
+//	}
+package conformance
+
+import (
+	"context"
+	"crypto/rand"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/batcher"
+	"github.com/0glabs/0g-da-client/disperser/batcher/dispatcher"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/0glabs/0g-da-client/disperser/contract"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// DispatchTarget describes a dispatch target under test
+type DispatchTarget struct {
+	// New returns the Dispatcher of a fresh target and the Confirmer following it
+	New func(t *testing.T) (disperser.Dispatcher, batcher.Confirmer)
+	// Sign returns the aggregate signatures to submit for the uploads. Targets checking the
+	// signatures must set it; unsigned submissions are used otherwise.
+	Sign func(t *testing.T, uploads []*contract.DataUploadEvent) []*core.CommitRootSubmission
+	// Timeout bounds each wait for a transaction, one minute if 0
+	Timeout time.Duration
+	// HashSuite is the suite the target computes the data roots of the batches with,
+	// keccak256 if nil
+	HashSuite core.HashSuite
+}
+
+// TestDispatchTarget checks a Dispatcher and its Confirmer
+func TestDispatchTarget(t *testing.T, target DispatchTarget) {
+	if target.Timeout == 0 {
+		target.Timeout = time.Minute
+	}
+	if target.Sign == nil {
+		target.Sign = unsignedSubmissions
+	}
+
+	t.Run("DisperseBatch", func(t *testing.T) {
+		d, c := target.New(t)
+		_, _, _ = disperse(t, target, d, c, 3)
+	})
+
+	t.Run("SubmitAggregateSignatures", func(t *testing.T) {
+		d, c := target.New(t)
+		uploads, uploadBlock, _ := disperse(t, target, d, c, 2)
+
+		ctx, cancel := context.WithTimeout(context.Background(), target.Timeout)
+		defer cancel()
+		txHash, err := d.SubmitAggregateSignatures(ctx, target.Sign(t, uploads))
+		require.NoError(t, err)
+		assert.NotEqual(t, eth_common.Hash{}, txHash)
+
+		block, err := c.WaitForConfirmation(ctx, txHash)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, block, uploadBlock, "signatures confirmed before the upload")
+
+		// the inclusion is reported again for reorg tracking
+		again, err := c.WaitForConfirmation(ctx, txHash)
+		require.NoError(t, err)
+		assert.Equal(t, block, again)
+	})
+
+	t.Run("DoneContext", func(t *testing.T) {
+		d, _ := target.New(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		header := &core.BatchHeader{}
+		txHash, err := d.DisperseBatch(ctx, [32]byte{}, header, commitments(1), nil)
+		assert.Error(t, err)
+		assert.Equal(t, eth_common.Hash{}, txHash)
+
+		txHash, err = d.SubmitAggregateSignatures(ctx, unsignedSubmissions(t, nil))
+		assert.Error(t, err)
+		assert.Equal(t, eth_common.Hash{}, txHash)
+	})
+
+	t.Run("UnknownTransaction", func(t *testing.T) {
+		_, c := target.New(t)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		unknown := eth_common.Hash(randomBytes(t))
+		_, block, err := c.WaitForUpload(ctx, unknown)
+		assert.Error(t, err)
+		assert.Zero(t, block)
+		block, err = c.WaitForConfirmation(ctx, unknown)
+		assert.Error(t, err)
+		assert.Zero(t, block)
+	})
+}
+
+// disperse disperses a batch of n blobs and checks the uploads recorded by the target
+func disperse(t *testing.T, target DispatchTarget, d disperser.Dispatcher, c batcher.Confirmer, n int) ([]*contract.DataUploadEvent, uint64, eth_common.Hash) {
+	ctx, cancel := context.WithTimeout(context.Background(), target.Timeout)
+	defer cancel()
+
+	blobCommitments := commitments(n)
+	dataRoots := make([]eth_common.Hash, n)
+	for i, commitment := range blobCommitments {
+		dataRoots[i] = eth_common.BytesToHash(commitment.StorageRoot)
+	}
+	expectedDataRoot, err := dispatcher.BatchDataRoot(dataRoots, target.HashSuite)
+	require.NoError(t, err)
+
+	header := &core.BatchHeader{}
+	txHash, err := d.DisperseBatch(ctx, [32]byte{}, header, blobCommitments, make([]*core.BlobHeader, n))
+	require.NoError(t, err)
+	assert.NotEqual(t, eth_common.Hash{}, txHash)
+	assert.Equal(t, expectedDataRoot, header.DataRoot, "batch data root")
+
+	uploads, block, err := c.WaitForUpload(ctx, txHash)
+	require.NoError(t, err)
+	assert.NotZero(t, block)
+	require.Len(t, uploads, n)
+	for i, upload := range uploads {
+		assert.Equal(t, [32]byte(dataRoots[i]), upload.DataRoot, "upload %d", i)
+	}
+	return uploads, block, txHash
+}
+
+func commitments(n int) []*core.BlobCommitments {
+	blobCommitments := make([]*core.BlobCommitments, n)
+	for i := range blobCommitments {
+		root := make([]byte, 32)
+		_, _ = rand.Read(root)
+		blobCommitments[i] = &core.BlobCommitments{StorageRoot: root}
+	}
+	return blobCommitments
+}
+
+func unsignedSubmissions(t *testing.T, uploads []*contract.DataUploadEvent) []*core.CommitRootSubmission {
+	submissions := make([]*core.CommitRootSubmission, len(uploads))
+	for i, upload := range uploads {
+		submissions[i] = &core.CommitRootSubmission{
+			DataRoot: upload.DataRoot,
+			Epoch:    upload.Epoch,
+			QuorumId: upload.QuorumId,
+		}
+	}
+	return submissions
+}
+
+func randomBytes(t *testing.T) [32]byte {
+	var b [32]byte
+	_, err := rand.Read(b[:])
+	require.NoError(t, err)
+	return b
+}
+
+// TestFinalizer checks a Finalizer working on the given store. The finalizer is not
+// started, and must not find the block math.MaxUint32 final.
+func TestFinalizer(t *testing.T, newFinalizer func(t *testing.T, store disperser.BlobStore) batcher.Finalizer) {
+	ctx := context.Background()
+
+	t.Run("EmptyStore", func(t *testing.T) {
+		f := newFinalizer(t, memorydb.NewBlobStore(core.MaxBlobSize*4, mock.NewLogger(false)))
+		before := f.LatestFinalizedBlock()
+		assert.NoError(t, f.FinalizeBlobs(ctx))
+		assert.GreaterOrEqual(t, f.LatestFinalizedBlock(), before)
+	})
+
+	t.Run("NotFinal", func(t *testing.T) {
+		store := memorydb.NewBlobStore(core.MaxBlobSize*4, mock.NewLogger(false))
+		key, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("blob")}, uint64(time.Now().UnixNano()))
+		require.NoError(t, err)
+		metadata, err := store.GetBlobMetadata(ctx, key)
+		require.NoError(t, err)
+		_, err = store.MarkBlobConfirmed(ctx, metadata, &disperser.ConfirmationInfo{
+			ConfirmationTxnHash:     eth_common.Hash(randomBytes(t)),
+			ConfirmationBlockNumber: math.MaxUint32,
+		})
+		require.NoError(t, err)
+
+		f := newFinalizer(t, store)
+		assert.NoError(t, f.FinalizeBlobs(ctx))
+		metadata, err = store.GetBlobMetadata(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, disperser.Confirmed, metadata.BlobStatus)
+	})
+}
@@ -0,0 +1,36 @@
+package conformance_test
+
+import (
+	"testing"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/batcher"
+	"github.com/0glabs/0g-da-client/disperser/batcher/conformance"
+	"github.com/0glabs/0g-da-client/disperser/batcher/dispatcher"
+)
+
+func TestMemoryTarget(t *testing.T) {
+	conformance.TestDispatchTarget(t, conformance.DispatchTarget{
+		New: func(t *testing.T) (disperser.Dispatcher, batcher.Confirmer) {
+			target := dispatcher.NewMemoryTarget(1, 0)
+			return target, target
+		},
+	})
+	// the data roots follow the hash suite of the batcher
+	conformance.TestDispatchTarget(t, conformance.DispatchTarget{
+		New: func(t *testing.T) (disperser.Dispatcher, batcher.Confirmer) {
+			target := dispatcher.NewMemoryTarget(1, 0)
+			target.SetHashSuite(core.SHA256Suite)
+			return target, target
+		},
+		HashSuite: core.SHA256Suite,
+	})
+}
+
+func TestFinalizer(t *testing.T) {
+	conformance.TestFinalizer(t, func(t *testing.T, store disperser.BlobStore) batcher.Finalizer {
+		return batcher.NewFinalizer(batcher.TimeoutConfig{}, batcher.Config{}, store, nil, nil, mock.NewLogger(false), nil, nil, nil, nil)
+	})
+}
@@ -0,0 +1,118 @@
+package batcher
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+)
+
+var ErrEncodingDivergence = errors.New("encoders returned divergent results")
+
+type CrossCheckConfig struct {
+	// SecondaryEncoderSocket is the address of the encoder used for cross checking.
+	// Cross checking is disabled if it is empty.
+	SecondaryEncoderSocket string
+	// SampleRate is the fraction of blobs that are encoded by both encoders
+	SampleRate float64
+	// FailOnDivergence fails the blob encoding when the results differ,
+	// otherwise the divergence is only reported and the primary result is used
+	FailOnDivergence bool
+}
+
+// CrossCheckEncoderClient sends a sampled fraction of blobs to two encoders and
+// compares the commitments, storage roots and encoded slices they return.
+type CrossCheckEncoderClient struct {
+	primary   disperser.EncoderClient
+	secondary disperser.EncoderClient
+	config    CrossCheckConfig
+	metrics   *Metrics
+	logger    common.Logger
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+var _ disperser.EncoderClient = (*CrossCheckEncoderClient)(nil)
+
+func NewCrossCheckEncoderClient(primary, secondary disperser.EncoderClient, config CrossCheckConfig, metrics *Metrics, logger common.Logger) *CrossCheckEncoderClient {
+	return &CrossCheckEncoderClient{
+		primary:   primary,
+		secondary: secondary,
+		config:    config,
+		metrics:   metrics,
+		logger:    logger,
+		rand:      rand.New(rand.NewSource(rand.Int63())),
+	}
+}
+
+func (c *CrossCheckEncoderClient) sampled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rand.Float64() < c.config.SampleRate
+}
+
+func (c *CrossCheckEncoderClient) EncodeBlob(ctx context.Context, data []byte, log common.Logger) (*core.BlobCommitments, error) {
+	if !c.sampled() {
+		return c.primary.EncodeBlob(ctx, data, log)
+	}
+
+	var (
+		wg           sync.WaitGroup
+		secondary    *core.BlobCommitments
+		secondaryErr error
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		secondary, secondaryErr = c.secondary.EncodeBlob(ctx, data, log)
+	}()
+	primary, err := c.primary.EncodeBlob(ctx, data, log)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+	if secondaryErr != nil {
+		c.logger.Warn("[encodingstreamer] secondary encoder failed, skipping cross check", "err", secondaryErr)
+		c.metrics.UpdateEncoderCrossCheck("secondary_error")
+		return primary, nil
+	}
+
+	if diff := compareCommitments(primary, secondary); diff != "" {
+		c.logger.Error("[encodingstreamer] encoder divergence detected", "diff", diff, "size", len(data))
+		c.metrics.UpdateEncoderCrossCheck("divergent")
+		if c.config.FailOnDivergence {
+			return nil, fmt.Errorf("%w: %s", ErrEncodingDivergence, diff)
+		}
+		return primary, nil
+	}
+	c.metrics.UpdateEncoderCrossCheck("match")
+	return primary, nil
+}
+
+// compareCommitments returns a description of the first difference between a and b,
+// or an empty string if they are identical.
+func compareCommitments(a, b *core.BlobCommitments) string {
+	if (a.ErasureCommitment == nil) != (b.ErasureCommitment == nil) ||
+		(a.ErasureCommitment != nil && !a.ErasureCommitment.Equal(b.ErasureCommitment.G1Affine)) {
+		return "erasure commitment"
+	}
+	if !bytes.Equal(a.StorageRoot, b.StorageRoot) {
+		return "storage root"
+	}
+	if len(a.EncodedSlice) != len(b.EncodedSlice) {
+		return fmt.Sprintf("slice count %d != %d", len(a.EncodedSlice), len(b.EncodedSlice))
+	}
+	for i := range a.EncodedSlice {
+		if !bytes.Equal(a.EncodedSlice[i], b.EncodedSlice[i]) {
+			return fmt.Sprintf("encoded slice %d", i)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,124 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubEncoder returns fixed commitments
+type stubEncoder struct {
+	commitments *core.BlobCommitments
+	err         error
+	requests    int
+}
+
+func (e *stubEncoder) EncodeBlob(ctx context.Context, data []byte, log common.Logger) (*core.BlobCommitments, error) {
+	e.requests++
+	return e.commitments, e.err
+}
+
+func crossCheckCommitments() *core.BlobCommitments {
+	return &core.BlobCommitments{
+		ErasureCommitment: core.NewG1Point(big.NewInt(1), big.NewInt(2)),
+		StorageRoot:       []byte{1, 2, 3},
+		EncodedSlice:      [][]byte{{1}, {2}},
+	}
+}
+
+func TestCrossCheckSampling(t *testing.T) {
+	logger := mock.NewLogger(false)
+	metrics := NewMetrics("9100", logger)
+	ctx := context.Background()
+
+	for _, test := range []struct {
+		name       string
+		sampleRate float64
+		sampled    bool
+	}{
+		{name: "off", sampleRate: 0, sampled: false},
+		{name: "on", sampleRate: 1, sampled: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			primary := &stubEncoder{commitments: crossCheckCommitments()}
+			secondary := &stubEncoder{commitments: crossCheckCommitments()}
+			c := NewCrossCheckEncoderClient(primary, secondary, CrossCheckConfig{SampleRate: test.sampleRate, FailOnDivergence: true}, metrics, logger)
+			for i := 0; i < 10; i++ {
+				commitments, err := c.EncodeBlob(ctx, []byte{1}, logger)
+				require.NoError(t, err)
+				assert.Same(t, primary.commitments, commitments)
+			}
+			assert.Equal(t, 10, primary.requests)
+			if test.sampled {
+				assert.Equal(t, 10, secondary.requests)
+			} else {
+				assert.Zero(t, secondary.requests)
+			}
+		})
+	}
+}
+
+func TestCrossCheckDivergence(t *testing.T) {
+	logger := mock.NewLogger(false)
+	metrics := NewMetrics("9100", logger)
+	ctx := context.Background()
+
+	for _, test := range []struct {
+		name   string
+		mutate func(*core.BlobCommitments)
+	}{
+		{name: "commitment", mutate: func(c *core.BlobCommitments) { c.ErasureCommitment = core.NewG1Point(big.NewInt(1), big.NewInt(3)) }},
+		{name: "missing commitment", mutate: func(c *core.BlobCommitments) { c.ErasureCommitment = nil }},
+		{name: "storage root", mutate: func(c *core.BlobCommitments) { c.StorageRoot = []byte{1, 2, 4} }},
+		{name: "slice count", mutate: func(c *core.BlobCommitments) { c.EncodedSlice = c.EncodedSlice[:1] }},
+		{name: "slice bytes", mutate: func(c *core.BlobCommitments) { c.EncodedSlice[1] = []byte{3} }},
+	} {
+		for _, failOnDivergence := range []bool{false, true} {
+			primary := &stubEncoder{commitments: crossCheckCommitments()}
+			secondary := &stubEncoder{commitments: crossCheckCommitments()}
+			test.mutate(secondary.commitments)
+			c := NewCrossCheckEncoderClient(primary, secondary, CrossCheckConfig{SampleRate: 1, FailOnDivergence: failOnDivergence}, metrics, logger)
+
+			commitments, err := c.EncodeBlob(ctx, []byte{1}, logger)
+			if failOnDivergence {
+				assert.ErrorIs(t, err, ErrEncodingDivergence, test.name)
+				assert.Nil(t, commitments, test.name)
+			} else {
+				// the divergence is only reported
+				assert.NoError(t, err, test.name)
+				assert.Same(t, primary.commitments, commitments, test.name)
+			}
+			assert.Equal(t, 1, secondary.requests, test.name)
+		}
+	}
+}
+
+func TestCrossCheckEncoderErrors(t *testing.T) {
+	logger := mock.NewLogger(false)
+	metrics := NewMetrics("9100", logger)
+	ctx := context.Background()
+	encodeErr := errors.New("encoder failed")
+
+	// a failed secondary encoder skips the cross check
+	primary := &stubEncoder{commitments: crossCheckCommitments()}
+	secondary := &stubEncoder{err: encodeErr}
+	c := NewCrossCheckEncoderClient(primary, secondary, CrossCheckConfig{SampleRate: 1, FailOnDivergence: true}, metrics, logger)
+	commitments, err := c.EncodeBlob(ctx, []byte{1}, logger)
+	require.NoError(t, err)
+	assert.Same(t, primary.commitments, commitments)
+	assert.Equal(t, 1, secondary.requests)
+
+	// while a failed primary encoder fails the encoding
+	primary = &stubEncoder{err: encodeErr}
+	secondary = &stubEncoder{commitments: crossCheckCommitments()}
+	c = NewCrossCheckEncoderClient(primary, secondary, CrossCheckConfig{SampleRate: 1, FailOnDivergence: false}, metrics, logger)
+	_, err = c.EncodeBlob(ctx, []byte{1}, logger)
+	assert.ErrorIs(t, err, encodeErr)
+}
@@ -0,0 +1,89 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/0glabs/0g-da-client/disperser"
+)
+
+// DeadLetterConfig moves blobs stuck in the Processing status to the dead-letter store.
+// Blobs exhausting their retries are dead-lettered by the blob store itself.
+type DeadLetterConfig struct {
+	// ProcessingTTL is how long a blob may stay in Processing since it was requested, the
+	// sweep is disabled if 0
+	ProcessingTTL time.Duration
+	// SweepInterval is how often the blobs in Processing are checked
+	SweepInterval time.Duration
+}
+
+func (c DeadLetterConfig) Enabled() bool {
+	return c.ProcessingTTL > 0
+}
+
+func (c DeadLetterConfig) validate() error {
+	if c.Enabled() && c.SweepInterval <= 0 {
+		return errors.New("the dead letter sweep interval must be positive")
+	}
+	return nil
+}
+
+// expired returns the metadatas requested longer than the TTL before now
+func (c DeadLetterConfig) expired(metadatas []*disperser.BlobMetadata, now time.Time) []*disperser.BlobMetadata {
+	cutoff := uint64(now.Add(-c.ProcessingTTL).UnixNano())
+	var expired []*disperser.BlobMetadata
+	for _, metadata := range metadatas {
+		if metadata.RequestMetadata != nil && metadata.RequestMetadata.RequestedAt < cutoff {
+			expired = append(expired, metadata)
+		}
+	}
+	return expired
+}
+
+// runDeadLetterSweep dead-letters the expired blobs every interval until ctx is done
+func (b *Batcher) runDeadLetterSweep(ctx context.Context) {
+	ticker := time.NewTicker(b.DeadLetter.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.sweepExpiredBlobs(ctx); err != nil {
+				b.logger.Error("[batcher] failed to sweep expired blobs", "err", err)
+			}
+		}
+	}
+}
+
+// sweepExpiredBlobs dead-letters the blobs in Processing for longer than the TTL. The blobs
+// held by the safe mode are not dispatched, so nothing is dead-lettered while it is on nor
+// before the blobs had the TTL to be dispatched once it is left.
+func (b *Batcher) sweepExpiredBlobs(ctx context.Context) error {
+	if b.EncodingStreamer.safeMode.heldWithin(b.DeadLetter.ProcessingTTL, time.Now()) {
+		return nil
+	}
+	metadatas, err := b.Queue.GetBlobMetadataByStatus(ctx, disperser.Processing)
+	if err != nil {
+		return err
+	}
+	for _, metadata := range b.DeadLetter.expired(metadatas, time.Now()) {
+		key := metadata.GetBlobKey()
+		// blobs being encoded or batched are left to the pipeline, which fails them if it must
+		if b.EncodingStreamer.EncodedBlobstore.HasEncodingRequested(key) {
+			continue
+		}
+		err := b.TimeoutConfig.Do(ctx, CallStoreWrite, func(ctx context.Context) error {
+			return b.Queue.MarkBlobDeadLettered(ctx, metadata, disperser.DeadLetterExpired)
+		})
+		if err != nil {
+			b.logger.Error("[batcher] failed to dead-letter an expired blob", "key", key.String(), "err", err)
+			continue
+		}
+		b.events.Record(key, disperser.BlobFailed, "dead-lettered: "+disperser.DeadLetterExpired)
+		b.logger.Warn("[batcher] blob dead-lettered", "key", key.String(), "reason", disperser.DeadLetterExpired)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package batcher
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DispatchDeadlineConfig bounds the dispersal of a batch to the operators of a quorum, from
+// the first signing request to the last signature, while the signing timeout bounds each
+// request on its own. Once the deadline of its quorum passes, the batch proceeds with the
+// signatures received so far, so quorums with slower operators can be given more time.
+type DispatchDeadlineConfig struct {
+	// Default is the deadline of the quorums without their own, no deadline if 0
+	Default time.Duration
+	// Quorums are the deadlines of some quorums as <quorum>=<duration>, e.g. 1=90s
+	Quorums []string
+}
+
+func (c DispatchDeadlineConfig) validate() error {
+	_, err := c.deadlines()
+	return err
+}
+
+// deadlines returns the deadline of each quorum given its own
+func (c DispatchDeadlineConfig) deadlines() (map[uint64]time.Duration, error) {
+	if c.Default < 0 {
+		return nil, fmt.Errorf("the dispatch deadline must not be negative")
+	}
+	deadlines := make(map[uint64]time.Duration, len(c.Quorums))
+	for _, entry := range c.Quorums {
+		quorum, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid quorum dispatch deadline %q, expected <quorum>=<duration>", entry)
+		}
+		quorumID, err := strconv.ParseUint(strings.TrimSpace(quorum), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quorum of dispatch deadline %q: %w", entry, err)
+		}
+		deadline, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid dispatch deadline %q: %w", entry, err)
+		}
+		if deadline < 0 {
+			return nil, fmt.Errorf("the dispatch deadline of quorum %d must not be negative", quorumID)
+		}
+		if _, ok := deadlines[quorumID]; ok {
+			return nil, fmt.Errorf("duplicate dispatch deadline for quorum %d", quorumID)
+		}
+		deadlines[quorumID] = deadline
+	}
+	return deadlines, nil
+}
+
+// dispatchDeadline returns the deadline of the dispersal to the operators of a quorum
+func (s *SliceSigner) dispatchDeadline(quorumID uint64) time.Duration {
+	if deadline, ok := s.quorumDeadlines[quorumID]; ok {
+		return deadline
+	}
+	return s.DispatchDeadline.Default
+}
+
+// withDispatchDeadline derives the context of the signing requests of a batch to the
+// operators of a quorum, cut short if the budget of the batch leaves less time
+func (s *SliceSigner) withDispatchDeadline(ctx context.Context, quorumID uint64, budget *batchBudget) (context.Context, context.CancelFunc) {
+	deadline := s.dispatchDeadline(quorumID)
+	if remaining, ok := budget.remainingLatency(); ok && (deadline <= 0 || remaining < deadline) {
+		deadline = remaining
+		s.metrics.IncrementBudgetDecision(BudgetDispatchCut)
+	}
+	if deadline <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, deadline)
+}
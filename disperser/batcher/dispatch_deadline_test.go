@@ -0,0 +1,23 @@
+package batcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatchDeadline(t *testing.T) {
+	config := DispatchDeadlineConfig{Default: 30 * time.Second, Quorums: []string{"1=90s", " 2 = 1m "}}
+	deadlines, err := config.deadlines()
+	assert.NoError(t, err)
+	s := &SliceSigner{SignerConfig: SignerConfig{DispatchDeadline: config}, quorumDeadlines: deadlines}
+	assert.Equal(t, 30*time.Second, s.dispatchDeadline(0))
+	assert.Equal(t, 90*time.Second, s.dispatchDeadline(1))
+	assert.Equal(t, time.Minute, s.dispatchDeadline(2))
+
+	for _, quorums := range [][]string{{"1"}, {"x=1s"}, {"1=soon"}, {"1=-1s"}, {"1=1s", "1=2s"}} {
+		assert.Error(t, DispatchDeadlineConfig{Quorums: quorums}.validate(), quorums)
+	}
+	assert.Error(t, DispatchDeadlineConfig{Default: -time.Second}.validate())
+}
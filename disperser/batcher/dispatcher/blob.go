@@ -0,0 +1,103 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/0glabs/0g-da-client/disperser/contract"
+	"github.com/0glabs/0g-da-client/disperser/contract/da_entrance"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	eth_common "github.com/ethereum/go-ethereum/common"
+)
+
+// BlobConfig submits the aggregate signatures in blob transactions, on chains supporting
+// EIP-4844 whose DA entrance contract has a method reading the submissions from a blob
+type BlobConfig struct {
+	// ABIFile holds the ABI of the blob method, which the DA entrance binding lacks
+	ABIFile string
+	// Method takes the versioned hash of the blob as its single bytes32 input, the blob
+	// carrying the ABI encoded inputs of submitVerifiedCommitRoots. Disabled if empty.
+	Method string
+	contract.BlobPolicy
+}
+
+func (c BlobConfig) Enabled() bool {
+	return c.Method != ""
+}
+
+// blobSubmitter chooses between calldata and a blob for each submission of the aggregate
+// signatures
+type blobSubmitter struct {
+	policy contract.BlobPolicy
+	// method is the blob method of the DA entrance, called as an inbox known by its ABI
+	method *contract.Inbox
+	// submit is the calldata method whose inputs the blob carries
+	submit abi.Method
+	fees   contract.BlobFeeReader
+}
+
+// EnableBlobs makes the dispatcher submit the aggregate signatures in blob transactions to
+// the DA entrance contract at entrance when its blob policy chooses blobs, see BlobConfig
+func (c *dispatcher) EnableBlobs(config BlobConfig, entrance eth_common.Address) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	abiJSON, err := os.ReadFile(config.ABIFile)
+	if err != nil {
+		return fmt.Errorf("failed to read the blob method abi: %w", err)
+	}
+	method, err := contract.NewInbox(c.daContract, entrance, string(abiJSON), config.Method)
+	if err != nil {
+		return err
+	}
+	if inputs := method.Method.Inputs; len(inputs) != 1 || inputs[0].Type.T != abi.FixedBytesTy || inputs[0].Type.Size != 32 {
+		return fmt.Errorf("blob method %s must take the versioned hash of the blob as its single bytes32 input", config.Method)
+	}
+	entranceABI, err := da_entrance.DAEntranceMetaData.GetAbi()
+	if err != nil {
+		return err
+	}
+	c.blobs = &blobSubmitter{
+		policy: config.BlobPolicy,
+		method: method,
+		submit: entranceABI.Methods["submitVerifiedCommitRoots"],
+		fees:   c.daContract,
+	}
+	return nil
+}
+
+// blobPayload returns the payload of the blob transaction submitting the aggregate
+// signatures, nil if they are submitted in calldata, with the error if the choice failed,
+// such as when the fees can't be read in auto mode
+func (s *blobSubmitter) blobPayload(ctx context.Context, submissions []da_entrance.IDAEntranceCommitRootSubmission) ([]byte, error) {
+	payload, err := s.submit.Inputs.Pack(submissions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack the submissions: %w", err)
+	}
+	calldata := append(append([]byte{}, s.submit.ID...), payload...)
+	blobCalldata, err := s.method.Calldata([32]byte(contract.PlaceholderBlobHash))
+	if err != nil {
+		return nil, err
+	}
+	useBlob, err := s.policy.UseBlob(ctx, s.fees, payload, calldata, blobCalldata)
+	if !useBlob {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// submitBlob submits the aggregate signatures packed in payload in a blob transaction
+func (c *dispatcher) submitBlob(ctx context.Context, payload []byte) (eth_common.Hash, error) {
+	sidecar, err := contract.NewBlobSidecar(payload)
+	if err != nil {
+		return eth_common.Hash{}, err
+	}
+	blobHash := sidecar.BlobHashes()[0]
+	c.logger.Debug("[dispatcher] submitting aggregate signatures in blob transaction", "payload size", len(payload), "versioned hash", blobHash)
+	txHash, err := c.transactor.SubmitVerifiedCommitRootsBlob(ctx, c.blobs.method, sidecar, []interface{}{[32]byte(blobHash)})
+	if err != nil {
+		return eth_common.Hash{}, fmt.Errorf("failed to submit verified commit roots: %w", err)
+	}
+	return txHash, nil
+}
@@ -15,22 +15,28 @@ import (
 	eth_common "github.com/ethereum/go-ethereum/common"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/go-merkletree"
-	"github.com/wealdtech/go-merkletree/keccak256"
 )
 
 type dispatcher struct {
 	daContract *contract.DAContract
 
 	transactor *transactor.Transactor
+	// hashSuite computes the data roots of the batches, the suite of their header hashes
+	hashSuite core.HashSuite
+	// blobs submits the aggregate signatures in blob transactions, nil unless EnableBlobs
+	blobs *blobSubmitter
 
 	logger common.Logger
 }
 
-func NewDispatcher(transactor *transactor.Transactor, daContract *contract.DAContract, logger common.Logger) (*dispatcher, error) {
+// NewDispatcher creates a dispatcher computing the data roots of the batches with hashSuite,
+// keccak256 if nil
+func NewDispatcher(transactor *transactor.Transactor, daContract *contract.DAContract, hashSuite core.HashSuite, logger common.Logger) (*dispatcher, error) {
 	return &dispatcher{
 		logger:     logger,
 		daContract: daContract,
 		transactor: transactor,
+		hashSuite:  hashSuite,
 	}, nil
 }
 
@@ -63,6 +69,27 @@ func DumpEncodedBlobs(extendedMatrix []*core.ExtendedMatrix) ([]byte, error) {
 }
 
 func (c *dispatcher) DisperseBatch(ctx context.Context, batchHeaderHash [32]byte, batchHeader *core.BatchHeader, blobCommitments []*core.BlobCommitments, blobHeaders []*core.BlobHeader) (eth_common.Hash, error) {
+	dataRoots, err := c.verifiedDataRoots(blobCommitments)
+	if err != nil {
+		return eth_common.Hash{}, err
+	}
+	batchHeader.DataRoot, err = BatchDataRoot(dataRoots, c.hashSuite)
+	if err != nil {
+		return eth_common.Hash{}, err
+	}
+
+	// upload batchly
+	txHash, err := c.transactor.BatchUpload(ctx, c.daContract, dataRoots)
+	if err != nil {
+		return eth_common.Hash{}, fmt.Errorf("failed to submit blob data roots: %w", err)
+	}
+
+	return txHash, nil
+}
+
+// verifiedDataRoots returns the storage roots of the blobs, checking those of the blobs
+// whose encoded data is at hand against the data
+func (c *dispatcher) verifiedDataRoots(blobCommitments []*core.BlobCommitments) ([]eth_common.Hash, error) {
 	n := len(blobCommitments)
 	dataRoots := make([]eth_common.Hash, n)
 
@@ -71,7 +98,7 @@ func (c *dispatcher) DisperseBatch(ctx context.Context, batchHeaderHash [32]byte
 			// encoded blobs
 			encodedBlobsData, err := zg_core.NewDataInMemory(commit.EncodedData)
 			if err != nil {
-				return eth_common.Hash{}, errors.WithMessage(err, "failed to build encoded blobs data")
+				return nil, errors.WithMessage(err, "failed to build encoded blobs data")
 			}
 
 			// c.logger.Info("[dispatcher] Data prepared to upload", "size", data.Size(), "chunks", data.NumChunks(), "segments", data.NumSegments())
@@ -79,36 +106,37 @@ func (c *dispatcher) DisperseBatch(ctx context.Context, batchHeaderHash [32]byte
 			// Calculate file merkle root.
 			tree, err := zg_core.MerkleTree(encodedBlobsData)
 			if err != nil {
-				return eth_common.Hash{}, errors.WithMessage(err, "Failed to create data merkle tree")
+				return nil, errors.WithMessage(err, "Failed to create data merkle tree")
 			}
 			c.logger.Info("[dispatcher] data merkle root calculated", "root", tree.Root())
 			dataRoots[i] = tree.Root()
 
 			if eth_common.BytesToHash(blobCommitments[i].StorageRoot) != dataRoots[i] {
-				return eth_common.Hash{}, fmt.Errorf("data merkle root is not match: local: %v, encoder: %v", dataRoots[i], eth_common.BytesToHash(blobCommitments[i].StorageRoot))
+				return nil, fmt.Errorf("data merkle root is not match: local: %v, encoder: %v", dataRoots[i], eth_common.BytesToHash(blobCommitments[i].StorageRoot))
 			}
 		} else {
 			dataRoots[i] = eth_common.BytesToHash(blobCommitments[i].StorageRoot)
 		}
 	}
 
+	return dataRoots, nil
+}
+
+// BatchDataRoot returns the data root of a batch, the merkle root of the storage roots of its
+// blobs in the hash suite of its header hash, keccak256 if suite is nil
+func BatchDataRoot(dataRoots []eth_common.Hash, suite core.HashSuite) (eth_common.Hash, error) {
+	if suite == nil {
+		suite = core.Keccak256Suite
+	}
 	leafs := make([][]byte, len(dataRoots))
 	for i, dataRoot := range dataRoots {
 		leafs[i] = dataRoot[:]
 	}
-	tree, err := merkletree.NewTree(merkletree.WithData(leafs), merkletree.WithHashType(keccak256.New()))
+	tree, err := merkletree.NewTree(merkletree.WithData(leafs), merkletree.WithHashType(suite))
 	if err != nil {
 		return eth_common.Hash{}, fmt.Errorf("failed to get batch data root: %v", err)
 	}
-	batchHeader.DataRoot = eth_common.Hash(tree.Root())
-
-	// upload batchly
-	txHash, err := c.transactor.BatchUpload(c.daContract, dataRoots)
-	if err != nil {
-		return eth_common.Hash{}, fmt.Errorf("failed to submit blob data roots: %v", err)
-	}
-
-	return txHash, nil
+	return eth_common.Hash(tree.Root()), nil
 }
 
 func (c *dispatcher) SubmitAggregateSignatures(ctx context.Context, rootSubmission []*core.CommitRootSubmission) (eth_common.Hash, error) {
@@ -141,9 +169,19 @@ func (c *dispatcher) SubmitAggregateSignatures(ctx context.Context, rootSubmissi
 		}
 	}
 
-	txHash, err := c.transactor.SubmitVerifiedCommitRoots(c.daContract, submissions)
+	if c.blobs != nil {
+		payload, err := c.blobs.blobPayload(ctx, submissions)
+		if err != nil {
+			c.logger.Warn("[dispatcher] failed to choose a blob for the aggregate signatures, submitting them in calldata", "err", err)
+		}
+		if payload != nil {
+			return c.submitBlob(ctx, payload)
+		}
+	}
+
+	txHash, err := c.transactor.SubmitVerifiedCommitRoots(ctx, c.daContract, submissions)
 	if err != nil {
-		return eth_common.Hash{}, fmt.Errorf("failed to submit verified commit roots: %v", err)
+		return eth_common.Hash{}, fmt.Errorf("failed to submit verified commit roots: %w", err)
 	}
 
 	return txHash, nil
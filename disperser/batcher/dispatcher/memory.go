@@ -0,0 +1,181 @@
+package dispatcher
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/contract"
+	"github.com/ethereum/go-ethereum"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var ErrUnknownTransaction = errors.New("unknown transaction")
+
+type memoryUpload struct {
+	uploads []*contract.DataUploadEvent
+	block   uint64
+}
+
+// MemoryTarget is an in-memory dispatch target implementing both disperser.Dispatcher and
+// batcher.Confirmer, for tests and local networks. Every transaction is included in a new
+// block as soon as it is sent, and the uploads are recorded with a fixed epoch and quorum.
+// The finalizer of the batcher reads from it when the blocks are final.
+type MemoryTarget struct {
+	epoch    *big.Int
+	quorumId *big.Int
+	// finalizationBlocks is how many blocks below the latest one the blocks are final
+	finalizationBlocks uint64
+	// hashSuite computes the data roots of the batches, keccak256 if nil
+	hashSuite core.HashSuite
+
+	mu            sync.Mutex
+	block         uint64
+	uploads       map[eth_common.Hash]memoryUpload
+	confirmations map[eth_common.Hash]uint64
+	submissions   []*core.CommitRootSubmission
+}
+
+var _ disperser.Dispatcher = (*MemoryTarget)(nil)
+
+func NewMemoryTarget(epoch uint64, quorumId uint64) *MemoryTarget {
+	return &MemoryTarget{
+		epoch:         new(big.Int).SetUint64(epoch),
+		quorumId:      new(big.Int).SetUint64(quorumId),
+		uploads:       make(map[eth_common.Hash]memoryUpload),
+		confirmations: make(map[eth_common.Hash]uint64),
+	}
+}
+
+// SetHashSuite sets the suite the data roots of the batches are computed with, that of the
+// header hashes of the batcher
+func (m *MemoryTarget) SetHashSuite(suite core.HashSuite) {
+	m.hashSuite = suite
+}
+
+// nextTransaction includes a new transaction in a new block. The caller must hold mu.
+func (m *MemoryTarget) nextTransaction() (eth_common.Hash, uint64) {
+	m.block++
+	var number [8]byte
+	binary.BigEndian.PutUint64(number[:], m.block)
+	return crypto.Keccak256Hash([]byte("memory-target"), number[:]), m.block
+}
+
+func (m *MemoryTarget) DisperseBatch(ctx context.Context, batchHeaderHash [32]byte, batchHeader *core.BatchHeader, blobCommitments []*core.BlobCommitments, blobHeaders []*core.BlobHeader) (eth_common.Hash, error) {
+	if err := ctx.Err(); err != nil {
+		return eth_common.Hash{}, err
+	}
+	dataRoots := make([]eth_common.Hash, len(blobCommitments))
+	uploads := make([]*contract.DataUploadEvent, len(blobCommitments))
+	for i, commitment := range blobCommitments {
+		dataRoots[i] = eth_common.BytesToHash(commitment.StorageRoot)
+		uploads[i] = &contract.DataUploadEvent{DataRoot: dataRoots[i], Epoch: m.epoch, QuorumId: m.quorumId}
+	}
+	dataRoot, err := BatchDataRoot(dataRoots, m.hashSuite)
+	if err != nil {
+		return eth_common.Hash{}, err
+	}
+	batchHeader.DataRoot = dataRoot
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	txHash, block := m.nextTransaction()
+	m.uploads[txHash] = memoryUpload{uploads: uploads, block: block}
+	return txHash, nil
+}
+
+func (m *MemoryTarget) SubmitAggregateSignatures(ctx context.Context, rootSubmission []*core.CommitRootSubmission) (eth_common.Hash, error) {
+	if err := ctx.Err(); err != nil {
+		return eth_common.Hash{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	txHash, block := m.nextTransaction()
+	m.confirmations[txHash] = block
+	m.submissions = append(m.submissions, rootSubmission...)
+	return txHash, nil
+}
+
+func (m *MemoryTarget) WaitForUpload(ctx context.Context, txHash eth_common.Hash) ([]*contract.DataUploadEvent, uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	upload, ok := m.uploads[txHash]
+	if !ok {
+		return nil, 0, ErrUnknownTransaction
+	}
+	return upload.uploads, upload.block, nil
+}
+
+func (m *MemoryTarget) WaitForConfirmation(ctx context.Context, txHash eth_common.Hash) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	block, ok := m.confirmations[txHash]
+	if !ok {
+		return 0, ErrUnknownTransaction
+	}
+	return block, nil
+}
+
+// SetFinalizationBlocks sets how many blocks below the latest one the blocks are final, 0
+// by default
+func (m *MemoryTarget) SetFinalizationBlocks(blocks uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.finalizationBlocks = blocks
+}
+
+// FinalizedBlock returns the number of the latest final block
+func (m *MemoryTarget) FinalizedBlock(ctx context.Context) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.block - min(m.block, m.finalizationBlocks), nil
+}
+
+// ConfirmationBlock returns the number of the block including a confirmation, and
+// ethereum.NotFound for a transaction the target didn't send
+func (m *MemoryTarget) ConfirmationBlock(ctx context.Context, txHash eth_common.Hash) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	block, ok := m.confirmations[txHash]
+	if !ok {
+		return 0, ethereum.NotFound
+	}
+	return block, nil
+}
+
+// Submissions returns the aggregate signatures submitted so far
+func (m *MemoryTarget) Submissions() []*core.CommitRootSubmission {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*core.CommitRootSubmission(nil), m.submissions...)
+}
+
+// Mine includes a new empty block, moving the blocks of the previous transactions away from
+// the head
+func (m *MemoryTarget) Mine() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.block++
+	return m.block
+}
+
+// LatestBlock returns the number of the block of the latest transaction
+func (m *MemoryTarget) LatestBlock() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.block
+}
+
+// GetCurrentBlockNumber returns the number of the latest block, for the target to serve as
+// the chain of a venue
+func (m *MemoryTarget) GetCurrentBlockNumber(ctx context.Context) (uint32, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return uint32(m.block), nil
+}
@@ -0,0 +1,182 @@
+package batcher
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/disperser"
+)
+
+// errBatchDeferred is returned when the batch cap of the drain strategy has been reached.
+// The encoded blobs stay in the encoded blob store for the next batch.
+var errBatchDeferred = errors.New("batch deferred by the drain strategy")
+
+const (
+	DrainOrderOldestFirst = "oldest-first"
+	DrainOrderFairShare   = "fair-share"
+
+	DrainCurveLinear      = "linear"
+	DrainCurveExponential = "exponential"
+)
+
+// DrainConfig governs how the batcher works through its backlog, in particular right
+// after startup when the backlog accumulated during downtime would otherwise be encoded
+// and batched as fast as the encoders allow.
+type DrainConfig struct {
+	// MaxBatchesPerMinute caps the number of batches created per minute, 0 for no cap
+	MaxBatchesPerMinute uint
+	// RampUpDuration is the time after startup over which the batch cap and the number of
+	// blobs pulled for encoding grow from RampUpStart to their full value. Disabled if 0.
+	RampUpDuration time.Duration
+	// RampUpStart is the fraction of the full value the ramp starts from, in (0, 1]
+	RampUpStart float64
+	// RampUpCurve is either linear or exponential
+	RampUpCurve string
+	// Order picks which blobs of the backlog are encoded first: oldest-first, or fair-share
+	// which alternates between accounts, oldest blob first within an account
+	Order string
+}
+
+// drainStrategy applies a DrainConfig. The ramp starts when start is called.
+type drainStrategy struct {
+	config DrainConfig
+	now    func() time.Time
+
+	mu         sync.Mutex
+	startedAt  time.Time
+	batchTimes []time.Time
+}
+
+func newDrainStrategy(config DrainConfig) (*drainStrategy, error) {
+	if config.Order == "" {
+		config.Order = DrainOrderOldestFirst
+	}
+	if config.Order != DrainOrderOldestFirst && config.Order != DrainOrderFairShare {
+		return nil, fmt.Errorf("unknown drain order %q, expected %s or %s", config.Order, DrainOrderOldestFirst, DrainOrderFairShare)
+	}
+	if config.RampUpDuration > 0 {
+		if config.RampUpCurve == "" {
+			config.RampUpCurve = DrainCurveLinear
+		}
+		if config.RampUpCurve != DrainCurveLinear && config.RampUpCurve != DrainCurveExponential {
+			return nil, fmt.Errorf("unknown drain ramp up curve %q, expected %s or %s", config.RampUpCurve, DrainCurveLinear, DrainCurveExponential)
+		}
+		if config.RampUpStart <= 0 || config.RampUpStart > 1 {
+			return nil, fmt.Errorf("drain ramp up start %v must be in (0, 1]", config.RampUpStart)
+		}
+	}
+	d := &drainStrategy{config: config, now: time.Now}
+	d.startedAt = d.now()
+	return d, nil
+}
+
+func (d *drainStrategy) start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.startedAt = d.now()
+}
+
+// rampFactor returns the fraction of the full rate allowed at this point of the ramp
+func (d *drainStrategy) rampFactor() float64 {
+	if d.config.RampUpDuration <= 0 {
+		return 1
+	}
+	d.mu.Lock()
+	elapsed := d.now().Sub(d.startedAt)
+	d.mu.Unlock()
+	if elapsed >= d.config.RampUpDuration {
+		return 1
+	}
+
+	progress := float64(elapsed) / float64(d.config.RampUpDuration)
+	floor := d.config.RampUpStart
+	if d.config.RampUpCurve == DrainCurveExponential {
+		return floor * math.Pow(1/floor, progress)
+	}
+	return floor + (1-floor)*progress
+}
+
+// scale applies the ramp to a limit, never going below 1
+func (d *drainStrategy) scale(limit int) int {
+	scaled := int(math.Ceil(float64(limit) * d.rampFactor()))
+	if scaled < 1 {
+		return 1
+	}
+	return scaled
+}
+
+// batchAllowed reports whether the batch cap leaves room for a batch now
+func (d *drainStrategy) batchAllowed() bool {
+	if d.config.MaxBatchesPerMinute == 0 {
+		return true
+	}
+	limit := d.scale(int(d.config.MaxBatchesPerMinute))
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	cutoff := d.now().Add(-time.Minute)
+	i := 0
+	for i < len(d.batchTimes) && !d.batchTimes[i].After(cutoff) {
+		i++
+	}
+	d.batchTimes = d.batchTimes[i:]
+	return len(d.batchTimes) < limit
+}
+
+// recordBatch counts a batch against the cap
+func (d *drainStrategy) recordBatch() {
+	if d.config.MaxBatchesPerMinute == 0 {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.batchTimes = append(d.batchTimes, d.now())
+}
+
+// order sorts the backlog in the order its blobs should be encoded
+func (d *drainStrategy) order(metadatas []*disperser.BlobMetadata) []*disperser.BlobMetadata {
+	sort.SliceStable(metadatas, func(i, j int) bool {
+		return requestedAt(metadatas[i]) < requestedAt(metadatas[j])
+	})
+	if d.config.Order != DrainOrderFairShare {
+		return metadatas
+	}
+
+	// accounts are visited in the order of their oldest blob
+	var accounts []string
+	byAccount := make(map[string][]*disperser.BlobMetadata)
+	for _, metadata := range metadatas {
+		account := ""
+		if metadata.RequestMetadata != nil {
+			account = metadata.RequestMetadata.AccountID
+		}
+		if _, ok := byAccount[account]; !ok {
+			accounts = append(accounts, account)
+		}
+		byAccount[account] = append(byAccount[account], metadata)
+	}
+
+	ordered := make([]*disperser.BlobMetadata, 0, len(metadatas))
+	for round := 0; len(ordered) < len(metadatas); round++ {
+		for _, account := range accounts {
+			if round < len(byAccount[account]) {
+				ordered = append(ordered, byAccount[account][round])
+			}
+		}
+	}
+	return ordered
+}
+
+func requestedAt(metadata *disperser.BlobMetadata) uint64 {
+	if metadata.RequestMetadata == nil {
+		return 0
+	}
+	return metadata.RequestMetadata.RequestedAt
+}
@@ -0,0 +1,82 @@
+package batcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrainRampUp(t *testing.T) {
+	now := time.Unix(1000, 0)
+	d, err := newDrainStrategy(DrainConfig{
+		MaxBatchesPerMinute: 10,
+		RampUpDuration:      10 * time.Minute,
+		RampUpStart:         0.5,
+	})
+	assert.NoError(t, err)
+	d.now = func() time.Time { return now }
+	d.start()
+
+	assert.Equal(t, 50, d.scale(100))
+	now = now.Add(5 * time.Minute)
+	assert.Equal(t, 75, d.scale(100))
+	now = now.Add(time.Hour)
+	assert.Equal(t, 100, d.scale(100))
+
+	d.config.RampUpCurve = DrainCurveExponential
+	d.start()
+	now = now.Add(5 * time.Minute)
+	assert.InDelta(t, 0.707, d.rampFactor(), 0.001)
+}
+
+func TestDrainBatchCap(t *testing.T) {
+	now := time.Unix(1000, 0)
+	d, err := newDrainStrategy(DrainConfig{MaxBatchesPerMinute: 2})
+	assert.NoError(t, err)
+	d.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		assert.True(t, d.batchAllowed())
+		d.recordBatch()
+	}
+	assert.False(t, d.batchAllowed())
+
+	now = now.Add(time.Minute + time.Second)
+	assert.True(t, d.batchAllowed())
+}
+
+func TestDrainOrder(t *testing.T) {
+	blob := func(account string, requestedAt uint64) *disperser.BlobMetadata {
+		return &disperser.BlobMetadata{
+			MetadataHash: account + string(rune('0'+requestedAt)),
+			RequestMetadata: &disperser.RequestMetadata{
+				BlobRequestHeader: core.BlobRequestHeader{AccountID: account},
+				RequestedAt:       requestedAt,
+			},
+		}
+	}
+	keys := func(metadatas []*disperser.BlobMetadata) []string {
+		out := make([]string, len(metadatas))
+		for i, m := range metadatas {
+			out[i] = m.MetadataHash
+		}
+		return out
+	}
+	backlog := func() []*disperser.BlobMetadata {
+		return []*disperser.BlobMetadata{blob("a", 4), blob("b", 3), blob("a", 1), blob("a", 2), blob("b", 5)}
+	}
+
+	d, err := newDrainStrategy(DrainConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a1", "a2", "b3", "a4", "b5"}, keys(d.order(backlog())))
+
+	d, err = newDrainStrategy(DrainConfig{Order: DrainOrderFairShare})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a1", "b3", "a2", "b5", "a4"}, keys(d.order(backlog())))
+
+	_, err = newDrainStrategy(DrainConfig{Order: "newest-first"})
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package batcher
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/0glabs/0g-da-client/common"
@@ -139,33 +140,59 @@ func (e *encodedBlobStore) DeleteEncodingResult(blobKey disperser.BlobKey) {
 	delete(e.batching, requestID)
 }
 
-// GetNewEncodingResults returns all the fresh encoded results
-func (e *encodedBlobStore) GetNewEncodingResults(ts uint64) []*EncodingResult {
+// GetNewEncodingResults returns the fresh encoded results, at most maxBlobs of them if
+// positive. Blobs of higher priority lanes are taken first, the oldest first within a lane,
+// and are returned in the order of lessByPriority.
+func (e *encodedBlobStore) GetNewEncodingResults(ts uint64, maxBlobs int) []*EncodingResult {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	fetched := make([]*EncodingResult, 0)
 	if _, ok := e.batches[ts]; !ok {
 		e.batches[ts] = make([]requestID, 0)
 	}
-	sliceSize := 0
-	for id, encodedResult := range e.encoded {
+	candidates := make([]requestID, 0)
+	for id := range e.encoded {
 		if _, ok := e.batching[id]; !ok {
-			t := sliceSize + len(encodedResult.BlobCommitments.EncodedSlice)*len(encodedResult.BlobCommitments.EncodedSlice[0])
-			if t > maxSliceSize {
-				e.logger.Info("maximum slice size reached", "current size", sliceSize)
-				break
-			}
-
-			fetched = append(fetched, encodedResult)
-			e.batching[id] = ts
-			e.batches[ts] = append(e.batches[ts], id)
-			sliceSize = t
+			candidates = append(candidates, id)
 		}
 	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return lessByPriority(e.encoded[candidates[i]].BlobMetadata, e.encoded[candidates[j]].BlobMetadata)
+	})
+	sliceSize := 0
+	for _, id := range candidates {
+		encodedResult := e.encoded[id]
+		if maxBlobs > 0 && len(fetched) >= maxBlobs {
+			e.logger.Info("maximum number of blobs reached", "maxBlobs", maxBlobs)
+			break
+		}
+		t := sliceSize + int(core.EncodedSize(encodedResult.BlobCommitments.EncodedSlice))
+		if t > maxSliceSize {
+			e.logger.Info("maximum slice size reached", "current size", sliceSize)
+			break
+		}
+
+		fetched = append(fetched, encodedResult)
+		e.batching[id] = ts
+		e.batches[ts] = append(e.batches[ts], id)
+		sliceSize = t
+	}
 	e.logger.Trace("consumed encoded results", "fetched", len(fetched), "encodedSize", e.encodedResultSize)
 	return fetched
 }
 
+// PutBatchingStatus marks blobs as part of the batch created at ts, keeping them out of new batches
+func (e *encodedBlobStore) PutBatchingStatus(ts uint64, blobKeys []disperser.BlobKey) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, blobKey := range blobKeys {
+		id := getRequestID(blobKey)
+		e.batching[id] = ts
+		e.batches[ts] = append(e.batches[ts], id)
+	}
+}
+
 func (e *encodedBlobStore) DeleteBatchingStatus(ts uint64) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -186,14 +213,48 @@ func (e *encodedBlobStore) GetEncodedResultSize() (int, uint64) {
 	return len(e.encoded), e.encodedResultSize
 }
 
+// GetPendingEncodedStats returns the number and total blob size of the encoded results not
+// in a batch yet, and the request time of the oldest of them in unix nanoseconds
+func (e *encodedBlobStore) GetPendingEncodedStats() (int, uint64, uint64) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	count := 0
+	size := uint64(0)
+	oldest := uint64(0)
+	for id, result := range e.encoded {
+		if _, ok := e.batching[id]; ok {
+			continue
+		}
+		count++
+		request := result.BlobMetadata.RequestMetadata
+		size += uint64(request.BlobSize)
+		if oldest == 0 || request.RequestedAt < oldest {
+			oldest = request.RequestedAt
+		}
+	}
+	return count, size, oldest
+}
+
 func (e *encodedBlobStore) GetEncodingRequestingSize() int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
 	return len(e.requested)
 }
 
+// GetBatchingSize returns the number of encoded blobs in batches not confirmed yet
+func (e *encodedBlobStore) GetBatchingSize() int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return len(e.batching)
+}
+
 func getRequestID(key disperser.BlobKey) requestID {
 	return requestID(fmt.Sprintf("%s", key.String()))
 }
 
 func getChunksSize(result *EncodingResult) uint64 {
-	return uint64(len(result.BlobCommitments.EncodedSlice) * len(result.BlobCommitments.EncodedSlice[0]))
+	return uint64(core.EncodedSize(result.BlobCommitments.EncodedSlice))
 }
@@ -0,0 +1,235 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Encoder balancing strategies
+const (
+	// EncoderRoundRobin sends the blobs to the healthy encoders in turn
+	EncoderRoundRobin = "round-robin"
+	// EncoderLeastLoaded sends each blob to the healthy encoder with the fewest requests in
+	// flight
+	EncoderLeastLoaded = "least-loaded"
+)
+
+// EncoderBalancerConfig balances the blobs across several encoders. An encoder is removed
+// from the rotation after MaxFailures consecutive failed requests or a failed health check,
+// and put back once a health check succeeds.
+type EncoderBalancerConfig struct {
+	// Strategy is EncoderRoundRobin or EncoderLeastLoaded
+	Strategy string
+	// MaxFailures is the number of consecutive failed requests removing an encoder
+	MaxFailures uint
+	// HealthCheckInterval is how often the encoders are probed
+	HealthCheckInterval time.Duration
+}
+
+func (c EncoderBalancerConfig) validate() error {
+	if c.Strategy != EncoderRoundRobin && c.Strategy != EncoderLeastLoaded {
+		return fmt.Errorf("unknown encoder balancing strategy %q, expected %s or %s", c.Strategy, EncoderRoundRobin, EncoderLeastLoaded)
+	}
+	if c.MaxFailures == 0 {
+		return errors.New("the max failures of an encoder must be positive")
+	}
+	if c.HealthCheckInterval <= 0 {
+		return errors.New("the encoder health check interval must be positive")
+	}
+	return nil
+}
+
+// EncoderSockets returns the addresses of the encoders, EncoderSocket being a comma
+// separated list
+func (c Config) EncoderSockets() []string {
+	sockets := make([]string, 0)
+	for _, socket := range strings.Split(c.EncoderSocket, ",") {
+		if socket = strings.TrimSpace(socket); socket != "" {
+			sockets = append(sockets, socket)
+		}
+	}
+	return sockets
+}
+
+// EncoderEndpoint is an encoder of a BalancedEncoderClient
+type EncoderEndpoint struct {
+	Addr   string
+	Client disperser.EncoderClient
+}
+
+type balancedEncoder struct {
+	EncoderEndpoint
+	inflight atomic.Int64
+	// failures is the number of consecutive failed requests, guarded by the client mutex
+	failures uint
+	healthy  atomic.Bool
+}
+
+// BalancedEncoderClient spreads the blobs across several encoders, skipping the unhealthy
+// ones. If no encoder is healthy, all of them are tried rather than failing every blob.
+type BalancedEncoderClient struct {
+	encoders []*balancedEncoder
+	config   EncoderBalancerConfig
+	// probe checks the health of the encoder at an address
+	probe   func(ctx context.Context, addr string) error
+	metrics *Metrics
+	logger  common.Logger
+
+	next atomic.Uint64
+	mu   sync.Mutex
+}
+
+var _ disperser.EncoderClient = (*BalancedEncoderClient)(nil)
+
+func NewBalancedEncoderClient(endpoints []EncoderEndpoint, config EncoderBalancerConfig, probe func(ctx context.Context, addr string) error, metrics *Metrics, logger common.Logger) (*BalancedEncoderClient, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	if len(endpoints) == 0 {
+		return nil, errors.New("no encoder to balance across")
+	}
+	encoders := make([]*balancedEncoder, len(endpoints))
+	for i, endpoint := range endpoints {
+		encoders[i] = &balancedEncoder{EncoderEndpoint: endpoint}
+		encoders[i].healthy.Store(true)
+		if metrics != nil {
+			metrics.UpdateEncoderHealth(endpoint.Addr, true)
+		}
+	}
+	return &BalancedEncoderClient{
+		encoders: encoders,
+		config:   config,
+		probe:    probe,
+		metrics:  metrics,
+		logger:   logger,
+	}, nil
+}
+
+func (c *BalancedEncoderClient) EncodeBlob(ctx context.Context, data []byte, log common.Logger) (*core.BlobCommitments, error) {
+	encoder := c.pick()
+	encoder.inflight.Add(1)
+	commitments, err := encoder.Client.EncodeBlob(ctx, data, log)
+	encoder.inflight.Add(-1)
+	c.record(ctx, encoder, err)
+	return commitments, err
+}
+
+// pick returns the encoder of the next blob
+func (c *BalancedEncoderClient) pick() *balancedEncoder {
+	candidates := make([]*balancedEncoder, 0, len(c.encoders))
+	for _, encoder := range c.encoders {
+		if encoder.healthy.Load() {
+			candidates = append(candidates, encoder)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = c.encoders
+	}
+	start := int((c.next.Add(1) - 1) % uint64(len(candidates)))
+	if c.config.Strategy == EncoderRoundRobin {
+		return candidates[start]
+	}
+	// ties are broken in turn so that idle encoders share the load
+	picked := candidates[start]
+	for i := 1; i < len(candidates); i++ {
+		candidate := candidates[(start+i)%len(candidates)]
+		if candidate.inflight.Load() < picked.inflight.Load() {
+			picked = candidate
+		}
+	}
+	return picked
+}
+
+// record updates the health of an encoder with the result of a request
+func (c *BalancedEncoderClient) record(ctx context.Context, encoder *balancedEncoder, err error) {
+	if err != nil && !encoderFault(ctx, err) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil {
+		encoder.failures = 0
+		return
+	}
+	encoder.failures++
+	if encoder.failures >= c.config.MaxFailures && encoder.healthy.Load() {
+		c.setHealthy(encoder, false, err)
+	}
+}
+
+// encoderFault tells whether a failed request hints at an unhealthy encoder, rather than at
+// a busy one, an invalid blob or a request canceled by the batcher
+func encoderFault(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.ResourceExhausted, codes.InvalidArgument, codes.Canceled:
+		return false
+	}
+	return true
+}
+
+// StartHealthChecks probes the encoders every health check interval until ctx is done
+func (c *BalancedEncoderClient) StartHealthChecks(ctx context.Context) {
+	c.checkHealth(ctx)
+	go func() {
+		ticker := time.NewTicker(c.config.HealthCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.checkHealth(ctx)
+			}
+		}
+	}()
+}
+
+func (c *BalancedEncoderClient) checkHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, encoder := range c.encoders {
+		wg.Add(1)
+		go func(encoder *balancedEncoder) {
+			defer wg.Done()
+			err := c.probe(ctx, encoder.Addr)
+			if ctx.Err() != nil {
+				return
+			}
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if err == nil {
+				encoder.failures = 0
+			}
+			if healthy := err == nil; healthy != encoder.healthy.Load() {
+				c.setHealthy(encoder, healthy, err)
+			}
+		}(encoder)
+	}
+	wg.Wait()
+}
+
+// setHealthy moves an encoder in or out of the rotation, with the client mutex held
+func (c *BalancedEncoderClient) setHealthy(encoder *balancedEncoder, healthy bool, err error) {
+	encoder.healthy.Store(healthy)
+	if healthy {
+		c.logger.Info("[batcher] encoder is healthy again, putting it back in rotation", "encoder", encoder.Addr)
+	} else {
+		c.logger.Warn("[batcher] encoder is unhealthy, removing it from rotation", "encoder", encoder.Addr, "failures", encoder.failures, "err", err)
+	}
+	if c.metrics != nil {
+		c.metrics.UpdateEncoderHealth(encoder.Addr, healthy)
+	}
+}
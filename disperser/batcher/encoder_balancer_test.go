@@ -0,0 +1,71 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type countingEncoder struct {
+	requests int
+	err      error
+}
+
+func (e *countingEncoder) EncodeBlob(ctx context.Context, data []byte, log common.Logger) (*core.BlobCommitments, error) {
+	e.requests++
+	return &core.BlobCommitments{}, e.err
+}
+
+func TestBalancedEncoderClient(t *testing.T) {
+	logger := mock.NewLogger(false)
+	healthy, broken := &countingEncoder{}, &countingEncoder{err: status.Error(codes.Unavailable, "down")}
+	probeErr := errors.New("down")
+	probe := func(ctx context.Context, addr string) error {
+		if addr == "broken" {
+			return probeErr
+		}
+		return nil
+	}
+	config := EncoderBalancerConfig{Strategy: EncoderRoundRobin, MaxFailures: 2, HealthCheckInterval: time.Minute}
+	client, err := NewBalancedEncoderClient([]EncoderEndpoint{{Addr: "healthy", Client: healthy}, {Addr: "broken", Client: broken}}, config, probe, nil, logger)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for i := 0; i < 8; i++ {
+		_, _ = client.EncodeBlob(ctx, []byte{1}, logger)
+	}
+	// the broken encoder is removed after its second consecutive failure
+	assert.Equal(t, 2, broken.requests)
+	assert.Equal(t, 6, healthy.requests)
+
+	// a busy encoder isn't removed
+	busy := &countingEncoder{err: status.Error(codes.ResourceExhausted, "busy")}
+	client.encoders[0].Client = busy
+	for i := 0; i < 4; i++ {
+		_, _ = client.EncodeBlob(ctx, []byte{1}, logger)
+	}
+	assert.Equal(t, 4, busy.requests)
+	assert.True(t, client.encoders[0].healthy.Load())
+
+	// the broken encoder is back in rotation once a health check succeeds
+	probeErr = nil
+	broken.err = nil
+	client.checkHealth(ctx)
+	for i := 0; i < 4; i++ {
+		_, _ = client.EncodeBlob(ctx, []byte{1}, logger)
+	}
+	assert.Equal(t, 4, broken.requests)
+
+	config.Strategy = "random"
+	_, err = NewBalancedEncoderClient([]EncoderEndpoint{{Addr: "healthy", Client: healthy}}, config, probe, nil, logger)
+	assert.Error(t, err)
+}
@@ -0,0 +1,112 @@
+package batcher
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+
+	"github.com/0glabs/0g-da-client/core"
+)
+
+type encodingCacheKey [32]byte
+
+type encodingCacheEntry struct {
+	key         encodingCacheKey
+	commitments *core.BlobCommitments
+	size        uint64
+}
+
+// encodingCache holds the encodings of recently encoded blobs, so that resubmitted blobs,
+// e.g. retried by their client or posted twice by a rollup, aren't encoded again. The
+// least recently used encodings are evicted beyond maxSize bytes.
+type encodingCache struct {
+	maxSize uint64
+
+	mu      sync.Mutex
+	size    uint64
+	entries map[encodingCacheKey]*list.Element
+	// order lists the entries from the most to the least recently used
+	order *list.List
+}
+
+// newEncodingCache returns a cache of maxSize bytes of encodings, nil if maxSize is 0
+func newEncodingCache(maxSize uint64) *encodingCache {
+	if maxSize == 0 {
+		return nil
+	}
+	return &encodingCache{
+		maxSize: maxSize,
+		entries: make(map[encodingCacheKey]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// cacheKey addresses the encoding of a blob by its data and its requested parameters
+func cacheKey(blob *core.Blob) encodingCacheKey {
+	h := sha256.New()
+	for _, param := range blob.RequestHeader.SecurityParams {
+		h.Write([]byte{param.QuorumID, param.AdversaryThreshold, param.QuorumThreshold})
+	}
+	h.Write([]byte{byte(len(blob.RequestHeader.SecurityParams))})
+	h.Write(blob.Data)
+	var key encodingCacheKey
+	h.Sum(key[:0])
+	return key
+}
+
+func commitmentsSize(commitments *core.BlobCommitments) uint64 {
+	size := uint64(len(commitments.EncodedData))
+	for _, slice := range commitments.EncodedSlice {
+		size += uint64(len(slice))
+	}
+	return size
+}
+
+// get returns the cached encoding of key. The encodings are shared, and must not be
+// modified.
+func (c *encodingCache) get(key encodingCacheKey) (*core.BlobCommitments, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*encodingCacheEntry).commitments, true
+}
+
+// put caches the encoding of key, unless it is larger than the cache
+func (c *encodingCache) put(key encodingCacheKey, commitments *core.BlobCommitments) {
+	if c == nil {
+		return
+	}
+	size := commitmentsSize(commitments)
+	if size > c.maxSize {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&encodingCacheEntry{key: key, commitments: commitments, size: size})
+	c.size += size
+	for c.size > c.maxSize {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*encodingCacheEntry)
+		c.order.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.size -= entry.size
+	}
+}
+
+// usage returns the number of cached encodings and their size in bytes
+func (c *encodingCache) usage() (int, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries), c.size
+}
@@ -0,0 +1,51 @@
+package batcher
+
+import (
+	"testing"
+
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodingCache(t *testing.T) {
+	assert.Nil(t, newEncodingCache(0))
+	cache := newEncodingCache(8)
+
+	blob := func(data string, quorums ...core.QuorumID) *core.Blob {
+		blob := &core.Blob{Data: []byte(data)}
+		for _, quorum := range quorums {
+			blob.RequestHeader.SecurityParams = append(blob.RequestHeader.SecurityParams, &core.SecurityParam{QuorumID: quorum})
+		}
+		return blob
+	}
+	encoding := func(size int) *core.BlobCommitments {
+		return &core.BlobCommitments{EncodedSlice: [][]byte{make([]byte, size)}}
+	}
+
+	// encodings are keyed by the data and the requested parameters
+	a, b := encoding(4), encoding(4)
+	cache.put(cacheKey(blob("a", 0)), a)
+	cache.put(cacheKey(blob("b", 0)), b)
+	cached, ok := cache.get(cacheKey(blob("a", 0)))
+	assert.True(t, ok)
+	assert.Same(t, a, cached)
+	_, ok = cache.get(cacheKey(blob("a", 1)))
+	assert.False(t, ok)
+
+	// the least recently used encoding is evicted
+	cache.put(cacheKey(blob("c", 0)), encoding(4))
+	_, ok = cache.get(cacheKey(blob("b", 0)))
+	assert.False(t, ok)
+	_, ok = cache.get(cacheKey(blob("a", 0)))
+	assert.True(t, ok)
+	count, size := cache.usage()
+	assert.Equal(t, 2, count)
+	assert.Equal(t, uint64(8), size)
+
+	// encodings larger than the cache are not cached
+	cache.put(cacheKey(blob("d", 0)), encoding(9))
+	_, ok = cache.get(cacheKey(blob("d", 0)))
+	assert.False(t, ok)
+	count, _ = cache.usage()
+	assert.Equal(t, 2, count)
+}
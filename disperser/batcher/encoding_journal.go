@@ -0,0 +1,100 @@
+package batcher
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/leveldb"
+)
+
+var encodingJournalPrefix = []byte("encoded-")
+
+// encodingJournal persists encoding results until the blob leaves the batcher so that
+// a restarted batcher can reload them instead of encoding the whole backlog again.
+type encodingJournal struct {
+	db     disperser.DB
+	logger common.Logger
+}
+
+func newEncodingJournal(path string, logger common.Logger) (*encodingJournal, error) {
+	db, err := leveldb.NewLevelDBStore(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open encoding journal at %s: %w", path, err)
+	}
+	return &encodingJournal{
+		db:     db,
+		logger: logger,
+	}, nil
+}
+
+func encodingJournalKey(blobKey disperser.BlobKey) []byte {
+	return append(append([]byte{}, encodingJournalPrefix...), blobKey.String()...)
+}
+
+func (j *encodingJournal) put(result *EncodingResult) error {
+	data, err := core.Encode(result)
+	if err != nil {
+		return err
+	}
+	return j.db.Put(encodingJournalKey(result.BlobMetadata.GetBlobKey()), data)
+}
+
+func (j *encodingJournal) delete(blobKey disperser.BlobKey) error {
+	return j.db.Delete(encodingJournalKey(blobKey))
+}
+
+// load returns all persisted results. Entries that can't be decoded are dropped.
+func (j *encodingJournal) load() []*EncodingResult {
+	iter := j.db.NewIterator(encodingJournalPrefix)
+	defer iter.Release()
+
+	results := make([]*EncodingResult, 0)
+	corrupted := make([][]byte, 0)
+	for iter.Next() {
+		result := &EncodingResult{}
+		if err := core.Decode(iter.Value(), result); err != nil || result.BlobMetadata == nil || result.BlobCommitments == nil {
+			j.logger.Warn("[encodingstreamer] dropping undecodable encoding journal entry", "key", string(iter.Key()), "err", err)
+			corrupted = append(corrupted, append([]byte{}, iter.Key()...))
+			continue
+		}
+		results = append(results, result)
+	}
+	if len(corrupted) > 0 {
+		if err := j.db.DeleteBatch(corrupted); err != nil {
+			j.logger.Warn("[encodingstreamer] failed to delete encoding journal entries", "err", err)
+		}
+	}
+	return results
+}
+
+// restoreEncodingResults loads the results persisted by a previous run into the encoded
+// blob store. Results of blobs that are no longer processing are discarded, and the
+// metadata is refreshed from the blob store since it may have changed in the meantime.
+func (e *EncodingStreamer) restoreEncodingResults(ctx context.Context) {
+	restored := 0
+	for _, result := range e.journal.load() {
+		blobKey := result.BlobMetadata.GetBlobKey()
+		metadata, err := e.blobStore.GetBlobMetadata(ctx, blobKey)
+		if err != nil || metadata.BlobStatus != disperser.Processing {
+			if err := e.journal.delete(blobKey); err != nil {
+				e.logger.Warn("[encodingstreamer] failed to delete encoding journal entry", "blob key", blobKey.String(), "err", err)
+			}
+			continue
+		}
+
+		result.BlobMetadata = metadata
+		e.EncodedBlobstore.PutEncodingRequest(blobKey)
+		if err := e.EncodedBlobstore.PutEncodingResult(result); err != nil {
+			e.logger.Warn("[encodingstreamer] failed to restore encoding result", "blob key", blobKey.String(), "err", err)
+			continue
+		}
+		restored++
+	}
+
+	count, encodedSize := e.EncodedBlobstore.GetEncodedResultSize()
+	e.metrics.UpdateEncodedBlobs(count, encodedSize)
+	e.logger.Info("[encodingstreamer] restored encoding results from journal", "count", restored)
+}
@@ -0,0 +1,40 @@
+package batcher
+
+import (
+	"testing"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodingJournal(t *testing.T) {
+	journal, err := newEncodingJournal(t.TempDir(), mock.NewLogger(false))
+	assert.NoError(t, err)
+
+	_, _, g1, _ := bn254.Generators()
+	result := &EncodingResult{
+		BlobMetadata: &disperser.BlobMetadata{
+			BlobHash:     "blob",
+			MetadataHash: "metadata",
+			BlobStatus:   disperser.Processing,
+		},
+		BlobCommitments: &core.BlobCommitments{
+			ErasureCommitment: &core.G1Point{G1Affine: &g1},
+			StorageRoot:       []byte{1, 2, 3},
+			EncodedSlice:      [][]byte{{4, 5}, {6, 7}},
+		},
+	}
+	assert.NoError(t, journal.put(result))
+
+	loaded := journal.load()
+	assert.Len(t, loaded, 1)
+	assert.Equal(t, result.BlobMetadata.GetBlobKey(), loaded[0].BlobMetadata.GetBlobKey())
+	assert.Equal(t, result.BlobCommitments.EncodedSlice, loaded[0].BlobCommitments.EncodedSlice)
+	assert.True(t, loaded[0].BlobCommitments.ErasureCommitment.Equal(&g1))
+
+	assert.NoError(t, journal.delete(result.BlobMetadata.GetBlobKey()))
+	assert.Empty(t, journal.load())
+}
@@ -9,10 +9,12 @@ import (
 	"time"
 
 	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/tracing"
 	"github.com/0glabs/0g-da-client/core"
 	"github.com/0glabs/0g-da-client/disperser"
 	eth_common "github.com/ethereum/go-ethereum/common"
 	"github.com/wealdtech/go-merkletree"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var errNoEncodedResults = errors.New("no encoded results")
@@ -29,16 +31,43 @@ type EncodedSizeNotifier struct {
 }
 
 type StreamerConfig struct {
+	// HashSuite is used to compute the batch root, keccak256 if nil
+	HashSuite core.HashSuite
 
 	// SRSOrder is the order of the SRS used for encoding
 	SRSOrder int
-	// EncodingRequestTimeout is the timeout for each encoding request
-	EncodingRequestTimeout time.Duration
+	// Timeouts bounds the encoding requests
+	Timeouts TimeoutConfig
 
 	// EncodingQueueLimit is the maximum number of encoding requests that can be queued
 	EncodingQueueLimit int
 
 	EncodingInterval time.Duration
+
+	// EncodingJournalPath is where encoding results are persisted for a warm start
+	// after a restart. Results are only kept in memory if it is empty.
+	EncodingJournalPath string
+
+	// BatchJournalPath is where the batches in flight are persisted, so that a restarted
+	// batcher resumes tracking their confirmation. They are only kept in memory if it is empty.
+	BatchJournalPath string
+
+	// Drain orders the backlog and ramps up the encoding queue limit after startup
+	Drain DrainConfig
+
+	// MaxBlobsPerBatch caps the number of blobs in a batch, no cap if 0
+	MaxBlobsPerBatch int
+
+	// EncodingCacheSize is the size in bytes of the encodings kept to be reused for
+	// resubmitted blobs, nothing is cached if 0
+	EncodingCacheSize uint64
+
+	// SafeMode starts the streamer in safe mode, see SafeModeStatus
+	SafeMode bool
+
+	// MaxNumRetriesPerBlob is how many times a blob failed to encode is retried without a
+	// retry policy of its own
+	MaxNumRetriesPerBlob uint
 }
 
 type EncodingStreamer struct {
@@ -58,6 +87,28 @@ type EncodingStreamer struct {
 
 	encodingCtxCancelFuncs []context.CancelFunc
 
+	// journal is nil if encoding results are not persisted
+	journal *encodingJournal
+	// batchJournal is nil if the batches in flight are not persisted
+	batchJournal *batchJournal
+
+	drain     *drainStrategy
+	expediter *expediter
+	// retries holds the blobs of failed batches back until their backoff elapses
+	retries *retryScheduler
+	// cache is nil if encodings are not reused
+	cache *encodingCache
+	// safeMode pauses the encoding and the dispatch of new batches
+	safeMode *safeMode
+	// intake stops pulling new blobs while the batcher drains
+	intake intake
+	// Tracer continues the traces of the blobs dispersed with one, set by the batcher
+	Tracer *tracing.Tracer
+	// events records the lifecycle of the blobs, shared with the batcher
+	events *disperser.BlobEventLog
+	// assignments writes the batch assignments of the blobs, cleared when their batch fails
+	assignments *assignmentWriter
+
 	metrics *EncodingStreamerMetrics
 	logger  common.Logger
 }
@@ -69,6 +120,10 @@ type batch struct {
 	BatchHeader  *core.BatchHeader
 	MerkleTree   *merkletree.MerkleTree
 	TxHash       eth_common.Hash
+	// budget is what is left of the time and fees the batch may spend, nil if unbounded
+	budget *batchBudget
+	// venue is the fallback venue the batch was dispersed to, nil for the primary one
+	venue *Venue
 }
 
 func NewEncodedSizeNotifier(notify chan struct{}, threshold uint64) *EncodedSizeNotifier {
@@ -79,6 +134,21 @@ func NewEncodedSizeNotifier(notify chan struct{}, threshold uint64) *EncodedSize
 	}
 }
 
+// setThreshold changes the encoded size that triggers the notifier, returning whether it changed
+func (n *EncodedSizeNotifier) setThreshold(threshold uint64) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	changed := n.threshold != threshold
+	n.threshold = threshold
+	return changed
+}
+
+func (n *EncodedSizeNotifier) getThreshold() uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.threshold
+}
+
 func NewEncodingStreamer(
 	config StreamerConfig,
 	blobStore disperser.BlobStore,
@@ -90,7 +160,30 @@ func NewEncodingStreamer(
 	if config.EncodingQueueLimit <= 0 {
 		return nil, fmt.Errorf("EncodingQueueLimit should be greater than 0")
 	}
-	return &EncodingStreamer{
+	if config.HashSuite == nil {
+		config.HashSuite = core.Keccak256Suite
+	}
+	drain, err := newDrainStrategy(config.Drain)
+	if err != nil {
+		return nil, err
+	}
+	var journal *encodingJournal
+	if config.EncodingJournalPath != "" {
+		var err error
+		journal, err = newEncodingJournal(config.EncodingJournalPath, logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var batchJournal *batchJournal
+	if config.BatchJournalPath != "" {
+		var err error
+		batchJournal, err = newBatchJournal(config.BatchJournalPath, logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+	streamer := &EncodingStreamer{
 		StreamerConfig:         config,
 		EncodedBlobstore:       newEncodedBlobStore(logger),
 		ReferenceBlockNumber:   uint(0),
@@ -99,12 +192,48 @@ func NewEncodingStreamer(
 		blobStore:              blobStore,
 		encoderClient:          encoderClient,
 		encodingCtxCancelFuncs: make([]context.CancelFunc, 0),
+		journal:                journal,
+		batchJournal:           batchJournal,
+		drain:                  drain,
+		expediter:              newExpediter(),
+		cache:                  newEncodingCache(config.EncodingCacheSize),
+		safeMode:               &safeMode{},
 		metrics:                metrics,
 		logger:                 logger,
-	}, nil
+	}
+	streamer.assignments = newAssignmentWriter(func(ctx context.Context, key disperser.BlobKey, assignment *disperser.BatchAssignment) error {
+		return streamer.Timeouts.Do(ctx, CallStoreWrite, func(ctx context.Context) error {
+			return blobStore.SetBlobBatchAssignment(ctx, key, assignment)
+		})
+	}, logger)
+	if batchJournal != nil {
+		persisted, ok, err := batchJournal.getSafeMode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the persisted safe mode: %w", err)
+		}
+		if ok {
+			streamer.safeMode.restore(persisted)
+			if metrics != nil {
+				metrics.UpdateSafeMode(persisted.Status.Enabled)
+			}
+			if persisted.Status.Enabled {
+				logger.Warn("[encodingstreamer] resumed the safe mode of the previous run", "since", persisted.Status.Since, "reason", persisted.Status.Reason)
+			}
+		}
+	}
+	if config.SafeMode {
+		streamer.SetSafeMode(true, "started in safe mode")
+	}
+	return streamer, nil
 }
 
 func (e *EncodingStreamer) Start(ctx context.Context) error {
+	e.drain.start()
+	e.assignments.start(ctx)
+	if e.journal != nil {
+		e.restoreEncodingResults(ctx)
+	}
+
 	encoderChan := make(chan EncodingResultOrStatus)
 
 	// goroutine for handling blob encoding responses
@@ -148,6 +277,14 @@ func (e *EncodingStreamer) Start(ctx context.Context) error {
 }
 
 func (e *EncodingStreamer) RequestEncoding(ctx context.Context, encoderChan chan EncodingResultOrStatus) error {
+	if e.safeMode.enabled() {
+		e.logger.Debug("[encodingstreamer] encoding paused by the safe mode")
+		return nil
+	}
+	if e.intake.stopped() {
+		e.logger.Debug("[encodingstreamer] draining, no new blobs are pulled")
+		return nil
+	}
 	stageTimer := time.Now()
 	// pull new blobs and send to encoder
 	e.logger.Trace("[encodingstreamer] requesting processing blobs..")
@@ -155,15 +292,23 @@ func (e *EncodingStreamer) RequestEncoding(ctx context.Context, encoderChan chan
 	if err != nil {
 		return fmt.Errorf("error getting blob metadatas: %w", err)
 	}
-	// filter requested/encoded blobs
-	n := 0
+	// filter requested/encoded blobs and those backing off from a failure
+	n, backingOff := 0, 0
 	for _, metadata := range metadatas {
-		if !e.EncodedBlobstore.HasEncodingRequested(metadata.GetBlobKey()) {
-			metadatas[n] = metadata
-			n++
+		if e.EncodedBlobstore.HasEncodingRequested(metadata.GetBlobKey()) {
+			continue
 		}
+		if !e.retries.due(metadata.GetBlobKey()) {
+			backingOff++
+			continue
+		}
+		metadatas[n] = metadata
+		n++
 	}
 	metadatas = metadatas[:n]
+	if backingOff > 0 {
+		e.logger.Debug("[encodingstreamer] failed blobs backing off before their retry", "numBlobs", backingOff)
+	}
 	if len(metadatas) == 0 {
 		e.logger.Info("[encodingstreamer] no new metadatas to encode")
 		return nil
@@ -171,8 +316,9 @@ func (e *EncodingStreamer) RequestEncoding(ctx context.Context, encoderChan chan
 
 	e.logger.Info("[encodingstreamer] metadata in processing status", "numMetadata", len(metadatas))
 
+	metadatas = e.expediter.order(orderByPriority(e.drain.order(metadatas)))
 	waitingQueueSize := e.Pool.WaitingQueueSize()
-	numMetadatastoProcess := e.EncodingQueueLimit - waitingQueueSize - e.EncodedBlobstore.GetEncodingRequestingSize()
+	numMetadatastoProcess := e.drain.scale(e.EncodingQueueLimit) - waitingQueueSize - e.EncodedBlobstore.GetEncodingRequestingSize()
 	if numMetadatastoProcess > len(metadatas) {
 		numMetadatastoProcess = len(metadatas)
 	}
@@ -189,7 +335,29 @@ func (e *EncodingStreamer) RequestEncoding(ctx context.Context, encoderChan chan
 
 	stageTimer = time.Now()
 	blobs, err := e.blobStore.GetBlobsByMetadata(ctx, metadatas)
-	if err != nil {
+	var corruptionErr *disperser.BlobCorruptionError
+	if errors.As(err, &corruptionErr) {
+		// retrying won't repair the stored payload, fail the corrupted blobs and go on with the rest
+		for _, key := range corruptionErr.Keys {
+			e.logger.Error("[encodingstreamer] blob content is corrupted", "blob key", key.String())
+			err := e.Timeouts.Do(ctx, CallStoreWrite, func(ctx context.Context) error {
+				return e.blobStore.MarkBlobFailed(ctx, key)
+			})
+			if err != nil {
+				e.logger.Error("[encodingstreamer] error marking corrupted blob as failed", "blob key", key.String(), "err", err)
+			}
+			e.events.Record(key, disperser.BlobFailed, "corrupted")
+		}
+		e.metrics.IncrementCorruptedBlobs(len(corruptionErr.Keys))
+		n := 0
+		for _, metadata := range metadatas {
+			if _, ok := blobs[metadata.GetBlobKey()]; ok {
+				metadatas[n] = metadata
+				n++
+			}
+		}
+		metadatas = metadatas[:n]
+	} else if err != nil {
 		return fmt.Errorf("error getting blobs from blob store: %w", err)
 	}
 	e.logger.Trace("[encodingstreamer] retrieved blobs to encode", "numBlobs", len(blobs), "duration", time.Since(stageTimer))
@@ -210,6 +378,7 @@ func (e *EncodingStreamer) RequestEncodingForBlob(ctx context.Context, metadata
 	// Validate the encoding parameters for each quorum
 
 	blobKey := metadata.GetBlobKey()
+	e.events.Record(blobKey, disperser.BlobQueued, "")
 	// blobLength := core.GetBlobLength(metadata.RequestMetadata.BlobSize)
 
 	// rows, cols := core.SplitToMatrix(blobLength, uint(blob.RequestHeader.TargetRowNum))
@@ -219,11 +388,43 @@ func (e *EncodingStreamer) RequestEncodingForBlob(ctx context.Context, metadata
 	// 	Cols: cols,
 	// }
 
-	encodingCtx, cancel := context.WithTimeout(ctx, e.EncodingRequestTimeout)
+	var key encodingCacheKey
+	if e.cache != nil {
+		key = cacheKey(blob)
+		blobCommits, ok := e.cache.get(key)
+		e.metrics.IncrementEncodingCacheLookup(ok)
+		if ok {
+			tracing.End(e.Tracer.StartChild(traceContextOf(metadata), "encode", tracing.String("blob.key", blobKey.String()), tracing.Bool("cached", true)), nil)
+			e.EncodedBlobstore.PutEncodingRequest(blobKey)
+			go func() {
+				encoderChan <- EncodingResultOrStatus{
+					EncodingResult: EncodingResult{
+						BlobMetadata:    metadata,
+						BlobCommitments: blobCommits,
+					},
+				}
+			}()
+			e.logger.Debug("[encodingstreamer] reusing the cached encoding of blob", "blob key", blobKey)
+			return
+		}
+	}
+
+	encodingCtx, cancel := e.Timeouts.WithTimeout(ctx, CallEncoder)
+	// the span includes the wait for a worker, and is propagated to the encoder
+	span := e.Tracer.StartChild(traceContextOf(metadata), "encode", tracing.String("blob.key", blobKey.String()), tracing.Int("blob.size", int64(metadata.RequestMetadata.BlobSize)))
+	encodingCtx = trace.ContextWithSpan(encodingCtx, span)
+	submittedAt := time.Now()
 	e.Pool.Submit(func() {
 		defer cancel()
+		e.metrics.ObserveEncodingPoolWait(time.Since(submittedAt))
 		blobCommits, err := e.encoderClient.EncodeBlob(encodingCtx, blob.Data, e.logger)
+		tracing.End(span, err)
+		if err == nil && e.cache != nil {
+			e.cache.put(key, blobCommits)
+			e.metrics.UpdateEncodingCacheUsage(e.cache.usage())
+		}
 		if err != nil {
+			err = e.Timeouts.Wrap(ctx, encodingCtx, CallEncoder, err)
 			encoderChan <- EncodingResultOrStatus{Err: err, EncodingResult: EncodingResult{
 				BlobMetadata: metadata,
 			}}
@@ -243,9 +444,25 @@ func (e *EncodingStreamer) RequestEncodingForBlob(ctx context.Context, metadata
 	e.logger.Trace("[encodingstreamer] requested encoding for blob", "blob key", blobKey)
 }
 
+// handleFailure hands a blob failed for reason back for retry under the retry policy of
+// reason, or dead-letters it once its retries are used up
+func (e *EncodingStreamer) handleFailure(ctx context.Context, metadata *disperser.BlobMetadata, reason FailReason, cause error) error {
+	err := e.Timeouts.Do(ctx, CallStoreWrite, func(ctx context.Context) error {
+		return e.retries.handleBlobFailure(ctx, e.blobStore, metadata, reason, e.MaxNumRetriesPerBlob)
+	})
+	e.events.Record(metadata.GetBlobKey(), disperser.BlobFailed, fmt.Sprintf("%s: %v", reason, cause))
+	return err
+}
+
 func (e *EncodingStreamer) ProcessEncodedBlobs(ctx context.Context, result EncodingResultOrStatus) error {
 	if result.Err != nil {
 		e.EncodedBlobstore.DeleteEncodingRequest(result.BlobMetadata.GetBlobKey())
+		if !strings.Contains(result.Err.Error(), context.Canceled.Error()) {
+			// canceled encoding requests are normal, the blob is just requested again
+			if err := e.handleFailure(ctx, result.BlobMetadata, FailEncoding, result.Err); err != nil {
+				e.logger.Error("[encodingstreamer] error handling blob failure", "blob key", result.BlobMetadata.GetBlobKey(), "err", err)
+			}
+		}
 		return fmt.Errorf("error encoding blob: %w, blob hash: %v", result.Err, result.BlobMetadata.BlobHash)
 	}
 
@@ -253,22 +470,30 @@ func (e *EncodingStreamer) ProcessEncodedBlobs(ctx context.Context, result Encod
 	if err != nil {
 		return fmt.Errorf("failed to putEncodedBlob: %w", err)
 	}
+	if e.journal != nil {
+		if err := e.journal.put(&result.EncodingResult); err != nil {
+			// the result is still usable, it just has to be encoded again after a restart
+			e.logger.Warn("[encodingstreamer] failed to persist encoding result", "blob key", result.BlobMetadata.GetBlobKey(), "err", err)
+		}
+	}
 
 	e.logger.Trace("[encodingstreamer] blob encoded", "blob key", result.BlobMetadata.GetBlobKey())
+	e.events.Record(result.BlobMetadata.GetBlobKey(), disperser.BlobEncoded, "")
+	if e.expediter.encoded(result.BlobMetadata.GetBlobKey()) {
+		e.logger.Info("[encodingstreamer] expedited blob encoded, requesting a batch", "blob key", result.BlobMetadata.GetBlobKey())
+		e.triggerBatch()
+	}
 
 	count, encodedSize := e.EncodedBlobstore.GetEncodedResultSize()
 	e.metrics.UpdateEncodedBlobs(count, encodedSize)
-	if e.EncodedSizeNotifier.threshold > 0 && encodedSize >= e.EncodedSizeNotifier.threshold {
-		e.EncodedSizeNotifier.mu.Lock()
-
-		if e.EncodedSizeNotifier.active {
-			e.logger.Info("[encodingstreamer] encoded size threshold reached", "size", encodedSize)
-			e.EncodedSizeNotifier.Notify <- struct{}{}
-			// make sure this doesn't keep triggering before encoded blob store is reset
-			e.EncodedSizeNotifier.active = false
-		}
-		e.EncodedSizeNotifier.mu.Unlock()
+	e.EncodedSizeNotifier.mu.Lock()
+	if e.EncodedSizeNotifier.threshold > 0 && encodedSize >= e.EncodedSizeNotifier.threshold && e.EncodedSizeNotifier.active {
+		e.logger.Info("[encodingstreamer] encoded size threshold reached", "size", encodedSize)
+		e.EncodedSizeNotifier.Notify <- struct{}{}
+		// make sure this doesn't keep triggering before encoded blob store is reset
+		e.EncodedSizeNotifier.active = false
 	}
+	e.EncodedSizeNotifier.mu.Unlock()
 
 	return nil
 }
@@ -277,10 +502,12 @@ func (e *EncodingStreamer) ProcessEncodedBlobs(ctx context.Context, result Encod
 // If successful, it returns a batch, and updates the reference block number for next batch to use.
 // Otherwise, it returns an error and keeps the blobs in the encoded blob store.
 // This function is meant to be called periodically in a single goroutine as it resets the state of the encoded blob store.
+// The blobs are indexed in the batch in the order of lessByPriority, the index they are
+// assigned and confirmed with.
 func (e *EncodingStreamer) CreateBatch() (*batch, uint64, error) {
 	// Get all encoded blobs
 	ts := uint64(time.Now().Nanosecond())
-	encodedResults := e.EncodedBlobstore.GetNewEncodingResults(ts)
+	encodedResults := e.EncodedBlobstore.GetNewEncodingResults(ts, e.MaxBlobsPerBatch)
 
 	// Reset the notifier
 	e.EncodedSizeNotifier.mu.Lock()
@@ -305,7 +532,7 @@ func (e *EncodingStreamer) CreateBatch() (*batch, uint64, error) {
 			metadataByKey[blobKey] = result.BlobMetadata
 		}
 		blobHeader := &core.BlobHeader{
-			Length:         uint(len(result.BlobCommitments.EncodedSlice) * len(result.BlobCommitments.EncodedSlice[0])),
+			Length:         core.EncodedSize(result.BlobCommitments.EncodedSlice),
 			CommitmentRoot: result.BlobCommitments.ErasureCommitment.Serialize(),
 		}
 		// if err := blobHeader.SetCommitmentRoot(result.Commitment.ErasureCommitment); err != nil {
@@ -337,7 +564,7 @@ func (e *EncodingStreamer) CreateBatch() (*batch, uint64, error) {
 		BatchRoot: [32]byte{},
 	}
 
-	tree, err := batchHeader.SetBatchRoot(blobHeaders)
+	tree, err := batchHeader.SetBatchRootWith(blobHeaders, e.HashSuite)
 	if err != nil {
 		return nil, ts, err
 	}
@@ -355,8 +582,14 @@ func (e *EncodingStreamer) CreateBatch() (*batch, uint64, error) {
 
 func (e *EncodingStreamer) RemoveEncodedBlob(metadata *disperser.BlobMetadata) {
 	e.EncodedBlobstore.DeleteEncodingResult(metadata.GetBlobKey())
+	if e.journal != nil {
+		if err := e.journal.delete(metadata.GetBlobKey()); err != nil {
+			e.logger.Warn("[encodingstreamer] failed to delete encoding journal entry", "blob key", metadata.GetBlobKey(), "err", err)
+		}
+	}
 }
 
 func (e *EncodingStreamer) RemoveBatchingStatus(ts uint64) {
 	e.EncodedBlobstore.DeleteBatchingStatus(ts)
+	e.forgetBatch(ts)
 }
@@ -0,0 +1,418 @@
+package batcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/approval"
+	"github.com/0glabs/0g-da-client/disperser"
+)
+
+// expediteTTL bounds how long a blob stays expedited, so that blobs leaving the queue
+// without being encoded by this batcher are forgotten
+const expediteTTL = time.Hour
+
+var errNotPending = errors.New("blob is not pending")
+
+type expediteRequest struct {
+	nextBatch   bool
+	requestedAt time.Time
+}
+
+// expediter keeps the blobs bumped to the front of the encoding queue through the admin API
+type expediter struct {
+	now func() time.Time
+
+	mu    sync.Mutex
+	blobs map[disperser.BlobKey]expediteRequest
+	// forceBatch makes the next batch skip the drain strategy cap
+	forceBatch bool
+}
+
+func newExpediter() *expediter {
+	return &expediter{
+		now:   time.Now,
+		blobs: make(map[disperser.BlobKey]expediteRequest),
+	}
+}
+
+func (x *expediter) add(key disperser.BlobKey, nextBatch bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	request := x.blobs[key]
+	request.nextBatch = request.nextBatch || nextBatch
+	request.requestedAt = x.now()
+	x.blobs[key] = request
+}
+
+// order moves the expedited blobs to the front of the backlog, oldest request first,
+// keeping the order of the others
+func (x *expediter) order(metadatas []*disperser.BlobMetadata) []*disperser.BlobMetadata {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if len(x.blobs) == 0 {
+		return metadatas
+	}
+	for key, request := range x.blobs {
+		if x.now().Sub(request.requestedAt) > expediteTTL {
+			delete(x.blobs, key)
+		}
+	}
+
+	expedited := make([]*disperser.BlobMetadata, 0, len(x.blobs))
+	rest := make([]*disperser.BlobMetadata, 0, len(metadatas))
+	for _, metadata := range metadatas {
+		if _, ok := x.blobs[metadata.GetBlobKey()]; ok {
+			expedited = append(expedited, metadata)
+		} else {
+			rest = append(rest, metadata)
+		}
+	}
+	sort.SliceStable(expedited, func(i, j int) bool {
+		return x.blobs[expedited[i].GetBlobKey()].requestedAt.Before(x.blobs[expedited[j].GetBlobKey()].requestedAt)
+	})
+	return append(expedited, rest...)
+}
+
+// encoded forgets an expedited blob once encoded, and reports whether a batch must be cut
+// right away for it
+func (x *expediter) encoded(key disperser.BlobKey) bool {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	request, ok := x.blobs[key]
+	if !ok {
+		return false
+	}
+	delete(x.blobs, key)
+	if request.nextBatch {
+		x.forceBatch = true
+	}
+	return request.nextBatch
+}
+
+func (x *expediter) requestBatch() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.forceBatch = true
+}
+
+// takeForceBatch reports whether the next batch was forced, and resets it
+func (x *expediter) takeForceBatch() bool {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	forced := x.forceBatch
+	x.forceBatch = false
+	return forced
+}
+
+type expeditedBlob struct {
+	RequestID   string    `json:"request_id"`
+	NextBatch   bool      `json:"next_batch"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+func (x *expediter) list() []expeditedBlob {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	blobs := make([]expeditedBlob, 0, len(x.blobs))
+	for key, request := range x.blobs {
+		blobs = append(blobs, expeditedBlob{RequestID: key.String(), NextBatch: request.nextBatch, RequestedAt: request.requestedAt})
+	}
+	sort.Slice(blobs, func(i, j int) bool {
+		return blobs[i].RequestedAt.Before(blobs[j].RequestedAt)
+	})
+	return blobs
+}
+
+// Expedite bumps a pending blob to the front of the encoding queue. With nextBatch, a batch
+// is also cut as soon as the blob is encoded, regardless of the drain strategy cap.
+func (e *EncodingStreamer) Expedite(ctx context.Context, key disperser.BlobKey, nextBatch bool) error {
+	metadata, err := e.blobStore.GetBlobMetadata(ctx, key)
+	if err != nil {
+		return fmt.Errorf("%w: %v", disperser.ErrBlobNotFound, err)
+	}
+	if metadata.BlobStatus != disperser.Processing {
+		return fmt.Errorf("%w: blob is %s", errNotPending, metadata.BlobStatus)
+	}
+
+	if _, err := e.EncodedBlobstore.GetEncodingResult(key, 0); err == nil {
+		// already encoded, it goes into the next batch anyway
+		if nextBatch {
+			e.expediter.requestBatch()
+			e.triggerBatch()
+		}
+		return nil
+	}
+	e.expediter.add(key, nextBatch)
+	return nil
+}
+
+// triggerBatch asks the batcher for a batch without waiting for the pull interval
+func (e *EncodingStreamer) triggerBatch() {
+	select {
+	case e.EncodedSizeNotifier.Notify <- struct{}{}:
+	default:
+		// a batch is already pending
+	}
+}
+
+// AdminConfig configures the admin API of the batcher
+type AdminConfig struct {
+	HTTPPort string
+	// Token must be sent as a bearer token to the admin API, which is disabled if empty
+	// and no approval admins are configured
+	Token string
+	// Approval gates the safe mode and the batches cut for expedited blobs behind the
+	// approval of several admins, whose tokens are accepted in place of Token
+	Approval approval.Config
+}
+
+func (c AdminConfig) Enabled() bool {
+	return c.Token != "" || c.Approval.Enabled()
+}
+
+// AdminServer serves the batcher admin API:
+//   - GET /blobs/expedited lists the expedited blobs not encoded yet
+//   - POST /blobs/expedite?request_id=<id>[&next_batch=true] bumps a pending blob to the
+//     front of the encoding queue, and with next_batch cuts a batch as soon as it is encoded
+//   - GET /safe-mode returns the SafeModeStatus
+//   - POST /safe-mode?enabled=<true|false>[&reason=<reason>] enters or leaves the safe mode
+//   - GET /batching returns the BatchingStatus
+//   - POST /batching?paused=<true|false>[&reason=<reason>] pauses or resumes the batches
+//   - GET /confirmation returns the ConfirmationStatus
+//   - POST /confirmation?paused=<true|false>[&reason=<reason>] pauses or resumes the
+//     submission of aggregate signatures, resuming after a cost spike
+//   - POST /batch creates a batch right away, without waiting for the pull interval nor the
+//     minimum batch size, unless the batches are paused
+//   - GET /batch-size returns the batch size limit in MB
+//   - POST /batch-size?mb=<mb> changes the batch size limit until the batcher restarts
+//   - GET /drain returns the DrainStatus
+//   - POST /drain?enabled=<true|false> starts or cancels draining before a shutdown
+//   - GET /state dumps the PipelineState
+//   - GET /approvals lists the actions waiting for the approval of more admins
+//
+// With approvals configured, the POST requests changing the batches and POST /blobs/expedite
+// with next_batch only run once enough admins sent the same request, see approval.Gate.
+type AdminServer struct {
+	config   AdminConfig
+	batcher  *Batcher
+	streamer *EncodingStreamer
+	// gate is nil unless the dangerous actions need the approval of several admins
+	gate   *approval.Gate
+	logger common.Logger
+}
+
+func NewAdminServer(config AdminConfig, batcher *Batcher, logger common.Logger) *AdminServer {
+	return &AdminServer{
+		config:   config,
+		batcher:  batcher,
+		streamer: batcher.EncodingStreamer,
+		gate:     approval.NewGate(config.Approval, logger),
+		logger:   logger,
+	}
+}
+
+func (s *AdminServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/blobs/expedited", s.authorized(http.MethodGet, s.handleList))
+	mux.HandleFunc("/blobs/expedite", func(w http.ResponseWriter, r *http.Request) {
+		// bumping a blob is harmless, cutting batches for it isn't
+		if r.URL.Query().Get("next_batch") == "true" {
+			s.gated(http.MethodPost, s.handleExpedite)(w, r)
+			return
+		}
+		s.authorized(http.MethodPost, s.handleExpedite)(w, r)
+	})
+	mux.HandleFunc("/safe-mode", s.authorizedMethods(map[string]http.HandlerFunc{
+		http.MethodGet:  s.authorized(http.MethodGet, s.handleGetSafeMode),
+		http.MethodPost: s.gated(http.MethodPost, s.handleSetSafeMode),
+	}))
+	mux.HandleFunc("/batching", s.authorizedMethods(map[string]http.HandlerFunc{
+		http.MethodGet:  s.authorized(http.MethodGet, s.handleGetBatching),
+		http.MethodPost: s.gated(http.MethodPost, s.handleSetBatching),
+	}))
+	mux.HandleFunc("/confirmation", s.authorizedMethods(map[string]http.HandlerFunc{
+		http.MethodGet:  s.authorized(http.MethodGet, s.handleGetConfirmation),
+		http.MethodPost: s.gated(http.MethodPost, s.handleSetConfirmation),
+	}))
+	mux.HandleFunc("/batch", s.gated(http.MethodPost, s.handleTriggerBatch))
+	mux.HandleFunc("/batch-size", s.authorizedMethods(map[string]http.HandlerFunc{
+		http.MethodGet:  s.authorized(http.MethodGet, s.handleGetBatchSize),
+		http.MethodPost: s.gated(http.MethodPost, s.handleSetBatchSize),
+	}))
+	mux.HandleFunc("/drain", s.authorizedMethods(map[string]http.HandlerFunc{
+		http.MethodGet:  s.authorized(http.MethodGet, s.handleGetDrain),
+		http.MethodPost: s.gated(http.MethodPost, s.handleSetDrain),
+	}))
+	mux.HandleFunc("/state", s.authorized(http.MethodGet, s.handleState))
+	if s.gate != nil {
+		mux.HandleFunc("/approvals", s.gate.HandlePending)
+	}
+	return mux
+}
+
+func (s *AdminServer) Start() {
+	go func() {
+		addr := fmt.Sprintf("%s:%s", disperser.Localhost, s.config.HTTPPort)
+		s.logger.Info("[batcher] admin api listening", "address", addr)
+		err := http.ListenAndServe(addr, s.Handler())
+		s.logger.Error("[batcher] admin api failed", "err", err)
+	}()
+}
+
+func (s *AdminServer) authorized(method string, handler http.HandlerFunc) http.HandlerFunc {
+	return approval.Authorized(s.config.Token, s.gate, method, handler)
+}
+
+// authorizedMethods serves each method with its authorized handler
+func (s *AdminServer) authorizedMethods(handlers map[string]http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler, ok := handlers[r.Method]
+		if !ok {
+			handler = s.authorized(r.Method, func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			})
+		}
+		handler(w, r)
+	}
+}
+
+// gated serves a dangerous action, once approved by enough admins if approvals are configured
+func (s *AdminServer) gated(method string, handler http.HandlerFunc) http.HandlerFunc {
+	if s.gate == nil {
+		return s.authorized(method, handler)
+	}
+	return s.gate.Gated(method, handler)
+}
+
+func (s *AdminServer) handleList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.streamer.expediter.list())
+}
+
+func (s *AdminServer) handleExpedite(w http.ResponseWriter, r *http.Request) {
+	requestID := r.URL.Query().Get("request_id")
+	key, err := disperser.ParseBlobKey(requestID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	nextBatch := r.URL.Query().Get("next_batch") == "true"
+
+	err = s.streamer.Expedite(r.Context(), key, nextBatch)
+	switch {
+	case errors.Is(err, disperser.ErrBlobNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, errNotPending):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.logger.Info("[batcher] blob expedited", "key", requestID, "nextBatch", nextBatch)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *AdminServer) handleGetSafeMode(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.streamer.SafeMode())
+}
+
+func (s *AdminServer) handleSetSafeMode(w http.ResponseWriter, r *http.Request) {
+	enabled, err := strconv.ParseBool(r.URL.Query().Get("enabled"))
+	if err != nil {
+		http.Error(w, "enabled must be true or false", http.StatusBadRequest)
+		return
+	}
+	s.streamer.SetSafeMode(enabled, r.URL.Query().Get("reason"))
+	s.handleGetSafeMode(w, r)
+}
+
+func (s *AdminServer) handleGetBatching(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.batcher.Batching())
+}
+
+func (s *AdminServer) handleSetBatching(w http.ResponseWriter, r *http.Request) {
+	paused, err := strconv.ParseBool(r.URL.Query().Get("paused"))
+	if err != nil {
+		http.Error(w, "paused must be true or false", http.StatusBadRequest)
+		return
+	}
+	s.batcher.SetBatchingPaused(paused, r.URL.Query().Get("reason"))
+	s.handleGetBatching(w, r)
+}
+
+func (s *AdminServer) handleGetConfirmation(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.batcher.Confirmation())
+}
+
+func (s *AdminServer) handleSetConfirmation(w http.ResponseWriter, r *http.Request) {
+	paused, err := strconv.ParseBool(r.URL.Query().Get("paused"))
+	if err != nil {
+		http.Error(w, "paused must be true or false", http.StatusBadRequest)
+		return
+	}
+	s.batcher.SetConfirmationPaused(paused, r.URL.Query().Get("reason"))
+	s.handleGetConfirmation(w, r)
+}
+
+func (s *AdminServer) handleTriggerBatch(w http.ResponseWriter, r *http.Request) {
+	if s.batcher.Batching().Paused {
+		http.Error(w, errBatchingPaused.Error(), http.StatusConflict)
+		return
+	}
+	s.streamer.expediter.requestBatch()
+	s.streamer.triggerBatch()
+	s.logger.Info("[batcher] batch requested through the admin api")
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *AdminServer) handleGetBatchSize(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]uint{"mb": s.batcher.BatchSizeMB()})
+}
+
+func (s *AdminServer) handleSetBatchSize(w http.ResponseWriter, r *http.Request) {
+	mb, err := strconv.ParseUint(r.URL.Query().Get("mb"), 10, 32)
+	if err != nil {
+		http.Error(w, "mb must be a positive integer", http.StatusBadRequest)
+		return
+	}
+	if err := s.batcher.SetBatchSizeMB(uint(mb)); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.handleGetBatchSize(w, r)
+}
+
+func (s *AdminServer) handleGetDrain(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.batcher.DrainStatus())
+}
+
+func (s *AdminServer) handleSetDrain(w http.ResponseWriter, r *http.Request) {
+	enabled, err := strconv.ParseBool(r.URL.Query().Get("enabled"))
+	if err != nil {
+		http.Error(w, "enabled must be true or false", http.StatusBadRequest)
+		return
+	}
+	s.batcher.Drain(enabled)
+	s.handleGetDrain(w, r)
+}
+
+func (s *AdminServer) handleState(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.batcher.PipelineState())
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,59 @@
+package batcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpediteAdminAPI(t *testing.T) {
+	ctx := context.Background()
+	logger := mock.NewLogger(false)
+	store := memorydb.NewBlobStore(core.MaxBlobSize*4, logger)
+	streamer, err := NewEncodingStreamer(StreamerConfig{EncodingQueueLimit: 10}, store, nil, NewEncodedSizeNotifier(make(chan struct{}, 1), 0), nil, nil, logger)
+	require.NoError(t, err)
+	server := httptest.NewServer(NewAdminServer(AdminConfig{Token: "token"}, &Batcher{EncodingStreamer: streamer}, logger).Handler())
+	defer server.Close()
+
+	keys := make([]disperser.BlobKey, 3)
+	for i := range keys {
+		keys[i], err = store.StoreBlob(ctx, &core.Blob{Data: []byte{byte(i)}}, uint64(i))
+		require.NoError(t, err)
+	}
+	require.NoError(t, store.MarkBlobFailed(ctx, keys[2]))
+
+	expedite := func(token string, requestID string) int {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/blobs/expedite?next_batch=true&request_id="+requestID, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusUnauthorized, expedite("wrong", keys[1].String()))
+	assert.Equal(t, http.StatusConflict, expedite("token", keys[2].String()))
+	assert.Equal(t, http.StatusNoContent, expedite("token", keys[1].String()))
+
+	metadatas, err := store.GetBlobMetadataByStatus(ctx, disperser.Processing)
+	require.NoError(t, err)
+	metadatas = streamer.expediter.order(streamer.drain.order(metadatas))
+	require.Len(t, metadatas, 2)
+	assert.Equal(t, keys[1], metadatas[0].GetBlobKey())
+	assert.Equal(t, keys[0], metadatas[1].GetBlobKey())
+
+	// the batch is forced once the blob is encoded, past the drain cap
+	assert.False(t, streamer.expediter.takeForceBatch())
+	assert.True(t, streamer.expediter.encoded(keys[1]))
+	assert.True(t, streamer.expediter.takeForceBatch())
+	assert.False(t, streamer.expediter.encoded(keys[1]))
+	assert.Empty(t, streamer.expediter.list())
+}
@@ -0,0 +1,173 @@
+package batcher
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	pb "github.com/0glabs/0g-da-client/disperser/api/grpc/signer"
+	eth_common "github.com/ethereum/go-ethereum/common"
+)
+
+// FairnessConfig compares the bytes assigned to each operator with its stake, the share of
+// the quorum slices it holds, and reports the operators whose assignments keep deviating
+// from it, which points at a misassignment bug or wrong quorum parameters.
+type FairnessConfig struct {
+	// ReportInterval is how often the fairness report is logged, the analyzer is disabled if 0
+	ReportInterval time.Duration
+	// Tolerance is the relative deviation of the assigned bytes from the stake proportion
+	// above which a batch counts as deviating for an operator, e.g. 0.1
+	Tolerance float64
+	// SustainedBatches is the number of consecutive deviating batches after which the
+	// deviation of an operator is reported
+	SustainedBatches uint
+}
+
+func (c FairnessConfig) Enabled() bool {
+	return c.ReportInterval > 0
+}
+
+func (c FairnessConfig) validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.Tolerance < 0 {
+		return errors.New("the fairness tolerance must not be negative")
+	}
+	if c.SustainedBatches == 0 {
+		return errors.New("the fairness sustained batches must be positive")
+	}
+	return nil
+}
+
+// OperatorFairness is the entry of an operator in the fairness report
+type OperatorFairness struct {
+	Operator eth_common.Address
+	// AssignedBytes and ExpectedBytes are the bytes assigned to the operator since the last
+	// report, and the bytes its stake proportion entitled it to
+	AssignedBytes uint64
+	ExpectedBytes uint64
+	// Deviation is the relative deviation of the assigned bytes of the last batch
+	Deviation float64
+	// DeviatingBatches is the number of consecutive batches deviating beyond the tolerance
+	DeviatingBatches uint
+	Sustained        bool
+}
+
+type fairnessAnalyzer struct {
+	config  FairnessConfig
+	metrics *Metrics
+
+	mu        sync.Mutex
+	operators map[eth_common.Address]*OperatorFairness
+}
+
+func newFairnessAnalyzer(config FairnessConfig, metrics *Metrics) *fairnessAnalyzer {
+	return &fairnessAnalyzer{
+		config:    config,
+		metrics:   metrics,
+		operators: make(map[eth_common.Address]*OperatorFairness),
+	}
+}
+
+// observe records the bytes of the sign requests of a batch against the stake of the signers
+func (a *fairnessAnalyzer) observe(signers map[eth_common.Address]*SignerState, requests map[eth_common.Address][]*pb.SignRequest) {
+	totalSlices := 0
+	for _, state := range signers {
+		totalSlices += len(state.sliceIndexes)
+	}
+	assigned := make(map[eth_common.Address]uint64, len(requests))
+	var totalBytes uint64
+	for addr, reqs := range requests {
+		for _, req := range reqs {
+			for _, slice := range req.GetEncodedSlice() {
+				assigned[addr] += uint64(len(slice))
+			}
+		}
+		totalBytes += assigned[addr]
+	}
+	if totalSlices == 0 || totalBytes == 0 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	sustained := 0
+	for addr, state := range signers {
+		expected := float64(totalBytes) * float64(len(state.sliceIndexes)) / float64(totalSlices)
+		if expected == 0 {
+			continue
+		}
+		operator, ok := a.operators[addr]
+		if !ok {
+			operator = &OperatorFairness{Operator: addr}
+			a.operators[addr] = operator
+		}
+		operator.AssignedBytes += assigned[addr]
+		operator.ExpectedBytes += uint64(expected)
+		operator.Deviation = float64(assigned[addr])/expected - 1
+		if operator.Deviation > a.config.Tolerance || operator.Deviation < -a.config.Tolerance {
+			operator.DeviatingBatches++
+		} else {
+			operator.DeviatingBatches = 0
+		}
+		operator.Sustained = operator.DeviatingBatches >= a.config.SustainedBatches
+		if operator.Sustained {
+			sustained++
+		}
+		if a.metrics != nil {
+			a.metrics.UpdateOperatorDeviation(addr.Hex(), operator.Deviation)
+		}
+	}
+	if a.metrics != nil {
+		a.metrics.UpdateSustainedDeviations(sustained)
+	}
+}
+
+// report returns the entries of the operators by address and resets the bytes they count
+func (a *fairnessAnalyzer) report() []OperatorFairness {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entries := make([]OperatorFairness, 0, len(a.operators))
+	for _, operator := range a.operators {
+		entries = append(entries, *operator)
+		operator.AssignedBytes = 0
+		operator.ExpectedBytes = 0
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].Operator[:], entries[j].Operator[:]) < 0
+	})
+	return entries
+}
+
+// run logs the fairness report every interval until ctx is done
+func (a *fairnessAnalyzer) run(ctx context.Context, logger common.Logger) {
+	ticker := time.NewTicker(a.config.ReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			entries := a.report()
+			sustained := 0
+			for _, entry := range entries {
+				if !entry.Sustained {
+					continue
+				}
+				sustained++
+				logger.Warn("[signer] sustained operator assignment deviation", "operator", entry.Operator.Hex(),
+					"assignedBytes", entry.AssignedBytes, "expectedBytes", entry.ExpectedBytes,
+					"deviation", entry.Deviation, "deviatingBatches", entry.DeviatingBatches)
+			}
+			logger.Info("[signer] operator fairness report", "operators", len(entries), "sustained", sustained)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package batcher
+
+import (
+	"testing"
+
+	pb "github.com/0glabs/0g-da-client/disperser/api/grpc/signer"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFairnessAnalyzer(t *testing.T) {
+	a := newFairnessAnalyzer(FairnessConfig{ReportInterval: 1, Tolerance: 0.1, SustainedBatches: 2}, nil)
+	fair, skewed := eth_common.HexToAddress("0x01"), eth_common.HexToAddress("0x02")
+	signers := map[eth_common.Address]*SignerState{
+		fair:   {sliceIndexes: []int{0, 1, 2}},
+		skewed: {sliceIndexes: []int{3}},
+	}
+	slices := func(n int) []*pb.SignRequest {
+		return []*pb.SignRequest{{EncodedSlice: make([][]byte, n)}}
+	}
+	requests := func(fairSlices, skewedSlices int) map[eth_common.Address][]*pb.SignRequest {
+		r := map[eth_common.Address][]*pb.SignRequest{fair: slices(fairSlices), skewed: slices(skewedSlices)}
+		for _, reqs := range r {
+			for i := range reqs[0].EncodedSlice {
+				reqs[0].EncodedSlice[i] = make([]byte, 100)
+			}
+		}
+		return r
+	}
+
+	// the skewed operator holds a quarter of the slices but gets half of the bytes
+	a.observe(signers, requests(2, 2))
+	entries := a.report()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, fair, entries[0].Operator)
+	assert.InDelta(t, -1.0/3, entries[0].Deviation, 1e-9)
+	assert.Equal(t, uint64(200), entries[1].AssignedBytes)
+	assert.Equal(t, uint64(100), entries[1].ExpectedBytes)
+	assert.InDelta(t, 1.0, entries[1].Deviation, 1e-9)
+	assert.False(t, entries[1].Sustained)
+
+	// a second deviating batch makes it sustained, a proportionate one clears it
+	a.observe(signers, requests(2, 2))
+	entries = a.report()
+	assert.True(t, entries[1].Sustained)
+	assert.Equal(t, uint(2), entries[1].DeviatingBatches)
+
+	a.observe(signers, requests(3, 1))
+	entries = a.report()
+	assert.False(t, entries[1].Sustained)
+	assert.Zero(t, entries[1].DeviatingBatches)
+}
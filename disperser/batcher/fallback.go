@@ -0,0 +1,276 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/geth"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/batcher/dispatcher"
+	"github.com/0glabs/0g-da-client/disperser/batcher/transactor"
+	"github.com/0glabs/0g-da-client/disperser/contract"
+	eth_common "github.com/ethereum/go-ethereum/common"
+)
+
+// FallbackConfig configures a fallback deployment of the DA contracts, for instance on a
+// backup chain, the batches are dispersed to while the primary chain is halted: both the
+// data roots and the aggregate signatures of a batch go to the deployment the batch was
+// dispersed to.
+type FallbackConfig struct {
+	// RPCURL is the chain of the fallback deployment, the fallback is disabled if empty
+	RPCURL string
+	// DAEntranceContractAddress and DASignersContractAddress are the contracts of the
+	// fallback deployment
+	DAEntranceContractAddress string
+	DASignersContractAddress  string
+	// HaltTimeout is how long the head of the primary chain must not advance for the chain
+	// to be considered halted. Failed reads of the head are not counted as a halt.
+	HaltTimeout time.Duration
+	// FinalityDepth is the number of blocks on top of a confirmation on the fallback chain
+	// for the confirmation to be final
+	FinalityDepth uint64
+}
+
+func (c FallbackConfig) Enabled() bool {
+	return c.RPCURL != ""
+}
+
+func (c FallbackConfig) validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if !eth_common.IsHexAddress(c.DAEntranceContractAddress) || !eth_common.IsHexAddress(c.DASignersContractAddress) {
+		return errors.New("the fallback deployment requires the addresses of its DA entrance and DA signers contracts")
+	}
+	if c.HaltTimeout <= 0 {
+		return errors.New("the halt timeout of the primary chain must be positive")
+	}
+	return nil
+}
+
+// haltChecks is the number of times the head of the primary chain is read per halt timeout
+const haltChecks = 10
+
+// Venue is a deployment the aggregate signatures can be submitted to
+type Venue struct {
+	disperser.ConfirmationVenue
+	Dispatcher disperser.Dispatcher
+	Confirmer  Confirmer
+	// Blocks reads the head of the chain of the deployment
+	Blocks BlockNumberReader
+	// Signers reads the signers of the quorums of the deployment
+	Signers SignerRegistry
+
+	// finalityDepth is the number of blocks on top of a block of the deployment for it to
+	// be final
+	finalityDepth uint64
+	// signerCache keeps the signers of the deployment apart from those of the primary one,
+	// whose epochs and quorums have the same numbers
+	signerCache *signerCache
+}
+
+// final returns whether a block of the chain of the venue is final. The chain isn't
+// followed for reorgs, the finality depth is expected to cover them.
+func (v *Venue) final(ctx context.Context, blockNumber uint32) (bool, error) {
+	head, err := v.Blocks.GetCurrentBlockNumber(ctx)
+	if err != nil {
+		return false, err
+	}
+	return uint64(blockNumber)+v.finalityDepth <= uint64(head), nil
+}
+
+// ConfirmationFallback watches the head of the primary chain, and routes the batches to the
+// fallback venue while it doesn't advance. The batches are confirmed on the venue their data
+// roots were uploaded to, which is recorded in their confirmation info.
+type ConfirmationFallback struct {
+	config   FallbackConfig
+	primary  disperser.ConfirmationVenue
+	fallback *Venue
+	// head reads the head of the primary chain
+	head    BlockNumberReader
+	metrics *Metrics
+	logger  common.Logger
+
+	mu          sync.RWMutex
+	lastHead    uint32
+	lastAdvance time.Time
+	halted      bool
+}
+
+func NewConfirmationFallback(config FallbackConfig, primary disperser.ConfirmationVenue, fallback Venue, head BlockNumberReader, metrics *Metrics, logger common.Logger) (*ConfirmationFallback, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	if !config.Enabled() {
+		return nil, errors.New("no fallback deployment configured")
+	}
+	fallback.Fallback = true
+	fallback.finalityDepth = config.FinalityDepth
+	fallback.signerCache = newSignerCache()
+	return &ConfirmationFallback{
+		config:      config,
+		primary:     primary,
+		fallback:    &fallback,
+		head:        head,
+		metrics:     metrics,
+		logger:      logger,
+		lastAdvance: time.Now(),
+	}, nil
+}
+
+// ConnectConfirmationFallback connects to the fallback deployment of config, with the
+// account and settings of ethConfig, for the primary deployment at daEntranceAddress on the
+// chain of client. The fallback computes the data roots of the batches with hashSuite.
+func ConnectConfirmationFallback(config FallbackConfig, ethConfig geth.EthClientConfig, gasLimit uint64, hashSuite core.HashSuite, client *geth.EthClient, daEntranceAddress eth_common.Address, metrics *Metrics, logger common.Logger) (*ConfirmationFallback, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	if !config.Enabled() {
+		return nil, errors.New("no fallback deployment configured")
+	}
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	fallbackEthConfig := ethConfig
+	fallbackEthConfig.RPCURL = config.RPCURL
+	fallbackClient, err := geth.NewClient(fallbackEthConfig, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the fallback chain: %w", err)
+	}
+	fallbackChainID, err := fallbackClient.ChainID(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	fallbackEntranceAddress := eth_common.HexToAddress(config.DAEntranceContractAddress)
+	fallbackSignersAddress := eth_common.HexToAddress(config.DASignersContractAddress)
+	daContract, err := contract.NewDAContract(fallbackEntranceAddress, fallbackSignersAddress, config.RPCURL, ethConfig.PrivateKeyString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fallback DAEntrance contract: %w", err)
+	}
+	fallbackDispatcher, err := dispatcher.NewDispatcher(transactor.NewTransactor(gasLimit, logger), daContract, hashSuite, logger)
+	if err != nil {
+		return nil, err
+	}
+	return NewConfirmationFallback(config,
+		disperser.ConfirmationVenue{ChainID: chainID.Uint64(), Contract: daEntranceAddress},
+		Venue{
+			ConfirmationVenue: disperser.ConfirmationVenue{ChainID: fallbackChainID.Uint64(), Contract: fallbackEntranceAddress},
+			Dispatcher:        fallbackDispatcher,
+			Confirmer:         NewChainConfirmer(daContract, ethConfig),
+			Blocks:            fallbackClient,
+			Signers:           daContract,
+		},
+		client, metrics, logger)
+}
+
+// Start reads the head of the primary chain until ctx is done
+func (f *ConfirmationFallback) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(f.config.HaltTimeout / haltChecks)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				head, err := f.head.GetCurrentBlockNumber(ctx)
+				if err != nil {
+					f.logger.Debug("[confirmer] failed to read the head of the primary chain", "err", err)
+				}
+				f.observe(head, err, time.Now())
+			}
+		}
+	}()
+}
+
+// observe records a read of the head of the primary chain. A failed read tells nothing of
+// the chain, which may be advancing behind a failing RPC endpoint, so it is ignored.
+func (f *ConfirmationFallback) observe(head uint32, err error, now time.Time) {
+	if err != nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if head > f.lastHead {
+		f.lastHead = head
+		f.lastAdvance = now
+	}
+	halted := now.Sub(f.lastAdvance) >= f.config.HaltTimeout
+	if halted == f.halted {
+		return
+	}
+	f.halted = halted
+	if halted {
+		f.logger.Warn("[confirmer] primary chain halted, dispersing the batches to the fallback venue", "head", f.lastHead, "since", f.lastAdvance, "chain ID", f.fallback.ChainID, "contract", f.fallback.Contract)
+	} else {
+		f.logger.Info("[confirmer] primary chain advancing again, dispersing the batches to it", "head", f.lastHead)
+	}
+	if f.metrics != nil {
+		f.metrics.UpdatePrimaryHalted(halted)
+	}
+}
+
+// Halted returns whether the primary chain is considered halted
+func (f *ConfirmationFallback) Halted() bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.halted
+}
+
+// route returns the venue the next batch is dispersed to, nil for the primary one
+func (f *ConfirmationFallback) route() *Venue {
+	if f.Halted() {
+		return f.fallback
+	}
+	return nil
+}
+
+// venueOf returns the recorded venue of the batches dispersed to venue, which is nil for the
+// primary one
+func (f *ConfirmationFallback) venueOf(venue *Venue) *disperser.ConfirmationVenue {
+	if venue != nil {
+		recorded := venue.ConfirmationVenue
+		return &recorded
+	}
+	primary := f.primary
+	return &primary
+}
+
+// splitByVenue returns the submissions dispersed to the venue of the first one, and the
+// batch ids of the others
+func splitByVenue(submissions []*BatchCommitRootSubmission) ([]*BatchCommitRootSubmission, []uint64) {
+	if len(submissions) == 0 {
+		return submissions, nil
+	}
+	venue := submissions[0].batch.venue
+	kept := make([]*BatchCommitRootSubmission, 0, len(submissions))
+	var deferred []uint64
+	for _, submission := range submissions {
+		if submission.batch.venue == venue {
+			kept = append(kept, submission)
+		} else {
+			deferred = append(deferred, submission.ts)
+		}
+	}
+	return kept, deferred
+}
+
+// confirmerOf returns the Confirmer of venue, primary unless it is the fallback venue. f may
+// be nil if there is no fallback venue.
+func (f *ConfirmationFallback) confirmerOf(venue *disperser.ConfirmationVenue, primary Confirmer) Confirmer {
+	if f != nil && venue != nil && venue.Fallback {
+		return f.fallback.Confirmer
+	}
+	return primary
+}
+
+// final returns whether a confirmation at blockNumber on the fallback chain is final
+func (f *ConfirmationFallback) final(ctx context.Context, blockNumber uint32) (bool, error) {
+	return f.fallback.final(ctx, blockNumber)
+}
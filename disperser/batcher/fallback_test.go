@@ -0,0 +1,224 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/batcher/dispatcher"
+	"github.com/0glabs/0g-da-client/disperser/contract/da_signers"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmationFallback(t *testing.T) {
+	config := FallbackConfig{
+		RPCURL:                    "http://backup:8545",
+		DAEntranceContractAddress: "0x0000000000000000000000000000000000000002",
+		DASignersContractAddress:  "0x0000000000000000000000000000000000000003",
+		HaltTimeout:               time.Minute,
+	}
+	primary, backup := &chainConfirmer{}, &chainConfirmer{}
+	fallback, err := NewConfirmationFallback(config,
+		disperser.ConfirmationVenue{ChainID: 1, Contract: eth_common.HexToAddress("0x01")},
+		Venue{ConfirmationVenue: disperser.ConfirmationVenue{ChainID: 2, Contract: eth_common.HexToAddress("0x02")}, Confirmer: backup},
+		nil, nil, mock.NewLogger(false))
+	require.NoError(t, err)
+
+	// the primary chain advances
+	now := time.Now()
+	fallback.observe(10, nil, now)
+	fallback.observe(11, nil, now.Add(30*time.Second))
+	assert.False(t, fallback.Halted())
+	venue := fallback.venueOf(fallback.route())
+	assert.Equal(t, disperser.ConfirmationVenue{ChainID: 1, Contract: eth_common.HexToAddress("0x01")}, *venue)
+	assert.Same(t, primary, fallback.confirmerOf(venue, primary))
+
+	// its head can't be read, which isn't a halt
+	fallback.observe(0, errors.New("unreachable"), now.Add(91*time.Second))
+	assert.False(t, fallback.Halted())
+
+	// its head stops advancing
+	fallback.observe(11, nil, now.Add(time.Minute))
+	assert.False(t, fallback.Halted())
+	fallback.observe(11, nil, now.Add(91*time.Second))
+	assert.True(t, fallback.Halted())
+	venue = fallback.venueOf(fallback.route())
+	assert.Equal(t, disperser.ConfirmationVenue{ChainID: 2, Contract: eth_common.HexToAddress("0x02"), Fallback: true}, *venue)
+	assert.Same(t, backup, fallback.confirmerOf(venue, primary))
+
+	// it advances again
+	fallback.observe(12, nil, now.Add(2*time.Minute))
+	assert.False(t, fallback.Halted())
+	assert.Nil(t, fallback.route())
+
+	// the batches confirmed on the primary venue without a fallback one record no venue
+	var none *ConfirmationFallback
+	assert.Same(t, primary, none.confirmerOf(nil, primary))
+
+	// the signatures of a batch are submitted to the venue its data roots were uploaded to
+	submission := func(ts uint64, venue *Venue) *BatchCommitRootSubmission {
+		return &BatchCommitRootSubmission{ts: ts, batch: &batch{venue: venue}}
+	}
+	signer := &SliceSigner{
+		pendingSubmissions:    make(map[uint64]*BatchCommitRootSubmission),
+		signedBatching:        make(map[uint64]uint64),
+		signedBatches:         make(map[uint64][]uint64),
+		SignatureSizeNotifier: NewSignatureSizeNotifier(make(chan struct{}, 1), 0),
+		logger:                mock.NewLogger(false),
+	}
+	signer.pendingSubmissions[1] = submission(1, nil)
+	signer.pendingSubmissions[2] = submission(2, fallback.fallback)
+	fetched, signedTs, err := signer.GetCommitRootSubmissionBatch()
+	require.NoError(t, err)
+	require.Len(t, fetched, 2)
+	kept, deferred := splitByVenue(fetched)
+	require.Len(t, kept, 1)
+	require.Len(t, deferred, 1)
+	assert.NotEqual(t, kept[0].ts, deferred[0])
+	signer.ReleaseSubmissions(signedTs, deferred)
+	assert.Equal(t, []uint64{kept[0].ts}, signer.signedBatches[signedTs])
+	// the other one is submitted next
+	fetched, _, err = signer.GetCommitRootSubmissionBatch()
+	require.NoError(t, err)
+	require.Len(t, fetched, 1)
+	assert.Equal(t, deferred[0], fetched[0].ts)
+
+	config.DASignersContractAddress = ""
+	_, err = NewConfirmationFallback(config, disperser.ConfirmationVenue{}, Venue{}, nil, nil, mock.NewLogger(false))
+	assert.Error(t, err)
+}
+
+// staticRegistry is a registry of signers whose every quorum has the same signers
+type staticRegistry []eth_common.Address
+
+func (r staticRegistry) GetQuorum(opts *bind.CallOpts, epoch *big.Int, quorumId *big.Int) ([]eth_common.Address, error) {
+	return r, nil
+}
+
+func (r staticRegistry) GetSigner(opts *bind.CallOpts, accounts []eth_common.Address) ([]da_signers.IDASignersSignerDetail, error) {
+	signers := make([]da_signers.IDASignersSignerDetail, len(accounts))
+	for i, account := range accounts {
+		signers[i] = da_signers.IDASignersSignerDetail{
+			Signer: account,
+			PkG1:   da_signers.BN254G1Point{X: big.NewInt(1), Y: big.NewInt(2)},
+			PkG2: da_signers.BN254G2Point{
+				X: [2]*big.Int{big.NewInt(1), big.NewInt(2)},
+				Y: [2]*big.Int{big.NewInt(3), big.NewInt(4)},
+			},
+		}
+	}
+	return signers, nil
+}
+
+// targetFinalizer reads the latest final block of the primary chain from a memory target
+type targetFinalizer struct {
+	Finalizer
+	target *dispatcher.MemoryTarget
+}
+
+func (f targetFinalizer) LatestFinalizedBlock() uint64 {
+	block, _ := f.target.FinalizedBlock(context.Background())
+	return block
+}
+
+func TestFallbackBatchesWaitOnTheirVenue(t *testing.T) {
+	ctx := context.Background()
+	logger := mock.NewLogger(false)
+	// both deployments number their epochs and quorums alike, but with other signers
+	primary, backup := dispatcher.NewMemoryTarget(1, 0), dispatcher.NewMemoryTarget(1, 0)
+	primarySigners := staticRegistry{eth_common.HexToAddress("0x11"), eth_common.HexToAddress("0x12")}
+	backupSigners := staticRegistry{eth_common.HexToAddress("0x21")}
+
+	fallback, err := NewConfirmationFallback(FallbackConfig{
+		RPCURL:                    "http://backup:8545",
+		DAEntranceContractAddress: "0x0000000000000000000000000000000000000002",
+		DASignersContractAddress:  "0x0000000000000000000000000000000000000003",
+		HaltTimeout:               time.Minute,
+		FinalityDepth:             2,
+	},
+		disperser.ConfirmationVenue{ChainID: 1, Contract: eth_common.HexToAddress("0x01")},
+		Venue{
+			ConfirmationVenue: disperser.ConfirmationVenue{ChainID: 2, Contract: eth_common.HexToAddress("0x02")},
+			Dispatcher:        backup,
+			Confirmer:         backup,
+			Blocks:            backup,
+			Signers:           backupSigners,
+		},
+		nil, nil, logger)
+	require.NoError(t, err)
+
+	signer := &SliceSigner{
+		// no block of the halted primary chain is final
+		Finalizer:    targetFinalizer{target: primary},
+		registry:     primarySigners,
+		confirmer:    primary,
+		metrics:      NewMetrics("9100", logger),
+		logger:       logger,
+		blobKeyCache: &disperser.BlobKeyCache{Key: make(map[[32]byte]bool)},
+		signerCache:  newSignerCache(),
+		finalityPoll: time.Millisecond,
+	}
+	primary.SetFinalizationBlocks(1 << 20)
+
+	disperse := func(venue *Venue, target *dispatcher.MemoryTarget, root byte) *SignInfo {
+		commitments := []*core.BlobCommitments{{StorageRoot: eth_common.BytesToHash([]byte{root}).Bytes()}}
+		txHash, err := target.DisperseBatch(ctx, [32]byte{root}, &core.BatchHeader{}, commitments, nil)
+		require.NoError(t, err)
+		return &SignInfo{ts: uint64(root), batch: &batch{EncodedBlobs: commitments, TxHash: txHash, venue: venue}}
+	}
+
+	// the primary chain halts, the next batch is dispersed to the fallback venue
+	now := time.Now()
+	fallback.observe(10, nil, now)
+	fallback.observe(10, nil, now.Add(time.Minute))
+	require.True(t, fallback.Halted())
+	info := disperse(fallback.route(), backup, 1)
+
+	// its upload is final once the fallback chain is deep enough on top of it
+	done := make(chan error, 1)
+	go func() { done <- signer.waitBatchTxFinalized(ctx, info) }()
+	select {
+	case err := <-done:
+		t.Fatalf("the upload was final before its depth on the fallback chain: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	backup.Mine()
+	backup.Mine()
+	require.NoError(t, <-done)
+
+	// and it is signed by the signers of the fallback deployment
+	assert.Equal(t, uint32(1), info.referenceBlock)
+	require.Len(t, info.signers, 1)
+	assert.Contains(t, info.signers, backupSigners[0])
+	assert.Equal(t, []int{0}, info.newBlobs)
+
+	// once the primary chain advances again, its batches are signed by its own signers,
+	// which the signers cached for the fallback deployment don't shadow
+	primary.SetFinalizationBlocks(0)
+	fallback.observe(11, nil, now.Add(2*time.Minute))
+	require.False(t, fallback.Halted())
+	info = disperse(fallback.route(), primary, 2)
+	require.NoError(t, signer.waitBatchTxFinalized(ctx, info))
+	require.Len(t, info.signers, 2)
+	assert.Contains(t, info.signers, primarySigners[0])
+	assert.Contains(t, info.signers, primarySigners[1])
+	assert.Len(t, signer.pendingBatchesToSign, 2)
+
+	// the signatures are submitted to the venue the data roots were uploaded to
+	venue := fallback.venueOf(signer.pendingBatchesToSign[0].batch.venue)
+	submission := &core.CommitRootSubmission{}
+	txHash, err := fallback.confirmerOf(venue, primary).(disperser.Dispatcher).SubmitAggregateSignatures(ctx, []*core.CommitRootSubmission{submission})
+	require.NoError(t, err)
+	_, err = backup.WaitForConfirmation(ctx, txHash)
+	assert.NoError(t, err)
+	assert.Len(t, backup.Submissions(), 1)
+	assert.Empty(t, primary.Submissions())
+}
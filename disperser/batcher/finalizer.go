@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"math/big"
 	"sync"
 	"time"
 
 	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/tracing"
 	"github.com/0glabs/0g-da-client/disperser"
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -19,17 +21,47 @@ import (
 const maxRetries = 3
 const baseDelay = 1 * time.Second
 
+// errNodeLagging is returned for the confirmations whose block the node doesn't know yet
+var errNodeLagging = errors.New("node is lagging behind the chain")
+
 // Finalizer runs periodically to finalize blobs that have been confirmed
 type Finalizer interface {
+	// Start runs FinalizeBlobs and refreshes the latest finalized block periodically until
+	// ctx is done
 	Start(ctx context.Context)
+	// FinalizeBlobs marks the confirmed blobs whose confirmation block is final as
+	// finalized. Blobs whose confirmation transaction was dropped by a reorg are rolled
+	// back to processing to be dispersed again. Blobs it fails to process are left
+	// confirmed for the next call.
 	FinalizeBlobs(ctx context.Context) error
+	// LatestFinalizedBlock returns the latest final block of the dispatch target. It never
+	// decreases, and is 0 until known.
 	LatestFinalizedBlock() uint64
+	// Reconcile converges the state left by interrupted finalizations, see ReconcileReport.
+	// It is safe to run any number of times, alongside FinalizeBlobs.
+	Reconcile(ctx context.Context) (ReconcileReport, error)
+}
+
+// ReconcileReport is the outcome of a reconciliation pass. The blob store is updated blob by
+// blob, so a finalization interrupted by a crash or a store failure leaves a batch with some
+// blobs finalized and the others confirmed, or finalized blobs not persisted to the kv db.
+type ReconcileReport struct {
+	// PartialBatches is the number of batches found partially finalized, whose confirmed
+	// blobs were Finalized since the finality of their batch is known
+	PartialBatches int
+	Finalized      int
+	// Requeued is the number of finalized blobs without confirmation info, which can't be
+	// persisted and are dispersed again
+	Requeued int
+	// Unpersisted is the number of finalized blobs still not persisted to the kv db after
+	// the pass, retried by the next rounds
+	Unpersisted int
 }
 
 type finalizer struct {
 	mu sync.RWMutex
 
-	timeout                    time.Duration
+	timeouts                   TimeoutConfig
 	loopInterval               time.Duration
 	blobStore                  disperser.BlobStore
 	ethClient                  common.EthClient
@@ -38,14 +70,27 @@ type finalizer struct {
 	logger                     common.Logger
 	latestFinalizedBlock       uint64
 	defaultFinalizedBlockCount uint64
-	kvStore                    *disperser.Store
-	ExpirationPollIntervalSec  uint64
-	blobKeyCache               *disperser.BlobKeyCache
+	// confirmationDepth replaces the finalized block of the chain with the block that many
+	// blocks below the head if set
+	confirmationDepth         uint64
+	kvStore                   *disperser.Store
+	ExpirationPollIntervalSec uint64
+	blobKeyCache              *disperser.BlobKeyCache
+	metrics                   *Metrics
+	// tracer continues the traces of the blobs dispersed with one, nil if they aren't traced
+	tracer *tracing.Tracer
+	events *disperser.BlobEventLog
+	// fallback tells when the blobs confirmed on the fallback venue are final, set by the
+	// batcher if there is one
+	fallback *ConfirmationFallback
+	// finality tells when the confirmations are final in place of the chain clients, set by
+	// the batcher if its Confirmer reads them from a dispatch target of its own
+	finality finalityReader
 }
 
-func NewFinalizer(timeout time.Duration, batcherConfig Config, blobStore disperser.BlobStore, ethClient common.EthClient, rpcClient common.RPCEthClient, logger common.Logger, kvStore *disperser.Store, blobKeyCache *disperser.BlobKeyCache) Finalizer {
+func NewFinalizer(timeouts TimeoutConfig, batcherConfig Config, blobStore disperser.BlobStore, ethClient common.EthClient, rpcClient common.RPCEthClient, logger common.Logger, kvStore *disperser.Store, blobKeyCache *disperser.BlobKeyCache, metrics *Metrics, tracer *tracing.Tracer) Finalizer {
 	return &finalizer{
-		timeout:                    timeout,
+		timeouts:                   timeouts,
 		loopInterval:               batcherConfig.FinalizerInterval,
 		blobStore:                  blobStore,
 		ethClient:                  ethClient,
@@ -54,19 +99,36 @@ func NewFinalizer(timeout time.Duration, batcherConfig Config, blobStore dispers
 		logger:                     logger,
 		latestFinalizedBlock:       0,
 		defaultFinalizedBlockCount: uint64(batcherConfig.FinalizedBlockCount),
+		confirmationDepth:          uint64(batcherConfig.ConfirmationDepth),
 		kvStore:                    kvStore,
 		ExpirationPollIntervalSec:  batcherConfig.ExpirationPollIntervalSec,
 		blobKeyCache:               blobKeyCache,
+		metrics:                    metrics,
+		tracer:                     tracer,
+		events:                     disperser.NewBlobEventLog(blobStore, logger),
 	}
 }
 
 func (f *finalizer) Start(ctx context.Context) {
-	go f.expireLoop()
+	f.events.Start(ctx)
+	go f.expireLoop(ctx)
+
+	report, err := f.Reconcile(ctx)
+	if err != nil {
+		f.logger.Error("[finalizer] failed to reconcile finalized blobs", "err", err)
+	} else if report != (ReconcileReport{}) {
+		f.logger.Warn("[finalizer] reconciled interrupted finalizations", "partialBatches", report.PartialBatches, "finalized", report.Finalized,
+			"requeued", report.Requeued, "unpersisted", report.Unpersisted)
+	}
 
 	go func() {
 		for {
 			f.updateFinalizedBlockNumber(ctx)
-			time.Sleep(time.Second * 5)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second * 5):
+			}
 		}
 	}()
 
@@ -90,27 +152,38 @@ func (f *finalizer) Start(ctx context.Context) {
 func (f *finalizer) updateFinalizedBlockNumber(ctx context.Context) {
 	var header = types.Header{}
 	var err error
+	if f.finality != nil {
+		f.updateTargetFinalizedBlock(ctx)
+		return
+	}
+	if f.confirmationDepth > 0 {
+		f.updateConfirmedBlockNumber(ctx)
+		return
+	}
 	for i := 0; i < maxRetries; i++ {
-		ctxWithTimeout, cancel := context.WithTimeout(ctx, f.timeout)
-		defer cancel()
-		err = f.rpcClient.CallContext(ctxWithTimeout, &header, "eth_getBlockByNumber", "finalized", false)
+		err = f.timeouts.Do(ctx, CallChainRead, func(ctx context.Context) error {
+			return f.rpcClient.CallContext(ctx, &header, "eth_getBlockByNumber", "finalized", false)
+		})
 		if err == nil {
 			break
 		}
 
 		retrySec := math.Pow(2, float64(i))
 		f.logger.Error("[finalizer] Finalizer: error getting latest finalized block", "err", err, "retrySec", retrySec)
-		time.Sleep(time.Duration(retrySec) * baseDelay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(retrySec) * baseDelay):
+		}
 	}
 
 	var blockNumber uint64
 	if err != nil {
 		f.logger.Error("[finalizer] error getting latest finalized block", "err", err)
 
-		ctxWithTimeout, cancel := context.WithTimeout(ctx, f.timeout)
-		defer cancel()
-
-		err := f.rpcClient.CallContext(ctxWithTimeout, &header, "eth_getBlockByNumber", "latest", false)
+		err := f.timeouts.Do(ctx, CallChainRead, func(ctx context.Context) error {
+			return f.rpcClient.CallContext(ctx, &header, "eth_getBlockByNumber", "latest", false)
+		})
 		if err != nil {
 			f.logger.Error("[finalizer] error getting latest block", "err", err)
 		} else {
@@ -128,6 +201,59 @@ func (f *finalizer) updateFinalizedBlockNumber(ctx context.Context) {
 	f.mu.Unlock()
 }
 
+// updateConfirmedBlockNumber sets the latest final block to the block confirmation depth
+// blocks below the head
+func (f *finalizer) updateConfirmedBlockNumber(ctx context.Context) {
+	var header = types.Header{}
+	err := f.timeouts.Do(ctx, CallChainRead, func(ctx context.Context) error {
+		return f.rpcClient.CallContext(ctx, &header, "eth_getBlockByNumber", "latest", false)
+	})
+	if err != nil {
+		f.logger.Error("[finalizer] error getting latest block", "err", err)
+		return
+	}
+	if header.Number.Uint64() < f.confirmationDepth {
+		return
+	}
+	blockNumber := header.Number.Uint64() - f.confirmationDepth
+
+	f.mu.Lock()
+	if blockNumber > f.latestFinalizedBlock {
+		f.latestFinalizedBlock = blockNumber
+		f.logger.Debug("[finalizer] latest confirmed block number updated", "number", f.latestFinalizedBlock, "depth", f.confirmationDepth)
+	}
+	f.mu.Unlock()
+}
+
+// updateTargetFinalizedBlock reads the latest final block from the dispatch target
+func (f *finalizer) updateTargetFinalizedBlock(ctx context.Context) {
+	var blockNumber uint64
+	err := f.timeouts.Do(ctx, CallChainRead, func(ctx context.Context) error {
+		var err error
+		blockNumber, err = f.finality.FinalizedBlock(ctx)
+		return err
+	})
+	if err != nil {
+		f.logger.Error("[finalizer] error getting the finalized block of the dispatch target", "err", err)
+		return
+	}
+
+	f.mu.Lock()
+	if blockNumber > f.latestFinalizedBlock {
+		f.latestFinalizedBlock = blockNumber
+		f.logger.Debug("[finalizer] latest finalized block number of the dispatch target updated", "number", f.latestFinalizedBlock)
+	}
+	f.mu.Unlock()
+}
+
+func (f *finalizer) setFallback(fallback *ConfirmationFallback) {
+	f.fallback = fallback
+}
+
+func (f *finalizer) setFinality(finality finalityReader) {
+	f.finality = finality
+}
+
 func (f *finalizer) LatestFinalizedBlock() uint64 {
 	f.mu.RLock()
 	blockNumber := f.latestFinalizedBlock
@@ -156,28 +282,72 @@ func (f *finalizer) FinalizeBlobs(ctx context.Context) error {
 	f.logger.Info("[finalizer] FinalizeBlobs: finalizing blobs", "numBlobs", len(metadatas), "finalizedBlockNumber", finalizedBlokNumber)
 
 	finalizedMetadatas := make([]*disperser.BlobMetadata, 0)
+	// the blobs of a batch share the confirmation transaction, looked up once
+	confirmations := make(map[gcommon.Hash]*txConfirmation)
+	reorged := make(map[gcommon.Hash]int)
 	for _, m := range metadatas {
 		blobKey := m.GetBlobKey()
-		confirmationMetadata, err := f.blobStore.GetBlobMetadata(ctx, blobKey)
+		finalizeStart := time.Now()
+		// the status index is eventually consistent, make sure the confirmation info is there
+		confirmationMetadata, err := f.blobStore.GetBlobMetadataWithOptions(ctx, blobKey, disperser.ReadOptions{
+			Consistency: disperser.EventualRead,
+			Condition:   disperser.StatusIs(disperser.Confirmed),
+		})
 		if err != nil {
 			f.logger.Error("[finalizer] FinalizeBlobs: error getting confirmed metadata", "blobKey", blobKey.String(), "err", err)
 			continue
 		}
 
-		// Leave as confirmed if the confirmation block is after the latest finalized block (not yet finalized)
-		if uint64(confirmationMetadata.ConfirmationInfo.ConfirmationBlockNumber) > finalizedBlokNumber {
+		if venue := confirmationMetadata.ConfirmationInfo.Venue; venue != nil && venue.Fallback {
+			// confirmed on the fallback chain, the finalized block of the primary one doesn't apply
+			if f.fallback == nil {
+				continue
+			}
+			final, err := f.fallback.final(ctx, confirmationMetadata.ConfirmationInfo.ConfirmationBlockNumber)
+			if err != nil {
+				f.logger.Error("[finalizer] FinalizeBlobs: error reading the head of the fallback chain", "err", err)
+				continue
+			}
+			if !final {
+				continue
+			}
+		} else if uint64(confirmationMetadata.ConfirmationInfo.ConfirmationBlockNumber) > finalizedBlokNumber {
+			// Leave as confirmed if the confirmation block is after the latest finalized block (not yet finalized)
 			continue
-		}
-
-		// confirmation block number may have changed due to reorg
-		if confirmationMetadata.ConfirmationInfo.ConfirmationTxnHash != gcommon.MaxHash {
-			confirmationBlockNumber, err := f.getTransactionBlockNumber(ctx, confirmationMetadata.ConfirmationInfo.ConfirmationTxnHash)
+		} else if txHash := confirmationMetadata.ConfirmationInfo.ConfirmationTxnHash; txHash != gcommon.MaxHash {
+			// confirmation block number may have changed due to reorg
+			confirmation, ok := confirmations[txHash]
+			if !ok {
+				confirmation = &txConfirmation{}
+				if f.finality != nil {
+					// the target knows its own transactions, a dropped one is reported as such
+					confirmation.blockNumber, confirmation.err = f.targetConfirmationBlock(ctx, txHash)
+				} else {
+					confirmation.blockNumber, confirmation.err = f.getTransactionBlockNumber(ctx, txHash)
+					if errors.Is(confirmation.err, ethereum.NotFound) {
+						confirmation.blockNumber, confirmation.err = f.canonicalBlockNumber(ctx, confirmationMetadata.ConfirmationInfo)
+					}
+				}
+				confirmations[txHash] = confirmation
+			}
+			confirmationBlockNumber, err := confirmation.blockNumber, confirmation.err
 			if errors.Is(err, ethereum.NotFound) {
-				// The confirmed block is finalized, but the transaction is not found. It means the transaction should be considered forked/invalid and the blob should be considered as failed.
-				err := f.blobStore.HandleBlobFailure(ctx, m, f.maxNumRetriesPerBlob)
+				// The confirmation block is final, but the transaction is not in the canonical
+				// block at its height: it was dropped by a reorg. The blob did nothing wrong, so it is dispersed again
+				// without counting a retry.
+				err := f.timeouts.Do(ctx, CallStoreWrite, func(ctx context.Context) error {
+					return f.blobStore.MarkBlobProcessing(ctx, blobKey)
+				})
+				tracing.End(f.tracer.StartChildAt(traceContextOf(m), "finalize", finalizeStart,
+					tracing.String("blob.key", blobKey.String()),
+					tracing.Bool("reorged", true),
+				), err)
 				if err != nil {
-					f.logger.Error("[finalizer] FinalizeBlobs: error marking blob as failed", "blobKey", blobKey.String(), "err", err)
+					f.logger.Error("[finalizer] FinalizeBlobs: error rolling back reorged blob", "blobKey", blobKey.String(), "err", err)
+					continue
 				}
+				f.events.Record(blobKey, disperser.BlobFailed, "reorged: "+txHash.Hex())
+				reorged[txHash]++
 				continue
 			}
 			if err != nil {
@@ -185,6 +355,13 @@ func (f *finalizer) FinalizeBlobs(ctx context.Context) error {
 				continue
 			}
 
+			if confirmationBlockNumber != uint64(confirmationMetadata.ConfirmationInfo.ConfirmationBlockNumber) && !confirmation.moved {
+				confirmation.moved = true
+				f.logger.Warn("[finalizer] confirmation transaction moved to another block by a reorg", "txHash", txHash, "from", confirmationMetadata.ConfirmationInfo.ConfirmationBlockNumber, "to", confirmationBlockNumber)
+				if f.metrics != nil {
+					f.metrics.IncrementReorgs("moved_batches", 1)
+				}
+			}
 			// Leave as confirmed if the reorged confirmation block is after the latest finalized block (not yet finalized)
 			if uint64(confirmationBlockNumber) > finalizedBlokNumber {
 				continue
@@ -193,20 +370,162 @@ func (f *finalizer) FinalizeBlobs(ctx context.Context) error {
 			confirmationMetadata.ConfirmationInfo.ConfirmationBlockNumber = uint32(confirmationBlockNumber)
 		}
 
-		err = f.blobStore.MarkBlobFinalized(ctx, blobKey)
+		err = f.markFinalized(ctx, blobKey)
+		tracing.End(f.tracer.StartChildAt(traceContextOf(m), "finalize", finalizeStart,
+			tracing.String("blob.key", blobKey.String()),
+			tracing.Int("block.number", int64(confirmationMetadata.ConfirmationInfo.ConfirmationBlockNumber)),
+			tracing.Int("finalized_block.number", int64(finalizedBlokNumber)),
+		), err)
+		if errors.Is(err, disperser.ErrStatusConflict) || errors.Is(err, disperser.ErrBlobNotFound) {
+			// moved on since it was read, by a reorg rollback or a concurrent finalization
+			f.logger.Warn("[finalizer] FinalizeBlobs: blob no longer confirmed, skipped", "blobKey", blobKey.String(), "err", err)
+			continue
+		}
 		if err != nil {
 			f.logger.Error("[finalizer] FinalizeBlobs: error marking blob as finalized", "blobKey", blobKey.String(), "err", err)
 			continue
 		}
+		f.recordFinalized(ctx, blobKey, "")
 
 		finalizedMetadatas = append(finalizedMetadatas, m)
 	}
 
-	f.PersistConfirmedBlobs(ctx, finalizedMetadatas)
+	for txHash, blobs := range reorged {
+		f.logger.Warn("[finalizer] confirmation transaction dropped by a reorg, dispersing its blobs again", "txHash", txHash, "numBlobs", blobs)
+		if f.metrics != nil {
+			f.metrics.IncrementReorgs("dropped_batches", 1)
+			f.metrics.IncrementReorgs("requeued_blobs", blobs)
+		}
+	}
+
+	if err := f.persistFinalizedBlobs(ctx, finalizedMetadatas); err != nil {
+		f.logger.Error("[finalizer] FinalizeBlobs: failed to persist finalized blobs to kv db, will retry", "err", err)
+	}
 	f.logger.Info("[finalizer] FinalizeBlobs: successfully processed all finalized blobs")
 	return nil
 }
 
+func (f *finalizer) markFinalized(ctx context.Context, blobKey disperser.BlobKey) error {
+	return f.timeouts.Do(ctx, CallStoreWrite, func(ctx context.Context) error {
+		return f.blobStore.MarkBlobFinalized(ctx, blobKey)
+	})
+}
+
+// recordFinalized appends the finalization of a blob to its history right away rather than
+// through the event log, so that the history moved to the kv db with the blob includes it
+func (f *finalizer) recordFinalized(ctx context.Context, blobKey disperser.BlobKey, detail string) {
+	event := disperser.BlobEvent{Type: disperser.BlobFinalized, Time: time.Now(), Detail: detail}
+	err := f.timeouts.Do(ctx, CallStoreWrite, func(ctx context.Context) error {
+		return f.blobStore.AppendBlobEvent(ctx, blobKey, event)
+	})
+	if err != nil {
+		f.logger.Debug("[finalizer] failed to record blob finalization", "blobKey", blobKey.String(), "err", err)
+	}
+}
+
+func (f *finalizer) Reconcile(ctx context.Context) (ReconcileReport, error) {
+	var report ReconcileReport
+	finalized, err := f.blobStore.GetBlobMetadataByStatus(ctx, disperser.Finalized)
+	if err != nil {
+		return report, fmt.Errorf("failed to get finalized blobs: %w", err)
+	}
+	confirmed, err := f.blobStore.GetBlobMetadataByStatus(ctx, disperser.Confirmed)
+	if err != nil {
+		return report, fmt.Errorf("failed to get confirmed blobs: %w", err)
+	}
+
+	// the batches with a finalized blob are final, identified by their header hash
+	finalBatches := make(map[[32]byte]struct{})
+	for _, m := range finalized {
+		blobKey := m.GetBlobKey()
+		if m.ConfirmationInfo == nil {
+			err := f.timeouts.Do(ctx, CallStoreWrite, func(ctx context.Context) error {
+				return f.blobStore.MarkBlobProcessing(ctx, blobKey)
+			})
+			if err != nil {
+				f.logger.Error("[finalizer] Reconcile: error requeuing finalized blob without confirmation info", "blobKey", blobKey.String(), "err", err)
+				continue
+			}
+			f.events.Record(blobKey, disperser.BlobFailed, "finalized without confirmation info")
+			report.Requeued++
+			continue
+		}
+		finalBatches[m.ConfirmationInfo.BatchHeaderHash] = struct{}{}
+	}
+
+	partial := make(map[[32]byte]struct{})
+	finalizedMetadatas := make([]*disperser.BlobMetadata, 0)
+	for _, m := range confirmed {
+		if m.ConfirmationInfo == nil {
+			continue
+		}
+		batchHeaderHash := m.ConfirmationInfo.BatchHeaderHash
+		if _, ok := finalBatches[batchHeaderHash]; !ok {
+			continue
+		}
+		partial[batchHeaderHash] = struct{}{}
+		blobKey := m.GetBlobKey()
+		err := f.markFinalized(ctx, blobKey)
+		if errors.Is(err, disperser.ErrStatusConflict) || errors.Is(err, disperser.ErrBlobNotFound) {
+			continue
+		}
+		if err != nil {
+			f.logger.Error("[finalizer] Reconcile: error finalizing blob of partially finalized batch", "blobKey", blobKey.String(), "err", err)
+			continue
+		}
+		f.recordFinalized(ctx, blobKey, "reconciled")
+		report.Finalized++
+		finalizedMetadatas = append(finalizedMetadatas, m)
+	}
+	report.PartialBatches = len(partial)
+
+	if err := f.persistFinalizedBlobs(ctx, finalizedMetadatas); err != nil {
+		f.logger.Warn("[finalizer] Reconcile: failed to persist finalized blobs to kv db, will retry", "err", err)
+	}
+	unpersisted, err := f.blobStore.GetBlobMetadataByStatus(ctx, disperser.Finalized)
+	if err != nil {
+		return report, fmt.Errorf("failed to get finalized blobs: %w", err)
+	}
+	report.Unpersisted = len(unpersisted)
+	return report, nil
+}
+
+// persistFinalizedBlobs persists the blobs finalized in this round along with the finalized
+// blobs left in the blob store by the previous rounds, whose kv writes failed or could not
+// be verified. A finalized blob stays in the blob store until its kv write is verified.
+func (f *finalizer) persistFinalizedBlobs(ctx context.Context, finalizedMetadatas []*disperser.BlobMetadata) error {
+	pending, err := f.blobStore.GetBlobMetadataByStatus(ctx, disperser.Finalized)
+	if err != nil {
+		// the blobs finalized in this round are still persisted
+		f.logger.Error("[finalizer] failed to get finalized blobs pending kv write", "err", err)
+	}
+
+	metadatas := finalizedMetadatas
+	seen := make(map[disperser.BlobKey]struct{}, len(finalizedMetadatas))
+	for _, m := range finalizedMetadatas {
+		seen[m.GetBlobKey()] = struct{}{}
+	}
+	for _, m := range pending {
+		if _, ok := seen[m.GetBlobKey()]; ok {
+			continue
+		}
+		if m.ConfirmationInfo == nil {
+			f.logger.Warn("[finalizer] finalized blob has no confirmation info", "blobKey", m.GetBlobKey().String())
+			continue
+		}
+		seen[m.GetBlobKey()] = struct{}{}
+		metadatas = append(metadatas, m)
+	}
+	if len(metadatas) > len(finalizedMetadatas) {
+		f.logger.Info("[finalizer] retrying kv writes of finalized blobs", "numBlobs", len(metadatas)-len(finalizedMetadatas))
+	}
+
+	return f.PersistConfirmedBlobs(ctx, metadatas)
+}
+
+// PersistConfirmedBlobs writes the retrieval metadata and content of finalized blobs to the
+// kv db, reads them back, and removes the verified blobs from the blob store. Blobs that
+// fail are left in the blob store as finalized to be retried.
 func (f *finalizer) PersistConfirmedBlobs(ctx context.Context, metadatas []*disperser.BlobMetadata) error {
 	if len(metadatas) == 0 {
 		return nil
@@ -214,7 +533,10 @@ func (f *finalizer) PersistConfirmedBlobs(ctx context.Context, metadatas []*disp
 
 	keys := make([][]byte, 0)
 	values := make([][]byte, 0)
+	confirmations := make([][]byte, 0)
 	blobs := make([][]byte, 0)
+	written := make([]*disperser.BlobMetadata, 0)
+	retrieveMetadatas := make([]*disperser.BlobRetrieveMetadata, 0)
 	for _, metadata := range metadatas {
 		retrieveMetadata := disperser.BlobRetrieveMetadata{
 			DataRoot: metadata.ConfirmationInfo.DataRoot,
@@ -222,49 +544,159 @@ func (f *finalizer) PersistConfirmedBlobs(ctx context.Context, metadatas []*disp
 			QuorumId: metadata.ConfirmationInfo.QuorumId,
 		}
 
-		f.blobKeyCache.Add(retrieveMetadata.Hash(), retrieveMetadata.Epoch)
 		val, err := retrieveMetadata.Serialize()
 		if err != nil {
-			return errors.WithMessage(err, "failed to serialize retrieve metadata")
+			f.logger.Error("[finalizer] failed to serialize retrieve metadata", "blobKey", metadata.GetBlobKey().String(), "err", err)
+			continue
+		}
+		blobConfirmation := &disperser.BlobConfirmation{
+			BlockNumber: metadata.ConfirmationInfo.ConfirmationBlockNumber,
+			ConfirmedAt: metadata.ConfirmationInfo.ConfirmedAt,
+			Info:        metadata.ConfirmationInfo,
+		}
+		if metadata.RequestMetadata != nil {
+			blobConfirmation.Tags = metadata.RequestMetadata.Tags
+		}
+		// the history is removed with the blob from the blob store, so it moves to the kv db
+		events, err := f.blobStore.GetBlobEvents(ctx, metadata.GetBlobKey())
+		if err != nil {
+			f.logger.Warn("[finalizer] failed to get blob history, persisting the blob without it", "blobKey", metadata.GetBlobKey().String(), "err", err)
+		}
+		blobConfirmation.Events = disperser.SortBlobEvents(events)
+		confirmation, err := blobConfirmation.Serialize()
+		if err != nil {
+			f.logger.Error("[finalizer] failed to serialize confirmation", "blobKey", metadata.GetBlobKey().String(), "err", err)
+			continue
 		}
-
-		key := []byte(metadata.GetBlobKey().String())
-		keys = append(keys, key)
-		values = append(values, val)
 
 		b, err := f.blobStore.GetBlobContent(ctx, metadata)
 		if err != nil {
-			return errors.WithMessage(err, "failed to get blob content")
+			f.logger.Error("[finalizer] failed to get blob content", "blobKey", metadata.GetBlobKey().String(), "err", err)
+			continue
 		}
+
+		keys = append(keys, []byte(metadata.GetBlobKey().String()))
+		values = append(values, val)
+		confirmations = append(confirmations, confirmation)
 		blobs = append(blobs, b)
+		written = append(written, metadata)
+		retrieveMetadatas = append(retrieveMetadatas, &retrieveMetadata)
+	}
+	if len(written) == 0 {
+		return fmt.Errorf("failed to prepare any of %d blobs for kv db", len(metadatas))
 	}
 
-	_, err := f.kvStore.StoreMetadataBatch(ctx, keys, values, blobs)
+	_, err := f.kvStore.StoreMetadataBatch(ctx, keys, values, confirmations, blobs)
 	if err != nil {
 		return errors.WithMessage(err, "failed to save retrieve metadata to kv db")
 	}
 
+	unverified, err := f.kvStore.VerifyMetadataBatch(ctx, keys, values, blobs)
+	if err != nil {
+		return errors.WithMessage(err, "failed to verify retrieve metadata in kv db")
+	}
+	verified := make([]*disperser.BlobMetadata, 0, len(written))
+	next := 0
+	for idx, metadata := range written {
+		if next < len(unverified) && unverified[next] == idx {
+			next++
+			f.logger.Warn("[finalizer] blob missing from kv db after write", "blobKey", metadata.GetBlobKey().String())
+			continue
+		}
+		f.blobKeyCache.Add(retrieveMetadatas[idx].Hash(), retrieveMetadatas[idx].Epoch)
+		verified = append(verified, metadata)
+	}
+
 	f.logger.Info("[finalizer] removing confirmed blobs")
-	for _, metadata := range metadatas {
+	for _, metadata := range verified {
 		f.logger.Info("[finalizer] removing blob", "blob key", metadata.GetBlobKey().String())
-		err := f.blobStore.RemoveBlob(ctx, metadata)
+		err := f.timeouts.Do(ctx, CallStoreWrite, func(ctx context.Context) error {
+			return f.blobStore.RemoveBlob(ctx, metadata)
+		})
 		if err != nil {
 			f.logger.Warn("[finalizer] failed to remove blob", "error", err)
 		}
 	}
 	f.logger.Info("[finalizer] confirmed blobs removed")
+
+	if failed := len(metadatas) - len(verified); failed > 0 {
+		return fmt.Errorf("%d of %d blobs not persisted to kv db", failed, len(metadatas))
+	}
 	return nil
 }
 
+// txConfirmation is the block a confirmation transaction was found in
+type txConfirmation struct {
+	blockNumber uint64
+	err         error
+	// moved is set once the move of the transaction to another block is reported
+	moved bool
+}
+
+// canonicalBlockNumber returns the block number of a confirmation whose receipt the node
+// doesn't know, if the block it was included in is still canonical. A node lagging behind
+// the chain, or whose transaction index is behind, doesn't know the receipt either, so the
+// confirmation is only reported dropped, with ethereum.NotFound, once the canonical block at
+// its height is known and differs from the recorded one. The blobs confirmed before the
+// block hash was recorded are looked up in the transactions of the block instead.
+func (f *finalizer) canonicalBlockNumber(ctx context.Context, info *disperser.ConfirmationInfo) (uint64, error) {
+	number := new(big.Int).SetUint64(uint64(info.ConfirmationBlockNumber))
+	if info.ConfirmationBlockHash != (gcommon.Hash{}) {
+		var header *types.Header
+		err := f.timeouts.Do(ctx, CallChainRead, func(ctx context.Context) error {
+			var err error
+			header, err = f.ethClient.HeaderByNumber(ctx, number)
+			return err
+		})
+		if errors.Is(err, ethereum.NotFound) {
+			return 0, fmt.Errorf("%w: block %d is not known to the node yet", errNodeLagging, number)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("Finalizer: error getting header of block %d: %w", number, err)
+		}
+		if header.Hash() != info.ConfirmationBlockHash {
+			return 0, ethereum.NotFound
+		}
+		return number.Uint64(), nil
+	}
+
+	var block *types.Block
+	err := f.timeouts.Do(ctx, CallChainRead, func(ctx context.Context) error {
+		var err error
+		block, err = f.ethClient.BlockByNumber(ctx, number)
+		return err
+	})
+	if errors.Is(err, ethereum.NotFound) {
+		return 0, fmt.Errorf("%w: block %d is not known to the node yet", errNodeLagging, number)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("Finalizer: error getting block %d: %w", number, err)
+	}
+	if block.Transaction(info.ConfirmationTxnHash) == nil {
+		return 0, ethereum.NotFound
+	}
+	return number.Uint64(), nil
+}
+
+func (f *finalizer) targetConfirmationBlock(ctx context.Context, txHash gcommon.Hash) (uint64, error) {
+	var blockNumber uint64
+	err := f.timeouts.Do(ctx, CallChainRead, func(ctx context.Context) error {
+		var err error
+		blockNumber, err = f.finality.ConfirmationBlock(ctx, txHash)
+		return err
+	})
+	return blockNumber, err
+}
+
 func (f *finalizer) getTransactionBlockNumber(ctx context.Context, hash gcommon.Hash) (uint64, error) {
-	var ctxWithTimeout context.Context
-	var cancel context.CancelFunc
 	var txReceipt *types.Receipt
 	var err error
 	for i := 0; i < maxRetries; i++ {
-		ctxWithTimeout, cancel = context.WithTimeout(ctx, f.timeout)
-		defer cancel()
-		txReceipt, err = f.ethClient.TransactionReceipt(ctxWithTimeout, hash)
+		err = f.timeouts.Do(ctx, CallChainRead, func(ctx context.Context) error {
+			var err error
+			txReceipt, err = f.ethClient.TransactionReceipt(ctx, hash)
+			return err
+		})
 		if err == nil {
 			break
 		}
@@ -286,14 +718,19 @@ func (f *finalizer) getTransactionBlockNumber(ctx context.Context, hash gcommon.
 }
 
 // The expireLoop is a loop that is run once per configured second(s) while the node
-// is running. It scans for expired blobs and removes them from the local database.
-func (f *finalizer) expireLoop() {
+// is running. It scans for expired blobs and removes them from the local database until
+// ctx is done.
+func (f *finalizer) expireLoop(ctx context.Context) {
 	f.logger.Info("[finalizer] start expireLoop goroutine in background to periodically remove expired blobs on the node")
 	ticker := time.NewTicker(time.Duration(f.ExpirationPollIntervalSec) * time.Second)
 	defer ticker.Stop()
 
 	for {
-		<-ticker.C
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 
 		// We cap the time the deletion function can run, to make sure there is no overlapping
 		// between loops and the garbage collection doesn't take too much resource.
@@ -0,0 +1,275 @@
+package batcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"math/big"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/ethereum/go-ethereum"
+	gcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receiptClient returns the receipts of the given transactions and NotFound for the others,
+// and the given canonical headers, empty blocks, NotFound for the other block numbers
+type receiptClient struct {
+	mock.MockEthClient
+	blocks  map[gcommon.Hash]int64
+	headers map[uint64]*types.Header
+	calls   int
+}
+
+func (c *receiptClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if header, ok := c.headers[number.Uint64()]; ok {
+		return header, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func (c *receiptClient) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	if header, ok := c.headers[number.Uint64()]; ok {
+		return types.NewBlockWithHeader(header), nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func (c *receiptClient) TransactionReceipt(ctx context.Context, txHash gcommon.Hash) (*types.Receipt, error) {
+	c.calls++
+	if block, ok := c.blocks[txHash]; ok {
+		return &types.Receipt{BlockNumber: big.NewInt(block)}, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func TestFinalizeReorgedBlobs(t *testing.T) {
+	ctx := context.Background()
+	logger := mock.NewLogger(false)
+	store := memorydb.NewBlobStore(core.MaxBlobSize*4, logger)
+
+	requestedAt := uint64(time.Now().UnixNano())
+	confirm := func(txHash gcommon.Hash, blockNumber uint32) disperser.BlobKey {
+		requestedAt++
+		key, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("blob")}, requestedAt)
+		require.NoError(t, err)
+		metadata, err := store.GetBlobMetadata(ctx, key)
+		require.NoError(t, err)
+		_, err = store.MarkBlobConfirmed(ctx, metadata, &disperser.ConfirmationInfo{
+			ConfirmationTxnHash:     txHash,
+			ConfirmationBlockNumber: blockNumber,
+		})
+		require.NoError(t, err)
+		return key
+	}
+	dropped := []disperser.BlobKey{confirm(gcommon.HexToHash("0x1"), 10), confirm(gcommon.HexToHash("0x1"), 10)}
+	moved := confirm(gcommon.HexToHash("0x2"), 10)
+
+	ethClient := &receiptClient{
+		blocks:  map[gcommon.Hash]int64{gcommon.HexToHash("0x2"): 12},
+		headers: map[uint64]*types.Header{10: {Number: big.NewInt(10)}},
+	}
+
+	f := NewFinalizer(TimeoutConfig{}, Config{}, store, ethClient, nil, logger, nil, nil, nil, nil).(*finalizer)
+	f.latestFinalizedBlock = 11
+	require.NoError(t, f.FinalizeBlobs(ctx))
+
+	// the receipt of a transaction is looked up once per round
+	assert.Equal(t, 2, ethClient.calls)
+	for _, key := range dropped {
+		metadata, err := store.GetBlobMetadata(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, disperser.Processing, metadata.BlobStatus)
+		assert.Zero(t, metadata.NumRetries)
+		assert.Nil(t, metadata.ConfirmationInfo)
+	}
+	// the transaction moved past the finalized block
+	metadata, err := store.GetBlobMetadata(ctx, moved)
+	require.NoError(t, err)
+	assert.Equal(t, disperser.Confirmed, metadata.BlobStatus)
+}
+
+func TestFinalizeOnLaggingNode(t *testing.T) {
+	ctx := context.Background()
+	logger := mock.NewLogger(false)
+	store := memorydb.NewBlobStore(core.MaxBlobSize*4, logger)
+
+	canonical := &types.Header{Number: big.NewInt(10)}
+	requestedAt := uint64(time.Now().UnixNano())
+	confirm := func(txHash gcommon.Hash, blockNumber uint32, blockHash gcommon.Hash) disperser.BlobKey {
+		requestedAt++
+		key, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("blob")}, requestedAt)
+		require.NoError(t, err)
+		metadata, err := store.GetBlobMetadata(ctx, key)
+		require.NoError(t, err)
+		_, err = store.MarkBlobConfirmed(ctx, metadata, &disperser.ConfirmationInfo{
+			ConfirmationTxnHash:     txHash,
+			ConfirmationBlockNumber: blockNumber,
+			ConfirmationBlockHash:   blockHash,
+		})
+		require.NoError(t, err)
+		return key
+	}
+	// the node knows neither receipt: its transaction index lags behind for the first, whose
+	// block is canonical, it hasn't synced the block of the second yet
+	indexLagging := confirm(gcommon.HexToHash("0x1"), 10, canonical.Hash())
+	blockLagging := confirm(gcommon.HexToHash("0x2"), 11, gcommon.HexToHash("0xb11"))
+	// the block of the third was replaced
+	reorged := confirm(gcommon.HexToHash("0x3"), 10, gcommon.HexToHash("0xb10"))
+
+	kvStore, err := disperser.NewLevelDBStore(t.TempDir(), 3600, logger)
+	require.NoError(t, err)
+	ethClient := &receiptClient{headers: map[uint64]*types.Header{10: canonical}}
+	f := NewFinalizer(TimeoutConfig{}, Config{}, store, ethClient, nil, logger, kvStore, &disperser.BlobKeyCache{Key: make(map[[32]byte]bool)}, nil, nil).(*finalizer)
+	f.latestFinalizedBlock = 11
+	require.NoError(t, f.FinalizeBlobs(ctx))
+
+	// finalized and persisted to the kv db
+	_, err = store.GetBlobMetadata(ctx, indexLagging)
+	assert.ErrorIs(t, err, disperser.ErrBlobNotFound)
+	metadata, err := store.GetBlobMetadata(ctx, blockLagging)
+	require.NoError(t, err)
+	assert.Equal(t, disperser.Confirmed, metadata.BlobStatus)
+	assert.NotNil(t, metadata.ConfirmationInfo)
+	metadata, err = store.GetBlobMetadata(ctx, reorged)
+	require.NoError(t, err)
+	assert.Equal(t, disperser.Processing, metadata.BlobStatus)
+	assert.Nil(t, metadata.ConfirmationInfo)
+}
+
+func TestReconcilePartiallyFinalizedBatch(t *testing.T) {
+	ctx := context.Background()
+	logger := mock.NewLogger(false)
+	store := memorydb.NewBlobStore(core.MaxBlobSize*4, logger)
+	kvStore, err := disperser.NewLevelDBStore(t.TempDir(), 3600, logger)
+	require.NoError(t, err)
+
+	requestedAt := uint64(time.Now().UnixNano())
+	confirm := func(batchHeaderHash [32]byte) disperser.BlobKey {
+		requestedAt++
+		blob := &core.Blob{Data: []byte("blob"), RequestHeader: core.BlobRequestHeader{Tags: map[string]string{"rollup": "a"}}}
+		key, err := store.StoreBlob(ctx, blob, requestedAt)
+		require.NoError(t, err)
+		metadata, err := store.GetBlobMetadata(ctx, key)
+		require.NoError(t, err)
+		_, err = store.MarkBlobConfirmed(ctx, metadata, &disperser.ConfirmationInfo{
+			BatchHeaderHash:         batchHeaderHash,
+			ConfirmationTxnHash:     gcommon.HexToHash("0x1"),
+			ConfirmationBlockNumber: 10,
+		})
+		require.NoError(t, err)
+		return key
+	}
+	// the finalization of the batch was interrupted after its first blob
+	finalized, confirmed := confirm([32]byte{1}), confirm([32]byte{1})
+	other := confirm([32]byte{2})
+	require.NoError(t, store.MarkBlobFinalized(ctx, finalized))
+	// finalizing again is a no-op
+	require.NoError(t, store.MarkBlobFinalized(ctx, finalized))
+
+	f := NewFinalizer(TimeoutConfig{}, Config{}, store, nil, nil, logger, kvStore, &disperser.BlobKeyCache{Key: make(map[[32]byte]bool)}, nil, nil).(*finalizer)
+	report, err := f.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, ReconcileReport{PartialBatches: 1, Finalized: 1}, report)
+	for _, key := range []disperser.BlobKey{finalized, confirmed} {
+		_, err := store.GetBlobMetadata(ctx, key)
+		assert.ErrorIs(t, err, disperser.ErrBlobNotFound)
+		assert.ErrorIs(t, store.MarkBlobFinalized(ctx, key), disperser.ErrBlobNotFound)
+
+		// the tags are kept in the kv store
+		data, err := kvStore.GetConfirmation(ctx, []byte(key.String()))
+		require.NoError(t, err)
+		confirmation, err := new(disperser.BlobConfirmation).Deserialize(data)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"rollup": "a"}, confirmation.Tags)
+		if key == confirmed {
+			// and the history, which is removed from the blob store
+			require.NotEmpty(t, confirmation.Events)
+			last := confirmation.Events[len(confirmation.Events)-1]
+			assert.Equal(t, disperser.BlobFinalized, last.Type)
+			assert.Equal(t, "reconciled", last.Detail)
+		}
+	}
+	metadata, err := store.GetBlobMetadata(ctx, other)
+	require.NoError(t, err)
+	assert.Equal(t, disperser.Confirmed, metadata.BlobStatus)
+
+	// the state converged
+	report, err = f.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, report)
+
+	require.NoError(t, store.MarkBlobProcessing(ctx, other))
+	assert.ErrorIs(t, store.MarkBlobFinalized(ctx, other), disperser.ErrStatusConflict)
+}
+
+// targetFinality reads the finality of the confirmations from a dispatch target
+type targetFinality struct {
+	finalized uint64
+	blocks    map[gcommon.Hash]uint64
+}
+
+func (t *targetFinality) FinalizedBlock(ctx context.Context) (uint64, error) {
+	return t.finalized, nil
+}
+
+func (t *targetFinality) ConfirmationBlock(ctx context.Context, txHash gcommon.Hash) (uint64, error) {
+	if block, ok := t.blocks[txHash]; ok {
+		return block, nil
+	}
+	return 0, ethereum.NotFound
+}
+
+func TestFinalizeOnDispatchTarget(t *testing.T) {
+	ctx := context.Background()
+	logger := mock.NewLogger(false)
+	store := memorydb.NewBlobStore(core.MaxBlobSize*4, logger)
+
+	requestedAt := uint64(time.Now().UnixNano())
+	confirm := func(txHash gcommon.Hash) disperser.BlobKey {
+		requestedAt++
+		key, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("blob")}, requestedAt)
+		require.NoError(t, err)
+		metadata, err := store.GetBlobMetadata(ctx, key)
+		require.NoError(t, err)
+		_, err = store.MarkBlobConfirmed(ctx, metadata, &disperser.ConfirmationInfo{
+			ConfirmationTxnHash:     txHash,
+			ConfirmationBlockNumber: 10,
+		})
+		require.NoError(t, err)
+		return key
+	}
+	final := confirm(gcommon.HexToHash("0x1"))
+	pending := confirm(gcommon.HexToHash("0x2"))
+	dropped := confirm(gcommon.HexToHash("0x3"))
+
+	target := &targetFinality{
+		finalized: 11,
+		blocks:    map[gcommon.Hash]uint64{gcommon.HexToHash("0x1"): 10, gcommon.HexToHash("0x2"): 12},
+	}
+	kvStore, err := disperser.NewLevelDBStore(t.TempDir(), 3600, logger)
+	require.NoError(t, err)
+	// no chain clients, the target tells the finality
+	f := NewFinalizer(TimeoutConfig{}, Config{}, store, nil, nil, logger, kvStore, &disperser.BlobKeyCache{Key: make(map[[32]byte]bool)}, nil, nil).(*finalizer)
+	f.setFinality(target)
+	f.updateFinalizedBlockNumber(ctx)
+	assert.Equal(t, uint64(11), f.LatestFinalizedBlock())
+	require.NoError(t, f.FinalizeBlobs(ctx))
+
+	// finalized and persisted to the kv db
+	_, err = store.GetBlobMetadata(ctx, final)
+	assert.ErrorIs(t, err, disperser.ErrBlobNotFound)
+	metadata, err := store.GetBlobMetadata(ctx, pending)
+	require.NoError(t, err)
+	assert.Equal(t, disperser.Confirmed, metadata.BlobStatus)
+	metadata, err = store.GetBlobMetadata(ctx, dropped)
+	require.NoError(t, err)
+	assert.Equal(t, disperser.Processing, metadata.BlobStatus)
+	assert.Nil(t, metadata.ConfirmationInfo)
+}
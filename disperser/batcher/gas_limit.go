@@ -0,0 +1,93 @@
+package batcher
+
+import "fmt"
+
+// Gas model of the transactions confirming a batch. The batch is uploaded with one
+// submitOriginalData call, and each of its blobs is then verified once per quorum in a
+// submitVerifiedCommitRoots call.
+const (
+	txBaseGas = 21000
+	// calldataGasPerByte assumes non-zero bytes, overestimating the zero ones
+	calldataGasPerByte = 16
+	// uploadGasPerBlob covers storing a data root and emitting its DataUpload event
+	uploadGasPerBlob = 45000
+	// verifyGasPerSubmission covers the pairing check of an aggregate signature and storing
+	// the verified commitment
+	verifyGasPerSubmission = 160000
+	// aggregationGasPerSigner covers loading a signer key and adding it to the aggregate
+	aggregationGasPerSigner = 2500
+	// submissionWords is the number of abi words of a commit root submission, without its
+	// quorum bitmap: data root, epoch, quorum id, erasure commitment (2), bitmap offset and
+	// length, aggregate public key (4), signature (2) and the tuple offset
+	submissionWords = 14
+)
+
+// BatchGasConfig caps the number of blobs in a batch so that the estimated gas of the
+// transactions confirming it stays below the block gas target
+type BatchGasConfig struct {
+	// BlockGasTarget is the gas the confirmation transactions must stay below, 0 to disable
+	BlockGasTarget uint64
+	// NumQuorums is the number of quorums each blob is verified in
+	NumQuorums uint64
+	// NumSigners is the number of signers per quorum, which sizes the quorum bitmaps and
+	// the key aggregation
+	NumSigners uint64
+}
+
+func (c BatchGasConfig) Enabled() bool {
+	return c.BlockGasTarget > 0
+}
+
+// uploadGas estimates the gas of uploading a batch of n blobs
+func (c BatchGasConfig) uploadGas(n uint64) uint64 {
+	// selector, array offset and length, then one data root per blob
+	calldata := 4 + 32*2 + 32*n
+	return txBaseGas + calldata*calldataGasPerByte + n*uploadGasPerBlob
+}
+
+// verifyGas estimates the gas of verifying the blobs of a batch of n blobs in all quorums
+func (c BatchGasConfig) verifyGas(n uint64) uint64 {
+	bitmapWords := (c.NumSigners + 255) / 256
+	submissionBytes := 32 * (submissionWords + bitmapWords)
+	perSubmission := submissionBytes*calldataGasPerByte + verifyGasPerSubmission + c.NumSigners*aggregationGasPerSigner
+	calldata := uint64(4 + 32*2)
+	return txBaseGas + calldata*calldataGasPerByte + n*c.NumQuorums*perSubmission
+}
+
+// EstimateGas returns the estimated gas of the costlier confirmation transaction of a
+// batch of n blobs
+func (c BatchGasConfig) EstimateGas(n uint64) uint64 {
+	upload, verify := c.uploadGas(n), c.verifyGas(n)
+	if upload > verify {
+		return upload
+	}
+	return verify
+}
+
+// MaxBlobs returns the largest number of blobs a batch can hold within the block gas
+// target, 0 if the cap is disabled
+func (c BatchGasConfig) MaxBlobs() (int, error) {
+	if !c.Enabled() {
+		return 0, nil
+	}
+	if c.NumQuorums == 0 {
+		return 0, fmt.Errorf("batch gas estimate needs at least one quorum")
+	}
+	if c.EstimateGas(1) > c.BlockGasTarget {
+		return 0, fmt.Errorf("block gas target %d is below the estimated gas of a single blob batch (%d)", c.BlockGasTarget, c.EstimateGas(1))
+	}
+	// the estimate is linear in n, so bisect on it
+	lo, hi := uint64(1), uint64(2)
+	for c.EstimateGas(hi) <= c.BlockGasTarget {
+		lo, hi = hi, hi*2
+	}
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		if c.EstimateGas(mid) <= c.BlockGasTarget {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return int(lo), nil
+}
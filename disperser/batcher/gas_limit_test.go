@@ -0,0 +1,30 @@
+package batcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchGasMaxBlobs(t *testing.T) {
+	n, err := BatchGasConfig{}.MaxBlobs()
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	config := BatchGasConfig{BlockGasTarget: 15_000_000, NumQuorums: 1, NumSigners: 64}
+	n, err = config.MaxBlobs()
+	require.NoError(t, err)
+	assert.LessOrEqual(t, config.EstimateGas(uint64(n)), config.BlockGasTarget)
+	assert.Greater(t, config.EstimateGas(uint64(n+1)), config.BlockGasTarget)
+
+	// more quorums and signers make verification costlier
+	config.NumQuorums, config.NumSigners = 2, 300
+	m, err := config.MaxBlobs()
+	require.NoError(t, err)
+	assert.Less(t, m, n)
+
+	config.BlockGasTarget = 100_000
+	_, err = config.MaxBlobs()
+	assert.Error(t, err)
+}
@@ -0,0 +1,151 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/healthcheck"
+	"github.com/0glabs/0g-da-client/disperser"
+)
+
+// HealthConfig bounds the backlog and the failures of the batcher reported as healthy
+type HealthConfig struct {
+	// MaxConfirmerBacklog is the number of batches waiting for confirmation above which the
+	// confirmer is degraded, never if 0
+	MaxConfirmerBacklog int
+	// MaxDispersalFailures is the number of consecutive failed dispersals from which the
+	// dispersal is down, never if 0. It is degraded from the first one.
+	MaxDispersalFailures int
+}
+
+// dispersalHealth tracks the consecutive failures of the dispersal of the batches
+type dispersalHealth struct {
+	mu          sync.Mutex
+	failures    int
+	lastErr     error
+	lastSuccess time.Time
+}
+
+func (d *dispersalHealth) record(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err != nil {
+		d.failures++
+		d.lastErr = err
+		return
+	}
+	d.failures = 0
+	d.lastErr = nil
+	d.lastSuccess = time.Now()
+}
+
+func (d *dispersalHealth) check(maxFailures int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.failures == 0 {
+		return nil
+	}
+	msg := fmt.Sprintf("%d consecutive failed dispersals, last success at %s: %v", d.failures, d.lastSuccess.Format(time.RFC3339), d.lastErr)
+	if maxFailures > 0 && d.failures >= maxFailures {
+		return errors.New(msg)
+	}
+	return healthcheck.Degraded("%s", msg)
+}
+
+// storageNodeHealth tracks how the storage nodes served the last signing round of a batch:
+// the operators whose signing request failed and the percentage of slices signed of the
+// blob signed the least
+type storageNodeHealth struct {
+	mu            sync.Mutex
+	recorded      bool
+	failed        int
+	signers       int
+	percentSigned uint8
+}
+
+// record records a signing round in which failed of the signers dispersed to failed to sign
+func (h *storageNodeHealth) record(failed int, signers int, percentSigned uint8) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.recorded = true
+	h.failed = failed
+	h.signers = signers
+	h.percentSigned = percentSigned
+}
+
+// check is down if no slice was signed in the last round, and degraded if some operators
+// failed to sign
+func (h *storageNodeHealth) check() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.recorded {
+		return nil
+	}
+	msg := fmt.Sprintf("%d of %d storage nodes failed to sign the last batch, %d%% of the slices signed", h.failed, h.signers, h.percentSigned)
+	if h.percentSigned == 0 {
+		return errors.New(msg)
+	}
+	if h.failed > 0 {
+		return healthcheck.Degraded("%s", msg)
+	}
+	return nil
+}
+
+// AddHealthChecks adds the checks of the stages of the batcher to monitor: the dispersal of
+// the batches, the signing of their slices by the storage nodes and the backlog of the
+// confirmer
+func (b *Batcher) AddHealthChecks(monitor *healthcheck.Monitor) {
+	monitor.Add("dispersal", func(ctx context.Context) error {
+		return b.dispersal.check(b.Health.MaxDispersalFailures)
+	})
+	monitor.Add("storage_nodes", func(ctx context.Context) error {
+		return b.sliceSigner.storageNodes.check()
+	})
+	monitor.Add("confirmer", func(ctx context.Context) error {
+		backlog := b.confirmer.PendingBatches()
+		if b.Health.MaxConfirmerBacklog > 0 && backlog > b.Health.MaxConfirmerBacklog {
+			return healthcheck.Degraded("%d batches waiting for confirmation", backlog)
+		}
+		return nil
+	})
+}
+
+// EncodersHealth returns the check of the encoders at sockets, degraded if some of them
+// are unreachable and down if all of them are
+func EncodersHealth(sockets []string, probe func(ctx context.Context, addr string) error) healthcheck.Check {
+	return func(ctx context.Context) error {
+		unreachable := make([]string, 0)
+		for _, socket := range sockets {
+			if err := probe(ctx, socket); err != nil {
+				unreachable = append(unreachable, fmt.Sprintf("%s: %v", socket, err))
+			}
+		}
+		switch {
+		case len(unreachable) == 0:
+			return nil
+		case len(unreachable) == len(sockets):
+			return fmt.Errorf("no encoder reachable: %s", strings.Join(unreachable, "; "))
+		default:
+			return healthcheck.Degraded("%d of %d encoders unreachable: %s", len(unreachable), len(sockets), strings.Join(unreachable, "; "))
+		}
+	}
+}
+
+// BlobStoreHealth returns the check of the blob store, which reads the metadata of a blob
+// that isn't stored
+func BlobStoreHealth(store disperser.BlobStoreReader) healthcheck.Check {
+	return func(ctx context.Context) error {
+		_, err := store.GetBlobMetadata(ctx, disperser.BlobKey{BlobHash: "health", MetadataHash: "check"})
+		if err == nil || errors.Is(err, disperser.ErrBlobNotFound) {
+			return nil
+		}
+		return err
+	}
+}
@@ -0,0 +1,30 @@
+package batcher
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/0glabs/0g-da-client/common/healthcheck"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorageNodeHealth(t *testing.T) {
+	h := &storageNodeHealth{}
+	assert.NoError(t, h.check())
+
+	h.record(0, 4, 100)
+	assert.NoError(t, h.check())
+	// an operator failed, the others signed enough slices
+	h.record(1, 4, 75)
+	assert.ErrorIs(t, h.check(), healthcheck.ErrDegraded)
+	// no slice signed
+	h.record(4, 4, 0)
+	err := h.check()
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, healthcheck.ErrDegraded))
+	h.record(0, 4, 100)
+	assert.NoError(t, h.check())
+
+	var nilHealth *storageNodeHealth
+	nilHealth.record(1, 4, 75)
+}
@@ -0,0 +1,166 @@
+package batcher
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	pb "github.com/0glabs/0g-da-client/disperser/api/grpc/signer"
+)
+
+const latencyWindowSize = 1000
+
+type HedgingConfig struct {
+	// Enabled turns on hedged BatchSign requests
+	Enabled bool
+	// Percentile of the observed latency after which a hedge is issued, e.g. 0.95
+	Percentile float64
+	// MinDelay is the lower bound of the hedge delay, also used until enough samples are collected
+	MinDelay time.Duration
+	// MaxRatio caps the number of hedges as a fraction of all requests
+	MaxRatio float64
+}
+
+// latencyTracker keeps a sliding window of recent request latencies.
+type latencyTracker struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+func newLatencyTracker() *latencyTracker {
+	return &latencyTracker{
+		samples: make([]time.Duration, 0, latencyWindowSize),
+	}
+}
+
+func (t *latencyTracker) observe(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.samples) < latencyWindowSize {
+		t.samples = append(t.samples, d)
+		return
+	}
+	t.samples[t.next] = d
+	t.next = (t.next + 1) % latencyWindowSize
+}
+
+// percentile returns the latency at the given percentile, or false if there are too few samples.
+func (t *latencyTracker) percentile(p float64) (time.Duration, bool) {
+	t.mu.Lock()
+	sorted := make([]time.Duration, len(t.samples))
+	copy(sorted, t.samples)
+	t.mu.Unlock()
+
+	if len(sorted) < 20 {
+		return 0, false
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(float64(len(sorted)-1) * p)
+	return sorted[idx], true
+}
+
+// hedgedSignerClient wraps a SignerClient and sends a second BatchSign request
+// when the first one is slower than the configured latency percentile. The
+// first successful reply wins.
+type hedgedSignerClient struct {
+	disperser.SignerClient
+	config  HedgingConfig
+	latency *latencyTracker
+	metrics *Metrics
+
+	mu       sync.Mutex
+	requests uint64
+	hedges   uint64
+}
+
+var _ disperser.SignerClient = (*hedgedSignerClient)(nil)
+
+func newHedgedSignerClient(client disperser.SignerClient, config HedgingConfig, metrics *Metrics) *hedgedSignerClient {
+	return &hedgedSignerClient{
+		SignerClient: client,
+		config:       config,
+		latency:      newLatencyTracker(),
+		metrics:      metrics,
+	}
+}
+
+func (c *hedgedSignerClient) hedgeDelay() time.Duration {
+	delay, ok := c.latency.percentile(c.config.Percentile)
+	if !ok || delay < c.config.MinDelay {
+		return c.config.MinDelay
+	}
+	return delay
+}
+
+// allowHedge reports whether another hedge fits under the configured ratio.
+func (c *hedgedSignerClient) allowHedge() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if float64(c.hedges+1) > c.config.MaxRatio*float64(c.requests) {
+		return false
+	}
+	c.hedges++
+	return true
+}
+
+type batchSignResult struct {
+	signatures []*core.Signature
+	err        error
+	hedge      bool
+}
+
+func (c *hedgedSignerClient) BatchSign(ctx context.Context, addr string, data []*pb.SignRequest, log common.Logger) ([]*core.Signature, error) {
+	c.mu.Lock()
+	c.requests++
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan batchSignResult, 2)
+	send := func(hedge bool) {
+		start := time.Now()
+		signatures, err := c.SignerClient.BatchSign(ctx, addr, data, log)
+		if err == nil {
+			c.latency.observe(time.Since(start))
+		}
+		results <- batchSignResult{signatures: signatures, err: err, hedge: hedge}
+	}
+	go send(false)
+
+	timer := time.NewTimer(c.hedgeDelay())
+	defer timer.Stop()
+
+	inflight := 1
+	var lastErr error
+	for inflight > 0 {
+		select {
+		case <-timer.C:
+			if !c.allowHedge() {
+				c.metrics.UpdateHedgedRequest("capped")
+				continue
+			}
+			log.Debug("[signer] hedging sign request", "signer", addr)
+			c.metrics.UpdateHedgedRequest("issued")
+			inflight++
+			go send(true)
+		case res := <-results:
+			inflight--
+			if res.err == nil {
+				if res.hedge {
+					c.metrics.UpdateHedgedRequest("won")
+				}
+				return res.signatures, nil
+			}
+			lastErr = res.err
+		}
+	}
+	return nil, lastErr
+}
@@ -0,0 +1,79 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	pb "github.com/0glabs/0g-da-client/disperser/api/grpc/signer"
+	"github.com/stretchr/testify/assert"
+)
+
+type slowSignerClient struct {
+	calls atomic.Int32
+	delay time.Duration
+}
+
+func (c *slowSignerClient) BatchSign(ctx context.Context, addr string, data []*pb.SignRequest, log common.Logger) ([]*core.Signature, error) {
+	// only the first call is slow
+	if c.calls.Add(1) == 1 {
+		select {
+		case <-time.After(c.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return nil, errors.New("slow")
+	}
+	return []*core.Signature{}, nil
+}
+
+func TestHedgedSignerClient(t *testing.T) {
+	logger := mock.NewLogger(false)
+	client := &slowSignerClient{delay: time.Second}
+	hedged := newHedgedSignerClient(client, HedgingConfig{
+		Enabled:    true,
+		Percentile: 0.95,
+		MinDelay:   10 * time.Millisecond,
+		MaxRatio:   1,
+	}, NewMetrics("9100", logger))
+
+	start := time.Now()
+	sigs, err := hedged.BatchSign(context.Background(), "127.0.0.1:1234", nil, logger)
+	assert.NoError(t, err)
+	assert.NotNil(t, sigs)
+	assert.Less(t, time.Since(start), client.delay)
+	assert.Equal(t, int32(2), client.calls.Load())
+}
+
+func TestHedgedSignerClientCapped(t *testing.T) {
+	logger := mock.NewLogger(false)
+	client := &slowSignerClient{delay: 50 * time.Millisecond}
+	hedged := newHedgedSignerClient(client, HedgingConfig{
+		Enabled:    true,
+		Percentile: 0.95,
+		MinDelay:   10 * time.Millisecond,
+		MaxRatio:   0,
+	}, NewMetrics("9100", logger))
+
+	_, err := hedged.BatchSign(context.Background(), "127.0.0.1:1234", nil, logger)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), client.calls.Load())
+}
+
+func TestLatencyTrackerPercentile(t *testing.T) {
+	tracker := newLatencyTracker()
+	_, ok := tracker.percentile(0.95)
+	assert.False(t, ok)
+
+	for i := 1; i <= 100; i++ {
+		tracker.observe(time.Duration(i) * time.Millisecond)
+	}
+	p, ok := tracker.percentile(0.95)
+	assert.True(t, ok)
+	assert.Equal(t, 95*time.Millisecond, p)
+}
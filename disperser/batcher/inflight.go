@@ -0,0 +1,42 @@
+package batcher
+
+import (
+	"errors"
+	"time"
+
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/contract"
+)
+
+// InFlightConfig keeps the blobs of a batch whose transaction has no receipt yet in flight:
+// they stay out of new batches, and the receipt is waited for again, until the transaction
+// resolves. Handing them back for retry right away could confirm them twice if the slow
+// transaction lands after all.
+type InFlightConfig struct {
+	// Timeout is how long a transaction without receipt is waited for before it is considered
+	// dropped and its blobs are retried, 0 to retry them after the first receipt wait
+	Timeout time.Duration
+}
+
+// inFlight returns whether the blobs of a batch whose receipt wait failed with err stay in
+// flight, the transaction having been waited for since the given time
+func (c InFlightConfig) inFlight(err error, since time.Time, now time.Time) bool {
+	return c.Timeout > 0 && receiptPending(err) && now.Sub(since) < c.Timeout
+}
+
+// receiptPending returns whether a receipt wait failed without learning the outcome of the
+// transaction, as opposed to the transaction being known to have failed
+func receiptPending(err error) bool {
+	var timeoutErr *TimeoutError
+	return errors.Is(err, contract.ErrNoReceipt) || errors.As(err, &timeoutErr)
+}
+
+// confirmedElsewhere returns whether a blob is already confirmed by a batch other than the one
+// of confirmationInfo. The transaction of a batch given up on can still land after its blobs
+// were retried in another batch, and the blobs are then confirmed by the first to land.
+func confirmedElsewhere(current *disperser.BlobMetadata, confirmationInfo *disperser.ConfirmationInfo) bool {
+	if current.BlobStatus != disperser.Confirmed && current.BlobStatus != disperser.Finalized {
+		return false
+	}
+	return current.ConfirmationInfo != nil && current.ConfirmationInfo.BatchHeaderHash != confirmationInfo.BatchHeaderHash
+}
@@ -0,0 +1,42 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/contract"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInFlight(t *testing.T) {
+	config := InFlightConfig{Timeout: time.Minute}
+	since := time.Now()
+	timeout := &TimeoutError{Call: CallReceiptWait, Timeout: time.Second, Err: context.DeadlineExceeded}
+
+	assert.True(t, config.inFlight(fmt.Errorf("wait: %w", contract.ErrNoReceipt), since, since.Add(time.Second)))
+	assert.True(t, config.inFlight(timeout, since, since.Add(time.Second)))
+	// a failed transaction resolved the batch
+	assert.False(t, config.inFlight(errors.New("Transaction execution failed"), since, since.Add(time.Second)))
+	// past the timeout the transaction is considered dropped
+	assert.False(t, config.inFlight(timeout, since, since.Add(time.Minute)))
+	assert.False(t, InFlightConfig{}.inFlight(timeout, since, since))
+}
+
+func TestConfirmedElsewhere(t *testing.T) {
+	retried := &disperser.ConfirmationInfo{BatchHeaderHash: [32]byte{2}}
+	late := &disperser.ConfirmationInfo{BatchHeaderHash: [32]byte{1}}
+
+	processing := &disperser.BlobMetadata{BlobStatus: disperser.Processing}
+	assert.False(t, confirmedElsewhere(processing, late))
+	// the batch the blob was retried in landed first
+	confirmed := &disperser.BlobMetadata{BlobStatus: disperser.Confirmed, ConfirmationInfo: retried}
+	assert.True(t, confirmedElsewhere(confirmed, late))
+	finalized := &disperser.BlobMetadata{BlobStatus: disperser.Finalized, ConfirmationInfo: retried}
+	assert.True(t, confirmedElsewhere(finalized, late))
+	// confirming the same batch again is not another batch
+	assert.False(t, confirmedElsewhere(confirmed, retried))
+}
@@ -4,13 +4,22 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/core"
 	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/batcher/transactor"
+	"github.com/0glabs/0g-da-client/disperser/contract"
+	"github.com/0glabs/0g-da-client/disperser/encoder"
+	"github.com/0glabs/0g-da-client/disperser/signer"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type FailReason string
@@ -30,17 +39,57 @@ const (
 	FailConfirmBatch              FailReason = "confirm_batch"
 	FailGetBatchID                FailReason = "get_batch_id"
 	FailUpdateConfirmationInfo    FailReason = "update_confirmation_info"
+	FailBudgetExhausted           FailReason = "budget_exhausted"
+	FailEncoding                  FailReason = "encoding"
 )
 
+// failReasons are the known failure reasons
+var failReasons = []FailReason{
+	FailBatchHeaderHash,
+	FailBatchBlobIndex,
+	FailBatchBlobHeaderHash,
+	FailBatchProof,
+	FailBatchSubmitRoot,
+	FailBatchReceipt,
+	FailBatchEpochMismatch,
+	FailGetSigners,
+	FailAggregateSignatures,
+	FailSubmitAggregateSignatures,
+	FailNoSignatures,
+	FailConfirmBatch,
+	FailGetBatchID,
+	FailUpdateConfirmationInfo,
+	FailBudgetExhausted,
+	FailEncoding,
+}
+
 type MetricsConfig struct {
 	HTTPPort      string
 	EnableMetrics bool
 }
 
 type EncodingStreamerMetrics struct {
-	EncodedBlobs *prometheus.GaugeVec
+	EncodedBlobs   *prometheus.GaugeVec
+	CorruptedBlobs prometheus.Counter
+
+	EncoderRequestSize prometheus.Histogram
+	EncoderLatency     *prometheus.HistogramVec
+	EncoderRejected    *prometheus.CounterVec
+	// EncodingPoolWait is the time encoding requests wait for a connection to the encoder
+	EncodingPoolWait prometheus.Histogram
+	// EncodingCache reports the lookups and the usage of the cache of encodings
+	EncodingCache      *prometheus.CounterVec
+	EncodingCacheUsage *prometheus.GaugeVec
+	// SafeMode is 1 while encoding and dispatch are paused by the safe mode
+	SafeMode prometheus.Gauge
 }
 
+var _ encoder.RequestObserver = (*EncodingStreamerMetrics)(nil)
+
+var _ contract.FeeMetrics = (*Metrics)(nil)
+
+var _ transactor.SenderMetrics = (*Metrics)(nil)
+
 type Metrics struct {
 	*EncodingStreamerMetrics
 
@@ -53,6 +102,54 @@ type Metrics struct {
 	Attestation      *prometheus.GaugeVec
 	BatchError       *prometheus.CounterVec
 	SignedBlobs      *prometheus.GaugeVec
+	HedgedRequests   *prometheus.CounterVec
+	EncoderCheck     *prometheus.CounterVec
+	SignerCache      *prometheus.CounterVec
+	PartialBatches   *prometheus.CounterVec
+	// QuorumFallbacks counts the blobs confirmed with the default thresholds after falling
+	// short of the optional thresholds they requested
+	QuorumFallbacks prometheus.Counter
+	// DispatchDeadlines counts the batches and signers cut off by the dispatch deadline of a quorum
+	DispatchDeadlines *prometheus.CounterVec
+	// OperatorBandwidth and BandwidthExclusions are set by the bandwidth monitor
+	OperatorBandwidth   *prometheus.GaugeVec
+	BandwidthExclusions *prometheus.CounterVec
+	InboxPosts          *prometheus.CounterVec
+	InboxTransactions   *prometheus.CounterVec
+	SignerVersions      *prometheus.GaugeVec
+	SignerFormats       *prometheus.GaugeVec
+	BatchSizeTarget     prometheus.Gauge
+	BatchSizeFactors    *prometheus.GaugeVec
+	BusyPipelines       prometheus.Gauge
+	// OperatorDeviation and SustainedDeviations are set by the fairness analyzer
+	OperatorDeviation   *prometheus.GaugeVec
+	SustainedDeviations prometheus.Gauge
+	// WatchdogChecks and WatchdogChallenges are set by the watchdog
+	WatchdogChecks     *prometheus.CounterVec
+	WatchdogChallenges *prometheus.CounterVec
+	// VenueSubmissions and PrimaryHalted are set by the confirmation fallback
+	VenueSubmissions *prometheus.CounterVec
+	PrimaryHalted    prometheus.Gauge
+	// the transaction metrics are set by the fee manager of the DA contract
+	TxReplacements      prometheus.Counter
+	TxEffectiveFee      prometheus.Gauge
+	TxReplacementsPerTx prometheus.Histogram
+	// the sender metrics are set by the transactor, per account
+	SenderTxs        *prometheus.CounterVec
+	SenderBalance    *prometheus.GaugeVec
+	SenderLowBalance *prometheus.GaugeVec
+	// Reorgs is set by the finalizer
+	Reorgs *prometheus.CounterVec
+	// BatchBudgetUsed and BudgetDecisions report the budgets of the batches
+	BatchBudgetUsed *prometheus.HistogramVec
+	BudgetDecisions *prometheus.CounterVec
+	// WalletSpend, ConfirmationPaused and SpendLimitBreaches report the spend limits of the
+	// confirmations
+	WalletSpend        *prometheus.GaugeVec
+	ConfirmationPaused prometheus.Gauge
+	SpendLimitBreaches *prometheus.CounterVec
+	// EncoderHealth is set by the encoder balancer
+	EncoderHealth *prometheus.GaugeVec
 
 	httpPort string
 	logger   common.Logger
@@ -73,6 +170,69 @@ func NewMetrics(httpPort string, logger common.Logger) *Metrics {
 			},
 			[]string{"type"},
 		),
+		CorruptedBlobs: promauto.With(reg).NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "corrupted_blobs_total",
+				Help:      "number of blobs whose stored payload failed checksum verification",
+			},
+		),
+		EncoderRequestSize: promauto.With(reg).NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "encoder_request_size_bytes",
+				Help:      "size of the blobs sent to the encoders",
+				Buckets:   prometheus.ExponentialBuckets(1024, 4, 10), // 1 KiB to 256 MiB
+			},
+		),
+		EncoderLatency: promauto.With(reg).NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "encoder_latency_ms",
+				Help:      "time of the encode requests per stage in milliseconds, as reported by the encoders; overhead is the round trip minus the time spent on the encoder",
+				Buckets:   prometheus.ExponentialBuckets(1, 2, 16),
+			},
+			[]string{"encoder", "stage"}, // round_trip, queue, fft, msm, proof or overhead
+		),
+		EncoderRejected: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "encoder_rejected_requests_total",
+				Help:      "number of encode requests rejected by the encoders per grpc code",
+			},
+			[]string{"encoder", "code"},
+		),
+		EncodingPoolWait: promauto.With(reg).NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "encoding_pool_wait_ms",
+				Help:      "time encode requests wait for a free encoder connection in milliseconds",
+				Buckets:   prometheus.ExponentialBuckets(1, 2, 16),
+			},
+		),
+		EncodingCache: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "encoding_cache_lookups_total",
+				Help:      "number of blobs looked up in the cache of encodings per result",
+			},
+			[]string{"result"}, // hit or miss
+		),
+		EncodingCacheUsage: promauto.With(reg).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "encoding_cache_usage",
+				Help:      "number and size of the cached encodings",
+			},
+			[]string{"type"},
+		),
+		SafeMode: promauto.With(reg).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "safe_mode",
+				Help:      "1 while blobs are persisted but neither encoded nor dispatched",
+			},
+		),
 	}
 
 	metrics := &Metrics{
@@ -133,13 +293,302 @@ func NewMetrics(httpPort string, logger common.Logger) *Metrics {
 			},
 			[]string{"type"},
 		),
+		HedgedRequests: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "hedged_sign_requests_total",
+				Help:      "number of hedged sign requests by result",
+			},
+			[]string{"result"}, // result is one of issued, won or capped
+		),
+		EncoderCheck: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "encoder_cross_check_total",
+				Help:      "number of blobs cross checked against the secondary encoder by result",
+			},
+			[]string{"result"},
+		),
+		SignerCache: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "signer_cache_lookups_total",
+				Help:      "number of signer cache lookups by entry type and result",
+			},
+			[]string{"type", "result"}, // type is either signers or agg_pubkey
+		),
+		PartialBatches: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "partial_batches_total",
+				Help:      "number of partially confirmed batches and of the blobs excluded from them",
+			},
+			[]string{"type"},
+		),
+		QuorumFallbacks: promauto.With(reg).NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "quorum_fallback_blobs_total",
+				Help:      "number of blobs confirmed with the default thresholds after falling short of their optional thresholds",
+			},
+		),
+		DispatchDeadlines: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "dispatch_deadline_exceeded_total",
+				Help:      "number of batches whose dispatch deadline passed before all the signers of the quorum replied, and of the signers that didn't",
+			},
+			[]string{"quorum", "type"}, // type is either batches or late_signers
+		),
+		OperatorBandwidth: promauto.With(reg).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "operator_bandwidth_bytes_per_second",
+				Help:      "upload bandwidth to an operator measured by the bandwidth probes",
+			},
+			[]string{"operator"},
+		),
+		BandwidthExclusions: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "bandwidth_exclusions_total",
+				Help:      "number of operators left out of the dispersal of a batch as their slices would take longer than the dispatch deadline to upload",
+			},
+			[]string{"quorum"},
+		),
+		WatchdogChecks: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "watchdog_checks_total",
+				Help:      "number of slices of confirmed blobs the watchdog asked their signers for, by result",
+			},
+			[]string{"result"},
+		),
+		WatchdogChallenges: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "watchdog_challenges_total",
+				Help:      "number of discrepancies found by the watchdog that were challenged, by result",
+			},
+			[]string{"result"},
+		),
+		VenueSubmissions: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "venue_submissions_total",
+				Help:      "number of aggregate signature submissions, by confirmation venue",
+			},
+			[]string{"venue"},
+		),
+		PrimaryHalted: promauto.With(reg).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "primary_chain_halted",
+				Help:      "1 while the primary chain is considered halted and the aggregate signatures are submitted to the fallback venue",
+			},
+		),
+		InboxPosts: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "inbox_posts_total",
+				Help:      "number of certificates posted to the rollup inbox by result",
+			},
+			[]string{"result"}, // success, retry or failure
+		),
+		InboxTransactions: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "inbox_transactions_total",
+				Help:      "number of inbox transactions sent by how they carry the certificate",
+			},
+			[]string{"mode"}, // calldata or blob
+		),
+		SignerVersions: promauto.With(reg).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "signer_versions",
+				Help:      "number of known signers by advertised node version",
+			},
+			[]string{"version"},
+		),
+		SignerFormats: promauto.With(reg).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "signer_chunk_formats",
+				Help:      "number of known signers by supported chunk format",
+			},
+			[]string{"format"},
+		),
+		BatchSizeTarget: promauto.With(reg).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "batch_size_target_bytes",
+				Help:      "encoded size at which a batch is created, set by adaptive batch sizing",
+			},
+		),
+		BatchSizeFactors: promauto.With(reg).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "batch_size_factor",
+				Help:      "factor the batch size target is scaled by, by signal",
+			},
+			[]string{"signal"}, // gas, backlog or latency
+		),
+		BusyPipelines: promauto.With(reg).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "busy_batch_pipelines",
+				Help:      "number of batch pipelines creating or dispatching a batch",
+			},
+		),
+		OperatorDeviation: promauto.With(reg).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "operator_assignment_deviation",
+				Help:      "relative deviation of the bytes assigned to an operator in the last batch from its stake proportion",
+			},
+			[]string{"operator"},
+		),
+		SustainedDeviations: promauto.With(reg).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "operators_sustained_deviation",
+				Help:      "number of operators whose assignments deviated from their stake proportion over consecutive batches",
+			},
+		),
+		TxReplacements: promauto.With(reg).NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "tx_replacements_total",
+				Help:      "number of pending transactions replaced with higher fees",
+			},
+		),
+		TxEffectiveFee: promauto.With(reg).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "tx_effective_gas_price_gwei",
+				Help:      "effective gas price of the last transaction included",
+			},
+		),
+		TxReplacementsPerTx: promauto.With(reg).NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "tx_replacements",
+				Help:      "number of replacements of the transactions included",
+				Buckets:   []float64{0, 1, 2, 3, 5, 8},
+			},
+		),
+		SenderTxs: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "sender_txs_total",
+				Help:      "number of transactions sent per sender account and result",
+			},
+			[]string{"account", "result"},
+		),
+		SenderBalance: promauto.With(reg).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "sender_balance",
+				Help:      "balance of the sender accounts in native tokens",
+			},
+			[]string{"account"},
+		),
+		SenderLowBalance: promauto.With(reg).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "sender_low_balance",
+				Help:      "1 if the balance of the sender account is below the min balance",
+			},
+			[]string{"account"},
+		),
+		Reorgs: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "reorgs",
+				Help:      "number of batch confirmations dropped (dropped_batches) or moved (moved_batches) by a chain reorg, and of blobs dispersed again (requeued_blobs)",
+			},
+			[]string{"type"},
+		),
+		BatchBudgetUsed: promauto.With(reg).NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "batch_budget_used",
+				Help:      "fraction of the latency and fee budgets used by the confirmed batches",
+				Buckets:   []float64{0.1, 0.25, 0.5, 0.75, 0.9, 1, 1.5, 2},
+			},
+			[]string{"resource"},
+		),
+		BudgetDecisions: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "budget_decisions_total",
+				Help:      "number of dispatches cut short, signings abandoned and fee bumps made or skipped for the budgets of the batches",
+			},
+			[]string{"decision"},
+		),
+		WalletSpend: promauto.With(reg).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "wallet_spend_gwei",
+				Help:      "fees in gwei spent on the confirmations over the last hour and day",
+			},
+			[]string{"window"},
+		),
+		ConfirmationPaused: promauto.With(reg).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "confirmation_paused",
+				Help:      "1 if the submission of aggregate signatures is paused by the spend limits or the admin API",
+			},
+		),
+		SpendLimitBreaches: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "spend_limit_breaches_total",
+				Help:      "number of hourly and daily spend caps reached and per-batch cost spikes detected",
+			},
+			[]string{"reason"},
+		),
+		EncoderHealth: promauto.With(reg).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "encoder_healthy",
+				Help:      "1 if the encoder is in the rotation of the balanced encoders, 0 if it was removed as unhealthy",
+			},
+			[]string{"encoder"},
+		),
 		registry: reg,
 		httpPort: httpPort,
 		logger:   logger,
 	}
+	registerBufferPoolMetrics(reg, namespace, "chunk_buffer_pool", core.ChunkBufferPool)
 	return metrics
 }
 
+// registerBufferPoolMetrics exports the operations of a buffer pool, whose efficiency is
+// the ratio of hits to gets
+func registerBufferPoolMetrics(reg prometheus.Registerer, namespace string, name string, pool *core.BufferPool) {
+	ops := map[string]func(core.BufferPoolStats) uint64{
+		"get":      func(s core.BufferPoolStats) uint64 { return s.Gets },
+		"hit":      func(s core.BufferPoolStats) uint64 { return s.Hits },
+		"put":      func(s core.BufferPoolStats) uint64 { return s.Puts },
+		"oversize": func(s core.BufferPoolStats) uint64 { return s.Oversize },
+	}
+	for op, value := range ops {
+		value := value
+		promauto.With(reg).NewCounterFunc(
+			prometheus.CounterOpts{
+				Namespace:   namespace,
+				Name:        name + "_ops_total",
+				Help:        "number of buffers taken from (get, of which hit were recycled) and returned to (put) the pool, oversize being those outside of its size classes",
+				ConstLabels: prometheus.Labels{"op": op},
+			},
+			func() float64 { return float64(value(pool.Stats())) },
+		)
+	}
+}
+
 func (g *Metrics) UpdateAttestation(operatorCount, nonSignerCount int) {
 	g.Attestation.WithLabelValues("signers").Set(float64(operatorCount - nonSignerCount))
 	g.Attestation.WithLabelValues("non_signers").Set(float64(nonSignerCount))
@@ -174,6 +623,194 @@ func (g *Metrics) UpdateBatchError(errType FailReason, numBlobs int) {
 	g.BatchError.WithLabelValues(string(errType)).Add(float64(numBlobs))
 }
 
+func (g *Metrics) UpdateHedgedRequest(result string) {
+	g.HedgedRequests.WithLabelValues(result).Inc()
+}
+
+func (g *Metrics) UpdateEncoderCrossCheck(result string) {
+	g.EncoderCheck.WithLabelValues(result).Inc()
+}
+
+func (g *Metrics) UpdateSignerCache(entryType string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	g.SignerCache.WithLabelValues(entryType, result).Inc()
+}
+
+func (g *Metrics) IncrementPartialBatch(excludedBlobs int) {
+	g.PartialBatches.WithLabelValues("batches").Inc()
+	g.PartialBatches.WithLabelValues("excluded_blobs").Add(float64(excludedBlobs))
+}
+
+func (g *Metrics) IncrementQuorumFallback(blobs int) {
+	g.QuorumFallbacks.Add(float64(blobs))
+}
+
+func (g *Metrics) IncrementDispatchDeadlineExceeded(quorumID uint64, lateSigners int) {
+	quorum := strconv.FormatUint(quorumID, 10)
+	g.DispatchDeadlines.WithLabelValues(quorum, "batches").Inc()
+	g.DispatchDeadlines.WithLabelValues(quorum, "late_signers").Add(float64(lateSigners))
+}
+
+func (g *Metrics) UpdateOperatorBandwidth(operator string, bytesPerSecond float64) {
+	g.OperatorBandwidth.WithLabelValues(operator).Set(bytesPerSecond)
+}
+
+func (g *Metrics) IncrementBandwidthExclusions(quorumID uint64, operators int) {
+	g.BandwidthExclusions.WithLabelValues(strconv.FormatUint(quorumID, 10)).Add(float64(operators))
+}
+
+func (g *Metrics) IncrementWatchdogChecks(result WatchdogResult) {
+	g.WatchdogChecks.WithLabelValues(string(result)).Inc()
+}
+
+func (g *Metrics) IncrementWatchdogChallenges(success bool) {
+	result := "success"
+	if !success {
+		result = "failure"
+	}
+	g.WatchdogChallenges.WithLabelValues(result).Inc()
+}
+
+func (g *Metrics) IncrementVenueSubmissions(fallback bool) {
+	venue := "primary"
+	if fallback {
+		venue = "fallback"
+	}
+	g.VenueSubmissions.WithLabelValues(venue).Inc()
+}
+
+func (g *Metrics) UpdatePrimaryHalted(halted bool) {
+	value := 0.0
+	if halted {
+		value = 1
+	}
+	g.PrimaryHalted.Set(value)
+}
+
+func (g *Metrics) IncrementTxReplacements() {
+	g.TxReplacements.Inc()
+}
+
+func (g *Metrics) UpdateTxEffectiveFee(effectiveGasPrice uint64, replacements int) {
+	g.TxEffectiveFee.Set(float64(effectiveGasPrice) / 1e9)
+	g.TxReplacementsPerTx.Observe(float64(replacements))
+}
+
+func (g *Metrics) IncrementReorgs(kind string, n int) {
+	g.Reorgs.WithLabelValues(kind).Add(float64(n))
+}
+
+// ObserveBatchBudget records the fractions of the latency and fee budgets a confirmed batch
+// used, skipping the negative ones of the unbounded budgets
+func (g *Metrics) ObserveBatchBudget(latency float64, fee float64) {
+	if latency >= 0 {
+		g.BatchBudgetUsed.WithLabelValues("latency").Observe(latency)
+	}
+	if fee >= 0 {
+		g.BatchBudgetUsed.WithLabelValues("fee").Observe(fee)
+	}
+}
+
+func (g *Metrics) IncrementBudgetDecision(decision string) {
+	g.BudgetDecisions.WithLabelValues(decision).Inc()
+}
+
+func (g *Metrics) UpdateConfirmationSpend(paused bool, hourlyGwei uint64, dailyGwei uint64) {
+	value := 0.0
+	if paused {
+		value = 1
+	}
+	g.ConfirmationPaused.Set(value)
+	g.WalletSpend.WithLabelValues("hour").Set(float64(hourlyGwei))
+	g.WalletSpend.WithLabelValues("day").Set(float64(dailyGwei))
+}
+
+func (g *Metrics) IncrementSpendLimitBreach(reason string) {
+	g.SpendLimitBreaches.WithLabelValues(reason).Inc()
+}
+
+func (g *Metrics) UpdateEncoderHealth(encoder string, healthy bool) {
+	value := 0.0
+	if healthy {
+		value = 1
+	}
+	g.EncoderHealth.WithLabelValues(encoder).Set(value)
+}
+
+func (g *Metrics) IncrementSenderTxs(account string, success bool) {
+	result := "success"
+	if !success {
+		result = "failure"
+	}
+	g.SenderTxs.WithLabelValues(account, result).Inc()
+}
+
+func (g *Metrics) UpdateSenderBalance(account string, balance float64, low bool) {
+	g.SenderBalance.WithLabelValues(account).Set(balance)
+	value := 0.0
+	if low {
+		value = 1
+	}
+	g.SenderLowBalance.WithLabelValues(account).Set(value)
+}
+
+func (g *Metrics) IncrementInboxPost(result string) {
+	g.InboxPosts.WithLabelValues(result).Inc()
+}
+
+func (g *Metrics) IncrementInboxTransaction(mode string) {
+	g.InboxTransactions.WithLabelValues(mode).Inc()
+}
+
+// ObserveSignerFleet sets the version and chunk format distributions of the known signers
+func (g *Metrics) ObserveSignerFleet(nodes map[string]*signer.NodeInfo) {
+	versions := make(map[string]int)
+	formats := make(map[string]int)
+	for _, info := range nodes {
+		versions[info.Version]++
+		formats[core.ChunkFormatLegacy.String()]++
+		for _, format := range info.ChunkFormats {
+			if format != core.ChunkFormatLegacy {
+				formats[format.String()]++
+			}
+		}
+	}
+	g.SignerVersions.Reset()
+	for version, n := range versions {
+		g.SignerVersions.WithLabelValues(version).Set(float64(n))
+	}
+	g.SignerFormats.Reset()
+	for format, n := range formats {
+		g.SignerFormats.WithLabelValues(format).Set(float64(n))
+	}
+}
+
+func (g *Metrics) UpdateBatchSizeTarget(target uint64, factors map[string]float64) {
+	g.BatchSizeTarget.Set(float64(target))
+	for signal, factor := range factors {
+		g.BatchSizeFactors.WithLabelValues(signal).Set(factor)
+	}
+}
+
+func (g *Metrics) UpdateBusyPipelines(n int) {
+	g.BusyPipelines.Set(float64(n))
+}
+
+func (g *Metrics) UpdateOperatorDeviation(operator string, deviation float64) {
+	g.OperatorDeviation.WithLabelValues(operator).Set(deviation)
+}
+
+func (g *Metrics) UpdateSustainedDeviations(n int) {
+	g.SustainedDeviations.Set(float64(n))
+}
+
+func (g *Metrics) Registry() *prometheus.Registry {
+	return g.registry
+}
+
 func (g *Metrics) ObserveLatency(stage string, latencyMs float64) {
 	g.BatchProcLatency.WithLabelValues(stage).Observe(latencyMs)
 }
@@ -202,3 +839,69 @@ func (e *EncodingStreamerMetrics) UpdateEncodedBlobs(count int, size uint64) {
 	e.EncodedBlobs.WithLabelValues("size").Set(float64(size))
 	e.EncodedBlobs.WithLabelValues("number").Set(float64(count))
 }
+
+func (e *EncodingStreamerMetrics) IncrementCorruptedBlobs(count int) {
+	e.CorruptedBlobs.Add(float64(count))
+}
+
+func (e *EncodingStreamerMetrics) IncrementEncodingCacheLookup(hit bool) {
+	if hit {
+		e.EncodingCache.WithLabelValues("hit").Inc()
+	} else {
+		e.EncodingCache.WithLabelValues("miss").Inc()
+	}
+}
+
+func (e *EncodingStreamerMetrics) UpdateEncodingCacheUsage(count int, size uint64) {
+	e.EncodingCacheUsage.WithLabelValues("size").Set(float64(size))
+	e.EncodingCacheUsage.WithLabelValues("number").Set(float64(count))
+}
+
+func (e *EncodingStreamerMetrics) UpdateSafeMode(enabled bool) {
+	if enabled {
+		e.SafeMode.Set(1)
+	} else {
+		e.SafeMode.Set(0)
+	}
+}
+
+func (e *EncodingStreamerMetrics) ObserveEncodingPoolWait(wait time.Duration) {
+	e.EncodingPoolWait.Observe(float64(wait.Milliseconds()))
+}
+
+// ObserveEncodeRequest implements encoder.RequestObserver
+func (e *EncodingStreamerMetrics) ObserveEncodeRequest(encoderAddr string, size int, roundTrip time.Duration, timing *encoder.Timing, err error) {
+	if err != nil {
+		if code, ok := rejectionCode(err); ok {
+			e.EncoderRejected.WithLabelValues(encoderAddr, code.String()).Inc()
+		}
+		return
+	}
+
+	e.EncoderRequestSize.Observe(float64(size))
+	e.EncoderLatency.WithLabelValues(encoderAddr, "round_trip").Observe(float64(roundTrip.Milliseconds()))
+	if timing == nil {
+		return
+	}
+	e.EncoderLatency.WithLabelValues(encoderAddr, "queue").Observe(float64(timing.Queue.Milliseconds()))
+	e.EncoderLatency.WithLabelValues(encoderAddr, "fft").Observe(float64(timing.FFT.Milliseconds()))
+	e.EncoderLatency.WithLabelValues(encoderAddr, "msm").Observe(float64(timing.MSM.Milliseconds()))
+	e.EncoderLatency.WithLabelValues(encoderAddr, "proof").Observe(float64(timing.Proof.Milliseconds()))
+	if overhead := roundTrip - timing.Total(); overhead >= 0 {
+		e.EncoderLatency.WithLabelValues(encoderAddr, "overhead").Observe(float64(overhead.Milliseconds()))
+	}
+}
+
+// rejectionCode returns the grpc code of an error of an encoder refusing a request, as
+// opposed to failing to encode it
+func rejectionCode(err error) (codes.Code, bool) {
+	s, ok := status.FromError(err)
+	if !ok {
+		return codes.Unknown, false
+	}
+	switch s.Code() {
+	case codes.ResourceExhausted, codes.Unavailable, codes.InvalidArgument, codes.FailedPrecondition:
+		return s.Code(), true
+	}
+	return s.Code(), false
+}
@@ -0,0 +1,45 @@
+package batcher
+
+import (
+	"errors"
+	"time"
+)
+
+// MinBatchConfig keeps the batch ticker from confirming near-empty batches during quiet
+// periods, which would pay the full confirmation gas for a few tiny blobs. Batches
+// triggered by the encoded size threshold or by an expedited blob are not affected.
+type MinBatchConfig struct {
+	// MinBytes is the total blob size the pending encoded blobs must reach, 0 for no minimum
+	MinBytes uint64
+	// MinBlobs is the number of pending encoded blobs required, 0 for no minimum
+	MinBlobs uint
+	// MaxWait is how long the oldest pending blob may wait for the minimum to be reached
+	// before a batch is created anyway
+	MaxWait time.Duration
+}
+
+func (c MinBatchConfig) Enabled() bool {
+	return c.MinBytes > 0 || c.MinBlobs > 0
+}
+
+func (c MinBatchConfig) validate() error {
+	if c.Enabled() && c.MaxWait <= 0 {
+		return errors.New("the max wait of the minimum batch size must be positive")
+	}
+	return nil
+}
+
+// reached returns whether a batch of the pending blobs should be created, given their
+// number, total size and the request time of the oldest in unix nanoseconds
+func (c MinBatchConfig) reached(count int, size uint64, oldest uint64, now time.Time) bool {
+	if !c.Enabled() || count == 0 {
+		return true
+	}
+	if c.MinBytes > 0 && size >= c.MinBytes {
+		return true
+	}
+	if c.MinBlobs > 0 && uint(count) >= c.MinBlobs {
+		return true
+	}
+	return now.Sub(time.Unix(0, int64(oldest))) >= c.MaxWait
+}
@@ -0,0 +1,27 @@
+package batcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinBatchReached(t *testing.T) {
+	now := time.Now()
+	recent := uint64(now.Add(-time.Minute).UnixNano())
+	old := uint64(now.Add(-time.Hour).UnixNano())
+
+	assert.True(t, MinBatchConfig{}.reached(1, 10, recent, now))
+
+	config := MinBatchConfig{MinBytes: 1000, MinBlobs: 5, MaxWait: 10 * time.Minute}
+	assert.NoError(t, config.validate())
+	assert.False(t, config.reached(1, 10, recent, now))
+	assert.True(t, config.reached(1, 1000, recent, now), "minimum size")
+	assert.True(t, config.reached(5, 10, recent, now), "minimum number of blobs")
+	assert.True(t, config.reached(1, 10, old, now), "max wait")
+	assert.True(t, config.reached(0, 0, 0, now), "nothing to defer")
+
+	config.MaxWait = 0
+	assert.Error(t, config.validate())
+}
@@ -3,6 +3,7 @@ package mock
 import (
 	"context"
 
+	"github.com/0glabs/0g-da-client/disperser/batcher"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -20,3 +21,13 @@ func (b *MockFinalizer) FinalizeBlobs(ctx context.Context) error {
 	args := b.Called()
 	return args.Error(0)
 }
+
+func (b *MockFinalizer) LatestFinalizedBlock() uint64 {
+	args := b.Called()
+	return args.Get(0).(uint64)
+}
+
+func (b *MockFinalizer) Reconcile(ctx context.Context) (batcher.ReconcileReport, error) {
+	args := b.Called()
+	return args.Get(0).(batcher.ReconcileReport), args.Error(1)
+}
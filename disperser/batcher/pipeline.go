@@ -0,0 +1,31 @@
+package batcher
+
+// batchPipelines bounds the number of batches created and dispatched at once. With more
+// than one pipeline, the next batch no longer waits for the dispatch transaction of the
+// previous one to be sent, so throughput keeps up with a slow chain.
+type batchPipelines struct {
+	slots chan struct{}
+}
+
+func newBatchPipelines(n uint) *batchPipelines {
+	return &batchPipelines{slots: make(chan struct{}, n)}
+}
+
+// run runs f in a free pipeline, returning false without running it if all are busy
+func (p *batchPipelines) run(f func()) bool {
+	select {
+	case p.slots <- struct{}{}:
+	default:
+		return false
+	}
+	go func() {
+		defer func() { <-p.slots }()
+		f()
+	}()
+	return true
+}
+
+// busy returns the number of pipelines running a batch
+func (p *batchPipelines) busy() int {
+	return len(p.slots)
+}
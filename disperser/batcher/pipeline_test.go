@@ -0,0 +1,31 @@
+package batcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchPipelines(t *testing.T) {
+	pipelines := newBatchPipelines(2)
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	batch := func() {
+		started <- struct{}{}
+		<-release
+	}
+
+	assert.True(t, pipelines.run(batch))
+	assert.True(t, pipelines.run(batch))
+	<-started
+	<-started
+	assert.Equal(t, 2, pipelines.busy())
+	assert.False(t, pipelines.run(batch))
+
+	release <- struct{}{}
+	assert.Eventually(t, func() bool { return pipelines.busy() == 1 }, time.Second, time.Millisecond)
+	assert.True(t, pipelines.run(batch))
+	close(release)
+	assert.Eventually(t, func() bool { return pipelines.busy() == 0 }, time.Second, time.Millisecond)
+}
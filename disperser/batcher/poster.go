@@ -0,0 +1,410 @@
+package batcher
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/geth"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/batcher/transactor"
+	"github.com/0glabs/0g-da-client/disperser/contract"
+	"github.com/0glabs/0g-da-client/rollup"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+)
+
+const (
+	// BlobModeAuto posts certificates in blob transactions when blob gas is cheaper than
+	// the calldata it replaces
+	BlobModeAuto = contract.BlobModeAuto
+	// BlobModeAlways posts every certificate in a blob transaction
+	BlobModeAlways = contract.BlobModeAlways
+)
+
+type PosterConfig struct {
+	// InboxAddress is the rollup inbox the certificates of confirmed blobs are posted to.
+	// Posting is disabled if empty.
+	InboxAddress string
+	InboxABIFile string
+	// InboxMethod is called with one argument per method input, chosen by the input name,
+	// see inboxArgs
+	InboxMethod string
+	// TxGasLimit is the gas limit of inbox transactions, estimated if 0
+	TxGasLimit uint64
+	MaxRetries uint
+	// BlobMethod is called instead of InboxMethod in blob transactions carrying the ABI
+	// encoded inputs of InboxMethod in a blob, with the versioned hash of the blob as its
+	// versionedHash (or blobHash) input. Blob transactions are disabled if empty.
+	BlobMethod string
+	// BlobMode is BlobModeAuto or BlobModeAlways
+	BlobMode string
+	// BlobMinPayload is the smallest payload posted in a blob transaction in auto mode
+	BlobMinPayload int
+	// MaxPending bounds the certificates waiting to be posted, retries included. Those of
+	// the blobs confirmed while it is reached are dropped. 10000 if zero.
+	MaxPending int
+	// JournalPath persists the certificates waiting to be posted across restarts, they are
+	// lost on restart if empty
+	JournalPath string
+}
+
+func (c PosterConfig) Enabled() bool {
+	return c.InboxAddress != ""
+}
+
+func (c PosterConfig) blobsEnabled() bool {
+	return c.BlobMethod != ""
+}
+
+func (c PosterConfig) blobPolicy() contract.BlobPolicy {
+	return contract.BlobPolicy{Mode: c.BlobMode, MinPayload: c.BlobMinPayload}
+}
+
+// defaultMaxPendingPosts bounds the certificates waiting to be posted if not configured
+const defaultMaxPendingPosts = 10000
+
+type pendingPost struct {
+	// id orders the posts in the journal
+	id      uint64
+	info    *disperser.ConfirmationInfo
+	retries uint
+}
+
+// Poster posts the certificates of confirmed blobs to a rollup inbox contract, for rollups
+// that don't run their own poster. Transactions go through the transactor of the batcher
+// so that they share its account without nonce conflicts.
+type Poster struct {
+	mu      sync.Mutex
+	pending []*pendingPost
+	// outstanding counts the posts queued or being posted, bounded by MaxPending
+	outstanding int
+	nextID      uint64
+	// journal is nil unless the posts are persisted
+	journal disperser.DB
+
+	config      PosterConfig
+	inbox       *contract.Inbox
+	daContract  *contract.DAContract
+	transactor  *transactor.Transactor
+	retryOption contract.RetryOption
+	// blobInbox calls the blob method, nil if blob transactions are disabled
+	blobInbox *contract.Inbox
+	// fees are read to choose between calldata and blobs, the DA contract's
+	fees contract.BlobFeeReader
+	// spends records the fees of the posts against the spend limits, set by the confirmer
+	spends spendRecorder
+
+	logger  common.Logger
+	metrics *Metrics
+}
+
+func NewPoster(config PosterConfig, ethConfig geth.EthClientConfig, daContract *contract.DAContract, transactor *transactor.Transactor, logger common.Logger, metrics *Metrics) (*Poster, error) {
+	abiJSON, err := os.ReadFile(config.InboxABIFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read inbox abi: %w", err)
+	}
+	inbox, err := contract.NewInbox(daContract, eth_common.HexToAddress(config.InboxAddress), string(abiJSON), config.InboxMethod)
+	if err != nil {
+		return nil, err
+	}
+	// fail on startup rather than on the first confirmed blob if the method can't be called
+	if _, err := inboxArgs(inbox.Method, &disperser.ConfirmationInfo{}, nil); err != nil {
+		return nil, err
+	}
+	var blobInbox *contract.Inbox
+	if config.blobsEnabled() {
+		if err := config.blobPolicy().Validate(); err != nil {
+			return nil, fmt.Errorf("inbox: %w", err)
+		}
+		blobInbox, err = contract.NewInbox(daContract, inbox.Address, string(abiJSON), config.BlobMethod)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := inboxArgs(blobInbox.Method, &disperser.ConfirmationInfo{}, &eth_common.Hash{}); err != nil {
+			return nil, err
+		}
+	}
+
+	p := &Poster{
+		config:     config,
+		inbox:      inbox,
+		blobInbox:  blobInbox,
+		daContract: daContract,
+		fees:       daContract,
+		transactor: transactor,
+		retryOption: contract.RetryOption{
+			Rounds:   ethConfig.ReceiptPollingRounds,
+			Interval: ethConfig.ReceiptPollingInterval,
+		},
+		logger:  logger,
+		metrics: metrics,
+	}
+	if config.JournalPath != "" {
+		if err := p.openJournal(config.JournalPath); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Enqueue schedules the certificate of a confirmed blob to be posted, unless MaxPending
+// certificates already wait
+func (p *Poster) Enqueue(info *disperser.ConfirmationInfo) {
+	maxPending := p.config.MaxPending
+	if maxPending <= 0 {
+		maxPending = defaultMaxPendingPosts
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.outstanding >= maxPending {
+		p.logger.Error("[poster] too many certificates waiting to be posted, dropping certificate", "data root", eth_common.Bytes2Hex(info.DataRoot), "max pending", maxPending)
+		p.metrics.IncrementInboxPost("dropped")
+		return
+	}
+	post := &pendingPost{id: p.nextID, info: info}
+	p.nextID++
+	p.outstanding++
+	p.journalPost(post)
+	p.pending = append(p.pending, post)
+}
+
+func (p *Poster) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(1 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// retried posts are queued again and wait for the next tick
+				for _, post := range p.drain() {
+					p.post(ctx, post)
+				}
+			}
+		}
+	}()
+}
+
+func (p *Poster) drain() []*pendingPost {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	posts := p.pending
+	p.pending = nil
+	return posts
+}
+
+func (p *Poster) post(ctx context.Context, post *pendingPost) {
+	err := p.postCertificate(ctx, post.info)
+	if err == nil {
+		p.forgetPost(post)
+		p.metrics.IncrementInboxPost("success")
+		return
+	}
+	if ctx.Err() != nil {
+		// the post stays in the journal for the next run
+		return
+	}
+
+	if post.retries < p.config.MaxRetries {
+		p.logger.Warn("[poster] failed to post certificate, retrying", "data root", eth_common.Bytes2Hex(post.info.DataRoot), "retries", post.retries, "err", err)
+		post.retries++
+		p.journalPost(post)
+		p.mu.Lock()
+		p.pending = append(p.pending, post)
+		p.mu.Unlock()
+		p.metrics.IncrementInboxPost("retry")
+		return
+	}
+	p.logger.Error("[poster] failed to post certificate", "data root", eth_common.Bytes2Hex(post.info.DataRoot), "err", err)
+	p.forgetPost(post)
+	p.metrics.IncrementInboxPost("failure")
+}
+
+func (p *Poster) postCertificate(ctx context.Context, info *disperser.ConfirmationInfo) error {
+	args, err := inboxArgs(p.inbox.Method, info, nil)
+	if err != nil {
+		return err
+	}
+	blob, err := p.useBlob(ctx, info, args)
+	if err != nil {
+		return err
+	}
+	var txHash eth_common.Hash
+	mode := "calldata"
+	if blob != nil {
+		mode = "blob"
+		txHash, err = p.postBlob(ctx, info, blob)
+	} else {
+		txHash, err = p.transactor.PostToInbox(ctx, p.inbox, args, p.config.TxGasLimit)
+	}
+	if err != nil {
+		return err
+	}
+	p.metrics.IncrementInboxTransaction(mode)
+	receipt, err := p.daContract.WaitForReceiptContext(ctx, txHash, true, p.retryOption)
+	if err != nil {
+		return fmt.Errorf("inbox transaction %s failed: %w", txHash, err)
+	}
+	p.recordFee(ctx, receipt)
+	p.logger.Debug("[poster] certificate posted", "data root", eth_common.Bytes2Hex(info.DataRoot), "transaction hash", txHash)
+	return nil
+}
+
+// recordFee records the fee of an inbox transaction, blob gas included, against the spend
+// limits
+func (p *Poster) recordFee(ctx context.Context, receipt *types.Receipt) {
+	if p.spends == nil {
+		return
+	}
+	fee, err := p.daContract.ReceiptFee(ctx, receipt)
+	if err != nil {
+		p.logger.Warn("[poster] failed to get the fee of the inbox transaction", "transaction hash", receipt.TransactionHash, "err", err)
+		return
+	}
+	p.spends.recordFee(fee, receipt.TransactionHash, 0)
+}
+
+// useBlob returns the payload of the blob transaction posting the inbox call with args,
+// nil if it is posted in calldata, including when the fees can't be read in auto mode
+func (p *Poster) useBlob(ctx context.Context, info *disperser.ConfirmationInfo, args []interface{}) ([]byte, error) {
+	if p.blobInbox == nil {
+		return nil, nil
+	}
+	payload, err := p.inbox.Method.Inputs.Pack(args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack inbox %s inputs: %w", p.inbox.Method.Name, err)
+	}
+	calldata := append(append([]byte{}, p.inbox.Method.ID...), payload...)
+	blobArgs, err := inboxArgs(p.blobInbox.Method, info, &contract.PlaceholderBlobHash)
+	if err != nil {
+		return nil, err
+	}
+	blobCalldata, err := p.blobInbox.Calldata(blobArgs...)
+	if err != nil {
+		return nil, err
+	}
+	useBlob, err := p.config.blobPolicy().UseBlob(ctx, p.fees, payload, calldata, blobCalldata)
+	if err != nil {
+		p.logger.Warn("[poster] failed to read the blob fees, posting the certificate in calldata", "data root", eth_common.Bytes2Hex(info.DataRoot), "err", err)
+	}
+	if !useBlob {
+		return nil, nil
+	}
+	return payload, nil
+}
+
+func (p *Poster) postBlob(ctx context.Context, info *disperser.ConfirmationInfo, payload []byte) (eth_common.Hash, error) {
+	sidecar, err := contract.NewBlobSidecar(payload)
+	if err != nil {
+		return eth_common.Hash{}, err
+	}
+	blobHash := sidecar.BlobHashes()[0]
+	args, err := inboxArgs(p.blobInbox.Method, info, &blobHash)
+	if err != nil {
+		return eth_common.Hash{}, err
+	}
+	p.logger.Debug("[poster] posting certificate in blob transaction", "data root", eth_common.Bytes2Hex(info.DataRoot), "payload size", len(payload), "versioned hash", blobHash)
+	return p.transactor.PostToInboxBlob(ctx, p.blobInbox, sidecar, args, p.config.TxGasLimit)
+}
+
+// inboxArgs returns the arguments of the inbox method for a blob. Inputs are matched by
+// name, ignoring case and underscores:
+//   - bytes32 dataRoot (or storageRoot), batchHeaderHash, batchRoot, commitmentRoot
+//   - uint epoch, quorumId, batchId, blobIndex, length, confirmationBlockNumber
+//   - bytes inclusionProof, and commitment (or certificate) holding the rollup.Commitment
+//   - bytes32 versionedHash (or blobHash), only in blob transactions where blobHash is set
+func inboxArgs(method abi.Method, info *disperser.ConfirmationInfo, blobHash *eth_common.Hash) ([]interface{}, error) {
+	commitment := &rollup.Commitment{Epoch: info.Epoch, QuorumId: info.QuorumId}
+	copy(commitment.StorageRoot[:], info.DataRoot)
+
+	args := make([]interface{}, len(method.Inputs))
+	for i, input := range method.Inputs {
+		var value interface{}
+		switch strings.ToLower(strings.ReplaceAll(input.Name, "_", "")) {
+		case "dataroot", "storageroot":
+			value = toBytes32(info.DataRoot)
+		case "batchheaderhash":
+			value = info.BatchHeaderHash
+		case "batchroot":
+			value = toBytes32(info.BatchRoot)
+		case "commitmentroot":
+			value = toBytes32(info.CommitmentRoot)
+		case "epoch":
+			value = info.Epoch
+		case "quorumid":
+			value = info.QuorumId
+		case "batchid":
+			value = uint64(info.BatchID)
+		case "blobindex":
+			value = uint64(info.BlobIndex)
+		case "length":
+			value = uint64(info.Length)
+		case "confirmationblocknumber":
+			value = uint64(info.ConfirmationBlockNumber)
+		case "inclusionproof":
+			value = info.BlobInclusionProof
+		case "commitment", "certificate":
+			value = commitment.Encode()
+		case "versionedhash", "blobhash":
+			if blobHash == nil {
+				return nil, fmt.Errorf("inbox method %s takes a blob hash, which only blob transactions have", method.Name)
+			}
+			value = [32]byte(*blobHash)
+		default:
+			return nil, fmt.Errorf("inbox method %s has unknown input %q", method.Name, input.Name)
+		}
+
+		arg, err := toABIType(value, input.Type)
+		if err != nil {
+			return nil, fmt.Errorf("inbox method %s input %s: %w", method.Name, input.Name, err)
+		}
+		args[i] = arg
+	}
+	return args, nil
+}
+
+func toABIType(value interface{}, typ abi.Type) (interface{}, error) {
+	switch v := value.(type) {
+	case uint64:
+		if typ.T != abi.UintTy {
+			return nil, fmt.Errorf("expected an unsigned integer, got %s", typ)
+		}
+		if typ.Size < 64 && v>>typ.Size != 0 {
+			return nil, fmt.Errorf("value %d overflows %s", v, typ)
+		}
+		// sizes other than 8, 16, 32 and 64 bits are packed from big integers
+		if typ.GetType() == reflect.TypeOf(&big.Int{}) {
+			return new(big.Int).SetUint64(v), nil
+		}
+		return reflect.ValueOf(v).Convert(typ.GetType()).Interface(), nil
+	case [32]byte:
+		if typ.T != abi.FixedBytesTy || typ.Size != 32 {
+			return nil, fmt.Errorf("expected bytes32, got %s", typ)
+		}
+		return v, nil
+	case []byte:
+		if typ.T != abi.BytesTy {
+			return nil, fmt.Errorf("expected bytes, got %s", typ)
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("unsupported value %T", value)
+}
+
+func toBytes32(b []byte) [32]byte {
+	var out [32]byte
+	copy(out[:], b)
+	return out
+}
@@ -0,0 +1,89 @@
+package batcher
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/leveldb"
+	eth_common "github.com/ethereum/go-ethereum/common"
+)
+
+var postJournalPrefix = []byte("post-")
+
+// journaledPost is a certificate waiting to be posted to the inbox
+type journaledPost struct {
+	Info    *disperser.ConfirmationInfo
+	Retries uint
+}
+
+func postJournalKey(id uint64) []byte {
+	return binary.BigEndian.AppendUint64(append([]byte{}, postJournalPrefix...), id)
+}
+
+// openJournal persists the certificates waiting to be posted at path, so that a restarted
+// batcher posts those queued before it stopped. They are queued again in their order.
+func (p *Poster) openJournal(path string) error {
+	db, err := leveldb.NewLevelDBStore(path)
+	if err != nil {
+		return fmt.Errorf("failed to open inbox post journal at %s: %w", path, err)
+	}
+
+	iter := db.NewIterator(postJournalPrefix)
+	defer iter.Release()
+	corrupted := make([][]byte, 0)
+	for iter.Next() {
+		key := iter.Key()
+		entry := &journaledPost{}
+		if len(key) != len(postJournalPrefix)+8 || core.Decode(iter.Value(), entry) != nil || entry.Info == nil {
+			p.logger.Warn("[poster] dropping undecodable inbox post journal entry", "key", key)
+			corrupted = append(corrupted, append([]byte{}, key...))
+			continue
+		}
+		id := binary.BigEndian.Uint64(key[len(postJournalPrefix):])
+		p.pending = append(p.pending, &pendingPost{id: id, info: entry.Info, retries: entry.Retries})
+		p.nextID = id + 1
+	}
+	if err := iter.Error(); err != nil {
+		return fmt.Errorf("failed to read inbox post journal: %w", err)
+	}
+	if len(corrupted) > 0 {
+		if err := db.DeleteBatch(corrupted); err != nil {
+			p.logger.Warn("[poster] failed to delete inbox post journal entries", "err", err)
+		}
+	}
+	p.outstanding = len(p.pending)
+	p.journal = db
+	if len(p.pending) > 0 {
+		p.logger.Info("[poster] recovered journaled inbox posts", "count", len(p.pending))
+	}
+	return nil
+}
+
+// journalPost records a post queued or retried
+func (p *Poster) journalPost(post *pendingPost) {
+	if p.journal == nil {
+		return
+	}
+	data, err := core.Encode(&journaledPost{Info: post.info, Retries: post.retries})
+	if err == nil {
+		err = p.journal.Put(postJournalKey(post.id), data)
+	}
+	if err != nil {
+		p.logger.Warn("[poster] failed to journal inbox post", "data root", eth_common.Bytes2Hex(post.info.DataRoot), "err", err)
+	}
+}
+
+// forgetPost removes a post that was posted or gave up on
+func (p *Poster) forgetPost(post *pendingPost) {
+	p.mu.Lock()
+	p.outstanding--
+	p.mu.Unlock()
+	if p.journal == nil {
+		return
+	}
+	if err := p.journal.Delete(postJournalKey(post.id)); err != nil {
+		p.logger.Warn("[poster] failed to delete inbox post journal entry", "data root", eth_common.Bytes2Hex(post.info.DataRoot), "err", err)
+	}
+}
@@ -0,0 +1,151 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/contract"
+	"github.com/0glabs/0g-da-client/disperser/leveldb"
+	"github.com/0glabs/0g-da-client/rollup"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testInboxABI = `[{"type":"function","name":"postCertificate","inputs":[
+	{"name":"dataRoot","type":"bytes32"},
+	{"name":"epoch","type":"uint256"},
+	{"name":"quorum_id","type":"uint32"},
+	{"name":"commitment","type":"bytes"}]},
+{"type":"function","name":"postProof","inputs":[{"name":"proof","type":"bytes"}]},
+{"type":"function","name":"postBlob","inputs":[
+	{"name":"dataRoot","type":"bytes32"},
+	{"name":"versionedHash","type":"bytes32"}]},
+{"type":"function","name":"postSmall","inputs":[{"name":"blobIndex","type":"uint8"}]}]`
+
+func TestInboxArgs(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(testInboxABI))
+	assert.NoError(t, err)
+
+	info := &disperser.ConfirmationInfo{
+		DataRoot:  []byte{1, 2, 3},
+		Epoch:     7,
+		QuorumId:  2,
+		BlobIndex: 300,
+	}
+	args, err := inboxArgs(parsed.Methods["postCertificate"], info, nil)
+	assert.NoError(t, err)
+	assert.Len(t, args, 4)
+	assert.Equal(t, big.NewInt(7), args[1])
+	assert.Equal(t, uint32(2), args[2])
+
+	commitment, err := rollup.DecodeCommitment(args[3].([]byte))
+	assert.NoError(t, err)
+	assert.Equal(t, args[0], commitment.StorageRoot)
+	assert.Equal(t, uint64(7), commitment.Epoch)
+
+	// the encoded arguments must match the abi
+	_, err = parsed.Pack("postCertificate", args...)
+	assert.NoError(t, err)
+
+	_, err = inboxArgs(parsed.Methods["postProof"], info, nil)
+	assert.Error(t, err)
+	_, err = inboxArgs(parsed.Methods["postSmall"], info, nil)
+	assert.Error(t, err)
+}
+
+type posterTestFees struct {
+	baseFee, blobFee *big.Int
+	err              error
+}
+
+func (f *posterTestFees) BlobFees(ctx context.Context) (*big.Int, *big.Int, error) {
+	return f.baseFee, f.blobFee, f.err
+}
+
+func TestPosterUseBlob(t *testing.T) {
+	ctx := context.Background()
+	daContract := &contract.DAContract{}
+	inbox, err := contract.NewInbox(daContract, eth_common.Address{}, testInboxABI, "postCertificate")
+	require.NoError(t, err)
+	blobInbox, err := contract.NewInbox(daContract, eth_common.Address{}, testInboxABI, "postBlob")
+	require.NoError(t, err)
+	fees := &posterTestFees{baseFee: big.NewInt(1_000_000), blobFee: big.NewInt(1)}
+	p := &Poster{
+		config:    PosterConfig{BlobMode: BlobModeAuto},
+		inbox:     inbox,
+		blobInbox: blobInbox,
+		fees:      fees,
+		logger:    mock.NewLogger(false),
+	}
+
+	info := &disperser.ConfirmationInfo{DataRoot: []byte{1, 2, 3}, Epoch: 7, QuorumId: 2}
+	args, err := inboxArgs(inbox.Method, info, nil)
+	require.NoError(t, err)
+	payload, err := p.useBlob(ctx, info, args)
+	require.NoError(t, err)
+	expected, err := inbox.Method.Inputs.Pack(args...)
+	require.NoError(t, err)
+	assert.Equal(t, expected, payload)
+
+	// the blob costs more than the calldata it saves
+	fees.blobFee = big.NewInt(1_000_000)
+	payload, err = p.useBlob(ctx, info, args)
+	require.NoError(t, err)
+	assert.Nil(t, payload)
+
+	// the certificate is posted in calldata when the fees can't be read
+	fees.err = errors.New("rpc down")
+	payload, err = p.useBlob(ctx, info, args)
+	require.NoError(t, err)
+	assert.Nil(t, payload)
+}
+
+func TestPosterJournal(t *testing.T) {
+	logger := mock.NewLogger(false)
+	metrics := NewMetrics("9100", logger)
+	path := filepath.Join(t.TempDir(), "posts")
+	newPoster := func() *Poster {
+		p := &Poster{config: PosterConfig{MaxPending: 2}, logger: logger, metrics: metrics}
+		require.NoError(t, p.openJournal(path))
+		return p
+	}
+
+	p := newPoster()
+	first := &disperser.ConfirmationInfo{DataRoot: []byte{1}, Epoch: 1}
+	second := &disperser.ConfirmationInfo{DataRoot: []byte{2}, Epoch: 2}
+	p.Enqueue(first)
+	p.Enqueue(second)
+	// the queue is bounded
+	p.Enqueue(&disperser.ConfirmationInfo{DataRoot: []byte{3}})
+	posts := p.drain()
+	require.Len(t, posts, 2)
+	// the first is posted, the second is retried when the batcher stops
+	p.forgetPost(posts[0])
+	posts[1].retries++
+	p.journalPost(posts[1])
+	p.Enqueue(&disperser.ConfirmationInfo{DataRoot: []byte{4}})
+	require.NoError(t, p.journal.(*leveldb.LevelDBStore).Close())
+
+	// the posts left are queued again in their order on restart
+	p = newPoster()
+	posts = p.drain()
+	require.Len(t, posts, 2)
+	assert.Equal(t, second.DataRoot, posts[0].info.DataRoot)
+	assert.Equal(t, uint(1), posts[0].retries)
+	assert.Equal(t, []byte{4}, posts[1].info.DataRoot)
+	assert.Equal(t, 2, p.outstanding)
+	p.Enqueue(&disperser.ConfirmationInfo{DataRoot: []byte{5}})
+	assert.Empty(t, p.pending)
+	p.forgetPost(posts[0])
+	p.Enqueue(&disperser.ConfirmationInfo{DataRoot: []byte{5}})
+	require.Len(t, p.pending, 1)
+	assert.Equal(t, posts[1].id+1, p.pending[0].id)
+}
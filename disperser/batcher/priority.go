@@ -0,0 +1,36 @@
+package batcher
+
+import (
+	"sort"
+
+	"github.com/0glabs/0g-da-client/disperser"
+)
+
+func blobPriority(metadata *disperser.BlobMetadata) uint8 {
+	if metadata.RequestMetadata == nil {
+		return 0
+	}
+	return metadata.RequestMetadata.Priority
+}
+
+// lessByPriority orders blobs by priority lane, highest first, then by request time, then by
+// blob key. It is the order the blobs of a batch are indexed in, total so that the index of a
+// blob doesn't depend on the iteration order of the encoded blob store.
+func lessByPriority(a, b *disperser.BlobMetadata) bool {
+	if pa, pb := blobPriority(a), blobPriority(b); pa != pb {
+		return pa > pb
+	}
+	if ta, tb := requestedAt(a), requestedAt(b); ta != tb {
+		return ta < tb
+	}
+	return a.GetBlobKey().String() < b.GetBlobKey().String()
+}
+
+// orderByPriority moves the blobs of higher priority lanes first, keeping the order of the
+// blobs within a lane
+func orderByPriority(metadatas []*disperser.BlobMetadata) []*disperser.BlobMetadata {
+	sort.SliceStable(metadatas, func(i, j int) bool {
+		return blobPriority(metadatas[i]) > blobPriority(metadatas[j])
+	})
+	return metadatas
+}
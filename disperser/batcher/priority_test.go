@@ -0,0 +1,39 @@
+package batcher
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderByPriority(t *testing.T) {
+	blob := func(priority uint8, requestedAt uint64) *disperser.BlobMetadata {
+		return &disperser.BlobMetadata{RequestMetadata: &disperser.RequestMetadata{
+			BlobRequestHeader: core.BlobRequestHeader{Priority: priority},
+			RequestedAt:       requestedAt,
+		}}
+	}
+	a, b, c, d := blob(0, 1), blob(2, 2), blob(0, 3), blob(1, 4)
+	assert.Equal(t, []*disperser.BlobMetadata{b, d, a, c}, orderByPriority([]*disperser.BlobMetadata{a, b, c, d}))
+
+	assert.True(t, lessByPriority(blob(1, 5), blob(0, 1)))
+	assert.True(t, lessByPriority(blob(1, 1), blob(1, 5)))
+	assert.False(t, lessByPriority(&disperser.BlobMetadata{}, blob(0, 0)))
+}
+
+func TestBatchIndexOrderIsTotal(t *testing.T) {
+	blob := func(blobHash string, requestedAt uint64) *disperser.BlobMetadata {
+		return &disperser.BlobMetadata{BlobHash: blobHash, MetadataHash: "m", RequestMetadata: &disperser.RequestMetadata{RequestedAt: requestedAt}}
+	}
+	expected := []*disperser.BlobMetadata{blob("a", 1), blob("b", 1), blob("c", 1), blob("a", 2)}
+	for i := 0; i < 10; i++ {
+		metadatas := append([]*disperser.BlobMetadata(nil), expected...)
+		rand.Shuffle(len(metadatas), func(i, j int) { metadatas[i], metadatas[j] = metadatas[j], metadatas[i] })
+		sort.Slice(metadatas, func(i, j int) bool { return lessByPriority(metadatas[i], metadatas[j]) })
+		assert.Equal(t, expected, metadatas)
+	}
+}
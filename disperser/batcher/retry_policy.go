@@ -0,0 +1,248 @@
+package batcher
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/disperser"
+)
+
+// RetryPolicy is how the blobs of a batch failed for some reason are retried. A blob is
+// held back from encoding for a backoff growing exponentially with the number of its
+// retries, and dead-lettered once it used up its retries.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries of a blob, the max number of retries per blob if 0
+	MaxRetries uint
+	// Backoff is the backoff of the first retry, blobs are retried right away if 0
+	Backoff time.Duration
+	// MaxBackoff caps the backoff, uncapped if 0
+	MaxBackoff time.Duration
+	// Multiplier is the growth of the backoff with each retry, 2 if 0
+	Multiplier float64
+	// Jitter is the fraction of the backoff drawn at random, in [0, 1], so that the blobs of
+	// a failed batch aren't all retried at once
+	Jitter float64
+}
+
+func (p RetryPolicy) validate() error {
+	if p.Backoff < 0 || p.MaxBackoff < 0 {
+		return fmt.Errorf("the retry backoff must not be negative")
+	}
+	if p.Multiplier != 0 && p.Multiplier < 1 {
+		return fmt.Errorf("the retry backoff multiplier must be at least 1")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("the retry jitter must be in [0, 1]")
+	}
+	return nil
+}
+
+// backoff returns the backoff of the retry following the given number of retries, before
+// jitter
+func (p RetryPolicy) backoff(retries uint) time.Duration {
+	if p.Backoff <= 0 {
+		return 0
+	}
+	multiplier := p.Multiplier
+	if multiplier == 0 {
+		multiplier = 2
+	}
+	backoff := float64(p.Backoff) * math.Pow(multiplier, float64(retries))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		return p.MaxBackoff
+	}
+	if backoff > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(backoff)
+}
+
+// RetryConfig is the retry policy of the failed blobs, per failure reason
+type RetryConfig struct {
+	// Default is the policy of the reasons without their own
+	Default RetryPolicy
+	// Policies are the policies of some failure reasons as
+	// <reason>=<field>:<value>[,<field>:<value>...], e.g. confirm_batch=backoff:30s,retries:5,
+	// with the fields retries, backoff, max-backoff, multiplier and jitter. The fields left out
+	// are those of the default policy. The reasons are the FailReason values.
+	Policies []string
+}
+
+func (c RetryConfig) validate() error {
+	_, err := c.policies()
+	return err
+}
+
+// policies returns the policy of each failure reason given its own
+func (c RetryConfig) policies() (map[FailReason]RetryPolicy, error) {
+	if err := c.Default.validate(); err != nil {
+		return nil, err
+	}
+	policies := make(map[FailReason]RetryPolicy, len(c.Policies))
+	for _, entry := range c.Policies {
+		reason, fields, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid retry policy %q, expected <reason>=<field>:<value>,...", entry)
+		}
+		failReason := FailReason(strings.TrimSpace(reason))
+		if !slices.Contains(failReasons, failReason) {
+			return nil, fmt.Errorf("unknown failure reason %q of retry policy %q", failReason, entry)
+		}
+		if _, ok := policies[failReason]; ok {
+			return nil, fmt.Errorf("duplicate retry policy for %s", failReason)
+		}
+		policy := c.Default
+		for _, field := range strings.Split(fields, ",") {
+			name, value, ok := strings.Cut(field, ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid field %q of retry policy %q, expected <field>:<value>", field, entry)
+			}
+			value = strings.TrimSpace(value)
+			var err error
+			switch strings.TrimSpace(name) {
+			case "retries":
+				var retries uint64
+				retries, err = strconv.ParseUint(value, 10, 32)
+				policy.MaxRetries = uint(retries)
+			case "backoff":
+				policy.Backoff, err = time.ParseDuration(value)
+			case "max-backoff":
+				policy.MaxBackoff, err = time.ParseDuration(value)
+			case "multiplier":
+				policy.Multiplier, err = strconv.ParseFloat(value, 64)
+			case "jitter":
+				policy.Jitter, err = strconv.ParseFloat(value, 64)
+			default:
+				return nil, fmt.Errorf("unknown field %q of retry policy %q", name, entry)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid field %q of retry policy %q: %w", field, entry, err)
+			}
+		}
+		if err := policy.validate(); err != nil {
+			return nil, fmt.Errorf("retry policy of %s: %w", failReason, err)
+		}
+		policies[failReason] = policy
+	}
+	return policies, nil
+}
+
+// retryScheduler holds the failed blobs back from encoding until their backoff elapses.
+// The backoffs are kept in memory, so the blobs are retried right away after a restart.
+type retryScheduler struct {
+	defaultPolicy RetryPolicy
+	policies      map[FailReason]RetryPolicy
+
+	mu        sync.Mutex
+	notBefore map[disperser.BlobKey]time.Time
+	rand      *rand.Rand
+	now       func() time.Time
+}
+
+// newRetryScheduler creates the scheduler of config, retrying a blob maxRetries times unless
+// its policy has its own max retries
+func newRetryScheduler(config RetryConfig, maxRetries uint) (*retryScheduler, error) {
+	policies, err := config.policies()
+	if err != nil {
+		return nil, err
+	}
+	if config.Default.MaxRetries == 0 {
+		config.Default.MaxRetries = maxRetries
+	}
+	for reason, policy := range policies {
+		if policy.MaxRetries == 0 {
+			policy.MaxRetries = config.Default.MaxRetries
+			policies[reason] = policy
+		}
+	}
+	return &retryScheduler{
+		defaultPolicy: config.Default,
+		policies:      policies,
+		notBefore:     make(map[disperser.BlobKey]time.Time),
+		rand:          rand.New(rand.NewSource(time.Now().UnixNano())),
+		now:           time.Now,
+	}, nil
+}
+
+// retryScheduler returns the retry scheduler of the failed blobs, nil without a streamer
+func (e *EncodingStreamer) retryScheduler() *retryScheduler {
+	if e == nil {
+		return nil
+	}
+	return e.retries
+}
+
+// policy returns the retry policy of the blobs failed for reason
+func (r *retryScheduler) policy(reason FailReason) RetryPolicy {
+	if policy, ok := r.policies[reason]; ok {
+		return policy
+	}
+	return r.defaultPolicy
+}
+
+// handleBatchFailure hands a blob of a failed batch back for retry like handleBlobFailure,
+// clearing its assignment to the batch
+func (e *EncodingStreamer) handleBatchFailure(ctx context.Context, blobStore disperser.BlobStore, metadata *disperser.BlobMetadata, reason FailReason, maxRetries uint) error {
+	if e != nil {
+		e.assignments.clear(metadata.GetBlobKey())
+	}
+	return e.retryScheduler().handleBlobFailure(ctx, blobStore, metadata, reason, maxRetries)
+}
+
+// handleBlobFailure hands a blob failed for reason back for retry under the policy of
+// reason, or dead-letters it once its retries are used up. Without a scheduler, the blob is
+// retried right away up to maxRetries times.
+func (r *retryScheduler) handleBlobFailure(ctx context.Context, blobStore disperser.BlobStore, metadata *disperser.BlobMetadata, reason FailReason, maxRetries uint) error {
+	if r == nil {
+		return blobStore.HandleBlobFailure(ctx, metadata, maxRetries)
+	}
+	policy := r.policy(reason)
+	// some stores update the metadata in place
+	retries := metadata.NumRetries
+	if err := blobStore.HandleBlobFailure(ctx, metadata, policy.MaxRetries); err != nil {
+		return err
+	}
+	if retries < policy.MaxRetries {
+		r.schedule(metadata.GetBlobKey(), policy, retries)
+	}
+	return nil
+}
+
+// schedule holds a blob back for the backoff of its next retry
+func (r *retryScheduler) schedule(key disperser.BlobKey, policy RetryPolicy, retries uint) {
+	backoff := policy.backoff(retries)
+	if backoff <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if policy.Jitter > 0 {
+		backoff -= time.Duration(policy.Jitter * r.rand.Float64() * float64(backoff))
+	}
+	r.notBefore[key] = r.now().Add(backoff)
+}
+
+// due tells whether a blob may be encoded, that is it isn't backing off
+func (r *retryScheduler) due(key disperser.BlobKey) bool {
+	if r == nil {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	notBefore, ok := r.notBefore[key]
+	if !ok {
+		return true
+	}
+	if r.now().Before(notBefore) {
+		return false
+	}
+	delete(r.notBefore, key)
+	return true
+}
@@ -0,0 +1,101 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicies(t *testing.T) {
+	config := RetryConfig{
+		Default:  RetryPolicy{Backoff: time.Second, MaxBackoff: 10 * time.Second},
+		Policies: []string{"confirm_batch=backoff:1m,max-backoff:1h,retries:1"},
+	}
+	r, err := newRetryScheduler(config, 3)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint(3), r.policy(FailGetSigners).MaxRetries)
+	assert.Equal(t, 4*time.Second, r.policy(FailGetSigners).backoff(2))
+	assert.Equal(t, 10*time.Second, r.policy(FailGetSigners).backoff(5))
+	assert.Equal(t, uint(1), r.policy(FailConfirmBatch).MaxRetries)
+	assert.Equal(t, 2*time.Minute, r.policy(FailConfirmBatch).backoff(1))
+
+	for _, policies := range [][]string{{"confirm_batch"}, {"confirm_batch=backoff"}, {"confirm_batch=delay:1s"}, {"confirm_batch=jitter:2"}, {"confirm_batches=retries:1"}} {
+		assert.Error(t, RetryConfig{Policies: policies}.validate(), policies)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	ctx := context.Background()
+	store := memorydb.NewBlobStore(core.MaxBlobSize*4, mock.NewLogger(false))
+	key, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("blob")}, uint64(time.Now().UnixNano()))
+	require.NoError(t, err)
+
+	r, err := newRetryScheduler(RetryConfig{
+		Default:  RetryPolicy{Backoff: time.Minute},
+		Policies: []string{"confirm_batch=retries:1"},
+	}, 3)
+	require.NoError(t, err)
+	now := time.Unix(1000, 0)
+	r.now = func() time.Time { return now }
+
+	metadata, err := store.GetBlobMetadata(ctx, key)
+	require.NoError(t, err)
+	require.NoError(t, r.handleBlobFailure(ctx, store, metadata, FailConfirmBatch, 3))
+	assert.False(t, r.due(key))
+	now = now.Add(time.Minute)
+	assert.True(t, r.due(key))
+
+	// the retries of the confirmation failures are used up
+	metadata, err = store.GetBlobMetadata(ctx, key)
+	require.NoError(t, err)
+	require.NoError(t, r.handleBlobFailure(ctx, store, metadata, FailConfirmBatch, 3))
+	metadata, err = store.GetBlobMetadata(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, disperser.DeadLettered, metadata.BlobStatus)
+}
+
+func TestEncodingFailureRetried(t *testing.T) {
+	ctx := context.Background()
+	logger := mock.NewLogger(false)
+	store := memorydb.NewBlobStore(core.MaxBlobSize*4, logger)
+	key, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("blob")}, uint64(time.Now().UnixNano()))
+	require.NoError(t, err)
+
+	streamer, err := NewEncodingStreamer(StreamerConfig{EncodingQueueLimit: 10, MaxNumRetriesPerBlob: 3}, store, nil, NewEncodedSizeNotifier(make(chan struct{}, 1), 0), nil, nil, logger)
+	require.NoError(t, err)
+	streamer.retries, err = newRetryScheduler(RetryConfig{
+		Default:  RetryPolicy{Backoff: time.Minute},
+		Policies: []string{"encoding=retries:1"},
+	}, 3)
+	require.NoError(t, err)
+
+	fail := func() {
+		metadata, err := store.GetBlobMetadata(ctx, key)
+		require.NoError(t, err)
+		assert.Error(t, streamer.ProcessEncodedBlobs(ctx, EncodingResultOrStatus{
+			EncodingResult: EncodingResult{BlobMetadata: metadata},
+			Err:            errors.New("encoder unavailable"),
+		}))
+	}
+	fail()
+	metadata, err := store.GetBlobMetadata(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, disperser.Processing, metadata.BlobStatus)
+	assert.Equal(t, uint(1), metadata.NumRetries)
+	assert.False(t, streamer.retries.due(key))
+
+	// the retries of the encoding failures are used up
+	fail()
+	metadata, err = store.GetBlobMetadata(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, disperser.DeadLettered, metadata.BlobStatus)
+}
@@ -0,0 +1,315 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/reload"
+	"github.com/0glabs/0g-da-client/disperser"
+)
+
+// errBatchingPaused is returned when batches are paused through the admin API
+var errBatchingPaused = errors.New("batching paused")
+
+// BatchingStatus reports whether the batches are paused through the admin API. Unlike the
+// safe mode, the blobs keep being encoded, so batching resumes with a backlog ready.
+type BatchingStatus struct {
+	Paused bool      `json:"paused"`
+	Since  time.Time `json:"since,omitempty"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+type batchingPause struct {
+	mu     sync.RWMutex
+	status BatchingStatus
+}
+
+func (p *batchingPause) paused() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.status.Paused
+}
+
+func (p *batchingPause) get() BatchingStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.status
+}
+
+// set pauses or resumes the batches, returning whether it changed
+func (p *batchingPause) set(paused bool, reason string, now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.status.Paused == paused {
+		return false
+	}
+	p.status = BatchingStatus{Paused: paused}
+	if paused {
+		p.status.Since = now
+		p.status.Reason = reason
+	}
+	return true
+}
+
+// Batching returns whether the batches are paused
+func (b *Batcher) Batching() BatchingStatus {
+	return b.pause.get()
+}
+
+// SetBatchingPaused pauses or resumes the creation of batches, see BatchingStatus. Batches
+// already created are still signed, submitted and confirmed.
+func (b *Batcher) SetBatchingPaused(paused bool, reason string) {
+	if !b.pause.set(paused, reason, time.Now()) {
+		return
+	}
+	if paused {
+		b.logger.Warn("[batcher] batching paused", "reason", reason)
+	} else {
+		b.logger.Info("[batcher] batching resumed")
+		b.EncodingStreamer.triggerBatch()
+	}
+}
+
+// DrainStatus reports the progress of a drain before shutdown: the encoding streamer stops
+// pulling new blobs, and the blobs already pulled are encoded and batched without waiting
+// for the minimum batch size. The batcher is drained once every blob pulled is confirmed,
+// none left encoding, outside a batch or in a batch being signed or confirmed.
+type DrainStatus struct {
+	Draining bool      `json:"draining"`
+	Since    time.Time `json:"since,omitempty"`
+	Drained  bool      `json:"drained"`
+	// Encoding is the number of blobs being encoded
+	Encoding int `json:"encoding"`
+	// Pending is the number of encoded blobs not in a batch yet
+	Pending int `json:"pending"`
+	// Batched is the number of encoded blobs in batches not confirmed yet
+	Batched int `json:"batched"`
+	// BatchesToSign and BatchesToConfirm are the batches waiting for the signer and the
+	// confirmer
+	BatchesToSign    int `json:"batches_to_sign"`
+	BatchesToConfirm int `json:"batches_to_confirm"`
+}
+
+// intake stops the encoding streamer from pulling new blobs while the batcher drains
+type intake struct {
+	mu    sync.RWMutex
+	since time.Time
+}
+
+func (i *intake) stopped() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return !i.since.IsZero()
+}
+
+func (i *intake) stoppedSince() time.Time {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.since
+}
+
+// set stops or resumes the intake, returning whether it changed
+func (i *intake) set(stopped bool, now time.Time) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if stopped == !i.since.IsZero() {
+		return false
+	}
+	i.since = time.Time{}
+	if stopped {
+		i.since = now
+	}
+	return true
+}
+
+// Drain starts or cancels draining the batcher before a shutdown, see DrainStatus
+func (b *Batcher) Drain(enabled bool) {
+	if !b.EncodingStreamer.intake.set(enabled, time.Now()) {
+		return
+	}
+	if enabled {
+		b.logger.Warn("[batcher] draining, no new blobs are pulled for encoding")
+		b.EncodingStreamer.triggerBatch()
+	} else {
+		b.logger.Info("[batcher] drain cancelled, resuming the encoding of new blobs")
+	}
+}
+
+// DrainStatus returns the progress of the drain
+func (b *Batcher) DrainStatus() DrainStatus {
+	since := b.EncodingStreamer.intake.stoppedSince()
+	status := DrainStatus{
+		Draining: !since.IsZero(),
+		Since:    since,
+		Encoding: b.EncodingStreamer.EncodedBlobstore.GetEncodingRequestingSize(),
+	}
+	status.Pending, _, _ = b.EncodingStreamer.EncodedBlobstore.GetPendingEncodedStats()
+	status.Batched = b.EncodingStreamer.EncodedBlobstore.GetBatchingSize()
+	if b.sliceSigner != nil {
+		status.BatchesToSign = b.sliceSigner.PendingBatches()
+	}
+	if b.confirmer != nil {
+		status.BatchesToConfirm = b.confirmer.PendingBatches()
+	}
+	status.Drained = status.Draining && status.Encoding == 0 && status.Pending == 0 &&
+		status.Batched == 0 && status.BatchesToSign == 0 && status.BatchesToConfirm == 0
+	return status
+}
+
+// drainPollInterval is how often the drain status is polled while a stage of the batcher stops
+const drainPollInterval = 100 * time.Millisecond
+
+// StopBatching drains the batcher, see DrainStatus, and waits until every blob pulled is
+// confirmed or ctx is done. The stages started by StartEncoding and StartConfirmation must
+// still run for the drain to complete.
+func (b *Batcher) StopBatching(ctx context.Context) error {
+	b.Drain(true)
+	return b.waitDrain(ctx, func(status DrainStatus) bool { return status.Drained })
+}
+
+// StopConfirmation waits until the batches created are signed and confirmed or ctx is done
+func (b *Batcher) StopConfirmation(ctx context.Context) error {
+	return b.waitDrain(ctx, func(status DrainStatus) bool {
+		return status.BatchesToSign == 0 && status.BatchesToConfirm == 0
+	})
+}
+
+// StopEncoding stops pulling new blobs and waits until the blobs being encoded are encoded
+// or ctx is done
+func (b *Batcher) StopEncoding(ctx context.Context) error {
+	b.Drain(true)
+	return b.waitDrain(ctx, func(status DrainStatus) bool { return status.Encoding == 0 })
+}
+
+func (b *Batcher) waitDrain(ctx context.Context, done func(DrainStatus) bool) error {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for {
+		status := b.DrainStatus()
+		if done(status) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %d blobs encoding, %d pending, %d batched, %d batches to sign, %d to confirm", ctx.Err(),
+				status.Encoding, status.Pending, status.Batched, status.BatchesToSign, status.BatchesToConfirm)
+		case <-ticker.C:
+		}
+	}
+}
+
+// BatchSizeMB returns the batch size limit in MB, BatchSizeMBLimit unless changed at runtime
+func (b *Batcher) BatchSizeMB() uint {
+	if mb := b.batchSizeMB.Load(); mb > 0 {
+		return uint(mb)
+	}
+	return b.BatchSizeMBLimit
+}
+
+// SetBatchSizeMB changes the batch size limit. Batch sizing adapts the new limit on its next
+// update, otherwise it applies right away.
+func (b *Batcher) SetBatchSizeMB(mb uint) error {
+	if mb == 0 {
+		return errors.New("batch size limit must be positive")
+	}
+	b.batchSizeMB.Store(uint64(mb))
+	if !b.BatchSizing.Enabled() {
+		b.EncodingStreamer.EncodedSizeNotifier.setThreshold(uint64(mb) * 1024 * 1024)
+	}
+	b.logger.Info("[batcher] batch size limit changed", "mb", mb)
+	return nil
+}
+
+// PullIntervalNow returns the interval batches are created at, PullInterval unless changed
+// by the reloaded tunables
+func (b *Batcher) PullIntervalNow() time.Duration {
+	if interval := b.pullInterval.Load(); interval > 0 {
+		return time.Duration(interval)
+	}
+	return b.PullInterval
+}
+
+// Tune applies the tunables reloaded at runtime, validated by Tunables.Validate. The tunables
+// left out keep their current value, such as the batch size limit set through the admin API.
+// The timeouts are set first, the only change that may fail, so that the tunables are
+// applied as a whole or not at all.
+func (b *Batcher) Tune(tunables *disperser.Tunables) {
+	timeouts := b.TimeoutConfig.Current()
+	overrideTimeout(&timeouts.EncodingTimeout, tunables.Timeouts.Encoding)
+	overrideTimeout(&timeouts.ChainReadTimeout, tunables.Timeouts.ChainRead)
+	overrideTimeout(&timeouts.ChainWriteTimeout, tunables.Timeouts.ChainWrite)
+	overrideTimeout(&timeouts.SigningTimeout, tunables.Timeouts.Signing)
+	overrideTimeout(&timeouts.StoreWriteTimeout, tunables.Timeouts.StoreWrite)
+	overrideTimeout(&timeouts.ReceiptWaitTimeout, tunables.Timeouts.ReceiptWait)
+	if err := b.TimeoutConfig.SetTimeouts(timeouts); err != nil {
+		b.logger.Error("[batcher] tunables not applied", "err", err)
+		return
+	}
+
+	if interval := time.Duration(tunables.PullInterval); interval > 0 && interval != b.PullIntervalNow() {
+		b.pullInterval.Store(int64(interval))
+		b.logger.Info("[batcher] pull interval changed", "interval", interval)
+	}
+	// the limit is positive, so setting it doesn't fail
+	if mb := tunables.BatchSizeMB; mb > 0 && mb != b.BatchSizeMB() {
+		_ = b.SetBatchSizeMB(mb)
+	}
+}
+
+func overrideTimeout(timeout *time.Duration, tunable reload.Duration) {
+	if tunable > 0 {
+		*timeout = time.Duration(tunable)
+	}
+}
+
+// PipelineState is a snapshot of the batcher pipeline, for debugging
+type PipelineState struct {
+	SafeMode SafeModeStatus `json:"safe_mode"`
+	Batching BatchingStatus `json:"batching"`
+	Drain    DrainStatus    `json:"drain"`
+	// BatchSizeMB is the batch size limit, and BatchThreshold the encoded size in bytes
+	// currently triggering a batch
+	BatchSizeMB    uint   `json:"batch_size_mb"`
+	BatchThreshold uint64 `json:"batch_threshold"`
+	// EncodingBlobs is the number of blobs being encoded
+	EncodingBlobs int `json:"encoding_blobs"`
+	// PendingBlobs, PendingSize and OldestPending describe the encoded blobs not in a
+	// batch yet
+	PendingBlobs  int       `json:"pending_blobs"`
+	PendingSize   uint64    `json:"pending_size"`
+	OldestPending time.Time `json:"oldest_pending,omitempty"`
+	// BatchedBlobs is the number of encoded blobs in batches not confirmed yet
+	BatchedBlobs int `json:"batched_blobs"`
+	// BatchesToSign and BatchesToConfirm are the batches waiting for the signer and the
+	// confirmer
+	BatchesToSign    int             `json:"batches_to_sign"`
+	BatchesToConfirm int             `json:"batches_to_confirm"`
+	Expedited        []expeditedBlob `json:"expedited"`
+}
+
+// PipelineState returns a snapshot of the pipeline
+func (b *Batcher) PipelineState() PipelineState {
+	e := b.EncodingStreamer
+	state := PipelineState{
+		SafeMode:       e.SafeMode(),
+		Batching:       b.Batching(),
+		Drain:          b.DrainStatus(),
+		BatchSizeMB:    b.BatchSizeMB(),
+		BatchThreshold: e.EncodedSizeNotifier.getThreshold(),
+		EncodingBlobs:  e.EncodedBlobstore.GetEncodingRequestingSize(),
+		Expedited:      e.expediter.list(),
+	}
+	var oldest uint64
+	state.PendingBlobs, state.PendingSize, oldest = e.EncodedBlobstore.GetPendingEncodedStats()
+	if oldest > 0 {
+		state.OldestPending = time.Unix(0, int64(oldest))
+	}
+	state.BatchedBlobs = state.Drain.Batched
+	state.BatchesToSign = state.Drain.BatchesToSign
+	state.BatchesToConfirm = state.Drain.BatchesToConfirm
+	return state
+}
@@ -0,0 +1,133 @@
+package batcher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/common/reload"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuntimeControl(t *testing.T) {
+	ctx := context.Background()
+	logger := mock.NewLogger(false)
+	store := memorydb.NewBlobStore(core.MaxBlobSize*4, logger)
+	streamer, err := NewEncodingStreamer(StreamerConfig{EncodingQueueLimit: 10}, store, nil, NewEncodedSizeNotifier(make(chan struct{}, 1), 0), nil, nil, logger)
+	require.NoError(t, err)
+	b := &Batcher{Config: Config{BatchSizeMBLimit: 8}, EncodingStreamer: streamer, logger: logger}
+	server := httptest.NewServer(NewAdminServer(AdminConfig{Token: "token"}, b, logger).Handler())
+	defer server.Close()
+
+	post := func(path string) int {
+		req, err := http.NewRequest(http.MethodPost, server.URL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer token")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, post("/batching?paused=true&reason=upgrade"))
+	_, err = b.HandleSingleBatch(ctx)
+	assert.ErrorIs(t, err, errBatchingPaused)
+	assert.Equal(t, http.StatusConflict, post("/batch"))
+	assert.Empty(t, streamer.EncodedSizeNotifier.Notify)
+	assert.Equal(t, http.StatusOK, post("/batching?paused=false"))
+	assert.False(t, b.Batching().Paused)
+
+	assert.Equal(t, http.StatusBadRequest, post("/batch-size?mb=0"))
+	assert.Equal(t, http.StatusOK, post("/batch-size?mb=2"))
+	assert.Equal(t, uint64(2<<20), streamer.EncodedSizeNotifier.getThreshold())
+
+	// a draining streamer pulls no new blobs
+	assert.Equal(t, http.StatusOK, post("/drain?enabled=true"))
+	_, err = store.StoreBlob(ctx, &core.Blob{Data: []byte{1}}, 1)
+	require.NoError(t, err)
+	require.NoError(t, streamer.RequestEncoding(ctx, make(chan EncodingResultOrStatus)))
+	assert.Zero(t, streamer.EncodedBlobstore.GetEncodingRequestingSize())
+
+	assert.Equal(t, http.StatusAccepted, post("/batch"))
+	assert.Len(t, streamer.EncodedSizeNotifier.Notify, 1)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/state", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer token")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var state PipelineState
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&state))
+	assert.True(t, state.Drain.Drained)
+	assert.Equal(t, uint(2), state.BatchSizeMB)
+	assert.False(t, state.Batching.Paused)
+}
+
+func TestTune(t *testing.T) {
+	logger := mock.NewLogger(false)
+	streamer, err := NewEncodingStreamer(StreamerConfig{EncodingQueueLimit: 10}, nil, nil, NewEncodedSizeNotifier(make(chan struct{}, 1), 0), nil, nil, logger)
+	require.NoError(t, err)
+	timeouts := TimeoutConfig{SigningTimeout: time.Minute, ChainReadTimeout: 10 * time.Second}.Reloadable()
+	b := &Batcher{Config: Config{BatchSizeMBLimit: 8, PullInterval: 5 * time.Second}, TimeoutConfig: timeouts, EncodingStreamer: streamer, logger: logger}
+
+	b.Tune(&disperser.Tunables{PullInterval: reload.Duration(2 * time.Second), BatchSizeMB: 4, Timeouts: disperser.TimeoutTunables{Signing: reload.Duration(30 * time.Second)}})
+	assert.Equal(t, 2*time.Second, b.PullIntervalNow())
+	assert.Equal(t, uint(4), b.BatchSizeMB())
+	assert.Equal(t, 30*time.Second, b.TimeoutConfig.Timeout(CallOperatorRPC))
+	assert.Equal(t, 10*time.Second, b.TimeoutConfig.Timeout(CallChainRead))
+
+	// the tunables left out keep their current value, such as the limit set through the
+	// admin API
+	require.NoError(t, b.SetBatchSizeMB(2))
+	b.Tune(&disperser.Tunables{Timeouts: disperser.TimeoutTunables{ChainRead: reload.Duration(20 * time.Second)}})
+	assert.Equal(t, 2*time.Second, b.PullIntervalNow())
+	assert.Equal(t, uint(2), b.BatchSizeMB())
+	assert.Equal(t, 30*time.Second, b.TimeoutConfig.Timeout(CallOperatorRPC))
+	assert.Equal(t, 20*time.Second, b.TimeoutConfig.Timeout(CallChainRead))
+
+	// tunables that can't all be applied aren't applied at all
+	b.TimeoutConfig = TimeoutConfig{}
+	b.Tune(&disperser.Tunables{PullInterval: reload.Duration(3 * time.Second), BatchSizeMB: 16})
+	assert.Equal(t, 2*time.Second, b.PullIntervalNow())
+	assert.Equal(t, uint(2), b.BatchSizeMB())
+
+	// intervals and timeouts too short to be useful are rejected
+	assert.Error(t, (&disperser.Tunables{PullInterval: reload.Duration(time.Millisecond)}).Validate())
+	assert.Error(t, (&disperser.Tunables{Timeouts: disperser.TimeoutTunables{Signing: reload.Duration(time.Millisecond)}}).Validate())
+	assert.NoError(t, (&disperser.Tunables{PullInterval: reload.Duration(time.Second), Timeouts: disperser.TimeoutTunables{Signing: reload.Duration(time.Second)}}).Validate())
+}
+
+func TestStopWaitsForDrain(t *testing.T) {
+	logger := mock.NewLogger(false)
+	streamer, err := NewEncodingStreamer(StreamerConfig{EncodingQueueLimit: 10}, nil, nil, NewEncodedSizeNotifier(make(chan struct{}, 1), 0), nil, nil, logger)
+	require.NoError(t, err)
+	b := &Batcher{Config: Config{BatchSizeMBLimit: 8}, EncodingStreamer: streamer, logger: logger}
+
+	// stopping waits for the blob being encoded
+	blobKey := disperser.BlobKey{MetadataHash: "1"}
+	streamer.EncodedBlobstore.PutEncodingRequest(blobKey)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*drainPollInterval)
+	defer cancel()
+	assert.ErrorIs(t, b.StopEncoding(ctx), context.DeadlineExceeded)
+	assert.True(t, b.DrainStatus().Draining)
+	assert.ErrorIs(t, b.StopBatching(ctx), context.DeadlineExceeded)
+
+	go func() {
+		time.Sleep(drainPollInterval)
+		streamer.EncodedBlobstore.DeleteEncodingRequest(blobKey)
+	}()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	require.NoError(t, b.StopBatching(ctx))
+	require.NoError(t, b.StopConfirmation(ctx))
+	require.NoError(t, b.StopEncoding(ctx))
+}
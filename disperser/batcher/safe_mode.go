@@ -0,0 +1,110 @@
+package batcher
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// errSafeMode is returned when batches are paused by the safe mode
+var errSafeMode = errors.New("batch paused by the safe mode")
+
+// SafeModeStatus reports whether the batcher is in safe mode: the API keeps accepting and
+// persisting blobs, but they are neither encoded nor dispatched in new batches. Batches
+// already dispatched are still confirmed. It lets the operator wait out a chain incident
+// without rejecting the clients.
+type SafeModeStatus struct {
+	Enabled bool      `json:"enabled"`
+	Since   time.Time `json:"since,omitempty"`
+	Reason  string    `json:"reason,omitempty"`
+}
+
+type safeMode struct {
+	mu     sync.RWMutex
+	status SafeModeStatus
+	// leftAt is when the safe mode was last left
+	leftAt time.Time
+}
+
+// persistedSafeMode is the safe mode as kept in the batch journal, which a restarted
+// batcher resumes from
+type persistedSafeMode struct {
+	Status SafeModeStatus
+	LeftAt time.Time
+}
+
+func (s *safeMode) enabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status.Enabled
+}
+
+func (s *safeMode) get() SafeModeStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+// heldWithin returns whether the safe mode is on or was left less than d before now, the
+// blobs it held not having had d to be dispatched since
+func (s *safeMode) heldWithin(d time.Duration, now time.Time) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status.Enabled || now.Sub(s.leftAt) < d
+}
+
+func (s *safeMode) persisted() persistedSafeMode {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return persistedSafeMode{Status: s.status, LeftAt: s.leftAt}
+}
+
+func (s *safeMode) restore(persisted persistedSafeMode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = persisted.Status
+	s.leftAt = persisted.LeftAt
+}
+
+// set enters or leaves the safe mode, returning whether it changed
+func (s *safeMode) set(enabled bool, reason string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status.Enabled == enabled {
+		return false
+	}
+	s.status = SafeModeStatus{Enabled: enabled}
+	if enabled {
+		s.status.Since = now
+		s.status.Reason = reason
+	} else {
+		s.leftAt = now
+	}
+	return true
+}
+
+// SafeMode returns whether the encoding streamer is in safe mode
+func (e *EncodingStreamer) SafeMode() SafeModeStatus {
+	return e.safeMode.get()
+}
+
+// SetSafeMode enters or leaves the safe mode, see SafeModeStatus. The safe mode is kept in
+// the batch journal if there is one, a restarted batcher staying in it.
+func (e *EncodingStreamer) SetSafeMode(enabled bool, reason string) {
+	if !e.safeMode.set(enabled, reason, time.Now()) {
+		return
+	}
+	if e.batchJournal != nil {
+		if err := e.batchJournal.putSafeMode(e.safeMode.persisted()); err != nil {
+			e.logger.Warn("[encodingstreamer] failed to persist the safe mode", "err", err)
+		}
+	}
+	if e.metrics != nil {
+		e.metrics.UpdateSafeMode(enabled)
+	}
+	if enabled {
+		e.logger.Warn("[encodingstreamer] entered safe mode, blobs are persisted but not encoded nor dispatched", "reason", reason)
+	} else {
+		e.logger.Info("[encodingstreamer] left safe mode, resuming encoding and dispatch")
+	}
+}
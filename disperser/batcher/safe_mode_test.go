@@ -0,0 +1,107 @@
+package batcher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/0glabs/0g-da-client/disperser/leveldb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSafeMode(t *testing.T) {
+	ctx := context.Background()
+	logger := mock.NewLogger(false)
+	store := memorydb.NewBlobStore(core.MaxBlobSize*4, logger)
+	streamer, err := NewEncodingStreamer(StreamerConfig{EncodingQueueLimit: 10, SafeMode: true}, store, nil, NewEncodedSizeNotifier(make(chan struct{}, 1), 0), nil, nil, logger)
+	require.NoError(t, err)
+	server := httptest.NewServer(NewAdminServer(AdminConfig{Token: "token"}, &Batcher{EncodingStreamer: streamer}, logger).Handler())
+	defer server.Close()
+
+	// blobs are persisted but not encoded, nor batched
+	_, err = store.StoreBlob(ctx, &core.Blob{Data: []byte{1}}, 1)
+	require.NoError(t, err)
+	require.NoError(t, streamer.RequestEncoding(ctx, make(chan EncodingResultOrStatus)))
+	assert.Zero(t, streamer.EncodedBlobstore.GetEncodingRequestingSize())
+	b := &Batcher{EncodingStreamer: streamer}
+	_, err = b.HandleSingleBatch(ctx)
+	assert.ErrorIs(t, err, errSafeMode)
+
+	safeMode := func(method string, query string) (int, SafeModeStatus) {
+		req, err := http.NewRequest(method, server.URL+"/safe-mode"+query, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer token")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var status SafeModeStatus
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+		}
+		return resp.StatusCode, status
+	}
+	code, status := safeMode(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, status.Enabled)
+	assert.Equal(t, "started in safe mode", status.Reason)
+	code, _ = safeMode(http.MethodPost, "?enabled=maybe")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = safeMode(http.MethodDelete, "")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+
+	code, status = safeMode(http.MethodPost, "?enabled=false")
+	assert.Equal(t, http.StatusOK, code)
+	assert.False(t, status.Enabled)
+	assert.False(t, streamer.safeMode.enabled())
+	code, status = safeMode(http.MethodPost, "?enabled=true&reason=chain+halted")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "chain halted", status.Reason)
+	assert.False(t, status.Since.IsZero())
+}
+
+func TestSafeModeHoldsBlobs(t *testing.T) {
+	ctx := context.Background()
+	logger := mock.NewLogger(false)
+	store := memorydb.NewBlobStore(core.MaxBlobSize*4, logger)
+	key, err := store.StoreBlob(ctx, &core.Blob{Data: []byte{1}}, 1)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "batches")
+	streamer, err := NewEncodingStreamer(StreamerConfig{EncodingQueueLimit: 10, BatchJournalPath: path}, store, nil, NewEncodedSizeNotifier(make(chan struct{}, 1), 0), nil, nil, logger)
+	require.NoError(t, err)
+	streamer.SetSafeMode(true, "chain halted")
+	require.NoError(t, streamer.batchJournal.db.(*leveldb.LevelDBStore).Close())
+
+	// a restarted batcher stays in safe mode
+	streamer, err = NewEncodingStreamer(StreamerConfig{EncodingQueueLimit: 10, BatchJournalPath: path}, store, nil, NewEncodedSizeNotifier(make(chan struct{}, 1), 0), nil, nil, logger)
+	require.NoError(t, err)
+	assert.True(t, streamer.SafeMode().Enabled)
+	assert.Equal(t, "chain halted", streamer.SafeMode().Reason)
+
+	// the blobs it holds past the TTL are not dead-lettered, nor right after it is left
+	b := &Batcher{Config: Config{DeadLetter: DeadLetterConfig{ProcessingTTL: time.Hour, SweepInterval: time.Minute}}, Queue: store, EncodingStreamer: streamer, logger: logger}
+	status := func() disperser.BlobStatus {
+		metadata, err := store.GetBlobMetadata(ctx, key)
+		require.NoError(t, err)
+		return metadata.BlobStatus
+	}
+	require.NoError(t, b.sweepExpiredBlobs(ctx))
+	assert.Equal(t, disperser.Processing, status())
+	streamer.SetSafeMode(false, "")
+	require.NoError(t, b.sweepExpiredBlobs(ctx))
+	assert.Equal(t, disperser.Processing, status())
+
+	// they are once they had the TTL to be dispatched
+	streamer.safeMode.restore(persistedSafeMode{LeftAt: time.Now().Add(-2 * time.Hour)})
+	require.NoError(t, b.sweepExpiredBlobs(ctx))
+	assert.Equal(t, disperser.DeadLettered, status())
+}
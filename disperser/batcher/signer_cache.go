@@ -0,0 +1,121 @@
+package batcher
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/0glabs/0g-da-client/core"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"golang.org/x/crypto/sha3"
+)
+
+// maxAggPubKeysPerQuorum bounds the aggregated public keys kept per quorum. The signing
+// set rarely changes within an epoch, so a handful of entries covers nearly all batches.
+const maxAggPubKeysPerQuorum = 64
+
+type quorumKey struct {
+	epoch    uint64
+	quorumId uint64
+}
+
+type quorumSigners struct {
+	signers map[eth_common.Address]*SignerState
+
+	mu         sync.Mutex
+	aggPubKeys map[[32]byte]*core.G2Point
+}
+
+// signerCache keeps the signers of each quorum and the aggregated public keys of the
+// signing sets seen so far, so that consecutive batches of the same epoch neither query
+// the signers from the contract again nor re-add the same public keys. Entries of older
+// epochs are dropped as soon as a batch of a newer epoch is seen, since the quorums of an
+// epoch are fixed once it started.
+type signerCache struct {
+	mu      sync.Mutex
+	epoch   uint64
+	quorums map[quorumKey]*quorumSigners
+}
+
+func newSignerCache() *signerCache {
+	return &signerCache{
+		quorums: make(map[quorumKey]*quorumSigners),
+	}
+}
+
+func (c *signerCache) get(epoch, quorumId *big.Int) (*quorumSigners, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	quorum, ok := c.quorums[quorumKey{epoch.Uint64(), quorumId.Uint64()}]
+	return quorum, ok
+}
+
+// put caches the signers of a quorum. Signers of an epoch older than the latest one are
+// returned without being cached.
+func (c *signerCache) put(epoch, quorumId *big.Int, signers map[eth_common.Address]*SignerState) *quorumSigners {
+	quorum := &quorumSigners{
+		signers:    signers,
+		aggPubKeys: make(map[[32]byte]*core.G2Point),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := epoch.Uint64()
+	if e < c.epoch {
+		return quorum
+	}
+	if e > c.epoch {
+		c.epoch = e
+		for key := range c.quorums {
+			if key.epoch < e {
+				delete(c.quorums, key)
+			}
+		}
+	}
+	c.quorums[quorumKey{e, quorumId.Uint64()}] = quorum
+	return quorum
+}
+
+// aggregatePubKey returns the sum of the G2 public keys of the given signers. The returned
+// point is a copy and may be modified by the caller.
+func (q *quorumSigners) aggregatePubKey(addresses []eth_common.Address) (*core.G2Point, bool) {
+	sorted := make([]eth_common.Address, len(addresses))
+	copy(sorted, addresses)
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i][:], sorted[j][:]) < 0 })
+
+	var key [32]byte
+	hasher := sha3.NewLegacyKeccak256()
+	for _, address := range sorted {
+		hasher.Write(address[:])
+	}
+	copy(key[:], hasher.Sum(nil))
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if aggPubKey, ok := q.aggPubKeys[key]; ok {
+		return aggPubKey.Clone(), true
+	}
+
+	var aggPubKey *core.G2Point
+	for _, address := range sorted {
+		pubKey := q.signers[address].PkG2
+		if aggPubKey == nil {
+			aggPubKey = pubKey.Clone()
+		} else {
+			aggPubKey.Add(pubKey)
+		}
+	}
+	if aggPubKey == nil {
+		return nil, false
+	}
+
+	if len(q.aggPubKeys) >= maxAggPubKeysPerQuorum {
+		q.aggPubKeys = make(map[[32]byte]*core.G2Point)
+	}
+	q.aggPubKeys[key] = aggPubKey
+	return aggPubKey.Clone(), false
+}
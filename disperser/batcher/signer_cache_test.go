@@ -0,0 +1,53 @@
+package batcher
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0glabs/0g-da-client/core"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignerCache(t *testing.T) {
+	signers := make(map[eth_common.Address]*SignerState)
+	addresses := make([]eth_common.Address, 3)
+	for i := range addresses {
+		keyPair, err := core.GenRandomBlsKeys()
+		assert.NoError(t, err)
+		addresses[i] = eth_common.BigToAddress(big.NewInt(int64(i + 1)))
+		signers[addresses[i]] = &SignerState{SignerInfo: &SignerInfo{Signer: addresses[i], PkG2: keyPair.GetPubKeyG2()}}
+	}
+
+	cache := newSignerCache()
+	_, ok := cache.get(big.NewInt(1), big.NewInt(0))
+	assert.False(t, ok)
+
+	quorum := cache.put(big.NewInt(1), big.NewInt(0), signers)
+	cached, ok := cache.get(big.NewInt(1), big.NewInt(0))
+	assert.True(t, ok)
+	assert.Equal(t, quorum, cached)
+
+	expected := signers[addresses[0]].PkG2.Clone()
+	expected.Add(signers[addresses[2]].PkG2)
+
+	aggPubKey, hit := quorum.aggregatePubKey([]eth_common.Address{addresses[2], addresses[0]})
+	assert.False(t, hit)
+	assert.True(t, expected.Equal(aggPubKey.G2Affine))
+
+	// the order of the signers doesn't matter and callers can't modify the cached key
+	aggPubKey.Add(signers[addresses[1]].PkG2)
+	aggPubKey, hit = quorum.aggregatePubKey([]eth_common.Address{addresses[0], addresses[2]})
+	assert.True(t, hit)
+	assert.True(t, expected.Equal(aggPubKey.G2Affine))
+
+	// a newer epoch invalidates the older ones, which are no longer cached
+	cache.put(big.NewInt(2), big.NewInt(0), signers)
+	_, ok = cache.get(big.NewInt(1), big.NewInt(0))
+	assert.False(t, ok)
+	cache.put(big.NewInt(1), big.NewInt(0), signers)
+	_, ok = cache.get(big.NewInt(1), big.NewInt(0))
+	assert.False(t, ok)
+	_, ok = cache.get(big.NewInt(2), big.NewInt(0))
+	assert.True(t, ok)
+}
@@ -11,14 +11,15 @@ import (
 	"time"
 
 	"github.com/0glabs/0g-da-client/common"
-	"github.com/0glabs/0g-da-client/common/geth"
+	"github.com/0glabs/0g-da-client/common/tracing"
 	"github.com/0glabs/0g-da-client/core"
 	"github.com/0glabs/0g-da-client/disperser"
 	pb "github.com/0glabs/0g-da-client/disperser/api/grpc/signer"
 	"github.com/0glabs/0g-da-client/disperser/contract"
+	"github.com/0glabs/0g-da-client/disperser/contract/da_signers"
 	"github.com/consensys/gnark-crypto/ecc/bn254"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
-	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	eth_common "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/hashicorp/go-multierror"
@@ -47,14 +48,81 @@ func NewSignatureSizeNotifier(notify chan struct{}, threshold uint64) *Signature
 }
 
 type SignerConfig struct {
-	// the timeout for each signing request
-	SigningRequestTimeout time.Duration
+	// Timeouts bounds the signing requests sent to the operators
+	Timeouts TimeoutConfig
 
 	MaxNumRetriesPerBlob uint
 
 	MaxNumRetriesSign uint
 
 	SigningInterval time.Duration
+
+	// PartialConfirmation submits the blobs of a batch that reached the signing threshold once
+	// the signing retries are used up, instead of failing every blob of the batch
+	PartialConfirmation bool
+	// QuorumFallback confirms the blobs that fell short of the optional thresholds they
+	// requested with the default thresholds once the signing retries are used up
+	QuorumFallback bool
+
+	// InFlight keeps batches whose upload has no receipt yet out of new batches
+	InFlight InFlightConfig
+
+	// Fairness reports operators assigned bytes out of proportion with their stake
+	Fairness FairnessConfig
+
+	// DispatchDeadline bounds the wait for the signatures of the operators of each quorum
+	DispatchDeadline DispatchDeadlineConfig
+
+	// Bandwidth leaves the operators too slow for the dispatch deadline out of dispersals
+	Bandwidth BandwidthConfig
+}
+
+// Security parameters reported in the certificates of the blobs confirmed without their own.
+// A blob is confirmed once two thirds of its slices are signed, tolerating an adversary
+// controlling a third of them.
+const (
+	defaultQuorumThreshold    uint8 = core.MinQuorumThreshold
+	defaultAdversaryThreshold uint8 = 33
+)
+
+// requestedSecurityParam returns the security parameters the blob requested for the quorum,
+// nil if it requested none
+func requestedSecurityParam(metadata *disperser.BlobMetadata, quorumID core.QuorumID) *core.SecurityParam {
+	if metadata == nil || metadata.RequestMetadata == nil {
+		return nil
+	}
+	for _, param := range metadata.RequestMetadata.SecurityParams {
+		if param.QuorumID == quorumID {
+			return param
+		}
+	}
+	return nil
+}
+
+// minSignedSlices is the number of the total slices of the quorum that must be signed for
+// the blob to reach its quorum threshold. A requested threshold may raise the two thirds of
+// the protocol, never lower them.
+func minSignedSlices(metadata *disperser.BlobMetadata, quorumID core.QuorumID, total int) int {
+	required := int(math.Ceil(float64(total) * 2 / 3))
+	if param := requestedSecurityParam(metadata, quorumID); param != nil {
+		required = max(required, int(math.Ceil(float64(total)*float64(param.QuorumThreshold)/100)))
+	}
+	return required
+}
+
+// getBlobQuorumPassStatus returns whether a blob signed on signed of the total slices of the
+// quorum passes, with the quorum threshold it requested or two thirds of the slices. A blob
+// has no slice to sign in a quorum without any, and never passes.
+func getBlobQuorumPassStatus(metadata *disperser.BlobMetadata, quorumID core.QuorumID, signed int, total int) bool {
+	return total > 0 && signed >= minSignedSlices(metadata, quorumID, total)
+}
+
+// signedPercent is the percentage of the total slices signed, 0 if there are none
+func signedPercent(signed int, total int) uint8 {
+	if total <= 0 {
+		return 0
+	}
+	return uint8(min(signed, total) * 100 / total)
 }
 
 type SignInfo struct {
@@ -67,10 +135,25 @@ type SignInfo struct {
 	epoch    *big.Int
 	quorumId *big.Int
 	signers  map[eth_common.Address]*SignerState
+	// referenceBlock is the block the data upload, and so the epoch, of the batch was set at
+	referenceBlock uint32
+	// waitingSince is when the receipt of the upload was first waited for
+	waitingSince time.Time
 
 	newBlobs []int
 }
 
+// minSignedSlices is the number of the total slices that must be signed for every new blob
+// of the batch to pass
+func (signInfo *SignInfo) minSignedSlices(total int) int {
+	quorumID := core.QuorumID(signInfo.quorumId.Uint64())
+	required := 0
+	for _, batchIdx := range signInfo.newBlobs {
+		required = max(required, minSignedSlices(signInfo.batch.BlobMetadata[batchIdx], quorumID, total))
+	}
+	return required
+}
+
 type SignRequestResult struct {
 	signatures []*core.Signature
 	signer     eth_common.Address
@@ -81,6 +164,15 @@ type SignRequestResultOrStatus struct {
 	Err error
 }
 
+// SignerRegistry reads the signers of the quorums of each epoch, the DA signers contract
+// unless the operators are simulated
+type SignerRegistry interface {
+	GetQuorum(opts *bind.CallOpts, epoch *big.Int, quorumId *big.Int) ([]eth_common.Address, error)
+	GetSigner(opts *bind.CallOpts, accounts []eth_common.Address) ([]da_signers.IDASignersSignerDetail, error)
+}
+
+var _ SignerRegistry = (*contract.DAContract)(nil)
+
 type SignerInfo struct {
 	Signer eth_common.Address
 	Socket string
@@ -90,6 +182,8 @@ type SignerInfo struct {
 
 type SignerState struct {
 	*SignerInfo
+	// index is the position of the signer among the distinct signers of the quorum
+	index        int
 	sliceIndexes []int
 }
 
@@ -101,6 +195,20 @@ type BatchCommitRootSubmission struct {
 	proofs      []*merkletree.Proof
 	epoch       *big.Int
 	quorumId    *big.Int
+
+	// percentSigned is the percentage of slices signed for each newly signed blob, by index in the batch
+	percentSigned map[int]uint8
+	// excluded are the indexes of the blobs left out of a partially confirmed batch
+	excluded map[int]struct{}
+	// signerBitmap are the signers that returned valid signatures
+	signerBitmap core.SignerBitmap
+	numSigners   int
+	// attesters are the signers of each newly signed blob, by index in the batch
+	attesters map[int][]*SignerState
+	// signatures are the aggregate signatures of each newly signed blob, by index in the batch
+	signatures map[int]*core.CommitRootSubmission
+	// referenceBlock is the block the epoch of the batch was set at
+	referenceBlock uint32
 }
 
 type SliceSigner struct {
@@ -114,6 +222,10 @@ type SliceSigner struct {
 
 	EncodingStreamer *EncodingStreamer
 	Finalizer        Finalizer
+	// Tracer continues the traces of the blobs dispersed with one, set by the batcher
+	Tracer *tracing.Tracer
+	// events records the lifecycle of the blobs, shared with the batcher
+	events *disperser.BlobEventLog
 
 	pendingBatches       []*SignInfo
 	pendingBatchesToSign []*SignInfo
@@ -122,10 +234,11 @@ type SliceSigner struct {
 	signedBatches        map[uint64][]uint64
 	signedBlobSize       uint64
 
-	daContract   *contract.DAContract
+	registry     SignerRegistry
 	signerClient disperser.SignerClient
-
-	retryOption contract.RetryOption
+	confirmer    Confirmer
+	// spends records the fees of the uploads against the spend limits, set by the batcher
+	spends *BatchConfirmer
 
 	blobStore disperser.BlobStore
 	metrics   *Metrics
@@ -135,34 +248,59 @@ type SliceSigner struct {
 	SignedBatchSize uint
 
 	blobKeyCache *disperser.BlobKeyCache
+	signerCache  *signerCache
+	fairness     *fairnessAnalyzer
+	bandwidth    *bandwidthMonitor
+	// exclusions are the operators the watchdog left out of the dispersals, nil if none are
+	exclusions *operatorExclusions
+
+	quorumDeadlines map[uint64]time.Duration
+	// storageNodes tracks the signing outcomes of the storage nodes for the health checks
+	storageNodes *storageNodeHealth
+	// finalityPoll is how often the finality of the uploads is checked again, 5 seconds if
+	// zero
+	finalityPoll time.Duration
 }
 
 func NewEncodedSliceSigner(
-	ethConfig geth.EthClientConfig,
+	confirmer Confirmer,
 	config SignerConfig,
 	workerPool common.WorkerPool,
 	signatureSizeNotifier *SignatureSizeNotifier,
 	signerClient disperser.SignerClient,
 	blobStore disperser.BlobStore,
-	daContract *contract.DAContract,
+	registry SignerRegistry,
 	metrics *Metrics,
 	logger common.Logger,
 	blobKeyCache *disperser.BlobKeyCache,
 ) (*SliceSigner, error) {
+	var fairness *fairnessAnalyzer
+	if config.Fairness.Enabled() {
+		fairness = newFairnessAnalyzer(config.Fairness, metrics)
+	}
+	quorumDeadlines, err := config.DispatchDeadline.deadlines()
+	if err != nil {
+		return nil, err
+	}
+	var bandwidth *bandwidthMonitor
+	if config.Bandwidth.Enabled() {
+		prober, ok := signerClient.(disperser.BandwidthProber)
+		if !ok {
+			return nil, errors.New("the signer client can't probe the bandwidth of the operators")
+		}
+		bandwidth = newBandwidthMonitor(config.Bandwidth, prober, metrics, logger)
+	}
 	return &SliceSigner{
 		SignerConfig:          config,
 		Pool:                  workerPool,
 		SignatureSizeNotifier: signatureSizeNotifier,
 		SignerChan:            make(chan *SignInfo),
-		daContract:            daContract,
+		registry:              registry,
 		signerClient:          signerClient,
-		retryOption: contract.RetryOption{
-			Rounds:   ethConfig.ReceiptPollingRounds,
-			Interval: ethConfig.ReceiptPollingInterval,
-		},
-		blobStore: blobStore,
-		metrics:   metrics,
-		logger:    logger,
+		confirmer:             confirmer,
+		blobStore:             blobStore,
+		metrics:               metrics,
+		logger:                logger,
 
 		pendingBatches:       make([]*SignInfo, 0),
 		pendingBatchesToSign: make([]*SignInfo, 0),
@@ -172,6 +310,11 @@ func NewEncodedSliceSigner(
 		signedBlobSize:       0,
 		SignedBatchSize:      0,
 		blobKeyCache:         blobKeyCache,
+		signerCache:          newSignerCache(),
+		fairness:             fairness,
+		bandwidth:            bandwidth,
+		quorumDeadlines:      quorumDeadlines,
+		storageNodes:         &storageNodeHealth{},
 	}, nil
 }
 
@@ -230,10 +373,25 @@ func (s *SliceSigner) Start(ctx context.Context) error {
 		}
 	}()
 
+	if s.fairness != nil {
+		go s.fairness.run(ctx, s.logger)
+	}
+	if s.bandwidth != nil {
+		go s.bandwidth.run(ctx)
+	}
+
 	return nil
 
 }
 
+// PendingBatches returns the number of batches waiting to be signed
+func (s *SliceSigner) PendingBatches() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.pendingBatches) + len(s.pendingBatchesToSign)
+}
+
 func (s *SliceSigner) putPendingBatches(info *SignInfo) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -256,9 +414,18 @@ func (s *SliceSigner) getPendingBatch() *SignInfo {
 }
 
 func (s *SliceSigner) waitBatchTxFinalized(ctx context.Context, batchInfo *SignInfo) error {
-	dataUploadEvents, blockNumber, err := s.waitForReceipt(batchInfo.batch.TxHash)
+	if batchInfo.waitingSince.IsZero() {
+		batchInfo.waitingSince = time.Now()
+	}
+	dataUploadEvents, blockNumber, err := s.waitForReceipt(ctx, batchInfo.batch.venue, batchInfo.batch.TxHash)
 	s.logger.Debug("[signer] batch tx finalized", "event size", len(dataUploadEvents), "block number", blockNumber)
 
+	if err != nil && s.InFlight.inFlight(err, batchInfo.waitingSince, time.Now()) {
+		// the upload may still land, its blobs keep their batching status until it resolves
+		s.logger.Warn("[signer] batch tx has no receipt yet, waiting again", "ts", batchInfo.ts, "tx hash", batchInfo.batch.TxHash, "err", err)
+		s.putPendingBatches(batchInfo)
+		return nil
+	}
 	if err != nil || len(dataUploadEvents) == 0 {
 		// batch is not confirmed
 		_ = s.handleFailure(ctx, batchInfo.batch.BlobMetadata, FailBatchReceipt)
@@ -274,9 +441,14 @@ func (s *SliceSigner) waitBatchTxFinalized(ctx context.Context, batchInfo *SignI
 		}
 	}
 
+	if err := consumeTxFee(ctx, s.confirmerOf(batchInfo.batch.venue), batchInfo.batch.TxHash, batchInfo.batch.budget); err != nil {
+		s.logger.Warn("[signer] failed to get the fee of the batch tx", "tx hash", batchInfo.batch.TxHash, "err", err)
+	}
+	s.spends.recordSpend(ctx, s.confirmerOf(batchInfo.batch.venue), batchInfo.batch.TxHash, 0)
+
 	epoch := dataUploadEvents[0].Epoch
 	quorumId := dataUploadEvents[0].QuorumId
-	signers, err := s.getSigners(epoch, quorumId)
+	signers, err := s.getQuorumSigners(batchInfo.batch.venue, epoch, quorumId)
 	if err != nil {
 		// if signInfo.reties < s.MaxNumRetriesSign {
 		// 	s.mu.Lock()
@@ -296,6 +468,7 @@ func (s *SliceSigner) waitBatchTxFinalized(ctx context.Context, batchInfo *SignI
 	batchInfo.epoch = epoch
 	batchInfo.quorumId = quorumId
 	batchInfo.signers = signers
+	batchInfo.referenceBlock = blockNumber
 
 	batchInfo.newBlobs = make([]int, 0)
 	for idx, blob := range batchInfo.batch.EncodedBlobs {
@@ -312,55 +485,99 @@ func (s *SliceSigner) waitBatchTxFinalized(ctx context.Context, batchInfo *SignI
 	return nil
 }
 
-func (s *SliceSigner) waitForReceipt(txHash eth_common.Hash) ([]*contract.DataUploadEvent, uint32, error) {
+// confirmerOf returns the Confirmer of the venue a batch was dispersed to, which is nil for
+// the primary one
+func (s *SliceSigner) confirmerOf(venue *Venue) Confirmer {
+	if venue != nil {
+		return venue.Confirmer
+	}
+	return s.confirmer
+}
+
+// registryOf returns the registry of the signers of the venue a batch was dispersed to
+func (s *SliceSigner) registryOf(venue *Venue) SignerRegistry {
+	if venue != nil && venue.Signers != nil {
+		return venue.Signers
+	}
+	return s.registry
+}
+
+// signerCacheOf returns the cache of the signers of the venue a batch was dispersed to
+func (s *SliceSigner) signerCacheOf(venue *Venue) *signerCache {
+	if venue != nil && venue.signerCache != nil {
+		return venue.signerCache
+	}
+	return s.signerCache
+}
+
+// uploadFinal returns whether the block of an upload to venue is final, on the chain of the
+// venue
+func (s *SliceSigner) uploadFinal(ctx context.Context, venue *Venue, blockNumber uint64) (bool, error) {
+	if venue != nil {
+		return venue.final(ctx, uint32(blockNumber))
+	}
+	return blockNumber <= s.Finalizer.LatestFinalizedBlock(), nil
+}
+
+func (s *SliceSigner) finalityPollInterval() time.Duration {
+	if s.finalityPoll > 0 {
+		return s.finalityPoll
+	}
+	return 5 * time.Second
+}
+
+// waitForReceipt waits for the upload of a batch to the venue it was dispersed to to be
+// final
+func (s *SliceSigner) waitForReceipt(ctx context.Context, venue *Venue, txHash eth_common.Hash) ([]*contract.DataUploadEvent, uint32, error) {
 	if txHash.Cmp(eth_common.Hash{}) == 0 {
 		return nil, 0, errors.New("empty transaction hash")
 	}
 	s.logger.Info("[signer] waiting batch tx be confirmed", "tx hash", txHash)
 	// data is not duplicate, there is a new transaction
-	var blockNumber uint64
-	submitEventHash := eth_common.HexToHash(contract.DataUploadEventHash)
-	var submissions []*contract.DataUploadEvent
-
 	for {
-		receipt, err := s.daContract.WaitForReceipt(txHash, true, s.retryOption)
+		// the receipt wait timeout bounds the wait for the receipt, not for its finality
+		var uploads []*contract.DataUploadEvent
+		var blockNumber uint64
+		err := s.Timeouts.Do(ctx, CallReceiptWait, func(ctx context.Context) error {
+			var err error
+			uploads, blockNumber, err = s.confirmerOf(venue).WaitForUpload(ctx, txHash)
+			return err
+		})
 		if err != nil {
 			return nil, 0, err
 		}
 
-		blockNumber = receipt.BlockNumber
-		s.logger.Debug("[signer] waiting batch tx to be confirmed", "receipt block", blockNumber, "finalized block", s.Finalizer.LatestFinalizedBlock())
+		final, err := s.uploadFinal(ctx, venue, blockNumber)
+		s.logger.Debug("[signer] waiting batch tx to be confirmed", "receipt block", blockNumber, "final", final, "fallback", venue != nil, "err", err)
 
-		if blockNumber > s.Finalizer.LatestFinalizedBlock() {
-			time.Sleep(time.Second * 5)
+		if !final {
+			time.Sleep(s.finalityPollInterval())
 			continue
 		}
+		return uploads, uint32(blockNumber), nil
+	}
+}
 
-		// parse submission from event log
-		for _, v := range receipt.Logs {
-			if v.Topics[0] == submitEventHash {
-				log := contract.ConvertToGethLog(v)
-
-				submission, err := s.daContract.ParseDataUpload(*log)
-				if err != nil {
-					return nil, 0, err
-				}
-
-				submissions = append(submissions, &contract.DataUploadEvent{
-					DataRoot: submission.DataRoot,
-					Epoch:    submission.Epoch,
-					QuorumId: submission.QuorumId,
-				})
-			}
-		}
-		break
+// getQuorumSigners returns the signers of a quorum, from the cache if a previous batch of
+// the same epoch already fetched them
+func (s *SliceSigner) getQuorumSigners(venue *Venue, epoch *big.Int, quorumId *big.Int) (map[eth_common.Address]*SignerState, error) {
+	cache := s.signerCacheOf(venue)
+	if quorum, ok := cache.get(epoch, quorumId); ok {
+		s.metrics.UpdateSignerCache("signers", true)
+		return quorum.signers, nil
 	}
+	s.metrics.UpdateSignerCache("signers", false)
 
-	return submissions, uint32(blockNumber), nil
+	signers, err := s.getSigners(s.registryOf(venue), epoch, quorumId)
+	if err != nil {
+		return nil, err
+	}
+	cache.put(epoch, quorumId, signers)
+	return signers, nil
 }
 
-func (s *SliceSigner) getSigners(epoch *big.Int, quorumId *big.Int) (map[eth_common.Address]*SignerState, error) {
-	signerAddresses, err := s.daContract.GetQuorum(nil, epoch, quorumId)
+func (s *SliceSigner) getSigners(registry SignerRegistry, epoch *big.Int, quorumId *big.Int) (map[eth_common.Address]*SignerState, error) {
+	signerAddresses, err := registry.GetQuorum(nil, epoch, quorumId)
 	s.logger.Debug("[signer] get signers for quorum", "size", len(signerAddresses))
 
 	if err != nil {
@@ -377,6 +594,7 @@ func (s *SliceSigner) getSigners(epoch *big.Int, quorumId *big.Int) (map[eth_com
 		if state, ok := hm[address]; !ok {
 			hm[address] = &SignerState{
 				SignerInfo:   nil,
+				index:        len(uniqueAddress),
 				sliceIndexes: []int{sliceIdx},
 			}
 			uniqueAddress = append(uniqueAddress, address)
@@ -385,7 +603,7 @@ func (s *SliceSigner) getSigners(epoch *big.Int, quorumId *big.Int) (map[eth_com
 		}
 	}
 
-	signers, err := s.daContract.GetSigner(nil, uniqueAddress)
+	signers, err := registry.GetSigner(nil, uniqueAddress)
 	if err != nil {
 		return nil, err
 	}
@@ -413,7 +631,9 @@ func (s *SliceSigner) getSigners(epoch *big.Int, quorumId *big.Int) (map[eth_com
 func (s *SliceSigner) handleFailure(ctx context.Context, blobMetadatas []*disperser.BlobMetadata, reason FailReason) error {
 	var result *multierror.Error
 	for _, metadata := range blobMetadatas {
-		err := s.blobStore.HandleBlobFailure(ctx, metadata, s.MaxNumRetriesPerBlob)
+		err := s.Timeouts.Do(ctx, CallStoreWrite, func(ctx context.Context) error {
+			return s.EncodingStreamer.handleBatchFailure(ctx, s.blobStore, metadata, reason, s.MaxNumRetriesPerBlob)
+		})
 		if err != nil {
 			s.logger.Error("[signer] error handling blob failure", "err", err)
 			// Append the error
@@ -421,6 +641,7 @@ func (s *SliceSigner) handleFailure(ctx context.Context, blobMetadatas []*disper
 		}
 		s.metrics.UpdateCompletedBlob(int(metadata.RequestMetadata.BlobSize), disperser.Failed)
 	}
+	s.events.RecordAll(blobMetadatas, disperser.BlobFailed, string(reason))
 	s.metrics.UpdateBatchError(reason, len(blobMetadatas))
 
 	// Return the error(s)
@@ -441,18 +662,65 @@ func (s *SliceSigner) getPendingBatchToSign() *SignInfo {
 }
 
 func (s *SliceSigner) doSigning(ctx context.Context, signInfo *SignInfo) error {
+	if signInfo.batch.budget.latencyExhausted() {
+		s.metrics.IncrementBudgetDecision(BudgetSigningAbandoned)
+		_ = s.handleFailure(ctx, signInfo.batch.BlobMetadata, FailBudgetExhausted)
+		s.removeFailedBlobs(ctx, signInfo.batch.BlobMetadata)
+		s.EncodingStreamer.RemoveBatchingStatus(signInfo.ts)
+		return fmt.Errorf("batch %d ran out of its latency budget before signing", signInfo.ts)
+	}
 	requestData := s.assignEncodedBlobs(signInfo)
 	if len(requestData) == 0 {
 		s.logger.Warn("[signer] data for sign is empty")
 		// return nil
 	}
+	if s.fairness != nil {
+		s.fairness.observe(signInfo.signers, requestData)
+	}
+	quorumID := signInfo.quorumId.Uint64()
+	var excluded map[eth_common.Address]struct{}
+	if s.bandwidth != nil {
+		s.bandwidth.track(signInfo.signers)
+		// retries are dispatched to every operator
+		if signInfo.reties == 0 {
+			excluded = s.bandwidth.exclude(signInfo.signers, requestData, s.dispatchDeadline(quorumID), signInfo.minSignedSlices)
+		}
+		if len(excluded) > 0 {
+			s.logger.Info("[signer] operators excluded from dispersal by their bandwidth", "ts", signInfo.ts, "quorum", quorumID, "excluded", len(excluded), "signers", len(signInfo.signers))
+			s.metrics.IncrementBandwidthExclusions(quorumID, len(excluded))
+		}
+	}
+	var watchdogExcluded map[eth_common.Address]struct{}
+	if s.exclusions != nil && signInfo.reties == 0 {
+		watchdogExcluded = s.exclusions.exclude(signInfo.signers, excluded, signInfo.minSignedSlices)
+		if len(watchdogExcluded) > 0 {
+			s.logger.Info("[signer] operators excluded from dispersal by the watchdog", "ts", signInfo.ts, "quorum", quorumID, "excluded", len(watchdogExcluded), "signers", len(signInfo.signers))
+		}
+	}
 	s.logger.Debug("[signer] signing data", "total length", len(signInfo.batch.EncodedBlobs), "sign length", len(signInfo.newBlobs))
+	spans := traceBlobs(s.Tracer, signInfo.batch.BlobMetadata, "sign", time.Now(), tracing.Int("batch.id", int64(signInfo.ts)), tracing.Int("quorum.id", int64(quorumID)), tracing.Int("retry", int64(signInfo.reties)), tracing.Int("signers", int64(len(requestData)-len(excluded)-len(watchdogExcluded))))
+	dispatchCtx, cancelDispatch := s.withDispatchDeadline(ctx, quorumID, signInfo.batch.budget)
+	defer cancelDispatch()
 	update := make(chan SignRequestResultOrStatus, len(requestData))
 	for signerAddress, content := range requestData {
 		address := eth_common.BytesToAddress(signerAddress[:])
+		if _, ok := excluded[address]; ok {
+			update <- SignRequestResultOrStatus{
+				Err:               errBandwidthExcluded,
+				SignRequestResult: SignRequestResult{signer: address},
+			}
+			continue
+		}
+		if _, ok := watchdogExcluded[address]; ok {
+			update <- SignRequestResultOrStatus{
+				Err:               errWatchdogExcluded,
+				SignRequestResult: SignRequestResult{signer: address},
+			}
+			continue
+		}
 		requests := make([]*pb.SignRequest, len(content))
 		copy(requests, content)
-		encodingCtx, cancel := context.WithTimeout(ctx, s.SigningRequestTimeout)
+		signingCtx, cancel := s.Timeouts.WithTimeout(dispatchCtx, CallOperatorRPC)
 		s.Pool.Submit(func() {
 			defer cancel()
 
@@ -465,8 +733,9 @@ func (s *SliceSigner) doSigning(ctx context.Context, signInfo *SignInfo) error {
 			// 	}
 			// }
 
-			reply, err := s.signerClient.BatchSign(encodingCtx, signInfo.signers[address].Socket, requests, s.logger)
+			reply, err := s.signerClient.BatchSign(signingCtx, signInfo.signers[address].Socket, requests, s.logger)
 			if err != nil {
+				err = s.Timeouts.Wrap(dispatchCtx, signingCtx, CallOperatorRPC, err)
 				update <- SignRequestResultOrStatus{
 					Err:               err,
 					SignRequestResult: SignRequestResult{signer: address},
@@ -486,7 +755,8 @@ func (s *SliceSigner) doSigning(ctx context.Context, signInfo *SignInfo) error {
 		s.logger.Trace("[signer] requested sign for batch", "ts", signInfo.ts, "signer", address)
 	}
 
-	err := s.aggregateSignature(ctx, signInfo, update)
+	err := s.aggregateSignature(ctx, dispatchCtx, signInfo, update)
+	endSpans(spans, err)
 	if err != nil {
 		return err
 	}
@@ -536,7 +806,51 @@ func (s *SliceSigner) assignEncodedBlobs(signInfo *SignInfo) map[eth_common.Addr
 	return requestData
 }
 
-func (s *SliceSigner) aggregateSignature(ctx context.Context, signInfo *SignInfo, update chan SignRequestResultOrStatus) error {
+// fallBack passes the blobs signed on enough slices for the default thresholds that fell
+// short of the optional thresholds they requested, returning whether every blob passed
+func (s *SliceSigner) fallBack(signInfo *SignInfo, aggSigs []*core.Signature, signedSliceCount, totalSliceCount []int, passed []bool) bool {
+	quorumID := core.QuorumID(signInfo.quorumId.Uint64())
+	valid := true
+	fallbacks := 0
+	for blobIdx, ok := range passed {
+		if !ok && aggSigs[blobIdx] != nil {
+			param := requestedSecurityParam(signInfo.batch.BlobMetadata[signInfo.newBlobs[blobIdx]], quorumID)
+			if param != nil && param.Optional && getBlobQuorumPassStatus(nil, quorumID, signedSliceCount[blobIdx], totalSliceCount[blobIdx]) {
+				passed[blobIdx] = true
+				fallbacks++
+			}
+		}
+		valid = valid && passed[blobIdx]
+	}
+	if fallbacks > 0 {
+		s.logger.Warn("[signer] blobs short of their optional thresholds fall back to the defaults", "ts", signInfo.ts, "quorum", quorumID, "blobs", fallbacks)
+		s.metrics.IncrementQuorumFallback(fallbacks)
+	}
+	return valid
+}
+
+// partialExclusions returns the blobs, by index in the batch, left out of the partial
+// confirmation of a batch, and whether it may be partially confirmed at all: a batch none of
+// whose new blobs passed has nothing to submit, and fails as a whole.
+func partialExclusions(signInfo *SignInfo, passed []bool) (map[int]struct{}, []*disperser.BlobMetadata, bool) {
+	excluded := make(map[int]struct{})
+	excludedMetadata := make([]*disperser.BlobMetadata, 0)
+	for blobIdx, ok := range passed {
+		if !ok {
+			batchIdx := signInfo.newBlobs[blobIdx]
+			excluded[batchIdx] = struct{}{}
+			excludedMetadata = append(excludedMetadata, signInfo.batch.BlobMetadata[batchIdx])
+		}
+	}
+	if len(excluded) == 0 || len(excluded) == len(passed) {
+		return nil, nil, false
+	}
+	return excluded, excludedMetadata, true
+}
+
+// aggregateSignature aggregates the signatures received through update, the requests
+// having been sent with dispatchCtx
+func (s *SliceSigner) aggregateSignature(ctx context.Context, dispatchCtx context.Context, signInfo *SignInfo, update chan SignRequestResultOrStatus) error {
 	signerCounter := len(signInfo.signers)
 
 	blobSize := len(signInfo.newBlobs)
@@ -550,7 +864,7 @@ func (s *SliceSigner) aggregateSignature(ctx context.Context, signInfo *SignInfo
 
 		erasureCommitments[idx] = blob.ErasureCommitment
 		storageRoots[idx] = dataRoot
-		msg, err := getHash(dataRoot, signInfo.epoch, signInfo.quorumId, blob.ErasureCommitment)
+		msg, err := core.SignatureMessage(dataRoot, signInfo.epoch, signInfo.quorumId, blob.ErasureCommitment)
 		if err != nil {
 			s.logger.Error("[signer] failed to get hash for batch", "batch", signInfo.ts, "error", err)
 			if signInfo.reties < s.MaxNumRetriesSign {
@@ -571,10 +885,16 @@ func (s *SliceSigner) aggregateSignature(ctx context.Context, signInfo *SignInfo
 
 	aggSigs := make([]*core.Signature, blobSize)
 	aggPubKeys := make([]*core.G2Point, blobSize)
+	blobSigners := make([][]eth_common.Address, blobSize)
 
 	signedSliceCount := make([]int, blobSize)
 	totalSliceCount := make([]int, blobSize)
 	quorumBitmap := make([][]byte, blobSize)
+	signerBitmap := core.NewSignerBitmap(signerCounter)
+	// lateSigners failed to reply before the dispatch deadline of the quorum
+	lateSigners := 0
+	// failedSigners failed to sign, the operators left out of the dispersal aside
+	failedSigners := 0
 
 	if blobSize > 0 {
 		for i := 0; i < signerCounter; i++ {
@@ -585,6 +905,12 @@ func (s *SliceSigner) aggregateSignature(ctx context.Context, signInfo *SignInfo
 
 			if recv.Err != nil {
 				s.logger.Warn("[signer] error returned from messageChan", "socket", signer.Socket, "err", recv.Err)
+				if errors.Is(dispatchCtx.Err(), context.DeadlineExceeded) {
+					lateSigners++
+				}
+				if !errors.Is(recv.Err, errBandwidthExcluded) && !errors.Is(recv.Err, errWatchdogExcluded) {
+					failedSigners++
+				}
 				continue
 			}
 
@@ -601,20 +927,20 @@ func (s *SliceSigner) aggregateSignature(ctx context.Context, signInfo *SignInfo
 
 				if aggSigs[blobIdx] == nil {
 					aggSigs[blobIdx] = &core.Signature{G1Point: sig.Clone()}
-					aggPubKeys[blobIdx] = signer.PkG2.Clone()
 
 					sliceSize := len(signInfo.batch.EncodedBlobs[signInfo.newBlobs[blobIdx]].EncodedSlice)
 					totalSliceCount[blobIdx] = sliceSize
 					signedSliceCount[blobIdx] = 0
 					bitmapLen := sliceSize / 8
 					if sliceSize%8 != 0 {
-						sliceSize++
+						bitmapLen++
 					}
 					quorumBitmap[blobIdx] = make([]byte, bitmapLen)
 				} else {
 					aggSigs[blobIdx].Add(sig.G1Point)
-					aggPubKeys[blobIdx].Add(signer.PkG2)
 				}
+				blobSigners[blobIdx] = append(blobSigners[blobIdx], signerAddress)
+				signerBitmap.Set(signer.index)
 
 				signedSliceCount[blobIdx] += len(signer.sliceIndexes)
 				for _, sliceIdx := range signer.sliceIndexes {
@@ -626,15 +952,91 @@ func (s *SliceSigner) aggregateSignature(ctx context.Context, signInfo *SignInfo
 		}
 	}
 
+	if blobSize > 0 {
+		leastSigned := uint8(100)
+		for blobIdx := range aggSigs {
+			leastSigned = min(leastSigned, signedPercent(signedSliceCount[blobIdx], totalSliceCount[blobIdx]))
+		}
+		s.storageNodes.record(failedSigners, signerCounter, leastSigned)
+	}
+
+	if lateSigners > 0 {
+		quorumID := signInfo.quorumId.Uint64()
+		s.logger.Warn("[signer] dispatch deadline of quorum passed, proceeding without the signatures of late signers",
+			"ts", signInfo.ts, "quorum", quorumID, "deadline", s.dispatchDeadline(quorumID), "late signers", lateSigners, "signers", signerCounter)
+		s.metrics.IncrementDispatchDeadlineExceeded(quorumID, lateSigners)
+	}
+
+	// blobs signed by the same set of signers share the aggregated public key
+	cache := s.signerCacheOf(signInfo.batch.venue)
+	quorum, ok := cache.get(signInfo.epoch, signInfo.quorumId)
+	if !ok {
+		quorum = cache.put(signInfo.epoch, signInfo.quorumId, signInfo.signers)
+	}
+	for blobIdx, addresses := range blobSigners {
+		if len(addresses) == 0 {
+			continue
+		}
+		aggPubKey, hit := quorum.aggregatePubKey(addresses)
+		aggPubKeys[blobIdx] = aggPubKey
+		s.metrics.UpdateSignerCache("agg_pubkey", hit)
+	}
+
+	attesters := make(map[int][]*SignerState)
+	for blobIdx, addresses := range blobSigners {
+		for _, address := range addresses {
+			attesters[signInfo.newBlobs[blobIdx]] = append(attesters[signInfo.newBlobs[blobIdx]], signInfo.signers[address])
+		}
+	}
+
 	valid := true
-	rootSubmissions := make([]*core.CommitRootSubmission, 0)
+	passed := make([]bool, blobSize)
+	percentSigned := make(map[int]uint8)
 	for blobIdx, sig := range aggSigs {
-		if signedSliceCount[blobIdx] < int(math.Ceil(float64(totalSliceCount[blobIdx])*2/3)) {
+		if sig == nil {
 			valid = false
-			break
+			continue
 		}
+		percentSigned[signInfo.newBlobs[blobIdx]] = signedPercent(signedSliceCount[blobIdx], totalSliceCount[blobIdx])
+		metadata := signInfo.batch.BlobMetadata[signInfo.newBlobs[blobIdx]]
+		passed[blobIdx] = getBlobQuorumPassStatus(metadata, core.QuorumID(signInfo.quorumId.Uint64()), signedSliceCount[blobIdx], totalSliceCount[blobIdx])
+		valid = valid && passed[blobIdx]
+	}
+
+	// signing isn't retried once the batch is out of time
+	outOfRetries := signInfo.reties >= s.MaxNumRetriesSign
+	if !valid && !outOfRetries && signInfo.batch.budget.latencyExhausted() {
+		outOfRetries = true
+		s.metrics.IncrementBudgetDecision(BudgetSigningAbandoned)
+		s.logger.Warn("[signer] batch ran out of its latency budget, not retrying signing", "ts", signInfo.ts)
+	}
+
+	// once out of retries, the blobs short of optional thresholds fall back to the defaults
+	if !valid && s.QuorumFallback && outOfRetries {
+		valid = s.fallBack(signInfo, aggSigs, signedSliceCount, totalSliceCount, passed)
+	}
 
-		rootSubmissions = append(rootSubmissions, &core.CommitRootSubmission{
+	// once out of retries, the blobs that reached the threshold are confirmed on their own
+	excluded := make(map[int]struct{})
+	if !valid && s.PartialConfirmation && outOfRetries {
+		if partial, excludedMetadata, ok := partialExclusions(signInfo, passed); ok {
+			excluded = partial
+			s.logger.Warn("[signer] partially confirming batch", "ts", signInfo.ts, "excluded blobs", len(excluded), "total blobs", len(signInfo.batch.EncodedBlobs))
+			_ = s.handleFailure(ctx, excludedMetadata, FailAggregateSignatures)
+			s.removeFailedBlobs(ctx, excludedMetadata)
+			s.metrics.IncrementPartialBatch(len(excluded))
+			valid = true
+		}
+	}
+
+	rootSubmissions := make([]*core.CommitRootSubmission, 0)
+	signatures := make(map[int]*core.CommitRootSubmission)
+	for blobIdx, sig := range aggSigs {
+		if !passed[blobIdx] {
+			continue
+		}
+
+		submission := &core.CommitRootSubmission{
 			DataRoot:          storageRoots[blobIdx],
 			ErasureCommitment: erasureCommitments[blobIdx],
 			Epoch:             signInfo.epoch,
@@ -642,7 +1044,9 @@ func (s *SliceSigner) aggregateSignature(ctx context.Context, signInfo *SignInfo
 			QuorumBitmap:      quorumBitmap[blobIdx],
 			AggPkG2:           aggPubKeys[blobIdx],
 			AggSigs:           sig,
-		})
+		}
+		rootSubmissions = append(rootSubmissions, submission)
+		signatures[signInfo.newBlobs[blobIdx]] = submission
 	}
 
 	if valid {
@@ -657,6 +1061,15 @@ func (s *SliceSigner) aggregateSignature(ctx context.Context, signInfo *SignInfo
 			proofs:      signInfo.proofs,
 			epoch:       signInfo.epoch,
 			quorumId:    signInfo.quorumId,
+
+			percentSigned: percentSigned,
+			excluded:      excluded,
+			signerBitmap:  signerBitmap,
+			numSigners:    signerCounter,
+			attesters:     attesters,
+			signatures:    signatures,
+
+			referenceBlock: signInfo.referenceBlock,
 		}
 		s.signedBlobSize += uint64(len(signInfo.batch.EncodedBlobs))
 		s.logger.Debug("[signer] get aggregate signature for batch", "ts", signInfo.ts)
@@ -674,7 +1087,7 @@ func (s *SliceSigner) aggregateSignature(ctx context.Context, signInfo *SignInfo
 			s.SignatureSizeNotifier.mu.Unlock()
 		}
 	} else {
-		if signInfo.reties < s.MaxNumRetriesSign {
+		if !outOfRetries {
 			s.mu.Lock()
 			defer s.mu.Unlock()
 
@@ -683,19 +1096,7 @@ func (s *SliceSigner) aggregateSignature(ctx context.Context, signInfo *SignInfo
 			s.logger.Warn("[signer] retry signing", "retries", signInfo.reties)
 		} else {
 			_ = s.handleFailure(ctx, signInfo.batch.BlobMetadata, FailAggregateSignatures)
-
-			for _, metadata := range signInfo.batch.BlobMetadata {
-				meta, err := s.blobStore.GetBlobMetadata(ctx, metadata.GetBlobKey())
-				if err != nil {
-					s.logger.Error("[signer] failed to get blob metadata", "key", metadata.GetBlobKey(), "err", err)
-				} else {
-					if meta.BlobStatus == disperser.Failed {
-						s.logger.Info("[signer] signing blob reach max retries", "key", metadata.GetBlobKey())
-						s.EncodingStreamer.RemoveEncodedBlob(metadata)
-						s.blobStore.RemoveBlob(ctx, metadata)
-					}
-				}
-			}
+			s.removeFailedBlobs(ctx, signInfo.batch.BlobMetadata)
 
 			s.EncodingStreamer.RemoveBatchingStatus(signInfo.ts)
 			return errors.New("failed aggregate signatures")
@@ -705,6 +1106,22 @@ func (s *SliceSigner) aggregateSignature(ctx context.Context, signInfo *SignInfo
 	return nil
 }
 
+// removeFailedBlobs drops the blobs dead-lettered after their last retry from the encoded
+// blobs, the blob store keeping them to be resubmitted
+func (s *SliceSigner) removeFailedBlobs(ctx context.Context, metadatas []*disperser.BlobMetadata) {
+	for _, metadata := range metadatas {
+		meta, err := s.blobStore.GetBlobMetadataWithOptions(ctx, metadata.GetBlobKey(), disperser.ReadOptions{Consistency: disperser.SessionRead})
+		if err != nil {
+			s.logger.Error("[signer] failed to get blob metadata", "key", metadata.GetBlobKey(), "err", err)
+		} else {
+			if meta.BlobStatus == disperser.DeadLettered {
+				s.logger.Info("[signer] signing blob reach max retries", "key", metadata.GetBlobKey())
+				s.EncodingStreamer.RemoveEncodedBlob(metadata)
+			}
+		}
+	}
+}
+
 func (s *SliceSigner) GetCommitRootSubmissionBatch() ([]*BatchCommitRootSubmission, uint64, error) {
 	ts := uint64(time.Now().Nanosecond())
 
@@ -763,6 +1180,29 @@ func (s *SliceSigner) RemoveSignedBlob(ts uint64) {
 	delete(s.signedBatching, ts)
 }
 
+// ReleaseSubmissions returns the signed batches ids taken in the submission batch signedTs to
+// the pending submissions, for a later submission batch
+func (s *SliceSigner) ReleaseSubmissions(signedTs uint64, ids []uint64) {
+	if len(ids) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	released := make(map[uint64]bool, len(ids))
+	for _, id := range ids {
+		delete(s.signedBatching, id)
+		released[id] = true
+	}
+	kept := s.signedBatches[signedTs][:0]
+	for _, id := range s.signedBatches[signedTs] {
+		if !released[id] {
+			kept = append(kept, id)
+		}
+	}
+	s.signedBatches[signedTs] = kept
+}
+
 func (s *SliceSigner) RemoveBatchingStatus(ts uint64) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -775,66 +1215,6 @@ func (s *SliceSigner) RemoveBatchingStatus(ts uint64) {
 	delete(s.signedBatches, ts)
 }
 
-func getHash(dataRoot [32]byte, epoch, quorumId *big.Int, erasureCommitment *core.G1Point) ([32]byte, error) {
-	dataType, err := abi.NewType("tuple", "", []abi.ArgumentMarshaling{
-		{
-			Name: "dataRoot",
-			Type: "bytes32",
-		},
-		{
-			Name: "epoch",
-			Type: "uint256",
-		},
-		{
-			Name: "quorumId",
-			Type: "uint256",
-		},
-		{
-			Name: "X",
-			Type: "uint256",
-		},
-		{
-			Name: "Y",
-			Type: "uint256",
-		},
-	})
-	if err != nil {
-		return [32]byte{}, err
-	}
-
-	arguments := abi.Arguments{
-		{
-			Type: dataType,
-		},
-	}
-
-	o := struct {
-		DataRoot [32]byte
-		Epoch    *big.Int
-		QuorumId *big.Int
-		X        *big.Int
-		Y        *big.Int
-	}{
-		DataRoot: dataRoot,
-		Epoch:    epoch,
-		QuorumId: quorumId,
-		X:        erasureCommitment.X.BigInt(new(big.Int)),
-		Y:        erasureCommitment.Y.BigInt(new(big.Int)),
-	}
-
-	bytes, err := arguments.Pack(o)
-	if err != nil {
-		return [32]byte{}, err
-	}
-
-	var headerHash [32]byte
-	hasher := sha3.NewLegacyKeccak256()
-	hasher.Write(bytes)
-	copy(headerHash[:], hasher.Sum(nil)[:32])
-
-	return headerHash, nil
-}
-
 func GetBlobHash(dataRoot []byte, epoch, quorumId uint64) [32]byte {
 	var message [32]byte
 	hasher := sha3.NewLegacyKeccak256()
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -17,7 +18,34 @@ func TestGetHash(t *testing.T) {
 
 	expectedHash := [32]byte{0xde, 0x7b, 0xb4, 0x32, 0xe4, 0xff, 0xf3, 0xff, 0xbd, 0x59, 0x3c, 0x99, 0x6a, 0x9a, 0x60, 0x62, 0x6d, 0x24, 0xa4, 0xaa, 0xc0, 0xa5, 0xd0, 0xbb, 0x49, 0x47, 0x66, 0x48, 0x92, 0x42, 0x91, 0xe}
 
-	resultHash, err := getHash(dataRoot, epoch, quorumId, erasureCommitment)
+	resultHash, err := core.SignatureMessage(dataRoot, epoch, quorumId, erasureCommitment)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedHash, resultHash, "Hashes should match")
 }
+
+func TestPartialConfirmation(t *testing.T) {
+	blobs := []*disperser.BlobMetadata{{BlobHash: "0"}, {BlobHash: "1"}, {BlobHash: "2"}}
+	// the first blob was signed already, the other two are new
+	signInfo := &SignInfo{quorumId: big.NewInt(0), batch: &batch{BlobMetadata: blobs}, newBlobs: []int{1, 2}}
+
+	excluded, metadata, ok := partialExclusions(signInfo, []bool{true, false})
+	assert.True(t, ok)
+	assert.Equal(t, map[int]struct{}{2: {}}, excluded)
+	assert.Equal(t, []*disperser.BlobMetadata{blobs[2]}, metadata)
+
+	// a failed quorum, whose new blobs all fell short, has nothing to submit
+	_, _, ok = partialExclusions(signInfo, []bool{false, false})
+	assert.False(t, ok)
+	// and a batch whose blobs all passed isn't partial
+	_, _, ok = partialExclusions(signInfo, []bool{true, true})
+	assert.False(t, ok)
+}
+
+func TestEmptyQuorum(t *testing.T) {
+	// a quorum without slices signs none, and no blob passes in it
+	assert.Equal(t, uint8(0), signedPercent(0, 0))
+	assert.False(t, getBlobQuorumPassStatus(nil, 0, 0, 0))
+	assert.Equal(t, uint8(70), signedPercent(7, 10))
+	assert.Equal(t, uint8(100), signedPercent(12, 10))
+	assert.True(t, getBlobQuorumPassStatus(nil, 0, 7, 10))
+}
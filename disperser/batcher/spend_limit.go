@@ -0,0 +1,402 @@
+package batcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/leveldb"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// errConfirmationPaused is returned when the submission of aggregate signatures is paused by
+// the spend limits or through the admin API
+var errConfirmationPaused = errors.New("confirmation paused")
+
+// SpendLimitConfig bounds the fees the batcher's wallets spend on their transactions, the
+// batch uploads, the confirmations and the inbox posts, to contain a fee-market incident or a
+// bug submitting too often or too dearly. While the fees of the last hour or day reach their
+// cap, the aggregate signatures aren't submitted, which resumes once the spend leaves the
+// window. A confirmation costing SpikeFactor times the average per-batch cost of the last
+// SpikeWindow batches pauses the confirmation until it is resumed through the admin API. Signed batches wait meanwhile, no new batch is dispersed, and the blobs expire as
+// usual if it lasts.
+type SpendLimitConfig struct {
+	// HourlyGwei caps the fees in gwei of the transactions of the last hour, no cap if 0
+	HourlyGwei uint64
+	// DailyGwei caps the fees in gwei of the transactions of the last day, no cap if 0
+	DailyGwei uint64
+	// SpikeFactor is how many times the average per-batch cost a batch may cost, unbounded
+	// if 0. Transactions confirming several batches are shared evenly between them.
+	SpikeFactor float64
+	// SpikeWindow is the number of confirmed batches the per-batch cost is averaged over
+	SpikeWindow int
+	// JournalPath persists the spends of the last day and the pause left to the admin API,
+	// so that a restarted batcher keeps them. Not persisted if empty.
+	JournalPath string
+	// AlertURL is sent a SpendAlert on each breach, such as a chat or paging webhook. No
+	// alert if empty.
+	AlertURL string
+}
+
+func (c SpendLimitConfig) Enabled() bool {
+	return c.HourlyGwei > 0 || c.DailyGwei > 0 || c.SpikeFactor > 0
+}
+
+func (c SpendLimitConfig) validate() error {
+	if c.SpikeFactor < 0 || (c.SpikeFactor > 0 && c.SpikeFactor <= 1) {
+		return errors.New("the spend spike factor must be greater than 1, or 0 to disable it")
+	}
+	if c.SpikeFactor > 0 && c.SpikeWindow <= 0 {
+		return errors.New("the spend spike detection requires a positive window")
+	}
+	if c.HourlyGwei > 0 && c.DailyGwei > 0 && c.DailyGwei < c.HourlyGwei {
+		return errors.New("the daily spend cap must not be below the hourly one")
+	}
+	return nil
+}
+
+// Spend limit breaches, as reported by the metrics
+const (
+	SpendHourlyCap = "hourly_cap"
+	SpendDailyCap  = "daily_cap"
+	SpendSpike     = "spike"
+)
+
+// ConfirmationStatus reports whether the submission of aggregate signatures is paused, and
+// the fees in gwei the confirmations spent over the windows of the caps
+type ConfirmationStatus struct {
+	Paused          bool      `json:"paused"`
+	Since           time.Time `json:"since,omitempty"`
+	Reason          string    `json:"reason,omitempty"`
+	HourlySpentGwei uint64    `json:"hourly_spent_gwei"`
+	DailySpentGwei  uint64    `json:"daily_spent_gwei"`
+}
+
+type spend struct {
+	At  time.Time
+	Fee *big.Int
+}
+
+// spendState is what the spend journal keeps of the spend limit
+type spendState struct {
+	Spends []spend
+	Costs  []float64
+	Held   ConfirmationStatus
+}
+
+var spendJournalKey = []byte("spend-state")
+
+// SpendAlert is posted as JSON to SpendLimitConfig.AlertURL on a breach
+type SpendAlert struct {
+	// Text summarizes the breach, as chat webhooks expect
+	Text    string    `json:"text"`
+	Breach  string    `json:"breach"`
+	Detail  string    `json:"detail"`
+	TxHash  string    `json:"tx_hash"`
+	FeeGwei uint64    `json:"fee_gwei"`
+	At      time.Time `json:"at"`
+}
+
+// spendLimit tracks the fees of the confirmations against SpendLimitConfig
+type spendLimit struct {
+	config SpendLimitConfig
+	now    func() time.Time
+
+	mu sync.Mutex
+	// spends are the fees of the transactions of the last day, oldest first
+	spends []spend
+	// costs are the per-batch costs in gwei of the last SpikeWindow confirmations
+	costs []float64
+	// held is the pause left only through the admin API, after a spike or set through it
+	held ConfirmationStatus
+	// journal is nil unless the state is persisted
+	journal disperser.DB
+
+	logger common.Logger
+}
+
+func newSpendLimit(config SpendLimitConfig, logger common.Logger) (*spendLimit, error) {
+	l := &spendLimit{config: config, now: time.Now, logger: logger}
+	if !config.Enabled() || config.JournalPath == "" {
+		return l, nil
+	}
+	db, err := leveldb.NewLevelDBStore(config.JournalPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spend journal at %s: %w", config.JournalPath, err)
+	}
+	l.journal = db
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// load restores the state the journal kept
+func (l *spendLimit) load() error {
+	data, err := l.journal.Get(spendJournalKey)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read spend journal: %w", err)
+	}
+	var state spendState
+	if err := core.Decode(data, &state); err != nil {
+		l.logger.Warn("[confirmer] dropping undecodable spend journal", "err", err)
+		return nil
+	}
+	l.spends, l.costs, l.held = state.Spends, state.Costs, state.Held
+	if len(l.costs) > l.config.SpikeWindow {
+		l.costs = l.costs[len(l.costs)-l.config.SpikeWindow:]
+	}
+	return nil
+}
+
+// persist writes the state to the journal, if any, under the lock
+func (l *spendLimit) persist() {
+	if l.journal == nil {
+		return
+	}
+	data, err := core.Encode(&spendState{Spends: l.spends, Costs: l.costs, Held: l.held})
+	if err == nil {
+		err = l.journal.Put(spendJournalKey, data)
+	}
+	if err != nil {
+		l.logger.Warn("[confirmer] failed to journal the spends", "err", err)
+	}
+}
+
+func gwei(wei *big.Int) uint64 {
+	return new(big.Int).Div(wei, big.NewInt(params.GWei)).Uint64()
+}
+
+// spent returns the fees in wei spent over the last hour and day, dropping older ones
+func (l *spendLimit) spent(now time.Time) (*big.Int, *big.Int) {
+	for len(l.spends) > 0 && now.Sub(l.spends[0].At) >= 24*time.Hour {
+		l.spends = l.spends[1:]
+	}
+	hourly, daily := new(big.Int), new(big.Int)
+	for _, s := range l.spends {
+		daily.Add(daily, s.Fee)
+		if now.Sub(s.At) < time.Hour {
+			hourly.Add(hourly, s.Fee)
+		}
+	}
+	return hourly, daily
+}
+
+// capReached returns the breach of the cap the fees reached, empty if none
+func (l *spendLimit) capReached(hourly, daily *big.Int) string {
+	if l.config.HourlyGwei > 0 && gwei(hourly) >= l.config.HourlyGwei {
+		return SpendHourlyCap
+	}
+	if l.config.DailyGwei > 0 && gwei(daily) >= l.config.DailyGwei {
+		return SpendDailyCap
+	}
+	return ""
+}
+
+func (l *spendLimit) status() ConfirmationStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	hourly, daily := l.spent(l.now())
+	status := l.held
+	status.HourlySpentGwei, status.DailySpentGwei = gwei(hourly), gwei(daily)
+	if status.Paused {
+		return status
+	}
+	switch l.capReached(hourly, daily) {
+	case SpendHourlyCap:
+		status.Paused, status.Reason = true, fmt.Sprintf("hourly spend cap of %d gwei reached", l.config.HourlyGwei)
+	case SpendDailyCap:
+		status.Paused, status.Reason = true, fmt.Sprintf("daily spend cap of %d gwei reached", l.config.DailyGwei)
+	default:
+		return status
+	}
+	// no batch is dispersed or confirmed once a cap is reached, so the last spend reached it
+	status.Since = l.spends[len(l.spends)-1].At
+	return status
+}
+
+// record adds the fee in wei of a transaction confirming the given number of batches,
+// returning the breach it caused, empty if none. The transactions confirming no batch, such
+// as the uploads and the inbox posts, count towards the caps only.
+func (l *spendLimit) record(fee *big.Int, batches int) (string, string) {
+	if !l.config.Enabled() {
+		return "", ""
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	defer l.persist()
+	now := l.now()
+	before := l.capReached(l.spent(now))
+	l.spends = append(l.spends, spend{At: now, Fee: fee})
+	if breach := l.capReached(l.spent(now)); breach != "" && breach != before {
+		hourly, daily := l.spent(now)
+		return breach, fmt.Sprintf("spent %d gwei in the last hour and %d gwei in the last day", gwei(hourly), gwei(daily))
+	}
+
+	if l.config.SpikeFactor == 0 || batches == 0 {
+		return "", ""
+	}
+	cost, _ := new(big.Rat).SetFrac(fee, big.NewInt(int64(batches)*params.GWei)).Float64()
+	if len(l.costs) == l.config.SpikeWindow {
+		average := 0.0
+		for _, c := range l.costs {
+			average += c
+		}
+		average /= float64(len(l.costs))
+		if cost > l.config.SpikeFactor*average {
+			// the spike stays out of the average, which would hide the next ones
+			if !l.held.Paused {
+				l.held = ConfirmationStatus{Paused: true, Since: now, Reason: fmt.Sprintf("per-batch cost of %.0f gwei over %.1f times the average of %.0f gwei", cost, l.config.SpikeFactor, average)}
+			}
+			return SpendSpike, l.held.Reason
+		}
+		l.costs = l.costs[1:]
+	}
+	l.costs = append(l.costs, cost)
+	return "", ""
+}
+
+// hold pauses or resumes the confirmation, returning whether it changed. Resuming restarts
+// the average per-batch cost, which a legitimate rise of the fees would keep below.
+func (l *spendLimit) hold(paused bool, reason string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held.Paused == paused {
+		return false
+	}
+	l.held = ConfirmationStatus{Paused: paused}
+	if paused {
+		l.held.Since = l.now()
+		l.held.Reason = reason
+	} else {
+		l.costs = nil
+	}
+	l.persist()
+	return true
+}
+
+// alert posts a breach to the alert URL, if any
+func (l *spendLimit) alert(alert SpendAlert) {
+	if l.config.AlertURL == "" {
+		return
+	}
+	go func() {
+		body, err := json.Marshal(alert)
+		if err != nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.config.AlertURL, bytes.NewReader(body))
+		if err != nil {
+			l.logger.Warn("[confirmer] failed to send the spend alert", "err", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			l.logger.Warn("[confirmer] failed to send the spend alert", "err", err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			l.logger.Warn("[confirmer] spend alert rejected", "status", resp.StatusCode)
+		}
+	}()
+}
+
+// spendRecorder records the fees of the transactions of the batcher against the spend limits
+type spendRecorder interface {
+	// recordFee records the fee in wei of an included transaction confirming the given
+	// number of batches, 0 if it confirms none
+	recordFee(fee *big.Int, txHash eth_common.Hash, batches int)
+}
+
+var _ spendRecorder = (*BatchConfirmer)(nil)
+
+// recordSpend records the fee of a transaction confirming the given number of batches, 0
+// for the uploads, against the spend limits, if the confirmer reports fees
+func (c *BatchConfirmer) recordSpend(ctx context.Context, confirmer Confirmer, txHash eth_common.Hash, batches int) {
+	reporter, ok := confirmer.(feeReporter)
+	if !ok || !c.spendLimited() {
+		return
+	}
+	fee, err := reporter.TxFee(ctx, txHash)
+	if err != nil {
+		c.logger.Warn("[confirmer] failed to get the fee of the tx", "transaction hash", txHash, "err", err)
+		return
+	}
+	c.recordFee(fee, txHash, batches)
+}
+
+// spendLimited tells whether the fees are recorded against spend limits
+func (c *BatchConfirmer) spendLimited() bool {
+	return c != nil && c.spend != nil && c.spend.config.Enabled()
+}
+
+func (c *BatchConfirmer) recordFee(fee *big.Int, txHash eth_common.Hash, batches int) {
+	if !c.spendLimited() {
+		return
+	}
+	breach, detail := c.spend.record(fee, batches)
+	c.updateSpendMetrics()
+	if breach != "" {
+		c.Metrics.IncrementSpendLimitBreach(breach)
+		c.logger.Warn("[confirmer] spend limit breached, confirmation paused", "breach", breach, "detail", detail, "transaction hash", txHash, "fee", fee)
+		c.spend.alert(SpendAlert{
+			Text:    fmt.Sprintf("batcher confirmation paused, %s: %s", breach, detail),
+			Breach:  breach,
+			Detail:  detail,
+			TxHash:  txHash.Hex(),
+			FeeGwei: gwei(fee),
+			At:      c.spend.now(),
+		})
+	}
+}
+
+func (c *BatchConfirmer) updateSpendMetrics() ConfirmationStatus {
+	status := c.spend.status()
+	c.Metrics.UpdateConfirmationSpend(status.Paused, status.HourlySpentGwei, status.DailySpentGwei)
+	return status
+}
+
+// confirmationPaused tells whether the aggregate signatures are held back
+func (c *BatchConfirmer) confirmationPaused() bool {
+	if c == nil || c.spend == nil {
+		return false
+	}
+	return c.updateSpendMetrics().Paused
+}
+
+// Confirmation returns whether the submission of aggregate signatures is paused
+func (b *Batcher) Confirmation() ConfirmationStatus {
+	return b.confirmer.spend.status()
+}
+
+// SetConfirmationPaused pauses or resumes the submission of aggregate signatures, see
+// SpendLimitConfig. The confirmations already submitted are still waited for. Resuming
+// doesn't lift a spend cap, which holds until the spend leaves its window.
+func (b *Batcher) SetConfirmationPaused(paused bool, reason string) {
+	if !b.confirmer.spend.hold(paused, reason) {
+		return
+	}
+	if paused {
+		b.logger.Warn("[confirmer] confirmation paused", "reason", reason)
+	} else {
+		b.logger.Info("[confirmer] confirmation resumed")
+	}
+	b.confirmer.updateSpendMetrics()
+}
@@ -0,0 +1,133 @@
+package batcher
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/disperser/contract"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpendLimit(t *testing.T) {
+	assert.Error(t, SpendLimitConfig{SpikeFactor: 0.5, SpikeWindow: 2}.validate())
+	assert.Error(t, SpendLimitConfig{SpikeFactor: 2}.validate())
+
+	config := SpendLimitConfig{HourlyGwei: 100, DailyGwei: 150, SpikeFactor: 3, SpikeWindow: 2}
+	require.NoError(t, config.validate())
+	logger := mock.NewLogger(false)
+	spend, err := newSpendLimit(config, logger)
+	require.NoError(t, err)
+	c := &BatchConfirmer{spend: spend, Metrics: NewMetrics("9100", logger), logger: logger}
+	now := time.Unix(1000, 0)
+	c.spend.now = func() time.Time { return now }
+	confirmer := &feeConfirmer{}
+	confirm := func(feeGwei int64, batches int) {
+		confirmer.fee = big.NewInt(feeGwei * params.GWei)
+		c.recordSpend(context.Background(), confirmer, eth_common.Hash{}, batches)
+	}
+
+	// a transaction confirming two batches costs each half its fee
+	confirm(20, 2)
+	confirm(10, 1)
+	assert.False(t, c.confirmationPaused())
+
+	// a batch costing three times the average pauses until resumed, new batches included
+	confirm(40, 1)
+	assert.True(t, c.confirmationPaused())
+	b := &Batcher{confirmer: c, EncodingStreamer: &EncodingStreamer{safeMode: &safeMode{}}, Metrics: c.Metrics, logger: logger}
+	assert.Contains(t, b.Confirmation().Reason, "per-batch cost")
+	_, err = b.HandleSingleBatch(context.Background())
+	assert.ErrorIs(t, err, errConfirmationPaused)
+	b.SetConfirmationPaused(false, "")
+	assert.False(t, c.confirmationPaused())
+
+	// the hourly cap holds until the spend leaves the window
+	confirm(40, 1)
+	status := b.Confirmation()
+	assert.True(t, status.Paused)
+	assert.Equal(t, uint64(110), status.HourlySpentGwei)
+	assert.Equal(t, now, status.Since)
+	now = now.Add(time.Hour)
+	assert.False(t, c.confirmationPaused())
+
+	// and the daily one for the day
+	confirm(50, 1)
+	assert.True(t, c.confirmationPaused())
+	assert.Equal(t, uint64(160), b.Confirmation().DailySpentGwei)
+	now = now.Add(23 * time.Hour)
+	assert.False(t, c.confirmationPaused())
+	assert.Equal(t, uint64(50), b.Confirmation().DailySpentGwei)
+}
+
+func TestSpendLimitJournalAndAlert(t *testing.T) {
+	alerts := make(chan SpendAlert, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert SpendAlert
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&alert))
+		alerts <- alert
+	}))
+	defer server.Close()
+
+	config := SpendLimitConfig{
+		HourlyGwei:  100,
+		JournalPath: filepath.Join(t.TempDir(), "spend"),
+		AlertURL:    server.URL,
+	}
+	logger := mock.NewLogger(false)
+	spend, err := newSpendLimit(config, logger)
+	require.NoError(t, err)
+	c := &BatchConfirmer{spend: spend, Metrics: NewMetrics("9100", logger), logger: logger}
+	c.recordSpend(context.Background(), &feeConfirmer{fee: big.NewInt(120 * params.GWei)}, eth_common.Hash{1}, 1)
+	assert.True(t, c.confirmationPaused())
+
+	select {
+	case alert := <-alerts:
+		assert.Equal(t, SpendHourlyCap, alert.Breach)
+		assert.Equal(t, uint64(120), alert.FeeGwei)
+		assert.Equal(t, eth_common.Hash{1}.Hex(), alert.TxHash)
+		assert.NotEmpty(t, alert.Text)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no spend alert")
+	}
+
+	// a restarted batcher keeps the spends of the window
+	restarted := &spendLimit{config: config, now: time.Now, journal: spend.journal, logger: logger}
+	require.NoError(t, restarted.load())
+	status := restarted.status()
+	assert.True(t, status.Paused)
+	assert.Equal(t, uint64(120), status.HourlySpentGwei)
+}
+
+func TestSpendLimitUploadsAndPosts(t *testing.T) {
+	config := SpendLimitConfig{HourlyGwei: 100, SpikeFactor: 3, SpikeWindow: 2}
+	logger := mock.NewLogger(false)
+	spend, err := newSpendLimit(config, logger)
+	require.NoError(t, err)
+	c := &BatchConfirmer{spend: spend, Metrics: NewMetrics("9100", logger), logger: logger}
+	c.recordSpend(context.Background(), &feeConfirmer{fee: big.NewInt(10 * params.GWei)}, eth_common.Hash{}, 1)
+	c.recordSpend(context.Background(), &feeConfirmer{fee: big.NewInt(10 * params.GWei)}, eth_common.Hash{}, 1)
+
+	// an upload counts towards the caps, not as a spike of the per-batch cost
+	c.recordSpend(context.Background(), &feeConfirmer{fee: big.NewInt(50 * params.GWei)}, eth_common.Hash{}, 0)
+	status := c.updateSpendMetrics()
+	assert.False(t, status.Paused)
+	assert.Equal(t, uint64(70), status.HourlySpentGwei)
+
+	// and so does an inbox post
+	p := &Poster{daContract: &contract.DAContract{}, spends: c, logger: logger}
+	p.recordFee(context.Background(), &types.Receipt{GasUsed: 30_000, EffectiveGasPrice: params.GWei / 1_000})
+	status = c.updateSpendMetrics()
+	assert.True(t, status.Paused)
+	assert.Equal(t, uint64(100), status.HourlySpentGwei)
+}
@@ -0,0 +1,273 @@
+package batcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+)
+
+const (
+	StatusOperational = "operational"
+	StatusDegraded    = "degraded"
+)
+
+type StatusPageConfig struct {
+	// Bucket is the bucket the status page is pushed to. Disabled if empty.
+	Bucket string
+	// Prefix is prepended to the keys of status.json and index.html
+	Prefix string
+	// Interval is how often the status page is rendered
+	Interval time.Duration
+	// Window is how far back the batches the status page reports on go
+	Window time.Duration
+	// StaleAfter is how long the disperser may go without confirming a batch while it has
+	// pending work before it is reported degraded
+	StaleAfter time.Duration
+}
+
+func (c StatusPageConfig) Enabled() bool {
+	return c.Bucket != ""
+}
+
+func (c StatusPageConfig) validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.Interval <= 0 || c.Window <= 0 || c.StaleAfter <= 0 {
+		return errors.New("the status page interval, window and stale duration must be positive")
+	}
+	return nil
+}
+
+// StatusPageStore is the object storage the status page is pushed to
+type StatusPageStore interface {
+	PutObject(ctx context.Context, bucket string, key string, data []byte, contentType string) error
+}
+
+// confirmedBatch is what the status page keeps of a confirmed batch
+type confirmedBatch struct {
+	confirmedAt time.Time
+	blobs       int
+	// latency is the mean time from the requests of the blobs to their confirmation
+	latency  time.Duration
+	quorumID uint64
+	// signersPercent and stakePercent are the shares of the signers and of the stake of
+	// the quorum that signed, negative if unknown
+	signersPercent float64
+	stakePercent   float64
+}
+
+// Status is the content of the status page
+type Status struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	Status    string    `json:"status"`
+	// WindowSeconds is how far back the batch statistics go
+	WindowSeconds float64 `json:"window_seconds"`
+
+	Batches              int        `json:"batches"`
+	Blobs                int        `json:"blobs"`
+	LastBatchConfirmedAt *time.Time `json:"last_batch_confirmed_at,omitempty"`
+	// BatchIntervalSeconds is the mean time between confirmed batches
+	BatchIntervalSeconds float64 `json:"batch_interval_seconds"`
+	// ConfirmationLatencySeconds is the mean time from the request of a blob to its confirmation
+	ConfirmationLatencySeconds float64 `json:"confirmation_latency_seconds"`
+
+	PendingBatches int `json:"pending_batches"`
+	PendingBlobs   int `json:"pending_blobs"`
+
+	Quorums []QuorumStatus `json:"quorums"`
+}
+
+// QuorumStatus is the participation of the signers of a quorum in the batches of the window
+type QuorumStatus struct {
+	QuorumID       uint64  `json:"quorum_id"`
+	Batches        int     `json:"batches"`
+	SignersPercent float64 `json:"signers_percent"`
+	StakePercent   float64 `json:"stake_percent"`
+}
+
+// StatusPage periodically renders the health of the disperser into a static JSON and HTML
+// status page pushed to object storage, for users without access to the API or metrics.
+type StatusPage struct {
+	mu      sync.Mutex
+	batches []*confirmedBatch
+	// lastConfirmedAt is kept beyond the window, to tell how long the disperser has been stuck
+	lastConfirmedAt time.Time
+	startedAt       time.Time
+
+	config StatusPageConfig
+	store  StatusPageStore
+	logger common.Logger
+}
+
+func NewStatusPage(config StatusPageConfig, store StatusPageStore, logger common.Logger) *StatusPage {
+	return &StatusPage{
+		config: config,
+		store:  store,
+		logger: logger,
+	}
+}
+
+// recordBatch adds a confirmed batch to the statistics of the status page
+func (p *StatusPage) recordBatch(batch *confirmedBatch) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.batches = append(p.batches, batch)
+	p.lastConfirmedAt = batch.confirmedAt
+}
+
+// Start renders and pushes the status page every interval, with the pending work of the
+// confirmer, until ctx is done
+func (p *StatusPage) Start(ctx context.Context, c *BatchConfirmer) {
+	p.mu.Lock()
+	p.startedAt = time.Now()
+	p.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(p.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				pendingBlobs := 0
+				if c.EncodingStreamer != nil {
+					pendingBlobs, _, _ = c.EncodingStreamer.EncodedBlobstore.GetPendingEncodedStats()
+				}
+				status := p.status(time.Now(), c.PendingBatches(), pendingBlobs)
+				if err := p.push(ctx, status); err != nil {
+					p.logger.Warn("[statuspage] failed to push status page", "err", err)
+				}
+			}
+		}
+	}()
+}
+
+// status computes the status at now from the batches of the window
+func (p *StatusPage) status(now time.Time, pendingBatches int, pendingBlobs int) *Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// drop the batches out of the window
+	start := 0
+	for start < len(p.batches) && now.Sub(p.batches[start].confirmedAt) > p.config.Window {
+		start++
+	}
+	p.batches = p.batches[start:]
+
+	status := &Status{
+		UpdatedAt:      now.UTC(),
+		Status:         StatusOperational,
+		WindowSeconds:  p.config.Window.Seconds(),
+		Batches:        len(p.batches),
+		PendingBatches: pendingBatches,
+		PendingBlobs:   pendingBlobs,
+		Quorums:        make([]QuorumStatus, 0),
+	}
+	// a disperser that just started has had no chance to confirm a batch yet
+	progressedAt := p.startedAt
+	if !p.lastConfirmedAt.IsZero() {
+		lastConfirmedAt := p.lastConfirmedAt.UTC()
+		status.LastBatchConfirmedAt = &lastConfirmedAt
+		if lastConfirmedAt.After(progressedAt) {
+			progressedAt = lastConfirmedAt
+		}
+	}
+	if pendingBatches+pendingBlobs > 0 && now.Sub(progressedAt) > p.config.StaleAfter {
+		status.Status = StatusDegraded
+	}
+	if len(p.batches) == 0 {
+		return status
+	}
+
+	var latency time.Duration
+	quorums := make(map[uint64]*QuorumStatus)
+	// batches whose shares are unknown are left out of the means of the shares
+	signers, stakes := make(map[uint64]int), make(map[uint64]int)
+	for _, batch := range p.batches {
+		status.Blobs += batch.blobs
+		latency += batch.latency * time.Duration(batch.blobs)
+
+		quorum, ok := quorums[batch.quorumID]
+		if !ok {
+			quorum = &QuorumStatus{QuorumID: batch.quorumID}
+			quorums[batch.quorumID] = quorum
+		}
+		quorum.Batches++
+		if batch.stakePercent >= 0 {
+			quorum.StakePercent += batch.stakePercent
+			stakes[batch.quorumID]++
+		}
+		if batch.signersPercent >= 0 {
+			quorum.SignersPercent += batch.signersPercent
+			signers[batch.quorumID]++
+		}
+	}
+	if status.Blobs > 0 {
+		status.ConfirmationLatencySeconds = (latency / time.Duration(status.Blobs)).Seconds()
+	}
+	if len(p.batches) > 1 {
+		span := p.batches[len(p.batches)-1].confirmedAt.Sub(p.batches[0].confirmedAt)
+		status.BatchIntervalSeconds = span.Seconds() / float64(len(p.batches)-1)
+	}
+	for id, quorum := range quorums {
+		if stakes[id] > 0 {
+			quorum.StakePercent /= float64(stakes[id])
+		}
+		if signers[id] > 0 {
+			quorum.SignersPercent /= float64(signers[id])
+		}
+		status.Quorums = append(status.Quorums, *quorum)
+	}
+	sort.Slice(status.Quorums, func(i, j int) bool { return status.Quorums[i].QuorumID < status.Quorums[j].QuorumID })
+	return status
+}
+
+var statusPageTemplate = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>0G DA disperser status</title></head>
+<body>
+<h1>Disperser is {{.Status}}</h1>
+<p>Updated at {{.UpdatedAt.Format "2006-01-02 15:04:05 MST"}}</p>
+<table>
+<tr><td>Batches confirmed</td><td>{{.Batches}} in the last {{printf "%.0f" .WindowSeconds}}s</td></tr>
+<tr><td>Blobs confirmed</td><td>{{.Blobs}}</td></tr>
+<tr><td>Last batch confirmed at</td><td>{{with .LastBatchConfirmedAt}}{{.Format "2006-01-02 15:04:05 MST"}}{{else}}-{{end}}</td></tr>
+<tr><td>Mean batch interval</td><td>{{printf "%.1f" .BatchIntervalSeconds}}s</td></tr>
+<tr><td>Mean confirmation latency</td><td>{{printf "%.1f" .ConfirmationLatencySeconds}}s</td></tr>
+<tr><td>Pending</td><td>{{.PendingBatches}} batches, {{.PendingBlobs}} blobs</td></tr>
+</table>
+<h2>Quorum participation</h2>
+<table>
+<tr><th>Quorum</th><th>Batches</th><th>Signers</th><th>Stake</th></tr>
+{{range .Quorums}}<tr><td>{{.QuorumID}}</td><td>{{.Batches}}</td><td>{{printf "%.1f" .SignersPercent}}%</td><td>{{printf "%.1f" .StakePercent}}%</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// push renders the status and uploads it as status.json and index.html
+func (p *StatusPage) push(ctx context.Context, status *Status) error {
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := p.store.PutObject(ctx, p.config.Bucket, path.Join(p.config.Prefix, "status.json"), data, "application/json"); err != nil {
+		return err
+	}
+	var html bytes.Buffer
+	if err := statusPageTemplate.Execute(&html, status); err != nil {
+		return err
+	}
+	return p.store.PutObject(ctx, p.config.Bucket, path.Join(p.config.Prefix, "index.html"), html.Bytes(), "text/html; charset=utf-8")
+}
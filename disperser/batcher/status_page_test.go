@@ -0,0 +1,53 @@
+package batcher
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusPage(t *testing.T) {
+	store := mock.NewS3Client()
+	config := StatusPageConfig{Bucket: "status", Prefix: "disperser", Interval: time.Minute, Window: time.Hour, StaleAfter: 10 * time.Minute}
+	p := NewStatusPage(config, store, mock.NewLogger(false))
+	now := time.Now()
+	p.startedAt = now.Add(-2 * time.Hour)
+
+	// out of the window
+	p.recordBatch(&confirmedBatch{confirmedAt: now.Add(-90 * time.Minute), blobs: 100, quorumID: 0, signersPercent: -1})
+	p.recordBatch(&confirmedBatch{confirmedAt: now.Add(-30 * time.Minute), blobs: 1, latency: 10 * time.Second, quorumID: 0, signersPercent: 50, stakePercent: 60})
+	p.recordBatch(&confirmedBatch{confirmedAt: now.Add(-20 * time.Minute), blobs: 3, latency: 30 * time.Second, quorumID: 0, signersPercent: -1, stakePercent: 80})
+	p.recordBatch(&confirmedBatch{confirmedAt: now.Add(-10 * time.Minute), blobs: 1, latency: 30 * time.Second, quorumID: 1, signersPercent: 100, stakePercent: 100})
+
+	status := p.status(now, 0, 0)
+	assert.Equal(t, StatusOperational, status.Status)
+	assert.Equal(t, 3, status.Batches)
+	assert.Equal(t, 5, status.Blobs)
+	assert.Equal(t, 600.0, status.BatchIntervalSeconds)
+	assert.Equal(t, 26.0, status.ConfirmationLatencySeconds)
+	assert.Equal(t, []QuorumStatus{
+		{QuorumID: 0, Batches: 2, SignersPercent: 50, StakePercent: 70},
+		{QuorumID: 1, Batches: 1, SignersPercent: 100, StakePercent: 100},
+	}, status.Quorums)
+
+	// pending work without a confirmation for too long
+	assert.Equal(t, StatusDegraded, p.status(now.Add(time.Minute), 1, 0).Status)
+
+	assert.NoError(t, p.push(context.Background(), status))
+	data, err := store.DownloadObject(context.Background(), "status", "disperser/status.json")
+	assert.NoError(t, err)
+	var pushed Status
+	assert.NoError(t, json.Unmarshal(data, &pushed))
+	assert.Equal(t, status.Quorums, pushed.Quorums)
+	html, err := store.DownloadObject(context.Background(), "status", "disperser/index.html")
+	assert.NoError(t, err)
+	assert.Contains(t, string(html), "Disperser is operational")
+
+	// the shares of a batch aren't known, so they're left out of the means
+	p.recordBatch(&confirmedBatch{confirmedAt: now.Add(-5 * time.Minute), blobs: 1, latency: 30 * time.Second, quorumID: 1, signersPercent: -1, stakePercent: -1})
+	assert.Equal(t, QuorumStatus{QuorumID: 1, Batches: 2, SignersPercent: 100, StakePercent: 100}, p.status(now, 0, 0).Quorums[1])
+}
@@ -0,0 +1,138 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// CallKind names a kind of outbound call of the pipeline, each with its own timeout
+type CallKind string
+
+const (
+	CallEncoder     CallKind = "encoder call"
+	CallStoreWrite  CallKind = "store write"
+	CallOperatorRPC CallKind = "operator rpc"
+	CallChainRead   CallKind = "chain read"
+	CallChainWrite  CallKind = "chain write"
+	CallReceiptWait CallKind = "receipt wait"
+)
+
+// TimeoutConfig is the timeout policy of the pipeline. Every outbound call derives its
+// context from the context of the stage making it, so a deadline set further up the
+// pipeline still applies when it is earlier than the timeout of the call. A timeout of 0
+// leaves the call bounded by the parent context only.
+type TimeoutConfig struct {
+	EncodingTimeout   time.Duration
+	ChainReadTimeout  time.Duration
+	ChainWriteTimeout time.Duration
+	// SigningTimeout applies to the signing requests sent to the operators
+	SigningTimeout     time.Duration
+	StoreWriteTimeout  time.Duration
+	ReceiptWaitTimeout time.Duration
+
+	// live holds the timeouts set at runtime, shared by the copies of a reloadable config
+	live *atomic.Pointer[TimeoutConfig]
+}
+
+// Reloadable returns a copy of the config whose timeouts can be changed at runtime with
+// SetTimeouts, in all the copies made from it
+func (c TimeoutConfig) Reloadable() TimeoutConfig {
+	c.live = &atomic.Pointer[TimeoutConfig]{}
+	return c
+}
+
+// Current returns the timeouts in effect, those last set if the config is reloadable
+func (c TimeoutConfig) Current() TimeoutConfig {
+	if c.live != nil {
+		if live := c.live.Load(); live != nil {
+			current := *live
+			current.live = c.live
+			return current
+		}
+	}
+	return c
+}
+
+// SetTimeouts replaces the timeouts of a reloadable config and of its copies, the calls in
+// progress keep their timeout
+func (c TimeoutConfig) SetTimeouts(timeouts TimeoutConfig) error {
+	if c.live == nil {
+		return errors.New("timeouts aren't reloadable")
+	}
+	timeouts.live = nil
+	c.live.Store(&timeouts)
+	return nil
+}
+
+// TimeoutError is returned when a call ran out of its own timeout, as opposed to the
+// parent context being cancelled or expiring, and names the call so the timeout to tune
+// can be told from the error.
+type TimeoutError struct {
+	Call    CallKind
+	Timeout time.Duration
+	Err     error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %v: %v", e.Call, e.Timeout, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout returns the timeout of the given kind of call
+func (c TimeoutConfig) Timeout(call CallKind) time.Duration {
+	if c.live != nil {
+		if live := c.live.Load(); live != nil {
+			return live.Timeout(call)
+		}
+	}
+	switch call {
+	case CallEncoder:
+		return c.EncodingTimeout
+	case CallStoreWrite:
+		return c.StoreWriteTimeout
+	case CallOperatorRPC:
+		return c.SigningTimeout
+	case CallChainRead:
+		return c.ChainReadTimeout
+	case CallChainWrite:
+		return c.ChainWriteTimeout
+	case CallReceiptWait:
+		return c.ReceiptWaitTimeout
+	}
+	return 0
+}
+
+// WithTimeout derives the context of a call from ctx
+func (c TimeoutConfig) WithTimeout(ctx context.Context, call CallKind) (context.Context, context.CancelFunc) {
+	timeout := c.Timeout(call)
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Wrap turns err into a *TimeoutError if callCtx, derived from parent by WithTimeout, ran
+// out of its own timeout. Other errors are returned as is.
+func (c TimeoutConfig) Wrap(parent context.Context, callCtx context.Context, call CallKind, err error) error {
+	if err == nil || parent.Err() != nil || !errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return err
+	}
+	return &TimeoutError{Call: call, Timeout: c.Timeout(call), Err: err}
+}
+
+// Do runs fn with the context of the given kind of call
+func (c TimeoutConfig) Do(ctx context.Context, call CallKind, fn func(ctx context.Context) error) error {
+	callCtx, cancel := c.WithTimeout(ctx, call)
+	defer cancel()
+	return c.Wrap(ctx, callCtx, call, fn(callCtx))
+}
@@ -0,0 +1,51 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeoutAttribution(t *testing.T) {
+	timeouts := TimeoutConfig{StoreWriteTimeout: time.Millisecond}
+	wait := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	err := timeouts.Do(context.Background(), CallStoreWrite, wait)
+	var timeoutErr *TimeoutError
+	assert.True(t, errors.As(err, &timeoutErr))
+	assert.Equal(t, CallStoreWrite, timeoutErr.Call)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	// a cancelled parent isn't a timeout of the call
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = timeouts.Do(ctx, CallStoreWrite, wait)
+	assert.False(t, errors.As(err, &timeoutErr))
+
+	// no timeout for chain reads, the call ends with its parent
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	err = timeouts.Do(ctx, CallChainRead, wait)
+	assert.False(t, errors.As(err, &timeoutErr))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestReloadableTimeouts(t *testing.T) {
+	timeouts := TimeoutConfig{StoreWriteTimeout: time.Second}
+	assert.Error(t, timeouts.SetTimeouts(TimeoutConfig{}))
+
+	// the copies made from a reloadable config share its timeouts
+	timeouts = timeouts.Reloadable()
+	copied := timeouts
+	assert.NoError(t, timeouts.SetTimeouts(TimeoutConfig{StoreWriteTimeout: time.Minute}))
+	assert.Equal(t, time.Minute, copied.Timeout(CallStoreWrite))
+	assert.Zero(t, copied.Timeout(CallEncoder))
+	// the configured timeouts are kept
+	assert.Equal(t, time.Second, copied.StoreWriteTimeout)
+}
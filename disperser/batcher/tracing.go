@@ -0,0 +1,37 @@
+package batcher
+
+import (
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/tracing"
+	"github.com/0glabs/0g-da-client/disperser"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceContextOf is the trace context the blob was dispersed with, nil if it wasn't traced
+func traceContextOf(metadata *disperser.BlobMetadata) map[string]string {
+	if metadata == nil || metadata.RequestMetadata == nil {
+		return nil
+	}
+	return metadata.RequestMetadata.TraceContext
+}
+
+// traceBlobs starts a span of each traced blob at start, continuing the traces of their
+// dispersals. The spans of the blobs that weren't traced record nothing.
+func traceBlobs(tracer *tracing.Tracer, metadatas []*disperser.BlobMetadata, name string, start time.Time, attrs ...tracing.Attribute) []trace.Span {
+	if tracer == nil {
+		return nil
+	}
+	spans := make([]trace.Span, len(metadatas))
+	for i, metadata := range metadatas {
+		spans[i] = tracer.StartChildAt(traceContextOf(metadata), name, start, append([]tracing.Attribute{tracing.String("blob.key", metadata.GetBlobKey().String())}, attrs...)...)
+	}
+	return spans
+}
+
+func endSpans(spans []trace.Span, err error, attrs ...tracing.Attribute) {
+	for _, span := range spans {
+		span.SetAttributes(attrs...)
+		tracing.End(span, err)
+	}
+}
@@ -0,0 +1,55 @@
+package transactor
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/urfave/cli"
+)
+
+const (
+	SenderPrivateKeysFlagName = "chain.sender-private-keys"
+	SenderMinBalanceFlagName  = "chain.sender-min-balance"
+	SenderHealthCheckFlagName = "chain.sender-health-check-interval"
+	BlobSenderKeyFlagName     = "chain.blob-sender-private-key"
+)
+
+// SenderPoolCLIFlags are the flags of the SenderPoolConfig, next to those of geth.EthClientFlags
+func SenderPoolCLIFlags(envPrefix string) []cli.Flag {
+	return []cli.Flag{
+		cli.StringSliceFlag{
+			Name:   SenderPrivateKeysFlagName,
+			Usage:  "private keys of additional funded accounts batches are uploaded and confirmed from in rotation with the disperser account, each with its own nonces",
+			EnvVar: common.PrefixEnvVar(envPrefix, "SENDER_PRIVATE_KEYS"),
+		},
+		cli.Float64Flag{
+			Name:   SenderMinBalanceFlagName,
+			Usage:  "balance in native tokens below which a sender account is reported and only used while no other sender has enough",
+			Value:  1,
+			EnvVar: common.PrefixEnvVar(envPrefix, "SENDER_MIN_BALANCE"),
+		},
+		cli.StringFlag{
+			Name:   BlobSenderKeyFlagName,
+			Usage:  "private key of the funded account blob transactions are sent from, which must send nothing else. Required to post in blob transactions",
+			EnvVar: common.PrefixEnvVar(envPrefix, "BLOB_SENDER_PRIVATE_KEY"),
+		},
+		cli.DurationFlag{
+			Name:   SenderHealthCheckFlagName,
+			Usage:  "interval of the balance checks of the sender accounts",
+			Value:  time.Minute,
+			EnvVar: common.PrefixEnvVar(envPrefix, "SENDER_HEALTH_CHECK_INTERVAL"),
+		},
+	}
+}
+
+func ReadSenderPoolConfig(ctx *cli.Context) SenderPoolConfig {
+	minBalance, _ := new(big.Float).Mul(big.NewFloat(ctx.GlobalFloat64(SenderMinBalanceFlagName)), big.NewFloat(params.Ether)).Int(nil)
+	return SenderPoolConfig{
+		PrivateKeys:         ctx.GlobalStringSlice(SenderPrivateKeysFlagName),
+		BlobPrivateKey:      ctx.GlobalString(BlobSenderKeyFlagName),
+		MinBalance:          minBalance,
+		HealthCheckInterval: ctx.GlobalDuration(SenderHealthCheckFlagName),
+	}
+}
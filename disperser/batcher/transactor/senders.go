@@ -0,0 +1,202 @@
+package transactor
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/0glabs/0g-da-client/disperser/contract"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/pkg/errors"
+)
+
+// SenderPoolConfig adds accounts the batches are uploaded and confirmed from next to the
+// disperser account, each with its own nonces, so that parallel batch pipelines don't wait
+// for each other's transactions.
+type SenderPoolConfig struct {
+	// PrivateKeys are the keys of the additional sender accounts, disabled if empty
+	PrivateKeys []string
+	// BlobPrivateKey is the key of the account the blob transactions are sent from, see
+	// SetBlobSender. Blob transactions can't be sent if empty.
+	BlobPrivateKey string
+	// MinBalance is the balance in wei below which a sender is reported low and only used
+	// while no other sender has enough
+	MinBalance *big.Int
+	// HealthCheckInterval is how often the balances of the senders are checked
+	HealthCheckInterval time.Duration
+}
+
+func (c SenderPoolConfig) Enabled() bool {
+	return len(c.PrivateKeys) > 0
+}
+
+func (c SenderPoolConfig) validate() error {
+	if c.HealthCheckInterval <= 0 {
+		return errors.New("the sender health check interval must be positive")
+	}
+	if c.MinBalance != nil && c.MinBalance.Sign() < 0 {
+		return errors.New("the sender min balance must not be negative")
+	}
+	return nil
+}
+
+// SenderMetrics records the transactions and the balances of the sender accounts
+type SenderMetrics interface {
+	IncrementSenderTxs(account string, success bool)
+	// UpdateSenderBalance records the balance of an account in native tokens
+	UpdateSenderBalance(account string, balance float64, low bool)
+}
+
+// sender is an account transactions are sent from, one at a time
+type sender struct {
+	// contract sends the transactions of the account, nil for the disperser account whose
+	// contract is passed to each call
+	contract *contract.DAContract
+	account  eth_common.Address
+	// lock holds a token while a transaction is being sent
+	lock chan struct{}
+	// low is set while the balance is below the min balance
+	low atomic.Bool
+}
+
+// errNoBlobSender is returned for a blob transaction sent without a blob sender account
+var errNoBlobSender = errors.New("no blob sender account to send blob transactions from")
+
+// SetSenderPool makes the batch uploads, commit root submissions and inbox posts rotate
+// across daContract, the disperser account, and the contracts of the additional senders.
+// Blob transactions are sent from the blob sender account, see SetBlobSender.
+func (t *Transactor) SetSenderPool(config SenderPoolConfig, daContract *contract.DAContract, senders []*contract.DAContract, metrics SenderMetrics) error {
+	if err := config.validate(); err != nil {
+		return err
+	}
+	t.senders[0].contract = daContract
+	t.senders[0].account = daContract.Account()
+	for _, c := range senders {
+		t.senders = append(t.senders, &sender{
+			contract: c,
+			account:  c.Account(),
+			lock:     make(chan struct{}, 1),
+		})
+	}
+	daContract.AddSenders(senders...)
+	t.poolConfig = config
+	t.metrics = metrics
+	return nil
+}
+
+// SetBlobSender makes the blob transactions be sent from blobContract, whose account must
+// not send any other transaction: nodes don't accept a blob transaction from an account
+// with pending transactions of another type, nor the other way round, so a blob
+// transaction left pending would hold the transactions of a shared account back.
+// daContract is the contract of the disperser account, which tracks the replacements of
+// the blob transactions.
+func (t *Transactor) SetBlobSender(daContract *contract.DAContract, blobContract *contract.DAContract, metrics SenderMetrics) {
+	if blobContract.Account() == daContract.Account() {
+		t.logger.Warn("[transactor] the blob sender account is the disperser account, blob transactions may hold the others back")
+	}
+	t.senders[0].contract = daContract
+	t.senders[0].account = daContract.Account()
+	t.blobSender = &sender{
+		contract: blobContract,
+		account:  blobContract.Account(),
+		lock:     make(chan struct{}, 1),
+	}
+	daContract.AddSenders(blobContract)
+	if t.metrics == nil {
+		t.metrics = metrics
+	}
+}
+
+// StartHealthChecks checks the balances of the senders every health check interval until
+// ctx is done
+func (t *Transactor) StartHealthChecks(ctx context.Context) {
+	if len(t.senders) < 2 {
+		return
+	}
+	t.checkBalances()
+	go func() {
+		ticker := time.NewTicker(t.poolConfig.HealthCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				t.checkBalances()
+			}
+		}
+	}()
+}
+
+func (t *Transactor) checkBalances() {
+	senders := t.senders
+	if t.blobSender != nil {
+		senders = append(senders[:len(senders):len(senders)], t.blobSender)
+	}
+	for _, s := range senders {
+		balance, err := s.contract.Balance()
+		if err != nil {
+			t.logger.Warn("[transactor] failed to check sender balance", "account", s.account, "err", err)
+			continue
+		}
+		low := t.poolConfig.MinBalance != nil && balance.Cmp(t.poolConfig.MinBalance) < 0
+		if low && !s.low.Load() {
+			t.logger.Error("[transactor] sender balance is below the min balance, using it only while no other sender has enough", "account", s.account, "balance", balance, "minBalance", t.poolConfig.MinBalance)
+		} else if !low && s.low.Load() {
+			t.logger.Info("[transactor] sender balance is back above the min balance", "account", s.account, "balance", balance)
+		}
+		s.low.Store(low)
+		if t.metrics != nil {
+			tokens, _ := new(big.Float).Quo(new(big.Float).SetInt(balance), big.NewFloat(params.Ether)).Float64()
+			t.metrics.UpdateSenderBalance(s.account.Hex(), tokens, low)
+		}
+	}
+}
+
+// acquireAny waits for one of the senders to be free, preferring those with enough balance
+func (t *Transactor) acquireAny(ctx context.Context) (*sender, error) {
+	if len(t.senders) == 1 {
+		return t.senders[0], t.acquire(ctx, t.senders[0])
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithMessage(err, "Waiting for the account")
+	}
+	candidates := make([]*sender, 0, len(t.senders))
+	for _, s := range t.senders {
+		if !s.low.Load() {
+			candidates = append(candidates, s)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = t.senders
+	}
+
+	cases := make([]reflect.SelectCase, 0, len(candidates)+1)
+	for _, s := range candidates {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(s.lock), Send: reflect.ValueOf(struct{}{})})
+	}
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())})
+	chosen, _, _ := reflect.Select(cases)
+	if chosen == len(candidates) {
+		return nil, errors.WithMessage(ctx.Err(), "Waiting for the account")
+	}
+	return candidates[chosen], nil
+}
+
+// contractOf returns the contract sending the transactions of s
+func (s *sender) contractOf(daContract *contract.DAContract) *contract.DAContract {
+	if s.contract == nil {
+		return daContract
+	}
+	return s.contract
+}
+
+func (t *Transactor) recordTx(s *sender, daContract *contract.DAContract, err error) {
+	if t.metrics == nil {
+		return
+	}
+	t.metrics.IncrementSenderTxs(s.contractOf(daContract).Account().Hex(), err == nil)
+}
@@ -0,0 +1,59 @@
+package transactor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAcquireAnySender(t *testing.T) {
+	tr := NewTransactor(0, mock.NewLogger(false))
+	tr.senders = append(tr.senders, &sender{lock: make(chan struct{}, 1)})
+
+	// both senders send in parallel, a third transaction waits
+	first, err := tr.acquireAny(context.Background())
+	assert.NoError(t, err)
+	second, err := tr.acquireAny(context.Background())
+	assert.NoError(t, err)
+	assert.NotSame(t, first, second)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	_, err = tr.acquireAny(ctx)
+	cancel()
+	assert.Error(t, err)
+	tr.release(first)
+	tr.release(second)
+
+	// a sender low on balance is left alone while another one has enough
+	tr.senders[0].low.Store(true)
+	for i := 0; i < 10; i++ {
+		s, err := tr.acquireAny(context.Background())
+		assert.NoError(t, err)
+		assert.Same(t, tr.senders[1], s)
+		tr.release(s)
+	}
+	held, _ := tr.acquireAny(context.Background())
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	_, err = tr.acquireAny(ctx)
+	cancel()
+	assert.Error(t, err)
+	tr.release(held)
+
+	// unless they are all low
+	tr.senders[1].low.Store(true)
+	s, err := tr.acquireAny(context.Background())
+	assert.NoError(t, err)
+	tr.release(s)
+
+	// inbox posts stay on the disperser account
+	assert.NoError(t, tr.acquire(context.Background(), tr.senders[0]))
+	tr.release(tr.senders[0])
+}
+
+func TestBlobTxsNeedBlobSender(t *testing.T) {
+	tr := NewTransactor(0, mock.NewLogger(false))
+	_, err := tr.PostToInboxBlob(context.Background(), nil, nil, nil, 0)
+	assert.ErrorIs(t, err, errNoBlobSender)
+}
@@ -1,48 +1,79 @@
 package transactor
 
 import (
-	"sync"
+	"context"
 	"time"
 
 	"github.com/0glabs/0g-da-client/common"
 	"github.com/0glabs/0g-da-client/disperser/contract"
 	"github.com/0glabs/0g-da-client/disperser/contract/da_entrance"
 	eth_common "github.com/ethereum/go-ethereum/common"
+	gethTypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/openweb3/web3go/types"
 	"github.com/pkg/errors"
 )
 
+// Transactor serializes the transactions of each account of the disperser so that they
+// don't race for nonces. The context of a call bounds the wait for an account; once a
+// transaction is being sent it is never abandoned, since it could still land on chain.
 type Transactor struct {
-	mu sync.Mutex
+	// senders are the accounts transactions are sent from, the disperser account first,
+	// see SetSenderPool
+	senders    []*sender
+	poolConfig SenderPoolConfig
+	// blobSender sends the blob transactions, nil if blob transactions can't be sent, see
+	// SetBlobSender
+	blobSender *sender
 
 	gasLimit uint64
+	metrics  SenderMetrics
 	logger   common.Logger
 }
 
 func NewTransactor(gasLimit uint64, logger common.Logger) *Transactor {
 	return &Transactor{
+		senders:  []*sender{{lock: make(chan struct{}, 1)}},
 		gasLimit: gasLimit,
 		logger:   logger,
 	}
 }
 
-func (t *Transactor) SubmitLogEntry(daContract *contract.DAContract, dataRoots []eth_common.Hash) (eth_common.Hash, error) {
-	t.mu.Lock()
-	defer t.mu.Unlock()
+func (t *Transactor) acquire(ctx context.Context, s *sender) error {
+	if err := ctx.Err(); err != nil {
+		return errors.WithMessage(err, "Waiting for the account")
+	}
+	select {
+	case s.lock <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return errors.WithMessage(ctx.Err(), "Waiting for the account")
+	}
+}
+
+func (t *Transactor) release(s *sender) {
+	<-s.lock
+}
+
+func (t *Transactor) SubmitLogEntry(ctx context.Context, daContract *contract.DAContract, dataRoots []eth_common.Hash) (eth_common.Hash, error) {
+	s, err := t.acquireAny(ctx)
+	if err != nil {
+		return eth_common.Hash{}, err
+	}
+	defer t.release(s)
 
 	// Append log on blockchain
-	var txHash eth_common.Hash
-	var err error
-	if txHash, _, err = daContract.SubmitOriginalData(dataRoots, false); err != nil {
+	txHash, _, err := s.contractOf(daContract).SubmitOriginalData(ctx, dataRoots, false)
+	t.recordTx(s, daContract, err)
+	if err != nil {
 		return eth_common.Hash{}, errors.WithMessage(err, "Failed to submit log entry")
 	}
 	return txHash, nil
 }
 
-func (t *Transactor) BatchUpload(daContract *contract.DAContract, dataRoots []eth_common.Hash) (eth_common.Hash, error) {
+func (t *Transactor) BatchUpload(ctx context.Context, daContract *contract.DAContract, dataRoots []eth_common.Hash) (eth_common.Hash, error) {
 	stageTimer := time.Now()
 
-	txHash, err := t.SubmitLogEntry(daContract, dataRoots)
+	txHash, err := t.SubmitLogEntry(ctx, daContract, dataRoots)
 	if err != nil {
 		return eth_common.Hash{}, err
 	}
@@ -52,18 +83,21 @@ func (t *Transactor) BatchUpload(daContract *contract.DAContract, dataRoots []et
 	return txHash, nil
 }
 
-func (t *Transactor) SubmitVerifiedCommitRoots(daContract *contract.DAContract, submissions []da_entrance.IDAEntranceCommitRootSubmission) (eth_common.Hash, error) {
+func (t *Transactor) SubmitVerifiedCommitRoots(ctx context.Context, daContract *contract.DAContract, submissions []da_entrance.IDAEntranceCommitRootSubmission) (eth_common.Hash, error) {
 	stageTimer := time.Now()
 
-	t.mu.Lock()
-	defer t.mu.Unlock()
+	s, err := t.acquireAny(ctx)
+	if err != nil {
+		return eth_common.Hash{}, err
+	}
+	defer t.release(s)
+	daContract = s.contractOf(daContract)
 
 	var tx *types.Transaction
-	var err error
 
 	var gasLimit uint64
 	if t.gasLimit == 0 {
-		if tx, _, err = daContract.SubmitVerifiedCommitRoots(submissions, 0, false, true); err != nil {
+		if tx, _, err = daContract.SubmitVerifiedCommitRoots(ctx, submissions, 0, false, true); err != nil {
 			return eth_common.Hash{}, errors.WithMessage(err, "Failed to estimate SubmitVerifiedCommitRoots")
 		}
 
@@ -73,7 +107,9 @@ func (t *Transactor) SubmitVerifiedCommitRoots(daContract *contract.DAContract,
 		gasLimit = t.gasLimit
 	}
 
-	if tx, _, err = daContract.SubmitVerifiedCommitRoots(submissions, gasLimit, false, false); err != nil {
+	tx, _, err = daContract.SubmitVerifiedCommitRoots(ctx, submissions, gasLimit, false, false)
+	t.recordTx(s, daContract, err)
+	if err != nil {
 		return eth_common.Hash{}, errors.WithMessage(err, "Failed to submit verified commit roots")
 	}
 
@@ -81,3 +117,73 @@ func (t *Transactor) SubmitVerifiedCommitRoots(daContract *contract.DAContract,
 
 	return tx.Hash(), nil
 }
+
+// SubmitVerifiedCommitRootsBlob submits the aggregate signatures in a blob transaction
+// carrying sidecar, calling the blob method of the DA entrance with args from the blob
+// sender account. The gas limit is that of SubmitVerifiedCommitRoots.
+func (t *Transactor) SubmitVerifiedCommitRootsBlob(ctx context.Context, method *contract.Inbox, sidecar *gethTypes.BlobTxSidecar, args []interface{}) (eth_common.Hash, error) {
+	txHash, err := t.PostToInboxBlob(ctx, method, sidecar, args, t.gasLimit)
+	if err != nil {
+		return eth_common.Hash{}, errors.WithMessage(err, "Failed to submit verified commit roots in a blob")
+	}
+	return txHash, nil
+}
+
+// PostToInbox sends an inbox transaction from one of the sender accounts, serialized with
+// the other transactions of the account so that they don't race for nonces. The gas is
+// estimated if gasLimit is 0.
+func (t *Transactor) PostToInbox(ctx context.Context, inbox *contract.Inbox, args []interface{}, gasLimit uint64) (eth_common.Hash, error) {
+	s, err := t.acquireAny(ctx)
+	if err != nil {
+		return eth_common.Hash{}, err
+	}
+	defer t.release(s)
+	if s.contract != nil {
+		inbox = inbox.From(s.contract)
+	}
+
+	if gasLimit == 0 {
+		tx, err := inbox.Post(ctx, args, 0, true)
+		if err != nil {
+			return eth_common.Hash{}, errors.WithMessage(err, "Failed to estimate inbox transaction")
+		}
+		gasLimit = tx.Gas()
+	}
+
+	tx, err := inbox.Post(ctx, args, gasLimit, false)
+	t.recordTx(s, nil, err)
+	if err != nil {
+		return eth_common.Hash{}, errors.WithMessage(err, "Failed to post to inbox")
+	}
+	return tx.Hash(), nil
+}
+
+// PostToInboxBlob is PostToInbox sending a blob transaction carrying sidecar from the blob
+// sender account, as nodes don't accept blob transactions from an account with pending
+// transactions of another type, nor the other way round
+func (t *Transactor) PostToInboxBlob(ctx context.Context, inbox *contract.Inbox, sidecar *gethTypes.BlobTxSidecar, args []interface{}, gasLimit uint64) (eth_common.Hash, error) {
+	s := t.blobSender
+	if s == nil {
+		return eth_common.Hash{}, errNoBlobSender
+	}
+	if err := t.acquire(ctx, s); err != nil {
+		return eth_common.Hash{}, err
+	}
+	defer t.release(s)
+	inbox = inbox.From(s.contract)
+
+	if gasLimit == 0 {
+		tx, err := inbox.PostBlob(ctx, sidecar, args, 0, true)
+		if err != nil {
+			return eth_common.Hash{}, errors.WithMessage(err, "Failed to estimate inbox blob transaction")
+		}
+		gasLimit = tx.Gas()
+	}
+
+	tx, err := inbox.PostBlob(ctx, sidecar, args, gasLimit, false)
+	t.recordTx(s, nil, err)
+	if err != nil {
+		return eth_common.Hash{}, errors.WithMessage(err, "Failed to post blob to inbox")
+	}
+	return tx.Hash(), nil
+}
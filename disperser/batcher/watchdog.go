@@ -0,0 +1,295 @@
+package batcher
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/disperser"
+	eth_common "github.com/ethereum/go-ethereum/common"
+)
+
+// watchdogQueueSize is the number of confirmed batches waiting to be checked, the batches
+// confirmed while it is full are not checked
+const watchdogQueueSize = 64
+
+// WatchdogConfig checks, once a batch is confirmed, that a sample of the operators who
+// signed for its blobs serve the slices their signatures attested to.
+type WatchdogConfig struct {
+	// SampleSize is the number of signers of each confirmed blob asked for one of their
+	// slices, the watchdog is disabled if 0
+	SampleSize int
+	// ExclusionPeriod is how long the operators found not serving a slice they signed for
+	// are left out of the dispersals, as long as the others hold enough slices for the
+	// batches to be signed. They are only flagged if 0.
+	ExclusionPeriod time.Duration
+}
+
+func (c WatchdogConfig) Enabled() bool {
+	return c.SampleSize > 0
+}
+
+func (c WatchdogConfig) validate() error {
+	if c.SampleSize < 0 {
+		return errors.New("the watchdog sample size must not be negative")
+	}
+	if c.ExclusionPeriod < 0 {
+		return errors.New("the watchdog exclusion period must not be negative")
+	}
+	return nil
+}
+
+// WatchdogResult is the outcome of asking a signer for a slice it attested to
+type WatchdogResult string
+
+const (
+	WatchdogServed WatchdogResult = "served"
+	// WatchdogMissing and WatchdogMismatch are discrepancies: the signer doesn't hold the
+	// slice, or serves other data
+	WatchdogMissing  WatchdogResult = "missing"
+	WatchdogMismatch WatchdogResult = "mismatch"
+	// WatchdogUnreachable is not a discrepancy, the signer may be down for a while
+	WatchdogUnreachable WatchdogResult = "unreachable"
+	// WatchdogUnsupported is not a discrepancy, the signer doesn't serve its slices
+	WatchdogUnsupported WatchdogResult = "unsupported"
+)
+
+// SliceDiscrepancy is a slice a signer attested to but doesn't serve as signed
+type SliceDiscrepancy struct {
+	BlobKey         disperser.BlobKey
+	BatchHeaderHash [32]byte
+	Operator        eth_common.Address
+	Socket          string
+	Epoch           uint64
+	QuorumID        uint64
+	StorageRoot     []byte
+	SliceIndex      int
+	Result          WatchdogResult
+}
+
+// SliceChallenger challenges the discrepancies found by the watchdog, for instance on chain
+type SliceChallenger interface {
+	Challenge(ctx context.Context, discrepancy SliceDiscrepancy) error
+}
+
+type sliceCheck struct {
+	discrepancy SliceDiscrepancy
+	expected    []byte
+}
+
+// Watchdog asks a sample of the signers of the confirmed blobs for their slices in the
+// background, and flags the discrepancies in the log and the metrics
+type Watchdog struct {
+	config WatchdogConfig
+	reader disperser.SliceReader
+	// Challenger challenges the discrepancies, which are only flagged if nil
+	Challenger SliceChallenger
+	timeouts   TimeoutConfig
+	metrics    *Metrics
+	logger     common.Logger
+
+	queue chan []sliceCheck
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func NewWatchdog(config WatchdogConfig, reader disperser.SliceReader, timeouts TimeoutConfig, metrics *Metrics, logger common.Logger) *Watchdog {
+	return &Watchdog{
+		config:   config,
+		reader:   reader,
+		timeouts: timeouts,
+		metrics:  metrics,
+		logger:   logger,
+		queue:    make(chan []sliceCheck, watchdogQueueSize),
+		rand:     rand.New(rand.NewSource(rand.Int63())),
+	}
+}
+
+func (w *Watchdog) Start(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case checks := <-w.queue:
+				for _, check := range checks {
+					w.check(ctx, check)
+				}
+			}
+		}
+	}()
+}
+
+// watch samples the signers of the blobs at blobIndexes of the batch at idx, which were
+// just confirmed, and queues their checks
+func (w *Watchdog) watch(batchInfo *BatchInfo, idx int, blobIndexes []int) {
+	if idx >= len(batchInfo.attesters) {
+		return
+	}
+	batch := batchInfo.batch[idx]
+	checks := make([]sliceCheck, 0, len(blobIndexes)*w.config.SampleSize)
+	for _, blobIndex := range blobIndexes {
+		blob := batch.EncodedBlobs[blobIndex]
+		for _, signer := range w.sample(batchInfo.attesters[idx][blobIndex]) {
+			sliceIndex := signer.sliceIndexes[w.intn(len(signer.sliceIndexes))]
+			checks = append(checks, sliceCheck{
+				discrepancy: SliceDiscrepancy{
+					BlobKey:         batch.BlobMetadata[blobIndex].GetBlobKey(),
+					BatchHeaderHash: batchInfo.headerHash[idx],
+					Operator:        signer.Signer,
+					Socket:          signer.Socket,
+					Epoch:           batchInfo.epochs[idx].Uint64(),
+					QuorumID:        batchInfo.quorumIds[idx].Uint64(),
+					StorageRoot:     blob.StorageRoot,
+					SliceIndex:      sliceIndex,
+				},
+				expected: blob.EncodedSlice[sliceIndex],
+			})
+		}
+	}
+	if len(checks) == 0 {
+		return
+	}
+	select {
+	case w.queue <- checks:
+	default:
+		w.logger.Warn("[watchdog] check queue full, confirmed batch not checked", "batch ID", batchInfo.ts[idx])
+	}
+}
+
+// sample picks up to SampleSize of the signers holding slices
+func (w *Watchdog) sample(signers []*SignerState) []*SignerState {
+	holders := make([]*SignerState, 0, len(signers))
+	for _, signer := range signers {
+		if len(signer.sliceIndexes) > 0 {
+			holders = append(holders, signer)
+		}
+	}
+	w.mu.Lock()
+	w.rand.Shuffle(len(holders), func(i, j int) { holders[i], holders[j] = holders[j], holders[i] })
+	w.mu.Unlock()
+	return holders[:min(len(holders), w.config.SampleSize)]
+}
+
+func (w *Watchdog) intn(n int) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.rand.Intn(n)
+}
+
+func (w *Watchdog) check(ctx context.Context, check sliceCheck) WatchdogResult {
+	d := check.discrepancy
+	readCtx, cancel := w.timeouts.WithTimeout(ctx, CallOperatorRPC)
+	slice, err := w.reader.ReadSlice(readCtx, d.Socket, d.Epoch, d.QuorumID, d.StorageRoot, d.SliceIndex)
+	cancel()
+	switch {
+	case errors.Is(err, disperser.ErrSliceNotFound):
+		d.Result = WatchdogMissing
+	case errors.Is(err, disperser.ErrSliceReadsUnsupported):
+		d.Result = WatchdogUnsupported
+	case err != nil:
+		d.Result = WatchdogUnreachable
+		w.logger.Debug("[watchdog] failed to read slice from operator", "operator", d.Operator, "socket", d.Socket, "err", err)
+	case !bytes.Equal(slice, check.expected):
+		d.Result = WatchdogMismatch
+	default:
+		d.Result = WatchdogServed
+	}
+	w.metrics.IncrementWatchdogChecks(d.Result)
+	if d.Result != WatchdogMissing && d.Result != WatchdogMismatch {
+		return d.Result
+	}
+
+	w.logger.Warn("[watchdog] operator doesn't serve a slice it signed for", "result", d.Result, "operator", d.Operator, "socket", d.Socket,
+		"blob key", d.BlobKey.String(), "batch header hash", eth_common.Hash(d.BatchHeaderHash).Hex(), "slice", d.SliceIndex)
+	if w.Challenger != nil {
+		if err := w.Challenger.Challenge(ctx, d); err != nil {
+			w.logger.Error("[watchdog] failed to challenge discrepancy", "operator", d.Operator, "blob key", d.BlobKey.String(), "err", err)
+			w.metrics.IncrementWatchdogChallenges(false)
+		} else {
+			w.metrics.IncrementWatchdogChallenges(true)
+		}
+	}
+	return d.Result
+}
+
+// errWatchdogExcluded is the result of the operators left out of a dispersal by the watchdog
+var errWatchdogExcluded = errors.New("operator excluded for not serving the slices it signed for")
+
+// operatorExclusions is the SliceChallenger leaving the operators out of the dispersals for a
+// period once they were found not serving a slice they signed for
+type operatorExclusions struct {
+	period time.Duration
+	now    func() time.Time
+
+	mu    sync.Mutex
+	until map[eth_common.Address]time.Time
+}
+
+func newOperatorExclusions(period time.Duration) *operatorExclusions {
+	return &operatorExclusions{
+		period: period,
+		now:    time.Now,
+		until:  make(map[eth_common.Address]time.Time),
+	}
+}
+
+func (e *operatorExclusions) Challenge(ctx context.Context, discrepancy SliceDiscrepancy) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.until[discrepancy.Operator] = e.now().Add(e.period)
+	return nil
+}
+
+// exclude returns the signers to leave out of a dispersal, on top of those already excluded,
+// keeping the slices of the others above those required of the total
+func (e *operatorExclusions) exclude(signers map[eth_common.Address]*SignerState, already map[eth_common.Address]struct{}, required func(totalSlices int) int) map[eth_common.Address]struct{} {
+	now := e.now()
+	candidates := make([]eth_common.Address, 0)
+	e.mu.Lock()
+	for addr, until := range e.until {
+		if !now.Before(until) {
+			delete(e.until, addr)
+			continue
+		}
+		if _, ok := already[addr]; ok {
+			continue
+		}
+		if _, ok := signers[addr]; ok {
+			candidates = append(candidates, addr)
+		}
+	}
+	e.mu.Unlock()
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return bytes.Compare(candidates[i][:], candidates[j][:]) < 0
+	})
+
+	totalSlices := 0
+	remaining := 0
+	for addr, state := range signers {
+		totalSlices += len(state.sliceIndexes)
+		if _, ok := already[addr]; !ok {
+			remaining += len(state.sliceIndexes)
+		}
+	}
+	minSlices := required(totalSlices)
+	excluded := make(map[eth_common.Address]struct{})
+	for _, addr := range candidates {
+		slices := len(signers[addr].sliceIndexes)
+		if remaining-slices < minSlices {
+			continue
+		}
+		remaining -= slices
+		excluded[addr] = struct{}{}
+	}
+	return excluded
+}
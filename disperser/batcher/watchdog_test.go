@@ -0,0 +1,100 @@
+package batcher
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSliceReader serves the slices held by each socket
+type fakeSliceReader map[string][]byte
+
+func (r fakeSliceReader) ReadSlice(ctx context.Context, addr string, epoch, quorumID uint64, storageRoot []byte, sliceIndex int) ([]byte, error) {
+	if addr == "legacy" {
+		return nil, disperser.ErrSliceReadsUnsupported
+	}
+	slice, ok := r[addr]
+	if !ok {
+		return nil, disperser.ErrSliceNotFound
+	}
+	return slice, nil
+}
+
+type fakeChallenger []SliceDiscrepancy
+
+func (c *fakeChallenger) Challenge(ctx context.Context, discrepancy SliceDiscrepancy) error {
+	*c = append(*c, discrepancy)
+	return nil
+}
+
+func TestWatchdog(t *testing.T) {
+	logger := mock.NewLogger(false)
+	signer := func(socket string, sliceIndex int) *SignerState {
+		return &SignerState{SignerInfo: &SignerInfo{Socket: socket}, sliceIndexes: []int{sliceIndex}}
+	}
+	batchInfo := &BatchInfo{
+		headerHash: [][32]byte{{1}},
+		batch: []*batch{{
+			EncodedBlobs: []*core.BlobCommitments{{StorageRoot: []byte{9}, EncodedSlice: [][]byte{{0}, {1}, {2}}}},
+			BlobMetadata: []*disperser.BlobMetadata{{BlobHash: "blob", MetadataHash: "m"}},
+		}},
+		ts:        []uint64{1},
+		epochs:    []*big.Int{big.NewInt(2)},
+		quorumIds: []*big.Int{big.NewInt(0)},
+		attesters: []map[int][]*SignerState{{0: {signer("honest", 0), signer("missing", 1), signer("corrupt", 2), signer("legacy", 0)}}},
+	}
+	reader := fakeSliceReader{"honest": {0}, "corrupt": {0}}
+	challenger := &fakeChallenger{}
+	// the metrics server isn't started
+	w := NewWatchdog(WatchdogConfig{SampleSize: 4}, reader, TimeoutConfig{}, NewMetrics("0", logger), logger)
+	w.Challenger = challenger
+
+	w.watch(batchInfo, 0, []int{0})
+	checks := <-w.queue
+	require.Len(t, checks, 4)
+	results := make(map[string]WatchdogResult)
+	for _, check := range checks {
+		results[check.discrepancy.Socket] = w.check(context.Background(), check)
+	}
+	assert.Equal(t, map[string]WatchdogResult{"honest": WatchdogServed, "missing": WatchdogMissing, "corrupt": WatchdogMismatch, "legacy": WatchdogUnsupported}, results)
+	require.Len(t, *challenger, 2)
+	for _, d := range *challenger {
+		assert.Equal(t, uint64(2), d.Epoch)
+		assert.Equal(t, []byte{9}, d.StorageRoot)
+	}
+
+	// batches recovered without their signers are not checked
+	w.watch(&BatchInfo{batch: batchInfo.batch}, 0, []int{0})
+	assert.Empty(t, w.queue)
+}
+
+func TestOperatorExclusions(t *testing.T) {
+	now := time.Now()
+	e := newOperatorExclusions(time.Minute)
+	e.now = func() time.Time { return now }
+	signer := func(slices int) *SignerState {
+		return &SignerState{sliceIndexes: make([]int, slices)}
+	}
+	a, b, c := eth_common.HexToAddress("0xa"), eth_common.HexToAddress("0xb"), eth_common.HexToAddress("0xc")
+	signers := map[eth_common.Address]*SignerState{a: signer(2), b: signer(2), c: signer(4)}
+	half := func(total int) int { return total / 2 }
+
+	assert.Empty(t, e.exclude(signers, nil, half))
+	require.NoError(t, e.Challenge(context.Background(), SliceDiscrepancy{Operator: a}))
+	require.NoError(t, e.Challenge(context.Background(), SliceDiscrepancy{Operator: b}))
+	assert.Equal(t, map[eth_common.Address]struct{}{a: {}, b: {}}, e.exclude(signers, nil, half))
+
+	// the operators excluded by their bandwidth count against the slices left
+	assert.Equal(t, map[eth_common.Address]struct{}{a: {}}, e.exclude(signers, map[eth_common.Address]struct{}{c: {}}, func(total int) int { return 2 }))
+
+	now = now.Add(2 * time.Minute)
+	assert.Empty(t, e.exclude(signers, nil, half))
+}
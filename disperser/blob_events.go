@@ -0,0 +1,138 @@
+package disperser
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+)
+
+// BlobEventType is a step of the lifecycle of a blob
+type BlobEventType string
+
+const (
+	// BlobReceived is recorded when the API server stores the blob, its detail is the
+	// quarantine reason of quarantined blobs
+	BlobReceived BlobEventType = "received"
+	// BlobQueued is recorded when the batcher requests the encoding of the blob
+	BlobQueued BlobEventType = "queued"
+	// BlobEncoded is recorded when the encoding of the blob is ready to be batched
+	BlobEncoded BlobEventType = "encoded"
+	// BlobBatched is recorded when the blob is put in a batch, its detail is the hex hash of
+	// the header of the batch and the index of the blob as <hash>/<index>
+	BlobBatched BlobEventType = "batched"
+	// BlobDispersed is recorded when the batch of the blob is submitted, its detail is the
+	// hash of the submission transaction
+	BlobDispersed BlobEventType = "dispersed"
+	// BlobConfirmed is recorded when the blob is confirmed, its detail is the hash of the
+	// confirmation transaction
+	BlobConfirmed BlobEventType = "confirmed"
+	// BlobFinalized is recorded when the confirmation of the blob is final
+	BlobFinalized BlobEventType = "finalized"
+	// BlobFailed is recorded when a step fails for the blob, its detail is the reason. The
+	// blob is retried unless it was dead-lettered.
+	BlobFailed BlobEventType = "failed"
+)
+
+// MaxBlobEvents bounds the history of a blob, the later events being dropped. A blob records
+// a handful of events unless it fails over and over.
+const MaxBlobEvents = 64
+
+// BlobEvent is an entry of the lifecycle history of a blob
+type BlobEvent struct {
+	Type BlobEventType `json:"type"`
+	Time time.Time     `json:"time"`
+	// Detail qualifies the event, such as the reason of a failure
+	Detail string `json:"detail,omitempty"`
+}
+
+// SortBlobEvents orders the history of a blob by time. The services of the disperser append
+// the events concurrently, so the stores return them roughly in order only.
+func SortBlobEvents(events []BlobEvent) []BlobEvent {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	return events
+}
+
+const (
+	blobEventQueueSize    = 4096
+	blobEventWriteTimeout = 5 * time.Second
+)
+
+type queuedBlobEvent struct {
+	key   BlobKey
+	event BlobEvent
+}
+
+// BlobEventLog appends the lifecycle events of the blobs to their history in the blob store.
+// The events are written in the background in the order they are recorded, so the dispersal
+// never waits on the history, and are dropped if the queue is full or fail to be written.
+// A nil log records nothing.
+type BlobEventLog struct {
+	store   BlobStore
+	logger  common.Logger
+	queue   chan queuedBlobEvent
+	dropped atomic.Uint64
+	once    sync.Once
+}
+
+func NewBlobEventLog(store BlobStore, logger common.Logger) *BlobEventLog {
+	return &BlobEventLog{
+		store:  store,
+		logger: logger,
+		queue:  make(chan queuedBlobEvent, blobEventQueueSize),
+	}
+}
+
+// Record records an event of the blob of key, timestamped now
+func (l *BlobEventLog) Record(key BlobKey, eventType BlobEventType, detail string) {
+	if l == nil {
+		return
+	}
+	select {
+	case l.queue <- queuedBlobEvent{key: key, event: BlobEvent{Type: eventType, Time: time.Now(), Detail: detail}}:
+	default:
+		l.dropped.Add(1)
+	}
+}
+
+// RecordAll records the same event for each blob of metadatas
+func (l *BlobEventLog) RecordAll(metadatas []*BlobMetadata, eventType BlobEventType, detail string) {
+	for _, metadata := range metadatas {
+		l.Record(metadata.GetBlobKey(), eventType, detail)
+	}
+}
+
+// Start writes the recorded events until ctx is done. Only the first call starts the writer,
+// so the components sharing a log may all start it.
+func (l *BlobEventLog) Start(ctx context.Context) {
+	if l == nil {
+		return
+	}
+	l.once.Do(func() {
+		go l.run(ctx)
+	})
+}
+
+func (l *BlobEventLog) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case queued := <-l.queue:
+			writeCtx, cancel := context.WithTimeout(ctx, blobEventWriteTimeout)
+			err := l.store.AppendBlobEvent(writeCtx, queued.key, queued.event)
+			cancel()
+			if err != nil {
+				l.logger.Debug("failed to record blob event", "key", queued.key.String(), "event", queued.event.Type, "err", err)
+			}
+			if dropped := l.dropped.Swap(0); dropped > 0 {
+				l.logger.Warn("blob event queue full, events dropped", "dropped", dropped)
+			}
+		}
+	}
+}
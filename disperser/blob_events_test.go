@@ -0,0 +1,58 @@
+package disperser_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlobEventLog(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := memorydb.NewBlobStore(1<<30, mock.NewLogger(false))
+	key, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("blob")}, uint64(time.Now().UnixNano()))
+	require.NoError(t, err)
+
+	log := disperser.NewBlobEventLog(store, mock.NewLogger(false))
+	log.Start(ctx)
+	log.Record(key, disperser.BlobReceived, "")
+	// the events of blobs no longer stored are dropped
+	log.Record(disperser.BlobKey{BlobHash: "removed", MetadataHash: "removed"}, disperser.BlobQueued, "")
+	log.Record(key, disperser.BlobFailed, "encoding: timeout")
+
+	var events []disperser.BlobEvent
+	require.Eventually(t, func() bool {
+		events, err = store.GetBlobEvents(ctx, key)
+		return err == nil && len(events) == 2
+	}, time.Second, 10*time.Millisecond)
+	events = disperser.SortBlobEvents(events)
+	assert.Equal(t, disperser.BlobReceived, events[0].Type)
+	assert.Equal(t, disperser.BlobFailed, events[1].Type)
+	assert.Equal(t, "encoding: timeout", events[1].Detail)
+
+	var disabled *disperser.BlobEventLog
+	disabled.Record(key, disperser.BlobQueued, "")
+}
+
+func TestBlobHistoryBounded(t *testing.T) {
+	ctx := context.Background()
+	store := memorydb.NewBlobStore(1<<30, mock.NewLogger(false))
+	key, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("blob")}, uint64(time.Now().UnixNano()))
+	require.NoError(t, err)
+
+	for i := 0; i < disperser.MaxBlobEvents; i++ {
+		require.NoError(t, store.AppendBlobEvent(ctx, key, disperser.BlobEvent{Type: disperser.BlobFailed, Time: time.Now()}))
+	}
+	err = store.AppendBlobEvent(ctx, key, disperser.BlobEvent{Type: disperser.BlobFailed, Time: time.Now()})
+	assert.ErrorIs(t, err, disperser.ErrBlobHistoryFull)
+	events, err := store.GetBlobEvents(ctx, key)
+	require.NoError(t, err)
+	assert.Len(t, events, disperser.MaxBlobEvents)
+}
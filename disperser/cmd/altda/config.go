@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/0glabs/0g-da-client/common/aws"
+	"github.com/0glabs/0g-da-client/common/lifecycle"
+	"github.com/0glabs/0g-da-client/common/logging"
+	"github.com/0glabs/0g-da-client/common/tlsconfig"
+	"github.com/0glabs/0g-da-client/disperser/cmd/altda/flags"
+	"github.com/0glabs/0g-da-client/rollup"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/urfave/cli"
+)
+
+type Config struct {
+	ServerConfig    rollup.OPServerConfig
+	DASConfig       rollup.DASServerConfig
+	AdapterConfig   rollup.Config
+	LoggerConfig    logging.Config
+	LifecycleConfig lifecycle.Config
+	AwsClientConfig aws.ClientConfig
+}
+
+func NewConfig(ctx *cli.Context) (Config, error) {
+	var batchPosters []eth_common.Address
+	for _, account := range ctx.GlobalStringSlice(flags.DASBatchPostersFlag.Name) {
+		if !eth_common.IsHexAddress(account) {
+			return Config{}, fmt.Errorf("invalid das batch poster address %q", account)
+		}
+		batchPosters = append(batchPosters, eth_common.HexToAddress(account))
+	}
+
+	return Config{
+		ServerConfig: rollup.OPServerConfig{
+			HTTPPort:   ctx.GlobalString(flags.HTTPPortFlag.Name),
+			DALayer:    byte(ctx.GlobalUint(flags.DALayerFlag.Name)),
+			PutTimeout: ctx.GlobalDuration(flags.PutTimeoutFlag.Name),
+			GetTimeout: ctx.GlobalDuration(flags.GetTimeoutFlag.Name),
+		},
+		DASConfig: rollup.DASServerConfig{
+			HTTPPort:        ctx.GlobalString(flags.DASHTTPPortFlag.Name),
+			IndexPath:       ctx.GlobalString(flags.DASIndexPathFlag.Name),
+			IndexBucket:     ctx.GlobalString(flags.DASIndexBucketFlag.Name),
+			IndexPrefix:     ctx.GlobalString(flags.DASIndexPrefixFlag.Name),
+			RetentionPeriod: ctx.GlobalDuration(flags.DASRetentionPeriodFlag.Name),
+			PutTimeout:      ctx.GlobalDuration(flags.PutTimeoutFlag.Name),
+			GetTimeout:      ctx.GlobalDuration(flags.GetTimeoutFlag.Name),
+			BLSKeyFile:      ctx.GlobalString(flags.DASBLSKeyFileFlag.Name),
+			BatchPosters:    batchPosters,
+		},
+		AdapterConfig: rollup.Config{
+			DisperserAddr:       ctx.GlobalString(flags.DisperserAddrFlag.Name),
+			RetrieverAddrs:      ctx.GlobalStringSlice(flags.RetrieverAddrsFlag.Name),
+			StatusPollInterval:  ctx.GlobalDuration(flags.StatusPollIntervalFlag.Name),
+			WaitForFinalization: ctx.GlobalBool(flags.WaitForFinalizationFlag.Name),
+			EncoderAddr:         ctx.GlobalString(flags.EncoderAddrFlag.Name),
+			EncoderTimeout:      ctx.GlobalDuration(flags.EncoderTimeoutFlag.Name),
+			TLS:                 tlsconfig.ReadClientCLIConfig(ctx, flags.FlagPrefix),
+		},
+		LoggerConfig:    logging.ReadCLIConfig(ctx, flags.FlagPrefix),
+		LifecycleConfig: lifecycle.ReadCLIConfig(ctx, flags.FlagPrefix),
+		AwsClientConfig: aws.ReadClientConfig(ctx, flags.FlagPrefix),
+	}, nil
+}
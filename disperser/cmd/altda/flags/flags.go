@@ -0,0 +1,165 @@
+package flags
+
+import (
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/aws"
+	"github.com/0glabs/0g-da-client/common/lifecycle"
+	"github.com/0glabs/0g-da-client/common/logging"
+	"github.com/0glabs/0g-da-client/common/tlsconfig"
+	"github.com/urfave/cli"
+)
+
+const (
+	FlagPrefix   = "altda"
+	EnvVarPrefix = "ALTDA"
+)
+
+var (
+	/* Required Flags */
+	DisperserAddrFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "disperser-address"),
+		Usage:    "address of the disperser the data is put to, and retrieved from without retrievers",
+		Required: true,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "DISPERSER_ADDRESS"),
+	}
+	EncoderAddrFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "encoder-address"),
+		Usage:    "address of the encoder the retrieved data is encoded with to check it against the storage root of its commitment",
+		Required: true,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "ENCODER_ADDRESS"),
+	}
+	/* Optional Flags*/
+	HTTPPortFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "http-port"),
+		Usage:    "Port at which the server listens for the alt-da requests of the op batcher and nodes, disabled if empty",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "HTTP_PORT"),
+	}
+	DASHTTPPortFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "das-http-port"),
+		Usage:    "Port at which the arbitrum nitro das rpc and rest interfaces are served, disabled if empty",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "DAS_HTTP_PORT"),
+	}
+	DASIndexPathFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "das-index-path"),
+		Usage:    "path of the database of the commitments by data hash of the das server",
+		Required: false,
+		Value:    "./das-index",
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "DAS_INDEX_PATH"),
+	}
+	DASIndexBucketFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "das-index-bucket"),
+		Usage:    "s3 bucket the das index is backed up to and restored from, so that the certificates outlive the index database. Not backed up if empty",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "DAS_INDEX_BUCKET"),
+	}
+	DASIndexPrefixFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "das-index-prefix"),
+		Usage:    "key prefix of the das index backup in its bucket",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "DAS_INDEX_PREFIX"),
+	}
+	DASRetentionPeriodFlag = cli.DurationFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "das-retention-period"),
+		Usage:    "maximum period from now of the timeouts of the das stores, at most the period the blobs are kept for. No bound if 0",
+		Required: false,
+		Value:    15 * 24 * time.Hour,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "DAS_RETENTION_PERIOD"),
+	}
+	DASBLSKeyFileFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "das-bls-key-file"),
+		Usage:    "file of the base64 bls private key the das certificates are signed with, as generated by the nitro datool keygen",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "DAS_BLS_KEY_FILE"),
+	}
+	DASBatchPostersFlag = cli.StringSliceFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "das-batch-poster"),
+		Usage:    "hex address of a batch poster allowed to store data through the das server, can be repeated",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "DAS_BATCH_POSTER"),
+	}
+	RetrieverAddrsFlag = cli.StringSliceFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "retriever-address"),
+		Usage:    "address of a retriever, can be repeated. Retrievers are tried in the given order before the disperser",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "RETRIEVER_ADDRESS"),
+	}
+	DALayerFlag = cli.UintFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "da-layer"),
+		Usage:    "DA layer byte of the generic commitments returned to the op batcher",
+		Required: false,
+		Value:    0,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "DA_LAYER"),
+	}
+	StatusPollIntervalFlag = cli.DurationFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "status-poll-interval"),
+		Usage:    "interval at which the status of a dispersed blob is polled",
+		Required: false,
+		Value:    5 * time.Second,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "STATUS_POLL_INTERVAL"),
+	}
+	WaitForFinalizationFlag = cli.BoolFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "wait-for-finalization"),
+		Usage:    "return the commitment of a blob once it is finalized rather than confirmed",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "WAIT_FOR_FINALIZATION"),
+	}
+	PutTimeoutFlag = cli.DurationFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "put-timeout"),
+		Usage:    "timeout for a blob to be available after it is put, no timeout if 0",
+		Required: false,
+		Value:    30 * time.Minute,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "PUT_TIMEOUT"),
+	}
+	EncoderTimeoutFlag = cli.DurationFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "encoder-timeout"),
+		Usage:    "timeout for encoding a retrieved blob",
+		Required: false,
+		Value:    time.Minute,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "ENCODER_TIMEOUT"),
+	}
+	GetTimeoutFlag = cli.DurationFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "get-timeout"),
+		Usage:    "timeout for retrieving a blob, no timeout if 0",
+		Required: false,
+		Value:    time.Minute,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "GET_TIMEOUT"),
+	}
+)
+
+var RequiredFlags = []cli.Flag{
+	DisperserAddrFlag,
+	EncoderAddrFlag,
+}
+
+var OptionalFlags = []cli.Flag{
+	HTTPPortFlag,
+	DASHTTPPortFlag,
+	DASIndexPathFlag,
+	DASIndexBucketFlag,
+	DASIndexPrefixFlag,
+	DASRetentionPeriodFlag,
+	DASBLSKeyFileFlag,
+	DASBatchPostersFlag,
+	RetrieverAddrsFlag,
+	DALayerFlag,
+	StatusPollIntervalFlag,
+	WaitForFinalizationFlag,
+	PutTimeoutFlag,
+	GetTimeoutFlag,
+	EncoderTimeoutFlag,
+}
+
+// Flags contains the list of configuration options available to the binary.
+var Flags []cli.Flag
+
+func init() {
+	Flags = append(RequiredFlags, OptionalFlags...)
+	Flags = append(Flags, logging.CLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, lifecycle.CLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, tlsconfig.ClientCLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, aws.ClientFlags(EnvVarPrefix, FlagPrefix)...)
+}
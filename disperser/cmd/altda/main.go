@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/0glabs/0g-da-client/common/aws/s3"
+	"github.com/0glabs/0g-da-client/common/lifecycle"
+	"github.com/0glabs/0g-da-client/common/logging"
+	"github.com/0glabs/0g-da-client/disperser/cmd/altda/flags"
+	"github.com/0glabs/0g-da-client/rollup"
+	"github.com/urfave/cli"
+)
+
+var (
+	// version is the version of the binary.
+	version   string
+	gitCommit string
+	gitDate   string
+)
+
+func main() {
+	app := cli.NewApp()
+	app.Flags = flags.Flags
+	app.Version = fmt.Sprintf("%s-%s-%s", version, gitCommit, gitDate)
+	app.Name = "altda"
+	app.Usage = "ZGDA Rollup DA Server"
+	app.Description = "Serves the OP Stack Alt-DA server and the Arbitrum Nitro DAS interfaces, dispersing to the disperser and retrieving from the retrievers"
+
+	app.Action = RunAltDA
+	err := app.Run(os.Args)
+	if err != nil {
+		log.Fatalf("application failed: %v", err)
+	}
+
+	select {}
+}
+
+func RunAltDA(ctx *cli.Context) error {
+	config, err := NewConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	logger, err := logging.GetLogger(config.LoggerConfig)
+	if err != nil {
+		return err
+	}
+
+	if config.ServerConfig.HTTPPort == "" && config.DASConfig.HTTPPort == "" {
+		return errors.New("either the op stack or the das http port is required")
+	}
+
+	adapter, err := rollup.NewAdapter(config.AdapterConfig, logger)
+	if err != nil {
+		return err
+	}
+	defer adapter.Close()
+
+	manager := lifecycle.NewManager(config.LifecycleConfig, logger)
+	if config.ServerConfig.HTTPPort != "" {
+		manager.Serve("op stack server", rollup.NewOPServer(config.ServerConfig, adapter, logger).Start)
+	}
+	if config.DASConfig.HTTPPort != "" {
+		var store rollup.DASIndexStore
+		if config.DASConfig.IndexBucket != "" {
+			s3Client, err := s3.NewClient(config.AwsClientConfig, logger)
+			if err != nil {
+				return err
+			}
+			store = s3Client
+		}
+		das, err := rollup.NewDASServer(config.DASConfig, adapter, store, logger)
+		if err != nil {
+			return err
+		}
+		defer das.Close()
+		manager.Serve("das server", das.Start)
+	}
+
+	return manager.Run(context.Background())
+}
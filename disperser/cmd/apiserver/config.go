@@ -1,32 +1,58 @@
 package main
 
 import (
+	"fmt"
+
 	"github.com/0glabs/0g-da-client/common/aws"
 	"github.com/0glabs/0g-da-client/common/geth"
+	"github.com/0glabs/0g-da-client/common/interceptors"
+	"github.com/0glabs/0g-da-client/common/lifecycle"
 	"github.com/0glabs/0g-da-client/common/logging"
 	"github.com/0glabs/0g-da-client/common/ratelimit"
+	"github.com/0glabs/0g-da-client/common/reload"
 	"github.com/0glabs/0g-da-client/common/storage_node"
+	"github.com/0glabs/0g-da-client/common/tlsconfig"
+	"github.com/0glabs/0g-da-client/common/tracing"
+	"github.com/0glabs/0g-da-client/core"
 	"github.com/0glabs/0g-da-client/disperser"
 	"github.com/0glabs/0g-da-client/disperser/apiserver"
 	"github.com/0glabs/0g-da-client/disperser/cmd/apiserver/flags"
 	"github.com/0glabs/0g-da-client/disperser/common/blobstore"
+	"github.com/0glabs/0g-da-client/disperser/common/pgstore"
+	"github.com/0glabs/0g-da-client/disperser/contract"
+	"github.com/0glabs/0g-da-client/disperser/payments"
+	eth_common "github.com/ethereum/go-ethereum/common"
 	"github.com/urfave/cli"
 )
 
 type Config struct {
 	AwsClientConfig   aws.ClientConfig
 	BlobstoreConfig   blobstore.Config
+	ReplicationConfig blobstore.ReplicationConfig
+	PostgresConfig    pgstore.Config
+	ExportConfig      pgstore.ExportConfig
+	LifecycleConfig   lifecycle.Config
+	ReloadConfig      reload.Config
 	ServerConfig      disperser.ServerConfig
 	LoggerConfig      logging.Config
 	MetricsConfig     disperser.MetricsConfig
 	RatelimiterConfig ratelimit.Config
 	RateConfig        apiserver.RateConfig
+	PricingConfig     apiserver.PricingConfig
+	QuarantineConfig  apiserver.QuarantineConfig
+	PaymentsConfig    payments.Config
+	DeadLetterConfig  apiserver.DeadLetterConfig
+	TracingConfig     tracing.Config
 	StorageNodeConfig storage_node.ClientConfig
 	EthClientConfig   geth.EthClientConfig
 	EnableRatelimiter bool
 	BucketTableName   string
 	BucketStoreSize   int
 	RetrieverAddr     string
+	// QuorumRPC and DASignersContract read the quorums the requested security params are
+	// checked against
+	QuorumRPC         string
+	DASignersContract string
 }
 
 func NewConfig(ctx *cli.Context) (Config, error) {
@@ -41,12 +67,102 @@ func NewConfig(ctx *cli.Context) (Config, error) {
 		return Config{}, err
 	}
 
+	pricingConfig, err := apiserver.ReadPricingConfig(ctx, flags.FlagPrefix)
+	if err != nil {
+		return Config{}, err
+	}
+
+	quotaConfig, err := apiserver.ReadQuotaConfig(ctx, flags.FlagPrefix)
+	if err != nil {
+		return Config{}, err
+	}
+
+	retrievalQuotaConfig, err := apiserver.ReadRetrievalQuotaConfig(ctx, flags.FlagPrefix)
+	if err != nil {
+		return Config{}, err
+	}
+
+	paymentsConfig, err := payments.ReadConfig(ctx, flags.FlagPrefix)
+	if err != nil {
+		return Config{}, err
+	}
+	paymentsConfig.Fees = contract.ReadFeeConfig(ctx)
+
+	deadLetterConfig, err := apiserver.ReadDeadLetterConfig(ctx, flags.FlagPrefix)
+	if err != nil {
+		return Config{}, err
+	}
+	quarantineConfig, err := apiserver.ReadQuarantineConfig(ctx, flags.FlagPrefix)
+	if err != nil {
+		return Config{}, err
+	}
+
+	tracingConfig, err := tracing.ReadCLIConfig(ctx, flags.FlagPrefix)
+	if err != nil {
+		return Config{}, err
+	}
+
+	replicaID := ctx.GlobalUint(flags.ReplicaIDFlag.Name)
+	if replicaID > disperser.MaxReplicaID {
+		return Config{}, fmt.Errorf("replica id %d exceeds %d", replicaID, disperser.MaxReplicaID)
+	}
+
+	dispersalContract := ctx.GlobalString(flags.DispersalContractFlag.Name)
+	if dispersalContract != "" && !eth_common.IsHexAddress(dispersalContract) {
+		return Config{}, fmt.Errorf("invalid dispersal contract address %q", dispersalContract)
+	}
+	dispersalDomain := core.DispersalDomain{
+		ChainID:  ctx.GlobalUint64(flags.DispersalChainIDFlag.Name),
+		Contract: eth_common.HexToAddress(dispersalContract),
+	}
+
 	config := Config{
 		AwsClientConfig: aws.ReadClientConfig(ctx, flags.FlagPrefix),
 		ServerConfig: disperser.ServerConfig{
-			GrpcPort: ctx.GlobalString(flags.GrpcPortFlag.Name),
+			GrpcPort:  ctx.GlobalString(flags.GrpcPortFlag.Name),
+			HTTPPort:  ctx.GlobalString(flags.HTTPPortFlag.Name),
+			HTTPHost:  ctx.GlobalString(flags.HTTPHostFlag.Name),
+			ReplicaID: uint16(replicaID),
+			EncoderParams: core.EncoderParams{
+				BlobCapacity: core.MaxBlobSize,
+				SliceSize:    ctx.GlobalUint(flags.EncoderSliceSizeFlag.Name),
+			},
+			Interceptors:                  interceptors.ReadCLIConfig(ctx, flags.FlagPrefix),
+			Guards:                        interceptors.ReadGuardCLIConfig(ctx, flags.FlagPrefix),
+			PriorityAccounts:              ctx.GlobalStringSlice(flags.PriorityAccountsFlag.Name),
+			RequireSignatures:             ctx.GlobalBool(flags.RequireSignaturesFlag.Name),
+			DispersalDomain:               dispersalDomain,
+			GatewayToken:                  ctx.GlobalString(flags.GatewayTokenFlag.Name),
+			StatusSubscriptionInterval:    ctx.GlobalDuration(flags.StatusSubscriptionIntervalFlag.Name),
+			MaxStatusSubscriptions:        ctx.GlobalInt(flags.MaxStatusSubscriptionsFlag.Name),
+			MaxClientStatusSubscriptions:  ctx.GlobalInt(flags.MaxClientStatusSubscriptionsFlag.Name),
+			MaxStatusSubscriptionDuration: ctx.GlobalDuration(flags.MaxStatusSubscriptionDurationFlag.Name),
+			Dedup: disperser.DedupConfig{
+				Window:     ctx.GlobalDuration(flags.DedupWindowFlag.Name),
+				MaxEntries: ctx.GlobalInt(flags.DedupMaxEntriesFlag.Name),
+			},
+			Retention: disperser.RetentionConfig{
+				PeriodBlocks:   ctx.GlobalUint64(flags.RetentionPeriodBlocksFlag.Name),
+				PeriodContract: ctx.GlobalString(flags.RetentionPeriodContractFlag.Name),
+				PeriodMethod:   ctx.GlobalString(flags.RetentionPeriodMethodFlag.Name),
+				BlockTime:      ctx.GlobalDuration(flags.RetentionBlockTimeFlag.Name),
+				RenewalWindow:  ctx.GlobalDuration(flags.RetentionRenewalWindowFlag.Name),
+			},
+			SecurityParams: disperser.SecurityParamLimits{
+				MinQuorumThreshold: uint8(ctx.GlobalUint(flags.MinQuorumThresholdFlag.Name)),
+				MinThresholdGap:    uint8(ctx.GlobalUint(flags.MinThresholdGapFlag.Name)),
+			},
+			Quotas:          quotaConfig,
+			RetrievalQuotas: retrievalQuotaConfig,
+			TLS:             tlsconfig.ReadServerCLIConfig(ctx, flags.FlagPrefix),
+			RetrieverTLS:    tlsconfig.ReadClientCLIConfig(ctx, flags.RetrieverTLSFlagPrefix),
 		},
-		EthClientConfig: geth.ReadEthClientConfig(ctx),
+		EthClientConfig:   geth.ReadEthClientConfig(ctx),
+		ReplicationConfig: blobstore.ReadReplicationConfig(ctx, flags.FlagPrefix),
+		PostgresConfig:    pgstore.ReadCLIConfig(ctx, flags.FlagPrefix),
+		ExportConfig:      pgstore.ReadExportConfig(ctx, flags.FlagPrefix),
+		LifecycleConfig:   lifecycle.ReadCLIConfig(ctx, flags.FlagPrefix),
+		ReloadConfig:      reload.ReadCLIConfig(ctx, flags.FlagPrefix),
 		BlobstoreConfig: blobstore.Config{
 			BucketName:            ctx.GlobalString(flags.S3BucketNameFlag.Name),
 			TableName:             ctx.GlobalString(flags.DynamoDBTableNameFlag.Name),
@@ -59,11 +175,21 @@ func NewConfig(ctx *cli.Context) (Config, error) {
 		},
 		RatelimiterConfig: ratelimiterConfig,
 		RateConfig:        rateConfig,
+		PricingConfig:     pricingConfig,
+		PaymentsConfig:    paymentsConfig,
+		QuarantineConfig:  quarantineConfig,
+		DeadLetterConfig:  deadLetterConfig,
+		TracingConfig:     tracingConfig,
 		EnableRatelimiter: ctx.GlobalBool(flags.EnableRatelimiter.Name),
 		BucketTableName:   ctx.GlobalString(flags.BucketTableName.Name),
 		BucketStoreSize:   ctx.GlobalInt(flags.BucketStoreSize.Name),
 		StorageNodeConfig: storage_node.ReadClientConfig(ctx, flags.FlagPrefix),
 		RetrieverAddr:     ctx.GlobalString(flags.RetrieverAddrName.Name),
+		QuorumRPC:         ctx.GlobalString(flags.QuorumRPCFlag.Name),
+		DASignersContract: ctx.GlobalString(flags.DASignersContractFlag.Name),
+	}
+	if config.ServerConfig.Retention.PeriodContract != "" && config.QuorumRPC == "" {
+		return Config{}, fmt.Errorf("--%s requires --%s", flags.RetentionPeriodContractFlag.Name, flags.QuorumRPCFlag.Name)
 	}
 	return config, nil
 }
@@ -1,16 +1,33 @@
 package flags
 
 import (
+	"time"
+
 	"github.com/0glabs/0g-da-client/common"
 	"github.com/0glabs/0g-da-client/common/aws"
+	"github.com/0glabs/0g-da-client/common/interceptors"
+	"github.com/0glabs/0g-da-client/common/lifecycle"
 	"github.com/0glabs/0g-da-client/common/logging"
 	"github.com/0glabs/0g-da-client/common/ratelimit"
+	"github.com/0glabs/0g-da-client/common/reload"
+	"github.com/0glabs/0g-da-client/common/tlsconfig"
+	"github.com/0glabs/0g-da-client/common/tracing"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser/apiserver"
+	"github.com/0glabs/0g-da-client/disperser/common/blobstore"
+	"github.com/0glabs/0g-da-client/disperser/common/pgstore"
+	"github.com/0glabs/0g-da-client/disperser/contract"
+	"github.com/0glabs/0g-da-client/disperser/payments"
 	"github.com/urfave/cli"
 )
 
 const (
 	FlagPrefix   = "disperser-server"
 	EnvVarPrefix = "DISPERSER_SERVER"
+
+	// RetrieverTLSFlagPrefix prefixes the tls flags of the retriever client
+	RetrieverTLSFlagPrefix   = FlagPrefix + ".retriever"
+	RetrieverTLSEnvVarPrefix = EnvVarPrefix + "_RETRIEVER"
 )
 
 var (
@@ -32,6 +49,57 @@ var (
 		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "GRPC_PORT"),
 	}
 	/* Optional Flags*/
+	HTTPPortFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "http-port"),
+		Usage:    "Port at which disperser serves the batch status and certificate http endpoints. Disabled if empty",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "HTTP_PORT"),
+	}
+	HTTPHostFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "http-host"),
+		Usage:    "Address the http endpoints are bound to. Set to 0.0.0.0 to serve them on all interfaces",
+		Required: false,
+		Value:    "127.0.0.1",
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "HTTP_HOST"),
+	}
+	ReplicaIDFlag = cli.UintFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "replica-id"),
+		Usage:    "ID from 1 to 1023 telling the request IDs of the API servers sharing a blob store apart, distinct per server. Random if 0",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "REPLICA_ID"),
+	}
+	EncoderSliceSizeFlag = cli.UintFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "encoder-slice-size"),
+		Usage:    "Size in bytes of the slices the encoder erasure codes a blob into, one per quorum slot, from which GET /estimate derives the encoded size of a blob",
+		Required: false,
+		Value:    core.DefaultEncoderParams.SliceSize,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "ENCODER_SLICE_SIZE"),
+	}
+	PriorityAccountsFlag = cli.StringSliceFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "priority-accounts"),
+		Usage:  "accounts, API key names or signer addresses, allowed to submit blobs above the default priority lane",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "PRIORITY_ACCOUNTS"),
+	}
+	RequireSignaturesFlag = cli.BoolFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "require-signatures"),
+		Usage:  "reject the dispersals not signed by their account",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "REQUIRE_SIGNATURES"),
+	}
+	DispersalChainIDFlag = cli.Uint64Flag{
+		Name:   common.PrefixFlag(FlagPrefix, "dispersal-chain-id"),
+		Usage:  "chain ID of the domain the dispersals are signed for, signed dispersals are rejected if unset",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "DISPERSAL_CHAIN_ID"),
+	}
+	DispersalContractFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "dispersal-contract"),
+		Usage:  "address of the DA entrance contract of the domain the dispersals are signed for",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "DISPERSAL_CONTRACT"),
+	}
+	GatewayTokenFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "gateway-token"),
+		Usage:  "token authenticating the gateways, whose clients are then limited by the address the gateway relays. Relayed addresses are ignored if empty",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "GATEWAY_TOKEN"),
+	}
 	MetricsHTTPPort = cli.StringFlag{
 		Name:     common.PrefixFlag(FlagPrefix, "metrics-http-port"),
 		Usage:    "the http port which the metrics prometheus server is listening",
@@ -68,6 +136,90 @@ var (
 		Usage:  "use metadata hash as blob key",
 		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "METADATA_HASH_AS_BLOB_KEY"),
 	}
+	StatusSubscriptionIntervalFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "status-subscription-interval"),
+		Usage:  "how often the status of the blobs watched with SubscribeBlobStatus is read",
+		Value:  time.Second,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "STATUS_SUBSCRIPTION_INTERVAL"),
+	}
+	MaxStatusSubscriptionsFlag = cli.IntFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "max-status-subscriptions"),
+		Usage:  "maximum number of concurrent SubscribeBlobStatus streams, the others are rejected with RESOURCE_EXHAUSTED",
+		Value:  1000,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "MAX_STATUS_SUBSCRIPTIONS"),
+	}
+	MaxClientStatusSubscriptionsFlag = cli.IntFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "max-client-status-subscriptions"),
+		Usage:  "maximum number of concurrent SubscribeBlobStatus streams of a client address, the others are rejected with RESOURCE_EXHAUSTED",
+		Value:  10,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "MAX_CLIENT_STATUS_SUBSCRIPTIONS"),
+	}
+	MaxStatusSubscriptionDurationFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "max-status-subscription-duration"),
+		Usage:  "how long a SubscribeBlobStatus stream is open at most before it ends with DEADLINE_EXCEEDED",
+		Value:  10 * time.Minute,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "MAX_STATUS_SUBSCRIPTION_DURATION"),
+	}
+	DedupWindowFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "dedup-window"),
+		Usage:  "how long a dispersed blob is answered with its request ID when dispersed again with the same data and security params, disabled if 0",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "DEDUP_WINDOW"),
+	}
+	DedupMaxEntriesFlag = cli.IntFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "dedup-max-entries"),
+		Usage:  "maximum number of dispersals tracked to detect duplicates",
+		Value:  100000,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "DEDUP_MAX_ENTRIES"),
+	}
+	RetentionPeriodBlocksFlag = cli.Uint64Flag{
+		Name:   common.PrefixFlag(FlagPrefix, "retention-period-blocks"),
+		Usage:  "number of blocks from their confirmation the operators keep the blobs retrievable, reported in the blob status and the batch certificates. Not reported if 0",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "RETENTION_PERIOD_BLOCKS"),
+	}
+	RetentionPeriodContractFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "retention-period-contract"),
+		Usage:  "contract the storage period of the blobs is read from with --retention-period-method on the quorum rpc, replacing --retention-period-blocks while it can be read. Not read if empty",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "RETENTION_PERIOD_CONTRACT"),
+	}
+	RetentionPeriodMethodFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "retention-period-method"),
+		Usage:  "signature of the view function of the retention period contract returning the storage period in blocks as a uint256",
+		Value:  "storagePeriodBlocks()",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "RETENTION_PERIOD_METHOD"),
+	}
+	RetentionBlockTimeFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "retention-block-time"),
+		Usage:  "block time of the confirmation chain the end time of the storage period is estimated with, not estimated if 0",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "RETENTION_BLOCK_TIME"),
+	}
+	RetentionRenewalWindowFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "retention-renewal-window"),
+		Usage:  "how long before the end of their storage period the blobs are reported due for renewal",
+		Value:  7 * 24 * time.Hour,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "RETENTION_RENEWAL_WINDOW"),
+	}
+	MinQuorumThresholdFlag = cli.UintFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "min-quorum-threshold"),
+		Usage:  "lowest quorum threshold, in percent of the slices of a quorum, clients may request for their blobs. Can't be below the 67 of the protocol",
+		Value:  67,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "MIN_QUORUM_THRESHOLD"),
+	}
+	MinThresholdGapFlag = cli.UintFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "min-threshold-gap"),
+		Usage:  "lowest gap, in percent of the slices of a quorum, between the quorum and adversary thresholds clients may request for their blobs",
+		Value:  10,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "MIN_THRESHOLD_GAP"),
+	}
+	QuorumRPCFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "quorum-rpc"),
+		Usage:  "chain rpc the quorums of the requested security params are checked against, unchecked if empty",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "QUORUM_RPC"),
+	}
+	DASignersContractFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "da-signers-contract"),
+		Usage:  "hex-encoded da-signers contract address the quorums are registered in",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "DA_SIGNERS_CONTRACT"),
+	}
 	RetrieverAddrName = cli.StringFlag{
 		Name:   common.PrefixFlag(FlagPrefix, "retriever-address"),
 		Usage:  "address of retriever",
@@ -84,12 +236,36 @@ var RequiredFlags = []cli.Flag{
 }
 
 var OptionalFlags = []cli.Flag{
+	HTTPPortFlag,
+	HTTPHostFlag,
+	ReplicaIDFlag,
+	EncoderSliceSizeFlag,
+	PriorityAccountsFlag,
+	RequireSignaturesFlag,
+	DispersalChainIDFlag,
+	DispersalContractFlag,
+	GatewayTokenFlag,
 	MetricsHTTPPort,
 	EnableMetrics,
 	EnableRatelimiter,
 	BucketStoreSize,
 	MetadataHashAsBlobKey,
 	RetrieverAddrName,
+	StatusSubscriptionIntervalFlag,
+	MaxStatusSubscriptionsFlag,
+	MaxClientStatusSubscriptionsFlag,
+	MaxStatusSubscriptionDurationFlag,
+	DedupWindowFlag,
+	DedupMaxEntriesFlag,
+	RetentionPeriodBlocksFlag,
+	RetentionPeriodContractFlag,
+	RetentionPeriodMethodFlag,
+	RetentionBlockTimeFlag,
+	RetentionRenewalWindowFlag,
+	MinQuorumThresholdFlag,
+	MinThresholdGapFlag,
+	QuorumRPCFlag,
+	DASignersContractFlag,
 }
 
 // Flags contains the list of configuration options available to the binary.
@@ -100,4 +276,20 @@ func init() {
 	Flags = append(Flags, logging.CLIFlags(EnvVarPrefix, FlagPrefix)...)
 	Flags = append(Flags, ratelimit.RatelimiterCLIFlags(EnvVarPrefix, FlagPrefix)...)
 	Flags = append(Flags, aws.ClientFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, blobstore.ReplicationCLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, pgstore.CLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, apiserver.PricingCLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, apiserver.QuarantineCLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, apiserver.DeadLetterCLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, apiserver.QuotaCLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, apiserver.RetrievalQuotaCLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, payments.CLIFlags(EnvVarPrefix, FlagPrefix)...)
+	// the fees of the settle transactions of the contract ledger
+	Flags = append(Flags, contract.FeeCLIFlags(EnvVarPrefix)...)
+	Flags = append(Flags, interceptors.CLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, lifecycle.CLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, reload.CLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, tlsconfig.ServerCLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, tlsconfig.ClientCLIFlags(RetrieverTLSEnvVarPrefix, RetrieverTLSFlagPrefix)...)
+	Flags = append(Flags, tracing.CLIFlags(EnvVarPrefix, FlagPrefix, "zgda-disperser")...)
 }
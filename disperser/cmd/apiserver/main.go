@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -9,14 +10,21 @@ import (
 	"github.com/0glabs/0g-da-client/common"
 	"github.com/0glabs/0g-da-client/disperser/apiserver"
 	"github.com/0glabs/0g-da-client/disperser/common/blobstore"
+	"github.com/0glabs/0g-da-client/disperser/common/pgstore"
+	"github.com/0glabs/0g-da-client/disperser/payments"
 
 	"github.com/0glabs/0g-da-client/common/aws/dynamodb"
 	"github.com/0glabs/0g-da-client/common/aws/s3"
+	"github.com/0glabs/0g-da-client/common/lifecycle"
 	"github.com/0glabs/0g-da-client/common/logging"
 	"github.com/0glabs/0g-da-client/common/ratelimit"
 	"github.com/0glabs/0g-da-client/common/store"
+	"github.com/0glabs/0g-da-client/common/tracing"
 	"github.com/0glabs/0g-da-client/disperser"
 	"github.com/0glabs/0g-da-client/disperser/cmd/apiserver/flags"
+	"github.com/0glabs/0g-da-client/disperser/contract/da_signers"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/urfave/cli"
 )
 
@@ -40,8 +48,6 @@ func main() {
 	if err != nil {
 		log.Fatalf("application failed: %v", err)
 	}
-
-	select {}
 }
 
 func RunDisperserServer(ctx *cli.Context) error {
@@ -55,23 +61,86 @@ func RunDisperserServer(ctx *cli.Context) error {
 		return err
 	}
 
-	var blobStore disperser.BlobStore
-	var ratelimiter common.RateLimiter
+	manager := lifecycle.NewManager(config.LifecycleConfig, logger)
 
-	s3Client, err := s3.NewClient(config.AwsClientConfig, logger)
+	// the tracer is started first so it is stopped last, flushing the spans of the others
+	tracer, err := tracing.NewTracer(config.TracingConfig, logger)
 	if err != nil {
 		return err
 	}
+	if tracer != nil {
+		manager.Add(lifecycle.Component{Name: "tracing", Start: func(ctx context.Context) error {
+			tracer.Start(ctx)
+			return nil
+		}, Stop: tracer.Stop})
+		logger.Info("Exporting traces", "endpoint", config.TracingConfig.Endpoint, "sampleRatio", config.TracingConfig.SampleRatio)
+	}
 
-	dynamoClient, err := dynamodb.NewClient(config.AwsClientConfig, logger)
-	if err != nil {
-		return err
+	var blobStore disperser.BlobStore
+	var readReplica disperser.MetadataReplica
+	var ratelimiter common.RateLimiter
+
+	if config.PostgresConfig.Enabled() {
+		if config.ReplicationConfig.Enabled() {
+			return errors.New("blob metadata replication requires the dynamodb blob store")
+		}
+		logger.Info("Creating postgres blob store")
+		pgStore, err := pgstore.Open(context.Background(), config.PostgresConfig, config.BlobstoreConfig.MetadataHashAsBlobKey, logger)
+		if err != nil {
+			return err
+		}
+		blobStore = pgStore
+	} else {
+		s3Client, err := s3.NewClient(config.AwsClientConfig, logger)
+		if err != nil {
+			return err
+		}
+
+		dynamoClient, err := dynamodb.NewClient(config.AwsClientConfig, logger)
+		if err != nil {
+			return err
+		}
+
+		bucketName := config.BlobstoreConfig.BucketName
+		logger.Info("Creating blob store", "bucket", bucketName)
+		blobMetadataStore := blobstore.NewBlobMetadataStore(dynamoClient, logger, config.BlobstoreConfig.TableName, 0)
+		blobStore = blobstore.NewSharedStorage(bucketName, s3Client, config.BlobstoreConfig.MetadataHashAsBlobKey, blobMetadataStore, logger)
+		if config.ReplicationConfig.Enabled() {
+			replicaMetadataStore, err := blobstore.NewReplicaMetadataStore(config.ReplicationConfig, config.AwsClientConfig, logger)
+			if err != nil {
+				return err
+			}
+			replicator := blobstore.NewMetadataReplicator(blobMetadataStore, replicaMetadataStore, config.ReplicationConfig, logger)
+			manager.Add(lifecycle.Component{Name: "replicator", Start: func(ctx context.Context) error {
+				replicator.Start(ctx)
+				return nil
+			}})
+			blobStore = blobstore.NewReplicatedBlobStore(blobStore, replicator)
+			logger.Info("Replicating blob metadata", "region", config.ReplicationConfig.Region, "table", config.ReplicationConfig.TableName)
+			if config.ReplicationConfig.ServeReads {
+				replica := blobstore.NewReadReplica(replicaMetadataStore, config.ReplicationConfig, logger)
+				manager.Add(lifecycle.Component{Name: "read replica", Start: func(ctx context.Context) error {
+					replica.Start(ctx)
+					return nil
+				}})
+				readReplica = replica
+				logger.Info("Serving blob status reads from the replica", "maxStaleness", config.ReplicationConfig.MaxReadStaleness)
+			}
+		}
 	}
 
-	bucketName := config.BlobstoreConfig.BucketName
-	logger.Info("Creating blob store", "bucket", bucketName)
-	blobMetadataStore := blobstore.NewBlobMetadataStore(dynamoClient, logger, config.BlobstoreConfig.TableName, 0)
-	blobStore = blobstore.NewSharedStorage(bucketName, s3Client, config.BlobstoreConfig.MetadataHashAsBlobKey, blobMetadataStore, logger)
+	if config.ExportConfig.Enabled() {
+		exporter, err := pgstore.OpenExporter(context.Background(), config.ExportConfig, blobStore, logger)
+		if err != nil {
+			return err
+		}
+		manager.Add(lifecycle.Component{Name: "exporter", Start: func(ctx context.Context) error {
+			exporter.Start(ctx)
+			return nil
+		}})
+		blobStore = pgstore.NewExportedBlobStore(blobStore, exporter)
+		logger.Info("Exporting blob metadata to the analytics database", "interval", config.ExportConfig.Interval)
+	}
 
 	// Create new store
 	kvStore, err := disperser.NewLevelDBStore(config.StorageNodeConfig.KvDbPath+"/chunk", config.StorageNodeConfig.TimeToExpire, logger)
@@ -99,17 +168,94 @@ func RunDisperserServer(ctx *cli.Context) error {
 		ratelimiter = ratelimit.NewRateLimiter(globalParams, bucketStore, config.RatelimiterConfig.Allowlist, logger)
 	}
 
+	var pricer *apiserver.Pricer
+	if config.PricingConfig.Enabled {
+		var gasOracle apiserver.GasPriceOracle
+		if config.PricingConfig.GasRPC != "" {
+			gasClient, err := ethclient.Dial(config.PricingConfig.GasRPC)
+			if err != nil {
+				return err
+			}
+			gasOracle = gasClient
+		}
+		var err error
+		pricer, err = apiserver.NewPricer(config.PricingConfig, disperser.ReadOnly(blobStore), gasOracle, logger)
+		if err != nil {
+			return err
+		}
+		manager.Add(lifecycle.Component{Name: "pricer", Start: func(ctx context.Context) error {
+			pricer.Start(ctx)
+			return nil
+		}})
+	}
+
+	var meter *payments.Meter
+	if config.PaymentsConfig.Enabled() {
+		ledger, err := payments.NewLedger(config.PaymentsConfig, logger)
+		if err != nil {
+			return err
+		}
+		usage, err := payments.NewUsageStore(config.PaymentsConfig, config.AwsClientConfig, logger)
+		if err != nil {
+			return err
+		}
+		meter = payments.NewMeter(config.PaymentsConfig, ledger, usage, logger)
+		manager.Add(lifecycle.Component{Name: "payments", Start: func(ctx context.Context) error {
+			meter.Start(ctx)
+			return nil
+		}})
+	}
+
+	var quarantine *apiserver.Quarantine
+	if config.QuarantineConfig.Enabled {
+		var err error
+		quarantine, err = apiserver.NewQuarantine(config.QuarantineConfig, blobStore, logger)
+		if err != nil {
+			return err
+		}
+		manager.Serve("quarantine", quarantine.Serve)
+	}
+	if config.DeadLetterConfig.Enabled() {
+		manager.Serve("dead-letter", apiserver.NewDeadLetters(config.DeadLetterConfig, blobStore, logger).Serve)
+	}
+
+	var quorums apiserver.QuorumRegistry
+	var storagePeriod apiserver.StoragePeriod
+	if config.QuorumRPC != "" {
+		quorumClient, err := ethclient.Dial(config.QuorumRPC)
+		if err != nil {
+			return err
+		}
+		signers, err := da_signers.NewDASignersCaller(eth_common.HexToAddress(config.DASignersContract), quorumClient)
+		if err != nil {
+			return err
+		}
+		quorums = apiserver.NewQuorumRegistry(signers)
+		if retention := config.ServerConfig.Retention; retention.PeriodContract != "" {
+			storagePeriod = apiserver.NewChainStoragePeriod(quorumClient, eth_common.HexToAddress(retention.PeriodContract), retention.PeriodMethod)
+		}
+	}
+
 	// TODO: create a separate metrics for batcher
 	metrics := disperser.NewMetrics(config.MetricsConfig.HTTPPort, logger)
 
-	server := apiserver.NewDispersalServer(config.ServerConfig, blobStore, logger, metrics, ratelimiter, config.RateConfig, config.BlobstoreConfig.MetadataHashAsBlobKey, kvStore, config.RetrieverAddr)
+	server := apiserver.NewDispersalServer(config.ServerConfig, blobStore, logger, metrics, ratelimiter, config.RateConfig, config.BlobstoreConfig.MetadataHashAsBlobKey, kvStore, config.RetrieverAddr, pricer, quarantine, readReplica, quorums, storagePeriod, meter, tracer)
 
 	// Enable Metrics Block
 	if config.MetricsConfig.EnableMetrics {
-		httpSocket := fmt.Sprintf(":%s", config.MetricsConfig.HTTPPort)
-		metrics.Start(context.Background())
-		logger.Info("Enabled metrics for Disperser", "socket", httpSocket)
+		manager.Add(lifecycle.Component{Name: "metrics", Start: func(ctx context.Context) error {
+			metrics.Start(ctx)
+			logger.Info("Enabled metrics for Disperser", "socket", fmt.Sprintf(":%s", config.MetricsConfig.HTTPPort))
+			return nil
+		}})
+	}
+	if config.ReloadConfig.Enabled() {
+		reloader := disperser.NewTunablesReloader(config.ReloadConfig, config.LoggerConfig, logger)
+		reloader.OnReload(server.Tune)
+		manager.Add(lifecycle.Component{Name: "reload", Start: reloader.Start})
+		logger.Info("Reloading tunables on SIGHUP", "file", config.ReloadConfig.File, "url", config.ReloadConfig.URL)
 	}
 
-	return server.Start(context.Background())
+	manager.Serve("api", server.Start)
+	return manager.Run(context.Background())
 }
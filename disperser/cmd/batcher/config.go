@@ -1,13 +1,28 @@
 package main
 
 import (
+	"fmt"
+
+	"github.com/0glabs/0g-da-client/common/approval"
 	"github.com/0glabs/0g-da-client/common/aws"
 	"github.com/0glabs/0g-da-client/common/geth"
+	"github.com/0glabs/0g-da-client/common/healthcheck"
+	"github.com/0glabs/0g-da-client/common/lifecycle"
 	"github.com/0glabs/0g-da-client/common/logging"
+	"github.com/0glabs/0g-da-client/common/reload"
 	"github.com/0glabs/0g-da-client/common/storage_node"
+	"github.com/0glabs/0g-da-client/common/tlsconfig"
+	"github.com/0glabs/0g-da-client/common/tracing"
 	"github.com/0glabs/0g-da-client/disperser/batcher"
+	"github.com/0glabs/0g-da-client/disperser/batcher/dispatcher"
+	"github.com/0glabs/0g-da-client/disperser/batcher/transactor"
 	"github.com/0glabs/0g-da-client/disperser/cmd/batcher/flags"
 	"github.com/0glabs/0g-da-client/disperser/common/blobstore"
+	"github.com/0glabs/0g-da-client/disperser/common/pgstore"
+	"github.com/0glabs/0g-da-client/disperser/contract"
+	"github.com/0glabs/0g-da-client/disperser/encoder"
+	"github.com/0glabs/0g-da-client/disperser/indexer"
+	"github.com/0glabs/0g-da-client/disperser/signer"
 	"github.com/urfave/cli"
 )
 
@@ -15,27 +30,66 @@ type Config struct {
 	BatcherConfig     batcher.Config
 	TimeoutConfig     batcher.TimeoutConfig
 	BlobstoreConfig   blobstore.Config
+	ReplicationConfig blobstore.ReplicationConfig
+	PostgresConfig    pgstore.Config
+	ExportConfig      pgstore.ExportConfig
+	LifecycleConfig   lifecycle.Config
+	HealthConfig      healthcheck.Config
+	ReloadConfig      reload.Config
 	EthClientConfig   geth.EthClientConfig
+	FeeConfig         contract.FeeConfig
+	BlobConfig        dispatcher.BlobConfig
+	SenderPoolConfig  transactor.SenderPoolConfig
 	AwsClientConfig   aws.ClientConfig
 	LoggerConfig      logging.Config
 	MetricsConfig     batcher.MetricsConfig
 	StorageNodeConfig storage_node.ClientConfig
+	IndexerConfig     indexer.Config
+	TracingConfig     tracing.Config
 }
 
-func NewConfig(ctx *cli.Context) Config {
+func NewConfig(ctx *cli.Context) (Config, error) {
+	adminApproval, err := approval.ReadCLIConfig(ctx, flags.AdminApprovalFlagPrefix)
+	if err != nil {
+		return Config{}, err
+	}
+	tracingConfig, err := tracing.ReadCLIConfig(ctx, flags.FlagPrefix)
+	if err != nil {
+		return Config{}, err
+	}
+
 	config := Config{
+		TracingConfig:     tracingConfig,
+		ReplicationConfig: blobstore.ReadReplicationConfig(ctx, flags.FlagPrefix),
+		PostgresConfig:    pgstore.ReadCLIConfig(ctx, flags.FlagPrefix),
+		ExportConfig:      pgstore.ReadExportConfig(ctx, flags.FlagPrefix),
+		LifecycleConfig:   lifecycle.ReadCLIConfig(ctx, flags.FlagPrefix),
+		HealthConfig:      healthcheck.ReadCLIConfig(ctx, flags.FlagPrefix),
+		ReloadConfig:      reload.ReadCLIConfig(ctx, flags.FlagPrefix),
+		IndexerConfig:     indexer.ReadConfig(ctx, flags.FlagPrefix),
 		BlobstoreConfig: blobstore.Config{
 			BucketName:            ctx.GlobalString(flags.S3BucketNameFlag.Name),
 			TableName:             ctx.GlobalString(flags.DynamoDBTableNameFlag.Name),
 			MetadataHashAsBlobKey: ctx.GlobalBool(flags.MetadataHashAsBlobKey.Name),
 		},
 		EthClientConfig: geth.ReadEthClientConfig(ctx),
-		AwsClientConfig: aws.ReadClientConfig(ctx, flags.FlagPrefix),
-		LoggerConfig:    logging.ReadCLIConfig(ctx, flags.FlagPrefix),
+		BlobConfig: dispatcher.BlobConfig{
+			ABIFile: ctx.GlobalString(flags.ConfirmBlobABIFileFlag.Name),
+			Method:  ctx.GlobalString(flags.ConfirmBlobMethodFlag.Name),
+			BlobPolicy: contract.BlobPolicy{
+				Mode:       ctx.GlobalString(flags.ConfirmBlobModeFlag.Name),
+				MinPayload: ctx.GlobalInt(flags.ConfirmBlobMinPayloadFlag.Name),
+			},
+		},
+		FeeConfig:        contract.ReadFeeConfig(ctx),
+		SenderPoolConfig: transactor.ReadSenderPoolConfig(ctx),
+		AwsClientConfig:  aws.ReadClientConfig(ctx, flags.FlagPrefix),
+		LoggerConfig:     logging.ReadCLIConfig(ctx, flags.FlagPrefix),
 		BatcherConfig: batcher.Config{
 			PullInterval:                  ctx.GlobalDuration(flags.PullIntervalFlag.Name),
 			FinalizerInterval:             ctx.GlobalDuration(flags.FinalizerIntervalFlag.Name),
 			EncoderSocket:                 ctx.GlobalString(flags.EncoderSocket.Name),
+			EncoderSharedMemoryDir:        ctx.GlobalString(flags.EncoderSharedMemoryDirFlag.Name),
 			NumConnections:                ctx.GlobalInt(flags.NumConnectionsFlag.Name),
 			EncodingRequestQueueSize:      ctx.GlobalInt(flags.EncodingRequestQueueSizeFlag.Name),
 			BatchSizeMBLimit:              ctx.GlobalUint(flags.BatchSizeLimitFlag.Name),
@@ -47,21 +101,178 @@ func NewConfig(ctx *cli.Context) Config {
 			SigningInterval:               ctx.GlobalDuration(flags.SigningIntervalFlag.Name),
 			MaxNumRetriesForSign:          ctx.GlobalUint(flags.MaxNumRetriesForSignFlag.Name),
 			FinalizedBlockCount:           ctx.GlobalUint(flags.FinalizedBlockCountFlag.Name),
+			ConfirmationDepth:             ctx.GlobalUint(flags.ConfirmationDepthFlag.Name),
 			ExpirationPollIntervalSec:     ctx.GlobalUint64(flags.ExpirationPollIntervalSecFlag.Name),
 			SignedPullInterval:            ctx.GlobalDuration(flags.SignedPullIntervalFlag.Name),
 			VerifiedCommitRootsTxGasLimit: ctx.GlobalUint64(flags.VerifiedCommitRootsTxGasLimitFlag.Name),
+			SignerHedging: batcher.HedgingConfig{
+				Enabled:    ctx.GlobalBool(flags.SignerHedgingFlag.Name),
+				Percentile: ctx.GlobalFloat64(flags.SignerHedgingPercentileFlag.Name),
+				MinDelay:   ctx.GlobalDuration(flags.SignerHedgingMinDelayFlag.Name),
+				MaxRatio:   ctx.GlobalFloat64(flags.SignerHedgingMaxRatioFlag.Name),
+			},
+			EncoderCrossCheck: batcher.CrossCheckConfig{
+				SecondaryEncoderSocket: ctx.GlobalString(flags.SecondaryEncoderSocketFlag.Name),
+				SampleRate:             ctx.GlobalFloat64(flags.EncoderCrossCheckRateFlag.Name),
+				FailOnDivergence:       ctx.GlobalBool(flags.EncoderCrossCheckStrictFlag.Name),
+			},
+			HashSuite:           ctx.GlobalString(flags.HashSuiteFlag.Name),
+			EncodingJournalPath: ctx.GlobalString(flags.EncodingJournalPathFlag.Name),
+			EncodingCacheSizeMB: ctx.GlobalUint(flags.EncodingCacheSizeFlag.Name),
+			BatchJournalPath:    ctx.GlobalString(flags.BatchJournalPathFlag.Name),
+			PartialConfirmation: ctx.GlobalBool(flags.PartialConfirmationFlag.Name),
+			QuorumFallback:      ctx.GlobalBool(flags.QuorumFallbackFlag.Name),
+			ChunkFormats:        ctx.GlobalStringSlice(flags.ChunkFormatsFlag.Name),
+			SignerDiscovery: signer.DiscoveryConfig{
+				Enabled: ctx.GlobalBool(flags.SignerDiscoveryFlag.Name),
+				TTL:     ctx.GlobalDuration(flags.SignerDiscoveryTTLFlag.Name),
+			},
+			SignerQUIC: signer.QUICConfig{
+				Enabled:    ctx.GlobalBool(flags.SignerQUICFlag.Name),
+				RetryAfter: ctx.GlobalDuration(flags.SignerQUICRetryAfterFlag.Name),
+			},
+			SignerTLS: tlsconfig.ReadClientCLIConfig(ctx, flags.SignerTLSFlagPrefix),
+			Poster: batcher.PosterConfig{
+				InboxAddress:   ctx.GlobalString(flags.InboxAddressFlag.Name),
+				InboxABIFile:   ctx.GlobalString(flags.InboxABIFileFlag.Name),
+				InboxMethod:    ctx.GlobalString(flags.InboxMethodFlag.Name),
+				TxGasLimit:     ctx.GlobalUint64(flags.InboxTxGasLimitFlag.Name),
+				MaxRetries:     ctx.GlobalUint(flags.InboxMaxRetriesFlag.Name),
+				BlobMethod:     ctx.GlobalString(flags.InboxBlobMethodFlag.Name),
+				BlobMode:       ctx.GlobalString(flags.InboxBlobModeFlag.Name),
+				BlobMinPayload: ctx.GlobalInt(flags.InboxBlobMinPayloadFlag.Name),
+				MaxPending:     ctx.GlobalInt(flags.InboxMaxPendingFlag.Name),
+				JournalPath:    ctx.GlobalString(flags.InboxJournalPathFlag.Name),
+			},
+			Drain: batcher.DrainConfig{
+				MaxBatchesPerMinute: ctx.GlobalUint(flags.DrainMaxBatchesPerMinuteFlag.Name),
+				RampUpDuration:      ctx.GlobalDuration(flags.DrainRampUpDurationFlag.Name),
+				RampUpStart:         ctx.GlobalFloat64(flags.DrainRampUpStartFlag.Name),
+				RampUpCurve:         ctx.GlobalString(flags.DrainRampUpCurveFlag.Name),
+				Order:               ctx.GlobalString(flags.DrainOrderFlag.Name),
+			},
+			BatchGas: batcher.BatchGasConfig{
+				BlockGasTarget: ctx.GlobalUint64(flags.BatchGasTargetFlag.Name),
+				NumQuorums:     ctx.GlobalUint64(flags.BatchGasQuorumsFlag.Name),
+				NumSigners:     ctx.GlobalUint64(flags.BatchGasSignersFlag.Name),
+			},
+			MinBatch: batcher.MinBatchConfig{
+				MinBytes: ctx.GlobalUint64(flags.MinBatchBytesFlag.Name),
+				MinBlobs: ctx.GlobalUint(flags.MinBatchBlobsFlag.Name),
+				MaxWait:  ctx.GlobalDuration(flags.MinBatchMaxWaitFlag.Name),
+			},
+			BatchAlign: batcher.BatchAlignConfig{
+				BlocksPerBatch: ctx.GlobalUint64(flags.BatchAlignBlocksFlag.Name),
+				Offset:         ctx.GlobalUint64(flags.BatchAlignOffsetFlag.Name),
+				PollInterval:   ctx.GlobalDuration(flags.BatchAlignPollIntervalFlag.Name),
+			},
+			BatchSizing: batcher.BatchSizingConfig{
+				MinSizeMB:         ctx.GlobalUint(flags.BatchSizeMinMBFlag.Name),
+				MaxSizeMB:         ctx.GlobalUint(flags.BatchSizeMaxMBFlag.Name),
+				ReferenceGasPrice: ctx.GlobalUint64(flags.BatchSizeReferenceGasPriceFlag.Name),
+				BacklogTarget:     ctx.GlobalUint(flags.BatchSizeBacklogTargetFlag.Name),
+				LatencyTarget:     ctx.GlobalDuration(flags.BatchSizeLatencyTargetFlag.Name),
+				Interval:          ctx.GlobalDuration(flags.BatchSizeIntervalFlag.Name),
+			},
+			BatchPipelines: ctx.GlobalUint(flags.BatchPipelinesFlag.Name),
+			DeadLetter: batcher.DeadLetterConfig{
+				ProcessingTTL: ctx.GlobalDuration(flags.DeadLetterProcessingTTLFlag.Name),
+				SweepInterval: ctx.GlobalDuration(flags.DeadLetterSweepIntervalFlag.Name),
+			},
+			InFlight: batcher.InFlightConfig{
+				Timeout: ctx.GlobalDuration(flags.InFlightTimeoutFlag.Name),
+			},
+			Fairness: batcher.FairnessConfig{
+				ReportInterval:   ctx.GlobalDuration(flags.FairnessReportIntervalFlag.Name),
+				Tolerance:        ctx.GlobalFloat64(flags.FairnessToleranceFlag.Name),
+				SustainedBatches: ctx.GlobalUint(flags.FairnessSustainedBatchesFlag.Name),
+			},
+			DispatchDeadline: batcher.DispatchDeadlineConfig{
+				Default: ctx.GlobalDuration(flags.DispatchDeadlineFlag.Name),
+				Quorums: ctx.GlobalStringSlice(flags.QuorumDispatchDeadlinesFlag.Name),
+			},
+			Bandwidth: batcher.BandwidthConfig{
+				ProbeInterval: ctx.GlobalDuration(flags.BandwidthProbeIntervalFlag.Name),
+				ProbeSize:     ctx.GlobalInt(flags.BandwidthProbeSizeFlag.Name),
+			},
+			StatusPage: batcher.StatusPageConfig{
+				Bucket:     ctx.GlobalString(flags.StatusPageBucketFlag.Name),
+				Prefix:     ctx.GlobalString(flags.StatusPagePrefixFlag.Name),
+				Interval:   ctx.GlobalDuration(flags.StatusPageIntervalFlag.Name),
+				Window:     ctx.GlobalDuration(flags.StatusPageWindowFlag.Name),
+				StaleAfter: ctx.GlobalDuration(flags.StatusPageStaleAfterFlag.Name),
+			},
+			Retry: batcher.RetryConfig{
+				Default: batcher.RetryPolicy{
+					Backoff:    ctx.GlobalDuration(flags.RetryBackoffFlag.Name),
+					MaxBackoff: ctx.GlobalDuration(flags.RetryMaxBackoffFlag.Name),
+					Multiplier: ctx.GlobalFloat64(flags.RetryBackoffMultiplierFlag.Name),
+					Jitter:     ctx.GlobalFloat64(flags.RetryJitterFlag.Name),
+				},
+				Policies: ctx.GlobalStringSlice(flags.RetryPoliciesFlag.Name),
+			},
+			Budget: batcher.BudgetConfig{
+				MaxLatency: ctx.GlobalDuration(flags.BatchMaxLatencyFlag.Name),
+				MaxFeeGwei: ctx.GlobalUint64(flags.BatchMaxFeeFlag.Name),
+				BumpBelow:  ctx.GlobalFloat64(flags.BatchFeeBumpBelowFlag.Name),
+			},
+			Watchdog: batcher.WatchdogConfig{
+				SampleSize:      ctx.GlobalInt(flags.WatchdogSampleSizeFlag.Name),
+				ExclusionPeriod: ctx.GlobalDuration(flags.WatchdogExclusionPeriodFlag.Name),
+			},
+			Fallback: batcher.FallbackConfig{
+				RPCURL:                    ctx.GlobalString(flags.FallbackRPCURLFlag.Name),
+				DAEntranceContractAddress: ctx.GlobalString(flags.FallbackDAEntranceContractAddressFlag.Name),
+				DASignersContractAddress:  ctx.GlobalString(flags.FallbackDASignersContractAddressFlag.Name),
+				HaltTimeout:               ctx.GlobalDuration(flags.FallbackHaltTimeoutFlag.Name),
+				FinalityDepth:             ctx.GlobalUint64(flags.FallbackFinalityDepthFlag.Name),
+			},
+			Health: batcher.HealthConfig{
+				MaxConfirmerBacklog:  ctx.GlobalInt(flags.HealthMaxConfirmerBacklogFlag.Name),
+				MaxDispersalFailures: ctx.GlobalInt(flags.HealthMaxDispersalFailuresFlag.Name),
+			},
+			SpendLimit: batcher.SpendLimitConfig{
+				HourlyGwei:  ctx.GlobalUint64(flags.SpendLimitHourlyGweiFlag.Name),
+				DailyGwei:   ctx.GlobalUint64(flags.SpendLimitDailyGweiFlag.Name),
+				SpikeFactor: ctx.GlobalFloat64(flags.SpendSpikeFactorFlag.Name),
+				SpikeWindow: ctx.GlobalInt(flags.SpendSpikeWindowFlag.Name),
+				JournalPath: ctx.GlobalString(flags.SpendJournalPathFlag.Name),
+				AlertURL:    ctx.GlobalString(flags.SpendAlertURLFlag.Name),
+			},
+			EncoderBalancer: batcher.EncoderBalancerConfig{
+				Strategy:            ctx.GlobalString(flags.EncoderBalancingFlag.Name),
+				MaxFailures:         ctx.GlobalUint(flags.EncoderMaxFailuresFlag.Name),
+				HealthCheckInterval: ctx.GlobalDuration(flags.EncoderHealthCheckIntervalFlag.Name),
+			},
+			EncoderBatching: encoder.BatchingConfig{
+				MaxBlobs:    ctx.GlobalInt(flags.EncoderBatchMaxBlobsFlag.Name),
+				MaxBlobSize: ctx.GlobalInt(flags.EncoderBatchMaxBlobSizeFlag.Name),
+				MaxWait:     ctx.GlobalDuration(flags.EncoderBatchMaxWaitFlag.Name),
+			},
+			EncoderTLS: tlsconfig.ReadClientCLIConfig(ctx, flags.EncoderTLSFlagPrefix),
+			Admin: batcher.AdminConfig{
+				HTTPPort: ctx.GlobalString(flags.AdminHTTPPortFlag.Name),
+				Token:    ctx.GlobalString(flags.AdminTokenFlag.Name),
+				Approval: adminApproval,
+			},
+			SafeMode: ctx.GlobalBool(flags.SafeModeFlag.Name),
 		},
 		TimeoutConfig: batcher.TimeoutConfig{
-			EncodingTimeout:   ctx.GlobalDuration(flags.EncodingTimeoutFlag.Name),
-			ChainReadTimeout:  ctx.GlobalDuration(flags.ChainReadTimeoutFlag.Name),
-			ChainWriteTimeout: ctx.GlobalDuration(flags.ChainWriteTimeoutFlag.Name),
-			SigningTimeout:    ctx.GlobalDuration(flags.SigningTimeoutFlag.Name),
-		},
+			EncodingTimeout:    ctx.GlobalDuration(flags.EncodingTimeoutFlag.Name),
+			ChainReadTimeout:   ctx.GlobalDuration(flags.ChainReadTimeoutFlag.Name),
+			ChainWriteTimeout:  ctx.GlobalDuration(flags.ChainWriteTimeoutFlag.Name),
+			SigningTimeout:     ctx.GlobalDuration(flags.SigningTimeoutFlag.Name),
+			StoreWriteTimeout:  ctx.GlobalDuration(flags.StoreWriteTimeoutFlag.Name),
+			ReceiptWaitTimeout: ctx.GlobalDuration(flags.ReceiptWaitTimeoutFlag.Name),
+		}.Reloadable(),
 		MetricsConfig: batcher.MetricsConfig{
 			HTTPPort:      ctx.GlobalString(flags.MetricsHTTPPort.Name),
 			EnableMetrics: ctx.GlobalBool(flags.EnableMetrics.Name),
 		},
 		StorageNodeConfig: storage_node.ReadClientConfig(ctx, flags.FlagPrefix),
 	}
-	return config
+	if (config.BlobConfig.Enabled() || config.BatcherConfig.Poster.BlobMethod != "") && config.SenderPoolConfig.BlobPrivateKey == "" {
+		return Config{}, fmt.Errorf("blob transactions are sent from a dedicated account, set --%s", transactor.BlobSenderKeyFlagName)
+	}
+	return config, nil
 }
@@ -1,19 +1,42 @@
 package flags
 
 import (
+	"github.com/0glabs/0g-da-client/common/healthcheck"
+	"github.com/0glabs/0g-da-client/common/lifecycle"
+	"github.com/0glabs/0g-da-client/common/tlsconfig"
+	"github.com/0glabs/0g-da-client/common/tracing"
 	"time"
 
 	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/approval"
 	"github.com/0glabs/0g-da-client/common/aws"
 	"github.com/0glabs/0g-da-client/common/geth"
 	"github.com/0glabs/0g-da-client/common/logging"
+	"github.com/0glabs/0g-da-client/common/reload"
 	"github.com/0glabs/0g-da-client/common/storage_node"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser/batcher"
+	"github.com/0glabs/0g-da-client/disperser/batcher/transactor"
+	"github.com/0glabs/0g-da-client/disperser/common/blobstore"
+	"github.com/0glabs/0g-da-client/disperser/common/pgstore"
+	"github.com/0glabs/0g-da-client/disperser/contract"
+	"github.com/0glabs/0g-da-client/disperser/indexer"
 	"github.com/urfave/cli"
 )
 
 const (
 	FlagPrefix   = "batcher"
 	EnvVarPrefix = "BATCHER"
+
+	// AdminApprovalFlagPrefix prefixes the approval flags of the admin server
+	AdminApprovalFlagPrefix   = FlagPrefix + ".admin"
+	AdminApprovalEnvVarPrefix = EnvVarPrefix + "_ADMIN"
+
+	// EncoderTLSFlagPrefix and SignerTLSFlagPrefix prefix the tls flags of the clients
+	EncoderTLSFlagPrefix   = FlagPrefix + ".encoder"
+	EncoderTLSEnvVarPrefix = EnvVarPrefix + "_ENCODER"
+	SignerTLSFlagPrefix    = FlagPrefix + ".signer"
+	SignerTLSEnvVarPrefix  = EnvVarPrefix + "_SIGNER"
 )
 
 var (
@@ -36,7 +59,7 @@ var (
 	}
 	EncoderSocket = cli.StringFlag{
 		Name:     "encoder-socket",
-		Usage:    "the http ip:port which the distributed encoder server is listening",
+		Usage:    "the ip:port which the distributed encoder server is listening, or unix:///path/to/socket for a co-located encoder. A comma separated list balances the blobs across several encoders",
 		Required: false,
 		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "ENCODER_ADDRESS"),
 	}
@@ -81,6 +104,19 @@ var (
 		Value:    90 * time.Second,
 		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "CHAIN_WRITE_TIMEOUT"),
 	}
+	StoreWriteTimeoutFlag = cli.DurationFlag{
+		Name:     "store-write-timeout",
+		Usage:    "timeout of each write to the blob metadata store",
+		Required: false,
+		Value:    10 * time.Second,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "STORE_WRITE_TIMEOUT"),
+	}
+	ReceiptWaitTimeoutFlag = cli.DurationFlag{
+		Name:     "receipt-wait-timeout",
+		Usage:    "timeout of each wait for a transaction receipt, only bounded by the receipt polling rounds if 0",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "RECEIPT_WAIT_TIMEOUT"),
+	}
 	NumConnectionsFlag = cli.IntFlag{
 		Name:     "num-connections",
 		Usage:    "maximum number of connections to encoders (defaults to 256)",
@@ -165,6 +201,13 @@ var (
 		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "FINALIZED_BLOCK_COUNT"),
 		Value:    1,
 	}
+	ConfirmationDepthFlag = cli.UintFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "confirmation-depth"),
+		Usage:    "Number of blocks below the latest block after which a batch confirmation is final. Uses the finalized block of the chain if 0",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "CONFIRMATION_DEPTH"),
+		Value:    0,
+	}
 	ExpirationPollIntervalSecFlag = cli.StringFlag{
 		Name:     common.PrefixFlag(FlagPrefix, "expiration-poll-interval"),
 		Usage:    "How often (in second) to poll status and expire outdated blobs",
@@ -185,6 +228,562 @@ var (
 		Value:    0,
 		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "VERIFIED_COMMIT_ROOTS_TX_GAS_LIMIT"),
 	}
+	SignerHedgingFlag = cli.BoolFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "signer-hedging"),
+		Usage:  "send a hedged sign request when a signer is slower than the observed latency percentile",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "SIGNER_HEDGING"),
+	}
+	SignerHedgingPercentileFlag = cli.Float64Flag{
+		Name:     common.PrefixFlag(FlagPrefix, "signer-hedging-percentile"),
+		Usage:    "latency percentile after which a sign request is hedged",
+		Required: false,
+		Value:    0.95,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "SIGNER_HEDGING_PERCENTILE"),
+	}
+	SignerHedgingMinDelayFlag = cli.DurationFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "signer-hedging-min-delay"),
+		Usage:    "minimum delay before a sign request is hedged",
+		Required: false,
+		Value:    2 * time.Second,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "SIGNER_HEDGING_MIN_DELAY"),
+	}
+	SignerHedgingMaxRatioFlag = cli.Float64Flag{
+		Name:     common.PrefixFlag(FlagPrefix, "signer-hedging-max-ratio"),
+		Usage:    "maximum fraction of sign requests that may be hedged",
+		Required: false,
+		Value:    0.1,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "SIGNER_HEDGING_MAX_RATIO"),
+	}
+	EncoderSharedMemoryDirFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "encoder-shm-dir"),
+		Usage:  "directory shared with a co-located encoder, e.g. on /dev/shm, to exchange blobs and encodings through files instead of gRPC messages",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "ENCODER_SHM_DIR"),
+	}
+	SecondaryEncoderSocketFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "secondary-encoder-socket"),
+		Usage:    "the ip:port of a second encoder used to cross check sampled encoding results",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "SECONDARY_ENCODER_ADDRESS"),
+	}
+	EncoderCrossCheckRateFlag = cli.Float64Flag{
+		Name:     common.PrefixFlag(FlagPrefix, "encoder-cross-check-rate"),
+		Usage:    "fraction of blobs encoded by both encoders",
+		Required: false,
+		Value:    0.01,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "ENCODER_CROSS_CHECK_RATE"),
+	}
+	EncoderCrossCheckStrictFlag = cli.BoolFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "encoder-cross-check-strict"),
+		Usage:  "fail the blob encoding when the encoders return different results",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "ENCODER_CROSS_CHECK_STRICT"),
+	}
+	HashSuiteFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "hash-suite"),
+		Usage:  "hash used for blob and batch header hashes, must match the settlement chain. One of keccak256, sha256, sha3-256, blake2b-256",
+		Value:  core.Keccak256SuiteName,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "HASH_SUITE"),
+	}
+	EncodingCacheSizeFlag = cli.UintFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "encoding-cache-size-mb"),
+		Usage:  "size in MB of the encodings reused for resubmitted blobs with the same data and parameters, nothing is cached if 0",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "ENCODING_CACHE_SIZE_MB"),
+	}
+	EncodingJournalPathFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "encoding-journal-path"),
+		Usage:  "directory where encoding results are persisted so a restarted batcher doesn't re-encode them. Disabled if empty",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "ENCODING_JOURNAL_PATH"),
+	}
+	BatchJournalPathFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "batch-journal-path"),
+		Usage:  "directory where the batches in flight are persisted so a restarted batcher resumes tracking their confirmation. Disabled if empty",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "BATCH_JOURNAL_PATH"),
+	}
+	PartialConfirmationFlag = cli.BoolFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "partial-confirmation"),
+		Usage:  "after the last signing retry, confirm the blobs of a batch that reached the signing threshold and retry the others instead of failing the whole batch",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "PARTIAL_CONFIRMATION"),
+	}
+	QuorumFallbackFlag = cli.BoolFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "quorum-fallback"),
+		Usage:  "after the last signing retry, confirm the blobs short of the optional thresholds they requested with the default thresholds",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "QUORUM_FALLBACK"),
+	}
+	ChunkFormatsFlag = cli.StringSliceFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "chunk-formats"),
+		Usage:  "encoded slice formats offered to signers in order of preference, e.g. framed-flate,framed. Signers not supporting any of them receive the legacy format",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "CHUNK_FORMATS"),
+	}
+	SignerDiscoveryFlag = cli.BoolFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "signer-discovery"),
+		Usage:  "query the node version and supported chunk formats of signers before sending them slices",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "SIGNER_DISCOVERY"),
+	}
+	SignerDiscoveryTTLFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "signer-discovery-ttl"),
+		Usage:  "how long the discovered version of a signer is used before it is queried again",
+		Value:  10 * time.Minute,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "SIGNER_DISCOVERY_TTL"),
+	}
+	SignerQUICFlag = cli.BoolFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "signer-quic"),
+		Usage:  "experimental: send slices over QUIC to the signers that advertise a QUIC address, falling back to gRPC when it fails. Requires TLS to the signers",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "SIGNER_QUIC"),
+	}
+	SignerQUICRetryAfterFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "signer-quic-retry-after"),
+		Usage:  "how long a signer whose request over QUIC failed is sent its slices over gRPC before QUIC is tried again",
+		Value:  time.Minute,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "SIGNER_QUIC_RETRY_AFTER"),
+	}
+	InboxAddressFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "inbox-address"),
+		Usage:  "rollup inbox contract the certificates of confirmed blobs are posted to. Disabled if empty",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "INBOX_ADDRESS"),
+	}
+	InboxABIFileFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "inbox-abi-file"),
+		Usage:  "json abi of the rollup inbox contract",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "INBOX_ABI_FILE"),
+	}
+	InboxMethodFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "inbox-method"),
+		Usage:  "inbox method called for each confirmed blob, its inputs are filled by name, e.g. dataRoot, epoch, quorumId, commitment",
+		Value:  "postCertificate",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "INBOX_METHOD"),
+	}
+	InboxTxGasLimitFlag = cli.Uint64Flag{
+		Name:   common.PrefixFlag(FlagPrefix, "inbox-tx-gas-limit"),
+		Usage:  "gas limit of inbox transactions, estimated if 0",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "INBOX_TX_GAS_LIMIT"),
+	}
+	InboxMaxRetriesFlag = cli.UintFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "inbox-max-retries"),
+		Usage:  "number of times posting a certificate to the inbox is retried",
+		Value:  3,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "INBOX_MAX_RETRIES"),
+	}
+	InboxBlobMethodFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "inbox-blob-method"),
+		Usage:  "inbox method called in blob transactions carrying the encoded inputs of the inbox method in a blob, its versionedHash input is the hash of the blob. Blob transactions are disabled if empty",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "INBOX_BLOB_METHOD"),
+	}
+	InboxBlobModeFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "inbox-blob-mode"),
+		Usage:  "when certificates are posted in blob transactions, auto (when blob gas is cheaper than calldata) or always",
+		Value:  batcher.BlobModeAuto,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "INBOX_BLOB_MODE"),
+	}
+	InboxBlobMinPayloadFlag = cli.IntFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "inbox-blob-min-payload"),
+		Usage:  "smallest certificate payload in bytes posted in a blob transaction in auto mode",
+		Value:  8192,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "INBOX_BLOB_MIN_PAYLOAD"),
+	}
+	InboxMaxPendingFlag = cli.IntFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "inbox-max-pending"),
+		Usage:  "maximum number of certificates waiting to be posted to the inbox, those of the blobs confirmed meanwhile are dropped",
+		Value:  10000,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "INBOX_MAX_PENDING"),
+	}
+	InboxJournalPathFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "inbox-journal-path"),
+		Usage:  "leveldb path persisting the certificates waiting to be posted to the inbox across restarts. Not persisted if empty",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "INBOX_JOURNAL_PATH"),
+	}
+	ConfirmBlobABIFileFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "confirm-blob-abi-file"),
+		Usage:  "json abi of the DA entrance method submitting the aggregate signatures from a blob",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "CONFIRM_BLOB_ABI_FILE"),
+	}
+	ConfirmBlobMethodFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "confirm-blob-method"),
+		Usage:  "DA entrance method called in blob transactions carrying the encoded inputs of submitVerifiedCommitRoots in a blob, its single bytes32 input is the versioned hash of the blob. Blob transactions are disabled if empty",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "CONFIRM_BLOB_METHOD"),
+	}
+	ConfirmBlobModeFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "confirm-blob-mode"),
+		Usage:  "when aggregate signatures are submitted in blob transactions, auto (when blob gas is cheaper than calldata) or always",
+		Value:  batcher.BlobModeAuto,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "CONFIRM_BLOB_MODE"),
+	}
+	ConfirmBlobMinPayloadFlag = cli.IntFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "confirm-blob-min-payload"),
+		Usage:  "smallest payload of aggregate signatures in bytes submitted in a blob transaction in auto mode",
+		Value:  8192,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "CONFIRM_BLOB_MIN_PAYLOAD"),
+	}
+	DrainMaxBatchesPerMinuteFlag = cli.UintFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "drain-max-batches-per-minute"),
+		Usage:  "maximum number of batches created per minute, 0 for no limit",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "DRAIN_MAX_BATCHES_PER_MINUTE"),
+	}
+	DrainRampUpDurationFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "drain-ramp-up-duration"),
+		Usage:  "time after startup over which the batch limit and the encoding queue limit ramp up to their full value. Disabled if 0",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "DRAIN_RAMP_UP_DURATION"),
+	}
+	DrainRampUpStartFlag = cli.Float64Flag{
+		Name:   common.PrefixFlag(FlagPrefix, "drain-ramp-up-start"),
+		Usage:  "fraction of the full limits the ramp up starts from",
+		Value:  0.1,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "DRAIN_RAMP_UP_START"),
+	}
+	DrainRampUpCurveFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "drain-ramp-up-curve"),
+		Usage:  "shape of the ramp up, linear or exponential",
+		Value:  batcher.DrainCurveLinear,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "DRAIN_RAMP_UP_CURVE"),
+	}
+	DrainOrderFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "drain-order"),
+		Usage:  "order in which the backlog is encoded, oldest-first or fair-share across accounts",
+		Value:  batcher.DrainOrderOldestFirst,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "DRAIN_ORDER"),
+	}
+	BatchGasTargetFlag = cli.Uint64Flag{
+		Name:   common.PrefixFlag(FlagPrefix, "batch-gas-target"),
+		Usage:  "cap batches so that the estimated gas of their confirmation transactions stays below this target, 0 to disable",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "BATCH_GAS_TARGET"),
+	}
+	BatchGasQuorumsFlag = cli.Uint64Flag{
+		Name:   common.PrefixFlag(FlagPrefix, "batch-gas-quorums"),
+		Usage:  "number of quorums each blob is verified in, for the batch gas estimate",
+		Value:  1,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "BATCH_GAS_QUORUMS"),
+	}
+	BatchGasSignersFlag = cli.Uint64Flag{
+		Name:   common.PrefixFlag(FlagPrefix, "batch-gas-signers"),
+		Usage:  "number of signers per quorum, for the batch gas estimate",
+		Value:  64,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "BATCH_GAS_SIGNERS"),
+	}
+	MinBatchBytesFlag = cli.Uint64Flag{
+		Name:   common.PrefixFlag(FlagPrefix, "min-batch-bytes"),
+		Usage:  "total blob size below which the batch ticker waits for more blobs, 0 for no minimum",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "MIN_BATCH_BYTES"),
+	}
+	MinBatchBlobsFlag = cli.UintFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "min-batch-blobs"),
+		Usage:  "number of blobs below which the batch ticker waits for more blobs, 0 for no minimum",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "MIN_BATCH_BLOBS"),
+	}
+	MinBatchMaxWaitFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "min-batch-max-wait"),
+		Usage:  "how long the oldest blob waits for the minimum batch size before it is batched anyway",
+		Value:  10 * time.Minute,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "MIN_BATCH_MAX_WAIT"),
+	}
+	BatchAlignBlocksFlag = cli.Uint64Flag{
+		Name:   common.PrefixFlag(FlagPrefix, "batch-align-blocks"),
+		Usage:  "create one batch every this many blocks instead of on the pull interval, 0 to disable",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "BATCH_ALIGN_BLOCKS"),
+	}
+	BatchAlignOffsetFlag = cli.Uint64Flag{
+		Name:   common.PrefixFlag(FlagPrefix, "batch-align-offset"),
+		Usage:  "block within each aligned window at which its batch is created",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "BATCH_ALIGN_OFFSET"),
+	}
+	BatchAlignPollIntervalFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "batch-align-poll-interval"),
+		Usage:  "how often the chain head is read for aligned batching",
+		Value:  time.Second,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "BATCH_ALIGN_POLL_INTERVAL"),
+	}
+	BatchSizeMinMBFlag = cli.UintFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "batch-size-min-mb"),
+		Usage:  "lower bound in MB of the adaptive batch size target",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "BATCH_SIZE_MIN_MB"),
+	}
+	BatchSizeMaxMBFlag = cli.UintFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "batch-size-max-mb"),
+		Usage:  "upper bound in MB of the adaptive batch size target, 0 to keep the batch size limit fixed",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "BATCH_SIZE_MAX_MB"),
+	}
+	BatchSizeReferenceGasPriceFlag = cli.Uint64Flag{
+		Name:   common.PrefixFlag(FlagPrefix, "batch-size-reference-gas-price"),
+		Usage:  "gas price in wei at which gas leaves the batch size target unchanged, 0 to ignore gas",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "BATCH_SIZE_REFERENCE_GAS_PRICE"),
+	}
+	BatchSizeBacklogTargetFlag = cli.UintFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "batch-size-backlog-target"),
+		Usage:  "batches waiting for confirmation at which the backlog leaves the batch size target unchanged, 0 to ignore the backlog",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "BATCH_SIZE_BACKLOG_TARGET"),
+	}
+	BatchSizeLatencyTargetFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "batch-size-latency-target"),
+		Usage:  "chain write latency at which it leaves the batch size target unchanged, 0 to ignore it",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "BATCH_SIZE_LATENCY_TARGET"),
+	}
+	BatchSizeIntervalFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "batch-size-interval"),
+		Usage:  "how often the adaptive batch size target is recomputed",
+		Value:  30 * time.Second,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "BATCH_SIZE_INTERVAL"),
+	}
+	BatchPipelinesFlag = cli.UintFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "batch-pipelines"),
+		Usage:  "number of batches created and dispatched concurrently",
+		Value:  1,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "BATCH_PIPELINES"),
+	}
+	DeadLetterProcessingTTLFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "dead-letter-processing-ttl"),
+		Usage:  "dead-letter blobs still processing this long after they were requested, 0 to disable",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "DEAD_LETTER_PROCESSING_TTL"),
+	}
+	DeadLetterSweepIntervalFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "dead-letter-sweep-interval"),
+		Usage:  "how often the processing blobs are checked against the dead letter ttl",
+		Value:  time.Minute,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "DEAD_LETTER_SWEEP_INTERVAL"),
+	}
+	InFlightTimeoutFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "in-flight-timeout"),
+		Usage:  "how long a batch transaction without receipt keeps its blobs out of new batches before they are retried, 0 to retry them right away",
+		Value:  10 * time.Minute,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "IN_FLIGHT_TIMEOUT"),
+	}
+	FairnessReportIntervalFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "fairness-report-interval"),
+		Usage:  "how often the report of the bytes assigned to operators against their stake is logged, 0 to disable the analyzer",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "FAIRNESS_REPORT_INTERVAL"),
+	}
+	FairnessToleranceFlag = cli.Float64Flag{
+		Name:   common.PrefixFlag(FlagPrefix, "fairness-tolerance"),
+		Usage:  "relative deviation of the bytes assigned to an operator from its stake proportion tolerated in a batch",
+		Value:  0.1,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "FAIRNESS_TOLERANCE"),
+	}
+	FairnessSustainedBatchesFlag = cli.UintFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "fairness-sustained-batches"),
+		Usage:  "number of consecutive deviating batches after which the deviation of an operator is reported",
+		Value:  10,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "FAIRNESS_SUSTAINED_BATCHES"),
+	}
+	DispatchDeadlineFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "dispatch-deadline"),
+		Usage:  "how long the signatures of the operators of a quorum are waited for before a batch proceeds without the missing ones, 0 to wait for every signing request",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "DISPATCH_DEADLINE"),
+	}
+	QuorumDispatchDeadlinesFlag = cli.StringSliceFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "quorum-dispatch-deadlines"),
+		Usage:  "dispatch deadlines of quorums with slower operators as <quorum>=<duration>, e.g. 1=90s, overriding the dispatch deadline",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "QUORUM_DISPATCH_DEADLINES"),
+	}
+	BandwidthProbeIntervalFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "bandwidth-probe-interval"),
+		Usage:  "how often the upload bandwidth to the operators is probed, leaving those whose slices would take longer than the dispatch deadline to upload out of dispersals. 0 to disable",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "BANDWIDTH_PROBE_INTERVAL"),
+	}
+	BandwidthProbeSizeFlag = cli.IntFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "bandwidth-probe-size"),
+		Usage:  "size in bytes of the uploads probing the bandwidth to the operators",
+		Value:  64 * 1024,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "BANDWIDTH_PROBE_SIZE"),
+	}
+	WatchdogSampleSizeFlag = cli.IntFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "watchdog-sample-size"),
+		Usage:  "number of signers of each confirmed blob asked for one of the slices they signed for, flagging those that don't serve it. 0 to disable",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "WATCHDOG_SAMPLE_SIZE"),
+	}
+	WatchdogExclusionPeriodFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "watchdog-exclusion-period"),
+		Usage:  "how long the operators found by the watchdog not serving a slice they signed for are left out of the dispersals, as long as the others hold enough slices. 0 to only flag them",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "WATCHDOG_EXCLUSION_PERIOD"),
+	}
+	FallbackRPCURLFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "fallback-rpc-url"),
+		Usage:  "RPC of the chain of a fallback deployment of the DA contracts, the batches are dispersed to while the primary chain is halted. Disabled if empty",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "FALLBACK_RPC_URL"),
+	}
+	FallbackDAEntranceContractAddressFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "fallback-da-entrance-contract"),
+		Usage:  "DAEntrance contract address of the fallback deployment",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "FALLBACK_DAENTRANCE_CONTRACT_ADDRESS"),
+	}
+	FallbackDASignersContractAddressFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "fallback-da-signers-contract"),
+		Usage:  "DASigners contract address of the fallback deployment",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "FALLBACK_DASIGNERS_CONTRACT_ADDRESS"),
+	}
+	FallbackHaltTimeoutFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "fallback-halt-timeout"),
+		Usage:  "how long the head of the primary chain must not advance for the chain to be considered halted and the fallback deployment used",
+		Value:  2 * time.Minute,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "FALLBACK_HALT_TIMEOUT"),
+	}
+	FallbackFinalityDepthFlag = cli.Uint64Flag{
+		Name:   common.PrefixFlag(FlagPrefix, "fallback-finality-depth"),
+		Usage:  "number of blocks on top of a confirmation on the fallback chain for the blobs it confirmed to be finalized",
+		Value:  64,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "FALLBACK_FINALITY_DEPTH"),
+	}
+	HealthMaxConfirmerBacklogFlag = cli.IntFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "health-max-confirmer-backlog"),
+		Usage:  "number of batches waiting for confirmation above which the confirmer is reported degraded. 0 to never report it",
+		Value:  10,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "HEALTH_MAX_CONFIRMER_BACKLOG"),
+	}
+	HealthMaxDispersalFailuresFlag = cli.IntFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "health-max-dispersal-failures"),
+		Usage:  "number of consecutive failed batch dispersals from which the dispersal is reported down, it is degraded from the first one. 0 to never report it down",
+		Value:  3,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "HEALTH_MAX_DISPERSAL_FAILURES"),
+	}
+	SpendLimitHourlyGweiFlag = cli.Uint64Flag{
+		Name:   common.PrefixFlag(FlagPrefix, "spend-limit-hourly-gwei"),
+		Usage:  "fees in gwei the uploads, confirmations and inbox posts may spend over the last hour, the aggregate signatures are held back once reached. No cap if 0",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "SPEND_LIMIT_HOURLY_GWEI"),
+	}
+	SpendLimitDailyGweiFlag = cli.Uint64Flag{
+		Name:   common.PrefixFlag(FlagPrefix, "spend-limit-daily-gwei"),
+		Usage:  "fees in gwei the uploads, confirmations and inbox posts may spend over the last day, the aggregate signatures are held back once reached. No cap if 0",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "SPEND_LIMIT_DAILY_GWEI"),
+	}
+	SpendSpikeFactorFlag = cli.Float64Flag{
+		Name:   common.PrefixFlag(FlagPrefix, "spend-spike-factor"),
+		Usage:  "how many times the average per-batch cost of the recent confirmations a batch may cost before the confirmation is paused until resumed through the admin api. Disabled if 0",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "SPEND_SPIKE_FACTOR"),
+	}
+	SpendSpikeWindowFlag = cli.IntFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "spend-spike-window"),
+		Usage:  "number of confirmed batches the per-batch cost is averaged over for the spike detection",
+		Value:  20,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "SPEND_SPIKE_WINDOW"),
+	}
+	SpendJournalPathFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "spend-journal-path"),
+		Usage:  "leveldb path persisting the spends of the last day and the confirmation pause across restarts. Not persisted if empty",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "SPEND_JOURNAL_PATH"),
+	}
+	SpendAlertURLFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "spend-alert-url"),
+		Usage:  "url the spend limit breaches are posted to as json, such as a chat or paging webhook. No alert if empty",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "SPEND_ALERT_URL"),
+	}
+	StatusPageBucketFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "status-page-bucket"),
+		Usage:  "bucket a public status page is pushed to, as status.json and index.html. Disabled if empty",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "STATUS_PAGE_BUCKET"),
+	}
+	StatusPagePrefixFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "status-page-prefix"),
+		Usage:  "key prefix of the status page objects",
+		Value:  "status",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "STATUS_PAGE_PREFIX"),
+	}
+	StatusPageIntervalFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "status-page-interval"),
+		Usage:  "how often the status page is pushed",
+		Value:  time.Minute,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "STATUS_PAGE_INTERVAL"),
+	}
+	StatusPageWindowFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "status-page-window"),
+		Usage:  "how far back the batches reported on the status page go",
+		Value:  time.Hour,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "STATUS_PAGE_WINDOW"),
+	}
+	StatusPageStaleAfterFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "status-page-stale-after"),
+		Usage:  "how long the disperser may go without confirming a batch while it has pending work before the status page reports it degraded",
+		Value:  10 * time.Minute,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "STATUS_PAGE_STALE_AFTER"),
+	}
+	RetryBackoffFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "retry-backoff"),
+		Usage:  "how long the blobs of a failed batch are held back before their first retry, 0 to retry them right away",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "RETRY_BACKOFF"),
+	}
+	RetryMaxBackoffFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "retry-max-backoff"),
+		Usage:  "cap of the backoff of the retries of a blob, 0 for no cap",
+		Value:  10 * time.Minute,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "RETRY_MAX_BACKOFF"),
+	}
+	RetryBackoffMultiplierFlag = cli.Float64Flag{
+		Name:   common.PrefixFlag(FlagPrefix, "retry-backoff-multiplier"),
+		Usage:  "growth of the backoff with each retry of a blob",
+		Value:  2,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "RETRY_BACKOFF_MULTIPLIER"),
+	}
+	RetryJitterFlag = cli.Float64Flag{
+		Name:   common.PrefixFlag(FlagPrefix, "retry-jitter"),
+		Usage:  "fraction of the retry backoff drawn at random, in [0, 1]",
+		Value:  0.2,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "RETRY_JITTER"),
+	}
+	RetryPoliciesFlag = cli.StringSliceFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "retry-policies"),
+		Usage:  "retry policies of failure reasons as <reason>=<field>:<value>,..., e.g. confirm_batch=backoff:30s,retries:5, with the fields retries, backoff, max-backoff, multiplier and jitter, overriding the default policy. The reasons are those of the batch error metric, encoding included",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "RETRY_POLICIES"),
+	}
+	BatchMaxLatencyFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "batch-max-latency"),
+		Usage:  "time budget of a batch from its creation to its confirmation, past which slow operators are given up and signing isn't retried. No budget if 0",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "BATCH_MAX_LATENCY"),
+	}
+	BatchMaxFeeFlag = cli.Uint64Flag{
+		Name:   common.PrefixFlag(FlagPrefix, "batch-max-fee-gwei"),
+		Usage:  "fee budget in gwei of the transactions of a batch, past which its confirmation isn't replaced with bumped fees. No budget if 0",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "BATCH_MAX_FEE_GWEI"),
+	}
+	BatchFeeBumpBelowFlag = cli.Float64Flag{
+		Name:   common.PrefixFlag(FlagPrefix, "batch-fee-bump-below"),
+		Usage:  "fraction of the time budget of a batch left below which its pending confirmation is replaced with bumped fees, never if 0. Requires dynamic fees",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "BATCH_FEE_BUMP_BELOW"),
+	}
+	EncoderBalancingFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "encoder-balancing"),
+		Usage:  "how the blobs are balanced across several encoders, round-robin or least-loaded",
+		Value:  batcher.EncoderRoundRobin,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "ENCODER_BALANCING"),
+	}
+	EncoderMaxFailuresFlag = cli.UintFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "encoder-max-failures"),
+		Usage:  "number of consecutive failed requests removing an encoder from the rotation until a health check succeeds",
+		Value:  3,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "ENCODER_MAX_FAILURES"),
+	}
+	EncoderHealthCheckIntervalFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "encoder-health-check-interval"),
+		Usage:  "how often the balanced encoders are probed",
+		Value:  10 * time.Second,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "ENCODER_HEALTH_CHECK_INTERVAL"),
+	}
+	EncoderBatchMaxBlobsFlag = cli.IntFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "encoder-batch-max-blobs"),
+		Usage:  "maximum number of small blobs sent to an encoder in a single request, not batched below 2. Encoders that don't support it are sent the blobs one at a time",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "ENCODER_BATCH_MAX_BLOBS"),
+	}
+	EncoderBatchMaxBlobSizeFlag = cli.IntFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "encoder-batch-max-blob-size"),
+		Usage:  "size in bytes of the largest blob batched with others",
+		Value:  64 * 1024,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "ENCODER_BATCH_MAX_BLOB_SIZE"),
+	}
+	EncoderBatchMaxWaitFlag = cli.DurationFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "encoder-batch-max-wait"),
+		Usage:  "how long a small blob waits for others to fill its request",
+		Value:  5 * time.Millisecond,
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "ENCODER_BATCH_MAX_WAIT"),
+	}
+	AdminHTTPPortFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "admin-http-port"),
+		Usage:  "port of the batcher admin api",
+		Value:  "9301",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "ADMIN_HTTP_PORT"),
+	}
+	AdminTokenFlag = cli.StringFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "admin-token"),
+		Usage:  "bearer token required by the batcher admin api. The admin api is disabled if empty",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "ADMIN_TOKEN"),
+	}
+	SafeModeFlag = cli.BoolFlag{
+		Name:   common.PrefixFlag(FlagPrefix, "safe-mode"),
+		Usage:  "start in safe mode: blobs are accepted and persisted but neither encoded nor dispatched until it is left through the admin api",
+		EnvVar: common.PrefixEnvVar(EnvVarPrefix, "SAFE_MODE"),
+	}
 
 	MetadataHashAsBlobKey = cli.BoolFlag{
 		Name:   common.PrefixFlag(FlagPrefix, "metadata-hash-as-blob-key"),
@@ -208,6 +807,8 @@ var OptionalFlags = []cli.Flag{
 	EncodingTimeoutFlag,
 	ChainReadTimeoutFlag,
 	ChainWriteTimeoutFlag,
+	StoreWriteTimeoutFlag,
+	ReceiptWaitTimeoutFlag,
 	NumConnectionsFlag,
 	FinalizerIntervalFlag,
 	EncodingRequestQueueSizeFlag,
@@ -220,9 +821,111 @@ var OptionalFlags = []cli.Flag{
 	SigningIntervalFlag,
 	MaxNumRetriesForSignFlag,
 	FinalizedBlockCountFlag,
+	ConfirmationDepthFlag,
 	ExpirationPollIntervalSecFlag,
 	MetadataHashAsBlobKey,
 	VerifiedCommitRootsTxGasLimitFlag,
+	SignerHedgingFlag,
+	SignerHedgingPercentileFlag,
+	SignerHedgingMinDelayFlag,
+	SignerHedgingMaxRatioFlag,
+	SecondaryEncoderSocketFlag,
+	EncoderSharedMemoryDirFlag,
+	EncoderCrossCheckRateFlag,
+	EncoderCrossCheckStrictFlag,
+	HashSuiteFlag,
+	EncodingJournalPathFlag,
+	EncodingCacheSizeFlag,
+	BatchJournalPathFlag,
+	PartialConfirmationFlag,
+	QuorumFallbackFlag,
+	ChunkFormatsFlag,
+	SignerDiscoveryFlag,
+	SignerDiscoveryTTLFlag,
+	SignerQUICFlag,
+	SignerQUICRetryAfterFlag,
+	InboxAddressFlag,
+	InboxABIFileFlag,
+	InboxMethodFlag,
+	InboxTxGasLimitFlag,
+	InboxMaxRetriesFlag,
+	InboxBlobMethodFlag,
+	InboxBlobModeFlag,
+	InboxBlobMinPayloadFlag,
+	InboxMaxPendingFlag,
+	InboxJournalPathFlag,
+	ConfirmBlobABIFileFlag,
+	ConfirmBlobMethodFlag,
+	ConfirmBlobModeFlag,
+	ConfirmBlobMinPayloadFlag,
+	DrainMaxBatchesPerMinuteFlag,
+	DrainRampUpDurationFlag,
+	DrainRampUpStartFlag,
+	DrainRampUpCurveFlag,
+	DrainOrderFlag,
+	BatchGasTargetFlag,
+	BatchGasQuorumsFlag,
+	BatchGasSignersFlag,
+	MinBatchBytesFlag,
+	MinBatchBlobsFlag,
+	MinBatchMaxWaitFlag,
+	BatchAlignBlocksFlag,
+	BatchAlignOffsetFlag,
+	BatchAlignPollIntervalFlag,
+	BatchSizeMinMBFlag,
+	BatchSizeMaxMBFlag,
+	BatchSizeReferenceGasPriceFlag,
+	BatchSizeBacklogTargetFlag,
+	BatchSizeLatencyTargetFlag,
+	BatchSizeIntervalFlag,
+	BatchPipelinesFlag,
+	DeadLetterProcessingTTLFlag,
+	DeadLetterSweepIntervalFlag,
+	InFlightTimeoutFlag,
+	FairnessReportIntervalFlag,
+	FairnessToleranceFlag,
+	FairnessSustainedBatchesFlag,
+	DispatchDeadlineFlag,
+	QuorumDispatchDeadlinesFlag,
+	BandwidthProbeIntervalFlag,
+	BandwidthProbeSizeFlag,
+	WatchdogSampleSizeFlag,
+	WatchdogExclusionPeriodFlag,
+	FallbackRPCURLFlag,
+	FallbackDAEntranceContractAddressFlag,
+	FallbackDASignersContractAddressFlag,
+	FallbackHaltTimeoutFlag,
+	FallbackFinalityDepthFlag,
+	HealthMaxConfirmerBacklogFlag,
+	HealthMaxDispersalFailuresFlag,
+	SpendLimitHourlyGweiFlag,
+	SpendLimitDailyGweiFlag,
+	SpendSpikeFactorFlag,
+	SpendSpikeWindowFlag,
+	SpendJournalPathFlag,
+	SpendAlertURLFlag,
+	StatusPageBucketFlag,
+	StatusPagePrefixFlag,
+	StatusPageIntervalFlag,
+	StatusPageWindowFlag,
+	StatusPageStaleAfterFlag,
+	RetryBackoffFlag,
+	RetryMaxBackoffFlag,
+	RetryBackoffMultiplierFlag,
+	RetryJitterFlag,
+	RetryPoliciesFlag,
+	BatchMaxLatencyFlag,
+	BatchMaxFeeFlag,
+	BatchFeeBumpBelowFlag,
+	EncoderBalancingFlag,
+	EncoderMaxFailuresFlag,
+	EncoderHealthCheckIntervalFlag,
+	EncoderBatchMaxBlobsFlag,
+	EncoderBatchMaxBlobSizeFlag,
+	EncoderBatchMaxWaitFlag,
+	AdminHTTPPortFlag,
+	AdminTokenFlag,
+	SafeModeFlag,
 }
 
 // Flags contains the list of configuration options available to the binary.
@@ -231,7 +934,19 @@ var Flags []cli.Flag
 func init() {
 	Flags = append(RequiredFlags, OptionalFlags...)
 	Flags = append(Flags, geth.EthClientFlags(EnvVarPrefix)...)
+	Flags = append(Flags, contract.FeeCLIFlags(EnvVarPrefix)...)
+	Flags = append(Flags, transactor.SenderPoolCLIFlags(EnvVarPrefix)...)
 	Flags = append(Flags, logging.CLIFlags(EnvVarPrefix, FlagPrefix)...)
 	Flags = append(Flags, aws.ClientFlags(EnvVarPrefix, FlagPrefix)...)
 	Flags = append(Flags, storage_node.ClientFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, blobstore.ReplicationCLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, pgstore.CLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, indexer.CLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, lifecycle.CLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, healthcheck.CLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, reload.CLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, approval.CLIFlags(AdminApprovalEnvVarPrefix, AdminApprovalFlagPrefix)...)
+	Flags = append(Flags, tlsconfig.ClientCLIFlags(EncoderTLSEnvVarPrefix, EncoderTLSFlagPrefix)...)
+	Flags = append(Flags, tlsconfig.ClientCLIFlags(SignerTLSEnvVarPrefix, SignerTLSFlagPrefix)...)
+	Flags = append(Flags, tracing.CLIFlags(EnvVarPrefix, FlagPrefix, "zgda-batcher")...)
 }
@@ -2,22 +2,31 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/0glabs/0g-da-client/common/aws/dynamodb"
 	"github.com/0glabs/0g-da-client/common/aws/s3"
 	"github.com/0glabs/0g-da-client/common/geth"
+	"github.com/0glabs/0g-da-client/common/healthcheck"
+	"github.com/0glabs/0g-da-client/common/lifecycle"
 	"github.com/0glabs/0g-da-client/common/logging"
+	"github.com/0glabs/0g-da-client/common/tlsconfig"
+	"github.com/0glabs/0g-da-client/common/tracing"
+	"github.com/0glabs/0g-da-client/core"
 	"github.com/0glabs/0g-da-client/disperser"
 	"github.com/0glabs/0g-da-client/disperser/batcher"
 	"github.com/0glabs/0g-da-client/disperser/batcher/dispatcher"
 	"github.com/0glabs/0g-da-client/disperser/batcher/transactor"
 	"github.com/0glabs/0g-da-client/disperser/cmd/batcher/flags"
 	"github.com/0glabs/0g-da-client/disperser/common/blobstore"
+	"github.com/0glabs/0g-da-client/disperser/common/pgstore"
 	"github.com/0glabs/0g-da-client/disperser/contract"
 	"github.com/0glabs/0g-da-client/disperser/encoder"
+	"github.com/0glabs/0g-da-client/disperser/indexer"
 	eth_common "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/urfave/cli"
@@ -43,17 +52,21 @@ func main() {
 	if err != nil {
 		log.Fatalf("application failed: %v", err)
 	}
-
-	select {}
 }
 
 func RunBatcher(ctx *cli.Context) error {
-	config := NewConfig(ctx)
+	config, err := NewConfig(ctx)
+	if err != nil {
+		return err
+	}
 
 	logger, err := logging.GetLogger(config.LoggerConfig)
 	if err != nil {
 		return err
 	}
+	manager := lifecycle.NewManager(config.LifecycleConfig, logger)
+	// started first, the components of the store are added as the store is built
+	var storeComponents []lifecycle.Component
 
 	daEntranceAddress := eth_common.HexToAddress(config.BatcherConfig.DAEntranceContractAddress)
 	daSignersAddress := eth_common.HexToAddress(config.BatcherConfig.DASignersContractAddress)
@@ -65,10 +78,20 @@ func RunBatcher(ctx *cli.Context) error {
 	// transactor
 	transactor := transactor.NewTransactor(config.BatcherConfig.VerifiedCommitRootsTxGasLimit, logger)
 	// dispatcher
-	dispatcher, err := dispatcher.NewDispatcher(transactor, daContract, logger)
+	hashSuite, err := core.GetHashSuite(config.BatcherConfig.HashSuite)
+	if err != nil {
+		return err
+	}
+	dispatcher, err := dispatcher.NewDispatcher(transactor, daContract, hashSuite, logger)
 	if err != nil {
 		return err
 	}
+	if config.BlobConfig.Enabled() {
+		if err := dispatcher.EnableBlobs(config.BlobConfig, daEntranceAddress); err != nil {
+			return err
+		}
+		logger.Info("Submitting aggregate signatures in blob transactions", "method", config.BlobConfig.Method, "mode", config.BlobConfig.Mode)
+	}
 
 	// eth clients
 	client, err := geth.NewClient(config.EthClientConfig, logger)
@@ -81,25 +104,136 @@ func RunBatcher(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	chainReady := func(ctx context.Context) error {
+		_, err := client.GetCurrentBlockNumber(ctx)
+		return err
+	}
+	chainComponent := lifecycle.Component{Name: "chain", Start: func(ctx context.Context) error {
+		return lifecycle.Poll(ctx, time.Second, chainReady)
+	}}
+	manager.AddReadiness("chain", chainReady)
+
+	// chain event indexer
+	var indexerComponent *lifecycle.Component
+	if config.IndexerConfig.Enabled() {
+		sinks, err := indexer.NewSinks(config.IndexerConfig)
+		if err != nil {
+			return err
+		}
+		eventIndexer, err := indexer.NewIndexer(config.IndexerConfig, client, daEntranceAddress, daSignersAddress, sinks, logger)
+		if err != nil {
+			return err
+		}
+		indexerComponent = &lifecycle.Component{Name: "indexer", StartTimeout: config.IndexerConfig.ReadyTimeout, Start: func(ctx context.Context) error {
+			if err := eventIndexer.Start(ctx); err != nil {
+				return err
+			}
+			logger.Info("Indexing chain events", "sinks", config.IndexerConfig.Sinks, "startBlock", config.IndexerConfig.StartBlock)
+			// the batcher starts once the indexer caught up with the chain
+			return lifecycle.Poll(ctx, config.IndexerConfig.PollInterval, eventIndexer.Ready)
+		}}
+		manager.AddReadiness("indexer", eventIndexer.Ready)
+	}
 
 	// blob store
 	var queue disperser.BlobStore
 
-	bucketName := config.BlobstoreConfig.BucketName
-	s3Client, err := s3.NewClient(config.AwsClientConfig, logger)
-	if err != nil {
-		return err
+	if config.PostgresConfig.Enabled() {
+		if config.ReplicationConfig.Enabled() {
+			return errors.New("blob metadata replication requires the dynamodb blob store")
+		}
+		logger.Info("Creating postgres blob store")
+		pgStore, err := pgstore.Open(context.Background(), config.PostgresConfig, config.BlobstoreConfig.MetadataHashAsBlobKey, logger)
+		if err != nil {
+			return err
+		}
+		queue = pgStore
+	} else {
+		bucketName := config.BlobstoreConfig.BucketName
+		s3Client, err := s3.NewClient(config.AwsClientConfig, logger)
+		if err != nil {
+			return err
+		}
+		logger.Info("Initialized S3 client", "bucket", bucketName)
+
+		dynamoClient, err := dynamodb.NewClient(config.AwsClientConfig, logger)
+		if err != nil {
+			return err
+		}
+		blobMetadataStore := blobstore.NewBlobMetadataStore(dynamoClient, logger, config.BlobstoreConfig.TableName, 0)
+		queue = blobstore.NewSharedStorage(bucketName, s3Client, config.BlobstoreConfig.MetadataHashAsBlobKey, blobMetadataStore, logger)
+		if config.ReplicationConfig.Enabled() {
+			replicaMetadataStore, err := blobstore.NewReplicaMetadataStore(config.ReplicationConfig, config.AwsClientConfig, logger)
+			if err != nil {
+				return err
+			}
+			replicator := blobstore.NewMetadataReplicator(blobMetadataStore, replicaMetadataStore, config.ReplicationConfig, logger)
+			storeComponents = append(storeComponents, lifecycle.Component{Name: "replicator", Start: func(ctx context.Context) error {
+				replicator.Start(ctx)
+				return nil
+			}})
+			queue = blobstore.NewReplicatedBlobStore(queue, replicator)
+			logger.Info("Replicating blob metadata", "region", config.ReplicationConfig.Region, "table", config.ReplicationConfig.TableName)
+		}
 	}
-	logger.Info("Initialized S3 client", "bucket", bucketName)
 
-	dynamoClient, err := dynamodb.NewClient(config.AwsClientConfig, logger)
-	if err != nil {
-		return err
+	if config.ExportConfig.Enabled() {
+		exporter, err := pgstore.OpenExporter(context.Background(), config.ExportConfig, queue, logger)
+		if err != nil {
+			return err
+		}
+		storeComponents = append(storeComponents, lifecycle.Component{Name: "exporter", Start: func(ctx context.Context) error {
+			exporter.Start(ctx)
+			return nil
+		}})
+		queue = pgstore.NewExportedBlobStore(queue, exporter)
+		logger.Info("Exporting blob metadata to the analytics database", "interval", config.ExportConfig.Interval)
 	}
-	blobMetadataStore := blobstore.NewBlobMetadataStore(dynamoClient, logger, config.BlobstoreConfig.TableName, 0)
-	queue = blobstore.NewSharedStorage(bucketName, s3Client, config.BlobstoreConfig.MetadataHashAsBlobKey, blobMetadataStore, logger)
 
 	metrics := batcher.NewMetrics(config.MetricsConfig.HTTPPort, logger)
+	var sendersComponent *lifecycle.Component
+	if config.FeeConfig.EIP1559 {
+		if err := daContract.EnableDynamicFees(config.FeeConfig, metrics, logger); err != nil {
+			return err
+		}
+		logger.Info("Sending EIP-1559 transactions", "replaceTimeout", config.FeeConfig.ReplaceTimeout, "maxFeeCap", config.FeeConfig.MaxFeeCap)
+	}
+
+	if config.SenderPoolConfig.Enabled() {
+		senders := make([]*contract.DAContract, len(config.SenderPoolConfig.PrivateKeys))
+		for i, key := range config.SenderPoolConfig.PrivateKeys {
+			senders[i], err = contract.NewDAContract(daEntranceAddress, daSignersAddress, config.EthClientConfig.RPCURL, key)
+			if err != nil {
+				return fmt.Errorf("failed to create DAEntrance contract of sender %d: %w", i, err)
+			}
+			if config.FeeConfig.EIP1559 {
+				if err := senders[i].EnableDynamicFees(config.FeeConfig, metrics, logger); err != nil {
+					return err
+				}
+			}
+		}
+		if err := transactor.SetSenderPool(config.SenderPoolConfig, daContract, senders, metrics); err != nil {
+			return err
+		}
+		sendersComponent = &lifecycle.Component{Name: "senders", Start: func(ctx context.Context) error {
+			transactor.StartHealthChecks(ctx)
+			return nil
+		}}
+		logger.Info("Rotating transactions across sender accounts", "senders", len(senders)+1, "minBalance", config.SenderPoolConfig.MinBalance)
+	}
+	if config.SenderPoolConfig.BlobPrivateKey != "" {
+		blobSender, err := contract.NewDAContract(daEntranceAddress, daSignersAddress, config.EthClientConfig.RPCURL, config.SenderPoolConfig.BlobPrivateKey)
+		if err != nil {
+			return fmt.Errorf("failed to create DAEntrance contract of the blob sender: %w", err)
+		}
+		if config.FeeConfig.EIP1559 {
+			if err := blobSender.EnableDynamicFees(config.FeeConfig, metrics, logger); err != nil {
+				return err
+			}
+		}
+		transactor.SetBlobSender(daContract, blobSender, metrics)
+		logger.Info("Sending blob transactions from the blob sender account", "account", blobSender.Account())
+	}
 
 	// Create new store
 	kvStore, err := disperser.NewLevelDBStore(config.StorageNodeConfig.KvDbPath+"/chunk", config.StorageNodeConfig.TimeToExpire, logger)
@@ -109,19 +243,78 @@ func RunBatcher(ctx *cli.Context) error {
 	}
 
 	// encoder
-	if len(config.BatcherConfig.EncoderSocket) == 0 {
+	var encodersComponent *lifecycle.Component
+	if len(config.BatcherConfig.EncoderSockets()) == 0 {
 		return fmt.Errorf("encoder socket must be specified")
 	}
-	encoderClient, err := encoder.NewEncoderClient(config.BatcherConfig.EncoderSocket, config.TimeoutConfig.EncodingTimeout)
+	encoderSockets := config.BatcherConfig.EncoderSockets()
+	encoderCreds, err := tlsconfig.ClientCredentials(config.BatcherConfig.EncoderTLS, logger)
 	if err != nil {
 		return err
 	}
+	endpoints := make([]batcher.EncoderEndpoint, len(encoderSockets))
+	for i, socket := range encoderSockets {
+		client, err := encoder.NewEncoderClient(socket, config.TimeoutConfig.EncodingTimeout, config.BatcherConfig.NumConnections, config.BatcherConfig.EncoderSharedMemoryDir, config.BatcherConfig.EncoderBatching, encoderCreds, metrics.EncodingStreamerMetrics)
+		if err != nil {
+			return err
+		}
+		endpoints[i] = batcher.EncoderEndpoint{Addr: socket, Client: client}
+	}
+	encoderClient := endpoints[0].Client
+	if len(endpoints) > 1 {
+		probe := func(ctx context.Context, addr string) error {
+			return encoder.Probe(ctx, addr, config.TimeoutConfig.EncodingTimeout, encoderCreds)
+		}
+		balancer, err := batcher.NewBalancedEncoderClient(endpoints, config.BatcherConfig.EncoderBalancer, probe, metrics, logger)
+		if err != nil {
+			return err
+		}
+		encoderClient = balancer
+		encodersComponent = &lifecycle.Component{Name: "encoders", Start: func(ctx context.Context) error {
+			balancer.StartHealthChecks(ctx)
+			return nil
+		}}
+		logger.Info("Balancing blobs across encoders", "encoders", encoderSockets, "strategy", config.BatcherConfig.EncoderBalancer.Strategy)
+	}
+	if config.BatcherConfig.EncoderCrossCheck.SecondaryEncoderSocket != "" {
+		secondaryClient, err := encoder.NewEncoderClient(config.BatcherConfig.EncoderCrossCheck.SecondaryEncoderSocket, config.TimeoutConfig.EncodingTimeout, config.BatcherConfig.NumConnections, "", encoder.BatchingConfig{}, encoderCreds, metrics.EncodingStreamerMetrics)
+		if err != nil {
+			return err
+		}
+		encoderClient = batcher.NewCrossCheckEncoderClient(encoderClient, secondaryClient, config.BatcherConfig.EncoderCrossCheck, metrics, logger)
+	}
+
+	// health
+	var monitor *healthcheck.Monitor
+	if config.HealthConfig.Enabled() {
+		monitor = healthcheck.NewMonitor(config.HealthConfig, metrics.Registry(), "zgda_batcher", logger)
+		monitor.Add("encoders", batcher.EncodersHealth(encoderSockets, func(ctx context.Context, addr string) error {
+			return encoder.Probe(ctx, addr, config.TimeoutConfig.EncodingTimeout, encoderCreds)
+		}))
+		monitor.Add("chain", chainReady)
+		monitor.Add("blob store", batcher.BlobStoreHealth(queue))
+	}
 
 	// confirmer
-	confirmer, err := batcher.NewConfirmer(config.EthClientConfig, config.BatcherConfig, queue, daContract, logger, metrics)
+	confirmer, err := batcher.NewBatchConfirmer(config.EthClientConfig, config.BatcherConfig, queue, batcher.NewChainConfirmer(daContract, config.EthClientConfig), logger, metrics)
 	if err != nil {
 		return err
 	}
+	if config.BatcherConfig.Poster.Enabled() {
+		confirmer.Poster, err = batcher.NewPoster(config.BatcherConfig.Poster, config.EthClientConfig, daContract, transactor, logger, metrics)
+		if err != nil {
+			return err
+		}
+		logger.Info("Posting certificates to rollup inbox", "address", config.BatcherConfig.Poster.InboxAddress, "method", config.BatcherConfig.Poster.InboxMethod, "blob method", config.BatcherConfig.Poster.BlobMethod)
+	}
+	if config.BatcherConfig.StatusPage.Enabled() {
+		s3Client, err := s3.NewClient(config.AwsClientConfig, logger)
+		if err != nil {
+			return err
+		}
+		confirmer.StatusPage = batcher.NewStatusPage(config.BatcherConfig.StatusPage, s3Client, logger)
+		logger.Info("Pushing status page", "bucket", config.BatcherConfig.StatusPage.Bucket, "prefix", config.BatcherConfig.StatusPage.Prefix)
+	}
 
 	blobKeyCache := disperser.BlobKeyCache{
 		Key:   make(map[[32]byte]bool),
@@ -137,13 +330,25 @@ func RunBatcher(ctx *cli.Context) error {
 		blobKeyCache.Add(metadata.Hash(), metadata.Epoch)
 	}
 	iter.Release()
+	tracer, err := tracing.NewTracer(config.TracingConfig, logger)
+	if err != nil {
+		return err
+	}
 	//finalizer
-	finalizer := batcher.NewFinalizer(config.TimeoutConfig.ChainReadTimeout, config.BatcherConfig, queue, client, rpcClient, logger, kvStore, &blobKeyCache)
+	finalizer := batcher.NewFinalizer(config.TimeoutConfig, config.BatcherConfig, queue, client, rpcClient, logger, kvStore, &blobKeyCache, metrics, tracer)
+
+	var fallback *batcher.ConfirmationFallback
+	if config.BatcherConfig.Fallback.Enabled() {
+		fallback, err = batcher.ConnectConfirmationFallback(config.BatcherConfig.Fallback, config.EthClientConfig, config.BatcherConfig.VerifiedCommitRootsTxGasLimit, hashSuite, client, daEntranceAddress, metrics, logger)
+		if err != nil {
+			return err
+		}
+		logger.Info("Dispersing the batches to a fallback deployment while the primary chain is halted", "rpc", config.BatcherConfig.Fallback.RPCURL, "address", config.BatcherConfig.Fallback.DAEntranceContractAddress)
+	}
 
 	//batcher
 	batcher, err := batcher.NewBatcher(config.BatcherConfig,
 		config.TimeoutConfig,
-		config.EthClientConfig,
 		queue,
 		dispatcher,
 		encoderClient,
@@ -156,19 +361,58 @@ func RunBatcher(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	batcher.Blocks = client
+	batcher.GasPrices = client
+	batcher.Tracer = tracer
+	batcher.ConfirmationFallback = fallback
 
+	// the tracer is started first so it is stopped last, flushing the spans of the others
+	if tracer != nil {
+		manager.Add(lifecycle.Component{Name: "tracing", Start: func(ctx context.Context) error {
+			tracer.Start(ctx)
+			return nil
+		}, Stop: tracer.Stop})
+		logger.Info("Exporting traces", "endpoint", config.TracingConfig.Endpoint)
+	}
+	manager.Add(storeComponents...)
+	manager.Add(chainComponent)
+	if indexerComponent != nil {
+		manager.Add(*indexerComponent)
+	}
+	if sendersComponent != nil {
+		manager.Add(*sendersComponent)
+	}
+	if encodersComponent != nil {
+		manager.Add(*encodersComponent)
+	}
 	// Enable Metrics Block
 	if config.MetricsConfig.EnableMetrics {
-		httpSocket := fmt.Sprintf(":%s", config.MetricsConfig.HTTPPort)
-		metrics.Start(context.Background())
-		logger.Info("Enabled metrics for Batcher", "socket", httpSocket)
+		manager.Add(lifecycle.Component{Name: "metrics", Start: func(ctx context.Context) error {
+			metrics.Start(ctx)
+			logger.Info("Enabled metrics for Batcher", "socket", fmt.Sprintf(":%s", config.MetricsConfig.HTTPPort))
+			return nil
+		}})
 	}
-
-	err = batcher.Start(context.Background())
-	if err != nil {
-		return err
+	if config.ReloadConfig.Enabled() {
+		reloader := disperser.NewTunablesReloader(config.ReloadConfig, config.LoggerConfig, logger)
+		reloader.OnReload(batcher.Tune)
+		manager.Add(lifecycle.Component{Name: "reload", Start: reloader.Start})
+		logger.Info("Reloading tunables on SIGHUP", "file", config.ReloadConfig.File, "url", config.ReloadConfig.URL)
+	}
+	manager.Add(
+		lifecycle.Component{Name: "streamer", Start: batcher.StartEncoding, Stop: batcher.StopEncoding},
+		lifecycle.Component{Name: "confirmer", Start: batcher.StartConfirmation, Stop: batcher.StopConfirmation},
+		lifecycle.Component{Name: "batcher", Start: batcher.StartBatching, Stop: batcher.StopBatching},
+	)
+	if monitor != nil {
+		batcher.AddHealthChecks(monitor)
+		manager.HandleProbe("/health", monitor.HandleHealth)
+		manager.HandleProbe("/livez", monitor.HandleLivez)
+		manager.Add(lifecycle.Component{Name: "health", Start: func(ctx context.Context) error {
+			monitor.Start(ctx)
+			return nil
+		}})
 	}
 
-	return nil
-
+	return manager.Run(context.Background())
 }
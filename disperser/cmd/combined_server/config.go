@@ -1,18 +1,37 @@
 package main
 
 import (
+	"fmt"
+
+	"github.com/0glabs/0g-da-client/common/approval"
 	"github.com/0glabs/0g-da-client/common/aws"
 	"github.com/0glabs/0g-da-client/common/geth"
+	"github.com/0glabs/0g-da-client/common/healthcheck"
+	"github.com/0glabs/0g-da-client/common/interceptors"
+	"github.com/0glabs/0g-da-client/common/lifecycle"
 	"github.com/0glabs/0g-da-client/common/logging"
 	"github.com/0glabs/0g-da-client/common/ratelimit"
+	"github.com/0glabs/0g-da-client/common/reload"
 	"github.com/0glabs/0g-da-client/common/storage_node"
+	"github.com/0glabs/0g-da-client/common/tlsconfig"
+	"github.com/0glabs/0g-da-client/common/tracing"
+	"github.com/0glabs/0g-da-client/core"
 	"github.com/0glabs/0g-da-client/disperser"
 	"github.com/0glabs/0g-da-client/disperser/apiserver"
 	"github.com/0glabs/0g-da-client/disperser/batcher"
+	"github.com/0glabs/0g-da-client/disperser/batcher/dispatcher"
+	"github.com/0glabs/0g-da-client/disperser/batcher/transactor"
 	server_flags "github.com/0glabs/0g-da-client/disperser/cmd/apiserver/flags"
 	batcher_flags "github.com/0glabs/0g-da-client/disperser/cmd/batcher/flags"
 	"github.com/0glabs/0g-da-client/disperser/cmd/combined_server/flags"
 	"github.com/0glabs/0g-da-client/disperser/common/blobstore"
+	"github.com/0glabs/0g-da-client/disperser/common/pgstore"
+	"github.com/0glabs/0g-da-client/disperser/contract"
+	"github.com/0glabs/0g-da-client/disperser/encoder"
+	"github.com/0glabs/0g-da-client/disperser/indexer"
+	"github.com/0glabs/0g-da-client/disperser/payments"
+	"github.com/0glabs/0g-da-client/disperser/signer"
+	eth_common "github.com/ethereum/go-ethereum/common"
 	"github.com/urfave/cli"
 )
 
@@ -20,17 +39,36 @@ type Config struct {
 	// api server
 	AwsClientConfig   aws.ClientConfig
 	BlobstoreConfig   blobstore.Config
+	ReplicationConfig blobstore.ReplicationConfig
+	PostgresConfig    pgstore.Config
+	ExportConfig      pgstore.ExportConfig
+	LifecycleConfig   lifecycle.Config
+	HealthConfig      healthcheck.Config
+	ReloadConfig      reload.Config
+	IndexerConfig     indexer.Config
+	TracingConfig     tracing.Config
 	ServerConfig      disperser.ServerConfig
 	LoggerConfig      logging.Config
 	MetricsConfig     disperser.MetricsConfig
 	RatelimiterConfig ratelimit.Config
 	RateConfig        apiserver.RateConfig
+	PricingConfig     apiserver.PricingConfig
+	QuarantineConfig  apiserver.QuarantineConfig
+	PaymentsConfig    payments.Config
+	DeadLetterConfig  apiserver.DeadLetterConfig
 	StorageNodeConfig storage_node.ClientConfig
 	EthClientConfig   geth.EthClientConfig
+	FeeConfig         contract.FeeConfig
+	BlobConfig        dispatcher.BlobConfig
+	SenderPoolConfig  transactor.SenderPoolConfig
 	EnableRatelimiter bool
 	BucketTableName   string
 	BucketStoreSize   int
 	RetrieverAddr     string
+	// QuorumRPC and DASignersContract read the quorums the requested security params are
+	// checked against
+	QuorumRPC         string
+	DASignersContract string
 	// batcher
 	BatcherConfig batcher.Config
 	TimeoutConfig batcher.TimeoutConfig
@@ -38,6 +76,11 @@ type Config struct {
 
 func NewConfig(ctx *cli.Context) (Config, error) {
 
+	tracingConfig, err := tracing.ReadCLIConfig(ctx, flags.FlagPrefix)
+	if err != nil {
+		return Config{}, err
+	}
+
 	ratelimiterConfig, err := ratelimit.ReadCLIConfig(ctx, server_flags.FlagPrefix)
 	if err != nil {
 		return Config{}, err
@@ -48,13 +91,116 @@ func NewConfig(ctx *cli.Context) (Config, error) {
 		return Config{}, err
 	}
 
+	pricingConfig, err := apiserver.ReadPricingConfig(ctx, server_flags.FlagPrefix)
+	if err != nil {
+		return Config{}, err
+	}
+
+	quotaConfig, err := apiserver.ReadQuotaConfig(ctx, server_flags.FlagPrefix)
+	if err != nil {
+		return Config{}, err
+	}
+
+	retrievalQuotaConfig, err := apiserver.ReadRetrievalQuotaConfig(ctx, server_flags.FlagPrefix)
+	if err != nil {
+		return Config{}, err
+	}
+
+	paymentsConfig, err := payments.ReadConfig(ctx, server_flags.FlagPrefix)
+	if err != nil {
+		return Config{}, err
+	}
+	paymentsConfig.Fees = contract.ReadFeeConfig(ctx)
+
+	deadLetterConfig, err := apiserver.ReadDeadLetterConfig(ctx, server_flags.FlagPrefix)
+	if err != nil {
+		return Config{}, err
+	}
+	quarantineConfig, err := apiserver.ReadQuarantineConfig(ctx, server_flags.FlagPrefix)
+	if err != nil {
+		return Config{}, err
+	}
+
+	adminApproval, err := approval.ReadCLIConfig(ctx, batcher_flags.AdminApprovalFlagPrefix)
+	if err != nil {
+		return Config{}, err
+	}
+
+	replicaID := ctx.GlobalUint(server_flags.ReplicaIDFlag.Name)
+	if replicaID > disperser.MaxReplicaID {
+		return Config{}, fmt.Errorf("replica id %d exceeds %d", replicaID, disperser.MaxReplicaID)
+	}
+
+	dispersalContract := ctx.GlobalString(server_flags.DispersalContractFlag.Name)
+	if dispersalContract != "" && !eth_common.IsHexAddress(dispersalContract) {
+		return Config{}, fmt.Errorf("invalid dispersal contract address %q", dispersalContract)
+	}
+	dispersalDomain := core.DispersalDomain{
+		ChainID:  ctx.GlobalUint64(server_flags.DispersalChainIDFlag.Name),
+		Contract: eth_common.HexToAddress(dispersalContract),
+	}
+
 	config := Config{
 		// api server
 		AwsClientConfig: aws.ReadClientConfig(ctx, flags.FlagPrefix),
 		ServerConfig: disperser.ServerConfig{
-			GrpcPort: ctx.GlobalString(server_flags.GrpcPortFlag.Name),
+			GrpcPort:  ctx.GlobalString(server_flags.GrpcPortFlag.Name),
+			HTTPPort:  ctx.GlobalString(server_flags.HTTPPortFlag.Name),
+			HTTPHost:  ctx.GlobalString(server_flags.HTTPHostFlag.Name),
+			ReplicaID: uint16(replicaID),
+			EncoderParams: core.EncoderParams{
+				BlobCapacity: core.MaxBlobSize,
+				SliceSize:    ctx.GlobalUint(server_flags.EncoderSliceSizeFlag.Name),
+			},
+			Interceptors:                  interceptors.ReadCLIConfig(ctx, server_flags.FlagPrefix),
+			Guards:                        interceptors.ReadGuardCLIConfig(ctx, server_flags.FlagPrefix),
+			PriorityAccounts:              ctx.GlobalStringSlice(server_flags.PriorityAccountsFlag.Name),
+			RequireSignatures:             ctx.GlobalBool(server_flags.RequireSignaturesFlag.Name),
+			DispersalDomain:               dispersalDomain,
+			GatewayToken:                  ctx.GlobalString(server_flags.GatewayTokenFlag.Name),
+			StatusSubscriptionInterval:    ctx.GlobalDuration(server_flags.StatusSubscriptionIntervalFlag.Name),
+			MaxStatusSubscriptions:        ctx.GlobalInt(server_flags.MaxStatusSubscriptionsFlag.Name),
+			MaxClientStatusSubscriptions:  ctx.GlobalInt(server_flags.MaxClientStatusSubscriptionsFlag.Name),
+			MaxStatusSubscriptionDuration: ctx.GlobalDuration(server_flags.MaxStatusSubscriptionDurationFlag.Name),
+			Dedup: disperser.DedupConfig{
+				Window:     ctx.GlobalDuration(server_flags.DedupWindowFlag.Name),
+				MaxEntries: ctx.GlobalInt(server_flags.DedupMaxEntriesFlag.Name),
+			},
+			Retention: disperser.RetentionConfig{
+				PeriodBlocks:   ctx.GlobalUint64(server_flags.RetentionPeriodBlocksFlag.Name),
+				PeriodContract: ctx.GlobalString(server_flags.RetentionPeriodContractFlag.Name),
+				PeriodMethod:   ctx.GlobalString(server_flags.RetentionPeriodMethodFlag.Name),
+				BlockTime:      ctx.GlobalDuration(server_flags.RetentionBlockTimeFlag.Name),
+				RenewalWindow:  ctx.GlobalDuration(server_flags.RetentionRenewalWindowFlag.Name),
+			},
+			SecurityParams: disperser.SecurityParamLimits{
+				MinQuorumThreshold: uint8(ctx.GlobalUint(server_flags.MinQuorumThresholdFlag.Name)),
+				MinThresholdGap:    uint8(ctx.GlobalUint(server_flags.MinThresholdGapFlag.Name)),
+			},
+			Quotas:          quotaConfig,
+			RetrievalQuotas: retrievalQuotaConfig,
+			TLS:             tlsconfig.ReadServerCLIConfig(ctx, server_flags.FlagPrefix),
+			RetrieverTLS:    tlsconfig.ReadClientCLIConfig(ctx, server_flags.RetrieverTLSFlagPrefix),
 		},
 		EthClientConfig: geth.ReadEthClientConfig(ctx),
+		BlobConfig: dispatcher.BlobConfig{
+			ABIFile: ctx.GlobalString(batcher_flags.ConfirmBlobABIFileFlag.Name),
+			Method:  ctx.GlobalString(batcher_flags.ConfirmBlobMethodFlag.Name),
+			BlobPolicy: contract.BlobPolicy{
+				Mode:       ctx.GlobalString(batcher_flags.ConfirmBlobModeFlag.Name),
+				MinPayload: ctx.GlobalInt(batcher_flags.ConfirmBlobMinPayloadFlag.Name),
+			},
+		},
+		FeeConfig:         contract.ReadFeeConfig(ctx),
+		SenderPoolConfig:  transactor.ReadSenderPoolConfig(ctx),
+		ReplicationConfig: blobstore.ReadReplicationConfig(ctx, flags.FlagPrefix),
+		PostgresConfig:    pgstore.ReadCLIConfig(ctx, flags.FlagPrefix),
+		ExportConfig:      pgstore.ReadExportConfig(ctx, flags.FlagPrefix),
+		LifecycleConfig:   lifecycle.ReadCLIConfig(ctx, flags.FlagPrefix),
+		HealthConfig:      healthcheck.ReadCLIConfig(ctx, flags.FlagPrefix),
+		ReloadConfig:      reload.ReadCLIConfig(ctx, flags.FlagPrefix),
+		IndexerConfig:     indexer.ReadConfig(ctx, flags.FlagPrefix),
+		TracingConfig:     tracingConfig,
 		BlobstoreConfig: blobstore.Config{
 			BucketName:            ctx.GlobalString(server_flags.S3BucketNameFlag.Name),
 			TableName:             ctx.GlobalString(server_flags.DynamoDBTableNameFlag.Name),
@@ -69,16 +215,23 @@ func NewConfig(ctx *cli.Context) (Config, error) {
 		},
 		RatelimiterConfig: ratelimiterConfig,
 		RateConfig:        rateConfig,
+		PricingConfig:     pricingConfig,
+		PaymentsConfig:    paymentsConfig,
+		QuarantineConfig:  quarantineConfig,
+		DeadLetterConfig:  deadLetterConfig,
 		EnableRatelimiter: ctx.GlobalBool(server_flags.EnableRatelimiter.Name),
 		BucketTableName:   ctx.GlobalString(server_flags.BucketTableName.Name),
 		BucketStoreSize:   ctx.GlobalInt(server_flags.BucketStoreSize.Name),
 		StorageNodeConfig: storage_node.ReadClientConfig(ctx, flags.FlagPrefix),
 		RetrieverAddr:     ctx.GlobalString(server_flags.RetrieverAddrName.Name),
+		QuorumRPC:         ctx.GlobalString(server_flags.QuorumRPCFlag.Name),
+		DASignersContract: ctx.GlobalString(server_flags.DASignersContractFlag.Name),
 		// batcher
 		BatcherConfig: batcher.Config{
 			PullInterval:                  ctx.GlobalDuration(batcher_flags.PullIntervalFlag.Name),
 			FinalizerInterval:             ctx.GlobalDuration(batcher_flags.FinalizerIntervalFlag.Name),
 			EncoderSocket:                 ctx.GlobalString(batcher_flags.EncoderSocket.Name),
+			EncoderSharedMemoryDir:        ctx.GlobalString(batcher_flags.EncoderSharedMemoryDirFlag.Name),
 			NumConnections:                ctx.GlobalInt(batcher_flags.NumConnectionsFlag.Name),
 			EncodingRequestQueueSize:      ctx.GlobalInt(batcher_flags.EncodingRequestQueueSizeFlag.Name),
 			BatchSizeMBLimit:              ctx.GlobalUint(batcher_flags.BatchSizeLimitFlag.Name),
@@ -90,16 +243,173 @@ func NewConfig(ctx *cli.Context) (Config, error) {
 			SigningInterval:               ctx.GlobalDuration(batcher_flags.SigningIntervalFlag.Name),
 			MaxNumRetriesForSign:          ctx.GlobalUint(batcher_flags.MaxNumRetriesForSignFlag.Name),
 			FinalizedBlockCount:           ctx.GlobalUint(batcher_flags.FinalizedBlockCountFlag.Name),
+			ConfirmationDepth:             ctx.GlobalUint(batcher_flags.ConfirmationDepthFlag.Name),
 			ExpirationPollIntervalSec:     ctx.GlobalUint64(batcher_flags.ExpirationPollIntervalSecFlag.Name),
 			SignedPullInterval:            ctx.GlobalDuration(batcher_flags.SignedPullIntervalFlag.Name),
 			VerifiedCommitRootsTxGasLimit: ctx.GlobalUint64(batcher_flags.VerifiedCommitRootsTxGasLimitFlag.Name),
+			SignerHedging: batcher.HedgingConfig{
+				Enabled:    ctx.GlobalBool(batcher_flags.SignerHedgingFlag.Name),
+				Percentile: ctx.GlobalFloat64(batcher_flags.SignerHedgingPercentileFlag.Name),
+				MinDelay:   ctx.GlobalDuration(batcher_flags.SignerHedgingMinDelayFlag.Name),
+				MaxRatio:   ctx.GlobalFloat64(batcher_flags.SignerHedgingMaxRatioFlag.Name),
+			},
+			EncoderCrossCheck: batcher.CrossCheckConfig{
+				SecondaryEncoderSocket: ctx.GlobalString(batcher_flags.SecondaryEncoderSocketFlag.Name),
+				SampleRate:             ctx.GlobalFloat64(batcher_flags.EncoderCrossCheckRateFlag.Name),
+				FailOnDivergence:       ctx.GlobalBool(batcher_flags.EncoderCrossCheckStrictFlag.Name),
+			},
+			HashSuite:           ctx.GlobalString(batcher_flags.HashSuiteFlag.Name),
+			EncodingJournalPath: ctx.GlobalString(batcher_flags.EncodingJournalPathFlag.Name),
+			EncodingCacheSizeMB: ctx.GlobalUint(batcher_flags.EncodingCacheSizeFlag.Name),
+			BatchJournalPath:    ctx.GlobalString(batcher_flags.BatchJournalPathFlag.Name),
+			PartialConfirmation: ctx.GlobalBool(batcher_flags.PartialConfirmationFlag.Name),
+			QuorumFallback:      ctx.GlobalBool(batcher_flags.QuorumFallbackFlag.Name),
+			ChunkFormats:        ctx.GlobalStringSlice(batcher_flags.ChunkFormatsFlag.Name),
+			SignerDiscovery: signer.DiscoveryConfig{
+				Enabled: ctx.GlobalBool(batcher_flags.SignerDiscoveryFlag.Name),
+				TTL:     ctx.GlobalDuration(batcher_flags.SignerDiscoveryTTLFlag.Name),
+			},
+			SignerQUIC: signer.QUICConfig{
+				Enabled:    ctx.GlobalBool(batcher_flags.SignerQUICFlag.Name),
+				RetryAfter: ctx.GlobalDuration(batcher_flags.SignerQUICRetryAfterFlag.Name),
+			},
+			SignerTLS: tlsconfig.ReadClientCLIConfig(ctx, batcher_flags.SignerTLSFlagPrefix),
+			Poster: batcher.PosterConfig{
+				InboxAddress:   ctx.GlobalString(batcher_flags.InboxAddressFlag.Name),
+				InboxABIFile:   ctx.GlobalString(batcher_flags.InboxABIFileFlag.Name),
+				InboxMethod:    ctx.GlobalString(batcher_flags.InboxMethodFlag.Name),
+				TxGasLimit:     ctx.GlobalUint64(batcher_flags.InboxTxGasLimitFlag.Name),
+				MaxRetries:     ctx.GlobalUint(batcher_flags.InboxMaxRetriesFlag.Name),
+				BlobMethod:     ctx.GlobalString(batcher_flags.InboxBlobMethodFlag.Name),
+				BlobMode:       ctx.GlobalString(batcher_flags.InboxBlobModeFlag.Name),
+				BlobMinPayload: ctx.GlobalInt(batcher_flags.InboxBlobMinPayloadFlag.Name),
+				MaxPending:     ctx.GlobalInt(batcher_flags.InboxMaxPendingFlag.Name),
+				JournalPath:    ctx.GlobalString(batcher_flags.InboxJournalPathFlag.Name),
+			},
+			Drain: batcher.DrainConfig{
+				MaxBatchesPerMinute: ctx.GlobalUint(batcher_flags.DrainMaxBatchesPerMinuteFlag.Name),
+				RampUpDuration:      ctx.GlobalDuration(batcher_flags.DrainRampUpDurationFlag.Name),
+				RampUpStart:         ctx.GlobalFloat64(batcher_flags.DrainRampUpStartFlag.Name),
+				RampUpCurve:         ctx.GlobalString(batcher_flags.DrainRampUpCurveFlag.Name),
+				Order:               ctx.GlobalString(batcher_flags.DrainOrderFlag.Name),
+			},
+			BatchGas: batcher.BatchGasConfig{
+				BlockGasTarget: ctx.GlobalUint64(batcher_flags.BatchGasTargetFlag.Name),
+				NumQuorums:     ctx.GlobalUint64(batcher_flags.BatchGasQuorumsFlag.Name),
+				NumSigners:     ctx.GlobalUint64(batcher_flags.BatchGasSignersFlag.Name),
+			},
+			MinBatch: batcher.MinBatchConfig{
+				MinBytes: ctx.GlobalUint64(batcher_flags.MinBatchBytesFlag.Name),
+				MinBlobs: ctx.GlobalUint(batcher_flags.MinBatchBlobsFlag.Name),
+				MaxWait:  ctx.GlobalDuration(batcher_flags.MinBatchMaxWaitFlag.Name),
+			},
+			BatchAlign: batcher.BatchAlignConfig{
+				BlocksPerBatch: ctx.GlobalUint64(batcher_flags.BatchAlignBlocksFlag.Name),
+				Offset:         ctx.GlobalUint64(batcher_flags.BatchAlignOffsetFlag.Name),
+				PollInterval:   ctx.GlobalDuration(batcher_flags.BatchAlignPollIntervalFlag.Name),
+			},
+			BatchSizing: batcher.BatchSizingConfig{
+				MinSizeMB:         ctx.GlobalUint(batcher_flags.BatchSizeMinMBFlag.Name),
+				MaxSizeMB:         ctx.GlobalUint(batcher_flags.BatchSizeMaxMBFlag.Name),
+				ReferenceGasPrice: ctx.GlobalUint64(batcher_flags.BatchSizeReferenceGasPriceFlag.Name),
+				BacklogTarget:     ctx.GlobalUint(batcher_flags.BatchSizeBacklogTargetFlag.Name),
+				LatencyTarget:     ctx.GlobalDuration(batcher_flags.BatchSizeLatencyTargetFlag.Name),
+				Interval:          ctx.GlobalDuration(batcher_flags.BatchSizeIntervalFlag.Name),
+			},
+			BatchPipelines: ctx.GlobalUint(batcher_flags.BatchPipelinesFlag.Name),
+			DeadLetter: batcher.DeadLetterConfig{
+				ProcessingTTL: ctx.GlobalDuration(batcher_flags.DeadLetterProcessingTTLFlag.Name),
+				SweepInterval: ctx.GlobalDuration(batcher_flags.DeadLetterSweepIntervalFlag.Name),
+			},
+			InFlight: batcher.InFlightConfig{
+				Timeout: ctx.GlobalDuration(batcher_flags.InFlightTimeoutFlag.Name),
+			},
+			Fairness: batcher.FairnessConfig{
+				ReportInterval:   ctx.GlobalDuration(batcher_flags.FairnessReportIntervalFlag.Name),
+				Tolerance:        ctx.GlobalFloat64(batcher_flags.FairnessToleranceFlag.Name),
+				SustainedBatches: ctx.GlobalUint(batcher_flags.FairnessSustainedBatchesFlag.Name),
+			},
+			DispatchDeadline: batcher.DispatchDeadlineConfig{
+				Default: ctx.GlobalDuration(batcher_flags.DispatchDeadlineFlag.Name),
+				Quorums: ctx.GlobalStringSlice(batcher_flags.QuorumDispatchDeadlinesFlag.Name),
+			},
+			Bandwidth: batcher.BandwidthConfig{
+				ProbeInterval: ctx.GlobalDuration(batcher_flags.BandwidthProbeIntervalFlag.Name),
+				ProbeSize:     ctx.GlobalInt(batcher_flags.BandwidthProbeSizeFlag.Name),
+			},
+			StatusPage: batcher.StatusPageConfig{
+				Bucket:     ctx.GlobalString(batcher_flags.StatusPageBucketFlag.Name),
+				Prefix:     ctx.GlobalString(batcher_flags.StatusPagePrefixFlag.Name),
+				Interval:   ctx.GlobalDuration(batcher_flags.StatusPageIntervalFlag.Name),
+				Window:     ctx.GlobalDuration(batcher_flags.StatusPageWindowFlag.Name),
+				StaleAfter: ctx.GlobalDuration(batcher_flags.StatusPageStaleAfterFlag.Name),
+			},
+			Retry: batcher.RetryConfig{
+				Default: batcher.RetryPolicy{
+					Backoff:    ctx.GlobalDuration(batcher_flags.RetryBackoffFlag.Name),
+					MaxBackoff: ctx.GlobalDuration(batcher_flags.RetryMaxBackoffFlag.Name),
+					Multiplier: ctx.GlobalFloat64(batcher_flags.RetryBackoffMultiplierFlag.Name),
+					Jitter:     ctx.GlobalFloat64(batcher_flags.RetryJitterFlag.Name),
+				},
+				Policies: ctx.GlobalStringSlice(batcher_flags.RetryPoliciesFlag.Name),
+			},
+			Budget: batcher.BudgetConfig{
+				MaxLatency: ctx.GlobalDuration(batcher_flags.BatchMaxLatencyFlag.Name),
+				MaxFeeGwei: ctx.GlobalUint64(batcher_flags.BatchMaxFeeFlag.Name),
+				BumpBelow:  ctx.GlobalFloat64(batcher_flags.BatchFeeBumpBelowFlag.Name),
+			},
+			Watchdog: batcher.WatchdogConfig{
+				SampleSize:      ctx.GlobalInt(batcher_flags.WatchdogSampleSizeFlag.Name),
+				ExclusionPeriod: ctx.GlobalDuration(batcher_flags.WatchdogExclusionPeriodFlag.Name),
+			},
+			Fallback: batcher.FallbackConfig{
+				RPCURL:                    ctx.GlobalString(batcher_flags.FallbackRPCURLFlag.Name),
+				DAEntranceContractAddress: ctx.GlobalString(batcher_flags.FallbackDAEntranceContractAddressFlag.Name),
+				DASignersContractAddress:  ctx.GlobalString(batcher_flags.FallbackDASignersContractAddressFlag.Name),
+				HaltTimeout:               ctx.GlobalDuration(batcher_flags.FallbackHaltTimeoutFlag.Name),
+				FinalityDepth:             ctx.GlobalUint64(batcher_flags.FallbackFinalityDepthFlag.Name),
+			},
+			Health: batcher.HealthConfig{
+				MaxConfirmerBacklog:  ctx.GlobalInt(batcher_flags.HealthMaxConfirmerBacklogFlag.Name),
+				MaxDispersalFailures: ctx.GlobalInt(batcher_flags.HealthMaxDispersalFailuresFlag.Name),
+			},
+			SpendLimit: batcher.SpendLimitConfig{
+				HourlyGwei:  ctx.GlobalUint64(batcher_flags.SpendLimitHourlyGweiFlag.Name),
+				DailyGwei:   ctx.GlobalUint64(batcher_flags.SpendLimitDailyGweiFlag.Name),
+				SpikeFactor: ctx.GlobalFloat64(batcher_flags.SpendSpikeFactorFlag.Name),
+				SpikeWindow: ctx.GlobalInt(batcher_flags.SpendSpikeWindowFlag.Name),
+				JournalPath: ctx.GlobalString(batcher_flags.SpendJournalPathFlag.Name),
+				AlertURL:    ctx.GlobalString(batcher_flags.SpendAlertURLFlag.Name),
+			},
+			EncoderBalancer: batcher.EncoderBalancerConfig{
+				Strategy:            ctx.GlobalString(batcher_flags.EncoderBalancingFlag.Name),
+				MaxFailures:         ctx.GlobalUint(batcher_flags.EncoderMaxFailuresFlag.Name),
+				HealthCheckInterval: ctx.GlobalDuration(batcher_flags.EncoderHealthCheckIntervalFlag.Name),
+			},
+			EncoderBatching: encoder.BatchingConfig{
+				MaxBlobs:    ctx.GlobalInt(batcher_flags.EncoderBatchMaxBlobsFlag.Name),
+				MaxBlobSize: ctx.GlobalInt(batcher_flags.EncoderBatchMaxBlobSizeFlag.Name),
+				MaxWait:     ctx.GlobalDuration(batcher_flags.EncoderBatchMaxWaitFlag.Name),
+			},
+			EncoderTLS: tlsconfig.ReadClientCLIConfig(ctx, batcher_flags.EncoderTLSFlagPrefix),
+			Admin: batcher.AdminConfig{
+				HTTPPort: ctx.GlobalString(batcher_flags.AdminHTTPPortFlag.Name),
+				Token:    ctx.GlobalString(batcher_flags.AdminTokenFlag.Name),
+				Approval: adminApproval,
+			},
+			SafeMode: ctx.GlobalBool(batcher_flags.SafeModeFlag.Name),
 		},
 		TimeoutConfig: batcher.TimeoutConfig{
-			EncodingTimeout:   ctx.GlobalDuration(batcher_flags.EncodingTimeoutFlag.Name),
-			ChainReadTimeout:  ctx.GlobalDuration(batcher_flags.ChainReadTimeoutFlag.Name),
-			ChainWriteTimeout: ctx.GlobalDuration(batcher_flags.ChainWriteTimeoutFlag.Name),
-			SigningTimeout:    ctx.GlobalDuration(batcher_flags.SigningTimeoutFlag.Name),
-		},
+			EncodingTimeout:    ctx.GlobalDuration(batcher_flags.EncodingTimeoutFlag.Name),
+			ChainReadTimeout:   ctx.GlobalDuration(batcher_flags.ChainReadTimeoutFlag.Name),
+			ChainWriteTimeout:  ctx.GlobalDuration(batcher_flags.ChainWriteTimeoutFlag.Name),
+			SigningTimeout:     ctx.GlobalDuration(batcher_flags.SigningTimeoutFlag.Name),
+			StoreWriteTimeout:  ctx.GlobalDuration(batcher_flags.StoreWriteTimeoutFlag.Name),
+			ReceiptWaitTimeout: ctx.GlobalDuration(batcher_flags.ReceiptWaitTimeoutFlag.Name),
+		}.Reloadable(),
+	}
+	if (config.BlobConfig.Enabled() || config.BatcherConfig.Poster.BlobMethod != "") && config.SenderPoolConfig.BlobPrivateKey == "" {
+		return Config{}, fmt.Errorf("blob transactions are sent from a dedicated account, set --%s", transactor.BlobSenderKeyFlagName)
 	}
 	return config, nil
 }
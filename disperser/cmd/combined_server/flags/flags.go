@@ -2,13 +2,27 @@ package flags
 
 import (
 	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/approval"
 	"github.com/0glabs/0g-da-client/common/aws"
 	"github.com/0glabs/0g-da-client/common/geth"
+	"github.com/0glabs/0g-da-client/common/healthcheck"
+	"github.com/0glabs/0g-da-client/common/interceptors"
+	"github.com/0glabs/0g-da-client/common/lifecycle"
 	"github.com/0glabs/0g-da-client/common/logging"
 	"github.com/0glabs/0g-da-client/common/ratelimit"
+	"github.com/0glabs/0g-da-client/common/reload"
 	"github.com/0glabs/0g-da-client/common/storage_node"
+	"github.com/0glabs/0g-da-client/common/tlsconfig"
+	"github.com/0glabs/0g-da-client/common/tracing"
+	"github.com/0glabs/0g-da-client/disperser/apiserver"
+	"github.com/0glabs/0g-da-client/disperser/batcher/transactor"
 	server_flags "github.com/0glabs/0g-da-client/disperser/cmd/apiserver/flags"
 	batcher_flags "github.com/0glabs/0g-da-client/disperser/cmd/batcher/flags"
+	"github.com/0glabs/0g-da-client/disperser/common/blobstore"
+	"github.com/0glabs/0g-da-client/disperser/common/pgstore"
+	"github.com/0glabs/0g-da-client/disperser/contract"
+	"github.com/0glabs/0g-da-client/disperser/indexer"
+	"github.com/0glabs/0g-da-client/disperser/payments"
 	"github.com/urfave/cli"
 )
 
@@ -63,15 +77,36 @@ func init() {
 	Flags = append(RequiredFlags, OptionalFlags...)
 	Flags = append(Flags, logging.CLIFlags(EnvVarPrefix, FlagPrefix)...)
 	Flags = append(Flags, geth.EthClientFlags(EnvVarPrefix)...)
+	Flags = append(Flags, contract.FeeCLIFlags(EnvVarPrefix)...)
+	Flags = append(Flags, transactor.SenderPoolCLIFlags(EnvVarPrefix)...)
 	Flags = append(Flags, aws.ClientFlags(EnvVarPrefix, FlagPrefix)...)
 	Flags = append(Flags, storage_node.ClientFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, blobstore.ReplicationCLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, pgstore.CLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, indexer.CLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, lifecycle.CLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, healthcheck.CLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, reload.CLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, tracing.CLIFlags(EnvVarPrefix, FlagPrefix, "zgda-combined-server")...)
 
 	// api server
 	Flags = append(Flags, server_flags.RequiredFlags...)
 	Flags = append(Flags, server_flags.OptionalFlags...)
 	Flags = append(Flags, ratelimit.RatelimiterCLIFlags(server_flags.EnvVarPrefix, server_flags.FlagPrefix)...)
+	Flags = append(Flags, apiserver.PricingCLIFlags(server_flags.EnvVarPrefix, server_flags.FlagPrefix)...)
+	Flags = append(Flags, apiserver.QuarantineCLIFlags(server_flags.EnvVarPrefix, server_flags.FlagPrefix)...)
+	Flags = append(Flags, apiserver.DeadLetterCLIFlags(server_flags.EnvVarPrefix, server_flags.FlagPrefix)...)
+	Flags = append(Flags, apiserver.QuotaCLIFlags(server_flags.EnvVarPrefix, server_flags.FlagPrefix)...)
+	Flags = append(Flags, apiserver.RetrievalQuotaCLIFlags(server_flags.EnvVarPrefix, server_flags.FlagPrefix)...)
+	Flags = append(Flags, payments.CLIFlags(server_flags.EnvVarPrefix, server_flags.FlagPrefix)...)
+	Flags = append(Flags, interceptors.CLIFlags(server_flags.EnvVarPrefix, server_flags.FlagPrefix)...)
+	Flags = append(Flags, tlsconfig.ServerCLIFlags(server_flags.EnvVarPrefix, server_flags.FlagPrefix)...)
+	Flags = append(Flags, tlsconfig.ClientCLIFlags(server_flags.RetrieverTLSEnvVarPrefix, server_flags.RetrieverTLSFlagPrefix)...)
 
 	// batcher
 	Flags = append(Flags, batcher_flags.RequiredFlags...)
 	Flags = append(Flags, batcher_flags.OptionalFlags...)
+	Flags = append(Flags, approval.CLIFlags(batcher_flags.AdminApprovalEnvVarPrefix, batcher_flags.AdminApprovalFlagPrefix)...)
+	Flags = append(Flags, tlsconfig.ClientCLIFlags(batcher_flags.EncoderTLSEnvVarPrefix, batcher_flags.EncoderTLSFlagPrefix)...)
+	Flags = append(Flags, tlsconfig.ClientCLIFlags(batcher_flags.SignerTLSEnvVarPrefix, batcher_flags.SignerTLSFlagPrefix)...)
 }
@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/0glabs/0g-da-client/common"
 	"github.com/0glabs/0g-da-client/disperser/apiserver"
@@ -13,19 +15,30 @@ import (
 	"github.com/0glabs/0g-da-client/disperser/batcher/transactor"
 	"github.com/0glabs/0g-da-client/disperser/common/blobstore"
 	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/0glabs/0g-da-client/disperser/common/pgstore"
 	"github.com/0glabs/0g-da-client/disperser/contract"
+	"github.com/0glabs/0g-da-client/disperser/contract/da_signers"
 	"github.com/0glabs/0g-da-client/disperser/encoder"
+	"github.com/0glabs/0g-da-client/disperser/indexer"
+	"github.com/0glabs/0g-da-client/disperser/payments"
 	eth_common "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/rpc"
 
 	"github.com/0glabs/0g-da-client/common/aws/dynamodb"
 	"github.com/0glabs/0g-da-client/common/aws/s3"
 	"github.com/0glabs/0g-da-client/common/geth"
+	"github.com/0glabs/0g-da-client/common/healthcheck"
+	"github.com/0glabs/0g-da-client/common/lifecycle"
 	"github.com/0glabs/0g-da-client/common/logging"
 	"github.com/0glabs/0g-da-client/common/ratelimit"
+	"github.com/0glabs/0g-da-client/common/reload"
 	"github.com/0glabs/0g-da-client/common/store"
+	"github.com/0glabs/0g-da-client/common/tlsconfig"
+	"github.com/0glabs/0g-da-client/common/tracing"
+	"github.com/0glabs/0g-da-client/core"
 	"github.com/0glabs/0g-da-client/disperser"
 	"github.com/0glabs/0g-da-client/disperser/cmd/combined_server/flags"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/urfave/cli"
 )
 
@@ -49,11 +62,10 @@ func main() {
 	if err != nil {
 		log.Fatalf("application failed: %v", err)
 	}
-
-	select {}
 }
 
-func RunDisperserServer(config Config, blobStore disperser.BlobStore, logger common.Logger, kvStore *disperser.Store) error {
+// setupDisperserServer adds the components of the disperser server to the manager
+func setupDisperserServer(manager *lifecycle.Manager, config Config, blobStore disperser.BlobStore, readReplica disperser.MetadataReplica, logger common.Logger, kvStore *disperser.Store, tracer *tracing.Tracer, reloader *reload.Reloader[disperser.Tunables]) error {
 	var ratelimiter common.RateLimiter
 	if config.EnableRatelimiter {
 		globalParams := config.RatelimiterConfig.GlobalRateParams
@@ -75,21 +87,99 @@ func RunDisperserServer(config Config, blobStore disperser.BlobStore, logger com
 		ratelimiter = ratelimit.NewRateLimiter(globalParams, bucketStore, config.RatelimiterConfig.Allowlist, logger)
 	}
 
+	var pricer *apiserver.Pricer
+	if config.PricingConfig.Enabled {
+		var gasOracle apiserver.GasPriceOracle
+		if config.PricingConfig.GasRPC != "" {
+			gasClient, err := ethclient.Dial(config.PricingConfig.GasRPC)
+			if err != nil {
+				return err
+			}
+			gasOracle = gasClient
+		}
+		var err error
+		pricer, err = apiserver.NewPricer(config.PricingConfig, disperser.ReadOnly(blobStore), gasOracle, logger)
+		if err != nil {
+			return err
+		}
+		manager.Add(lifecycle.Component{Name: "pricer", Start: func(ctx context.Context) error {
+			pricer.Start(ctx)
+			return nil
+		}})
+	}
+
+	var meter *payments.Meter
+	if config.PaymentsConfig.Enabled() {
+		ledger, err := payments.NewLedger(config.PaymentsConfig, logger)
+		if err != nil {
+			return err
+		}
+		usage, err := payments.NewUsageStore(config.PaymentsConfig, config.AwsClientConfig, logger)
+		if err != nil {
+			return err
+		}
+		meter = payments.NewMeter(config.PaymentsConfig, ledger, usage, logger)
+		manager.Add(lifecycle.Component{Name: "payments", Start: func(ctx context.Context) error {
+			meter.Start(ctx)
+			return nil
+		}})
+	}
+
+	var quarantine *apiserver.Quarantine
+	if config.QuarantineConfig.Enabled {
+		var err error
+		quarantine, err = apiserver.NewQuarantine(config.QuarantineConfig, blobStore, logger)
+		if err != nil {
+			return err
+		}
+		manager.Serve("quarantine", quarantine.Serve)
+	}
+	if config.DeadLetterConfig.Enabled() {
+		manager.Serve("dead-letter", apiserver.NewDeadLetters(config.DeadLetterConfig, blobStore, logger).Serve)
+	}
+
+	// the quorums are read from the chain of the batcher unless configured otherwise
+	quorumRPC, signersAddress := config.QuorumRPC, config.DASignersContract
+	if quorumRPC == "" {
+		quorumRPC = config.EthClientConfig.RPCURL
+	}
+	if signersAddress == "" {
+		signersAddress = config.BatcherConfig.DASignersContractAddress
+	}
+	quorumClient, err := ethclient.Dial(quorumRPC)
+	if err != nil {
+		return err
+	}
+	signers, err := da_signers.NewDASignersCaller(eth_common.HexToAddress(signersAddress), quorumClient)
+	if err != nil {
+		return err
+	}
+	quorums := apiserver.NewQuorumRegistry(signers)
+	var storagePeriod apiserver.StoragePeriod
+	if retention := config.ServerConfig.Retention; retention.PeriodContract != "" {
+		storagePeriod = apiserver.NewChainStoragePeriod(quorumClient, eth_common.HexToAddress(retention.PeriodContract), retention.PeriodMethod)
+	}
+
 	metrics := disperser.NewMetrics(config.MetricsConfig.HTTPPort, logger)
 
-	server := apiserver.NewDispersalServer(config.ServerConfig, blobStore, logger, metrics, ratelimiter, config.RateConfig, config.BlobstoreConfig.MetadataHashAsBlobKey, kvStore, config.RetrieverAddr)
+	server := apiserver.NewDispersalServer(config.ServerConfig, blobStore, logger, metrics, ratelimiter, config.RateConfig, config.BlobstoreConfig.MetadataHashAsBlobKey, kvStore, config.RetrieverAddr, pricer, quarantine, readReplica, quorums, storagePeriod, meter, tracer)
+	reloader.OnReload(server.Tune)
 
 	// Enable Metrics Block
 	if config.MetricsConfig.EnableMetrics {
-		httpSocket := fmt.Sprintf(":%s", config.MetricsConfig.HTTPPort)
-		metrics.Start(context.Background())
-		logger.Info("Enabled metrics for Disperser", "socket", httpSocket)
+		manager.Add(lifecycle.Component{Name: "disperser metrics", Start: func(ctx context.Context) error {
+			metrics.Start(ctx)
+			logger.Info("Enabled metrics for Disperser", "socket", fmt.Sprintf(":%s", config.MetricsConfig.HTTPPort))
+			return nil
+		}})
 	}
 
-	return server.Start(context.Background())
+	manager.Serve("api", server.Start)
+	return nil
 }
 
-func RunBatcher(config Config, queue disperser.BlobStore, logger common.Logger, kvStore *disperser.Store) error {
+// setupBatcher adds the components of the batcher to the manager
+func setupBatcher(manager *lifecycle.Manager, config Config, queue disperser.BlobStore, logger common.Logger, kvStore *disperser.Store, tracer *tracing.Tracer, reloader *reload.Reloader[disperser.Tunables]) error {
 	// transactor
 	transactor := transactor.NewTransactor(config.BatcherConfig.VerifiedCommitRootsTxGasLimit, logger)
 	// dispatcher
@@ -100,10 +190,20 @@ func RunBatcher(config Config, queue disperser.BlobStore, logger common.Logger,
 		return fmt.Errorf("failed to create DAEntrance contract: %w", err)
 	}
 
-	dispatcher, err := dispatcher.NewDispatcher(transactor, daContract, logger)
+	hashSuite, err := core.GetHashSuite(config.BatcherConfig.HashSuite)
+	if err != nil {
+		return err
+	}
+	dispatcher, err := dispatcher.NewDispatcher(transactor, daContract, hashSuite, logger)
 	if err != nil {
 		return err
 	}
+	if config.BlobConfig.Enabled() {
+		if err := dispatcher.EnableBlobs(config.BlobConfig, daEntranceAddress); err != nil {
+			return err
+		}
+		logger.Info("Submitting aggregate signatures in blob transactions", "method", config.BlobConfig.Method, "mode", config.BlobConfig.Mode)
+	}
 
 	// eth clients
 	client, err := geth.NewClient(config.EthClientConfig, logger)
@@ -116,23 +216,152 @@ func RunBatcher(config Config, queue disperser.BlobStore, logger common.Logger,
 	if err != nil {
 		return err
 	}
+	chainReady := func(ctx context.Context) error {
+		_, err := client.GetCurrentBlockNumber(ctx)
+		return err
+	}
+	manager.Add(lifecycle.Component{Name: "chain", Start: func(ctx context.Context) error {
+		return lifecycle.Poll(ctx, time.Second, chainReady)
+	}})
+	manager.AddReadiness("chain", chainReady)
+
+	// chain event indexer
+	if config.IndexerConfig.Enabled() {
+		sinks, err := indexer.NewSinks(config.IndexerConfig)
+		if err != nil {
+			return err
+		}
+		eventIndexer, err := indexer.NewIndexer(config.IndexerConfig, client, daEntranceAddress, daSignersAddress, sinks, logger)
+		if err != nil {
+			return err
+		}
+		manager.Add(lifecycle.Component{Name: "indexer", StartTimeout: config.IndexerConfig.ReadyTimeout, Start: func(ctx context.Context) error {
+			if err := eventIndexer.Start(ctx); err != nil {
+				return err
+			}
+			logger.Info("Indexing chain events", "sinks", config.IndexerConfig.Sinks, "startBlock", config.IndexerConfig.StartBlock)
+			// the batcher starts once the indexer caught up with the chain
+			return lifecycle.Poll(ctx, config.IndexerConfig.PollInterval, eventIndexer.Ready)
+		}})
+		manager.AddReadiness("indexer", eventIndexer.Ready)
+	}
 
 	metrics := batcher.NewMetrics(config.MetricsConfig.HTTPPort, logger)
+	if config.FeeConfig.EIP1559 {
+		if err := daContract.EnableDynamicFees(config.FeeConfig, metrics, logger); err != nil {
+			return err
+		}
+		logger.Info("Sending EIP-1559 transactions", "replaceTimeout", config.FeeConfig.ReplaceTimeout, "maxFeeCap", config.FeeConfig.MaxFeeCap)
+	}
+
+	if config.SenderPoolConfig.Enabled() {
+		senders := make([]*contract.DAContract, len(config.SenderPoolConfig.PrivateKeys))
+		for i, key := range config.SenderPoolConfig.PrivateKeys {
+			senders[i], err = contract.NewDAContract(daEntranceAddress, daSignersAddress, config.EthClientConfig.RPCURL, key)
+			if err != nil {
+				return fmt.Errorf("failed to create DAEntrance contract of sender %d: %w", i, err)
+			}
+			if config.FeeConfig.EIP1559 {
+				if err := senders[i].EnableDynamicFees(config.FeeConfig, metrics, logger); err != nil {
+					return err
+				}
+			}
+		}
+		if err := transactor.SetSenderPool(config.SenderPoolConfig, daContract, senders, metrics); err != nil {
+			return err
+		}
+		manager.Add(lifecycle.Component{Name: "senders", Start: func(ctx context.Context) error {
+			transactor.StartHealthChecks(ctx)
+			return nil
+		}})
+		logger.Info("Rotating transactions across sender accounts", "senders", len(senders)+1, "minBalance", config.SenderPoolConfig.MinBalance)
+	}
+	if config.SenderPoolConfig.BlobPrivateKey != "" {
+		blobSender, err := contract.NewDAContract(daEntranceAddress, daSignersAddress, config.EthClientConfig.RPCURL, config.SenderPoolConfig.BlobPrivateKey)
+		if err != nil {
+			return fmt.Errorf("failed to create DAEntrance contract of the blob sender: %w", err)
+		}
+		if config.FeeConfig.EIP1559 {
+			if err := blobSender.EnableDynamicFees(config.FeeConfig, metrics, logger); err != nil {
+				return err
+			}
+		}
+		transactor.SetBlobSender(daContract, blobSender, metrics)
+		logger.Info("Sending blob transactions from the blob sender account", "account", blobSender.Account())
+	}
 
 	// encoder
-	if len(config.BatcherConfig.EncoderSocket) == 0 {
+	if len(config.BatcherConfig.EncoderSockets()) == 0 {
 		return fmt.Errorf("encoder socket must be specified")
 	}
-	encoderClient, err := encoder.NewEncoderClient(config.BatcherConfig.EncoderSocket, config.TimeoutConfig.EncodingTimeout)
+	encoderSockets := config.BatcherConfig.EncoderSockets()
+	encoderCreds, err := tlsconfig.ClientCredentials(config.BatcherConfig.EncoderTLS, logger)
 	if err != nil {
 		return err
 	}
+	endpoints := make([]batcher.EncoderEndpoint, len(encoderSockets))
+	for i, socket := range encoderSockets {
+		client, err := encoder.NewEncoderClient(socket, config.TimeoutConfig.EncodingTimeout, config.BatcherConfig.NumConnections, config.BatcherConfig.EncoderSharedMemoryDir, config.BatcherConfig.EncoderBatching, encoderCreds, metrics.EncodingStreamerMetrics)
+		if err != nil {
+			return err
+		}
+		endpoints[i] = batcher.EncoderEndpoint{Addr: socket, Client: client}
+	}
+	encoderClient := endpoints[0].Client
+	if len(endpoints) > 1 {
+		probe := func(ctx context.Context, addr string) error {
+			return encoder.Probe(ctx, addr, config.TimeoutConfig.EncodingTimeout, encoderCreds)
+		}
+		balancer, err := batcher.NewBalancedEncoderClient(endpoints, config.BatcherConfig.EncoderBalancer, probe, metrics, logger)
+		if err != nil {
+			return err
+		}
+		encoderClient = balancer
+		manager.Add(lifecycle.Component{Name: "encoders", Start: func(ctx context.Context) error {
+			balancer.StartHealthChecks(ctx)
+			return nil
+		}})
+		logger.Info("Balancing blobs across encoders", "encoders", encoderSockets, "strategy", config.BatcherConfig.EncoderBalancer.Strategy)
+	}
+	if config.BatcherConfig.EncoderCrossCheck.SecondaryEncoderSocket != "" {
+		secondaryClient, err := encoder.NewEncoderClient(config.BatcherConfig.EncoderCrossCheck.SecondaryEncoderSocket, config.TimeoutConfig.EncodingTimeout, config.BatcherConfig.NumConnections, "", encoder.BatchingConfig{}, encoderCreds, metrics.EncodingStreamerMetrics)
+		if err != nil {
+			return err
+		}
+		encoderClient = batcher.NewCrossCheckEncoderClient(encoderClient, secondaryClient, config.BatcherConfig.EncoderCrossCheck, metrics, logger)
+	}
+
+	// health
+	var monitor *healthcheck.Monitor
+	if config.HealthConfig.Enabled() {
+		monitor = healthcheck.NewMonitor(config.HealthConfig, metrics.Registry(), "zgda_batcher", logger)
+		monitor.Add("encoders", batcher.EncodersHealth(encoderSockets, func(ctx context.Context, addr string) error {
+			return encoder.Probe(ctx, addr, config.TimeoutConfig.EncodingTimeout, encoderCreds)
+		}))
+		monitor.Add("chain", chainReady)
+		monitor.Add("blob store", batcher.BlobStoreHealth(queue))
+	}
 
 	// confirmer
-	confirmer, err := batcher.NewConfirmer(config.EthClientConfig, config.BatcherConfig, queue, daContract, logger, metrics)
+	confirmer, err := batcher.NewBatchConfirmer(config.EthClientConfig, config.BatcherConfig, queue, batcher.NewChainConfirmer(daContract, config.EthClientConfig), logger, metrics)
 	if err != nil {
 		return err
 	}
+	if config.BatcherConfig.Poster.Enabled() {
+		confirmer.Poster, err = batcher.NewPoster(config.BatcherConfig.Poster, config.EthClientConfig, daContract, transactor, logger, metrics)
+		if err != nil {
+			return err
+		}
+		logger.Info("Posting certificates to rollup inbox", "address", config.BatcherConfig.Poster.InboxAddress, "method", config.BatcherConfig.Poster.InboxMethod, "blob method", config.BatcherConfig.Poster.BlobMethod)
+	}
+	if config.BatcherConfig.StatusPage.Enabled() {
+		s3Client, err := s3.NewClient(config.AwsClientConfig, logger)
+		if err != nil {
+			return err
+		}
+		confirmer.StatusPage = batcher.NewStatusPage(config.BatcherConfig.StatusPage, s3Client, logger)
+		logger.Info("Pushing status page", "bucket", config.BatcherConfig.StatusPage.Bucket, "prefix", config.BatcherConfig.StatusPage.Prefix)
+	}
 
 	blobKeyCache := disperser.BlobKeyCache{
 		Key:   make(map[[32]byte]bool),
@@ -150,13 +379,21 @@ func RunBatcher(config Config, queue disperser.BlobStore, logger common.Logger,
 	iter.Release()
 
 	//finalizer
-	finalizer := batcher.NewFinalizer(config.TimeoutConfig.ChainReadTimeout, config.BatcherConfig, queue, client, rpcClient, logger, kvStore, &blobKeyCache)
+	finalizer := batcher.NewFinalizer(config.TimeoutConfig, config.BatcherConfig, queue, client, rpcClient, logger, kvStore, &blobKeyCache, metrics, tracer)
+
+	var fallback *batcher.ConfirmationFallback
+	if config.BatcherConfig.Fallback.Enabled() {
+		fallback, err = batcher.ConnectConfirmationFallback(config.BatcherConfig.Fallback, config.EthClientConfig, config.BatcherConfig.VerifiedCommitRootsTxGasLimit, hashSuite, client, daEntranceAddress, metrics, logger)
+		if err != nil {
+			return err
+		}
+		logger.Info("Dispersing the batches to a fallback deployment while the primary chain is halted", "rpc", config.BatcherConfig.Fallback.RPCURL, "address", config.BatcherConfig.Fallback.DAEntranceContractAddress)
+	}
 
 	//batcher
 	batcher, err := batcher.NewBatcher(
 		config.BatcherConfig,
 		config.TimeoutConfig,
-		config.EthClientConfig,
 		queue,
 		dispatcher,
 		encoderClient,
@@ -169,15 +406,36 @@ func RunBatcher(config Config, queue disperser.BlobStore, logger common.Logger,
 	if err != nil {
 		return err
 	}
+	batcher.Blocks = client
+	batcher.GasPrices = client
+	batcher.Tracer = tracer
+	batcher.ConfirmationFallback = fallback
+	reloader.OnReload(batcher.Tune)
 
 	// Enable Metrics Block
 	if config.MetricsConfig.EnableMetrics {
-		httpSocket := fmt.Sprintf(":%s", config.MetricsConfig.HTTPPort)
-		metrics.Start(context.Background())
-		logger.Info("Enabled metrics for Batcher", "socket", httpSocket)
+		manager.Add(lifecycle.Component{Name: "batcher metrics", Start: func(ctx context.Context) error {
+			metrics.Start(ctx)
+			logger.Info("Enabled metrics for Batcher", "socket", fmt.Sprintf(":%s", config.MetricsConfig.HTTPPort))
+			return nil
+		}})
 	}
 
-	return batcher.Start(context.Background())
+	manager.Add(
+		lifecycle.Component{Name: "streamer", Start: batcher.StartEncoding, Stop: batcher.StopEncoding},
+		lifecycle.Component{Name: "confirmer", Start: batcher.StartConfirmation, Stop: batcher.StopConfirmation},
+		lifecycle.Component{Name: "batcher", Start: batcher.StartBatching, Stop: batcher.StopBatching},
+	)
+	if monitor != nil {
+		batcher.AddHealthChecks(monitor)
+		manager.HandleProbe("/health", monitor.HandleHealth)
+		manager.HandleProbe("/livez", monitor.HandleLivez)
+		manager.Add(lifecycle.Component{Name: "health", Start: func(ctx context.Context) error {
+			monitor.Start(ctx)
+			return nil
+		}})
+	}
+	return nil
 }
 
 func RunCombinedServer(ctx *cli.Context) error {
@@ -191,9 +449,24 @@ func RunCombinedServer(ctx *cli.Context) error {
 		return err
 	}
 
+	manager := lifecycle.NewManager(config.LifecycleConfig, logger)
+	// started first, the components of the store are added as the store is built
+	var storeComponents []lifecycle.Component
+
 	var blobStore disperser.BlobStore
+	var readReplica disperser.MetadataReplica
 
-	if !config.BlobstoreConfig.InMemory {
+	if config.PostgresConfig.Enabled() {
+		if config.ReplicationConfig.Enabled() {
+			return errors.New("blob metadata replication requires the dynamodb blob store")
+		}
+		logger.Info("Creating postgres blob store")
+		pgStore, err := pgstore.Open(context.Background(), config.PostgresConfig, config.BlobstoreConfig.MetadataHashAsBlobKey, logger)
+		if err != nil {
+			return err
+		}
+		blobStore = pgStore
+	} else if !config.BlobstoreConfig.InMemory {
 		s3Client, err := s3.NewClient(config.AwsClientConfig, logger)
 		if err != nil {
 			return err
@@ -208,11 +481,46 @@ func RunCombinedServer(ctx *cli.Context) error {
 		logger.Info("Creating blob store", "bucket", bucketName)
 		blobMetadataStore := blobstore.NewBlobMetadataStore(dynamoClient, logger, config.BlobstoreConfig.TableName, 0)
 		blobStore = blobstore.NewSharedStorage(bucketName, s3Client, config.BlobstoreConfig.MetadataHashAsBlobKey, blobMetadataStore, logger)
+		if config.ReplicationConfig.Enabled() {
+			replicaMetadataStore, err := blobstore.NewReplicaMetadataStore(config.ReplicationConfig, config.AwsClientConfig, logger)
+			if err != nil {
+				return err
+			}
+			replicator := blobstore.NewMetadataReplicator(blobMetadataStore, replicaMetadataStore, config.ReplicationConfig, logger)
+			storeComponents = append(storeComponents, lifecycle.Component{Name: "replicator", Start: func(ctx context.Context) error {
+				replicator.Start(ctx)
+				return nil
+			}})
+			blobStore = blobstore.NewReplicatedBlobStore(blobStore, replicator)
+			logger.Info("Replicating blob metadata", "region", config.ReplicationConfig.Region, "table", config.ReplicationConfig.TableName)
+			if config.ReplicationConfig.ServeReads {
+				replica := blobstore.NewReadReplica(replicaMetadataStore, config.ReplicationConfig, logger)
+				storeComponents = append(storeComponents, lifecycle.Component{Name: "read replica", Start: func(ctx context.Context) error {
+					replica.Start(ctx)
+					return nil
+				}})
+				readReplica = replica
+				logger.Info("Serving blob status reads from the replica", "maxStaleness", config.ReplicationConfig.MaxReadStaleness)
+			}
+		}
 	} else {
 		config.BlobstoreConfig.MetadataHashAsBlobKey = true
 		blobStore = memorydb.NewBlobStore(config.BlobstoreConfig.MemoryDBSize, logger)
 	}
 
+	if config.ExportConfig.Enabled() {
+		exporter, err := pgstore.OpenExporter(context.Background(), config.ExportConfig, blobStore, logger)
+		if err != nil {
+			return err
+		}
+		storeComponents = append(storeComponents, lifecycle.Component{Name: "exporter", Start: func(ctx context.Context) error {
+			exporter.Start(ctx)
+			return nil
+		}})
+		blobStore = pgstore.NewExportedBlobStore(blobStore, exporter)
+		logger.Info("Exporting blob metadata to the analytics database", "interval", config.ExportConfig.Interval)
+	}
+
 	// Create new store
 	kvStore, err := disperser.NewLevelDBStore(config.StorageNodeConfig.KvDbPath+"/chunk", config.StorageNodeConfig.TimeToExpire, logger)
 	if err != nil {
@@ -220,15 +528,30 @@ func RunCombinedServer(ctx *cli.Context) error {
 		return nil
 	}
 
-	errChan := make(chan error)
-	go func() {
-		err := RunDisperserServer(config, blobStore, logger, kvStore)
-		errChan <- err
-	}()
-	go func() {
-		err := RunBatcher(config, blobStore, logger, kvStore)
-		errChan <- err
-	}()
-	err = <-errChan
-	return err
+	// the tracer is started first so it is stopped last, flushing the spans of the others
+	tracer, err := tracing.NewTracer(config.TracingConfig, logger)
+	if err != nil {
+		return err
+	}
+	if tracer != nil {
+		manager.Add(lifecycle.Component{Name: "tracing", Start: func(ctx context.Context) error {
+			tracer.Start(ctx)
+			return nil
+		}, Stop: tracer.Stop})
+		logger.Info("Exporting traces", "endpoint", config.TracingConfig.Endpoint, "sampleRatio", config.TracingConfig.SampleRatio)
+	}
+	manager.Add(storeComponents...)
+	// the components register how they apply the tunables, loaded once they are all set up
+	reloader := disperser.NewTunablesReloader(config.ReloadConfig, config.LoggerConfig, logger)
+	if err := setupBatcher(manager, config, blobStore, logger, kvStore, tracer, reloader); err != nil {
+		return err
+	}
+	if err := setupDisperserServer(manager, config, blobStore, readReplica, logger, kvStore, tracer, reloader); err != nil {
+		return err
+	}
+	if config.ReloadConfig.Enabled() {
+		manager.Add(lifecycle.Component{Name: "reload", Start: reloader.Start})
+		logger.Info("Reloading tunables on SIGHUP", "file", config.ReloadConfig.File, "url", config.ReloadConfig.URL)
+	}
+	return manager.Run(context.Background())
 }
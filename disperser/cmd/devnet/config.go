@@ -0,0 +1,30 @@
+package main
+
+import (
+	"github.com/0glabs/0g-da-client/common/logging"
+	"github.com/0glabs/0g-da-client/disperser/cmd/devnet/flags"
+	"github.com/0glabs/0g-da-client/tools/devnet"
+	"github.com/urfave/cli"
+)
+
+type Config struct {
+	Devnet       devnet.Config
+	LoggerConfig logging.Config
+}
+
+func NewConfig(ctx *cli.Context) Config {
+	return Config{
+		Devnet: devnet.Config{
+			GrpcPort:           ctx.GlobalString(flags.GrpcPortFlag.Name),
+			HTTPPort:           ctx.GlobalString(flags.HTTPPortFlag.Name),
+			EncoderAddr:        ctx.GlobalString(flags.EncoderAddrFlag.Name),
+			RetrieverAddr:      ctx.GlobalString(flags.RetrieverAddrFlag.Name),
+			DataDir:            ctx.GlobalString(flags.DataDirFlag.Name),
+			NumOperators:       ctx.GlobalInt(flags.NumOperatorsFlag.Name),
+			BlockTime:          ctx.GlobalDuration(flags.BlockTimeFlag.Name),
+			FinalizationBlocks: ctx.GlobalUint64(flags.FinalizationBlocksFlag.Name),
+			MaxRetries:         ctx.GlobalUint(flags.MaxRetriesFlag.Name),
+		},
+		LoggerConfig: logging.ReadCLIConfig(ctx, flags.FlagPrefix),
+	}
+}
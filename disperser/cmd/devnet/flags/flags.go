@@ -0,0 +1,101 @@
+package flags
+
+import (
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/logging"
+	"github.com/urfave/cli"
+)
+
+const (
+	FlagPrefix   = "devnet"
+	EnvVarPrefix = "DEVNET"
+)
+
+var (
+	/* Optional Flags*/
+	GrpcPortFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "grpc-port"),
+		Usage:    "port at which the disperser API listens for grpc calls",
+		Required: false,
+		Value:    "51001",
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "GRPC_PORT"),
+	}
+	HTTPPortFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "http-port"),
+		Usage:    "port of the batch status and certificate endpoints, disabled if empty",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "HTTP_PORT"),
+	}
+	EncoderAddrFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "encoder-addr"),
+		Usage:    "address the local encoder listens on",
+		Required: false,
+		Value:    "127.0.0.1:34000",
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "ENCODER_ADDR"),
+	}
+	RetrieverAddrFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "retriever-addr"),
+		Usage:    "address the retriever listens on",
+		Required: false,
+		Value:    "127.0.0.1:32011",
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "RETRIEVER_ADDR"),
+	}
+	DataDirFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "data-dir"),
+		Usage:    "directory of the kv store of the finalized blobs, a temporary directory removed on exit if empty",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "DATA_DIR"),
+	}
+	NumOperatorsFlag = cli.IntFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "num-operators"),
+		Usage:    "number of simulated operators, each storing a slice of every blob",
+		Required: false,
+		Value:    4,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "NUM_OPERATORS"),
+	}
+	BlockTimeFlag = cli.DurationFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "block-time"),
+		Usage:    "interval between the blocks of the simulated chain, and between the batches of the batcher",
+		Required: false,
+		Value:    time.Second,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "BLOCK_TIME"),
+	}
+	FinalizationBlocksFlag = cli.Uint64Flag{
+		Name:     common.PrefixFlag(FlagPrefix, "finalization-blocks"),
+		Usage:    "number of blocks below the head of the simulated chain after which blocks are final",
+		Required: false,
+		Value:    3,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "FINALIZATION_BLOCKS"),
+	}
+	MaxRetriesFlag = cli.UintFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "max-retries"),
+		Usage:    "number of times the blob of a failed batch is dispersed again before it fails",
+		Required: false,
+		Value:    3,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "MAX_RETRIES"),
+	}
+)
+
+var RequiredFlags = []cli.Flag{}
+
+var OptionalFlags = []cli.Flag{
+	GrpcPortFlag,
+	HTTPPortFlag,
+	EncoderAddrFlag,
+	RetrieverAddrFlag,
+	DataDirFlag,
+	NumOperatorsFlag,
+	BlockTimeFlag,
+	FinalizationBlocksFlag,
+	MaxRetriesFlag,
+}
+
+// Flags contains the list of configuration options available to the binary.
+var Flags []cli.Flag
+
+func init() {
+	Flags = append(RequiredFlags, OptionalFlags...)
+	Flags = append(Flags, logging.CLIFlags(EnvVarPrefix, FlagPrefix)...)
+}
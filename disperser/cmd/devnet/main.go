@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/0glabs/0g-da-client/common/lifecycle"
+	"github.com/0glabs/0g-da-client/common/logging"
+	"github.com/0glabs/0g-da-client/disperser/cmd/devnet/flags"
+	"github.com/0glabs/0g-da-client/tools/devnet"
+	"github.com/urfave/cli"
+)
+
+var (
+	// version is the version of the binary.
+	version   string
+	gitCommit string
+	gitDate   string
+)
+
+func main() {
+	app := cli.NewApp()
+	app.Flags = flags.Flags
+	app.Version = fmt.Sprintf("%s-%s-%s", version, gitCommit, gitDate)
+	app.Name = "devnet"
+	app.Usage = "ZGDA Local Devnet"
+	app.Description = "Runs the disperser API and the batcher against a simulated chain, operators, encoder and retriever in one process"
+
+	app.Action = RunDevnet
+	err := app.Run(os.Args)
+	if err != nil {
+		log.Fatalf("application failed: %v", err)
+	}
+}
+
+func RunDevnet(ctx *cli.Context) error {
+	config := NewConfig(ctx)
+
+	logger, err := logging.GetLogger(config.LoggerConfig)
+	if err != nil {
+		return err
+	}
+
+	d, err := devnet.New(config.Devnet, logger)
+	if err != nil {
+		return err
+	}
+	manager := lifecycle.NewManager(lifecycle.Config{}, logger)
+	if err := d.Setup(manager); err != nil {
+		return err
+	}
+	return manager.Run(context.Background())
+}
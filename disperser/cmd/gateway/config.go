@@ -0,0 +1,33 @@
+package main
+
+import (
+	"github.com/0glabs/0g-da-client/common/logging"
+	"github.com/0glabs/0g-da-client/common/tlsconfig"
+	"github.com/0glabs/0g-da-client/disperser/cmd/gateway/flags"
+	"github.com/0glabs/0g-da-client/disperser/gateway"
+	"github.com/urfave/cli"
+)
+
+type Config struct {
+	GatewayConfig gateway.Config
+	LoggerConfig  logging.Config
+}
+
+func NewConfig(ctx *cli.Context) Config {
+	return Config{
+		GatewayConfig: gateway.Config{
+			HTTPPort:       ctx.GlobalString(flags.HTTPPortFlag.Name),
+			RetrieverAddrs: ctx.GlobalStringSlice(flags.RetrieverAddrsFlag.Name),
+			CacheSize:      ctx.GlobalInt(flags.CacheSizeFlag.Name),
+			RequestTimeout: ctx.GlobalDuration(flags.RequestTimeoutFlag.Name),
+			DisperserAddr:  ctx.GlobalString(flags.DisperserAddrFlag.Name),
+			DisperserToken: ctx.GlobalString(flags.DisperserTokenFlag.Name),
+			EncoderAddr:    ctx.GlobalString(flags.EncoderAddrFlag.Name),
+			EncoderTimeout: ctx.GlobalDuration(flags.EncoderTimeoutFlag.Name),
+			RetrieverTLS:   tlsconfig.ReadClientCLIConfig(ctx, flags.RetrieverTLSFlagPrefix),
+			DisperserTLS:   tlsconfig.ReadClientCLIConfig(ctx, flags.DisperserTLSFlagPrefix),
+			EncoderTLS:     tlsconfig.ReadClientCLIConfig(ctx, flags.EncoderTLSFlagPrefix),
+		},
+		LoggerConfig: logging.ReadCLIConfig(ctx, flags.FlagPrefix),
+	}
+}
@@ -0,0 +1,102 @@
+package flags
+
+import (
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/logging"
+	"github.com/0glabs/0g-da-client/common/tlsconfig"
+	"github.com/urfave/cli"
+)
+
+const (
+	FlagPrefix   = "gateway"
+	EnvVarPrefix = "GATEWAY"
+
+	// RetrieverTLSFlagPrefix, DisperserTLSFlagPrefix and EncoderTLSFlagPrefix prefix the tls
+	// flags of the clients
+	RetrieverTLSFlagPrefix = FlagPrefix + ".retriever"
+	DisperserTLSFlagPrefix = FlagPrefix + ".disperser"
+	EncoderTLSFlagPrefix   = FlagPrefix + ".encoder"
+)
+
+var (
+	/* Required Flags */
+	HTTPPortFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "http-port"),
+		Usage:    "Port at which the gateway listens for http requests",
+		Required: true,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "HTTP_PORT"),
+	}
+	RetrieverAddrsFlag = cli.StringSliceFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "retriever-address"),
+		Usage:    "address of a retriever, can be repeated. Retrievers are tried in the given order",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "RETRIEVER_ADDRESS"),
+	}
+	/* Optional Flags*/
+	DisperserAddrFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "disperser-address"),
+		Usage:    "address of the disperser grpc api served as json under /v1. Required without retrievers",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "DISPERSER_ADDRESS"),
+	}
+	DisperserTokenFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "disperser-token"),
+		Usage:    "token authenticating the gateway to the disperser, which then limits the clients of the gateway by their address. Must match the gateway token of the disperser",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "DISPERSER_TOKEN"),
+	}
+	EncoderAddrFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "encoder-address"),
+		Usage:    "address of the encoder the retrieved blobs are encoded with to check them against their storage root. Blobs aren't checked, cached or served as immutable if empty",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "ENCODER_ADDRESS"),
+	}
+	EncoderTimeoutFlag = cli.DurationFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "encoder-timeout"),
+		Usage:    "timeout for encoding a retrieved blob",
+		Required: false,
+		Value:    time.Minute,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "ENCODER_TIMEOUT"),
+	}
+	CacheSizeFlag = cli.IntFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "cache-size"),
+		Usage:    "maximum number of blobs kept in the in-memory cache",
+		Required: false,
+		Value:    1024,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "CACHE_SIZE"),
+	}
+	RequestTimeoutFlag = cli.DurationFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "request-timeout"),
+		Usage:    "timeout for retrieving a blob from the retrievers",
+		Required: false,
+		Value:    30 * time.Second,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "REQUEST_TIMEOUT"),
+	}
+)
+
+var RequiredFlags = []cli.Flag{
+	HTTPPortFlag,
+}
+
+var OptionalFlags = []cli.Flag{
+	RetrieverAddrsFlag,
+	DisperserAddrFlag,
+	DisperserTokenFlag,
+	EncoderAddrFlag,
+	EncoderTimeoutFlag,
+	CacheSizeFlag,
+	RequestTimeoutFlag,
+}
+
+// Flags contains the list of configuration options available to the binary.
+var Flags []cli.Flag
+
+func init() {
+	Flags = append(RequiredFlags, OptionalFlags...)
+	Flags = append(Flags, logging.CLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, tlsconfig.ClientCLIFlags(EnvVarPrefix+"_RETRIEVER", RetrieverTLSFlagPrefix)...)
+	Flags = append(Flags, tlsconfig.ClientCLIFlags(EnvVarPrefix+"_DISPERSER", DisperserTLSFlagPrefix)...)
+	Flags = append(Flags, tlsconfig.ClientCLIFlags(EnvVarPrefix+"_ENCODER", EncoderTLSFlagPrefix)...)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/0glabs/0g-da-client/common/logging"
+	"github.com/0glabs/0g-da-client/disperser/cmd/gateway/flags"
+	"github.com/0glabs/0g-da-client/disperser/gateway"
+	"github.com/urfave/cli"
+)
+
+var (
+	// version is the version of the binary.
+	version   string
+	gitCommit string
+	gitDate   string
+)
+
+func main() {
+	app := cli.NewApp()
+	app.Flags = flags.Flags
+	app.Version = fmt.Sprintf("%s-%s-%s", version, gitCommit, gitDate)
+	app.Name = "gateway"
+	app.Usage = "ZGDA Gateway"
+	app.Description = "HTTP gateway serving cached blobs from the retrievers, and the disperser api as json"
+
+	app.Action = RunGateway
+	err := app.Run(os.Args)
+	if err != nil {
+		log.Fatalf("application failed: %v", err)
+	}
+
+	select {}
+}
+
+func RunGateway(ctx *cli.Context) error {
+	config := NewConfig(ctx)
+
+	logger, err := logging.GetLogger(config.LoggerConfig)
+	if err != nil {
+		return err
+	}
+
+	server, err := gateway.NewServer(config.GatewayConfig, logger)
+	if err != nil {
+		return err
+	}
+
+	return server.Start(context.Background())
+}
@@ -2,7 +2,9 @@ package blobstore
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
 	"strconv"
 	"time"
 
@@ -11,6 +13,7 @@ import (
 	"github.com/0glabs/0g-da-client/disperser"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
@@ -18,6 +21,8 @@ import (
 const (
 	statusIndexName = "StatusIndex"
 	batchIndexName  = "BatchIndex"
+	// eventsAttribute is the list attribute of the history of a blob, left out of BlobMetadata
+	eventsAttribute = "Events"
 )
 
 // BlobMetadataStore is a blob metadata storage backed by DynamoDB
@@ -43,12 +48,28 @@ func NewBlobMetadataStore(dynamoDBClient *commondynamodb.Client, logger common.L
 	}
 }
 
+// QueueNewBlobMetadata writes the metadata of a new blob. disperser.ErrBlobKeyConflict is
+// returned if its key is taken, the stored metadata being left as it is.
 func (s *BlobMetadataStore) QueueNewBlobMetadata(ctx context.Context, blobMetadata *disperser.BlobMetadata) error {
 	item, err := MarshalBlobMetadata(blobMetadata)
 	if err != nil {
 		return err
 	}
 
+	err = s.dynamoDBClient.PutItemIf(ctx, s.tableName, item, expression.AttributeNotExists(expression.Name("BlobHash")))
+	if errors.Is(err, commondynamodb.ErrConditionFailed) {
+		return fmt.Errorf("%w: %s", disperser.ErrBlobKeyConflict, blobMetadata.GetBlobKey().String())
+	}
+	return err
+}
+
+// PutBlobMetadata writes the metadata of a blob, replacing the stored metadata if any
+func (s *BlobMetadataStore) PutBlobMetadata(ctx context.Context, blobMetadata *disperser.BlobMetadata) error {
+	item, err := MarshalBlobMetadata(blobMetadata)
+	if err != nil {
+		return err
+	}
+
 	return s.dynamoDBClient.PutItem(ctx, s.tableName, item)
 }
 
@@ -64,14 +85,30 @@ func (s *BlobMetadataStore) RemoveBlobMetadata(ctx context.Context, blobMetadata
 }
 
 func (s *BlobMetadataStore) GetBlobMetadata(ctx context.Context, metadataKey disperser.BlobKey) (*disperser.BlobMetadata, error) {
-	item, err := s.dynamoDBClient.GetItem(ctx, s.tableName, map[string]types.AttributeValue{
+	return s.getBlobMetadata(ctx, metadataKey, false)
+}
+
+// GetBlobMetadataConsistent returns the metadata using a strongly consistent read
+func (s *BlobMetadataStore) GetBlobMetadataConsistent(ctx context.Context, metadataKey disperser.BlobKey) (*disperser.BlobMetadata, error) {
+	return s.getBlobMetadata(ctx, metadataKey, true)
+}
+
+func (s *BlobMetadataStore) getBlobMetadata(ctx context.Context, metadataKey disperser.BlobKey, consistent bool) (*disperser.BlobMetadata, error) {
+	key := map[string]types.AttributeValue{
 		"BlobHash": &types.AttributeValueMemberS{
 			Value: metadataKey.BlobHash,
 		},
 		"MetadataHash": &types.AttributeValueMemberS{
 			Value: metadataKey.MetadataHash,
 		},
-	})
+	}
+	var item commondynamodb.Item
+	var err error
+	if consistent {
+		item, err = s.dynamoDBClient.GetItemConsistent(ctx, s.tableName, key)
+	} else {
+		item, err = s.dynamoDBClient.GetItem(ctx, s.tableName, key)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -176,6 +213,72 @@ func (s *BlobMetadataStore) IncrementNumRetries(ctx context.Context, existingMet
 	return err
 }
 
+// AppendEvent appends an event to the history of a blob, kept in the Events attribute of its
+// metadata up to disperser.MaxBlobEvents events
+func (s *BlobMetadataStore) AppendEvent(ctx context.Context, metadataKey disperser.BlobKey, event disperser.BlobEvent) error {
+	value, err := attributevalue.Marshal(event)
+	if err != nil {
+		return err
+	}
+	err = s.dynamoDBClient.AppendToList(ctx, s.tableName, map[string]types.AttributeValue{
+		"BlobHash": &types.AttributeValueMemberS{
+			Value: metadataKey.BlobHash,
+		},
+		"MetadataHash": &types.AttributeValueMemberS{
+			Value: metadataKey.MetadataHash,
+		},
+	}, eventsAttribute, []types.AttributeValue{value}, disperser.MaxBlobEvents)
+	if errors.Is(err, commondynamodb.ErrItemNotFound) {
+		return disperser.ErrBlobNotFound
+	}
+	if errors.Is(err, commondynamodb.ErrConditionFailed) {
+		return disperser.ErrBlobHistoryFull
+	}
+	return err
+}
+
+// GetEvents returns the history of a blob in the order it was appended
+func (s *BlobMetadataStore) GetEvents(ctx context.Context, metadataKey disperser.BlobKey) ([]disperser.BlobEvent, error) {
+	item, err := s.dynamoDBClient.GetItem(ctx, s.tableName, map[string]types.AttributeValue{
+		"BlobHash": &types.AttributeValueMemberS{
+			Value: metadataKey.BlobHash,
+		},
+		"MetadataHash": &types.AttributeValueMemberS{
+			Value: metadataKey.MetadataHash,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, disperser.ErrBlobNotFound
+	}
+	events := make([]disperser.BlobEvent, 0)
+	if value, ok := item[eventsAttribute]; ok {
+		if err := attributevalue.Unmarshal(value, &events); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// SetBatchAssignment updates the batch assignment attribute of a blob only, so it can't
+// overwrite a concurrent update of its status, removing it if assignment is nil.
+// commondynamodb.ErrConditionFailed is returned if the blob isn't stored.
+func (s *BlobMetadataStore) SetBatchAssignment(ctx context.Context, metadataKey disperser.BlobKey, assignment *disperser.BatchAssignment) error {
+	exists := expression.AttributeExists(expression.Name("BlobHash"))
+	if assignment == nil {
+		return s.dynamoDBClient.UpdateItemRemovingIf(ctx, s.tableName, blobItemKey(metadataKey), nil, []string{"BatchAssignment"}, exists)
+	}
+	value, err := attributevalue.Marshal(assignment)
+	if err != nil {
+		return err
+	}
+	return s.dynamoDBClient.UpdateItemIf(ctx, s.tableName, blobItemKey(metadataKey), commondynamodb.Item{
+		"BatchAssignment": value,
+	}, exists)
+}
+
 func (s *BlobMetadataStore) UpdateBlobMetadata(ctx context.Context, metadataKey disperser.BlobKey, updated *disperser.BlobMetadata) error {
 	item, err := MarshalBlobMetadata(updated)
 	if err != nil {
@@ -211,6 +314,193 @@ func (s *BlobMetadataStore) SetBlobStatus(ctx context.Context, metadataKey dispe
 	return err
 }
 
+// ClearConfirmation sets the status of a blob and removes the attributes of its confirmation
+// info, so that a blob rolled back after a reorg is no longer found in its former batch
+func (s *BlobMetadataStore) ClearConfirmation(ctx context.Context, metadataKey disperser.BlobKey, status disperser.BlobStatus) error {
+	return s.dynamoDBClient.UpdateItemRemoving(ctx, s.tableName, map[string]types.AttributeValue{
+		"BlobHash": &types.AttributeValueMemberS{
+			Value: metadataKey.BlobHash,
+		},
+		"MetadataHash": &types.AttributeValueMemberS{
+			Value: metadataKey.MetadataHash,
+		},
+	}, commondynamodb.Item{
+		"BlobStatus": &types.AttributeValueMemberN{
+			Value: strconv.Itoa(int(status)),
+		},
+	}, confirmationInfoAttributes)
+}
+
+// confirmationInfoAttributes are the attributes the confirmation info is flattened into
+var confirmationInfoAttributes = func() []string {
+	fields := reflect.VisibleFields(reflect.TypeOf(disperser.ConfirmationInfo{}))
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		names = append(names, field.Name)
+	}
+	return names
+}()
+
+// SetBlobStatusIf sets the status of a blob currently in the from status.
+// commondynamodb.ErrConditionFailed is returned if it is in another status or not stored.
+func (s *BlobMetadataStore) SetBlobStatusIf(ctx context.Context, metadataKey disperser.BlobKey, status disperser.BlobStatus, from disperser.BlobStatus) error {
+	return s.dynamoDBClient.UpdateItemIf(ctx, s.tableName, map[string]types.AttributeValue{
+		"BlobHash": &types.AttributeValueMemberS{
+			Value: metadataKey.BlobHash,
+		},
+		"MetadataHash": &types.AttributeValueMemberS{
+			Value: metadataKey.MetadataHash,
+		},
+	}, commondynamodb.Item{
+		"BlobStatus": &types.AttributeValueMemberN{
+			Value: strconv.Itoa(int(status)),
+		},
+	}, expression.Name("BlobStatus").Equal(expression.Value(&types.AttributeValueMemberN{
+		Value: strconv.Itoa(int(from)),
+	})))
+}
+
+// SetDeadLettered dead-letters a blob with the reason, unless it was confirmed meanwhile.
+// commondynamodb.ErrConditionFailed is returned if it is confirmed, finalized or not stored.
+func (s *BlobMetadataStore) SetDeadLettered(ctx context.Context, metadataKey disperser.BlobKey, reason string) error {
+	return s.dynamoDBClient.UpdateItemIf(ctx, s.tableName, blobItemKey(metadataKey), commondynamodb.Item{
+		"BlobStatus":       &types.AttributeValueMemberN{Value: strconv.Itoa(int(disperser.DeadLettered))},
+		"DeadLetterReason": &types.AttributeValueMemberS{Value: reason},
+	}, expression.AttributeExists(expression.Name("BlobHash")).
+		And(expression.Name("BlobStatus").NotEqual(expression.Value(&types.AttributeValueMemberN{Value: strconv.Itoa(int(disperser.Confirmed))}))).
+		And(expression.Name("BlobStatus").NotEqual(expression.Value(&types.AttributeValueMemberN{Value: strconv.Itoa(int(disperser.Finalized))}))))
+}
+
+// ResetDeadLettered hands a dead-lettered blob back to processing with its retries reset.
+// commondynamodb.ErrConditionFailed is returned if it isn't dead-lettered.
+func (s *BlobMetadataStore) ResetDeadLettered(ctx context.Context, metadataKey disperser.BlobKey) error {
+	return s.dynamoDBClient.UpdateItemIf(ctx, s.tableName, blobItemKey(metadataKey), commondynamodb.Item{
+		"BlobStatus":       &types.AttributeValueMemberN{Value: strconv.Itoa(int(disperser.Processing))},
+		"NumRetries":       &types.AttributeValueMemberN{Value: "0"},
+		"DeadLetterReason": &types.AttributeValueMemberS{Value: ""},
+	}, expression.Name("BlobStatus").Equal(expression.Value(&types.AttributeValueMemberN{Value: strconv.Itoa(int(disperser.DeadLettered))})))
+}
+
+func blobItemKey(metadataKey disperser.BlobKey) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"BlobHash":     &types.AttributeValueMemberS{Value: metadataKey.BlobHash},
+		"MetadataHash": &types.AttributeValueMemberS{Value: metadataKey.MetadataHash},
+	}
+}
+
+// dispersalNoncePartition is the BlobHash of the records of the last nonces of the signers,
+// keyed by signer in their MetadataHash. Blob hashes are hex encoded, so it can't collide
+// with a blob.
+const dispersalNoncePartition = "dispersal-nonce"
+
+func isDispersalNonce(item commondynamodb.Item) bool {
+	hash, ok := item["BlobHash"].(*types.AttributeValueMemberS)
+	return ok && hash.Value == dispersalNoncePartition
+}
+
+// nonceWindowAttempts bounds the reads and conditional writes of the nonce window of a
+// signer raced by the concurrent dispersals of the signer
+const nonceWindowAttempts = 8
+
+// UseDispersalNonce records nonce as used in the NonceWindow of signer if the window accepts
+// it, returning disperser.ErrNonceUsed otherwise. The window is written on the condition that
+// it is still the one read, and read again if a concurrent dispersal changed it.
+func (s *BlobMetadataStore) UseDispersalNonce(ctx context.Context, signer string, nonce uint64) error {
+	key := map[string]types.AttributeValue{
+		"BlobHash":     &types.AttributeValueMemberS{Value: dispersalNoncePartition},
+		"MetadataHash": &types.AttributeValueMemberS{Value: signer},
+	}
+	for attempt := 0; attempt < nonceWindowAttempts; attempt++ {
+		item, err := s.dynamoDBClient.GetItemConsistent(ctx, s.tableName, key)
+		if err != nil {
+			return err
+		}
+		window, condition, err := nonceWindowOf(item)
+		if err != nil {
+			return err
+		}
+		next, ok := window.Use(nonce)
+		if !ok {
+			return disperser.ErrNonceUsed
+		}
+		err = s.dynamoDBClient.UpdateItemIf(ctx, s.tableName, key, commondynamodb.Item{
+			"Nonce":       &types.AttributeValueMemberN{Value: strconv.FormatUint(next.Highest, 10)},
+			"NonceWindow": &types.AttributeValueMemberB{Value: next.Bytes()},
+		}, condition)
+		if !errors.Is(err, commondynamodb.ErrConditionFailed) {
+			return err
+		}
+	}
+	return fmt.Errorf("nonce window of %s changed by %d concurrent dispersals", signer, nonceWindowAttempts)
+}
+
+var _ disperser.DispersalNonceStore = (*BlobMetadataStore)(nil)
+
+// DispersalNonces returns the nonce windows of the records of the dispersal nonce partition
+func (s *BlobMetadataStore) DispersalNonces(ctx context.Context) (map[string]disperser.NonceWindow, error) {
+	items, err := s.dynamoDBClient.Query(ctx, s.tableName, "BlobHash = :partition", commondynamodb.ExpresseionValues{
+		":partition": &types.AttributeValueMemberS{Value: dispersalNoncePartition},
+	})
+	if err != nil {
+		return nil, err
+	}
+	nonces := make(map[string]disperser.NonceWindow, len(items))
+	for _, item := range items {
+		signer, ok := item["MetadataHash"].(*types.AttributeValueMemberS)
+		if !ok {
+			continue
+		}
+		window, _, err := nonceWindowOf(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid nonce window of %s: %w", signer.Value, err)
+		}
+		nonces[signer.Value] = window
+	}
+	return nonces, nil
+}
+
+// RestoreDispersalNonce writes the nonce window of signer on the condition that the record
+// has no nonce or a lower one
+func (s *BlobMetadataStore) RestoreDispersalNonce(ctx context.Context, signer string, window disperser.NonceWindow) error {
+	key := map[string]types.AttributeValue{
+		"BlobHash":     &types.AttributeValueMemberS{Value: dispersalNoncePartition},
+		"MetadataHash": &types.AttributeValueMemberS{Value: signer},
+	}
+	highest := &types.AttributeValueMemberN{Value: strconv.FormatUint(window.Highest, 10)}
+	err := s.dynamoDBClient.UpdateItemIf(ctx, s.tableName, key, commondynamodb.Item{
+		"Nonce":       highest,
+		"NonceWindow": &types.AttributeValueMemberB{Value: window.Bytes()},
+	}, expression.AttributeNotExists(expression.Name("Nonce")).Or(expression.Name("Nonce").LessThan(expression.Value(highest))))
+	if errors.Is(err, commondynamodb.ErrConditionFailed) {
+		return nil
+	}
+	return err
+}
+
+// nonceWindowOf returns the nonce window of an item, and the condition that the item still
+// holds it. The items written before the windows only hold the last nonce, all the nonces
+// up to which are used.
+func nonceWindowOf(item commondynamodb.Item) (disperser.NonceWindow, expression.ConditionBuilder, error) {
+	nonce, ok := item["Nonce"].(*types.AttributeValueMemberN)
+	if !ok {
+		return disperser.NonceWindow{}, expression.AttributeNotExists(expression.Name("Nonce")), nil
+	}
+	highest, err := strconv.ParseUint(nonce.Value, 10, 64)
+	if err != nil {
+		return disperser.NonceWindow{}, expression.ConditionBuilder{}, err
+	}
+	sameNonce := expression.Name("Nonce").Equal(expression.Value(nonce))
+	used, ok := item["NonceWindow"].(*types.AttributeValueMemberB)
+	if !ok {
+		return disperser.ClosedNonceWindow(highest), sameNonce.And(expression.AttributeNotExists(expression.Name("NonceWindow"))), nil
+	}
+	window, err := disperser.NonceWindowFromBytes(highest, used.Value)
+	if err != nil {
+		return disperser.NonceWindow{}, expression.ConditionBuilder{}, err
+	}
+	return window, sameNonce.And(expression.Name("NonceWindow").Equal(expression.Value(used))), nil
+}
+
 func GenerateTableSchema(metadataTableName string, readCapacityUnits int64, writeCapacityUnits int64) *dynamodb.CreateTableInput {
 	return &dynamodb.CreateTableInput{
 		AttributeDefinitions: []types.AttributeDefinition{
@@ -0,0 +1,74 @@
+package blobstore
+
+import (
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/urfave/cli"
+)
+
+const (
+	ReplicaRegionFlagName       = "replica.region"
+	ReplicaEndpointURLFlagName  = "replica.endpoint-url"
+	ReplicaTableNameFlagName    = "replica.table-name"
+	ReplicaQueueSizeFlagName    = "replica.queue-size"
+	ReplicaHeartbeatFlagName    = "replica.heartbeat-interval"
+	ReplicaServeReadsFlagName   = "replica.serve-reads"
+	ReplicaMaxStalenessFlagName = "replica.max-read-staleness"
+)
+
+func ReplicationCLIFlags(envPrefix string, flagPrefix string) []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, ReplicaRegionFlagName),
+			Usage:  "AWS region of the blob metadata replica. Replication is disabled if empty",
+			EnvVar: common.PrefixEnvVar(envPrefix, "REPLICA_REGION"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, ReplicaEndpointURLFlagName),
+			Usage:  "AWS endpoint URL of the blob metadata replica",
+			EnvVar: common.PrefixEnvVar(envPrefix, "REPLICA_ENDPOINT_URL"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, ReplicaTableNameFlagName),
+			Usage:  "Name of the dynamodb table replicating blob metadata",
+			EnvVar: common.PrefixEnvVar(envPrefix, "REPLICA_TABLE_NAME"),
+		},
+		cli.IntFlag{
+			Name:   common.PrefixFlag(flagPrefix, ReplicaQueueSizeFlagName),
+			Usage:  "Number of pending metadata replications buffered before the writes wait for room",
+			Value:  10000,
+			EnvVar: common.PrefixEnvVar(envPrefix, "REPLICA_QUEUE_SIZE"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, ReplicaHeartbeatFlagName),
+			Usage:  "Interval of the heartbeats written to the replica to measure its staleness",
+			Value:  10 * time.Second,
+			EnvVar: common.PrefixEnvVar(envPrefix, "REPLICA_HEARTBEAT_INTERVAL"),
+		},
+		cli.BoolFlag{
+			Name:   common.PrefixFlag(flagPrefix, ReplicaServeReadsFlagName),
+			Usage:  "Serve blob status reads from the replica while it is fresh enough",
+			EnvVar: common.PrefixEnvVar(envPrefix, "REPLICA_SERVE_READS"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, ReplicaMaxStalenessFlagName),
+			Usage:  "Maximum staleness of the replica for reads to be served from it",
+			Value:  30 * time.Second,
+			EnvVar: common.PrefixEnvVar(envPrefix, "REPLICA_MAX_READ_STALENESS"),
+		},
+	}
+}
+
+func ReadReplicationConfig(ctx *cli.Context, flagPrefix string) ReplicationConfig {
+	return ReplicationConfig{
+		Region:      ctx.GlobalString(common.PrefixFlag(flagPrefix, ReplicaRegionFlagName)),
+		EndpointURL: ctx.GlobalString(common.PrefixFlag(flagPrefix, ReplicaEndpointURLFlagName)),
+		TableName:   ctx.GlobalString(common.PrefixFlag(flagPrefix, ReplicaTableNameFlagName)),
+		QueueSize:   ctx.GlobalInt(common.PrefixFlag(flagPrefix, ReplicaQueueSizeFlagName)),
+
+		HeartbeatInterval: ctx.GlobalDuration(common.PrefixFlag(flagPrefix, ReplicaHeartbeatFlagName)),
+		ServeReads:        ctx.GlobalBool(common.PrefixFlag(flagPrefix, ReplicaServeReadsFlagName)),
+		MaxReadStaleness:  ctx.GlobalDuration(common.PrefixFlag(flagPrefix, ReplicaMaxStalenessFlagName)),
+	}
+}
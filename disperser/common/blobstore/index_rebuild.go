@@ -0,0 +1,183 @@
+package blobstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	commondynamodb "github.com/0glabs/0g-da-client/common/aws/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// RebuildCheckpoint records how far an index rebuild has progressed so that an
+// interrupted rebuild can be resumed from the last completed page.
+type RebuildCheckpoint struct {
+	// LastKey is the primary key (BlobHash, MetadataHash) of the last scanned item.
+	// It is nil before the first page and after the scan completed.
+	LastKey   *RebuildKey `json:"last_key,omitempty"`
+	Scanned   int         `json:"scanned"`
+	Rewritten int         `json:"rewritten"`
+	Skipped   int         `json:"skipped"`
+	// Changed counts the records a live writer updated since they were scanned. They are
+	// left alone, the writer having regenerated their attributes.
+	Changed int  `json:"changed"`
+	Done    bool `json:"done"`
+}
+
+type RebuildKey struct {
+	BlobHash     string `json:"blob_hash"`
+	MetadataHash string `json:"metadata_hash"`
+}
+
+// LoadRebuildCheckpoint reads a checkpoint written by SaveRebuildCheckpoint.
+// A missing file yields an empty checkpoint.
+func LoadRebuildCheckpoint(path string) (*RebuildCheckpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &RebuildCheckpoint{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	checkpoint := &RebuildCheckpoint{}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", path, err)
+	}
+	return checkpoint, nil
+}
+
+// SaveRebuildCheckpoint atomically writes the checkpoint to path
+func SaveRebuildCheckpoint(path string, checkpoint *RebuildCheckpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// RebuildIndexes scans every blob metadata record in the table and updates it with the
+// attributes regenerated from the decoded metadata. DynamoDB maintains the secondary
+// indexes (StatusIndex, BatchIndex) from these attributes, so records that
+// were written with a missing or stale attribute reappear in the indexes once updated.
+//
+// It runs alongside the live writers: only the regenerated attributes are set, and only
+// while the record is still as scanned, so a status written meanwhile is never reverted.
+//
+// The scan starts after checkpoint.LastKey and onPage is called with the updated
+// checkpoint after each page has been written. Returning an error from onPage stops the rebuild.
+func (s *BlobMetadataStore) RebuildIndexes(ctx context.Context, checkpoint *RebuildCheckpoint, pageSize int32, onPage func(*RebuildCheckpoint) error) error {
+	if checkpoint.Done {
+		return nil
+	}
+	var startKey commondynamodb.Key
+	if checkpoint.LastKey != nil {
+		startKey = commondynamodb.Key{
+			"BlobHash":     &types.AttributeValueMemberS{Value: checkpoint.LastKey.BlobHash},
+			"MetadataHash": &types.AttributeValueMemberS{Value: checkpoint.LastKey.MetadataHash},
+		}
+	}
+
+	for {
+		items, lastKey, err := s.dynamoDBClient.ScanPage(ctx, s.tableName, startKey, pageSize)
+		if err != nil {
+			return err
+		}
+
+		var rewritten, changed int
+		for _, item := range items {
+			if isReplicationHeartbeat(item) || isDispersalNonce(item) {
+				// heartbeats and nonces must stay out of the status index
+				continue
+			}
+			fields, err := rebuildItem(item)
+			if err != nil {
+				s.logger.Warn("skipping undecodable blob metadata", "key", describeKey(item), "err", err)
+				checkpoint.Skipped++
+				continue
+			}
+			key := commondynamodb.Key{"BlobHash": item["BlobHash"], "MetadataHash": item["MetadataHash"]}
+			err = s.dynamoDBClient.UpdateItemIf(ctx, s.tableName, key, fields, rebuildCondition(item))
+			if errors.Is(err, commondynamodb.ErrConditionFailed) {
+				changed++
+				continue
+			}
+			if err != nil {
+				// the page is not checkpointed so that it is retried on resume
+				return fmt.Errorf("failed to rewrite blob metadata %s: %w", describeKey(item), err)
+			}
+			rewritten++
+		}
+
+		checkpoint.Scanned += len(items)
+		checkpoint.Rewritten += rewritten
+		checkpoint.Changed += changed
+		checkpoint.LastKey = nil
+		if len(lastKey) == 0 {
+			checkpoint.Done = true
+		} else {
+			checkpoint.LastKey = &RebuildKey{}
+			if err := attributeString(lastKey, "BlobHash", &checkpoint.LastKey.BlobHash); err != nil {
+				return err
+			}
+			if err := attributeString(lastKey, "MetadataHash", &checkpoint.LastKey.MetadataHash); err != nil {
+				return err
+			}
+		}
+		if err := onPage(checkpoint); err != nil {
+			return err
+		}
+		if checkpoint.Done {
+			return nil
+		}
+		startKey = lastKey
+	}
+}
+
+// rebuildItem returns the attributes marshalled from the decoded metadata of the item. The
+// attributes the metadata type doesn't know about are left out, so that they are kept.
+func rebuildItem(item commondynamodb.Item) (commondynamodb.Item, error) {
+	metadata, err := UnmarshalBlobMetadata(item)
+	if err != nil {
+		return nil, err
+	}
+	return MarshalBlobMetadata(metadata)
+}
+
+// rebuildGuardAttributes are the attributes every live write of a blob metadata changes
+var rebuildGuardAttributes = []string{"BlobStatus", "NumRetries", "ConfirmationBlockNumber", "ConfirmationTxnHash"}
+
+// rebuildCondition holds while the stored record is as scanned in item
+func rebuildCondition(item commondynamodb.Item) expression.ConditionBuilder {
+	condition := expression.AttributeExists(expression.Name("BlobHash"))
+	for _, name := range rebuildGuardAttributes {
+		if value, ok := item[name]; ok {
+			condition = condition.And(expression.Name(name).Equal(expression.Value(value)))
+		} else {
+			condition = condition.And(expression.AttributeNotExists(expression.Name(name)))
+		}
+	}
+	return condition
+}
+
+func attributeString(item commondynamodb.Item, name string, out *string) error {
+	value, ok := item[name].(*types.AttributeValueMemberS)
+	if !ok {
+		return fmt.Errorf("attribute %s is missing or not a string", name)
+	}
+	*out = value.Value
+	return nil
+}
+
+func describeKey(item commondynamodb.Item) string {
+	var blobHash, metadataHash string
+	_ = attributeString(item, "BlobHash", &blobHash)
+	_ = attributeString(item, "MetadataHash", &metadataHash)
+	return blobHash + "-" + metadataHash
+}
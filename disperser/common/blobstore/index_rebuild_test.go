@@ -0,0 +1,47 @@
+package blobstore
+
+import (
+	"testing"
+
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebuildItemUpdate(t *testing.T) {
+	item, err := MarshalBlobMetadata(&disperser.BlobMetadata{
+		BlobHash:     "hash",
+		MetadataHash: "metadata",
+		BlobStatus:   disperser.Processing,
+		NumRetries:   2,
+	})
+	require.NoError(t, err)
+	item["Events"] = &types.AttributeValueMemberL{}
+
+	// only the attributes of the metadata are set, the others are kept
+	fields, err := rebuildItem(item)
+	require.NoError(t, err)
+	assert.Contains(t, fields, "BlobStatus")
+	assert.NotContains(t, fields, "Events")
+
+	// the update holds only while the status and the retries are as scanned
+	expr, err := expression.NewBuilder().WithCondition(rebuildCondition(item)).Build()
+	require.NoError(t, err)
+	guarded := make(map[string]bool)
+	for _, name := range expr.Names() {
+		guarded[name] = true
+	}
+	for _, name := range rebuildGuardAttributes {
+		assert.True(t, guarded[name], name)
+	}
+	var statuses []string
+	for _, value := range expr.Values() {
+		if n, ok := value.(*types.AttributeValueMemberN); ok {
+			statuses = append(statuses, n.Value)
+		}
+	}
+	assert.ElementsMatch(t, []string{"0", "2"}, statuses)
+	assert.Contains(t, *expr.Condition(), "attribute_not_exists")
+}
@@ -0,0 +1,172 @@
+package blobstore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	commondynamodb "github.com/0glabs/0g-da-client/common/aws/dynamodb"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// heartbeatPartition is the BlobHash of the replication heartbeat records. Blob hashes
+	// are hex encoded, so it can't collide with a blob.
+	heartbeatPartition = "replication-heartbeat"
+	// heartbeatExpiryIntervals is the number of heartbeat intervals after which the
+	// heartbeats of a writer are ignored, assuming the writer stopped
+	heartbeatExpiryIntervals = 10
+)
+
+// replicationHeartbeat is written to the replica through the replication queue of a
+// writer. Since the queue is processed in order, every write the writer enqueued before
+// EnqueuedAt has been replicated once the heartbeat is, retried writes aside.
+type replicationHeartbeat struct {
+	Writer     string
+	EnqueuedAt time.Time
+}
+
+func replicationWriterID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().UnixNano())
+}
+
+func isReplicationHeartbeat(item commondynamodb.Item) bool {
+	hash, ok := item["BlobHash"].(*types.AttributeValueMemberS)
+	return ok && hash.Value == heartbeatPartition
+}
+
+func (s *BlobMetadataStore) putReplicationHeartbeat(ctx context.Context, heartbeat replicationHeartbeat, expiry time.Time) error {
+	return s.dynamoDBClient.PutItem(ctx, s.tableName, commondynamodb.Item{
+		"BlobHash":     &types.AttributeValueMemberS{Value: heartbeatPartition},
+		"MetadataHash": &types.AttributeValueMemberS{Value: heartbeat.Writer},
+		"EnqueuedAt":   &types.AttributeValueMemberN{Value: strconv.FormatInt(heartbeat.EnqueuedAt.UnixNano(), 10)},
+		// lets the table TTL clean up the heartbeats of writers that are gone
+		"Expiry": &types.AttributeValueMemberN{Value: strconv.FormatInt(expiry.Unix(), 10)},
+	})
+}
+
+func (s *BlobMetadataStore) getReplicationHeartbeats(ctx context.Context) ([]replicationHeartbeat, error) {
+	items, err := s.dynamoDBClient.Query(ctx, s.tableName, "BlobHash = :partition", commondynamodb.ExpresseionValues{
+		":partition": &types.AttributeValueMemberS{Value: heartbeatPartition},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	heartbeats := make([]replicationHeartbeat, 0, len(items))
+	for _, item := range items {
+		writer, ok := item["MetadataHash"].(*types.AttributeValueMemberS)
+		if !ok {
+			continue
+		}
+		enqueuedAt, ok := item["EnqueuedAt"].(*types.AttributeValueMemberN)
+		if !ok {
+			continue
+		}
+		nanos, err := strconv.ParseInt(enqueuedAt.Value, 10, 64)
+		if err != nil {
+			continue
+		}
+		heartbeats = append(heartbeats, replicationHeartbeat{Writer: writer.Value, EnqueuedAt: time.Unix(0, nanos)})
+	}
+	return heartbeats, nil
+}
+
+// ReadReplica serves metadata reads from the replica table. The replication lag is bounded
+// by the oldest heartbeat of the writers still sending them: a writer whose heartbeats
+// stopped for heartbeatExpiryIntervals is assumed gone, so a writer stuck for that long
+// goes unnoticed. The bound is no watermark, the readers only trust the replica with the
+// blobs it has in a final status.
+type ReadReplica struct {
+	store  *BlobMetadataStore
+	config ReplicationConfig
+	logger common.Logger
+	now    func() time.Time
+
+	mu sync.RWMutex
+	// oldest is the oldest heartbeat of the live writers, zero if there is none
+	oldest time.Time
+}
+
+var _ disperser.MetadataReplica = (*ReadReplica)(nil)
+
+func NewReadReplica(store *BlobMetadataStore, config ReplicationConfig, logger common.Logger) *ReadReplica {
+	return &ReadReplica{
+		store:  store,
+		config: config,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+func (r *ReadReplica) Start(ctx context.Context) {
+	r.refresh(ctx)
+	go func() {
+		ticker := time.NewTicker(r.config.HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.refresh(ctx)
+			}
+		}
+	}()
+}
+
+func (r *ReadReplica) refresh(ctx context.Context) {
+	heartbeats, err := r.store.getReplicationHeartbeats(ctx)
+	if err != nil {
+		r.logger.Warn("[replication] failed to read replication heartbeats", "err", err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.oldest = oldestLiveHeartbeat(heartbeats, r.now(), heartbeatExpiryIntervals*r.config.HeartbeatInterval)
+}
+
+func oldestLiveHeartbeat(heartbeats []replicationHeartbeat, now time.Time, expiry time.Duration) time.Time {
+	var oldest time.Time
+	for _, heartbeat := range heartbeats {
+		if now.Sub(heartbeat.EnqueuedAt) > expiry {
+			continue
+		}
+		if oldest.IsZero() || heartbeat.EnqueuedAt.Before(oldest) {
+			oldest = heartbeat.EnqueuedAt
+		}
+	}
+	return oldest
+}
+
+// Staleness returns an upper bound of the replication lag, and false if it is unknown or
+// exceeds the maximum read staleness
+func (r *ReadReplica) Staleness() (time.Duration, bool) {
+	r.mu.RLock()
+	oldest := r.oldest
+	r.mu.RUnlock()
+
+	if oldest.IsZero() {
+		return 0, false
+	}
+	staleness := r.now().Sub(oldest)
+	return staleness, staleness <= r.config.MaxReadStaleness
+}
+
+func (r *ReadReplica) GetBlobMetadata(ctx context.Context, blobKey disperser.BlobKey) (*disperser.BlobMetadata, error) {
+	return r.store.GetBlobMetadata(ctx, blobKey)
+}
+
+func (r *ReadReplica) GetAllBlobMetadataByBatch(ctx context.Context, batchHeaderHash [32]byte) ([]*disperser.BlobMetadata, error) {
+	return r.store.GetAllBlobMetadataByBatch(ctx, batchHeaderHash)
+}
@@ -0,0 +1,36 @@
+package blobstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadReplicaStaleness(t *testing.T) {
+	now := time.Unix(1000, 0)
+	r := NewReadReplica(nil, ReplicationConfig{HeartbeatInterval: 10 * time.Second, MaxReadStaleness: 30 * time.Second}, mock.NewLogger(false))
+	r.now = func() time.Time { return now }
+
+	// no heartbeat yet
+	_, ok := r.Staleness()
+	assert.False(t, ok)
+
+	heartbeats := []replicationHeartbeat{
+		{Writer: "a", EnqueuedAt: now.Add(-5 * time.Second)},
+		{Writer: "b", EnqueuedAt: now.Add(-20 * time.Second)},
+		// stopped long ago, ignored
+		{Writer: "c", EnqueuedAt: now.Add(-time.Hour)},
+	}
+	r.oldest = oldestLiveHeartbeat(heartbeats, now, heartbeatExpiryIntervals*r.config.HeartbeatInterval)
+	staleness, ok := r.Staleness()
+	assert.True(t, ok)
+	assert.Equal(t, 20*time.Second, staleness)
+
+	// the replica falls behind
+	now = now.Add(15 * time.Second)
+	staleness, ok = r.Staleness()
+	assert.False(t, ok)
+	assert.Equal(t, 35*time.Second, staleness)
+}
@@ -0,0 +1,418 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	commonaws "github.com/0glabs/0g-da-client/common/aws"
+	commondynamodb "github.com/0glabs/0g-da-client/common/aws/dynamodb"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	maxReplicationAttempts = 5
+	replicationRetryDelay  = 5 * time.Second
+)
+
+type ReplicationConfig struct {
+	// Region and TableName identify the replica table. Replication is disabled if either is empty.
+	Region      string
+	EndpointURL string
+	TableName   string
+	// QueueSize is the number of pending replications buffered before the writes wait for room
+	QueueSize int
+	// HeartbeatInterval is how often writers send a heartbeat through their replication
+	// queue, which readers of the replica use to bound its staleness
+	HeartbeatInterval time.Duration
+	// ServeReads lets the api server serve blob status reads from the replica as long as
+	// its staleness is at most MaxReadStaleness
+	ServeReads       bool
+	MaxReadStaleness time.Duration
+}
+
+func (c ReplicationConfig) Enabled() bool {
+	return c.Region != "" && c.TableName != ""
+}
+
+// replicationOp copies a record of the primary table to the replica, or removes it from the
+// replica if the primary no longer has it
+type replicationOp struct {
+	// key is that of the record, a blob or the dispersal nonces of a signer
+	key      disperser.BlobKey
+	attempts int
+	// heartbeat is set for heartbeat ops, which carry no blob key
+	heartbeat *replicationHeartbeat
+}
+
+// MetadataReplicator asynchronously copies blob metadata, including the confirmation
+// info of confirmed blobs, the batch assignments and the lifecycle histories, and the
+// dispersal nonces from the primary table to a replica table in another region. Blob
+// payloads are not replicated. A record is copied as the primary holds it when the copy
+// runs, so the copies may run in any order and a write is never lost: the writes wait for
+// room in the queue, and the records whose copy couldn't be queued or kept failing are
+// kept in a backlog swept into the queue again until they are copied.
+type MetadataReplicator struct {
+	primary           *BlobMetadataStore
+	replica           *BlobMetadataStore
+	queue             chan replicationOp
+	heartbeatInterval time.Duration
+	writerID          string
+	logger            common.Logger
+
+	mu      sync.Mutex
+	backlog map[disperser.BlobKey]struct{}
+}
+
+func NewMetadataReplicator(primary *BlobMetadataStore, replica *BlobMetadataStore, config ReplicationConfig, logger common.Logger) *MetadataReplicator {
+	return &MetadataReplicator{
+		primary:           primary,
+		replica:           replica,
+		queue:             make(chan replicationOp, config.QueueSize),
+		heartbeatInterval: config.HeartbeatInterval,
+		writerID:          replicationWriterID(),
+		logger:            logger,
+		backlog:           make(map[disperser.BlobKey]struct{}),
+	}
+}
+
+func (r *MetadataReplicator) Start(ctx context.Context) {
+	if r.heartbeatInterval > 0 {
+		go r.sendHeartbeats(ctx)
+	}
+	go r.sweepBacklog(ctx)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case op := <-r.queue:
+				if err := r.replicate(ctx, op); err != nil {
+					r.retry(ctx, op, err)
+				}
+			}
+		}
+	}()
+}
+
+// Replicate schedules the record of key to be copied to the replica as the primary holds
+// it, removed from the replica if the primary doesn't. It waits for room in the queue until
+// ctx is done, leaving the record to the backlog then.
+func (r *MetadataReplicator) Replicate(ctx context.Context, key disperser.BlobKey) {
+	r.enqueue(ctx, replicationOp{key: key})
+}
+
+func (r *MetadataReplicator) enqueue(ctx context.Context, op replicationOp) {
+	if op.heartbeat != nil {
+		// the next heartbeat supersedes this one
+		select {
+		case r.queue <- op:
+		default:
+			r.logger.Warn("[replication] queue is full, dropping heartbeat")
+		}
+		return
+	}
+	select {
+	case r.queue <- op:
+	case <-ctx.Done():
+		r.logger.Warn("[replication] queue is full, deferring replication to the backlog", "blobKey", op.key.String())
+		r.postpone(op.key)
+	}
+}
+
+// postpone adds a record to the backlog
+func (r *MetadataReplicator) postpone(key disperser.BlobKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.backlog[key] = struct{}{}
+}
+
+// sweepBacklog queues the records of the backlog again every replicationRetryDelay
+func (r *MetadataReplicator) sweepBacklog(ctx context.Context) {
+	ticker := time.NewTicker(replicationRetryDelay)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		r.mu.Lock()
+		keys := make([]disperser.BlobKey, 0, len(r.backlog))
+		for key := range r.backlog {
+			keys = append(keys, key)
+		}
+		r.backlog = make(map[disperser.BlobKey]struct{})
+		r.mu.Unlock()
+		for _, key := range keys {
+			r.enqueue(ctx, replicationOp{key: key})
+		}
+	}
+}
+
+func (r *MetadataReplicator) sendHeartbeats(ctx context.Context) {
+	ticker := time.NewTicker(r.heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		r.enqueue(ctx, replicationOp{heartbeat: &replicationHeartbeat{Writer: r.writerID, EnqueuedAt: time.Now()}})
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *MetadataReplicator) retry(ctx context.Context, op replicationOp, err error) {
+	if op.heartbeat != nil {
+		r.logger.Warn("[replication] failed to replicate heartbeat", "err", err)
+		return
+	}
+	op.attempts++
+	if op.attempts >= maxReplicationAttempts {
+		r.logger.Error("[replication] failed to replicate blob metadata, deferring it to the backlog", "blobKey", op.key.String(), "attempts", op.attempts, "err", err)
+		r.postpone(op.key)
+		return
+	}
+	r.logger.Warn("[replication] failed to replicate blob metadata, retrying", "blobKey", op.key.String(), "attempt", op.attempts, "err", err)
+	// the worker must not wait for room in its own queue
+	time.AfterFunc(replicationRetryDelay, func() {
+		r.enqueue(ctx, op)
+	})
+}
+
+func (r *MetadataReplicator) replicate(ctx context.Context, op replicationOp) error {
+	if op.heartbeat != nil {
+		expiry := op.heartbeat.EnqueuedAt.Add(heartbeatExpiryIntervals * r.heartbeatInterval)
+		return r.replica.putReplicationHeartbeat(ctx, *op.heartbeat, expiry)
+	}
+
+	key := recordKey(op.key)
+	item, err := r.primary.dynamoDBClient.GetItemConsistent(ctx, r.primary.tableName, key)
+	if err != nil {
+		return err
+	}
+	if len(item) == 0 {
+		// removed from the primary
+		return r.replica.dynamoDBClient.DeleteItem(ctx, r.replica.tableName, key)
+	}
+	return r.replica.dynamoDBClient.PutItem(ctx, r.replica.tableName, item)
+}
+
+// recordKey returns the key of the record of a blob, or of the dispersal nonces of a signer
+func recordKey(key disperser.BlobKey) commondynamodb.Key {
+	return commondynamodb.Key{
+		"BlobHash":     &types.AttributeValueMemberS{Value: key.BlobHash},
+		"MetadataHash": &types.AttributeValueMemberS{Value: key.MetadataHash},
+	}
+}
+
+// nonceRecord returns the key of the record of the dispersal nonces of signer
+func nonceRecord(signer string) disperser.BlobKey {
+	return disperser.BlobKey{BlobHash: dispersalNoncePartition, MetadataHash: signer}
+}
+
+// ReplicatedBlobStore schedules a replication of the record it changed after every
+// successful write to the underlying store. Every write method of disperser.BlobStore is
+// overridden, so that none is replicated only by the next write of the blob.
+type ReplicatedBlobStore struct {
+	disperser.BlobStore
+	replicator *MetadataReplicator
+}
+
+var _ disperser.BlobStore = (*ReplicatedBlobStore)(nil)
+var _ disperser.DispersalNonceStore = (*ReplicatedBlobStore)(nil)
+
+func NewReplicatedBlobStore(store disperser.BlobStore, replicator *MetadataReplicator) *ReplicatedBlobStore {
+	return &ReplicatedBlobStore{
+		BlobStore:  store,
+		replicator: replicator,
+	}
+}
+
+func (s *ReplicatedBlobStore) StoreBlob(ctx context.Context, blob *core.Blob, requestedAt uint64) (disperser.BlobKey, error) {
+	key, err := s.BlobStore.StoreBlob(ctx, blob, requestedAt)
+	return key, s.replicateAfter(ctx, key, err)
+}
+
+func (s *ReplicatedBlobStore) StoreQuarantinedBlob(ctx context.Context, blob *core.Blob, requestedAt uint64, reason string) (disperser.BlobKey, error) {
+	key, err := s.BlobStore.StoreQuarantinedBlob(ctx, blob, requestedAt, reason)
+	return key, s.replicateAfter(ctx, key, err)
+}
+
+func (s *ReplicatedBlobStore) RemoveBlob(ctx context.Context, metadata *disperser.BlobMetadata) error {
+	return s.replicateAfter(ctx, metadata.GetBlobKey(), s.BlobStore.RemoveBlob(ctx, metadata))
+}
+
+func (s *ReplicatedBlobStore) MarkBlobConfirmed(ctx context.Context, existingMetadata *disperser.BlobMetadata, confirmationInfo *disperser.ConfirmationInfo) (*disperser.BlobMetadata, error) {
+	metadata, err := s.BlobStore.MarkBlobConfirmed(ctx, existingMetadata, confirmationInfo)
+	return metadata, s.replicateAfter(ctx, existingMetadata.GetBlobKey(), err)
+}
+
+func (s *ReplicatedBlobStore) MarkBlobFinalized(ctx context.Context, blobKey disperser.BlobKey) error {
+	return s.replicateAfter(ctx, blobKey, s.BlobStore.MarkBlobFinalized(ctx, blobKey))
+}
+
+func (s *ReplicatedBlobStore) MarkBlobProcessing(ctx context.Context, blobKey disperser.BlobKey) error {
+	return s.replicateAfter(ctx, blobKey, s.BlobStore.MarkBlobProcessing(ctx, blobKey))
+}
+
+func (s *ReplicatedBlobStore) MarkBlobFailed(ctx context.Context, blobKey disperser.BlobKey) error {
+	return s.replicateAfter(ctx, blobKey, s.BlobStore.MarkBlobFailed(ctx, blobKey))
+}
+
+func (s *ReplicatedBlobStore) MarkBlobDeadLettered(ctx context.Context, existingMetadata *disperser.BlobMetadata, reason string) error {
+	return s.replicateAfter(ctx, existingMetadata.GetBlobKey(), s.BlobStore.MarkBlobDeadLettered(ctx, existingMetadata, reason))
+}
+
+func (s *ReplicatedBlobStore) ResubmitBlob(ctx context.Context, existingMetadata *disperser.BlobMetadata) error {
+	return s.replicateAfter(ctx, existingMetadata.GetBlobKey(), s.BlobStore.ResubmitBlob(ctx, existingMetadata))
+}
+
+func (s *ReplicatedBlobStore) ReviewQuarantinedBlob(ctx context.Context, blobKey disperser.BlobKey, status disperser.BlobStatus) error {
+	return s.replicateAfter(ctx, blobKey, s.BlobStore.ReviewQuarantinedBlob(ctx, blobKey, status))
+}
+
+func (s *ReplicatedBlobStore) IncrementBlobRetryCount(ctx context.Context, existingMetadata *disperser.BlobMetadata) error {
+	return s.replicateAfter(ctx, existingMetadata.GetBlobKey(), s.BlobStore.IncrementBlobRetryCount(ctx, existingMetadata))
+}
+
+func (s *ReplicatedBlobStore) SetBlobBatchAssignment(ctx context.Context, blobKey disperser.BlobKey, assignment *disperser.BatchAssignment) error {
+	return s.replicateAfter(ctx, blobKey, s.BlobStore.SetBlobBatchAssignment(ctx, blobKey, assignment))
+}
+
+func (s *ReplicatedBlobStore) AppendBlobEvent(ctx context.Context, blobKey disperser.BlobKey, event disperser.BlobEvent) error {
+	return s.replicateAfter(ctx, blobKey, s.BlobStore.AppendBlobEvent(ctx, blobKey, event))
+}
+
+func (s *ReplicatedBlobStore) HandleBlobFailure(ctx context.Context, metadata *disperser.BlobMetadata, maxRetry uint) error {
+	return s.replicateAfter(ctx, metadata.GetBlobKey(), s.BlobStore.HandleBlobFailure(ctx, metadata, maxRetry))
+}
+
+func (s *ReplicatedBlobStore) UseDispersalNonce(ctx context.Context, signer string, nonce uint64) error {
+	return s.replicateAfter(ctx, nonceRecord(signer), s.BlobStore.UseDispersalNonce(ctx, signer, nonce))
+}
+
+func (s *ReplicatedBlobStore) DispersalNonces(ctx context.Context) (map[string]disperser.NonceWindow, error) {
+	store, ok := s.BlobStore.(disperser.DispersalNonceStore)
+	if !ok {
+		return nil, errors.New("the replicated blob store can't list its dispersal nonces")
+	}
+	return store.DispersalNonces(ctx)
+}
+
+func (s *ReplicatedBlobStore) RestoreDispersalNonce(ctx context.Context, signer string, window disperser.NonceWindow) error {
+	store, ok := s.BlobStore.(disperser.DispersalNonceStore)
+	if !ok {
+		return errors.New("the replicated blob store can't restore dispersal nonces")
+	}
+	return s.replicateAfter(ctx, nonceRecord(signer), store.RestoreDispersalNonce(ctx, signer, window))
+}
+
+// replicateAfter schedules the replication of the record of key if the write err is that
+// of succeeded. The write is replicated even if ctx is done, through the backlog.
+func (s *ReplicatedBlobStore) replicateAfter(ctx context.Context, key disperser.BlobKey, err error) error {
+	if err == nil {
+		s.replicator.Replicate(ctx, key)
+	}
+	return err
+}
+
+// NewReplicaMetadataStore returns a metadata store for the replica table. The replica
+// uses the credentials of the primary with the region and endpoint of the replica.
+func NewReplicaMetadataStore(config ReplicationConfig, primary commonaws.ClientConfig, logger common.Logger) (*BlobMetadataStore, error) {
+	awsConfig := primary
+	awsConfig.Region = config.Region
+	awsConfig.EndpointURL = config.EndpointURL
+	client, err := commondynamodb.NewStandaloneClient(awsConfig, logger)
+	if err != nil {
+		return nil, err
+	}
+	return NewBlobMetadataStore(client, logger, config.TableName, 0), nil
+}
+
+// CopyTo makes dst a copy of the table: it copies every record of the table to dst,
+// overwriting existing records, then deletes the records of dst the table doesn't have,
+// such as the blobs removed from the table while the replication lagged. It is used to
+// catch a replica up with the primary before promoting it. onPage is called with the
+// records copied, and then deleted, so far.
+func (s *BlobMetadataStore) CopyTo(ctx context.Context, dst *BlobMetadataStore, pageSize int32, onPage func(copied int) error) error {
+	copiedKeys := make(map[disperser.BlobKey]struct{})
+	copied := 0
+	err := s.scanPages(ctx, pageSize, func(items []commondynamodb.Item) error {
+		failed, err := dst.dynamoDBClient.PutItems(ctx, dst.tableName, items)
+		if err != nil {
+			return err
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("failed to copy %d blob metadata records", len(failed))
+		}
+		for _, item := range items {
+			copiedKeys[blobKeyOfItem(item)] = struct{}{}
+		}
+		copied += len(items)
+		return onPage(copied)
+	})
+	if err != nil {
+		return err
+	}
+
+	deleted := 0
+	return dst.scanPages(ctx, pageSize, func(items []commondynamodb.Item) error {
+		extras := make([]commondynamodb.Key, 0)
+		for _, item := range items {
+			key := blobKeyOfItem(item)
+			if _, ok := copiedKeys[key]; !ok && key.BlobHash != heartbeatPartition {
+				extras = append(extras, recordKey(key))
+			}
+		}
+		if len(extras) == 0 {
+			return nil
+		}
+		failed, err := dst.dynamoDBClient.DeleteItems(ctx, dst.tableName, extras)
+		if err != nil {
+			return err
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("failed to delete %d blob metadata records the table doesn't have", len(failed))
+		}
+		deleted += len(extras)
+		s.logger.Info("[replication] deleted records the table doesn't have", "deleted", deleted)
+		return onPage(copied + deleted)
+	})
+}
+
+// scanPages calls onPage with the pages of up to pageSize records of the table
+func (s *BlobMetadataStore) scanPages(ctx context.Context, pageSize int32, onPage func(items []commondynamodb.Item) error) error {
+	var startKey commondynamodb.Key
+	for {
+		items, lastKey, err := s.dynamoDBClient.ScanPage(ctx, s.tableName, startKey, pageSize)
+		if err != nil {
+			return err
+		}
+		if err := onPage(items); err != nil {
+			return err
+		}
+		if len(lastKey) == 0 {
+			return nil
+		}
+		startKey = lastKey
+	}
+}
+
+// blobKeyOfItem returns the key of a record as a blob key
+func blobKeyOfItem(item commondynamodb.Item) disperser.BlobKey {
+	var key disperser.BlobKey
+	if hash, ok := item["BlobHash"].(*types.AttributeValueMemberS); ok {
+		key.BlobHash = hash.Value
+	}
+	if hash, ok := item["MetadataHash"].(*types.AttributeValueMemberS); ok {
+		key.MetadataHash = hash.Value
+	}
+	return key
+}
@@ -0,0 +1,68 @@
+package blobstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicatedBlobStoreWrites(t *testing.T) {
+	ctx := context.Background()
+	logger := mock.NewLogger(false)
+	replicator := NewMetadataReplicator(nil, nil, ReplicationConfig{QueueSize: 10}, logger)
+	store := NewReplicatedBlobStore(memorydb.NewBlobStore(core.MaxBlobSize*8, logger), replicator)
+	queued := func() disperser.BlobKey {
+		select {
+		case op := <-replicator.queue:
+			return op.key
+		default:
+			t.Fatal("no replication queued")
+			return disperser.BlobKey{}
+		}
+	}
+
+	key, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("blob")}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, key, queued())
+
+	// the lifecycle history and the dispersal nonces are replicated too
+	require.NoError(t, store.AppendBlobEvent(ctx, key, disperser.BlobEvent{Type: disperser.BlobConfirmed}))
+	assert.Equal(t, key, queued())
+	require.NoError(t, store.UseDispersalNonce(ctx, "signer", 1))
+	assert.Equal(t, nonceRecord("signer"), queued())
+	window, _ := disperser.NonceWindow{}.Use(5)
+	require.NoError(t, store.RestoreDispersalNonce(ctx, "other", window))
+	assert.Equal(t, nonceRecord("other"), queued())
+	nonces, err := store.DispersalNonces(ctx)
+	require.NoError(t, err)
+	assert.Len(t, nonces, 2)
+
+	// a failed write isn't
+	assert.ErrorIs(t, store.UseDispersalNonce(ctx, "signer", 1), disperser.ErrNonceUsed)
+	assert.Empty(t, replicator.queue)
+}
+
+func TestReplicationBacklog(t *testing.T) {
+	replicator := NewMetadataReplicator(nil, nil, ReplicationConfig{QueueSize: 1}, mock.NewLogger(false))
+	first := disperser.BlobKey{BlobHash: "first", MetadataHash: "1"}
+	second := disperser.BlobKey{BlobHash: "second", MetadataHash: "1"}
+	replicator.Replicate(context.Background(), first)
+
+	// a write whose replication can't be queued before its context is done leaves it to the
+	// backlog rather than dropping it
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	replicator.Replicate(ctx, second)
+	assert.Equal(t, first, (<-replicator.queue).key)
+	assert.Contains(t, replicator.backlog, second)
+
+	// as does a replication failing every attempt
+	replicator.retry(context.Background(), replicationOp{key: first, attempts: maxReplicationAttempts - 1}, assert.AnError)
+	assert.Contains(t, replicator.backlog, first)
+}
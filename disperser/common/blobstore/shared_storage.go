@@ -9,14 +9,22 @@ import (
 	"time"
 
 	"github.com/0glabs/0g-da-client/common"
+	commondynamodb "github.com/0glabs/0g-da-client/common/aws/dynamodb"
 	"github.com/0glabs/0g-da-client/common/aws/s3"
 	"github.com/0glabs/0g-da-client/core"
 	"github.com/0glabs/0g-da-client/disperser"
 	"github.com/gammazero/workerpool"
+	"github.com/hashicorp/golang-lru/v2/expirable"
 )
 
 const (
 	maxS3BlobFetchWorkers = 64
+	// sessionWindow is how long a write made through this store is served with
+	// strongly consistent reads under disperser.SessionRead
+	sessionWindow = 30 * time.Second
+	// maxSessionWrites bounds the writes remembered, the least recent ones being read
+	// eventually again before their window ends
+	maxSessionWrites = 4096
 )
 
 // The shared blob store that the disperser is operating on.
@@ -40,12 +48,24 @@ const (
 // See blob_metadata_store.go for more details on BlobMetadataStore.
 type SharedBlobStore struct {
 	bucketName            string
-	s3Client              *s3.Client
+	s3Client              objectStore
 	blobMetadataStore     *BlobMetadataStore
 	metadataHashAsBlobKey bool
 	logger                common.Logger
+
+	// sessionWrites are the blobs written through this store within the session window
+	sessionWrites *expirable.LRU[disperser.BlobKey, struct{}]
+}
+
+// objectStore is what the store uses of the s3 client
+type objectStore interface {
+	UploadObject(ctx context.Context, bucket string, key string, data []byte) error
+	DownloadObject(ctx context.Context, bucket string, key string) ([]byte, error)
+	DeleteObject(ctx context.Context, bucket string, key string) error
 }
 
+var _ objectStore = (*s3.Client)(nil)
+
 type Config struct {
 	BucketName            string
 	TableName             string
@@ -74,6 +94,7 @@ func NewSharedStorage(bucketName string, s3Client *s3.Client, MetadataHashAsBlob
 		blobMetadataStore:     blobMetadataStore,
 		metadataHashAsBlobKey: MetadataHashAsBlobKey,
 		logger:                logger,
+		sessionWrites:         newSessionWrites(sessionWindow),
 	}
 }
 
@@ -90,6 +111,14 @@ func (s *SharedBlobStore) RemoveBlob(ctx context.Context, metadata *disperser.Bl
 }
 
 func (s *SharedBlobStore) StoreBlob(ctx context.Context, blob *core.Blob, requestedAt uint64) (disperser.BlobKey, error) {
+	return s.storeBlob(ctx, blob, requestedAt, disperser.Processing, "")
+}
+
+func (s *SharedBlobStore) StoreQuarantinedBlob(ctx context.Context, blob *core.Blob, requestedAt uint64, reason string) (disperser.BlobKey, error) {
+	return s.storeBlob(ctx, blob, requestedAt, disperser.Quarantined, reason)
+}
+
+func (s *SharedBlobStore) storeBlob(ctx context.Context, blob *core.Blob, requestedAt uint64, status disperser.BlobStatus, reason string) (disperser.BlobKey, error) {
 	metadataKey := disperser.BlobKey{}
 	if blob == nil {
 		return metadataKey, errors.New("blob is nil")
@@ -123,45 +152,51 @@ func (s *SharedBlobStore) StoreBlob(ctx context.Context, blob *core.Blob, reques
 		BlobHash:     blobHash,
 		MetadataHash: metadataHash,
 		NumRetries:   0,
-		BlobStatus:   disperser.Processing,
+		BlobStatus:   status,
 		Expiry:       expiry,
 		RequestMetadata: &disperser.RequestMetadata{
 			BlobRequestHeader: blob.RequestHeader,
 			BlobSize:          uint(len(blob.Data)),
 			RequestedAt:       requestedAt,
 		},
+		QuarantineReason: reason,
 	}
 	err = s.blobMetadataStore.QueueNewBlobMetadata(ctx, &metadata)
 	if err != nil {
 		s.logger.Error("[sharedstorage] error uploading blob metadata", "err", err)
 		return metadataKey, err
 	}
+	s.recordWrite(metadataKey)
 
 	return metadataKey, nil
 }
 
 // GetBlobContent retrieves blob content by the blob key.
 func (s *SharedBlobStore) GetBlobContent(ctx context.Context, metadata *disperser.BlobMetadata) ([]byte, error) {
+	var data []byte
+	var err error
 	if s.metadataHashAsBlobKey {
-		return s.s3Client.DownloadObject(ctx, s.bucketName, metadata.MetadataHash)
+		data, err = s.s3Client.DownloadObject(ctx, s.bucketName, metadata.MetadataHash)
 	} else {
-		return s.s3Client.DownloadObject(ctx, s.bucketName, blobObjectKey(metadata.BlobHash))
+		data, err = s.s3Client.DownloadObject(ctx, s.bucketName, blobObjectKey(metadata.BlobHash))
 	}
+	if err != nil {
+		return nil, err
+	}
+	if err := metadata.VerifyContent(data); err != nil {
+		s.logger.Error("[sharedstorage] blob checksum mismatch", "key", metadata.GetBlobKey().String())
+		return nil, err
+	}
+	return data, nil
 }
 
-func (s *SharedBlobStore) getBlobContentParallel(ctx context.Context, blobKey disperser.BlobKey, blobRequestHeader core.BlobRequestHeader, resultChan chan<- blobResultOrError) {
-	var blob []byte
-	var err error
-	if s.metadataHashAsBlobKey {
-		blob, err = s.s3Client.DownloadObject(ctx, s.bucketName, blobKey.MetadataHash)
-	} else {
-		blob, err = s.s3Client.DownloadObject(ctx, s.bucketName, blobObjectKey(blobKey.BlobHash))
-	}
+func (s *SharedBlobStore) getBlobContentParallel(ctx context.Context, metadata *disperser.BlobMetadata, resultChan chan<- blobResultOrError) {
+	blob, err := s.GetBlobContent(ctx, metadata)
 	if err != nil {
-		resultChan <- blobResultOrError{err: err}
+		resultChan <- blobResultOrError{err: err, blobKey: metadata.GetBlobKey()}
 		return
 	}
-	resultChan <- blobResultOrError{blob: blob, blobKey: blobKey, blobRequestHeader: blobRequestHeader}
+	resultChan <- blobResultOrError{blob: blob, blobKey: metadata.GetBlobKey(), blobRequestHeader: metadata.RequestMetadata.BlobRequestHeader}
 }
 
 func (s *SharedBlobStore) MarkBlobConfirmed(ctx context.Context, existingMetadata *disperser.BlobMetadata, confirmationInfo *disperser.ConfirmationInfo) (*disperser.BlobMetadata, error) {
@@ -173,25 +208,106 @@ func (s *SharedBlobStore) MarkBlobConfirmed(ctx context.Context, existingMetadat
 	}
 	newMetadata.BlobStatus = disperser.Confirmed
 	newMetadata.ConfirmationInfo = confirmationInfo
+	s.recordWrite(existingMetadata.GetBlobKey())
 	return &newMetadata, s.blobMetadataStore.UpdateBlobMetadata(ctx, existingMetadata.GetBlobKey(), &newMetadata)
 }
 
 func (s *SharedBlobStore) MarkBlobFinalized(ctx context.Context, metadataKey disperser.BlobKey) error {
-	return s.blobMetadataStore.SetBlobStatus(ctx, metadataKey, disperser.Finalized)
+	s.recordWrite(metadataKey)
+	err := s.blobMetadataStore.SetBlobStatusIf(ctx, metadataKey, disperser.Finalized, disperser.Confirmed)
+	if !errors.Is(err, commondynamodb.ErrConditionFailed) {
+		return err
+	}
+	// the blob isn't confirmed, which is fine if it is finalized already
+	metadata, err := s.blobMetadataStore.GetBlobMetadataConsistent(ctx, metadataKey)
+	if err != nil {
+		return err
+	}
+	switch {
+	case metadata == nil || metadata.BlobHash == "":
+		return disperser.ErrBlobNotFound
+	case metadata.BlobStatus == disperser.Finalized:
+		return nil
+	default:
+		return fmt.Errorf("%w: blob is %s", disperser.ErrStatusConflict, metadata.BlobStatus)
+	}
 }
 
 func (s *SharedBlobStore) MarkBlobProcessing(ctx context.Context, metadataKey disperser.BlobKey) error {
-	return s.blobMetadataStore.SetBlobStatus(ctx, metadataKey, disperser.Processing)
+	s.recordWrite(metadataKey)
+	return s.blobMetadataStore.ClearConfirmation(ctx, metadataKey, disperser.Processing)
 }
 
 func (s *SharedBlobStore) MarkBlobFailed(ctx context.Context, metadataKey disperser.BlobKey) error {
+	s.recordWrite(metadataKey)
 	return s.blobMetadataStore.SetBlobStatus(ctx, metadataKey, disperser.Failed)
 }
 
+// MarkBlobDeadLettered only sets the status and the reason, so that the fields written since
+// existingMetadata was read are kept. A blob confirmed meanwhile stays confirmed.
+func (s *SharedBlobStore) MarkBlobDeadLettered(ctx context.Context, existingMetadata *disperser.BlobMetadata, reason string) error {
+	s.recordWrite(existingMetadata.GetBlobKey())
+	err := s.blobMetadataStore.SetDeadLettered(ctx, existingMetadata.GetBlobKey(), reason)
+	if errors.Is(err, commondynamodb.ErrConditionFailed) {
+		return fmt.Errorf("%w: blob is confirmed or not stored", disperser.ErrStatusConflict)
+	}
+	return err
+}
+
+func (s *SharedBlobStore) ResubmitBlob(ctx context.Context, existingMetadata *disperser.BlobMetadata) error {
+	s.recordWrite(existingMetadata.GetBlobKey())
+	err := s.blobMetadataStore.ResetDeadLettered(ctx, existingMetadata.GetBlobKey())
+	if errors.Is(err, commondynamodb.ErrConditionFailed) {
+		return fmt.Errorf("%w: blob isn't dead-lettered", disperser.ErrStatusConflict)
+	}
+	return err
+}
+
+func (s *SharedBlobStore) ReviewQuarantinedBlob(ctx context.Context, blobKey disperser.BlobKey, status disperser.BlobStatus) error {
+	s.recordWrite(blobKey)
+	err := s.blobMetadataStore.SetBlobStatusIf(ctx, blobKey, status, disperser.Quarantined)
+	if errors.Is(err, commondynamodb.ErrConditionFailed) {
+		return fmt.Errorf("%w: blob isn't quarantined", disperser.ErrStatusConflict)
+	}
+	return err
+}
+
 func (s *SharedBlobStore) IncrementBlobRetryCount(ctx context.Context, existingMetadata *disperser.BlobMetadata) error {
+	s.recordWrite(existingMetadata.GetBlobKey())
 	return s.blobMetadataStore.IncrementNumRetries(ctx, existingMetadata)
 }
 
+func (s *SharedBlobStore) SetBlobBatchAssignment(ctx context.Context, blobKey disperser.BlobKey, assignment *disperser.BatchAssignment) error {
+	s.recordWrite(blobKey)
+	err := s.blobMetadataStore.SetBatchAssignment(ctx, blobKey, assignment)
+	if errors.Is(err, commondynamodb.ErrConditionFailed) {
+		return disperser.ErrBlobNotFound
+	}
+	return err
+}
+
+func (s *SharedBlobStore) AppendBlobEvent(ctx context.Context, blobKey disperser.BlobKey, event disperser.BlobEvent) error {
+	return s.blobMetadataStore.AppendEvent(ctx, blobKey, event)
+}
+
+func (s *SharedBlobStore) UseDispersalNonce(ctx context.Context, signer string, nonce uint64) error {
+	return s.blobMetadataStore.UseDispersalNonce(ctx, signer, nonce)
+}
+
+var _ disperser.DispersalNonceStore = (*SharedBlobStore)(nil)
+
+func (s *SharedBlobStore) DispersalNonces(ctx context.Context) (map[string]disperser.NonceWindow, error) {
+	return s.blobMetadataStore.DispersalNonces(ctx)
+}
+
+func (s *SharedBlobStore) RestoreDispersalNonce(ctx context.Context, signer string, window disperser.NonceWindow) error {
+	return s.blobMetadataStore.RestoreDispersalNonce(ctx, signer, window)
+}
+
+func (s *SharedBlobStore) GetBlobEvents(ctx context.Context, blobKey disperser.BlobKey) ([]disperser.BlobEvent, error) {
+	return s.blobMetadataStore.GetEvents(ctx, blobKey)
+}
+
 func (s *SharedBlobStore) GetBlobsByMetadata(ctx context.Context, metadata []*disperser.BlobMetadata) (map[disperser.BlobKey]*core.Blob, error) {
 	pool := workerpool.New(maxS3BlobFetchWorkers)
 	resultChan := make(chan blobResultOrError, len(metadata))
@@ -202,7 +318,7 @@ func (s *SharedBlobStore) GetBlobsByMetadata(ctx context.Context, metadata []*di
 		mCopy := m // avoid capturing loop variable "m" directly by making a copy
 		pool.Submit(func() {
 			// Fetch blob content from S3
-			s.getBlobContentParallel(ctx, mCopy.GetBlobKey(), mCopy.RequestMetadata.BlobRequestHeader, resultChan)
+			s.getBlobContentParallel(ctx, mCopy, resultChan)
 		})
 	}
 
@@ -210,7 +326,12 @@ func (s *SharedBlobStore) GetBlobsByMetadata(ctx context.Context, metadata []*di
 	close(resultChan)
 
 	// Collect results from channel
+	var corrupted []disperser.BlobKey
 	for result := range resultChan {
+		if errors.Is(result.err, disperser.ErrBlobCorrupted) {
+			corrupted = append(corrupted, result.blobKey)
+			continue
+		}
 		if result.err != nil {
 			return nil, result.err
 		}
@@ -220,6 +341,10 @@ func (s *SharedBlobStore) GetBlobsByMetadata(ctx context.Context, metadata []*di
 		}
 	}
 
+	// corrupted blobs are reported along with the blobs that were read successfully
+	if len(corrupted) > 0 {
+		return blobs, &disperser.BlobCorruptionError{Keys: corrupted}
+	}
 	return blobs, nil
 }
 
@@ -240,11 +365,58 @@ func (s *SharedBlobStore) GetBlobMetadata(ctx context.Context, metadataKey dispe
 	return s.blobMetadataStore.GetBlobMetadata(ctx, metadataKey)
 }
 
+func (s *SharedBlobStore) GetBlobMetadataWithOptions(ctx context.Context, metadataKey disperser.BlobKey, opts disperser.ReadOptions) (*disperser.BlobMetadata, error) {
+	consistent := opts.Consistency == disperser.StrongRead ||
+		(opts.Consistency == disperser.SessionRead && s.inSession(metadataKey))
+
+	var metadata *disperser.BlobMetadata
+	var err error
+	if consistent {
+		metadata, err = s.blobMetadataStore.GetBlobMetadataConsistent(ctx, metadataKey)
+	} else {
+		metadata, err = s.blobMetadataStore.GetBlobMetadata(ctx, metadataKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if opts.Condition == nil || opts.Condition(metadata) {
+		return metadata, nil
+	}
+	if consistent {
+		return metadata, disperser.ErrConditionNotMet
+	}
+
+	// the eventually consistent read may be stale, check again against the latest state
+	metadata, err = s.blobMetadataStore.GetBlobMetadataConsistent(ctx, metadataKey)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.Condition(metadata) {
+		return metadata, disperser.ErrConditionNotMet
+	}
+	return metadata, nil
+}
+
+func newSessionWrites(window time.Duration) *expirable.LRU[disperser.BlobKey, struct{}] {
+	return expirable.NewLRU[disperser.BlobKey, struct{}](maxSessionWrites, nil, window)
+}
+
+// recordWrite remembers that the blob was written through this store so that
+// session reads of it are served consistently.
+func (s *SharedBlobStore) recordWrite(key disperser.BlobKey) {
+	s.sessionWrites.Add(key, struct{}{})
+}
+
+func (s *SharedBlobStore) inSession(key disperser.BlobKey) bool {
+	_, ok := s.sessionWrites.Peek(key)
+	return ok
+}
+
 func (s *SharedBlobStore) HandleBlobFailure(ctx context.Context, metadata *disperser.BlobMetadata, maxRetry uint) error {
 	if metadata.NumRetries < maxRetry {
 		return s.IncrementBlobRetryCount(ctx, metadata)
 	} else {
-		return s.MarkBlobFailed(ctx, metadata.GetBlobKey())
+		return s.MarkBlobDeadLettered(ctx, metadata, disperser.DeadLetterRetriesExhausted)
 	}
 }
 
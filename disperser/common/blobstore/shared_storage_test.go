@@ -0,0 +1,69 @@
+package blobstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionWrites(t *testing.T) {
+	s := &SharedBlobStore{sessionWrites: newSessionWrites(50 * time.Millisecond)}
+	written := disperser.BlobKey{BlobHash: "written", MetadataHash: "1"}
+	s.recordWrite(written)
+	assert.True(t, s.inSession(written))
+	assert.False(t, s.inSession(disperser.BlobKey{BlobHash: "other", MetadataHash: "1"}))
+
+	// a write past the window is read eventually again
+	assert.Eventually(t, func() bool { return !s.inSession(written) }, time.Second, 5*time.Millisecond)
+	s.recordWrite(written)
+	assert.True(t, s.inSession(written))
+
+	// the least recent writes are forgotten once the writes pile up
+	s = &SharedBlobStore{sessionWrites: newSessionWrites(sessionWindow)}
+	old := disperser.BlobKey{BlobHash: "old", MetadataHash: "1"}
+	s.recordWrite(old)
+	for i := 0; i < maxSessionWrites; i++ {
+		s.recordWrite(disperser.BlobKey{BlobHash: "filler", MetadataHash: fmt.Sprint(i)})
+	}
+	assert.False(t, s.inSession(old))
+	assert.Equal(t, maxSessionWrites, s.sessionWrites.Len())
+	s.recordWrite(written)
+	assert.True(t, s.inSession(written))
+}
+
+func TestCorruptedBlob(t *testing.T) {
+	ctx := context.Background()
+	objects := mock.NewS3Client()
+	s := &SharedBlobStore{bucketName: "blobs", s3Client: objects, logger: mock.NewLogger(false)}
+	put := func(data, stored string, metadataHash string) *disperser.BlobMetadata {
+		sum := sha256.Sum256([]byte(data))
+		metadata := &disperser.BlobMetadata{BlobHash: hex.EncodeToString(sum[:]), MetadataHash: metadataHash, RequestMetadata: &disperser.RequestMetadata{}}
+		require.NoError(t, objects.UploadObject(ctx, "blobs", blobObjectKey(metadata.BlobHash), []byte(stored)))
+		return metadata
+	}
+	intact := put("intact", "intact", "1")
+	corrupted := put("corrupted", "corrupteD", "2")
+
+	data, err := s.GetBlobContent(ctx, intact)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("intact"), data)
+	_, err = s.GetBlobContent(ctx, corrupted)
+	assert.ErrorIs(t, err, disperser.ErrBlobCorrupted)
+
+	// the corrupted blobs are reported along with the intact ones
+	blobs, err := s.GetBlobsByMetadata(ctx, []*disperser.BlobMetadata{intact, corrupted})
+	assert.ErrorIs(t, err, disperser.ErrBlobCorrupted)
+	var corruption *disperser.BlobCorruptionError
+	require.ErrorAs(t, err, &corruption)
+	assert.Equal(t, []disperser.BlobKey{corrupted.GetBlobKey()}, corruption.Keys)
+	require.Len(t, blobs, 1)
+	assert.Equal(t, []byte("intact"), blobs[intact.GetBlobKey()].Data)
+}
@@ -6,19 +6,30 @@ import (
 	"encoding/hex"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/0glabs/0g-da-client/common"
 	"github.com/0glabs/0g-da-client/core"
 	"github.com/0glabs/0g-da-client/disperser"
 )
 
+// DeadLetterRetention is how long the dead-lettered blobs are kept for the admin API to
+// resubmit them. They are purged sooner if the store runs out of space.
+const DeadLetterRetention = 24 * time.Hour
+
 // SharedBlobStore is an in-memory implementation of the SharedBlobStore interface
 type SharedBlobStore struct {
-	mu        sync.RWMutex
-	Blobs     map[string]*BlobHolder
-	Metadata  map[disperser.BlobKey]*disperser.BlobMetadata
+	mu       sync.RWMutex
+	Blobs    map[string]*BlobHolder
+	Metadata map[disperser.BlobKey]*disperser.BlobMetadata
+	events   map[disperser.BlobKey][]disperser.BlobEvent
+	// nonces are the windows of the nonces used by the signers of the dispersals
+	nonces    map[string]disperser.NonceWindow
 	sizeLimit uint64
 	size      uint64
+	// deadLettered are the times the dead-lettered blobs were dead-lettered at
+	deadLettered map[disperser.BlobKey]time.Time
+	now          func() time.Time
 
 	logger common.Logger
 }
@@ -33,21 +44,42 @@ var _ disperser.BlobStore = (*SharedBlobStore)(nil)
 // NewBlobStore creates an empty BlobStore
 func NewBlobStore(sizeLimit uint64, logger common.Logger) disperser.BlobStore {
 	return &SharedBlobStore{
-		Blobs:     make(map[string]*BlobHolder),
-		Metadata:  make(map[disperser.BlobKey]*disperser.BlobMetadata),
-		sizeLimit: sizeLimit,
-		logger:    logger,
+		Blobs:        make(map[string]*BlobHolder),
+		Metadata:     make(map[disperser.BlobKey]*disperser.BlobMetadata),
+		events:       make(map[disperser.BlobKey][]disperser.BlobEvent),
+		nonces:       make(map[string]disperser.NonceWindow),
+		sizeLimit:    sizeLimit,
+		deadLettered: make(map[disperser.BlobKey]time.Time),
+		now:          time.Now,
+		logger:       logger,
 	}
 }
 
+func sizeOfEvent(event disperser.BlobEvent) uint64 {
+	// Type, Time and Detail
+	return 16 + uint64(len(event.Type)) + 24 + 16 + uint64(len(event.Detail))
+}
+
 func sizeOf(metadata *disperser.BlobMetadata) uint64 {
 	var size uint64
 	size += 16 + uint64(len(metadata.BlobHash))
 	size += 16 + uint64(len(metadata.MetadataHash))
 	size += 40 // other fields
+	size += 16 + uint64(len(metadata.QuarantineReason))
+	size += 16 + uint64(len(metadata.DeadLetterReason))
 	if metadata.RequestMetadata != nil {
 		// AccountID
 		size += 16 + uint64(len(metadata.RequestMetadata.AccountID))
+		// Signer
+		size += 16 + uint64(len(metadata.RequestMetadata.Signer))
+		// TraceContext
+		for key, value := range metadata.RequestMetadata.TraceContext {
+			size += 32 + uint64(len(key)+len(value))
+		}
+		// Tags
+		for key, value := range metadata.RequestMetadata.Tags {
+			size += 32 + uint64(len(key)+len(value))
+		}
 		// blob commitments: 64+64+8
 		// security params: 24
 		// TargetChunkNum: 8
@@ -55,6 +87,10 @@ func sizeOf(metadata *disperser.BlobMetadata) uint64 {
 		// RequestedAt: 8
 		size += 184
 	}
+	if metadata.BatchAssignment != nil {
+		// BatchHeaderHash: 32, BlobIndex: 4, BlobCount: 4
+		size += 40
+	}
 	if metadata.ConfirmationInfo != nil {
 		// BatchRoot
 		size += 24 + uint64(len(metadata.ConfirmationInfo.BatchRoot))
@@ -64,6 +100,8 @@ func sizeOf(metadata *disperser.BlobMetadata) uint64 {
 		size += 24 + uint64(len(metadata.ConfirmationInfo.Fee))
 		// Commitment
 		size += 24 + uint64(len(metadata.ConfirmationInfo.CommitmentRoot))
+		// SignerBitmap
+		size += 24 + uint64(len(metadata.ConfirmationInfo.SignerBitmap))
 		// BatchHeaderHash: 32
 		// BlobIndex: 8
 		// BlobCount: 8
@@ -76,7 +114,8 @@ func sizeOf(metadata *disperser.BlobMetadata) uint64 {
 		// ConfirmationBlockNumber: 4
 		// QuorumResults: 8
 		// BlobQuorumInfos: 24
-		size += 172
+		// NumSigners: 4
+		size += 176
 	}
 	return size
 }
@@ -89,19 +128,58 @@ func (q *SharedBlobStore) RemoveBlob(ctx context.Context, metadata *disperser.Bl
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
-	if _, ok := q.Blobs[metadata.MetadataHash]; ok {
+	q.remove(metadata.GetBlobKey())
+	q.logger.Info("[memdb] blob removed", "mem db used", q.size, "limit", q.sizeLimit)
+	return nil
+}
+
+// remove removes a blob under the lock
+func (q *SharedBlobStore) remove(blobKey disperser.BlobKey) {
+	if _, ok := q.Blobs[blobKey.MetadataHash]; ok {
 		q.size -= core.MaxBlobSize
-		delete(q.Blobs, metadata.MetadataHash)
+		delete(q.Blobs, blobKey.MetadataHash)
 	}
-	if existing, ok := q.Metadata[metadata.GetBlobKey()]; ok {
+	if existing, ok := q.Metadata[blobKey]; ok {
 		q.size -= sizeOf(existing)
-		delete(q.Metadata, metadata.GetBlobKey())
+		delete(q.Metadata, blobKey)
+	}
+	for _, event := range q.events[blobKey] {
+		q.size -= sizeOfEvent(event)
+	}
+	delete(q.events, blobKey)
+	delete(q.deadLettered, blobKey)
+}
+
+// purgeDeadLettered removes the blobs dead-lettered for longer than DeadLetterRetention, or
+// all of them if a blob of size more doesn't fit, under the lock
+func (q *SharedBlobStore) purgeDeadLettered(size uint64) {
+	now := q.now()
+	full := q.size+size > q.sizeLimit
+	purged := 0
+	for blobKey, at := range q.deadLettered {
+		if metadata, ok := q.Metadata[blobKey]; !ok || metadata.BlobStatus != disperser.DeadLettered {
+			delete(q.deadLettered, blobKey)
+			continue
+		}
+		if full || now.Sub(at) >= DeadLetterRetention {
+			q.remove(blobKey)
+			purged++
+		}
+	}
+	if purged > 0 {
+		q.logger.Info("[memdb] purged dead-lettered blobs", "count", purged, "mem db used", q.size, "limit", q.sizeLimit)
 	}
-	q.logger.Info("[memdb] blob removed", "mem db used", q.size, "limit", q.sizeLimit)
-	return nil
 }
 
 func (q *SharedBlobStore) StoreBlob(ctx context.Context, blob *core.Blob, requestedAt uint64) (disperser.BlobKey, error) {
+	return q.storeBlob(blob, requestedAt, disperser.Processing, "")
+}
+
+func (q *SharedBlobStore) StoreQuarantinedBlob(ctx context.Context, blob *core.Blob, requestedAt uint64, reason string) (disperser.BlobKey, error) {
+	return q.storeBlob(blob, requestedAt, disperser.Quarantined, reason)
+}
+
+func (q *SharedBlobStore) storeBlob(blob *core.Blob, requestedAt uint64, status disperser.BlobStatus, reason string) (disperser.BlobKey, error) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 	blobKey := disperser.BlobKey{}
@@ -109,36 +187,38 @@ func (q *SharedBlobStore) StoreBlob(ctx context.Context, blob *core.Blob, reques
 	blobHash := getBlobHash(blob)
 	blobKey.BlobHash = blobHash
 	blobKey.MetadataHash = getMetadataHash(requestedAt)
+	q.purgeDeadLettered(core.MaxBlobSize)
 
-	if _, ok := q.Blobs[blobKey.MetadataHash]; !ok {
-		q.size += core.MaxBlobSize
-		if q.size > q.sizeLimit {
-			return blobKey, disperser.ErrMemoryDbIsFull
-		}
-		// Add the blob to the queue
-		q.Blobs[blobKey.MetadataHash] = &BlobHolder{
-			Data: blob.Data,
-		}
+	// the payloads are kept by metadata hash, which is taken by another request if it is stored
+	if _, ok := q.Blobs[blobKey.MetadataHash]; ok {
+		return blobKey, fmt.Errorf("%w: %s", disperser.ErrBlobKeyConflict, blobKey.String())
+	}
+	q.size += core.MaxBlobSize
+	if q.size > q.sizeLimit {
+		return blobKey, disperser.ErrMemoryDbIsFull
+	}
+	// Add the blob to the queue
+	q.Blobs[blobKey.MetadataHash] = &BlobHolder{
+		Data: blob.Data,
 	}
 
-	if _, ok := q.Metadata[blobKey]; !ok {
-		metadata := &disperser.BlobMetadata{
-			BlobHash:     blobHash,
-			MetadataHash: blobKey.MetadataHash,
-			BlobStatus:   disperser.Processing,
-			NumRetries:   0,
-			RequestMetadata: &disperser.RequestMetadata{
-				BlobRequestHeader: blob.RequestHeader,
-				BlobSize:          uint(len(blob.Data)),
-				RequestedAt:       requestedAt,
-			},
-		}
-		q.size += sizeOf(metadata)
-		if q.size > q.sizeLimit {
-			return blobKey, disperser.ErrMemoryDbIsFull
-		}
-		q.Metadata[blobKey] = metadata
+	metadata := &disperser.BlobMetadata{
+		BlobHash:     blobHash,
+		MetadataHash: blobKey.MetadataHash,
+		BlobStatus:   status,
+		NumRetries:   0,
+		RequestMetadata: &disperser.RequestMetadata{
+			BlobRequestHeader: blob.RequestHeader,
+			BlobSize:          uint(len(blob.Data)),
+			RequestedAt:       requestedAt,
+		},
+		QuarantineReason: reason,
 	}
+	q.size += sizeOf(metadata)
+	if q.size > q.sizeLimit {
+		return blobKey, disperser.ErrMemoryDbIsFull
+	}
+	q.Metadata[blobKey] = metadata
 	q.logger.Info("[memdb] blob stored", "mem db used", q.size, "limit", q.sizeLimit)
 	return blobKey, nil
 }
@@ -147,6 +227,9 @@ func (q *SharedBlobStore) GetBlobContent(ctx context.Context, metadata *disperse
 	q.mu.RLock()
 	defer q.mu.RUnlock()
 	if holder, ok := q.Blobs[metadata.MetadataHash]; ok {
+		if err := metadata.VerifyContent(holder.Data); err != nil {
+			return nil, err
+		}
 		return holder.Data, nil
 	} else {
 		return nil, disperser.ErrBlobNotFound
@@ -186,12 +269,23 @@ func (q *SharedBlobStore) MarkBlobConfirmed(ctx context.Context, existingMetadat
 func (q *SharedBlobStore) MarkBlobFinalized(ctx context.Context, blobKey disperser.BlobKey) error {
 	q.mu.Lock()
 	defer q.mu.Unlock()
-	if _, ok := q.Metadata[blobKey]; !ok {
+	metadata, ok := q.Metadata[blobKey]
+	if !ok {
 		return disperser.ErrBlobNotFound
 	}
 
-	q.Metadata[blobKey].BlobStatus = disperser.Finalized
-	return nil
+	switch metadata.BlobStatus {
+	case disperser.Finalized:
+		return nil
+	case disperser.Confirmed:
+		// the metadata read before keeps its status, as with MarkBlobConfirmed
+		finalized := *metadata
+		finalized.BlobStatus = disperser.Finalized
+		q.Metadata[blobKey] = &finalized
+		return nil
+	default:
+		return fmt.Errorf("%w: blob is %s", disperser.ErrStatusConflict, metadata.BlobStatus)
+	}
 }
 
 func (q *SharedBlobStore) MarkBlobProcessing(ctx context.Context, blobKey disperser.BlobKey) error {
@@ -201,7 +295,9 @@ func (q *SharedBlobStore) MarkBlobProcessing(ctx context.Context, blobKey disper
 		return disperser.ErrBlobNotFound
 	}
 
+	// a rolled back blob no longer has the confirmation it had
 	q.Metadata[blobKey].BlobStatus = disperser.Processing
+	q.Metadata[blobKey].ConfirmationInfo = nil
 	return nil
 }
 
@@ -216,6 +312,65 @@ func (q *SharedBlobStore) MarkBlobFailed(ctx context.Context, blobKey disperser.
 	return nil
 }
 
+// MarkBlobDeadLettered leaves a blob confirmed meanwhile confirmed
+func (q *SharedBlobStore) MarkBlobDeadLettered(ctx context.Context, existingMetadata *disperser.BlobMetadata, reason string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	metadata, ok := q.Metadata[existingMetadata.GetBlobKey()]
+	if !ok {
+		return disperser.ErrBlobNotFound
+	}
+	if metadata.BlobStatus == disperser.Confirmed || metadata.BlobStatus == disperser.Finalized {
+		return fmt.Errorf("%w: blob is %s", disperser.ErrStatusConflict, metadata.BlobStatus)
+	}
+
+	q.size -= sizeOf(metadata)
+	metadata.BlobStatus = disperser.DeadLettered
+	metadata.DeadLetterReason = reason
+	q.size += sizeOf(metadata)
+	q.deadLettered[existingMetadata.GetBlobKey()] = q.now()
+	return nil
+}
+
+// ResubmitBlob resets the blob only while it is dead-lettered, so that concurrent resubmissions
+// queue it once
+func (q *SharedBlobStore) ResubmitBlob(ctx context.Context, existingMetadata *disperser.BlobMetadata) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	metadata, ok := q.Metadata[existingMetadata.GetBlobKey()]
+	if !ok {
+		return disperser.ErrBlobNotFound
+	}
+	if metadata.BlobStatus != disperser.DeadLettered {
+		return fmt.Errorf("%w: blob is %s", disperser.ErrStatusConflict, metadata.BlobStatus)
+	}
+
+	q.size -= sizeOf(metadata)
+	metadata.BlobStatus = disperser.Processing
+	metadata.NumRetries = 0
+	metadata.DeadLetterReason = ""
+	q.size += sizeOf(metadata)
+	delete(q.deadLettered, existingMetadata.GetBlobKey())
+	return nil
+}
+
+// ReviewQuarantinedBlob reviews the blob only while it is quarantined, so that concurrent
+// reviews apply once
+func (q *SharedBlobStore) ReviewQuarantinedBlob(ctx context.Context, blobKey disperser.BlobKey, status disperser.BlobStatus) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	metadata, ok := q.Metadata[blobKey]
+	if !ok {
+		return disperser.ErrBlobNotFound
+	}
+	if metadata.BlobStatus != disperser.Quarantined {
+		return fmt.Errorf("%w: blob is %s", disperser.ErrStatusConflict, metadata.BlobStatus)
+	}
+
+	metadata.BlobStatus = status
+	return nil
+}
+
 func (q *SharedBlobStore) IncrementBlobRetryCount(ctx context.Context, existingMetadata *disperser.BlobMetadata) error {
 	q.mu.Lock()
 	defer q.mu.Unlock()
@@ -227,12 +382,55 @@ func (q *SharedBlobStore) IncrementBlobRetryCount(ctx context.Context, existingM
 	return nil
 }
 
+func (q *SharedBlobStore) SetBlobBatchAssignment(ctx context.Context, blobKey disperser.BlobKey, assignment *disperser.BatchAssignment) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	metadata, ok := q.Metadata[blobKey]
+	if !ok {
+		return disperser.ErrBlobNotFound
+	}
+
+	q.size -= sizeOf(metadata)
+	metadata.BatchAssignment = assignment
+	q.size += sizeOf(metadata)
+	return nil
+}
+
+func (q *SharedBlobStore) AppendBlobEvent(ctx context.Context, blobKey disperser.BlobKey, event disperser.BlobEvent) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.Metadata[blobKey]; !ok {
+		return disperser.ErrBlobNotFound
+	}
+	if len(q.events[blobKey]) >= disperser.MaxBlobEvents {
+		return disperser.ErrBlobHistoryFull
+	}
+
+	q.events[blobKey] = append(q.events[blobKey], event)
+	q.size += sizeOfEvent(event)
+	return nil
+}
+
+func (q *SharedBlobStore) GetBlobEvents(ctx context.Context, blobKey disperser.BlobKey) ([]disperser.BlobEvent, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if _, ok := q.Metadata[blobKey]; !ok {
+		return nil, disperser.ErrBlobNotFound
+	}
+	return append([]disperser.BlobEvent{}, q.events[blobKey]...), nil
+}
+
 func (q *SharedBlobStore) GetBlobsByMetadata(ctx context.Context, metadata []*disperser.BlobMetadata) (map[disperser.BlobKey]*core.Blob, error) {
 	q.mu.RLock()
 	defer q.mu.RUnlock()
 	blobs := make(map[disperser.BlobKey]*core.Blob)
+	var corrupted []disperser.BlobKey
 	for _, meta := range metadata {
 		if holder, ok := q.Blobs[meta.MetadataHash]; ok {
+			if err := meta.VerifyContent(holder.Data); err != nil {
+				corrupted = append(corrupted, meta.GetBlobKey())
+				continue
+			}
 			blobs[meta.GetBlobKey()] = &core.Blob{
 				RequestHeader: meta.RequestMetadata.BlobRequestHeader,
 				Data:          holder.Data,
@@ -241,6 +439,9 @@ func (q *SharedBlobStore) GetBlobsByMetadata(ctx context.Context, metadata []*di
 			return nil, disperser.ErrBlobNotFound
 		}
 	}
+	if len(corrupted) > 0 {
+		return blobs, &disperser.BlobCorruptionError{Keys: corrupted}
+	}
 	return blobs, nil
 }
 
@@ -287,14 +488,60 @@ func (q *SharedBlobStore) GetBlobMetadata(ctx context.Context, blobKey disperser
 	return nil, disperser.ErrBlobNotFound
 }
 
+// GetBlobMetadataWithOptions serves every read consistently since the store is in memory.
+func (q *SharedBlobStore) GetBlobMetadataWithOptions(ctx context.Context, blobKey disperser.BlobKey, opts disperser.ReadOptions) (*disperser.BlobMetadata, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	meta, err := q.GetBlobMetadata(ctx, blobKey)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Condition != nil && !opts.Condition(meta) {
+		return meta, disperser.ErrConditionNotMet
+	}
+	return meta, nil
+}
+
 func (q *SharedBlobStore) HandleBlobFailure(ctx context.Context, metadata *disperser.BlobMetadata, maxRetry uint) error {
 	if metadata.NumRetries < maxRetry {
 		return q.IncrementBlobRetryCount(ctx, metadata)
 	} else {
-		return q.MarkBlobFailed(ctx, metadata.GetBlobKey())
+		return q.MarkBlobDeadLettered(ctx, metadata, disperser.DeadLetterRetriesExhausted)
 	}
 }
 
+func (q *SharedBlobStore) UseDispersalNonce(ctx context.Context, signer string, nonce uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	window, ok := q.nonces[signer].Use(nonce)
+	if !ok {
+		return disperser.ErrNonceUsed
+	}
+	q.nonces[signer] = window
+	return nil
+}
+
+var _ disperser.DispersalNonceStore = (*SharedBlobStore)(nil)
+
+func (q *SharedBlobStore) DispersalNonces(ctx context.Context) (map[string]disperser.NonceWindow, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	nonces := make(map[string]disperser.NonceWindow, len(q.nonces))
+	for signer, window := range q.nonces {
+		nonces[signer] = window
+	}
+	return nonces, nil
+}
+
+func (q *SharedBlobStore) RestoreDispersalNonce(ctx context.Context, signer string, window disperser.NonceWindow) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if current, ok := q.nonces[signer]; !ok || current.Highest < window.Highest {
+		q.nonces[signer] = window
+	}
+	return nil
+}
+
 func getBlobHash(blob *core.Blob) disperser.BlobHash {
 	hasher := sha256.New()
 	hasher.Write(blob.Data)
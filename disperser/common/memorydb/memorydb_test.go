@@ -0,0 +1,122 @@
+package memorydb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurgeDeadLettered(t *testing.T) {
+	ctx := context.Background()
+	store := NewBlobStore(core.MaxBlobSize*6, mock.NewLogger(false)).(*SharedBlobStore)
+	now := time.Unix(1000, 0)
+	store.now = func() time.Time { return now }
+
+	dead, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("dead")}, 1)
+	require.NoError(t, err)
+	resubmitted, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("resubmitted")}, 2)
+	require.NoError(t, err)
+	for _, key := range []disperser.BlobKey{dead, resubmitted} {
+		require.NoError(t, store.MarkBlobDeadLettered(ctx, &disperser.BlobMetadata{BlobHash: key.BlobHash, MetadataHash: key.MetadataHash}, disperser.DeadLetterRetriesExhausted))
+	}
+	require.NoError(t, store.ResubmitBlob(ctx, &disperser.BlobMetadata{BlobHash: resubmitted.BlobHash, MetadataHash: resubmitted.MetadataHash}))
+
+	// kept for the admin api within the retention
+	_, err = store.StoreBlob(ctx, &core.Blob{Data: []byte("new")}, 3)
+	require.NoError(t, err)
+	_, err = store.GetBlobMetadata(ctx, dead)
+	assert.NoError(t, err)
+
+	// purged past it
+	now = now.Add(DeadLetterRetention)
+	_, err = store.StoreBlob(ctx, &core.Blob{Data: []byte("newer")}, 4)
+	require.NoError(t, err)
+	_, err = store.GetBlobMetadata(ctx, dead)
+	assert.ErrorIs(t, err, disperser.ErrBlobNotFound)
+	metadata, err := store.GetBlobMetadata(ctx, resubmitted)
+	require.NoError(t, err)
+	assert.Equal(t, disperser.Processing, metadata.BlobStatus)
+
+	// or once the store is full
+	require.NoError(t, store.MarkBlobDeadLettered(ctx, &disperser.BlobMetadata{BlobHash: resubmitted.BlobHash, MetadataHash: resubmitted.MetadataHash}, disperser.DeadLetterExpired))
+	for i, data := range []string{"a", "b", "c"} {
+		_, err = store.StoreBlob(ctx, &core.Blob{Data: []byte(data)}, uint64(5+i))
+		require.NoError(t, err)
+	}
+	_, err = store.GetBlobMetadata(ctx, resubmitted)
+	assert.ErrorIs(t, err, disperser.ErrBlobNotFound)
+}
+
+func TestDeadLetterTransitionsConditional(t *testing.T) {
+	ctx := context.Background()
+	store := NewBlobStore(core.MaxBlobSize*4, mock.NewLogger(false))
+	key, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("data")}, 1)
+	require.NoError(t, err)
+	metadata, err := store.GetBlobMetadata(ctx, key)
+	require.NoError(t, err)
+
+	// a blob can only be resubmitted once dead-lettered, and only once
+	assert.ErrorIs(t, store.ResubmitBlob(ctx, metadata), disperser.ErrStatusConflict)
+	require.NoError(t, store.MarkBlobDeadLettered(ctx, metadata, disperser.DeadLetterExpired))
+	require.NoError(t, store.ResubmitBlob(ctx, metadata))
+	assert.ErrorIs(t, store.ResubmitBlob(ctx, metadata), disperser.ErrStatusConflict)
+
+	// a blob confirmed meanwhile stays confirmed
+	_, err = store.MarkBlobConfirmed(ctx, metadata, &disperser.ConfirmationInfo{})
+	require.NoError(t, err)
+	assert.ErrorIs(t, store.MarkBlobDeadLettered(ctx, metadata, disperser.DeadLetterExpired), disperser.ErrStatusConflict)
+	metadata, err = store.GetBlobMetadata(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, disperser.Confirmed, metadata.BlobStatus)
+}
+
+func TestStoreBlobKeyConflict(t *testing.T) {
+	ctx := context.Background()
+	store := NewBlobStore(core.MaxBlobSize*4, mock.NewLogger(false))
+
+	key, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("first")}, 1)
+	require.NoError(t, err)
+	_, err = store.StoreBlob(ctx, &core.Blob{Data: []byte("second")}, 1)
+	assert.ErrorIs(t, err, disperser.ErrBlobKeyConflict)
+
+	metadata, err := store.GetBlobMetadata(ctx, key)
+	require.NoError(t, err)
+	data, err := store.GetBlobContent(ctx, metadata)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("first"), data)
+}
+
+func TestCorruptedBlob(t *testing.T) {
+	ctx := context.Background()
+	store := NewBlobStore(core.MaxBlobSize*4, mock.NewLogger(false)).(*SharedBlobStore)
+	intact, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("intact")}, 1)
+	require.NoError(t, err)
+	corrupted, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("corrupted")}, 2)
+	require.NoError(t, err)
+	store.Blobs[corrupted.MetadataHash].Data = []byte("corrupteD")
+
+	intactMetadata, err := store.GetBlobMetadata(ctx, intact)
+	require.NoError(t, err)
+	corruptedMetadata, err := store.GetBlobMetadata(ctx, corrupted)
+	require.NoError(t, err)
+	data, err := store.GetBlobContent(ctx, intactMetadata)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("intact"), data)
+	_, err = store.GetBlobContent(ctx, corruptedMetadata)
+	assert.ErrorIs(t, err, disperser.ErrBlobCorrupted)
+
+	// the corrupted blobs are reported along with the intact ones
+	blobs, err := store.GetBlobsByMetadata(ctx, []*disperser.BlobMetadata{intactMetadata, corruptedMetadata})
+	assert.ErrorIs(t, err, disperser.ErrBlobCorrupted)
+	var corruption *disperser.BlobCorruptionError
+	require.ErrorAs(t, err, &corruption)
+	assert.Equal(t, []disperser.BlobKey{corrupted}, corruption.Keys)
+	require.Len(t, blobs, 1)
+	assert.Equal(t, []byte("intact"), blobs[intact].Data)
+}
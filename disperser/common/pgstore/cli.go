@@ -0,0 +1,89 @@
+package pgstore
+
+import (
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/urfave/cli"
+)
+
+const (
+	DSNFlagName          = "postgres.dsn"
+	MaxOpenConnsFlagName = "postgres.max-open-conns"
+	MaxRetriesFlagName   = "postgres.max-retries"
+	RetryBackoffFlagName = "postgres.retry-backoff"
+
+	ExportDSNFlagName       = "postgres.export-dsn"
+	ExportQueueSizeFlagName = "postgres.export-queue-size"
+	ExportIntervalFlagName  = "postgres.export-interval"
+	ExportBatchSizeFlagName = "postgres.export-batch-size"
+)
+
+func CLIFlags(envPrefix string, flagPrefix string) []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, DSNFlagName),
+			Usage:  "PostgreSQL connection string of the blob store. The S3 and DynamoDB blob store is used if empty",
+			EnvVar: common.PrefixEnvVar(envPrefix, "POSTGRES_DSN"),
+		},
+		cli.IntFlag{
+			Name:   common.PrefixFlag(flagPrefix, MaxOpenConnsFlagName),
+			Usage:  "Maximum number of open connections to PostgreSQL",
+			Value:  16,
+			EnvVar: common.PrefixEnvVar(envPrefix, "POSTGRES_MAX_OPEN_CONNS"),
+		},
+		cli.IntFlag{
+			Name:   common.PrefixFlag(flagPrefix, MaxRetriesFlagName),
+			Usage:  "Number of times a PostgreSQL operation failing with a transient error is retried",
+			Value:  3,
+			EnvVar: common.PrefixEnvVar(envPrefix, "POSTGRES_MAX_RETRIES"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, RetryBackoffFlagName),
+			Usage:  "Delay before the first retry of a PostgreSQL operation, doubled on each retry",
+			Value:  100 * time.Millisecond,
+			EnvVar: common.PrefixEnvVar(envPrefix, "POSTGRES_RETRY_BACKOFF"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, ExportDSNFlagName),
+			Usage:  "PostgreSQL connection string of the analytics database the blob and batch metadata is exported to. Disabled if empty",
+			EnvVar: common.PrefixEnvVar(envPrefix, "POSTGRES_EXPORT_DSN"),
+		},
+		cli.IntFlag{
+			Name:   common.PrefixFlag(flagPrefix, ExportQueueSizeFlagName),
+			Usage:  "Number of blobs waiting for their export before the exports of new ones are dropped",
+			Value:  100000,
+			EnvVar: common.PrefixEnvVar(envPrefix, "POSTGRES_EXPORT_QUEUE_SIZE"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, ExportIntervalFlagName),
+			Usage:  "How often the pending blobs are exported to the analytics database",
+			Value:  5 * time.Second,
+			EnvVar: common.PrefixEnvVar(envPrefix, "POSTGRES_EXPORT_INTERVAL"),
+		},
+		cli.IntFlag{
+			Name:   common.PrefixFlag(flagPrefix, ExportBatchSizeFlagName),
+			Usage:  "Number of blobs exported per transaction",
+			Value:  500,
+			EnvVar: common.PrefixEnvVar(envPrefix, "POSTGRES_EXPORT_BATCH_SIZE"),
+		},
+	}
+}
+
+func ReadCLIConfig(ctx *cli.Context, flagPrefix string) Config {
+	return Config{
+		DSN:          ctx.GlobalString(common.PrefixFlag(flagPrefix, DSNFlagName)),
+		MaxOpenConns: ctx.GlobalInt(common.PrefixFlag(flagPrefix, MaxOpenConnsFlagName)),
+		MaxRetries:   ctx.GlobalInt(common.PrefixFlag(flagPrefix, MaxRetriesFlagName)),
+		RetryBackoff: ctx.GlobalDuration(common.PrefixFlag(flagPrefix, RetryBackoffFlagName)),
+	}
+}
+
+func ReadExportConfig(ctx *cli.Context, flagPrefix string) ExportConfig {
+	return ExportConfig{
+		DSN:       ctx.GlobalString(common.PrefixFlag(flagPrefix, ExportDSNFlagName)),
+		QueueSize: ctx.GlobalInt(common.PrefixFlag(flagPrefix, ExportQueueSizeFlagName)),
+		Interval:  ctx.GlobalDuration(common.PrefixFlag(flagPrefix, ExportIntervalFlagName)),
+		BatchSize: ctx.GlobalInt(common.PrefixFlag(flagPrefix, ExportBatchSizeFlagName)),
+	}
+}
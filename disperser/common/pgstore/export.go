@@ -0,0 +1,403 @@
+package pgstore
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/lib/pq"
+)
+
+//go:embed export_schema.sql
+var exportSchema string
+
+type ExportConfig struct {
+	// DSN is the connection string of the analytics database. The export is disabled if empty.
+	DSN string
+	// QueueSize is the number of blobs waiting for their export before new ones are dropped
+	QueueSize int
+	// Interval is how often the pending blobs are exported
+	Interval time.Duration
+	// BatchSize is the number of blobs exported per transaction
+	BatchSize int
+}
+
+func (c ExportConfig) Enabled() bool {
+	return c.DSN != ""
+}
+
+func (c ExportConfig) validate() error {
+	if c.QueueSize <= 0 || c.BatchSize <= 0 {
+		return errors.New("the export queue and batch sizes must be positive")
+	}
+	if c.Interval <= 0 {
+		return errors.New("the export interval must be positive")
+	}
+	return nil
+}
+
+// Exporter asynchronously mirrors the metadata of blobs and of the batches confirming them
+// into the relational schema of export_schema.sql, for the analytics and reporting queries
+// the blob store can't serve efficiently. Blobs are exported with their latest metadata,
+// read back from the blob store, so the updates of a blob between two exports coalesce.
+// Removed blobs are kept with their removal time, exported with the metadata they had when
+// removed if they were removed before their first export. The removals are kept as
+// tombstones until they are exported, so that neither an update of the blob nor a failed
+// export loses them.
+//
+// The export is best effort: blobs updated while the queue is full are dropped, and the
+// exports failing are retried until they succeed or the queue overflows.
+type Exporter struct {
+	db     *sql.DB
+	source disperser.BlobStore
+	config ExportConfig
+	logger common.Logger
+
+	mu sync.Mutex
+	// pending are the blobs whose current metadata is to export
+	pending map[disperser.BlobKey]struct{}
+	// tombstones are the removals to export, removed once exported
+	tombstones map[disperser.BlobKey]*removal
+}
+
+// removal is the removal of a blob, whose metadata can't be read back from the blob store
+type removal struct {
+	metadata  *disperser.BlobMetadata
+	removedAt time.Time
+}
+
+// OpenExporter connects to the analytics database of the config and creates its schema
+func OpenExporter(ctx context.Context, config ExportConfig, source disperser.BlobStore, logger common.Logger) (*Exporter, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("postgres", config.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to connect to the analytics database: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, exportSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to create the analytics schema: %w", err)
+	}
+	return NewExporter(db, config, source, logger), nil
+}
+
+// NewExporter returns an exporter to db, whose schema must have been created
+func NewExporter(db *sql.DB, config ExportConfig, source disperser.BlobStore, logger common.Logger) *Exporter {
+	return &Exporter{
+		db:         db,
+		source:     source,
+		config:     config,
+		logger:     logger,
+		pending:    make(map[disperser.BlobKey]struct{}),
+		tombstones: make(map[disperser.BlobKey]*removal),
+	}
+}
+
+// Start exports the pending blobs every interval until ctx is done
+func (e *Exporter) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(e.config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.flush(ctx)
+			}
+		}
+	}()
+}
+
+// Export schedules the export of the current metadata of a blob
+func (e *Exporter) Export(key disperser.BlobKey) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.pending[key]; ok {
+		return
+	}
+	if e.full(key) {
+		return
+	}
+	e.pending[key] = struct{}{}
+}
+
+// ExportRemoval schedules the export of the removal of a blob, with its metadata before the
+// removal
+func (e *Exporter) ExportRemoval(metadata *disperser.BlobMetadata) {
+	key := metadata.GetBlobKey()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.tombstones[key]; !ok && e.full(key) {
+		return
+	}
+	e.tombstones[key] = &removal{metadata: metadata, removedAt: time.Now()}
+}
+
+// full returns whether the queue is full, dropping the export of key if it is
+func (e *Exporter) full(key disperser.BlobKey) bool {
+	if len(e.pending)+len(e.tombstones) < e.config.QueueSize {
+		return false
+	}
+	e.logger.Warn("[pgexport] queue is full, dropping export", "blobKey", key.String())
+	return true
+}
+
+// flush exports the pending blobs and removals, BatchSize blobs per transaction. The blobs
+// of a failed transaction are scheduled again, and its removals stay in the tombstones, to
+// be exported on the next flush.
+func (e *Exporter) flush(ctx context.Context) {
+	e.mu.Lock()
+	pending := e.pending
+	e.pending = make(map[disperser.BlobKey]struct{})
+	removed := make(map[disperser.BlobKey]*removal, len(e.tombstones))
+	for key, r := range e.tombstones {
+		removed[key] = r
+	}
+	e.mu.Unlock()
+
+	keys := make([]disperser.BlobKey, 0, len(pending)+len(removed))
+	for key := range pending {
+		keys = append(keys, key)
+	}
+	for key := range removed {
+		if _, ok := pending[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	for start := 0; start < len(keys); start += e.config.BatchSize {
+		end := start + e.config.BatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		batch := keys[start:end]
+		if err := e.export(ctx, batch, pending, removed); err != nil {
+			e.logger.Warn("[pgexport] failed to export blobs, retrying on the next flush", "blobs", end-start, "err", err)
+			for _, key := range batch {
+				if _, ok := pending[key]; ok {
+					e.Export(key)
+				}
+			}
+			continue
+		}
+		e.buryTombstones(batch, removed)
+	}
+}
+
+// buryTombstones removes the tombstones of the removals exported, unless the blob was
+// removed again meanwhile
+func (e *Exporter) buryTombstones(keys []disperser.BlobKey, removed map[disperser.BlobKey]*removal) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, key := range keys {
+		if r, ok := removed[key]; ok && e.tombstones[key] == r {
+			delete(e.tombstones, key)
+		}
+	}
+}
+
+// export exports the removals and the current metadata of the blobs of keys in a
+// transaction. A removal is exported before the current metadata, so that a blob stored
+// again after its removal is no longer removed.
+func (e *Exporter) export(ctx context.Context, keys []disperser.BlobKey, pending map[disperser.BlobKey]struct{}, removed map[disperser.BlobKey]*removal) error {
+	metadata := make(map[disperser.BlobKey]*disperser.BlobMetadata, len(keys))
+	for _, key := range keys {
+		if _, ok := pending[key]; !ok {
+			continue
+		}
+		meta, err := e.source.GetBlobMetadata(ctx, key)
+		if errors.Is(err, disperser.ErrBlobNotFound) {
+			// removed since it was scheduled, its removal is exported from its tombstone
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		metadata[key] = meta
+	}
+
+	tx, err := e.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if r := removed[key]; r != nil {
+			err = exportBlob(ctx, tx, r.metadata, &r.removedAt)
+		}
+		if meta, ok := metadata[key]; ok && err == nil {
+			err = exportBlob(ctx, tx, meta, nil)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// exportBlob upserts the metadata of a blob, and of the batch confirming it. A removed blob
+// keeps the time it was first removed at, and a blob stored again is no longer removed.
+func exportBlob(ctx context.Context, tx *sql.Tx, meta *disperser.BlobMetadata, removedAt *time.Time) error {
+	request := meta.RequestMetadata
+	if request == nil {
+		request = &disperser.RequestMetadata{}
+	}
+	quorumIDs := make([]int64, len(request.SecurityParams))
+	for i, param := range request.SecurityParams {
+		quorumIDs[i] = int64(param.QuorumID)
+	}
+	var expiry *time.Time
+	if meta.Expiry > 0 {
+		t := time.Unix(int64(meta.Expiry), 0)
+		expiry = &t
+	}
+	var batchHeaderHash []byte
+	var blobIndex, batchID, confirmationBlock *int64
+	info := meta.ConfirmationInfo
+	if info != nil {
+		batchHeaderHash = info.BatchHeaderHash[:]
+		blobIndex, batchID, confirmationBlock = int64Ptr(int64(info.BlobIndex)), int64Ptr(int64(info.BatchID)), int64Ptr(int64(info.ConfirmationBlockNumber))
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO analytics_blobs (blob_hash, metadata_hash, status, account_id, priority, quorum_ids, blob_size, requested_at, num_retries, expiry,
+			batch_header_hash, blob_index, batch_id, confirmation_block_number, quarantine_reason, dead_letter_reason, removed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		ON CONFLICT (blob_hash, metadata_hash) DO UPDATE SET
+			status = EXCLUDED.status, num_retries = EXCLUDED.num_retries, expiry = EXCLUDED.expiry,
+			batch_header_hash = EXCLUDED.batch_header_hash, blob_index = EXCLUDED.blob_index, batch_id = EXCLUDED.batch_id,
+			confirmation_block_number = EXCLUDED.confirmation_block_number, quarantine_reason = EXCLUDED.quarantine_reason,
+			dead_letter_reason = EXCLUDED.dead_letter_reason, exported_at = now(),
+			removed_at = CASE WHEN EXCLUDED.removed_at IS NULL THEN NULL ELSE COALESCE(analytics_blobs.removed_at, EXCLUDED.removed_at) END`,
+		meta.BlobHash, meta.MetadataHash, meta.BlobStatus.String(), string(request.AccountID), int(request.Priority), pq.Array(quorumIDs),
+		int64(request.BlobSize), time.Unix(0, int64(request.RequestedAt)), int(meta.NumRetries), expiry,
+		batchHeaderHash, blobIndex, batchID, confirmationBlock, meta.QuarantineReason, meta.DeadLetterReason, removedAt); err != nil {
+		return err
+	}
+	if info == nil {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO analytics_batches (batch_header_hash, batch_id, batch_root, epoch, quorum_id, reference_block_number, confirmation_block_number,
+			submission_tx_hash, confirmation_tx_hash, num_signers, num_signed)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (batch_header_hash) DO UPDATE SET
+			confirmation_block_number = EXCLUDED.confirmation_block_number, confirmation_tx_hash = EXCLUDED.confirmation_tx_hash, exported_at = now()`,
+		batchHeaderHash, int64(info.BatchID), info.BatchRoot, int64(info.Epoch), int64(info.QuorumId), int64(info.ReferenceBlockNumber),
+		int64(info.ConfirmationBlockNumber), info.SubmissionTxnHash.Hex(), info.ConfirmationTxnHash.Hex(), int(info.NumSigners), numSigned(info.SignerBitmap))
+	return err
+}
+
+func numSigned(bitmap core.SignerBitmap) int {
+	if bitmap == nil {
+		return 0
+	}
+	return bitmap.Count()
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+// ExportedBlobStore schedules an export of the blob metadata after every successful
+// metadata write to the underlying store.
+type ExportedBlobStore struct {
+	disperser.BlobStore
+	exporter *Exporter
+}
+
+var _ disperser.BlobStore = (*ExportedBlobStore)(nil)
+
+func NewExportedBlobStore(store disperser.BlobStore, exporter *Exporter) *ExportedBlobStore {
+	return &ExportedBlobStore{
+		BlobStore: store,
+		exporter:  exporter,
+	}
+}
+
+func (s *ExportedBlobStore) StoreBlob(ctx context.Context, blob *core.Blob, requestedAt uint64) (disperser.BlobKey, error) {
+	key, err := s.BlobStore.StoreBlob(ctx, blob, requestedAt)
+	if err == nil {
+		s.exporter.Export(key)
+	}
+	return key, err
+}
+
+func (s *ExportedBlobStore) StoreQuarantinedBlob(ctx context.Context, blob *core.Blob, requestedAt uint64, reason string) (disperser.BlobKey, error) {
+	key, err := s.BlobStore.StoreQuarantinedBlob(ctx, blob, requestedAt, reason)
+	if err == nil {
+		s.exporter.Export(key)
+	}
+	return key, err
+}
+
+func (s *ExportedBlobStore) RemoveBlob(ctx context.Context, metadata *disperser.BlobMetadata) error {
+	err := s.BlobStore.RemoveBlob(ctx, metadata)
+	if err == nil {
+		s.exporter.ExportRemoval(metadata)
+	}
+	return err
+}
+
+func (s *ExportedBlobStore) MarkBlobConfirmed(ctx context.Context, existingMetadata *disperser.BlobMetadata, confirmationInfo *disperser.ConfirmationInfo) (*disperser.BlobMetadata, error) {
+	metadata, err := s.BlobStore.MarkBlobConfirmed(ctx, existingMetadata, confirmationInfo)
+	if err == nil {
+		s.exporter.Export(existingMetadata.GetBlobKey())
+	}
+	return metadata, err
+}
+
+func (s *ExportedBlobStore) MarkBlobFinalized(ctx context.Context, blobKey disperser.BlobKey) error {
+	return s.exportAfter(blobKey, s.BlobStore.MarkBlobFinalized(ctx, blobKey))
+}
+
+func (s *ExportedBlobStore) MarkBlobProcessing(ctx context.Context, blobKey disperser.BlobKey) error {
+	return s.exportAfter(blobKey, s.BlobStore.MarkBlobProcessing(ctx, blobKey))
+}
+
+func (s *ExportedBlobStore) MarkBlobFailed(ctx context.Context, blobKey disperser.BlobKey) error {
+	return s.exportAfter(blobKey, s.BlobStore.MarkBlobFailed(ctx, blobKey))
+}
+
+func (s *ExportedBlobStore) MarkBlobDeadLettered(ctx context.Context, existingMetadata *disperser.BlobMetadata, reason string) error {
+	return s.exportAfter(existingMetadata.GetBlobKey(), s.BlobStore.MarkBlobDeadLettered(ctx, existingMetadata, reason))
+}
+
+func (s *ExportedBlobStore) ResubmitBlob(ctx context.Context, existingMetadata *disperser.BlobMetadata) error {
+	return s.exportAfter(existingMetadata.GetBlobKey(), s.BlobStore.ResubmitBlob(ctx, existingMetadata))
+}
+
+func (s *ExportedBlobStore) ReviewQuarantinedBlob(ctx context.Context, blobKey disperser.BlobKey, status disperser.BlobStatus) error {
+	return s.exportAfter(blobKey, s.BlobStore.ReviewQuarantinedBlob(ctx, blobKey, status))
+}
+
+func (s *ExportedBlobStore) IncrementBlobRetryCount(ctx context.Context, existingMetadata *disperser.BlobMetadata) error {
+	return s.exportAfter(existingMetadata.GetBlobKey(), s.BlobStore.IncrementBlobRetryCount(ctx, existingMetadata))
+}
+
+func (s *ExportedBlobStore) SetBlobBatchAssignment(ctx context.Context, blobKey disperser.BlobKey, assignment *disperser.BatchAssignment) error {
+	return s.exportAfter(blobKey, s.BlobStore.SetBlobBatchAssignment(ctx, blobKey, assignment))
+}
+
+func (s *ExportedBlobStore) HandleBlobFailure(ctx context.Context, metadata *disperser.BlobMetadata, maxRetry uint) error {
+	return s.exportAfter(metadata.GetBlobKey(), s.BlobStore.HandleBlobFailure(ctx, metadata, maxRetry))
+}
+
+func (s *ExportedBlobStore) exportAfter(key disperser.BlobKey, err error) error {
+	if err == nil {
+		s.exporter.Export(key)
+	}
+	return err
+}
@@ -0,0 +1,46 @@
+-- Analytics schema the exporter mirrors the blob metadata into. Tables and columns are
+-- only ever added, so that the statements stay idempotent across releases.
+CREATE TABLE IF NOT EXISTS analytics_blobs (
+    blob_hash                 TEXT NOT NULL,
+    metadata_hash             TEXT NOT NULL,
+    status                    TEXT NOT NULL,
+    account_id                TEXT NOT NULL,
+    priority                  SMALLINT NOT NULL DEFAULT 0,
+    quorum_ids                INTEGER[] NOT NULL,
+    blob_size                 BIGINT NOT NULL,
+    requested_at              TIMESTAMPTZ NOT NULL,
+    num_retries               INTEGER NOT NULL DEFAULT 0,
+    expiry                    TIMESTAMPTZ,
+    batch_header_hash         BYTEA,
+    blob_index                BIGINT,
+    batch_id                  BIGINT,
+    confirmation_block_number BIGINT,
+    quarantine_reason         TEXT NOT NULL DEFAULT '',
+    dead_letter_reason        TEXT NOT NULL DEFAULT '',
+    removed_at                TIMESTAMPTZ,
+    exported_at               TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (blob_hash, metadata_hash)
+);
+
+CREATE INDEX IF NOT EXISTS analytics_blobs_account_idx ON analytics_blobs (account_id, requested_at);
+CREATE INDEX IF NOT EXISTS analytics_blobs_status_idx ON analytics_blobs (status, requested_at);
+CREATE INDEX IF NOT EXISTS analytics_blobs_batch_idx ON analytics_blobs (batch_header_hash) WHERE batch_header_hash IS NOT NULL;
+
+-- A batch is exported with the first of its confirmed blobs, and kept if a reorg rolls its
+-- blobs back to processing.
+CREATE TABLE IF NOT EXISTS analytics_batches (
+    batch_header_hash         BYTEA PRIMARY KEY,
+    batch_id                  BIGINT NOT NULL,
+    batch_root                BYTEA,
+    epoch                     BIGINT NOT NULL,
+    quorum_id                 BIGINT NOT NULL,
+    reference_block_number    BIGINT NOT NULL,
+    confirmation_block_number BIGINT NOT NULL,
+    submission_tx_hash        TEXT NOT NULL,
+    confirmation_tx_hash      TEXT NOT NULL,
+    num_signers               INTEGER NOT NULL DEFAULT 0,
+    num_signed                INTEGER NOT NULL DEFAULT 0,
+    exported_at               TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS analytics_batches_block_idx ON analytics_batches (confirmation_block_number);
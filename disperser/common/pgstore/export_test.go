@@ -0,0 +1,97 @@
+package pgstore
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// removedAt matches the removal time of a removed blob
+type removedAt struct{}
+
+func (removedAt) Match(v driver.Value) bool {
+	t, ok := v.(time.Time)
+	return ok && time.Since(t) < time.Minute
+}
+
+func TestExportedBlobStore(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	logger := mock.NewLogger(false)
+	source := memorydb.NewBlobStore(core.MaxBlobSize*4, logger)
+	exporter := NewExporter(db, ExportConfig{QueueSize: 10, Interval: time.Second, BatchSize: 10}, source, logger)
+	store := NewExportedBlobStore(source, exporter)
+	ctx := context.Background()
+
+	blob := &core.Blob{Data: []byte("blob"), RequestHeader: core.BlobRequestHeader{SecurityParams: []*core.SecurityParam{{QuorumID: 0}}}}
+	confirmed, err := store.StoreBlob(ctx, blob, 1)
+	require.NoError(t, err)
+	removed, err := store.StoreBlob(ctx, blob, 2)
+	require.NoError(t, err)
+	metadata, err := store.GetBlobMetadata(ctx, confirmed)
+	require.NoError(t, err)
+	_, err = store.MarkBlobConfirmed(ctx, metadata, &disperser.ConfirmationInfo{BatchHeaderHash: [32]byte{1}, BatchID: 7})
+	require.NoError(t, err)
+	metadata, err = store.GetBlobMetadata(ctx, removed)
+	require.NoError(t, err)
+	require.NoError(t, store.RemoveBlob(ctx, metadata))
+
+	// the updates of the confirmed blob coalesce into a single export with its batch
+	dbMock.MatchExpectationsInOrder(false)
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("INSERT INTO analytics_blobs").
+		WithArgs(confirmed.BlobHash, confirmed.MetadataHash, disperser.Confirmed.String(), "", 0, sqlmock.AnyArg(), 4, sqlmock.AnyArg(), 0, nil,
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "", "", nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("INSERT INTO analytics_batches").WillReturnResult(sqlmock.NewResult(0, 1))
+	// the removed blob was never exported, its row is inserted with the metadata it was removed with
+	dbMock.ExpectExec("INSERT INTO analytics_blobs").
+		WithArgs(removed.BlobHash, removed.MetadataHash, disperser.Processing.String(), "", 0, sqlmock.AnyArg(), 4, sqlmock.AnyArg(), 0, nil,
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "", "", removedAt{}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+	exporter.flush(ctx)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+	assert.Empty(t, exporter.pending)
+	assert.Empty(t, exporter.tombstones)
+
+	// failed exports are retried on the next flush
+	require.NoError(t, store.MarkBlobFinalized(ctx, confirmed))
+	dbMock.ExpectBegin().WillReturnError(errors.New("connection refused"))
+	exporter.flush(ctx)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+	assert.Len(t, exporter.pending, 1)
+
+	// a removal is kept until exported, through failed exports and later updates
+	metadata, err = store.GetBlobMetadata(ctx, confirmed)
+	require.NoError(t, err)
+	require.NoError(t, store.RemoveBlob(ctx, metadata))
+	exporter.Export(confirmed)
+	dbMock.ExpectBegin().WillReturnError(errors.New("connection refused"))
+	exporter.flush(ctx)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+	assert.Len(t, exporter.tombstones, 1)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("INSERT INTO analytics_blobs").
+		WithArgs(confirmed.BlobHash, confirmed.MetadataHash, disperser.Finalized.String(), "", 0, sqlmock.AnyArg(), 4, sqlmock.AnyArg(), 0, nil,
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "", "", removedAt{}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("INSERT INTO analytics_batches").WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+	exporter.flush(ctx)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+	assert.Empty(t, exporter.pending)
+	assert.Empty(t, exporter.tombstones)
+}
@@ -0,0 +1,102 @@
+package pgstore
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// migrationLockID is the advisory lock serializing the migrations of the disperser
+// processes sharing a database
+const migrationLockID = 0x7a67646100000001
+
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// loadMigrations returns the embedded migrations ordered by version. Their files are
+// named <version>_<description>.sql and must never change once released.
+func loadMigrations() ([]migration, error) {
+	entries, err := migrations.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+	result := make([]migration, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s is not named <version>_<description>.sql", name)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has an invalid version: %w", name, err)
+		}
+		data, err := migrations.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, migration{version: version, name: name, sql: string(data)})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].version < result[j].version })
+	for i := 1; i < len(result); i++ {
+		if result[i].version == result[i-1].version {
+			return nil, fmt.Errorf("migrations %s and %s have the same version", result[i-1].name, result[i].name)
+		}
+	}
+	return result, nil
+}
+
+// Migrate applies the migrations missing from the database, each in its own transaction
+func (s *BlobStore) Migrate(ctx context.Context) error {
+	all, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return fmt.Errorf("failed to create the migrations table: %w", err)
+	}
+	for _, m := range all {
+		applied := false
+		err := s.withTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, int64(migrationLockID)); err != nil {
+				return err
+			}
+			var exists bool
+			if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, m.version).Scan(&exists); err != nil {
+				return err
+			}
+			if exists {
+				return nil
+			}
+			if _, err := tx.ExecContext(ctx, m.sql); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, m.version); err != nil {
+				return err
+			}
+			applied = true
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", m.name, err)
+		}
+		if applied {
+			s.logger.Info("[pgstore] applied migration", "migration", m.name)
+		}
+	}
+	return nil
+}
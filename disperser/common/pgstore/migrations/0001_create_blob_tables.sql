@@ -0,0 +1,27 @@
+-- Payloads are keyed by the metadata hash, each request owning its copy of the blob.
+CREATE TABLE blob_payloads (
+    metadata_hash TEXT PRIMARY KEY,
+    data          BYTEA NOT NULL
+);
+
+CREATE TABLE blob_metadata (
+    blob_hash          TEXT NOT NULL,
+    metadata_hash      TEXT NOT NULL,
+    status             SMALLINT NOT NULL,
+    expiry             BIGINT NOT NULL DEFAULT 0,
+    num_retries        INTEGER NOT NULL DEFAULT 0,
+    requested_at       BIGINT NOT NULL,
+    request_metadata   JSONB NOT NULL,
+    confirmation_info  JSONB,
+    batch_header_hash  BYTEA,
+    blob_index         BIGINT,
+    batch_id           BIGINT,
+    quarantine_reason  TEXT NOT NULL DEFAULT '',
+    dead_letter_reason TEXT NOT NULL DEFAULT '',
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (blob_hash, metadata_hash)
+);
+
+CREATE INDEX blob_metadata_status_idx ON blob_metadata (status, requested_at);
+CREATE INDEX blob_metadata_batch_idx ON blob_metadata (batch_header_hash, blob_index) WHERE batch_header_hash IS NOT NULL;
+CREATE INDEX blob_metadata_batch_id_idx ON blob_metadata (batch_id) WHERE batch_id IS NOT NULL;
@@ -0,0 +1,2 @@
+-- The position of a blob in the batch it was put in, known before the batch is confirmed.
+ALTER TABLE blob_metadata ADD COLUMN batch_assignment JSONB;
@@ -0,0 +1,11 @@
+-- The lifecycle history of the blobs, removed with their metadata.
+CREATE TABLE blob_events (
+    id            BIGSERIAL PRIMARY KEY,
+    blob_hash     TEXT NOT NULL,
+    metadata_hash TEXT NOT NULL,
+    event_type    TEXT NOT NULL,
+    event_time    TIMESTAMPTZ NOT NULL,
+    detail        TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX blob_events_blob_idx ON blob_events (blob_hash, metadata_hash, id);
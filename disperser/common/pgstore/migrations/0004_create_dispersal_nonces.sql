@@ -0,0 +1,5 @@
+-- The last nonce of the signed dispersals of each signer, uint64 stored as numeric.
+CREATE TABLE dispersal_nonces (
+    signer TEXT PRIMARY KEY,
+    nonce  NUMERIC(20, 0) NOT NULL
+);
@@ -0,0 +1,3 @@
+-- The nonces used below the highest one of each signer, see disperser.NonceWindow. The rows
+-- recorded before have none, all the nonces up to theirs being used.
+ALTER TABLE dispersal_nonces ADD COLUMN used BYTEA;
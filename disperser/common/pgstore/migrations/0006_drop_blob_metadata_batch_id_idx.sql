@@ -0,0 +1,2 @@
+-- Batches are looked up by header hash only, the batch IDs not being unique
+DROP INDEX IF EXISTS blob_metadata_batch_id_idx;
@@ -0,0 +1,75 @@
+package pgstore
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// isTransient returns whether an operation failing with err may succeed if retried
+func isTransient(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		// connection exceptions, serialization failures and deadlocks, insufficient resources
+		case "08", "40", "53":
+			return true
+		}
+		// the server is shutting down or restarting
+		return pqErr.Code == "57P01" || pqErr.Code == "57P03"
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// retry runs op until it succeeds, fails with an error that isn't transient or has been
+// retried MaxRetries times, waiting RetryBackoff before the first retry and twice as long
+// before each of the next ones
+func (s *BlobStore) retry(ctx context.Context, op func() error) error {
+	backoff := s.config.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := op()
+		if err == nil || !isTransient(err) || attempt >= s.config.MaxRetries {
+			return err
+		}
+		s.logger.Warn("[pgstore] retrying operation", "attempt", attempt+1, "err", err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// once runs op a single time, for the statements that aren't idempotent
+func (s *BlobStore) once(ctx context.Context, op func() error) error {
+	return op()
+}
+
+// withTx runs fn in a transaction committed if fn succeeds, retrying the whole
+// transaction on transient errors
+func (s *BlobStore) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return s.retry(ctx, func() error {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if err := fn(tx); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	})
+}
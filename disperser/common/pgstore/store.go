@@ -0,0 +1,675 @@
+package pgstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/lib/pq"
+)
+
+// metadataColumns are the columns scanned by scanMetadata, in order
+const metadataColumns = `blob_hash, metadata_hash, status, expiry, num_retries, request_metadata, confirmation_info, quarantine_reason, dead_letter_reason, batch_assignment`
+
+type Config struct {
+	// DSN is the connection string of the database. The store is disabled if empty.
+	DSN          string
+	MaxOpenConns int
+	// MaxRetries is the number of times an operation failing with a transient error, such as
+	// a dropped connection or a serialization failure, is retried
+	MaxRetries   int
+	RetryBackoff time.Duration
+}
+
+func (c Config) Enabled() bool {
+	return c.DSN != ""
+}
+
+// BlobStore is a disperser.BlobStore keeping both the blobs and their metadata in
+// PostgreSQL, for dispersers running without AWS.
+//
+// The payloads are keyed by the metadata hash of the request, so the same blob dispersed
+// twice is stored twice, as with the in-memory store. The scalar fields of the metadata the
+// batcher updates or queries by have their own columns, the request and confirmation info
+// are stored as JSON.
+type BlobStore struct {
+	db                    *sql.DB
+	config                Config
+	metadataHashAsBlobKey bool
+	logger                common.Logger
+}
+
+var _ disperser.BlobStore = (*BlobStore)(nil)
+
+// Open connects to the database of the config and migrates its schema
+func Open(ctx context.Context, config Config, metadataHashAsBlobKey bool, logger common.Logger) (*BlobStore, error) {
+	db, err := sql.Open("postgres", config.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if config.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(config.MaxOpenConns)
+		db.SetMaxIdleConns(config.MaxOpenConns)
+	}
+	s := NewBlobStore(db, config, metadataHashAsBlobKey, logger)
+	if err := s.retry(ctx, func() error { return db.PingContext(ctx) }); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+	if err := s.Migrate(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewBlobStore returns a store on db, whose schema must have been migrated
+func NewBlobStore(db *sql.DB, config Config, metadataHashAsBlobKey bool, logger common.Logger) *BlobStore {
+	return &BlobStore{
+		db:                    db,
+		config:                config,
+		metadataHashAsBlobKey: metadataHashAsBlobKey,
+		logger:                logger,
+	}
+}
+
+func (s *BlobStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *BlobStore) MetadataHashAsBlobKey() bool {
+	return s.metadataHashAsBlobKey
+}
+
+func (s *BlobStore) StoreBlob(ctx context.Context, blob *core.Blob, requestedAt uint64) (disperser.BlobKey, error) {
+	return s.storeBlob(ctx, blob, requestedAt, disperser.Processing, "")
+}
+
+func (s *BlobStore) StoreQuarantinedBlob(ctx context.Context, blob *core.Blob, requestedAt uint64, reason string) (disperser.BlobKey, error) {
+	return s.storeBlob(ctx, blob, requestedAt, disperser.Quarantined, reason)
+}
+
+// storeBlob writes the payload and the metadata of a blob. disperser.ErrBlobKeyConflict is
+// returned if another request is stored under its key. A retried transaction finds the rows
+// of its earlier attempt if that attempt committed without the commit being acknowledged,
+// which are taken as its own.
+func (s *BlobStore) storeBlob(ctx context.Context, blob *core.Blob, requestedAt uint64, status disperser.BlobStatus, reason string) (disperser.BlobKey, error) {
+	blobKey := disperser.BlobKey{}
+	if blob == nil {
+		return blobKey, errors.New("blob is nil")
+	}
+	blobKey.BlobHash = getBlobHash(blob)
+	blobKey.MetadataHash = getMetadataHash(requestedAt, blob.RequestHeader.SecurityParams)
+
+	requestMetadata, err := json.Marshal(&disperser.RequestMetadata{
+		BlobRequestHeader: blob.RequestHeader,
+		BlobSize:          uint(len(blob.Data)),
+		RequestedAt:       requestedAt,
+	})
+	if err != nil {
+		return blobKey, err
+	}
+
+	attempts := 0
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		attempts++
+		// a payload already stored under the key only counts as written if it is the same
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO blob_payloads (metadata_hash, data) VALUES ($1, $2)
+			ON CONFLICT (metadata_hash) DO UPDATE SET data = blob_payloads.data WHERE blob_payloads.data = EXCLUDED.data`,
+			blobKey.MetadataHash, blob.Data)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return fmt.Errorf("%w: %s", disperser.ErrBlobKeyConflict, blobKey.String())
+		}
+		res, err = tx.ExecContext(ctx,
+			`INSERT INTO blob_metadata (blob_hash, metadata_hash, status, requested_at, request_metadata, quarantine_reason)
+			VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT DO NOTHING`,
+			blobKey.BlobHash, blobKey.MetadataHash, int(status), int64(requestedAt), requestMetadata, reason)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 && attempts == 1 {
+			return fmt.Errorf("%w: %s", disperser.ErrBlobKeyConflict, blobKey.String())
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("[pgstore] error storing blob", "err", err)
+		return blobKey, err
+	}
+	return blobKey, nil
+}
+
+func (s *BlobStore) RemoveBlob(ctx context.Context, metadata *disperser.BlobMetadata) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM blob_payloads WHERE metadata_hash = $1`, metadata.MetadataHash); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM blob_events WHERE blob_hash = $1 AND metadata_hash = $2`, metadata.BlobHash, metadata.MetadataHash); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM blob_metadata WHERE blob_hash = $1 AND metadata_hash = $2`, metadata.BlobHash, metadata.MetadataHash)
+		return err
+	})
+}
+
+func (s *BlobStore) GetBlobContent(ctx context.Context, metadata *disperser.BlobMetadata) ([]byte, error) {
+	var data []byte
+	err := s.retry(ctx, func() error {
+		return s.db.QueryRowContext(ctx, `SELECT data FROM blob_payloads WHERE metadata_hash = $1`, metadata.MetadataHash).Scan(&data)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, disperser.ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := metadata.VerifyContent(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *BlobStore) GetBlobsByMetadata(ctx context.Context, metadata []*disperser.BlobMetadata) (map[disperser.BlobKey]*core.Blob, error) {
+	hashes := make([]string, len(metadata))
+	for i, meta := range metadata {
+		hashes[i] = meta.MetadataHash
+	}
+	payloads := make(map[string][]byte, len(metadata))
+	err := s.retry(ctx, func() error {
+		rows, err := s.db.QueryContext(ctx, `SELECT metadata_hash, data FROM blob_payloads WHERE metadata_hash = ANY($1)`, pq.Array(hashes))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var hash string
+			var data []byte
+			if err := rows.Scan(&hash, &data); err != nil {
+				return err
+			}
+			payloads[hash] = data
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	blobs := make(map[disperser.BlobKey]*core.Blob, len(metadata))
+	var corrupted []disperser.BlobKey
+	for _, meta := range metadata {
+		data, ok := payloads[meta.MetadataHash]
+		if !ok {
+			return nil, disperser.ErrBlobNotFound
+		}
+		if err := meta.VerifyContent(data); err != nil {
+			corrupted = append(corrupted, meta.GetBlobKey())
+			continue
+		}
+		blobs[meta.GetBlobKey()] = &core.Blob{
+			RequestHeader: meta.RequestMetadata.BlobRequestHeader,
+			Data:          data,
+		}
+	}
+	if len(corrupted) > 0 {
+		return blobs, &disperser.BlobCorruptionError{Keys: corrupted}
+	}
+	return blobs, nil
+}
+
+func (s *BlobStore) MarkBlobConfirmed(ctx context.Context, existingMetadata *disperser.BlobMetadata, confirmationInfo *disperser.ConfirmationInfo) (*disperser.BlobMetadata, error) {
+	info, err := json.Marshal(confirmationInfo)
+	if err != nil {
+		return nil, err
+	}
+	blobKey := existingMetadata.GetBlobKey()
+
+	var result *disperser.BlobMetadata
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		current, err := scanMetadata(tx.QueryRowContext(ctx,
+			`SELECT `+metadataColumns+` FROM blob_metadata WHERE blob_hash = $1 AND metadata_hash = $2 FOR UPDATE`,
+			blobKey.BlobHash, blobKey.MetadataHash))
+		if err != nil {
+			return err
+		}
+		// the batch may be confirmed again after a retry, the first confirmation is kept
+		if alreadyConfirmed, _ := current.IsConfirmed(); alreadyConfirmed {
+			result = current
+			return nil
+		}
+		_, err = tx.ExecContext(ctx,
+			`UPDATE blob_metadata SET status = $3, confirmation_info = $4, batch_header_hash = $5, blob_index = $6, batch_id = $7, updated_at = now()
+			WHERE blob_hash = $1 AND metadata_hash = $2`,
+			blobKey.BlobHash, blobKey.MetadataHash, int(disperser.Confirmed), info,
+			confirmationInfo.BatchHeaderHash[:], int64(confirmationInfo.BlobIndex), int64(confirmationInfo.BatchID))
+		if err != nil {
+			return err
+		}
+		newMetadata := *existingMetadata
+		newMetadata.BlobStatus = disperser.Confirmed
+		newMetadata.ConfirmationInfo = confirmationInfo
+		result = &newMetadata
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *BlobStore) MarkBlobFinalized(ctx context.Context, blobKey disperser.BlobKey) error {
+	var updated int64
+	err := s.retry(ctx, func() error {
+		result, err := s.db.ExecContext(ctx,
+			`UPDATE blob_metadata SET status = $3, updated_at = now() WHERE blob_hash = $1 AND metadata_hash = $2 AND status = $4`,
+			blobKey.BlobHash, blobKey.MetadataHash, int(disperser.Finalized), int(disperser.Confirmed))
+		if err != nil {
+			return err
+		}
+		updated, err = result.RowsAffected()
+		return err
+	})
+	if err != nil || updated > 0 {
+		return err
+	}
+	// the blob isn't confirmed, which is fine if it is finalized already
+	meta, err := s.GetBlobMetadata(ctx, blobKey)
+	if err != nil {
+		return err
+	}
+	if meta.BlobStatus != disperser.Finalized {
+		return fmt.Errorf("%w: blob is %s", disperser.ErrStatusConflict, meta.BlobStatus)
+	}
+	return nil
+}
+
+// MarkBlobProcessing clears the confirmation of the blob, which a rolled back blob no longer has
+func (s *BlobStore) MarkBlobProcessing(ctx context.Context, blobKey disperser.BlobKey) error {
+	return s.update(ctx, blobKey, `status = $3, confirmation_info = NULL, batch_header_hash = NULL, blob_index = NULL, batch_id = NULL`, int(disperser.Processing))
+}
+
+func (s *BlobStore) MarkBlobFailed(ctx context.Context, blobKey disperser.BlobKey) error {
+	return s.updateStatus(ctx, blobKey, disperser.Failed)
+}
+
+// MarkBlobDeadLettered only sets the status and the reason, so that the fields written since
+// existingMetadata was read are kept. A blob confirmed meanwhile stays confirmed.
+func (s *BlobStore) MarkBlobDeadLettered(ctx context.Context, existingMetadata *disperser.BlobMetadata, reason string) error {
+	return s.updateIf(ctx, existingMetadata.GetBlobKey(), `status NOT IN ($5, $6)`, `status = $3, dead_letter_reason = $4`,
+		int(disperser.DeadLettered), reason, int(disperser.Confirmed), int(disperser.Finalized))
+}
+
+// ResubmitBlob resets the blob only while it is dead-lettered, so that concurrent resubmissions
+// queue it once
+func (s *BlobStore) ResubmitBlob(ctx context.Context, existingMetadata *disperser.BlobMetadata) error {
+	return s.updateIf(ctx, existingMetadata.GetBlobKey(), `status = $4`, `status = $3, num_retries = 0, dead_letter_reason = ''`,
+		int(disperser.Processing), int(disperser.DeadLettered))
+}
+
+// ReviewQuarantinedBlob reviews the blob only while it is quarantined, so that concurrent
+// reviews apply once
+func (s *BlobStore) ReviewQuarantinedBlob(ctx context.Context, blobKey disperser.BlobKey, status disperser.BlobStatus) error {
+	return s.updateIf(ctx, blobKey, `status = $4`, `status = $3`, int(status), int(disperser.Quarantined))
+}
+
+// IncrementBlobRetryCount isn't retried, the increment would be applied twice if the first
+// attempt was committed but its result lost
+func (s *BlobStore) IncrementBlobRetryCount(ctx context.Context, existingMetadata *disperser.BlobMetadata) error {
+	return s.updateWith(ctx, s.once, existingMetadata.GetBlobKey(), `num_retries = num_retries + 1`)
+}
+
+// SetBlobBatchAssignment stores a nil assignment as NULL, which clears it
+func (s *BlobStore) SetBlobBatchAssignment(ctx context.Context, blobKey disperser.BlobKey, assignment *disperser.BatchAssignment) error {
+	if assignment == nil {
+		return s.update(ctx, blobKey, `batch_assignment = NULL`)
+	}
+	data, err := json.Marshal(assignment)
+	if err != nil {
+		return err
+	}
+	return s.update(ctx, blobKey, `batch_assignment = $3`, data)
+}
+
+// UseDispersalNonce isn't retried, which could reject a nonce recorded by the failed attempt.
+// The row of the signer is locked while its nonce window is read and written.
+func (s *BlobStore) UseDispersalNonce(ctx context.Context, signer string, nonce uint64) error {
+	return s.once(ctx, func() error {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO dispersal_nonces (signer, nonce, used) VALUES ($1, 0, $2) ON CONFLICT (signer) DO NOTHING`,
+			signer, disperser.NonceWindow{}.Bytes()); err != nil {
+			return err
+		}
+		var highest string
+		var used []byte
+		if err := tx.QueryRowContext(ctx,
+			`SELECT nonce, used FROM dispersal_nonces WHERE signer = $1 FOR UPDATE`, signer).Scan(&highest, &used); err != nil {
+			return err
+		}
+		window, err := nonceWindowOf(highest, used)
+		if err != nil {
+			return err
+		}
+		next, ok := window.Use(nonce)
+		if !ok {
+			return disperser.ErrNonceUsed
+		}
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE dispersal_nonces SET nonce = $2::numeric, used = $3 WHERE signer = $1`,
+			signer, strconv.FormatUint(next.Highest, 10), next.Bytes()); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// nonceWindowOf returns the nonce window of a row. The rows written before the windows
+// only hold the last nonce, all the nonces up to which are used.
+func nonceWindowOf(highest string, used []byte) (disperser.NonceWindow, error) {
+	last, err := strconv.ParseUint(highest, 10, 64)
+	if err != nil {
+		return disperser.NonceWindow{}, err
+	}
+	if used == nil {
+		return disperser.ClosedNonceWindow(last), nil
+	}
+	return disperser.NonceWindowFromBytes(last, used)
+}
+
+var _ disperser.DispersalNonceStore = (*BlobStore)(nil)
+
+func (s *BlobStore) DispersalNonces(ctx context.Context) (map[string]disperser.NonceWindow, error) {
+	var nonces map[string]disperser.NonceWindow
+	err := s.retry(ctx, func() error {
+		nonces = make(map[string]disperser.NonceWindow)
+		rows, err := s.db.QueryContext(ctx, `SELECT signer, nonce, used FROM dispersal_nonces`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var signer, highest string
+			var used []byte
+			if err := rows.Scan(&signer, &highest, &used); err != nil {
+				return err
+			}
+			window, err := nonceWindowOf(highest, used)
+			if err != nil {
+				return fmt.Errorf("invalid nonce window of %s: %w", signer, err)
+			}
+			nonces[signer] = window
+		}
+		return rows.Err()
+	})
+	return nonces, err
+}
+
+func (s *BlobStore) RestoreDispersalNonce(ctx context.Context, signer string, window disperser.NonceWindow) error {
+	return s.retry(ctx, func() error {
+		_, err := s.db.ExecContext(ctx,
+			`INSERT INTO dispersal_nonces (signer, nonce, used) VALUES ($1, $2::numeric, $3)
+			ON CONFLICT (signer) DO UPDATE SET nonce = EXCLUDED.nonce, used = EXCLUDED.used
+			WHERE dispersal_nonces.nonce < EXCLUDED.nonce`,
+			signer, strconv.FormatUint(window.Highest, 10), window.Bytes())
+		return err
+	})
+}
+
+// AppendBlobEvent isn't retried, which could record the event twice. The events appended
+// concurrently may take the history slightly over disperser.MaxBlobEvents.
+func (s *BlobStore) AppendBlobEvent(ctx context.Context, blobKey disperser.BlobKey, event disperser.BlobEvent) error {
+	return s.once(ctx, func() error {
+		result, err := s.db.ExecContext(ctx,
+			`INSERT INTO blob_events (blob_hash, metadata_hash, event_type, event_time, detail)
+			SELECT blob_hash, metadata_hash, $3, $4, $5 FROM blob_metadata WHERE blob_hash = $1 AND metadata_hash = $2
+			AND (SELECT count(*) FROM blob_events WHERE blob_hash = $1 AND metadata_hash = $2) < $6`,
+			blobKey.BlobHash, blobKey.MetadataHash, string(event.Type), event.Time, event.Detail, disperser.MaxBlobEvents)
+		if err != nil {
+			return err
+		}
+		if inserted, err := result.RowsAffected(); err != nil || inserted > 0 {
+			return nil
+		}
+		var exists bool
+		err = s.db.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM blob_metadata WHERE blob_hash = $1 AND metadata_hash = $2)`,
+			blobKey.BlobHash, blobKey.MetadataHash).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return disperser.ErrBlobHistoryFull
+		}
+		return disperser.ErrBlobNotFound
+	})
+}
+
+func (s *BlobStore) GetBlobEvents(ctx context.Context, blobKey disperser.BlobKey) ([]disperser.BlobEvent, error) {
+	var events []disperser.BlobEvent
+	err := s.retry(ctx, func() error {
+		events = make([]disperser.BlobEvent, 0)
+		rows, err := s.db.QueryContext(ctx,
+			`SELECT event_type, event_time, detail FROM blob_events WHERE blob_hash = $1 AND metadata_hash = $2 ORDER BY id`,
+			blobKey.BlobHash, blobKey.MetadataHash)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var event disperser.BlobEvent
+			var eventType string
+			if err := rows.Scan(&eventType, &event.Time, &event.Detail); err != nil {
+				return err
+			}
+			event.Type = disperser.BlobEventType(eventType)
+			events = append(events, event)
+		}
+		return rows.Err()
+	})
+	return events, err
+}
+
+func (s *BlobStore) HandleBlobFailure(ctx context.Context, metadata *disperser.BlobMetadata, maxRetry uint) error {
+	if metadata.NumRetries < maxRetry {
+		return s.IncrementBlobRetryCount(ctx, metadata)
+	}
+	return s.MarkBlobDeadLettered(ctx, metadata, disperser.DeadLetterRetriesExhausted)
+}
+
+func (s *BlobStore) updateStatus(ctx context.Context, blobKey disperser.BlobKey, status disperser.BlobStatus) error {
+	return s.update(ctx, blobKey, `status = $3`, int(status))
+}
+
+// update sets the columns of the metadata of a blob, retrying on transient errors. The
+// assignments reference args from $3, $1 and $2 being the blob key, and must be idempotent.
+func (s *BlobStore) update(ctx context.Context, blobKey disperser.BlobKey, assignments string, args ...interface{}) error {
+	return s.updateWith(ctx, s.retry, blobKey, assignments, args...)
+}
+
+// updateIf is update applied only if the row matches condition, which takes the arguments
+// after those of the assignments. disperser.ErrStatusConflict is returned if it doesn't.
+func (s *BlobStore) updateIf(ctx context.Context, blobKey disperser.BlobKey, condition string, assignments string, args ...interface{}) error {
+	updated, err := s.exec(ctx, s.retry, blobKey, `UPDATE blob_metadata SET `+assignments+`, updated_at = now() WHERE blob_hash = $1 AND metadata_hash = $2 AND `+condition, args...)
+	if err != nil || updated > 0 {
+		return err
+	}
+	meta, err := s.GetBlobMetadata(ctx, blobKey)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: blob is %s", disperser.ErrStatusConflict, meta.BlobStatus)
+}
+
+// updateWith is update running the statement with run, s.retry or s.once
+func (s *BlobStore) updateWith(ctx context.Context, run func(context.Context, func() error) error, blobKey disperser.BlobKey, assignments string, args ...interface{}) error {
+	updated, err := s.exec(ctx, run, blobKey, `UPDATE blob_metadata SET `+assignments+`, updated_at = now() WHERE blob_hash = $1 AND metadata_hash = $2`, args...)
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return disperser.ErrBlobNotFound
+	}
+	return nil
+}
+
+// exec runs an update of the row of blobKey, bound to $1 and $2, returning the rows updated
+func (s *BlobStore) exec(ctx context.Context, run func(context.Context, func() error) error, blobKey disperser.BlobKey, query string, args ...interface{}) (int64, error) {
+	args = append([]interface{}{blobKey.BlobHash, blobKey.MetadataHash}, args...)
+
+	var updated int64
+	err := run(ctx, func() error {
+		result, err := s.db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		updated, err = result.RowsAffected()
+		return err
+	})
+	return updated, err
+}
+
+func (s *BlobStore) GetBlobMetadataByStatus(ctx context.Context, status disperser.BlobStatus) ([]*disperser.BlobMetadata, error) {
+	return s.queryMetadata(ctx, `WHERE status = $1 ORDER BY requested_at`, int(status))
+}
+
+func (s *BlobStore) GetMetadataInBatch(ctx context.Context, batchHeaderHash [32]byte, blobIndex uint32) (*disperser.BlobMetadata, error) {
+	metas, err := s.queryMetadata(ctx, `WHERE batch_header_hash = $1 AND blob_index = $2 LIMIT 1`, batchHeaderHash[:], int64(blobIndex))
+	if err != nil {
+		return nil, err
+	}
+	if len(metas) == 0 {
+		return nil, disperser.ErrBlobNotFound
+	}
+	return metas[0], nil
+}
+
+func (s *BlobStore) GetAllBlobMetadataByBatch(ctx context.Context, batchHeaderHash [32]byte) ([]*disperser.BlobMetadata, error) {
+	return s.queryMetadata(ctx, `WHERE batch_header_hash = $1 ORDER BY blob_index`, batchHeaderHash[:])
+}
+
+func (s *BlobStore) GetBlobMetadata(ctx context.Context, blobKey disperser.BlobKey) (*disperser.BlobMetadata, error) {
+	metas, err := s.queryMetadata(ctx, `WHERE blob_hash = $1 AND metadata_hash = $2`, blobKey.BlobHash, blobKey.MetadataHash)
+	if err != nil {
+		return nil, err
+	}
+	if len(metas) == 0 {
+		return nil, disperser.ErrBlobNotFound
+	}
+	return metas[0], nil
+}
+
+// GetBlobMetadataWithOptions serves every read consistently since there is a single database.
+func (s *BlobStore) GetBlobMetadataWithOptions(ctx context.Context, blobKey disperser.BlobKey, opts disperser.ReadOptions) (*disperser.BlobMetadata, error) {
+	meta, err := s.GetBlobMetadata(ctx, blobKey)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Condition != nil && !opts.Condition(meta) {
+		return meta, disperser.ErrConditionNotMet
+	}
+	return meta, nil
+}
+
+func (s *BlobStore) queryMetadata(ctx context.Context, where string, args ...interface{}) ([]*disperser.BlobMetadata, error) {
+	var metas []*disperser.BlobMetadata
+	err := s.retry(ctx, func() error {
+		metas = make([]*disperser.BlobMetadata, 0)
+		rows, err := s.db.QueryContext(ctx, `SELECT `+metadataColumns+` FROM blob_metadata `+where, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			meta, err := scanMetadata(rows)
+			if err != nil {
+				return err
+			}
+			metas = append(metas, meta)
+		}
+		return rows.Err()
+	})
+	return metas, err
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanMetadata(row scanner) (*disperser.BlobMetadata, error) {
+	var (
+		meta            disperser.BlobMetadata
+		status          int
+		expiry          int64
+		numRetries      int64
+		requestMetadata []byte
+		info            []byte
+		assignment      []byte
+	)
+	err := row.Scan(&meta.BlobHash, &meta.MetadataHash, &status, &expiry, &numRetries, &requestMetadata, &info, &meta.QuarantineReason, &meta.DeadLetterReason, &assignment)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, disperser.ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	meta.BlobStatus = disperser.BlobStatus(status)
+	meta.Expiry = uint64(expiry)
+	meta.NumRetries = uint(numRetries)
+
+	meta.RequestMetadata = &disperser.RequestMetadata{}
+	if err := json.Unmarshal(requestMetadata, meta.RequestMetadata); err != nil {
+		return nil, fmt.Errorf("invalid request metadata of blob %s: %w", meta.GetBlobKey().String(), err)
+	}
+	if info != nil {
+		meta.ConfirmationInfo = &disperser.ConfirmationInfo{}
+		if err := json.Unmarshal(info, meta.ConfirmationInfo); err != nil {
+			return nil, fmt.Errorf("invalid confirmation info of blob %s: %w", meta.GetBlobKey().String(), err)
+		}
+	}
+	if assignment != nil {
+		meta.BatchAssignment = &disperser.BatchAssignment{}
+		if err := json.Unmarshal(assignment, meta.BatchAssignment); err != nil {
+			return nil, fmt.Errorf("invalid batch assignment of blob %s: %w", meta.GetBlobKey().String(), err)
+		}
+	}
+	return &meta, nil
+}
+
+func getBlobHash(blob *core.Blob) disperser.BlobHash {
+	hasher := sha256.New()
+	hasher.Write(blob.Data)
+	hash := hasher.Sum(nil)
+	return hex.EncodeToString(hash)
+}
+
+// getMetadataHash derives the metadata hash as the shared storage does, so that blob keys
+// have the same format whichever store is used
+func getMetadataHash(requestedAt uint64, securityParams []*core.SecurityParam) string {
+	str := fmt.Sprintf("%d/", requestedAt)
+	for _, param := range securityParams {
+		str += fmt.Sprintf("%d/%d/", param.QuorumID, param.AdversaryThreshold)
+	}
+	return hex.EncodeToString(sha256.New().Sum([]byte(str)))
+}
@@ -0,0 +1,292 @@
+package contract
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	gethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// blobElementPayload is the payload bytes of a blob field element, whose first byte
+	// is left zero to stay below the field modulus
+	blobElementPayload = 31
+	blobElements       = len(kzg4844.Blob{}) / 32
+	// MaxBlobPayload is the largest payload a blob carries, after its 4 bytes length prefix
+	MaxBlobPayload = blobElements*blobElementPayload - 4
+
+	// BlobModeAuto posts a payload in a blob transaction when blob gas is cheaper than the
+	// calldata it replaces
+	BlobModeAuto = "auto"
+	// BlobModeAlways posts every payload that fits in a blob transaction
+	BlobModeAlways = "always"
+)
+
+// PlaceholderBlobHash stands for the versioned hash of a blob before the blob is built, to
+// price the calldata of a blob transaction taking it, as a hash has no zero bytes
+var PlaceholderBlobHash = eth_common.BytesToHash(bytes.Repeat([]byte{0xff}, eth_common.HashLength))
+
+// BlobPolicy chooses whether the payload of a contract call is posted in calldata or in a
+// blob transaction calling a method that reads it by the versioned hash of the blob
+type BlobPolicy struct {
+	// Mode is BlobModeAuto or BlobModeAlways
+	Mode string
+	// MinPayload is the smallest payload posted in a blob transaction in auto mode
+	MinPayload int
+}
+
+func (p BlobPolicy) Validate() error {
+	if p.Mode != BlobModeAuto && p.Mode != BlobModeAlways {
+		return fmt.Errorf("unknown blob mode %q", p.Mode)
+	}
+	return nil
+}
+
+// BlobFeeReader reads the base fee and the blob base fee of the head, see DAContract.BlobFees
+type BlobFeeReader interface {
+	BlobFees(ctx context.Context) (*big.Int, *big.Int, error)
+}
+
+// UseBlob returns whether payload is posted in a blob transaction whose own calldata is
+// blobCalldata, rather than in a transaction whose calldata is calldata. In auto mode, the
+// error of reading the fees is returned along with false, the payload going in calldata.
+func (p BlobPolicy) UseBlob(ctx context.Context, fees BlobFeeReader, payload, calldata, blobCalldata []byte) (bool, error) {
+	if len(payload) > MaxBlobPayload {
+		return false, nil
+	}
+	if p.Mode == BlobModeAlways {
+		return true, nil
+	}
+	if len(payload) < p.MinPayload {
+		return false, nil
+	}
+	baseFee, blobFee, err := fees.BlobFees(ctx)
+	if err != nil {
+		return false, err
+	}
+	return blobCheaper(calldata, blobCalldata, baseFee, blobFee), nil
+}
+
+// blobCheaper returns whether a blob transaction whose calldata is blobCalldata costs less
+// than a transaction whose calldata is calldata, at the base fee and blob base fee of the
+// head. The blob base fee is nil on chains without blob transactions.
+func blobCheaper(calldata, blobCalldata []byte, baseFee, blobFee *big.Int) bool {
+	if baseFee == nil || blobFee == nil {
+		return false
+	}
+	blobCost := new(big.Int).Mul(blobFee, big.NewInt(params.BlobTxBlobGasPerBlob))
+	blobCost.Add(blobCost, new(big.Int).Mul(baseFee, new(big.Int).SetUint64(CalldataGas(blobCalldata))))
+	calldataCost := new(big.Int).Mul(baseFee, new(big.Int).SetUint64(CalldataGas(calldata)))
+	return blobCost.Cmp(calldataCost) < 0
+}
+
+// CalldataGas returns the gas calldata costs, zero bytes being cheaper than the others
+func CalldataGas(calldata []byte) uint64 {
+	var gas uint64
+	for _, b := range calldata {
+		if b == 0 {
+			gas += params.TxDataZeroGas
+		} else {
+			gas += params.TxDataNonZeroGasEIP2028
+		}
+	}
+	return gas
+}
+
+// EncodeBlobPayload packs a payload into a blob, prefixed by its length as 4 big endian
+// bytes and 31 bytes per field element
+func EncodeBlobPayload(payload []byte) (*kzg4844.Blob, error) {
+	if len(payload) > MaxBlobPayload {
+		return nil, fmt.Errorf("payload of %d bytes exceeds the %d bytes of a blob", len(payload), MaxBlobPayload)
+	}
+	data := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(payload)), uint32(len(payload)))
+	data = append(data, payload...)
+
+	var blob kzg4844.Blob
+	for i := 0; len(data) > 0; i++ {
+		n := copy(blob[i*32+1:(i+1)*32], data)
+		data = data[n:]
+	}
+	return &blob, nil
+}
+
+// DecodeBlobPayload returns the payload packed into a blob by EncodeBlobPayload
+func DecodeBlobPayload(blob *kzg4844.Blob) ([]byte, error) {
+	data := make([]byte, 0, blobElements*blobElementPayload)
+	for i := 0; i < blobElements; i++ {
+		if blob[i*32] != 0 {
+			return nil, fmt.Errorf("field element %d of the blob has a non zero first byte", i)
+		}
+		data = append(data, blob[i*32+1:(i+1)*32]...)
+	}
+	length := int(binary.BigEndian.Uint32(data))
+	if length > MaxBlobPayload {
+		return nil, fmt.Errorf("blob payload length %d exceeds %d bytes", length, MaxBlobPayload)
+	}
+	return data[4 : 4+length], nil
+}
+
+// blobHeader holds the fields of the head the web3go header conversion leaves out
+type blobHeader struct {
+	BaseFee       *hexutil.Big    `json:"baseFeePerGas"`
+	ExcessBlobGas *hexutil.Uint64 `json:"excessBlobGas"`
+}
+
+// BlobFees returns the base fee and the blob base fee of the head, a nil blob base fee if
+// the chain doesn't support blob transactions
+func (c *DAContract) BlobFees(ctx context.Context) (*big.Int, *big.Int, error) {
+	var head blobHeader
+	if err := c.client.Provider().CallContext(ctx, &head, "eth_getBlockByNumber", "latest", false); err != nil {
+		return nil, nil, errors.WithMessage(err, "Failed to get the head")
+	}
+	if head.BaseFee == nil || head.ExcessBlobGas == nil {
+		return (*big.Int)(head.BaseFee), nil, nil
+	}
+	return (*big.Int)(head.BaseFee), eip4844.CalcBlobFee(uint64(*head.ExcessBlobGas)), nil
+}
+
+// blobReceipt holds the fields of a receipt the web3go receipt conversion leaves out
+type blobReceipt struct {
+	BlobGasUsed  *hexutil.Uint64 `json:"blobGasUsed"`
+	BlobGasPrice *hexutil.Big    `json:"blobGasPrice"`
+}
+
+// ReceiptFee returns the fee in wei a transaction paid, its gas at the effective gas price
+// and, for blob transactions, its blob gas at the blob gas price
+func (c *DAContract) ReceiptFee(ctx context.Context, receipt *types.Receipt) (*big.Int, error) {
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), new(big.Int).SetUint64(receipt.EffectiveGasPrice))
+	if receipt.Type == nil || *receipt.Type != gethTypes.BlobTxType {
+		return fee, nil
+	}
+	var blob blobReceipt
+	if err := c.client.Provider().CallContext(ctx, &blob, "eth_getTransactionReceipt", receipt.TransactionHash); err != nil {
+		return nil, errors.WithMessage(err, "Failed to get the blob gas of the receipt")
+	}
+	if blob.BlobGasUsed != nil && blob.BlobGasPrice != nil {
+		fee.Add(fee, new(big.Int).Mul(new(big.Int).SetUint64(uint64(*blob.BlobGasUsed)), (*big.Int)(blob.BlobGasPrice)))
+	}
+	return fee, nil
+}
+
+// NewBlobSidecar returns the sidecar of a blob transaction carrying payload in one blob,
+// whose versioned hash is BlobHashes()[0]
+func NewBlobSidecar(payload []byte) (*gethTypes.BlobTxSidecar, error) {
+	blob, err := EncodeBlobPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	commitment, err := kzg4844.BlobToCommitment(*blob)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to commit to blob")
+	}
+	proof, err := kzg4844.ComputeBlobProof(*blob, commitment)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to compute blob proof")
+	}
+	return &gethTypes.BlobTxSidecar{
+		Blobs:       []kzg4844.Blob{*blob},
+		Commitments: []kzg4844.Commitment{commitment},
+		Proofs:      []kzg4844.Proof{proof},
+	}, nil
+}
+
+// PostBlob calls the inbox method with args in a blob transaction carrying sidecar, the
+// method reading the payload by the versioned hash of the blob. With estimateGas the
+// transaction is signed but not sent, and its gas limit is the estimate of the call
+// without the blob, as nodes don't estimate blob transactions.
+//
+// With dynamic fees, blob transactions left pending are replaced by the fee manager like
+// the others, see blobFeeCap for their blob fee cap.
+func (i *Inbox) PostBlob(ctx context.Context, sidecar *gethTypes.BlobTxSidecar, args []interface{}, gasLimit uint64, estimateGas bool) (*gethTypes.Transaction, error) {
+	data, err := i.Calldata(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := i.da.CreateTransactOpts(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to create opts to send transaction")
+	}
+	baseFee, blobFee, err := i.da.BlobFees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if baseFee == nil || blobFee == nil {
+		return nil, errors.New("chain doesn't support blob transactions")
+	}
+	if opts.GasFeeCap == nil {
+		tipCap, err := i.da.backend.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, errors.WithMessage(err, "Failed to suggest the tip cap")
+		}
+		opts.GasTipCap = tipCap
+		opts.GasFeeCap = new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tipCap)
+	}
+	if opts.Nonce == nil {
+		nonce, err := i.da.backend.PendingNonceAt(ctx, i.da.account)
+		if err != nil {
+			return nil, errors.WithMessage(err, "Failed to get the pending nonce")
+		}
+		opts.Nonce = new(big.Int).SetUint64(nonce)
+	}
+	if estimateGas {
+		gasLimit, err = i.da.backend.EstimateGas(ctx, ethereum.CallMsg{
+			From:      i.da.account,
+			To:        &i.Address,
+			GasFeeCap: opts.GasFeeCap,
+			GasTipCap: opts.GasTipCap,
+			Data:      data,
+		})
+		if err != nil {
+			return nil, errors.WithMessagef(err, "Failed to estimate inbox %s call", i.Method.Name)
+		}
+	}
+
+	tx, err := i.da.signer(i.da.account, gethTypes.NewTx(&gethTypes.BlobTx{
+		Nonce:      opts.Nonce.Uint64(),
+		GasTipCap:  uint256.MustFromBig(opts.GasTipCap),
+		GasFeeCap:  uint256.MustFromBig(opts.GasFeeCap),
+		Gas:        gasLimit,
+		To:         i.Address,
+		Value:      new(uint256.Int),
+		Data:       data,
+		BlobFeeCap: uint256.MustFromBig(i.da.blobFeeCap(blobFee)),
+		BlobHashes: sidecar.BlobHashes(),
+		Sidecar:    sidecar,
+	}))
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to sign blob transaction")
+	}
+	if estimateGas {
+		return tx, nil
+	}
+
+	err = i.da.backend.SendTransaction(ctx, tx)
+	i.da.sent(tx, err)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "Failed to send blob transaction to inbox %s", i.Method.Name)
+	}
+	return tx, nil
+}
+
+// blobFeeCap returns the blob fee cap of a blob transaction at the blob base fee of the
+// head: the base fee multiplier of the fee manager times it, up to its max blob fee cap,
+// or twice it without dynamic fees
+func (c *DAContract) blobFeeCap(blobFee *big.Int) *big.Int {
+	if c.fees == nil {
+		return new(big.Int).Mul(blobFee, big.NewInt(2))
+	}
+	return c.fees.blobFeeCap(blobFee)
+}
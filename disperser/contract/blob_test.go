@@ -0,0 +1,81 @@
+package contract
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlobPayload(t *testing.T) {
+	for _, size := range []int{0, 1, 31, 1000, MaxBlobPayload} {
+		payload := bytes.Repeat([]byte{0xff}, size)
+		blob, err := EncodeBlobPayload(payload)
+		require.NoError(t, err)
+		for i := 0; i < len(blob); i += 32 {
+			require.Zero(t, blob[i])
+		}
+		decoded, err := DecodeBlobPayload(blob)
+		require.NoError(t, err)
+		assert.Equal(t, payload, decoded)
+	}
+
+	_, err := EncodeBlobPayload(make([]byte, MaxBlobPayload+1))
+	assert.Error(t, err)
+}
+
+type blobTestFees struct {
+	baseFee, blobFee *big.Int
+	err              error
+}
+
+func (f *blobTestFees) BlobFees(ctx context.Context) (*big.Int, *big.Int, error) {
+	return f.baseFee, f.blobFee, f.err
+}
+
+func TestBlobCheaper(t *testing.T) {
+	// a blob costs as much as 8192 non zero bytes of calldata at equal fees
+	assert.False(t, blobCheaper(bytes.Repeat([]byte{1}, 8192), nil, big.NewInt(10), big.NewInt(10)))
+	assert.True(t, blobCheaper(bytes.Repeat([]byte{1}, 8193), nil, big.NewInt(10), big.NewInt(10)))
+	// zero bytes cost a quarter of the others
+	assert.False(t, blobCheaper(make([]byte, 8193), nil, big.NewInt(10), big.NewInt(10)))
+	// the calldata of the blob transaction counts too
+	assert.False(t, blobCheaper(bytes.Repeat([]byte{1}, 8193), []byte{1, 1}, big.NewInt(10), big.NewInt(10)))
+	assert.True(t, blobCheaper([]byte{1, 1, 1}, []byte{1}, big.NewInt(100000), big.NewInt(1)))
+	// chains without blob transactions
+	assert.False(t, blobCheaper(bytes.Repeat([]byte{1}, 100000), nil, big.NewInt(10), nil))
+}
+
+func TestBlobPolicy(t *testing.T) {
+	ctx := context.Background()
+	payload := bytes.Repeat([]byte{1}, 10000)
+	cheap := &blobTestFees{baseFee: big.NewInt(10), blobFee: big.NewInt(1)}
+
+	auto := BlobPolicy{Mode: BlobModeAuto, MinPayload: 1000}
+	useBlob, err := auto.UseBlob(ctx, cheap, payload, payload, nil)
+	assert.NoError(t, err)
+	assert.True(t, useBlob)
+	useBlob, err = auto.UseBlob(ctx, cheap, payload[:999], payload[:999], nil)
+	assert.NoError(t, err)
+	assert.False(t, useBlob)
+
+	// auto mode falls back to calldata when the fees can't be read
+	useBlob, err = auto.UseBlob(ctx, &blobTestFees{err: errors.New("rpc down")}, payload, payload, nil)
+	assert.Error(t, err)
+	assert.False(t, useBlob)
+
+	// always mode doesn't read the fees, but a payload must fit in a blob
+	always := BlobPolicy{Mode: BlobModeAlways}
+	useBlob, err = always.UseBlob(ctx, &blobTestFees{err: errors.New("rpc down")}, payload, payload, nil)
+	assert.NoError(t, err)
+	assert.True(t, useBlob)
+	useBlob, err = always.UseBlob(ctx, cheap, make([]byte, MaxBlobPayload+1), nil, nil)
+	assert.NoError(t, err)
+	assert.False(t, useBlob)
+
+	assert.Error(t, BlobPolicy{Mode: "never"}.Validate())
+}
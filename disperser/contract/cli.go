@@ -0,0 +1,82 @@
+package contract
+
+import (
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/urfave/cli"
+)
+
+const (
+	EIP1559FlagName           = "chain.eip1559"
+	MaxTipCapFlagName         = "chain.max-tip-cap"
+	MaxFeeCapFlagName         = "chain.max-fee-cap"
+	MaxBlobFeeCapFlagName     = "chain.max-blob-fee-cap"
+	BaseFeeMultiplierFlagName = "chain.base-fee-multiplier"
+	ReplaceTimeoutFlagName    = "chain.replace-timeout"
+	FeeBumpPercentFlagName    = "chain.fee-bump-percent"
+	MaxReplacementsFlagName   = "chain.max-replacements"
+)
+
+// FeeCLIFlags are the flags of the FeeConfig, next to those of geth.EthClientFlags
+func FeeCLIFlags(envPrefix string) []cli.Flag {
+	return []cli.Flag{
+		cli.BoolFlag{
+			Name:   EIP1559FlagName,
+			Usage:  "send EIP-1559 transactions and replace those left pending with higher fees",
+			EnvVar: common.PrefixEnvVar(envPrefix, "CHAIN_EIP1559"),
+		},
+		cli.Uint64Flag{
+			Name:   MaxTipCapFlagName,
+			Usage:  "maximum priority fee per gas in wei, the node suggestion is used up to it. No cap if 0",
+			EnvVar: common.PrefixEnvVar(envPrefix, "CHAIN_MAX_TIP_CAP"),
+		},
+		cli.Uint64Flag{
+			Name:   MaxFeeCapFlagName,
+			Usage:  "maximum fee per gas in wei of a transaction and its replacements. No cap if 0",
+			EnvVar: common.PrefixEnvVar(envPrefix, "CHAIN_MAX_FEE_CAP"),
+		},
+		cli.Uint64Flag{
+			Name:   MaxBlobFeeCapFlagName,
+			Usage:  "maximum fee per blob gas in wei of a blob transaction and its replacements. No cap if 0",
+			EnvVar: common.PrefixEnvVar(envPrefix, "CHAIN_MAX_BLOB_FEE_CAP"),
+		},
+		cli.Uint64Flag{
+			Name:   BaseFeeMultiplierFlagName,
+			Usage:  "multiple of the base fee of the head the fee cap of a transaction covers",
+			Value:  2,
+			EnvVar: common.PrefixEnvVar(envPrefix, "CHAIN_BASE_FEE_MULTIPLIER"),
+		},
+		cli.DurationFlag{
+			Name:   ReplaceTimeoutFlagName,
+			Usage:  "how long a transaction may stay pending before it is replaced with higher fees. Never replaced if 0",
+			Value:  time.Minute,
+			EnvVar: common.PrefixEnvVar(envPrefix, "CHAIN_REPLACE_TIMEOUT"),
+		},
+		cli.Uint64Flag{
+			Name:   FeeBumpPercentFlagName,
+			Usage:  "percentage the fees of a replacement are raised by, at least 10",
+			Value:  15,
+			EnvVar: common.PrefixEnvVar(envPrefix, "CHAIN_FEE_BUMP_PERCENT"),
+		},
+		cli.UintFlag{
+			Name:   MaxReplacementsFlagName,
+			Usage:  "maximum number of replacements of a transaction",
+			Value:  5,
+			EnvVar: common.PrefixEnvVar(envPrefix, "CHAIN_MAX_REPLACEMENTS"),
+		},
+	}
+}
+
+func ReadFeeConfig(ctx *cli.Context) FeeConfig {
+	return FeeConfig{
+		EIP1559:           ctx.GlobalBool(EIP1559FlagName),
+		MaxTipCap:         ctx.GlobalUint64(MaxTipCapFlagName),
+		MaxFeeCap:         ctx.GlobalUint64(MaxFeeCapFlagName),
+		MaxBlobFeeCap:     ctx.GlobalUint64(MaxBlobFeeCapFlagName),
+		BaseFeeMultiplier: ctx.GlobalUint64(BaseFeeMultiplierFlagName),
+		ReplaceTimeout:    ctx.GlobalDuration(ReplaceTimeoutFlagName),
+		BumpPercent:       ctx.GlobalUint64(FeeBumpPercentFlagName),
+		MaxReplacements:   ctx.GlobalUint(MaxReplacementsFlagName),
+	}
+}
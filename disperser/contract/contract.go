@@ -1,6 +1,7 @@
 package contract
 
 import (
+	"context"
 	"math/big"
 	"time"
 
@@ -39,8 +40,15 @@ type DAContract struct {
 	*da_entrance.DAEntrance
 	*da_signers.DASigners
 	client  *web3go.Client
+	backend bind.ContractBackend
 	account eth_common.Address // account to send transaction
 	signer  bind.SignerFn
+	// nonces is nil unless nonces are tracked locally, see TrackNonces
+	nonces *nonceTracker
+	// fees is nil unless the fees are set by the fee manager, see EnableDynamicFees
+	fees *feeManager
+	// senders are the contracts of the other accounts of the disperser, see AddSenders
+	senders []*DAContract
 }
 
 func defaultSigner(clientWithSigner *web3go.Client) (interfaces.Signer, error) {
@@ -84,13 +92,14 @@ func NewDAContract(daEntranceAddress, daSignersAddress eth_common.Address, rpcUR
 		DAEntrance: flow,
 		DASigners:  signers,
 		client:     clientWithSigner,
+		backend:    backend,
 		account:    default_signer.Address(),
 		signer:     signer,
 	}, nil
 }
 
-func (c *DAContract) SubmitVerifiedCommitRoots(submissions []da_entrance.IDAEntranceCommitRootSubmission, gasLimit uint64, waitForReceipt bool, estimateGas bool) (*types.Transaction, *types.Receipt, error) {
-	opts, err := c.CreateTransactOpts()
+func (c *DAContract) SubmitVerifiedCommitRoots(ctx context.Context, submissions []da_entrance.IDAEntranceCommitRootSubmission, gasLimit uint64, waitForReceipt bool, estimateGas bool) (*types.Transaction, *types.Receipt, error) {
+	opts, err := c.CreateTransactOpts(ctx)
 	if err != nil {
 		return nil, nil, errors.WithMessage(err, "Failed to create opts to send transaction")
 	}
@@ -102,6 +111,9 @@ func (c *DAContract) SubmitVerifiedCommitRoots(submissions []da_entrance.IDAEntr
 	}
 
 	tx, err := c.DAEntrance.SubmitVerifiedCommitRoots(opts, submissions)
+	if !estimateGas {
+		c.sent(tx, err)
+	}
 
 	if err != nil {
 		return nil, nil, errors.WithMessage(err, "Failed to send transaction to submit verified commit roots")
@@ -115,19 +127,20 @@ func (c *DAContract) SubmitVerifiedCommitRoots(submissions []da_entrance.IDAEntr
 	return tx, nil, nil
 }
 
-func (c *DAContract) SubmitOriginalData(dataRoots []eth_common.Hash, waitForReceipt bool) (eth_common.Hash, *types.Receipt, error) {
+func (c *DAContract) SubmitOriginalData(ctx context.Context, dataRoots []eth_common.Hash, waitForReceipt bool) (eth_common.Hash, *types.Receipt, error) {
 	params := make([][32]byte, len(dataRoots))
 	for i, dataRoot := range dataRoots {
 		params[i] = dataRoot
 	}
 
 	// Submit log entry to smart contract.
-	opts, err := c.CreateTransactOpts()
+	opts, err := c.CreateTransactOpts(ctx)
 	if err != nil {
 		return eth_common.Hash{}, nil, errors.WithMessage(err, "Failed to create opts to send transaction")
 	}
 
 	tx, err := c.DAEntrance.SubmitOriginalData(opts, params)
+	c.sent(tx, err)
 
 	if err != nil {
 		return eth_common.Hash{}, nil, errors.WithMessage(err, "Failed to send transaction to submit original data")
@@ -141,25 +154,74 @@ func (c *DAContract) SubmitOriginalData(dataRoots []eth_common.Hash, waitForRece
 	return tx.Hash(), nil, nil
 }
 
-func (c *DAContract) CreateTransactOpts() (*bind.TransactOpts, error) {
+// CreateTransactOpts returns the options of a transaction sent with ctx, whose fees are
+// suggested with ctx
+func (c *DAContract) CreateTransactOpts(ctx context.Context) (*bind.TransactOpts, error) {
 	var gasPrice *big.Int
 	if CustomGasPrice > 0 {
 		gasPrice = new(big.Int).SetUint64(CustomGasPrice)
 	}
 
-	return &bind.TransactOpts{
+	opts := &bind.TransactOpts{
 		From:     c.account,
 		GasPrice: gasPrice,
 		GasLimit: CustomGasLimit,
 		Signer:   c.signer,
-	}, nil
+		Context:  ctx,
+	}
+	if c.nonces != nil {
+		nonce, err := c.nonce()
+		if err != nil {
+			return nil, err
+		}
+		opts.Nonce = nonce
+	}
+	if c.fees != nil {
+		feeCap, tipCap, err := c.fees.suggest(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if feeCap != nil {
+			opts.GasPrice = nil
+			opts.GasFeeCap = feeCap
+			opts.GasTipCap = tipCap
+		}
+	}
+	return opts, nil
 }
 
 func (c *DAContract) WaitForReceipt(txHash eth_common.Hash, successRequired bool, opts ...RetryOption) (*types.Receipt, error) {
 	return WaitForReceipt(c.client, txHash, successRequired, opts...)
 }
 
-func WaitForReceipt(client *web3go.Client, txHash eth_common.Hash, successRequired bool, opts ...RetryOption) (receipt *types.Receipt, err error) {
+// WaitForReceiptContext is WaitForReceipt giving up once ctx is done. The receipt of a
+// transaction replaced by the fee manager, of c or of one of its senders, is that of the
+// replacement included.
+func (c *DAContract) WaitForReceiptContext(ctx context.Context, txHash eth_common.Hash, successRequired bool, opts ...RetryOption) (*types.Receipt, error) {
+	if fees, tracked := c.tracked(txHash); tracked != nil {
+		return waitForReceipt(ctx, func() (*types.Receipt, error) {
+			return fees.lookup(ctx, tracked)
+		}, successRequired, opts...)
+	}
+	return WaitForReceiptContext(ctx, c.client, txHash, successRequired, opts...)
+}
+
+// ErrNoReceipt is returned when a transaction has no receipt after the polling rounds. The
+// transaction may still be included later.
+var ErrNoReceipt = errors.New("no receipt after max retries")
+
+func WaitForReceipt(client *web3go.Client, txHash eth_common.Hash, successRequired bool, opts ...RetryOption) (*types.Receipt, error) {
+	return WaitForReceiptContext(context.Background(), client, txHash, successRequired, opts...)
+}
+
+func WaitForReceiptContext(ctx context.Context, client *web3go.Client, txHash eth_common.Hash, successRequired bool, opts ...RetryOption) (*types.Receipt, error) {
+	return waitForReceipt(ctx, func() (*types.Receipt, error) {
+		return client.Eth.TransactionReceipt(txHash)
+	}, successRequired, opts...)
+}
+
+// waitForReceipt polls lookup until it returns a receipt
+func waitForReceipt(ctx context.Context, lookup func() (*types.Receipt, error), successRequired bool, opts ...RetryOption) (receipt *types.Receipt, err error) {
 	var opt RetryOption
 	if len(opts) > 0 {
 		opt = opts[0]
@@ -172,10 +234,14 @@ func WaitForReceipt(client *web3go.Client, txHash eth_common.Hash, successRequir
 	var tries uint
 	for receipt == nil {
 		if tries > opt.Rounds+1 && opt.Rounds != 0 {
-			return nil, errors.New("no receipt after max retries")
+			return nil, ErrNoReceipt
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(opt.Interval):
 		}
-		time.Sleep(opt.Interval)
-		if receipt, err = client.Eth.TransactionReceipt(txHash); err != nil {
+		if receipt, err = lookup(); err != nil {
 			return nil, err
 		}
 		tries++
@@ -0,0 +1,452 @@
+package contract
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	gethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+)
+
+// minBumpPercent is the fee increase nodes require to accept a replacement transaction
+const minBumpPercent = 10
+
+// minBlobBumpPercent is the fee increase nodes require to accept a replacement blob
+// transaction, all its fee caps doubling
+const minBlobBumpPercent = 100
+
+// trackedRetention is how long a sent transaction is tracked, so that the receipt waits
+// following reorgs still find its replacements
+const trackedRetention = time.Hour
+
+// FeeConfig sets the fees of the transactions of the DA contract. With EIP-1559 enabled,
+// transactions left pending are replaced with the same nonce and higher fees, so that a
+// gas spike doesn't hold the confirmations of the batches back.
+type FeeConfig struct {
+	// EIP1559 sends dynamic fee transactions with the fees set by the fee manager
+	EIP1559 bool
+	// MaxTipCap caps the priority fee suggested by the node in wei, no cap if 0
+	MaxTipCap uint64
+	// MaxFeeCap caps the fee per gas of a transaction and its replacements in wei, no cap if 0
+	MaxFeeCap uint64
+	// MaxBlobFeeCap caps the fee per blob gas of a blob transaction and its replacements in
+	// wei, no cap if 0
+	MaxBlobFeeCap uint64
+	// BaseFeeMultiplier sets the fee cap to the base fee of the head times it, plus the tip,
+	// and the blob fee cap of blob transactions to the blob base fee times it
+	BaseFeeMultiplier uint64
+	// ReplaceTimeout is how long a transaction is pending before it is replaced, never if 0
+	ReplaceTimeout time.Duration
+	// BumpPercent raises the fees of each replacement, at least by 10 percent
+	BumpPercent uint64
+	// MaxReplacements bounds the replacements of a transaction
+	MaxReplacements uint
+}
+
+func (c FeeConfig) validate() error {
+	if c.BaseFeeMultiplier == 0 {
+		return errors.New("the base fee multiplier must be positive")
+	}
+	if c.BumpPercent < minBumpPercent {
+		return fmt.Errorf("the fee bump must be at least %d percent for nodes to accept replacements", minBumpPercent)
+	}
+	if c.MaxFeeCap > 0 && c.MaxTipCap > c.MaxFeeCap {
+		return errors.New("the max tip cap must not exceed the max fee cap")
+	}
+	return nil
+}
+
+// FeeMetrics records the replacements and the fees paid by the transactions of the fee manager
+type FeeMetrics interface {
+	IncrementTxReplacements()
+	// UpdateTxEffectiveFee records the effective gas price in wei a transaction was
+	// included with, after the given number of replacements
+	UpdateTxEffectiveFee(effectiveGasPrice uint64, replacements int)
+}
+
+// FeeBackend is the part of the contract backend the fee manager uses
+type FeeBackend interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*gethTypes.Header, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	SendTransaction(ctx context.Context, tx *gethTypes.Transaction) error
+}
+
+// trackedTx is a transaction and its replacements, the latest last
+type trackedTx struct {
+	txs      []*gethTypes.Transaction
+	lastSent time.Time
+	// capped is set once the fee cap leaves no room for another replacement
+	capped   bool
+	included bool
+}
+
+// feeManager sets the fees of the transactions sent and replaces those left pending
+type feeManager struct {
+	config  FeeConfig
+	backend FeeBackend
+	signer  bind.SignerFn
+	account eth_common.Address
+	// receipt looks up the receipt of a transaction, nil if it is pending
+	receipt func(txHash eth_common.Hash) (*types.Receipt, error)
+	// blobFees reads the base fee and the blob base fee of the head, see DAContract.BlobFees
+	blobFees func(ctx context.Context) (*big.Int, *big.Int, error)
+
+	mu sync.Mutex
+	// tracked maps the hash of each transaction sent, replacements included, to its chain
+	tracked map[eth_common.Hash]*trackedTx
+
+	metrics FeeMetrics
+	logger  common.Logger
+}
+
+// EnableDynamicFees makes the contract send EIP-1559 transactions with the fees of config,
+// replacing those that stay pending. It takes precedence over CustomGasPrice.
+func (c *DAContract) EnableDynamicFees(config FeeConfig, metrics FeeMetrics, logger common.Logger) error {
+	if err := config.validate(); err != nil {
+		return err
+	}
+	c.fees = &feeManager{
+		config:   config,
+		backend:  c.backend,
+		signer:   c.signer,
+		account:  c.account,
+		receipt:  c.client.Eth.TransactionReceipt,
+		blobFees: c.BlobFees,
+		tracked:  make(map[eth_common.Hash]*trackedTx),
+		metrics:  metrics,
+		logger:   logger,
+	}
+	return nil
+}
+
+// suggest returns the fee cap and tip cap of a new transaction, nil if the chain has no
+// base fee
+func (m *feeManager) suggest(ctx context.Context) (*big.Int, *big.Int, error) {
+	head, err := m.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "Failed to get the head to set fees")
+	}
+	if head.BaseFee == nil {
+		return nil, nil, nil
+	}
+	tipCap, err := m.backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "Failed to suggest the tip cap")
+	}
+	if m.config.MaxTipCap > 0 && tipCap.Cmp(new(big.Int).SetUint64(m.config.MaxTipCap)) > 0 {
+		tipCap = new(big.Int).SetUint64(m.config.MaxTipCap)
+	}
+	feeCap := new(big.Int).Mul(head.BaseFee, new(big.Int).SetUint64(m.config.BaseFeeMultiplier))
+	feeCap.Add(feeCap, tipCap)
+	if m.config.MaxFeeCap > 0 && feeCap.Cmp(new(big.Int).SetUint64(m.config.MaxFeeCap)) > 0 {
+		feeCap = new(big.Int).SetUint64(m.config.MaxFeeCap)
+	}
+	return feeCap, tipCap, nil
+}
+
+// blobFeeCap returns the blob fee cap of a new blob transaction at the blob base fee of
+// the head
+func (m *feeManager) blobFeeCap(blobFee *big.Int) *big.Int {
+	feeCap := new(big.Int).Mul(blobFee, new(big.Int).SetUint64(m.config.BaseFeeMultiplier))
+	if m.config.MaxBlobFeeCap > 0 && feeCap.Cmp(new(big.Int).SetUint64(m.config.MaxBlobFeeCap)) > 0 {
+		feeCap = new(big.Int).SetUint64(m.config.MaxBlobFeeCap)
+	}
+	return feeCap
+}
+
+// suggestBlob returns the blob fee cap of the replacement of a blob transaction
+func (m *feeManager) suggestBlob(ctx context.Context) (*big.Int, error) {
+	_, blobFee, err := m.blobFees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if blobFee == nil {
+		return nil, errors.New("chain doesn't support blob transactions")
+	}
+	return m.blobFeeCap(blobFee), nil
+}
+
+// track starts tracking a dynamic fee or blob transaction that was sent
+func (m *feeManager) track(tx *gethTypes.Transaction) {
+	if tx.Type() != gethTypes.DynamicFeeTxType && tx.Type() != gethTypes.BlobTxType {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for hash, t := range m.tracked {
+		if now.Sub(t.lastSent) > trackedRetention {
+			delete(m.tracked, hash)
+		}
+	}
+	m.tracked[tx.Hash()] = &trackedTx{txs: []*gethTypes.Transaction{tx}, lastSent: now}
+}
+
+func (m *feeManager) get(txHash eth_common.Hash) *trackedTx {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.tracked[txHash]
+}
+
+// replacementFees are the fees of the replacement of a transaction, blobFeeCap being nil
+// unless it is a blob transaction
+type replacementFees struct {
+	feeCap, tipCap, blobFeeCap *big.Int
+}
+
+// bump returns the fees of the replacement of a transaction, at least the suggested fees,
+// and false if the max fee caps leave no room for the increase nodes require
+func (m *feeManager) bump(tx *gethTypes.Transaction, suggested replacementFees) (replacementFees, bool) {
+	percent := m.config.BumpPercent
+	if tx.Type() == gethTypes.BlobTxType && percent < minBlobBumpPercent {
+		percent = minBlobBumpPercent
+	}
+	raise := func(fee *big.Int, suggested *big.Int) *big.Int {
+		raised := new(big.Int).Mul(fee, new(big.Int).SetUint64(100+percent))
+		raised.Div(raised, big.NewInt(100))
+		// round up so that small fees still increase
+		raised.Add(raised, big.NewInt(1))
+		if suggested != nil && suggested.Cmp(raised) > 0 {
+			return suggested
+		}
+		return raised
+	}
+	fees := replacementFees{
+		feeCap: raise(tx.GasFeeCap(), suggested.feeCap),
+		tipCap: raise(tx.GasTipCap(), suggested.tipCap),
+	}
+	if m.config.MaxFeeCap > 0 && fees.feeCap.Cmp(new(big.Int).SetUint64(m.config.MaxFeeCap)) > 0 {
+		return replacementFees{}, false
+	}
+	if fees.tipCap.Cmp(fees.feeCap) > 0 {
+		fees.tipCap = fees.feeCap
+	}
+	if tx.Type() == gethTypes.BlobTxType {
+		fees.blobFeeCap = raise(tx.BlobGasFeeCap(), suggested.blobFeeCap)
+		if m.config.MaxBlobFeeCap > 0 && fees.blobFeeCap.Cmp(new(big.Int).SetUint64(m.config.MaxBlobFeeCap)) > 0 {
+			return replacementFees{}, false
+		}
+	}
+	return fees, true
+}
+
+// ErrOverFeeBudget is the replacement of a transaction whose fee caps would let it cost more
+// than the budget it was given
+var ErrOverFeeBudget = errors.New("replacement fees exceed the fee budget")
+
+// maxCost returns the fee in wei a transaction with the given fees may cost at most
+func (fees replacementFees) maxCost(tx *gethTypes.Transaction) *big.Int {
+	cost := new(big.Int).Mul(fees.feeCap, new(big.Int).SetUint64(tx.Gas()))
+	if fees.blobFeeCap != nil {
+		cost.Add(cost, new(big.Int).Mul(fees.blobFeeCap, new(big.Int).SetUint64(tx.BlobGas())))
+	}
+	return cost
+}
+
+// replacement signs the replacement of tx with the same nonce and higher fees, nil if the
+// max fee caps leave no room for it, failing with ErrOverFeeBudget if it may cost more than
+// maxFee wei, unless nil
+func (m *feeManager) replacement(ctx context.Context, tx *gethTypes.Transaction, maxFee *big.Int) (*gethTypes.Transaction, error) {
+	var suggested replacementFees
+	var err error
+	suggested.feeCap, suggested.tipCap, err = m.suggest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tx.Type() == gethTypes.BlobTxType {
+		if suggested.blobFeeCap, err = m.suggestBlob(ctx); err != nil {
+			return nil, err
+		}
+	}
+	fees, ok := m.bump(tx, suggested)
+	if !ok {
+		m.logger.Warn("[contract] pending transaction reached the max fee cap, not replacing it", "tx hash", tx.Hash(), "fee cap", tx.GasFeeCap(), "blob fee cap", tx.BlobGasFeeCap())
+		return nil, nil
+	}
+	if maxFee != nil && fees.maxCost(tx).Cmp(maxFee) > 0 {
+		// unlike the max fee caps, the budget is that of the caller, later replacements
+		// may still fit the budget they are given
+		return nil, ErrOverFeeBudget
+	}
+
+	var unsigned *gethTypes.Transaction
+	if tx.Type() == gethTypes.BlobTxType {
+		// the replacement carries the same blobs, which nodes require
+		unsigned = gethTypes.NewTx(&gethTypes.BlobTx{
+			ChainID:    uint256.MustFromBig(tx.ChainId()),
+			Nonce:      tx.Nonce(),
+			GasTipCap:  uint256.MustFromBig(fees.tipCap),
+			GasFeeCap:  uint256.MustFromBig(fees.feeCap),
+			Gas:        tx.Gas(),
+			To:         *tx.To(),
+			Value:      uint256.MustFromBig(tx.Value()),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+			BlobFeeCap: uint256.MustFromBig(fees.blobFeeCap),
+			BlobHashes: tx.BlobHashes(),
+			Sidecar:    tx.BlobTxSidecar(),
+		})
+	} else {
+		unsigned = gethTypes.NewTx(&gethTypes.DynamicFeeTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasTipCap:  fees.tipCap,
+			GasFeeCap:  fees.feeCap,
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		})
+	}
+	replacement, err := m.signer(m.account, unsigned)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to sign replacement transaction")
+	}
+	return replacement, nil
+}
+
+// replace sends the latest transaction of t again with the same nonce and higher fees,
+// failing with ErrOverFeeBudget if the replacement may cost more than maxFee wei, unless nil
+func (m *feeManager) replace(ctx context.Context, t *trackedTx, maxFee *big.Int) error {
+	m.mu.Lock()
+	latest := t.txs[len(t.txs)-1]
+	m.mu.Unlock()
+
+	replacement, err := m.replacement(ctx, latest, maxFee)
+	if err != nil {
+		return err
+	}
+	if replacement == nil {
+		m.mu.Lock()
+		t.capped = true
+		m.mu.Unlock()
+		return nil
+	}
+	if err := m.backend.SendTransaction(ctx, replacement); err != nil {
+		// the transaction may have been included in the meantime, which the next
+		// receipt lookup finds out
+		return errors.WithMessage(err, "Failed to send replacement transaction")
+	}
+
+	m.mu.Lock()
+	t.txs = append(t.txs, replacement)
+	t.lastSent = time.Now()
+	m.tracked[replacement.Hash()] = t
+	replacements := len(t.txs) - 1
+	m.mu.Unlock()
+	m.metrics.IncrementTxReplacements()
+	m.logger.Info("[contract] replaced pending transaction", "tx hash", latest.Hash(), "replacement", replacement.Hash(), "nonce", latest.Nonce(), "fee cap", replacement.GasFeeCap(), "tip cap", replacement.GasTipCap(), "replacements", replacements)
+	return nil
+}
+
+// lookup returns the receipt of the transaction of t that was included, nil if they are
+// all pending, replacing the latest one if it has been pending for too long
+func (m *feeManager) lookup(ctx context.Context, t *trackedTx) (*types.Receipt, error) {
+	m.mu.Lock()
+	txs := append([]*gethTypes.Transaction{}, t.txs...)
+	m.mu.Unlock()
+
+	for i := len(txs) - 1; i >= 0; i-- {
+		receipt, err := m.receipt(txs[i].Hash())
+		if err != nil {
+			return nil, err
+		}
+		if receipt == nil {
+			continue
+		}
+		m.mu.Lock()
+		first := !t.included
+		t.included = true
+		m.mu.Unlock()
+		if first {
+			m.metrics.UpdateTxEffectiveFee(receipt.EffectiveGasPrice, len(txs)-1)
+		}
+		return receipt, nil
+	}
+
+	m.mu.Lock()
+	due := m.config.ReplaceTimeout > 0 && !t.capped && !t.included &&
+		uint(len(t.txs)-1) < m.config.MaxReplacements && time.Since(t.lastSent) >= m.config.ReplaceTimeout
+	m.mu.Unlock()
+	if due {
+		if err := m.replace(ctx, t, nil); err != nil {
+			m.logger.Warn("[contract] failed to replace pending transaction", "tx hash", txs[len(txs)-1].Hash(), "err", err)
+		}
+	}
+	return nil, nil
+}
+
+// BumpFees replaces a pending transaction sent by the contract or one of its senders with
+// higher fees right away, ahead of the replace timeout. It does nothing if the transaction
+// was included or can't be replaced anymore, and fails if its fees aren't managed. The
+// replacement may cost at most maxFee wei at its fee caps, unbounded if nil, and it fails
+// with ErrOverFeeBudget otherwise.
+func (c *DAContract) BumpFees(ctx context.Context, txHash eth_common.Hash, maxFee *big.Int) error {
+	m, t := c.tracked(txHash)
+	if t == nil {
+		return fmt.Errorf("transaction %s is not tracked by a fee manager", txHash)
+	}
+	m.mu.Lock()
+	done := t.capped || t.included || uint(len(t.txs)-1) >= m.config.MaxReplacements
+	m.mu.Unlock()
+	if done {
+		return nil
+	}
+	return m.replace(ctx, t, maxFee)
+}
+
+// FeeManager sets the fees of the transactions an account sends outside of the DA contract,
+// such as the settlements of the payments, and signs their replacements. Unlike those of the
+// contract, the transactions aren't tracked: their sender keeps them, and sends them, so
+// that they outlive the process.
+type FeeManager struct {
+	fees *feeManager
+}
+
+// NewFeeManager returns the fee manager of the dynamic fee transactions account signs with
+// signer and sends to backend
+func NewFeeManager(config FeeConfig, backend FeeBackend, signer bind.SignerFn, account eth_common.Address, logger common.Logger) (*FeeManager, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return &FeeManager{fees: &feeManager{
+		config:  config,
+		backend: backend,
+		signer:  signer,
+		account: account,
+		tracked: make(map[eth_common.Hash]*trackedTx),
+		logger:  logger,
+	}}, nil
+}
+
+// Suggest returns the fee cap and tip cap of a new transaction, nil if the chain has no
+// base fee
+func (f *FeeManager) Suggest(ctx context.Context) (*big.Int, *big.Int, error) {
+	return f.fees.suggest(ctx)
+}
+
+// ReplaceDue returns whether a transaction pending since sentAt, after the given number of
+// replacements, is due to be replaced
+func (f *FeeManager) ReplaceDue(sentAt time.Time, replacements int) bool {
+	config := f.fees.config
+	return config.ReplaceTimeout > 0 && uint(replacements) < config.MaxReplacements && time.Since(sentAt) >= config.ReplaceTimeout
+}
+
+// Replacement signs the replacement of the pending dynamic fee transaction tx with the same
+// nonce and higher fees, for the caller to send. It is nil if the max fee caps leave no room
+// for it.
+func (f *FeeManager) Replacement(ctx context.Context, tx *gethTypes.Transaction) (*gethTypes.Transaction, error) {
+	if tx.Type() != gethTypes.DynamicFeeTxType {
+		return nil, fmt.Errorf("transaction %s doesn't have dynamic fees to raise", tx.Hash())
+	}
+	return f.fees.replacement(ctx, tx, nil)
+}
@@ -0,0 +1,227 @@
+package contract
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	gethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+	"github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type feeTestBackend struct {
+	baseFee *big.Int
+	tipCap  *big.Int
+	sent    []*gethTypes.Transaction
+}
+
+func (b *feeTestBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*gethTypes.Header, error) {
+	return &gethTypes.Header{BaseFee: b.baseFee}, nil
+}
+
+func (b *feeTestBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return b.tipCap, nil
+}
+
+func (b *feeTestBackend) SendTransaction(ctx context.Context, tx *gethTypes.Transaction) error {
+	b.sent = append(b.sent, tx)
+	return nil
+}
+
+type feeTestMetrics struct {
+	replacements  int
+	effectiveFee  uint64
+	includedAfter int
+}
+
+func (m *feeTestMetrics) IncrementTxReplacements() { m.replacements++ }
+
+func (m *feeTestMetrics) UpdateTxEffectiveFee(effectiveGasPrice uint64, replacements int) {
+	m.effectiveFee, m.includedAfter = effectiveGasPrice, replacements
+}
+
+func TestFeeReplacement(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	opts, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1))
+	assert.NoError(t, err)
+
+	backend := &feeTestBackend{baseFee: big.NewInt(100), tipCap: big.NewInt(10)}
+	metrics := &feeTestMetrics{}
+	included := map[eth_common.Hash]*types.Receipt{}
+	m := &feeManager{
+		config:  FeeConfig{BaseFeeMultiplier: 2, MaxFeeCap: 300, BumpPercent: 20, MaxReplacements: 3},
+		backend: backend,
+		signer:  opts.Signer,
+		account: opts.From,
+		receipt: func(txHash eth_common.Hash) (*types.Receipt, error) { return included[txHash], nil },
+		tracked: make(map[eth_common.Hash]*trackedTx),
+		metrics: metrics,
+		logger:  mock.NewLogger(false),
+	}
+
+	feeCap, tipCap, err := m.suggest(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(210), feeCap)
+	assert.Equal(t, big.NewInt(10), tipCap)
+
+	to := eth_common.HexToAddress("0x01")
+	tx, err := opts.Signer(opts.From, gethTypes.NewTx(&gethTypes.DynamicFeeTx{
+		ChainID: big.NewInt(1), Nonce: 7, GasTipCap: tipCap, GasFeeCap: feeCap, Gas: 21000, To: &to,
+	}))
+	assert.NoError(t, err)
+	m.track(tx)
+	tracked := m.get(tx.Hash())
+
+	// pending within the timeout, left alone
+	m.config.ReplaceTimeout = time.Hour
+	receipt, err := m.lookup(context.Background(), tracked)
+	assert.NoError(t, err)
+	assert.Nil(t, receipt)
+	assert.Empty(t, backend.sent)
+
+	// a replacement that may cost more than the budget it is given isn't sent
+	assert.ErrorIs(t, m.replace(context.Background(), tracked, big.NewInt(253*21000-1)), ErrOverFeeBudget)
+	assert.Empty(t, backend.sent)
+	assert.False(t, tracked.capped)
+
+	// past the timeout, replaced with the same nonce and fees raised by 20 percent
+	m.config.ReplaceTimeout = time.Nanosecond
+	_, err = m.lookup(context.Background(), tracked)
+	assert.NoError(t, err)
+	assert.Len(t, backend.sent, 1)
+	replacement := backend.sent[0]
+	assert.Equal(t, uint64(7), replacement.Nonce())
+	assert.Equal(t, big.NewInt(253), replacement.GasFeeCap())
+	assert.Equal(t, big.NewInt(13), replacement.GasTipCap())
+	assert.Equal(t, tracked, m.get(replacement.Hash()))
+
+	// another raise would exceed the max fee cap
+	_, err = m.lookup(context.Background(), tracked)
+	assert.NoError(t, err)
+	assert.Len(t, backend.sent, 1)
+	assert.True(t, tracked.capped)
+
+	// the receipt of the replacement is that of the original transaction
+	included[replacement.Hash()] = &types.Receipt{BlockNumber: 5, EffectiveGasPrice: 113}
+	receipt, err = m.lookup(context.Background(), m.get(tx.Hash()))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), receipt.BlockNumber)
+	assert.Equal(t, 1, metrics.replacements)
+	assert.Equal(t, uint64(113), metrics.effectiveFee)
+	assert.Equal(t, 1, metrics.includedAfter)
+
+	assert.Error(t, FeeConfig{BaseFeeMultiplier: 2, BumpPercent: 5}.validate())
+}
+
+func TestFeeManager(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	opts, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1))
+	assert.NoError(t, err)
+	backend := &feeTestBackend{baseFee: big.NewInt(100), tipCap: big.NewInt(10)}
+	config := FeeConfig{BaseFeeMultiplier: 2, MaxFeeCap: 300, BumpPercent: 20, ReplaceTimeout: time.Minute, MaxReplacements: 2}
+	f, err := NewFeeManager(config, backend, opts.Signer, opts.From, mock.NewLogger(false))
+	assert.NoError(t, err)
+
+	assert.False(t, f.ReplaceDue(time.Now(), 0))
+	assert.True(t, f.ReplaceDue(time.Now().Add(-time.Minute), 1))
+	assert.False(t, f.ReplaceDue(time.Now().Add(-time.Minute), 2))
+
+	feeCap, tipCap, err := f.Suggest(context.Background())
+	assert.NoError(t, err)
+	to := eth_common.HexToAddress("0x01")
+	tx, err := opts.Signer(opts.From, gethTypes.NewTx(&gethTypes.DynamicFeeTx{
+		ChainID: big.NewInt(1), Nonce: 7, GasTipCap: tipCap, GasFeeCap: feeCap, Gas: 21000, To: &to, Data: []byte{1},
+	}))
+	assert.NoError(t, err)
+
+	// the replacement is signed for the caller to send, and isn't tracked
+	replacement, err := f.Replacement(context.Background(), tx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), replacement.Nonce())
+	assert.Equal(t, big.NewInt(253), replacement.GasFeeCap())
+	assert.Equal(t, tx.Data(), replacement.Data())
+	assert.Empty(t, backend.sent)
+	assert.Nil(t, f.fees.get(tx.Hash()))
+
+	// another raise would exceed the max fee cap
+	replacement, err = f.Replacement(context.Background(), replacement)
+	assert.NoError(t, err)
+	assert.Nil(t, replacement)
+
+	legacy, err := opts.Signer(opts.From, gethTypes.NewTx(&gethTypes.LegacyTx{Nonce: 7, GasPrice: feeCap, Gas: 21000, To: &to}))
+	assert.NoError(t, err)
+	_, err = f.Replacement(context.Background(), legacy)
+	assert.Error(t, err)
+}
+
+func TestBlobFeeReplacement(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	opts, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1))
+	assert.NoError(t, err)
+
+	backend := &feeTestBackend{baseFee: big.NewInt(100), tipCap: big.NewInt(10)}
+	blobFee := big.NewInt(50)
+	m := &feeManager{
+		config:  FeeConfig{BaseFeeMultiplier: 2, BumpPercent: 20, MaxReplacements: 3, MaxBlobFeeCap: 500, ReplaceTimeout: time.Nanosecond},
+		backend: backend,
+		signer:  opts.Signer,
+		account: opts.From,
+		receipt: func(txHash eth_common.Hash) (*types.Receipt, error) { return nil, nil },
+		blobFees: func(ctx context.Context) (*big.Int, *big.Int, error) {
+			return backend.baseFee, blobFee, nil
+		},
+		tracked: make(map[eth_common.Hash]*trackedTx),
+		metrics: &feeTestMetrics{},
+		logger:  mock.NewLogger(false),
+	}
+	assert.Equal(t, big.NewInt(100), m.blobFeeCap(blobFee))
+
+	sidecar, err := NewBlobSidecar([]byte("certificate"))
+	assert.NoError(t, err)
+	tx, err := opts.Signer(opts.From, gethTypes.NewTx(&gethTypes.BlobTx{
+		ChainID: uint256.NewInt(1), Nonce: 3, GasTipCap: uint256.NewInt(10), GasFeeCap: uint256.NewInt(210), Gas: 50000,
+		To: eth_common.HexToAddress("0x01"), Value: new(uint256.Int), BlobFeeCap: uint256.NewInt(100),
+		BlobHashes: sidecar.BlobHashes(), Sidecar: sidecar,
+	}))
+	assert.NoError(t, err)
+	m.track(tx)
+	tracked := m.get(tx.Hash())
+	assert.NotNil(t, tracked)
+
+	// replaced with the same blob and every fee cap doubled, as nodes require of blob
+	// transactions
+	_, err = m.lookup(context.Background(), tracked)
+	assert.NoError(t, err)
+	assert.Len(t, backend.sent, 1)
+	replacement := backend.sent[0]
+	assert.Equal(t, uint8(gethTypes.BlobTxType), replacement.Type())
+	assert.Equal(t, uint64(3), replacement.Nonce())
+	assert.Equal(t, big.NewInt(421), replacement.GasFeeCap())
+	assert.Equal(t, big.NewInt(21), replacement.GasTipCap())
+	assert.Equal(t, big.NewInt(201), replacement.BlobGasFeeCap())
+	assert.Equal(t, sidecar.BlobHashes(), replacement.BlobHashes())
+	assert.Equal(t, sidecar, replacement.BlobTxSidecar())
+
+	// a blob base fee spike raises the blob fee cap to the suggested one
+	blobFee = big.NewInt(220)
+	_, err = m.lookup(context.Background(), tracked)
+	assert.NoError(t, err)
+	assert.Len(t, backend.sent, 2)
+	assert.Equal(t, big.NewInt(440), backend.sent[1].BlobGasFeeCap())
+
+	// doubling again would exceed the max blob fee cap
+	_, err = m.lookup(context.Background(), tracked)
+	assert.NoError(t, err)
+	assert.Len(t, backend.sent, 2)
+	assert.True(t, tracked.capped)
+}
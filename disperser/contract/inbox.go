@@ -0,0 +1,90 @@
+package contract
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	gethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// Inbox is a rollup inbox contract known only by its ABI. Transactions are sent from the
+// account of the DA contract.
+type Inbox struct {
+	Address eth_common.Address
+	Method  abi.Method
+
+	da       *DAContract
+	abi      abi.ABI
+	contract *bind.BoundContract
+}
+
+func NewInbox(daContract *DAContract, address eth_common.Address, abiJSON string, method string) (*Inbox, error) {
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to parse inbox abi")
+	}
+	m, ok := parsed.Methods[method]
+	if !ok {
+		return nil, fmt.Errorf("inbox abi has no method %s", method)
+	}
+
+	return &Inbox{
+		Address:  address,
+		Method:   m,
+		da:       daContract,
+		abi:      parsed,
+		contract: bind.NewBoundContract(address, parsed, daContract.backend, daContract.backend, daContract.backend),
+	}, nil
+}
+
+// From returns the inbox sending its transactions from the account of daContract
+func (i *Inbox) From(daContract *DAContract) *Inbox {
+	if daContract == i.da {
+		return i
+	}
+	return &Inbox{
+		Address:  i.Address,
+		Method:   i.Method,
+		da:       daContract,
+		abi:      i.abi,
+		contract: bind.NewBoundContract(i.Address, i.abi, daContract.backend, daContract.backend, daContract.backend),
+	}
+}
+
+// Calldata returns the calldata of a call to the inbox method with args
+func (i *Inbox) Calldata(args ...interface{}) ([]byte, error) {
+	data, err := i.abi.Pack(i.Method.Name, args...)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "Failed to pack inbox %s call", i.Method.Name)
+	}
+	return data, nil
+}
+
+// Post calls the inbox method with args. With estimateGas the transaction is signed but
+// not sent, and its gas limit is the estimate.
+func (i *Inbox) Post(ctx context.Context, args []interface{}, gasLimit uint64, estimateGas bool) (*gethTypes.Transaction, error) {
+	opts, err := i.da.CreateTransactOpts(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to create opts to send transaction")
+	}
+
+	if estimateGas {
+		opts.NoSend = estimateGas
+	} else {
+		opts.GasLimit = gasLimit
+	}
+
+	tx, err := i.contract.Transact(opts, i.Method.Name, args...)
+	if !estimateGas {
+		i.da.sent(tx, err)
+	}
+	if err != nil {
+		return nil, errors.WithMessagef(err, "Failed to send transaction to inbox %s", i.Method.Name)
+	}
+	return tx, nil
+}
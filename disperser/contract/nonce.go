@@ -0,0 +1,64 @@
+package contract
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	gethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// nonceTracker assigns the nonces of the account locally instead of asking the node for
+// its pending nonce on every transaction, which can lag behind transactions sent back to
+// back. The transactions of the account must be serialized, see transactor.Transactor.
+type nonceTracker struct {
+	mu    sync.Mutex
+	next  uint64
+	known bool
+}
+
+// TrackNonces makes the contract and its senders assign the nonces of their transactions
+// locally. The nonce is read from the node again after a failed send.
+func (c *DAContract) TrackNonces() {
+	if c.nonces == nil {
+		c.nonces = &nonceTracker{}
+	}
+	for _, sender := range c.senders {
+		sender.TrackNonces()
+	}
+}
+
+// nonce returns the nonce of the next transaction
+func (c *DAContract) nonce() (*big.Int, error) {
+	t := c.nonces
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.known {
+		next, err := c.backend.PendingNonceAt(context.Background(), c.account)
+		if err != nil {
+			return nil, errors.WithMessage(err, "Failed to get the pending nonce")
+		}
+		t.next, t.known = next, true
+	}
+	return new(big.Int).SetUint64(t.next), nil
+}
+
+// sent records the result of sending a transaction with the nonce last returned
+func (c *DAContract) sent(tx *gethTypes.Transaction, err error) {
+	if c.fees != nil && err == nil {
+		c.fees.track(tx)
+	}
+	if c.nonces == nil {
+		return
+	}
+	t := c.nonces
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		// the transaction may or may not have reached the node
+		t.known = false
+		return
+	}
+	t.next++
+}
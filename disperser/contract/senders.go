@@ -0,0 +1,47 @@
+package contract
+
+import (
+	"math/big"
+
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// AddSenders registers the contracts of the other accounts transactions are sent from, so
+// that the receipt waits of c follow the fee replacements of their transactions as well.
+// TrackNonces on c applies to them too.
+func (c *DAContract) AddSenders(senders ...*DAContract) {
+	c.senders = append(c.senders, senders...)
+	if c.nonces != nil {
+		for _, sender := range senders {
+			sender.TrackNonces()
+		}
+	}
+}
+
+// Account returns the account the transactions of the contract are sent from
+func (c *DAContract) Account() eth_common.Address {
+	return c.account
+}
+
+// Balance returns the balance of the account in wei at the latest block
+func (c *DAContract) Balance() (*big.Int, error) {
+	balance, err := c.client.Eth.Balance(c.account, nil)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to get the balance")
+	}
+	return balance, nil
+}
+
+// tracked returns the fee manager tracking a transaction sent by c or one of its senders
+func (c *DAContract) tracked(txHash eth_common.Hash) (*feeManager, *trackedTx) {
+	for _, sender := range append([]*DAContract{c}, c.senders...) {
+		if sender.fees == nil {
+			continue
+		}
+		if t := sender.fees.get(txHash); t != nil {
+			return sender.fees, t
+		}
+	}
+	return nil, nil
+}
@@ -11,5 +11,7 @@ type DB interface {
 	Delete(key []byte) error
 	DeleteBatch(keys [][]byte) error
 	WriteBatch(keys, values [][]byte) error
+	// SyncWriteBatch is WriteBatch returning once the writes are durable
+	SyncWriteBatch(keys, values [][]byte) error
 	NewIterator(prefix []byte) iterator.Iterator
 }
@@ -0,0 +1,41 @@
+// Package dispersal holds the parts of the dispersal API shared by the API server and its
+// clients, without the dependencies of the server.
+package dispersal
+
+import (
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/core"
+)
+
+// The grpc metadata keys of the DisperseBlob requests
+const (
+	// PriorityHeader is the priority lane a request asks for, from 0, the default, to
+	// core.MaxBlobPriority
+	PriorityHeader = "x-zgda-priority"
+	// PriceQuoteHeader is the quote a request is made under
+	PriceQuoteHeader = "x-zgda-price-quote"
+	// GatewayTokenHeader authenticates the gateway relaying a request, and OriginHeader is
+	// the address of the client it relays it for. The API server trusts the origin only
+	// along with the token it is configured with.
+	GatewayTokenHeader = "x-zgda-gateway-token"
+	OriginHeader       = "x-zgda-origin"
+)
+
+// SignedRequest returns the part of a dispersal request signed by its account
+func SignedRequest(req *pb.DisperseBlobRequest) core.DispersalRequest {
+	params := make([]core.DispersalSecurityParam, 0, len(req.GetSecurityParams()))
+	for _, param := range req.GetSecurityParams() {
+		params = append(params, core.DispersalSecurityParam{
+			QuorumID:           param.GetQuorumId(),
+			AdversaryThreshold: param.GetAdversaryThreshold(),
+			QuorumThreshold:    param.GetQuorumThreshold(),
+			Optional:           param.GetOptional(),
+		})
+	}
+	return core.DispersalRequest{
+		Data:           req.GetData(),
+		Nonce:          req.GetNonce(),
+		SecurityParams: params,
+		Tags:           req.GetTags(),
+	}
+}
@@ -6,8 +6,8 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
-	"strings"
 	"sync"
+	"time"
 
 	"github.com/0glabs/0g-da-client/common"
 	"github.com/0glabs/0g-da-client/core"
@@ -27,6 +27,17 @@ const (
 	Failed
 	Finalized
 	InsufficientSignatures
+	// Quarantined blobs are held out of the encoding queue until they are released or rejected
+	Quarantined
+	// DeadLettered blobs expired or exhausted their retries, they are kept with the reason
+	// until they are resubmitted
+	DeadLettered
+)
+
+// Reason codes of dead-lettered blobs
+const (
+	DeadLetterRetriesExhausted = "retries_exhausted"
+	DeadLetterExpired          = "expired"
 )
 
 var enumStrings = map[BlobStatus]string{
@@ -35,6 +46,8 @@ var enumStrings = map[BlobStatus]string{
 	Failed:                 "Failed",
 	Finalized:              "Finalized",
 	InsufficientSignatures: "InsufficientSignatures",
+	Quarantined:            "Quarantined",
+	DeadLettered:           "DeadLettered",
 }
 
 func (bs BlobStatus) String() string {
@@ -47,6 +60,8 @@ func (bs BlobStatus) String() string {
 type BlobHash = string
 type MetadataHash = string
 
+// BlobKey identifies a dispersal request. Its string form is the request ID returned to
+// clients, see request_id.go for the format.
 type BlobKey struct {
 	BlobHash     BlobHash
 	MetadataHash MetadataHash
@@ -56,17 +71,6 @@ func (mk BlobKey) String() string {
 	return fmt.Sprintf("%s-%s", mk.BlobHash, mk.MetadataHash)
 }
 
-func ParseBlobKey(key string) (BlobKey, error) {
-	parts := strings.Split(key, "-")
-	if len(parts) != 2 {
-		return BlobKey{}, fmt.Errorf("invalid metadata key: %s", key)
-	}
-	return BlobKey{
-		BlobHash:     parts[0],
-		MetadataHash: parts[1],
-	}, nil
-}
-
 type BlobKeyCache struct {
 	mu    sync.Mutex
 	Key   map[[32]byte]bool
@@ -125,6 +129,32 @@ func (m *BlobRetrieveMetadata) Hash() [32]byte {
 	return message
 }
 
+// BlobConfirmation is the confirmation of a finalized blob, stored in the kv store next to
+// its retrieval metadata, which keys the content of the blob and so can't change
+type BlobConfirmation struct {
+	BlockNumber uint32
+	// ConfirmedAt is the unix time in seconds the confirmation was observed at
+	ConfirmedAt int64
+	// Info is the confirmation info of the blob, which the kv store also indexes by the
+	// position of the blob in its batch. It is nil for the blobs finalized before it was
+	// recorded.
+	Info *ConfirmationInfo
+	// Tags are the tags the blob was dispersed with, which the kv store also indexes. They
+	// are kept out of BlobRetrieveMetadata, whose encoding is the key of the blob content.
+	Tags map[string]string
+	// Events is the lifecycle history of the blob up to its finalization
+	Events []BlobEvent
+}
+
+func (c *BlobConfirmation) Serialize() ([]byte, error) {
+	return core.Encode(c)
+}
+
+func (c *BlobConfirmation) Deserialize(data []byte) (*BlobConfirmation, error) {
+	err := core.Decode(data, c)
+	return c, err
+}
+
 type BlobMetadata struct {
 	BlobHash     BlobHash     `json:"blob_hash"`
 	MetadataHash MetadataHash `json:"metadata_hash"`
@@ -141,6 +171,24 @@ type BlobMetadata struct {
 	// This field is nil if the blob has not been confirmed
 	// This field is omitted when marshalling to DynamoDB attributevalue as this field will be flattened
 	ConfirmationInfo *ConfirmationInfo `json:"blob_confirmation_info" dynamodbav:"-"`
+	// QuarantineReason is the reason the blob was quarantined on intake, empty otherwise
+	QuarantineReason string `json:"quarantine_reason,omitempty"`
+	// DeadLetterReason is the reason code the blob was dead-lettered with, empty otherwise
+	DeadLetterReason string `json:"dead_letter_reason,omitempty"`
+	// BatchAssignment is the position of the blob in the batch it was last put in, set when
+	// the batch is created so clients learn it before the batch is confirmed. It is nil until
+	// the blob is batched, and replaced if the batch fails and the blob is batched again.
+	BatchAssignment *BatchAssignment `json:"batch_assignment,omitempty" dynamodbav:",omitempty"`
+}
+
+// BatchAssignment is the position of a blob in a batch. The blobs of a batch are indexed in
+// the order of their priority lane, highest first, then of their request time, then of their
+// blob key, so the index of a blob is the same whichever batcher builds the batch. The index
+// is the one the blob is confirmed with on chain.
+type BatchAssignment struct {
+	BatchHeaderHash [32]byte `json:"batch_header_hash"`
+	BlobIndex       uint32   `json:"blob_index"`
+	BlobCount       uint32   `json:"blob_count"`
 }
 
 func (m *BlobMetadata) Serialize() ([]byte, error) {
@@ -159,6 +207,15 @@ func (m *BlobMetadata) GetBlobKey() BlobKey {
 	}
 }
 
+// VerifyContent checks the payload against BlobHash, the sha256 checksum recorded when the blob was stored
+func (m *BlobMetadata) VerifyContent(data []byte) error {
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != m.BlobHash {
+		return fmt.Errorf("%w: %s", ErrBlobCorrupted, m.GetBlobKey().String())
+	}
+	return nil
+}
+
 func (m *BlobMetadata) IsConfirmed() (bool, error) {
 	if m.BlobStatus != Confirmed && m.BlobStatus != Finalized {
 		return false, nil
@@ -196,44 +253,197 @@ type ConfirmationInfo struct {
 	Fee                     []byte                               `json:"fee"`
 	QuorumResults           map[core.QuorumID]*core.QuorumResult `json:"quorum_results"`
 	BlobQuorumInfos         []*core.BlobQuorumInfo               `json:"blob_quorum_infos"`
+	// SignerBitmap are the signers of the quorum at Epoch that signed the batch, out of NumSigners
+	SignerBitmap core.SignerBitmap `json:"signer_bitmap,omitempty"`
+	NumSigners   uint32            `json:"num_signers,omitempty"`
+	// AggregateSignature is the aggregate signature of the signers of the blob over its
+	// core.SignatureMessage, a serialized G1 point, and AggregatePubKey their aggregate
+	// public key, a serialized G2 point. Both are empty for the blobs confirmed before they
+	// were recorded.
+	AggregateSignature []byte `json:"aggregate_signature,omitempty"`
+	AggregatePubKey    []byte `json:"aggregate_pub_key,omitempty"`
+	// Venue is the deployment the batch was confirmed on, set if a fallback deployment is
+	// configured. ConfirmationTxnHash and ConfirmationBlockNumber are on its chain.
+	Venue *ConfirmationVenue `json:"venue,omitempty"`
+	// ConfirmedAt is the unix time in seconds the confirmation was observed at, 0 for the
+	// blobs confirmed before it was recorded
+	ConfirmedAt int64 `json:"confirmed_at,omitempty"`
+	// ConfirmationBlockHash is the hash of the block ConfirmationTxnHash was included in, zero
+	// for the blobs confirmed before it was recorded
+	ConfirmationBlockHash eth_common.Hash `json:"confirmation_block_hash,omitempty"`
+}
+
+// ConfirmationVenue is a deployment of the DA contracts the aggregate signatures of a batch
+// can be submitted to, so that verifiers know which chain to check the confirmation on
+type ConfirmationVenue struct {
+	ChainID uint64 `json:"chain_id"`
+	// Contract is the DA entrance contract of the deployment
+	Contract eth_common.Address `json:"contract"`
+	// Fallback is set for the fallback deployment, used while the primary chain is halted
+	Fallback bool `json:"fallback,omitempty"`
+}
+
+type ReadConsistency uint8
+
+const (
+	// EventualRead may return stale metadata
+	EventualRead ReadConsistency = iota
+	// StrongRead reflects all writes acknowledged before the read
+	StrongRead
+	// SessionRead is strong for blobs written by this store instance recently, eventual
+	// otherwise. It only reads the writes of the same process consistently, the reads of the
+	// blobs written through other instances sharing the store need StrongRead.
+	SessionRead
+)
+
+// ReadOptions controls how a metadata read is served by the BlobStore.
+type ReadOptions struct {
+	Consistency ReadConsistency
+	// Condition, if set, must hold for the returned metadata. A stale read that fails the
+	// condition is retried once with strong consistency.
+	Condition func(*BlobMetadata) bool
+}
+
+// StatusIs returns a read condition matching any of the given statuses.
+func StatusIs(statuses ...BlobStatus) func(*BlobMetadata) bool {
+	return func(m *BlobMetadata) bool {
+		for _, status := range statuses {
+			if m.BlobStatus == status {
+				return true
+			}
+		}
+		return false
+	}
 }
 
+// BlobStore keeps the blobs dispersed and their metadata until they are confirmed. It is
+// implemented over S3 and DynamoDB (common/blobstore), PostgreSQL (common/pgstore) and in
+// memory (common/memorydb); all of them return ErrBlobNotFound for unknown blob keys.
 type BlobStore interface {
+	BlobStoreReader
+	BlobStoreWriter
+}
+
+// BlobStoreReader is the read side of a BlobStore. The components that only read blobs take
+// a BlobStoreReader, so they can be wired with read-only credentials or backends, and the
+// compiler rejects any change of the blob state they would make.
+type BlobStoreReader interface {
 	// MetadataHashAsBlobKey if blob key is metadatahash, the blob and metadata will be removed once confirmed
 	MetadataHashAsBlobKey() bool
+	// GetBlobContent retrieves a blob's content, returning ErrBlobCorrupted if it doesn't match its checksum
+	GetBlobContent(ctx context.Context, blobMetadata *BlobMetadata) ([]byte, error)
+	// GetBlobEvents returns the lifecycle history of a blob, not necessarily in time order
+	GetBlobEvents(ctx context.Context, blobKey BlobKey) ([]BlobEvent, error)
+	// GetBlobsByMetadata retrieves a list of blobs given a list of metadata.
+	// Blobs failing checksum verification are left out of the result and reported with a *BlobCorruptionError.
+	GetBlobsByMetadata(ctx context.Context, metadata []*BlobMetadata) (map[BlobKey]*core.Blob, error)
+	// GetBlobMetadataByStatus returns a list of blob metadata for blobs with the given status
+	GetBlobMetadataByStatus(ctx context.Context, blobStatus BlobStatus) ([]*BlobMetadata, error)
+	// GetMetadataInBatch returns the metadata in a given batch at given index.
+	GetMetadataInBatch(ctx context.Context, batchHeaderHash [32]byte, blobIndex uint32) (*BlobMetadata, error)
+	// GetAllBlobMetadataByBatch returns the metadata of all the blobs in the batch.
+	GetAllBlobMetadataByBatch(ctx context.Context, batchHeaderHash [32]byte) ([]*BlobMetadata, error)
+	// GetBlobMetadata returns a blob metadata given a metadata key
+	GetBlobMetadata(ctx context.Context, blobKey BlobKey) (*BlobMetadata, error)
+	// GetBlobMetadataWithOptions returns a blob metadata given a metadata key, honoring the
+	// requested read consistency and condition. ErrConditionNotMet is returned if the
+	// condition doesn't hold even on a strongly consistent read.
+	GetBlobMetadataWithOptions(ctx context.Context, blobKey BlobKey, opts ReadOptions) (*BlobMetadata, error)
+}
+
+// BlobStoreWriter is the write side of a BlobStore, changing the blobs and their status
+type BlobStoreWriter interface {
 	// StoreBlob adds a blob to the queue and returns a key that can be used to retrieve the blob later
 	StoreBlob(ctx context.Context, blob *core.Blob, requestedAt uint64) (BlobKey, error)
-	// RemoveBlob remove a blob and its metadata from s3 and dynamodb
+	// StoreQuarantinedBlob stores a blob in the Quarantined status, out of the queue until it is
+	// reviewed with ReviewQuarantinedBlob
+	StoreQuarantinedBlob(ctx context.Context, blob *core.Blob, requestedAt uint64, reason string) (BlobKey, error)
+	// RemoveBlob removes a blob and its metadata from the store
 	RemoveBlob(ctx context.Context, metadata *BlobMetadata) error
-	// GetBlobContent retrieves a blob's content
-	GetBlobContent(ctx context.Context, blobMetadata *BlobMetadata) ([]byte, error)
 	// MarkBlobConfirmed updates blob metadata to Confirmed status with confirmation info
 	// Returns the updated metadata and error
 	MarkBlobConfirmed(ctx context.Context, existingMetadata *BlobMetadata, confirmationInfo *ConfirmationInfo) (*BlobMetadata, error)
-	// MarkBlobFinalized marks a blob as finalized
+	// MarkBlobFinalized moves a Confirmed blob to the Finalized status, atomically with the
+	// check of its status. It is idempotent: a Finalized blob is left as it is and nil is
+	// returned. ErrStatusConflict is returned for a blob in any other status, such as one
+	// rolled back to Processing by a reorg meanwhile, and ErrBlobNotFound for a blob not
+	// stored, which includes the finalized blobs already persisted and removed. A failed
+	// call leaves the blob as it was, so the finalization of a batch may be resumed blob by
+	// blob.
 	MarkBlobFinalized(ctx context.Context, blobKey BlobKey) error
 	// MarkBlobProcessing marks a blob as processing
 	MarkBlobProcessing(ctx context.Context, blobKey BlobKey) error
 	// MarkBlobFailed marks a blob as failed
 	MarkBlobFailed(ctx context.Context, blobKey BlobKey) error
+	// MarkBlobDeadLettered moves a blob to the DeadLettered status with a reason code
+	MarkBlobDeadLettered(ctx context.Context, existingMetadata *BlobMetadata, reason string) error
+	// ResubmitBlob queues a dead-lettered blob for encoding again with its retries reset
+	ResubmitBlob(ctx context.Context, existingMetadata *BlobMetadata) error
+	// ReviewQuarantinedBlob moves a quarantined blob to status, Processing to release it or
+	// Failed to reject it, atomically with the check that it is still quarantined.
+	// ErrStatusConflict is returned if it isn't, so a blob is reviewed once.
+	ReviewQuarantinedBlob(ctx context.Context, blobKey BlobKey, status BlobStatus) error
 	// IncrementBlobRetryCount increments the retry count of a blob
 	IncrementBlobRetryCount(ctx context.Context, existingMetadata *BlobMetadata) error
-	// GetBlobsByMetadata retrieves a list of blobs given a list of metadata
-	GetBlobsByMetadata(ctx context.Context, metadata []*BlobMetadata) (map[BlobKey]*core.Blob, error)
-	// GetBlobMetadataByStatus returns a list of blob metadata for blobs with the given status
-	GetBlobMetadataByStatus(ctx context.Context, blobStatus BlobStatus) ([]*BlobMetadata, error)
-	// GetMetadataInBatch returns the metadata in a given batch at given index.
-	GetMetadataInBatch(ctx context.Context, batchHeaderHash [32]byte, blobIndex uint32) (*BlobMetadata, error)
-	// GetAllBlobMetadataByBatch returns the metadata of all the blobs in the batch.
-	GetAllBlobMetadataByBatch(ctx context.Context, batchHeaderHash [32]byte) ([]*BlobMetadata, error)
-	// GetBlobMetadata returns a blob metadata given a metadata key
-	GetBlobMetadata(ctx context.Context, blobKey BlobKey) (*BlobMetadata, error)
-	// HandleBlobFailure handles a blob failure by either incrementing the retry count or marking the blob as failed
+	// SetBlobBatchAssignment records the position of a blob in the batch it was put in,
+	// leaving the rest of its metadata as it is, or clears it if assignment is nil.
+	// ErrBlobNotFound is returned if the blob isn't stored, nothing being written.
+	SetBlobBatchAssignment(ctx context.Context, blobKey BlobKey, assignment *BatchAssignment) error
+	// AppendBlobEvent appends an event to the lifecycle history of a blob, which is removed
+	// with the blob. ErrBlobNotFound is returned if the blob isn't stored, and
+	// ErrBlobHistoryFull if it has MaxBlobEvents events already.
+	AppendBlobEvent(ctx context.Context, blobKey BlobKey, event BlobEvent) error
+	// HandleBlobFailure handles a blob failure by either incrementing the retry count or dead-lettering the blob
 	HandleBlobFailure(ctx context.Context, metadata *BlobMetadata, maxRetry uint) error
+	// UseDispersalNonce records nonce as used by the signed dispersals of signer, atomically
+	// with the check that the NonceWindow of signer accepts it, and returns ErrNonceUsed
+	// otherwise. The windows are kept for as long as the store.
+	UseDispersalNonce(ctx context.Context, signer string, nonce uint64) error
+}
+
+// DispersalNonceStore is implemented by the blob stores whose dispersal nonce windows can be
+// copied to another store, as the snapshots of a disperser do so that the signed
+// dispersals accepted by one instance can't be replayed on the next
+type DispersalNonceStore interface {
+	// DispersalNonces returns the nonce windows of the signers which dispersed
+	DispersalNonces(ctx context.Context) (map[string]NonceWindow, error)
+	// RestoreDispersalNonce sets the nonce window of signer, unless the store holds one of
+	// a higher nonce already
+	RestoreDispersalNonce(ctx context.Context, signer string, window NonceWindow) error
+}
+
+// ReadOnly returns store as a BlobStoreReader that can't be asserted back to a BlobStore
+func ReadOnly(store BlobStoreReader) BlobStoreReader {
+	return readOnlyBlobStore{store}
+}
+
+type readOnlyBlobStore struct {
+	BlobStoreReader
+}
+
+// MetadataReplica serves blob metadata reads from an asynchronously replicated copy of the
+// metadata store, so the reads may lag behind the primary.
+type MetadataReplica interface {
+	// Staleness returns an upper bound of the replication lag. ok is false if the bound is
+	// unknown or too large for reads to be served from the replica.
+	Staleness() (staleness time.Duration, ok bool)
+	GetBlobMetadata(ctx context.Context, blobKey BlobKey) (*BlobMetadata, error)
+	GetAllBlobMetadataByBatch(ctx context.Context, batchHeaderHash [32]byte) ([]*BlobMetadata, error)
 }
 
+// Dispatcher submits batches to the dispatch target, the DA entrance contract by default.
+// The transactions it returns are followed by a batcher.Confirmer of the same target.
+// Implementations must be safe for concurrent use and must not return a transaction hash
+// along with an error.
 type Dispatcher interface {
+	// DisperseBatch registers the storage roots of the blobs of a batch, in order, and sets
+	// batchHeader.DataRoot to the merkle root of those storage roots, in the hash suite of
+	// batchHeaderHash. It returns
+	// an error without sending anything if ctx is done before the submission is sent.
 	DisperseBatch(ctx context.Context, batchHeaderHash [32]byte, batchHeader *core.BatchHeader, blobCommitments []*core.BlobCommitments, blobHeaders []*core.BlobHeader) (eth_common.Hash, error)
+	// SubmitAggregateSignatures submits the aggregate signatures of the operators over
+	// uploaded data roots, in a single transaction. Like DisperseBatch it doesn't send
+	// anything once ctx is done.
 	SubmitAggregateSignatures(ctx context.Context, rootSubmission []*core.CommitRootSubmission) (eth_common.Hash, error)
 }
 
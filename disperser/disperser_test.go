@@ -0,0 +1,30 @@
+package disperser_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnlyBlobStore(t *testing.T) {
+	ctx := context.Background()
+	store := memorydb.NewBlobStore(1<<30, mock.NewLogger(false))
+	key, err := store.StoreBlob(ctx, &core.Blob{Data: []byte("blob")}, uint64(time.Now().UnixNano()))
+	require.NoError(t, err)
+
+	reader := disperser.ReadOnly(store)
+	metadata, err := reader.GetBlobMetadata(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, disperser.Processing, metadata.BlobStatus)
+
+	// the writes can't be recovered from the reader
+	_, ok := reader.(disperser.BlobStoreWriter)
+	assert.False(t, ok)
+}
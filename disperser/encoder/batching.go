@@ -0,0 +1,154 @@
+package encoder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/core"
+	pb "github.com/0glabs/0g-da-client/disperser/api/grpc/encoder"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// BatchingConfig batches the requests of small blobs into EncodeBlobs requests, saving the
+// per request overhead that dominates their encoding. Encoders that don't support it are
+// sent the blobs one at a time.
+type BatchingConfig struct {
+	// MaxBlobs is the maximum number of blobs of a request, batching is disabled below 2.
+	// At most as many blobs as connections are encoded concurrently, so it shouldn't
+	// exceed the number of connections.
+	MaxBlobs int
+	// MaxBlobSize is the size of the largest blob that is batched
+	MaxBlobSize int
+	// MaxWait is how long a blob waits for others to fill its request
+	MaxWait time.Duration
+}
+
+func (c BatchingConfig) Enabled() bool {
+	return c.MaxBlobs > 1
+}
+
+func (c BatchingConfig) validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.MaxBlobSize <= 0 {
+		return errors.New("the maximum size of batched blobs must be positive")
+	}
+	if c.MaxWait <= 0 {
+		return errors.New("the maximum wait of batched blobs must be positive")
+	}
+	return nil
+}
+
+type batchedBlob struct {
+	ctx  context.Context
+	data []byte
+	log  common.Logger
+	done chan batchedResult
+}
+
+type batchedResult struct {
+	commitments *core.BlobCommitments
+	err         error
+}
+
+// batchQueue accumulates the small blobs until a request is full or its first blob waited
+// for MaxWait
+type batchQueue struct {
+	config BatchingConfig
+	// unsupported is set once the encoder answered that it doesn't batch requests
+	unsupported atomic.Bool
+
+	mu      sync.Mutex
+	pending []*batchedBlob
+}
+
+func newBatchQueue(config BatchingConfig) *batchQueue {
+	return &batchQueue{config: config}
+}
+
+// accepts returns whether data is sent in a batched request
+func (q *batchQueue) accepts(data []byte) bool {
+	return q != nil && len(data) <= q.config.MaxBlobSize && !q.unsupported.Load()
+}
+
+// add queues a blob, calling send with the blobs of a request once it is complete
+func (q *batchQueue) add(blob *batchedBlob, send func([]*batchedBlob)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = append(q.pending, blob)
+	if len(q.pending) >= q.config.MaxBlobs {
+		blobs := q.pending
+		q.pending = nil
+		go send(blobs)
+		return
+	}
+	if len(q.pending) == 1 {
+		time.AfterFunc(q.config.MaxWait, func() {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			// the request of blob may have been sent full already
+			if len(q.pending) == 0 || q.pending[0] != blob {
+				return
+			}
+			blobs := q.pending
+			q.pending = nil
+			go send(blobs)
+		})
+	}
+}
+
+func (c client) encodeBatched(ctx context.Context, data []byte, log common.Logger) (*core.BlobCommitments, error) {
+	blob := &batchedBlob{ctx: ctx, data: data, log: log, done: make(chan batchedResult, 1)}
+	c.batches.add(blob, c.sendBatch)
+	select {
+	case result := <-blob.done:
+		return result.commitments, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// sendBatch encodes the blobs of a request, one at a time if the encoder doesn't batch
+// requests
+func (c client) sendBatch(blobs []*batchedBlob) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	request := &pb.EncodeBlobsRequest{Requests: make([]*pb.EncodeBlobRequest, len(blobs))}
+	for i, blob := range blobs {
+		request.Requests[i] = &pb.EncodeBlobRequest{Data: blob.data}
+	}
+	reply, err := pb.NewEncoderClient(c.pool.get()).EncodeBlobs(ctx, request)
+	if status.Code(err) == codes.Unimplemented {
+		if !c.batches.unsupported.Swap(true) {
+			blobs[0].log.Warn("[encoder] encoder does not batch requests, sending blobs one at a time", "addr", c.addr)
+		}
+		for _, blob := range blobs {
+			go func(blob *batchedBlob) {
+				var trailer metadata.MD
+				commitments, err := c.encodeBlob(blob.ctx, blob.data, &trailer, blob.log)
+				blob.done <- batchedResult{commitments: commitments, err: err}
+			}(blob)
+		}
+		return
+	}
+	if err == nil && len(reply.GetReplies()) != len(blobs) {
+		err = fmt.Errorf("encoder replied %d encodings to %d blobs", len(reply.GetReplies()), len(blobs))
+	}
+	for i, blob := range blobs {
+		if err != nil {
+			blob.done <- batchedResult{err: err}
+			continue
+		}
+		r := reply.GetReplies()[i]
+		commitments, err := commitmentsFromReply(r, r.GetEncodedData(), r.GetEncodedSlice(), blob.log)
+		blob.done <- batchedResult{commitments: commitments, err: err}
+	}
+}
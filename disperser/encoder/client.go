@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/tracing"
 	"github.com/0glabs/0g-da-client/core"
 	"github.com/0glabs/0g-da-client/disperser"
 	pb "github.com/0glabs/0g-da-client/disperser/api/grpc/encoder"
@@ -14,44 +17,115 @@ import (
 	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type client struct {
-	addr    string
-	timeout time.Duration
+	addr     string
+	timeout  time.Duration
+	observer RequestObserver
+	// shmDir is the directory shared with the encoder, blobs travel in gRPC messages if empty
+	shmDir string
+	pool   *connPool
+	// batches is nil if the requests of small blobs are not batched
+	batches *batchQueue
 }
 
-func NewEncoderClient(addr string, timeout time.Duration) (disperser.EncoderClient, error) {
-	return client{
-		addr:    addr,
-		timeout: timeout,
-	}, nil
+// NewEncoderClient returns a client of the encoder at addr, which may be a unix domain
+// socket such as unix:///run/encoder.sock for a co-located encoder. The requests are spread
+// over numConnections persistent connections. If shmDir is set, blobs and their encoding
+// are exchanged through files of shmDir instead of the gRPC messages, see
+// SharedMemoryInputHeader, and are not batched. The connections are secured by creds.
+// observer may be nil.
+func NewEncoderClient(addr string, timeout time.Duration, numConnections int, shmDir string, batching BatchingConfig, creds credentials.TransportCredentials, observer RequestObserver) (disperser.EncoderClient, error) {
+	if shmDir != "" {
+		if info, err := os.Stat(shmDir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("encoder shared memory directory %s is not a directory", shmDir)
+		}
+	}
+	if err := batching.validate(); err != nil {
+		return nil, err
+	}
+	pool, err := newConnPool(addr, numConnections, creds)
+	if err != nil {
+		return nil, err
+	}
+	c := client{
+		addr:     addr,
+		timeout:  timeout,
+		observer: observer,
+		shmDir:   shmDir,
+		pool:     pool,
+	}
+	if batching.Enabled() && shmDir == "" {
+		c.batches = newBatchQueue(batching)
+	}
+	return c, nil
 }
 
 func (c client) EncodeBlob(ctx context.Context, data []byte, log common.Logger) (*core.BlobCommitments, error) {
-	ctxWithTimeout, cancel := context.WithTimeout(ctx, c.timeout)
-	defer cancel()
-	conn, err := grpc.DialContext(
-		ctxWithTimeout,
-		c.addr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(1024*1024*1024)), // 1 GiB
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to dial encoder: %w", err)
+	start := time.Now()
+	var trailer metadata.MD
+	var commitments *core.BlobCommitments
+	var err error
+	if c.batches.accepts(data) {
+		commitments, err = c.encodeBatched(ctx, data, log)
+	} else {
+		commitments, err = c.encodeBlob(ctx, data, &trailer, log)
 	}
-	defer conn.Close()
+	if c.observer != nil {
+		timing, ok := TimingFromTrailer(trailer)
+		if !ok {
+			timing = nil
+		}
+		c.observer.ObserveEncodeRequest(c.addr, len(data), time.Since(start), timing, err)
+	}
+	return commitments, err
+}
 
-	encoder := pb.NewEncoderClient(conn)
-	encodeBlobReply, err := encoder.EncodeBlob(ctx, &pb.EncodeBlobRequest{
+func (c client) encodeBlob(ctx context.Context, data []byte, trailer *metadata.MD, log common.Logger) (*core.BlobCommitments, error) {
+	request := &pb.EncodeBlobRequest{
 		Data:        data,
 		RequireData: false,
-	})
+	}
+	var shm *sharedMemoryRequest
+	var err error
+	if c.shmDir != "" {
+		shm, err = newSharedMemoryRequest(c.shmDir, data)
+		if err != nil {
+			return nil, err
+		}
+		defer shm.remove()
+		request.Data = nil
+		ctx = metadata.NewOutgoingContext(ctx, shm.metadata())
+	}
+
+	encoder := pb.NewEncoderClient(c.pool.get())
+	encodeBlobReply, err := encoder.EncodeBlob(ctx, request, grpc.Trailer(trailer))
 	if err != nil {
 		return nil, err
 	}
+	encodedData, encodedSlice := encodeBlobReply.GetEncodedData(), encodeBlobReply.GetEncodedSlice()
+	if shm != nil {
+		layout, err := parseSharedMemoryLayout(*trailer)
+		if err != nil {
+			return nil, err
+		}
+		encodedData, encodedSlice, err = shm.read(layout)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return commitmentsFromReply(encodeBlobReply, encodedData, encodedSlice, log)
+}
 
+// commitmentsFromReply returns the commitments of a reply, whose encoding may have been
+// exchanged out of band
+func commitmentsFromReply(encodeBlobReply *pb.EncodeBlobReply, encodedData []byte, encodedSlice [][]byte, log common.Logger) (*core.BlobCommitments, error) {
 	// little endian to big endian
 	commitment := encodeBlobReply.GetErasureCommitment()
 	if len(commitment) != bn.SizeOfG1AffineUncompressed {
@@ -77,7 +151,73 @@ func (c client) EncodeBlob(ctx context.Context, data []byte, log common.Logger)
 	return &core.BlobCommitments{
 		ErasureCommitment: commitmentPoint,
 		StorageRoot:       encodeBlobReply.GetStorageRoot(),
-		EncodedData:       encodeBlobReply.GetEncodedData(),
-		EncodedSlice:      encodeBlobReply.GetEncodedSlice(),
+		EncodedData:       encodedData,
+		EncodedSlice:      encodedSlice,
 	}, nil
 }
+
+// connPool holds persistent connections to an encoder, which the requests use in turn
+// instead of dialing the encoder each time
+type connPool struct {
+	conns []*grpc.ClientConn
+	next  atomic.Uint64
+}
+
+func newConnPool(addr string, size int, creds credentials.TransportCredentials) (*connPool, error) {
+	if size < 1 {
+		size = 1
+	}
+	pool := &connPool{conns: make([]*grpc.ClientConn, size)}
+	for i := range pool.conns {
+		// connections are established on their first request, and re-established if lost
+		// the trace context of the blobs is propagated to the encoder
+		conn, err := grpc.Dial(
+			addr,
+			append(tracing.DialOptions(),
+				grpc.WithTransportCredentials(creds),
+				grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(1024*1024*1024)), // 1 GiB
+			)...,
+		)
+		if err != nil {
+			pool.close()
+			return nil, fmt.Errorf("failed to dial encoder: %w", err)
+		}
+		pool.conns[i] = conn
+	}
+	return pool, nil
+}
+
+func (p *connPool) get() *grpc.ClientConn {
+	return p.conns[(p.next.Add(1)-1)%uint64(len(p.conns))]
+}
+
+func (p *connPool) close() {
+	for _, conn := range p.conns {
+		if conn != nil {
+			_ = conn.Close()
+		}
+	}
+}
+
+// Probe checks that the encoder at addr serves requests, through the gRPC health checking
+// protocol. Encoders that don't implement it are healthy as long as they answer.
+func Probe(ctx context.Context, addr string, timeout time.Duration, creds credentials.TransportCredentials) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return fmt.Errorf("failed to dial encoder: %w", err)
+	}
+	defer conn.Close()
+	reply, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if status.Code(err) == codes.Unimplemented {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if reply.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVING {
+		return fmt.Errorf("encoder is %s", reply.GetStatus())
+	}
+	return nil
+}
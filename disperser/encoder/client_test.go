@@ -0,0 +1,161 @@
+package encoder
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	pb "github.com/0glabs/0g-da-client/disperser/api/grpc/encoder"
+	bn "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// fakeEncoder commits every blob to the generator, spending overhead on each request in
+// one of its slots if it has any
+type fakeEncoder struct {
+	pb.UnimplementedEncoderServer
+	overhead time.Duration
+	slots    chan struct{}
+	requests atomic.Int64
+}
+
+func (e *fakeEncoder) serve() {
+	e.requests.Add(1)
+	if e.slots != nil {
+		e.slots <- struct{}{}
+		defer func() { <-e.slots }()
+	}
+	time.Sleep(e.overhead)
+}
+
+func (e *fakeEncoder) EncodeBlob(ctx context.Context, request *pb.EncodeBlobRequest) (*pb.EncodeBlobReply, error) {
+	e.serve()
+	return fakeReply(request.GetData()), nil
+}
+
+// batchingEncoder also serves EncodeBlobs
+type batchingEncoder struct {
+	fakeEncoder
+}
+
+func (e *batchingEncoder) EncodeBlobs(ctx context.Context, request *pb.EncodeBlobsRequest) (*pb.EncodeBlobsReply, error) {
+	e.serve()
+	reply := &pb.EncodeBlobsReply{}
+	for _, r := range request.GetRequests() {
+		reply.Replies = append(reply.Replies, fakeReply(r.GetData()))
+	}
+	return reply, nil
+}
+
+func fakeReply(data []byte) *pb.EncodeBlobReply {
+	_, _, g1, _ := bn.Generators()
+	// the encoder serializes the coordinates in little endian
+	commitment := g1.RawBytes()
+	for i := 0; i < fp.Bytes/2; i++ {
+		commitment[i], commitment[fp.Bytes-i-1] = commitment[fp.Bytes-i-1], commitment[i]
+		commitment[fp.Bytes+i], commitment[len(commitment)-i-1] = commitment[len(commitment)-i-1], commitment[fp.Bytes+i]
+	}
+	return &pb.EncodeBlobReply{ErasureCommitment: commitment[:], StorageRoot: data}
+}
+
+func serveEncoder(t testing.TB, server pb.EncoderServer) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	pb.RegisterEncoderServer(s, server)
+	go func() { _ = s.Serve(listener) }()
+	t.Cleanup(s.Stop)
+	return listener.Addr().String()
+}
+
+// encodeConcurrently encodes blobs with as many workers as the encoding streamer has
+// connections
+func encodeConcurrently(t testing.TB, c *client, blobs int, size int, workers int) {
+	logger := mock.NewLogger(false)
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				data := make([]byte, size)
+				data[0] = byte(i)
+				commitments, err := c.EncodeBlob(context.Background(), data, logger)
+				if assert.NoError(t, err) {
+					assert.Equal(t, data, commitments.StorageRoot)
+				}
+			}
+		}()
+	}
+	for i := 0; i < blobs; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+func newTestClient(t testing.TB, addr string, batching BatchingConfig) *client {
+	c, err := NewEncoderClient(addr, time.Minute, 4, "", batching, insecure.NewCredentials(), nil)
+	require.NoError(t, err)
+	encoderClient := c.(client)
+	t.Cleanup(encoderClient.pool.close)
+	return &encoderClient
+}
+
+func TestBatchedEncodeBlob(t *testing.T) {
+	batching := BatchingConfig{MaxBlobs: 4, MaxBlobSize: 1024, MaxWait: time.Minute}
+
+	// full requests are sent without waiting
+	server := &batchingEncoder{}
+	c := newTestClient(t, serveEncoder(t, server), batching)
+	encodeConcurrently(t, c, 8, 16, 8)
+	assert.Equal(t, int64(2), server.requests.Load())
+
+	// blobs above the size limit are sent one at a time
+	encodeConcurrently(t, c, 1, 2048, 1)
+	assert.Equal(t, int64(3), server.requests.Load())
+
+	// partial requests are sent after the wait
+	batching.MaxWait = 10 * time.Millisecond
+	c = newTestClient(t, serveEncoder(t, server), batching)
+	encodeConcurrently(t, c, 2, 16, 2)
+	assert.Equal(t, int64(4), server.requests.Load())
+
+	// encoders that don't batch requests are sent the blobs one at a time
+	legacy := &fakeEncoder{}
+	c = newTestClient(t, serveEncoder(t, legacy), batching)
+	encodeConcurrently(t, c, 4, 16, 4)
+	assert.Equal(t, int64(4), legacy.requests.Load())
+	assert.False(t, c.batches.accepts(make([]byte, 16)))
+}
+
+// BenchmarkEncodeSmallBlobs compares the throughput of small blobs sent one at a time and
+// in batched requests by 16 workers, the encoder spending a fixed overhead on each request
+// in one of its 4 slots
+func BenchmarkEncodeSmallBlobs(b *testing.B) {
+	for _, bench := range []struct {
+		name     string
+		batching BatchingConfig
+	}{
+		{"single", BatchingConfig{}},
+		{"batched", BatchingConfig{MaxBlobs: 16, MaxBlobSize: 64 * 1024, MaxWait: time.Millisecond}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			server := &batchingEncoder{fakeEncoder{overhead: time.Millisecond, slots: make(chan struct{}, 4)}}
+			c := newTestClient(b, serveEncoder(b, server), bench.batching)
+			b.SetBytes(4 * 1024)
+			b.ResetTimer()
+			encodeConcurrently(b, c, b.N, 4*1024, 16)
+			b.ReportMetric(float64(server.requests.Load())/float64(b.N), "requests/blob")
+		})
+	}
+}
@@ -25,6 +25,9 @@ type Metrics struct {
 
 	NumEncodeBlobRequests *prometheus.CounterVec
 	Latency               *prometheus.SummaryVec
+	RequestSize           prometheus.Histogram
+	StageLatency          *prometheus.HistogramVec
+	RejectedRequests      *prometheus.CounterVec
 }
 
 func NewMetrics(httpPort string, logger common.Logger) *Metrics {
@@ -53,6 +56,31 @@ func NewMetrics(httpPort string, logger common.Logger) *Metrics {
 			},
 			[]string{"time"},
 		),
+		RequestSize: promauto.With(reg).NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: "zgda_encoder",
+				Name:      "request_size_bytes",
+				Help:      "size of the blobs of the encode blob requests",
+				Buckets:   prometheus.ExponentialBuckets(1024, 4, 10), // 1 KiB to 256 MiB
+			},
+		),
+		StageLatency: promauto.With(reg).NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "zgda_encoder",
+				Name:      "stage_latency_ms",
+				Help:      "time spent by each request per stage in milliseconds",
+				Buckets:   prometheus.ExponentialBuckets(1, 2, 16),
+			},
+			[]string{"stage"}, // queue, fft, msm or proof
+		),
+		RejectedRequests: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "zgda_encoder",
+				Name:      "rejected_requests_total",
+				Help:      "number of encode blob requests rejected before encoding per reason",
+			},
+			[]string{"reason"},
+		),
 	}
 }
 
@@ -85,6 +113,22 @@ func (m *Metrics) TakeLatency(encoding, total time.Duration) {
 	m.Latency.WithLabelValues("total").Observe(float64(total.Milliseconds()))
 }
 
+// ObserveRequest records the size of an encoded blob and the time its request spent in
+// each stage. Servers also report the timing to the client with Timing.Trailer.
+func (m *Metrics) ObserveRequest(size int, timing Timing) {
+	m.RequestSize.Observe(float64(size))
+	m.StageLatency.WithLabelValues("queue").Observe(float64(timing.Queue.Milliseconds()))
+	m.StageLatency.WithLabelValues("fft").Observe(float64(timing.FFT.Milliseconds()))
+	m.StageLatency.WithLabelValues("msm").Observe(float64(timing.MSM.Milliseconds()))
+	m.StageLatency.WithLabelValues("proof").Observe(float64(timing.Proof.Milliseconds()))
+}
+
+// IncrementRejectedRequestNum counts a request rejected before encoding, e.g. because the
+// queue is full or the blob is too large
+func (m *Metrics) IncrementRejectedRequestNum(reason string) {
+	m.RejectedRequests.WithLabelValues(reason).Inc()
+}
+
 func (m *Metrics) Start(ctx context.Context) {
 	m.logger.Info("Starting metrics server at ", "port", m.httpPort)
 
@@ -0,0 +1,115 @@
+package encoder
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Metadata of the shared memory transport. Instead of the blob and its encoding travelling
+// in the gRPC messages, the client writes the blob to a file of a directory shared with
+// the encoder, typically on a tmpfs such as /dev/shm, and sends its name in the input
+// header with the name of the file the encoder must write the encoding to in the output
+// header. The encoder leaves encoded_data and encoded_slice empty in the reply, and reports
+// the layout of the output file in the layout trailer.
+const (
+	SharedMemoryInputHeader  = "x-zgda-shm-input"
+	SharedMemoryOutputHeader = "x-zgda-shm-output"
+	// SharedMemoryLayoutTrailer is "<encoded data length>,<slice count>,<slice length>". The
+	// output file holds the encoded data followed by the slices, all of the same length.
+	SharedMemoryLayoutTrailer = "x-zgda-shm-layout"
+)
+
+var errNoSharedMemoryLayout = errors.New("encoder replied without a shared memory layout, it may not support the transport")
+
+// sharedMemoryLayout is the layout of an output file
+type sharedMemoryLayout struct {
+	dataLength  int
+	sliceCount  int
+	sliceLength int
+}
+
+func (l sharedMemoryLayout) size() int {
+	return l.dataLength + l.sliceCount*l.sliceLength
+}
+
+func parseSharedMemoryLayout(md metadata.MD) (sharedMemoryLayout, error) {
+	values := md.Get(SharedMemoryLayoutTrailer)
+	if len(values) == 0 {
+		return sharedMemoryLayout{}, errNoSharedMemoryLayout
+	}
+	parts := strings.Split(values[0], ",")
+	if len(parts) != 3 {
+		return sharedMemoryLayout{}, fmt.Errorf("invalid shared memory layout %q", values[0])
+	}
+	fields := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n < 0 {
+			return sharedMemoryLayout{}, fmt.Errorf("invalid shared memory layout %q", values[0])
+		}
+		fields[i] = n
+	}
+	return sharedMemoryLayout{dataLength: fields[0], sliceCount: fields[1], sliceLength: fields[2]}, nil
+}
+
+// sharedMemoryRequest is the pair of files of a request
+type sharedMemoryRequest struct {
+	input  string
+	output string
+}
+
+// newSharedMemoryRequest writes the blob to a new input file of dir
+func newSharedMemoryRequest(dir string, data []byte) (*sharedMemoryRequest, error) {
+	file, err := os.CreateTemp(dir, "blob-*.in")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create shared memory input: %w", err)
+	}
+	input := file.Name()
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(input)
+		return nil, fmt.Errorf("failed to write shared memory input: %w", err)
+	}
+	return &sharedMemoryRequest{input: input, output: strings.TrimSuffix(input, ".in") + ".out"}, nil
+}
+
+// metadata returns the request metadata naming the files, relative to the shared directory
+func (r *sharedMemoryRequest) metadata() metadata.MD {
+	return metadata.Pairs(
+		SharedMemoryInputHeader, filepath.Base(r.input),
+		SharedMemoryOutputHeader, filepath.Base(r.output),
+	)
+}
+
+// read returns the encoded data and slices of the output file. The slices share a single
+// buffer with the data.
+func (r *sharedMemoryRequest) read(layout sharedMemoryLayout) ([]byte, [][]byte, error) {
+	buf, err := os.ReadFile(r.output)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read shared memory output: %w", err)
+	}
+	if len(buf) != layout.size() {
+		return nil, nil, fmt.Errorf("shared memory output is %d bytes, expected %d", len(buf), layout.size())
+	}
+	data := buf[:layout.dataLength:layout.dataLength]
+	slices := make([][]byte, layout.sliceCount)
+	for i := range slices {
+		offset := layout.dataLength + i*layout.sliceLength
+		slices[i] = buf[offset : offset+layout.sliceLength : offset+layout.sliceLength]
+	}
+	return data, slices, nil
+}
+
+func (r *sharedMemoryRequest) remove() {
+	os.Remove(r.input)
+	os.Remove(r.output)
+}
@@ -0,0 +1,45 @@
+package encoder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestSharedMemoryRequest(t *testing.T) {
+	dir := t.TempDir()
+	req, err := newSharedMemoryRequest(dir, []byte("blob"))
+	require.NoError(t, err)
+	input, err := os.ReadFile(filepath.Join(dir, req.metadata().Get(SharedMemoryInputHeader)[0]))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("blob"), input)
+
+	// the encoder writes the encoded data followed by the slices
+	output := filepath.Join(dir, req.metadata().Get(SharedMemoryOutputHeader)[0])
+	require.NoError(t, os.WriteFile(output, []byte("dataaaabbbb"), 0o600))
+
+	_, err = parseSharedMemoryLayout(metadata.MD{})
+	assert.ErrorIs(t, err, errNoSharedMemoryLayout)
+	_, err = parseSharedMemoryLayout(metadata.Pairs(SharedMemoryLayoutTrailer, "3,2"))
+	assert.Error(t, err)
+
+	layout, err := parseSharedMemoryLayout(metadata.Pairs(SharedMemoryLayoutTrailer, "3,2,4"))
+	require.NoError(t, err)
+	data, slices, err := req.read(layout)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("dat"), data)
+	assert.Equal(t, [][]byte{[]byte("aaaa"), []byte("bbbb")}, slices)
+
+	// a size mismatch means the layout doesn't describe the file
+	_, _, err = req.read(sharedMemoryLayout{dataLength: 4, sliceCount: 2, sliceLength: 4})
+	assert.Error(t, err)
+
+	req.remove()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
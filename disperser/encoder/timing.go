@@ -0,0 +1,69 @@
+package encoder
+
+import (
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Trailers an encoder server may set on EncodeBlob replies to report where the time of the
+// request went, in milliseconds. A server reporting its timing must set all of them.
+const (
+	QueueTimeTrailer = "x-zgda-encoder-queue-ms"
+	FFTTimeTrailer   = "x-zgda-encoder-fft-ms"
+	MSMTimeTrailer   = "x-zgda-encoder-msm-ms"
+	ProofTimeTrailer = "x-zgda-encoder-proof-ms"
+)
+
+// Timing is the breakdown of the time an encoder server spent on a request
+type Timing struct {
+	// Queue is the time the request waited for an encoding slot
+	Queue time.Duration
+	FFT   time.Duration
+	MSM   time.Duration
+	Proof time.Duration
+}
+
+// Total returns the time spent on the server
+func (t Timing) Total() time.Duration {
+	return t.Queue + t.FFT + t.MSM + t.Proof
+}
+
+// Trailer returns the trailer reporting the timing
+func (t Timing) Trailer() metadata.MD {
+	return metadata.Pairs(
+		QueueTimeTrailer, strconv.FormatInt(t.Queue.Milliseconds(), 10),
+		FFTTimeTrailer, strconv.FormatInt(t.FFT.Milliseconds(), 10),
+		MSMTimeTrailer, strconv.FormatInt(t.MSM.Milliseconds(), 10),
+		ProofTimeTrailer, strconv.FormatInt(t.Proof.Milliseconds(), 10),
+	)
+}
+
+// TimingFromTrailer parses the timing reported in a trailer, false if it reports none
+func TimingFromTrailer(md metadata.MD) (*Timing, bool) {
+	var timing Timing
+	for key, out := range map[string]*time.Duration{
+		QueueTimeTrailer: &timing.Queue,
+		FFTTimeTrailer:   &timing.FFT,
+		MSMTimeTrailer:   &timing.MSM,
+		ProofTimeTrailer: &timing.Proof,
+	} {
+		values := md.Get(key)
+		if len(values) == 0 {
+			return nil, false
+		}
+		ms, err := strconv.ParseInt(values[0], 10, 64)
+		if err != nil || ms < 0 {
+			return nil, false
+		}
+		*out = time.Duration(ms) * time.Millisecond
+	}
+	return &timing, true
+}
+
+// RequestObserver is notified of every EncodeBlob request of a client. timing is nil if
+// the server didn't report one.
+type RequestObserver interface {
+	ObserveEncodeRequest(encoder string, size int, roundTrip time.Duration, timing *Timing, err error)
+}
@@ -0,0 +1,37 @@
+package encoder_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/disperser/encoder"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestTimingTrailer(t *testing.T) {
+	timing := encoder.Timing{
+		Queue: 20 * time.Millisecond,
+		FFT:   300 * time.Millisecond,
+		MSM:   1500 * time.Millisecond,
+		Proof: 4 * time.Second,
+	}
+
+	parsed, ok := encoder.TimingFromTrailer(timing.Trailer())
+	assert.True(t, ok)
+	assert.Equal(t, timing, *parsed)
+	assert.Equal(t, 5820*time.Millisecond, parsed.Total())
+
+	_, ok = encoder.TimingFromTrailer(metadata.MD{})
+	assert.False(t, ok)
+
+	partial := timing.Trailer()
+	partial.Delete(encoder.MSMTimeTrailer)
+	_, ok = encoder.TimingFromTrailer(partial)
+	assert.False(t, ok)
+
+	invalid := timing.Trailer()
+	invalid.Set(encoder.QueueTimeTrailer, "-1")
+	_, ok = encoder.TimingFromTrailer(invalid)
+	assert.False(t, ok)
+}
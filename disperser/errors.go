@@ -1,9 +1,38 @@
 package disperser
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrBlobNotFound   = errors.New("blob not found")
 	ErrMemoryDbIsFull = errors.New("memory db is full")
 	ErrKeyNotFound    = errors.New("key not found in db")
+	// ErrConditionNotMet is returned by a conditional metadata read whose condition doesn't hold
+	ErrConditionNotMet = errors.New("blob metadata condition not met")
+	// ErrBlobCorrupted is returned when the stored payload doesn't match its checksum
+	ErrBlobCorrupted = errors.New("blob content is corrupted")
+	// ErrStatusConflict is returned by a status transition from a status the blob isn't in
+	ErrStatusConflict = errors.New("blob status conflicts with the transition")
+	// ErrNonceUsed is returned for a signed dispersal whose nonce isn't greater than the last
+	// nonce of its signer
+	ErrNonceUsed = errors.New("dispersal nonce already used")
+	// ErrBlobKeyConflict is returned for a blob stored under the key of another request
+	ErrBlobKeyConflict = errors.New("blob key already taken by another request")
+	// ErrBlobHistoryFull is returned for an event of a blob whose history has MaxBlobEvents
+	ErrBlobHistoryFull = errors.New("blob history is full")
 )
+
+// BlobCorruptionError lists the blobs whose payload failed checksum verification
+type BlobCorruptionError struct {
+	Keys []BlobKey
+}
+
+func (e *BlobCorruptionError) Error() string {
+	return fmt.Sprintf("%v: %d blobs", ErrBlobCorrupted, len(e.Keys))
+}
+
+func (e *BlobCorruptionError) Unwrap() error {
+	return ErrBlobCorrupted
+}
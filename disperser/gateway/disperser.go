@@ -0,0 +1,243 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser/dispersal"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Routes of the JSON gateway to the disperser API, served if Config.DisperserAddr is set:
+//   - POST /v1/blobs disperses the body, raw bytes unless its Content-Type is
+//     application/json, then a DisperseBlobRequest in the proto JSON mapping
+//   - GET /v1/blobs/status/{requestId} returns the BlobStatusReply
+//   - GET /v1/blobs/{storageRoot}/{epoch}/{quorumId} returns the raw blob, or the
+//     RetrieveBlobReply if application/json is accepted
+//
+// The x-zgda-* request headers are forwarded as grpc metadata, except those the gateway
+// authenticates itself with, and the x-zgda-* response headers of the disperser are returned
+// as http headers. Calls rejected with a RetryInfo set the Retry-After header.
+const (
+	disperseBlobPath = "/v1/blobs"
+	blobStatusPrefix = "/v1/blobs/status/"
+	retrieveBlobPath = "/v1/blobs/"
+
+	jsonContentType   = "application/json"
+	binaryContentType = "application/octet-stream"
+
+	forwardedHeaderPrefix = "x-zgda-"
+)
+
+var jsonMarshaler = protojson.MarshalOptions{UseProtoNames: true}
+
+// disperseReply is the reply to POST /v1/blobs. The request id is returned as the string
+// the status route takes, instead of the base64 of the proto JSON mapping.
+type disperseReply struct {
+	Result    string `json:"result"`
+	RequestID string `json:"request_id"`
+}
+
+func (s *Server) handleDisperseBlob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	isJSON := hasMediaType(r.Header.Get("Content-Type"), jsonContentType)
+	limit := int64(core.MaxBlobSize)
+	if isJSON {
+		// base64 takes 4 bytes for 3, plus some room for the object around it
+		limit = limit/3*4 + 1024
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	req := &pb.DisperseBlobRequest{Data: body}
+	if isJSON {
+		req = &pb.DisperseBlobRequest{}
+		if err := protojson.Unmarshal(body, req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var header metadata.MD
+	reply, err := s.disperser.DisperseBlob(s.forwardMetadata(r), req, grpc.Header(&header))
+	writeForwardedHeaders(w, header)
+	if err != nil {
+		s.writeGRPCError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", jsonContentType)
+	_ = json.NewEncoder(w).Encode(disperseReply{
+		Result:    reply.GetResult().String(),
+		RequestID: string(reply.GetRequestId()),
+	})
+}
+
+func (s *Server) handleBlobStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	requestID := strings.TrimPrefix(r.URL.Path, blobStatusPrefix)
+	if requestID == "" {
+		http.Error(w, "expected /v1/blobs/status/{requestId}", http.StatusBadRequest)
+		return
+	}
+
+	var header metadata.MD
+	reply, err := s.disperser.GetBlobStatus(s.forwardMetadata(r), &pb.BlobStatusRequest{RequestId: []byte(requestID)}, grpc.Header(&header))
+	writeForwardedHeaders(w, header)
+	if err != nil {
+		s.writeGRPCError(w, err)
+		return
+	}
+	writeJSON(w, reply)
+}
+
+func (s *Server) handleRetrieveBlob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	blob, err := parseBlobPath(blobPathPrefix + strings.TrimPrefix(r.URL.Path, retrieveBlobPath))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var header metadata.MD
+	reply, err := s.disperser.RetrieveBlob(s.forwardMetadata(r), &pb.RetrieveBlobRequest{
+		StorageRoot: blob.DataRoot,
+		Epoch:       blob.Epoch,
+		QuorumId:    blob.QuorumId,
+	}, grpc.Header(&header))
+	writeForwardedHeaders(w, header)
+	if err != nil {
+		s.writeGRPCError(w, err)
+		return
+	}
+	if acceptsJSON(r.Header.Get("Accept")) {
+		writeJSON(w, reply)
+		return
+	}
+	w.Header().Set("Content-Type", binaryContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(reply.GetData())))
+	_, _ = w.Write(reply.GetData())
+}
+
+// forwardMetadata returns the context of a call for r, with its x-zgda-* headers. The
+// x-forwarded-for of the client is dropped for the address of its connection, and the
+// address is sent along with the gateway token if configured, so that the disperser limits
+// the client rather than the gateway.
+func (s *Server) forwardMetadata(r *http.Request) context.Context {
+	md := metadata.MD{}
+	for name, values := range r.Header {
+		name = strings.ToLower(name)
+		if !strings.HasPrefix(name, forwardedHeaderPrefix) || name == dispersal.GatewayTokenHeader || name == dispersal.OriginHeader {
+			continue
+		}
+		md.Append(name, values...)
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		md.Set("x-forwarded-for", host)
+		if s.config.DisperserToken != "" {
+			md.Set(dispersal.GatewayTokenHeader, s.config.DisperserToken)
+			md.Set(dispersal.OriginHeader, host)
+		}
+	}
+	return metadata.NewOutgoingContext(r.Context(), md)
+}
+
+func writeForwardedHeaders(w http.ResponseWriter, header metadata.MD) {
+	for name, values := range header {
+		if !strings.HasPrefix(name, forwardedHeaderPrefix) {
+			continue
+		}
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, m proto.Message) {
+	data, err := jsonMarshaler.Marshal(m)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", jsonContentType)
+	_, _ = w.Write(data)
+}
+
+func (s *Server) writeGRPCError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	s.logger.Debug("[gateway] disperser call failed", "code", st.Code(), "err", st.Message())
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			seconds := int(math.Ceil(info.GetRetryDelay().AsDuration().Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+		}
+	}
+	http.Error(w, st.Message(), httpStatus(st.Code()))
+}
+
+// httpStatus maps the code of a failed disperser call to the status of the reply
+func httpStatus(code codes.Code) int {
+	switch code {
+	// the disperser rejects invalid requests with errors without a code
+	case codes.Unknown, codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+func hasMediaType(header string, mediaType string) bool {
+	parsed, _, err := mime.ParseMediaType(header)
+	return err == nil && parsed == mediaType
+}
+
+// acceptsJSON returns whether an Accept header asks for JSON over raw bytes
+func acceptsJSON(accept string) bool {
+	for _, candidate := range strings.Split(accept, ",") {
+		if hasMediaType(strings.TrimSpace(candidate), jsonContentType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type fakeDisperser struct {
+	pb.DisperserClient
+	dispersed [][]byte
+	md        metadata.MD
+}
+
+func (f *fakeDisperser) DisperseBlob(ctx context.Context, in *pb.DisperseBlobRequest, opts ...grpc.CallOption) (*pb.DisperseBlobReply, error) {
+	f.dispersed = append(f.dispersed, in.GetData())
+	f.md, _ = metadata.FromOutgoingContext(ctx)
+	return &pb.DisperseBlobReply{Result: pb.BlobStatus_PROCESSING, RequestId: []byte("request")}, nil
+}
+
+func (f *fakeDisperser) RetrieveBlob(ctx context.Context, in *pb.RetrieveBlobRequest, opts ...grpc.CallOption) (*pb.RetrieveBlobReply, error) {
+	return &pb.RetrieveBlobReply{Data: []byte("blob")}, nil
+}
+
+func TestDisperserGateway(t *testing.T) {
+	fake := &fakeDisperser{}
+	server := &Server{disperser: fake, logger: mock.NewLogger(false)}
+	handler := server.handler()
+
+	do := func(r *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	// raw and JSON bodies are dispersed alike, with the x-zgda-* headers forwarded
+	r := httptest.NewRequest(http.MethodPost, "/v1/blobs", strings.NewReader("blob"))
+	r.Header.Set("Content-Type", "application/octet-stream")
+	r.Header.Set("X-Zgda-Priority", "1")
+	w := do(r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"result":"PROCESSING","request_id":"request"}`, w.Body.String())
+	assert.Equal(t, []string{"1"}, fake.md.Get("x-zgda-priority"))
+	assert.Equal(t, []string{"192.0.2.1"}, fake.md.Get("x-forwarded-for"))
+
+	r = httptest.NewRequest(http.MethodPost, "/v1/blobs", strings.NewReader(`{"data":"YmxvYg=="}`))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	assert.Equal(t, http.StatusOK, do(r).Code)
+	assert.Equal(t, [][]byte{[]byte("blob"), []byte("blob")}, fake.dispersed)
+
+	// retrieved blobs are raw unless JSON is accepted
+	w = do(httptest.NewRequest(http.MethodGet, "/v1/blobs/0x0102/7/0", nil))
+	assert.Equal(t, "application/octet-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "blob", w.Body.String())
+
+	r = httptest.NewRequest(http.MethodGet, "/v1/blobs/0x0102/7/0", nil)
+	r.Header.Set("Accept", "application/json")
+	assert.JSONEq(t, `{"data":"YmxvYg=="}`, do(r).Body.String())
+}
+
+func TestForwardMetadata(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/v1/blobs", nil)
+	r.RemoteAddr = "1.2.3.4:5678"
+	r.Header.Set("X-Forwarded-For", "10.0.0.1")
+	r.Header.Set("X-Zgda-Origin", "10.0.0.1")
+	r.Header.Set("X-Zgda-Gateway-Token", "guess")
+
+	// the addresses and token set by the client are dropped
+	md, _ := metadata.FromOutgoingContext((&Server{}).forwardMetadata(r))
+	assert.Equal(t, []string{"1.2.3.4"}, md.Get("x-forwarded-for"))
+	assert.Empty(t, md.Get("x-zgda-origin"))
+	assert.Empty(t, md.Get("x-zgda-gateway-token"))
+
+	// the gateway relays the address of the client with its token
+	md, _ = metadata.FromOutgoingContext((&Server{config: Config{DisperserToken: "secret"}}).forwardMetadata(r))
+	assert.Equal(t, []string{"1.2.3.4"}, md.Get("x-zgda-origin"))
+	assert.Equal(t, []string{"secret"}, md.Get("x-zgda-gateway-token"))
+}
@@ -0,0 +1,336 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/clients"
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/tlsconfig"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/api/grpc/retriever"
+	"github.com/0glabs/0g-da-client/disperser/encoder"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const blobPathPrefix = "/blob/"
+
+type Config struct {
+	HTTPPort string
+	// RetrieverAddrs are the retrievers blobs are fetched from, tried in order
+	RetrieverAddrs []string
+	// CacheSize is the maximum number of blobs kept in memory
+	CacheSize      int
+	RequestTimeout time.Duration
+	// DisperserAddr is the disperser API served as JSON under /v1, not served if empty
+	DisperserAddr string
+	// DisperserToken authenticates the gateway to the disperser, which then limits the
+	// clients of the gateway by their address rather than the gateway. It must match the
+	// gateway token of the disperser.
+	DisperserToken string
+	// EncoderAddr is the encoder the retrieved blobs are encoded with to check them against
+	// their storage root. Blobs aren't checked, cached or served as immutable if empty.
+	EncoderAddr    string
+	EncoderTimeout time.Duration
+	// RetrieverTLS, DisperserTLS and EncoderTLS secure the connections to the retrievers,
+	// disperser and encoder
+	RetrieverTLS tlsconfig.ClientConfig
+	DisperserTLS tlsconfig.ClientConfig
+	EncoderTLS   tlsconfig.ClientConfig
+}
+
+// retrievedBlob is the data of a blob, verified if it encodes to the storage root it was
+// retrieved for
+type retrievedBlob struct {
+	data     []byte
+	verified bool
+}
+
+// inflight is a retrieval shared by all concurrent requests for the same blob.
+type inflight struct {
+	done chan struct{}
+	blob *retrievedBlob
+	err  error
+}
+
+// Server is an HTTP gateway in front of the retrievers. Blobs are immutable once
+// confirmed, so the retrieved blobs verified against their storage root are cached and
+// served as immutable, with their storage root as ETag. It can also serve the disperser API
+// to clients without gRPC stubs.
+type Server struct {
+	config    Config
+	cache     *lru.Cache[[32]byte, *retrievedBlob]
+	fetch     func(ctx context.Context, addr string, metadata *disperser.BlobRetrieveMetadata) ([]byte, error)
+	disperser pb.DisperserClient
+	// verifier checks the retrieved blobs, nil if they aren't checked
+	verifier clients.Verifier
+	logger   common.Logger
+
+	mu       sync.Mutex
+	inflight map[[32]byte]*inflight
+}
+
+func NewServer(config Config, logger common.Logger) (*Server, error) {
+	if len(config.RetrieverAddrs) == 0 && config.DisperserAddr == "" {
+		return nil, errors.New("at least one retriever address or the disperser address is required")
+	}
+	cache, err := lru.New[[32]byte, *retrievedBlob](config.CacheSize)
+	if err != nil {
+		return nil, err
+	}
+	retrieverCreds, err := tlsconfig.ClientCredentials(config.RetrieverTLS, logger)
+	if err != nil {
+		return nil, err
+	}
+	var disperserClient pb.DisperserClient
+	if config.DisperserAddr != "" {
+		disperserCreds, err := tlsconfig.ClientCredentials(config.DisperserTLS, logger)
+		if err != nil {
+			return nil, err
+		}
+		conn, err := grpc.Dial(
+			config.DisperserAddr,
+			grpc.WithTransportCredentials(disperserCreds),
+			grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(1024*1024*1024)), // 1 GiB
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial disperser: %w", err)
+		}
+		disperserClient = pb.NewDisperserClient(conn)
+	}
+	var verifier clients.Verifier
+	if config.EncoderAddr != "" {
+		encoderCreds, err := tlsconfig.ClientCredentials(config.EncoderTLS, logger)
+		if err != nil {
+			return nil, err
+		}
+		encoderClient, err := encoder.NewEncoderClient(config.EncoderAddr, config.EncoderTimeout, 1, "", encoder.BatchingConfig{}, encoderCreds, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial encoder: %w", err)
+		}
+		verifier = &clients.EncoderVerifier{Encoder: encoderClient, Logger: logger}
+	} else if len(config.RetrieverAddrs) > 0 {
+		logger.Warn("[gateway] no encoder address, the retrieved blobs are served unchecked")
+	}
+	return &Server{
+		config:    config,
+		cache:     cache,
+		fetch:     retrieveWith(retrieverCreds),
+		disperser: disperserClient,
+		verifier:  verifier,
+		logger:    logger,
+		inflight:  make(map[[32]byte]*inflight),
+	}, nil
+}
+
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	if len(s.config.RetrieverAddrs) > 0 {
+		mux.HandleFunc(blobPathPrefix, s.handleGetBlob)
+	}
+	if s.disperser != nil {
+		mux.HandleFunc(disperseBlobPath, s.handleDisperseBlob)
+		mux.HandleFunc(blobStatusPrefix, s.handleBlobStatus)
+		mux.HandleFunc(retrieveBlobPath, s.handleRetrieveBlob)
+	}
+	return mux
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	server := &http.Server{
+		Addr:    fmt.Sprintf("%s:%s", disperser.Localhost, s.config.HTTPPort),
+		Handler: s.handler(),
+	}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+
+	s.logger.Info("[gateway] listening", "port", s.config.HTTPPort)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("could not start http server: %w", err)
+	}
+	return nil
+}
+
+// handleGetBlob serves GET /blob/{storageRoot}/{epoch}/{quorumId}
+func (s *Server) handleGetBlob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	metadata, err := parseBlobPath(r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// the blob is retrieved before answering a conditional request, so that blobs which
+	// can't be retrieved are never reported unmodified
+	blob, err := s.getBlob(r.Context(), metadata)
+	if err != nil {
+		s.logger.Warn("[gateway] failed to retrieve blob", "storage root", hexutil.Encode(metadata.DataRoot), "err", err)
+		http.Error(w, "failed to retrieve blob", http.StatusBadGateway)
+		return
+	}
+
+	// verified blobs are tagged with the storage root they encode to, the others with their
+	// hash and revalidated on each use since another retriever may serve other data
+	var etag string
+	if blob.verified {
+		etag = fmt.Sprintf(`"%s"`, hexutil.Encode(metadata.DataRoot))
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		etag = fmt.Sprintf(`"%s"`, crypto.Keccak256Hash(blob.data).Hex())
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	w.Header().Set("ETag", etag)
+	if match := r.Header.Get("If-None-Match"); match != "" && etagMatches(match, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.Itoa(len(blob.data)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(blob.data)
+	}
+}
+
+// getBlob returns the blob from the cache, or retrieves it once no matter how
+// many requests for it arrive concurrently. Only verified blobs are cached.
+func (s *Server) getBlob(ctx context.Context, metadata *disperser.BlobRetrieveMetadata) (*retrievedBlob, error) {
+	key := metadata.Hash()
+	if blob, ok := s.cache.Get(key); ok {
+		return blob, nil
+	}
+
+	s.mu.Lock()
+	call, ok := s.inflight[key]
+	if !ok {
+		call = &inflight{done: make(chan struct{})}
+		s.inflight[key] = call
+		go func() {
+			// detached from the request so a client going away doesn't fail the other waiters
+			ctx, cancel := context.WithTimeout(context.Background(), s.config.RequestTimeout)
+			defer cancel()
+			call.blob, call.err = s.retrieve(ctx, metadata)
+			if call.err == nil && call.blob.verified {
+				s.cache.Add(key, call.blob)
+			}
+
+			s.mu.Lock()
+			delete(s.inflight, key)
+			s.mu.Unlock()
+			close(call.done)
+		}()
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.blob, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// retrieve retrieves a blob from the retrievers in order, skipping those whose data doesn't
+// encode to the storage root of the blob if it is verified
+func (s *Server) retrieve(ctx context.Context, metadata *disperser.BlobRetrieveMetadata) (*retrievedBlob, error) {
+	// the storage root of the request is the one the data must encode to
+	certificate := &pb.BlobStatusReply{
+		Status: pb.BlobStatus_CONFIRMED,
+		Info:   &pb.BlobInfo{BlobHeader: &pb.BlobHeader{StorageRoot: metadata.DataRoot, Epoch: metadata.Epoch, QuorumId: metadata.QuorumId}},
+	}
+	var lastErr error
+	for _, addr := range s.config.RetrieverAddrs {
+		data, err := s.fetch(ctx, addr, metadata)
+		if err == nil && s.verifier != nil {
+			err = s.verifier.Verify(ctx, certificate, data)
+		}
+		if err == nil {
+			return &retrievedBlob{data: data, verified: s.verifier != nil}, nil
+		}
+		s.logger.Debug("[gateway] retriever failed", "addr", addr, "err", err)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// retrieveWith returns a fetch of the blobs from a retriever connected with creds
+func retrieveWith(creds credentials.TransportCredentials) func(context.Context, string, *disperser.BlobRetrieveMetadata) ([]byte, error) {
+	return func(ctx context.Context, addr string, metadata *disperser.BlobRetrieveMetadata) ([]byte, error) {
+		return retrieveFrom(ctx, addr, metadata, creds)
+	}
+}
+
+func retrieveFrom(ctx context.Context, addr string, metadata *disperser.BlobRetrieveMetadata, creds credentials.TransportCredentials) ([]byte, error) {
+	conn, err := grpc.DialContext(
+		ctx,
+		addr,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(1024*1024*1024)), // 1 GiB
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial retriever: %w", err)
+	}
+	defer conn.Close()
+
+	reply, err := retriever.NewRetrieverClient(conn).RetrieveBlob(ctx, &retriever.BlobRequest{
+		StorageRoot: metadata.DataRoot,
+		Epoch:       metadata.Epoch,
+		QuorumId:    metadata.QuorumId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reply.GetData(), nil
+}
+
+func parseBlobPath(path string) (*disperser.BlobRetrieveMetadata, error) {
+	parts := strings.Split(strings.TrimPrefix(path, blobPathPrefix), "/")
+	if len(parts) != 3 {
+		return nil, errors.New("expected /blob/{storageRoot}/{epoch}/{quorumId}")
+	}
+	dataRoot, err := hexutil.Decode(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage root: %w", err)
+	}
+	epoch, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid epoch: %w", err)
+	}
+	quorumId, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quorum id: %w", err)
+	}
+	return &disperser.BlobRetrieveMetadata{
+		DataRoot: dataRoot,
+		Epoch:    epoch,
+		QuorumId: quorumId,
+	}, nil
+}
+
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
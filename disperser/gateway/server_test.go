@@ -0,0 +1,108 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/stretchr/testify/assert"
+)
+
+// rootVerifier accepts the data "blob" for the storage root 0x0102
+type rootVerifier struct{}
+
+func (rootVerifier) Verify(ctx context.Context, certificate *pb.BlobStatusReply, data []byte) error {
+	if !bytes.Equal(certificate.GetInfo().GetBlobHeader().GetStorageRoot(), []byte{1, 2}) || string(data) != "blob" {
+		return errors.New("storage root mismatch")
+	}
+	return nil
+}
+
+func newTestServer(t *testing.T, calls *atomic.Int32) *Server {
+	server, err := NewServer(Config{
+		HTTPPort:       "0",
+		RetrieverAddrs: []string{"bad", "good"},
+		CacheSize:      16,
+		RequestTimeout: time.Second,
+	}, mock.NewLogger(false))
+	assert.NoError(t, err)
+	server.fetch = func(ctx context.Context, addr string, metadata *disperser.BlobRetrieveMetadata) ([]byte, error) {
+		calls.Add(1)
+		if addr == "bad" {
+			return nil, errors.New("unavailable")
+		}
+		return []byte("blob"), nil
+	}
+	server.verifier = rootVerifier{}
+	return server
+}
+
+func TestGetBlobCachedWithETag(t *testing.T) {
+	var calls atomic.Int32
+	server := newTestServer(t, &calls)
+
+	req := httptest.NewRequest(http.MethodGet, "/blob/0x0102/7/0", nil)
+	rec := httptest.NewRecorder()
+	server.handleGetBlob(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "blob", rec.Body.String())
+	etag := rec.Header().Get("ETag")
+	assert.Equal(t, `"0x0102"`, etag)
+	assert.Contains(t, rec.Header().Get("Cache-Control"), "immutable")
+	assert.Equal(t, int32(2), calls.Load())
+
+	// served from the cache
+	rec = httptest.NewRecorder()
+	server.handleGetBlob(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, int32(2), calls.Load())
+
+	req = httptest.NewRequest(http.MethodGet, "/blob/0x0102/7/0", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	server.handleGetBlob(rec, req)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.Bytes())
+}
+
+func TestGetBlobBadPath(t *testing.T) {
+	var calls atomic.Int32
+	server := newTestServer(t, &calls)
+
+	rec := httptest.NewRecorder()
+	server.handleGetBlob(rec, httptest.NewRequest(http.MethodGet, "/blob/0x0102/x", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, int32(0), calls.Load())
+}
+
+func TestGetBlobVerified(t *testing.T) {
+	var calls atomic.Int32
+	server := newTestServer(t, &calls)
+
+	// data that doesn't encode to the storage root is never served
+	req := httptest.NewRequest(http.MethodGet, "/blob/0x0103/7/0", nil)
+	req.Header.Set("If-None-Match", `"0x0103"`)
+	rec := httptest.NewRecorder()
+	server.handleGetBlob(rec, req)
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+	assert.Empty(t, rec.Header().Get("ETag"))
+
+	// unverified blobs are neither cached nor immutable
+	server.verifier = nil
+	rec = httptest.NewRecorder()
+	server.handleGetBlob(rec, httptest.NewRequest(http.MethodGet, "/blob/0x0103/7/0", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+	assert.NotEqual(t, `"0x0103"`, rec.Header().Get("ETag"))
+	calls.Store(0)
+	server.handleGetBlob(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/blob/0x0103/7/0", nil))
+	assert.Equal(t, int32(2), calls.Load())
+}
@@ -0,0 +1,141 @@
+package indexer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/urfave/cli"
+)
+
+const (
+	SinksFlagName         = "indexer.sinks"
+	DBPathFlagName        = "indexer.db-path"
+	StartBlockFlagName    = "indexer.start-block"
+	PollIntervalFlagName  = "indexer.poll-interval"
+	MaxBlockRangeFlagName = "indexer.max-block-range"
+	ConfirmationsFlagName = "indexer.confirmations"
+	ReorgDepthFlagName    = "indexer.reorg-depth"
+	ReadyLagFlagName      = "indexer.ready-lag"
+	ReadyTimeoutFlagName  = "indexer.ready-timeout"
+)
+
+const (
+	MemorySinkName = "memory"
+	KVSinkName     = "kv"
+)
+
+type Config struct {
+	// Sinks are the names of the sinks to write to, the indexer is disabled if empty
+	Sinks []string
+	// DBPath is the path of the database of the kv sink
+	DBPath        string
+	StartBlock    uint64
+	PollInterval  time.Duration
+	MaxBlockRange uint64
+	// Confirmations is the number of blocks a block is followed by before it is indexed
+	Confirmations uint64
+	// ReorgDepth is the number of blocks back the indexer checks for reorgs
+	ReorgDepth uint64
+	// ReadyLag is the number of blocks ready to be indexed the indexer may not have indexed
+	// yet to be ready
+	ReadyLag uint64
+	// ReadyTimeout is how long the start waits for the indexer to be ready
+	ReadyTimeout time.Duration
+}
+
+func (c Config) Enabled() bool {
+	return len(c.Sinks) > 0
+}
+
+// NewSinks opens the configured sinks
+func NewSinks(config Config) ([]Sink, error) {
+	sinks := make([]Sink, 0, len(config.Sinks))
+	for _, name := range config.Sinks {
+		switch name {
+		case MemorySinkName:
+			sinks = append(sinks, NewMemorySink())
+		case KVSinkName:
+			if config.DBPath == "" {
+				return nil, fmt.Errorf("%s must be set for the kv indexer sink", DBPathFlagName)
+			}
+			sink, err := NewKVSink(config.DBPath)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		default:
+			return nil, fmt.Errorf("unknown indexer sink %q, must be %s or %s", name, MemorySinkName, KVSinkName)
+		}
+	}
+	return sinks, nil
+}
+
+func CLIFlags(envPrefix string, flagPrefix string) []cli.Flag {
+	return []cli.Flag{
+		cli.StringSliceFlag{
+			Name:   common.PrefixFlag(flagPrefix, SinksFlagName),
+			Usage:  "Sinks the chain event indexer writes to (memory, kv). The indexer is disabled if none",
+			EnvVar: common.PrefixEnvVar(envPrefix, "INDEXER_SINKS"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, DBPathFlagName),
+			Usage:  "Path of the database of the kv indexer sink",
+			EnvVar: common.PrefixEnvVar(envPrefix, "INDEXER_DB_PATH"),
+		},
+		cli.Uint64Flag{
+			Name:   common.PrefixFlag(flagPrefix, StartBlockFlagName),
+			Usage:  "Block the indexer starts from when its sinks are empty",
+			EnvVar: common.PrefixEnvVar(envPrefix, "INDEXER_START_BLOCK"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, PollIntervalFlagName),
+			Usage:  "Interval at which the indexer polls for new blocks",
+			Value:  5 * time.Second,
+			EnvVar: common.PrefixEnvVar(envPrefix, "INDEXER_POLL_INTERVAL"),
+		},
+		cli.Uint64Flag{
+			Name:   common.PrefixFlag(flagPrefix, MaxBlockRangeFlagName),
+			Usage:  "Maximum number of blocks the indexer queries the logs of at once",
+			Value:  1000,
+			EnvVar: common.PrefixEnvVar(envPrefix, "INDEXER_MAX_BLOCK_RANGE"),
+		},
+		cli.Uint64Flag{
+			Name:   common.PrefixFlag(flagPrefix, ConfirmationsFlagName),
+			Usage:  "Number of blocks a block must be followed by before it is indexed",
+			EnvVar: common.PrefixEnvVar(envPrefix, "INDEXER_CONFIRMATIONS"),
+		},
+		cli.Uint64Flag{
+			Name:   common.PrefixFlag(flagPrefix, ReorgDepthFlagName),
+			Usage:  "Number of blocks back the indexer checks for reorgs",
+			Value:  64,
+			EnvVar: common.PrefixEnvVar(envPrefix, "INDEXER_REORG_DEPTH"),
+		},
+		cli.Uint64Flag{
+			Name:   common.PrefixFlag(flagPrefix, ReadyLagFlagName),
+			Usage:  "Number of blocks the indexer may trail the chain by to be ready. The components reading the indexed events start once it is",
+			Value:  10,
+			EnvVar: common.PrefixEnvVar(envPrefix, "INDEXER_READY_LAG"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, ReadyTimeoutFlagName),
+			Usage:  "How long the start waits for the indexer to catch up with the chain",
+			Value:  10 * time.Minute,
+			EnvVar: common.PrefixEnvVar(envPrefix, "INDEXER_READY_TIMEOUT"),
+		},
+	}
+}
+
+func ReadConfig(ctx *cli.Context, flagPrefix string) Config {
+	return Config{
+		Sinks:         ctx.GlobalStringSlice(common.PrefixFlag(flagPrefix, SinksFlagName)),
+		DBPath:        ctx.GlobalString(common.PrefixFlag(flagPrefix, DBPathFlagName)),
+		StartBlock:    ctx.GlobalUint64(common.PrefixFlag(flagPrefix, StartBlockFlagName)),
+		PollInterval:  ctx.GlobalDuration(common.PrefixFlag(flagPrefix, PollIntervalFlagName)),
+		MaxBlockRange: ctx.GlobalUint64(common.PrefixFlag(flagPrefix, MaxBlockRangeFlagName)),
+		Confirmations: ctx.GlobalUint64(common.PrefixFlag(flagPrefix, ConfirmationsFlagName)),
+		ReorgDepth:    ctx.GlobalUint64(common.PrefixFlag(flagPrefix, ReorgDepthFlagName)),
+		ReadyLag:      ctx.GlobalUint64(common.PrefixFlag(flagPrefix, ReadyLagFlagName)),
+		ReadyTimeout:  ctx.GlobalDuration(common.PrefixFlag(flagPrefix, ReadyTimeoutFlagName)),
+	}
+}
@@ -0,0 +1,399 @@
+// Package indexer follows the events of the DA contracts and writes them to pluggable
+// sinks, which answer queries on them through Index, such as the double-dispersal check of
+// IsDispersed. The indexer detects the reorgs itself, from the hashes of the recent blocks
+// it indexed, and rewinds the sinks past them before indexing the new blocks.
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/disperser/contract/da_entrance"
+	"github.com/0glabs/0g-da-client/disperser/contract/da_signers"
+	"github.com/ethereum/go-ethereum"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type EventKind string
+
+const (
+	// DataUpload is emitted when a batch of blobs is dispersed
+	DataUpload EventKind = "DataUpload"
+	// CommitmentVerified is emitted when the aggregate signatures of a blob are accepted,
+	// i.e. the blob is confirmed
+	CommitmentVerified EventKind = "ErasureCommitmentVerified"
+	// NewSigner is emitted when an operator registers
+	NewSigner EventKind = "NewSigner"
+	// SocketUpdated is emitted when an operator changes its socket
+	SocketUpdated EventKind = "SocketUpdated"
+)
+
+// Event is an indexed contract event. DataRoot, Epoch and QuorumId are set on DataUpload
+// and CommitmentVerified events, Signer on NewSigner and SocketUpdated events, and Socket
+// on SocketUpdated events.
+type Event struct {
+	Kind        EventKind
+	BlockNumber uint64
+	BlockHash   eth_common.Hash
+	TxHash      eth_common.Hash
+	LogIndex    uint
+
+	DataRoot [32]byte
+	Epoch    uint64
+	QuorumId uint64
+
+	Signer eth_common.Address
+	Socket string
+}
+
+// Checkpoint is the last block written to a sink
+type Checkpoint struct {
+	Block uint64
+	Hash  eth_common.Hash
+}
+
+// Sink stores indexed events
+type Sink interface {
+	// Write stores the events of the blocks from from to checkpoint.Block, in chain order,
+	// replacing the events already stored for these blocks, and moves the checkpoint. Blocks
+	// are written again after a restart.
+	Write(ctx context.Context, from uint64, events []*Event, checkpoint Checkpoint) error
+	// Rewind removes the events from block on and moves the checkpoint before it
+	Rewind(ctx context.Context, block uint64) error
+	// Checkpoint returns the last block written, the zero Checkpoint if none
+	Checkpoint(ctx context.Context) (Checkpoint, error)
+}
+
+// Index answers queries on the indexed events. Each answer comes with the checkpoint of the
+// events it was read from, read along with them, so that it tells up to which block it holds
+// even while the sink is rewound past a reorg and the new blocks are indexed again.
+type Index interface {
+	// EventsByDataRoot returns the DataUpload and CommitmentVerified events of a data root
+	// in chain order
+	EventsByDataRoot(ctx context.Context, dataRoot [32]byte) ([]*Event, Checkpoint, error)
+	// SignerSocket returns the latest socket of a registered signer
+	SignerSocket(ctx context.Context, signer eth_common.Address) (string, bool, Checkpoint, error)
+}
+
+// IsDispersed returns whether a data root has already been uploaded in the epoch, as of the
+// checkpoint returned
+func IsDispersed(ctx context.Context, index Index, dataRoot [32]byte, epoch uint64) (bool, Checkpoint, error) {
+	events, checkpoint, err := index.EventsByDataRoot(ctx, dataRoot)
+	if err != nil {
+		return false, Checkpoint{}, err
+	}
+	for _, event := range events {
+		if event.Kind == DataUpload && event.Epoch == epoch {
+			return true, checkpoint, nil
+		}
+	}
+	return false, checkpoint, nil
+}
+
+// ChainReader is the part of the eth client used by the indexer
+type ChainReader interface {
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+type Indexer struct {
+	config           Config
+	chain            ChainReader
+	sinks            []Sink
+	entranceAddress  eth_common.Address
+	signersAddress   eth_common.Address
+	entranceFilterer *da_entrance.DAEntranceFilterer
+	signersFilterer  *da_signers.DASignersFilterer
+	topics           map[eth_common.Hash]EventKind
+	logger           common.Logger
+
+	// next is the next block to index
+	next uint64
+	// synced is next, read by the readiness checks while the indexer runs
+	synced atomic.Uint64
+	// checkpoints are the recent blocks indexed, oldest first, to detect reorgs
+	checkpoints []Checkpoint
+}
+
+func NewIndexer(config Config, chain ChainReader, entranceAddress, signersAddress eth_common.Address, sinks []Sink, logger common.Logger) (*Indexer, error) {
+	if len(sinks) == 0 {
+		return nil, fmt.Errorf("indexer needs at least one sink")
+	}
+	if config.MaxBlockRange == 0 {
+		return nil, fmt.Errorf("indexer max block range must be positive")
+	}
+
+	entranceFilterer, err := da_entrance.NewDAEntranceFilterer(entranceAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+	signersFilterer, err := da_signers.NewDASignersFilterer(signersAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+	entranceABI, err := da_entrance.DAEntranceMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	signersABI, err := da_signers.DASignersMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+
+	i := &Indexer{
+		config:           config,
+		chain:            chain,
+		sinks:            sinks,
+		entranceAddress:  entranceAddress,
+		signersAddress:   signersAddress,
+		entranceFilterer: entranceFilterer,
+		signersFilterer:  signersFilterer,
+		topics: map[eth_common.Hash]EventKind{
+			entranceABI.Events["DataUpload"].ID:                DataUpload,
+			entranceABI.Events["ErasureCommitmentVerified"].ID: CommitmentVerified,
+			signersABI.Events["NewSigner"].ID:                  NewSigner,
+			signersABI.Events["SocketUpdated"].ID:              SocketUpdated,
+		},
+		logger: logger,
+	}
+	i.setNext(config.StartBlock)
+	return i, nil
+}
+
+func (i *Indexer) setNext(block uint64) {
+	i.next = block
+	i.synced.Store(block)
+}
+
+// Ready returns nil once at most ReadyLag of the blocks ready to be indexed are not indexed
+// yet, so that the components reading the indexed events start once the indexer caught up
+func (i *Indexer) Ready(ctx context.Context) error {
+	head, err := i.chain.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get head block: %w", err)
+	}
+	if head.Number.Uint64() < i.config.Confirmations {
+		return nil
+	}
+	safe := head.Number.Uint64() - i.config.Confirmations
+	next := i.synced.Load()
+	if next > safe || safe-next+1 <= i.config.ReadyLag {
+		return nil
+	}
+	return fmt.Errorf("indexer %d blocks behind, at block %d of %d", safe-next+1, next, safe)
+}
+
+// Start resumes from the oldest sink checkpoint and indexes new blocks until ctx is done
+func (i *Indexer) Start(ctx context.Context) error {
+	if err := i.resume(ctx); err != nil {
+		return err
+	}
+	i.logger.Info("[indexer] starting", "block", i.next, "sinks", len(i.sinks))
+
+	go func() {
+		ticker := time.NewTicker(i.config.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			for {
+				more, err := i.IndexOnce(ctx)
+				if err != nil {
+					i.logger.Error("[indexer] failed to index blocks", "block", i.next, "err", err)
+				}
+				if err != nil || !more {
+					break
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+func (i *Indexer) resume(ctx context.Context) error {
+	var oldest *Checkpoint
+	for _, sink := range i.sinks {
+		checkpoint, err := sink.Checkpoint(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read indexer checkpoint: %w", err)
+		}
+		if oldest == nil || checkpoint.Block < oldest.Block {
+			oldest = &checkpoint
+		}
+	}
+	if oldest.Block == 0 || oldest.Block < i.config.StartBlock {
+		return nil
+	}
+	// sinks ahead of the oldest one write the same blocks again
+	i.setNext(oldest.Block + 1)
+	i.checkpoints = []Checkpoint{*oldest}
+	return nil
+}
+
+// IndexOnce indexes the next range of blocks, and reports whether more blocks are ready
+func (i *Indexer) IndexOnce(ctx context.Context) (bool, error) {
+	head, err := i.chain.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to get head block: %w", err)
+	}
+	if head.Number.Uint64() < i.config.Confirmations {
+		return false, nil
+	}
+	safe := head.Number.Uint64() - i.config.Confirmations
+
+	if err := i.detectReorg(ctx); err != nil {
+		return false, err
+	}
+	if i.next > safe {
+		return false, nil
+	}
+
+	to := safe
+	if to-i.next >= i.config.MaxBlockRange {
+		to = i.next + i.config.MaxBlockRange - 1
+	}
+	header, err := i.chain.HeaderByNumber(ctx, new(big.Int).SetUint64(to))
+	if err != nil {
+		return false, fmt.Errorf("failed to get block %d: %w", to, err)
+	}
+	topics := make([]eth_common.Hash, 0, len(i.topics))
+	for topic := range i.topics {
+		topics = append(topics, topic)
+	}
+	logs, err := i.chain.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(i.next),
+		ToBlock:   new(big.Int).SetUint64(to),
+		Addresses: []eth_common.Address{i.entranceAddress, i.signersAddress},
+		Topics:    [][]eth_common.Hash{topics},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to get logs of blocks %d-%d: %w", i.next, to, err)
+	}
+
+	events := make([]*Event, 0, len(logs))
+	for _, log := range logs {
+		if log.Removed {
+			continue
+		}
+		event, err := i.parse(log)
+		if err != nil {
+			i.logger.Warn("[indexer] failed to parse log", "txHash", log.TxHash.Hex(), "index", log.Index, "err", err)
+			continue
+		}
+		if event != nil {
+			events = append(events, event)
+		}
+	}
+
+	checkpoint := Checkpoint{Block: to, Hash: header.Hash()}
+	for _, sink := range i.sinks {
+		if err := sink.Write(ctx, i.next, events, checkpoint); err != nil {
+			return false, fmt.Errorf("failed to write events of blocks %d-%d: %w", i.next, to, err)
+		}
+	}
+	i.logger.Debug("[indexer] indexed blocks", "from", i.next, "to", to, "events", len(events))
+
+	i.checkpoints = append(i.checkpoints, checkpoint)
+	for len(i.checkpoints) > 1 && i.checkpoints[0].Block+i.config.ReorgDepth < to {
+		i.checkpoints = i.checkpoints[1:]
+	}
+	i.setNext(to + 1)
+	return to < safe, nil
+}
+
+// detectReorg checks the hash of the last block indexed and rewinds the sinks to the latest
+// checkpoint still on the chain if it changed
+func (i *Indexer) detectReorg(ctx context.Context) error {
+	for j := len(i.checkpoints) - 1; j >= 0; j-- {
+		checkpoint := i.checkpoints[j]
+		header, err := i.chain.HeaderByNumber(ctx, new(big.Int).SetUint64(checkpoint.Block))
+		if err != nil {
+			return fmt.Errorf("failed to get block %d: %w", checkpoint.Block, err)
+		}
+		if header.Hash() == checkpoint.Hash {
+			if j == len(i.checkpoints)-1 {
+				return nil
+			}
+			i.checkpoints = i.checkpoints[:j+1]
+			return i.rewind(ctx, checkpoint.Block+1)
+		}
+	}
+	if len(i.checkpoints) == 0 {
+		return nil
+	}
+
+	// the reorg is deeper than the checkpoints kept
+	from := i.config.StartBlock
+	if oldest := i.checkpoints[0].Block; oldest > from+i.config.ReorgDepth {
+		from = oldest - i.config.ReorgDepth
+	}
+	i.logger.Error("[indexer] reorg deeper than the checkpoints kept", "oldestCheckpoint", i.checkpoints[0].Block, "rewindTo", from)
+	i.checkpoints = nil
+	return i.rewind(ctx, from)
+}
+
+func (i *Indexer) rewind(ctx context.Context, block uint64) error {
+	i.logger.Warn("[indexer] chain reorganized, rewinding", "from", i.next-1, "to", block)
+	for _, sink := range i.sinks {
+		if err := sink.Rewind(ctx, block); err != nil {
+			return fmt.Errorf("failed to rewind sink to block %d: %w", block, err)
+		}
+	}
+	i.setNext(block)
+	return nil
+}
+
+func (i *Indexer) parse(log types.Log) (*Event, error) {
+	if len(log.Topics) == 0 {
+		return nil, nil
+	}
+	kind, ok := i.topics[log.Topics[0]]
+	if !ok {
+		return nil, nil
+	}
+	event := &Event{
+		Kind:        kind,
+		BlockNumber: log.BlockNumber,
+		BlockHash:   log.BlockHash,
+		TxHash:      log.TxHash,
+		LogIndex:    log.Index,
+	}
+
+	switch kind {
+	case DataUpload:
+		upload, err := i.entranceFilterer.ParseDataUpload(log)
+		if err != nil {
+			return nil, err
+		}
+		event.DataRoot, event.Epoch, event.QuorumId = upload.DataRoot, upload.Epoch.Uint64(), upload.QuorumId.Uint64()
+	case CommitmentVerified:
+		verified, err := i.entranceFilterer.ParseErasureCommitmentVerified(log)
+		if err != nil {
+			return nil, err
+		}
+		event.DataRoot, event.Epoch, event.QuorumId = verified.DataRoot, verified.Epoch.Uint64(), verified.QuorumId.Uint64()
+	case NewSigner:
+		signer, err := i.signersFilterer.ParseNewSigner(log)
+		if err != nil {
+			return nil, err
+		}
+		event.Signer = signer.Signer
+	case SocketUpdated:
+		updated, err := i.signersFilterer.ParseSocketUpdated(log)
+		if err != nil {
+			return nil, err
+		}
+		event.Signer, event.Socket = updated.Signer, updated.Socket
+	}
+	return event, nil
+}
@@ -0,0 +1,148 @@
+package indexer_test
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/disperser/contract/da_entrance"
+	"github.com/0glabs/0g-da-client/disperser/indexer"
+	"github.com/ethereum/go-ethereum"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	entranceAddress = eth_common.HexToAddress("0x01")
+	signersAddress  = eth_common.HexToAddress("0x02")
+)
+
+// chain is a fake chain whose blocks are identified by their number and fork
+type chain struct {
+	mu   sync.Mutex
+	head uint64
+	fork map[uint64]int64
+	logs []types.Log
+}
+
+func (c *chain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := c.head
+	if number != nil {
+		n = number.Uint64()
+	}
+	return &types.Header{Number: new(big.Int).SetUint64(n), Time: uint64(c.fork[n])}, nil
+}
+
+func (c *chain) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	logs := make([]types.Log, 0)
+	for _, log := range c.logs {
+		if log.BlockNumber >= q.FromBlock.Uint64() && log.BlockNumber <= q.ToBlock.Uint64() {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func uploadLog(t *testing.T, block uint64, dataRoot [32]byte, epoch int64) types.Log {
+	entranceABI, err := da_entrance.DAEntranceMetaData.GetAbi()
+	require.NoError(t, err)
+	event := entranceABI.Events["DataUpload"]
+	data, err := event.Inputs.NonIndexed().Pack(dataRoot, big.NewInt(epoch), big.NewInt(0))
+	require.NoError(t, err)
+	return types.Log{Address: entranceAddress, Topics: []eth_common.Hash{event.ID}, Data: data, BlockNumber: block}
+}
+
+func TestIndexer(t *testing.T) {
+	ctx := context.Background()
+	kv, err := indexer.NewKVSink(t.TempDir())
+	require.NoError(t, err)
+	defer kv.Close()
+	sinks := []indexer.Sink{indexer.NewMemorySink(), kv}
+
+	rootA, rootB := [32]byte{0xa}, [32]byte{0xb}
+	c := &chain{head: 30, fork: map[uint64]int64{}}
+	c.logs = []types.Log{uploadLog(t, 5, rootA, 1), uploadLog(t, 25, rootB, 1)}
+
+	config := indexer.Config{StartBlock: 1, MaxBlockRange: 10, Confirmations: 2, ReorgDepth: 64}
+	ix, err := indexer.NewIndexer(config, c, entranceAddress, signersAddress, sinks, mock.NewLogger(false))
+	require.NoError(t, err)
+
+	more := true
+	for more {
+		more, err = ix.IndexOnce(ctx)
+		require.NoError(t, err)
+	}
+	for _, sink := range sinks {
+		checkpoint, err := sink.Checkpoint(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(28), checkpoint.Block)
+
+		dispersed, at, err := indexer.IsDispersed(ctx, sink.(indexer.Index), rootB, 1)
+		require.NoError(t, err)
+		assert.True(t, dispersed)
+		assert.Equal(t, checkpoint, at)
+	}
+
+	// block 25 is reorganized and no longer contains the upload of rootB
+	c.mu.Lock()
+	for n := uint64(25); n <= 30; n++ {
+		c.fork[n] = 1
+	}
+	c.logs = c.logs[:1]
+	c.mu.Unlock()
+
+	_, err = ix.IndexOnce(ctx)
+	require.NoError(t, err)
+	head, err := c.HeaderByNumber(ctx, big.NewInt(28))
+	require.NoError(t, err)
+	for _, sink := range sinks {
+		dispersed, at, err := indexer.IsDispersed(ctx, sink.(indexer.Index), rootB, 1)
+		require.NoError(t, err)
+		assert.False(t, dispersed)
+		// the answer is read along with the checkpoint of the new fork
+		assert.Equal(t, indexer.Checkpoint{Block: 28, Hash: head.Hash()}, at)
+		dispersed, _, err = indexer.IsDispersed(ctx, sink.(indexer.Index), rootA, 1)
+		require.NoError(t, err)
+		assert.True(t, dispersed)
+
+		// while rewound, the answers hold up to the block before the rewind
+		require.NoError(t, sink.Rewind(ctx, 5))
+		dispersed, at, err = indexer.IsDispersed(ctx, sink.(indexer.Index), rootA, 1)
+		require.NoError(t, err)
+		assert.False(t, dispersed)
+		assert.Equal(t, uint64(4), at.Block)
+	}
+}
+
+func TestIndexerReady(t *testing.T) {
+	ctx := context.Background()
+	c := &chain{head: 30, fork: map[uint64]int64{}}
+	config := indexer.Config{StartBlock: 1, MaxBlockRange: 10, Confirmations: 2, ReorgDepth: 64, ReadyLag: 5}
+	ix, err := indexer.NewIndexer(config, c, entranceAddress, signersAddress, []indexer.Sink{indexer.NewMemorySink()}, mock.NewLogger(false))
+	require.NoError(t, err)
+
+	// blocks 1-28 are ready to be indexed
+	assert.ErrorContains(t, ix.Ready(ctx), "28 blocks behind")
+	_, err = ix.IndexOnce(ctx)
+	require.NoError(t, err)
+	_, err = ix.IndexOnce(ctx)
+	require.NoError(t, err)
+	assert.ErrorContains(t, ix.Ready(ctx), "8 blocks behind")
+	_, err = ix.IndexOnce(ctx)
+	require.NoError(t, err)
+	assert.NoError(t, ix.Ready(ctx))
+
+	// the chain moves on within the lag
+	c.mu.Lock()
+	c.head = 35
+	c.mu.Unlock()
+	assert.NoError(t, ix.Ready(ctx))
+}
@@ -0,0 +1,198 @@
+package indexer
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+
+	zg_leveldb "github.com/0glabs/0g-da-client/disperser/leveldb"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	checkpointKey = "_IDX_CHECKPOINT_"
+	// events by block and log index
+	eventPrefix = "_IDX_EVENT_"
+	// DataUpload and CommitmentVerified events by data root
+	dataRootPrefix = "_IDX_ROOT_"
+	// NewSigner and SocketUpdated events by signer
+	signerPrefix = "_IDX_SIGNER_"
+)
+
+// KVSink stores the indexed events in a leveldb database, with secondary keys by data root
+// and signer
+type KVSink struct {
+	db *zg_leveldb.LevelDBStore
+}
+
+var _ Sink = (*KVSink)(nil)
+var _ Index = (*KVSink)(nil)
+
+func NewKVSink(path string) (*KVSink, error) {
+	db, err := zg_leveldb.NewLevelDBStore(path)
+	if err != nil {
+		return nil, err
+	}
+	return &KVSink{db: db}, nil
+}
+
+func eventKey(block uint64, logIndex uint) []byte {
+	key := make([]byte, 0, len(eventPrefix)+16)
+	key = append(key, eventPrefix...)
+	key = binary.BigEndian.AppendUint64(key, block)
+	return binary.BigEndian.AppendUint64(key, uint64(logIndex))
+}
+
+// secondaryKey returns the key of the event by data root or signer, nil if none
+func secondaryKey(event *Event) []byte {
+	var key []byte
+	switch event.Kind {
+	case DataUpload, CommitmentVerified:
+		key = append([]byte(dataRootPrefix), event.DataRoot[:]...)
+	case NewSigner, SocketUpdated:
+		key = append([]byte(signerPrefix), event.Signer[:]...)
+	default:
+		return nil
+	}
+	return append(key, eventKey(event.BlockNumber, event.LogIndex)[len(eventPrefix):]...)
+}
+
+func (s *KVSink) Write(ctx context.Context, from uint64, events []*Event, checkpoint Checkpoint) error {
+	batch := new(leveldb.Batch)
+	if err := s.truncate(batch, from); err != nil {
+		return err
+	}
+	for _, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		batch.Put(eventKey(event.BlockNumber, event.LogIndex), value)
+		if key := secondaryKey(event); key != nil {
+			batch.Put(key, value)
+		}
+	}
+	batch.Put([]byte(checkpointKey), encodeCheckpoint(checkpoint))
+	return s.db.DB.Write(batch, &opt.WriteOptions{Sync: true})
+}
+
+func (s *KVSink) Rewind(ctx context.Context, block uint64) error {
+	batch := new(leveldb.Batch)
+	if err := s.truncate(batch, block); err != nil {
+		return err
+	}
+	checkpoint, err := s.Checkpoint(ctx)
+	if err != nil {
+		return err
+	}
+	if block == 0 {
+		batch.Delete([]byte(checkpointKey))
+	} else if checkpoint.Block >= block {
+		batch.Put([]byte(checkpointKey), encodeCheckpoint(Checkpoint{Block: block - 1}))
+	}
+	return s.db.DB.Write(batch, &opt.WriteOptions{Sync: true})
+}
+
+// truncate adds the deletion of the events from block on to the batch
+func (s *KVSink) truncate(batch *leveldb.Batch, block uint64) error {
+	iter := s.db.DB.NewIterator(&util.Range{
+		Start: eventKey(block, 0),
+		Limit: util.BytesPrefix([]byte(eventPrefix)).Limit,
+	}, nil)
+	defer iter.Release()
+	for iter.Next() {
+		var event Event
+		if err := json.Unmarshal(iter.Value(), &event); err != nil {
+			return err
+		}
+		batch.Delete(append([]byte(nil), iter.Key()...))
+		if key := secondaryKey(&event); key != nil {
+			batch.Delete(key)
+		}
+	}
+	return iter.Error()
+}
+
+func (s *KVSink) Checkpoint(ctx context.Context) (Checkpoint, error) {
+	snapshot, err := s.db.DB.GetSnapshot()
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	defer snapshot.Release()
+	return readCheckpoint(snapshot)
+}
+
+// readCheckpoint reads the checkpoint of a snapshot
+func readCheckpoint(snapshot *leveldb.Snapshot) (Checkpoint, error) {
+	value, err := snapshot.Get([]byte(checkpointKey), nil)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return Checkpoint{}, nil
+	}
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	if len(value) != 8+eth_common.HashLength {
+		return Checkpoint{}, errors.New("invalid indexer checkpoint")
+	}
+	return Checkpoint{
+		Block: binary.BigEndian.Uint64(value[:8]),
+		Hash:  eth_common.BytesToHash(value[8:]),
+	}, nil
+}
+
+func encodeCheckpoint(checkpoint Checkpoint) []byte {
+	return append(binary.BigEndian.AppendUint64(nil, checkpoint.Block), checkpoint.Hash[:]...)
+}
+
+func (s *KVSink) EventsByDataRoot(ctx context.Context, dataRoot [32]byte) ([]*Event, Checkpoint, error) {
+	return s.events(append([]byte(dataRootPrefix), dataRoot[:]...))
+}
+
+func (s *KVSink) SignerSocket(ctx context.Context, signer eth_common.Address) (string, bool, Checkpoint, error) {
+	events, checkpoint, err := s.events(append([]byte(signerPrefix), signer[:]...))
+	if err != nil {
+		return "", false, Checkpoint{}, err
+	}
+	socket := ""
+	for _, event := range events {
+		if event.Kind == SocketUpdated {
+			socket = event.Socket
+		}
+	}
+	return socket, len(events) > 0, checkpoint, nil
+}
+
+// events returns the events under a secondary key prefix with the checkpoint, both read from
+// one snapshot, since a write or a rewind may land in between otherwise
+func (s *KVSink) events(prefix []byte) ([]*Event, Checkpoint, error) {
+	snapshot, err := s.db.DB.GetSnapshot()
+	if err != nil {
+		return nil, Checkpoint{}, err
+	}
+	defer snapshot.Release()
+	checkpoint, err := readCheckpoint(snapshot)
+	if err != nil {
+		return nil, Checkpoint{}, err
+	}
+
+	iter := snapshot.NewIterator(util.BytesPrefix(prefix), nil)
+	defer iter.Release()
+	events := make([]*Event, 0)
+	for iter.Next() {
+		event := new(Event)
+		if err := json.Unmarshal(iter.Value(), event); err != nil {
+			return nil, Checkpoint{}, err
+		}
+		events = append(events, event)
+	}
+	return events, checkpoint, iter.Error()
+}
+
+// Close closes the database
+func (s *KVSink) Close() error {
+	return s.db.Close()
+}
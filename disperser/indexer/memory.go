@@ -0,0 +1,91 @@
+package indexer
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	eth_common "github.com/ethereum/go-ethereum/common"
+)
+
+// MemorySink keeps the indexed events in memory, for tests and short-lived processes. The
+// events are indexed again from the start block after a restart.
+type MemorySink struct {
+	mu         sync.RWMutex
+	events     []*Event
+	checkpoint Checkpoint
+}
+
+var _ Sink = (*MemorySink)(nil)
+var _ Index = (*MemorySink)(nil)
+
+func NewMemorySink() *MemorySink {
+	return &MemorySink{}
+}
+
+func (s *MemorySink) Write(ctx context.Context, from uint64, events []*Event, checkpoint Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// blocks are written in order, so the ones written again are the latest ones
+	s.truncate(from)
+	s.events = append(s.events, events...)
+	s.checkpoint = checkpoint
+	return nil
+}
+
+func (s *MemorySink) Rewind(ctx context.Context, block uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.truncate(block)
+	if block == 0 {
+		s.checkpoint = Checkpoint{}
+	} else if s.checkpoint.Block >= block {
+		s.checkpoint = Checkpoint{Block: block - 1}
+	}
+	return nil
+}
+
+// truncate removes the events from block on. The caller must hold mu.
+func (s *MemorySink) truncate(block uint64) {
+	n := sort.Search(len(s.events), func(i int) bool {
+		return s.events[i].BlockNumber >= block
+	})
+	s.events = s.events[:n]
+}
+
+func (s *MemorySink) Checkpoint(ctx context.Context) (Checkpoint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.checkpoint, nil
+}
+
+func (s *MemorySink) EventsByDataRoot(ctx context.Context, dataRoot [32]byte) ([]*Event, Checkpoint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	events := make([]*Event, 0)
+	for _, event := range s.events {
+		if (event.Kind == DataUpload || event.Kind == CommitmentVerified) && event.DataRoot == dataRoot {
+			events = append(events, event)
+		}
+	}
+	return events, s.checkpoint, nil
+}
+
+func (s *MemorySink) SignerSocket(ctx context.Context, signer eth_common.Address) (string, bool, Checkpoint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	socket, found := "", false
+	for _, event := range s.events {
+		if event.Signer != signer {
+			continue
+		}
+		switch event.Kind {
+		case NewSigner:
+			found = true
+		case SocketUpdated:
+			socket, found = event.Socket, true
+		}
+	}
+	return socket, found, s.checkpoint, nil
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
 	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
@@ -58,3 +59,13 @@ func (d *LevelDBStore) WriteBatch(keys, values [][]byte) error {
 	}
 	return d.DB.Write(batch, nil)
 }
+
+// SyncWriteBatch writes the batch and syncs it to disk before returning, so that the
+// writes survive a crash of the process or the host
+func (d *LevelDBStore) SyncWriteBatch(keys, values [][]byte) error {
+	batch := new(leveldb.Batch)
+	for i, key := range keys {
+		batch.Put(key, values[i])
+	}
+	return d.DB.Write(batch, &opt.WriteOptions{Sync: true})
+}
@@ -23,6 +23,10 @@ type Metrics struct {
 	NumBlobRequests *prometheus.CounterVec
 	BlobSize        *prometheus.GaugeVec
 	Latency         *prometheus.SummaryVec
+	// ThrottledRetrievals and RetrievalQueueWait track the retrievals limited by the
+	// retrieval quotas of their account
+	ThrottledRetrievals *prometheus.CounterVec
+	RetrievalQueueWait  *prometheus.HistogramVec
 
 	httpPort string
 	logger   common.Logger
@@ -60,6 +64,23 @@ func NewMetrics(httpPort string, logger common.Logger) *Metrics {
 			},
 			[]string{"method"},
 		),
+		ThrottledRetrievals: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "throttled_retrievals_total",
+				Help:      "the number of retrievals rejected by the retrieval quotas, by the exceeded quota",
+			},
+			[]string{"reason", "method"},
+		),
+		RetrievalQueueWait: promauto.With(reg).NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "retrieval_queue_wait_seconds",
+				Help:      "how long the retrievals waited for a concurrent retrieval of their account to finish",
+				Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+			},
+			[]string{"method"},
+		),
 		registry: reg,
 		httpPort: httpPort,
 		logger:   logger,
@@ -67,6 +88,10 @@ func NewMetrics(httpPort string, logger common.Logger) *Metrics {
 	return metrics
 }
 
+func (g *Metrics) Registry() *prometheus.Registry {
+	return g.registry
+}
+
 // ObserveLatency observes the latency of a stage in 'stage
 func (g *Metrics) ObserveLatency(method string, latencyMs float64) {
 	g.Latency.WithLabelValues(method).Observe(latencyMs)
@@ -106,6 +131,19 @@ func (g *Metrics) HandleFailedRequest(blobBytes int, method string) {
 	}).Add(float64(blobBytes))
 }
 
+// HandleDuplicateRequest updates the number of requests answered with an earlier dispersal
+// of the same blob and the size of the blob
+func (g *Metrics) HandleDuplicateRequest(blobBytes int, method string) {
+	g.NumBlobRequests.With(prometheus.Labels{
+		"status": "duplicate",
+		"method": method,
+	}).Inc()
+	g.BlobSize.With(prometheus.Labels{
+		"status": "duplicate",
+		"method": method,
+	}).Add(float64(blobBytes))
+}
+
 // HandleSystemRateLimitedRequest updates the number of system rate limited requests and the size of the blob
 func (g *Metrics) HandleSystemRateLimitedRequest(blobBytes int, method string) {
 	g.NumBlobRequests.With(prometheus.Labels{
@@ -130,6 +168,37 @@ func (g *Metrics) HandleAccountRateLimitedRequest(blobBytes int, method string)
 	}).Add(float64(blobBytes))
 }
 
+// HandleInsufficientCreditRequest updates the number of requests rejected for the credit of
+// their account and the size of the blob
+func (g *Metrics) HandleInsufficientCreditRequest(blobBytes int, method string) {
+	g.NumBlobRequests.With(prometheus.Labels{
+		"status": "insufficient-credit",
+		"method": method,
+	}).Inc()
+	g.BlobSize.With(prometheus.Labels{
+		"status": "insufficient-credit",
+		"method": method,
+	}).Add(float64(blobBytes))
+}
+
+// HandleThrottledRetrieval counts a retrieval rejected over the retrieval quota named by reason
+func (g *Metrics) HandleThrottledRetrieval(reason string, method string) {
+	g.ThrottledRetrievals.With(prometheus.Labels{
+		"reason": reason,
+		"method": method,
+	}).Inc()
+	g.NumBlobRequests.With(prometheus.Labels{
+		"status": "ratelimited-account",
+		"method": method,
+	}).Inc()
+}
+
+// ObserveRetrievalQueueWait records how long a retrieval waited for a concurrent retrieval
+// of its account to finish
+func (g *Metrics) ObserveRetrievalQueueWait(method string, seconds float64) {
+	g.RetrievalQueueWait.WithLabelValues(method).Observe(seconds)
+}
+
 // Start starts the metrics server
 func (g *Metrics) Start(ctx context.Context) {
 	g.logger.Info("Starting metrics server at ", "port", g.httpPort)
@@ -0,0 +1,99 @@
+package disperser
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// NonceWindowSize is how many nonces below the highest one of a signer may still be used, so
+// that dispersals signed concurrently are accepted in whatever order they reach the store
+const NonceWindowSize = 256
+
+const nonceWindowWords = NonceWindowSize / 64
+
+// NonceWindow is the sliding window of the nonces used by the signed dispersals of a signer.
+// It tracks the highest nonce used and which of the NonceWindowSize nonces up to it were
+// used: a nonce is accepted once if it is above the highest one or within the window, and
+// rejected if it is below the window. The zero value is the window of a signer which never
+// dispersed.
+type NonceWindow struct {
+	Highest uint64
+	// used has bit i%64 of word i/64 set if nonce Highest-i was used
+	used [nonceWindowWords]uint64
+}
+
+// ClosedNonceWindow returns the window of a signer whose nonces up to highest are all used,
+// as recorded by the stores keeping the last nonce only
+func ClosedNonceWindow(highest uint64) NonceWindow {
+	w := NonceWindow{Highest: highest}
+	for i := range w.used {
+		w.used[i] = ^uint64(0)
+	}
+	return w
+}
+
+// NonceWindowFromBytes returns the window of highest whose used nonces are encoded in used,
+// as returned by Bytes
+func NonceWindowFromBytes(highest uint64, used []byte) (NonceWindow, error) {
+	if len(used) != 8*nonceWindowWords {
+		return NonceWindow{}, fmt.Errorf("nonce window of %d bytes, expected %d", len(used), 8*nonceWindowWords)
+	}
+	w := NonceWindow{Highest: highest}
+	for i := range w.used {
+		w.used[i] = binary.BigEndian.Uint64(used[8*i:])
+	}
+	return w, nil
+}
+
+// Bytes encodes the used nonces of the window, its highest nonce apart
+func (w NonceWindow) Bytes() []byte {
+	used := make([]byte, 0, 8*nonceWindowWords)
+	for _, word := range w.used {
+		used = binary.BigEndian.AppendUint64(used, word)
+	}
+	return used
+}
+
+func (w NonceWindow) empty() bool {
+	return w == NonceWindow{}
+}
+
+func (w NonceWindow) isUsed(offset uint64) bool {
+	return w.used[offset/64]&(1<<(offset%64)) != 0
+}
+
+func (w *NonceWindow) setUsed(offset uint64) {
+	w.used[offset/64] |= 1 << (offset % 64)
+}
+
+// Use returns the window with nonce used, and false if nonce was already used or is below
+// the window
+func (w NonceWindow) Use(nonce uint64) (NonceWindow, bool) {
+	if w.empty() {
+		w.Highest = nonce
+		w.setUsed(0)
+		return w, true
+	}
+	if nonce > w.Highest {
+		shift := nonce - w.Highest
+		var used [nonceWindowWords]uint64
+		if shift < NonceWindowSize {
+			words, bits := shift/64, shift%64
+			for i := nonceWindowWords - 1; i >= int(words); i-- {
+				used[i] = w.used[i-int(words)] << bits
+				if bits > 0 && i-int(words)-1 >= 0 {
+					used[i] |= w.used[i-int(words)-1] >> (64 - bits)
+				}
+			}
+		}
+		w.Highest, w.used = nonce, used
+		w.setUsed(0)
+		return w, true
+	}
+	offset := w.Highest - nonce
+	if offset >= NonceWindowSize || w.isUsed(offset) {
+		return w, false
+	}
+	w.setUsed(offset)
+	return w, true
+}
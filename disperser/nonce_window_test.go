@@ -0,0 +1,65 @@
+package disperser_test
+
+import (
+	"testing"
+
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNonceWindow(t *testing.T) {
+	use := func(w disperser.NonceWindow, nonce uint64, accepted bool) disperser.NonceWindow {
+		t.Helper()
+		next, ok := w.Use(nonce)
+		require.Equal(t, accepted, ok, "nonce %d", nonce)
+		if !ok {
+			assert.Equal(t, w, next)
+		}
+		return next
+	}
+
+	var w disperser.NonceWindow
+	w = use(w, 1000, true)
+	w = use(w, 1000, false)
+
+	// concurrent dispersals land in any order within the window
+	w = use(w, 1003, true)
+	w = use(w, 1001, true)
+	w = use(w, 1002, true)
+	w = use(w, 1001, false)
+	w = use(w, 1003, false)
+	w = use(w, 1003-disperser.NonceWindowSize+1, true)
+	w = use(w, 1003-disperser.NonceWindowSize, false)
+
+	// the used nonces move along with the highest one, across words
+	w = use(w, 1070, true)
+	w = use(w, 1002, false)
+	w = use(w, 1004, true)
+	w = use(w, 1200, true)
+	w = use(w, 1070, false)
+	w = use(w, 1004, false)
+	w = use(w, 1005, true)
+
+	// they survive the encoding
+	decoded, err := disperser.NonceWindowFromBytes(w.Highest, w.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, w, decoded)
+	_, err = disperser.NonceWindowFromBytes(w.Highest, w.Bytes()[1:])
+	assert.Error(t, err)
+
+	// a jump past the window forgets every used nonce
+	w = use(w, 1200+disperser.NonceWindowSize, true)
+	w = use(w, 1201, true)
+	w = use(w, 1200, false)
+
+	// the first nonce of a signer may be 0
+	w = use(disperser.NonceWindow{}, 0, true)
+	use(w, 0, false)
+
+	// a closed window accepts the nonces above its highest one only
+	w = disperser.ClosedNonceWindow(50)
+	use(w, 50, false)
+	use(w, 49, false)
+	use(w, 51, true)
+}
@@ -0,0 +1,168 @@
+package payments
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/aws"
+	commondynamodb "github.com/0glabs/0g-da-client/common/aws/dynamodb"
+	"github.com/0glabs/0g-da-client/disperser/contract"
+	"github.com/urfave/cli"
+)
+
+const (
+	LedgerFlagName         = "payments.ledger"
+	LedgerFileFlagName     = "payments.ledger-file"
+	ContractFlagName       = "payments.contract"
+	RPCFlagName            = "payments.rpc"
+	PrivateKeyFlagName     = "payments.private-key"
+	FeePerByteFlagName     = "payments.fee-per-byte"
+	CreditTTLFlagName      = "payments.credit-ttl"
+	SettleIntervalFlagName = "payments.settle-interval"
+	UsageTableFlagName     = "payments.usage-table"
+	UsagePathFlagName      = "payments.usage-path"
+)
+
+const (
+	FileLedgerName     = "file"
+	ContractLedgerName = "contract"
+)
+
+type Config struct {
+	// Ledger is the name of the ledger holding the prepaid credit of the accounts, metering
+	// is disabled if empty
+	Ledger string
+	// LedgerFile is the path of the json file of the file ledger
+	LedgerFile string
+	// ContractAddress, RPC and PrivateKey are the credit contract of the contract ledger,
+	// its chain and the key of the account settling the usage on it
+	ContractAddress string
+	RPC             string
+	PrivateKey      string
+	// Fees sets the fees of the settle transactions of the contract ledger, and their
+	// replacements while they stay pending
+	Fees contract.FeeConfig
+	// FeePerByte is charged for the dispersals not made under a price quote
+	FeePerByte *big.Int
+	// CreditTTL is how long the credit read from the ledger is used before it is read again
+	CreditTTL      time.Duration
+	SettleInterval time.Duration
+	// UsageTable is the DynamoDB table of the usage not settled yet, shared by the replicas
+	// of the disperser. The usage is kept in the leveldb database at UsagePath if empty,
+	// which only a single disperser may charge against the ledger with.
+	UsageTable string
+	UsagePath  string
+}
+
+func (c Config) Enabled() bool {
+	return c.Ledger != ""
+}
+
+// NewLedger opens the configured ledger
+func NewLedger(config Config, logger common.Logger) (Ledger, error) {
+	switch config.Ledger {
+	case FileLedgerName:
+		if config.LedgerFile == "" {
+			return nil, fmt.Errorf("%s must be set for the file ledger", LedgerFileFlagName)
+		}
+		return NewFileLedger(config.LedgerFile)
+	case ContractLedgerName:
+		if config.ContractAddress == "" || config.RPC == "" || config.PrivateKey == "" {
+			return nil, fmt.Errorf("%s, %s and %s must be set for the contract ledger", ContractFlagName, RPCFlagName, PrivateKeyFlagName)
+		}
+		return NewContractLedger(config.RPC, config.ContractAddress, config.PrivateKey, config.Fees, logger)
+	}
+	return nil, fmt.Errorf("unknown payments ledger %q, must be %s or %s", config.Ledger, FileLedgerName, ContractLedgerName)
+}
+
+// NewUsageStore opens the configured store of the usage not settled yet
+func NewUsageStore(config Config, awsConfig aws.ClientConfig, logger common.Logger) (UsageStore, error) {
+	if config.UsageTable != "" {
+		client, err := commondynamodb.NewClient(awsConfig, logger)
+		if err != nil {
+			return nil, err
+		}
+		return NewDynamoUsageStore(client, config.UsageTable), nil
+	}
+	return NewLevelDBUsageStore(config.UsagePath)
+}
+
+func CLIFlags(envPrefix string, flagPrefix string) []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, LedgerFlagName),
+			Usage:  "Ledger holding the prepaid credit dispersals are charged to (file, contract). Dispersals aren't charged if empty",
+			EnvVar: common.PrefixEnvVar(envPrefix, "PAYMENTS_LEDGER"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, LedgerFileFlagName),
+			Usage:  "Path of the json file of the file ledger",
+			EnvVar: common.PrefixEnvVar(envPrefix, "PAYMENTS_LEDGER_FILE"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, ContractFlagName),
+			Usage:  "Address of the credit contract of the contract ledger",
+			EnvVar: common.PrefixEnvVar(envPrefix, "PAYMENTS_CONTRACT"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, RPCFlagName),
+			Usage:  "Chain rpc of the credit contract",
+			EnvVar: common.PrefixEnvVar(envPrefix, "PAYMENTS_RPC"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, PrivateKeyFlagName),
+			Usage:  "Hex private key of the account settling the usage on the credit contract",
+			EnvVar: common.PrefixEnvVar(envPrefix, "PAYMENTS_PRIVATE_KEY"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, FeePerByteFlagName),
+			Usage:  "Fee per byte in wei charged for the dispersals not made under a price quote",
+			Value:  "1",
+			EnvVar: common.PrefixEnvVar(envPrefix, "PAYMENTS_FEE_PER_BYTE"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, CreditTTLFlagName),
+			Usage:  "How long the credit of an account is cached before it is read from the ledger again",
+			Value:  time.Minute,
+			EnvVar: common.PrefixEnvVar(envPrefix, "PAYMENTS_CREDIT_TTL"),
+		},
+		cli.DurationFlag{
+			Name:   common.PrefixFlag(flagPrefix, SettleIntervalFlagName),
+			Usage:  "Interval at which the usage of the accounts is settled on the ledger",
+			Value:  10 * time.Minute,
+			EnvVar: common.PrefixEnvVar(envPrefix, "PAYMENTS_SETTLE_INTERVAL"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, UsageTableFlagName),
+			Usage:  "DynamoDB table of the usage not settled yet, required when several dispersers charge against the same ledger",
+			EnvVar: common.PrefixEnvVar(envPrefix, "PAYMENTS_USAGE_TABLE"),
+		},
+		cli.StringFlag{
+			Name:   common.PrefixFlag(flagPrefix, UsagePathFlagName),
+			Usage:  "Path of the leveldb database of the usage not settled yet, used if no usage table is set",
+			Value:  "payments-usage",
+			EnvVar: common.PrefixEnvVar(envPrefix, "PAYMENTS_USAGE_PATH"),
+		},
+	}
+}
+
+func ReadConfig(ctx *cli.Context, flagPrefix string) (Config, error) {
+	fee, ok := new(big.Int).SetString(ctx.GlobalString(common.PrefixFlag(flagPrefix, FeePerByteFlagName)), 10)
+	if !ok || fee.Sign() < 0 {
+		return Config{}, fmt.Errorf("invalid %s", FeePerByteFlagName)
+	}
+	return Config{
+		Ledger:          ctx.GlobalString(common.PrefixFlag(flagPrefix, LedgerFlagName)),
+		LedgerFile:      ctx.GlobalString(common.PrefixFlag(flagPrefix, LedgerFileFlagName)),
+		ContractAddress: ctx.GlobalString(common.PrefixFlag(flagPrefix, ContractFlagName)),
+		RPC:             ctx.GlobalString(common.PrefixFlag(flagPrefix, RPCFlagName)),
+		PrivateKey:      ctx.GlobalString(common.PrefixFlag(flagPrefix, PrivateKeyFlagName)),
+		FeePerByte:      fee,
+		CreditTTL:       ctx.GlobalDuration(common.PrefixFlag(flagPrefix, CreditTTLFlagName)),
+		SettleInterval:  ctx.GlobalDuration(common.PrefixFlag(flagPrefix, SettleIntervalFlagName)),
+		UsageTable:      ctx.GlobalString(common.PrefixFlag(flagPrefix, UsageTableFlagName)),
+		UsagePath:       ctx.GlobalString(common.PrefixFlag(flagPrefix, UsagePathFlagName)),
+	}, nil
+}
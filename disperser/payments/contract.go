@@ -0,0 +1,259 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/disperser/contract"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// creditABI is the part of the credit contract the contract ledger calls. Accounts
+// deposit their credit to the contract, and the disperser settles their usage in batches.
+const creditABI = `[
+{"type":"function","name":"credit","stateMutability":"view","inputs":[{"name":"account","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+{"type":"function","name":"settle","stateMutability":"nonpayable","inputs":[{"name":"accounts","type":"address[]"},{"name":"amounts","type":"uint256[]"}],"outputs":[]}]`
+
+// ContractLedger reads the credit of the accounts from a credit contract and settles their
+// usage on it. Only the accounts of signed dispersals, which are addresses, have credit. The
+// fees of the settle transactions are set by a fee manager, which replaces those left pending.
+type ContractLedger struct {
+	client   *ethclient.Client
+	contract *bind.BoundContract
+	opts     *bind.TransactOpts
+	fees     *contract.FeeManager
+	logger   common.Logger
+}
+
+func NewContractLedger(rpcURL string, address string, privateKey string, fees contract.FeeConfig, logger common.Logger) (*ContractLedger, error) {
+	if !eth_common.IsHexAddress(address) {
+		return nil, fmt.Errorf("invalid credit contract address %q", address)
+	}
+	parsed, err := abi.JSON(strings.NewReader(creditABI))
+	if err != nil {
+		return nil, err
+	}
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKey, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid payments private key: %w", err)
+	}
+	client, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		return nil, err
+	}
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the chain id of the credit contract: %w", err)
+	}
+	opts, err := bind.NewKeyedTransactorWithChainID(key, chainID)
+	if err != nil {
+		return nil, err
+	}
+	feeManager, err := contract.NewFeeManager(fees, client, opts.Signer, opts.From, logger)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fees of the settle transactions: %w", err)
+	}
+	return &ContractLedger{
+		client:   client,
+		contract: bind.NewBoundContract(eth_common.HexToAddress(address), parsed, client, client, client),
+		opts:     opts,
+		fees:     feeManager,
+		logger:   logger,
+	}, nil
+}
+
+func (l *ContractLedger) Credit(ctx context.Context, account string) (*big.Int, error) {
+	if !eth_common.IsHexAddress(account) {
+		return new(big.Int), nil
+	}
+	var out []interface{}
+	if err := l.contract.Call(&bind.CallOpts{Context: ctx}, &out, "credit", eth_common.HexToAddress(account)); err != nil {
+		return nil, fmt.Errorf("failed to read the credit of %s: %w", account, err)
+	}
+	return *abi.ConvertType(out[0], new(*big.Int)).(**big.Int), nil
+}
+
+// settleRef is the reference of a settle transaction: the transactions sent with its nonce,
+// any of which may be included
+type settleRef struct {
+	// Hashes are the hashes of the settle transaction and its replacements, the latest last
+	Hashes []eth_common.Hash `json:"hashes"`
+	// Latest is the latest raw transaction, sent again or replaced while none is included
+	Latest hexutil.Bytes `json:"latest"`
+	// SentAt is the unix time in nanoseconds the latest transaction was sent at
+	SentAt int64 `json:"sent_at"`
+}
+
+func (r *settleRef) encode() (string, error) {
+	data, err := json.Marshal(r)
+	return string(data), err
+}
+
+func (r *settleRef) latest() (*gethTypes.Transaction, error) {
+	tx := new(gethTypes.Transaction)
+	if err := tx.UnmarshalBinary(r.Latest); err != nil {
+		return nil, fmt.Errorf("invalid settle transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// decodeSettleRef decodes the reference of a settle transaction, the raw transaction itself
+// for those submitted before the replacements were referenced
+func decodeSettleRef(ref string) (*settleRef, error) {
+	if strings.HasPrefix(ref, "0x") {
+		raw, err := hexutil.Decode(ref)
+		if err != nil {
+			return nil, fmt.Errorf("invalid settle transaction: %w", err)
+		}
+		r := &settleRef{Latest: raw}
+		tx, err := r.latest()
+		if err != nil {
+			return nil, err
+		}
+		r.Hashes = []eth_common.Hash{tx.Hash()}
+		return r, nil
+	}
+	r := &settleRef{}
+	if err := json.Unmarshal([]byte(ref), r); err != nil || len(r.Hashes) == 0 {
+		return nil, fmt.Errorf("invalid settle transaction reference %q", ref)
+	}
+	return r, nil
+}
+
+// Settle signs a settle transaction at the fees of the fee manager, hands it to submitted as
+// its reference, then sends it and waits for it to be included. Settled replaces it with
+// higher fees if it stays pending, or sends it again as long as its nonce isn't used, so
+// that a retried settlement never takes a second transaction.
+func (l *ContractLedger) Settle(ctx context.Context, id string, settlements []Settlement, submitted func(ref string) error) error {
+	accounts := make([]eth_common.Address, 0, len(settlements))
+	amounts := make([]*big.Int, 0, len(settlements))
+	for _, s := range settlements {
+		if !eth_common.IsHexAddress(s.Account) {
+			return fmt.Errorf("account %s has no credit to settle", s.Account)
+		}
+		accounts = append(accounts, eth_common.HexToAddress(s.Account))
+		amounts = append(amounts, s.Fee)
+	}
+
+	opts := *l.opts
+	opts.Context = ctx
+	opts.NoSend = true
+	feeCap, tipCap, err := l.fees.Suggest(ctx)
+	if err != nil {
+		return err
+	}
+	opts.GasFeeCap, opts.GasTipCap = feeCap, tipCap
+	tx, err := l.contract.Transact(&opts, "settle", accounts, amounts)
+	if err != nil {
+		return fmt.Errorf("failed to sign settle transaction: %w", err)
+	}
+	if err := l.submit(ctx, tx, nil, submitted); err != nil {
+		return err
+	}
+	receipt, err := bind.WaitMined(ctx, l.client, tx)
+	if err != nil {
+		return fmt.Errorf("failed to wait for settle transaction %s: %w", tx.Hash(), err)
+	}
+	if receipt.Status != gethTypes.ReceiptStatusSuccessful {
+		return fmt.Errorf("settle transaction %s failed", tx.Hash())
+	}
+	return nil
+}
+
+// submit hands the reference of tx, sent after the transactions of prev, to submitted before
+// it sends tx
+func (l *ContractLedger) submit(ctx context.Context, tx *gethTypes.Transaction, prev *settleRef, submitted func(ref string) error) error {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	ref := &settleRef{Latest: raw, SentAt: time.Now().UnixNano()}
+	if prev != nil {
+		ref.Hashes = append(ref.Hashes, prev.Hashes...)
+	}
+	ref.Hashes = append(ref.Hashes, tx.Hash())
+	encoded, err := ref.encode()
+	if err != nil {
+		return err
+	}
+	if err := submitted(encoded); err != nil {
+		return err
+	}
+	if err := l.client.SendTransaction(ctx, tx); err != nil && !strings.Contains(err.Error(), "already known") {
+		return fmt.Errorf("failed to send settle transaction %s: %w", tx.Hash(), err)
+	}
+	return nil
+}
+
+// receipt returns the receipt of the transaction of ref that was included, nil if none was
+func (l *ContractLedger) receipt(ctx context.Context, ref *settleRef) (*gethTypes.Receipt, error) {
+	for i := len(ref.Hashes) - 1; i >= 0; i-- {
+		receipt, err := l.client.TransactionReceipt(ctx, ref.Hashes[i])
+		if err == nil {
+			return receipt, nil
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// Settled looks the settle transactions of ref up. They were dropped if their nonce was used
+// by another transaction. Otherwise the latest is replaced with higher fees, handed to
+// replaced as the new reference first, once it has been pending for the replace timeout,
+// and sent again until then.
+func (l *ContractLedger) Settled(ctx context.Context, ref string, replaced func(ref string) error) (bool, error) {
+	r, err := decodeSettleRef(ref)
+	if err != nil {
+		return false, err
+	}
+	latest, err := r.latest()
+	if err != nil {
+		return false, err
+	}
+	receipt, err := l.receipt(ctx, r)
+	if err != nil || receipt != nil {
+		return receipt != nil && receipt.Status == gethTypes.ReceiptStatusSuccessful, err
+	}
+	nonce, err := l.client.NonceAt(ctx, l.opts.From, nil)
+	if err != nil {
+		return false, err
+	}
+	if nonce > latest.Nonce() {
+		// a receipt may have been included since they were looked up
+		receipt, err := l.receipt(ctx, r)
+		return receipt != nil && receipt.Status == gethTypes.ReceiptStatusSuccessful, err
+	}
+
+	if latest.Type() == gethTypes.DynamicFeeTxType && l.fees.ReplaceDue(time.Unix(0, r.SentAt), len(r.Hashes)-1) {
+		replacement, err := l.fees.Replacement(ctx, latest)
+		if err != nil {
+			return false, err
+		}
+		if replacement != nil {
+			if err := l.submit(ctx, replacement, r, replaced); err != nil {
+				return false, err
+			}
+			l.logger.Info("[payments] replaced pending settle transaction", "tx hash", latest.Hash(), "replacement", replacement.Hash(), "fee cap", replacement.GasFeeCap(), "replacements", len(r.Hashes))
+			return false, fmt.Errorf("%w: settle transaction %s", ErrSettlementPending, replacement.Hash())
+		}
+	}
+	if err := l.client.SendTransaction(ctx, latest); err != nil && !strings.Contains(err.Error(), "already known") {
+		return false, fmt.Errorf("failed to send settle transaction %s again: %w", latest.Hash(), err)
+	}
+	return false, fmt.Errorf("%w: settle transaction %s", ErrSettlementPending, latest.Hash())
+}
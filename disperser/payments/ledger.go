@@ -0,0 +1,193 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Usage is the dispersals of an account
+type Usage struct {
+	Blobs uint64
+	Bytes uint64
+	// Fee is the total fee of the dispersals in wei
+	Fee *big.Int
+}
+
+func (u *Usage) add(other Usage) {
+	u.Blobs += other.Blobs
+	u.Bytes += other.Bytes
+	u.Fee = new(big.Int).Add(u.Fee, other.Fee)
+}
+
+// sub subtracts other, stopping at 0, and returns the fee of other beyond that of u, which
+// was moved to a settlement already
+func (u *Usage) sub(other Usage) *big.Int {
+	u.Blobs -= min(u.Blobs, other.Blobs)
+	u.Bytes -= min(u.Bytes, other.Bytes)
+	excess := new(big.Int).Sub(other.Fee, u.Fee)
+	if excess.Sign() <= 0 {
+		u.Fee = new(big.Int).Sub(u.Fee, other.Fee)
+		return new(big.Int)
+	}
+	u.Fee = new(big.Int)
+	return excess
+}
+
+// Settlement is the usage of an account settled on the ledger, whose fee is deducted from
+// the credit of the account
+type Settlement struct {
+	Account string
+	Usage
+}
+
+// ErrSettlementPending is returned by Ledger.Settled while a submitted settlement is neither
+// applied nor known to be dropped
+var ErrSettlementPending = errors.New("settlement is pending")
+
+// Ledger holds the prepaid credit of the accounts
+type Ledger interface {
+	// Credit returns the credit left to an account in wei, zero for unknown accounts
+	Credit(ctx context.Context, account string) (*big.Int, error)
+	// Settle deducts the usage of the accounts from their credit, all or none of them, under
+	// the settlement id. submitted is called with a reference of the submission, e.g. the
+	// signed transaction, before the ledger may apply it, so that a settlement interrupted
+	// afterwards is checked with Settled rather than submitted again.
+	Settle(ctx context.Context, id string, settlements []Settlement, submitted func(ref string) error) error
+	// Settled returns whether the submission of ref was applied, false if it never will be
+	// and the settlement must be submitted again, ErrSettlementPending while unknown yet.
+	// The ledger may replace a pending submission, e.g. with higher fees, calling replaced
+	// with the reference of the replacement before it may apply it.
+	Settled(ctx context.Context, ref string, replaced func(ref string) error) (bool, error)
+}
+
+// fileAccount is an account of the file ledger
+type fileAccount struct {
+	Credit *big.Int `json:"credit"`
+	Blobs  uint64   `json:"blobs"`
+	Bytes  uint64   `json:"bytes"`
+	Spent  *big.Int `json:"spent"`
+	// Settlement is the id of the last settlement applied to the account
+	Settlement string `json:"settlement,omitempty"`
+}
+
+// FileLedger is an off-chain ledger kept in a json file mapping the accounts to their
+// credit, e.g. {"0x1234...": {"credit": 1000000}}, to which the settled usage is written.
+// Credit is topped up by editing the file while the disperser is stopped.
+type FileLedger struct {
+	path string
+
+	mu       sync.Mutex
+	accounts map[string]*fileAccount
+}
+
+func NewFileLedger(path string) (*FileLedger, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payments ledger: %w", err)
+	}
+	accounts := make(map[string]*fileAccount)
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return nil, fmt.Errorf("failed to parse payments ledger: %w", err)
+	}
+	for account, a := range accounts {
+		if a.Credit == nil || a.Credit.Sign() < 0 {
+			return nil, fmt.Errorf("invalid credit of account %s in payments ledger", account)
+		}
+		if a.Spent == nil {
+			a.Spent = new(big.Int)
+		}
+	}
+	return &FileLedger{path: path, accounts: accounts}, nil
+}
+
+func (l *FileLedger) Credit(ctx context.Context, account string) (*big.Int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	a, ok := l.accounts[account]
+	if !ok {
+		return new(big.Int), nil
+	}
+	return new(big.Int).Set(a.Credit), nil
+}
+
+// Settle writes the settlement to the file. The accounts the settlement was applied to already
+// are left as they are, so a settlement is never applied twice.
+func (l *FileLedger) Settle(ctx context.Context, id string, settlements []Settlement, submitted func(ref string) error) error {
+	if err := submitted(id); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range settlements {
+		if _, ok := l.accounts[s.Account]; !ok {
+			return fmt.Errorf("account %s has no credit to settle", s.Account)
+		}
+	}
+
+	accounts := make(map[string]*fileAccount, len(l.accounts))
+	for account, a := range l.accounts {
+		copied := *a
+		accounts[account] = &copied
+	}
+	for _, s := range settlements {
+		a := accounts[s.Account]
+		if a.Settlement == id {
+			continue
+		}
+		a.Settlement = id
+		// usage beyond the credit, charged concurrently, is settled as far as it goes
+		a.Credit = new(big.Int).Sub(a.Credit, s.Fee)
+		if a.Credit.Sign() < 0 {
+			a.Credit = new(big.Int)
+		}
+		a.Spent = new(big.Int).Add(a.Spent, s.Fee)
+		a.Blobs += s.Blobs
+		a.Bytes += s.Bytes
+	}
+	if err := l.write(accounts); err != nil {
+		return err
+	}
+	l.accounts = accounts
+	return nil
+}
+
+// Settled returns whether the settlement of ref, its id, was written to the file
+func (l *FileLedger) Settled(ctx context.Context, ref string, replaced func(ref string) error) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, a := range l.accounts {
+		if a.Settlement == ref {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// write replaces the ledger file so that it is never left half written
+func (l *FileLedger) write(accounts map[string]*fileAccount) error {
+	data, err := json.MarshalIndent(accounts, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write payments ledger: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), l.path)
+	}
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to write payments ledger: %w", err), os.Remove(tmp.Name()))
+	}
+	return nil
+}
@@ -0,0 +1,427 @@
+package payments
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+)
+
+// settleTimeout bounds a settlement of the usage
+const settleTimeout = time.Minute
+
+// settleLease is how long a replica holds the settlement it started, the others take it over
+// once it expired
+const settleLease = 2 * settleTimeout
+
+// maxUpdateAttempts bounds the attempts of an update of a usage record written concurrently
+const maxUpdateAttempts = 16
+
+const (
+	accountUsagePrefix = "account/"
+	settlementKey      = "settlement"
+)
+
+var ErrInsufficientCredit = errors.New("insufficient credit")
+
+// Charge is the usage charged to an account for a dispersal
+type Charge struct {
+	Account string
+	Usage
+}
+
+// accountUsage is the usage record of an account in the usage store
+type accountUsage struct {
+	// Unsettled is the usage charged since the last settlement began
+	Unsettled Usage `json:"unsettled"`
+	// Settling is the usage being settled by the settlement SettlementID
+	Settling     Usage  `json:"settling"`
+	SettlementID string `json:"settlement_id,omitempty"`
+	// Refunded is the fee of the charges refunded after they were moved to a settlement,
+	// owed back to the account. It counts toward the credit left to the account, and is
+	// deducted from the next usage settled.
+	Refunded *big.Int `json:"refunded,omitempty"`
+	// SettledAt is the unix time in nanoseconds the last settlement of the account was
+	// applied at, the credit read before it doesn't have the usage deducted
+	SettledAt int64 `json:"settled_at,omitempty"`
+}
+
+func (u *accountUsage) normalize() {
+	for _, usage := range []*Usage{&u.Unsettled, &u.Settling} {
+		if usage.Fee == nil {
+			usage.Fee = new(big.Int)
+		}
+	}
+	if u.Refunded == nil {
+		u.Refunded = new(big.Int)
+	}
+}
+
+// settlementRecord is the settlement in progress in the usage store, there is one at a time
+type settlementRecord struct {
+	ID string `json:"id"`
+	// Claimant is the replica settling it until ClaimedUntil, unix time in nanoseconds
+	Claimant     string `json:"claimant"`
+	ClaimedUntil int64  `json:"claimed_until"`
+	// Ref is the reference of its submission to the ledger, empty until submitted
+	Ref  string `json:"ref,omitempty"`
+	Done bool   `json:"done,omitempty"`
+}
+
+// cachedCredit is the credit of an account read from the ledger
+type cachedCredit struct {
+	credit *big.Int
+	readAt time.Time
+}
+
+// Meter charges the dispersals of the accounts against their credit on a ledger, and
+// settles the usage on the ledger periodically. Charges reserve the credit in the usage
+// store until they are settled, so the accounts can't spend more than their credit between
+// settlements across the replicas sharing the store. The usage is moved to a settlement
+// with an id before it is submitted to the ledger, which applies a settlement once.
+type Meter struct {
+	config Config
+	ledger Ledger
+	usage  UsageStore
+	logger common.Logger
+	// id identifies the replica in the settlements it claims
+	id string
+
+	mu      sync.Mutex
+	credits map[string]*cachedCredit
+	now     func() time.Time
+}
+
+func NewMeter(config Config, ledger Ledger, usage UsageStore, logger common.Logger) *Meter {
+	return &Meter{
+		config:  config,
+		ledger:  ledger,
+		usage:   usage,
+		logger:  logger,
+		id:      randomID(),
+		credits: make(map[string]*cachedCredit),
+		now:     time.Now,
+	}
+}
+
+func randomID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// Charge charges a dispersal of size bytes at feePerByte to an account, at the configured
+// fee if feePerByte is nil. It fails with ErrInsufficientCredit if the credit of the account
+// left after its unsettled usage doesn't cover the fee. A nil meter charges nothing.
+func (m *Meter) Charge(ctx context.Context, account string, size int, feePerByte *big.Int) (*Charge, error) {
+	if m == nil {
+		return nil, nil
+	}
+	if feePerByte == nil {
+		feePerByte = m.config.FeePerByte
+	}
+	charge := &Charge{
+		Account: account,
+		Usage: Usage{
+			Blobs: 1,
+			Bytes: uint64(size),
+			Fee:   new(big.Int).Mul(big.NewInt(int64(size)), feePerByte),
+		},
+	}
+
+	err := m.updateAccount(ctx, account, func(usage *accountUsage) error {
+		credit, err := m.credit(ctx, account, time.Unix(0, usage.SettledAt))
+		if err != nil {
+			return err
+		}
+		available := new(big.Int).Sub(credit, usage.Unsettled.Fee)
+		available.Sub(available, usage.Settling.Fee)
+		available.Add(available, usage.Refunded)
+		if available.Cmp(charge.Fee) < 0 {
+			return fmt.Errorf("%w: account %s has %s wei left, dispersal costs %s wei", ErrInsufficientCredit, account, available, charge.Fee)
+		}
+		usage.Unsettled.add(charge.Usage)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return charge, nil
+}
+
+// Refund cancels a charge of a dispersal which failed
+func (m *Meter) Refund(ctx context.Context, charge *Charge) {
+	if m == nil || charge == nil {
+		return
+	}
+	err := m.updateAccount(ctx, charge.Account, func(usage *accountUsage) error {
+		// a charge already being settled is deducted from the next settlement
+		usage.Refunded = new(big.Int).Add(usage.Refunded, usage.Unsettled.sub(charge.Usage))
+		return nil
+	})
+	if err != nil {
+		m.logger.Error("[payments] failed to refund charge", "account", charge.Account, "fee", charge.Fee, "err", err)
+	}
+}
+
+// Unsettled returns the usage of an account not settled on the ledger yet, its fee net of
+// the fee refunded after it was settled
+func (m *Meter) Unsettled(ctx context.Context, account string) (Usage, error) {
+	usage, _, err := m.getAccount(ctx, account)
+	if err != nil {
+		return Usage{}, err
+	}
+	unsettled := Usage{Fee: new(big.Int)}
+	unsettled.add(usage.Unsettled)
+	unsettled.add(usage.Settling)
+	unsettled.Fee.Sub(unsettled.Fee, usage.Refunded)
+	if unsettled.Fee.Sign() < 0 {
+		unsettled.Fee = new(big.Int)
+	}
+	return unsettled, nil
+}
+
+// credit returns the credit of an account, read from the ledger if the cached credit expired
+// or was read before notBefore
+func (m *Meter) credit(ctx context.Context, account string, notBefore time.Time) (*big.Int, error) {
+	m.mu.Lock()
+	cached, ok := m.credits[account]
+	m.mu.Unlock()
+	if ok && m.now().Sub(cached.readAt) < m.config.CreditTTL && !cached.readAt.Before(notBefore) {
+		return cached.credit, nil
+	}
+
+	readAt := m.now()
+	credit, err := m.ledger.Credit(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.credits[account] = &cachedCredit{credit: credit, readAt: readAt}
+	return credit, nil
+}
+
+func (m *Meter) getAccount(ctx context.Context, account string) (*accountUsage, uint64, error) {
+	data, version, err := m.usage.Get(ctx, accountUsagePrefix+account)
+	if err != nil {
+		return nil, 0, err
+	}
+	usage := &accountUsage{}
+	if data != nil {
+		if err := json.Unmarshal(data, usage); err != nil {
+			return nil, 0, fmt.Errorf("corrupt usage of account %s: %w", account, err)
+		}
+	}
+	usage.normalize()
+	return usage, version, nil
+}
+
+// updateAccount applies update to the usage record of an account, again if it was written
+// concurrently
+func (m *Meter) updateAccount(ctx context.Context, account string, update func(usage *accountUsage) error) error {
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		usage, version, err := m.getAccount(ctx, account)
+		if err != nil {
+			return err
+		}
+		if err := update(usage); err != nil {
+			return err
+		}
+		data, err := json.Marshal(usage)
+		if err != nil {
+			return err
+		}
+		err = m.usage.Put(ctx, accountUsagePrefix+account, data, version)
+		if !errors.Is(err, ErrVersionConflict) {
+			return err
+		}
+	}
+	return fmt.Errorf("usage of account %s: %w", account, ErrVersionConflict)
+}
+
+// claim returns the settlement in progress, or starts one, if no other replica holds it
+func (m *Meter) claim(ctx context.Context) (*settlementRecord, uint64, error) {
+	data, version, err := m.usage.Get(ctx, settlementKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	now := m.now()
+	record := &settlementRecord{}
+	if data != nil {
+		if err := json.Unmarshal(data, record); err != nil {
+			return nil, 0, fmt.Errorf("corrupt settlement: %w", err)
+		}
+	}
+	if data == nil || record.Done {
+		record = &settlementRecord{ID: randomID()}
+	} else if record.Claimant != m.id && now.UnixNano() < record.ClaimedUntil {
+		return nil, 0, nil
+	}
+	record.Claimant, record.ClaimedUntil = m.id, now.Add(settleLease).UnixNano()
+	if err := m.putSettlement(ctx, record, &version); err != nil {
+		if errors.Is(err, ErrVersionConflict) {
+			// claimed by another replica
+			return nil, 0, nil
+		}
+		return nil, 0, err
+	}
+	return record, version, nil
+}
+
+// putSettlement writes the settlement at version, which is updated
+func (m *Meter) putSettlement(ctx context.Context, record *settlementRecord, version *uint64) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := m.usage.Put(ctx, settlementKey, data, *version); err != nil {
+		return err
+	}
+	*version++
+	return nil
+}
+
+// collect returns the usage of the settlement, moving the unsettled usage of the accounts to
+// it unless it was submitted already
+func (m *Meter) collect(ctx context.Context, record *settlementRecord) ([]Settlement, error) {
+	keys, err := m.usage.Keys(ctx, accountUsagePrefix)
+	if err != nil {
+		return nil, err
+	}
+	settlements := make([]Settlement, 0)
+	for _, key := range keys {
+		account := strings.TrimPrefix(key, accountUsagePrefix)
+		var settling Usage
+		err := m.updateAccount(ctx, account, func(usage *accountUsage) error {
+			settling = Usage{}
+			if usage.SettlementID == "" && record.Ref == "" && usage.Unsettled.Fee.Sign() > 0 {
+				usage.Settling, usage.SettlementID = usage.Unsettled, record.ID
+				usage.Unsettled = Usage{Fee: new(big.Int)}
+				// the fee refunded after it was settled is returned by settling less
+				deducted := usage.Refunded
+				if deducted.Cmp(usage.Settling.Fee) > 0 {
+					deducted = usage.Settling.Fee
+				}
+				usage.Settling.Fee = new(big.Int).Sub(usage.Settling.Fee, deducted)
+				usage.Refunded = new(big.Int).Sub(usage.Refunded, deducted)
+			}
+			if usage.SettlementID == record.ID {
+				settling = usage.Settling
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if settling.Fee != nil {
+			settlements = append(settlements, Settlement{Account: account, Usage: settling})
+		}
+	}
+	return settlements, nil
+}
+
+// complete clears the usage of an applied settlement from its accounts and closes it
+func (m *Meter) complete(ctx context.Context, record *settlementRecord, version uint64, settlements []Settlement) error {
+	settledAt := m.now().UnixNano()
+	for _, s := range settlements {
+		err := m.updateAccount(ctx, s.Account, func(usage *accountUsage) error {
+			if usage.SettlementID == record.ID {
+				usage.Settling, usage.SettlementID = Usage{Fee: new(big.Int)}, ""
+				usage.SettledAt = settledAt
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	record.Done = true
+	return m.putSettlement(ctx, record, &version)
+}
+
+// Settle settles the unsettled usage of the accounts on the ledger, unless another replica is
+// settling it. A settlement failing after it was submitted is checked on the ledger by the
+// next attempt, and submitted again only if it was dropped.
+func (m *Meter) Settle(ctx context.Context) error {
+	record, version, err := m.claim(ctx)
+	if err != nil || record == nil {
+		return err
+	}
+	settlements, err := m.collect(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	if record.Ref != "" {
+		settled, err := m.ledger.Settled(ctx, record.Ref, func(ref string) error {
+			record.Ref = ref
+			return m.putSettlement(ctx, record, &version)
+		})
+		if err != nil {
+			return err
+		}
+		if !settled {
+			m.logger.Warn("[payments] settlement was dropped, submitting it again", "settlement", record.ID)
+			record.Ref = ""
+		}
+	}
+	if record.Ref == "" && len(settlements) > 0 {
+		err := m.ledger.Settle(ctx, record.ID, settlements, func(ref string) error {
+			record.Ref = ref
+			return m.putSettlement(ctx, record, &version)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := m.complete(ctx, record, version, settlements); err != nil {
+		return err
+	}
+	if len(settlements) > 0 {
+		m.logger.Info("[payments] settled usage", "settlement", record.ID, "accounts", len(settlements))
+	}
+
+	// forget the credit of the accounts expired since
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for account, cached := range m.credits {
+		if m.now().Sub(cached.readAt) >= m.config.CreditTTL {
+			delete(m.credits, account)
+		}
+	}
+	return nil
+}
+
+// Start settles the usage every settle interval until ctx is done, and once more then
+func (m *Meter) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(m.config.SettleInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				settleCtx, cancel := context.WithTimeout(context.Background(), settleTimeout)
+				defer cancel()
+				if err := m.Settle(settleCtx); err != nil {
+					m.logger.Error("[payments] failed to settle usage on shutdown", "err", err)
+				}
+				return
+			case <-ticker.C:
+				settleCtx, cancel := context.WithTimeout(ctx, settleTimeout)
+				if err := m.Settle(settleCtx); err != nil {
+					m.logger.Warn("[payments] failed to settle usage, retrying on the next interval", "err", err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
@@ -0,0 +1,174 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLedger(t *testing.T) (*FileLedger, string) {
+	path := filepath.Join(t.TempDir(), "ledger.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"alice": {"credit": 1000}}`), 0o600))
+	ledger, err := NewFileLedger(path)
+	require.NoError(t, err)
+	return ledger, path
+}
+
+func newTestUsageStore(t *testing.T, path string) *LevelDBUsageStore {
+	store, err := NewLevelDBUsageStore(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func TestMeter(t *testing.T) {
+	ledger, path := newTestLedger(t)
+	usagePath := filepath.Join(t.TempDir(), "usage")
+	store := newTestUsageStore(t, usagePath)
+	config := Config{FeePerByte: big.NewInt(2), CreditTTL: time.Hour}
+	meter := NewMeter(config, ledger, store, mock.NewLogger(false))
+	ctx := context.Background()
+
+	// charges reserve the credit until they are settled, failed dispersals are refunded
+	charge, err := meter.Charge(ctx, "alice", 300, nil)
+	require.NoError(t, err)
+	_, err = meter.Charge(ctx, "alice", 250, nil)
+	assert.ErrorIs(t, err, ErrInsufficientCredit)
+	meter.Refund(ctx, charge)
+	_, err = meter.Charge(ctx, "alice", 250, nil)
+	require.NoError(t, err)
+	_, err = meter.Charge(ctx, "alice", 100, big.NewInt(1))
+	require.NoError(t, err)
+	_, err = meter.Charge(ctx, "bob", 1, nil)
+	assert.ErrorIs(t, err, ErrInsufficientCredit)
+	unsettled, err := meter.Unsettled(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, Usage{Blobs: 2, Bytes: 350, Fee: big.NewInt(600)}, unsettled)
+
+	// the unsettled usage survives a restart
+	require.NoError(t, store.Close())
+	store = newTestUsageStore(t, usagePath)
+	meter = NewMeter(config, ledger, store, mock.NewLogger(false))
+	_, err = meter.Charge(ctx, "alice", 201, nil)
+	assert.ErrorIs(t, err, ErrInsufficientCredit)
+
+	// the usage is written to the ledger, and the credit read from it again
+	require.NoError(t, meter.Settle(ctx))
+	unsettled, err = meter.Unsettled(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), unsettled.Blobs)
+	reopened, err := NewFileLedger(path)
+	require.NoError(t, err)
+	credit, err := reopened.Credit(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(400), credit)
+	_, err = meter.Charge(ctx, "alice", 200, nil)
+	require.NoError(t, err)
+	_, err = meter.Charge(ctx, "alice", 1, nil)
+	assert.ErrorIs(t, err, ErrInsufficientCredit)
+}
+
+func TestMetersSharingUsage(t *testing.T) {
+	ledger, _ := newTestLedger(t)
+	store := newTestUsageStore(t, filepath.Join(t.TempDir(), "usage"))
+	config := Config{FeePerByte: big.NewInt(1), CreditTTL: time.Hour}
+	replicas := []*Meter{
+		NewMeter(config, ledger, store, mock.NewLogger(false)),
+		NewMeter(config, ledger, store, mock.NewLogger(false)),
+	}
+	ctx := context.Background()
+
+	// the replicas reserve the credit of an account together
+	_, err := replicas[0].Charge(ctx, "alice", 600, nil)
+	require.NoError(t, err)
+	_, err = replicas[1].Charge(ctx, "alice", 600, nil)
+	assert.ErrorIs(t, err, ErrInsufficientCredit)
+	_, err = replicas[1].Charge(ctx, "alice", 400, nil)
+	require.NoError(t, err)
+
+	// the usage is settled once, by either replica
+	require.NoError(t, replicas[1].Settle(ctx))
+	require.NoError(t, replicas[0].Settle(ctx))
+	credit, err := ledger.Credit(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, new(big.Int), credit)
+	_, err = replicas[0].Charge(ctx, "alice", 1, nil)
+	assert.ErrorIs(t, err, ErrInsufficientCredit)
+}
+
+// interruptedLedger fails its settlements after submitting them
+type interruptedLedger struct {
+	*FileLedger
+	settles int
+}
+
+func (l *interruptedLedger) Settle(ctx context.Context, id string, settlements []Settlement, submitted func(ref string) error) error {
+	l.settles++
+	if err := l.FileLedger.Settle(ctx, id, settlements, submitted); err != nil {
+		return err
+	}
+	return errors.New("timed out waiting for the settlement")
+}
+
+func TestSettleInterrupted(t *testing.T) {
+	fileLedger, _ := newTestLedger(t)
+	ledger := &interruptedLedger{FileLedger: fileLedger}
+	meter := NewMeter(Config{FeePerByte: big.NewInt(1), CreditTTL: time.Hour}, ledger, newTestUsageStore(t, filepath.Join(t.TempDir(), "usage")), mock.NewLogger(false))
+	ctx := context.Background()
+
+	_, err := meter.Charge(ctx, "alice", 300, nil)
+	require.NoError(t, err)
+	require.Error(t, meter.Settle(ctx))
+	// usage charged meanwhile is settled by the next settlement
+	_, err = meter.Charge(ctx, "alice", 100, nil)
+	require.NoError(t, err)
+
+	// the applied settlement is completed without submitting it again
+	require.NoError(t, meter.Settle(ctx))
+	assert.Equal(t, 1, ledger.settles)
+	credit, err := fileLedger.Credit(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(700), credit)
+	unsettled, err := meter.Unsettled(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, Usage{Blobs: 1, Bytes: 100, Fee: big.NewInt(100)}, unsettled)
+}
+
+func TestRefundSettling(t *testing.T) {
+	fileLedger, _ := newTestLedger(t)
+	ledger := &interruptedLedger{FileLedger: fileLedger}
+	meter := NewMeter(Config{FeePerByte: big.NewInt(1), CreditTTL: time.Hour}, ledger, newTestUsageStore(t, filepath.Join(t.TempDir(), "usage")), mock.NewLogger(false))
+	ctx := context.Background()
+
+	charge, err := meter.Charge(ctx, "alice", 300, nil)
+	require.NoError(t, err)
+	require.Error(t, meter.Settle(ctx))
+	// the charge was settled already, it is owed back to the account rather than turning
+	// its unsettled usage negative
+	meter.Refund(ctx, charge)
+	unsettled, err := meter.Unsettled(ctx, "alice")
+	require.NoError(t, err)
+	assert.Zero(t, unsettled.Fee.Sign())
+	require.NoError(t, meter.Settle(ctx))
+
+	// the refunded fee counts toward the credit, and is deducted from the next settlement
+	_, err = meter.Charge(ctx, "alice", 1001, nil)
+	assert.ErrorIs(t, err, ErrInsufficientCredit)
+	_, err = meter.Charge(ctx, "alice", 1000, nil)
+	require.NoError(t, err)
+	require.Error(t, meter.Settle(ctx))
+	credit, err := fileLedger.Credit(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, new(big.Int), credit)
+	usage, _, err := meter.getAccount(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, new(big.Int), usage.Refunded)
+}
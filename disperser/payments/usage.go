@@ -0,0 +1,185 @@
+package payments
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	commondynamodb "github.com/0glabs/0g-da-client/common/aws/dynamodb"
+	zg_leveldb "github.com/0glabs/0g-da-client/disperser/leveldb"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrVersionConflict is returned by a UsageStore when the record was written since it was read
+var ErrVersionConflict = errors.New("usage record was written concurrently")
+
+// UsageStore keeps the usage charged to the accounts until it is settled on the ledger, so
+// that it survives restarts. The replicas of the disperser charging against the same ledger
+// must share the store, the credit of an account being reserved against the usage charged
+// by all of them.
+type UsageStore interface {
+	// Get returns the record of key and its version, nil and 0 if there is none
+	Get(ctx context.Context, key string) ([]byte, uint64, error)
+	// Put writes the record of key at the next version if its stored version is still
+	// version, 0 for a new record, and fails with ErrVersionConflict otherwise
+	Put(ctx context.Context, key string, value []byte, version uint64) error
+	// Keys returns the keys of the records starting with prefix
+	Keys(ctx context.Context, prefix string) ([]string, error)
+}
+
+// LevelDBUsageStore is a UsageStore in a local leveldb database, for a single disperser
+type LevelDBUsageStore struct {
+	db *zg_leveldb.LevelDBStore
+	// mu serializes the writes, whose version checks are not atomic otherwise
+	mu sync.Mutex
+}
+
+func NewLevelDBUsageStore(path string) (*LevelDBUsageStore, error) {
+	db, err := zg_leveldb.NewLevelDBStore(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open payments usage store: %w", err)
+	}
+	return &LevelDBUsageStore{db: db}, nil
+}
+
+func (s *LevelDBUsageStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *LevelDBUsageStore) Get(ctx context.Context, key string) ([]byte, uint64, error) {
+	data, err := s.db.Get([]byte(key))
+	if errors.Is(err, zg_leveldb.ErrNotFound) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(data) < 8 {
+		return nil, 0, fmt.Errorf("corrupt usage record %s", key)
+	}
+	return data[8:], binary.BigEndian.Uint64(data[:8]), nil
+}
+
+func (s *LevelDBUsageStore) Put(ctx context.Context, key string, value []byte, version uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, stored, err := s.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if stored != version {
+		return ErrVersionConflict
+	}
+	data := binary.BigEndian.AppendUint64(make([]byte, 0, 8+len(value)), version+1)
+	return s.db.SyncWriteBatch([][]byte{[]byte(key)}, [][]byte{append(data, value...)})
+}
+
+func (s *LevelDBUsageStore) Keys(ctx context.Context, prefix string) ([]string, error) {
+	iter := s.db.NewIterator([]byte(prefix))
+	defer iter.Release()
+	keys := make([]string, 0)
+	for iter.Next() {
+		keys = append(keys, string(iter.Key()))
+	}
+	return keys, iter.Error()
+}
+
+// DynamoUsageStore is a UsageStore in a DynamoDB table shared by the replicas of the
+// disperser, see GenerateUsageTableSchema
+type DynamoUsageStore struct {
+	client    *commondynamodb.Client
+	tableName string
+}
+
+func NewDynamoUsageStore(client *commondynamodb.Client, tableName string) *DynamoUsageStore {
+	return &DynamoUsageStore{client: client, tableName: tableName}
+}
+
+func usageItemKey(key string) commondynamodb.Key {
+	return commondynamodb.Key{"UsageKey": &types.AttributeValueMemberS{Value: key}}
+}
+
+func (s *DynamoUsageStore) Get(ctx context.Context, key string) ([]byte, uint64, error) {
+	item, err := s.client.GetItemConsistent(ctx, s.tableName, usageItemKey(key))
+	if err != nil {
+		return nil, 0, err
+	}
+	if item == nil {
+		return nil, 0, nil
+	}
+	value, ok := item["Value"].(*types.AttributeValueMemberB)
+	version, okVersion := item["Version"].(*types.AttributeValueMemberN)
+	if !ok || !okVersion {
+		return nil, 0, fmt.Errorf("corrupt usage record %s", key)
+	}
+	v, err := strconv.ParseUint(version.Value, 10, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("corrupt usage record %s: %w", key, err)
+	}
+	return value.Value, v, nil
+}
+
+func (s *DynamoUsageStore) Put(ctx context.Context, key string, value []byte, version uint64) error {
+	condition := expression.AttributeNotExists(expression.Name("Version"))
+	if version > 0 {
+		condition = expression.Name("Version").Equal(expression.Value(&types.AttributeValueMemberN{Value: strconv.FormatUint(version, 10)}))
+	}
+	err := s.client.UpdateItemIf(ctx, s.tableName, usageItemKey(key), commondynamodb.Item{
+		"Value":   &types.AttributeValueMemberB{Value: value},
+		"Version": &types.AttributeValueMemberN{Value: strconv.FormatUint(version+1, 10)},
+	}, condition)
+	if errors.Is(err, commondynamodb.ErrConditionFailed) {
+		return ErrVersionConflict
+	}
+	return err
+}
+
+func (s *DynamoUsageStore) Keys(ctx context.Context, prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	var start commondynamodb.Key
+	for {
+		items, last, err := s.client.ScanPage(ctx, s.tableName, start, 100)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if key, ok := item["UsageKey"].(*types.AttributeValueMemberS); ok && strings.HasPrefix(key.Value, prefix) {
+				keys = append(keys, key.Value)
+			}
+		}
+		if len(last) == 0 {
+			return keys, nil
+		}
+		start = last
+	}
+}
+
+// GenerateUsageTableSchema is the schema of the table of a DynamoUsageStore
+func GenerateUsageTableSchema(tableName string, readCapacityUnits int64, writeCapacityUnits int64) *dynamodb.CreateTableInput {
+	return &dynamodb.CreateTableInput{
+		AttributeDefinitions: []types.AttributeDefinition{
+			{
+				AttributeName: aws.String("UsageKey"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{
+				AttributeName: aws.String("UsageKey"),
+				KeyType:       types.KeyTypeHash,
+			},
+		},
+		TableName: aws.String(tableName),
+		ProvisionedThroughput: &types.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(readCapacityUnits),
+			WriteCapacityUnits: aws.Int64(writeCapacityUnits),
+		},
+	}
+}
@@ -0,0 +1,111 @@
+package disperser
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// The request ID returned for a dispersed blob is its blob key, formatted as
+//
+//	<blob hash>-<metadata hash>
+//
+// with both hashes in lower case hex without a 0x prefix. The blob hash is derived from the
+// blob data and the metadata hash from the time the request was received, in nanoseconds,
+// and its security parameters. Requests of the same blob with the same parameters so only
+// get distinct IDs if they are received at distinct times. Each API server draws the request
+// times from a RequestClock, which makes them unique within the server and, as long as the
+// servers sharing a blob store run with distinct replica IDs, across the servers. The blob
+// stores refuse to store a request under a key that is already taken with ErrBlobKeyConflict
+// rather than aliasing two blobs, which two servers with the same replica ID could cause.
+//
+// ParseBlobKey also accepts the formats request IDs were handed around in by clients of
+// older releases: hex with a 0x prefix or in upper case, and the 0x hex encoding of the
+// request ID bytes.
+
+// ReplicaIDBits is the number of low bits of the request times taken by the replica ID of
+// the API server
+const ReplicaIDBits = 10
+
+// MaxReplicaID is the largest replica ID of an API server
+const MaxReplicaID = 1<<ReplicaIDBits - 1
+
+// RequestClock hands out request times in unix nanoseconds that are strictly increasing and
+// end with the ReplicaIDBits of the replica ID of the server, moving a request received
+// within the same 2^ReplicaIDBits nanoseconds as the previous one to the next step. The zero
+// value is ready to use, with replica ID 0.
+type RequestClock struct {
+	last    atomic.Uint64
+	now     func() time.Time
+	replica uint64
+}
+
+// NewRequestClock returns a clock for the API server with replica ID replica, of which only
+// the low ReplicaIDBits are used
+func NewRequestClock(replica uint16) *RequestClock {
+	return &RequestClock{replica: uint64(replica) & MaxReplicaID}
+}
+
+// RandomReplicaID draws a replica ID for an API server started without one, from 1 to
+// MaxReplicaID
+func RandomReplicaID() uint16 {
+	return uint16(1 + rand.Intn(MaxReplicaID))
+}
+
+// Next returns the time of a request received now
+func (c *RequestClock) Next() uint64 {
+	now := time.Now
+	if c.now != nil {
+		now = c.now
+	}
+	const step = 1 << ReplicaIDBits
+	t := uint64(now().UnixNano())&^(step-1) | c.replica
+	for {
+		last := c.last.Load()
+		next := t
+		if next <= last {
+			next = last + step
+		}
+		if c.last.CompareAndSwap(last, next) {
+			return next
+		}
+	}
+}
+
+func ParseBlobKey(key string) (BlobKey, error) {
+	key = strings.TrimSpace(key)
+	if decoded, ok := decodeHexRequestID(key); ok {
+		key = decoded
+	}
+	parts := strings.Split(key, "-")
+	if len(parts) != 2 {
+		return BlobKey{}, fmt.Errorf("invalid metadata key: %s", key)
+	}
+	for i, part := range parts {
+		part = strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(part, "0x"), "0X"))
+		if _, err := hex.DecodeString(part); err != nil || part == "" {
+			return BlobKey{}, fmt.Errorf("invalid metadata key: %s", key)
+		}
+		parts[i] = part
+	}
+	return BlobKey{
+		BlobHash:     parts[0],
+		MetadataHash: parts[1],
+	}, nil
+}
+
+// decodeHexRequestID decodes a request ID whose bytes were hex encoded, as clients that
+// treat the request ID as opaque bytes print it
+func decodeHexRequestID(key string) (string, bool) {
+	if !strings.HasPrefix(key, "0x") || strings.Contains(key, "-") {
+		return "", false
+	}
+	decoded, err := hex.DecodeString(key[2:])
+	if err != nil || !strings.Contains(string(decoded), "-") {
+		return "", false
+	}
+	return string(decoded), true
+}
@@ -0,0 +1,67 @@
+package disperser_test
+
+import (
+	"encoding/hex"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestClockUnique(t *testing.T) {
+	var clock disperser.RequestClock
+	var mu sync.Mutex
+	seen := make(map[uint64]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				ts := clock.Next()
+				mu.Lock()
+				seen[ts] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, seen, 8000)
+}
+
+func TestParseBlobKeyFormats(t *testing.T) {
+	key := disperser.BlobKey{BlobHash: "ab01", MetadataHash: "cd02"}
+	for _, id := range []string{
+		key.String(),
+		" ab01-cd02\n",
+		"0xAB01-0xCD02",
+		"0x" + hex.EncodeToString([]byte(key.String())),
+	} {
+		parsed, err := disperser.ParseBlobKey(id)
+		require.NoError(t, err, id)
+		assert.Equal(t, key, parsed, id)
+	}
+
+	for _, id := range []string{"", "ab01", "ab01-cd02-ef03", "ab01-", "xyz-cd02", strings.Repeat("-", 2)} {
+		_, err := disperser.ParseBlobKey(id)
+		assert.Error(t, err, id)
+	}
+}
+
+func TestRequestClockReplicas(t *testing.T) {
+	first := disperser.NewRequestClock(1)
+	second := disperser.NewRequestClock(2)
+	seen := make(map[uint64]bool)
+	for i := 0; i < 1000; i++ {
+		for _, clock := range []*disperser.RequestClock{first, second} {
+			ts := clock.Next()
+			assert.False(t, seen[ts])
+			seen[ts] = true
+		}
+	}
+	assert.Equal(t, uint64(1), first.Next()&disperser.MaxReplicaID)
+	assert.Equal(t, uint64(2), second.Next()&disperser.MaxReplicaID)
+}
@@ -1,9 +1,151 @@
 package disperser
 
+import (
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/interceptors"
+	"github.com/0glabs/0g-da-client/common/tlsconfig"
+	"github.com/0glabs/0g-da-client/core"
+)
+
 const (
 	Localhost = "0.0.0.0"
 )
 
 type ServerConfig struct {
 	GrpcPort string
+	// HTTPPort serves the batch status and certificate endpoints. Disabled if empty.
+	HTTPPort string
+	// HTTPHost is the address HTTPPort is bound to, the loopback address if empty
+	HTTPHost string
+	// ReplicaID tells the request IDs of the API servers sharing a blob store apart, from 1 to
+	// MaxReplicaID. A random ID is drawn if zero.
+	ReplicaID    uint16
+	Interceptors interceptors.Config
+	// Guards bounds the resources of the clients of the grpc server. The message size
+	// defaults to what the largest blob takes.
+	Guards interceptors.GuardConfig
+	// PriorityAccounts are the accounts, API key names or signer addresses, allowed to submit
+	// blobs above the default priority lane
+	PriorityAccounts []string
+	// StatusSubscriptionInterval is how often the status of the blobs watched with
+	// SubscribeBlobStatus is read
+	StatusSubscriptionInterval time.Duration
+	// MaxStatusSubscriptions bounds the concurrent SubscribeBlobStatus streams, each reading
+	// the store at StatusSubscriptionInterval. 1000 if zero.
+	MaxStatusSubscriptions int
+	// MaxClientStatusSubscriptions bounds the concurrent SubscribeBlobStatus streams of a
+	// client address. 10 if zero.
+	MaxClientStatusSubscriptions int
+	// MaxStatusSubscriptionDuration is how long a SubscribeBlobStatus stream is open at most
+	// before it ends with DEADLINE_EXCEEDED. 10 minutes if zero.
+	MaxStatusSubscriptionDuration time.Duration
+	// Dedup answers the dispersals of a blob already dispersed with the earlier request
+	Dedup DedupConfig
+	// Retention reports the storage period of the confirmed blobs to the clients
+	Retention RetentionConfig
+	// ReadRequestsPerMinute bounds the retrievals of each client, 20 if zero. The dispersals
+	// are bounded by the Quotas of their account.
+	ReadRequestsPerMinute int
+	// SecurityParams bounds the security parameters clients request for their blobs
+	SecurityParams SecurityParamLimits
+	// Quotas bounds the bytes and dispersals per second of each account
+	Quotas QuotaConfig
+	// RetrievalQuotas bounds the bytes and concurrent retrievals of each account
+	RetrievalQuotas RetrievalQuotaConfig
+	// RequireSignatures rejects the dispersals not signed by their account
+	RequireSignatures bool
+	// DispersalDomain is the disperser the dispersals are signed for. Signed dispersals are
+	// rejected if it isn't set.
+	DispersalDomain core.DispersalDomain
+	// GatewayToken authenticates the gateways relaying requests, whose clients are limited
+	// by the address the gateway relays rather than that of the gateway. Relayed addresses
+	// are ignored if empty.
+	GatewayToken string
+	// EncoderParams are the parameters of the encoder the encoded size of a blob is
+	// estimated from, core.DefaultEncoderParams if the slice size is zero
+	EncoderParams core.EncoderParams
+	// TLS secures the grpc server, and RetrieverTLS the connections to the retriever
+	TLS          tlsconfig.ServerConfig
+	RetrieverTLS tlsconfig.ClientConfig
+}
+
+// SecurityParamLimits bounds the thresholds of the security parameters requested for the
+// blobs, percentages of the slices of a quorum. A quorum threshold must be at least
+// MinQuorumThreshold, which can't be below core.MinQuorumThreshold, and exceed the adversary
+// threshold by at least MinThresholdGap.
+type SecurityParamLimits struct {
+	MinQuorumThreshold uint8
+	MinThresholdGap    uint8
+}
+
+// DedupConfig detects the dispersals of blobs with the same data and security params as
+// a blob dispersed within Window, which are answered with the request ID of the earlier
+// dispersal instead of being dispersed twice. Dispersals are tracked per API server, at
+// most MaxEntries at a time.
+type DedupConfig struct {
+	Window     time.Duration
+	MaxEntries int
+}
+
+func (c DedupConfig) Enabled() bool {
+	return c.Window > 0
+}
+
+// RetentionConfig is how long the operators keep the slices of the confirmed blobs, which
+// the blob status and the batch certificates report so that clients renew or archive their
+// blobs in time. The storage period of a blob runs for PeriodBlocks from its confirmation
+// block, and its end time is estimated from the confirmation time with BlockTime. A renewal
+// is due within RenewalWindow of the end. The storage period is read from the view function
+// PeriodMethod of PeriodContract if set, PeriodBlocks being used while it can't be read.
+type RetentionConfig struct {
+	PeriodBlocks   uint64
+	PeriodContract string
+	PeriodMethod   string
+	BlockTime      time.Duration
+	RenewalWindow  time.Duration
+}
+
+func (c RetentionConfig) Enabled() bool {
+	return c.PeriodBlocks > 0 || c.PeriodContract != ""
+}
+
+// QuotaConfig bounds the bytes and the dispersals per second of each account with token
+// buckets holding Burst worth of the quotas. The accounts are the signers of the signed
+// dispersals, the API keys sent in the APIKeyHeader, named by APIKeys, and the address of
+// the connection of the other requests, or the client address relayed by an authenticated
+// gateway. The accounts in Allowlist, key names or signer addresses such as those of
+// trusted rollup sequencers, are never limited; the client addresses aren't authenticated
+// so they can't be allowlisted. A quota of 0 is unlimited. The API keys are ignored if none
+// are configured.
+type QuotaConfig struct {
+	BytesPerSecond    int
+	RequestsPerSecond float64
+	Burst             time.Duration
+	APIKeyHeader      string
+	// APIKeys maps the API keys to the names of their accounts
+	APIKeys   map[string]string
+	Allowlist []string
+}
+
+func (c QuotaConfig) Enabled() bool {
+	return c.BytesPerSecond > 0 || c.RequestsPerSecond > 0
+}
+
+// RetrievalQuotaConfig bounds the retrievals of each account, identified and allowlisted as
+// for the dispersals. At most MaxConcurrent retrievals of an account run at once, the others
+// waiting up to QueueTimeout for one to finish. The bytes retrieved are taken from a token
+// bucket refilled at BytesPerSecond, holding Burst worth of it: a retrieval reserves its
+// estimated size, the size of the blob if known or else that of the last retrieval of the
+// account, and is rejected if the bucket doesn't hold it. The difference with the bytes
+// retrieved is settled once done. A bound of 0 is unlimited.
+type RetrievalQuotaConfig struct {
+	BytesPerSecond int
+	Burst          time.Duration
+	MaxConcurrent  int
+	QueueTimeout   time.Duration
+}
+
+func (c RetrievalQuotaConfig) Enabled() bool {
+	return c.BytesPerSecond > 0 || c.MaxConcurrent > 0
 }
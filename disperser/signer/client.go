@@ -6,6 +6,7 @@ import (
 	"io"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/0glabs/0g-da-client/common"
@@ -15,60 +16,105 @@ import (
 	bn "github.com/consensys/gnark-crypto/ecc/bn254"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 const ipv4WithPortPattern = `\b(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)(?::\d{1,5})\b`
 const ipv4Pattern = `\b(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\b`
 const portPattern = `\b(\d{1,5})\b`
 
+const (
+	// ChunkFormatsHeader is the response header in which a signer advertises the chunk
+	// formats it accepts, as a comma separated list of names
+	ChunkFormatsHeader = "x-zgda-chunk-formats"
+	// ChunkFormatHeader is the request header naming the chunk format of the encoded slices.
+	// Requests without it use the legacy format.
+	ChunkFormatHeader = "x-zgda-chunk-format"
+)
+
 type client struct {
 	timeout   time.Duration
 	ipv4Regex *regexp.Regexp
+
+	// chunkFormats are the formats to use in order of preference. The format of each signer
+	// is negotiated from the formats it advertised in its last reply.
+	chunkFormats []core.ChunkFormat
+	negotiated   *sync.Map
+
+	discovery DiscoveryConfig
+	nodes     *fleet
+	// quic is nil unless slices may be sent over QUIC
+	quic *quicTransport
+	// creds secure the grpc connections to the signers
+	creds credentials.TransportCredentials
 }
 
-func NewSignerClient(timeout time.Duration) (disperser.SignerClient, error) {
+// NewSignerClient returns a client offering the chunk formats to signers in order of
+// preference over grpc connections secured by creds, which also secure the QUIC connections
+// if enabled. The observer, if not nil, is notified of
+// the versions of the signers.
+func NewSignerClient(timeout time.Duration, discovery DiscoveryConfig, quicConfig QUICConfig, creds credentials.TransportCredentials, observer FleetObserver, chunkFormats ...core.ChunkFormat) (disperser.SignerClient, error) {
 	regex := regexp.MustCompile(ipv4WithPortPattern)
+	quic, err := newQUICTransport(quicConfig, creds)
+	if err != nil {
+		return nil, err
+	}
 
 	return client{
-		timeout:   timeout,
-		ipv4Regex: regex,
+		timeout:      timeout,
+		ipv4Regex:    regex,
+		chunkFormats: chunkFormats,
+		negotiated:   &sync.Map{},
+		discovery:    discovery,
+		nodes:        newFleet(observer),
+		quic:         quic,
+		creds:        creds,
 	}, nil
 }
 
-func (c client) BatchSign(ctx context.Context, addr string, data []*pb.SignRequest, log common.Logger) ([]*core.Signature, error) {
+// formatAddr returns the ip:port address of a signer socket
+func (c client) formatAddr(addr string) (string, error) {
 	matches := c.ipv4Regex.FindAllString(addr, -1)
-	if len(matches) != 1 {
-		formattedAddr := ""
-		prefix := "http://"
-		if strings.HasPrefix(strings.ToLower(addr), prefix) {
-			addr = addr[len(prefix):]
-		}
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+
+	formattedAddr := ""
+	prefix := "http://"
+	if strings.HasPrefix(strings.ToLower(addr), prefix) {
+		addr = addr[len(prefix):]
+	}
+
+	idx := strings.Index(addr, ":")
+	if idx != -1 {
+		ipv4Reg := regexp.MustCompile(ipv4Pattern)
+		matches := ipv4Reg.FindAllString(addr[:idx], -1)
+		if len(matches) == 1 {
+			formattedAddr = matches[0]
 
-		idx := strings.Index(addr, ":")
-		if idx != -1 {
-			ipv4Reg := regexp.MustCompile(ipv4Pattern)
-			matches := ipv4Reg.FindAllString(addr[:idx], -1)
+			portReg := regexp.MustCompile(portPattern)
+			matches := portReg.FindAllString(addr[idx+1:], -1)
 			if len(matches) == 1 {
-				formattedAddr = matches[0]
-
-				portReg := regexp.MustCompile(portPattern)
-				matches := portReg.FindAllString(addr[idx+1:], -1)
-				if len(matches) == 1 {
-					formattedAddr += ":" + matches[0]
-				} else {
-					formattedAddr = ""
-				}
+				formattedAddr += ":" + matches[0]
+			} else {
+				formattedAddr = ""
 			}
 		}
+	}
 
-		if formattedAddr == "" {
-			return nil, fmt.Errorf("signer addr is not correct: %v", addr)
-		}
+	if formattedAddr == "" {
+		return "", fmt.Errorf("signer addr is not correct: %v", addr)
+	}
+	return formattedAddr, nil
+}
 
-		addr = formattedAddr
-	} else {
-		addr = matches[0]
+func (c client) BatchSign(ctx context.Context, addr string, data []*pb.SignRequest, log common.Logger) ([]*core.Signature, error) {
+	addr, err := c.formatAddr(addr)
+	if err != nil {
+		return nil, err
 	}
 
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, c.timeout)
@@ -76,7 +122,7 @@ func (c client) BatchSign(ctx context.Context, addr string, data []*pb.SignReque
 	conn, err := grpc.DialContext(
 		ctxWithTimeout,
 		addr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(c.creds),
 		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(1024*1024*1024)), // 1 GiB
 	)
 	if err != nil {
@@ -85,22 +131,39 @@ func (c client) BatchSign(ctx context.Context, addr string, data []*pb.SignReque
 	defer conn.Close()
 
 	signer := pb.NewSignerClient(conn)
-	// requests := make([]*pb.SignRequest, 0, len(data))
-	// for i, req := range data {
-	// 	requests[i] = &pb.SignRequest{
-	// 		Epoch:             req.Epoch,
-	// 		ErasureCommitment: req.ErasureCommitment,
-	// 		StorageRoot:       req.StorageRoot,
-	// 		EncodedSlice:      req.EncodedSlice,
-	// 	}
-	// }
-
-	reply, err := signer.BatchSign(ctx, &pb.BatchSignRequest{
-		Requests: data,
-	})
+	if c.discovery.Enabled {
+		if _, ok := c.nodes.get(addr, c.discovery.TTL, time.Now()); !ok {
+			c.discover(ctx, signer, addr, log)
+		}
+	}
+
+	format := c.chunkFormat(addr)
+	requests, release, err := encodeRequests(format, data)
 	if err != nil {
 		return nil, err
 	}
+	// the frames are serialized into the request once it is sent
+	defer release()
+	if format != core.ChunkFormatLegacy {
+		ctx = metadata.AppendToOutgoingContext(ctx, ChunkFormatHeader, format.String())
+	}
+
+	reply, header, err := c.batchSign(ctx, signer, addr, &pb.BatchSignRequest{
+		Requests: requests,
+	}, log)
+	if err != nil {
+		if format != core.ChunkFormatLegacy {
+			code := status.Code(err)
+			if code == codes.InvalidArgument || code == codes.Unimplemented {
+				// the signer may have been downgraded, renegotiate on its next reply
+				log.Warn("[signer] signer rejected chunk format, falling back to legacy", "addr", addr, "format", format, "err", err)
+				c.negotiated.Delete(addr)
+				c.nodes.remove(addr)
+			}
+		}
+		return nil, err
+	}
+	c.record(addr, header)
 
 	sigBytes := reply.GetSignatures()
 	signatures := make([]*core.Signature, len(data))
@@ -121,6 +184,57 @@ func (c client) BatchSign(ctx context.Context, addr string, data []*pb.SignReque
 	return signatures, nil
 }
 
+func (c client) chunkFormat(addr string) core.ChunkFormat {
+	if format, ok := c.negotiated.Load(addr); ok {
+		return format.(core.ChunkFormat)
+	}
+	return core.ChunkFormatLegacy
+}
+
+func (c client) negotiate(addr string, header metadata.MD) {
+	if len(c.chunkFormats) == 0 {
+		return
+	}
+	supported := make([]core.ChunkFormat, 0)
+	for _, list := range header.Get(ChunkFormatsHeader) {
+		supported = append(supported, core.ParseChunkFormats(list)...)
+	}
+	c.negotiated.Store(addr, core.NegotiateChunkFormat(c.chunkFormats, supported))
+}
+
+// encodeRequests returns copies of the requests with the encoded slices in the given
+// format. The requests themselves are left untouched since they may be sent to other
+// signers concurrently. The frames are taken from core.ChunkBufferPool, calling release
+// returns them once the requests are sent.
+func encodeRequests(format core.ChunkFormat, data []*pb.SignRequest) (requests []*pb.SignRequest, release func(), err error) {
+	if format == core.ChunkFormatLegacy {
+		return data, func() {}, nil
+	}
+	releases := make([]func(), 0, len(data))
+	release = func() {
+		for _, r := range releases {
+			r()
+		}
+	}
+	requests = make([]*pb.SignRequest, len(data))
+	for i, req := range data {
+		encodedSlice, releaseFrame, err := core.EncodeChunksPooled(core.ChunkBufferPool, format, req.EncodedSlice)
+		if err != nil {
+			release()
+			return nil, nil, fmt.Errorf("failed to encode slices in format %s: %w", format, err)
+		}
+		releases = append(releases, releaseFrame)
+		requests[i] = &pb.SignRequest{
+			Epoch:             req.Epoch,
+			QuorumId:          req.QuorumId,
+			ErasureCommitment: req.ErasureCommitment,
+			StorageRoot:       req.StorageRoot,
+			EncodedSlice:      encodedSlice,
+		}
+	}
+	return requests, release, nil
+}
+
 func toBigEndian(b []byte) ([]byte, error) {
 	if len(b) != bn.SizeOfG1AffineUncompressed {
 		return nil, io.ErrShortBuffer
@@ -1,11 +1,17 @@
 package signer
 
 import (
+	"bytes"
 	"encoding/hex"
 	"io"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/0glabs/0g-da-client/core"
+	pb "github.com/0glabs/0g-da-client/disperser/api/grpc/signer"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
 )
 
 func TestToBigEndian(t *testing.T) {
@@ -36,3 +42,83 @@ func TestToBigEndian(t *testing.T) {
 		assert.Equal(t, tc.expectedOutput, output, "Output should match")
 	}
 }
+
+func TestEncodeRequests(t *testing.T) {
+	slices := [][]byte{bytes.Repeat([]byte{1}, 512), {}, bytes.Repeat([]byte{2, 3}, 300)}
+	data := []*pb.SignRequest{{Epoch: 1, QuorumId: 2, EncodedSlice: slices}}
+	stats := core.ChunkBufferPool.Stats()
+
+	for _, format := range []core.ChunkFormat{core.ChunkFormatLegacy, core.ChunkFormatFramed, core.ChunkFormatFramedFlate} {
+		requests, release, err := encodeRequests(format, data)
+		assert.NoError(t, err)
+		assert.Equal(t, slices, data[0].EncodedSlice, "requests must not be modified")
+
+		decoded, err := core.DecodeChunks(format, requests[0].EncodedSlice)
+		assert.NoError(t, err)
+		assert.Equal(t, len(slices), len(decoded))
+		for i := range slices {
+			assert.True(t, bytes.Equal(slices[i], decoded[i]))
+		}
+		if format == core.ChunkFormatFramedFlate {
+			assert.Less(t, len(requests[0].EncodedSlice[0]), 512)
+		}
+		release()
+	}
+
+	// the frames of both framed formats were taken from the pool and returned to it
+	assert.Equal(t, stats.Gets+2, core.ChunkBufferPool.Stats().Gets)
+	assert.Equal(t, stats.Puts+2, core.ChunkBufferPool.Stats().Puts)
+}
+
+func TestNegotiateChunkFormat(t *testing.T) {
+	c := client{
+		chunkFormats: []core.ChunkFormat{core.ChunkFormatFramedFlate, core.ChunkFormatFramed},
+		negotiated:   &sync.Map{},
+	}
+	assert.Equal(t, core.ChunkFormatLegacy, c.chunkFormat("a"))
+
+	c.negotiate("a", metadata.Pairs(ChunkFormatsHeader, "framed, zstd"))
+	assert.Equal(t, core.ChunkFormatFramed, c.chunkFormat("a"))
+	c.negotiate("b", metadata.MD{})
+	assert.Equal(t, core.ChunkFormatLegacy, c.chunkFormat("b"))
+}
+
+type fleetRecorder struct {
+	nodes map[string]*NodeInfo
+	calls int
+}
+
+func (r *fleetRecorder) ObserveSignerFleet(nodes map[string]*NodeInfo) {
+	r.nodes = nodes
+	r.calls++
+}
+
+func TestRecordNodeInfo(t *testing.T) {
+	observer := &fleetRecorder{}
+	c := client{
+		chunkFormats: []core.ChunkFormat{core.ChunkFormatFramedFlate, core.ChunkFormatFramed},
+		negotiated:   &sync.Map{},
+		nodes:        newFleet(observer),
+	}
+
+	info := c.record("a", metadata.Pairs(NodeVersionHeader, "v1.2.0", ChunkFormatsHeader, "framed", NodeFeaturesHeader, "compression, streaming"))
+	assert.Equal(t, "v1.2.0", info.Version)
+	assert.Equal(t, []core.ChunkFormat{core.ChunkFormatFramed}, info.ChunkFormats)
+	assert.True(t, info.HasFeature("compression"))
+	assert.Equal(t, core.ChunkFormatFramed, c.chunkFormat("a"))
+
+	c.record("b", metadata.MD{})
+	assert.Equal(t, 2, observer.calls)
+	assert.Equal(t, UnknownVersion, observer.nodes["b"].Version)
+	assert.Equal(t, core.ChunkFormatLegacy, c.chunkFormat("b"))
+
+	// an unchanged reply refreshes the cache without notifying the observer
+	c.record("b", metadata.MD{})
+	assert.Equal(t, 2, observer.calls)
+
+	now := time.Now()
+	_, ok := c.nodes.get("a", time.Minute, now)
+	assert.True(t, ok)
+	_, ok = c.nodes.get("a", time.Minute, now.Add(2*time.Minute))
+	assert.False(t, ok)
+}
@@ -0,0 +1,171 @@
+package signer
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/core"
+	pb "github.com/0glabs/0g-da-client/disperser/api/grpc/signer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// NodeVersionHeader is the response header in which a signer advertises its node version
+	NodeVersionHeader = "x-zgda-node-version"
+	// NodeFeaturesHeader is the response header in which a signer advertises the optional
+	// features it supports, as a comma separated list of names
+	NodeFeaturesHeader = "x-zgda-node-features"
+
+	// UnknownVersion is the version of signers that don't advertise one
+	UnknownVersion = "unknown"
+)
+
+// DiscoveryConfig configures the discovery of the version and features of signers before
+// they are sent slices to sign
+type DiscoveryConfig struct {
+	Enabled bool
+	// TTL is how long the discovered info of a signer is used before it is queried again.
+	// Every reply of a signer refreshes its info.
+	TTL time.Duration
+}
+
+// NodeInfo is what a signer advertised in its last reply
+type NodeInfo struct {
+	Version      string
+	ChunkFormats []core.ChunkFormat
+	Features     []string
+	UpdatedAt    time.Time
+}
+
+// HasFeature returns whether the signer advertised the feature
+func (n *NodeInfo) HasFeature(feature string) bool {
+	for _, f := range n.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// FleetObserver is notified of the info of all known signers whenever the info of one changes
+type FleetObserver interface {
+	ObserveSignerFleet(nodes map[string]*NodeInfo)
+}
+
+// parseNodeInfo reads the info advertised in the metadata of a reply
+func parseNodeInfo(md metadata.MD, now time.Time) *NodeInfo {
+	info := &NodeInfo{Version: UnknownVersion, UpdatedAt: now}
+	if values := md.Get(NodeVersionHeader); len(values) > 0 && strings.TrimSpace(values[0]) != "" {
+		info.Version = strings.TrimSpace(values[0])
+	}
+	for _, list := range md.Get(ChunkFormatsHeader) {
+		info.ChunkFormats = append(info.ChunkFormats, core.ParseChunkFormats(list)...)
+	}
+	for _, list := range md.Get(NodeFeaturesHeader) {
+		for _, feature := range strings.Split(list, ",") {
+			if feature = strings.TrimSpace(feature); feature != "" {
+				info.Features = append(info.Features, feature)
+			}
+		}
+	}
+	sort.Strings(info.Features)
+	return info
+}
+
+// fleet is the cache of the info advertised by signers, keyed by address
+type fleet struct {
+	mu       sync.Mutex
+	nodes    map[string]*NodeInfo
+	observer FleetObserver
+}
+
+func newFleet(observer FleetObserver) *fleet {
+	return &fleet{nodes: make(map[string]*NodeInfo), observer: observer}
+}
+
+// get returns the info of the signer if it was updated within ttl
+func (f *fleet) get(addr string, ttl time.Duration, now time.Time) (*NodeInfo, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	info, ok := f.nodes[addr]
+	if !ok || now.Sub(info.UpdatedAt) > ttl {
+		return nil, false
+	}
+	return info, true
+}
+
+func (f *fleet) update(addr string, info *NodeInfo) {
+	f.mu.Lock()
+	old, known := f.nodes[addr]
+	f.nodes[addr] = info
+	changed := !known || old.Version != info.Version || !equalFormats(old.ChunkFormats, info.ChunkFormats)
+	var snapshot map[string]*NodeInfo
+	if changed && f.observer != nil {
+		snapshot = f.snapshot()
+	}
+	f.mu.Unlock()
+
+	if snapshot != nil {
+		f.observer.ObserveSignerFleet(snapshot)
+	}
+}
+
+func (f *fleet) remove(addr string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.nodes, addr)
+}
+
+// snapshot copies the nodes. The caller must hold mu.
+func (f *fleet) snapshot() map[string]*NodeInfo {
+	nodes := make(map[string]*NodeInfo, len(f.nodes))
+	for addr, info := range f.nodes {
+		nodes[addr] = info
+	}
+	return nodes
+}
+
+func equalFormats(a, b []core.ChunkFormat) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// discover queries the info of a signer with an empty BatchSign request, which signers
+// answer without signing anything. Signers predating discovery are recorded with an
+// unknown version and get the legacy chunk format.
+func (c client) discover(ctx context.Context, signer pb.SignerClient, addr string, log common.Logger) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var header, trailer metadata.MD
+	_, err := signer.BatchSign(ctx, &pb.BatchSignRequest{}, grpc.Header(&header), grpc.Trailer(&trailer))
+	if err != nil && (ctx.Err() != nil || status.Code(err) == codes.Unavailable) {
+		log.Warn("[signer] failed to discover signer", "addr", addr, "err", err)
+		return
+	}
+	info := c.record(addr, metadata.Join(header, trailer))
+	log.Debug("[signer] discovered signer", "addr", addr, "version", info.Version, "chunkFormats", info.ChunkFormats, "features", info.Features)
+}
+
+// record caches the info advertised in a reply of a signer and negotiates its chunk format
+func (c client) record(addr string, md metadata.MD) *NodeInfo {
+	info := parseNodeInfo(md, time.Now())
+	c.nodes.update(addr, info)
+	c.negotiate(addr, md)
+	c.quic.negotiate(addr, md)
+	return info
+}
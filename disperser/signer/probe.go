@@ -0,0 +1,71 @@
+package signer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/0glabs/0g-da-client/disperser"
+	pb "github.com/0glabs/0g-da-client/disperser/api/grpc/signer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BandwidthProbesFeature is the feature advertised by the signers serving ProbeBandwidth
+const BandwidthProbesFeature = "bandwidth-probes"
+
+var _ disperser.BandwidthProber = client{}
+
+// Probe uploads payload to the signer at addr with ProbeBandwidth, which the signer
+// acknowledges with the size it received. The round trip of an empty probe is subtracted,
+// so the duration returned is the time the upload of the payload took. The signers not
+// serving the probes aren't measured.
+func (c client) Probe(ctx context.Context, addr string, payload []byte) (time.Duration, error) {
+	addr, err := c.formatAddr(addr)
+	if err != nil {
+		return 0, err
+	}
+	if c.discovery.Enabled {
+		if info, ok := c.nodes.get(addr, c.discovery.TTL, time.Now()); ok && !info.HasFeature(BandwidthProbesFeature) {
+			return 0, disperser.ErrBandwidthProbesUnsupported
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(c.creds))
+	if err != nil {
+		return 0, fmt.Errorf("failed to dial signer: %w", err)
+	}
+	defer conn.Close()
+
+	signer := pb.NewSignerClient(conn)
+	// the empty probe also connects to the signer
+	roundTrip, err := probeRequest(ctx, signer, nil)
+	if err != nil {
+		return 0, err
+	}
+	upload, err := probeRequest(ctx, signer, payload)
+	if err != nil {
+		return 0, err
+	}
+	return max(upload-roundTrip, 0), nil
+}
+
+// probeRequest times a probe, which fails unless the signer received all of payload
+func probeRequest(ctx context.Context, signer pb.SignerClient, payload []byte) (time.Duration, error) {
+	start := time.Now()
+	reply, err := signer.ProbeBandwidth(ctx, &pb.ProbeRequest{Payload: payload})
+	elapsed := time.Since(start)
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.Unimplemented:
+		return 0, fmt.Errorf("%w: %v", disperser.ErrBandwidthProbesUnsupported, err)
+	default:
+		return 0, err
+	}
+	if reply.GetSize() != uint64(len(payload)) {
+		return 0, fmt.Errorf("signer received %d bytes of a %d bytes probe", reply.GetSize(), len(payload))
+	}
+	return elapsed, nil
+}
@@ -0,0 +1,55 @@
+package signer
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/disperser"
+	pb "github.com/0glabs/0g-da-client/disperser/api/grpc/signer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// probeServer acknowledges the probes, short of drop bytes
+type probeServer struct {
+	pb.UnimplementedSignerServer
+	drop int
+}
+
+func (s *probeServer) ProbeBandwidth(ctx context.Context, req *pb.ProbeRequest) (*pb.ProbeReply, error) {
+	size := len(req.GetPayload())
+	if size > 0 {
+		size -= s.drop
+	}
+	return &pb.ProbeReply{Size: uint64(size)}, nil
+}
+
+func TestProbe(t *testing.T) {
+	ctx := context.Background()
+	c := client{
+		timeout:   time.Second,
+		ipv4Regex: regexp.MustCompile(ipv4WithPortPattern),
+		nodes:     newFleet(nil),
+		creds:     insecure.NewCredentials(),
+	}
+	payload := make([]byte, 1024)
+
+	addr := serveSigner(t, &probeServer{})
+	elapsed, err := c.Probe(ctx, addr, payload)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, elapsed, time.Duration(0))
+
+	// a signer that didn't receive the whole payload
+	short := serveSigner(t, &probeServer{drop: 1})
+	_, err = c.Probe(ctx, short, payload)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, disperser.ErrBandwidthProbesUnsupported)
+
+	// signers predating the method
+	legacy := serveSigner(t, &pb.UnimplementedSignerServer{})
+	_, err = c.Probe(ctx, legacy, payload)
+	assert.ErrorIs(t, err, disperser.ErrBandwidthProbesUnsupported)
+}
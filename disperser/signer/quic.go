@@ -0,0 +1,224 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/tlsconfig"
+	pb "github.com/0glabs/0g-da-client/disperser/api/grpc/signer"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// QUICAddrHeader is the response header in which a signer advertises the address, as
+	// host:port or :port on the host of its gRPC address, where it serves BatchSign over QUIC.
+	// Addresses on other hosts are ignored. Like the chunk formats, it must be sent in every
+	// reply, over either transport.
+	QUICAddrHeader = "x-zgda-quic-addr"
+
+	// QUICBatchSignPath is the path of BatchSign over QUIC. The request is a POST of the
+	// protobuf encoded BatchSignRequest over HTTP/3, with the request metadata as headers,
+	// and the reply the protobuf encoded BatchSignReply with the reply metadata as headers.
+	QUICBatchSignPath = "/signer.Signer/BatchSign"
+
+	quicContentType = "application/x-protobuf"
+	// maxQUICReplySize bounds the reply like the max message size of the gRPC transport
+	maxQUICReplySize = 1024 * 1024 * 1024 // 1 GiB
+)
+
+// QUICConfig configures the experimental dispersal of slices over QUIC to the signers that
+// advertise a QUIC address, which fares better than gRPC over TCP on lossy, high latency
+// links. Signers are sent their slices over gRPC until they advertise one, and whenever a
+// request over QUIC fails. The QUIC connections are secured by the TLS config of the gRPC
+// connections, so it requires TLS to the signers.
+type QUICConfig struct {
+	Enabled bool
+	// RetryAfter is how long a signer whose request over QUIC failed is sent its slices
+	// over gRPC before QUIC is tried again
+	RetryAfter time.Duration
+}
+
+// quicTransport sends the BatchSign requests over QUIC to the signers that negotiated it
+type quicTransport struct {
+	config QUICConfig
+	client *http.Client
+
+	mu sync.Mutex
+	// addrs are the QUIC addresses advertised by the signers, keyed by gRPC address
+	addrs map[string]string
+	// suspended are the times until which the signers are sent requests over gRPC
+	suspended map[string]time.Time
+	now       func() time.Time
+}
+
+// newQUICTransport returns the QUIC transport of config, nil if it is disabled. The
+// connections are secured by the TLS config of creds, which must have TLS enabled.
+func newQUICTransport(config QUICConfig, creds credentials.TransportCredentials) (*quicTransport, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+	source, ok := creds.(tlsconfig.Source)
+	if !ok {
+		return nil, errors.New("sending slices over QUIC requires TLS to the signers")
+	}
+	return &quicTransport{
+		config: config,
+		client: &http.Client{
+			Transport: &http3.RoundTripper{
+				QuicConfig: &quic.Config{KeepAlivePeriod: 15 * time.Second},
+				// each connection is secured by the tls config last loaded, like the grpc ones
+				Dial: func(ctx context.Context, addr string, tlsConfig *tls.Config, quicConfig *quic.Config) (quic.EarlyConnection, error) {
+					current := source.TLSConfig()
+					if current.ServerName == "" {
+						current.ServerName = tlsConfig.ServerName
+					}
+					current.NextProtos = tlsConfig.NextProtos
+					return quic.DialAddrEarly(ctx, addr, current, quicConfig)
+				},
+			},
+		},
+		addrs:     make(map[string]string),
+		suspended: make(map[string]time.Time),
+		now:       time.Now,
+	}, nil
+}
+
+// negotiate records the QUIC address a signer advertised in a reply, if any
+func (q *quicTransport) negotiate(addr string, md metadata.MD) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	values := md.Get(QUICAddrHeader)
+	if len(values) == 0 || strings.TrimSpace(values[0]) == "" {
+		delete(q.addrs, addr)
+		return
+	}
+	quicAddr, err := quicAddrOn(addr, strings.TrimSpace(values[0]))
+	if err != nil {
+		delete(q.addrs, addr)
+		return
+	}
+	q.addrs[addr] = quicAddr
+}
+
+// quicAddrOn returns the QUIC address advertised by the signer at addr, which must be on
+// the host of addr, so signers can't have the slices sent to other hosts
+func quicAddrOn(addr string, advertised string) (string, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+	quicHost, port, err := net.SplitHostPort(advertised)
+	if err != nil {
+		return "", err
+	}
+	if quicHost != "" && quicHost != host {
+		return "", fmt.Errorf("QUIC address %s is not on host %s", advertised, host)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+		return "", fmt.Errorf("QUIC address %s has an invalid port", advertised)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// addr returns the QUIC address of a signer, false if it is sent its requests over gRPC
+func (q *quicTransport) addr(addr string) (string, bool) {
+	if q == nil {
+		return "", false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if until, ok := q.suspended[addr]; ok {
+		if q.now().Before(until) {
+			return "", false
+		}
+		delete(q.suspended, addr)
+	}
+	quicAddr, ok := q.addrs[addr]
+	return quicAddr, ok
+}
+
+// suspend sends the requests of a signer over gRPC for the retry period
+func (q *quicTransport) suspend(addr string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.suspended[addr] = q.now().Add(q.config.RetryAfter)
+}
+
+func (q *quicTransport) batchSign(ctx context.Context, quicAddr string, request *pb.BatchSignRequest) (*pb.BatchSignReply, metadata.MD, error) {
+	body, err := proto.Marshal(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://"+quicAddr+QUICBatchSignPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	httpRequest.Header.Set("Content-Type", quicContentType)
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		for key, values := range md {
+			for _, value := range values {
+				httpRequest.Header.Add(key, value)
+			}
+		}
+	}
+
+	response, err := q.client.Do(httpRequest)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer response.Body.Close()
+	replyBody, err := io.ReadAll(io.LimitReader(response.Body, maxQUICReplySize))
+	if err != nil {
+		return nil, nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("signer answered %s: %s", response.Status, strings.TrimSpace(string(replyBody)))
+	}
+	reply := &pb.BatchSignReply{}
+	if err := proto.Unmarshal(replyBody, reply); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode reply: %w", err)
+	}
+	header := metadata.MD{}
+	for key, values := range response.Header {
+		header.Append(key, values...)
+	}
+	return reply, header, nil
+}
+
+// batchSign sends a BatchSign request to a signer over QUIC if it negotiated it, falling
+// back to gRPC if the request over QUIC fails
+func (c client) batchSign(ctx context.Context, signer pb.SignerClient, addr string, request *pb.BatchSignRequest, log common.Logger) (*pb.BatchSignReply, metadata.MD, error) {
+	if quicAddr, ok := c.quic.addr(addr); ok {
+		reply, header, err := c.quic.batchSign(ctx, quicAddr, request)
+		if err == nil {
+			return reply, header, nil
+		}
+		if ctx.Err() != nil {
+			return nil, nil, err
+		}
+		log.Warn("[signer] failed to send slices over QUIC, falling back to gRPC", "addr", addr, "quicAddr", quicAddr, "retryAfter", c.quic.config.RetryAfter, "err", err)
+		c.quic.suspend(addr)
+	}
+	var header metadata.MD
+	reply, err := signer.BatchSign(ctx, request, grpc.Header(&header))
+	return reply, header, err
+}
@@ -0,0 +1,151 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/common/tlsconfig"
+	pb "github.com/0glabs/0g-da-client/disperser/api/grpc/signer"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+type grpcSigner struct {
+	pb.SignerClient
+	requests int
+}
+
+func (s *grpcSigner) BatchSign(ctx context.Context, in *pb.BatchSignRequest, opts ...grpc.CallOption) (*pb.BatchSignReply, error) {
+	s.requests++
+	return &pb.BatchSignReply{Signatures: [][]byte{{2}}}, nil
+}
+
+// serveQUIC serves BatchSign over QUIC on localhost, answering with one signature and
+// echoing the chunk format of the request. It returns the address and a file holding the
+// certificate of the server.
+func serveQUIC(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), NotAfter: time.Now().Add(time.Hour), IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)}}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	certFile := filepath.Join(t.TempDir(), "signer.crt")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0o600))
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &http3.Server{
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{cert}, PrivateKey: key}}},
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			request := &pb.BatchSignRequest{}
+			if r.URL.Path != QUICBatchSignPath || proto.Unmarshal(body, request) != nil {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			reply, _ := proto.Marshal(&pb.BatchSignReply{Signatures: [][]byte{{1}}})
+			w.Header().Set(ChunkFormatHeader, r.Header.Get(ChunkFormatHeader))
+			_, _ = w.Write(reply)
+		}),
+	}
+	go func() { _ = server.Serve(conn) }()
+	t.Cleanup(func() { _ = server.Close() })
+	return conn.LocalAddr().String(), certFile
+}
+
+// newTestQUICTransport returns a QUIC transport trusting the certificates of caFile
+func newTestQUICTransport(t *testing.T, caFile string) *quicTransport {
+	creds, err := tlsconfig.ClientCredentials(tlsconfig.ClientConfig{Enabled: true, CAFile: caFile}, mock.NewLogger(false))
+	require.NoError(t, err)
+	q, err := newQUICTransport(QUICConfig{Enabled: true, RetryAfter: time.Minute}, creds)
+	require.NoError(t, err)
+	q.client.Transport.(*http3.RoundTripper).QuicConfig.HandshakeIdleTimeout = 500 * time.Millisecond
+	return q
+}
+
+func TestQUICTransport(t *testing.T) {
+	logger := mock.NewLogger(false)
+	quicAddr, certFile := serveQUIC(t)
+	_, port, err := net.SplitHostPort(quicAddr)
+	require.NoError(t, err)
+
+	c := client{quic: newTestQUICTransport(t, certFile)}
+	fallback := &grpcSigner{}
+	request := &pb.BatchSignRequest{Requests: []*pb.SignRequest{{Epoch: 1}}}
+
+	// signers are sent their slices over gRPC until they advertise a QUIC address
+	reply, _, err := c.batchSign(context.Background(), fallback, "127.0.0.1:9000", request, logger)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{{2}}, reply.Signatures)
+
+	c.quic.negotiate("127.0.0.1:9000", metadata.Pairs(QUICAddrHeader, ":"+port))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), ChunkFormatHeader, "framed")
+	reply, header, err := c.batchSign(ctx, fallback, "127.0.0.1:9000", request, logger)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{{1}}, reply.Signatures)
+	assert.Equal(t, []string{"framed"}, header.Get(ChunkFormatHeader))
+	assert.Equal(t, 1, fallback.requests)
+
+	// a failed request over QUIC falls back to gRPC and suspends QUIC for the signer
+	c.quic.negotiate("127.0.0.1:9001", metadata.Pairs(QUICAddrHeader, "127.0.0.1:1"))
+	reply, _, err = c.batchSign(context.Background(), fallback, "127.0.0.1:9001", request, logger)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{{2}}, reply.Signatures)
+	_, ok := c.quic.addr("127.0.0.1:9001")
+	assert.False(t, ok)
+	c.quic.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	_, ok = c.quic.addr("127.0.0.1:9001")
+	assert.True(t, ok)
+}
+
+func TestQUICTransportSecurity(t *testing.T) {
+	logger := mock.NewLogger(false)
+	quicAddr, _ := serveQUIC(t)
+	_, port, err := net.SplitHostPort(quicAddr)
+	require.NoError(t, err)
+	request := &pb.BatchSignRequest{Requests: []*pb.SignRequest{{Epoch: 1}}}
+
+	// QUIC is only used over TLS to the signers
+	_, err = newQUICTransport(QUICConfig{Enabled: true}, insecure.NewCredentials())
+	assert.Error(t, err)
+
+	// signers whose certificate isn't trusted are sent their slices over gRPC
+	_, otherCA := serveQUIC(t)
+	c := client{quic: newTestQUICTransport(t, otherCA)}
+	fallback := &grpcSigner{}
+	c.quic.negotiate("127.0.0.1:9000", metadata.Pairs(QUICAddrHeader, ":"+port))
+	reply, _, err := c.batchSign(context.Background(), fallback, "127.0.0.1:9000", request, logger)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{{2}}, reply.Signatures)
+	assert.Equal(t, 1, fallback.requests)
+
+	// QUIC addresses on other hosts, or not addresses at all, are ignored
+	for _, advertised := range []string{"10.0.0.1:" + port, "example.com:" + port, "127.0.0.1", ":0", ":http"} {
+		c.quic.negotiate("127.0.0.1:9001", metadata.Pairs(QUICAddrHeader, advertised))
+		_, ok := c.quic.addr("127.0.0.1:9001")
+		assert.False(t, ok, advertised)
+	}
+	c.quic.negotiate("127.0.0.1:9001", metadata.Pairs(QUICAddrHeader, "127.0.0.1:"+port))
+	negotiated, ok := c.quic.addr("127.0.0.1:9001")
+	assert.True(t, ok)
+	assert.Equal(t, quicAddr, negotiated)
+}
@@ -0,0 +1,61 @@
+package signer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/0glabs/0g-da-client/disperser"
+	pb "github.com/0glabs/0g-da-client/disperser/api/grpc/signer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SliceReadsFeature is the feature advertised by the signers serving GetSlice
+const SliceReadsFeature = "slice-reads"
+
+var _ disperser.SliceReader = client{}
+
+// ReadSlice asks the signer at addr for the slice at sliceIndex of the blob with storageRoot
+// it stored in the epoch and quorum
+func (c client) ReadSlice(ctx context.Context, addr string, epoch, quorumID uint64, storageRoot []byte, sliceIndex int) ([]byte, error) {
+	addr, err := c.formatAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	if c.discovery.Enabled {
+		if info, ok := c.nodes.get(addr, c.discovery.TTL, time.Now()); ok && !info.HasFeature(SliceReadsFeature) {
+			return nil, disperser.ErrSliceReadsUnsupported
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	conn, err := grpc.DialContext(
+		ctx,
+		addr,
+		grpc.WithTransportCredentials(c.creds),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(1024*1024*1024)), // 1 GiB
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial signer: %w", err)
+	}
+	defer conn.Close()
+
+	reply, err := pb.NewSignerClient(conn).GetSlice(ctx, &pb.GetSliceRequest{
+		Epoch:       epoch,
+		QuorumId:    quorumID,
+		StorageRoot: storageRoot,
+		Index:       uint64(sliceIndex),
+	})
+	switch status.Code(err) {
+	case codes.OK:
+		return reply.GetEncodedSlice(), nil
+	case codes.NotFound:
+		return nil, fmt.Errorf("%w: %v", disperser.ErrSliceNotFound, err)
+	case codes.Unimplemented:
+		return nil, fmt.Errorf("%w: %v", disperser.ErrSliceReadsUnsupported, err)
+	default:
+		return nil, err
+	}
+}
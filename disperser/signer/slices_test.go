@@ -0,0 +1,71 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/disperser"
+	pb "github.com/0glabs/0g-da-client/disperser/api/grpc/signer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// sliceServer serves the slices of one storage root
+type sliceServer struct {
+	pb.UnimplementedSignerServer
+	storageRoot []byte
+	slices      [][]byte
+}
+
+func (s *sliceServer) GetSlice(ctx context.Context, req *pb.GetSliceRequest) (*pb.GetSliceReply, error) {
+	if !bytes.Equal(req.GetStorageRoot(), s.storageRoot) || req.GetIndex() >= uint64(len(s.slices)) {
+		return nil, status.Error(codes.NotFound, "no such slice")
+	}
+	return &pb.GetSliceReply{EncodedSlice: s.slices[req.GetIndex()]}, nil
+}
+
+func serveSigner(t *testing.T, server pb.SignerServer) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	pb.RegisterSignerServer(s, server)
+	go func() { _ = s.Serve(listener) }()
+	t.Cleanup(s.Stop)
+	return listener.Addr().String()
+}
+
+func TestReadSlice(t *testing.T) {
+	ctx := context.Background()
+	c := client{
+		timeout:   time.Second,
+		ipv4Regex: regexp.MustCompile(ipv4WithPortPattern),
+		nodes:     newFleet(nil),
+		creds:     insecure.NewCredentials(),
+	}
+
+	addr := serveSigner(t, &sliceServer{storageRoot: []byte{9}, slices: [][]byte{{0}, {1}}})
+	slice, err := c.ReadSlice(ctx, addr, 1, 0, []byte{9}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1}, slice)
+	_, err = c.ReadSlice(ctx, addr, 1, 0, []byte{8}, 1)
+	assert.ErrorIs(t, err, disperser.ErrSliceNotFound)
+
+	// signers predating the method
+	legacy := serveSigner(t, &pb.UnimplementedSignerServer{})
+	_, err = c.ReadSlice(ctx, legacy, 1, 0, []byte{9}, 1)
+	assert.ErrorIs(t, err, disperser.ErrSliceReadsUnsupported)
+
+	// signers whose features are known aren't asked unless they advertise it
+	c.discovery = DiscoveryConfig{Enabled: true, TTL: time.Minute}
+	c.nodes.update(addr, &NodeInfo{Version: "v1", UpdatedAt: time.Now()})
+	_, err = c.ReadSlice(ctx, addr, 1, 0, []byte{9}, 1)
+	assert.ErrorIs(t, err, disperser.ErrSliceReadsUnsupported)
+}
@@ -2,6 +2,8 @@ package disperser
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/0glabs/0g-da-client/common"
 	"github.com/0glabs/0g-da-client/core"
@@ -11,3 +13,27 @@ import (
 type SignerClient interface {
 	BatchSign(ctx context.Context, addr string, data []*pb.SignRequest, log common.Logger) ([]*core.Signature, error)
 }
+
+// BandwidthProber measures the upload bandwidth to the signers
+type BandwidthProber interface {
+	// Probe uploads payload to the signer at addr, returning how long the upload took
+	Probe(ctx context.Context, addr string, payload []byte) (time.Duration, error)
+}
+
+// ErrBandwidthProbesUnsupported is returned by a BandwidthProber when the signer doesn't
+// serve the bandwidth probes
+var ErrBandwidthProbesUnsupported = errors.New("signer doesn't serve bandwidth probes")
+
+// ErrSliceNotFound is returned by a SliceReader when the signer doesn't hold the slice
+var ErrSliceNotFound = errors.New("slice not found")
+
+// ErrSliceReadsUnsupported is returned by a SliceReader when the signer doesn't serve the
+// slices it stored
+var ErrSliceReadsUnsupported = errors.New("signer doesn't serve slice reads")
+
+// SliceReader reads back the encoded slices the signers stored for the blobs they signed
+type SliceReader interface {
+	// ReadSlice returns the encoded slice at sliceIndex of the blob with storageRoot in the
+	// epoch and quorum, as served by the signer at addr
+	ReadSlice(ctx context.Context, addr string, epoch, quorumID uint64, storageRoot []byte, sliceIndex int) ([]byte, error)
+}
@@ -0,0 +1,414 @@
+// Package snapshot copies the state of a disperser to another instance, so that a new
+// version can be started next to the old one and take over its queued blobs.
+//
+// The queue of the disperser is the blobs of the blob store that haven't reached a final
+// status, the finalized blobs not yet moved to the kv store included, the batcher keeping
+// the blobs it is working on in memory only, along with the journals of the batcher: the
+// encoding journal, which records the blobs already encoded, and the batch journal, which
+// records the batches in flight. A snapshot holds them and the dispersal nonces of the
+// signers, so that the signed dispersals accepted by the old instance can't be replayed on
+// the new one, and is consistent if the api server and batcher are stopped while it is
+// taken: the journals can't be opened while the batcher runs.
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/leveldb"
+)
+
+// Version is the version of the snapshot format. Version 1 snapshots, without the
+// finalized blobs, the batch journal and the dispersal nonces, are still restored.
+const Version = 2
+
+const (
+	manifestEntry   = "manifest.json"
+	noncesEntry     = "nonces.json"
+	blobsDir        = "blobs"
+	journalDir      = "journal"
+	batchJournalDir = "batch-journal"
+)
+
+// Statuses are the statuses of the blobs a snapshot holds, those a disperser still acts on
+var Statuses = []disperser.BlobStatus{
+	disperser.Processing,
+	disperser.Quarantined,
+	disperser.DeadLettered,
+	disperser.Confirmed,
+	disperser.Finalized,
+}
+
+// Journals are the paths of the leveldb journals of the batcher a snapshot holds, each left
+// out if empty
+type Journals struct {
+	// Encoding is the encoding journal, which records the blobs already encoded
+	Encoding string
+	// Batch is the batch journal, which records the batches in flight
+	Batch string
+}
+
+// nonce is the dispersal nonce window of a signer in a snapshot
+type nonce struct {
+	Highest uint64 `json:"highest"`
+	Used    string `json:"used"`
+}
+
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Summary counts what a snapshot was taken or restored with
+type Summary struct {
+	// Blobs counts the blobs by status
+	Blobs map[string]int
+	// Skipped counts the confirmed and finalized blobs whose content was already removed
+	// from the store, which are left to the old instance to finalize
+	Skipped             int
+	JournalEntries      int
+	BatchJournalEntries int
+	// Nonces counts the signers whose dispersal nonces were copied
+	Nonces int
+}
+
+// Take writes a snapshot of the blobs and dispersal nonces of store and of the journals to
+// w. It fails if store can't list its dispersal nonces.
+func Take(ctx context.Context, store disperser.BlobStoreReader, journals Journals, w io.Writer, logger common.Logger) (*Summary, error) {
+	nonceStore, ok := store.(disperser.DispersalNonceStore)
+	if !ok {
+		return nil, errors.New("the blob store can't list its dispersal nonces, which the new disperser would accept again")
+	}
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	summary := &Summary{Blobs: make(map[string]int)}
+
+	manifest, err := json.Marshal(Manifest{Version: Version, CreatedAt: time.Now().UTC()})
+	if err != nil {
+		return nil, err
+	}
+	if err := writeEntry(tw, manifestEntry, manifest); err != nil {
+		return nil, err
+	}
+
+	for _, status := range Statuses {
+		metadatas, err := store.GetBlobMetadataByStatus(ctx, status)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s blobs: %w", status, err)
+		}
+		for _, metadata := range metadatas {
+			data, err := store.GetBlobContent(ctx, metadata)
+			if errors.Is(err, disperser.ErrBlobNotFound) && (status == disperser.Confirmed || status == disperser.Finalized) {
+				summary.Skipped++
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read blob %s: %w", metadata.GetBlobKey().String(), err)
+			}
+			encoded, err := json.Marshal(metadata)
+			if err != nil {
+				return nil, err
+			}
+			dir := path.Join(blobsDir, metadata.GetBlobKey().String())
+			if err := writeEntry(tw, path.Join(dir, "metadata.json"), encoded); err != nil {
+				return nil, err
+			}
+			if err := writeEntry(tw, path.Join(dir, "data"), data); err != nil {
+				return nil, err
+			}
+			summary.Blobs[status.String()]++
+		}
+	}
+
+	windows, err := nonceStore.DispersalNonces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the dispersal nonces: %w", err)
+	}
+	nonces := make(map[string]nonce, len(windows))
+	for signer, window := range windows {
+		nonces[signer] = nonce{Highest: window.Highest, Used: hex.EncodeToString(window.Bytes())}
+	}
+	encoded, err := json.Marshal(nonces)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeEntry(tw, noncesEntry, encoded); err != nil {
+		return nil, err
+	}
+	summary.Nonces = len(nonces)
+
+	if summary.JournalEntries, err = takeJournal(tw, journals.Encoding, journalDir, "encoding"); err != nil {
+		return nil, err
+	}
+	if summary.BatchJournalEntries, err = takeJournal(tw, journals.Batch, batchJournalDir, "batch"); err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	logger.Info("[snapshot] snapshot taken", "blobs", summary.Blobs, "skipped", summary.Skipped, "journal entries", summary.JournalEntries, "batch journal entries", summary.BatchJournalEntries, "nonces", summary.Nonces)
+	return summary, nil
+}
+
+// takeJournal writes the entries of the journal at journalPath under dir, returning how
+// many it wrote. Nothing is written if journalPath is empty.
+func takeJournal(tw *tar.Writer, journalPath, dir, name string) (int, error) {
+	if journalPath == "" {
+		return 0, nil
+	}
+	journal, err := leveldb.NewLevelDBStore(journalPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open the %s journal, is the batcher stopped: %w", name, err)
+	}
+	defer journal.Close()
+	entries := 0
+	iter := journal.NewIterator(nil)
+	defer iter.Release()
+	for iter.Next() {
+		if err := writeEntry(tw, path.Join(dir, hex.EncodeToString(iter.Key())), iter.Value()); err != nil {
+			return 0, err
+		}
+		entries++
+	}
+	if err := iter.Error(); err != nil {
+		return 0, fmt.Errorf("failed to read the %s journal: %w", name, err)
+	}
+	return entries, nil
+}
+
+// Restore stores the blobs of the snapshot read from r in store with their status and
+// retries, restores its dispersal nonces, and writes its journal entries to the journals,
+// each left out if its path is empty. Blobs already in the store are overwritten, so a
+// restore that failed can be run again.
+func Restore(ctx context.Context, r io.Reader, store disperser.BlobStore, journals Journals, logger common.Logger) (*Summary, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	tr := tar.NewReader(gz)
+
+	header, err := tr.Next()
+	if err != nil || header.Name != manifestEntry {
+		return nil, fmt.Errorf("invalid snapshot: missing %s", manifestEntry)
+	}
+	var manifest Manifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid snapshot manifest: %w", err)
+	}
+	if manifest.Version < 1 || manifest.Version > Version {
+		return nil, fmt.Errorf("unsupported snapshot version %d, expected %d", manifest.Version, Version)
+	}
+
+	journal, err := openJournal(journals.Encoding, "encoding")
+	if err != nil {
+		return nil, err
+	}
+	if journal != nil {
+		defer journal.Close()
+	}
+	batchJournal, err := openJournal(journals.Batch, "batch")
+	if err != nil {
+		return nil, err
+	}
+	if batchJournal != nil {
+		defer batchJournal.Close()
+	}
+
+	summary := &Summary{Blobs: make(map[string]int)}
+	var metadata *disperser.BlobMetadata
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot: %w", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case strings.HasPrefix(header.Name, blobsDir+"/") && path.Base(header.Name) == "metadata.json":
+			metadata = &disperser.BlobMetadata{}
+			if err := json.Unmarshal(data, metadata); err != nil {
+				return nil, fmt.Errorf("invalid metadata %s: %w", header.Name, err)
+			}
+		case strings.HasPrefix(header.Name, blobsDir+"/") && path.Base(header.Name) == "data":
+			if metadata == nil || path.Dir(header.Name) != path.Join(blobsDir, metadata.GetBlobKey().String()) {
+				return nil, fmt.Errorf("invalid snapshot: %s without its metadata", header.Name)
+			}
+			if err := restoreBlob(ctx, store, metadata, data); err != nil {
+				return nil, err
+			}
+			summary.Blobs[metadata.BlobStatus.String()]++
+			metadata = nil
+		case header.Name == noncesEntry:
+			restored, err := restoreNonces(ctx, store, data)
+			if err != nil {
+				return nil, err
+			}
+			summary.Nonces = restored
+		case strings.HasPrefix(header.Name, journalDir+"/"):
+			restored, err := restoreJournalEntry(journal, header.Name, data)
+			if err != nil {
+				return nil, err
+			}
+			summary.JournalEntries += restored
+		case strings.HasPrefix(header.Name, batchJournalDir+"/"):
+			restored, err := restoreJournalEntry(batchJournal, header.Name, data)
+			if err != nil {
+				return nil, err
+			}
+			summary.BatchJournalEntries += restored
+		default:
+			return nil, fmt.Errorf("invalid snapshot: unexpected entry %s", header.Name)
+		}
+	}
+	logger.Info("[snapshot] snapshot restored", "created at", manifest.CreatedAt, "blobs", summary.Blobs, "journal entries", summary.JournalEntries, "batch journal entries", summary.BatchJournalEntries, "nonces", summary.Nonces)
+	return summary, nil
+}
+
+// openJournal opens the journal at journalPath, nil if journalPath is empty
+func openJournal(journalPath, name string) (*leveldb.LevelDBStore, error) {
+	if journalPath == "" {
+		return nil, nil
+	}
+	journal, err := leveldb.NewLevelDBStore(journalPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the %s journal, is the batcher stopped: %w", name, err)
+	}
+	return journal, nil
+}
+
+// restoreJournalEntry writes the journal entry of the snapshot named name, returning 1 if
+// it was written and 0 if the journal is left out
+func restoreJournalEntry(journal *leveldb.LevelDBStore, name string, data []byte) (int, error) {
+	if journal == nil {
+		return 0, nil
+	}
+	key, err := hex.DecodeString(path.Base(name))
+	if err != nil {
+		return 0, fmt.Errorf("invalid journal entry %s: %w", name, err)
+	}
+	if err := journal.Put(key, data); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// restoreNonces restores the dispersal nonce windows of the snapshot, returning how many
+func restoreNonces(ctx context.Context, store disperser.BlobStore, data []byte) (int, error) {
+	var nonces map[string]nonce
+	if err := json.Unmarshal(data, &nonces); err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", noncesEntry, err)
+	}
+	if len(nonces) == 0 {
+		return 0, nil
+	}
+	nonceStore, ok := store.(disperser.DispersalNonceStore)
+	if !ok {
+		return 0, errors.New("the blob store can't restore the dispersal nonces of the snapshot")
+	}
+	for signer, n := range nonces {
+		used, err := hex.DecodeString(n.Used)
+		if err != nil {
+			return 0, fmt.Errorf("invalid dispersal nonces of %s: %w", signer, err)
+		}
+		window, err := disperser.NonceWindowFromBytes(n.Highest, used)
+		if err != nil {
+			return 0, fmt.Errorf("invalid dispersal nonces of %s: %w", signer, err)
+		}
+		if err := nonceStore.RestoreDispersalNonce(ctx, signer, window); err != nil {
+			return 0, fmt.Errorf("failed to restore the dispersal nonces of %s: %w", signer, err)
+		}
+	}
+	return len(nonces), nil
+}
+
+// restoreBlob stores a blob and brings it to the status and retries of its metadata
+func restoreBlob(ctx context.Context, store disperser.BlobStore, metadata *disperser.BlobMetadata, data []byte) error {
+	if err := metadata.VerifyContent(data); err != nil {
+		return err
+	}
+	if metadata.RequestMetadata == nil {
+		return fmt.Errorf("blob %s has no request metadata", metadata.GetBlobKey().String())
+	}
+	blob := &core.Blob{RequestHeader: metadata.RequestMetadata.BlobRequestHeader, Data: data}
+	requestedAt := metadata.RequestMetadata.RequestedAt
+
+	var key disperser.BlobKey
+	var err error
+	if metadata.BlobStatus == disperser.Quarantined {
+		key, err = store.StoreQuarantinedBlob(ctx, blob, requestedAt, metadata.QuarantineReason)
+	} else {
+		key, err = store.StoreBlob(ctx, blob, requestedAt)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store blob %s: %w", metadata.GetBlobKey().String(), err)
+	}
+	// the request ids given out and the journal entries refer to the key of the snapshot
+	if key != metadata.GetBlobKey() {
+		return fmt.Errorf("blob %s is stored under another key %s, the blob stores derive keys differently", metadata.GetBlobKey().String(), key.String())
+	}
+
+	stored, err := store.GetBlobMetadata(ctx, key)
+	if err != nil {
+		return err
+	}
+	for retries := stored.NumRetries; retries < metadata.NumRetries; retries++ {
+		if err := store.IncrementBlobRetryCount(ctx, metadata); err != nil {
+			return err
+		}
+	}
+	switch metadata.BlobStatus {
+	case disperser.Processing:
+		if stored.BlobStatus != disperser.Processing {
+			return store.MarkBlobProcessing(ctx, key)
+		}
+	case disperser.DeadLettered:
+		return store.MarkBlobDeadLettered(ctx, metadata, metadata.DeadLetterReason)
+	case disperser.Confirmed, disperser.Finalized:
+		if metadata.ConfirmationInfo == nil {
+			return fmt.Errorf("%s blob %s has no confirmation info", metadata.BlobStatus, key.String())
+		}
+		if _, err := store.MarkBlobConfirmed(ctx, stored, metadata.ConfirmationInfo); err != nil {
+			return err
+		}
+		if metadata.BlobStatus == disperser.Finalized {
+			// left for the finalizer of the new instance to move to the kv store
+			return store.MarkBlobFinalized(ctx, key)
+		}
+	}
+	return nil
+}
+
+func writeEntry(tw *tar.Writer, name string, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
@@ -0,0 +1,129 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/0glabs/0g-da-client/disperser/leveldb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTakeAndRestore(t *testing.T) {
+	ctx := context.Background()
+	logger := mock.NewLogger(false)
+	source := memorydb.NewBlobStore(core.MaxBlobSize*8, logger)
+
+	processing, err := source.StoreBlob(ctx, &core.Blob{Data: []byte("processing")}, 1)
+	assert.NoError(t, err)
+	meta, err := source.GetBlobMetadata(ctx, processing)
+	assert.NoError(t, err)
+	assert.NoError(t, source.IncrementBlobRetryCount(ctx, meta))
+	assert.NoError(t, source.IncrementBlobRetryCount(ctx, meta))
+
+	quarantined, err := source.StoreQuarantinedBlob(ctx, &core.Blob{Data: []byte("quarantined")}, 2, "too large")
+	assert.NoError(t, err)
+
+	deadLettered, err := source.StoreBlob(ctx, &core.Blob{Data: []byte("dead-lettered")}, 3)
+	assert.NoError(t, err)
+	meta, err = source.GetBlobMetadata(ctx, deadLettered)
+	assert.NoError(t, err)
+	assert.NoError(t, source.MarkBlobDeadLettered(ctx, meta, disperser.DeadLetterExpired))
+
+	// finalized blobs wait for the finalizer to move them to the kv store
+	finalized, err := source.StoreBlob(ctx, &core.Blob{Data: []byte("finalized")}, 4)
+	assert.NoError(t, err)
+	meta, err = source.GetBlobMetadata(ctx, finalized)
+	assert.NoError(t, err)
+	_, err = source.MarkBlobConfirmed(ctx, meta, &disperser.ConfirmationInfo{BatchID: 7})
+	assert.NoError(t, err)
+	assert.NoError(t, source.MarkBlobFinalized(ctx, finalized))
+
+	// final blobs are left out
+	failed, err := source.StoreBlob(ctx, &core.Blob{Data: []byte("failed")}, 5)
+	assert.NoError(t, err)
+	assert.NoError(t, source.MarkBlobFailed(ctx, failed))
+
+	assert.NoError(t, source.UseDispersalNonce(ctx, "signer", 10))
+	assert.NoError(t, source.UseDispersalNonce(ctx, "signer", 8))
+
+	journalPath := filepath.Join(t.TempDir(), "journal")
+	journal, err := leveldb.NewLevelDBStore(journalPath)
+	assert.NoError(t, err)
+	assert.NoError(t, journal.Put([]byte("encoded-"+processing.String()), []byte("result")))
+	assert.NoError(t, journal.Close())
+	batchJournalPath := filepath.Join(t.TempDir(), "batches")
+	batchJournal, err := leveldb.NewLevelDBStore(batchJournalPath)
+	assert.NoError(t, err)
+	assert.NoError(t, batchJournal.Put([]byte("batch-1"), []byte("in flight")))
+	assert.NoError(t, batchJournal.Close())
+
+	// the signed dispersals could be replayed if the nonces were left out
+	_, err = Take(ctx, disperser.ReadOnly(source), Journals{}, &bytes.Buffer{}, logger)
+	assert.Error(t, err)
+
+	var buf bytes.Buffer
+	summary, err := Take(ctx, source, Journals{Encoding: journalPath, Batch: batchJournalPath}, &buf, logger)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"Processing": 1, "Quarantined": 1, "DeadLettered": 1, "Finalized": 1}, summary.Blobs)
+	assert.Equal(t, 1, summary.JournalEntries)
+	assert.Equal(t, 1, summary.BatchJournalEntries)
+	assert.Equal(t, 1, summary.Nonces)
+
+	target := memorydb.NewBlobStore(core.MaxBlobSize*8, logger)
+	restored := Journals{Encoding: filepath.Join(t.TempDir(), "journal"), Batch: filepath.Join(t.TempDir(), "batches")}
+	summary, err = Restore(ctx, &buf, target, restored, logger)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.JournalEntries)
+	assert.Equal(t, 1, summary.BatchJournalEntries)
+	assert.Equal(t, 1, summary.Nonces)
+
+	meta, err = target.GetBlobMetadata(ctx, processing)
+	assert.NoError(t, err)
+	assert.Equal(t, disperser.Processing, meta.BlobStatus)
+	assert.Equal(t, uint(2), meta.NumRetries)
+	data, err := target.GetBlobContent(ctx, meta)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("processing"), data)
+
+	meta, err = target.GetBlobMetadata(ctx, quarantined)
+	assert.NoError(t, err)
+	assert.Equal(t, disperser.Quarantined, meta.BlobStatus)
+	assert.Equal(t, "too large", meta.QuarantineReason)
+
+	meta, err = target.GetBlobMetadata(ctx, deadLettered)
+	assert.NoError(t, err)
+	assert.Equal(t, disperser.DeadLettered, meta.BlobStatus)
+	assert.Equal(t, disperser.DeadLetterExpired, meta.DeadLetterReason)
+
+	meta, err = target.GetBlobMetadata(ctx, finalized)
+	assert.NoError(t, err)
+	assert.Equal(t, disperser.Finalized, meta.BlobStatus)
+	assert.Equal(t, uint32(7), meta.ConfirmationInfo.BatchID)
+
+	_, err = target.GetBlobMetadata(ctx, failed)
+	assert.ErrorIs(t, err, disperser.ErrBlobNotFound)
+
+	assert.ErrorIs(t, target.UseDispersalNonce(ctx, "signer", 10), disperser.ErrNonceUsed)
+	assert.ErrorIs(t, target.UseDispersalNonce(ctx, "signer", 8), disperser.ErrNonceUsed)
+	assert.NoError(t, target.UseDispersalNonce(ctx, "signer", 9))
+
+	journal, err = leveldb.NewLevelDBStore(restored.Encoding)
+	assert.NoError(t, err)
+	defer journal.Close()
+	value, err := journal.Get([]byte("encoded-" + processing.String()))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("result"), value)
+
+	batchJournal, err = leveldb.NewLevelDBStore(restored.Batch)
+	assert.NoError(t, err)
+	defer batchJournal.Close()
+	value, err = batchJournal.Get([]byte("batch-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("in flight"), value)
+}
@@ -122,7 +122,10 @@ func (s *Store) deleteNBlobs(currentTimeUnixSec int64, numBatches int) (int, err
 				return -1, err
 			}
 
-			expiredKeys = append(expiredKeys, blobHeaderKey)
+			expiredKeys = append(expiredKeys, blobHeaderKey, EncodeBlobConfirmationKey(chunk))
+			if confirmation, err := s.db.Get(EncodeBlobConfirmationKey(chunk)); err == nil {
+				expiredKeys = append(expiredKeys, blobIndexKeysOf(chunk, confirmation)...)
+			}
 
 			metaData, err := s.db.Get(blobHeaderKey)
 			if err != nil {
@@ -163,7 +166,9 @@ func (s *Store) StoreMetadata(ctx context.Context, key []byte, value []byte) err
 	return nil
 }
 
-func (s *Store) StoreMetadataBatch(ctx context.Context, blobKeys [][]byte, metadatas [][]byte, blobs [][]byte) (*[][]byte, error) {
+// StoreMetadataBatch writes the metadata and content of a batch of blobs, and their
+// confirmations unless nil, in a single write expiring with the batch.
+func (s *Store) StoreMetadataBatch(ctx context.Context, blobKeys [][]byte, metadatas [][]byte, confirmations [][]byte, blobs [][]byte) (*[][]byte, error) {
 	keys := make([][]byte, 0)
 	values := make([][]byte, 0)
 
@@ -188,6 +193,15 @@ func (s *Store) StoreMetadataBatch(ctx context.Context, blobKeys [][]byte, metad
 
 		keys = append(keys, metadatas[idx])
 		values = append(values, blobs[idx])
+
+		if confirmations != nil && confirmations[idx] != nil {
+			keys = append(keys, EncodeBlobConfirmationKey(key))
+			values = append(values, confirmations[idx])
+			for _, indexKey := range blobIndexKeysOf(key, confirmations[idx]) {
+				keys = append(keys, indexKey)
+				values = append(values, key)
+			}
+		}
 	}
 
 	curr := time.Now().Unix()
@@ -199,7 +213,8 @@ func (s *Store) StoreMetadataBatch(ctx context.Context, blobKeys [][]byte, metad
 
 	start := time.Now()
 
-	err := s.db.WriteBatch(keys, values)
+	// the blobs are removed from the blob store once written here, so the write must be durable
+	err := s.db.SyncWriteBatch(keys, values)
 	if err != nil {
 		s.logger.Error("Failed to write the batch into local database:", "err", err)
 		return nil, err
@@ -209,6 +224,35 @@ func (s *Store) StoreMetadataBatch(ctx context.Context, blobKeys [][]byte, metad
 	return &keys, nil
 }
 
+// VerifyMetadataBatch reads back the entries written by StoreMetadataBatch and returns the
+// indexes of the blobs whose metadata or content is missing or differs.
+func (s *Store) VerifyMetadataBatch(ctx context.Context, blobKeys [][]byte, metadatas [][]byte, blobs [][]byte) ([]int, error) {
+	unverified := make([]int, 0)
+	for idx, key := range blobKeys {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		metadata, err := s.GetMetadata(ctx, key)
+		if err != nil && !errors.Is(err, ErrKeyNotFound) {
+			return nil, err
+		}
+		if !bytes.Equal(metadata, metadatas[idx]) {
+			unverified = append(unverified, idx)
+			continue
+		}
+
+		blob, err := s.GetBlob(ctx, metadatas[idx])
+		if err != nil && !errors.Is(err, ErrKeyNotFound) {
+			return nil, err
+		}
+		if !bytes.Equal(blob, blobs[idx]) {
+			unverified = append(unverified, idx)
+		}
+	}
+	return unverified, nil
+}
+
 func (s *Store) GetMetadata(ctx context.Context, key []byte) ([]byte, error) {
 	blobHeaderKey, err := EncodeBlobHeaderKey(key)
 	if err != nil {
@@ -226,6 +270,50 @@ func (s *Store) GetMetadata(ctx context.Context, key []byte) ([]byte, error) {
 	return data, nil
 }
 
+// GetConfirmation returns the confirmation stored with the metadata of a blob, ErrKeyNotFound
+// for the blobs stored without it
+func (s *Store) GetConfirmation(ctx context.Context, key []byte) ([]byte, error) {
+	data, err := s.db.Get(EncodeBlobConfirmationKey(key))
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetBatchBlobKey returns the key of the blob at blobIndex in the batch of batchHeaderHash,
+// ErrKeyNotFound if the blob isn't stored with its confirmation info
+func (s *Store) GetBatchBlobKey(ctx context.Context, batchHeaderHash [32]byte, blobIndex uint32) ([]byte, error) {
+	data, err := s.db.Get(EncodeBlobBatchKey(batchHeaderHash, blobIndex))
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetBatchBlobKeys returns the keys of the blobs of the batch of batchHeaderHash stored with
+// their confirmation info, by blob index
+func (s *Store) GetBatchBlobKeys(ctx context.Context, batchHeaderHash [32]byte) (map[uint32][]byte, error) {
+	prefix := EncodeBlobBatchKeyPrefix(batchHeaderHash)
+	iter := s.db.NewIterator(prefix)
+	defer iter.Release()
+
+	keys := make(map[uint32][]byte)
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) != len(prefix)+4 {
+			continue
+		}
+		keys[binary.BigEndian.Uint32(key[len(prefix):])] = append([]byte(nil), iter.Value()...)
+	}
+	return keys, iter.Error()
+}
+
 func (s *Store) GetBlob(ctx context.Context, blobKey []byte) ([]byte, error) {
 	data, err := s.db.Get(blobKey)
 	if err != nil {
@@ -241,6 +329,12 @@ func (s *Store) MetadataIterator(ctx context.Context) iterator.Iterator {
 	return s.db.NewIterator(EncodeBlobHeaderKeyPrefix())
 }
 
+// TaggedBlobIterator iterates over the blobs stored with the tag of tagKey, whatever its
+// value if value is nil. The value of each entry is the key of a blob.
+func (s *Store) TaggedBlobIterator(ctx context.Context, tagKey string, value *string) iterator.Iterator {
+	return s.db.NewIterator(EncodeBlobTagKeyPrefix(tagKey, value))
+}
+
 // HasKey returns if a given key has been stored.
 func (s *Store) HasKey(ctx context.Context, key []byte) bool {
 	_, err := s.db.Get(key)
@@ -260,6 +354,12 @@ const (
 	// making sure the new code work with old data in DA Node store.
 	blobHeaderPrefix      = "_BLOB_HEADER_" // The prefix of the blob header key.
 	batchExpirationPrefix = "_EXPIRATION_"  // The prefix of the batch expiration key.
+	// The prefix of the blob confirmation key.
+	blobConfirmationPrefix = "_BLOB_CONFIRMATION_"
+	// The prefix of the key of a blob by its position in its batch.
+	blobBatchPrefix = "_BLOB_BATCH_"
+	// The prefix of the key of a blob by one of its tags.
+	blobTagPrefix = "_BLOB_TAG_"
 )
 
 func EncodeBatchExpirationKey(expirationTime int64) []byte {
@@ -291,6 +391,59 @@ func EncodeBlobHeaderKey(key []byte) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// EncodeBlobConfirmationKey returns the key of the confirmation of a blob.
+func EncodeBlobConfirmationKey(key []byte) []byte {
+	return append([]byte(blobConfirmationPrefix), key...)
+}
+
+// EncodeBlobBatchKey returns the key of the blob at blobIndex in the batch of
+// batchHeaderHash, whose value is the key of the blob.
+func EncodeBlobBatchKey(batchHeaderHash [32]byte, blobIndex uint32) []byte {
+	return binary.BigEndian.AppendUint32(EncodeBlobBatchKeyPrefix(batchHeaderHash), blobIndex)
+}
+
+// EncodeBlobBatchKeyPrefix returns the prefix of the batch keys of the blobs of a batch
+func EncodeBlobBatchKeyPrefix(batchHeaderHash [32]byte) []byte {
+	return append([]byte(blobBatchPrefix), batchHeaderHash[:]...)
+}
+
+// EncodeBlobTagKey returns the key of the blob of key by one of its tags, whose value is the
+// key of the blob. The tag key and value are length prefixed so that the keys of a tag
+// whatever its value share a prefix.
+func EncodeBlobTagKey(tagKey, value string, key []byte) []byte {
+	return append(EncodeBlobTagKeyPrefix(tagKey, &value), key...)
+}
+
+// EncodeBlobTagKeyPrefix returns the prefix of the tag keys of the blobs with the tag of
+// tagKey, whatever its value if value is nil
+func EncodeBlobTagKeyPrefix(tagKey string, value *string) []byte {
+	prefix := []byte(blobTagPrefix)
+	prefix = binary.BigEndian.AppendUint16(prefix, uint16(len(tagKey)))
+	prefix = append(prefix, tagKey...)
+	if value == nil {
+		return prefix
+	}
+	prefix = binary.BigEndian.AppendUint16(prefix, uint16(len(*value)))
+	return append(prefix, *value...)
+}
+
+// blobIndexKeysOf returns the keys indexing the blob of key by the serialized confirmation,
+// its batch key if it has confirmation info and a key per tag
+func blobIndexKeysOf(key []byte, confirmation []byte) [][]byte {
+	decoded, err := new(BlobConfirmation).Deserialize(confirmation)
+	if err != nil {
+		return nil
+	}
+	keys := make([][]byte, 0, len(decoded.Tags)+1)
+	if decoded.Info != nil {
+		keys = append(keys, EncodeBlobBatchKey(decoded.Info.BatchHeaderHash, decoded.Info.BlobIndex))
+	}
+	for tagKey, value := range decoded.Tags {
+		keys = append(keys, EncodeBlobTagKey(tagKey, value, key))
+	}
+	return keys
+}
+
 // Returns an encoded prefix of blob header key.
 func EncodeBlobHeaderKeyPrefix() []byte {
 	return []byte(blobHeaderPrefix)
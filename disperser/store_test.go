@@ -0,0 +1,135 @@
+package disperser_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyMetadataBatch(t *testing.T) {
+	ctx := context.Background()
+	store, err := disperser.NewLevelDBStore(t.TempDir(), 3600, mock.NewLogger(false))
+	require.NoError(t, err)
+
+	keys := [][]byte{[]byte("blob-0"), []byte("blob-1"), []byte("blob-2")}
+	metadatas := [][]byte{[]byte("metadata-0"), []byte("metadata-1"), []byte("metadata-2")}
+	blobs := [][]byte{[]byte("data-0"), []byte("data-1"), []byte("data-2")}
+
+	_, err = store.StoreMetadataBatch(ctx, keys[:2], metadatas[:2], nil, blobs[:2])
+	require.NoError(t, err)
+
+	unverified, err := store.VerifyMetadataBatch(ctx, keys, metadatas, blobs)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, unverified)
+
+	// content differing from what was written
+	changed := [][]byte{blobs[0], []byte("other"), blobs[2]}
+	unverified, err = store.VerifyMetadataBatch(ctx, keys[:2], metadatas[:2], changed[:2])
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, unverified)
+}
+
+func TestStoreConfirmation(t *testing.T) {
+	ctx := context.Background()
+	store, err := disperser.NewLevelDBStore(t.TempDir(), 0, mock.NewLogger(false))
+	require.NoError(t, err)
+
+	confirmation, err := (&disperser.BlobConfirmation{BlockNumber: 500, ConfirmedAt: 10000}).Serialize()
+	require.NoError(t, err)
+	keys := [][]byte{[]byte("blob-0"), []byte("blob-1")}
+	_, err = store.StoreMetadataBatch(ctx, keys, [][]byte{[]byte("metadata-0"), []byte("metadata-1")}, [][]byte{confirmation, nil}, [][]byte{[]byte("data-0"), []byte("data-1")})
+	require.NoError(t, err)
+
+	data, err := store.GetConfirmation(ctx, keys[0])
+	require.NoError(t, err)
+	stored, err := new(disperser.BlobConfirmation).Deserialize(data)
+	require.NoError(t, err)
+	assert.Equal(t, &disperser.BlobConfirmation{BlockNumber: 500, ConfirmedAt: 10000}, stored)
+	_, err = store.GetConfirmation(ctx, keys[1])
+	assert.ErrorIs(t, err, disperser.ErrKeyNotFound)
+
+	// the confirmation expires with the blob
+	_, err = store.DeleteExpiredEntries(time.Now().Unix()+1, 10)
+	require.NoError(t, err)
+	_, err = store.GetConfirmation(ctx, keys[0])
+	assert.ErrorIs(t, err, disperser.ErrKeyNotFound)
+	_, err = store.GetMetadata(ctx, keys[0])
+	assert.ErrorIs(t, err, disperser.ErrKeyNotFound)
+}
+
+func TestStoreBatchBlobKey(t *testing.T) {
+	ctx := context.Background()
+	store, err := disperser.NewLevelDBStore(t.TempDir(), 0, mock.NewLogger(false))
+	require.NoError(t, err)
+
+	batchHeaderHash := [32]byte{1, 2, 3}
+	confirmation, err := (&disperser.BlobConfirmation{
+		BlockNumber: 500,
+		Info:        &disperser.ConfirmationInfo{BatchHeaderHash: batchHeaderHash, BlobIndex: 3},
+	}).Serialize()
+	require.NoError(t, err)
+	_, err = store.StoreMetadataBatch(ctx, [][]byte{[]byte("blob-0")}, [][]byte{[]byte("metadata-0")}, [][]byte{confirmation}, [][]byte{[]byte("data-0")})
+	require.NoError(t, err)
+
+	key, err := store.GetBatchBlobKey(ctx, batchHeaderHash, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("blob-0"), key)
+	_, err = store.GetBatchBlobKey(ctx, batchHeaderHash, 2)
+	assert.ErrorIs(t, err, disperser.ErrKeyNotFound)
+
+	// the position expires with the blob
+	_, err = store.DeleteExpiredEntries(time.Now().Unix()+1, 10)
+	require.NoError(t, err)
+	_, err = store.GetBatchBlobKey(ctx, batchHeaderHash, 3)
+	assert.ErrorIs(t, err, disperser.ErrKeyNotFound)
+}
+
+func TestStoreBlobTags(t *testing.T) {
+	ctx := context.Background()
+	store, err := disperser.NewLevelDBStore(t.TempDir(), 0, mock.NewLogger(false))
+	require.NoError(t, err)
+
+	tagged := func(batchHeaderHash [32]byte, tags map[string]string) []byte {
+		confirmation, err := (&disperser.BlobConfirmation{
+			Info: &disperser.ConfirmationInfo{BatchHeaderHash: batchHeaderHash},
+			Tags: tags,
+		}).Serialize()
+		require.NoError(t, err)
+		return confirmation
+	}
+	keys := [][]byte{[]byte("blob-0"), []byte("blob-1"), []byte("blob-2")}
+	confirmations := [][]byte{
+		tagged([32]byte{1}, map[string]string{"rollup": "a", "block": "7"}),
+		tagged([32]byte{2}, map[string]string{"rollup": "ab"}),
+		tagged([32]byte{3}, nil),
+	}
+	_, err = store.StoreMetadataBatch(ctx, keys, [][]byte{[]byte("metadata-0"), []byte("metadata-1"), []byte("metadata-2")}, confirmations, [][]byte{[]byte("data-0"), []byte("data-1"), []byte("data-2")})
+	require.NoError(t, err)
+
+	blobsTagged := func(key string, value *string) []string {
+		iter := store.TaggedBlobIterator(ctx, key, value)
+		defer iter.Release()
+		blobs := make([]string, 0)
+		for iter.Next() {
+			blobs = append(blobs, string(iter.Value()))
+		}
+		require.NoError(t, iter.Error())
+		return blobs
+	}
+	a, b := "a", "7"
+	assert.Equal(t, []string{"blob-0", "blob-1"}, blobsTagged("rollup", nil))
+	// the value of a tag doesn't match the values it prefixes
+	assert.Equal(t, []string{"blob-0"}, blobsTagged("rollup", &a))
+	assert.Equal(t, []string{"blob-0"}, blobsTagged("block", &b))
+	assert.Empty(t, blobsTagged("rollu", nil))
+
+	// the tags expire with the blob
+	_, err = store.DeleteExpiredEntries(time.Now().Unix()+1, 10)
+	require.NoError(t, err)
+	assert.Empty(t, blobsTagged("rollup", nil))
+}
@@ -0,0 +1,83 @@
+package disperser
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/logging"
+	"github.com/0glabs/0g-da-client/common/reload"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Tunables are the settings of the disperser reloaded on SIGHUP without a restart, from the
+// JSON document of the reload file or config service. A field left out keeps its current
+// value, that of its flag or set by an earlier reload or through the admin API.
+type Tunables struct {
+	// LogLevel is the lowest level logged to stdout and to the log file
+	LogLevel string `json:"log_level,omitempty"`
+	// PullInterval is the interval the batcher creates batches at
+	PullInterval reload.Duration `json:"pull_interval,omitempty"`
+	// BatchSizeMB is the batch size limit
+	BatchSizeMB uint            `json:"batch_size_mb,omitempty"`
+	Timeouts    TimeoutTunables `json:"timeouts,omitempty"`
+	// WriteRequestsPerMinute is the dispersals allowed per minute to each account, the
+	// requests quota of the api server, and ReadRequestsPerMinute the retrievals allowed per
+	// minute to each client
+	WriteRequestsPerMinute int `json:"write_requests_per_minute,omitempty"`
+	ReadRequestsPerMinute  int `json:"read_requests_per_minute,omitempty"`
+}
+
+// TimeoutTunables are the timeouts of the calls of the batcher, see the timeout flags
+type TimeoutTunables struct {
+	Encoding    reload.Duration `json:"encoding,omitempty"`
+	ChainRead   reload.Duration `json:"chain_read,omitempty"`
+	ChainWrite  reload.Duration `json:"chain_write,omitempty"`
+	Signing     reload.Duration `json:"signing,omitempty"`
+	StoreWrite  reload.Duration `json:"store_write,omitempty"`
+	ReceiptWait reload.Duration `json:"receipt_wait,omitempty"`
+}
+
+// MinPullInterval and MinTimeout are the lowest pull interval and call timeouts the tunables
+// may set
+const (
+	MinPullInterval = time.Second
+	MinTimeout      = time.Second
+)
+
+// NewTunablesReloader creates the reloader of the tunables, which applies the log level of
+// logger configured with loggerConfig. The components register how they apply the others.
+func NewTunablesReloader(config reload.Config, loggerConfig logging.Config, logger common.Logger) *reload.Reloader[Tunables] {
+	reloader := reload.NewReloader(config, (*Tunables).Validate, logger)
+	reloader.OnReload(func(tunables *Tunables) {
+		if tunables.LogLevel == "" {
+			return
+		}
+		if err := logging.SetLevel(logger, loggerConfig, tunables.LogLevel); err != nil {
+			logger.Error("[reload] failed to change log level", "err", err)
+		}
+	})
+	return reloader
+}
+
+func (t *Tunables) Validate() error {
+	if t.LogLevel != "" {
+		if _, err := log.LvlFromString(t.LogLevel); err != nil {
+			return fmt.Errorf("log level: %w", err)
+		}
+	}
+	if t.PullInterval != 0 && time.Duration(t.PullInterval) < MinPullInterval {
+		return fmt.Errorf("pull interval must be at least %v", MinPullInterval)
+	}
+	timeouts := []reload.Duration{t.Timeouts.Encoding, t.Timeouts.ChainRead, t.Timeouts.ChainWrite, t.Timeouts.Signing, t.Timeouts.StoreWrite, t.Timeouts.ReceiptWait}
+	for _, timeout := range timeouts {
+		if timeout != 0 && time.Duration(timeout) < MinTimeout {
+			return fmt.Errorf("timeouts must be at least %v", MinTimeout)
+		}
+	}
+	if t.WriteRequestsPerMinute < 0 || t.ReadRequestsPerMinute < 0 {
+		return errors.New("requests per minute must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+// Package rollup adapts the disperser and the retrievers to the put/get interface rollup
+// stacks use for alternative DA layers. The sequencer puts its batch data and posts the
+// returned commitment to its inbox; derivation gets the data back from the commitment.
+package rollup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/clients"
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/tlsconfig"
+	"github.com/0glabs/0g-da-client/disperser/api/grpc/retriever"
+	"github.com/0glabs/0g-da-client/disperser/encoder"
+	"github.com/hashicorp/go-multierror"
+	"google.golang.org/grpc"
+)
+
+var ErrDispersalFailed = errors.New("blob dispersal failed")
+
+// DA is the interface of an alternative DA provider
+type DA interface {
+	// Put disperses data and returns its commitment once the data is available
+	Put(ctx context.Context, data []byte) ([]byte, error)
+	// Get returns the data of a commitment returned by Put
+	Get(ctx context.Context, commitment []byte) ([]byte, error)
+}
+
+type Config struct {
+	DisperserAddr string
+	// RetrieverAddrs are tried in order by Get before falling back to the disperser
+	RetrieverAddrs     []string
+	StatusPollInterval time.Duration
+	// WaitForFinalization makes Put return once the blob is finalized rather than confirmed
+	WaitForFinalization bool
+	// EncoderAddr is the address of the encoder the retrieved data is encoded with, so that
+	// Get returns the data only if it encodes to the storage root of the commitment
+	EncoderAddr    string
+	EncoderTimeout time.Duration
+	// TLS secures the connections to the disperser, the retrievers and the encoder
+	TLS tlsconfig.ClientConfig
+}
+
+// Adapter implements DA on top of the disperser and retriever gRPC APIs
+type Adapter struct {
+	config     Config
+	disperser  pb.DisperserClient
+	retrievers []retriever.RetrieverClient
+	conns      []*grpc.ClientConn
+	verifier   clients.Verifier
+	logger     common.Logger
+}
+
+var _ DA = (*Adapter)(nil)
+
+func NewAdapter(config Config, logger common.Logger) (*Adapter, error) {
+	if config.DisperserAddr == "" {
+		return nil, errors.New("disperser address is required")
+	}
+	if config.StatusPollInterval <= 0 {
+		return nil, errors.New("status poll interval must be positive")
+	}
+	if config.EncoderAddr == "" {
+		return nil, errors.New("encoder address is required")
+	}
+
+	creds, err := tlsconfig.ClientCredentials(config.TLS, logger)
+	if err != nil {
+		return nil, err
+	}
+	dial := func(addr string) (*grpc.ClientConn, error) {
+		return grpc.Dial(
+			addr,
+			grpc.WithTransportCredentials(creds),
+			grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(1024*1024*1024)), // 1 GiB
+		)
+	}
+
+	encoderClient, err := encoder.NewEncoderClient(config.EncoderAddr, config.EncoderTimeout, 1, "", encoder.BatchingConfig{}, creds, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial encoder: %w", err)
+	}
+	a := &Adapter{config: config, verifier: &clients.EncoderVerifier{Encoder: encoderClient, Logger: logger}, logger: logger}
+	conn, err := dial(config.DisperserAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial disperser: %w", err)
+	}
+	a.conns = append(a.conns, conn)
+	a.disperser = pb.NewDisperserClient(conn)
+
+	for _, addr := range config.RetrieverAddrs {
+		conn, err := dial(addr)
+		if err != nil {
+			_ = a.Close()
+			return nil, fmt.Errorf("failed to dial retriever %s: %w", addr, err)
+		}
+		a.conns = append(a.conns, conn)
+		a.retrievers = append(a.retrievers, retriever.NewRetrieverClient(conn))
+	}
+	return a, nil
+}
+
+func (a *Adapter) Close() error {
+	var result *multierror.Error
+	for _, conn := range a.conns {
+		if err := conn.Close(); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+	return result.ErrorOrNil()
+}
+
+// TaggingDA is a DA which disperses the data with tags, returned with the status of the blob
+type TaggingDA interface {
+	DA
+	// PutTagged is Put with the tags of the blob, also returning the request id of the blob
+	// at the disperser
+	PutTagged(ctx context.Context, data []byte, tags map[string]string) ([]byte, string, error)
+}
+
+var _ TaggingDA = (*Adapter)(nil)
+
+// Put disperses data and polls its status until it is confirmed, or finalized if configured
+func (a *Adapter) Put(ctx context.Context, data []byte) ([]byte, error) {
+	commitment, _, err := a.PutTagged(ctx, data, nil)
+	return commitment, err
+}
+
+func (a *Adapter) PutTagged(ctx context.Context, data []byte, tags map[string]string) ([]byte, string, error) {
+	reply, err := a.disperser.DisperseBlob(ctx, &pb.DisperseBlobRequest{Data: data, Tags: tags})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to disperse blob: %w", err)
+	}
+	requestID := reply.GetRequestId()
+	a.logger.Debug("[rollup] blob dispersed", "request id", string(requestID), "size", len(data))
+
+	ticker := time.NewTicker(a.config.StatusPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		case <-ticker.C:
+		}
+
+		status, err := a.disperser.GetBlobStatus(ctx, &pb.BlobStatusRequest{RequestId: requestID})
+		if err != nil {
+			a.logger.Warn("[rollup] failed to get blob status", "request id", string(requestID), "err", err)
+			continue
+		}
+
+		switch status.GetStatus() {
+		case pb.BlobStatus_FINALIZED:
+		case pb.BlobStatus_CONFIRMED:
+			if a.config.WaitForFinalization {
+				continue
+			}
+		case pb.BlobStatus_FAILED, pb.BlobStatus_INSUFFICIENT_SIGNATURES:
+			return nil, "", fmt.Errorf("%w: request %s is %s", ErrDispersalFailed, requestID, status.GetStatus())
+		default:
+			continue
+		}
+
+		header := status.GetInfo().GetBlobHeader()
+		if len(header.GetStorageRoot()) != 32 {
+			return nil, "", fmt.Errorf("%w: request %s has no storage root", ErrDispersalFailed, requestID)
+		}
+		commitment := &Commitment{
+			Epoch:    header.GetEpoch(),
+			QuorumId: header.GetQuorumId(),
+		}
+		copy(commitment.StorageRoot[:], header.GetStorageRoot())
+		return commitment.Encode(), string(requestID), nil
+	}
+}
+
+// Get retrieves the data of a commitment from the retrievers, then from the disperser. The
+// data of a source is returned only if it encodes to the storage root of the commitment,
+// otherwise the next source is tried.
+func (a *Adapter) Get(ctx context.Context, commitment []byte) ([]byte, error) {
+	c, err := DecodeCommitment(commitment)
+	if err != nil {
+		return nil, err
+	}
+	// the commitment posted by the rollup certifies the blob
+	certificate := &pb.BlobStatusReply{
+		Status: pb.BlobStatus_CONFIRMED,
+		Info:   &pb.BlobInfo{BlobHeader: &pb.BlobHeader{StorageRoot: c.StorageRoot[:], Epoch: c.Epoch, QuorumId: c.QuorumId}},
+	}
+
+	var result *multierror.Error
+	for i, r := range a.retrievers {
+		reply, err := r.RetrieveBlob(ctx, &retriever.BlobRequest{
+			StorageRoot: c.StorageRoot[:],
+			Epoch:       c.Epoch,
+			QuorumId:    c.QuorumId,
+		})
+		if err == nil {
+			err = a.verifier.Verify(ctx, certificate, reply.GetData())
+		}
+		if err == nil {
+			return reply.GetData(), nil
+		}
+		a.logger.Debug("[rollup] retriever failed", "addr", a.config.RetrieverAddrs[i], "err", err)
+		result = multierror.Append(result, err)
+	}
+
+	reply, err := a.disperser.RetrieveBlob(ctx, &pb.RetrieveBlobRequest{
+		StorageRoot: c.StorageRoot[:],
+		Epoch:       c.Epoch,
+		QuorumId:    c.QuorumId,
+	})
+	if err == nil {
+		err = a.verifier.Verify(ctx, certificate, reply.GetData())
+	}
+	if err != nil {
+		result = multierror.Append(result, err)
+		return nil, fmt.Errorf("failed to retrieve blob: %w", result.ErrorOrNil())
+	}
+	return reply.GetData(), nil
+}
@@ -0,0 +1,151 @@
+package rollup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/disperser/api/grpc/retriever"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+type fakeDisperser struct {
+	pb.DisperserClient
+	statuses []pb.BlobStatus
+	blobs    map[[32]byte][]byte
+	tags     map[string]string
+	// dispersed is the data dispersed by storage root, which the retrieved data is checked
+	// against
+	dispersed map[[32]byte][]byte
+}
+
+func (d *fakeDisperser) DisperseBlob(ctx context.Context, in *pb.DisperseBlobRequest, opts ...grpc.CallOption) (*pb.DisperseBlobReply, error) {
+	d.blobs[[32]byte{1}] = in.GetData()
+	if d.dispersed == nil {
+		d.dispersed = make(map[[32]byte][]byte)
+	}
+	d.dispersed[[32]byte{1}] = in.GetData()
+	d.tags = in.GetTags()
+	return &pb.DisperseBlobReply{Result: pb.BlobStatus_PROCESSING, RequestId: []byte("request")}, nil
+}
+
+func (d *fakeDisperser) GetBlobStatus(ctx context.Context, in *pb.BlobStatusRequest, opts ...grpc.CallOption) (*pb.BlobStatusReply, error) {
+	status := d.statuses[0]
+	if len(d.statuses) > 1 {
+		d.statuses = d.statuses[1:]
+	}
+	root := [32]byte{1}
+	return &pb.BlobStatusReply{
+		Status: status,
+		Info:   &pb.BlobInfo{BlobHeader: &pb.BlobHeader{StorageRoot: root[:], Epoch: 3, QuorumId: 4}},
+	}, nil
+}
+
+func (d *fakeDisperser) RetrieveBlob(ctx context.Context, in *pb.RetrieveBlobRequest, opts ...grpc.CallOption) (*pb.RetrieveBlobReply, error) {
+	var root [32]byte
+	copy(root[:], in.GetStorageRoot())
+	data, ok := d.blobs[root]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &pb.RetrieveBlobReply{Data: data}, nil
+}
+
+type failingRetriever struct{}
+
+func (failingRetriever) RetrieveBlob(ctx context.Context, in *retriever.BlobRequest, opts ...grpc.CallOption) (*retriever.BlobReply, error) {
+	return nil, errors.New("unavailable")
+}
+
+type forgingRetriever struct{}
+
+func (forgingRetriever) RetrieveBlob(ctx context.Context, in *retriever.BlobRequest, opts ...grpc.CallOption) (*retriever.BlobReply, error) {
+	return &retriever.BlobReply{Data: []byte("forged")}, nil
+}
+
+// fakeVerifier checks the data against the data dispersed, in place of its encoding
+type fakeVerifier struct {
+	d *fakeDisperser
+}
+
+func (v fakeVerifier) Verify(ctx context.Context, certificate *pb.BlobStatusReply, data []byte) error {
+	if !bytes.Equal(v.d.dispersed[[32]byte(certificate.GetInfo().GetBlobHeader().GetStorageRoot())], data) {
+		return errors.New("storage root mismatch")
+	}
+	return nil
+}
+
+func newTestAdapter(config Config, d *fakeDisperser) *Adapter {
+	config.StatusPollInterval = time.Millisecond
+	config.RetrieverAddrs = []string{"retriever"}
+	return &Adapter{
+		config:     config,
+		disperser:  d,
+		retrievers: []retriever.RetrieverClient{failingRetriever{}},
+		verifier:   fakeVerifier{d},
+		logger:     mock.NewLogger(false),
+	}
+}
+
+func TestPutGet(t *testing.T) {
+	d := &fakeDisperser{
+		statuses: []pb.BlobStatus{pb.BlobStatus_PROCESSING, pb.BlobStatus_CONFIRMED, pb.BlobStatus_FINALIZED},
+		blobs:    make(map[[32]byte][]byte),
+	}
+	a := newTestAdapter(Config{WaitForFinalization: true}, d)
+
+	commitment, err := a.Put(context.Background(), []byte("batch"))
+	assert.NoError(t, err)
+	assert.Empty(t, d.statuses[1:], "put must wait for finalization")
+
+	c, err := DecodeCommitment(commitment)
+	assert.NoError(t, err)
+	assert.Equal(t, &Commitment{StorageRoot: [32]byte{1}, Epoch: 3, QuorumId: 4}, c)
+
+	data, err := a.Get(context.Background(), commitment)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("batch"), data)
+
+	_, err = a.Get(context.Background(), commitment[:10])
+	assert.ErrorIs(t, err, ErrInvalidCommitment)
+}
+
+func TestPutFailed(t *testing.T) {
+	d := &fakeDisperser{
+		statuses: []pb.BlobStatus{pb.BlobStatus_PROCESSING, pb.BlobStatus_INSUFFICIENT_SIGNATURES},
+		blobs:    make(map[[32]byte][]byte),
+	}
+	a := newTestAdapter(Config{}, d)
+
+	_, err := a.Put(context.Background(), []byte("batch"))
+	assert.ErrorIs(t, err, ErrDispersalFailed)
+}
+
+func TestGetVerified(t *testing.T) {
+	d := &fakeDisperser{
+		statuses: []pb.BlobStatus{pb.BlobStatus_CONFIRMED},
+		blobs:    make(map[[32]byte][]byte),
+	}
+	a := newTestAdapter(Config{}, d)
+	a.config.RetrieverAddrs = []string{"forging retriever"}
+	a.retrievers = []retriever.RetrieverClient{forgingRetriever{}}
+
+	commitment, err := a.Put(context.Background(), []byte("batch"))
+	require.NoError(t, err)
+
+	// the forged data of the retriever is skipped for the data of the disperser
+	data, err := a.Get(context.Background(), commitment)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("batch"), data)
+
+	// and rejected from the disperser too
+	d.blobs[[32]byte{1}] = []byte("forged")
+	_, err = a.Get(context.Background(), commitment)
+	assert.ErrorContains(t, err, "storage root mismatch")
+}
@@ -0,0 +1,418 @@
+package rollup
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/aws/s3"
+	zg_leveldb "github.com/0glabs/0g-da-client/disperser/leveldb"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// dasEntryPrefix prefixes the keys of the commitments by data hash in the index
+const dasEntryPrefix = "_DAS_HASH_"
+
+// DASExpirationPolicy is the expiration policy the DAS server reports: the blobs are kept
+// by the DA for its retention period, which the timeouts are bounded by
+const DASExpirationPolicy = "DiscardAfterDataTimeout"
+
+// DASServerConfig configures the Arbitrum Nitro DAS server
+type DASServerConfig struct {
+	HTTPPort string
+	// IndexPath is the path of the leveldb database of the commitments by data hash
+	IndexPath string
+	// IndexBucket is the bucket of the object storage the entries of the index are also
+	// written to, under IndexPrefix, so that the certificates stay retrievable if the
+	// database is lost. The entries missing from the database are restored from it. The
+	// index is only local if empty.
+	IndexBucket string
+	IndexPrefix string
+	// RetentionPeriod bounds the timeouts of the stores, which are rejected beyond it, no bound
+	// if 0. It should not exceed the period the DA keeps the blobs for.
+	RetentionPeriod time.Duration
+	// PutTimeout bounds the wait for a blob to be confirmed, or finalized if configured,
+	// no bound if 0
+	PutTimeout time.Duration
+	// GetTimeout bounds the retrieval of a blob, no bound if 0
+	GetTimeout time.Duration
+	// BLSKeyFile is the file of the BLS private key the certificates are signed with, see
+	// NewDASSignerFromFile
+	BLSKeyFile string
+	// BatchPosters are the accounts of the batch posters allowed to store data
+	BatchPosters []eth_common.Address
+}
+
+// DASStoreResult is the reply of das_store, the fields of the data availability certificate
+// the batch poster posts to the sequencer inbox
+type DASStoreResult struct {
+	DataHash    hexutil.Bytes  `json:"dataHash,omitempty"`
+	Timeout     hexutil.Uint64 `json:"timeout,omitempty"`
+	SignersMask hexutil.Uint64 `json:"signersMask,omitempty"`
+	KeysetHash  hexutil.Bytes  `json:"keysetHash,omitempty"`
+	Sig         hexutil.Bytes  `json:"sig,omitempty"`
+	Version     hexutil.Uint64 `json:"version,omitempty"`
+}
+
+// DASIndexStore is the object storage the index of the DAS server is backed up to
+type DASIndexStore interface {
+	PutObject(ctx context.Context, bucket string, key string, data []byte, contentType string) error
+	// DownloadObject returns s3.ErrObjectNotFound if there is no object at key
+	DownloadObject(ctx context.Context, bucket string, key string) ([]byte, error)
+}
+
+// dasResponse is the reply of the REST endpoints
+type dasResponse struct {
+	Data             string `json:"data,omitempty"`
+	ExpirationPolicy string `json:"expirationPolicy,omitempty"`
+}
+
+// DASDataHashTag is the tag of the blobs stored through the DAS server, the hex data hash of
+// the certificate the blob backs
+const DASDataHashTag = "das-data-hash"
+
+// dasEntry is the 0g DA commitment of the data of a hash, as indexed, and the request id of
+// its blob at the disperser
+type dasEntry struct {
+	Commitment hexutil.Bytes `json:"commitment"`
+	Timeout    uint64        `json:"timeout"`
+	RequestID  string        `json:"requestId,omitempty"`
+}
+
+// DASConfirmation is the 0g DA blob backing a certificate, whose storage root, epoch and quorum
+// are those confirmed on chain in the batch of the blob
+type DASConfirmation struct {
+	DataHash    hexutil.Bytes  `json:"dataHash"`
+	Timeout     hexutil.Uint64 `json:"timeout"`
+	Commitment  hexutil.Bytes  `json:"commitment"`
+	StorageRoot hexutil.Bytes  `json:"storageRoot"`
+	Epoch       hexutil.Uint64 `json:"epoch"`
+	QuorumID    hexutil.Uint64 `json:"quorumId"`
+	RequestID   string         `json:"requestId,omitempty"`
+}
+
+// DASServer serves the Data Availability Server interface of Arbitrum Nitro on top of a DA,
+// so that Orbit chains in AnyTrust mode register it as a member of their committee:
+//   - the das_store and das_healthCheck JSON-RPC methods, POST /
+//   - GET /get-by-hash/<hex data hash> replies with the base64 data of a hash
+//   - GET /confirmation/<hex data hash> replies with the DASConfirmation of a hash
+//   - GET /expiration-policy and GET /health
+//
+// A store signed by a batch poster puts the data to the DA, tagged with the dastree hash of
+// the data, and replies once the blob is confirmed with a certificate of that hash signed
+// with the BLS key of the member. The commitment of the blob is indexed by the hash, so that
+// the blob is retrieved by hash and the certificate mapped to the confirmation of the blob.
+// The index is backed up to object storage if configured, as the tags of the blobs don't
+// outlive their finalization, so nothing else maps a hash back to its blob.
+type DASServer struct {
+	config       DASServerConfig
+	da           TaggingDA
+	signer       *DASSigner
+	batchPosters map[eth_common.Address]bool
+	db           *zg_leveldb.LevelDBStore
+	// store is nil unless the index is backed up
+	store  DASIndexStore
+	rpc    *rpc.Server
+	logger common.Logger
+	now    func() time.Time
+}
+
+// NewDASServer returns the DAS server of da, whose index is backed up to store if the
+// index bucket is configured
+func NewDASServer(config DASServerConfig, da TaggingDA, store DASIndexStore, logger common.Logger) (*DASServer, error) {
+	if config.IndexPath == "" {
+		return nil, errors.New("das index path is required")
+	}
+	if config.IndexBucket == "" {
+		store = nil
+	} else if store == nil {
+		return nil, errors.New("das index bucket requires an object storage")
+	}
+	if config.BLSKeyFile == "" {
+		return nil, errors.New("das bls key file is required")
+	}
+	if len(config.BatchPosters) == 0 {
+		return nil, errors.New("das batch posters are required")
+	}
+	signer, err := NewDASSignerFromFile(config.BLSKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	batchPosters := make(map[eth_common.Address]bool, len(config.BatchPosters))
+	for _, account := range config.BatchPosters {
+		batchPosters[account] = true
+	}
+	db, err := zg_leveldb.NewLevelDBStore(config.IndexPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open das index: %w", err)
+	}
+	s := &DASServer{
+		config:       config,
+		da:           da,
+		signer:       signer,
+		batchPosters: batchPosters,
+		db:           db,
+		store:        store,
+		rpc:          rpc.NewServer(),
+		logger:       logger,
+		now:          time.Now,
+	}
+	if err := s.rpc.RegisterName("das", &dasAPI{s}); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *DASServer) Close() error {
+	s.rpc.Stop()
+	return s.db.Close()
+}
+
+// Store puts the data to the DA and returns its certificate once the blob is available.
+// The timeout is a unix time in seconds.
+func (s *DASServer) Store(ctx context.Context, data []byte, timeout uint64) (*DASStoreResult, error) {
+	if len(data) == 0 {
+		return nil, errors.New("data is empty")
+	}
+	now := s.now()
+	if timeout <= uint64(now.Unix()) {
+		return nil, fmt.Errorf("timeout %d has passed", timeout)
+	}
+	if s.config.RetentionPeriod > 0 && timeout > uint64(now.Add(s.config.RetentionPeriod).Unix()) {
+		return nil, fmt.Errorf("timeout %d is beyond the retention period of %v", timeout, s.config.RetentionPeriod)
+	}
+
+	if s.config.PutTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.PutTimeout)
+		defer cancel()
+	}
+	hash := DASTreeHash(data)
+	commitment, requestID, err := s.da.PutTagged(ctx, data, map[string]string{DASDataHashTag: hash.Hex()})
+	if err != nil {
+		return nil, err
+	}
+	value, err := json.Marshal(dasEntry{Commitment: commitment, Timeout: timeout, RequestID: requestID})
+	if err != nil {
+		return nil, err
+	}
+	// the certificate is only signed once the entry is durable
+	if s.store != nil {
+		if err := s.store.PutObject(ctx, s.config.IndexBucket, s.objectKey(hash), value, "application/json"); err != nil {
+			return nil, fmt.Errorf("failed to back up commitment: %w", err)
+		}
+	}
+	if err := s.db.SyncWriteBatch([][]byte{dasKey(hash)}, [][]byte{value}); err != nil {
+		return nil, fmt.Errorf("failed to index commitment: %w", err)
+	}
+	s.logger.Debug("[rollup] das data stored", "size", len(data), "hash", hash, "commitment", hexutil.Encode(commitment), "request id", requestID)
+	result := &DASStoreResult{DataHash: hash[:], Timeout: hexutil.Uint64(timeout), Version: DASCertificateVersion}
+	s.signer.Sign(result)
+	return result, nil
+}
+
+// entry returns the index entry of a hash, restoring it from the backup if the database
+// doesn't hold it, zg_leveldb.ErrNotFound if neither does
+func (s *DASServer) entry(ctx context.Context, hash eth_common.Hash) (*dasEntry, error) {
+	value, err := s.db.Get(dasKey(hash))
+	if errors.Is(err, zg_leveldb.ErrNotFound) && s.store != nil {
+		value, err = s.store.DownloadObject(ctx, s.config.IndexBucket, s.objectKey(hash))
+		if errors.Is(err, s3.ErrObjectNotFound) {
+			return nil, zg_leveldb.ErrNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read commitment backup: %w", err)
+		}
+		if err := s.db.SyncWriteBatch([][]byte{dasKey(hash)}, [][]byte{value}); err != nil {
+			s.logger.Warn("[rollup] failed to restore das index entry", "hash", hash, "err", err)
+		} else {
+			s.logger.Info("[rollup] das index entry restored from backup", "hash", hash)
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	var entry dasEntry
+	if err := json.Unmarshal(value, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Confirmation returns the 0g DA blob backing the certificate of a hash
+func (s *DASServer) Confirmation(ctx context.Context, hash eth_common.Hash) (*DASConfirmation, error) {
+	entry, err := s.entry(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	c, err := DecodeCommitment(entry.Commitment)
+	if err != nil {
+		return nil, err
+	}
+	return &DASConfirmation{
+		DataHash:    hash[:],
+		Timeout:     hexutil.Uint64(entry.Timeout),
+		Commitment:  entry.Commitment,
+		StorageRoot: c.StorageRoot[:],
+		Epoch:       hexutil.Uint64(c.Epoch),
+		QuorumID:    hexutil.Uint64(c.QuorumId),
+		RequestID:   entry.RequestID,
+	}, nil
+}
+
+// GetByHash returns the data of a hash stored through the server, checked against the hash
+func (s *DASServer) GetByHash(ctx context.Context, hash eth_common.Hash) ([]byte, error) {
+	entry, err := s.entry(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.config.GetTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.GetTimeout)
+		defer cancel()
+	}
+	data, err := s.da.Get(ctx, entry.Commitment)
+	if err != nil {
+		return nil, err
+	}
+	if DASTreeHash(data) != hash {
+		return nil, fmt.Errorf("data of commitment %s does not match hash %s", hexutil.Encode(entry.Commitment), hash)
+	}
+	return data, nil
+}
+
+func dasKey(hash eth_common.Hash) []byte {
+	return append([]byte(dasEntryPrefix), hash[:]...)
+}
+
+// objectKey is the key of the backup of the index entry of a hash
+func (s *DASServer) objectKey(hash eth_common.Hash) string {
+	return path.Join(s.config.IndexPrefix, "das", hash.Hex()+".json")
+}
+
+func (s *DASServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/get-by-hash/", s.handleGetByHash)
+	mux.HandleFunc("/confirmation/", s.handleConfirmation)
+	mux.HandleFunc("/expiration-policy", func(w http.ResponseWriter, r *http.Request) {
+		writeDASResponse(w, dasResponse{ExpirationPolicy: DASExpirationPolicy})
+	})
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.Handle("/", s.rpc)
+	return mux
+}
+
+func (s *DASServer) Start(ctx context.Context) error {
+	server := newHTTPServer(s.config.HTTPPort, s.Handler(), s.config.PutTimeout, s.config.GetTimeout)
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+
+	s.logger.Info("[rollup] das server listening", "port", s.config.HTTPPort, "keyset hash", s.signer.KeysetHash(), "keyset", hexutil.Encode(s.signer.Keyset()))
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("could not start http server: %w", err)
+	}
+	return nil
+}
+
+// pathHash returns the hex data hash following prefix in the path of a GET request, with or
+// without 0x prefix, replying with an error if there is none
+func pathHash(w http.ResponseWriter, r *http.Request, prefix string) (eth_common.Hash, bool) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return eth_common.Hash{}, false
+	}
+	encoded := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "0x")
+	raw, err := hex.DecodeString(encoded)
+	if err != nil || len(raw) != eth_common.HashLength {
+		http.Error(w, "invalid data hash", http.StatusBadRequest)
+		return eth_common.Hash{}, false
+	}
+	return eth_common.BytesToHash(raw), true
+}
+
+// handleGetByHash serves GET /get-by-hash/<hex data hash>
+func (s *DASServer) handleGetByHash(w http.ResponseWriter, r *http.Request) {
+	hash, ok := pathHash(w, r, "/get-by-hash/")
+	if !ok {
+		return
+	}
+
+	data, err := s.GetByHash(r.Context(), hash)
+	if errors.Is(err, zg_leveldb.ErrNotFound) {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.logger.Warn("[rollup] failed to get das data", "hash", hash, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeDASResponse(w, dasResponse{Data: base64.StdEncoding.EncodeToString(data)})
+}
+
+// handleConfirmation serves GET /confirmation/<hex data hash>
+func (s *DASServer) handleConfirmation(w http.ResponseWriter, r *http.Request) {
+	hash, ok := pathHash(w, r, "/confirmation/")
+	if !ok {
+		return
+	}
+	confirmation, err := s.Confirmation(r.Context(), hash)
+	if errors.Is(err, zg_leveldb.ErrNotFound) {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(confirmation)
+}
+
+func writeDASResponse(w http.ResponseWriter, response dasResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// dasAPI is the das namespace of the JSON-RPC server
+type dasAPI struct {
+	s *DASServer
+}
+
+// Store serves das_store, for the batch posters only: sig is the ECDSA signature of the batch
+// poster over dastree(prefix || timeout || message), as Nitro signs its stores with the DAS
+// signer of the batch poster
+func (a *dasAPI) Store(ctx context.Context, message hexutil.Bytes, timeout hexutil.Uint64, sig hexutil.Bytes) (*DASStoreResult, error) {
+	signer, err := RecoverDASStoreSigner(message, uint64(timeout), sig)
+	if err != nil {
+		return nil, err
+	}
+	if !a.s.batchPosters[signer] {
+		return nil, fmt.Errorf("%w: %s is not a batch poster", ErrInvalidStoreSignature, signer)
+	}
+	result, err := a.s.Store(ctx, message, uint64(timeout))
+	if err != nil {
+		a.s.logger.Warn("[rollup] failed to store das data", "size", len(message), "err", err)
+	}
+	return result, err
+}
+
+// HealthCheck serves das_healthCheck
+func (a *dasAPI) HealthCheck(ctx context.Context) error {
+	return nil
+}
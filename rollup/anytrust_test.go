@@ -0,0 +1,197 @@
+package rollup
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/common/mock"
+	zg_leveldb "github.com/0glabs/0g-da-client/disperser/leveldb"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDASServer(t *testing.T) {
+	d := &fakeDisperser{
+		statuses: []pb.BlobStatus{pb.BlobStatus_PROCESSING, pb.BlobStatus_CONFIRMED},
+		blobs:    make(map[[32]byte][]byte),
+	}
+	blsKey := big.NewInt(12345)
+	keyFile := filepath.Join(t.TempDir(), "bls")
+	require.NoError(t, os.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString(blsKey.Bytes())), 0600))
+	batchPoster, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	s, err := NewDASServer(DASServerConfig{
+		IndexPath:       t.TempDir(),
+		RetentionPeriod: time.Hour,
+		BLSKeyFile:      keyFile,
+		BatchPosters:    []eth_common.Address{crypto.PubkeyToAddress(batchPoster.PublicKey)},
+	}, newTestAdapter(Config{}, d), nil, mock.NewLogger(false))
+	require.NoError(t, err)
+	defer s.Close()
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+	client, err := rpc.Dial(server.URL)
+	require.NoError(t, err)
+	defer client.Close()
+
+	store := func(message []byte, timeout hexutil.Uint64, sig []byte) (DASStoreResult, error) {
+		var result DASStoreResult
+		err := client.CallContext(context.Background(), &result, "das_store", hexutil.Bytes(message), timeout, hexutil.Bytes(sig))
+		return result, err
+	}
+	sign := func(message []byte, timeout hexutil.Uint64) []byte {
+		sig, err := crypto.Sign(dasStoreDigest(message, uint64(timeout)), batchPoster)
+		require.NoError(t, err)
+		return sig
+	}
+
+	// the stores of other accounts are rejected
+	timeout := hexutil.Uint64(time.Now().Add(time.Minute).Unix())
+	_, err = store([]byte("batch"), timeout, nil)
+	assert.ErrorContains(t, err, ErrInvalidStoreSignature.Error())
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sig, err := crypto.Sign(dasStoreDigest([]byte("batch"), uint64(timeout)), other)
+	require.NoError(t, err)
+	_, err = store([]byte("batch"), timeout, sig)
+	assert.ErrorContains(t, err, "is not a batch poster")
+	// as are the signatures of another message
+	_, err = store([]byte("batch"), timeout, sign([]byte("other"), timeout))
+	assert.ErrorContains(t, err, "is not a batch poster")
+
+	// the batch poster stores the data and gets its certificate back
+	result, err := store([]byte("batch"), timeout, sign([]byte("batch"), timeout))
+	require.NoError(t, err)
+	hash := DASTreeHash([]byte("batch"))
+	assert.Equal(t, hash[:], []byte(result.DataHash))
+	assert.Equal(t, timeout, result.Timeout)
+	assert.Equal(t, hexutil.Uint64(DASCertificateVersion), result.Version)
+	assert.Equal(t, hexutil.Uint64(1), result.SignersMask)
+	assert.Equal(t, DASTreeHash(s.signer.Keyset()).Bytes(), []byte(result.KeysetHash))
+	assert.Equal(t, hash.Hex(), d.tags[DASDataHashTag])
+
+	// the certificate is signed with the bls key of the keyset
+	g1, g2 := bls12381.NewG1(), bls12381.NewG2()
+	signature, err := g1.FromBytes(result.Sig)
+	require.NoError(t, err)
+	fields := append(binary.BigEndian.AppendUint64(hash.Bytes(), uint64(timeout)), DASCertificateVersion)
+	var padding [16]byte
+	message, err := g1.MapToCurve(append(padding[:], crypto.Keccak256(fields)...))
+	require.NoError(t, err)
+	engine := bls12381.NewPairingEngine()
+	engine.AddPair(signature, g2.One())
+	engine.AddPairInv(message, g2.MulScalar(g2.New(), g2.One(), blsKey))
+	assert.True(t, engine.Check())
+
+	// timeouts beyond the retention period are rejected
+	_, err = store([]byte("batch"), timeout+3600, sign([]byte("batch"), timeout+3600))
+	assert.ErrorContains(t, err, "retention period")
+
+	// the nodes get the data by hash
+	get := func(path string, response any) int {
+		resp, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(response))
+		}
+		return resp.StatusCode
+	}
+	var response dasResponse
+	require.Equal(t, http.StatusOK, get("/get-by-hash/"+result.DataHash.String()[2:], &response))
+	data, err := base64.StdEncoding.DecodeString(response.Data)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("batch"), data)
+
+	assert.Equal(t, http.StatusNotFound, get("/get-by-hash/"+hexutil.Encode(crypto.Keccak256([]byte("unknown"))), &response))
+	assert.Equal(t, http.StatusBadRequest, get("/get-by-hash/0x01", &response))
+
+	// the certificate maps to the confirmed blob
+	var confirmation DASConfirmation
+	require.Equal(t, http.StatusOK, get("/confirmation/"+result.DataHash.String(), &confirmation))
+	root := [32]byte{1}
+	assert.Equal(t, root[:], []byte(confirmation.StorageRoot))
+	assert.Equal(t, hexutil.Uint64(3), confirmation.Epoch)
+	assert.Equal(t, hexutil.Uint64(4), confirmation.QuorumID)
+	assert.Equal(t, "request", confirmation.RequestID)
+}
+
+func TestDASIndexBackup(t *testing.T) {
+	ctx := context.Background()
+	d := &fakeDisperser{
+		statuses: []pb.BlobStatus{pb.BlobStatus_CONFIRMED},
+		blobs:    make(map[[32]byte][]byte),
+	}
+	keyFile := filepath.Join(t.TempDir(), "bls")
+	require.NoError(t, os.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString(big.NewInt(12345).Bytes())), 0600))
+	config := DASServerConfig{
+		IndexPath:    t.TempDir(),
+		IndexBucket:  "das",
+		IndexPrefix:  "member",
+		BLSKeyFile:   keyFile,
+		BatchPosters: []eth_common.Address{{1}},
+	}
+	_, err := NewDASServer(config, newTestAdapter(Config{}, d), nil, mock.NewLogger(false))
+	assert.Error(t, err)
+
+	store := mock.NewS3Client()
+	s, err := NewDASServer(config, newTestAdapter(Config{}, d), store, mock.NewLogger(false))
+	require.NoError(t, err)
+	result, err := s.Store(ctx, []byte("batch"), uint64(time.Now().Add(time.Minute).Unix()))
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+	hash := eth_common.BytesToHash(result.DataHash)
+	_, err = store.DownloadObject(ctx, "das", "member/das/"+hash.Hex()+".json")
+	assert.NoError(t, err)
+
+	// the index is lost, its entries are restored from the backup
+	config.IndexPath = t.TempDir()
+	s, err = NewDASServer(config, newTestAdapter(Config{}, d), store, mock.NewLogger(false))
+	require.NoError(t, err)
+	defer s.Close()
+	confirmation, err := s.Confirmation(ctx, hash)
+	require.NoError(t, err)
+	assert.Equal(t, "request", confirmation.RequestID)
+	data, err := s.GetByHash(ctx, hash)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("batch"), data)
+	_, err = s.db.Get(dasKey(hash))
+	assert.NoError(t, err)
+
+	_, err = s.Confirmation(ctx, DASTreeHash([]byte("unknown")))
+	assert.ErrorIs(t, err, zg_leveldb.ErrNotFound)
+}
+
+func TestDASTreeHash(t *testing.T) {
+	leaf := func(bin []byte) []byte {
+		return crypto.Keccak256([]byte{dasTreeLeafByte}, crypto.Keccak256(bin))
+	}
+	node := func(left, right []byte, size uint32) []byte {
+		return crypto.Keccak256([]byte{dasTreeNodeByte}, left, right, binary.BigEndian.AppendUint32(nil, size))
+	}
+
+	assert.Equal(t, leaf([]byte("batch")), DASTreeHash([]byte("batch")).Bytes())
+
+	// three bins, the last one carried up
+	data := make([]byte, 2*dasTreeBinSize+10)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	a, b, c := leaf(data[:dasTreeBinSize]), leaf(data[dasTreeBinSize:2*dasTreeBinSize]), leaf(data[2*dasTreeBinSize:])
+	assert.Equal(t, node(node(a, b, 2*dasTreeBinSize), c, uint32(len(data))), DASTreeHash(data).Bytes())
+}
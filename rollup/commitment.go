@@ -0,0 +1,50 @@
+package rollup
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// CommitmentVersion0 is the first byte of commitments laid out as
+// version (1) | storage root (32) | epoch (8) | quorum id (8), integers big endian
+const CommitmentVersion0 byte = 0
+
+const commitmentV0Length = 1 + 32 + 8 + 8
+
+var ErrInvalidCommitment = errors.New("invalid commitment")
+
+// Commitment identifies a blob dispersed to 0g DA, which is what a rollup posts to its
+// inbox in place of the data
+type Commitment struct {
+	StorageRoot [32]byte
+	Epoch       uint64
+	QuorumId    uint64
+}
+
+func (c *Commitment) Encode() []byte {
+	data := make([]byte, commitmentV0Length)
+	data[0] = CommitmentVersion0
+	copy(data[1:33], c.StorageRoot[:])
+	binary.BigEndian.PutUint64(data[33:41], c.Epoch)
+	binary.BigEndian.PutUint64(data[41:49], c.QuorumId)
+	return data
+}
+
+func DecodeCommitment(data []byte) (*Commitment, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: empty", ErrInvalidCommitment)
+	}
+	if data[0] != CommitmentVersion0 {
+		return nil, fmt.Errorf("%w: unknown version %d", ErrInvalidCommitment, data[0])
+	}
+	if len(data) != commitmentV0Length {
+		return nil, fmt.Errorf("%w: expected %d bytes, got %d", ErrInvalidCommitment, commitmentV0Length, len(data))
+	}
+	c := &Commitment{
+		Epoch:    binary.BigEndian.Uint64(data[33:41]),
+		QuorumId: binary.BigEndian.Uint64(data[41:49]),
+	}
+	copy(c.StorageRoot[:], data[1:33])
+	return c, nil
+}
@@ -0,0 +1,28 @@
+package rollup
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// FuzzDecodeCommitment checks that certificates posted to an inbox decode without panicking,
+// and that those accepted encode back to the same bytes
+func FuzzDecodeCommitment(f *testing.F) {
+	f.Add((&Commitment{StorageRoot: [32]byte{1}, Epoch: 3, QuorumId: 1}).Encode())
+	f.Add([]byte{})
+	f.Add([]byte{1})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		commitment, err := DecodeCommitment(data)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidCommitment) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			return
+		}
+		if !bytes.Equal(commitment.Encode(), data) {
+			t.Fatalf("commitment %x doesn't encode back to itself", data)
+		}
+	})
+}
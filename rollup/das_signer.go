@@ -0,0 +1,139 @@
+package rollup
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+)
+
+// DASCertificateVersion is the version of the certificates signed by the DAS server, whose
+// data hashes are dastree roots
+const DASCertificateVersion = 1
+
+// dasStorePrefix prefixes the message the batch poster signs for das_store
+const dasStorePrefix = "Arbitrum Nitro DAS API Store:"
+
+var ErrInvalidStoreSignature = errors.New("invalid das store signature")
+
+// DASSigner signs the certificates of the DAS server with the BLS key of a member of the
+// AnyTrust committee, as a Nitro DAS does: the batch poster aggregates the signatures of the
+// members into the certificate it posts. The keyset of the member alone, with which it may
+// also form a committee of one, is Keyset.
+type DASSigner struct {
+	key        *big.Int
+	keyset     []byte
+	keysetHash eth_common.Hash
+}
+
+// NewDASSignerFromFile loads the BLS private key of a committee member from a file holding
+// its base64 encoding, as written by Nitro's datool keygen
+func NewDASSignerFromFile(path string) (*DASSigner, error) {
+	encoded, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read das bls key: %w", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid das bls key: %w", err)
+	}
+	return NewDASSigner(new(big.Int).SetBytes(key))
+}
+
+func NewDASSigner(key *big.Int) (*DASSigner, error) {
+	if key.Sign() <= 0 || key.Cmp(bls12381.NewG2().Q()) >= 0 {
+		return nil, errors.New("invalid das bls key")
+	}
+	s := &DASSigner{key: key}
+	g2 := bls12381.NewG2()
+	publicKey := g2.ToBytes(g2.MulScalar(g2.New(), g2.One(), key))
+	// the public key is registered with the proof of the possession of its private key
+	proof := bls12381.NewG1().ToBytes(s.sign(publicKey, true))
+
+	// the keyset of the member, trusted to be honest, and its key with the length of the proof
+	// prefixed and both prefixed with their length
+	keyBytes := append(append([]byte{byte(len(proof))}, proof...), publicKey...)
+	var keyset bytes.Buffer
+	keyset.Write(binary.BigEndian.AppendUint64(nil, 1))
+	keyset.Write(binary.BigEndian.AppendUint64(nil, 1))
+	keyset.Write(binary.BigEndian.AppendUint16(nil, uint16(len(keyBytes))))
+	keyset.Write(keyBytes)
+	s.keyset = keyset.Bytes()
+	s.keysetHash = DASTreeHash(s.keyset)
+	return s, nil
+}
+
+// Keyset is the serialized keyset of the member, to be registered with setValidKeyset of the
+// sequencer inbox for a committee of one
+func (s *DASSigner) Keyset() []byte {
+	return s.keyset
+}
+
+func (s *DASSigner) KeysetHash() eth_common.Hash {
+	return s.keysetHash
+}
+
+// sign returns the BLS signature of message, the hash of the message mapped to G1 times the
+// private key. The public keys sign themselves in a separate domain.
+func (s *DASSigner) sign(message []byte, keyValidation bool) *bls12381.PointG1 {
+	var padding [16]byte
+	if keyValidation {
+		padding[0] = 1
+	}
+	point, err := bls12381.NewG1().MapToCurve(append(padding[:], crypto.Keccak256(message)...))
+	if err != nil {
+		// the input is always 48 bytes
+		panic(err)
+	}
+	g1 := bls12381.NewG1()
+	return g1.MulScalar(g1.New(), point, s.key)
+}
+
+// Sign signs the certificate of a store, setting its signature, keyset hash and signers mask
+func (s *DASSigner) Sign(result *DASStoreResult) {
+	fields := make([]byte, 0, 41)
+	fields = append(fields, result.DataHash...)
+	fields = binary.BigEndian.AppendUint64(fields, uint64(result.Timeout))
+	if result.Version != 0 {
+		fields = append(fields, byte(result.Version))
+	}
+	result.Sig = bls12381.NewG1().ToBytes(s.sign(fields, false))
+	result.KeysetHash = s.keysetHash[:]
+	// the batch poster sets the mask of the signers it aggregated
+	result.SignersMask = 1
+}
+
+// dasStoreDigest is the digest the batch poster signs for das_store,
+// dastree(prefix || timeout || message) with the timeout as 8 big endian bytes
+func dasStoreDigest(message []byte, timeout uint64) []byte {
+	preimage := make([]byte, 0, len(dasStorePrefix)+8+len(message))
+	preimage = append(preimage, dasStorePrefix...)
+	preimage = binary.BigEndian.AppendUint64(preimage, timeout)
+	preimage = append(preimage, message...)
+	hash := DASTreeHash(preimage)
+	return hash[:]
+}
+
+// RecoverDASStoreSigner returns the account which signed a das_store request
+func RecoverDASStoreSigner(message []byte, timeout uint64, sig []byte) (eth_common.Address, error) {
+	if len(sig) != crypto.SignatureLength {
+		return eth_common.Address{}, ErrInvalidStoreSignature
+	}
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig = append([]byte{}, sig...)
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	publicKey, err := crypto.SigToPub(dasStoreDigest(message, timeout), sig)
+	if err != nil {
+		return eth_common.Address{}, ErrInvalidStoreSignature
+	}
+	return crypto.PubkeyToAddress(*publicKey), nil
+}
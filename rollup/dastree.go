@@ -0,0 +1,54 @@
+package rollup
+
+import (
+	"encoding/binary"
+
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// The data hashes of the Nitro DAS certificates of version 1 are the roots of a Merkle tree
+// over the data, as computed by Nitro's dastree package
+const (
+	dasTreeBinSize       = 64 * 1024
+	dasTreeLeafByte byte = 0xfe
+	dasTreeNodeByte byte = 0xff
+)
+
+type dasTreeNode struct {
+	hash eth_common.Hash
+	size uint32
+}
+
+// DASTreeHash returns the dastree root of data: the leaves are keccak256(0xfe || keccak256(bin))
+// of its 64 KiB bins, the pairs of nodes are hashed as keccak256(0xff || left || right || size)
+// with the size of the data under them as 4 big endian bytes, an odd node left over being
+// carried to the next layer
+func DASTreeHash(data []byte) eth_common.Hash {
+	leaf := func(bin []byte) eth_common.Hash {
+		return crypto.Keccak256Hash([]byte{dasTreeLeafByte}, crypto.Keccak256(bin))
+	}
+	if len(data) == 0 {
+		return leaf(nil)
+	}
+
+	length := uint32(len(data))
+	layer := make([]dasTreeNode, 0, (length+dasTreeBinSize-1)/dasTreeBinSize)
+	for start := uint32(0); start < length; start += dasTreeBinSize {
+		end := min(start+dasTreeBinSize, length)
+		layer = append(layer, dasTreeNode{hash: leaf(data[start:end]), size: end - start})
+	}
+	for len(layer) > 1 {
+		paired := make([]dasTreeNode, 0, (len(layer)+1)/2)
+		for i := 0; i+1 < len(layer); i += 2 {
+			size := layer[i].size + layer[i+1].size
+			hash := crypto.Keccak256Hash([]byte{dasTreeNodeByte}, layer[i].hash[:], layer[i+1].hash[:], binary.BigEndian.AppendUint32(nil, size))
+			paired = append(paired, dasTreeNode{hash: hash, size: size})
+		}
+		if len(layer)%2 == 1 {
+			paired = append(paired, layer[len(layer)-1])
+		}
+		layer = paired
+	}
+	return layer[0].hash
+}
@@ -0,0 +1,207 @@
+package rollup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Commitment types of the OP Stack Alt-DA commitments, the first byte of the commitments
+// OP nodes request
+const (
+	OPKeccak256CommitmentType byte = 0
+	OPGenericCommitmentType   byte = 1
+)
+
+// OPServerConfig configures the OP Stack Alt-DA server
+type OPServerConfig struct {
+	HTTPPort string
+	// DALayer is the DA layer byte following the commitment type in the generic commitments
+	DALayer byte
+	// PutTimeout bounds the wait for a blob to be confirmed, or finalized if configured,
+	// no bound if 0
+	PutTimeout time.Duration
+	// GetTimeout bounds the retrieval of a blob, no bound if 0
+	GetTimeout time.Duration
+}
+
+// OPServer serves the OP Stack Alt-DA server HTTP interface on top of a DA, so that the
+// batcher and the nodes of an OP chain run with da-service enabled and point their da-server
+// at it:
+//   - POST /put with the data as body replies with a generic commitment,
+//     type (1) | DA layer (1) | 0g DA commitment, once the blob is available
+//   - GET /get/0x<hex commitment> replies with the data of a generic commitment, 404 if
+//     the DA knows no such blob
+//   - GET /health replies 200 while the server runs
+//
+// Keccak256 commitments, whose data the server would have to store itself, are rejected.
+type OPServer struct {
+	config OPServerConfig
+	da     DA
+	logger common.Logger
+}
+
+func NewOPServer(config OPServerConfig, da DA, logger common.Logger) *OPServer {
+	return &OPServer{config: config, da: da, logger: logger}
+}
+
+// EncodeOPCommitment wraps a 0g DA commitment in a generic OP Stack commitment
+func EncodeOPCommitment(daLayer byte, commitment []byte) []byte {
+	return append([]byte{OPGenericCommitmentType, daLayer}, commitment...)
+}
+
+// DecodeOPCommitment returns the 0g DA commitment of a generic OP Stack commitment
+func DecodeOPCommitment(daLayer byte, data []byte) ([]byte, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("%w: expected a commitment type and a DA layer", ErrInvalidCommitment)
+	}
+	if data[0] != OPGenericCommitmentType {
+		return nil, fmt.Errorf("%w: unsupported commitment type %d", ErrInvalidCommitment, data[0])
+	}
+	if data[1] != daLayer {
+		return nil, fmt.Errorf("%w: unknown DA layer %d", ErrInvalidCommitment, data[1])
+	}
+	return data[2:], nil
+}
+
+func (s *OPServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/put", s.handlePut)
+	mux.HandleFunc("/put/", s.handlePut)
+	mux.HandleFunc("/get/", s.handleGet)
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
+
+// readHeaderTimeout bounds the reading of the request headers of the servers, and
+// unboundedWriteTimeout the writing of the replies when the puts or gets are unbounded
+const (
+	readHeaderTimeout     = 10 * time.Second
+	unboundedWriteTimeout = time.Hour
+)
+
+// newHTTPServer returns the server of handler on port, whose replies are written within the
+// longest of the put and get timeouts and a minute for the rest of the request
+func newHTTPServer(port string, handler http.Handler, putTimeout, getTimeout time.Duration) *http.Server {
+	writeTimeout := unboundedWriteTimeout
+	if putTimeout > 0 && getTimeout > 0 {
+		writeTimeout = max(putTimeout, getTimeout) + time.Minute
+	}
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%s", port),
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+		WriteTimeout:      writeTimeout,
+	}
+}
+
+func (s *OPServer) Start(ctx context.Context) error {
+	server := newHTTPServer(s.config.HTTPPort, s.Handler(), s.config.PutTimeout, s.config.GetTimeout)
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+
+	s.logger.Info("[rollup] op stack alt-da server listening", "port", s.config.HTTPPort)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("could not start http server: %w", err)
+	}
+	return nil
+}
+
+// handlePut serves POST /put, and POST /put/0x<hex commitment> with a keccak256 commitment
+// to reject it
+func (s *OPServer) handlePut(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if key := strings.TrimPrefix(r.URL.Path, "/put/"); key != r.URL.Path && key != "" {
+		http.Error(w, "keccak256 commitments are not supported, enable da-service to use generic commitments", http.StatusBadRequest)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(core.MaxBlobSize)))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("data exceeds %d bytes", core.MaxBlobSize), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(data) == 0 {
+		http.Error(w, "data is empty", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	if s.config.PutTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.PutTimeout)
+		defer cancel()
+	}
+	commitment, err := s.da.Put(ctx, data)
+	if err != nil {
+		s.logger.Warn("[rollup] failed to put data", "size", len(data), "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.logger.Debug("[rollup] data put", "size", len(data), "commitment", hexutil.Encode(commitment))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, _ = w.Write(EncodeOPCommitment(s.config.DALayer, commitment))
+}
+
+// handleGet serves GET /get/0x<hex commitment>
+func (s *OPServer) handleGet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	encoded, err := hexutil.Decode(strings.TrimPrefix(r.URL.Path, "/get/"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid commitment: %v", err), http.StatusBadRequest)
+		return
+	}
+	commitment, err := DecodeOPCommitment(s.config.DALayer, encoded)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	if s.config.GetTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.GetTimeout)
+		defer cancel()
+	}
+	data, err := s.da.Get(ctx, commitment)
+	switch {
+	case errors.Is(err, ErrInvalidCommitment):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case status.Code(err) == codes.NotFound:
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	case err != nil:
+		// the node retries the other failures, which a 404 would have it treat as missing data
+		s.logger.Warn("[rollup] failed to get data", "commitment", hexutil.Encode(commitment), "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, _ = w.Write(data)
+}
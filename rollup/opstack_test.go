@@ -0,0 +1,55 @@
+package rollup
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOPServer(t *testing.T) {
+	d := &fakeDisperser{
+		statuses: []pb.BlobStatus{pb.BlobStatus_PROCESSING, pb.BlobStatus_CONFIRMED},
+		blobs:    make(map[[32]byte][]byte),
+	}
+	server := httptest.NewServer(NewOPServer(OPServerConfig{DALayer: 7}, newTestAdapter(Config{}, d), mock.NewLogger(false)).Handler())
+	defer server.Close()
+	call := func(method, path string, body []byte) (int, []byte) {
+		req, err := http.NewRequest(method, server.URL+path, bytes.NewReader(body))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, data
+	}
+
+	// the batcher gets a generic commitment, which the nodes get the data back with
+	code, commitment := call(http.MethodPost, "/put", []byte("batch"))
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []byte{OPGenericCommitmentType, 7}, commitment[:2])
+	c, err := DecodeCommitment(commitment[2:])
+	require.NoError(t, err)
+	assert.Equal(t, [32]byte{1}, c.StorageRoot)
+
+	code, data := call(http.MethodGet, "/get/"+hexutil.Encode(commitment), nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []byte("batch"), data)
+
+	// keccak256 commitments and other DA layers are rejected
+	code, _ = call(http.MethodPost, "/put/"+hexutil.Encode(make([]byte, 33)), []byte("batch"))
+	assert.Equal(t, http.StatusBadRequest, code)
+	commitment[1] = 8
+	code, _ = call(http.MethodGet, "/get/"+hexutil.Encode(commitment), nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = call(http.MethodGet, "/get/0x01", nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
@@ -0,0 +1,24 @@
+package devnet
+
+import (
+	"context"
+	"time"
+
+	"github.com/0glabs/0g-da-client/disperser/batcher/dispatcher"
+)
+
+// mine mines a block of the in-memory dispatch target every blockTime until ctx is done. The
+// batcher sends its transactions to the target, each included in a new block, and its
+// finalizer reads from the target when they are final.
+func mine(ctx context.Context, target *dispatcher.MemoryTarget, blockTime time.Duration) {
+	ticker := time.NewTicker(blockTime)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			target.Mine()
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package devnet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/geth"
+	"github.com/0glabs/0g-da-client/common/lifecycle"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	"github.com/0glabs/0g-da-client/disperser/apiserver"
+	"github.com/0glabs/0g-da-client/disperser/batcher"
+	"github.com/0glabs/0g-da-client/disperser/batcher/dispatcher"
+	"github.com/0glabs/0g-da-client/disperser/common/memorydb"
+	"github.com/0glabs/0g-da-client/disperser/encoder"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// requestsPerMinute lifts the rate limits of the API server, which a single local client
+// would hit in tests
+const requestsPerMinute = 60000
+
+// Config of a local devnet. The API server listens on GrpcPort, the encoder and the
+// retriever on EncoderAddr and RetrieverAddr, which pick a free port if it is 0.
+type Config struct {
+	GrpcPort      string
+	HTTPPort      string
+	EncoderAddr   string
+	RetrieverAddr string
+	// DataDir holds the kv store of the finalized blobs, a temporary directory removed on
+	// stop if empty
+	DataDir      string
+	NumOperators int
+	// BlockTime is the interval between the blocks of the simulated chain, and between the
+	// batches of the batcher
+	BlockTime          time.Duration
+	FinalizationBlocks uint64
+	MaxRetries         uint
+}
+
+func (c Config) validate() error {
+	if c.GrpcPort == "" {
+		return errors.New("the grpc port of the API server must be set")
+	}
+	if c.NumOperators <= 0 {
+		return errors.New("the devnet needs at least one operator")
+	}
+	if c.BlockTime <= 0 {
+		return errors.New("the block time must be positive")
+	}
+	return nil
+}
+
+// Devnet runs the API server and the batcher against the in-memory dispatch target, a mock
+// of the DA signers contract with simulated operators, a local encoder and a retriever, so
+// that applications integrate against the disperser API without external dependencies. The
+// blobs are batched, signed by the operators with their BLS keys, confirmed and finalized
+// by the batcher of a real deployment, but they aren't KZG encoded nor posted to the DA
+// contracts, and the chain has a single epoch.
+type Devnet struct {
+	config    Config
+	blobStore disperser.BlobStore
+	operators *serviceManager
+	target    *dispatcher.MemoryTarget
+	logger    common.Logger
+}
+
+func New(config Config, logger common.Logger) (*Devnet, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	operators, err := newServiceManager(config.NumOperators)
+	if err != nil {
+		return nil, err
+	}
+	return &Devnet{
+		config:    config,
+		blobStore: memorydb.NewBlobStore(core.MaxBlobSize*64, logger),
+		operators: operators,
+		target:    dispatcher.NewMemoryTarget(0, 0),
+		logger:    logger,
+	}, nil
+}
+
+// SetOperatorOnline takes an operator online or offline. The blobs dispersed while too many
+// operators are offline fail to reach their quorum threshold, and the blobs with a slice on
+// an offline operator can't be retrieved.
+func (d *Devnet) SetOperatorOnline(index int, online bool) error {
+	return d.operators.setOnline(index, online)
+}
+
+// Setup adds the components of the devnet to the manager
+func (d *Devnet) Setup(manager *lifecycle.Manager) error {
+	dataDir := d.config.DataDir
+	if dataDir == "" {
+		var err error
+		dataDir, err = os.MkdirTemp("", "zgda-devnet-")
+		if err != nil {
+			return err
+		}
+		manager.Add(lifecycle.Component{
+			Name:  "data dir",
+			Start: func(ctx context.Context) error { return nil },
+			Stop:  func(ctx context.Context) error { return os.RemoveAll(dataDir) },
+		})
+	}
+	kvStore, err := disperser.NewLevelDBStore(dataDir+"/chunk", 0, d.logger)
+	if err != nil {
+		return fmt.Errorf("failed to create the kv store: %w", err)
+	}
+
+	var retrieverAddr string
+	manager.Add(lifecycle.Component{Name: "retriever", Start: func(ctx context.Context) error {
+		addr, err := serveRetriever(ctx, d.config.RetrieverAddr, d.operators)
+		if err != nil {
+			return err
+		}
+		retrieverAddr = addr
+		d.logger.Info("[devnet] retriever listening", "address", addr)
+		return nil
+	}})
+	manager.Add(lifecycle.Component{Name: "encoder", Start: func(ctx context.Context) error {
+		addr, err := serveEncoder(ctx, d.config.EncoderAddr, d.config.NumOperators)
+		if err != nil {
+			return err
+		}
+		d.logger.Info("[devnet] encoder listening", "address", addr)
+		encoderClient, err := encoder.NewEncoderClient(addr, time.Minute, 1, "", encoder.BatchingConfig{}, insecure.NewCredentials(), nil)
+		if err != nil {
+			return err
+		}
+		return d.startBatcher(ctx, encoderClient, kvStore)
+	}})
+
+	metrics := disperser.NewMetrics("", d.logger)
+	manager.Serve("api", func(ctx context.Context) error {
+		server := apiserver.NewDispersalServer(
+			disperser.ServerConfig{
+				GrpcPort:              d.config.GrpcPort,
+				HTTPPort:              d.config.HTTPPort,
+				ReadRequestsPerMinute: requestsPerMinute,
+			},
+			// as with the memory db of the combined server, finalized blobs are read from the kv store
+			d.blobStore, d.logger, metrics, nil, apiserver.RateConfig{}, true, kvStore, retrieverAddr, nil, nil, nil, nil, nil, nil, nil,
+		)
+		return server.Start(ctx)
+	})
+	return nil
+}
+
+// startBatcher starts a batcher dispersing to the operators of the service manager and
+// confirming on the in-memory dispatch target, with a finalizer following the target
+func (d *Devnet) startBatcher(ctx context.Context, encoderClient disperser.EncoderClient, kvStore *disperser.Store) error {
+	config := batcher.Config{
+		PullInterval:              d.config.BlockTime,
+		FinalizerInterval:         d.config.BlockTime,
+		NumConnections:            d.config.NumOperators,
+		EncodingRequestQueueSize:  64,
+		BatchSizeMBLimit:          64,
+		MaxNumRetriesPerBlob:      d.config.MaxRetries,
+		ConfirmerNum:              1,
+		EncodingInterval:          d.config.BlockTime,
+		SigningInterval:           d.config.BlockTime,
+		SignedPullInterval:        d.config.BlockTime,
+		FinalizedBlockCount:       uint(d.config.FinalizationBlocks),
+		ExpirationPollIntervalSec: 60,
+	}
+	metrics := batcher.NewMetrics("", d.logger)
+	blobKeyCache := &disperser.BlobKeyCache{Key: make(map[[32]byte]bool)}
+	// the finalizer reads the final blocks from the target, the confirmer of the batcher
+	d.target.SetFinalizationBlocks(d.config.FinalizationBlocks)
+	finalizer := batcher.NewFinalizer(batcher.TimeoutConfig{}, config, d.blobStore, nil, nil, d.logger, kvStore, blobKeyCache, metrics, nil)
+	confirmer, err := batcher.NewBatchConfirmer(geth.EthClientConfig{}, config, d.blobStore, d.target, d.logger, metrics)
+	if err != nil {
+		return err
+	}
+	b, err := batcher.NewBatcher(config, batcher.TimeoutConfig{}, d.blobStore, d.target, encoderClient, finalizer, confirmer, nil, d.logger, metrics, blobKeyCache)
+	if err != nil {
+		return err
+	}
+	b.SetSigners(d.operators, d.operators)
+
+	go mine(ctx, d.target, d.config.BlockTime)
+	return b.Start(ctx)
+}
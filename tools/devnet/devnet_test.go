@@ -0,0 +1,99 @@
+package devnet
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	pb "github.com/0glabs/0g-da-client/api/grpc/disperser"
+	"github.com/0glabs/0g-da-client/common/lifecycle"
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/disperser/api/grpc/retriever"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func freePort(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+}
+
+func dial(t *testing.T, addr string) *grpc.ClientConn {
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// disperse retries the dispersals rejected while the server starts
+func disperse(t *testing.T, client pb.DisperserClient, data []byte) *pb.DisperseBlobReply {
+	var reply *pb.DisperseBlobReply
+	require.Eventually(t, func() bool {
+		var err error
+		reply, err = client.DisperseBlob(context.Background(), &pb.DisperseBlobRequest{Data: data})
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	return reply
+}
+
+func waitForStatus(t *testing.T, client pb.DisperserClient, requestID []byte, status pb.BlobStatus) *pb.BlobStatusReply {
+	var reply *pb.BlobStatusReply
+	require.Eventually(t, func() bool {
+		var err error
+		reply, err = client.GetBlobStatus(context.Background(), &pb.BlobStatusRequest{RequestId: requestID})
+		return err == nil && reply.GetStatus() == status
+	}, time.Minute, 10*time.Millisecond)
+	return reply
+}
+
+func TestDevnet(t *testing.T) {
+	logger := mock.NewLogger(false)
+	retrieverPort := freePort(t)
+	devnet, err := New(Config{
+		GrpcPort:           freePort(t),
+		RetrieverAddr:      "127.0.0.1:" + retrieverPort,
+		NumOperators:       4,
+		BlockTime:          10 * time.Millisecond,
+		FinalizationBlocks: 2,
+	}, logger)
+	require.NoError(t, err)
+	manager := lifecycle.NewManager(lifecycle.Config{StartTimeout: 5 * time.Second}, logger)
+	require.NoError(t, devnet.Setup(manager))
+	require.NoError(t, manager.Start(context.Background()))
+	t.Cleanup(func() { assert.NoError(t, manager.Stop()) })
+
+	client := pb.NewDisperserClient(dial(t, "127.0.0.1:"+devnet.config.GrpcPort))
+	reply := disperse(t, client, []byte("hello devnet"))
+	assert.Equal(t, pb.BlobStatus_PROCESSING, reply.GetResult())
+
+	status := waitForStatus(t, client, reply.GetRequestId(), pb.BlobStatus_FINALIZED)
+	header := status.GetInfo().GetBlobHeader()
+	retrieved, err := client.RetrieveBlob(context.Background(), &pb.RetrieveBlobRequest{
+		StorageRoot: header.GetStorageRoot(),
+		Epoch:       header.GetEpoch(),
+		QuorumId:    header.GetQuorumId(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello devnet"), retrieved.GetData())
+
+	// the operators hold the slices the retriever reassembles the blob from
+	retrieverClient := retriever.NewRetrieverClient(dial(t, "127.0.0.1:"+retrieverPort))
+	request := &retriever.BlobRequest{StorageRoot: header.GetStorageRoot(), Epoch: header.GetEpoch(), QuorumId: header.GetQuorumId()}
+	fromOperators, err := retrieverClient.RetrieveBlob(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello devnet"), fromOperators.GetData())
+	require.NoError(t, devnet.SetOperatorOnline(0, false))
+	_, err = retrieverClient.RetrieveBlob(context.Background(), request)
+	assert.Error(t, err)
+
+	// blobs fail below their quorum threshold
+	require.NoError(t, devnet.SetOperatorOnline(1, false))
+	reply = disperse(t, client, []byte("below the threshold"))
+	waitForStatus(t, client, reply.GetRequestId(), pb.BlobStatus_FAILED)
+}
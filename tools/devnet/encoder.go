@@ -0,0 +1,77 @@
+package devnet
+
+import (
+	"context"
+	"net"
+
+	pb "github.com/0glabs/0g-da-client/disperser/api/grpc/encoder"
+	bn "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/ethereum/go-ethereum/crypto"
+	"google.golang.org/grpc"
+)
+
+// localEncoder serves the encoder API without the KZG setup of the real encoder. It commits
+// every blob to the generator, roots it by its keccak hash, and splits it into one slice
+// per operator instead of erasure coding it, so any missing slice loses the blob.
+type localEncoder struct {
+	pb.UnimplementedEncoderServer
+	numSlices int
+}
+
+func (e *localEncoder) EncodeBlob(ctx context.Context, request *pb.EncodeBlobRequest) (*pb.EncodeBlobReply, error) {
+	return e.encode(request.GetData()), nil
+}
+
+func (e *localEncoder) EncodeBlobs(ctx context.Context, request *pb.EncodeBlobsRequest) (*pb.EncodeBlobsReply, error) {
+	reply := &pb.EncodeBlobsReply{Replies: make([]*pb.EncodeBlobReply, len(request.GetRequests()))}
+	for i, r := range request.GetRequests() {
+		reply.Replies[i] = e.encode(r.GetData())
+	}
+	return reply, nil
+}
+
+func (e *localEncoder) encode(data []byte) *pb.EncodeBlobReply {
+	_, _, g1, _ := bn.Generators()
+	// the encoder serializes the coordinates in little endian
+	commitment := g1.RawBytes()
+	for i := 0; i < fp.Bytes/2; i++ {
+		commitment[i], commitment[fp.Bytes-i-1] = commitment[fp.Bytes-i-1], commitment[i]
+		commitment[fp.Bytes+i], commitment[len(commitment)-i-1] = commitment[len(commitment)-i-1], commitment[fp.Bytes+i]
+	}
+	return &pb.EncodeBlobReply{
+		ErasureCommitment: commitment[:],
+		StorageRoot:       crypto.Keccak256(data),
+		EncodedData:       data,
+		EncodedSlice:      splitSlices(data, e.numSlices),
+	}
+}
+
+// splitSlices splits data into n slices of about the same size
+func splitSlices(data []byte, n int) [][]byte {
+	slices := make([][]byte, n)
+	size := (len(data) + n - 1) / n
+	for i := range slices {
+		start := min(i*size, len(data))
+		end := min(start+size, len(data))
+		slices[i] = data[start:end]
+	}
+	return slices
+}
+
+// serveEncoder serves the local encoder on addr until ctx is done, returning the address
+// it listens on
+func serveEncoder(ctx context.Context, addr string, numSlices int) (string, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+	server := grpc.NewServer()
+	pb.RegisterEncoderServer(server, &localEncoder{numSlices: numSlices})
+	go func() { _ = server.Serve(listener) }()
+	go func() {
+		<-ctx.Done()
+		server.Stop()
+	}()
+	return listener.Addr().String(), nil
+}
@@ -0,0 +1,208 @@
+package devnet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/disperser"
+	pb "github.com/0glabs/0g-da-client/disperser/api/grpc/signer"
+	"github.com/0glabs/0g-da-client/disperser/batcher"
+	"github.com/0glabs/0g-da-client/disperser/contract/da_signers"
+	bn "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	eth_common "github.com/ethereum/go-ethereum/common"
+)
+
+var errOperatorOffline = errors.New("operator offline")
+
+// sliceKey addresses the slices of a confirmed blob, as the retrieval requests do
+type sliceKey struct {
+	storageRoot string
+	epoch       uint64
+	quorumID    uint64
+}
+
+// operator is a simulated DA node, which stores the slices of each blob it is sent and signs
+// for them with its BLS key
+type operator struct {
+	index   int
+	address eth_common.Address
+	socket  string
+	key     *core.KeyPair
+
+	mu     sync.RWMutex
+	online bool
+	slices map[sliceKey][]byte
+}
+
+// sign stores the slices of the requests, returning the signature of each blob
+func (o *operator) sign(requests []*pb.SignRequest) ([]*core.Signature, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !o.online {
+		return nil, errOperatorOffline
+	}
+	signatures := make([]*core.Signature, len(requests))
+	for i, request := range requests {
+		if len(request.GetEncodedSlice()) != 1 {
+			return nil, fmt.Errorf("operator %d holds one slice of each blob, sent %d", o.index, len(request.GetEncodedSlice()))
+		}
+		commitment, err := requestCommitment(request.GetErasureCommitment())
+		if err != nil {
+			return nil, err
+		}
+		var dataRoot [32]byte
+		copy(dataRoot[:], request.GetStorageRoot())
+		message, err := core.SignatureMessage(dataRoot, new(big.Int).SetUint64(request.GetEpoch()), new(big.Int).SetUint64(request.GetQuorumId()), commitment)
+		if err != nil {
+			return nil, err
+		}
+		o.slices[sliceKey{
+			storageRoot: string(request.GetStorageRoot()),
+			epoch:       request.GetEpoch(),
+			quorumID:    request.GetQuorumId(),
+		}] = request.GetEncodedSlice()[0]
+		signatures[i] = o.key.SignMessage(message)
+	}
+	return signatures, nil
+}
+
+func (o *operator) slice(key sliceKey) ([]byte, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if !o.online {
+		return nil, false
+	}
+	slice, ok := o.slices[key]
+	return slice, ok
+}
+
+// requestCommitment parses the erasure commitment of a signing request, serialized in
+// little endian as the encoder replies it
+func requestCommitment(serialized []byte) (*core.G1Point, error) {
+	if len(serialized) != bn.SizeOfG1AffineUncompressed {
+		return nil, fmt.Errorf("erasure commitment of %d bytes", len(serialized))
+	}
+	commitment := make([]byte, len(serialized))
+	copy(commitment, serialized)
+	for i := 0; i < fp.Bytes/2; i++ {
+		commitment[i], commitment[fp.Bytes-i-1] = commitment[fp.Bytes-i-1], commitment[i]
+		commitment[fp.Bytes+i], commitment[len(commitment)-i-1] = commitment[len(commitment)-i-1], commitment[fp.Bytes+i]
+	}
+	return new(core.G1Point).Deserialize(commitment)
+}
+
+// serviceManager is a mock of the DA signers contract, registering the operators of the
+// single quorum of the devnet, each assigned one slice of every blob. It serves the signing
+// requests of the batcher to the operators in process.
+type serviceManager struct {
+	operators []*operator
+}
+
+var (
+	_ batcher.SignerRegistry = (*serviceManager)(nil)
+	_ disperser.SignerClient = (*serviceManager)(nil)
+)
+
+func newServiceManager(numOperators int) (*serviceManager, error) {
+	operators := make([]*operator, numOperators)
+	for i := range operators {
+		key, err := core.GenRandomBlsKeys()
+		if err != nil {
+			return nil, err
+		}
+		operators[i] = &operator{
+			index:   i,
+			address: eth_common.BigToAddress(big.NewInt(int64(i + 1))),
+			socket:  fmt.Sprintf("operator-%d", i),
+			key:     key,
+			online:  true,
+			slices:  make(map[sliceKey][]byte),
+		}
+	}
+	return &serviceManager{operators: operators}, nil
+}
+
+// setOnline takes an operator online or offline, to exercise the batches that fail to reach
+// their quorum threshold or the blobs that can't be retrieved
+func (m *serviceManager) setOnline(index int, online bool) error {
+	if index < 0 || index >= len(m.operators) {
+		return fmt.Errorf("no operator %d out of %d", index, len(m.operators))
+	}
+	o := m.operators[index]
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.online = online
+	return nil
+}
+
+// GetQuorum returns the operator assigned each slice, the same in every epoch and quorum
+func (m *serviceManager) GetQuorum(opts *bind.CallOpts, epoch *big.Int, quorumId *big.Int) ([]eth_common.Address, error) {
+	addresses := make([]eth_common.Address, len(m.operators))
+	for i, o := range m.operators {
+		addresses[i] = o.address
+	}
+	return addresses, nil
+}
+
+func (m *serviceManager) GetSigner(opts *bind.CallOpts, accounts []eth_common.Address) ([]da_signers.IDASignersSignerDetail, error) {
+	details := make([]da_signers.IDASignersSignerDetail, len(accounts))
+	for i, account := range accounts {
+		o, err := m.operator(account)
+		if err != nil {
+			return nil, err
+		}
+		pkG2 := o.key.GetPubKeyG2()
+		details[i] = da_signers.IDASignersSignerDetail{
+			Signer: o.address,
+			Socket: o.socket,
+			PkG1: da_signers.BN254G1Point{
+				X: o.key.PubKey.X.BigInt(new(big.Int)),
+				Y: o.key.PubKey.Y.BigInt(new(big.Int)),
+			},
+			PkG2: da_signers.BN254G2Point{
+				X: [2]*big.Int{pkG2.X.A0.BigInt(new(big.Int)), pkG2.X.A1.BigInt(new(big.Int))},
+				Y: [2]*big.Int{pkG2.Y.A0.BigInt(new(big.Int)), pkG2.Y.A1.BigInt(new(big.Int))},
+			},
+		}
+	}
+	return details, nil
+}
+
+// BatchSign sends the slices of a batch to the operator at addr
+func (m *serviceManager) BatchSign(ctx context.Context, addr string, data []*pb.SignRequest, log common.Logger) ([]*core.Signature, error) {
+	for _, o := range m.operators {
+		if o.socket == addr {
+			return o.sign(data)
+		}
+	}
+	return nil, fmt.Errorf("no operator at %s", addr)
+}
+
+func (m *serviceManager) operator(address eth_common.Address) (*operator, error) {
+	for _, o := range m.operators {
+		if o.address == address {
+			return o, nil
+		}
+	}
+	return nil, fmt.Errorf("no operator %s", address)
+}
+
+// retrieve reassembles a blob from the slices of the operators
+func (m *serviceManager) retrieve(key sliceKey) ([]byte, error) {
+	data := make([]byte, 0)
+	for _, o := range m.operators {
+		slice, ok := o.slice(key)
+		if !ok {
+			return nil, errors.New("blob not found on all the operators")
+		}
+		data = append(data, slice...)
+	}
+	return data, nil
+}
@@ -0,0 +1,47 @@
+package devnet
+
+import (
+	"context"
+	"net"
+
+	"github.com/0glabs/0g-da-client/disperser/api/grpc/retriever"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// localRetriever serves the retriever API, reassembling the blobs from the slices of the
+// simulated operators
+type localRetriever struct {
+	retriever.UnimplementedRetrieverServer
+	operators *serviceManager
+}
+
+func (r *localRetriever) RetrieveBlob(ctx context.Context, request *retriever.BlobRequest) (*retriever.BlobReply, error) {
+	data, err := r.operators.retrieve(sliceKey{
+		storageRoot: string(request.GetStorageRoot()),
+		epoch:       request.GetEpoch(),
+		quorumID:    request.GetQuorumId(),
+	})
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return &retriever.BlobReply{Data: data}, nil
+}
+
+// serveRetriever serves the retriever on addr until ctx is done, returning the address it
+// listens on
+func serveRetriever(ctx context.Context, addr string, operators *serviceManager) (string, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+	server := grpc.NewServer(grpc.MaxSendMsgSize(1024 * 1024 * 1024))
+	retriever.RegisterRetrieverServer(server, &localRetriever{operators: operators})
+	go func() { _ = server.Serve(listener) }()
+	go func() {
+		<-ctx.Done()
+		server.Stop()
+	}()
+	return listener.Addr().String(), nil
+}
@@ -0,0 +1,80 @@
+package quorumsim
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// QuorumReader reads the quorums from the DASigners contract
+type QuorumReader interface {
+	EpochNumber(opts *bind.CallOpts) (*big.Int, error)
+	QuorumCount(opts *bind.CallOpts, epoch *big.Int) (*big.Int, error)
+	GetQuorum(opts *bind.CallOpts, epoch *big.Int, quorumId *big.Int) ([]common.Address, error)
+}
+
+// SnapshotFromChain takes a snapshot of the quorums of an epoch, the current one if epoch
+// is nil. A quorum is a list of slots, so the stake of a signer is its number of slots.
+func SnapshotFromChain(ctx context.Context, reader QuorumReader, epoch *big.Int) (*Snapshot, error) {
+	opts := &bind.CallOpts{Context: ctx}
+	if epoch == nil {
+		current, err := reader.EpochNumber(opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get epoch number: %w", err)
+		}
+		epoch = current
+	}
+
+	count, err := reader.QuorumCount(opts, epoch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quorum count of epoch %s: %w", epoch, err)
+	}
+	if !count.IsUint64() || count.Uint64() > 256 {
+		return nil, fmt.Errorf("unexpected quorum count %s", count)
+	}
+
+	snapshot := &Snapshot{
+		Epoch:   epoch.Uint64(),
+		Quorums: make(map[core.QuorumID][]OperatorStake),
+	}
+	for i := uint64(0); i < count.Uint64(); i++ {
+		slots, err := reader.GetQuorum(opts, epoch, new(big.Int).SetUint64(i))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get quorum %d of epoch %s: %w", i, epoch, err)
+		}
+
+		index := make(map[common.Address]int)
+		operators := make([]OperatorStake, 0)
+		for _, signer := range slots {
+			j, ok := index[signer]
+			if !ok {
+				j = len(operators)
+				index[signer] = j
+				operators = append(operators, OperatorStake{OperatorID: signer.Hex(), Stake: new(big.Int)})
+			}
+			operators[j].Stake.Add(operators[j].Stake, big.NewInt(1))
+		}
+		if len(operators) > 0 {
+			snapshot.Quorums[core.QuorumID(i)] = operators
+		}
+	}
+	if len(snapshot.Quorums) == 0 {
+		return nil, fmt.Errorf("epoch %s has no signers", epoch)
+	}
+	return snapshot, nil
+}
+
+// Save writes the snapshot to a json file that LoadSnapshot can read
+func (s *Snapshot) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/0glabs/0g-da-client/common/logging"
+	"github.com/0glabs/0g-da-client/tools/quorumsim"
+	"github.com/0glabs/0g-da-client/tools/quorumsim/flags"
+	"github.com/urfave/cli"
+)
+
+type Config struct {
+	SnapshotFile             string
+	RPCURL                   string
+	DASignersContractAddress string
+	Epoch                    uint64
+	SaveSnapshotFile         string
+	OutputFile               string
+	Trials                   int
+	Seed                     int64
+	Params                   []quorumsim.Params
+	Scenarios                []quorumsim.Scenario
+	Criteria                 quorumsim.Criteria
+	LoggerConfig             logging.Config
+}
+
+func NewConfig(ctx *cli.Context) (Config, error) {
+	adversaryThresholds, err := parseList(ctx.GlobalString(flags.AdversaryThresholdsFlag.Name), parsePercentage)
+	if err != nil {
+		return Config{}, err
+	}
+	quorumThresholds, err := parseList(ctx.GlobalString(flags.QuorumThresholdsFlag.Name), parsePercentage)
+	if err != nil {
+		return Config{}, err
+	}
+	quantizations, err := parseList(ctx.GlobalString(flags.QuantizationsFlag.Name), func(s string) (uint, error) {
+		v, err := strconv.ParseUint(s, 10, 32)
+		return uint(v), err
+	})
+	if err != nil {
+		return Config{}, err
+	}
+	collusionFactors, err := parseList(ctx.GlobalString(flags.CollusionFactorsFlag.Name), parseFloat)
+	if err != nil {
+		return Config{}, err
+	}
+	churnRates, err := parseList(ctx.GlobalString(flags.ChurnRatesFlag.Name), parseFloat)
+	if err != nil {
+		return Config{}, err
+	}
+
+	config := Config{
+		SnapshotFile:             ctx.GlobalString(flags.SnapshotFileFlag.Name),
+		RPCURL:                   ctx.GlobalString(flags.RPCFlag.Name),
+		DASignersContractAddress: ctx.GlobalString(flags.DASignersContractAddressFlag.Name),
+		Epoch:                    ctx.GlobalUint64(flags.EpochFlag.Name),
+		SaveSnapshotFile:         ctx.GlobalString(flags.SaveSnapshotFileFlag.Name),
+		OutputFile:               ctx.GlobalString(flags.OutputFileFlag.Name),
+		Trials:                   ctx.GlobalInt(flags.TrialsFlag.Name),
+		Seed:                     ctx.GlobalInt64(flags.SeedFlag.Name),
+		Params:                   quorumsim.ParamGrid(adversaryThresholds, quorumThresholds, quantizations),
+		Scenarios:                quorumsim.Scenarios(collusionFactors, churnRates),
+		Criteria: quorumsim.Criteria{
+			MinConfirmationRate: ctx.GlobalFloat64(flags.MinConfirmationRateFlag.Name),
+			MaxUnsafeRate:       ctx.GlobalFloat64(flags.MaxUnsafeRateFlag.Name),
+		},
+		LoggerConfig: logging.ReadCLIConfig(ctx, flags.FlagPrefix),
+	}
+	if config.SnapshotFile == "" && (config.RPCURL == "" || config.DASignersContractAddress == "") {
+		return Config{}, fmt.Errorf("either %s or both %s and %s must be set", flags.SnapshotFileFlag.Name, flags.RPCFlag.Name, flags.DASignersContractAddressFlag.Name)
+	}
+	return config, nil
+}
+
+func parseList[T any](s string, parse func(string) (T, error)) ([]T, error) {
+	values := make([]T, 0)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		v, err := parse(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q: %w", item, err)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func parsePercentage(s string) (uint8, error) {
+	v, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return 0, err
+	}
+	if v > 100 {
+		return 0, fmt.Errorf("percentage exceeds 100")
+	}
+	return uint8(v), nil
+}
+
+func parseFloat(s string) (float64, error) {
+	return strconv.ParseFloat(s, 64)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+
+	"github.com/0glabs/0g-da-client/common/logging"
+	"github.com/0glabs/0g-da-client/disperser/contract/da_signers"
+	"github.com/0glabs/0g-da-client/tools/quorumsim"
+	"github.com/0glabs/0g-da-client/tools/quorumsim/flags"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/urfave/cli"
+)
+
+var (
+	// version is the version of the binary.
+	version   string
+	gitCommit string
+	gitDate   string
+)
+
+func main() {
+	app := cli.NewApp()
+	app.Flags = flags.Flags
+	app.Version = fmt.Sprintf("%s-%s-%s", version, gitCommit, gitDate)
+	app.Name = "quorumsim"
+	app.Usage = "ZGDA Quorum Simulator"
+	app.Description = "Simulates blob availability for a stake snapshot and recommends per-quorum security parameters"
+
+	app.Action = RunSimulation
+	err := app.Run(os.Args)
+	if err != nil {
+		log.Fatalf("application failed: %v", err)
+	}
+}
+
+func RunSimulation(ctx *cli.Context) error {
+	config, err := NewConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	logger, err := logging.GetLogger(config.LoggerConfig)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := loadSnapshot(config)
+	if err != nil {
+		return err
+	}
+	if config.SaveSnapshotFile != "" {
+		if err := snapshot.Save(config.SaveSnapshotFile); err != nil {
+			return fmt.Errorf("failed to save snapshot: %w", err)
+		}
+	}
+
+	simulator, err := quorumsim.NewSimulator(config.Trials, config.Criteria, config.Seed)
+	if err != nil {
+		return err
+	}
+	report, err := simulator.Run(snapshot, config.Params, config.Scenarios)
+	if err != nil {
+		return err
+	}
+
+	for _, quorum := range report.Quorums {
+		if quorum.Recommendation == nil {
+			logger.Warn("no parameters satisfy the criteria", "quorum", quorum.QuorumID, "operators", quorum.NumOperators)
+			continue
+		}
+		logger.Info("recommended parameters", "quorum", quorum.QuorumID, "operators", quorum.NumOperators,
+			"adversaryThreshold", quorum.Recommendation.SecurityParam.AdversaryThreshold,
+			"quorumThreshold", quorum.Recommendation.SecurityParam.QuorumThreshold,
+			"quantization", quorum.Recommendation.Quantization,
+			"overhead", quorum.Recommendation.Overhead)
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if config.OutputFile == "" {
+		fmt.Println(string(data))
+		return nil
+	}
+	return os.WriteFile(config.OutputFile, data, 0644)
+}
+
+func loadSnapshot(config Config) (*quorumsim.Snapshot, error) {
+	if config.SnapshotFile != "" {
+		return quorumsim.LoadSnapshot(config.SnapshotFile)
+	}
+
+	client, err := ethclient.Dial(config.RPCURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %w", config.RPCURL, err)
+	}
+	defer client.Close()
+
+	signers, err := da_signers.NewDASignersCaller(eth_common.HexToAddress(config.DASignersContractAddress), client)
+	if err != nil {
+		return nil, err
+	}
+	var epoch *big.Int
+	if config.Epoch > 0 {
+		epoch = new(big.Int).SetUint64(config.Epoch)
+	}
+	return quorumsim.SnapshotFromChain(context.Background(), signers, epoch)
+}
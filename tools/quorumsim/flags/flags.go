@@ -0,0 +1,143 @@
+package flags
+
+import (
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/logging"
+	"github.com/urfave/cli"
+)
+
+const (
+	FlagPrefix   = "quorumsim"
+	EnvVarPrefix = "QUORUMSIM"
+)
+
+var (
+	/* Optional Flags*/
+	SnapshotFileFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "snapshot-file"),
+		Usage:    "json file with the stake of each operator per quorum. The snapshot is taken from the chain if empty",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "SNAPSHOT_FILE"),
+	}
+	RPCFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "rpc"),
+		Usage:    "chain rpc endpoint the snapshot is taken from",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "RPC"),
+	}
+	DASignersContractAddressFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "da-signers-contract"),
+		Usage:    "address of the DASigners contract",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "DA_SIGNERS_CONTRACT"),
+	}
+	EpochFlag = cli.Uint64Flag{
+		Name:     common.PrefixFlag(FlagPrefix, "epoch"),
+		Usage:    "epoch the snapshot is taken at, the current epoch if zero",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "EPOCH"),
+	}
+	SaveSnapshotFileFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "save-snapshot-file"),
+		Usage:    "file the snapshot taken from the chain is written to, so that it can be simulated again",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "SAVE_SNAPSHOT_FILE"),
+	}
+	AdversaryThresholdsFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "adversary-thresholds"),
+		Usage:    "comma separated adversary thresholds to simulate, in percent",
+		Required: false,
+		Value:    "20,25,33,40",
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "ADVERSARY_THRESHOLDS"),
+	}
+	QuorumThresholdsFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "quorum-thresholds"),
+		Usage:    "comma separated quorum thresholds to simulate, in percent",
+		Required: false,
+		Value:    "50,60,67,75,80,90",
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "QUORUM_THRESHOLDS"),
+	}
+	QuantizationsFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "quantizations"),
+		Usage:    "comma separated quantization factors to simulate, the number of recovery chunks per operator",
+		Required: false,
+		Value:    "1,2,4,8",
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "QUANTIZATIONS"),
+	}
+	CollusionFactorsFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "collusion-factors"),
+		Usage:    "comma separated adversary stakes as multiples of the adversary threshold",
+		Required: false,
+		Value:    "0,1",
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "COLLUSION_FACTORS"),
+	}
+	ChurnRatesFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "churn-rates"),
+		Usage:    "comma separated probabilities of an honest operator being offline",
+		Required: false,
+		Value:    "0,0.05,0.1",
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "CHURN_RATES"),
+	}
+	TrialsFlag = cli.IntFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "trials"),
+		Usage:    "number of trials per scenario",
+		Required: false,
+		Value:    1000,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "TRIALS"),
+	}
+	SeedFlag = cli.Int64Flag{
+		Name:     common.PrefixFlag(FlagPrefix, "seed"),
+		Usage:    "seed of the random number generator",
+		Required: false,
+		Value:    1,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "SEED"),
+	}
+	MinConfirmationRateFlag = cli.Float64Flag{
+		Name:     common.PrefixFlag(FlagPrefix, "min-confirmation-rate"),
+		Usage:    "minimum rate of confirmed batches in every scenario for parameters to be recommended",
+		Required: false,
+		Value:    0.99,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "MIN_CONFIRMATION_RATE"),
+	}
+	MaxUnsafeRateFlag = cli.Float64Flag{
+		Name:     common.PrefixFlag(FlagPrefix, "max-unsafe-rate"),
+		Usage:    "maximum rate of confirmed but unrecoverable blobs in every scenario for parameters to be recommended",
+		Required: false,
+		Value:    0,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "MAX_UNSAFE_RATE"),
+	}
+	OutputFileFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "output-file"),
+		Usage:    "file the json report is written to, stdout if empty",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "OUTPUT_FILE"),
+	}
+)
+
+var RequiredFlags = []cli.Flag{}
+
+var OptionalFlags = []cli.Flag{
+	SnapshotFileFlag,
+	RPCFlag,
+	DASignersContractAddressFlag,
+	EpochFlag,
+	SaveSnapshotFileFlag,
+	AdversaryThresholdsFlag,
+	QuorumThresholdsFlag,
+	QuantizationsFlag,
+	CollusionFactorsFlag,
+	ChurnRatesFlag,
+	TrialsFlag,
+	SeedFlag,
+	MinConfirmationRateFlag,
+	MaxUnsafeRateFlag,
+	OutputFileFlag,
+}
+
+// Flags contains the list of configuration options available to the binary.
+var Flags []cli.Flag
+
+func init() {
+	Flags = append(RequiredFlags, OptionalFlags...)
+	Flags = append(Flags, logging.CLIFlags(EnvVarPrefix, FlagPrefix)...)
+}
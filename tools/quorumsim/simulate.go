@@ -0,0 +1,302 @@
+// Package quorumsim simulates blob availability for a stake distribution under a grid of
+// security parameters and adversary scenarios, and recommends per-quorum parameters.
+//
+// Chunks are assigned as the disperser does: with coding rate gamma = (quorum threshold -
+// adversary threshold), operator i receives ceil(stake_i * M / gamma) chunks, where
+// M = quantization * number of operators is the number of chunks the blob is recoverable
+// from. Any set of operators holding gamma of the stake then holds at least M chunks.
+package quorumsim
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/0glabs/0g-da-client/core"
+)
+
+// Params are the security parameters of one simulation run
+type Params struct {
+	AdversaryThreshold uint8 `json:"adversary_threshold"`
+	QuorumThreshold    uint8 `json:"quorum_threshold"`
+	Quantization       uint  `json:"quantization"`
+}
+
+func (p Params) validate() error {
+	if p.QuorumThreshold > 100 {
+		return fmt.Errorf("quorum threshold %d exceeds 100", p.QuorumThreshold)
+	}
+	if p.AdversaryThreshold >= p.QuorumThreshold {
+		return fmt.Errorf("adversary threshold %d must be below quorum threshold %d", p.AdversaryThreshold, p.QuorumThreshold)
+	}
+	if p.Quantization == 0 {
+		return errors.New("quantization must be positive")
+	}
+	return nil
+}
+
+// Scenario describes the adversary and the honest operators going offline
+type Scenario struct {
+	Name string `json:"name"`
+	// CollusionFactor is the stake controlled by the adversary as a multiple of the
+	// adversary threshold. Values above 1 break the security assumption on purpose.
+	CollusionFactor float64 `json:"collusion_factor"`
+	// ChurnRate is the probability that an honest operator is offline
+	ChurnRate float64 `json:"churn_rate"`
+}
+
+// Scenarios returns the cross product of collusion factors and churn rates
+func Scenarios(collusionFactors, churnRates []float64) []Scenario {
+	scenarios := make([]Scenario, 0, len(collusionFactors)*len(churnRates))
+	for _, factor := range collusionFactors {
+		for _, churn := range churnRates {
+			scenarios = append(scenarios, Scenario{
+				Name:            fmt.Sprintf("collusion=%gx,churn=%g", factor, churn),
+				CollusionFactor: factor,
+				ChurnRate:       churn,
+			})
+		}
+	}
+	return scenarios
+}
+
+// ScenarioResult aggregates the trials of one scenario. A trial confirms when the signing
+// stake reaches the quorum threshold; it is unsafe when it confirms but the honest signers
+// hold fewer chunks than needed to recover the blob.
+type ScenarioResult struct {
+	Scenario         Scenario `json:"scenario"`
+	AdversaryStake   float64  `json:"adversary_stake"`
+	ConfirmationRate float64  `json:"confirmation_rate"`
+	UnsafeRate       float64  `json:"unsafe_rate"`
+}
+
+// Result is the outcome of all scenarios for one set of parameters
+type Result struct {
+	Params Params `json:"params"`
+	// Overhead is the total number of chunks divided by the chunks needed for recovery
+	Overhead  float64          `json:"overhead"`
+	Scenarios []ScenarioResult `json:"scenarios"`
+}
+
+// Criteria select the acceptable parameters
+type Criteria struct {
+	MinConfirmationRate float64
+	MaxUnsafeRate       float64
+}
+
+func (r *Result) satisfies(criteria Criteria) bool {
+	for _, s := range r.Scenarios {
+		if s.ConfirmationRate < criteria.MinConfirmationRate || s.UnsafeRate > criteria.MaxUnsafeRate {
+			return false
+		}
+	}
+	return true
+}
+
+// Recommendation is the parameter set recommended for a quorum
+type Recommendation struct {
+	SecurityParam core.SecurityParam `json:"security_param"`
+	Quantization  uint               `json:"quantization"`
+	Overhead      float64            `json:"overhead"`
+}
+
+type QuorumReport struct {
+	QuorumID       core.QuorumID   `json:"quorum_id"`
+	NumOperators   int             `json:"num_operators"`
+	Results        []Result        `json:"results"`
+	Recommendation *Recommendation `json:"recommendation"`
+}
+
+type Report struct {
+	Epoch   uint64         `json:"epoch"`
+	Trials  int            `json:"trials"`
+	Quorums []QuorumReport `json:"quorums"`
+}
+
+type Simulator struct {
+	Trials   int
+	Criteria Criteria
+	rng      *rand.Rand
+}
+
+func NewSimulator(trials int, criteria Criteria, seed int64) (*Simulator, error) {
+	if trials <= 0 {
+		return nil, errors.New("number of trials must be positive")
+	}
+	return &Simulator{
+		Trials:   trials,
+		Criteria: criteria,
+		rng:      rand.New(rand.NewSource(seed)),
+	}, nil
+}
+
+// Run simulates every quorum of the snapshot under all given parameters and scenarios.
+// Parameters that are invalid, e.g. an adversary threshold above the quorum threshold,
+// are skipped.
+func (s *Simulator) Run(snapshot *Snapshot, params []Params, scenarios []Scenario) (*Report, error) {
+	report := &Report{
+		Epoch:  snapshot.Epoch,
+		Trials: s.Trials,
+	}
+	for _, quorumID := range snapshot.QuorumIDs() {
+		shares, err := stakeShares(snapshot.Quorums[quorumID])
+		if err != nil {
+			return nil, fmt.Errorf("quorum %d: %w", quorumID, err)
+		}
+
+		quorumReport := QuorumReport{
+			QuorumID:     quorumID,
+			NumOperators: len(shares),
+		}
+		for _, p := range params {
+			if p.validate() != nil {
+				continue
+			}
+			quorumReport.Results = append(quorumReport.Results, s.simulate(shares, p, scenarios))
+		}
+		quorumReport.Recommendation = s.recommend(quorumID, quorumReport.Results)
+		report.Quorums = append(report.Quorums, quorumReport)
+	}
+	return report, nil
+}
+
+// assignChunks returns the number of chunks of each operator and the number of chunks
+// needed to recover the blob
+func assignChunks(shares []float64, p Params) ([]uint64, uint64) {
+	gamma := float64(p.QuorumThreshold-p.AdversaryThreshold) / 100
+	recovery := uint64(p.Quantization) * uint64(len(shares))
+	chunks := make([]uint64, len(shares))
+	for i, share := range shares {
+		chunks[i] = uint64(math.Ceil(share * float64(recovery) / gamma))
+	}
+	return chunks, recovery
+}
+
+func (s *Simulator) simulate(shares []float64, p Params, scenarios []Scenario) Result {
+	chunks, recovery := assignChunks(shares, p)
+	var total uint64
+	for _, c := range chunks {
+		total += c
+	}
+
+	result := Result{
+		Params:   p,
+		Overhead: float64(total) / float64(recovery),
+	}
+	quorumThreshold := float64(p.QuorumThreshold) / 100
+	for _, scenario := range scenarios {
+		adversary, adversaryStake := selectAdversary(shares, chunks, scenario.CollusionFactor*float64(p.AdversaryThreshold)/100)
+
+		confirmed, unsafe := 0, 0
+		for trial := 0; trial < s.Trials; trial++ {
+			signingStake := adversaryStake
+			var honestChunks uint64
+			for i := range shares {
+				if adversary[i] || s.rng.Float64() < scenario.ChurnRate {
+					continue
+				}
+				signingStake += shares[i]
+				honestChunks += chunks[i]
+			}
+			// the adversary signs to get the batch confirmed but withholds its chunks
+			if signingStake+1e-9 < quorumThreshold {
+				continue
+			}
+			confirmed++
+			if honestChunks < recovery {
+				unsafe++
+			}
+		}
+
+		result.Scenarios = append(result.Scenarios, ScenarioResult{
+			Scenario:         scenario,
+			AdversaryStake:   adversaryStake,
+			ConfirmationRate: float64(confirmed) / float64(s.Trials),
+			UnsafeRate:       float64(unsafe) / float64(s.Trials),
+		})
+	}
+	return result
+}
+
+// selectAdversary corrupts operators in order of chunks per unit of stake, which withholds
+// the most chunks for the stake the adversary is allowed to control
+func selectAdversary(shares []float64, chunks []uint64, budget float64) ([]bool, float64) {
+	order := make([]int, len(shares))
+	for i := range order {
+		order[i] = i
+	}
+	ratio := func(i int) float64 {
+		if shares[i] == 0 {
+			return math.Inf(1)
+		}
+		return float64(chunks[i]) / shares[i]
+	}
+	sort.SliceStable(order, func(a, b int) bool { return ratio(order[a]) > ratio(order[b]) })
+
+	adversary := make([]bool, len(shares))
+	stake := 0.0
+	for _, i := range order {
+		if stake+shares[i] > budget+1e-9 {
+			continue
+		}
+		adversary[i] = true
+		stake += shares[i]
+	}
+	return adversary, stake
+}
+
+// recommend picks among the results satisfying the criteria the one tolerating the largest
+// adversary, preferring the lowest overhead and then the lowest quorum threshold
+func (s *Simulator) recommend(quorumID core.QuorumID, results []Result) *Recommendation {
+	var best *Result
+	for i := range results {
+		r := &results[i]
+		if !r.satisfies(s.Criteria) {
+			continue
+		}
+		if best == nil || better(r, best) {
+			best = r
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return &Recommendation{
+		SecurityParam: core.SecurityParam{
+			QuorumID:           quorumID,
+			AdversaryThreshold: best.Params.AdversaryThreshold,
+			QuorumThreshold:    best.Params.QuorumThreshold,
+		},
+		Quantization: best.Params.Quantization,
+		Overhead:     best.Overhead,
+	}
+}
+
+func better(a, b *Result) bool {
+	if a.Params.AdversaryThreshold != b.Params.AdversaryThreshold {
+		return a.Params.AdversaryThreshold > b.Params.AdversaryThreshold
+	}
+	if a.Overhead != b.Overhead {
+		return a.Overhead < b.Overhead
+	}
+	return a.Params.QuorumThreshold < b.Params.QuorumThreshold
+}
+
+// ParamGrid returns all combinations of the given thresholds and quantizations
+func ParamGrid(adversaryThresholds, quorumThresholds []uint8, quantizations []uint) []Params {
+	params := make([]Params, 0, len(adversaryThresholds)*len(quorumThresholds)*len(quantizations))
+	for _, a := range adversaryThresholds {
+		for _, q := range quorumThresholds {
+			for _, quantization := range quantizations {
+				params = append(params, Params{
+					AdversaryThreshold: a,
+					QuorumThreshold:    q,
+					Quantization:       quantization,
+				})
+			}
+		}
+	}
+	return params
+}
@@ -0,0 +1,105 @@
+package quorumsim
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSnapshot() *Snapshot {
+	operators := make([]OperatorStake, 0)
+	for i := 1; i <= 10; i++ {
+		operators = append(operators, OperatorStake{Stake: big.NewInt(int64(i * 100))})
+	}
+	return &Snapshot{Quorums: map[uint8][]OperatorStake{0: operators}}
+}
+
+func TestAssignChunks(t *testing.T) {
+	shares, err := stakeShares(testSnapshot().Quorums[0])
+	assert.NoError(t, err)
+
+	p := Params{AdversaryThreshold: 33, QuorumThreshold: 67, Quantization: 2}
+	chunks, recovery := assignChunks(shares, p)
+	assert.Equal(t, uint64(20), recovery)
+	for i, share := range shares {
+		// operators never receive fewer chunks than their stake entitles them to
+		assert.GreaterOrEqual(t, float64(chunks[i]), share*float64(recovery)/0.34)
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	simulator, err := NewSimulator(200, Criteria{MinConfirmationRate: 0.99}, 1)
+	assert.NoError(t, err)
+
+	params := ParamGrid([]uint8{20, 33}, []uint8{30, 67}, []uint{1, 4})
+	report, err := simulator.Run(testSnapshot(), params, Scenarios([]float64{0, 1, 2}, []float64{0, 0.2}))
+	assert.NoError(t, err)
+	assert.Len(t, report.Quorums, 1)
+
+	quorum := report.Quorums[0]
+	// 33/30 is not a valid combination
+	assert.Len(t, quorum.Results, 6)
+	for _, result := range quorum.Results {
+		for _, s := range result.Scenarios {
+			if s.Scenario.CollusionFactor <= 1 {
+				assert.Zero(t, s.UnsafeRate, "params %+v scenario %s", result.Params, s.Scenario.Name)
+			}
+		}
+	}
+
+	// a doubled adversary breaks the assumption once honest operators go offline
+	for _, result := range quorum.Results {
+		if result.Params.AdversaryThreshold != 33 {
+			continue
+		}
+		for _, s := range result.Scenarios {
+			if s.Scenario.CollusionFactor == 2 && s.Scenario.ChurnRate > 0 {
+				assert.Greater(t, s.UnsafeRate, 0.0)
+			}
+		}
+	}
+	if assert.NotNil(t, quorum.Recommendation) {
+		assert.NotEqual(t, uint8(33), quorum.Recommendation.SecurityParam.AdversaryThreshold)
+	}
+
+	report, err = simulator.Run(testSnapshot(), params, Scenarios([]float64{0, 1}, []float64{0}))
+	assert.NoError(t, err)
+	recommendation := report.Quorums[0].Recommendation
+	assert.NotNil(t, recommendation)
+	assert.Equal(t, uint8(33), recommendation.SecurityParam.AdversaryThreshold)
+	assert.Equal(t, uint8(67), recommendation.SecurityParam.QuorumThreshold)
+}
+
+type quorumReader struct {
+	quorums [][]common.Address
+}
+
+func (r *quorumReader) EpochNumber(opts *bind.CallOpts) (*big.Int, error) {
+	return big.NewInt(7), nil
+}
+
+func (r *quorumReader) QuorumCount(opts *bind.CallOpts, epoch *big.Int) (*big.Int, error) {
+	return big.NewInt(int64(len(r.quorums))), nil
+}
+
+func (r *quorumReader) GetQuorum(opts *bind.CallOpts, epoch *big.Int, quorumId *big.Int) ([]common.Address, error) {
+	return r.quorums[quorumId.Int64()], nil
+}
+
+func TestSnapshotFromChain(t *testing.T) {
+	a, b := common.HexToAddress("0x01"), common.HexToAddress("0x02")
+	reader := &quorumReader{quorums: [][]common.Address{{a, b, a, a}, {b}}}
+
+	snapshot, err := SnapshotFromChain(context.Background(), reader, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), snapshot.Epoch)
+	assert.Equal(t, []OperatorStake{
+		{OperatorID: a.Hex(), Stake: big.NewInt(3)},
+		{OperatorID: b.Hex(), Stake: big.NewInt(1)},
+	}, snapshot.Quorums[0])
+	assert.Len(t, snapshot.Quorums[1], 1)
+}
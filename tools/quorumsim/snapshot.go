@@ -0,0 +1,78 @@
+package quorumsim
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/0glabs/0g-da-client/core"
+)
+
+// OperatorStake is the stake of one operator in a quorum
+type OperatorStake struct {
+	OperatorID string   `json:"operator_id"`
+	Stake      *big.Int `json:"stake"`
+}
+
+// Snapshot is the stake distribution of each quorum in an epoch
+type Snapshot struct {
+	Epoch   uint64                            `json:"epoch"`
+	Quorums map[core.QuorumID][]OperatorStake `json:"quorums"`
+}
+
+// LoadSnapshot reads a snapshot from a json file
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stake snapshot: %w", err)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse stake snapshot: %w", err)
+	}
+	if len(snapshot.Quorums) == 0 {
+		return nil, fmt.Errorf("stake snapshot %s has no quorums", path)
+	}
+	for quorumID, operators := range snapshot.Quorums {
+		if _, err := stakeShares(operators); err != nil {
+			return nil, fmt.Errorf("quorum %d: %w", quorumID, err)
+		}
+	}
+	return &snapshot, nil
+}
+
+// QuorumIDs returns the quorums of the snapshot in ascending order
+func (s *Snapshot) QuorumIDs() []core.QuorumID {
+	ids := make([]core.QuorumID, 0, len(s.Quorums))
+	for id := range s.Quorums {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// stakeShares returns the fraction of the total stake held by each operator
+func stakeShares(operators []OperatorStake) ([]float64, error) {
+	if len(operators) == 0 {
+		return nil, fmt.Errorf("no operators")
+	}
+	total := new(big.Int)
+	for _, op := range operators {
+		if op.Stake == nil || op.Stake.Sign() < 0 {
+			return nil, fmt.Errorf("operator %s has an invalid stake", op.OperatorID)
+		}
+		total.Add(total, op.Stake)
+	}
+	if total.Sign() == 0 {
+		return nil, fmt.Errorf("total stake is zero")
+	}
+
+	totalFloat := new(big.Float).SetInt(total)
+	shares := make([]float64, len(operators))
+	for i, op := range operators {
+		shares[i], _ = new(big.Float).Quo(new(big.Float).SetInt(op.Stake), totalFloat).Float64()
+	}
+	return shares, nil
+}
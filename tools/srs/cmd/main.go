@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/0glabs/0g-da-client/common/logging"
+	"github.com/0glabs/0g-da-client/common/srs"
+	"github.com/0glabs/0g-da-client/tools/srs/flags"
+	"github.com/urfave/cli"
+)
+
+var (
+	// version is the version of the binary.
+	version   string
+	gitCommit string
+	gitDate   string
+)
+
+func main() {
+	app := cli.NewApp()
+	app.Flags = flags.Flags
+	app.Version = fmt.Sprintf("%s-%s-%s", version, gitCommit, gitDate)
+	app.Name = "srs"
+	app.Usage = "ZGDA SRS Downloader"
+	app.Description = "Downloads the SRS subset the encoder loads into the directory its G1_PATH and G2_PATH point to, run before the encoder starts"
+
+	app.Action = RunDownloader
+	err := app.Run(os.Args)
+	if err != nil {
+		log.Fatalf("application failed: %v", err)
+	}
+}
+
+func RunDownloader(ctx *cli.Context) error {
+	logger, err := logging.GetLogger(logging.ReadCLIConfig(ctx, flags.FlagPrefix))
+	if err != nil {
+		return err
+	}
+	config := srs.ReadCLIConfig(ctx, flags.FlagPrefix)
+	downloader, err := srs.NewDownloader(config, nil, logger)
+	if err != nil {
+		return err
+	}
+	if err := downloader.Ensure(context.Background()); err != nil {
+		return err
+	}
+	logger.Info("[srs] ready", "g1", downloader.Path(srs.G1FileName), "g2", downloader.Path(srs.G2FileName), "order", config.Order)
+	return nil
+}
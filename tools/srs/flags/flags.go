@@ -0,0 +1,20 @@
+package flags
+
+import (
+	"github.com/0glabs/0g-da-client/common/logging"
+	"github.com/0glabs/0g-da-client/common/srs"
+	"github.com/urfave/cli"
+)
+
+const (
+	FlagPrefix   = "srs"
+	EnvVarPrefix = "SRS"
+)
+
+// Flags contains the list of configuration options available to the binary.
+var Flags []cli.Flag
+
+func init() {
+	Flags = append(Flags, srs.CLIFlags(EnvVarPrefix, FlagPrefix)...)
+	Flags = append(Flags, logging.CLIFlags(EnvVarPrefix, FlagPrefix)...)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/tools/testvectors/flags"
+	"github.com/urfave/cli"
+)
+
+type Config struct {
+	Seed         int64
+	PayloadSizes []int
+	HashSuites   []core.HashSuite
+	Epoch        uint64
+	QuorumId     uint64
+	OutputFile   string
+	CorpusDir    string
+	CheckFile    string
+}
+
+func NewConfig(ctx *cli.Context) (Config, error) {
+	payloadSizes := make([]int, 0)
+	for _, item := range strings.Split(ctx.GlobalString(flags.PayloadSizesFlag.Name), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		size, err := strconv.Atoi(item)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid payload size %q: %w", item, err)
+		}
+		payloadSizes = append(payloadSizes, size)
+	}
+
+	names := core.HashSuiteNames()
+	if list := ctx.GlobalString(flags.HashSuitesFlag.Name); list != "" {
+		names = strings.Split(list, ",")
+	}
+	suites := make([]core.HashSuite, len(names))
+	for i, name := range names {
+		suite, err := core.GetHashSuite(strings.TrimSpace(name))
+		if err != nil {
+			return Config{}, err
+		}
+		suites[i] = suite
+	}
+
+	return Config{
+		Seed:         ctx.GlobalInt64(flags.SeedFlag.Name),
+		PayloadSizes: payloadSizes,
+		HashSuites:   suites,
+		Epoch:        ctx.GlobalUint64(flags.EpochFlag.Name),
+		QuorumId:     ctx.GlobalUint64(flags.QuorumIdFlag.Name),
+		OutputFile:   ctx.GlobalString(flags.OutputFileFlag.Name),
+		CorpusDir:    ctx.GlobalString(flags.CorpusDirFlag.Name),
+		CheckFile:    ctx.GlobalString(flags.CheckFileFlag.Name),
+	}, nil
+}
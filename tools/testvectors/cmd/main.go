@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/0glabs/0g-da-client/tools/testvectors"
+	"github.com/0glabs/0g-da-client/tools/testvectors/flags"
+	"github.com/urfave/cli"
+)
+
+var (
+	// version is the version of the binary.
+	version   string
+	gitCommit string
+	gitDate   string
+)
+
+func main() {
+	app := cli.NewApp()
+	app.Flags = flags.Flags
+	app.Version = fmt.Sprintf("%s-%s-%s", version, gitCommit, gitDate)
+	app.Name = "testvectors"
+	app.Usage = "ZGDA Test Vectors Generator"
+	app.Description = "Generates canonical blob and batch hashing vectors for clients implemented in other languages"
+
+	app.Action = RunGenerator
+	err := app.Run(os.Args)
+	if err != nil {
+		log.Fatalf("application failed: %v", err)
+	}
+}
+
+func RunGenerator(ctx *cli.Context) error {
+	config, err := NewConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if config.CheckFile != "" {
+		return check(config.CheckFile)
+	}
+
+	vectors, err := testvectors.Generate(config.Seed, config.PayloadSizes, config.HashSuites, config.Epoch, config.QuorumId)
+	if err != nil {
+		return err
+	}
+	if config.CorpusDir != "" {
+		if err := writeCorpus(config.CorpusDir, vectors); err != nil {
+			return err
+		}
+	}
+	data, err := json.MarshalIndent(vectors, "", "  ")
+	if err != nil {
+		return err
+	}
+	if config.OutputFile == "" {
+		fmt.Println(string(data))
+		return nil
+	}
+	return os.WriteFile(config.OutputFile, data, 0644)
+}
+
+// writeCorpus writes the fuzz corpus seeds serialized from the vectors under dir
+func writeCorpus(dir string, vectors *testvectors.Vectors) error {
+	corpus, err := vectors.FuzzCorpus()
+	if err != nil {
+		return err
+	}
+	for path, data := range corpus {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func check(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var vectors testvectors.Vectors
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		return fmt.Errorf("failed to parse vectors: %w", err)
+	}
+	if vectors.Version != testvectors.Version {
+		return fmt.Errorf("vectors have version %d, the generator produces version %d", vectors.Version, testvectors.Version)
+	}
+	for _, batch := range vectors.Batches {
+		if err := batch.Verify(); err != nil {
+			return fmt.Errorf("hash suite %s: %w", batch.HashSuite, err)
+		}
+	}
+
+	regenerated, err := vectors.Regenerate()
+	if err != nil {
+		return err
+	}
+	expected, _ := json.Marshal(&vectors)
+	actual, err := json.Marshal(regenerated)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(expected, actual) {
+		return fmt.Errorf("vectors in %s are no longer produced by the current code", path)
+	}
+	fmt.Printf("vectors in %s are up to date\n", path)
+	return nil
+}
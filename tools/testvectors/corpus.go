@@ -0,0 +1,76 @@
+package testvectors
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/0glabs/0g-da-client/core"
+)
+
+// FuzzCorpus returns the seeds of the fuzz targets of the core package serialized from the
+// batches of the vectors, keyed by their path in the fuzz corpora of the package, like
+// FuzzBatchHeaderDeserialize/golden-keccak256. The blob and batch headers are serialized
+// as the batcher stores them, and the inclusion proofs are the ones served to the clients.
+func (v *Vectors) FuzzCorpus() (map[string][]byte, error) {
+	corpus := make(map[string][]byte)
+	for _, batch := range v.Batches {
+		var batchHeader core.BatchHeader
+		copy(batchHeader.BatchRoot[:], batch.BatchRoot)
+		data, err := batchHeader.Serialize()
+		if err != nil {
+			return nil, err
+		}
+		corpus[corpusPath("FuzzBatchHeaderDeserialize", batch.HashSuite)] = corpusFile(data)
+
+		for _, blob := range batch.Blobs {
+			header := &core.BlobHeader{CommitmentRoot: blob.ErasureCommitment, Length: blob.Length}
+			data, err := header.Serialize()
+			if err != nil {
+				return nil, err
+			}
+			name := fmt.Sprintf("%s-blob-%d", batch.HashSuite, blob.BlobIndex)
+			corpus[corpusPath("FuzzVerifyInclusionProof", name)] = corpusFile(data, []byte(blob.InclusionProof), uint64(blob.BlobIndex), []byte(batch.BatchRoot), batch.HashSuite)
+			// the blob headers don't depend on the suite
+			corpus[corpusPath("FuzzBlobHeaderDeserialize", fmt.Sprintf("blob-%d", blob.BlobIndex))] = corpusFile(data)
+		}
+	}
+	if len(v.Batches) > 0 {
+		// FuzzBatchInclusionProofs splits its data into commitment roots of 64 bytes
+		commitments := make([][]byte, 0, len(v.Batches[0].Blobs))
+		for _, blob := range v.Batches[0].Blobs {
+			if len(blob.ErasureCommitment) != 64 {
+				return nil, fmt.Errorf("blob %d: erasure commitment of %d bytes", blob.BlobIndex, len(blob.ErasureCommitment))
+			}
+			commitments = append(commitments, blob.ErasureCommitment)
+		}
+		corpus[corpusPath("FuzzBatchInclusionProofs", "batch")] = corpusFile(bytes.Join(commitments, nil), uint8(63), uint8(0))
+	}
+	return corpus, nil
+}
+
+func corpusPath(target string, name string) string {
+	return filepath.Join(target, "golden-"+name)
+}
+
+// corpusFile encodes the arguments of a fuzz target in the corpus file format of go test
+func corpusFile(args ...any) []byte {
+	var b strings.Builder
+	b.WriteString("go test fuzz v1\n")
+	for _, arg := range args {
+		switch arg := arg.(type) {
+		case []byte:
+			fmt.Fprintf(&b, "[]byte(%q)\n", arg)
+		case string:
+			fmt.Fprintf(&b, "string(%q)\n", arg)
+		case uint8:
+			fmt.Fprintf(&b, "byte(%q)\n", arg)
+		case uint64:
+			fmt.Fprintf(&b, "uint64(%d)\n", arg)
+		default:
+			panic(fmt.Sprintf("unsupported fuzz argument %T", arg))
+		}
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,86 @@
+package flags
+
+import (
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/urfave/cli"
+)
+
+const (
+	FlagPrefix   = "testvectors"
+	EnvVarPrefix = "TESTVECTORS"
+)
+
+var (
+	/* Optional Flags*/
+	SeedFlag = cli.Int64Flag{
+		Name:     common.PrefixFlag(FlagPrefix, "seed"),
+		Usage:    "seed of the payload generator",
+		Required: false,
+		Value:    1,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "SEED"),
+	}
+	PayloadSizesFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "payload-sizes"),
+		Usage:    "comma separated sizes of the blob payloads in bytes, one blob per size",
+		Required: false,
+		Value:    "1,31,32,1000,4096",
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "PAYLOAD_SIZES"),
+	}
+	HashSuitesFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "hash-suites"),
+		Usage:    "comma separated hash suites to generate a batch for, all suites if empty",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "HASH_SUITES"),
+	}
+	EpochFlag = cli.Uint64Flag{
+		Name:     common.PrefixFlag(FlagPrefix, "epoch"),
+		Usage:    "epoch of the generated batches",
+		Required: false,
+		Value:    1,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "EPOCH"),
+	}
+	QuorumIdFlag = cli.Uint64Flag{
+		Name:     common.PrefixFlag(FlagPrefix, "quorum-id"),
+		Usage:    "quorum id of the generated batches",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "QUORUM_ID"),
+	}
+	OutputFileFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "output-file"),
+		Usage:    "file the json vectors are written to, stdout if empty",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "OUTPUT_FILE"),
+	}
+	CorpusDirFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "corpus-dir"),
+		Usage:    "fuzz corpora directory of the core package the seeds serialized from the vectors are written to, not written if empty",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "CORPUS_DIR"),
+	}
+	CheckFileFlag = cli.StringFlag{
+		Name:     common.PrefixFlag(FlagPrefix, "check-file"),
+		Usage:    "instead of generating vectors, check that the vectors in this file are still produced by the current code",
+		Required: false,
+		EnvVar:   common.PrefixEnvVar(EnvVarPrefix, "CHECK_FILE"),
+	}
+)
+
+var RequiredFlags = []cli.Flag{}
+
+var OptionalFlags = []cli.Flag{
+	SeedFlag,
+	PayloadSizesFlag,
+	HashSuitesFlag,
+	EpochFlag,
+	QuorumIdFlag,
+	OutputFileFlag,
+	CorpusDirFlag,
+	CheckFileFlag,
+}
+
+// Flags contains the list of configuration options available to the binary.
+var Flags []cli.Flag
+
+func init() {
+	Flags = append(RequiredFlags, OptionalFlags...)
+}
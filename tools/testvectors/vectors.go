@@ -0,0 +1,231 @@
+// Package testvectors generates canonical vectors for the hashes and proofs clients have to
+// reproduce, from the blob payload up to the certificate, so that implementations in other
+// languages can check byte for byte compatibility with this one.
+//
+// The erasure commitment and the storage root of a blob are computed by the encoder, which
+// is not part of this module. The vectors use deterministic stand-ins derived from the
+// payload instead: the commitment is the G1 generator multiplied by keccak256(payload), and
+// the storage root is the 0g storage merkle root of the payload. Everything downstream of
+// them is computed exactly as the batcher does.
+//
+// The vectors of the default parameters of the generator are checked in at
+// testdata/vectors.json, and TestGolden fails when the code no longer produces them. The
+// seeds of the fuzz corpora of the core package serialized from them are checked in along,
+// see FuzzCorpus.
+package testvectors
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/0glabs/0g-da-client/rollup"
+	zg_core "github.com/0glabs/0g-storage-client/core"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/wealdtech/go-merkletree"
+)
+
+// Version is bumped whenever the layout of the vectors or any of the hashes changes
+const Version = 1
+
+// BlobVector follows one blob from its payload to its certificate
+type BlobVector struct {
+	Payload           hexutil.Bytes `json:"payload"`
+	StorageRoot       hexutil.Bytes `json:"storage_root"`
+	ErasureCommitment hexutil.Bytes `json:"erasure_commitment"`
+	Length            uint          `json:"length"`
+	HeaderBytes       hexutil.Bytes `json:"header_bytes"`
+	HeaderHash        hexutil.Bytes `json:"header_hash"`
+	BlobIndex         uint32        `json:"blob_index"`
+	// InclusionProof is the concatenation of the sibling hashes from the leaf to the root
+	InclusionProof hexutil.Bytes `json:"inclusion_proof"`
+	// Commitment is the certificate handed to rollups, see rollup.Commitment
+	Commitment hexutil.Bytes `json:"commitment"`
+}
+
+// BatchVector is a batch of blobs hashed with one hash suite
+type BatchVector struct {
+	HashSuite        string        `json:"hash_suite"`
+	Epoch            uint64        `json:"epoch"`
+	QuorumId         uint64        `json:"quorum_id"`
+	Blobs            []BlobVector  `json:"blobs"`
+	BatchRoot        hexutil.Bytes `json:"batch_root"`
+	BatchHeaderBytes hexutil.Bytes `json:"batch_header_bytes"`
+	BatchHeaderHash  hexutil.Bytes `json:"batch_header_hash"`
+}
+
+type Vectors struct {
+	Version int           `json:"version"`
+	Seed    int64         `json:"seed"`
+	Batches []BatchVector `json:"batches"`
+}
+
+// Generate returns one batch per hash suite, each holding a blob of each payload size. The
+// payloads are drawn from a PRNG seeded with seed, so the output only depends on the
+// arguments.
+func Generate(seed int64, payloadSizes []int, suites []core.HashSuite, epoch, quorumId uint64) (*Vectors, error) {
+	if len(payloadSizes) == 0 {
+		return nil, errors.New("at least one payload size is required")
+	}
+	rng := rand.New(rand.NewSource(seed))
+	payloads := make([][]byte, len(payloadSizes))
+	for i, size := range payloadSizes {
+		if size <= 0 {
+			return nil, fmt.Errorf("payload size %d must be positive", size)
+		}
+		payloads[i] = make([]byte, size)
+		rng.Read(payloads[i])
+	}
+
+	vectors := &Vectors{Version: Version, Seed: seed}
+	for _, suite := range suites {
+		batch, err := generateBatch(payloads, suite, epoch, quorumId)
+		if err != nil {
+			return nil, fmt.Errorf("hash suite %s: %w", suite.Name(), err)
+		}
+		vectors.Batches = append(vectors.Batches, *batch)
+	}
+	return vectors, nil
+}
+
+func generateBatch(payloads [][]byte, suite core.HashSuite, epoch, quorumId uint64) (*BatchVector, error) {
+	blobs := make([]BlobVector, len(payloads))
+	headers := make([]*core.BlobHeader, len(payloads))
+	for i, payload := range payloads {
+		storageRoot, err := storageRoot(payload)
+		if err != nil {
+			return nil, err
+		}
+		commitment := erasureCommitment(payload)
+		headers[i] = &core.BlobHeader{
+			CommitmentRoot: commitment.Serialize(),
+			Length:         core.GetBlobLength(uint(len(payload))),
+		}
+		headerBytes, err := headers[i].Encode()
+		if err != nil {
+			return nil, err
+		}
+		headerHash, err := headers[i].GetBlobHeaderHashWith(suite)
+		if err != nil {
+			return nil, err
+		}
+
+		certificate := &rollup.Commitment{Epoch: epoch, QuorumId: quorumId}
+		copy(certificate.StorageRoot[:], storageRoot)
+		blobs[i] = BlobVector{
+			Payload:           payload,
+			StorageRoot:       storageRoot,
+			ErasureCommitment: commitment.Serialize(),
+			Length:            headers[i].Length,
+			HeaderBytes:       headerBytes,
+			HeaderHash:        headerHash[:],
+			BlobIndex:         uint32(i),
+			Commitment:        certificate.Encode(),
+		}
+	}
+
+	var batchHeader core.BatchHeader
+	if _, err := batchHeader.SetBatchRootWith(headers, suite); err != nil {
+		return nil, err
+	}
+	proofs, err := core.BatchInclusionProofs(headers, suite)
+	if err != nil {
+		return nil, err
+	}
+	for i := range blobs {
+		blobs[i].InclusionProof = bytes.Join(proofs[i].Hashes, nil)
+	}
+
+	batchHeaderBytes, err := batchHeader.Encode()
+	if err != nil {
+		return nil, err
+	}
+	batchHeaderHash, err := batchHeader.GetBatchHeaderHashWith(suite)
+	if err != nil {
+		return nil, err
+	}
+	return &BatchVector{
+		HashSuite:        suite.Name(),
+		Epoch:            epoch,
+		QuorumId:         quorumId,
+		Blobs:            blobs,
+		BatchRoot:        batchHeader.BatchRoot[:],
+		BatchHeaderBytes: batchHeaderBytes,
+		BatchHeaderHash:  batchHeaderHash[:],
+	}, nil
+}
+
+// Verify recomputes the batch root from the header hashes and inclusion proofs of the blobs
+func (b *BatchVector) Verify() error {
+	suite, err := core.GetHashSuite(b.HashSuite)
+	if err != nil {
+		return err
+	}
+	hashLength := suite.HashLength()
+	for _, blob := range b.Blobs {
+		if len(blob.InclusionProof)%hashLength != 0 {
+			return fmt.Errorf("blob %d: inclusion proof length %d is not a multiple of %d", blob.BlobIndex, len(blob.InclusionProof), hashLength)
+		}
+		hashes := make([][]byte, 0, len(blob.InclusionProof)/hashLength)
+		for i := 0; i < len(blob.InclusionProof); i += hashLength {
+			hashes = append(hashes, blob.InclusionProof[i:i+hashLength])
+		}
+		ok, err := merkletree.VerifyProofUsing(blob.HeaderHash, false, &merkletree.Proof{Hashes: hashes, Index: uint64(blob.BlobIndex)}, [][]byte{b.BatchRoot}, suite)
+		if err != nil {
+			return fmt.Errorf("blob %d: %w", blob.BlobIndex, err)
+		}
+		if !ok {
+			return fmt.Errorf("blob %d: inclusion proof doesn't match the batch root", blob.BlobIndex)
+		}
+	}
+	return nil
+}
+
+func storageRoot(payload []byte) ([]byte, error) {
+	data, err := zg_core.NewDataInMemory(payload)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := zg_core.MerkleTree(data)
+	if err != nil {
+		return nil, err
+	}
+	root := tree.Root()
+	return root[:], nil
+}
+
+func erasureCommitment(payload []byte) *core.G1Point {
+	var scalar fr.Element
+	scalar.SetBytes(crypto.Keccak256(payload))
+	_, _, g1, _ := bn254.Generators()
+	var point bn254.G1Affine
+	point.ScalarMultiplication(&g1, scalar.BigInt(new(big.Int)))
+	return &core.G1Point{G1Affine: &point}
+}
+
+// Regenerate generates the vectors again from the parameters they were generated with
+func (v *Vectors) Regenerate() (*Vectors, error) {
+	if len(v.Batches) == 0 {
+		return nil, errors.New("vectors have no batches")
+	}
+	first := v.Batches[0]
+	payloadSizes := make([]int, len(first.Blobs))
+	for i, blob := range first.Blobs {
+		payloadSizes[i] = len(blob.Payload)
+	}
+	suites := make([]core.HashSuite, len(v.Batches))
+	for i, batch := range v.Batches {
+		suite, err := core.GetHashSuite(batch.HashSuite)
+		if err != nil {
+			return nil, err
+		}
+		suites[i] = suite
+	}
+	return Generate(v.Seed, payloadSizes, suites, first.Epoch, first.QuorumId)
+}
@@ -0,0 +1,85 @@
+package testvectors
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	suites := []core.HashSuite{core.Keccak256Suite, core.SHA256Suite}
+	vectors, err := Generate(7, []int{1, 31, 1000}, suites, 3, 1)
+	assert.NoError(t, err)
+	assert.Len(t, vectors.Batches, 2)
+	for _, batch := range vectors.Batches {
+		assert.Len(t, batch.Blobs, 3)
+		assert.NoError(t, batch.Verify())
+	}
+	// header bytes don't depend on the suite, header hashes do
+	assert.Equal(t, vectors.Batches[0].Blobs[0].HeaderBytes, vectors.Batches[1].Blobs[0].HeaderBytes)
+	assert.NotEqual(t, vectors.Batches[0].Blobs[0].HeaderHash, vectors.Batches[1].Blobs[0].HeaderHash)
+
+	data, err := json.Marshal(vectors)
+	assert.NoError(t, err)
+	var decoded Vectors
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	regenerated, err := decoded.Regenerate()
+	assert.NoError(t, err)
+	assert.Equal(t, vectors, regenerated)
+
+	decoded.Batches[0].Blobs[1].InclusionProof[0] ^= 1
+	assert.Error(t, decoded.Batches[0].Verify())
+}
+
+// TestGolden checks that testdata/vectors.json, which the clients in other languages are
+// checked against, is still what the code produces with the default flags of the generator.
+// Run make golden to regenerate it after a deliberate change, bumping Version.
+func TestGolden(t *testing.T) {
+	golden, err := os.ReadFile("testdata/vectors.json")
+	require.NoError(t, err)
+
+	names := core.HashSuiteNames()
+	suites := make([]core.HashSuite, len(names))
+	for i, name := range names {
+		suites[i], err = core.GetHashSuite(name)
+		require.NoError(t, err)
+	}
+	vectors, err := Generate(1, []int{1, 31, 32, 1000, 4096}, suites, 1, 0)
+	require.NoError(t, err)
+	data, err := json.MarshalIndent(vectors, "", "  ")
+	require.NoError(t, err)
+	if !bytes.Equal(bytes.TrimSpace(golden), data) {
+		assert.JSONEq(t, string(golden), string(data), "testdata/vectors.json is no longer produced by the code")
+	}
+
+	var decoded Vectors
+	require.NoError(t, json.Unmarshal(golden, &decoded))
+	assert.Equal(t, Version, decoded.Version)
+	for _, batch := range decoded.Batches {
+		assert.NoError(t, batch.Verify(), batch.HashSuite)
+	}
+}
+
+// TestFuzzCorpus checks that the seeds of the fuzz corpora of the core package serialized
+// from testdata/vectors.json are checked in. make golden writes them along with the vectors.
+func TestFuzzCorpus(t *testing.T) {
+	golden, err := os.ReadFile("testdata/vectors.json")
+	require.NoError(t, err)
+	var vectors Vectors
+	require.NoError(t, json.Unmarshal(golden, &vectors))
+	corpus, err := vectors.FuzzCorpus()
+	require.NoError(t, err)
+	assert.NotEmpty(t, corpus)
+	for path, expected := range corpus {
+		data, err := os.ReadFile(filepath.Join("../../core/testdata/fuzz", path))
+		if assert.NoError(t, err, path) {
+			assert.Equal(t, string(expected), string(data), path)
+		}
+	}
+}
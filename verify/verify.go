@@ -0,0 +1,235 @@
+// Package verify lets clients check a blob retrieved from the operators or the disperser
+// against its certificate, without trusting either. The blob is encoded again by an encoder
+// of the client's choosing, and the commitments are compared with those certified by the
+// signers. The certified header is proven against the batch root, and the aggregate
+// signature of the signers against the commitments. A mismatch is evidence that the
+// certified commitments are not those of the data, which the Result records in a form
+// fraud-proof tooling can consume.
+package verify
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"math/big"
+
+	"github.com/0glabs/0g-da-client/certificate"
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Encoder encodes the blobs to verify, disperser.EncoderClient being one
+type Encoder interface {
+	EncodeBlob(ctx context.Context, data []byte, log common.Logger) (*core.BlobCommitments, error)
+}
+
+// Options are the parameters of the batches the blobs are verified against
+type Options struct {
+	// HashSuite is the hash of the blob headers and the batch root, keccak256 if nil, see
+	// core.GetHashSuite
+	HashSuite core.HashSuite
+}
+
+// Verdict is the outcome of the verification of a blob
+type Verdict int
+
+const (
+	// Valid blobs encode to the certified commitments
+	Valid Verdict = iota
+	// StorageRootMismatch blobs don't encode to the certified storage root
+	StorageRootMismatch
+	// CommitmentMismatch blobs don't encode to the certified erasure commitment
+	CommitmentMismatch
+	// LengthMismatch blobs don't encode to the certified length
+	LengthMismatch
+	// InclusionMismatch blobs have a certified header whose inclusion proof doesn't lead to
+	// the batch root
+	InclusionMismatch
+	// SignatureMismatch blobs have an aggregate signature that doesn't sign their
+	// commitments under the certified aggregate public key
+	SignatureMismatch
+)
+
+func (v Verdict) String() string {
+	switch v {
+	case Valid:
+		return "valid"
+	case StorageRootMismatch:
+		return "storage root mismatch"
+	case CommitmentMismatch:
+		return "commitment mismatch"
+	case LengthMismatch:
+		return "length mismatch"
+	case InclusionMismatch:
+		return "inclusion mismatch"
+	case SignatureMismatch:
+		return "signature mismatch"
+	default:
+		return fmt.Sprintf("verdict(%d)", int(v))
+	}
+}
+
+func (v Verdict) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// Field is a commitment of a blob as certified and as recomputed from its data
+type Field struct {
+	Certified  hexutil.Bytes `json:"certified"`
+	Recomputed hexutil.Bytes `json:"recomputed"`
+}
+
+func (f Field) matches() bool {
+	return bytes.Equal(f.Certified, f.Recomputed)
+}
+
+// Result of the verification of a blob. The verdict is the first mismatch of the storage
+// root, the erasure commitment, the length, the batch root and the aggregate signature, in
+// that order.
+type Result struct {
+	Verdict Verdict `json:"verdict"`
+	// DataHash is the keccak256 hash of the verified data
+	DataHash          hexutil.Bytes `json:"data_hash"`
+	BlobIndex         uint32        `json:"blob_index"`
+	StorageRoot       Field         `json:"storage_root"`
+	ErasureCommitment Field         `json:"erasure_commitment"`
+	// Length is the big endian encoding of the length of the encoded blob
+	Length Field `json:"length"`
+	// BatchRoot is the root of the batch as certified and as recomputed from the inclusion
+	// proof of the certified header
+	BatchRoot Field `json:"batch_root"`
+	// SignedMessage is the message the aggregate signature was checked against, see
+	// core.SignatureMessage
+	SignedMessage      hexutil.Bytes `json:"signed_message"`
+	AggregateSignature hexutil.Bytes `json:"aggregate_signature"`
+	AggregatePubKey    hexutil.Bytes `json:"aggregate_pub_key"`
+}
+
+func (r *Result) Valid() bool {
+	return r.Verdict == Valid
+}
+
+// Blob encodes data with encoder and compares the commitments with those of the blob at
+// blobIndex in batch, the certificate of its batch. The inclusion proof of the blob is
+// checked against the batch root and its aggregate signature against the aggregate public
+// key of the certificate, which the DA contract checked against the registered signers on
+// confirmation. Errors are returned if the blob couldn't be verified, the blobs confirmed
+// before their aggregate signature was recorded included, and a mismatch is a result with an
+// invalid verdict.
+func Blob(ctx context.Context, encoder Encoder, data []byte, batch *certificate.BatchCertificate, blobIndex uint32, options Options, logger common.Logger) (*Result, error) {
+	if batch == nil {
+		return nil, errors.New("certificate is required")
+	}
+	blob := batch.Blob(blobIndex)
+	if blob == nil {
+		return nil, fmt.Errorf("certificate has no blob %d", blobIndex)
+	}
+	if len(data) == 0 {
+		return nil, errors.New("blob is empty")
+	}
+	if len(blob.DataRoot) != 32 {
+		return nil, fmt.Errorf("certified data root has length %d", len(blob.DataRoot))
+	}
+	signature, err := new(core.G1Point).Deserialize(blob.AggregateSignature)
+	if err != nil {
+		return nil, fmt.Errorf("certificate has no valid aggregate signature: %w", err)
+	}
+	pubKey, err := new(core.G2Point).Deserialize(blob.AggregatePubKey)
+	if err != nil {
+		return nil, fmt.Errorf("certificate has no valid aggregate public key: %w", err)
+	}
+	suite := options.HashSuite
+	if suite == nil {
+		suite = core.Keccak256Suite
+	}
+	commitments, err := encoder.EncodeBlob(ctx, data, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode blob: %w", err)
+	}
+	if commitments.ErasureCommitment == nil || len(commitments.EncodedSlice) == 0 {
+		return nil, errors.New("encoder returned no commitment")
+	}
+	batchRoot, err := proofRoot(blob, suite)
+	if err != nil {
+		return nil, err
+	}
+	var dataRoot [32]byte
+	copy(dataRoot[:], blob.DataRoot)
+	message, err := core.SignatureMessage(dataRoot, new(big.Int).SetUint64(batch.Epoch), new(big.Int).SetUint64(batch.QuorumId), commitments.ErasureCommitment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute the signed message: %w", err)
+	}
+
+	// the length of a blob is certified as the batcher computes it from the encoding
+	length := uint32(len(commitments.EncodedSlice) * len(commitments.EncodedSlice[0]))
+	result := &Result{
+		DataHash:  crypto.Keccak256(data),
+		BlobIndex: blob.BlobIndex,
+		StorageRoot: Field{
+			Certified:  blob.DataRoot,
+			Recomputed: commitments.StorageRoot,
+		},
+		ErasureCommitment: Field{
+			Certified:  blob.CommitmentRoot,
+			Recomputed: commitments.ErasureCommitment.Serialize(),
+		},
+		Length: Field{
+			Certified:  bigEndian(blob.Length),
+			Recomputed: bigEndian(length),
+		},
+		BatchRoot: Field{
+			Certified:  batch.BatchRoot,
+			Recomputed: batchRoot,
+		},
+		SignedMessage:      message[:],
+		AggregateSignature: blob.AggregateSignature,
+		AggregatePubKey:    blob.AggregatePubKey,
+	}
+	switch {
+	case !result.StorageRoot.matches():
+		result.Verdict = StorageRootMismatch
+	case !result.ErasureCommitment.matches():
+		result.Verdict = CommitmentMismatch
+	case !result.Length.matches():
+		result.Verdict = LengthMismatch
+	case !result.BatchRoot.matches():
+		result.Verdict = InclusionMismatch
+	case !(&core.Signature{G1Point: signature}).Verify(pubKey, message):
+		result.Verdict = SignatureMismatch
+	}
+	return result, nil
+}
+
+// proofRoot returns the batch root the inclusion proof of blob leads to, the proof being the
+// sibling hashes from the leaf of its header up to the root, see core.BatchInclusionProofs
+func proofRoot(blob *certificate.BlobCertificate, suite core.HashSuite) ([]byte, error) {
+	hashLength := suite.HashLength()
+	if len(blob.InclusionProof)%hashLength != 0 {
+		return nil, fmt.Errorf("inclusion proof length %d is not a multiple of %d", len(blob.InclusionProof), hashLength)
+	}
+	headerHash, err := core.BlobHeader{CommitmentRoot: blob.CommitmentRoot}.GetBlobHeaderHashWith(suite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute the blob header hash: %w", err)
+	}
+	node := suite.Hash(headerHash[:])
+	index := blob.BlobIndex
+	for i := 0; i < len(blob.InclusionProof); i += hashLength {
+		sibling := blob.InclusionProof[i : i+hashLength]
+		if index%2 == 0 {
+			node = suite.Hash(node, sibling)
+		} else {
+			node = suite.Hash(sibling, node)
+		}
+		index /= 2
+	}
+	return node, nil
+}
+
+func bigEndian(v uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, v)
+}
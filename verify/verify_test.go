@@ -0,0 +1,134 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/0glabs/0g-da-client/certificate"
+	"github.com/0glabs/0g-da-client/common"
+	"github.com/0glabs/0g-da-client/common/mock"
+	"github.com/0glabs/0g-da-client/core"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEncoder commits every blob to the generator and roots it by its keccak hash
+type fakeEncoder struct{}
+
+func (fakeEncoder) EncodeBlob(ctx context.Context, data []byte, log common.Logger) (*core.BlobCommitments, error) {
+	_, _, g1, _ := bn254.Generators()
+	return &core.BlobCommitments{
+		ErasureCommitment: &core.G1Point{G1Affine: &g1},
+		StorageRoot:       crypto.Keccak256(data),
+		EncodedSlice:      [][]byte{data, data},
+	}, nil
+}
+
+func TestBlob(t *testing.T) {
+	ctx := context.Background()
+	logger := mock.NewLogger(false)
+	data := []byte("retrieved blob")
+	commitments, _ := fakeEncoder{}.EncodeBlob(ctx, data, logger)
+
+	// the blob is the second of a batch of two, signed by two signers
+	headers := []*core.BlobHeader{
+		{CommitmentRoot: []byte("other blob")},
+		{CommitmentRoot: commitments.ErasureCommitment.Serialize()},
+	}
+	var batchHeader core.BatchHeader
+	_, err := batchHeader.SetBatchRootWith(headers, core.Keccak256Suite)
+	require.NoError(t, err)
+	proofs, err := core.BatchInclusionProofs(headers, core.Keccak256Suite)
+	require.NoError(t, err)
+	var dataRoot [32]byte
+	copy(dataRoot[:], commitments.StorageRoot)
+	message, err := core.SignatureMessage(dataRoot, big.NewInt(9), big.NewInt(0), commitments.ErasureCommitment)
+	require.NoError(t, err)
+	signature, pubKey := aggregate(t, message, 2)
+
+	blob := &certificate.BlobCertificate{
+		BlobIndex:          1,
+		DataRoot:           commitments.StorageRoot,
+		CommitmentRoot:     headers[1].CommitmentRoot,
+		Length:             uint32(2 * len(data)),
+		InclusionProof:     bytes.Join(proofs[1].Hashes, nil),
+		AggregateSignature: signature.Serialize(),
+		AggregatePubKey:    pubKey.Serialize(),
+	}
+	batch := &certificate.BatchCertificate{
+		BatchStatus: certificate.BatchStatus{BatchRoot: batchHeader.BatchRoot[:], Epoch: 9},
+		Blobs:       []*certificate.BlobCertificate{blob},
+	}
+
+	result, err := Blob(ctx, fakeEncoder{}, data, batch, 1, Options{}, logger)
+	require.NoError(t, err)
+	assert.True(t, result.Valid(), result.Verdict)
+	assert.Equal(t, uint32(1), result.BlobIndex)
+	assert.Equal(t, message[:], []byte(result.SignedMessage))
+
+	// data other than the certified one
+	result, err = Blob(ctx, fakeEncoder{}, []byte("tampered blob!"), batch, 1, Options{}, logger)
+	require.NoError(t, err)
+	assert.Equal(t, StorageRootMismatch, result.Verdict)
+	assert.Equal(t, crypto.Keccak256([]byte("tampered blob!")), []byte(result.StorageRoot.Recomputed))
+
+	// a header that isn't that of the batch
+	blob.InclusionProof = bytes.Join(proofs[0].Hashes, nil)
+	result, err = Blob(ctx, fakeEncoder{}, data, batch, 1, Options{}, logger)
+	require.NoError(t, err)
+	assert.Equal(t, InclusionMismatch, result.Verdict)
+	blob.InclusionProof = bytes.Join(proofs[1].Hashes, nil)
+
+	// a signature of other signers, or over another epoch
+	_, otherPubKey := aggregate(t, message, 1)
+	blob.AggregatePubKey = otherPubKey.Serialize()
+	result, err = Blob(ctx, fakeEncoder{}, data, batch, 1, Options{}, logger)
+	require.NoError(t, err)
+	assert.Equal(t, SignatureMismatch, result.Verdict)
+	blob.AggregatePubKey = pubKey.Serialize()
+	batch.Epoch = 10
+	result, err = Blob(ctx, fakeEncoder{}, data, batch, 1, Options{}, logger)
+	require.NoError(t, err)
+	assert.Equal(t, SignatureMismatch, result.Verdict)
+	batch.Epoch = 9
+
+	blob.CommitmentRoot = make([]byte, len(blob.CommitmentRoot))
+	result, err = Blob(ctx, fakeEncoder{}, data, batch, 1, Options{}, logger)
+	require.NoError(t, err)
+	assert.Equal(t, CommitmentMismatch, result.Verdict)
+
+	encoded, err := json.Marshal(result)
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"verdict":"commitment mismatch"`)
+
+	_, err = Blob(ctx, fakeEncoder{}, nil, batch, 1, Options{}, logger)
+	assert.Error(t, err)
+	_, err = Blob(ctx, fakeEncoder{}, data, batch, 0, Options{}, logger)
+	assert.Error(t, err)
+	// blobs confirmed without their aggregate signature can't be verified
+	blob.AggregateSignature = nil
+	_, err = Blob(ctx, fakeEncoder{}, data, batch, 1, Options{}, logger)
+	assert.Error(t, err)
+}
+
+// aggregate signs message with n random keys, returning the aggregate signature and public key
+func aggregate(t *testing.T, message [32]byte, n int) (*core.Signature, *core.G2Point) {
+	var signature *core.Signature
+	var pubKey *core.G2Point
+	for i := 0; i < n; i++ {
+		keys, err := core.GenRandomBlsKeys()
+		require.NoError(t, err)
+		if signature == nil {
+			signature, pubKey = keys.SignMessage(message), keys.GetPubKeyG2().Clone()
+			continue
+		}
+		signature.Add(keys.SignMessage(message).G1Point)
+		pubKey.Add(keys.GetPubKeyG2())
+	}
+	return signature, pubKey
+}